@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/cloud-on-k8s/pkg/backup"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/bootstrap"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func newExportCmd(flags *clientFlags) *cobra.Command {
+	var output, passphraseFile string
+	var allNamespaces bool
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the Elastic Stack resources and their Secrets to an encrypted bundle",
+		Long: "Export the Elastic Stack resources with the Secrets required to recreate them in another Kubernetes " +
+			"cluster, such as the certificate authorities and the credentials of the elastic user, to a bundle " +
+			"encrypted with a passphrase.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase, err := readPassphrase(passphraseFile)
+			if err != nil {
+				return err
+			}
+			c, namespace, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			if allNamespaces {
+				namespace = ""
+			}
+			data, err := exportBundle(c, namespace, passphrase)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(output, data, 0600); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Bundle written to %s\n", output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "eck-bundle.json", "File to write the bundle to")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the passphrase the bundle is encrypted with")
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Export the resources of all namespaces")
+	_ = cmd.MarkFlagRequired("passphrase-file")
+	return cmd
+}
+
+func newImportCmd(flags *clientFlags) *cobra.Command {
+	var passphraseFile string
+	var newClusters bool
+	cmd := &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Import the Elastic Stack resources and their Secrets from an encrypted bundle",
+		Long: "Create the Elastic Stack resources and Secrets of a bundle written by the export command, in the " +
+			"namespaces they were exported from. Existing resources are left untouched.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase, err := readPassphrase(passphraseFile)
+			if err != nil {
+				return err
+			}
+			data, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			c, _, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			return importBundle(c, data, passphrase, newClusters, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File holding the passphrase the bundle is encrypted with")
+	cmd.Flags().BoolVar(&newClusters, "new-clusters", false, "Bootstrap new Elasticsearch clusters instead of keeping the UUIDs of the exported ones, if their data is not restored")
+	_ = cmd.MarkFlagRequired("passphrase-file")
+	return cmd
+}
+
+// readPassphrase reads a passphrase from the given file, ignoring the trailing new lines.
+func readPassphrase(file string) ([]byte, error) {
+	passphrase, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the passphrase")
+	}
+	passphrase = bytes.TrimRight(passphrase, "\r\n")
+	if len(passphrase) == 0 {
+		return nil, errors.Errorf("empty passphrase in %s", file)
+	}
+	return passphrase, nil
+}
+
+// exportBundle exports the resources of the given namespace, or all namespaces if empty, to an encrypted bundle.
+func exportBundle(c k8s.Client, namespace string, passphrase []byte) ([]byte, error) {
+	bundle, err := backup.Export(c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Encrypt(passphrase)
+}
+
+// importBundle creates the resources of the given encrypted bundle, and reports them to out. The cluster UUIDs of the
+// Elasticsearch clusters are removed for them to be bootstrapped again if newClusters is true.
+func importBundle(c k8s.Client, data []byte, passphrase []byte, newClusters bool, out io.Writer) error {
+	bundle, err := backup.Decrypt(data, passphrase)
+	if err != nil {
+		return err
+	}
+	if newClusters {
+		for _, es := range bundle.Elasticsearches {
+			delete(es.Annotations, bootstrap.ClusterUUIDAnnotationName)
+		}
+	}
+	created, existing, err := backup.Import(c, bundle)
+	for _, resource := range created {
+		fmt.Fprintf(out, "%s created\n", resource)
+	}
+	for _, resource := range existing {
+		fmt.Fprintf(out, "%s already exists, left untouched\n", resource)
+	}
+	return err
+}
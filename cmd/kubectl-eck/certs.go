@@ -0,0 +1,57 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func newRotateCertsCmd(flags *clientFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate-certs <cluster>",
+		Short: "Re-issue the HTTP and transport certificates of an Elasticsearch cluster",
+		Long: "Re-issue the HTTP and transport certificates generated by the operator for an Elasticsearch cluster, " +
+			"signed by the same certificate authorities. Certificates provided by the user are left untouched.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			return rotateCerts(c, types.NamespacedName{Namespace: namespace, Name: args[0]}, cmd.OutOrStdout())
+		},
+	}
+}
+
+// rotateCerts deletes the Secrets holding the certificates generated for the given cluster, which are then issued
+// again by the operator on the next reconciliation.
+func rotateCerts(c k8s.Client, cluster types.NamespacedName, out io.Writer) error {
+	var es esv1.Elasticsearch
+	if err := c.Get(cluster, &es); err != nil {
+		return err
+	}
+	for _, name := range []string{
+		certificates.HTTPCertsInternalSecretName(esv1.ESNamer, es.Name),
+		esv1.TransportCertificatesSecret(es.Name),
+	} {
+		secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: es.Namespace, Name: name}}
+		if err := c.Delete(&secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Certificates of Elasticsearch %s will be re-issued by the operator\n", cluster)
+	return nil
+}
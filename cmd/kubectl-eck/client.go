@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	eckscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// clientFlags are the flags common to all commands to reach the Kubernetes API server, named after the kubectl ones.
+type clientFlags struct {
+	kubeconfig string
+	context    string
+	namespace  string
+}
+
+func (f *clientFlags) bind(flags *pflag.FlagSet) {
+	flags.StringVar(&f.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use")
+	flags.StringVar(&f.context, "context", "", "Name of the kubeconfig context to use")
+	flags.StringVarP(&f.namespace, "namespace", "n", "", "Namespace of the resources, defaults to the namespace of the kubeconfig context")
+}
+
+// newClient returns a client for the API server, and the namespace the command applies to.
+func (f *clientFlags) newClient() (k8s.Client, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = f.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: f.context}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	namespace := f.namespace
+	if namespace == "" {
+		var err error
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, "", errors.Wrap(err, "failed to get the namespace of the kubeconfig context")
+		}
+	}
+	cfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to get a Kubernetes config")
+	}
+	if err := eckscheme.SetupScheme(); err != nil {
+		return nil, "", errors.Wrap(err, "failed to set up the ECK scheme")
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to create a Kubernetes client")
+	}
+	return k8s.WrapClient(c), namespace, nil
+}
+
+// kinds are the resources accepted by the commands applying to any resource managed by the operator.
+var kinds = map[string]func() runtime.Object{
+	"apmserver":        func() runtime.Object { return &apmv1.ApmServer{} },
+	"elasticsearch":    func() runtime.Object { return &esv1.Elasticsearch{} },
+	"enterprisesearch": func() runtime.Object { return &entsv1beta1.EnterpriseSearch{} },
+	"kibana":           func() runtime.Object { return &kbv1.Kibana{} },
+}
+
+// parseResource parses a resource argument, either a name of the given default kind or kind/name as with kubectl.
+func parseResource(arg string, defaultKind string) (string, runtime.Object, error) {
+	kind, name := defaultKind, arg
+	if parts := strings.SplitN(arg, "/", 2); len(parts) == 2 {
+		kind, name = strings.ToLower(parts[0]), parts[1]
+	}
+	newObject, supported := kinds[kind]
+	if !supported || name == "" {
+		return "", nil, fmt.Errorf("invalid resource %s, expected <name> or <kind>/<name> with kind among apmserver, elasticsearch, enterprisesearch or kibana", arg)
+	}
+	return name, newObject(), nil
+}
+
+// kindOf returns the kind of the given resource, since the objects returned by the client do not have their type
+// metadata set.
+func kindOf(obj runtime.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+	if err != nil {
+		return "Resource"
+	}
+	return gvk.Kind
+}
@@ -0,0 +1,94 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/diagnostics"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func newDiagnosticsCmd(flags *clientFlags) *cobra.Command {
+	var output string
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "diagnostics <cluster>",
+		Short: "Download a diagnostics bundle of an Elasticsearch cluster",
+		Long: "Request a diagnostics bundle of an Elasticsearch cluster through the " + diagnostics.DiagnosticsAnnotation +
+			" annotation, wait for the operator to generate it, and write it to a local file.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			cluster := types.NamespacedName{Namespace: namespace, Name: args[0]}
+			request := strconv.FormatInt(time.Now().Unix(), 10)
+			if err := requestDiagnostics(c, cluster, request); err != nil {
+				return err
+			}
+			var bundle []byte
+			if err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+				bundle, err = fetchDiagnostics(c, cluster, request)
+				return bundle != nil, err
+			}); err != nil {
+				return fmt.Errorf("diagnostics bundle of Elasticsearch %s not generated after %s: %v", cluster, timeout, err)
+			}
+			if output == "" {
+				output = fmt.Sprintf("%s-%s-diagnostics-%s.tar.gz", cluster.Namespace, cluster.Name, request)
+			}
+			if err := ioutil.WriteFile(output, bundle, 0600); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Diagnostics bundle of Elasticsearch %s written to %s\n", cluster, output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the bundle to, defaults to <namespace>-<cluster>-diagnostics-<timestamp>.tar.gz")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Maximum duration to wait for the bundle")
+	return cmd
+}
+
+// requestDiagnostics sets the diagnostics annotation of the given cluster to the given request.
+func requestDiagnostics(c k8s.Client, cluster types.NamespacedName, request string) error {
+	var es esv1.Elasticsearch
+	if err := c.Get(cluster, &es); err != nil {
+		return err
+	}
+	original := es.DeepCopy()
+	if es.Annotations == nil {
+		es.Annotations = map[string]string{}
+	}
+	es.Annotations[diagnostics.DiagnosticsAnnotation] = request
+	return c.Patch(&es, client.MergeFrom(original))
+}
+
+// fetchDiagnostics returns the bundle generated for the given request, or nil if not generated yet.
+func fetchDiagnostics(c k8s.Client, cluster types.NamespacedName, request string) ([]byte, error) {
+	var secret corev1.Secret
+	err := c.Get(types.NamespacedName{Namespace: cluster.Namespace, Name: esv1.DiagnosticsSecret(cluster.Name)}, &secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if secret.Annotations[diagnostics.DiagnosticsRequestAnnotation] != request {
+		return nil, nil
+	}
+	return secret.Data[diagnostics.BundleKey], nil
+}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func newGetCmd(flags *clientFlags) *cobra.Command {
+	getCmd := &cobra.Command{
+		Use:   "get",
+		Short: "Display resources managed by the operator",
+	}
+	var allNamespaces bool
+	clustersCmd := &cobra.Command{
+		Use:     "clusters",
+		Aliases: []string{"cluster", "elasticsearch"},
+		Short:   "List the Elasticsearch clusters with their health, version and orchestration phase",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, namespace, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			if allNamespaces {
+				namespace = ""
+			}
+			return getClusters(c, namespace, cmd.OutOrStdout(), time.Now())
+		},
+	}
+	clustersCmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List the clusters of all namespaces")
+	getCmd.AddCommand(clustersCmd)
+	return getCmd
+}
+
+// getClusters prints a table of the Elasticsearch clusters of the given namespace, or of all namespaces if empty.
+func getClusters(c k8s.Client, namespace string, out io.Writer, now time.Time) error {
+	var clusters esv1.ElasticsearchList
+	if err := c.List(&clusters, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	sort.Slice(clusters.Items, func(i, j int) bool {
+		if clusters.Items[i].Namespace != clusters.Items[j].Namespace {
+			return clusters.Items[i].Namespace < clusters.Items[j].Namespace
+		}
+		return clusters.Items[i].Name < clusters.Items[j].Name
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tHEALTH\tNODES\tVERSION\tPHASE\tPAUSED\tAGE")
+	for _, es := range clusters.Items {
+		health := es.Status.Health
+		if health == "" {
+			health = esv1.ElasticsearchUnknownHealth
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%t\t%s\n",
+			es.Namespace, es.Name, health, es.Status.AvailableNodes, es.Spec.Version, es.Status.Phase,
+			common.IsPaused(es.ObjectMeta), duration.HumanDuration(now.Sub(es.CreationTimestamp.Time)))
+	}
+	return w.Flush()
+}
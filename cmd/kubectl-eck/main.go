@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // auth on gke
+)
+
+// kubectl plugin performing common operations on the resources managed by the operator, through the annotations and
+// resources documented for them. Install it by putting the kubectl-eck binary in the PATH, then use it as `kubectl eck`:
+//
+//  > kubectl eck get clusters
+//  NAMESPACE   NAME         HEALTH   NODES   VERSION   PHASE   PAUSED   AGE
+//  default     quickstart   green    3       7.6.2     Ready   false    2h
+//
+//  > kubectl eck restart --rolling quickstart
+//  Rolling restart of Elasticsearch default/quickstart requested
+//
+//  > kubectl eck export --all-namespaces --passphrase-file passphrase.txt
+//  Bundle written to eck-bundle.json
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	var flags clientFlags
+	rootCmd := &cobra.Command{
+		Use:          "kubectl-eck",
+		Short:        "Perform common operations on the Elastic Stack resources managed by ECK",
+		SilenceUsage: true,
+	}
+	flags.bind(rootCmd.PersistentFlags())
+	rootCmd.AddCommand(
+		newGetCmd(&flags),
+		newDiagnosticsCmd(&flags),
+		newPauseCmd(&flags, true),
+		newPauseCmd(&flags, false),
+		newRotateCertsCmd(&flags),
+		newRestartCmd(&flags),
+		newExportCmd(&flags),
+		newImportCmd(&flags),
+	)
+	return rootCmd
+}
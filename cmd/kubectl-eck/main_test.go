@@ -0,0 +1,181 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	eckscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/bootstrap"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/diagnostics"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var cluster = types.NamespacedName{Namespace: "ns", Name: "es"}
+
+func newES() *esv1.Elasticsearch {
+	return &esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: cluster.Name},
+		Spec: esv1.ElasticsearchSpec{
+			Version:  "7.6.2",
+			NodeSets: []esv1.NodeSet{{Name: "masters", Count: 3}, {Name: "data", Count: 2}},
+		},
+	}
+}
+
+func Test_parseResource(t *testing.T) {
+	name, obj, err := parseResource("es", "elasticsearch")
+	require.NoError(t, err)
+	require.Equal(t, "es", name)
+	require.IsType(t, &esv1.Elasticsearch{}, obj)
+
+	name, obj, err = parseResource("Kibana/kb", "elasticsearch")
+	require.NoError(t, err)
+	require.Equal(t, "kb", name)
+	require.IsType(t, &kbv1.Kibana{}, obj)
+
+	_, _, err = parseResource("beat/b", "elasticsearch")
+	require.Error(t, err)
+	_, _, err = parseResource("kibana/", "elasticsearch")
+	require.Error(t, err)
+}
+
+func Test_getClusters(t *testing.T) {
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	es := newES()
+	es.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+	es.Annotations = map[string]string{common.PauseAnnotationName: "true"}
+	es.Status = esv1.ElasticsearchStatus{Health: esv1.ElasticsearchGreenHealth, Phase: esv1.ElasticsearchReadyPhase}
+	es.Status.AvailableNodes = 5
+	other := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "es", CreationTimestamp: metav1.NewTime(now)}}
+	c := k8s.WrappedFakeClient(es, &other)
+
+	var out bytes.Buffer
+	require.NoError(t, getClusters(c, "ns", &out, now))
+	require.Equal(t, `NAMESPACE   NAME   HEALTH   NODES   VERSION   PHASE   PAUSED   AGE
+ns          es     green    5       7.6.2     Ready   true     120m
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, getClusters(c, "", &out, now))
+	require.Contains(t, out.String(), "other       es     unknown   0")
+}
+
+func Test_setPaused(t *testing.T) {
+	require.NoError(t, eckscheme.SetupScheme())
+	c := k8s.WrappedFakeClient(newES())
+	var out bytes.Buffer
+
+	require.NoError(t, setPaused(c, cluster, &esv1.Elasticsearch{}, true, &out))
+	var es esv1.Elasticsearch
+	require.NoError(t, c.Get(cluster, &es))
+	require.True(t, common.IsPaused(es.ObjectMeta))
+	require.Equal(t, "Elasticsearch ns/es paused\n", out.String())
+
+	out.Reset()
+	require.NoError(t, setPaused(c, cluster, &esv1.Elasticsearch{}, true, &out))
+	require.Equal(t, "Elasticsearch ns/es is already paused\n", out.String())
+
+	require.NoError(t, setPaused(c, cluster, &esv1.Elasticsearch{}, false, &out))
+	var resumed esv1.Elasticsearch
+	require.NoError(t, c.Get(cluster, &resumed))
+	require.NotContains(t, resumed.Annotations, common.PauseAnnotationName)
+}
+
+func Test_rotateCerts(t *testing.T) {
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: name}}
+	}
+	c := k8s.WrappedFakeClient(newES(), secret("es-es-http-certs-internal"), secret("es-es-http-ca-internal"))
+
+	// the transport certificates Secret does not exist
+	require.NoError(t, rotateCerts(c, cluster, &bytes.Buffer{}))
+	require.True(t, apierrors.IsNotFound(c.Get(types.NamespacedName{Namespace: "ns", Name: "es-es-http-certs-internal"}, &corev1.Secret{})))
+	// the CA is kept
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "es-es-http-ca-internal"}, &corev1.Secret{}))
+
+	require.True(t, apierrors.IsNotFound(rotateCerts(c, types.NamespacedName{Namespace: "ns", Name: "unknown"}, &bytes.Buffer{})))
+}
+
+func Test_rollingRestart(t *testing.T) {
+	c := k8s.WrappedFakeClient(newES())
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	require.NoError(t, rollingRestart(c, cluster, now, &bytes.Buffer{}))
+
+	var es esv1.Elasticsearch
+	require.NoError(t, c.Get(cluster, &es))
+	for _, nodeSet := range es.Spec.NodeSets {
+		require.Equal(t, "2020-03-04T05:06:07Z", nodeSet.PodTemplate.Annotations[RestartedAtAnnotation])
+	}
+}
+
+func Test_diagnostics(t *testing.T) {
+	c := k8s.WrappedFakeClient(newES())
+	require.NoError(t, requestDiagnostics(c, cluster, "1"))
+	var es esv1.Elasticsearch
+	require.NoError(t, c.Get(cluster, &es))
+	require.Equal(t, "1", es.Annotations[diagnostics.DiagnosticsAnnotation])
+
+	// not generated yet
+	bundle, err := fetchDiagnostics(c, cluster, "1")
+	require.NoError(t, err)
+	require.Nil(t, bundle)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-es-diagnostics", Annotations: map[string]string{
+			diagnostics.DiagnosticsRequestAnnotation: "0",
+		}},
+		Data: map[string][]byte{diagnostics.BundleKey: []byte("bundle")},
+	}
+	require.NoError(t, c.Create(&secret))
+	// generated for a previous request
+	bundle, err = fetchDiagnostics(c, cluster, "1")
+	require.NoError(t, err)
+	require.Nil(t, bundle)
+
+	secret.Annotations[diagnostics.DiagnosticsRequestAnnotation] = "1"
+	require.NoError(t, c.Update(&secret))
+	bundle, err = fetchDiagnostics(c, cluster, "1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("bundle"), bundle)
+}
+
+func Test_exportImportBundle(t *testing.T) {
+	require.NoError(t, eckscheme.SetupScheme())
+	passphrase := []byte("passphrase")
+	exported := newES()
+	exported.Annotations = map[string]string{bootstrap.ClusterUUIDAnnotationName: "uuid"}
+	source := k8s.WrappedFakeClient(exported, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-es-elastic-user"},
+		Data:       map[string][]byte{"elastic": []byte("password")},
+	})
+	data, err := exportBundle(source, "ns", passphrase)
+	require.NoError(t, err)
+
+	target := k8s.WrappedFakeClient()
+	var out bytes.Buffer
+	require.Error(t, importBundle(target, data, []byte("wrong"), false, &out))
+	require.NoError(t, importBundle(target, data, passphrase, true, &out))
+	require.Equal(t, "Secret ns/es-es-elastic-user created\nElasticsearch ns/es created\n", out.String())
+	var es esv1.Elasticsearch
+	require.NoError(t, target.Get(cluster, &es))
+	require.Equal(t, newES().Spec, es.Spec)
+	require.NotContains(t, es.Annotations, bootstrap.ClusterUUIDAnnotationName)
+
+	out.Reset()
+	require.NoError(t, importBundle(target, data, passphrase, false, &out))
+	require.Equal(t, "Secret ns/es-es-elastic-user already exists, left untouched\nElasticsearch ns/es already exists, left untouched\n", out.String())
+}
@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// newPauseCmd returns the pause command, or the resume command if pause is false.
+func newPauseCmd(flags *clientFlags, pause bool) *cobra.Command {
+	use, short := "pause", "Stop the operator from reconciling a resource"
+	if !pause {
+		use, short = "resume", "Let the operator reconcile a paused resource again"
+	}
+	return &cobra.Command{
+		Use:   use + " <name> | <kind>/<name>",
+		Short: short,
+		Long: short + ", through the " + common.PauseAnnotationName + " annotation. " +
+			"The resource is an Elasticsearch cluster unless its kind is specified.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, obj, err := parseResource(args[0], "elasticsearch")
+			if err != nil {
+				return err
+			}
+			c, namespace, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			return setPaused(c, types.NamespacedName{Namespace: namespace, Name: name}, obj, pause, cmd.OutOrStdout())
+		},
+	}
+}
+
+// setPaused sets or removes the pause annotation of the given resource.
+func setPaused(c k8s.Client, nsn types.NamespacedName, obj runtime.Object, pause bool, out io.Writer) error {
+	if err := c.Get(nsn, obj); err != nil {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	kind := kindOf(obj)
+	if common.IsPaused(metav1.ObjectMeta{Annotations: accessor.GetAnnotations()}) == pause {
+		fmt.Fprintf(out, "%s %s is already %s\n", kind, nsn, pausedState(pause))
+		return nil
+	}
+
+	original := obj.DeepCopyObject()
+	annotations := accessor.GetAnnotations()
+	if pause {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[common.PauseAnnotationName] = "true"
+	} else {
+		delete(annotations, common.PauseAnnotationName)
+	}
+	accessor.SetAnnotations(annotations)
+	if err := c.Patch(obj, client.MergeFrom(original)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s %s %s\n", kind, nsn, pausedState(pause))
+	return nil
+}
+
+func pausedState(paused bool) string {
+	if paused {
+		return "paused"
+	}
+	return "resumed"
+}
@@ -0,0 +1,66 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// RestartedAtAnnotation is set in the Pod template of all the NodeSets of a cluster to restart it. Changing the Pod
+// template makes the operator replace the Pods one at a time, as for any other change of the specification.
+const RestartedAtAnnotation = "elasticsearch.k8s.elastic.co/restarted-at"
+
+func newRestartCmd(flags *clientFlags) *cobra.Command {
+	var rolling bool
+	cmd := &cobra.Command{
+		Use:   "restart --rolling <cluster>",
+		Short: "Restart the nodes of an Elasticsearch cluster",
+		Long: "Restart the nodes of an Elasticsearch cluster one at a time, by setting the " + RestartedAtAnnotation +
+			" annotation in the Pod template of all its NodeSets.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !rolling {
+				return errors.New("only rolling restarts are supported, use --rolling")
+			}
+			c, namespace, err := flags.newClient()
+			if err != nil {
+				return err
+			}
+			return rollingRestart(c, types.NamespacedName{Namespace: namespace, Name: args[0]}, time.Now(), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&rolling, "rolling", false, "Restart the nodes one at a time, keeping the cluster available")
+	return cmd
+}
+
+// rollingRestart updates the Pod template of all the NodeSets of the given cluster with the restart time.
+func rollingRestart(c k8s.Client, cluster types.NamespacedName, now time.Time, out io.Writer) error {
+	var es esv1.Elasticsearch
+	if err := c.Get(cluster, &es); err != nil {
+		return err
+	}
+	restartedAt := now.UTC().Format(time.RFC3339)
+	for i := range es.Spec.NodeSets {
+		template := &es.Spec.NodeSets[i].PodTemplate
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[RestartedAtAnnotation] = restartedAt
+	}
+	if err := c.Update(&es); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Rolling restart of Elasticsearch %s requested\n", cluster)
+	return nil
+}
@@ -0,0 +1,227 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package manager
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	stackv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stack/v1alpha1"
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/apmserver"
+	asesassn "github.com/elastic/cloud-on-k8s/pkg/controller/apmserverelasticsearchassociation"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearchclone"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticstack"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch"
+	entsassn "github.com/elastic/cloud-on-k8s/pkg/controller/entsearchassociation"
+	esauditassn "github.com/elastic/cloud-on-k8s/pkg/controller/esauditassociation"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana"
+	kbassn "github.com/elastic/cloud-on-k8s/pkg/controller/kibanaassociation"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/license"
+	licensetrial "github.com/elastic/cloud-on-k8s/pkg/controller/license/trial"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/reindexjob"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/remoteca"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/stackconfigpolicy"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/set"
+)
+
+// Controllers that can be enabled with the controllers flag.
+const (
+	ApmServerController         = "apmserver"
+	ElasticsearchController     = "elasticsearch"
+	ElasticStackController      = "elasticstack"
+	EnterpriseSearchController  = "enterprisesearch"
+	KibanaController            = "kibana"
+	StackConfigPolicyController = "stackconfigpolicy"
+)
+
+// AllControllers are the controllers enabled by default.
+var AllControllers = []string{
+	ApmServerController,
+	ElasticsearchController,
+	ElasticStackController,
+	EnterpriseSearchController,
+	KibanaController,
+	StackConfigPolicyController,
+}
+
+// controllerSpec describes how to create a controller, and when.
+type controllerSpec struct {
+	// name identifies the controller in logs
+	name string
+	// requires are the controllers that must be enabled for this controller to be created
+	requires []string
+	// resources are the custom resources managed or watched by the controller, whose CRDs must be installed
+	resources []runtime.Object
+	add       func() error
+}
+
+func controllerSpecs(mgr manager.Manager, params operator.Parameters, accessReviewer rbac.AccessReviewer) []controllerSpec {
+	return []controllerSpec{
+		{
+			name:      "ApmServer",
+			requires:  []string{ApmServerController},
+			resources: []runtime.Object{&apmv1.ApmServer{}},
+			add:       func() error { return apmserver.Add(mgr, params) },
+		},
+		{
+			name:      "Elasticsearch",
+			requires:  []string{ElasticsearchController},
+			resources: []runtime.Object{&esv1.Elasticsearch{}, &authv1alpha1.ElasticsearchUser{}, &authv1alpha1.ElasticsearchRole{}},
+			add:       func() error { return elasticsearch.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "Kibana",
+			requires:  []string{KibanaController},
+			resources: []runtime.Object{&kbv1.Kibana{}, &esv1.Elasticsearch{}},
+			add:       func() error { return kibana.Add(mgr, params) },
+		},
+		{
+			name:      "EnterpriseSearch",
+			requires:  []string{EnterpriseSearchController},
+			resources: []runtime.Object{&entsv1beta1.EnterpriseSearch{}},
+			add:       func() error { return enterprisesearch.Add(mgr, params) },
+		},
+		{
+			name:      "ApmServerElasticsearchAssociation",
+			requires:  []string{ApmServerController, ElasticsearchController},
+			resources: []runtime.Object{&apmv1.ApmServer{}, &esv1.Elasticsearch{}},
+			add:       func() error { return asesassn.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "KibanaAssociation",
+			requires:  []string{KibanaController, ElasticsearchController},
+			resources: []runtime.Object{&kbv1.Kibana{}, &esv1.Elasticsearch{}},
+			add:       func() error { return kbassn.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "EnterpriseSearchAssociation",
+			requires:  []string{EnterpriseSearchController, ElasticsearchController},
+			resources: []runtime.Object{&entsv1beta1.EnterpriseSearch{}, &esv1.Elasticsearch{}},
+			add:       func() error { return entsassn.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "ElasticsearchAuditAssociation",
+			requires:  []string{ElasticsearchController},
+			resources: []runtime.Object{&esv1.Elasticsearch{}},
+			add:       func() error { return esauditassn.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "ElasticsearchClone",
+			requires:  []string{ElasticsearchController},
+			resources: []runtime.Object{&esv1.ElasticsearchClone{}, &esv1.Elasticsearch{}},
+			add:       func() error { return elasticsearchclone.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "ReindexJob",
+			requires:  []string{ElasticsearchController},
+			resources: []runtime.Object{&esv1.ReindexJob{}},
+			add:       func() error { return reindexjob.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "RemoteClusterCertificateAuthorites",
+			requires:  []string{ElasticsearchController},
+			resources: []runtime.Object{&esv1.Elasticsearch{}},
+			add:       func() error { return remoteca.Add(mgr, accessReviewer, params) },
+		},
+		{
+			name:      "StackConfigPolicy",
+			requires:  []string{StackConfigPolicyController},
+			resources: []runtime.Object{&policyv1alpha1.StackConfigPolicy{}, &esv1.Elasticsearch{}, &kbv1.Kibana{}},
+			add:       func() error { return stackconfigpolicy.Add(mgr, params) },
+		},
+		{
+			name:      "ElasticStack",
+			requires:  []string{ElasticStackController},
+			resources: []runtime.Object{&stackv1alpha1.ElasticStack{}, &esv1.Elasticsearch{}, &kbv1.Kibana{}, &apmv1.ApmServer{}, &entsv1beta1.EnterpriseSearch{}},
+			add:       func() error { return elasticstack.Add(mgr, params) },
+		},
+		{
+			name:      "License",
+			requires:  []string{ElasticsearchController},
+			resources: []runtime.Object{&esv1.Elasticsearch{}},
+			add:       func() error { return license.Add(mgr, params) },
+		},
+		{
+			name: "LicenseTrial",
+			add:  func() error { return licensetrial.Add(mgr, params) },
+		},
+	}
+}
+
+// setupControllers creates the controllers required by the enabled ones, skipping the controllers whose CRDs are not
+// installed. It returns the names of the created controllers.
+func setupControllers(mgr manager.Manager, params operator.Parameters, accessReviewer rbac.AccessReviewer, enabled []string) (set.StringSet, error) {
+	for _, c := range enabled {
+		if !set.Make(AllControllers...).Has(c) {
+			return nil, fmt.Errorf("unknown controller %s, supported controllers are %v", c, AllControllers)
+		}
+	}
+	enabledControllers := set.Make(enabled...)
+
+	created := set.StringSet{}
+	for _, spec := range controllerSpecs(mgr, params, accessReviewer) {
+		if missing := missingControllers(enabledControllers, spec.requires); len(missing) > 0 {
+			log.Info("Controller disabled", "controller", spec.name, "reason", "not enabled", "required", missing)
+			continue
+		}
+		missing, err := missingCRDs(mgr.GetRESTMapper(), spec.resources)
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) > 0 {
+			log.Info("Controller disabled", "controller", spec.name, "reason", "CRDs not installed", "missing_crds", missing)
+			continue
+		}
+		if err := spec.add(); err != nil {
+			log.Error(err, "unable to create controller", "controller", spec.name)
+			return nil, err
+		}
+		created.Add(spec.name)
+	}
+	return created, nil
+}
+
+func missingControllers(enabled set.StringSet, required []string) []string {
+	var missing []string
+	for _, c := range required {
+		if !enabled.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// missingCRDs returns the kinds of the given resources which are not served by the API server.
+func missingCRDs(mapper meta.RESTMapper, resources []runtime.Object) ([]string, error) {
+	var missing []string
+	for _, resource := range resources {
+		gvk, err := apiutil.GVKForObject(resource, clientgoscheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				missing = append(missing, gvk.GroupKind().String())
+				continue
+			}
+			return nil, err
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,48 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/set"
+)
+
+func Test_missingControllers(t *testing.T) {
+	enabled := set.Make(ElasticsearchController, KibanaController)
+	require.Empty(t, missingControllers(enabled, nil))
+	require.Empty(t, missingControllers(enabled, []string{KibanaController, ElasticsearchController}))
+	require.Equal(t, []string{ApmServerController}, missingControllers(enabled, []string{ApmServerController, ElasticsearchController}))
+}
+
+func Test_missingCRDs(t *testing.T) {
+	require.NoError(t, controllerscheme.SetupScheme())
+	esGVK := schema.GroupVersionKind{Group: "elasticsearch.k8s.elastic.co", Version: "v1", Kind: "Elasticsearch"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{esGVK.GroupVersion()})
+	mapper.Add(esGVK, meta.RESTScopeNamespace)
+
+	missing, err := missingCRDs(mapper, []runtime.Object{&esv1.Elasticsearch{}})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	missing, err = missingCRDs(mapper, []runtime.Object{&kbv1.Kibana{}, &esv1.Elasticsearch{}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Kibana.kibana.k8s.elastic.co"}, missing)
+}
+
+func Test_setupControllers_unknownController(t *testing.T) {
+	_, err := setupControllers(nil, operator.Parameters{}, rbac.NewPermissiveAccessReviewer(), []string{ElasticsearchController, "beat"})
+	require.Error(t, err)
+}
@@ -15,7 +15,6 @@ import (
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -26,37 +25,59 @@ import (
 	// allow gcp authentication
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/elastic/cloud-on-k8s/pkg/about"
 	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	esv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1beta1"
 	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/apmserver"
 	asesassn "github.com/elastic/cloud-on-k8s/pkg/controller/apmserverelasticsearchassociation"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/association"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/audit"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/cloudevents"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/footprint"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/health"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/namespaces"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/notifier"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/profile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/quota"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch"
-	entsassn "github.com/elastic/cloud-on-k8s/pkg/controller/entsearchassociation"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/shutdown"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/validation/policy"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/cleanup"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	esauditassn "github.com/elastic/cloud-on-k8s/pkg/controller/esauditassociation"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/federation"
 	kbassn "github.com/elastic/cloud-on-k8s/pkg/controller/kibanaassociation"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/license"
-	licensetrial "github.com/elastic/cloud-on-k8s/pkg/controller/license/trial"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/remoteca"
+	namespacescontroller "github.com/elastic/cloud-on-k8s/pkg/controller/namespaces"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/operatorconfig"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/webhook"
 	"github.com/elastic/cloud-on-k8s/pkg/dev"
 	"github.com/elastic/cloud-on-k8s/pkg/dev/portforward"
 	licensing "github.com/elastic/cloud-on-k8s/pkg/license"
+	"github.com/elastic/cloud-on-k8s/pkg/selfmonitoring"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	logutil "github.com/elastic/cloud-on-k8s/pkg/utils/log"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/set"
 	"go.uber.org/automaxprocs/maxprocs"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -64,9 +85,20 @@ const (
 	DefaultMetricPort        = 0 // disabled
 	WebhookConfigurationName = "elastic-webhook.k8s.elastic.co"
 	WebhookPort              = 9443
+
+	// highestUserDefinablePriority is the highest value of the PriorityClasses that are not reserved to the system
+	highestUserDefinablePriority = 1000000000
 )
 
 var (
+	// ConversionCRDs are the CRDs of the resources converted between their versions by the conversion webhook of the
+	// operator, once their conversion strategy is Webhook.
+	ConversionCRDs = []string{
+		"apmservers.apm.k8s.elastic.co",
+		"elasticsearches.elasticsearch.k8s.elastic.co",
+		"kibanas.kibana.k8s.elastic.co",
+	}
+
 	// Cmd is the cobra command to start the manager.
 	Cmd = &cobra.Command{
 		Use:   "manager",
@@ -82,6 +114,16 @@ var (
 )
 
 func init() {
+	Cmd.Flags().String(
+		operator.AWSRegionFlag,
+		"",
+		"AWS region of the Secrets Manager used as credentials store (defaults to the AWS_REGION environment variable)",
+	)
+	Cmd.Flags().String(
+		operator.AuditLogFlag,
+		"",
+		fmt.Sprintf("Destination of the audit log of the mutations performed by the operator, %s or the path of a file (defaults to none)", audit.StdoutDestination),
+	)
 	Cmd.Flags().Bool(
 		operator.AutoPortForwardFlag,
 		false,
@@ -108,11 +150,52 @@ func init() {
 		certificates.DefaultCertValidity,
 		"Duration representing how long before a newly created TLS certificate expires",
 	)
+	Cmd.Flags().String(
+		operator.CloudEventsEndpointFlag,
+		"",
+		"HTTP endpoint or kafka:// topic to which the lifecycle milestones of the Elasticsearch clusters are published as CloudEvents (defaults to none)",
+	)
+	Cmd.Flags().StringSlice(
+		operator.CloudEventsTypesFlag,
+		nil,
+		fmt.Sprintf("Types of the CloudEvents to publish, among %s (defaults to all)", strings.Join(cloudevents.Types, ", ")),
+	)
 	Cmd.Flags().String(
 		operator.ContainerRegistryFlag,
 		container.DefaultContainerRegistry,
 		"Container registry to use when downloading Elastic Stack container images",
 	)
+	Cmd.Flags().StringSlice(
+		operator.ContainerRegistriesByArchFlag,
+		nil,
+		"Comma-separated list of container registries holding the Elastic Stack images of specific architectures, as <architecture>=<registry>, for example arm64=registry.example.com/arm64 (defaults to the multi-architecture images of the container registry)",
+	)
+	Cmd.Flags().StringSlice(
+		operator.ContainerRegistryMirrorsFlag,
+		nil,
+		"Comma-separated list of mirrors replacing container registries in the default images and the custom images of the resources, as <registry>=<mirror>, for example docker.elastic.co=registry.example.com/elastic",
+	)
+	Cmd.Flags().StringSlice(
+		operator.ControllersFlag,
+		AllControllers,
+		fmt.Sprintf("Comma-separated list of controllers to enable, among %s", strings.Join(AllControllers, ",")),
+	)
+	Cmd.Flags().String(
+		operator.CredentialsStoreFlag,
+		credentials.KubernetesStoreType,
+		fmt.Sprintf("External store in which generated credentials are persisted, one of %s, %s or %s",
+			credentials.KubernetesStoreType, credentials.VaultStoreType, credentials.AWSSecretsManagerStoreType),
+	)
+	Cmd.Flags().Duration(
+		operator.CredentialsStoreCacheTTLFlag,
+		credentials.DefaultCacheTTL,
+		"Duration during which the credentials read from or written to the external credentials store are reused without reading the store again",
+	)
+	Cmd.Flags().String(
+		operator.CredentialsStorePrefixFlag,
+		"eck",
+		"Prefix of the keys under which generated credentials are persisted in the external credentials store",
+	)
 	Cmd.Flags().String(
 		operator.DebugHTTPListenFlag,
 		"localhost:6060",
@@ -123,6 +206,78 @@ func init() {
 		false, // Set to false for backward compatibility
 		"Restrict cross-namespace resource association through RBAC (eg. referencing Elasticsearch from Kibana)",
 	)
+	Cmd.Flags().String(
+		operator.FederationClusterNameFlag,
+		"",
+		"Name of this Kubernetes cluster in the operator federation, enables remote clusters running in other Kubernetes clusters (defaults to none)",
+	)
+	Cmd.Flags().String(
+		operator.FederationKubeconfigFlag,
+		"",
+		"Path to the kubeconfig of the Kubernetes cluster holding the Secrets shared by the federated operators (defaults to this Kubernetes cluster)",
+	)
+	Cmd.Flags().String(
+		operator.FederationNamespaceFlag,
+		"",
+		"Namespace of the Secrets shared by the federated operators (defaults to the operator namespace)",
+	)
+	Cmd.Flags().StringSlice(
+		operator.ForeignFieldsFlag,
+		nil,
+		"Comma-separated list of fields of the generated objects managed by external mutators and ignored by the operator, as <kind>:<path>, for example *:metadata.annotations[sidecar.istio.io/*]",
+	)
+	Cmd.Flags().Bool(
+		operator.GCDryRunFlag,
+		false,
+		"Log the orphaned resources instead of garbage collecting them",
+	)
+	Cmd.Flags().Duration(
+		operator.GCGracePeriodFlag,
+		cleanup.DeleteAfter,
+		"Duration after the creation of an orphaned resource before it can be garbage collected",
+	)
+	Cmd.Flags().String(
+		operator.GeoIPDownloaderEndpointFlag,
+		"",
+		"Endpoint from which the managed Elasticsearch clusters download the GeoIP database updates, such as an internal mirror (defaults to the Elastic GeoIP endpoint)",
+	)
+	Cmd.Flags().String(
+		operator.ImageDigestPolicyFlag,
+		container.ImageDigestPolicyNone,
+		fmt.Sprintf("Whether the Elasticsearch images are deployed by tag (%s), or pinned to the digest their tag resolves to when first deployed (%s)",
+			container.ImageDigestPolicyNone, container.ImageDigestPolicyPin),
+	)
+	Cmd.Flags().Bool(
+		operator.ElasticsearchClientCompressionFlag,
+		false,
+		"Compress the bodies of the requests to the Elasticsearch clusters with gzip",
+	)
+	Cmd.Flags().Duration(
+		operator.ElasticsearchClientReadTimeoutFlag,
+		esclient.DefaultReqTimeout,
+		"Timeout of the requests retrieving the state of the Elasticsearch clusters, such as their health. 0 to disable",
+	)
+	Cmd.Flags().Duration(
+		operator.ElasticsearchClientWriteTimeoutFlag,
+		esclient.DefaultReqTimeout,
+		"Timeout of the requests changing the state of the Elasticsearch clusters, such as their settings or the shutdown of their nodes. 0 to disable",
+	)
+	Cmd.Flags().Duration(
+		operator.ElasticsearchObservationIntervalFlag,
+		observer.DefaultObservationInterval,
+		"Interval between two observations of the health of each Elasticsearch cluster",
+	)
+	Cmd.Flags().StringSlice(
+		operator.ElasticsearchObserverProbesFlag,
+		nil,
+		fmt.Sprintf("Additional probes run at each observation of each Elasticsearch cluster, of the form <name>[=<max>], among %s and %s. Healthy nodes are not restarted while a probe fails",
+			observer.PendingTasksProbeName, observer.CircuitBreakerTripsProbeName),
+	)
+	Cmd.Flags().Int(
+		operator.ElasticsearchObserverWorkersFlag,
+		0,
+		"Number of workers observing the Elasticsearch clusters in batches at each observation interval, instead of one goroutine per cluster. Recommended when managing a large number of clusters. 0 to disable",
+	)
 	Cmd.Flags().Bool(
 		operator.EnableTracingFlag,
 		false,
@@ -137,6 +292,11 @@ func init() {
 		true,
 		"Enables automatic certificates management for the webhook. The Secret and the ValidatingWebhookConfiguration must be created before running the operator",
 	)
+	Cmd.Flags().Int(
+		operator.HealthProbePortFlag,
+		0,
+		"Port to use for exposing the health probes on /healthz and /readyz (set 0 to disable)",
+	)
 	Cmd.Flags().Int(
 		operator.MaxConcurrentReconcilesFlag,
 		3,
@@ -147,16 +307,132 @@ func init() {
 		DefaultMetricPort,
 		"Port to use for exposing metrics in the Prometheus format (set 0 to disable)",
 	)
+	Cmd.Flags().String(
+		operator.MonitoringElasticsearchFlag,
+		"",
+		"Elasticsearch cluster managed by the operator to ship the operator logs and metrics to, as namespace/name or name in the operator namespace (defaults to none)",
+	)
+	Cmd.Flags().Duration(
+		operator.MonitoringIntervalFlag,
+		selfmonitoring.DefaultInterval,
+		"Interval at which the operator logs and metrics are shipped to the monitoring Elasticsearch cluster",
+	)
 	Cmd.Flags().StringSlice(
 		operator.NamespacesFlag,
 		nil,
 		"comma-separated list of namespaces in which this operator should manage resources (defaults to all namespaces)",
 	)
+	Cmd.Flags().String(
+		operator.NamespacesConfigMapFlag,
+		"",
+		"Name of a ConfigMap in the operator namespace listing additional namespaces to manage, updated at runtime",
+	)
+	Cmd.Flags().Bool(
+		operator.NodeDrainHandlingFlag,
+		false,
+		"Migrates the data of the Elasticsearch nodes away from the Kubernetes nodes cordoned for a drain, before their Pods are evicted",
+	)
+	Cmd.Flags().Duration(
+		operator.NotificationUpgradeStallFlag,
+		notifier.DefaultUpgradeStallThreshold,
+		"Duration after which an Elasticsearch upgrade in progress is notified as stalled",
+	)
+	Cmd.Flags().String(
+		operator.NotificationWebhookFlag,
+		"",
+		"Webhook to which the Elasticsearch clusters turning red and the stalled upgrades are notified, unless the cluster sets its own (defaults to none)",
+	)
+	Cmd.Flags().StringSlice(
+		operator.NotificationWebhookAllowedHostsFlag,
+		nil,
+		"Hosts of the webhooks the Elasticsearch clusters can set to receive their own notifications (defaults to none, the webhooks of the clusters are ignored)",
+	)
+	Cmd.Flags().Bool(
+		operator.OpenShiftFlag,
+		false,
+		"Enables the OpenShift profile: Routes for the HTTP services, and security contexts compatible with the restricted Security Context Constraints",
+	)
+	Cmd.Flags().String(
+		operator.OperatorConfigMapFlag,
+		"",
+		"Name of a ConfigMap in the operator namespace overriding the settings that can be updated without restarting the operator",
+	)
 	Cmd.Flags().String(
 		operator.OperatorNamespaceFlag,
 		"",
 		"K8s namespace the operator runs in",
 	)
+	Cmd.Flags().String(
+		operator.PodSecurityStandardFlag,
+		podsecurity.StandardNone,
+		fmt.Sprintf("Pod Security Standard the generated Pods comply with by default: %s, or %s to leave their security context to the user",
+			podsecurity.StandardRestricted, podsecurity.StandardNone),
+	)
+	Cmd.Flags().StringSlice(
+		operator.PreemptionTaintsFlag,
+		nil,
+		"Comma-separated keys of the taints set by the node termination handlers on the Kubernetes nodes about to be reclaimed, such as spot instances, to migrate the data of their Elasticsearch nodes away",
+	)
+	Cmd.Flags().Int32(
+		operator.PriorityClassMaxValueFlag,
+		0,
+		"Maximum value of the PriorityClasses the operator creates for the Elasticsearch node roles declaring one, 0 to never create PriorityClasses",
+	)
+	Cmd.Flags().Bool(
+		operator.RightSizingRecommendationsFlag,
+		false,
+		"Adds right-sizing recommendations derived from the observed heap, disk, indexing and search usage of the nodes to the Elasticsearch reports",
+	)
+	Cmd.Flags().Duration(
+		operator.ShutdownDrainTimeoutFlag,
+		shutdown.DefaultDrainTimeout,
+		"Maximum duration to wait for in-flight reconciliations to complete when the operator stops",
+	)
+	Cmd.Flags().String(
+		operator.VaultAddressFlag,
+		"",
+		"Address of the Vault server used as credentials store",
+	)
+	Cmd.Flags().String(
+		operator.VaultCACertFlag,
+		"",
+		"Path to a PEM-encoded CA certificate file used to verify the certificate of the Vault server",
+	)
+	Cmd.Flags().String(
+		operator.VaultClientCertFlag,
+		"",
+		"Path to a PEM-encoded certificate file used to authenticate to the Vault server with TLS",
+	)
+	Cmd.Flags().String(
+		operator.VaultClientKeyFlag,
+		"",
+		"Path to the PEM-encoded private key file of the certificate used to authenticate to the Vault server with TLS",
+	)
+	Cmd.Flags().String(
+		operator.VaultKubernetesAuthMountFlag,
+		credentials.DefaultVaultKubernetesAuthMount,
+		"Mount path of the Vault Kubernetes auth method",
+	)
+	Cmd.Flags().String(
+		operator.VaultKubernetesAuthRoleFlag,
+		"",
+		"Role used to log in to Vault with the Kubernetes auth method and the service account token of the operator",
+	)
+	Cmd.Flags().String(
+		operator.VaultMountFlag,
+		credentials.DefaultVaultMount,
+		"Mount path of the Vault KV version 2 secrets engine used as credentials store",
+	)
+	Cmd.Flags().String(
+		operator.VaultNamespaceFlag,
+		"",
+		"Vault Enterprise namespace of the secrets engine and auth method used as credentials store",
+	)
+	Cmd.Flags().String(
+		operator.VaultTokenFileFlag,
+		"",
+		"Path to a file containing the Vault token, read before each request (defaults to the Kubernetes auth method if a role is set, or to the VAULT_TOKEN environment variable)",
+	)
 	Cmd.Flags().String(
 		operator.WebhookCertDirFlag,
 		// this is controller-runtime's own default, copied here for making the default explicit when using `--help`
@@ -188,9 +464,12 @@ func execute() {
 		os.Exit(1)
 	}
 
+	// the controllers register their debug endpoints on the mux of the debug HTTP server, if enabled
+	var debugMux *http.ServeMux
 	if dev.Enabled {
 		// expose pprof if development mode is enabled
 		mux := http.NewServeMux()
+		debugMux = mux
 		mux.HandleFunc("/debug/pprof/", pprof.Index)
 		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
@@ -231,6 +510,72 @@ func execute() {
 	containerRegistry := viper.GetString(operator.ContainerRegistryFlag)
 	log.Info("Setting default container registry", "registry", containerRegistry)
 	container.SetContainerRegistry(containerRegistry)
+	archContainerRegistries, err := container.ParseArchContainerRegistries(viper.GetStringSlice(operator.ContainerRegistriesByArchFlag))
+	if err != nil {
+		log.Error(err, "invalid container registries", "flag", operator.ContainerRegistriesByArchFlag)
+		os.Exit(1)
+	}
+	container.SetArchContainerRegistries(archContainerRegistries)
+	registryMirrors, err := container.ParseRegistryMirrors(viper.GetStringSlice(operator.ContainerRegistryMirrorsFlag))
+	if err != nil {
+		log.Error(err, "invalid container registry mirrors", "flag", operator.ContainerRegistryMirrorsFlag)
+		os.Exit(1)
+	}
+	container.SetRegistryMirrors(registryMirrors)
+	foreignFields, err := reconciler.ParseForeignFields(viper.GetStringSlice(operator.ForeignFieldsFlag))
+	if err != nil {
+		log.Error(err, "invalid foreign fields", "flag", operator.ForeignFieldsFlag)
+		os.Exit(1)
+	}
+	reconciler.SetForeignFields(foreignFields)
+	if err := podsecurity.ValidateStandard(viper.GetString(operator.PodSecurityStandardFlag)); err != nil {
+		log.Error(err, "invalid Pod Security Standard", "flag", operator.PodSecurityStandardFlag)
+		os.Exit(1)
+	}
+	priorityClassMaxValue := viper.GetInt32(operator.PriorityClassMaxValueFlag)
+	if priorityClassMaxValue < 0 || priorityClassMaxValue > highestUserDefinablePriority {
+		log.Error(fmt.Errorf("must be between 0 and %d", highestUserDefinablePriority),
+			"invalid PriorityClass maximum value", "flag", operator.PriorityClassMaxValueFlag)
+		os.Exit(1)
+	}
+	// Kubernetes nodes are cluster-scoped: they are not in the cache of an operator restricted to some namespaces
+	if viper.GetBool(operator.NodeDrainHandlingFlag) || len(viper.GetStringSlice(operator.PreemptionTaintsFlag)) > 0 {
+		if len(viper.GetStringSlice(operator.NamespacesFlag)) > 0 || viper.GetString(operator.NamespacesConfigMapFlag) != "" {
+			log.Error(fmt.Errorf("requires the operator to manage all namespaces"), "invalid leaving Kubernetes nodes handling",
+				"flags", []string{operator.NodeDrainHandlingFlag, operator.PreemptionTaintsFlag})
+			os.Exit(1)
+		}
+	}
+	imageDigestResolver, err := container.NewDigestResolver(viper.GetString(operator.ImageDigestPolicyFlag))
+	if err != nil {
+		log.Error(err, "invalid image digest policy", "flag", operator.ImageDigestPolicyFlag)
+		os.Exit(1)
+	}
+
+	// set the external store of generated credentials, if any
+	credentialsStore, err := credentials.NewStore(credentials.Params{
+		Type:                     viper.GetString(operator.CredentialsStoreFlag),
+		VaultAddress:             viper.GetString(operator.VaultAddressFlag),
+		VaultMount:               viper.GetString(operator.VaultMountFlag),
+		VaultTokenFile:           viper.GetString(operator.VaultTokenFileFlag),
+		VaultKubernetesAuthRole:  viper.GetString(operator.VaultKubernetesAuthRoleFlag),
+		VaultKubernetesAuthMount: viper.GetString(operator.VaultKubernetesAuthMountFlag),
+		VaultNamespace:           viper.GetString(operator.VaultNamespaceFlag),
+		VaultCACert:              viper.GetString(operator.VaultCACertFlag),
+		VaultClientCert:          viper.GetString(operator.VaultClientCertFlag),
+		VaultClientKey:           viper.GetString(operator.VaultClientKeyFlag),
+		AWSRegion:                viper.GetString(operator.AWSRegionFlag),
+	})
+	if err != nil {
+		log.Error(err, "Error setting up the credentials store")
+		os.Exit(1)
+	}
+	log.Info("Setting credentials store", "type", viper.GetString(operator.CredentialsStoreFlag))
+	credentials.SetStore(
+		credentialsStore,
+		viper.GetString(operator.CredentialsStorePrefixFlag),
+		viper.GetDuration(operator.CredentialsStoreCacheTTLFlag),
+	)
 
 	// Get a config to talk to the apiserver
 	log.Info("Setting up client for manager")
@@ -256,7 +601,16 @@ func execute() {
 
 	// configure the manager cache based on the number of managed namespaces
 	managedNamespaces := viper.GetStringSlice(operator.NamespacesFlag)
+	namespacesConfigMap := viper.GetString(operator.NamespacesConfigMapFlag)
+	var dynamicCache *namespaces.DynamicCache
 	switch {
+	case namespacesConfigMap != "":
+		log.Info("Operator configured to manage namespaces listed in a ConfigMap", "namespaces", managedNamespaces,
+			"configmap_name", namespacesConfigMap, "operator_namespace", operatorNamespace)
+		// managed namespaces are updated at runtime, in addition to the ones from the flag and the operator namespace
+		managedNamespaces = append(managedNamespaces, operatorNamespace)
+		dynamicCache = namespaces.NewDynamicCache(managedNamespaces)
+		opts.NewCache = dynamicCache.New
 	case len(managedNamespaces) == 0:
 		log.Info("Operator configured to manage all namespaces")
 	case len(managedNamespaces) == 1 && managedNamespaces[0] == operatorNamespace:
@@ -275,6 +629,24 @@ func execute() {
 	}
 	opts.MetricsBindAddress = fmt.Sprintf(":%d", metricsPort) // 0 to disable
 
+	// only expose the health probes if provided a non-zero port
+	healthProbePort := viper.GetInt(operator.HealthProbePortFlag)
+	if healthProbePort != 0 {
+		log.Info("Exposing health probes on /healthz and /readyz, and the health of the managed resources on /managed",
+			"port", healthProbePort)
+	}
+
+	if auditLog := viper.GetString(operator.AuditLogFlag); auditLog != "" {
+		logger, err := audit.Open(auditLog)
+		if err != nil {
+			log.Error(err, "unable to open the audit log", "audit_log", auditLog)
+			os.Exit(1)
+		}
+		log.Info("Recording the mutations performed by the operator in the audit log", "audit_log", auditLog)
+		audit.Default = logger
+		opts.NewClient = newAuditClient(logger)
+	}
+
 	opts.Port = WebhookPort
 	mgr, err := ctrl.NewManager(cfg, opts)
 	if err != nil {
@@ -288,6 +660,13 @@ func execute() {
 	certValidity, certRotateBefore := ValidateCertExpirationFlags(operator.CertValidityFlag, operator.CertRotateBeforeFlag)
 	log.V(1).Info("Using certificate rotation parameters", operator.CertValidityFlag, certValidity, operator.CertRotateBeforeFlag, certRotateBefore)
 
+	// Verify the observer probes
+	observerProbes := viper.GetStringSlice(operator.ElasticsearchObserverProbesFlag)
+	if _, err := observer.ParseProbes(observerProbes); err != nil {
+		log.Error(err, "invalid observer probes", "flag", operator.ElasticsearchObserverProbesFlag)
+		os.Exit(1)
+	}
+
 	// Setup a client to set the operator uuid config map
 	clientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
@@ -305,7 +684,37 @@ func execute() {
 	if viper.GetBool(operator.EnableTracingFlag) {
 		tracer = tracing.NewTracer("elastic-operator")
 	}
+	var cloudEvents *cloudevents.Publisher
+	if endpoint := viper.GetString(operator.CloudEventsEndpointFlag); endpoint != "" {
+		cloudEvents, err = cloudevents.NewPublisher(endpoint, viper.GetStringSlice(operator.CloudEventsTypesFlag))
+		if err != nil {
+			log.Error(err, "invalid CloudEvents configuration", "flag", operator.CloudEventsEndpointFlag)
+			os.Exit(1)
+		}
+		if err := mgr.Add(cloudEvents); err != nil {
+			log.Error(err, "unable to publish CloudEvents")
+			os.Exit(1)
+		}
+	}
+	notifications, err := notifier.New(
+		viper.GetString(operator.NotificationWebhookFlag),
+		viper.GetStringSlice(operator.NotificationWebhookAllowedHostsFlag),
+		viper.GetDuration(operator.NotificationUpgradeStallFlag),
+	)
+	if err != nil {
+		log.Error(err, "invalid notification configuration", "flag", operator.NotificationWebhookFlag)
+		os.Exit(1)
+	}
+	if err := mgr.Add(notifications); err != nil {
+		log.Error(err, "unable to post notifications")
+		os.Exit(1)
+	}
+	// keep the recent logs in memory for diagnostics bundles
+	recentLogs := logutil.NewRecentLogs(logutil.DefaultRecentLogsSize)
+	logutil.AddOutput(recentLogs)
+
 	params := operator.Parameters{
+		APIReader:         mgr.GetAPIReader(),
 		Dialer:            dialer,
 		OperatorNamespace: operatorNamespace,
 		OperatorInfo:      operatorInfo,
@@ -317,12 +726,64 @@ func execute() {
 			Validity:     certValidity,
 			RotateBefore: certRotateBefore,
 		},
-		MaxConcurrentReconciles: viper.GetInt(operator.MaxConcurrentReconcilesFlag),
-		Tracer:                  tracer,
+		ElasticsearchClientCompression:  viper.GetBool(operator.ElasticsearchClientCompressionFlag),
+		ElasticsearchClientReadTimeout:  viper.GetDuration(operator.ElasticsearchClientReadTimeoutFlag),
+		ElasticsearchClientWriteTimeout: viper.GetDuration(operator.ElasticsearchClientWriteTimeoutFlag),
+		MaxConcurrentReconciles:         viper.GetInt(operator.MaxConcurrentReconcilesFlag),
+		Tracer:                          tracer,
+		CloudEvents:                     cloudEvents,
+		DebugMux:                        debugMux,
+		Drainer:                         shutdown.NewDrainer(),
+		ManagedNamespaces:               dynamicCache,
+		RecentLogs:                      recentLogs,
+		GCGracePeriod:                   viper.GetDuration(operator.GCGracePeriodFlag),
+		GCDryRun:                        viper.GetBool(operator.GCDryRunFlag),
+		GeoIPDownloaderEndpoint:         viper.GetString(operator.GeoIPDownloaderEndpointFlag),
+		ImageDigestResolver:             imageDigestResolver,
+		NodeDrainHandling:               viper.GetBool(operator.NodeDrainHandlingFlag),
+		Notifier:                        notifications,
+		ObserverBatchWorkers:            viper.GetInt(operator.ElasticsearchObserverWorkersFlag),
+		ObserverProbes:                  observerProbes,
+		OpenShift:                       viper.GetBool(operator.OpenShiftFlag),
+		PodSecurityStandard:             viper.GetString(operator.PodSecurityStandardFlag),
+		PreemptionTaints:                viper.GetStringSlice(operator.PreemptionTaintsFlag),
+		PriorityClassMaxValue:           priorityClassMaxValue,
+		RightSizingRecommendations:      viper.GetBool(operator.RightSizingRecommendationsFlag),
+	}
+
+	// settings that can be updated at runtime, through the operator ConfigMap
+	defaultSettings := operator.Settings{
+		CACertRotation:      params.CACertRotation,
+		CertRotation:        params.CertRotation,
+		ObservationInterval: viper.GetDuration(operator.ElasticsearchObservationIntervalFlag),
+		LogVerbosity:        viper.GetInt(operatorconfig.LogVerbosityKey),
+	}
+	operatorConfigMap := types.NamespacedName{Namespace: operatorNamespace, Name: viper.GetString(operator.OperatorConfigMapFlag)}
+	params.Config = operator.NewConfig(defaultSettings)
+	if operatorConfigMap.Name != "" {
+		settings, err := operatorconfig.Load(mgr.GetAPIReader(), operatorConfigMap, defaultSettings)
+		if err != nil {
+			log.Error(err, "invalid operator configuration, using the flag values", "configmap_name", operatorConfigMap.Name)
+		}
+		params.Config.Set(settings)
+		logutil.SetVerbosity(settings.LogVerbosity)
+		params.Config.OnChange(func(settings operator.Settings) {
+			logutil.SetVerbosity(settings.LogVerbosity)
+		})
 	}
 
+	// the defaults profiles of the operator namespace override some of the settings for the resources of a namespace
+	overlays := profile.NewOverlays(k8s.WrapClient(mgr.GetClient()), operatorNamespace)
+	params.Overlays = overlays
+	container.SetNamespaceContainerRegistry(func(namespace string) string {
+		if overlay := overlays.Overlay(namespace); overlay != nil {
+			return overlay.ContainerRegistry
+		}
+		return ""
+	})
+
 	if viper.GetBool(operator.EnableWebhookFlag) {
-		setupWebhook(mgr, params.CertRotation, clientset)
+		setupWebhook(mgr, params, clientset)
 	}
 
 	enforceRbacOnRefs := viper.GetBool(operator.EnforceRBACOnRefsFlag)
@@ -334,50 +795,59 @@ func execute() {
 		accessReviewer = rbac.NewPermissiveAccessReviewer()
 	}
 
-	if err = apmserver.Add(mgr, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "ApmServer")
-		os.Exit(1)
-	}
-	if err = elasticsearch.Add(mgr, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "Elasticsearch")
-		os.Exit(1)
-	}
-	if err = kibana.Add(mgr, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "Kibana")
-		os.Exit(1)
-	}
-	if err = enterprisesearch.Add(mgr, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "EnterpriseSearch")
-		os.Exit(1)
-	}
-	if err = asesassn.Add(mgr, accessReviewer, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "ApmServerElasticsearchAssociation")
+	controllers, err := setupControllers(mgr, params, accessReviewer, viper.GetStringSlice(operator.ControllersFlag))
+	if err != nil {
+		log.Error(err, "unable to set up controllers")
 		os.Exit(1)
 	}
-	if err = kbassn.Add(mgr, accessReviewer, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "KibanaAssociation")
-		os.Exit(1)
+
+	if operatorConfigMap.Name != "" {
+		if err = operatorconfig.Add(mgr, params.Config, defaultSettings, operatorConfigMap, params); err != nil {
+			log.Error(err, "unable to create controller", "controller", "OperatorConfig")
+			os.Exit(1)
+		}
 	}
-	if err = entsassn.Add(mgr, accessReviewer, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "EnterpriseSearchAssociation")
-		os.Exit(1)
+	if dynamicCache != nil {
+		configMap := types.NamespacedName{Namespace: operatorNamespace, Name: namespacesConfigMap}
+		if err = namespacescontroller.Add(mgr, dynamicCache, configMap, params); err != nil {
+			log.Error(err, "unable to create controller", "controller", "Namespaces")
+			os.Exit(1)
+		}
 	}
-	if err = remoteca.Add(mgr, accessReviewer, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "RemoteClusterCertificateAuthorites")
-		os.Exit(1)
+
+	if federationCluster := viper.GetString(operator.FederationClusterNameFlag); federationCluster != "" && controllers.Has("Elasticsearch") {
+		if err := setupFederation(mgr, cfg, params, federationCluster); err != nil {
+			log.Error(err, "unable to create controller", "controller", "Federation")
+			os.Exit(1)
+		}
 	}
 
-	if err = license.Add(mgr, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "License")
-		os.Exit(1)
+	if monitoringES := viper.GetString(operator.MonitoringElasticsearchFlag); monitoringES != "" {
+		if err := setupSelfMonitoring(mgr, dialer, operatorNamespace, monitoringES); err != nil {
+			log.Error(err, "unable to set up self-monitoring")
+			os.Exit(1)
+		}
 	}
-	if err = licensetrial.Add(mgr, params); err != nil {
-		log.Error(err, "unable to create controller", "controller", "LicenseTrial")
-		os.Exit(1)
+
+	if healthProbePort != 0 {
+		if err := setupHealthProbes(mgr, healthProbePort, controllers); err != nil {
+			log.Error(err, "unable to set up health probes")
+			os.Exit(1)
+		}
 	}
 
 	// Garbage collect any orphaned user Secrets leftover from deleted resources while the operator was not running.
-	garbageCollectUsers(cfg, managedNamespaces)
+	garbageCollectUsers(cfg, managedNamespaces, controllers)
+	if controllers.Has("Elasticsearch") {
+		if params.GCGracePeriod < 0 {
+			log.Error(fmt.Errorf("%s must not be negative", operator.GCGracePeriodFlag), "invalid garbage collection settings")
+			os.Exit(1)
+		}
+		if err := garbageCollectSoftOwnedResources(mgr, cfg, managedNamespaces, params); err != nil {
+			log.Error(err, "unable to set up the soft-owned resources garbage collector")
+			os.Exit(1)
+		}
+	}
 
 	go func() {
 		time.Sleep(10 * time.Second)         // wait some arbitrary time for the manager to start
@@ -394,6 +864,8 @@ func execute() {
 		log.Error(err, "unable to run the manager")
 		os.Exit(1)
 	}
+	// the manager does not wait for running reconciliations when stopped, let them complete
+	params.Drainer.Drain(viper.GetDuration(operator.ShutdownDrainTimeoutFlag))
 }
 
 func ValidateCertExpirationFlags(validityFlag string, rotateBeforeFlag string) (time.Duration, time.Duration) {
@@ -406,23 +878,149 @@ func ValidateCertExpirationFlags(validityFlag string, rotateBeforeFlag string) (
 	return certValidity, certRotateBefore
 }
 
-func garbageCollectUsers(cfg *rest.Config, managedNamespaces []string) {
+// newAuditClient returns a function creating the default caching client of the manager, recording the mutations
+// in the given audit log.
+func newAuditClient(logger *audit.Logger) manager.NewClientFunc {
+	return func(cache cache.Cache, config *rest.Config, options client.Options) (client.Client, error) {
+		c, err := client.New(config, options)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewClient(&client.DelegatingClient{
+			Reader: &client.DelegatingReader{
+				CacheReader:  cache,
+				ClientReader: c,
+			},
+			Writer:       c,
+			StatusClient: c,
+		}, options.Scheme, logger), nil
+	}
+}
+
+// setupHealthProbes serves the liveness and readiness probes of the operator on the given port, and the health of the
+// resources managed by the enabled controllers on a separate endpoint of the same port.
+func setupHealthProbes(mgr manager.Manager, port int, controllers set.StringSet) error {
+	var kinds []string
+	for _, kind := range []string{health.ElasticsearchKind, health.KibanaKind, health.ApmServerKind, health.EnterpriseSearchKind} {
+		if controllers.Has(kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return mgr.Add(health.NewProbesServer(
+		fmt.Sprintf(":%d", port),
+		health.ManagedResourcesCheck(k8s.WrapClient(mgr.GetClient()), kinds),
+	))
+}
+
+// setupSelfMonitoring ships the operator logs and metrics to the given Elasticsearch cluster.
+func setupSelfMonitoring(mgr manager.Manager, dialer net.Dialer, operatorNamespace string, monitoringES string) error {
+	esKey := types.NamespacedName{Namespace: operatorNamespace, Name: monitoringES}
+	if parts := strings.Split(monitoringES, "/"); len(parts) == 2 {
+		esKey = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+	if esKey.Namespace == "" || esKey.Name == "" || strings.Count(monitoringES, "/") > 1 {
+		return fmt.Errorf("invalid %s %q, expected namespace/name or name", operator.MonitoringElasticsearchFlag, monitoringES)
+	}
+	shipper := selfmonitoring.NewShipper(selfmonitoring.Params{
+		Client:            k8s.WrapClient(mgr.GetClient()),
+		Dialer:            dialer,
+		Elasticsearch:     esKey,
+		OperatorNamespace: operatorNamespace,
+		Interval:          viper.GetDuration(operator.MonitoringIntervalFlag),
+		Gatherer:          metrics.Registry,
+	})
+	if err := mgr.Add(shipper); err != nil {
+		return err
+	}
+	logutil.AddOutput(shipper)
+	return nil
+}
+
+// setupFederation creates the controller exchanging the Elasticsearch clusters with the operators of other Kubernetes
+// clusters, through the Secrets of the federation namespace.
+func setupFederation(mgr manager.Manager, cfg *rest.Config, params operator.Parameters, federationCluster string) error {
+	backendCfg := cfg
+	if kubeconfig := viper.GetString(operator.FederationKubeconfigFlag); kubeconfig != "" {
+		var err error
+		if backendCfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig); err != nil {
+			return err
+		}
+	}
+	backendClient, err := client.New(backendCfg, client.Options{Scheme: clientgoscheme.Scheme})
+	if err != nil {
+		return err
+	}
+	namespace := viper.GetString(operator.FederationNamespaceFlag)
+	if namespace == "" {
+		namespace = params.OperatorNamespace
+	}
+	log.Info("Setting up federation", "federation_cluster", federationCluster, "federation_namespace", namespace)
+	return federation.Add(mgr, federationCluster, federation.Backend{
+		Client:    k8s.WrapClient(backendClient),
+		Namespace: namespace,
+	}, params)
+}
+
+func garbageCollectUsers(cfg *rest.Config, managedNamespaces []string, controllers set.StringSet) {
 	ugc, err := association.NewUsersGarbageCollector(cfg, managedNamespaces)
 	if err != nil {
 		log.Error(err, "user garbage collector creation failed")
 		os.Exit(1)
 	}
-	err = ugc.
-		For(&apmv1.ApmServerList{}, asesassn.AssociationLabelNamespace, asesassn.AssociationLabelName).
-		For(&kbv1.KibanaList{}, kbassn.AssociationLabelNamespace, kbassn.AssociationLabelName).
-		DoGarbageCollection()
+	// only consider the associations of the resources whose controllers run, their CRDs may not be installed
+	if controllers.Has("ApmServerElasticsearchAssociation") {
+		ugc.For(&apmv1.ApmServerList{}, asesassn.AssociationLabelNamespace, asesassn.AssociationLabelName)
+	}
+	if controllers.Has("KibanaAssociation") {
+		ugc.For(&kbv1.KibanaList{}, kbassn.AssociationLabelNamespace, kbassn.AssociationLabelName)
+	}
+	if controllers.Has("ElasticsearchAuditAssociation") {
+		ugc.For(&esv1.ElasticsearchList{}, esauditassn.AssociationLabelNamespace, esauditassn.AssociationLabelName)
+	}
+	err = ugc.DoGarbageCollection()
 	if err != nil {
 		log.Error(err, "user garbage collector failed")
 		os.Exit(1)
 	}
 }
 
-func setupWebhook(mgr manager.Manager, certRotation certificates.RotationParams, clientset kubernetes.Interface) {
+// softOwnedResourcesGCRetryInterval is the interval between two attempts to garbage collect the soft-owned resources.
+const softOwnedResourcesGCRetryInterval = 1 * time.Minute
+
+// garbageCollectSoftOwnedResources deletes the resources of the Elasticsearch clusters deleted while the operator was
+// not running, which the Kubernetes garbage collector cannot delete since they have no owner reference. The garbage
+// collection runs once the manager starts and is elected leader, and is retried until it succeeds: a failure does not
+// prevent the operator from starting.
+func garbageCollectSoftOwnedResources(
+	mgr manager.Manager,
+	cfg *rest.Config,
+	managedNamespaces []string,
+	params operator.Parameters,
+) error {
+	gcParams := cleanup.Params{GracePeriod: params.GCGracePeriod, DryRun: params.GCDryRun}
+	return mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		err := wait.PollImmediateUntil(softOwnedResourcesGCRetryInterval, func() (bool, error) {
+			// use a sync client here in order not to depend on any cache initialization
+			c, err := client.New(cfg, client.Options{})
+			if err != nil {
+				log.Error(err, "soft-owned resources garbage collector creation failed, retrying")
+				return false, nil
+			}
+			if err := cleanup.DeleteSoftOwnedOrphans(k8s.WrapClient(c), managedNamespaces, gcParams); err != nil {
+				log.Error(err, "soft-owned resources garbage collector failed, retrying")
+				return false, nil
+			}
+			return true, nil
+		}, stop)
+		if err == wait.ErrWaitTimeout {
+			// the manager is stopping
+			return nil
+		}
+		return err
+	}))
+}
+
+func setupWebhook(mgr manager.Manager, params operator.Parameters, clientset kubernetes.Interface) {
 	manageWebhookCerts := viper.GetBool(operator.ManageWebhookCertsFlag)
 	if manageWebhookCerts {
 		log.Info("Automatic management of the webhook certificates enabled")
@@ -431,7 +1029,9 @@ func setupWebhook(mgr manager.Manager, certRotation certificates.RotationParams,
 			Namespace:                viper.GetString(operator.OperatorNamespaceFlag),
 			SecretName:               viper.GetString(operator.WebhookSecretFlag),
 			WebhookConfigurationName: WebhookConfigurationName,
-			Rotation:                 certRotation,
+			ConversionCRDs:           ConversionCRDs,
+			Rotation:                 params.CertRotation,
+			Config:                   params.Config,
 		}
 
 		// Force a first reconciliation to create the resources before the server is started
@@ -446,7 +1046,8 @@ func setupWebhook(mgr manager.Manager, certRotation certificates.RotationParams,
 		}
 	}
 
-	// setup v1 and v1beta1 webhooks
+	// setup v1 and v1beta1 webhooks, including the conversion webhook of the resources with several versions, served
+	// on /convert for all of them
 	if err := (&esv1.Elasticsearch{}).SetupWebhookWithManager(mgr); err != nil {
 		log.Error(err, "unable to create webhook", "version", "v1", "webhook", "Elasticsearch")
 		os.Exit(1)
@@ -456,6 +1057,27 @@ func setupWebhook(mgr manager.Manager, certRotation certificates.RotationParams,
 		os.Exit(1)
 	}
 
+	// setup the webhook enforcing user-defined validation policies
+	mgr.GetWebhookServer().Register(policy.WebhookPath, &ctrlwebhook.Admission{
+		Handler: policy.NewHandler(k8s.WrapClient(mgr.GetClient()), viper.GetString(operator.OperatorNamespaceFlag)),
+	})
+	// setup the webhook estimating the footprint of the resources
+	mgr.GetWebhookServer().Register(footprint.WebhookPath, &ctrlwebhook.Admission{
+		Handler: footprint.NewHandler(
+			k8s.WrapClient(mgr.GetClient()),
+			mgr.GetEventRecorderFor("footprint-webhook"),
+			viper.GetString(operator.OperatorNamespaceFlag),
+		),
+	})
+	// setup the webhook enforcing the resource quotas of the tenants
+	mgr.GetWebhookServer().Register(quota.WebhookPath, &ctrlwebhook.Admission{
+		Handler: quota.NewHandler(k8s.WrapClient(mgr.GetClient()), mgr.GetAPIReader(), viper.GetString(operator.OperatorNamespaceFlag)),
+	})
+	// setup the webhook applying the defaults profiles
+	mgr.GetWebhookServer().Register(profile.ElasticsearchWebhookPath, &ctrlwebhook.Admission{
+		Handler: profile.NewHandler(k8s.WrapClient(mgr.GetClient()), viper.GetString(operator.OperatorNamespaceFlag)),
+	})
+
 	// wait for the secret to be populated in the local filesystem before returning
 	interval := time.Second * 1
 	timeout := time.Second * 30
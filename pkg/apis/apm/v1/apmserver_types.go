@@ -35,6 +35,11 @@ type ApmServerSpec struct {
 	// ElasticsearchRef is a reference to the output Elasticsearch cluster running in the same Kubernetes cluster.
 	ElasticsearchRef commonv1.ObjectSelector `json:"elasticsearchRef,omitempty"`
 
+	// Output configures an output other than Elasticsearch the APM Server sends its events to, for example to buffer
+	// them in a pipeline. It is mutually exclusive with ElasticsearchRef.
+	// +optional
+	Output *ApmServerOutput `json:"output,omitempty"`
+
 	// PodTemplate provides customisation options (labels, annotations, affinity rules, resource requests, and so on) for the APM Server pods.
 	// +kubebuilder:validation:Optional
 	PodTemplate corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
@@ -49,6 +54,69 @@ type ApmServerSpec struct {
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
 
+// ApmServerOutput is an output of the APM Server other than Elasticsearch. Logstash and Kafka are mutually exclusive.
+type ApmServerOutput struct {
+	// Logstash sends the events to Logstash.
+	// +optional
+	Logstash *LogstashOutput `json:"logstash,omitempty"`
+
+	// Kafka sends the events to Kafka.
+	// +optional
+	Kafka *KafkaOutput `json:"kafka,omitempty"`
+}
+
+// LogstashOutput configures the output of the events to Logstash.
+type LogstashOutput struct {
+	// Hosts lists the Logstash hosts, as host:port.
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+
+	// TLS configures the TLS connection to Logstash.
+	// +optional
+	TLS *OutputTLS `json:"tls,omitempty"`
+}
+
+// KafkaOutput configures the output of the events to Kafka.
+type KafkaOutput struct {
+	// Hosts lists the Kafka brokers, as host:port.
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+
+	// Topic is the Kafka topic the events are published to. It can reference fields of the events, for example
+	// apm-%{[processor.event]}.
+	Topic string `json:"topic"`
+
+	// CredentialsSecretName is the name of a Secret holding the username and password keys the APM Server
+	// authenticates with to the Kafka brokers, using SASL/PLAIN.
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+
+	// TLS configures the TLS connection to the Kafka brokers.
+	// +optional
+	TLS *OutputTLS `json:"tls,omitempty"`
+}
+
+// OutputTLS configures the TLS connection to an output.
+type OutputTLS struct {
+	// SecretName is the name of a Secret holding the ca.crt certificate authorities to trust and, for client
+	// authentication, the tls.crt certificate and tls.key private key of the APM Server.
+	SecretName string `json:"secretName"`
+}
+
+// TLS returns the TLS configuration of the output, if any.
+func (o *ApmServerOutput) TLS() *OutputTLS {
+	switch {
+	case o == nil:
+		return nil
+	case o.Logstash != nil:
+		return o.Logstash.TLS
+	case o.Kafka != nil:
+		return o.Kafka.TLS
+	default:
+		return nil
+	}
+}
+
 // ApmServerHealth expresses the status of the Apm Server instances.
 type ApmServerHealth string
 
@@ -23,7 +23,7 @@ func (in *ApmServer) DeepCopyInto(out *ApmServer) {
 	if in.assocConf != nil {
 		in, out := &in.assocConf, &out.assocConf
 		*out = new(commonv1.AssociationConf)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -77,6 +77,31 @@ func (in *ApmServerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApmServerOutput) DeepCopyInto(out *ApmServerOutput) {
+	*out = *in
+	if in.Logstash != nil {
+		in, out := &in.Logstash, &out.Logstash
+		*out = new(LogstashOutput)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaOutput)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApmServerOutput.
+func (in *ApmServerOutput) DeepCopy() *ApmServerOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(ApmServerOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApmServerSpec) DeepCopyInto(out *ApmServerSpec) {
 	*out = *in
@@ -86,6 +111,11 @@ func (in *ApmServerSpec) DeepCopyInto(out *ApmServerSpec) {
 	}
 	in.HTTP.DeepCopyInto(&out.HTTP)
 	out.ElasticsearchRef = in.ElasticsearchRef
+	if in.Output != nil {
+		in, out := &in.Output, &out.Output
+		*out = new(ApmServerOutput)
+		(*in).DeepCopyInto(*out)
+	}
 	in.PodTemplate.DeepCopyInto(&out.PodTemplate)
 	if in.SecureSettings != nil {
 		in, out := &in.SecureSettings, &out.SecureSettings
@@ -121,3 +151,68 @@ func (in *ApmServerStatus) DeepCopy() *ApmServerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaOutput) DeepCopyInto(out *KafkaOutput) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(OutputTLS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaOutput.
+func (in *KafkaOutput) DeepCopy() *KafkaOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogstashOutput) DeepCopyInto(out *LogstashOutput) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(OutputTLS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogstashOutput.
+func (in *LogstashOutput) DeepCopy() *LogstashOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(LogstashOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputTLS) DeepCopyInto(out *OutputTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputTLS.
+func (in *OutputTLS) DeepCopy() *OutputTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputTLS)
+	in.DeepCopyInto(out)
+	return out
+}
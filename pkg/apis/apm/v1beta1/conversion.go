@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// conversionData is the data of an APM Server resource in the storage version, held by the conversion data
+// annotation.
+type conversionData struct {
+	Spec   apmv1.ApmServerSpec   `json:"spec"`
+	Status apmv1.ApmServerStatus `json:"status"`
+}
+
+// ConvertTo converts this APM Server resource to the hub version, restoring the fields it cannot represent from its
+// conversion data.
+func (as *ApmServer) ConvertTo(hub conversion.Hub) error {
+	dst := hub.(*apmv1.ApmServer)
+	dst.ObjectMeta = *as.ObjectMeta.DeepCopy()
+	if err := commonv1beta1.ConvertJSON(as.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(as.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	var restored conversionData
+	if ok, err := commonv1beta1.UnmarshalConversionData(dst, &restored); err != nil || !ok {
+		return err
+	}
+	// fields introduced in v1
+	dst.Spec.Output = restored.Spec.Output
+	dst.Spec.ServiceAccountName = restored.Spec.ServiceAccountName
+	commonv1beta1.RestoreSecretSources(dst.Spec.SecureSettings, restored.Spec.SecureSettings)
+	return nil
+}
+
+// ConvertFrom converts the given APM Server resource of the hub version to this version, keeping the fields this
+// version cannot represent in its conversion data.
+func (as *ApmServer) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*apmv1.ApmServer)
+	as.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := commonv1beta1.ConvertJSON(src.Spec, &as.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(src.Status, &as.Status); err != nil {
+		return err
+	}
+
+	var convertedBack conversionData
+	if err := commonv1beta1.ConvertJSON(as.Spec, &convertedBack.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(as.Status, &convertedBack.Status); err != nil {
+		return err
+	}
+	return commonv1beta1.MarshalConversionData(conversionData{Spec: src.Spec, Status: src.Status}, convertedBack, as)
+}
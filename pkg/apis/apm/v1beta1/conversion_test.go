@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/test/fill"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func requireJSONEqual(t *testing.T, expected, actual interface{}) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
+
+func TestApmServer_HubRoundTrip(t *testing.T) {
+	var hub apmv1.ApmServer
+	fill.Fill(&hub)
+	hub.TypeMeta = metav1.TypeMeta{}
+	hub.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "apm"}
+
+	var as ApmServer
+	require.NoError(t, as.ConvertFrom(&hub))
+	require.Contains(t, as.Annotations, commonv1beta1.ConversionDataAnnotation)
+
+	var converted apmv1.ApmServer
+	require.NoError(t, as.ConvertTo(&converted))
+	requireJSONEqual(t, hub, converted)
+}
+
+func TestApmServer_SpokeRoundTrip(t *testing.T) {
+	var as ApmServer
+	fill.Fill(&as)
+	as.TypeMeta = metav1.TypeMeta{}
+	as.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "apm"}
+
+	var hub apmv1.ApmServer
+	require.NoError(t, as.ConvertTo(&hub))
+
+	var converted ApmServer
+	require.NoError(t, converted.ConvertFrom(&hub))
+	require.NotContains(t, converted.Annotations, commonv1beta1.ConversionDataAnnotation)
+	requireJSONEqual(t, as, converted)
+}
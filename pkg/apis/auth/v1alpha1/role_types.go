@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+)
+
+const (
+	// RoleKind is inferred from the struct name using reflection in SchemeBuilder.Register()
+	// we duplicate it as a constant here for practical purposes.
+	RoleKind = "ElasticsearchRole"
+)
+
+// ElasticsearchRoleSpec holds the specification of an ElasticsearchRole resource.
+type ElasticsearchRoleSpec struct {
+	// ElasticsearchRef references the Elasticsearch cluster, in the namespace of the role, to create the role in.
+	ElasticsearchRef corev1.LocalObjectReference `json:"elasticsearchRef"`
+	// RoleName is the name of the role in Elasticsearch. Defaults to the name of the resource.
+	// +kubebuilder:validation:Optional
+	RoleName string `json:"roleName,omitempty"`
+	// Definition holds the definition of the role (cluster, indices, applications, run_as, metadata),
+	// as described in the Elasticsearch roles file.
+	Definition commonv1.Config `json:"definition"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchRole represents a file-based role of an Elasticsearch cluster.
+// +kubebuilder:resource:categories=elastic,shortName=esrole
+// +kubebuilder:printcolumn:name="elasticsearch",type="string",JSONPath=".spec.elasticsearchRef.name"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:storageversion
+type ElasticsearchRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchRoleSpec `json:"spec,omitempty"`
+}
+
+// RoleName returns the name of the role in Elasticsearch.
+func (r ElasticsearchRole) RoleName() string {
+	if r.Spec.RoleName != "" {
+		return r.Spec.RoleName
+	}
+	return r.Name
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchRoleList contains a list of ElasticsearchRole resources.
+type ElasticsearchRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticsearchRole `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticsearchRole{}, &ElasticsearchRoleList{})
+}
@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// UserKind is inferred from the struct name using reflection in SchemeBuilder.Register()
+	// we duplicate it as a constant here for practical purposes.
+	UserKind = "ElasticsearchUser"
+)
+
+// ElasticsearchUserSpec holds the specification of an ElasticsearchUser resource.
+type ElasticsearchUserSpec struct {
+	// ElasticsearchRef references the Elasticsearch cluster, in the namespace of the user, to create the user in.
+	ElasticsearchRef corev1.LocalObjectReference `json:"elasticsearchRef"`
+	// Username is the name of the user in Elasticsearch. Defaults to the name of the resource.
+	// +kubebuilder:validation:Optional
+	Username string `json:"username,omitempty"`
+	// PasswordSecretKeyRef references the key of a Secret, in the namespace of the user, holding the password of the user.
+	PasswordSecretKeyRef corev1.SecretKeySelector `json:"passwordSecretKeyRef"`
+	// Roles are the names of the roles assigned to the user.
+	// +kubebuilder:validation:Optional
+	Roles []string `json:"roles,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchUser represents a user of the file realm of an Elasticsearch cluster.
+// +kubebuilder:resource:categories=elastic,shortName=esuser
+// +kubebuilder:printcolumn:name="elasticsearch",type="string",JSONPath=".spec.elasticsearchRef.name"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:storageversion
+type ElasticsearchUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchUserSpec `json:"spec,omitempty"`
+}
+
+// UserName returns the name of the user in Elasticsearch.
+func (u ElasticsearchUser) UserName() string {
+	if u.Spec.Username != "" {
+		return u.Spec.Username
+	}
+	return u.Name
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchUserList contains a list of ElasticsearchUser resources.
+type ElasticsearchUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticsearchUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticsearchUser{}, &ElasticsearchUserList{})
+}
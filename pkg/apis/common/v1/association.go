@@ -47,6 +47,10 @@ type AssociationConf struct {
 	CACertProvided bool   `json:"caCertProvided"`
 	CASecretName   string `json:"caSecretName"`
 	URL            string `json:"url"`
+	// Hosts lists the URLs of the individual Elasticsearch nodes to connect to instead of URL, if set.
+	Hosts []string `json:"hosts,omitempty"`
+	// IsServiceAccount is true if the auth secret holds a service account token, rather than a user password.
+	IsServiceAccount bool `json:"isServiceAccount,omitempty"`
 }
 
 // IsConfigured returns true if all the fields are set.
@@ -112,3 +116,25 @@ func (ac *AssociationConf) GetURL() string {
 	}
 	return ac.URL
 }
+
+// GetHosts returns the URLs of the Elasticsearch nodes to connect to, or the URL of the Elasticsearch service if no
+// node is listed.
+func (ac *AssociationConf) GetHosts() []string {
+	if ac == nil {
+		return nil
+	}
+	if len(ac.Hosts) > 0 {
+		return ac.Hosts
+	}
+	if ac.URL == "" {
+		return nil
+	}
+	return []string{ac.URL}
+}
+
+func (ac *AssociationConf) GetIsServiceAccount() bool {
+	if ac == nil {
+		return false
+	}
+	return ac.IsServiceAccount
+}
@@ -146,6 +146,15 @@ func (p *PodDisruptionBudgetTemplate) IsDisabled() bool {
 type SecretSource struct {
 	// SecretName is the name of the secret.
 	SecretName string `json:"secretName"`
+	// Namespace is the namespace of the secret, defaults to the namespace of the resource. A secret of another
+	// namespace must list the namespace of the resource, or *, in its common.k8s.elastic.co/shared-with-namespaces
+	// annotation, so that it can be shared with several resources across namespaces.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	// Prefix is prepended to the keys projected from the secret, for example s3.client.default. to use a secret
+	// holding generic access_key and secret_key entries as the credentials of a given S3 client.
+	// +kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
 	// Entries define how to project each key-value pair in the secret to filesystem paths.
 	// If not defined, all keys will be projected to similarly named paths in the filesystem.
 	// If defined, only the specified keys will be projected to the corresponding paths.
@@ -0,0 +1,76 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a condition of a resource.
+type ConditionType string
+
+// Condition reports an aspect of the state of a resource.
+type Condition struct {
+	// Type of the condition.
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is the last time the condition changed from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a brief machine-readable explanation of the condition.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable explanation of the condition.
+	Message string `json:"message,omitempty"`
+}
+
+// Conditions are the conditions of a resource, with at most one condition of each type.
+type Conditions []Condition
+
+// Get returns the condition of the given type, or nil if there is none.
+func (c Conditions) Get(t ConditionType) *Condition {
+	for i := range c {
+		if c[i].Type == t {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true if the condition of the given type exists and its status is True.
+func (c Conditions) IsTrue(t ConditionType) bool {
+	condition := c.Get(t)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// Remove returns the conditions without the condition of the given type.
+func (c Conditions) Remove(t ConditionType) Conditions {
+	var remaining Conditions
+	for _, existing := range c {
+		if existing.Type != t {
+			remaining = append(remaining, existing)
+		}
+	}
+	return remaining
+}
+
+// MergeWith returns the conditions updated with the given condition, which replaces the existing condition of the
+// same type. The last transition time of the existing condition is kept if its status does not change.
+func (c Conditions) MergeWith(condition Condition) Conditions {
+	merged := make(Conditions, 0, len(c)+1)
+	for _, existing := range c {
+		if existing.Type != condition.Type {
+			merged = append(merged, existing)
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	return append(merged, condition)
+}
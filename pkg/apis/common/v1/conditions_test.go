@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConditions_MergeWith(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	other := Condition{Type: "Other", Status: corev1.ConditionTrue, LastTransitionTime: past}
+	existing := Conditions{
+		other,
+		{Type: "Adopted", Status: corev1.ConditionFalse, LastTransitionTime: past, Reason: "InProgress"},
+	}
+
+	// same status: the transition time is kept, the reason and message are updated
+	merged := existing.MergeWith(Condition{Type: "Adopted", Status: corev1.ConditionFalse, Reason: "Waiting", Message: "m"})
+	require.Len(t, merged, 2)
+	require.Equal(t, other, merged[0])
+	require.Equal(t, Condition{Type: "Adopted", Status: corev1.ConditionFalse, LastTransitionTime: past, Reason: "Waiting", Message: "m"}, *merged.Get("Adopted"))
+	require.False(t, merged.IsTrue("Adopted"))
+	// the original conditions are not modified
+	require.Equal(t, "InProgress", existing.Get("Adopted").Reason)
+
+	// new status: the transition time is updated
+	merged = merged.MergeWith(Condition{Type: "Adopted", Status: corev1.ConditionTrue})
+	require.Len(t, merged, 2)
+	require.True(t, merged.IsTrue("Adopted"))
+	require.True(t, merged.Get("Adopted").LastTransitionTime.After(past.Time))
+
+	// new condition
+	merged = Conditions(nil).MergeWith(Condition{Type: "Adopted", Status: corev1.ConditionTrue})
+	require.Len(t, merged, 1)
+	require.False(t, merged.Get("Adopted").LastTransitionTime.IsZero())
+	require.Nil(t, merged.Get("Other"))
+}
+
+func TestConditions_Remove(t *testing.T) {
+	conditions := Conditions{
+		{Type: "Adopted", Status: corev1.ConditionTrue},
+		{Type: "Other", Status: corev1.ConditionFalse},
+	}
+	require.Equal(t, Conditions{{Type: "Other", Status: corev1.ConditionFalse}}, conditions.Remove("Adopted"))
+	require.Equal(t, conditions, conditions.Remove("Missing"))
+	require.Nil(t, Conditions(nil).Remove("Adopted"))
+}
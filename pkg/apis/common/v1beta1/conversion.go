@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	"bytes"
+	"encoding/json"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConversionDataAnnotation holds, on a resource converted from the storage version, the JSON of the specification and
+// status of the resource in the storage version. The fields that cannot be represented in this version are restored
+// from it when the resource is converted back, so that they are not lost when it is updated in this version.
+const ConversionDataAnnotation = "common.k8s.elastic.co/conversion-data"
+
+// ConvertJSON converts between two versions of a structure sharing the same JSON field names: the fields of src
+// unknown to dst are dropped, and the fields of dst unknown to src are left empty.
+func ConvertJSON(src interface{}, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// MarshalConversionData stores the given data of a resource in the storage version in the conversion data annotation
+// of the resource converted to this version, unless it is the same as the converted data once converted back: the
+// annotation is only set when the conversion loses some fields.
+func MarshalConversionData(data interface{}, convertedBack interface{}, dst metav1.Object) error {
+	expected, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	actual, err := json.Marshal(convertedBack)
+	if err != nil {
+		return err
+	}
+	annotations := dst.GetAnnotations()
+	if bytes.Equal(expected, actual) {
+		if _, exists := annotations[ConversionDataAnnotation]; exists {
+			delete(annotations, ConversionDataAnnotation)
+			dst.SetAnnotations(annotations)
+		}
+		return nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ConversionDataAnnotation] = string(expected)
+	dst.SetAnnotations(annotations)
+	return nil
+}
+
+// UnmarshalConversionData removes the conversion data annotation from the given resource converted to the storage
+// version, and unmarshals it into data. It returns false if the resource has no conversion data.
+func UnmarshalConversionData(dst metav1.Object, data interface{}) (bool, error) {
+	annotations := dst.GetAnnotations()
+	value, exists := annotations[ConversionDataAnnotation]
+	if !exists {
+		return false, nil
+	}
+	delete(annotations, ConversionDataAnnotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	dst.SetAnnotations(annotations)
+	return true, json.Unmarshal([]byte(value), data)
+}
+
+// RestoreSecretSources restores the fields of the secure settings that cannot be represented in this version from the
+// secure settings of the storage version referencing the same secrets, at the same position if possible since secrets
+// of different namespaces may share the same name.
+func RestoreSecretSources(dst []commonv1.SecretSource, restored []commonv1.SecretSource) {
+	for i := range dst {
+		if i < len(restored) && restored[i].SecretName == dst[i].SecretName {
+			dst[i].Namespace = restored[i].Namespace
+			dst[i].Prefix = restored[i].Prefix
+			continue
+		}
+		for _, source := range restored {
+			if source.SecretName == dst[i].SecretName {
+				dst[i].Namespace = source.Namespace
+				dst[i].Prefix = source.Prefix
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	"testing"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConversionData(t *testing.T) {
+	type data struct {
+		Name  string `json:"name"`
+		Extra string `json:"extra,omitempty"`
+	}
+	obj := metav1.ObjectMeta{Annotations: map[string]string{"a": "b"}}
+
+	// no annotation if nothing is lost
+	require.NoError(t, MarshalConversionData(data{Name: "a"}, data{Name: "a"}, &obj))
+	require.Equal(t, map[string]string{"a": "b"}, obj.Annotations)
+
+	require.NoError(t, MarshalConversionData(data{Name: "a", Extra: "b"}, data{Name: "a"}, &obj))
+	require.Equal(t, `{"name":"a","extra":"b"}`, obj.Annotations[ConversionDataAnnotation])
+
+	var restored data
+	ok, err := UnmarshalConversionData(&obj, &restored)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, data{Name: "a", Extra: "b"}, restored)
+	require.Equal(t, map[string]string{"a": "b"}, obj.Annotations)
+
+	ok, err = UnmarshalConversionData(&obj, &restored)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestRestoreSecretSources(t *testing.T) {
+	restored := []commonv1.SecretSource{
+		{SecretName: "shared", Namespace: "ns1", Prefix: "a."},
+		{SecretName: "shared", Namespace: "ns2", Prefix: "b."},
+		{SecretName: "other", Prefix: "c."},
+	}
+	dst := []commonv1.SecretSource{{SecretName: "other"}, {SecretName: "shared"}, {SecretName: "new"}}
+	RestoreSecretSources(dst, restored)
+	require.Equal(t, []commonv1.SecretSource{
+		{SecretName: "other", Prefix: "c."},
+		// matched at the same position
+		{SecretName: "shared", Namespace: "ns2", Prefix: "b."},
+		{SecretName: "new"},
+	}, dst)
+}
@@ -0,0 +1,61 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClassAppliedAnnotation is the annotation recording the name of the ElasticsearchClass applied to an Elasticsearch
+// resource. The class is applied once: updating it does not modify the existing resources.
+const ClassAppliedAnnotation = "elasticsearch.k8s.elastic.co/applied-class"
+
+// ElasticsearchClassSpec defines a reusable blueprint of Elasticsearch clusters.
+type ElasticsearchClassSpec struct {
+	// Description of the blueprint, for the users picking a class.
+	// +kubebuilder:validation:Optional
+	Description string `json:"description,omitempty"`
+
+	// Template is the specification of the Elasticsearch clusters created from this class. Its fields are copied to
+	// the fields left empty in the Elasticsearch resources referencing the class, NodeSets being merged by name.
+	// It is validated as part of the Elasticsearch resources it applies to.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template ElasticsearchSpec `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchClass is a cluster-wide blueprint of Elasticsearch clusters, which Elasticsearch resources reference in
+// spec.className and selectively override.
+// +kubebuilder:resource:scope=Cluster,categories=elastic,shortName=esclass
+// +kubebuilder:printcolumn:name="version",type="string",JSONPath=".spec.template.version"
+// +kubebuilder:printcolumn:name="description",type="string",JSONPath=".spec.description"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type ElasticsearchClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchClassSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchClassList contains a list of ElasticsearchClass.
+type ElasticsearchClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticsearchClass `json:"items"`
+}
+
+// ClassPending returns true if the Elasticsearch resource references an ElasticsearchClass and no class was applied to
+// it yet: its specification is incomplete until then.
+func (es Elasticsearch) ClassPending() bool {
+	return es.Spec.ClassName != "" && es.Annotations[ClassAppliedAnnotation] == ""
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticsearchClass{}, &ElasticsearchClassList{})
+}
@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+)
+
+// ElasticsearchCloneSpec defines the Elasticsearch cluster to clone and how its data is copied.
+type ElasticsearchCloneSpec struct {
+	// SourceRef is a reference to the Elasticsearch cluster to clone.
+	SourceRef commonv1.ObjectSelector `json:"sourceRef"`
+
+	// ElasticsearchName is the name of the Elasticsearch resource created in the namespace of the clone.
+	// Defaults to the name of the clone.
+	// +kubebuilder:validation:Optional
+	ElasticsearchName string `json:"elasticsearchName,omitempty"`
+
+	// Repository is the name of the snapshot repository of the source cluster the data is copied through. It is
+	// registered read-only in the new cluster, and must be reachable from it.
+	Repository string `json:"repository"`
+
+	// Snapshot is the name of an existing snapshot of the repository to restore. A new snapshot of the source cluster
+	// is taken if not set.
+	// +kubebuilder:validation:Optional
+	Snapshot string `json:"snapshot,omitempty"`
+
+	// Indices are the names or patterns of the indices to restore. Defaults to all the indices except the hidden and
+	// system ones.
+	// +kubebuilder:validation:Optional
+	Indices []string `json:"indices,omitempty"`
+
+	// ServiceAccountName is used to check access to the source cluster if it is in a different namespace.
+	// Can only be used if ECK is enforcing RBAC on references.
+	// +kubebuilder:validation:Optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// ElasticsearchClonePhase is the step of the clone operation in progress.
+type ElasticsearchClonePhase string
+
+const (
+	// CloneSnapshottingPhase is the phase during which the snapshot of the source cluster is taken.
+	CloneSnapshottingPhase ElasticsearchClonePhase = "Snapshotting"
+	// CloneCreatingPhase is the phase during which the new cluster is created, until it is ready.
+	CloneCreatingPhase ElasticsearchClonePhase = "Creating"
+	// CloneRestoringPhase is the phase during which the snapshot is restored in the new cluster.
+	CloneRestoringPhase ElasticsearchClonePhase = "Restoring"
+	// CloneCompletedPhase is the phase of a clone whose data is restored.
+	CloneCompletedPhase ElasticsearchClonePhase = "Completed"
+	// CloneFailedPhase is the phase of a clone that cannot complete, as described by its status message.
+	CloneFailedPhase ElasticsearchClonePhase = "Failed"
+)
+
+// ElasticsearchCloneStatus reports the progress of the clone operation.
+type ElasticsearchCloneStatus struct {
+	// Phase is the step of the clone operation in progress.
+	Phase ElasticsearchClonePhase `json:"phase,omitempty"`
+	// Snapshot is the name of the snapshot restored in the new cluster.
+	Snapshot string `json:"snapshot,omitempty"`
+	// TotalShards is the number of primary shards to restore.
+	TotalShards int `json:"totalShards,omitempty"`
+	// RestoredShards is the number of primary shards restored so far.
+	RestoredShards int `json:"restoredShards,omitempty"`
+	// Message describes the current state of the operation, or why it failed.
+	Message string `json:"message,omitempty"`
+	// CompletedAt is the time the data was restored.
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// IsDone returns true if the clone operation completed or failed.
+func (s ElasticsearchCloneStatus) IsDone() bool {
+	return s.Phase == CloneCompletedPhase || s.Phase == CloneFailedPhase
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchClone creates a new Elasticsearch cluster with the specification of an existing one, and restores its
+// data from a snapshot.
+// +kubebuilder:resource:categories=elastic,shortName=esclone
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="source",type="string",JSONPath=".spec.sourceRef.name"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="restored",type="integer",JSONPath=".status.restoredShards",description="Restored primary shards"
+// +kubebuilder:printcolumn:name="total",type="integer",JSONPath=".status.totalShards",description="Primary shards to restore"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type ElasticsearchClone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElasticsearchCloneSpec   `json:"spec,omitempty"`
+	Status ElasticsearchCloneStatus `json:"status,omitempty"`
+}
+
+// ElasticsearchName returns the name of the Elasticsearch resource created by the clone.
+func (c ElasticsearchClone) ElasticsearchName() string {
+	if c.Spec.ElasticsearchName != "" {
+		return c.Spec.ElasticsearchName
+	}
+	return c.Name
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchCloneList contains a list of ElasticsearchClone.
+type ElasticsearchCloneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticsearchClone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticsearchClone{}, &ElasticsearchCloneList{})
+}
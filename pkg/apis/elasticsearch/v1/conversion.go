@@ -0,0 +1,9 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+// Hub marks this version as the conversion hub: the other versions of the Elasticsearch resources are converted to and
+// from it by the conversion webhook.
+func (*Elasticsearch) Hub() {}
@@ -0,0 +1,107 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+// DataTier is a data tier of a hot-warm-cold architecture.
+type DataTier string
+
+const (
+	HotTier    DataTier = "hot"
+	WarmTier   DataTier = "warm"
+	ColdTier   DataTier = "cold"
+	FrozenTier DataTier = "frozen"
+)
+
+// DataTierAttributeName is the node attribute holding the data tier of the nodes, to filter the allocation of the
+// indices on them.
+const DataTierAttributeName = "data"
+
+// DataTiers are the supported data tiers, from the most to the least frequently accessed.
+var DataTiers = []DataTier{HotTier, WarmTier, ColdTier, FrozenTier}
+
+var (
+	// DataTierRolesMinVersion is the first version of Elasticsearch with a node role per data tier.
+	DataTierRolesMinVersion = version.MustParse("7.10.0")
+	// FrozenTierMinVersion is the first version of Elasticsearch with the frozen tier.
+	FrozenTierMinVersion = version.MustParse("7.12.0")
+)
+
+// frozenTierSharedCacheSize is the default size of the cache of the searchable snapshots mounted on the frozen tier,
+// relative to the disk of the nodes.
+const frozenTierSharedCacheSize = "90%"
+
+// roleSettings are the settings configuring the roles of the nodes, which must not be mixed with node.roles.
+var roleSettings = []string{NodeRoles, NodeMaster, NodeData, NodeIngest, NodeML}
+
+// IsValid returns true if the tier is one of the supported data tiers.
+func (t DataTier) IsValid() bool {
+	for _, tier := range DataTiers {
+		if t == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// Roles returns the node roles of the nodes of the tier. The hot tier also holds the content indices, and runs the
+// ingest pipelines of the documents indexed on it.
+func (t DataTier) Roles() []string {
+	if t == HotTier {
+		return []string{"data_content", "data_hot", "ingest"}
+	}
+	return []string{"data_" + string(t)}
+}
+
+// TierSettings returns the settings derived from the data tier of the NodeSet which are not set in its configuration:
+// the allocation attribute of the tier, the roles of the tier with the given version of Elasticsearch if no role is
+// configured, and the size of the searchable snapshots cache of the frozen tier.
+func (n NodeSet) TierSettings(ver version.Version) (map[string]interface{}, error) {
+	if n.Tier == "" {
+		return nil, nil
+	}
+	var data map[string]interface{}
+	if n.Config != nil {
+		data = n.Config.Data
+	}
+	userCfg, err := common.NewCanonicalConfigFrom(data)
+	if err != nil {
+		return nil, err
+	}
+	settings := map[string]interface{}{}
+	if len(userCfg.HasKeys([]string{NodeAttrData})) == 0 {
+		settings[NodeAttrData] = string(n.Tier)
+	}
+	if ver.IsSameOrAfter(DataTierRolesMinVersion) && len(userCfg.HasKeys(roleSettings)) == 0 {
+		settings[NodeRoles] = n.Tier.Roles()
+	}
+	if n.Tier == FrozenTier && len(userCfg.HasKeys([]string{XPackSearchableSnapshotSharedCacheSize})) == 0 {
+		settings[XPackSearchableSnapshotSharedCacheSize] = frozenTierSharedCacheSize
+	}
+	return settings, nil
+}
+
+// TierConfig returns the configuration of the NodeSet completed with the settings derived from its data tier.
+func (n NodeSet) TierConfig(ver version.Version) (*commonv1.Config, error) {
+	settings, err := n.TierSettings(ver)
+	if err != nil || len(settings) == 0 {
+		return n.Config, err
+	}
+	data := map[string]interface{}{}
+	if n.Config != nil {
+		for k, v := range n.Config.Data {
+			data[k] = v
+		}
+	}
+	for k, v := range settings {
+		data[k] = v
+	}
+	return &commonv1.Config{Data: data}, nil
+}
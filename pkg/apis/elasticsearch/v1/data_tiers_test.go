@@ -0,0 +1,70 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+func TestNodeSet_TierSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeSet NodeSet
+		version string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "no tier",
+			nodeSet: NodeSet{Name: "default"},
+			version: "7.10.0",
+			want:    nil,
+		},
+		{
+			name:    "hot tier",
+			nodeSet: NodeSet{Name: "hot", Tier: HotTier},
+			version: "7.10.0",
+			want: map[string]interface{}{
+				NodeAttrData: "hot",
+				NodeRoles:    []string{"data_content", "data_hot", "ingest"},
+			},
+		},
+		{
+			name:    "warm tier before the tier roles",
+			nodeSet: NodeSet{Name: "warm", Tier: WarmTier},
+			version: "7.9.3",
+			want:    map[string]interface{}{NodeAttrData: "warm"},
+		},
+		{
+			name:    "frozen tier",
+			nodeSet: NodeSet{Name: "frozen", Tier: FrozenTier},
+			version: "7.12.0",
+			want: map[string]interface{}{
+				NodeAttrData:                           "frozen",
+				NodeRoles:                              []string{"data_frozen"},
+				XPackSearchableSnapshotSharedCacheSize: "90%",
+			},
+		},
+		{
+			name: "user settings take precedence",
+			nodeSet: NodeSet{Name: "cold", Tier: ColdTier, Config: &commonv1.Config{Data: map[string]interface{}{
+				"node": map[string]interface{}{"master": false, "attr": map[string]interface{}{"data": "archive"}},
+			}}},
+			version: "7.10.0",
+			want:    map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.nodeSet.TierSettings(version.MustParse(tt.version))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
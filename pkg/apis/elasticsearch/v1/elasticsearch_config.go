@@ -5,6 +5,10 @@
 package v1
 
 import (
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/elastic/go-ucfg"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
@@ -30,10 +34,39 @@ type Node struct {
 	ML     bool `config:"ml"`
 }
 
+// withRoles returns the node with its boolean roles derived from the given node.roles setting. Any data role, including
+// the roles of the data tiers, makes a data node.
+func (n Node) withRoles(roles []string) Node {
+	if roles == nil {
+		return n
+	}
+	var node Node
+	for _, role := range roles {
+		switch {
+		case role == "master":
+			node.Master = true
+		case role == "data" || strings.HasPrefix(role, "data_"):
+			node.Data = true
+		case role == "ingest":
+			node.Ingest = true
+		case role == "ml":
+			node.ML = true
+		}
+	}
+	return node
+}
+
 // ElasticsearchSettings is a typed subset of elasticsearch.yml for purposes of the operator.
 type ElasticsearchSettings struct {
 	Node    Node            `config:"node"`
 	Cluster ClusterSettings `config:"cluster"`
+	// NodeRoles holds the node.roles setting, which replaces the boolean roles of Node when set.
+	NodeRoles []string `config:"node.roles"`
+}
+
+// ApplyRoles derives the boolean roles of the node from the node.roles setting, if set.
+func (s *ElasticsearchSettings) ApplyRoles() {
+	s.Node = s.Node.withRoles(s.NodeRoles)
 }
 
 // DefaultCfg is an instance of ElasticsearchSettings with defaults set as they are in Elasticsearch.
@@ -58,5 +91,103 @@ func UnpackConfig(c *commonv1.Config) (ElasticsearchSettings, error) {
 		return esSettings, err
 	}
 	err = config.Unpack(&esSettings, commonv1.CfgOptions...)
+	esSettings.ApplyRoles()
 	return esSettings, err
 }
+
+const configSecretRefPrefix = "${secret:"
+
+// configSecretRefPattern matches the references to Secret keys in the configuration, as
+// ${secret:<namespace>/<name>/<key>}.
+var configSecretRefPattern = regexp.MustCompile(`\$\{secret:([^/}]+)/([^/}]+)/([^/}]+)\}`)
+
+// ConfigSecretRef is a reference to the key of a Secret in the configuration of a NodeSet, resolved by the operator
+// when rendering elasticsearch.yml.
+type ConfigSecretRef struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// String returns the reference as written in the configuration.
+func (r ConfigSecretRef) String() string {
+	return configSecretRefPrefix + r.Namespace + "/" + r.Name + "/" + r.Key + "}"
+}
+
+// ConfigSecretRefs returns the Secret keys referenced in the given configuration, sorted and without duplicates.
+func ConfigSecretRefs(c *commonv1.Config) []ConfigSecretRef {
+	seen := map[ConfigSecretRef]struct{}{}
+	InterpolateConfigSecretRefs(c, func(ref ConfigSecretRef) string {
+		seen[ref] = struct{}{}
+		return ref.String()
+	})
+	refs := make([]ConfigSecretRef, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].String() < refs[j].String() })
+	return refs
+}
+
+// InterpolateConfigSecretRefs returns a copy of the given configuration where the references to Secret keys in string
+// values are replaced by the result of the given function.
+func InterpolateConfigSecretRefs(c *commonv1.Config, replace func(ConfigSecretRef) string) *commonv1.Config {
+	if c == nil {
+		return nil
+	}
+	data, _ := mapConfigStrings(c.Data, func(value string) string {
+		if !strings.Contains(value, configSecretRefPrefix) {
+			return value
+		}
+		return configSecretRefPattern.ReplaceAllStringFunc(value, func(match string) string {
+			groups := configSecretRefPattern.FindStringSubmatch(match)
+			return replace(ConfigSecretRef{Namespace: groups[1], Name: groups[2], Key: groups[3]})
+		})
+	}).(map[string]interface{})
+	return &commonv1.Config{Data: data}
+}
+
+// hasMalformedConfigSecretRef returns true if a string value of the given configuration starts a reference to a Secret
+// key that does not match the ${secret:<namespace>/<name>/<key>} format.
+func hasMalformedConfigSecretRef(c *commonv1.Config) bool {
+	if c == nil {
+		return false
+	}
+	malformed := false
+	mapConfigStrings(c.Data, func(value string) string {
+		if strings.Contains(configSecretRefPattern.ReplaceAllString(value, ""), configSecretRefPrefix) {
+			malformed = true
+		}
+		return value
+	})
+	return malformed
+}
+
+// mapConfigStrings returns a copy of the given configuration value where the strings are replaced by the result of the
+// given function.
+func mapConfigStrings(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = mapConfigStrings(item, fn)
+		}
+		return result
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = mapConfigStrings(item, fn)
+		}
+		return result
+	case string:
+		return fn(v)
+	default:
+		return v
+	}
+}
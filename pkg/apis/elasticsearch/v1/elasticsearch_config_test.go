@@ -180,6 +180,23 @@ func TestConfig_Unpack(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "node.roles replaces the boolean roles",
+			args: &commonv1.Config{
+				Data: map[string]interface{}{
+					"node.roles":  []string{"data_hot", "ingest"},
+					"node.master": true,
+				},
+			},
+			want: ElasticsearchSettings{
+				Node: Node{
+					Data:   true,
+					Ingest: true,
+				},
+				NodeRoles: []string{"data_hot", "ingest"},
+			},
+			wantErr: false,
+		},
 		{
 			name:    "Unpack is nil safe",
 			args:    nil,
@@ -200,3 +217,33 @@ func TestConfig_Unpack(t *testing.T) {
 		})
 	}
 }
+
+func TestInterpolateConfigSecretRefs(t *testing.T) {
+	config := &commonv1.Config{Data: map[string]interface{}{
+		"smtp.password": "${secret:ns/smtp/password}",
+		"ldap": map[string]interface{}{
+			"url":           []interface{}{"ldaps://${secret:ns/ldap/host}:636", "ldaps://backup:636"},
+			"bind_password": "${secret:ns/smtp/password}",
+		},
+		"node.store.allow_mmap": false,
+	}}
+	interpolated := InterpolateConfigSecretRefs(config, func(ref ConfigSecretRef) string {
+		return "${" + ref.Name + "_" + ref.Key + "}"
+	})
+	require.Equal(t, &commonv1.Config{Data: map[string]interface{}{
+		"smtp.password": "${smtp_password}",
+		"ldap": map[string]interface{}{
+			"url":           []interface{}{"ldaps://${ldap_host}:636", "ldaps://backup:636"},
+			"bind_password": "${smtp_password}",
+		},
+		"node.store.allow_mmap": false,
+	}}, interpolated)
+	// the original configuration is left untouched
+	require.Equal(t, "${secret:ns/smtp/password}", config.Data["smtp.password"])
+
+	require.Equal(t, []ConfigSecretRef{
+		{Namespace: "ns", Name: "ldap", Key: "host"},
+		{Namespace: "ns", Name: "smtp", Key: "password"},
+	}, ConfigSecretRefs(config))
+	require.Empty(t, ConfigSecretRefs(nil))
+}
@@ -5,8 +5,12 @@
 package v1
 
 import (
+	"strings"
+	"time"
+
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
@@ -17,8 +21,15 @@ const ElasticsearchContainerName = "elasticsearch"
 
 // ElasticsearchSpec holds the specification of an Elasticsearch cluster.
 type ElasticsearchSpec struct {
+	// ClassName is the name of the ElasticsearchClass this cluster is created from. The fields of the class template
+	// are copied to the empty fields of this specification when the class is first applied. Version and NodeSets are
+	// required unless a class is set.
+	// +kubebuilder:validation:Optional
+	ClassName string `json:"className,omitempty"`
+
 	// Version of Elasticsearch.
-	Version string `json:"version"`
+	// +kubebuilder:validation:Optional
+	Version string `json:"version,omitempty"`
 
 	// Image is the Elasticsearch Docker image to deploy.
 	Image string `json:"image,omitempty"`
@@ -33,8 +44,9 @@ type ElasticsearchSpec struct {
 
 	// NodeSets allow specifying groups of Elasticsearch nodes sharing the same configuration and Pod templates.
 	// See: https://www.elastic.co/guide/en/cloud-on-k8s/current/k8s-orchestration.html
+	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:MinItems=1
-	NodeSets []NodeSet `json:"nodeSets"`
+	NodeSets []NodeSet `json:"nodeSets,omitempty"`
 
 	// UpdateStrategy specifies how updates to the cluster should be performed.
 	// +kubebuilder:validation:Optional
@@ -46,6 +58,32 @@ type ElasticsearchSpec struct {
 	// +kubebuilder:validation:Optional
 	PodDisruptionBudget *commonv1.PodDisruptionBudgetTemplate `json:"podDisruptionBudget,omitempty"`
 
+	// TopologySpread generates default topology spread constraints for the Pods of the master and data nodes, unless
+	// topology spread constraints are set in their Pod template.
+	// +kubebuilder:validation:Optional
+	TopologySpread *TopologySpread `json:"topologySpread,omitempty"`
+
+	// LifecycleHooks customizes the pre-stop hook of the Elasticsearch nodes, and adds a post-start hook to them, unless
+	// the corresponding hooks are set in their Pod template.
+	// +kubebuilder:validation:Optional
+	LifecycleHooks *LifecycleHooks `json:"lifecycleHooks,omitempty"`
+
+	// Plugins to install on the Elasticsearch nodes, by an init container, before Elasticsearch starts.
+	// +kubebuilder:validation:Optional
+	Plugins *Plugins `json:"plugins,omitempty"`
+
+	// AnalysisFiles are ConfigMaps holding analysis files, such as synonyms or dictionaries, mounted in the
+	// configuration directory of the Elasticsearch nodes. Changes to their content are propagated to the running nodes,
+	// and the search analyzers reloaded, without restarting the nodes.
+	// +kubebuilder:validation:Optional
+	AnalysisFiles []AnalysisFiles `json:"analysisFiles,omitempty"`
+
+	// GeoIP configures where the Elasticsearch nodes get the GeoIP databases used by the geoip ingest processor from,
+	// such as an internal mirror of the Elastic GeoIP endpoint in air-gapped environments.
+	// Requires Elasticsearch 7.14.0 or later.
+	// +kubebuilder:validation:Optional
+	GeoIP *GeoIP `json:"geoip,omitempty"`
+
 	// Auth contains user authentication and authorization security settings for Elasticsearch.
 	// +kubebuilder:validation:Optional
 	Auth Auth `json:"auth,omitempty"`
@@ -63,6 +101,66 @@ type ElasticsearchSpec struct {
 	// RemoteClusters enables you to establish uni-directional connections to a remote Elasticsearch cluster.
 	// +optional
 	RemoteClusters []RemoteCluster `json:"remoteClusters,omitempty"`
+
+	// RemoteClusterServer enables the remote cluster server, for other clusters to connect to this one with API keys.
+	// +kubebuilder:validation:Optional
+	RemoteClusterServer RemoteClusterServer `json:"remoteClusterServer,omitempty"`
+
+	// CrossClusterReplication declares the indices replicated from the remote clusters. Requires a license allowing
+	// cross-cluster replication.
+	// +kubebuilder:validation:Optional
+	CrossClusterReplication *CrossClusterReplication `json:"crossClusterReplication,omitempty"`
+
+	// Audit enables audit logging on the Elasticsearch nodes, and optionally ships the audit logs to a monitoring
+	// Elasticsearch cluster. Requires a license allowing audit logging.
+	// +kubebuilder:validation:Optional
+	Audit *AuditLogging `json:"audit,omitempty"`
+
+	// ServiceMesh declares the service mesh the Elasticsearch Pods are injected in. The operator annotates the Pods
+	// for the sidecar of the mesh, and delegates the encryption of the HTTP layer to the mutual TLS of the mesh unless
+	// disabled.
+	// +kubebuilder:validation:Optional
+	ServiceMesh *ServiceMesh `json:"serviceMesh,omitempty"`
+
+	// Logging sets the levels of the loggers and the slow log thresholds of the indices at runtime, through the
+	// cluster and index settings APIs, without restarting the nodes.
+	// +kubebuilder:validation:Optional
+	Logging *Logging `json:"logging,omitempty"`
+
+	// RuntimeConfig holds dynamic cluster settings applied at runtime as persistent cluster settings, rather than
+	// written to the configuration file of the nodes: changing them does not restart the nodes. Settings removed from
+	// it are reset to their default value.
+	// +kubebuilder:validation:Optional
+	RuntimeConfig *commonv1.Config `json:"runtimeConfig,omitempty"`
+
+	// DiskPressure specifies how the operator handles the indices made read-only by the flood-stage disk watermark.
+	// +kubebuilder:validation:Optional
+	DiskPressure *DiskPressure `json:"diskPressure,omitempty"`
+
+	// CriticalIndices are names or wildcard patterns of indices whose health is reported individually in the
+	// CriticalIndicesAvailable condition of the resource, with an event when one of them becomes red.
+	// +kubebuilder:validation:Optional
+	CriticalIndices []string `json:"criticalIndices,omitempty"`
+
+	// NodeDebug holds Pods of the cluster in an init container before Elasticsearch starts, for maintenance of their
+	// volumes.
+	// +kubebuilder:validation:Optional
+	NodeDebug *NodeDebug `json:"nodeDebug,omitempty"`
+
+	// Maintenance holds the retention jobs the operator runs periodically on the indices of the cluster, for indices
+	// not managed by index lifecycle management.
+	// +kubebuilder:validation:Optional
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+
+	// VirtualMemory checks that the hosts of the Elasticsearch Pods set the vm.max_map_count kernel setting required
+	// by memory mapping, as an alternative to privileged init containers setting it.
+	// +kubebuilder:validation:Optional
+	VirtualMemory *VirtualMemory `json:"virtualMemory,omitempty"`
+
+	// PriorityClasses sets the priority class of the Elasticsearch Pods by node role, so that the master nodes are
+	// not preempted, or evicted under node pressure, before the other nodes of the cluster.
+	// +kubebuilder:validation:Optional
+	PriorityClasses *PriorityClasses `json:"priorityClasses,omitempty"`
 }
 
 // TransportConfig holds the transport layer settings for Elasticsearch.
@@ -82,29 +180,642 @@ type RemoteCluster struct {
 	// ElasticsearchRef is a reference to an Elasticsearch cluster running within the same k8s cluster.
 	ElasticsearchRef commonv1.ObjectSelector `json:"elasticsearchRef,omitempty"`
 
+	// KubernetesCluster is the name of the Kubernetes cluster running the referenced Elasticsearch cluster, as
+	// registered in the operator federation. Defaults to the Kubernetes cluster of this cluster.
+	// +kubebuilder:validation:Optional
+	KubernetesCluster string `json:"kubernetesCluster,omitempty"`
+
+	// APIKey connects to the remote cluster with a cross-cluster API key created by the operator, instead of relying
+	// on the trust of the transport certificates. The remote cluster must have its remote cluster server enabled.
+	// Requires Elasticsearch 8.10.0 or later.
+	// +kubebuilder:validation:Optional
+	APIKey *RemoteClusterAPIKey `json:"apiKey,omitempty"`
+
 	// TODO: Allow the user to specify some options (transport.compress, transport.ping_schedule)
 
 }
 
+// RemoteClusterAPIKey describes the cross-cluster API key created on the remote cluster.
+type RemoteClusterAPIKey struct {
+	// Access is the access to the indices of the remote cluster granted by the API key.
+	Access RemoteClusterAccess `json:"access"`
+}
+
+// RemoteClusterAccess is the access to the indices of a remote cluster.
+type RemoteClusterAccess struct {
+	// Search grants the access to search the indices with cross-cluster search.
+	// +kubebuilder:validation:Optional
+	Search *RemoteClusterIndices `json:"search,omitempty"`
+	// Replication grants the access to replicate the indices with cross-cluster replication.
+	// +kubebuilder:validation:Optional
+	Replication *RemoteClusterIndices `json:"replication,omitempty"`
+}
+
+// RemoteClusterIndices are the indices of a remote cluster an API key grants access to.
+type RemoteClusterIndices struct {
+	// Names are the names or patterns of the indices.
+	// +kubebuilder:validation:MinItems=1
+	Names []string `json:"names"`
+}
+
+// RemoteClusterServer is the configuration of the remote cluster server of the cluster.
+type RemoteClusterServer struct {
+	// Enabled exposes the remote cluster server on port 9443 of the transport service, for remote clusters to connect
+	// with cross-cluster API keys. Requires Elasticsearch 8.10.0 or later.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// IsFederated returns true if the remote cluster runs in another Kubernetes cluster of the operator federation.
+func (r RemoteCluster) IsFederated() bool {
+	return r.KubernetesCluster != ""
+}
+
+// HasAPIKey returns true if the remote cluster is reached with an API key.
+func (r RemoteCluster) HasAPIKey() bool {
+	return r.APIKey != nil
+}
+
 func (r RemoteCluster) ConfigHash() string {
 	return hash.HashObject(r)
 }
 
+// CrossClusterReplication declares the follower indices and auto-follow patterns of the cluster.
+type CrossClusterReplication struct {
+	// FollowerIndices are the indices of this cluster replicating an index of a remote cluster. Follower indices
+	// removed from this list are not converted back to regular indices.
+	FollowerIndices []FollowerIndex `json:"followerIndices,omitempty"`
+	// AutoFollowPatterns create follower indices for the new indices of the remote clusters matching patterns.
+	AutoFollowPatterns []AutoFollowPattern `json:"autoFollowPatterns,omitempty"`
+}
+
+// FollowerIndex is an index replicating an index of a remote cluster.
+type FollowerIndex struct {
+	// Name of the follower index.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// RemoteCluster is the name of the remote cluster of the leader index, as declared in RemoteClusters.
+	// +kubebuilder:validation:MinLength=1
+	RemoteCluster string `json:"remoteCluster"`
+	// LeaderIndex is the name of the index replicated from the remote cluster.
+	// +kubebuilder:validation:MinLength=1
+	LeaderIndex string `json:"leaderIndex"`
+}
+
+// AutoFollowPattern automatically follows the indices of a remote cluster matching patterns.
+type AutoFollowPattern struct {
+	// Name of the auto-follow pattern.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// RemoteCluster is the name of the remote cluster of the leader indices, as declared in RemoteClusters.
+	// +kubebuilder:validation:MinLength=1
+	RemoteCluster string `json:"remoteCluster"`
+	// LeaderIndexPatterns are the patterns of the names of the indices to follow.
+	// +kubebuilder:validation:MinItems=1
+	LeaderIndexPatterns []string `json:"leaderIndexPatterns"`
+	// FollowIndexPattern is the name of the follower indices, where {{leader_index}} is replaced by the name of the
+	// leader index. Defaults to the name of the leader index.
+	FollowIndexPattern string `json:"followIndexPattern,omitempty"`
+}
+
 // NodeCount returns the total number of nodes of the Elasticsearch cluster
 func (es ElasticsearchSpec) NodeCount() int32 {
 	count := int32(0)
 	for _, topoElem := range es.NodeSets {
-		count += topoElem.Count
+		count += topoElem.TotalCount()
 	}
 	return count
 }
 
+// EffectiveHTTP returns the HTTP layer settings applied to the Elasticsearch cluster: the settings of the
+// specification, with TLS disabled if its encryption is delegated to the mutual TLS of a service mesh.
+func (es ElasticsearchSpec) EffectiveHTTP() commonv1.HTTPConfig {
+	if !es.ServiceMesh.MTLSEnabled() {
+		return es.HTTP
+	}
+	httpCfg := *es.HTTP.DeepCopy()
+	if httpCfg.TLS.SelfSignedCertificate == nil {
+		httpCfg.TLS.SelfSignedCertificate = &commonv1.SelfSignedCertificate{}
+	}
+	httpCfg.TLS.SelfSignedCertificate.Disabled = true
+	return httpCfg
+}
+
+// TopologySpread specifies how the master and data nodes are spread across topology domains.
+type TopologySpread struct {
+	// TopologyKey is the label of the Kubernetes nodes holding their topology domain. Defaults to
+	// topology.kubernetes.io/zone.
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// MaxSkew is the maximum difference in the number of master, or data, nodes between two topology domains.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	MaxSkew *int32 `json:"maxSkew,omitempty"`
+	// WhenUnsatisfiable indicates how to deal with a Pod that cannot be scheduled without exceeding the skew.
+	// Defaults to ScheduleAnyway.
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	WhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
+// TopologyKeyOrDefault returns the label of the Kubernetes nodes holding their topology domain.
+func (t TopologySpread) TopologyKeyOrDefault() string {
+	if t.TopologyKey == "" {
+		return DefaultZoneTopologyKey
+	}
+	return t.TopologyKey
+}
+
+// MaxSkewOrDefault returns the maximum skew between two topology domains.
+func (t TopologySpread) MaxSkewOrDefault() int32 {
+	if t.MaxSkew == nil {
+		return 1
+	}
+	return *t.MaxSkew
+}
+
+// WhenUnsatisfiableOrDefault returns how to deal with a Pod that cannot be scheduled without exceeding the skew.
+func (t TopologySpread) WhenUnsatisfiableOrDefault() corev1.UnsatisfiableConstraintAction {
+	if t.WhenUnsatisfiable == "" {
+		return corev1.ScheduleAnyway
+	}
+	return t.WhenUnsatisfiable
+}
+
+// LifecycleHooks holds the configuration of the lifecycle hooks of the Elasticsearch nodes.
+type LifecycleHooks struct {
+	// PreStop configures the pre-stop hook, which waits for the Pod to be removed from the Services before letting
+	// Elasticsearch stop.
+	PreStop *PreStopHook `json:"preStop,omitempty"`
+	// PostStart configures a post-start hook, run right after the Elasticsearch container is started.
+	PostStart *PostStartHook `json:"postStart,omitempty"`
+}
+
+// PreStopHook configures the pre-stop hook of the Elasticsearch nodes.
+type PreStopHook struct {
+	// MaxWaitSeconds is the maximum time to wait for the Pod IP to disappear from the DNS records of the headless
+	// Service. Defaults to 20.
+	// +kubebuilder:validation:Minimum=0
+	MaxWaitSeconds *int32 `json:"maxWaitSeconds,omitempty"`
+	// AdditionalWaitSeconds is the time to wait once the Pod IP disappeared from the DNS records, to let in-flight
+	// requests complete before Elasticsearch stops. Defaults to 30.
+	// +kubebuilder:validation:Minimum=0
+	AdditionalWaitSeconds *int32 `json:"additionalWaitSeconds,omitempty"`
+	// SkipCondition is a shell command evaluated first: the hook exits without waiting if it succeeds.
+	SkipCondition string `json:"skipCondition,omitempty"`
+	// Script is a bash script run before waiting. Its failure does not prevent the hook from waiting.
+	Script string `json:"script,omitempty"`
+}
+
+// PostStartHook configures the post-start hook of the Elasticsearch nodes.
+type PostStartHook struct {
+	// Script is a bash script run right after the Elasticsearch container is started, concurrently with
+	// Elasticsearch. The container is restarted if the script fails.
+	// +kubebuilder:validation:MinLength=1
+	Script string `json:"script"`
+}
+
+// PreStopHook returns the configuration of the pre-stop hook, if any.
+func (l *LifecycleHooks) PreStopHook() *PreStopHook {
+	if l == nil {
+		return nil
+	}
+	return l.PreStop
+}
+
+// PostStartHook returns the configuration of the post-start hook, if any.
+func (l *LifecycleHooks) PostStartHook() *PostStartHook {
+	if l == nil {
+		return nil
+	}
+	return l.PostStart
+}
+
+// DefaultPluginsRegistryURL is the base URL of the registry of the official Elasticsearch plugins.
+const DefaultPluginsRegistryURL = "https://artifacts.elastic.co/downloads/elasticsearch-plugins"
+
+// Plugins specifies the plugins to install on the Elasticsearch nodes, and where to get them from.
+type Plugins struct {
+	// Install lists the plugins to install.
+	// +kubebuilder:validation:MinItems=1
+	Install []Plugin `json:"install"`
+	// RegistryURL is the base URL from which the plugin archives are downloaded, as <registryURL>/<name>/<name>-<version>.zip.
+	// Defaults to https://artifacts.elastic.co/downloads/elasticsearch-plugins.
+	RegistryURL string `json:"registryURL,omitempty"`
+	// Bundle is an in-cluster artifact holding the plugin archives, named <name>-<version>.zip. When set, the plugins
+	// are installed from it instead of being downloaded, for air-gapped environments.
+	Bundle *PluginBundle `json:"bundle,omitempty"`
+}
+
+// Plugin is an Elasticsearch plugin to install.
+type Plugin struct {
+	// Name of the plugin, for example analysis-icu.
+	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9_-]+$
+	Name string `json:"name"`
+	// Version of the plugin. Defaults to the version of Elasticsearch, which official plugins must match.
+	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9._-]+$
+	Version string `json:"version,omitempty"`
+	// URL from which the plugin archive is downloaded, instead of the registry.
+	URL string `json:"url,omitempty"`
+	// SHA512 is the hex-encoded SHA-512 checksum the plugin archive must match before being installed.
+	// +kubebuilder:validation:Pattern=^[a-fA-F0-9]{128}$
+	SHA512 string `json:"sha512,omitempty"`
+}
+
+// VersionOrDefault returns the version of the plugin, or else the given version of Elasticsearch.
+func (p Plugin) VersionOrDefault(esVersion string) string {
+	if p.Version == "" {
+		return esVersion
+	}
+	return p.Version
+}
+
+// ArchiveName returns the name of the archive of the plugin at the given version.
+func (p Plugin) ArchiveName(version string) string {
+	return p.Name + "-" + version + ".zip"
+}
+
+// PluginBundle references the in-cluster artifact holding the plugin archives. Exactly one of SecretName and
+// PersistentVolumeClaimName must be set.
+type PluginBundle struct {
+	// SecretName is the name of a Secret holding one plugin archive per key.
+	SecretName string `json:"secretName,omitempty"`
+	// PersistentVolumeClaimName is the name of a PersistentVolumeClaim holding the plugin archives at its root. The
+	// claim is mounted read-only by all the Pods of the cluster.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+}
+
+// DefaultAnalysisFilesDir is the directory, relative to the configuration directory of Elasticsearch, in which the
+// analysis files are mounted by default.
+const DefaultAnalysisFilesDir = "analysis"
+
+// AnalysisFiles references a ConfigMap holding analysis files, one per key.
+type AnalysisFiles struct {
+	// ConfigMapName is the name of the ConfigMap holding the files.
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+	// Path is the directory, relative to the configuration directory of Elasticsearch, in which the files are mounted.
+	// Defaults to analysis/<configMapName>. Analyzers reference the files relative to the configuration directory,
+	// for example analysis/my-synonyms/synonyms.txt.
+	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)*$
+	Path string `json:"path,omitempty"`
+}
+
+// PathOrDefault returns the directory, relative to the configuration directory of Elasticsearch, in which the files
+// are mounted.
+func (a AnalysisFiles) PathOrDefault() string {
+	if a.Path == "" {
+		return DefaultAnalysisFilesDir + "/" + a.ConfigMapName
+	}
+	return a.Path
+}
+
+// GeoIPDatabasesDir is the directory, relative to the configuration directory of Elasticsearch, from which the nodes
+// load the GeoIP databases supplied through a PersistentVolumeClaim.
+const GeoIPDatabasesDir = "ingest-geoip"
+
+// GeoIP specifies where the Elasticsearch nodes get the GeoIP databases from.
+type GeoIP struct {
+	// Endpoint is the URL of the service from which the nodes download the GeoIP database updates, such as a mirror of
+	// the Elastic GeoIP endpoint hosted in the Kubernetes cluster. Defaults to the endpoint set in the operator
+	// configuration, if any, or else to the Elastic GeoIP endpoint.
+	Endpoint string `json:"endpoint,omitempty"`
+	// PersistentVolumeClaimName is the name of a PersistentVolumeClaim holding GeoIP databases (.mmdb files) at its
+	// root. The claim is mounted read-only by all the Pods of the cluster, in the ingest-geoip directory of the
+	// configuration directory. The download of database updates is disabled unless an endpoint is set.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+}
+
+// ServiceMeshProvider is a service mesh supported by the operator.
+type ServiceMeshProvider string
+
+// IstioServiceMesh is the Istio service mesh.
+const IstioServiceMesh ServiceMeshProvider = "istio"
+
+// ServiceMesh specifies the service mesh the Elasticsearch Pods are injected in.
+type ServiceMesh struct {
+	// Provider of the service mesh.
+	// +kubebuilder:validation:Enum=istio
+	Provider ServiceMeshProvider `json:"provider"`
+	// MTLS delegates the encryption of the HTTP layer to the mutual TLS of the mesh: the operator disables TLS on the
+	// HTTP layer of Elasticsearch, and does not issue HTTP certificates. The transport layer is excluded from the mesh
+	// and keeps the TLS set up by the operator. Defaults to true.
+	// +kubebuilder:validation:Optional
+	MTLS *bool `json:"mtls,omitempty"`
+}
+
+// MTLSEnabled returns true if the encryption of the HTTP layer is delegated to the mutual TLS of the mesh.
+func (sm *ServiceMesh) MTLSEnabled() bool {
+	return sm != nil && (sm.MTLS == nil || *sm.MTLS)
+}
+
+// LogLevel is the level of an Elasticsearch logger.
+// +kubebuilder:validation:Enum=TRACE;DEBUG;INFO;WARN;ERROR;FATAL;OFF
+type LogLevel string
+
+// Logging specifies the logging settings applied at runtime.
+type Logging struct {
+	// Loggers are the levels of the loggers, indexed by their name, such as org.elasticsearch.discovery, or _root for
+	// the root logger. Loggers removed from the specification are reset to their default level.
+	Loggers map[string]LogLevel `json:"loggers,omitempty"`
+	// SlowLog are the slow log thresholds of the indices.
+	SlowLog []SlowLogThresholds `json:"slowLog,omitempty"`
+}
+
+// SlowLogThresholds are the slow log thresholds of a set of indices.
+type SlowLogThresholds struct {
+	// Indices are the names or wildcard patterns of the indices the thresholds apply to, including the indices created
+	// after the thresholds are set.
+	// +kubebuilder:validation:MinItems=1
+	Indices []string `json:"indices"`
+	// Thresholds are indexed by the setting they set, relative to the slow log settings of the indices: search.query,
+	// search.fetch or indexing.index, followed by the level, such as search.query.warn for the
+	// index.search.slowlog.threshold.query.warn setting. Values are time values, such as 500ms, or -1 to disable the
+	// threshold. Thresholds removed from the specification are left set on the indices: set them to -1 instead.
+	Thresholds map[string]string `json:"thresholds"`
+}
+
+// IndexSetting returns the index setting set with the given threshold key, such as
+// index.search.slowlog.threshold.query.warn for search.query.warn.
+func (s SlowLogThresholds) IndexSetting(key string) string {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) != 2 {
+		return key
+	}
+	return "index." + parts[0] + ".slowlog.threshold." + parts[1]
+}
+
+// LoggingStatus reports the logging settings applied at runtime.
+type LoggingStatus struct {
+	// Loggers are the names of the loggers whose level is set by the operator.
+	Loggers []string `json:"loggers,omitempty"`
+	// Applied is true if the last observation of the cluster confirmed that the logger levels and slow log thresholds
+	// of the specification are applied.
+	Applied bool `json:"applied"`
+	// Pending are the settings not confirmed by the last observation of the cluster yet, as logger.<name> for the
+	// loggers, and as <index>/<setting> for the slow log thresholds.
+	Pending []string `json:"pending,omitempty"`
+}
+
+// DiskPressure specifies how the operator handles the indices made read-only by the flood-stage disk watermark.
+type DiskPressure struct {
+	// ReleaseReadOnlyBlocks removes the index.blocks.read_only_allow_delete block from the indices observed as
+	// read-only once no data node exceeds the high disk watermark anymore. Elasticsearch 7.4 and later release the
+	// block automatically.
+	ReleaseReadOnlyBlocks bool `json:"releaseReadOnlyBlocks,omitempty"`
+}
+
+// ReleasesReadOnlyBlocks returns true if the operator releases the read-only blocks once the disk pressure is relieved.
+func (dp *DiskPressure) ReleasesReadOnlyBlocks() bool {
+	return dp != nil && dp.ReleaseReadOnlyBlocks
+}
+
+// MinMaxMapCount is the lowest vm.max_map_count kernel setting of the hosts for Elasticsearch to memory map the indices.
+const MinMaxMapCount = 262144
+
+// VirtualMemory specifies how the vm.max_map_count kernel setting of the hosts is checked.
+type VirtualMemory struct {
+	// CheckMaxMapCount runs the elastic-internal-check-vm init container after the other init containers of the
+	// Elasticsearch Pods, which fails until the vm.max_map_count kernel setting of the host is at least 262144. The
+	// hosts below are reported in the VirtualMemorySufficient status condition. NodeSets disabling memory mapping with
+	// node.store.allow_mmap set to false are not checked.
+	CheckMaxMapCount bool `json:"checkMaxMapCount,omitempty"`
+}
+
+// IsMaxMapCountChecked returns true if the vm.max_map_count kernel setting of the hosts is checked.
+func (vm *VirtualMemory) IsMaxMapCountChecked() bool {
+	return vm != nil && vm.CheckMaxMapCount
+}
+
+// PriorityClasses specifies the priority classes of the Elasticsearch Pods by node role. A priority class set in the
+// Pod template of a NodeSet takes precedence.
+type PriorityClasses struct {
+	// Master is the priority class of the master-eligible nodes.
+	// +kubebuilder:validation:Optional
+	Master *RolePriorityClass `json:"master,omitempty"`
+	// Data is the priority class of the data nodes that are not master-eligible.
+	// +kubebuilder:validation:Optional
+	Data *RolePriorityClass `json:"data,omitempty"`
+	// Coordinating is the priority class of the nodes that are neither master-eligible nor data nodes.
+	// +kubebuilder:validation:Optional
+	Coordinating *RolePriorityClass `json:"coordinating,omitempty"`
+}
+
+// RolePriorityClass is the priority class of the Elasticsearch Pods of a node role.
+type RolePriorityClass struct {
+	// Name of the PriorityClass.
+	Name string `json:"name"`
+	// Value of the PriorityClass, created by the operator if it does not exist. The PriorityClass is expected to exist
+	// if not set. The value of an existing PriorityClass is never updated.
+	// +kubebuilder:validation:Optional
+	Value *int32 `json:"value,omitempty"`
+	// PreemptionPolicy of the PriorityClass created by the operator: PreemptLowerPriority by default, or Never.
+	// +kubebuilder:validation:Optional
+	PreemptionPolicy *corev1.PreemptionPolicy `json:"preemptionPolicy,omitempty"`
+}
+
+// PriorityClassRoles are the node roles of the priority classes, from the highest priority to the lowest.
+var PriorityClassRoles = []string{"master", "data", "coordinating"}
+
+// ForRole returns the priority class of the given node role, or nil if not specified.
+func (pc *PriorityClasses) ForRole(role string) *RolePriorityClass {
+	if pc == nil {
+		return nil
+	}
+	switch role {
+	case "master":
+		return pc.Master
+	case "data":
+		return pc.Data
+	case "coordinating":
+		return pc.Coordinating
+	}
+	return nil
+}
+
+// ForRoles returns the priority class of the nodes with the given roles, or nil if not specified: master-eligible
+// nodes take the master priority, other data nodes the data priority.
+func (pc *PriorityClasses) ForRoles(master, data bool) *RolePriorityClass {
+	switch {
+	case master:
+		return pc.ForRole("master")
+	case data:
+		return pc.ForRole("data")
+	default:
+		return pc.ForRole("coordinating")
+	}
+}
+
+// NodeDebug specifies the Pods held in an init container before Elasticsearch starts.
+type NodeDebug struct {
+	// SuspendedPods are the Pods held in the elastic-internal-suspend init container, with the volumes of the
+	// Elasticsearch container mounted, until they are removed from the list. Running Pods are restarted to be held.
+	SuspendedPods []SuspendedPod `json:"suspendedPods,omitempty"`
+}
+
+// SuspendedPod is a Pod held in an init container before Elasticsearch starts.
+type SuspendedPod struct {
+	// Name of the Pod.
+	Name string `json:"name"`
+	// Reason for suspending the Pod, reported in the status.
+	// +kubebuilder:validation:Optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// SuspendedPodNames returns the names of the suspended Pods.
+func (nd *NodeDebug) SuspendedPodNames() []string {
+	if nd == nil {
+		return nil
+	}
+	names := make([]string, len(nd.SuspendedPods))
+	for i, pod := range nd.SuspendedPods {
+		names[i] = pod.Name
+	}
+	return names
+}
+
+// DefaultRetentionJobInterval is the default interval between two runs of a retention job.
+const DefaultRetentionJobInterval = 24 * time.Hour
+
+// Maintenance specifies the maintenance jobs run by the operator on the cluster.
+type Maintenance struct {
+	// RetentionJobs are the retention actions applied periodically to the indices of the cluster.
+	// +kubebuilder:validation:Optional
+	RetentionJobs []RetentionJob `json:"retentionJobs,omitempty"`
+}
+
+// RetentionJob deletes the old indices, and force-merges the read-only indices, matching a set of patterns.
+type RetentionJob struct {
+	// Name of the job, unique in the cluster.
+	Name string `json:"name"`
+	// Indices are the names or wildcard patterns of the indices the job applies to. Hidden indices, whose name starts
+	// with a dot, only match patterns starting with a dot.
+	// +kubebuilder:validation:MinItems=1
+	Indices []string `json:"indices"`
+	// Interval between two runs of the job, such as 1h. Defaults to 24h.
+	// +kubebuilder:validation:Optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// DeleteAfter deletes the indices created longer ago than this duration, such as 720h for 30 days.
+	// +kubebuilder:validation:Optional
+	DeleteAfter *metav1.Duration `json:"deleteAfter,omitempty"`
+	// ForceMerge merges the segments of the read-only indices, whose writes are blocked.
+	// +kubebuilder:validation:Optional
+	ForceMerge *ForceMerge `json:"forceMerge,omitempty"`
+}
+
+// ForceMerge specifies the force merge of the read-only indices.
+type ForceMerge struct {
+	// MaxNumSegments is the number of segments the indices are merged down to. Defaults to 1.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxNumSegments int `json:"maxNumSegments,omitempty"`
+}
+
+// SegmentsOrDefault returns the number of segments the indices are merged down to.
+func (fm ForceMerge) SegmentsOrDefault() int {
+	if fm.MaxNumSegments <= 0 {
+		return 1
+	}
+	return fm.MaxNumSegments
+}
+
+// IntervalOrDefault returns the interval between two runs of the job.
+func (j RetentionJob) IntervalOrDefault() time.Duration {
+	if j.Interval == nil {
+		return DefaultRetentionJobInterval
+	}
+	return j.Interval.Duration
+}
+
 // Auth contains user authentication and authorization security settings for Elasticsearch.
 type Auth struct {
 	// Roles to propagate to the Elasticsearch cluster.
 	Roles []RoleSource `json:"roles,omitempty"`
 	// FileRealm to propagate to the Elasticsearch cluster.
 	FileRealm []FileRealmSource `json:"fileRealm,omitempty"`
+	// SAML realms to configure in the Elasticsearch cluster. A matching auth provider is configured in the
+	// associated Kibana instances.
+	SAML []SAMLRealm `json:"saml,omitempty"`
+	// OIDC realms to configure in the Elasticsearch cluster. A matching auth provider is configured in the
+	// associated Kibana instances. Requires Elasticsearch 7.2 or later.
+	OIDC []OIDCRealm `json:"oidc,omitempty"`
+	// PasswordRotation is the rotation policy of the passwords generated for the elastic user and the internal users.
+	// Passwords can also be rotated on demand by changing the value of the
+	// elasticsearch.k8s.elastic.co/rotate-passwords annotation of the Elasticsearch resource.
+	PasswordRotation *PasswordRotation `json:"passwordRotation,omitempty"`
+}
+
+// PasswordRotation is the rotation policy of the generated passwords.
+type PasswordRotation struct {
+	// MaxAge is the maximum age of the generated passwords, after which they are rotated.
+	// Generated passwords are not rotated based on their age if not set.
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// AuditLogging holds the audit logging configuration of the Elasticsearch nodes.
+type AuditLogging struct {
+	// IncludeEvents is the list of event types to audit. Elasticsearch defaults apply if empty.
+	IncludeEvents []string `json:"includeEvents,omitempty"`
+	// ExcludeEvents is the list of event types not to audit.
+	ExcludeEvents []string `json:"excludeEvents,omitempty"`
+	// EmitRequestBody includes the body of REST requests in the audit events.
+	EmitRequestBody bool `json:"emitRequestBody,omitempty"`
+	// IgnoreFilters are policies discarding the audit events matching all of their attributes.
+	IgnoreFilters []AuditIgnoreFilter `json:"ignoreFilters,omitempty"`
+	// Shipping deploys a Filebeat sidecar container named audit-beat next to each Elasticsearch node, shipping the
+	// audit logs to a monitoring Elasticsearch cluster. The container can be customized in the Pod template.
+	// Requires Elasticsearch 7.0 or later.
+	Shipping *AuditShipping `json:"shipping,omitempty"`
+}
+
+// AuditIgnoreFilter is a policy discarding the audit events matching all its attributes.
+// Each attribute accepts wildcards, and matches any value if empty.
+type AuditIgnoreFilter struct {
+	// Name of the policy, unique among the ignore filters.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Users are the names of the users to ignore.
+	Users []string `json:"users,omitempty"`
+	// Realms are the names of the realms to ignore.
+	Realms []string `json:"realms,omitempty"`
+	// Roles are the roles to ignore.
+	Roles []string `json:"roles,omitempty"`
+	// Indices are the names of the indices to ignore.
+	Indices []string `json:"indices,omitempty"`
+}
+
+// AuditShipping describes where audit logs are shipped.
+type AuditShipping struct {
+	// ElasticsearchRef is a reference to the monitoring Elasticsearch cluster receiving the audit logs, running in
+	// the same Kubernetes cluster.
+	ElasticsearchRef commonv1.ObjectSelector `json:"elasticsearchRef"`
+	// Image is the Filebeat Docker image to deploy. Defaults to the Filebeat image matching the Elasticsearch version.
+	Image string `json:"image,omitempty"`
+}
+
+// ShippingEnabled returns true if audit logs are shipped to a monitoring cluster.
+func (a *AuditLogging) ShippingEnabled() bool {
+	return a != nil && a.Shipping != nil && a.Shipping.ElasticsearchRef.IsDefined()
+}
+
+// PasswordRotationMaxAge returns the maximum age of the generated passwords, zero if not set.
+func (a Auth) PasswordRotationMaxAge() time.Duration {
+	if a.PasswordRotation == nil || a.PasswordRotation.MaxAge == nil {
+		return 0
+	}
+	return a.PasswordRotation.MaxAge.Duration
+}
+
+// SecureSettings returns the secure settings required by the realms, such as the OIDC client secrets.
+func (a Auth) SecureSettings() []commonv1.SecretSource {
+	secureSettings := make([]commonv1.SecretSource, 0, len(a.OIDC))
+	for _, realm := range a.OIDC {
+		secureSettings = append(secureSettings, commonv1.SecretSource{
+			SecretName: realm.ClientSecretKeyRef.Name,
+			Entries: []commonv1.KeyToPath{{
+				Key:  realm.ClientSecretKeyRef.Key,
+				Path: OIDCRealmSettingsPrefix(realm.Name) + "rp.client_secret",
+			}},
+		})
+	}
+	return secureSettings
 }
 
 // RoleSource references roles to create in the Elasticsearch cluster.
@@ -167,6 +878,94 @@ type FileRealmSource struct {
 	commonv1.SecretRef `json:",inline"`
 }
 
+// SAMLRealm describes a SAML realm to configure in the Elasticsearch cluster.
+// See https://www.elastic.co/guide/en/elasticsearch/reference/current/saml-guide.html.
+type SAMLRealm struct {
+	// Name of the realm, also used as the name of the matching Kibana auth provider.
+	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9_-]+$
+	Name string `json:"name"`
+	// IdPMetadata references the SAML metadata of the identity provider, mounted in the Elasticsearch Pods.
+	IdPMetadata RealmFileSource `json:"idpMetadata"`
+	// IdPEntityID is the identifier of the identity provider, as specified in its metadata.
+	IdPEntityID string `json:"idpEntityID"`
+	// SPEntityID is the identifier of the service provider, usually the public URL of Kibana.
+	SPEntityID string `json:"spEntityID"`
+	// SPACS is the URL of the assertion consumer service of Kibana, for example
+	// https://kibana.example.com/api/security/saml/callback.
+	SPACS string `json:"spACS"`
+	// SPLogout is the URL of the logout service of Kibana, for example https://kibana.example.com/logout.
+	SPLogout string `json:"spLogout,omitempty"`
+	// AttributesPrincipal is the SAML attribute holding the user principal. Defaults to "nameid:persistent".
+	AttributesPrincipal string `json:"attributesPrincipal,omitempty"`
+	// AttributesGroups is the SAML attribute holding the user groups, to be used in role mappings.
+	AttributesGroups string `json:"attributesGroups,omitempty"`
+	// Config holds additional realm settings, relative to the realm, which take precedence over the ones derived
+	// from the other fields. For example: `sp.signing.certificate`.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config *commonv1.Config `json:"config,omitempty"`
+}
+
+// DefaultRealmFileKey is the default key of a realm file in a Secret or ConfigMap.
+const DefaultRealmFileKey = "metadata.xml"
+
+// RealmFileSource references a file stored in a Secret or in a ConfigMap in the same namespace as the Elasticsearch
+// resource. Exactly one of SecretName and ConfigMapName must be set.
+type RealmFileSource struct {
+	// SecretName is the name of the Secret holding the file.
+	SecretName string `json:"secretName,omitempty"`
+	// ConfigMapName is the name of the ConfigMap holding the file.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Key of the file in the Secret or ConfigMap. Defaults to "metadata.xml".
+	Key string `json:"key,omitempty"`
+}
+
+// FileKey returns the key of the file in the Secret or ConfigMap.
+func (r RealmFileSource) FileKey() string {
+	if r.Key == "" {
+		return DefaultRealmFileKey
+	}
+	return r.Key
+}
+
+// OIDCRealm describes an OpenID Connect realm to configure in the Elasticsearch cluster.
+// See https://www.elastic.co/guide/en/elasticsearch/reference/current/oidc-guide.html.
+type OIDCRealm struct {
+	// Name of the realm, also used as the name of the matching Kibana auth provider.
+	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9_-]+$
+	Name string `json:"name"`
+	// ClientID is the client identifier of Elasticsearch, registered in the OpenID Connect provider.
+	ClientID string `json:"clientID"`
+	// ClientSecretKeyRef references the client secret in a Secret in the same namespace as the Elasticsearch
+	// resource. It is added to the Elasticsearch keystore.
+	ClientSecretKeyRef corev1.SecretKeySelector `json:"clientSecretKeyRef"`
+	// RedirectURI is the URL of the OpenID Connect callback of Kibana, for example
+	// https://kibana.example.com/api/security/oidc/callback.
+	RedirectURI string `json:"redirectURI"`
+	// PostLogoutRedirectURI is the URL Kibana is redirected to after logout, for example
+	// https://kibana.example.com/security/logged_out.
+	PostLogoutRedirectURI string `json:"postLogoutRedirectURI,omitempty"`
+	// Issuer is the identifier of the OpenID Connect provider.
+	Issuer string `json:"issuer"`
+	// AuthorizationEndpoint is the URL of the authorization endpoint of the OpenID Connect provider.
+	AuthorizationEndpoint string `json:"authorizationEndpoint"`
+	// TokenEndpoint is the URL of the token endpoint of the OpenID Connect provider.
+	TokenEndpoint string `json:"tokenEndpoint,omitempty"`
+	// UserinfoEndpoint is the URL of the user info endpoint of the OpenID Connect provider.
+	UserinfoEndpoint string `json:"userinfoEndpoint,omitempty"`
+	// EndSessionEndpoint is the URL of the end session endpoint of the OpenID Connect provider.
+	EndSessionEndpoint string `json:"endSessionEndpoint,omitempty"`
+	// JWKSetPath is the URL of the JSON Web Key Set of the OpenID Connect provider.
+	JWKSetPath string `json:"jwkSetPath"`
+	// ClaimsPrincipal is the claim holding the user principal. Defaults to "sub".
+	ClaimsPrincipal string `json:"claimsPrincipal,omitempty"`
+	// ClaimsGroups is the claim holding the user groups, to be used in role mappings.
+	ClaimsGroups string `json:"claimsGroups,omitempty"`
+	// Config holds additional realm settings, relative to the realm, which take precedence over the ones derived
+	// from the other fields. For example: `rp.requested_scopes`.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config *commonv1.Config `json:"config,omitempty"`
+}
+
 // NodeSet is the specification for a group of Elasticsearch nodes sharing the same configuration and a Pod template.
 type NodeSet struct {
 	// Name of this set of nodes. Becomes a part of the Elasticsearch node.name setting.
@@ -191,6 +990,204 @@ type NodeSet struct {
 	// See: https://www.elastic.co/guide/en/cloud-on-k8s/current/k8s-volume-claim-templates.html
 	// +kubebuilder:validation:Optional
 	VolumeClaimTemplates []corev1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+
+	// ZoneSpread expands this NodeSet into one StatefulSet per zone, each of them deploying Count nodes.
+	// +kubebuilder:validation:Optional
+	ZoneSpread *ZoneSpread `json:"zoneSpread,omitempty"`
+
+	// JVMOptions are JVM options, such as garbage collection settings or heap dump paths, written one per line to a
+	// fragment of the jvm.options.d directory of the nodes of this NodeSet. Changing them restarts the nodes of this
+	// NodeSet only. Requires Elasticsearch 7.7.0 or later.
+	// +kubebuilder:validation:Optional
+	JVMOptions []string `json:"jvmOptions,omitempty"`
+
+	// Architecture is the CPU architecture of the Kubernetes nodes the Pods of this NodeSet are scheduled on, for
+	// clusters mixing architectures or operating systems. The Pods are restricted to the Linux nodes of this
+	// architecture, and the default image is the one of the container registry of this architecture in the operator
+	// configuration. Requires Elasticsearch 7.8.0 or later for arm64, unless a custom image is set.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +kubebuilder:validation:Optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// Image is the Elasticsearch Docker image to deploy for this NodeSet, overriding the image of the cluster.
+	// The version of Elasticsearch it runs must match the version of the cluster.
+	// +kubebuilder:validation:Optional
+	Image string `json:"image,omitempty"`
+
+	// Diagnostics captures the heap dumps and thread dumps of the nodes of this NodeSet to a PersistentVolumeClaim, to
+	// investigate nodes running out of memory, crashing, or not responding anymore. Changing it restarts the nodes of
+	// this NodeSet. Requires Elasticsearch 7.7.0 or later.
+	// +kubebuilder:validation:Optional
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+
+	// Profile is the name of a NodeSet profile declared by the operator administrator, setting the resources of the
+	// Elasticsearch container, JVM options and storage of this NodeSet where left empty. The profile is expanded at
+	// each reconciliation: updating it updates the NodeSets referencing it.
+	// +kubebuilder:validation:Optional
+	Profile string `json:"profile,omitempty"`
+
+	// ScheduledScaling overrides the count and the resources of the Elasticsearch container of this NodeSet during
+	// recurring time windows, for workloads with predictable load patterns. Outside of the windows, the NodeSet runs
+	// the count and resources of its specification. Nodes removed at the start or end of a window have their data
+	// migrated away first, as with any other downscale.
+	// +kubebuilder:validation:Optional
+	ScheduledScaling []ScalingWindow `json:"scheduledScaling,omitempty"`
+
+	// ResourceDetection overrides the memory and processors Elasticsearch and the JVM detect for the nodes of this
+	// NodeSet, for container runtimes they do not detect correctly, such as cgroup v2 with older JVMs. Changing it
+	// restarts the nodes of this NodeSet. Requires Elasticsearch 7.7.0 or later.
+	// +kubebuilder:validation:Optional
+	ResourceDetection *ResourceDetection `json:"resourceDetection,omitempty"`
+
+	// Tier is the data tier of the nodes of this NodeSet, in a hot-warm-cold architecture. It sets the node.attr.data
+	// allocation attribute of the nodes to the tier name and, with Elasticsearch 7.10.0 or later, their node.roles to
+	// the data role of the tier, unless the roles or the attribute are set in the configuration. Requires
+	// Elasticsearch 7.12.0 or later for the frozen tier.
+	// +kubebuilder:validation:Enum=hot;warm;cold;frozen
+	// +kubebuilder:validation:Optional
+	Tier DataTier `json:"tier,omitempty"`
+
+	// RuntimeClassName is the name of the RuntimeClass of the Pods of this NodeSet, to run Elasticsearch in a sandboxed
+	// runtime such as gVisor or Kata Containers, or in confidential VMs. The pod overhead of the RuntimeClass is
+	// accounted for in the resource quotas. Changing it restarts the nodes of this NodeSet.
+	// +kubebuilder:validation:Optional
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+}
+
+// ResourceDetection overrides the resources detected by Elasticsearch and the JVM, which default to the limits of the
+// Elasticsearch container.
+type ResourceDetection struct {
+	// Memory is the memory available to the nodes, used by the JVM to size the default heap, and by Elasticsearch
+	// 7.16.0 or later to size the machine learning jobs. Defaults to the memory limit of the Elasticsearch container.
+	// +kubebuilder:validation:Optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// Processors is the number of processors available to the nodes, used by the JVM and Elasticsearch to size their
+	// thread pools. Defaults to the CPU limit of the Elasticsearch container, rounded up.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	Processors *int32 `json:"processors,omitempty"`
+}
+
+// MaxScalingWindowDuration is the longest duration of a scheduled scaling window.
+const MaxScalingWindowDuration = 7 * 24 * time.Hour
+
+// AutoscalingSpecAnnotation is the annotation declaring the autoscaling policies of an Elasticsearch resource, whose
+// NodeSets count and resources are then adjusted by an autoscaling controller. It cannot be combined with scheduled
+// scaling, which would override the capacity set by the autoscaler.
+const AutoscalingSpecAnnotation = "elasticsearch.alpha.elastic.co/autoscaling-spec"
+
+// ScalingWindow is a recurring time window during which a NodeSet runs a different capacity.
+type ScalingWindow struct {
+	// Name of the window, unique in the NodeSet.
+	Name string `json:"name"`
+	// Schedule is the cron expression of the start of the window, with the 5 fields minute, hour, day of month, month
+	// and day of week, evaluated in UTC. For example "0 8 * * 1-5" starts the window at 8:00 on week days.
+	Schedule string `json:"schedule"`
+	// Duration of the window from each of its starts, at most 168h. The first window listed applies if several
+	// windows overlap.
+	Duration metav1.Duration `json:"duration"`
+	// Count of Elasticsearch nodes to deploy during the window. Defaults to the count of the NodeSet.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	Count *int32 `json:"count,omitempty"`
+	// Resources of the Elasticsearch container during the window, replacing the resources of the Pod template.
+	// Changing the resources restarts the nodes of the NodeSet, one at a time.
+	// +kubebuilder:validation:Optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Diagnostics specifies the capture of the heap dumps and thread dumps of the nodes of a NodeSet.
+type Diagnostics struct {
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim the dumps are written to, in a directory per
+	// Pod. The claim must support the ReadWriteMany access mode for the Pods of the NodeSet to be scheduled on
+	// different Kubernetes nodes, and be writable by the Elasticsearch user.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+	// ThreadDumpAfterFailures captures a thread dump of a node once its readiness probe fails the given number of
+	// consecutive times. Disabled if 0, the default. Heap dumps and fatal error logs are always captured.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	ThreadDumpAfterFailures int32 `json:"threadDumpAfterFailures,omitempty"`
+}
+
+// RuntimeClass returns the name of the RuntimeClass of the Pods of the NodeSet, set in the NodeSet or in its Pod
+// template, or empty for the default container runtime.
+func (n NodeSet) RuntimeClass() string {
+	if n.RuntimeClassName != "" {
+		return n.RuntimeClassName
+	}
+	if n.PodTemplate.Spec.RuntimeClassName != nil {
+		return *n.PodTemplate.Spec.RuntimeClassName
+	}
+	return ""
+}
+
+// ImageOrDefault returns the custom image of the NodeSet, or else the given custom image of the cluster.
+func (n NodeSet) ImageOrDefault(clusterImage string) string {
+	if n.Image == "" {
+		return clusterImage
+	}
+	return n.Image
+}
+
+// DefaultZoneTopologyKey is the label of the Kubernetes nodes holding their zone.
+const DefaultZoneTopologyKey = "topology.kubernetes.io/zone"
+
+// ZoneSpread specifies the zones across which the nodes of a NodeSet are spread.
+type ZoneSpread struct {
+	// Zones across which the nodes are spread. The StatefulSet of each zone is named after the NodeSet and the zone,
+	// its Pods are scheduled on the Kubernetes nodes of the zone, and its Elasticsearch nodes are given the zone
+	// attribute used for shard allocation awareness.
+	// +kubebuilder:validation:MinItems=1
+	Zones []string `json:"zones"`
+	// TopologyKey is the label of the Kubernetes nodes holding their zone. Defaults to topology.kubernetes.io/zone.
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// TopologyKeyOrDefault returns the label of the Kubernetes nodes holding their zone.
+func (z ZoneSpread) TopologyKeyOrDefault() string {
+	if z.TopologyKey == "" {
+		return DefaultZoneTopologyKey
+	}
+	return z.TopologyKey
+}
+
+// TotalCount returns the number of Elasticsearch nodes of the NodeSet, across all its zones.
+func (n NodeSet) TotalCount() int32 {
+	if n.ZoneSpread == nil {
+		return n.Count
+	}
+	return n.Count * int32(len(n.ZoneSpread.Zones))
+}
+
+// ZoneNodeSetName returns the name of the NodeSet expanded from this NodeSet for the given zone.
+func (n NodeSet) ZoneNodeSetName(zone string) string {
+	return n.Name + "-" + zone
+}
+
+// ExpandedNames returns the names of the NodeSets this NodeSet expands into: one per zone if the NodeSet is spread
+// across zones, its own name otherwise.
+func (n NodeSet) ExpandedNames() []string {
+	if n.ZoneSpread == nil {
+		return []string{n.Name}
+	}
+	names := make([]string, 0, len(n.ZoneSpread.Zones))
+	for _, zone := range n.ZoneSpread.Zones {
+		names = append(names, n.ZoneNodeSetName(zone))
+	}
+	return names
+}
+
+// ForZone returns the NodeSet expanded from this NodeSet for the given zone: its Pods are restricted to the
+// Kubernetes nodes of the zone.
+func (n NodeSet) ForZone(zone string) NodeSet {
+	zoned := *n.DeepCopy()
+	zoned.Name = n.ZoneNodeSetName(zone)
+	zoned.ZoneSpread = nil
+	if zoned.PodTemplate.Spec.NodeSelector == nil {
+		zoned.PodTemplate.Spec.NodeSelector = map[string]string{}
+	}
+	zoned.PodTemplate.Spec.NodeSelector[n.ZoneSpread.TopologyKeyOrDefault()] = zone
+	return zoned
 }
 
 // GetESContainerTemplate returns the Elasticsearch container (if set) from the NodeSet's PodTemplate
@@ -207,6 +1204,135 @@ func (n NodeSet) GetESContainerTemplate() *corev1.Container {
 type UpdateStrategy struct {
 	// ChangeBudget defines the constraints to consider when applying changes to the Elasticsearch cluster.
 	ChangeBudget ChangeBudget `json:"changeBudget,omitempty"`
+	// DataMigration bounds the duration of the data migration away from the nodes being removed. The operator waits
+	// for the data migration to complete before removing the nodes if not set.
+	DataMigration *DataMigrationPolicy `json:"dataMigration,omitempty"`
+	// IndexingTasks defines how the transforms and rollup jobs running on the nodes to restart are handled:
+	// Ignore (default) restarts the nodes regardless of them, WaitForCheckpoint delays the restart of a node until
+	// the tasks it runs complete their current checkpoint, Stop additionally stops the tasks gracefully before
+	// the restart of the nodes running them, and starts them again once all the nodes are upgraded.
+	// +kubebuilder:validation:Optional
+	IndexingTasks IndexingTasksStrategy `json:"indexingTasks,omitempty"`
+	// CacheWarmup defines searches run once the data is migrated away from the data nodes being removed, before
+	// removing them, to warm up the caches of the nodes the data was migrated to, such as the shared cache of the
+	// searchable snapshots.
+	CacheWarmup *CacheWarmup `json:"cacheWarmup,omitempty"`
+	// SnapshotBeforeUpgrade requires a recent successful snapshot of the cluster before upgrading it to a new version.
+	// The operator takes a snapshot if the most recent one is too old, and delays the upgrade until it succeeds.
+	SnapshotBeforeUpgrade *SnapshotBeforeUpgradePolicy `json:"snapshotBeforeUpgrade,omitempty"`
+}
+
+// DefaultCacheWarmupTimeout is the default maximum duration of the cache warm-up searches.
+const DefaultCacheWarmupTimeout = time.Minute
+
+// CacheWarmup defines searches warming up the caches of the nodes the data is migrated to, before removing the nodes
+// the data was migrated away from.
+type CacheWarmup struct {
+	// Searches run against the cluster, in order.
+	// +kubebuilder:validation:MinItems=1
+	Searches []WarmupSearch `json:"searches"`
+	// Timeout is the maximum duration of each search, such as 5m. Defaults to 1m.
+	// +kubebuilder:validation:Optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TimeoutOrDefault returns the maximum duration of each warm-up search.
+func (c CacheWarmup) TimeoutOrDefault() time.Duration {
+	if c.Timeout == nil {
+		return DefaultCacheWarmupTimeout
+	}
+	return c.Timeout.Duration
+}
+
+// WarmupSearch is a search warming up the caches of the nodes holding the searched indices.
+type WarmupSearch struct {
+	// Indices are the names or wildcard patterns of the indices searched.
+	// +kubebuilder:validation:MinItems=1
+	Indices []string `json:"indices"`
+	// Body of the search request, as in the body of the Elasticsearch search API. Searches all the documents if
+	// not set.
+	// +kubebuilder:validation:Optional
+	Body *commonv1.Config `json:"body,omitempty"`
+}
+
+// IndexingTasksStrategy defines how the transforms and rollup jobs running on the nodes to restart are handled.
+// +kubebuilder:validation:Enum=Ignore;WaitForCheckpoint;Stop
+type IndexingTasksStrategy string
+
+const (
+	// IgnoreIndexingTasks restarts the nodes regardless of the transforms and rollup jobs they run.
+	IgnoreIndexingTasks IndexingTasksStrategy = "Ignore"
+	// WaitForIndexingTasksCheckpoint delays the restart of a node until the transforms and rollup jobs it runs
+	// complete their current checkpoint.
+	WaitForIndexingTasksCheckpoint IndexingTasksStrategy = "WaitForCheckpoint"
+	// StopIndexingTasks stops the transforms and rollup jobs before the restart of the nodes running them, and starts
+	// them again once all the nodes are upgraded.
+	StopIndexingTasks IndexingTasksStrategy = "Stop"
+)
+
+// IndexingTasksOrDefault returns the strategy for the transforms and rollup jobs, or the default one if not set.
+func (s UpdateStrategy) IndexingTasksOrDefault() IndexingTasksStrategy {
+	if s.IndexingTasks == "" {
+		return IgnoreIndexingTasks
+	}
+	return s.IndexingTasks
+}
+
+// DataMigrationTimeoutAction is the action taken when the data migration away from a node being removed times out.
+// +kubebuilder:validation:Enum=Abort;Proceed
+type DataMigrationTimeoutAction string
+
+const (
+	// AbortDataMigration keeps the node in the cluster, and allocates shards to it again.
+	AbortDataMigration DataMigrationTimeoutAction = "Abort"
+	// ProceedWithDataMigration removes the node regardless of the shards still allocated to it, which may lose data.
+	ProceedWithDataMigration DataMigrationTimeoutAction = "Proceed"
+)
+
+// DataMigrationPolicy bounds the duration of the data migration away from the nodes being removed.
+type DataMigrationPolicy struct {
+	// Timeout is the maximum duration of the data migration away from a node being removed, such as 2h.
+	Timeout metav1.Duration `json:"timeout"`
+	// OnTimeout is the action taken when the data migration times out: Abort keeps the node in the cluster and
+	// allocates shards to it again, until the specification of the cluster changes. Proceed removes the node
+	// regardless of the shards still allocated to it, which may lose data. Defaults to Abort.
+	// +kubebuilder:validation:Optional
+	OnTimeout DataMigrationTimeoutAction `json:"onTimeout,omitempty"`
+}
+
+// TimeoutAction returns the action taken when the data migration times out.
+func (p DataMigrationPolicy) TimeoutAction() DataMigrationTimeoutAction {
+	if p.OnTimeout == "" {
+		return AbortDataMigration
+	}
+	return p.OnTimeout
+}
+
+// DefaultSnapshotMaxAge is the default maximum age of the snapshot required before a version upgrade.
+const DefaultSnapshotMaxAge = 24 * time.Hour
+
+// SnapshotBeforeUpgradePolicy requires a recent successful snapshot of the cluster before a version upgrade.
+type SnapshotBeforeUpgradePolicy struct {
+	// Repository is the name of the snapshot repository registered in the cluster holding the snapshots.
+	// +kubebuilder:validation:MinLength=1
+	Repository string `json:"repository"`
+	// MaxAge is the maximum age of the most recent successful snapshot in the repository, such as 6h.
+	// Defaults to 24h.
+	// +kubebuilder:validation:Optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+	// SLMPolicy is the snapshot lifecycle management policy executed to take the snapshot if the most recent one is
+	// too old, rather than an ad-hoc snapshot of all the indices and the cluster state. The policy must write to the
+	// repository above. Requires Elasticsearch 7.4.0 or later.
+	// +kubebuilder:validation:Optional
+	SLMPolicy string `json:"slmPolicy,omitempty"`
+}
+
+// MaxAgeOrDefault returns the maximum age of the most recent successful snapshot.
+func (p SnapshotBeforeUpgradePolicy) MaxAgeOrDefault() time.Duration {
+	if p.MaxAge == nil {
+		return DefaultSnapshotMaxAge
+	}
+	return p.MaxAge.Duration
 }
 
 // ChangeBudget defines the constraints to consider when applying changes to the Elasticsearch cluster.
@@ -305,6 +1431,84 @@ type ElasticsearchStatus struct {
 	commonv1.ReconcilerStatus `json:",inline"`
 	Health                    ElasticsearchHealth             `json:"health,omitempty"`
 	Phase                     ElasticsearchOrchestrationPhase `json:"phase,omitempty"`
+	// AuditAssociationStatus is the status of the association with the cluster audit logs are shipped to.
+	AuditAssociationStatus commonv1.AssociationStatus `json:"auditAssociationStatus,omitempty"`
+	// ImageDigests are the digests the images of the nodes are pinned to, when the operator resolves image tags to
+	// digests. The tag of an image is resolved once, when its reference first appears.
+	ImageDigests []ImageDigest `json:"imageDigests,omitempty"`
+	// Logging reports the logging settings applied at runtime, if any.
+	Logging *LoggingStatus `json:"logging,omitempty"`
+	// RuntimeSettings are the names of the cluster settings applied from the runtime configuration.
+	RuntimeSettings []string `json:"runtimeSettings,omitempty"`
+	// ReadOnlyIndices is the number of indices made read-only by the flood-stage disk watermark, as of the last
+	// observation of the cluster.
+	ReadOnlyIndices int `json:"readOnlyIndices,omitempty"`
+	// DataMigration reports the progress of the data migration away from the nodes being removed from the cluster.
+	DataMigration []NodeDataMigration `json:"dataMigration,omitempty"`
+	// SuspendedPods reports the Pods held in an init container before Elasticsearch starts.
+	SuspendedPods []SuspendedPodStatus `json:"suspendedPods,omitempty"`
+	// RetentionJobs reports the last run of the retention jobs.
+	RetentionJobs []RetentionJobStatus `json:"retentionJobs,omitempty"`
+	// Conditions report the state of the operations performed on the cluster, such as its adoption.
+	Conditions commonv1.Conditions `json:"conditions,omitempty"`
+}
+
+// ImageDigest is the digest an image reference is pinned to.
+type ImageDigest struct {
+	// Image is the reference of the image, by tag.
+	Image string `json:"image"`
+	// Digest of the manifest of the image when its tag was resolved.
+	Digest string `json:"digest"`
+}
+
+// NodeDataMigration is the progress of the data migration away from a node being removed from the cluster.
+type NodeDataMigration struct {
+	// Node is the name of the node being removed.
+	Node string `json:"node"`
+	// RemainingShards is the number of shards still allocated to the node.
+	RemainingShards int `json:"remainingShards"`
+	// RemainingBytes is the size of the shards still allocated to the node.
+	RemainingBytes int64 `json:"remainingBytes"`
+	// InitialBytes is the size of the shards allocated to the node when the data migration started, or the highest
+	// size observed since if the shards grew.
+	InitialBytes int64 `json:"initialBytes"`
+	// StartTime is the time the data migration started.
+	StartTime metav1.Time `json:"startTime"`
+	// LastProgressTime is the last time a shard moved away from the node, or the time the data migration started.
+	LastProgressTime metav1.Time `json:"lastProgressTime"`
+	// EstimatedCompletionTime extrapolates the average migration rate since the start of the data migration. It is
+	// empty until some data has moved away from the node.
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+	// TimeoutAction is the action taken when the data migration timed out, if it did.
+	TimeoutAction DataMigrationTimeoutAction `json:"timeoutAction,omitempty"`
+	// TimeoutGeneration is the generation of the Elasticsearch resource the data migration timed out for. An aborted
+	// data migration starts again once the generation changes.
+	TimeoutGeneration int64 `json:"timeoutGeneration,omitempty"`
+}
+
+// SuspendedPodStatus reports the state of a Pod to suspend.
+type SuspendedPodStatus struct {
+	// Name of the Pod.
+	Name string `json:"name"`
+	// Reason for suspending the Pod.
+	Reason string `json:"reason,omitempty"`
+	// Suspended is true if the Pod is held in the suspend init container, false while it restarts or if it does
+	// not exist.
+	Suspended bool `json:"suspended"`
+}
+
+// RetentionJobStatus reports the last run of a retention job.
+type RetentionJobStatus struct {
+	// Name of the job.
+	Name string `json:"name"`
+	// LastRunTime is the time the job last ran.
+	LastRunTime metav1.Time `json:"lastRunTime"`
+	// DeletedIndices is the number of indices deleted by the last run.
+	DeletedIndices int `json:"deletedIndices,omitempty"`
+	// ForceMergedIndices is the number of indices force-merged by the last run.
+	ForceMergedIndices int `json:"forceMergedIndices,omitempty"`
+	// Error reports why the last run failed.
+	Error string `json:"error,omitempty"`
 }
 
 type ZenDiscoveryStatus struct {
@@ -333,6 +1537,8 @@ type Elasticsearch struct {
 
 	Spec   ElasticsearchSpec   `json:"spec,omitempty"`
 	Status ElasticsearchStatus `json:"status,omitempty"`
+	// assocConf is the configuration of the association with the monitoring cluster receiving the audit logs
+	assocConf *commonv1.AssociationConf `json:"-"` //nolint:govet
 }
 
 // IsMarkedForDeletion returns true if the Elasticsearch is going to be deleted
@@ -340,8 +1546,34 @@ func (es Elasticsearch) IsMarkedForDeletion() bool {
 	return !es.DeletionTimestamp.IsZero()
 }
 
+// ElasticsearchRef returns the reference to the monitoring cluster receiving the audit logs, if any.
+func (es *Elasticsearch) ElasticsearchRef() commonv1.ObjectSelector {
+	if !es.Spec.Audit.ShippingEnabled() {
+		return commonv1.ObjectSelector{}
+	}
+	return es.Spec.Audit.Shipping.ElasticsearchRef
+}
+
+func (es *Elasticsearch) ServiceAccountName() string {
+	return es.Spec.ServiceAccountName
+}
+
+// AssociationConf returns the configuration of the association with the monitoring cluster receiving the audit logs.
+func (es *Elasticsearch) AssociationConf() *commonv1.AssociationConf {
+	return es.assocConf
+}
+
+func (es *Elasticsearch) SetAssociationConf(assocConf *commonv1.AssociationConf) {
+	es.assocConf = assocConf
+}
+
 func (es Elasticsearch) SecureSettings() []commonv1.SecretSource {
-	return es.Spec.SecureSettings
+	realmSecureSettings := es.Spec.Auth.SecureSettings()
+	if len(realmSecureSettings) == 0 {
+		return es.Spec.SecureSettings
+	}
+	secureSettings := make([]commonv1.SecretSource, 0, len(es.Spec.SecureSettings)+len(realmSecureSettings))
+	return append(append(secureSettings, es.Spec.SecureSettings...), realmSecureSettings...)
 }
 
 // +kubebuilder:object:root=true
@@ -11,10 +11,12 @@ import (
 	"testing"
 	"time"
 
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/pointer"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -212,3 +214,101 @@ func Test_GetMaxUnavailableOrDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestElasticsearch_SecureSettings(t *testing.T) {
+	es := Elasticsearch{Spec: ElasticsearchSpec{
+		SecureSettings: []commonv1.SecretSource{{SecretName: "user-secure-settings"}},
+		Auth: Auth{OIDC: []OIDCRealm{{
+			Name: "oidc1",
+			ClientSecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "oidc-client"},
+				Key:                  "secret",
+			},
+		}}},
+	}}
+	require.Equal(t, []commonv1.SecretSource{
+		{SecretName: "user-secure-settings"},
+		{SecretName: "oidc-client", Entries: []commonv1.KeyToPath{
+			{Key: "secret", Path: "xpack.security.authc.realms.oidc.oidc1.rp.client_secret"},
+		}},
+	}, es.SecureSettings())
+	// the spec is left untouched
+	require.Len(t, es.Spec.SecureSettings, 1)
+}
+
+func TestNodeSet_ForZone(t *testing.T) {
+	nodeSet := NodeSet{
+		Name:  "data",
+		Count: 2,
+		PodTemplate: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{NodeSelector: map[string]string{"disktype": "ssd"}},
+		},
+		ZoneSpread: &ZoneSpread{Zones: []string{"a", "b", "c"}},
+	}
+	require.Equal(t, int32(6), nodeSet.TotalCount())
+	require.Equal(t, []string{"data-a", "data-b", "data-c"}, nodeSet.ExpandedNames())
+	require.Equal(t, int32(6), ElasticsearchSpec{NodeSets: []NodeSet{nodeSet}}.NodeCount())
+
+	zoned := nodeSet.ForZone("b")
+	require.Equal(t, "data-b", zoned.Name)
+	require.Equal(t, int32(2), zoned.Count)
+	require.Nil(t, zoned.ZoneSpread)
+	require.Equal(t, map[string]string{"disktype": "ssd", DefaultZoneTopologyKey: "b"}, zoned.PodTemplate.Spec.NodeSelector)
+	// the original NodeSet is left untouched
+	require.Equal(t, map[string]string{"disktype": "ssd"}, nodeSet.PodTemplate.Spec.NodeSelector)
+
+	nodeSet.ZoneSpread.TopologyKey = "failure-domain.beta.kubernetes.io/zone"
+	require.Equal(t, "a", nodeSet.ForZone("a").PodTemplate.Spec.NodeSelector["failure-domain.beta.kubernetes.io/zone"])
+
+	// a NodeSet not spread across zones is not expanded
+	nodeSet.ZoneSpread = nil
+	require.Equal(t, int32(2), nodeSet.TotalCount())
+	require.Equal(t, []string{"data"}, nodeSet.ExpandedNames())
+}
+
+func TestElasticsearchSpec_EffectiveHTTP(t *testing.T) {
+	sans := []commonv1.SubjectAlternativeName{{DNS: "es.example.com"}}
+	spec := ElasticsearchSpec{HTTP: commonv1.HTTPConfig{TLS: commonv1.TLSOptions{
+		SelfSignedCertificate: &commonv1.SelfSignedCertificate{SubjectAlternativeNames: sans},
+	}}}
+	// no service mesh: the settings of the specification apply
+	require.Equal(t, "https", spec.EffectiveHTTP().Protocol())
+
+	// mTLS not delegated to the mesh
+	mtls := false
+	spec.ServiceMesh = &ServiceMesh{Provider: IstioServiceMesh, MTLS: &mtls}
+	require.Equal(t, "https", spec.EffectiveHTTP().Protocol())
+
+	// mTLS delegated to the mesh: TLS is disabled
+	spec.ServiceMesh.MTLS = nil
+	httpCfg := spec.EffectiveHTTP()
+	require.Equal(t, "http", httpCfg.Protocol())
+	require.Equal(t, sans, httpCfg.TLS.SelfSignedCertificate.SubjectAlternativeNames)
+	// the spec is left untouched
+	require.False(t, spec.HTTP.TLS.SelfSignedCertificate.Disabled)
+}
+
+func TestSlowLogThresholds_IndexSetting(t *testing.T) {
+	var thresholds SlowLogThresholds
+	require.Equal(t, "index.search.slowlog.threshold.query.warn", thresholds.IndexSetting("search.query.warn"))
+	require.Equal(t, "index.search.slowlog.threshold.fetch.info", thresholds.IndexSetting("search.fetch.info"))
+	require.Equal(t, "index.indexing.slowlog.threshold.index.trace", thresholds.IndexSetting("indexing.index.trace"))
+}
+
+func TestElasticsearch_ClassPending(t *testing.T) {
+	es := Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       ElasticsearchSpec{ClassName: "production"},
+	}
+	// the specification is only validated once the class is applied
+	require.True(t, es.ClassPending())
+	require.NoError(t, es.ValidateCreate())
+
+	es.Annotations = map[string]string{ClassAppliedAnnotation: "production"}
+	require.False(t, es.ClassPending())
+	require.Error(t, es.ValidateCreate())
+
+	// another class is applied to a complete specification
+	es.Spec.ClassName = "development"
+	require.False(t, es.ClassPending())
+}
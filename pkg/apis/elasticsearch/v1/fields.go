@@ -16,7 +16,16 @@ const (
 	NetworkHost        = "network.host"
 	NetworkPublishHost = "network.publish_host"
 
-	NodeName = "node.name"
+	NodeName       = "node.name"
+	NodeProcessors = "node.processors" // >= 7.4.0
+	NodeRoles      = "node.roles"      // >= 7.9.0
+
+	NodeAttrData                                = "node.attr.data"
+	NodeAttrZone                                = "node.attr.zone"
+	ClusterRoutingAllocationAwarenessAttributes = "cluster.routing.allocation.awareness.attributes"
+
+	IngestGeoIPDownloaderEnabled  = "ingest.geoip.downloader.enabled"  // >= 7.14.0
+	IngestGeoIPDownloaderEndpoint = "ingest.geoip.downloader.endpoint" // >= 7.14.0
 
 	PathData = "path.data"
 	PathLogs = "path.logs"
@@ -28,7 +37,14 @@ const (
 	XPackSecurityAuthcRealmsNative1Order       = "xpack.security.authc.realms.native1.order"        // 6.x realm syntax
 	XPackSecurityAuthcRealmsNative1Type        = "xpack.security.authc.realms.native1.type"         // 6.x realm syntax
 
+	XPackSecurityAuditEnabled                       = "xpack.security.audit.enabled"
+	XPackSecurityAuditLogfileEventsEmitRequestBody  = "xpack.security.audit.logfile.events.emit_request_body"
+	XPackSecurityAuditLogfileEventsExclude          = "xpack.security.audit.logfile.events.exclude"
+	XPackSecurityAuditLogfileEventsIgnoreFilters    = "xpack.security.audit.logfile.events.ignore_filters"
+	XPackSecurityAuditLogfileEventsInclude          = "xpack.security.audit.logfile.events.include"
+	XPackSecurityAuthcRealms                        = "xpack.security.authc.realms"
 	XPackSecurityAuthcReservedRealmEnabled          = "xpack.security.authc.reserved_realm.enabled"
+	XPackSecurityAuthcTokenEnabled                  = "xpack.security.authc.token.enabled"
 	XPackSecurityEnabled                            = "xpack.security.enabled"
 	XPackSecurityHttpSslCertificate                 = "xpack.security.http.ssl.certificate"
 	XPackSecurityHttpSslCertificateAuthorities      = "xpack.security.http.ssl.certificate_authorities"
@@ -41,8 +57,27 @@ const (
 	XPackSecurityTransportSslVerificationMode       = "xpack.security.transport.ssl.verification_mode"
 
 	XPackLicenseUploadTypes = "xpack.license.upload.types" // >= 7.6.0
+
+	XPackSearchableSnapshotSharedCacheSize = "xpack.searchable.snapshot.shared_cache.size" // >= 7.12.0
+
+	RemoteClusterServerEnabled                                = "remote_cluster_server.enabled"                                    // >= 8.10.0
+	XPackSecurityRemoteClusterServerSslCertificate            = "xpack.security.remote_cluster_server.ssl.certificate"             // >= 8.10.0
+	XPackSecurityRemoteClusterServerSslKey                    = "xpack.security.remote_cluster_server.ssl.key"                     // >= 8.10.0
+	XPackSecurityRemoteClusterClientSslEnabled                = "xpack.security.remote_cluster_client.ssl.enabled"                 // >= 8.10.0
+	XPackSecurityRemoteClusterClientSslCertificateAuthorities = "xpack.security.remote_cluster_client.ssl.certificate_authorities" // >= 8.10.0
+	XPackSecurityRemoteClusterClientSslVerificationMode       = "xpack.security.remote_cluster_client.ssl.verification_mode"       // >= 8.10.0
 )
 
+// SAMLRealmSettingsPrefix returns the prefix of the settings of the SAML realm with the given name (7.x realm syntax).
+func SAMLRealmSettingsPrefix(name string) string {
+	return XPackSecurityAuthcRealms + ".saml." + name + "."
+}
+
+// OIDCRealmSettingsPrefix returns the prefix of the settings of the OIDC realm with the given name.
+func OIDCRealmSettingsPrefix(name string) string {
+	return XPackSecurityAuthcRealms + ".oidc." + name + "."
+}
+
 var UnsupportedSettings = []string{
 	ClusterName,
 	DiscoveryZenMinimumMasterNodes,
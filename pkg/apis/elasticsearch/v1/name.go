@@ -8,9 +8,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
 	common_name "github.com/elastic/cloud-on-k8s/pkg/controller/common/name"
 	"github.com/pkg/errors"
 	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/types"
 	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -26,6 +28,10 @@ const (
 	defaultPodDisruptionBudget        = "default"
 	scriptsConfigMapSuffix            = "scripts"
 	transportCertificatesSecretSuffix = "transport-certificates"
+	auditBeatConfigSecretSuffix       = "audit-beat-config"
+	diagnosticsSecretSuffix           = "diagnostics"
+	metadataBackupSecretSuffix        = "metadata-backup"
+	renameSecretSuffix                = "rename"
 
 	// calling this secret "xpack-file-realm" is conceptually wrong since it also holds the file-based roles which
 	// are not part of the file realm - let's still keep this legacy name for convenience
@@ -54,6 +60,10 @@ var (
 		scriptsConfigMapSuffix,
 		transportCertificatesSecretSuffix,
 		remoteCaNameSuffix,
+		auditBeatConfigSecretSuffix,
+		diagnosticsSecretSuffix,
+		metadataBackupSecretSuffix,
+		renameSecretSuffix,
 	}
 )
 
@@ -63,6 +73,7 @@ func validateNames(es *Elasticsearch) error {
 		return errors.Errorf("name exceeds maximum allowed length of %d", common_name.MaxResourceNameLength)
 	}
 	nodeSetNames := map[string]struct{}{}
+	expandedNames := map[string]struct{}{}
 	// validate ssets
 	for _, nodeSet := range es.Spec.NodeSets {
 		if _, ok := nodeSetNames[nodeSet.Name]; ok {
@@ -70,22 +81,30 @@ func validateNames(es *Elasticsearch) error {
 		}
 		nodeSetNames[nodeSet.Name] = struct{}{}
 
-		if errs := apimachineryvalidation.NameIsDNSSubdomain(nodeSet.Name, false); len(errs) > 0 {
-			return errors.Errorf("invalid nodeSet name '%s': [%s]", nodeSet.Name, strings.Join(errs, ","))
-		}
-
-		ssetName, err := ESNamer.SafeSuffix(es.Name, nodeSet.Name)
-		if err != nil {
-			return errors.Wrapf(err, "error generating StatefulSet name for nodeSet: '%s'", nodeSet.Name)
-		}
-
-		// length of the ordinal suffix that will be added to the pods of this sset (dash + ordinal)
-		podOrdinalSuffixLen := len(strconv.FormatInt(int64(nodeSet.Count), 10)) + 1
-		// there should be enough space for the ordinal suffix and the controller revision hash
-		if utilvalidation.LabelValueMaxLength-len(ssetName) < podOrdinalSuffixLen+controllerRevisionHashLen {
-			return errors.Errorf("generated StatefulSet name '%s' exceeds allowed length of %d",
-				ssetName,
-				utilvalidation.LabelValueMaxLength-podOrdinalSuffixLen-controllerRevisionHashLen)
+		// a NodeSet spread across zones results in one StatefulSet per zone
+		for _, name := range nodeSet.ExpandedNames() {
+			if _, ok := expandedNames[name]; ok {
+				return errors.Errorf("duplicated nodeSet name: '%s'", name)
+			}
+			expandedNames[name] = struct{}{}
+
+			if errs := apimachineryvalidation.NameIsDNSSubdomain(name, false); len(errs) > 0 {
+				return errors.Errorf("invalid nodeSet name '%s': [%s]", name, strings.Join(errs, ","))
+			}
+
+			ssetName, err := ESNamer.SafeSuffix(es.Name, name)
+			if err != nil {
+				return errors.Wrapf(err, "error generating StatefulSet name for nodeSet: '%s'", name)
+			}
+
+			// length of the ordinal suffix that will be added to the pods of this sset (dash + ordinal)
+			podOrdinalSuffixLen := len(strconv.FormatInt(int64(nodeSet.Count), 10)) + 1
+			// there should be enough space for the ordinal suffix and the controller revision hash
+			if utilvalidation.LabelValueMaxLength-len(ssetName) < podOrdinalSuffixLen+controllerRevisionHashLen {
+				return errors.Errorf("generated StatefulSet name '%s' exceeds allowed length of %d",
+					ssetName,
+					utilvalidation.LabelValueMaxLength-podOrdinalSuffixLen-controllerRevisionHashLen)
+			}
 		}
 	}
 
@@ -156,3 +175,26 @@ func DefaultPodDisruptionBudget(esName string) string {
 func RemoteCaSecretName(esName string) string {
 	return ESNamer.Suffix(esName, remoteCaNameSuffix)
 }
+
+// AuditBeatConfigSecret returns the name of the Secret holding the configuration of the audit logs shipping sidecar.
+func AuditBeatConfigSecret(esName string) string {
+	return ESNamer.Suffix(esName, auditBeatConfigSecretSuffix)
+}
+
+// DiagnosticsSecret returns the name of the Secret holding the diagnostics bundle of the given cluster.
+func DiagnosticsSecret(esName string) string {
+	return ESNamer.Suffix(esName, diagnosticsSecretSuffix)
+}
+
+// MetadataBackupSecret returns the name of the Secret holding the backup of the metadata of the given cluster, taken
+// before its last destructive operation.
+func MetadataBackupSecret(esName string) string {
+	return ESNamer.Suffix(esName, metadataBackupSecretSuffix)
+}
+
+// RenameSecret returns the name of the Secret of the operator namespace handing over the volumes and credentials of a
+// renamed cluster to the given cluster. The name includes a hash of the namespace of the cluster, so that the clusters
+// with the same name in different namespaces do not share the same Secret.
+func RenameSecret(es types.NamespacedName) string {
+	return ESNamer.Suffix(es.Name, renameSecretSuffix, hash.HashObject(es.Namespace))
+}
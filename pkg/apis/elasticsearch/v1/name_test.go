@@ -79,3 +79,63 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateNames_ZoneSpread(t *testing.T) {
+	testCases := []struct {
+		name       string
+		nodeSets   []NodeSet
+		wantErrMsg string
+	}{
+		{
+			name: "valid zone names",
+			nodeSets: []NodeSet{
+				{Name: "default", Count: 1, ZoneSpread: &ZoneSpread{Zones: []string{"europe-west1-b", "europe-west1-c"}}},
+			},
+		},
+		{
+			name: "duplicated zone",
+			nodeSets: []NodeSet{
+				{Name: "default", Count: 1, ZoneSpread: &ZoneSpread{Zones: []string{"a", "a"}}},
+			},
+			wantErrMsg: "duplicated nodeSet name: 'default-a'",
+		},
+		{
+			name: "expanded name conflicting with another nodeSet",
+			nodeSets: []NodeSet{
+				{Name: "default", Count: 1, ZoneSpread: &ZoneSpread{Zones: []string{"a", "b"}}},
+				{Name: "default-b", Count: 1},
+			},
+			wantErrMsg: "duplicated nodeSet name: 'default-b'",
+		},
+		{
+			name: "invalid characters in zone",
+			nodeSets: []NodeSet{
+				{Name: "default", Count: 1, ZoneSpread: &ZoneSpread{Zones: []string{"zone_a"}}},
+			},
+			wantErrMsg: "invalid nodeSet name 'default-zone_a'",
+		},
+		{
+			name: "long zone name",
+			nodeSets: []NodeSet{
+				{Name: "default", Count: 1, ZoneSpread: &ZoneSpread{Zones: []string{"extremely-long-zone-name-for-no-particular-reason"}}},
+			},
+			wantErrMsg: "suffix exceeds max length",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			es := &Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-es", Namespace: "test"},
+				Spec:       ElasticsearchSpec{NodeSets: tc.nodeSets},
+			}
+			err := validateNames(es)
+			if tc.wantErrMsg != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErrMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
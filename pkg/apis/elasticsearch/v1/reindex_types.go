@@ -0,0 +1,158 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+)
+
+// DefaultReindexMaxRetries is the default number of times a failed reindex operation is started again.
+const DefaultReindexMaxRetries = 3
+
+// ReindexJobSpec defines the documents to copy and how they are copied.
+type ReindexJobSpec struct {
+	// ElasticsearchRef is a reference to the Elasticsearch cluster the documents are reindexed in.
+	ElasticsearchRef commonv1.ObjectSelector `json:"elasticsearchRef"`
+
+	// Source selects the documents to copy.
+	Source ReindexSource `json:"source"`
+
+	// Destination is the index the documents are copied to.
+	Destination ReindexDestination `json:"destination"`
+
+	// Slices is the number of slices the operation is split into, to reindex them in parallel. Defaults to auto, one
+	// slice per shard of the source indices.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	Slices int `json:"slices,omitempty"`
+
+	// RequestsPerSecond throttles the operation to the given number of sub-requests per second. Not throttled by
+	// default.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerSecond int `json:"requestsPerSecond,omitempty"`
+
+	// Conflicts is the behavior of the operation on version conflicts: abort, the default, or proceed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=abort;proceed
+	Conflicts string `json:"conflicts,omitempty"`
+
+	// MaxRetries is the number of times the operation is started again if it fails. Defaults to 3.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRetries *int `json:"maxRetries,omitempty"`
+
+	// ServiceAccountName is used to check access to the Elasticsearch cluster if it is in a different namespace.
+	// Can only be used if ECK is enforcing RBAC on references.
+	// +kubebuilder:validation:Optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// ReindexSource selects the documents to copy.
+type ReindexSource struct {
+	// Indices are the names or patterns of the indices to copy the documents from.
+	// +kubebuilder:validation:MinItems=1
+	Indices []string `json:"indices"`
+	// Query selects the documents to copy, in the Elasticsearch query DSL. Defaults to all the documents.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Query *commonv1.Config `json:"query,omitempty"`
+}
+
+// ReindexDestination is the index the documents are copied to.
+type ReindexDestination struct {
+	// Index is the name of the destination index. Create it beforehand to set its mappings and settings.
+	Index string `json:"index"`
+	// Pipeline is the name of the ingest pipeline the documents go through.
+	// +kubebuilder:validation:Optional
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// MaxRetriesOrDefault returns the number of times the operation is started again if it fails.
+func (s ReindexJobSpec) MaxRetriesOrDefault() int {
+	if s.MaxRetries == nil {
+		return DefaultReindexMaxRetries
+	}
+	return *s.MaxRetries
+}
+
+// ReindexJobPhase is the step of the reindex operation in progress.
+type ReindexJobPhase string
+
+const (
+	// ReindexPendingPhase is the phase during which the job waits for the cluster to be ready.
+	ReindexPendingPhase ReindexJobPhase = "Pending"
+	// ReindexRunningPhase is the phase during which the documents are copied.
+	ReindexRunningPhase ReindexJobPhase = "Running"
+	// ReindexCompletedPhase is the phase of a job whose documents are copied.
+	ReindexCompletedPhase ReindexJobPhase = "Completed"
+	// ReindexFailedPhase is the phase of a job that failed more than its maximum number of retries, as described by
+	// its status message.
+	ReindexFailedPhase ReindexJobPhase = "Failed"
+)
+
+// ReindexJobStatus reports the progress of the reindex operation.
+type ReindexJobStatus struct {
+	// Phase is the step of the reindex operation in progress.
+	Phase ReindexJobPhase `json:"phase,omitempty"`
+	// TaskID is the identifier of the Elasticsearch task of the current attempt.
+	TaskID string `json:"taskID,omitempty"`
+	// Attempts is the number of times the operation was started.
+	Attempts int `json:"attempts,omitempty"`
+	// Total is the number of documents to copy.
+	Total int64 `json:"total,omitempty"`
+	// Created is the number of documents created in the destination index.
+	Created int64 `json:"created,omitempty"`
+	// Updated is the number of documents updated in the destination index.
+	Updated int64 `json:"updated,omitempty"`
+	// VersionConflicts is the number of version conflicts encountered.
+	VersionConflicts int64 `json:"versionConflicts,omitempty"`
+	// Message describes the current state of the operation, or why the last attempt failed.
+	Message string `json:"message,omitempty"`
+	// StartedAt is the time the current attempt started.
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// CompletedAt is the time the documents were copied.
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// IsDone returns true if the reindex operation completed or failed.
+func (s ReindexJobStatus) IsDone() bool {
+	return s.Phase == ReindexCompletedPhase || s.Phase == ReindexFailedPhase
+}
+
+// +kubebuilder:object:root=true
+
+// ReindexJob copies documents between the indices of an Elasticsearch cluster with the reindex API, for example to
+// migrate an index to new mappings.
+// +kubebuilder:resource:categories=elastic,shortName=esreindex
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="elasticsearch",type="string",JSONPath=".spec.elasticsearchRef.name"
+// +kubebuilder:printcolumn:name="destination",type="string",JSONPath=".spec.destination.index"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="created",type="integer",JSONPath=".status.created",description="Documents created"
+// +kubebuilder:printcolumn:name="total",type="integer",JSONPath=".status.total",description="Documents to copy"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type ReindexJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReindexJobSpec   `json:"spec,omitempty"`
+	Status ReindexJobStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReindexJobList contains a list of ReindexJob.
+type ReindexJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReindexJob `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReindexJob{}, &ReindexJobList{})
+}
@@ -0,0 +1,44 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// RenameToAnnotation requests the operator to prepare the rename of an Elasticsearch resource to the
+	// <namespace>/<name> or <name> it is set to: the volumes of the cluster are retained and handed over to the
+	// Elasticsearch resource created with that name once this one is deleted.
+	RenameToAnnotation = "elasticsearch.k8s.elastic.co/rename-to"
+	// RenamedFromAnnotation declares the <namespace>/<name> or <name> of the Elasticsearch resource an Elasticsearch
+	// resource is renamed from: the operator reuses its volumes and credentials instead of creating an empty cluster.
+	RenamedFromAnnotation = "elasticsearch.k8s.elastic.co/renamed-from"
+)
+
+// ParseRenameReference returns the namespace and name of the Elasticsearch resource referenced by the value of a
+// rename annotation, of the form <namespace>/<name> or <name> in the given default namespace.
+func ParseRenameReference(value string, defaultNamespace string) (types.NamespacedName, error) {
+	parts := strings.Split(value, "/")
+	ref := types.NamespacedName{Namespace: defaultNamespace, Name: parts[0]}
+	switch len(parts) {
+	case 1:
+	case 2:
+		ref = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	default:
+		return ref, fmt.Errorf("%q must be of the form <namespace>/<name> or <name>", value)
+	}
+	if errs := utilvalidation.IsDNS1123Label(ref.Namespace); len(errs) > 0 {
+		return ref, fmt.Errorf("invalid namespace %q: %s", ref.Namespace, strings.Join(errs, ", "))
+	}
+	if errs := utilvalidation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+		return ref, fmt.Errorf("invalid name %q: %s", ref.Name, strings.Join(errs, ", "))
+	}
+	return ref, nil
+}
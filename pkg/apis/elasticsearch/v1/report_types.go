@@ -0,0 +1,165 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DiskWatermark is the highest disk watermark exceeded by a node of the cluster.
+type DiskWatermark string
+
+const (
+	// DiskWatermarkNone means no disk watermark is exceeded.
+	DiskWatermarkNone DiskWatermark = "none"
+	// DiskWatermarkLow means no new shards are allocated to some nodes.
+	DiskWatermarkLow DiskWatermark = "low"
+	// DiskWatermarkHigh means shards are relocated away from some nodes.
+	DiskWatermarkHigh DiskWatermark = "high"
+	// DiskWatermarkFloodStage means some indices are read-only.
+	DiskWatermarkFloodStage DiskWatermark = "flood_stage"
+)
+
+var diskWatermarkOrder = map[DiskWatermark]int{
+	DiskWatermarkNone:       1,
+	DiskWatermarkLow:        2,
+	DiskWatermarkHigh:       3,
+	DiskWatermarkFloodStage: 4,
+}
+
+// Less for DiskWatermark means none < low < high < flood_stage.
+func (w DiskWatermark) Less(other DiskWatermark) bool {
+	return diskWatermarkOrder[w] < diskWatermarkOrder[other]
+}
+
+// DiskReport summarizes the disk usage of the data nodes of a cluster.
+type DiskReport struct {
+	// Watermark is the highest disk watermark exceeded by a data node.
+	Watermark DiskWatermark `json:"watermark,omitempty"`
+	// MaxUsedPercent is the highest disk usage of a data node, in percent.
+	MaxUsedPercent int `json:"maxUsedPercent"`
+	// Node is the name of the data node with the highest disk usage.
+	Node string `json:"node,omitempty"`
+}
+
+// ShardsReport summarizes the number of shards of a cluster.
+type ShardsReport struct {
+	// Total is the number of shards of the cluster, including replicas and unassigned shards.
+	Total int `json:"total"`
+	// Unassigned is the number of unassigned shards.
+	Unassigned int `json:"unassigned"`
+	// Limit is the maximum number of shards of the cluster, derived from cluster.max_shards_per_node.
+	// Not set if the limit does not apply to the version of the cluster.
+	Limit int `json:"limit,omitempty"`
+	// UsedPercent is the number of shards relative to the limit, in percent.
+	UsedPercent int `json:"usedPercent,omitempty"`
+}
+
+// JVMReport summarizes the JVM memory pressure of the nodes of a cluster.
+type JVMReport struct {
+	// MaxHeapUsedPercent is the highest heap usage of a node, in percent.
+	MaxHeapUsedPercent int `json:"maxHeapUsedPercent"`
+	// Node is the name of the node with the highest heap usage.
+	Node string `json:"node,omitempty"`
+}
+
+// ReplicationReport summarizes the cross-cluster replication of the follower indices of a cluster.
+type ReplicationReport struct {
+	// FollowerIndices is the number of follower indices, including the ones created by auto-follow patterns.
+	FollowerIndices int `json:"followerIndices"`
+	// MaxOperationsLag is the highest number of operations of a leader index not replicated yet to its follower index.
+	MaxOperationsLag int64 `json:"maxOperationsLag"`
+	// Index is the name of the follower index with the highest lag.
+	Index string `json:"index,omitempty"`
+	// FailingIndices is the number of follower indices retrying failed reads from their leader index.
+	FailingIndices int `json:"failingIndices,omitempty"`
+}
+
+// SizingAssessment is the assessment of the capacity of a NodeSet relative to the observed usage of its nodes.
+type SizingAssessment string
+
+const (
+	// OverProvisioned means fewer nodes of the same size would keep the usage under target.
+	OverProvisioned SizingAssessment = "OverProvisioned"
+	// UnderProvisioned means more nodes of the same size are needed to bring the usage under target.
+	UnderProvisioned SizingAssessment = "UnderProvisioned"
+)
+
+// SizingRecommendation is a right-sizing hint for a NodeSet, derived from the observed usage of its nodes.
+type SizingRecommendation struct {
+	// NodeSet is the name of the NodeSet, suffixed with the zone for the NodeSets spread across zones.
+	NodeSet string `json:"nodeSet"`
+	// Assessment of the capacity of the NodeSet.
+	Assessment SizingAssessment `json:"assessment"`
+	// Nodes is the number of observed nodes of the NodeSet.
+	Nodes int `json:"nodes"`
+	// RecommendedNodes is the number of nodes of the same size bringing the usage close to the target.
+	RecommendedNodes int `json:"recommendedNodes"`
+	// HeapUsedPercent is the average heap usage of the nodes, in percent.
+	HeapUsedPercent int `json:"heapUsedPercent"`
+	// DiskUsedPercent is the highest disk usage of the nodes, in percent. Not set for the nodes not holding data.
+	DiskUsedPercent int `json:"diskUsedPercent,omitempty"`
+	// IndexingRate is the number of documents indexed per second by the nodes since the previous observation.
+	IndexingRate int64 `json:"indexingRate,omitempty"`
+	// SearchRate is the number of shard queries run per second by the nodes since the previous observation.
+	SearchRate int64 `json:"searchRate,omitempty"`
+	// Message summarizes the recommendation.
+	Message string `json:"message,omitempty"`
+}
+
+// ElasticsearchReportStatus summarizes the resource usage and health of an Elasticsearch cluster.
+type ElasticsearchReportStatus struct {
+	// ObservedAt is the time of the observation the report is built from.
+	ObservedAt metav1.Time `json:"observedAt,omitempty"`
+	// Health is the health of the cluster.
+	Health ElasticsearchHealth `json:"health,omitempty"`
+	// Nodes is the number of nodes of the cluster.
+	Nodes int `json:"nodes,omitempty"`
+	// License is the type of the license applied to the cluster.
+	License string `json:"license,omitempty"`
+	// Disk summarizes the disk usage of the data nodes. Not set if it could not be observed.
+	Disk *DiskReport `json:"disk,omitempty"`
+	// Shards summarizes the number of shards. Not set if it could not be observed.
+	Shards *ShardsReport `json:"shards,omitempty"`
+	// JVM summarizes the JVM memory pressure of the nodes. Not set if it could not be observed.
+	JVM *JVMReport `json:"jvm,omitempty"`
+	// Replication summarizes the replication lag of the follower indices. Not set if the cluster has no follower
+	// indices or if it could not be observed.
+	Replication *ReplicationReport `json:"replication,omitempty"`
+	// Recommendations are right-sizing hints for the NodeSets whose usage departs from the target usage, for review
+	// before changing their count or resources. Only set if enabled in the operator configuration.
+	Recommendations []SizingRecommendation `json:"recommendations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchReport is a periodically updated summary of the resource usage and health of the Elasticsearch
+// cluster with the same name, maintained by the operator.
+// +kubebuilder:resource:categories=elastic,shortName=esreport
+// +kubebuilder:printcolumn:name="health",type="string",JSONPath=".status.health"
+// +kubebuilder:printcolumn:name="disk",type="string",JSONPath=".status.disk.watermark",description="Highest disk watermark exceeded"
+// +kubebuilder:printcolumn:name="shards",type="integer",JSONPath=".status.shards.usedPercent",description="Shards relative to the limit, in percent"
+// +kubebuilder:printcolumn:name="heap",type="integer",JSONPath=".status.jvm.maxHeapUsedPercent",description="Highest heap usage, in percent"
+// +kubebuilder:printcolumn:name="license",type="string",JSONPath=".status.license"
+// +kubebuilder:printcolumn:name="observed",type="date",JSONPath=".status.observedAt"
+type ElasticsearchReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status ElasticsearchReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticsearchReportList contains a list of ElasticsearchReport.
+type ElasticsearchReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticsearchReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticsearchReport{}, &ElasticsearchReportList{})
+}
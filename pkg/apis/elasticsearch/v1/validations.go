@@ -7,31 +7,138 @@ package v1
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	esversion "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/version"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/chrono"
 	netutil "github.com/elastic/cloud-on-k8s/pkg/utils/net"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const (
-	cfgInvalidMsg            = "Configuration invalid"
-	masterRequiredMsg        = "Elasticsearch needs to have at least one master node"
-	parseVersionErrMsg       = "Cannot parse Elasticsearch version"
-	parseStoredVersionErrMsg = "Cannot parse current Elasticsearch version"
-	invalidSanIPErrMsg       = "Invalid SAN IP address"
-	pvcImmutableMsg          = "Volume claim templates cannot be modified"
-	invalidNamesErrMsg       = "Elasticsearch configuration would generate resources with invalid names"
-	unsupportedVersionErrMsg = "Unsupported version"
-	unsupportedConfigErrMsg  = "Configuration setting is reserved for internal use. User-configured use is unsupported"
-	duplicateNodeSets        = "NodeSet names must be unique"
-	noDowngradesMsg          = "Downgrades are not supported"
-	unsupportedVersionMsg    = "Unsupported version"
-	unsupportedUpgradeMsg    = "Unsupported version upgrade path"
+	cfgInvalidMsg             = "Configuration invalid"
+	masterRequiredMsg         = "Elasticsearch needs to have at least one master node"
+	parseVersionErrMsg        = "Cannot parse Elasticsearch version"
+	parseStoredVersionErrMsg  = "Cannot parse current Elasticsearch version"
+	invalidSanIPErrMsg        = "Invalid SAN IP address"
+	pvcImmutableMsg           = "Volume claim templates cannot be modified"
+	invalidNamesErrMsg        = "Elasticsearch configuration would generate resources with invalid names"
+	unsupportedVersionErrMsg  = "Unsupported version"
+	unsupportedConfigErrMsg   = "Configuration setting is reserved for internal use. User-configured use is unsupported"
+	duplicateNodeSets         = "NodeSet names must be unique"
+	noDowngradesMsg           = "Downgrades are not supported"
+	unsupportedVersionMsg     = "Unsupported version"
+	unsupportedUpgradeMsg     = "Unsupported version upgrade path"
+	duplicateRealmsMsg        = "Realm names must be unique"
+	invalidRealmFileMsg       = "Exactly one of secretName and configMapName must be set"
+	unsupportedOIDCMsg        = "OIDC realms require Elasticsearch 7.2.0 or later"
+	passwordMaxAgeMsg         = "Password max age must be at least 1h"
+	duplicateAuditFilterMsg   = "Audit ignore filter names must be unique"
+	unsupportedAuditShipping  = "Audit logs shipping requires Elasticsearch 7.0.0 or later"
+	duplicateFollowerIndices  = "Follower index names must be unique"
+	duplicateAutoFollowMsg    = "Auto-follow pattern names must be unique"
+	undeclaredRemoteCluster   = "Remote cluster must be declared in spec.remoteClusters"
+	unsupportedReplication    = "Cross-cluster replication requires Elasticsearch 6.7.0 or later"
+	unsupportedAPIKeyMsg      = "Remote clusters with API keys require Elasticsearch 8.10.0 or later"
+	noAPIKeyAccessMsg         = "API key must grant search or replication access"
+	unsupportedRCSMsg         = "Remote cluster server requires Elasticsearch 8.10.0 or later"
+	federatedAPIKeyMsg        = "API keys are not supported with remote clusters running in another Kubernetes cluster"
+	fewerMastersThanZonesMsg  = "Fewer master nodes than zones: the master nodes cannot be spread across all the zones"
+	noDisruptionAllowedMsg    = "Pod disruption budget allows no disruption: Pods cannot be evicted to restore their topology spread"
+	duplicatePluginMsg        = "Plugin names must be unique"
+	invalidPluginBundleMsg    = "Exactly one of secretName and persistentVolumeClaimName must be set"
+	pluginURLWithBundleMsg    = "Plugins cannot be downloaded from a URL when installed from a bundle"
+	analysisFilesPathMsg      = "Analysis files path must not be nested in, or hold, the path of other analysis files"
+	reservedAnalysisPathMsg   = "Analysis files path must not overlap the files managed by the operator"
+	unsupportedJVMOptionsMsg  = "JVM options require Elasticsearch 7.7.0 or later"
+	invalidJVMOptionMsg       = "JVM option must be a single non-empty line"
+	unsupportedGeoIPMsg       = "GeoIP databases configuration requires Elasticsearch 7.14.0 or later"
+	invalidGeoIPEndpointMsg   = "GeoIP endpoint must be an absolute http or https URL"
+	reservedGeoIPPathMsg      = "Analysis files path must not overlap the GeoIP databases directory"
+	unsupportedArchMsg        = "Default arm64 images require Elasticsearch 7.8.0 or later: set a custom image for older versions"
+	meshHTTPCertificateMsg    = "HTTP certificate cannot be set when the HTTP layer is encrypted by the mutual TLS of the service mesh"
+	invalidSlowLogKeyMsg      = "Slow log threshold must be one of search.query, search.fetch or indexing.index followed by warn, info, debug or trace"
+	invalidSlowLogValueMsg    = "Slow log threshold must be a time value, such as 500ms, or -1"
+	invalidSlowLogIndexMsg    = "Slow log index must be a non-empty name or wildcard pattern, without exclusion"
+	unsupportedDiagnostics    = "Diagnostics require Elasticsearch 7.7.0 or later"
+	diagnosticsClaimMsg       = "Diagnostics PersistentVolumeClaim name must be set"
+	managedRuntimeSettingMsg  = "Cluster setting is managed by the operator at runtime"
+	dataMigrationTimeoutMsg   = "Data migration timeout must be positive"
+	suspendedPodNameMsg       = "Suspended Pod name must not be empty"
+	secretRefFormatMsg        = "Secret references must be formatted as ${secret:<namespace>/<name>/<key>}"
+	secretRefNamespaceMsg     = "Secret references must be in the namespace of the Elasticsearch resource"
+	retentionJobActionMsg     = "Retention job must set at least one of deleteAfter and forceMerge"
+	positiveDurationMsg       = "Duration must be positive"
+	scalingWindowNameMsg      = "Scaling window name must not be empty"
+	scalingWindowDurationMsg  = "Scaling window duration must be positive and at most 168h"
+	scalingWithAutoscalingMsg = "Scheduled scaling cannot be combined with autoscaling"
+	unsupportedResourceDetMsg = "Resource detection overrides require Elasticsearch 7.7.0 or later"
+	detectedMemoryMsg         = "Detected memory must be positive"
+	unsupportedFrozenTierMsg  = "Frozen tier requires Elasticsearch 7.12.0 or later"
+	renameBothWaysMsg         = "Elasticsearch resource cannot be renamed from and to another resource at the same time"
+	renameToSelfMsg           = "Elasticsearch resource cannot be renamed to or from itself"
+	renamedFromImmutableMsg   = "Renamed-from annotation cannot be changed, only removed"
+	reservedPriorityClassMsg  = "Priority class names starting with system- are reserved to the system"
+	priorityValueMsg          = "Priority class value must be at most 1000000000, higher values are reserved to the system"
+	preemptionPolicyMsg       = "Preemption policy must be PreemptLowerPriority or Never"
+	priorityOrderMsg          = "Priority class value must not be higher than the value of the roles above: master nodes could be preempted first"
+	priorityClassConflictMsg  = "Priority class must be specified with the same value and preemption policy for all the roles using it"
+	runtimeClassConflictMsg   = "Runtime class name must be the same as the runtime class name of the Pod template, if set"
+	unsupportedSLMMsg         = "Snapshot lifecycle management policies require Elasticsearch 7.4.0 or later"
 )
 
+// operatorRuntimeSettings are the cluster settings updated by the operator at runtime: to manage the remote clusters
+// and the logger levels, to exclude the nodes being removed from shard allocation, and to disable the allocation of the
+// replica shards during rolling upgrades.
+var operatorRuntimeSettings = []string{
+	"cluster.remote",
+	"logger",
+	"cluster.routing.allocation.exclude._name",
+	"cluster.routing.allocation.enable",
+}
+
+var (
+	slowLogKeyPattern   = regexp.MustCompile(`^(search\.(query|fetch)|indexing\.index)\.(warn|info|debug|trace)$`)
+	slowLogValuePattern = regexp.MustCompile(`^(-1|0|[0-9]+(nanos|micros|ms|s|m|h|d))$`)
+)
+
+// reservedConfigPaths are the paths of the configuration directory of Elasticsearch managed by the operator.
+var reservedConfigPaths = []string{
+	"elasticsearch.yml",
+	"http-certs",
+	"saml-metadata",
+	"transport-certs",
+	"transport-remote-certs",
+}
+
+// JVMOptionsMinVersion is the first version of Elasticsearch reading the JVM options of the jvm.options.d directory.
+var JVMOptionsMinVersion = version.MustParse("7.7.0")
+
+// GeoIPDownloaderMinVersion is the first version of Elasticsearch downloading the GeoIP database updates.
+var GeoIPDownloaderMinVersion = version.MustParse("7.14.0")
+
+// ARM64ImageMinVersion is the first version of Elasticsearch whose default image is published for arm64.
+var ARM64ImageMinVersion = version.MustParse("7.8.0")
+
+// SLMMinVersion is the first version of Elasticsearch with snapshot lifecycle management.
+var SLMMinVersion = version.MustParse("7.4.0")
+
+// HighestUserDefinablePriority is the highest value of a PriorityClass not created by the system.
+const HighestUserDefinablePriority = int32(1000000000)
+
+// RemoteClusterAPIKeyMinVersion is the first version of Elasticsearch supporting remote clusters with API keys.
+var RemoteClusterAPIKeyMinVersion = version.MustParse("8.10.0")
+
 type validation func(*Elasticsearch) field.ErrorList
 
 // validations are the validation funcs that apply to creates or updates
@@ -41,6 +148,31 @@ var validations = []validation{
 	hasMaster,
 	supportedVersion,
 	validSanIP,
+	validRealms,
+	validPasswordRotation,
+	validAudit,
+	validCrossClusterReplication,
+	validRemoteClusterAPIKeys,
+	validPlugins,
+	validAnalysisFiles,
+	validJVMOptions,
+	validGeoIP,
+	validArchitectures,
+	validServiceMesh,
+	validLogging,
+	validDiagnostics,
+	validRuntimeConfig,
+	validDataMigrationPolicy,
+	validSnapshotBeforeUpgrade,
+	validNodeDebug,
+	validConfigSecretRefs,
+	validRetentionJobs,
+	validScheduledScaling,
+	validResourceDetection,
+	validDataTiers,
+	validRename,
+	validPriorityClasses,
+	validRuntimeClasses,
 }
 
 type updateValidation func(*Elasticsearch, *Elasticsearch) field.ErrorList
@@ -50,6 +182,7 @@ var updateValidations = []updateValidation{
 	noDowngrades,
 	validUpgradePath,
 	pvcModification,
+	renamedFromImmutable,
 }
 
 func (es *Elasticsearch) check(validations []validation) field.ErrorList {
@@ -94,7 +227,7 @@ func hasMaster(es *Elasticsearch) field.ErrorList {
 	var errs field.ErrorList
 	var hasMaster bool
 	for i, t := range es.Spec.NodeSets {
-		cfg, err := UnpackConfig(t.Config)
+		cfg, err := UnpackConfig(nodeSetConfig(es, t))
 		if err != nil {
 			errs = append(errs, field.Invalid(field.NewPath("spec").Child("nodeSets").Index(i), t.Config, cfgInvalidMsg))
 		}
@@ -122,6 +255,266 @@ func validSanIP(es *Elasticsearch) field.ErrorList {
 	return errs
 }
 
+// validRealms checks the SAML and OIDC realms have unique names and valid sources.
+func validRealms(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	authPath := field.NewPath("spec").Child("auth")
+	names := make(map[string]struct{})
+	checkName := func(path *field.Path, name string) {
+		if _, exists := names[name]; exists {
+			errs = append(errs, field.Invalid(path.Child("name"), name, duplicateRealmsMsg))
+		}
+		names[name] = struct{}{}
+	}
+	for i, realm := range es.Spec.Auth.SAML {
+		path := authPath.Child("saml").Index(i)
+		checkName(path, realm.Name)
+		if (realm.IdPMetadata.SecretName == "") == (realm.IdPMetadata.ConfigMapName == "") {
+			errs = append(errs, field.Invalid(path.Child("idpMetadata"), realm.IdPMetadata, invalidRealmFileMsg))
+		}
+	}
+	for i, realm := range es.Spec.Auth.OIDC {
+		checkName(authPath.Child("oidc").Index(i), realm.Name)
+	}
+	if len(es.Spec.Auth.OIDC) > 0 {
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && !ver.IsSameOrAfter(version.MustParse("7.2.0")) {
+			errs = append(errs, field.Invalid(authPath.Child("oidc"), es.Spec.Version, unsupportedOIDCMsg))
+		}
+	}
+	return errs
+}
+
+func validAudit(es *Elasticsearch) field.ErrorList {
+	audit := es.Spec.Audit
+	if audit == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	auditPath := field.NewPath("spec").Child("audit")
+	names := make(map[string]struct{})
+	for i, filter := range audit.IgnoreFilters {
+		if _, exists := names[filter.Name]; exists {
+			errs = append(errs, field.Invalid(auditPath.Child("ignoreFilters").Index(i).Child("name"), filter.Name, duplicateAuditFilterMsg))
+		}
+		names[filter.Name] = struct{}{}
+	}
+	if audit.ShippingEnabled() {
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && ver.Major < 7 {
+			errs = append(errs, field.Invalid(auditPath.Child("shipping"), es.Spec.Version, unsupportedAuditShipping))
+		}
+	}
+	return errs
+}
+
+func validCrossClusterReplication(es *Elasticsearch) field.ErrorList {
+	replication := es.Spec.CrossClusterReplication
+	if replication == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	replicationPath := field.NewPath("spec").Child("crossClusterReplication")
+	remoteClusters := make(map[string]struct{}, len(es.Spec.RemoteClusters))
+	for _, remoteCluster := range es.Spec.RemoteClusters {
+		remoteClusters[remoteCluster.Name] = struct{}{}
+	}
+	checkRemoteCluster := func(path *field.Path, name string) {
+		if _, exists := remoteClusters[name]; !exists {
+			errs = append(errs, field.Invalid(path.Child("remoteCluster"), name, undeclaredRemoteCluster))
+		}
+	}
+
+	followers := make(map[string]struct{})
+	for i, follower := range replication.FollowerIndices {
+		path := replicationPath.Child("followerIndices").Index(i)
+		if _, exists := followers[follower.Name]; exists {
+			errs = append(errs, field.Invalid(path.Child("name"), follower.Name, duplicateFollowerIndices))
+		}
+		followers[follower.Name] = struct{}{}
+		checkRemoteCluster(path, follower.RemoteCluster)
+	}
+	patterns := make(map[string]struct{})
+	for i, pattern := range replication.AutoFollowPatterns {
+		path := replicationPath.Child("autoFollowPatterns").Index(i)
+		if _, exists := patterns[pattern.Name]; exists {
+			errs = append(errs, field.Invalid(path.Child("name"), pattern.Name, duplicateAutoFollowMsg))
+		}
+		patterns[pattern.Name] = struct{}{}
+		checkRemoteCluster(path, pattern.RemoteCluster)
+	}
+
+	if len(replication.FollowerIndices) > 0 || len(replication.AutoFollowPatterns) > 0 {
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && !ver.IsSameOrAfter(version.MustParse("6.7.0")) {
+			errs = append(errs, field.Invalid(replicationPath, es.Spec.Version, unsupportedReplication))
+		}
+	}
+	return errs
+}
+
+func validRemoteClusterAPIKeys(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	ver, err := version.Parse(es.Spec.Version)
+	supported := err != nil || ver.IsSameOrAfter(RemoteClusterAPIKeyMinVersion)
+	for i, remoteCluster := range es.Spec.RemoteClusters {
+		if !remoteCluster.HasAPIKey() {
+			continue
+		}
+		path := field.NewPath("spec").Child("remoteClusters").Index(i).Child("apiKey")
+		if !supported {
+			errs = append(errs, field.Invalid(path, es.Spec.Version, unsupportedAPIKeyMsg))
+		}
+		if remoteCluster.IsFederated() {
+			errs = append(errs, field.Invalid(path, remoteCluster.KubernetesCluster, federatedAPIKeyMsg))
+		}
+		access := remoteCluster.APIKey.Access
+		if access.Search == nil && access.Replication == nil {
+			errs = append(errs, field.Required(path.Child("access"), noAPIKeyAccessMsg))
+		}
+	}
+	if es.Spec.RemoteClusterServer.Enabled && !supported {
+		errs = append(errs, field.Invalid(field.NewPath("spec").Child("remoteClusterServer"), es.Spec.Version, unsupportedRCSMsg))
+	}
+	return errs
+}
+
+// validPlugins checks that the plugins to install are unique, and that they can be installed from their source.
+func validPlugins(es *Elasticsearch) field.ErrorList {
+	plugins := es.Spec.Plugins
+	if plugins == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := field.NewPath("spec").Child("plugins")
+	names := make(map[string]struct{}, len(plugins.Install))
+	for i, plugin := range plugins.Install {
+		if _, exists := names[plugin.Name]; exists {
+			errs = append(errs, field.Invalid(path.Child("install").Index(i).Child("name"), plugin.Name, duplicatePluginMsg))
+		}
+		names[plugin.Name] = struct{}{}
+		if plugins.Bundle != nil && plugin.URL != "" {
+			errs = append(errs, field.Invalid(path.Child("install").Index(i).Child("url"), plugin.URL, pluginURLWithBundleMsg))
+		}
+	}
+	if bundle := plugins.Bundle; bundle != nil && (bundle.SecretName == "") == (bundle.PersistentVolumeClaimName == "") {
+		errs = append(errs, field.Invalid(path.Child("bundle"), bundle, invalidPluginBundleMsg))
+	}
+	return errs
+}
+
+// validAnalysisFiles checks that the analysis files are mounted in distinct directories, outside the paths managed
+// by the operator.
+func validAnalysisFiles(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	for i, files := range es.Spec.AnalysisFiles {
+		path := field.NewPath("spec").Child("analysisFiles").Index(i).Child("path")
+		for _, reserved := range reservedConfigPaths {
+			if nestedPaths(files.PathOrDefault(), reserved) {
+				errs = append(errs, field.Invalid(path, files.PathOrDefault(), reservedAnalysisPathMsg))
+			}
+		}
+		for _, other := range es.Spec.AnalysisFiles[:i] {
+			if nestedPaths(files.PathOrDefault(), other.PathOrDefault()) {
+				errs = append(errs, field.Invalid(path, files.PathOrDefault(), analysisFilesPathMsg))
+			}
+		}
+	}
+	return errs
+}
+
+// nestedPaths returns true if the given slash-separated paths are equal, or one is nested in the other.
+func nestedPaths(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// validJVMOptions checks that the JVM options of the NodeSets are single lines, supported by the version of Elasticsearch.
+func validJVMOptions(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	for i, nodeSet := range es.Spec.NodeSets {
+		if len(nodeSet.JVMOptions) == 0 {
+			continue
+		}
+		path := field.NewPath("spec").Child("nodeSets").Index(i).Child("jvmOptions")
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && !ver.IsSameOrAfter(JVMOptionsMinVersion) {
+			errs = append(errs, field.Invalid(path, nodeSet.JVMOptions, unsupportedJVMOptionsMsg))
+		}
+		for j, option := range nodeSet.JVMOptions {
+			if strings.TrimSpace(option) == "" || strings.ContainsAny(option, "\r\n") {
+				errs = append(errs, field.Invalid(path.Index(j), option, invalidJVMOptionMsg))
+			}
+		}
+	}
+	return errs
+}
+
+// validDiagnostics checks that the NodeSets capturing dumps set the claim to write them to, and run a version of
+// Elasticsearch reading the JVM options of the jvm.options.d directory.
+func validDiagnostics(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	for i, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.Diagnostics == nil {
+			continue
+		}
+		path := field.NewPath("spec").Child("nodeSets").Index(i).Child("diagnostics")
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && !ver.IsSameOrAfter(JVMOptionsMinVersion) {
+			errs = append(errs, field.Invalid(path, es.Spec.Version, unsupportedDiagnostics))
+		}
+		if nodeSet.Diagnostics.PersistentVolumeClaimName == "" {
+			errs = append(errs, field.Required(path.Child("persistentVolumeClaimName"), diagnosticsClaimMsg))
+		}
+	}
+	return errs
+}
+
+// validGeoIP checks that the GeoIP databases configuration is supported by the version of Elasticsearch, and that
+// the databases directory does not overlap the analysis files.
+func validGeoIP(es *Elasticsearch) field.ErrorList {
+	geoIP := es.Spec.GeoIP
+	if geoIP == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := field.NewPath("spec").Child("geoip")
+	ver, err := version.Parse(es.Spec.Version)
+	if err == nil && !ver.IsSameOrAfter(GeoIPDownloaderMinVersion) {
+		errs = append(errs, field.Invalid(path, es.Spec.Version, unsupportedGeoIPMsg))
+	}
+	if geoIP.Endpoint != "" {
+		u, err := url.Parse(geoIP.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(path.Child("endpoint"), geoIP.Endpoint, invalidGeoIPEndpointMsg))
+		}
+	}
+	if geoIP.PersistentVolumeClaimName != "" {
+		for i, files := range es.Spec.AnalysisFiles {
+			if nestedPaths(files.PathOrDefault(), GeoIPDatabasesDir) {
+				filesPath := field.NewPath("spec").Child("analysisFiles").Index(i).Child("path")
+				errs = append(errs, field.Invalid(filesPath, files.PathOrDefault(), reservedGeoIPPathMsg))
+			}
+		}
+	}
+	return errs
+}
+
+// validArchitectures checks that a default image is published for the architecture of the NodeSets.
+func validArchitectures(es *Elasticsearch) field.ErrorList {
+	ver, err := version.Parse(es.Spec.Version)
+	if err != nil || ver.IsSameOrAfter(ARM64ImageMinVersion) {
+		return nil
+	}
+	var errs field.ErrorList
+	for i, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.Architecture == "arm64" && nodeSet.ImageOrDefault(es.Spec.Image) == "" {
+			path := field.NewPath("spec").Child("nodeSets").Index(i).Child("architecture")
+			errs = append(errs, field.Invalid(path, nodeSet.Architecture, unsupportedArchMsg))
+		}
+	}
+	return errs
+}
+
 func checkNodeSetNameUniqueness(es *Elasticsearch) field.ErrorList {
 	var errs field.ErrorList
 	nodeSets := es.Spec.NodeSets
@@ -223,3 +616,365 @@ func getNode(name string, es *Elasticsearch) *NodeSet {
 	}
 	return nil
 }
+
+// validPasswordRotation checks the max age of the generated passwords leaves time for a rotation to complete.
+func validPasswordRotation(es *Elasticsearch) field.ErrorList {
+	rotation := es.Spec.Auth.PasswordRotation
+	if rotation == nil || rotation.MaxAge == nil || rotation.MaxAge.Duration >= time.Hour {
+		return nil
+	}
+	path := field.NewPath("spec").Child("auth", "passwordRotation", "maxAge")
+	return field.ErrorList{field.Invalid(path, rotation.MaxAge.Duration.String(), passwordMaxAgeMsg)}
+}
+
+// validServiceMesh checks that no HTTP certificate is set when the encryption of the HTTP layer is delegated to the
+// mutual TLS of the service mesh.
+func validServiceMesh(es *Elasticsearch) field.ErrorList {
+	if !es.Spec.ServiceMesh.MTLSEnabled() || es.Spec.HTTP.TLS.Certificate.SecretName == "" {
+		return nil
+	}
+	path := field.NewPath("spec").Child("http", "tls", "certificate", "secretName")
+	return field.ErrorList{field.Invalid(path, es.Spec.HTTP.TLS.Certificate.SecretName, meshHTTPCertificateMsg)}
+}
+
+// validLogging checks that the slow log thresholds are valid index settings, applied to explicit sets of indices.
+func validLogging(es *Elasticsearch) field.ErrorList {
+	if es.Spec.Logging == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	for i, entry := range es.Spec.Logging.SlowLog {
+		path := field.NewPath("spec").Child("logging", "slowLog").Index(i)
+		for j, index := range entry.Indices {
+			if index == "" || strings.HasPrefix(index, "-") || strings.Contains(index, ",") {
+				errs = append(errs, field.Invalid(path.Child("indices").Index(j), index, invalidSlowLogIndexMsg))
+			}
+		}
+		for key, value := range entry.Thresholds {
+			if !slowLogKeyPattern.MatchString(key) {
+				errs = append(errs, field.Invalid(path.Child("thresholds").Key(key), key, invalidSlowLogKeyMsg))
+				continue
+			}
+			if !slowLogValuePattern.MatchString(value) {
+				errs = append(errs, field.Invalid(path.Child("thresholds").Key(key), value, invalidSlowLogValueMsg))
+			}
+		}
+	}
+	return errs
+}
+
+// validRuntimeConfig checks that the runtime configuration does not hold settings reserved for internal use, or
+// updated by the operator at runtime.
+func validRuntimeConfig(es *Elasticsearch) field.ErrorList {
+	if es.Spec.RuntimeConfig == nil {
+		return nil
+	}
+	path := field.NewPath("spec").Child("runtimeConfig")
+	config, err := common.NewCanonicalConfigFrom(es.Spec.RuntimeConfig.Data)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, es.Spec.RuntimeConfig, cfgInvalidMsg)}
+	}
+	var errs field.ErrorList
+	for _, setting := range config.HasKeys(UnsupportedSettings) {
+		errs = append(errs, field.Forbidden(path.Child(setting), unsupportedConfigErrMsg))
+	}
+	keys := config.Diff(nil, nil)
+	for _, setting := range operatorRuntimeSettings {
+		for _, key := range keys {
+			if key == setting || strings.HasPrefix(key, setting+".") {
+				errs = append(errs, field.Forbidden(path.Child(setting), managedRuntimeSettingMsg))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// validDataMigrationPolicy checks that the data migration timeout is positive.
+func validDataMigrationPolicy(es *Elasticsearch) field.ErrorList {
+	policy := es.Spec.UpdateStrategy.DataMigration
+	if policy == nil || policy.Timeout.Duration > 0 {
+		return nil
+	}
+	path := field.NewPath("spec").Child("updateStrategy").Child("dataMigration").Child("timeout")
+	return field.ErrorList{field.Invalid(path, policy.Timeout.Duration.String(), dataMigrationTimeoutMsg)}
+}
+
+// validSnapshotBeforeUpgrade checks that the maximum age of the snapshot required before a version upgrade is positive,
+// and that the snapshot lifecycle management policy taking it is supported by the version of Elasticsearch.
+func validSnapshotBeforeUpgrade(es *Elasticsearch) field.ErrorList {
+	policy := es.Spec.UpdateStrategy.SnapshotBeforeUpgrade
+	if policy == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := field.NewPath("spec").Child("updateStrategy").Child("snapshotBeforeUpgrade")
+	if policy.MaxAge != nil && policy.MaxAge.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("maxAge"), policy.MaxAge.Duration.String(), positiveDurationMsg))
+	}
+	if policy.SLMPolicy != "" {
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && !ver.IsSameOrAfter(SLMMinVersion) {
+			errs = append(errs, field.Invalid(path.Child("slmPolicy"), policy.SLMPolicy, unsupportedSLMMsg))
+		}
+	}
+	return errs
+}
+
+// validNodeDebug checks that the suspended Pods are named, and listed once.
+func validNodeDebug(es *Elasticsearch) field.ErrorList {
+	if es.Spec.NodeDebug == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	seen := make(map[string]bool, len(es.Spec.NodeDebug.SuspendedPods))
+	for i, pod := range es.Spec.NodeDebug.SuspendedPods {
+		path := field.NewPath("spec").Child("nodeDebug").Child("suspendedPods").Index(i).Child("name")
+		switch {
+		case pod.Name == "":
+			errs = append(errs, field.Required(path, suspendedPodNameMsg))
+		case seen[pod.Name]:
+			errs = append(errs, field.Duplicate(path, pod.Name))
+		}
+		seen[pod.Name] = true
+	}
+	return errs
+}
+
+// validConfigSecretRefs checks that the Secret keys referenced in the configuration of the NodeSets are well formed,
+// and belong to the namespace of the cluster: environment variables can only reference Secrets of the Pod namespace.
+func validConfigSecretRefs(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	for i, nodeSet := range es.Spec.NodeSets {
+		path := field.NewPath("spec").Child("nodeSets").Index(i).Child("config")
+		if hasMalformedConfigSecretRef(nodeSet.Config) {
+			errs = append(errs, field.Invalid(path, nodeSet.Name, secretRefFormatMsg))
+		}
+		if es.Namespace == "" {
+			continue
+		}
+		for _, ref := range ConfigSecretRefs(nodeSet.Config) {
+			if ref.Namespace != es.Namespace {
+				errs = append(errs, field.Invalid(path, ref.String(), secretRefNamespaceMsg))
+			}
+		}
+	}
+	return errs
+}
+
+// validRetentionJobs checks that the retention jobs are uniquely named, and apply at least one action on a positive
+// schedule.
+func validRetentionJobs(es *Elasticsearch) field.ErrorList {
+	if es.Spec.Maintenance == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	seen := make(map[string]bool, len(es.Spec.Maintenance.RetentionJobs))
+	for i, job := range es.Spec.Maintenance.RetentionJobs {
+		path := field.NewPath("spec").Child("maintenance").Child("retentionJobs").Index(i)
+		if seen[job.Name] {
+			errs = append(errs, field.Duplicate(path.Child("name"), job.Name))
+		}
+		seen[job.Name] = true
+		if job.DeleteAfter == nil && job.ForceMerge == nil {
+			errs = append(errs, field.Required(path, retentionJobActionMsg))
+		}
+		if job.Interval != nil && job.Interval.Duration <= 0 {
+			errs = append(errs, field.Invalid(path.Child("interval"), job.Interval.Duration.String(), positiveDurationMsg))
+		}
+		if job.DeleteAfter != nil && job.DeleteAfter.Duration <= 0 {
+			errs = append(errs, field.Invalid(path.Child("deleteAfter"), job.DeleteAfter.Duration.String(), positiveDurationMsg))
+		}
+	}
+	return errs
+}
+
+// validScheduledScaling checks that the scaling windows of the NodeSets are uniquely named, and start on a valid cron
+// schedule for a bounded duration. Scaling windows are not allowed on autoscaled clusters.
+func validScheduledScaling(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	_, autoscaled := es.Annotations[AutoscalingSpecAnnotation]
+	for i, nodeSet := range es.Spec.NodeSets {
+		if autoscaled && len(nodeSet.ScheduledScaling) > 0 {
+			path := field.NewPath("spec").Child("nodeSets").Index(i).Child("scheduledScaling")
+			errs = append(errs, field.Forbidden(path, scalingWithAutoscalingMsg))
+		}
+		seen := make(map[string]bool, len(nodeSet.ScheduledScaling))
+		for j, window := range nodeSet.ScheduledScaling {
+			path := field.NewPath("spec").Child("nodeSets").Index(i).Child("scheduledScaling").Index(j)
+			switch {
+			case window.Name == "":
+				errs = append(errs, field.Required(path.Child("name"), scalingWindowNameMsg))
+			case seen[window.Name]:
+				errs = append(errs, field.Duplicate(path.Child("name"), window.Name))
+			}
+			seen[window.Name] = true
+			if _, err := chrono.ParseCronSchedule(window.Schedule); err != nil {
+				errs = append(errs, field.Invalid(path.Child("schedule"), window.Schedule, err.Error()))
+			}
+			if window.Duration.Duration <= 0 || window.Duration.Duration > MaxScalingWindowDuration {
+				errs = append(errs, field.Invalid(path.Child("duration"), window.Duration.Duration.String(), scalingWindowDurationMsg))
+			}
+		}
+	}
+	return errs
+}
+
+// validResourceDetection checks that the NodeSets overriding the detected resources run a version of Elasticsearch
+// reading the JVM options of the jvm.options.d directory, and override the memory with a positive quantity.
+func validResourceDetection(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	for i, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.ResourceDetection == nil {
+			continue
+		}
+		path := field.NewPath("spec").Child("nodeSets").Index(i).Child("resourceDetection")
+		ver, err := version.Parse(es.Spec.Version)
+		if err == nil && !ver.IsSameOrAfter(JVMOptionsMinVersion) {
+			errs = append(errs, field.Invalid(path, es.Spec.Version, unsupportedResourceDetMsg))
+		}
+		if memory := nodeSet.ResourceDetection.Memory; memory != nil && memory.Sign() <= 0 {
+			errs = append(errs, field.Invalid(path.Child("memory"), memory.String(), detectedMemoryMsg))
+		}
+	}
+	return errs
+}
+
+// validDataTiers checks that the NodeSets of the frozen tier run a version of Elasticsearch supporting it.
+func validDataTiers(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	ver, err := version.Parse(es.Spec.Version)
+	if err != nil {
+		// reported by the validations
+		return errs
+	}
+	for i, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.Tier == FrozenTier && !ver.IsSameOrAfter(FrozenTierMinVersion) {
+			errs = append(errs, field.Invalid(field.NewPath("spec").Child("nodeSets").Index(i).Child("tier"), nodeSet.Tier, unsupportedFrozenTierMsg))
+		}
+	}
+	return errs
+}
+
+// validRename checks the references of the rename annotations, which cannot be both set nor reference the resource
+// itself.
+func validRename(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	self := types.NamespacedName{Namespace: es.Namespace, Name: es.Name}
+	renameTo, hasRenameTo := es.Annotations[RenameToAnnotation]
+	renamedFrom, hasRenamedFrom := es.Annotations[RenamedFromAnnotation]
+	for _, annotation := range []struct {
+		name  string
+		value string
+		set   bool
+	}{
+		{name: RenameToAnnotation, value: renameTo, set: hasRenameTo},
+		{name: RenamedFromAnnotation, value: renamedFrom, set: hasRenamedFrom},
+	} {
+		if !annotation.set {
+			continue
+		}
+		path := field.NewPath("metadata").Child("annotations").Key(annotation.name)
+		ref, err := ParseRenameReference(annotation.value, es.Namespace)
+		switch {
+		case err != nil:
+			errs = append(errs, field.Invalid(path, annotation.value, err.Error()))
+		case ref == self:
+			errs = append(errs, field.Invalid(path, annotation.value, renameToSelfMsg))
+		}
+	}
+	if hasRenameTo && hasRenamedFrom {
+		errs = append(errs, field.Forbidden(field.NewPath("metadata").Child("annotations").Key(RenameToAnnotation), renameBothWaysMsg))
+	}
+	return errs
+}
+
+// renamedFromImmutable checks that the resource a cluster is renamed from is not changed once set.
+func renamedFromImmutable(current, proposed *Elasticsearch) field.ErrorList {
+	previous, wasSet := current.Annotations[RenamedFromAnnotation]
+	value, isSet := proposed.Annotations[RenamedFromAnnotation]
+	if !wasSet || !isSet || previous == value {
+		return nil
+	}
+	path := field.NewPath("metadata").Child("annotations").Key(RenamedFromAnnotation)
+	return field.ErrorList{field.Invalid(path, value, renamedFromImmutableMsg)}
+}
+
+// nodeSetConfig returns the configuration of the given NodeSet completed with the settings derived from its data
+// tier, which set the roles of its nodes.
+func nodeSetConfig(es *Elasticsearch, nodeSet NodeSet) *commonv1.Config {
+	ver, err := version.Parse(es.Spec.Version)
+	if err != nil {
+		return nodeSet.Config
+	}
+	cfg, err := nodeSet.TierConfig(*ver)
+	if err != nil {
+		// the invalid configuration is reported when unpacked
+		return nodeSet.Config
+	}
+	return cfg
+}
+
+// validPriorityClasses checks that the priority classes of the node roles are valid PriorityClasses, and that the
+// master nodes do not have a lower priority than the data nodes, and the data nodes than the coordinating nodes: the
+// nodes with the lowest priority are preempted, or evicted under node pressure, first.
+func validPriorityClasses(es *Elasticsearch) field.ErrorList {
+	if es.Spec.PriorityClasses == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	var higher *int32
+	seen := make(map[string]RolePriorityClass, len(PriorityClassRoles))
+	for _, role := range PriorityClassRoles {
+		class := es.Spec.PriorityClasses.ForRole(role)
+		if class == nil {
+			continue
+		}
+		path := field.NewPath("spec").Child("priorityClasses").Child(role)
+		for _, msg := range utilvalidation.IsDNS1123Subdomain(class.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), class.Name, msg))
+		}
+		if strings.HasPrefix(class.Name, "system-") {
+			errs = append(errs, field.Invalid(path.Child("name"), class.Name, reservedPriorityClassMsg))
+		}
+		if previous, exists := seen[class.Name]; exists && !reflect.DeepEqual(previous, *class) {
+			errs = append(errs, field.Invalid(path, class.Name, priorityClassConflictMsg))
+		}
+		seen[class.Name] = *class
+		if policy := class.PreemptionPolicy; policy != nil && *policy != corev1.PreemptLowerPriority && *policy != corev1.PreemptNever {
+			errs = append(errs, field.NotSupported(path.Child("preemptionPolicy"), *policy, []string{
+				string(corev1.PreemptLowerPriority), string(corev1.PreemptNever),
+			}))
+		}
+		if class.Value == nil {
+			continue
+		}
+		if *class.Value > HighestUserDefinablePriority {
+			errs = append(errs, field.Invalid(path.Child("value"), *class.Value, priorityValueMsg))
+		}
+		if higher != nil && *class.Value > *higher {
+			errs = append(errs, field.Invalid(path.Child("value"), *class.Value, priorityOrderMsg))
+		}
+		higher = class.Value
+	}
+	return errs
+}
+
+// validRuntimeClasses checks that the runtime classes of the NodeSets are valid RuntimeClass names, which do not
+// conflict with the runtime class of their Pod template.
+func validRuntimeClasses(es *Elasticsearch) field.ErrorList {
+	var errs field.ErrorList
+	for i, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.RuntimeClassName == "" {
+			continue
+		}
+		path := field.NewPath("spec").Child("nodeSets").Index(i).Child("runtimeClassName")
+		for _, msg := range utilvalidation.IsDNS1123Subdomain(nodeSet.RuntimeClassName) {
+			errs = append(errs, field.Invalid(path, nodeSet.RuntimeClassName, msg))
+		}
+		if podRuntimeClass := nodeSet.PodTemplate.Spec.RuntimeClassName; podRuntimeClass != nil && *podRuntimeClass != nodeSet.RuntimeClassName {
+			errs = append(errs, field.Invalid(path, nodeSet.RuntimeClassName, runtimeClassConflictMsg))
+		}
+	}
+	return errs
+}
@@ -6,8 +6,11 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/pointer"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -327,6 +330,989 @@ func Test_validSanIP(t *testing.T) {
 	}
 }
 
+func Test_validRealms(t *testing.T) {
+	metadata := RealmFileSource{SecretName: "idp-metadata"}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no realms: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0"}},
+			expectErrors: false,
+		},
+		{
+			name: "valid realms: OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Auth: Auth{
+				SAML: []SAMLRealm{{Name: "saml1", IdPMetadata: metadata}},
+				OIDC: []OIDCRealm{{Name: "oidc1"}},
+			}}},
+			expectErrors: false,
+		},
+		{
+			name: "duplicate realm names: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Auth: Auth{
+				SAML: []SAMLRealm{{Name: "sso", IdPMetadata: metadata}},
+				OIDC: []OIDCRealm{{Name: "sso"}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name: "metadata in both a secret and a config map: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Auth: Auth{
+				SAML: []SAMLRealm{{Name: "saml1", IdPMetadata: RealmFileSource{SecretName: "a", ConfigMapName: "b"}}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name: "no metadata: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Auth: Auth{
+				SAML: []SAMLRealm{{Name: "saml1"}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name: "OIDC realm before 7.2.0: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.1.0", Auth: Auth{
+				OIDC: []OIDCRealm{{Name: "oidc1"}},
+			}}},
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validRealms(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validRealms(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec)
+			}
+		})
+	}
+}
+
+func Test_validPasswordRotation(t *testing.T) {
+	withMaxAge := func(d time.Duration) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{Auth: Auth{
+			PasswordRotation: &PasswordRotation{MaxAge: &metav1.Duration{Duration: d}},
+		}}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no rotation policy: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name:         "no max age: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Auth: Auth{PasswordRotation: &PasswordRotation{}}}},
+			expectErrors: false,
+		},
+		{
+			name:         "max age of 30 days: OK",
+			es:           withMaxAge(30 * 24 * time.Hour),
+			expectErrors: false,
+		},
+		{
+			name:         "max age too short: NOT OK",
+			es:           withMaxAge(time.Minute),
+			expectErrors: true,
+		},
+		{
+			name:         "zero max age: NOT OK",
+			es:           withMaxAge(0),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validPasswordRotation(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validPasswordRotation(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec)
+			}
+		})
+	}
+}
+
+func Test_validAudit(t *testing.T) {
+	shipping := &AuditShipping{ElasticsearchRef: commonv1.ObjectSelector{Name: "monitoring"}}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no audit: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name: "unique ignore filters: OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Audit: &AuditLogging{
+				IgnoreFilters: []AuditIgnoreFilter{{Name: "probes", Users: []string{"elastic-internal-probe"}}, {Name: "kibana"}},
+			}}},
+			expectErrors: false,
+		},
+		{
+			name: "duplicate ignore filters: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Audit: &AuditLogging{
+				IgnoreFilters: []AuditIgnoreFilter{{Name: "probes"}, {Name: "probes"}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name:         "shipping with 7.x: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.0.0", Audit: &AuditLogging{Shipping: shipping}}},
+			expectErrors: false,
+		},
+		{
+			name:         "shipping with 6.x: NOT OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "6.8.5", Audit: &AuditLogging{Shipping: shipping}}},
+			expectErrors: true,
+		},
+		{
+			name:         "audit logging without shipping with 6.x: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "6.8.5", Audit: &AuditLogging{}}},
+			expectErrors: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validAudit(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validAudit(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec)
+			}
+		})
+	}
+}
+
+func Test_validCrossClusterReplication(t *testing.T) {
+	remoteClusters := []RemoteCluster{{Name: "prod", ElasticsearchRef: commonv1.ObjectSelector{Name: "prod"}}}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no replication: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name: "followers of declared remote clusters: OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", RemoteClusters: remoteClusters, CrossClusterReplication: &CrossClusterReplication{
+				FollowerIndices:    []FollowerIndex{{Name: "logs", RemoteCluster: "prod", LeaderIndex: "logs"}},
+				AutoFollowPatterns: []AutoFollowPattern{{Name: "metrics", RemoteCluster: "prod", LeaderIndexPatterns: []string{"metrics-*"}}},
+			}}},
+			expectErrors: false,
+		},
+		{
+			name: "undeclared remote cluster: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", RemoteClusters: remoteClusters, CrossClusterReplication: &CrossClusterReplication{
+				AutoFollowPatterns: []AutoFollowPattern{{Name: "metrics", RemoteCluster: "staging", LeaderIndexPatterns: []string{"metrics-*"}}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name: "duplicate follower indices: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", RemoteClusters: remoteClusters, CrossClusterReplication: &CrossClusterReplication{
+				FollowerIndices: []FollowerIndex{{Name: "logs", RemoteCluster: "prod", LeaderIndex: "logs"}, {Name: "logs", RemoteCluster: "prod", LeaderIndex: "logs2"}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name: "replication with 6.6: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "6.6.0", RemoteClusters: remoteClusters, CrossClusterReplication: &CrossClusterReplication{
+				FollowerIndices: []FollowerIndex{{Name: "logs", RemoteCluster: "prod", LeaderIndex: "logs"}},
+			}}},
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validCrossClusterReplication(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validCrossClusterReplication(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec)
+			}
+		})
+	}
+}
+
+func Test_validRemoteClusterAPIKeys(t *testing.T) {
+	withAPIKey := func(access RemoteClusterAccess) []RemoteCluster {
+		return []RemoteCluster{{Name: "prod", ElasticsearchRef: commonv1.ObjectSelector{Name: "prod"}, APIKey: &RemoteClusterAPIKey{Access: access}}}
+	}
+	search := RemoteClusterAccess{Search: &RemoteClusterIndices{Names: []string{"logs-*"}}}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "certificate based remote clusters: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", RemoteClusters: []RemoteCluster{{Name: "prod"}}}},
+			expectErrors: false,
+		},
+		{
+			name:         "API key and remote cluster server with 8.10: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "8.10.0", RemoteClusters: withAPIKey(search), RemoteClusterServer: RemoteClusterServer{Enabled: true}}},
+			expectErrors: false,
+		},
+		{
+			name:         "API key with 8.9: NOT OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "8.9.2", RemoteClusters: withAPIKey(search)}},
+			expectErrors: true,
+		},
+		{
+			name:         "API key without access: NOT OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "8.10.0", RemoteClusters: withAPIKey(RemoteClusterAccess{})}},
+			expectErrors: true,
+		},
+		{
+			name: "API key with a remote cluster in another Kubernetes cluster: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{Version: "8.10.0", RemoteClusters: []RemoteCluster{
+				{Name: "prod", ElasticsearchRef: commonv1.ObjectSelector{Name: "prod"}, KubernetesCluster: "east", APIKey: &RemoteClusterAPIKey{Access: search}},
+			}}},
+			expectErrors: true,
+		},
+		{
+			name:         "remote cluster server with 7.17: NOT OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.17.0", RemoteClusterServer: RemoteClusterServer{Enabled: true}}},
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validRemoteClusterAPIKeys(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validRemoteClusterAPIKeys(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec)
+			}
+		})
+	}
+}
+
+func Test_validPlugins(t *testing.T) {
+	withPlugins := func(plugins *Plugins) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0", Plugins: plugins}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no plugins: OK",
+			es:           withPlugins(nil),
+			expectErrors: false,
+		},
+		{
+			name:         "plugins from the registry and from a URL: OK",
+			es:           withPlugins(&Plugins{Install: []Plugin{{Name: "analysis-icu"}, {Name: "custom", URL: "https://example.com/custom.zip"}}}),
+			expectErrors: false,
+		},
+		{
+			name:         "plugins from a Secret: OK",
+			es:           withPlugins(&Plugins{Install: []Plugin{{Name: "analysis-icu"}}, Bundle: &PluginBundle{SecretName: "plugins"}}),
+			expectErrors: false,
+		},
+		{
+			name:         "duplicate plugins: NOT OK",
+			es:           withPlugins(&Plugins{Install: []Plugin{{Name: "analysis-icu"}, {Name: "analysis-icu", Version: "7.6.1"}}}),
+			expectErrors: true,
+		},
+		{
+			name:         "plugin URL with a bundle: NOT OK",
+			es:           withPlugins(&Plugins{Install: []Plugin{{Name: "custom", URL: "https://example.com/custom.zip"}}, Bundle: &PluginBundle{SecretName: "plugins"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "bundle from both a Secret and a PVC: NOT OK",
+			es:           withPlugins(&Plugins{Install: []Plugin{{Name: "analysis-icu"}}, Bundle: &PluginBundle{SecretName: "plugins", PersistentVolumeClaimName: "plugins"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "empty bundle: NOT OK",
+			es:           withPlugins(&Plugins{Install: []Plugin{{Name: "analysis-icu"}}, Bundle: &PluginBundle{}}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validPlugins(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validPlugins(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.Plugins)
+			}
+		})
+	}
+}
+
+func Test_validAnalysisFiles(t *testing.T) {
+	withAnalysisFiles := func(files ...AnalysisFiles) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{AnalysisFiles: files}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no analysis files: OK",
+			es:           withAnalysisFiles(),
+			expectErrors: false,
+		},
+		{
+			name:         "default and custom paths: OK",
+			es:           withAnalysisFiles(AnalysisFiles{ConfigMapName: "synonyms"}, AnalysisFiles{ConfigMapName: "dict", Path: "hunspell/en_US"}),
+			expectErrors: false,
+		},
+		{
+			name:         "paths sharing a prefix: OK",
+			es:           withAnalysisFiles(AnalysisFiles{ConfigMapName: "a", Path: "analysis/syn"}, AnalysisFiles{ConfigMapName: "b", Path: "analysis/synonyms"}),
+			expectErrors: false,
+		},
+		{
+			name:         "same path: NOT OK",
+			es:           withAnalysisFiles(AnalysisFiles{ConfigMapName: "synonyms"}, AnalysisFiles{ConfigMapName: "other", Path: "analysis/synonyms"}),
+			expectErrors: true,
+		},
+		{
+			name:         "nested paths: NOT OK",
+			es:           withAnalysisFiles(AnalysisFiles{ConfigMapName: "a", Path: "analysis"}, AnalysisFiles{ConfigMapName: "b"}),
+			expectErrors: true,
+		},
+		{
+			name:         "path managed by the operator: NOT OK",
+			es:           withAnalysisFiles(AnalysisFiles{ConfigMapName: "a", Path: "http-certs/synonyms"}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validAnalysisFiles(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validAnalysisFiles(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.AnalysisFiles)
+			}
+		})
+	}
+}
+
+func Test_validJVMOptions(t *testing.T) {
+	withJVMOptions := func(version string, options ...string) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{
+			Version:  version,
+			NodeSets: []NodeSet{{Name: "default"}, {Name: "data", JVMOptions: options}},
+		}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no JVM options: OK",
+			es:           withJVMOptions("7.6.0"),
+			expectErrors: false,
+		},
+		{
+			name:         "JVM options: OK",
+			es:           withJVMOptions("7.7.0", "-XX:+UseG1GC", "-XX:HeapDumpPath=/usr/share/elasticsearch/data", "14-:-XX:G1ReservePercent=25"),
+			expectErrors: false,
+		},
+		{
+			name:         "JVM options before 7.7.0: NOT OK",
+			es:           withJVMOptions("7.6.2", "-XX:+UseG1GC"),
+			expectErrors: true,
+		},
+		{
+			name:         "empty JVM option: NOT OK",
+			es:           withJVMOptions("7.7.0", " "),
+			expectErrors: true,
+		},
+		{
+			name:         "multi-line JVM option: NOT OK",
+			es:           withJVMOptions("7.7.0", "-XX:+UseG1GC\n-XX:+AlwaysPreTouch"),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validJVMOptions(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validJVMOptions(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_validGeoIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no GeoIP configuration: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "7.6.0"}},
+			expectErrors: false,
+		},
+		{
+			name: "endpoint and databases: OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{
+				Version: "7.14.0",
+				GeoIP:   &GeoIP{Endpoint: "http://geoip-mirror.default.svc:8080", PersistentVolumeClaimName: "geoip"},
+			}},
+			expectErrors: false,
+		},
+		{
+			name: "GeoIP configuration before 7.14.0: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{
+				Version: "7.13.4",
+				GeoIP:   &GeoIP{PersistentVolumeClaimName: "geoip"},
+			}},
+			expectErrors: true,
+		},
+		{
+			name: "relative endpoint: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{
+				Version: "7.14.0",
+				GeoIP:   &GeoIP{Endpoint: "geoip-mirror:8080"},
+			}},
+			expectErrors: true,
+		},
+		{
+			name: "analysis files in the databases directory: NOT OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{
+				Version:       "7.14.0",
+				GeoIP:         &GeoIP{PersistentVolumeClaimName: "geoip"},
+				AnalysisFiles: []AnalysisFiles{{ConfigMapName: "synonyms", Path: "ingest-geoip/synonyms"}},
+			}},
+			expectErrors: true,
+		},
+		{
+			name: "analysis files in the databases directory without databases: OK",
+			es: &Elasticsearch{Spec: ElasticsearchSpec{
+				Version:       "7.14.0",
+				GeoIP:         &GeoIP{Endpoint: "https://geoip-mirror.example.com"},
+				AnalysisFiles: []AnalysisFiles{{ConfigMapName: "synonyms", Path: "ingest-geoip/synonyms"}},
+			}},
+			expectErrors: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validGeoIP(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validGeoIP(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.GeoIP)
+			}
+		})
+	}
+}
+
+func Test_validArchitectures(t *testing.T) {
+	withArchitecture := func(version string, architecture string, image string) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{
+			Version:  version,
+			NodeSets: []NodeSet{{Name: "default"}, {Name: "arm", Architecture: architecture, Image: image}},
+		}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no architecture: OK",
+			es:           withArchitecture("7.6.0", "", ""),
+			expectErrors: false,
+		},
+		{
+			name:         "amd64 before 7.8.0: OK",
+			es:           withArchitecture("7.6.0", "amd64", ""),
+			expectErrors: false,
+		},
+		{
+			name:         "arm64: OK",
+			es:           withArchitecture("7.8.0", "arm64", ""),
+			expectErrors: false,
+		},
+		{
+			name:         "arm64 before 7.8.0: NOT OK",
+			es:           withArchitecture("7.7.1", "arm64", ""),
+			expectErrors: true,
+		},
+		{
+			name:         "arm64 before 7.8.0 with a custom image: OK",
+			es:           withArchitecture("7.7.1", "arm64", "registry.example.com/elasticsearch-arm64:7.7.1"),
+			expectErrors: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validArchitectures(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validArchitectures(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_validServiceMesh(t *testing.T) {
+	mtlsDisabled := false
+	withCertificate := func(mesh *ServiceMesh, secretName string) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{
+			HTTP:        commonv1.HTTPConfig{TLS: commonv1.TLSOptions{Certificate: commonv1.SecretRef{SecretName: secretName}}},
+			ServiceMesh: mesh,
+		}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no service mesh: OK",
+			es:           withCertificate(nil, "my-cert"),
+			expectErrors: false,
+		},
+		{
+			name:         "mTLS delegated to the mesh: OK",
+			es:           withCertificate(&ServiceMesh{Provider: IstioServiceMesh}, ""),
+			expectErrors: false,
+		},
+		{
+			name:         "HTTP certificate with mTLS delegated to the mesh: NOT OK",
+			es:           withCertificate(&ServiceMesh{Provider: IstioServiceMesh}, "my-cert"),
+			expectErrors: true,
+		},
+		{
+			name:         "HTTP certificate with mTLS not delegated to the mesh: OK",
+			es:           withCertificate(&ServiceMesh{Provider: IstioServiceMesh, MTLS: &mtlsDisabled}, "my-cert"),
+			expectErrors: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validServiceMesh(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validServiceMesh(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.ServiceMesh)
+			}
+		})
+	}
+}
+
+func Test_validLogging(t *testing.T) {
+	withSlowLog := func(slowLog ...SlowLogThresholds) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{Logging: &Logging{
+			Loggers: map[string]LogLevel{"org.elasticsearch.discovery": "DEBUG"},
+			SlowLog: slowLog,
+		}}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no logging: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name:         "loggers only: OK",
+			es:           withSlowLog(),
+			expectErrors: false,
+		},
+		{
+			name: "valid thresholds: OK",
+			es: withSlowLog(
+				SlowLogThresholds{Indices: []string{"logs-*"}, Thresholds: map[string]string{"search.query.warn": "10s", "search.fetch.info": "500ms"}},
+				SlowLogThresholds{Indices: []string{"metrics"}, Thresholds: map[string]string{"indexing.index.trace": "-1", "indexing.index.debug": "0"}},
+			),
+			expectErrors: false,
+		},
+		{
+			name:         "unknown threshold: NOT OK",
+			es:           withSlowLog(SlowLogThresholds{Indices: []string{"logs-*"}, Thresholds: map[string]string{"search.query.error": "10s"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "full setting name: NOT OK",
+			es:           withSlowLog(SlowLogThresholds{Indices: []string{"logs-*"}, Thresholds: map[string]string{"index.search.slowlog.threshold.query.warn": "10s"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "invalid time value: NOT OK",
+			es:           withSlowLog(SlowLogThresholds{Indices: []string{"logs-*"}, Thresholds: map[string]string{"search.query.warn": "10 seconds"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "excluded index: NOT OK",
+			es:           withSlowLog(SlowLogThresholds{Indices: []string{"*", "-logs-*"}, Thresholds: map[string]string{"search.query.warn": "10s"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "comma-separated indices: NOT OK",
+			es:           withSlowLog(SlowLogThresholds{Indices: []string{"logs,metrics"}, Thresholds: map[string]string{"search.query.warn": "10s"}}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validLogging(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validLogging(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.Logging)
+			}
+		})
+	}
+}
+
+func Test_validDiagnostics(t *testing.T) {
+	withDiagnostics := func(version string, diagnostics *Diagnostics) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{
+			Version:  version,
+			NodeSets: []NodeSet{{Name: "default"}, {Name: "data", Diagnostics: diagnostics}},
+		}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no diagnostics: OK",
+			es:           withDiagnostics("7.6.0", nil),
+			expectErrors: false,
+		},
+		{
+			name:         "diagnostics: OK",
+			es:           withDiagnostics("7.7.0", &Diagnostics{PersistentVolumeClaimName: "dumps", ThreadDumpAfterFailures: 6}),
+			expectErrors: false,
+		},
+		{
+			name:         "diagnostics before 7.7.0: NOT OK",
+			es:           withDiagnostics("7.6.2", &Diagnostics{PersistentVolumeClaimName: "dumps"}),
+			expectErrors: true,
+		},
+		{
+			name:         "no claim: NOT OK",
+			es:           withDiagnostics("7.7.0", &Diagnostics{ThreadDumpAfterFailures: 6}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validDiagnostics(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validDiagnostics(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_validRuntimeConfig(t *testing.T) {
+	withRuntimeConfig := func(data map[string]interface{}) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{RuntimeConfig: &commonv1.Config{Data: data}}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no runtime config: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name: "dynamic cluster settings: OK",
+			es: withRuntimeConfig(map[string]interface{}{
+				"indices.recovery.max_bytes_per_sec": "100mb",
+				"cluster":                            map[string]interface{}{"max_shards_per_node": 2000},
+			}),
+			expectErrors: false,
+		},
+		{
+			name:         "reserved setting: NOT OK",
+			es:           withRuntimeConfig(map[string]interface{}{"discovery.zen.minimum_master_nodes": 2}),
+			expectErrors: true,
+		},
+		{
+			name:         "remote cluster managed by the operator: NOT OK",
+			es:           withRuntimeConfig(map[string]interface{}{"cluster.remote.other.seeds": []interface{}{"other:9300"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "logger managed by the operator: NOT OK",
+			es:           withRuntimeConfig(map[string]interface{}{"logger": map[string]interface{}{"org.elasticsearch.discovery": "DEBUG"}}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validRuntimeConfig(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validRuntimeConfig(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.RuntimeConfig)
+			}
+		})
+	}
+}
+
+func Test_validDataMigrationPolicy(t *testing.T) {
+	withTimeout := func(timeout time.Duration) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{UpdateStrategy: UpdateStrategy{
+			DataMigration: &DataMigrationPolicy{Timeout: metav1.Duration{Duration: timeout}},
+		}}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no data migration policy: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name:         "positive timeout: OK",
+			es:           withTimeout(2 * time.Hour),
+			expectErrors: false,
+		},
+		{
+			name:         "no timeout: NOT OK",
+			es:           withTimeout(0),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validDataMigrationPolicy(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validDataMigrationPolicy(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.UpdateStrategy)
+			}
+		})
+	}
+}
+
+func Test_validSnapshotBeforeUpgrade(t *testing.T) {
+	withPolicy := func(v string, policy SnapshotBeforeUpgradePolicy) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{Version: v, UpdateStrategy: UpdateStrategy{
+			SnapshotBeforeUpgrade: &policy,
+		}}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no snapshot policy: OK",
+			es:           &Elasticsearch{Spec: ElasticsearchSpec{Version: "6.8.0"}},
+			expectErrors: false,
+		},
+		{
+			name:         "ad-hoc snapshot with the default max age: OK",
+			es:           withPolicy("6.8.0", SnapshotBeforeUpgradePolicy{Repository: "backups"}),
+			expectErrors: false,
+		},
+		{
+			name: "SLM policy: OK",
+			es: withPolicy("7.4.0", SnapshotBeforeUpgradePolicy{
+				Repository: "backups", SLMPolicy: "nightly", MaxAge: &metav1.Duration{Duration: 6 * time.Hour},
+			}),
+			expectErrors: false,
+		},
+		{
+			name:         "SLM policy before 7.4.0: NOT OK",
+			es:           withPolicy("7.3.2", SnapshotBeforeUpgradePolicy{Repository: "backups", SLMPolicy: "nightly"}),
+			expectErrors: true,
+		},
+		{
+			name: "no max age: NOT OK",
+			es: withPolicy("7.4.0", SnapshotBeforeUpgradePolicy{
+				Repository: "backups", MaxAge: &metav1.Duration{},
+			}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validSnapshotBeforeUpgrade(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validSnapshotBeforeUpgrade(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.UpdateStrategy)
+			}
+		})
+	}
+}
+
+func Test_validNodeDebug(t *testing.T) {
+	withSuspendedPods := func(names ...string) *Elasticsearch {
+		es := &Elasticsearch{Spec: ElasticsearchSpec{NodeDebug: &NodeDebug{}}}
+		for _, name := range names {
+			es.Spec.NodeDebug.SuspendedPods = append(es.Spec.NodeDebug.SuspendedPods, SuspendedPod{Name: name})
+		}
+		return es
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no node debug: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name:         "suspended Pods: OK",
+			es:           withSuspendedPods("es-default-0", "es-default-1"),
+			expectErrors: false,
+		},
+		{
+			name:         "unnamed Pod: NOT OK",
+			es:           withSuspendedPods("es-default-0", ""),
+			expectErrors: true,
+		},
+		{
+			name:         "Pod listed twice: NOT OK",
+			es:           withSuspendedPods("es-default-0", "es-default-0"),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validNodeDebug(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validNodeDebug(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeDebug)
+			}
+		})
+	}
+}
+
+func Test_validConfigSecretRefs(t *testing.T) {
+	withConfig := func(config map[string]interface{}) *Elasticsearch {
+		return &Elasticsearch{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+			Spec: ElasticsearchSpec{NodeSets: []NodeSet{
+				{Name: "default", Config: &commonv1.Config{Data: config}},
+			}},
+		}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no reference: OK",
+			es:           withConfig(map[string]interface{}{"node.store.allow_mmap": false}),
+			expectErrors: false,
+		},
+		{
+			name: "references in the namespace of the cluster: OK",
+			es: withConfig(map[string]interface{}{
+				"xpack.notification.email.account.work.smtp.password": "${secret:ns/smtp/password}",
+				"xpack.security.authc.realms.ldap.ldap1": map[string]interface{}{
+					"url": []interface{}{"ldaps://${secret:ns/ldap/host}:636"},
+				},
+			}),
+			expectErrors: false,
+		},
+		{
+			name:         "reference to another namespace: NOT OK",
+			es:           withConfig(map[string]interface{}{"smtp.password": "${secret:other/smtp/password}"}),
+			expectErrors: true,
+		},
+		{
+			name:         "malformed reference: NOT OK",
+			es:           withConfig(map[string]interface{}{"smtp.password": "${secret:smtp/password}"}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validConfigSecretRefs(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validConfigSecretRefs(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_validRetentionJobs(t *testing.T) {
+	withJobs := func(jobs ...RetentionJob) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{Maintenance: &Maintenance{RetentionJobs: jobs}}}
+	}
+	thirtyDays := &metav1.Duration{Duration: 30 * 24 * time.Hour}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no maintenance: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name: "retention jobs: OK",
+			es: withJobs(
+				RetentionJob{Name: "logs", Indices: []string{"logs-*"}, DeleteAfter: thirtyDays},
+				RetentionJob{Name: "metrics", Indices: []string{"metrics-*"}, Interval: &metav1.Duration{Duration: time.Hour}, ForceMerge: &ForceMerge{}},
+			),
+			expectErrors: false,
+		},
+		{
+			name: "duplicate names: NOT OK",
+			es: withJobs(
+				RetentionJob{Name: "logs", Indices: []string{"logs-*"}, DeleteAfter: thirtyDays},
+				RetentionJob{Name: "logs", Indices: []string{"logs-*"}, ForceMerge: &ForceMerge{}},
+			),
+			expectErrors: true,
+		},
+		{
+			name:         "no action: NOT OK",
+			es:           withJobs(RetentionJob{Name: "logs", Indices: []string{"logs-*"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "negative interval: NOT OK",
+			es:           withJobs(RetentionJob{Name: "logs", Indices: []string{"logs-*"}, DeleteAfter: thirtyDays, Interval: &metav1.Duration{Duration: -time.Hour}}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validRetentionJobs(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validRetentionJobs(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.Maintenance)
+			}
+		})
+	}
+}
+
 func Test_pvcModified(t *testing.T) {
 	current := getEsCluster()
 
@@ -715,3 +1701,391 @@ func getEsCluster() *Elasticsearch {
 		},
 	}
 }
+
+func Test_validScheduledScaling(t *testing.T) {
+	withWindows := func(windows ...ScalingWindow) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{NodeSets: []NodeSet{{Name: "hot", Count: 3, ScheduledScaling: windows}}}}
+	}
+	tenHours := metav1.Duration{Duration: 10 * time.Hour}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no scheduled scaling: OK",
+			es:           withWindows(),
+			expectErrors: false,
+		},
+		{
+			name: "scaling windows: OK",
+			es: withWindows(
+				ScalingWindow{Name: "business-hours", Schedule: "0 8 * * 1-5", Duration: tenHours, Count: pointer.Int32(6)},
+				ScalingWindow{Name: "month-end", Schedule: "0 0 28-31 * *", Duration: metav1.Duration{Duration: 168 * time.Hour}},
+			),
+			expectErrors: false,
+		},
+		{
+			name: "duplicate names: NOT OK",
+			es: withWindows(
+				ScalingWindow{Name: "peak", Schedule: "0 8 * * *", Duration: tenHours},
+				ScalingWindow{Name: "peak", Schedule: "0 20 * * *", Duration: tenHours},
+			),
+			expectErrors: true,
+		},
+		{
+			name:         "invalid schedule: NOT OK",
+			es:           withWindows(ScalingWindow{Name: "peak", Schedule: "0 25 * * *", Duration: tenHours}),
+			expectErrors: true,
+		},
+		{
+			name:         "no duration: NOT OK",
+			es:           withWindows(ScalingWindow{Name: "peak", Schedule: "0 8 * * *"}),
+			expectErrors: true,
+		},
+		{
+			name:         "duration too long: NOT OK",
+			es:           withWindows(ScalingWindow{Name: "peak", Schedule: "0 8 * * *", Duration: metav1.Duration{Duration: 200 * time.Hour}}),
+			expectErrors: true,
+		},
+		{
+			name: "autoscaled cluster without scheduled scaling: OK",
+			es: func() *Elasticsearch {
+				es := withWindows()
+				es.Annotations = map[string]string{AutoscalingSpecAnnotation: "{}"}
+				return es
+			}(),
+			expectErrors: false,
+		},
+		{
+			name: "scheduled scaling of an autoscaled cluster: NOT OK",
+			es: func() *Elasticsearch {
+				es := withWindows(ScalingWindow{Name: "peak", Schedule: "0 8 * * *", Duration: tenHours})
+				es.Annotations = map[string]string{AutoscalingSpecAnnotation: "{}"}
+				return es
+			}(),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validScheduledScaling(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validScheduledScaling(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_validResourceDetection(t *testing.T) {
+	withDetection := func(version string, detection *ResourceDetection) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{
+			Version:  version,
+			NodeSets: []NodeSet{{Name: "default"}, {Name: "ml", ResourceDetection: detection}},
+		}}
+	}
+	memory := func(q string) *resource.Quantity {
+		quantity := resource.MustParse(q)
+		return &quantity
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no resource detection: OK",
+			es:           withDetection("7.6.0", nil),
+			expectErrors: false,
+		},
+		{
+			name:         "resource detection: OK",
+			es:           withDetection("7.7.0", &ResourceDetection{Memory: memory("8Gi"), Processors: pointer.Int32(2)}),
+			expectErrors: false,
+		},
+		{
+			name:         "resource detection before 7.7.0: NOT OK",
+			es:           withDetection("7.6.2", &ResourceDetection{Processors: pointer.Int32(2)}),
+			expectErrors: true,
+		},
+		{
+			name:         "zero memory: NOT OK",
+			es:           withDetection("7.7.0", &ResourceDetection{Memory: memory("0")}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validResourceDetection(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validResourceDetection(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_validDataTiers(t *testing.T) {
+	withTier := func(version string, tier DataTier) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{
+			Version:  version,
+			NodeSets: []NodeSet{{Name: "default"}, {Name: "tiered", Tier: tier}},
+		}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no tier: OK",
+			es:           withTier("7.9.0", ""),
+			expectErrors: false,
+		},
+		{
+			name:         "warm tier before 7.10.0: OK",
+			es:           withTier("7.9.0", WarmTier),
+			expectErrors: false,
+		},
+		{
+			name:         "frozen tier: OK",
+			es:           withTier("7.12.0", FrozenTier),
+			expectErrors: false,
+		},
+		{
+			name:         "frozen tier before 7.12.0: NOT OK",
+			es:           withTier("7.11.2", FrozenTier),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validDataTiers(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validDataTiers(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
+
+func Test_hasMaster_dataTiers(t *testing.T) {
+	es := &Elasticsearch{Spec: ElasticsearchSpec{
+		Version:  "7.10.0",
+		NodeSets: []NodeSet{{Name: "hot", Count: 3, Tier: HotTier}, {Name: "warm", Count: 3, Tier: WarmTier}},
+	}}
+	// the roles of the data tiers exclude the master role
+	require.NotEmpty(t, hasMaster(es))
+	// unless the roles are configured
+	es.Spec.NodeSets[0].Config = &commonv1.Config{Data: map[string]interface{}{"node.roles": []string{"master", "data_hot"}}}
+	require.Empty(t, hasMaster(es))
+	// the roles of the data tiers do not exist before 7.10.0
+	es.Spec.NodeSets[0].Config = nil
+	es.Spec.Version = "7.9.0"
+	require.Empty(t, hasMaster(es))
+}
+
+func Test_validRename(t *testing.T) {
+	withAnnotations := func(annotations map[string]string) *Elasticsearch {
+		return &Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: annotations}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no rename: OK",
+			es:           withAnnotations(nil),
+			expectErrors: false,
+		},
+		{
+			name:         "rename to a name: OK",
+			es:           withAnnotations(map[string]string{RenameToAnnotation: "new"}),
+			expectErrors: false,
+		},
+		{
+			name:         "renamed from another namespace: OK",
+			es:           withAnnotations(map[string]string{RenamedFromAnnotation: "other/old"}),
+			expectErrors: false,
+		},
+		{
+			name:         "rename to itself: NOT OK",
+			es:           withAnnotations(map[string]string{RenameToAnnotation: "ns/es"}),
+			expectErrors: true,
+		},
+		{
+			name:         "invalid reference: NOT OK",
+			es:           withAnnotations(map[string]string{RenamedFromAnnotation: "a/b/c"}),
+			expectErrors: true,
+		},
+		{
+			name:         "invalid name: NOT OK",
+			es:           withAnnotations(map[string]string{RenameToAnnotation: "Not_Valid"}),
+			expectErrors: true,
+		},
+		{
+			name:         "renamed both ways: NOT OK",
+			es:           withAnnotations(map[string]string{RenameToAnnotation: "new", RenamedFromAnnotation: "old"}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validRename(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validRename(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Annotations)
+			}
+		})
+	}
+}
+
+func Test_renamedFromImmutable(t *testing.T) {
+	renamedFrom := func(value string) *Elasticsearch {
+		es := &Elasticsearch{}
+		if value != "" {
+			es.Annotations = map[string]string{RenamedFromAnnotation: value}
+		}
+		return es
+	}
+	require.Empty(t, renamedFromImmutable(renamedFrom(""), renamedFrom("old")))
+	require.Empty(t, renamedFromImmutable(renamedFrom("old"), renamedFrom("old")))
+	require.Empty(t, renamedFromImmutable(renamedFrom("old"), renamedFrom("")))
+	require.NotEmpty(t, renamedFromImmutable(renamedFrom("old"), renamedFrom("other")))
+}
+
+func Test_validPriorityClasses(t *testing.T) {
+	value := func(v int32) *int32 { return &v }
+	policy := func(p corev1.PreemptionPolicy) *corev1.PreemptionPolicy { return &p }
+	withClasses := func(classes PriorityClasses) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{PriorityClasses: &classes}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no priority classes: OK",
+			es:           &Elasticsearch{},
+			expectErrors: false,
+		},
+		{
+			name: "ordered priority classes: OK",
+			es: withClasses(PriorityClasses{
+				Master:       &RolePriorityClass{Name: "es-master", Value: value(HighestUserDefinablePriority)},
+				Data:         &RolePriorityClass{Name: "es-data", Value: value(1000), PreemptionPolicy: policy(corev1.PreemptNever)},
+				Coordinating: &RolePriorityClass{Name: "es-data", Value: value(1000), PreemptionPolicy: policy(corev1.PreemptNever)},
+			}),
+			expectErrors: false,
+		},
+		{
+			name: "existing priority classes, without value: OK",
+			es: withClasses(PriorityClasses{
+				Master:       &RolePriorityClass{Name: "critical"},
+				Coordinating: &RolePriorityClass{Name: "es-coordinating", Value: value(10)},
+			}),
+			expectErrors: false,
+		},
+		{
+			name: "coordinating nodes above the master nodes: NOT OK",
+			es: withClasses(PriorityClasses{
+				Master:       &RolePriorityClass{Name: "es-master", Value: value(10)},
+				Data:         &RolePriorityClass{Name: "es-data"},
+				Coordinating: &RolePriorityClass{Name: "es-coordinating", Value: value(100)},
+			}),
+			expectErrors: true,
+		},
+		{
+			name:         "value reserved to the system: NOT OK",
+			es:           withClasses(PriorityClasses{Master: &RolePriorityClass{Name: "es-master", Value: value(2000000000)}}),
+			expectErrors: true,
+		},
+		{
+			name:         "system name: NOT OK",
+			es:           withClasses(PriorityClasses{Master: &RolePriorityClass{Name: "system-cluster-critical"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "invalid name: NOT OK",
+			es:           withClasses(PriorityClasses{Master: &RolePriorityClass{Name: "ES_Master"}}),
+			expectErrors: true,
+		},
+		{
+			name:         "unknown preemption policy: NOT OK",
+			es:           withClasses(PriorityClasses{Master: &RolePriorityClass{Name: "es-master", PreemptionPolicy: policy("Always")}}),
+			expectErrors: true,
+		},
+		{
+			name: "same name with different values: NOT OK",
+			es: withClasses(PriorityClasses{
+				Master: &RolePriorityClass{Name: "es", Value: value(100)},
+				Data:   &RolePriorityClass{Name: "es", Value: value(10)},
+			}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validPriorityClasses(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validPriorityClasses(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.PriorityClasses)
+			}
+		})
+	}
+}
+
+func Test_validRuntimeClasses(t *testing.T) {
+	kata := "kata"
+	gvisor := "gvisor"
+	withNodeSet := func(nodeSet NodeSet) *Elasticsearch {
+		return &Elasticsearch{Spec: ElasticsearchSpec{NodeSets: []NodeSet{nodeSet}}}
+	}
+	tests := []struct {
+		name         string
+		es           *Elasticsearch
+		expectErrors bool
+	}{
+		{
+			name:         "no runtime class: OK",
+			es:           withNodeSet(NodeSet{Name: "default"}),
+			expectErrors: false,
+		},
+		{
+			name:         "runtime class: OK",
+			es:           withNodeSet(NodeSet{Name: "default", RuntimeClassName: kata}),
+			expectErrors: false,
+		},
+		{
+			name: "same runtime class in the Pod template: OK",
+			es: withNodeSet(NodeSet{Name: "default", RuntimeClassName: kata, PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{RuntimeClassName: &kata},
+			}}),
+			expectErrors: false,
+		},
+		{
+			name: "other runtime class in the Pod template: NOT OK",
+			es: withNodeSet(NodeSet{Name: "default", RuntimeClassName: kata, PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{RuntimeClassName: &gvisor},
+			}}),
+			expectErrors: true,
+		},
+		{
+			name:         "invalid name: NOT OK",
+			es:           withNodeSet(NodeSet{Name: "default", RuntimeClassName: "Kata_QEMU"}),
+			expectErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validRuntimeClasses(tt.es)
+			actualErrors := len(actual) > 0
+			if tt.expectErrors != actualErrors {
+				t.Errorf("failed validRuntimeClasses(). Name: %v, actual %v, wanted: %v, value: %v", tt.name, actual, tt.expectErrors, tt.es.Spec.NodeSets)
+			}
+		})
+	}
+}
@@ -6,11 +6,13 @@ package v1
 
 import (
 	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 var warnings = []validation{
 	noUnsupportedSettings,
+	satisfiableTopologySpread,
 }
 
 func noUnsupportedSettings(es *Elasticsearch) field.ErrorList {
@@ -32,6 +34,47 @@ func noUnsupportedSettings(es *Elasticsearch) field.ErrorList {
 	return errs
 }
 
+// satisfiableTopologySpread checks that the topology spread of the master and data nodes is compatible with the
+// number of master nodes and with the pod disruption budget.
+func satisfiableTopologySpread(es *Elasticsearch) field.ErrorList {
+	if es.Spec.TopologySpread == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	// zones are only known from the NodeSets spread across zones
+	zones := map[string]struct{}{}
+	masters := int32(0)
+	for _, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.ZoneSpread != nil {
+			for _, zone := range nodeSet.ZoneSpread.Zones {
+				zones[zone] = struct{}{}
+			}
+		}
+		cfg, err := UnpackConfig(nodeSetConfig(es, nodeSet))
+		if err != nil {
+			// reported by the validations
+			continue
+		}
+		if cfg.Node.Master {
+			masters += nodeSet.TotalCount()
+		}
+	}
+	if len(zones) > 0 && masters < int32(len(zones)) {
+		errs = append(errs, field.Invalid(field.NewPath("spec").Child("topologySpread"), masters, fewerMastersThanZonesMsg))
+	}
+
+	pdb := es.Spec.PodDisruptionBudget
+	if pdb != nil && !pdb.IsDisabled() {
+		maxUnavailable := pdb.Spec.MaxUnavailable
+		minAvailable := pdb.Spec.MinAvailable
+		if (maxUnavailable != nil && maxUnavailable.Type == intstr.Int && maxUnavailable.IntVal == 0) ||
+			(minAvailable != nil && minAvailable.Type == intstr.Int && minAvailable.IntVal >= es.Spec.NodeCount()) {
+			errs = append(errs, field.Invalid(field.NewPath("spec").Child("podDisruptionBudget"), pdb.Spec, noDisruptionAllowedMsg))
+		}
+	}
+	return errs
+}
+
 func (es *Elasticsearch) CheckForWarnings() error {
 	warnings := es.check(warnings)
 	if len(warnings) > 0 {
@@ -7,6 +7,10 @@ package v1
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 )
 
@@ -134,3 +138,93 @@ func Test_noUnsupportedSettings(t *testing.T) {
 		})
 	}
 }
+
+func Test_satisfiableTopologySpread(t *testing.T) {
+	dataConfig := &commonv1.Config{Data: map[string]interface{}{"node.master": false}}
+	pdb := func(spec v1beta1.PodDisruptionBudgetSpec) *commonv1.PodDisruptionBudgetTemplate {
+		return &commonv1.PodDisruptionBudgetTemplate{Spec: spec}
+	}
+	intOrString := func(i int) *intstr.IntOrString {
+		v := intstr.FromInt(i)
+		return &v
+	}
+	tests := []struct {
+		name     string
+		spec     ElasticsearchSpec
+		wantErrs int
+	}{
+		{
+			name: "no topology spread",
+			spec: ElasticsearchSpec{NodeSets: []NodeSet{
+				{Name: "master", Count: 1},
+				{Name: "data", Count: 1, Config: dataConfig, ZoneSpread: &ZoneSpread{Zones: []string{"a", "b", "c"}}},
+			}},
+		},
+		{
+			name: "as many masters as zones",
+			spec: ElasticsearchSpec{
+				TopologySpread: &TopologySpread{},
+				NodeSets: []NodeSet{
+					{Name: "master", Count: 1, ZoneSpread: &ZoneSpread{Zones: []string{"a", "b", "c"}}},
+				},
+			},
+		},
+		{
+			name: "fewer masters than zones",
+			spec: ElasticsearchSpec{
+				TopologySpread: &TopologySpread{},
+				NodeSets: []NodeSet{
+					{Name: "master", Count: 2},
+					{Name: "data", Count: 1, Config: dataConfig, ZoneSpread: &ZoneSpread{Zones: []string{"a", "b", "c"}}},
+				},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "zones unknown",
+			spec: ElasticsearchSpec{
+				TopologySpread: &TopologySpread{},
+				NodeSets:       []NodeSet{{Name: "master", Count: 1}},
+			},
+		},
+		{
+			name: "default pod disruption budget",
+			spec: ElasticsearchSpec{
+				TopologySpread:      &TopologySpread{},
+				PodDisruptionBudget: pdb(v1beta1.PodDisruptionBudgetSpec{MaxUnavailable: intOrString(1)}),
+				NodeSets:            []NodeSet{{Name: "master", Count: 3}},
+			},
+		},
+		{
+			name: "pod disruption budget without unavailable Pods",
+			spec: ElasticsearchSpec{
+				TopologySpread:      &TopologySpread{},
+				PodDisruptionBudget: pdb(v1beta1.PodDisruptionBudgetSpec{MaxUnavailable: intOrString(0)}),
+				NodeSets:            []NodeSet{{Name: "master", Count: 3}},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "pod disruption budget requiring all Pods",
+			spec: ElasticsearchSpec{
+				TopologySpread:      &TopologySpread{},
+				PodDisruptionBudget: pdb(v1beta1.PodDisruptionBudgetSpec{MinAvailable: intOrString(3)}),
+				NodeSets:            []NodeSet{{Name: "master", Count: 3}},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "disabled pod disruption budget",
+			spec: ElasticsearchSpec{
+				TopologySpread:      &TopologySpread{},
+				PodDisruptionBudget: &commonv1.PodDisruptionBudgetTemplate{},
+				NodeSets:            []NodeSet{{Name: "master", Count: 3}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Len(t, satisfiableTopologySpread(&Elasticsearch{Spec: tt.spec}), tt.wantErrs)
+		})
+	}
+}
@@ -45,6 +45,10 @@ func (es *Elasticsearch) ValidateUpdate(old runtime.Object) error {
 		return errors.New("cannot cast old object to Elasticsearch type")
 	}
 
+	if es.ClassPending() {
+		// validated once the class is applied
+		return nil
+	}
 	var errs field.ErrorList
 	for _, val := range updateValidations {
 		if err := val(oldEs, es); err != nil {
@@ -60,6 +64,10 @@ func (es *Elasticsearch) ValidateUpdate(old runtime.Object) error {
 }
 
 func (es *Elasticsearch) validateElasticsearch() error {
+	if es.ClassPending() {
+		// validated once the class is applied
+		return nil
+	}
 	errs := es.check(validations)
 	if len(errs) > 0 {
 		return apierrors.NewInvalid(
@@ -11,9 +11,113 @@ package v1
 import (
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisFiles) DeepCopyInto(out *AnalysisFiles) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisFiles.
+func (in *AnalysisFiles) DeepCopy() *AnalysisFiles {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisFiles)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditIgnoreFilter) DeepCopyInto(out *AuditIgnoreFilter) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Realms != nil {
+		in, out := &in.Realms, &out.Realms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditIgnoreFilter.
+func (in *AuditIgnoreFilter) DeepCopy() *AuditIgnoreFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditIgnoreFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogging) DeepCopyInto(out *AuditLogging) {
+	*out = *in
+	if in.IncludeEvents != nil {
+		in, out := &in.IncludeEvents, &out.IncludeEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeEvents != nil {
+		in, out := &in.ExcludeEvents, &out.ExcludeEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IgnoreFilters != nil {
+		in, out := &in.IgnoreFilters, &out.IgnoreFilters
+		*out = make([]AuditIgnoreFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Shipping != nil {
+		in, out := &in.Shipping, &out.Shipping
+		*out = new(AuditShipping)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogging.
+func (in *AuditLogging) DeepCopy() *AuditLogging {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogging)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditShipping) DeepCopyInto(out *AuditShipping) {
+	*out = *in
+	out.ElasticsearchRef = in.ElasticsearchRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditShipping.
+func (in *AuditShipping) DeepCopy() *AuditShipping {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditShipping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Auth) DeepCopyInto(out *Auth) {
 	*out = *in
@@ -27,284 +131,1801 @@ func (in *Auth) DeepCopyInto(out *Auth) {
 		*out = make([]FileRealmSource, len(*in))
 		copy(*out, *in)
 	}
+	if in.SAML != nil {
+		in, out := &in.SAML, &out.SAML
+		*out = make([]SAMLRealm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = make([]OIDCRealm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(PasswordRotation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Auth.
+func (in *Auth) DeepCopy() *Auth {
+	if in == nil {
+		return nil
+	}
+	out := new(Auth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoFollowPattern) DeepCopyInto(out *AutoFollowPattern) {
+	*out = *in
+	if in.LeaderIndexPatterns != nil {
+		in, out := &in.LeaderIndexPatterns, &out.LeaderIndexPatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoFollowPattern.
+func (in *AutoFollowPattern) DeepCopy() *AutoFollowPattern {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoFollowPattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheWarmup) DeepCopyInto(out *CacheWarmup) {
+	*out = *in
+	if in.Searches != nil {
+		in, out := &in.Searches, &out.Searches
+		*out = make([]WarmupSearch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheWarmup.
+func (in *CacheWarmup) DeepCopy() *CacheWarmup {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheWarmup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeBudget) DeepCopyInto(out *ChangeBudget) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeBudget.
+func (in *ChangeBudget) DeepCopy() *ChangeBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSettings) DeepCopyInto(out *ClusterSettings) {
+	*out = *in
+	if in.InitialMasterNodes != nil {
+		in, out := &in.InitialMasterNodes, &out.InitialMasterNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSettings.
+func (in *ClusterSettings) DeepCopy() *ClusterSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CrossClusterReplication) DeepCopyInto(out *CrossClusterReplication) {
+	*out = *in
+	if in.FollowerIndices != nil {
+		in, out := &in.FollowerIndices, &out.FollowerIndices
+		*out = make([]FollowerIndex, len(*in))
+		copy(*out, *in)
+	}
+	if in.AutoFollowPatterns != nil {
+		in, out := &in.AutoFollowPatterns, &out.AutoFollowPatterns
+		*out = make([]AutoFollowPattern, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrossClusterReplication.
+func (in *CrossClusterReplication) DeepCopy() *CrossClusterReplication {
+	if in == nil {
+		return nil
+	}
+	out := new(CrossClusterReplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMigrationPolicy) DeepCopyInto(out *DataMigrationPolicy) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMigrationPolicy.
+func (in *DataMigrationPolicy) DeepCopy() *DataMigrationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMigrationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Diagnostics) DeepCopyInto(out *Diagnostics) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Diagnostics.
+func (in *Diagnostics) DeepCopy() *Diagnostics {
+	if in == nil {
+		return nil
+	}
+	out := new(Diagnostics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskPressure) DeepCopyInto(out *DiskPressure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskPressure.
+func (in *DiskPressure) DeepCopy() *DiskPressure {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskPressure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskReport) DeepCopyInto(out *DiskReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskReport.
+func (in *DiskReport) DeepCopy() *DiskReport {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Elasticsearch) DeepCopyInto(out *Elasticsearch) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	if in.assocConf != nil {
+		in, out := &in.assocConf, &out.assocConf
+		*out = new(commonv1.AssociationConf)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Elasticsearch.
+func (in *Elasticsearch) DeepCopy() *Elasticsearch {
+	if in == nil {
+		return nil
+	}
+	out := new(Elasticsearch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Elasticsearch) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClass) DeepCopyInto(out *ElasticsearchClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClass.
+func (in *ElasticsearchClass) DeepCopy() *ElasticsearchClass {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClassList) DeepCopyInto(out *ElasticsearchClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClassList.
+func (in *ElasticsearchClassList) DeepCopy() *ElasticsearchClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClassSpec) DeepCopyInto(out *ElasticsearchClassSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClassSpec.
+func (in *ElasticsearchClassSpec) DeepCopy() *ElasticsearchClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClone) DeepCopyInto(out *ElasticsearchClone) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClone.
+func (in *ElasticsearchClone) DeepCopy() *ElasticsearchClone {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchClone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCloneList) DeepCopyInto(out *ElasticsearchCloneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchClone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCloneList.
+func (in *ElasticsearchCloneList) DeepCopy() *ElasticsearchCloneList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCloneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchCloneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCloneSpec) DeepCopyInto(out *ElasticsearchCloneSpec) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCloneSpec.
+func (in *ElasticsearchCloneSpec) DeepCopy() *ElasticsearchCloneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCloneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCloneStatus) DeepCopyInto(out *ElasticsearchCloneStatus) {
+	*out = *in
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCloneStatus.
+func (in *ElasticsearchCloneStatus) DeepCopy() *ElasticsearchCloneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCloneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchList) DeepCopyInto(out *ElasticsearchList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Elasticsearch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchList.
+func (in *ElasticsearchList) DeepCopy() *ElasticsearchList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchReport) DeepCopyInto(out *ElasticsearchReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchReport.
+func (in *ElasticsearchReport) DeepCopy() *ElasticsearchReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchReportList) DeepCopyInto(out *ElasticsearchReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchReportList.
+func (in *ElasticsearchReportList) DeepCopy() *ElasticsearchReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchReportStatus) DeepCopyInto(out *ElasticsearchReportStatus) {
+	*out = *in
+	in.ObservedAt.DeepCopyInto(&out.ObservedAt)
+	if in.Disk != nil {
+		in, out := &in.Disk, &out.Disk
+		*out = new(DiskReport)
+		**out = **in
+	}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = new(ShardsReport)
+		**out = **in
+	}
+	if in.JVM != nil {
+		in, out := &in.JVM, &out.JVM
+		*out = new(JVMReport)
+		**out = **in
+	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(ReplicationReport)
+		**out = **in
+	}
+	if in.Recommendations != nil {
+		in, out := &in.Recommendations, &out.Recommendations
+		*out = make([]SizingRecommendation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchReportStatus.
+func (in *ElasticsearchReportStatus) DeepCopy() *ElasticsearchReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchSettings) DeepCopyInto(out *ElasticsearchSettings) {
+	*out = *in
+	out.Node = in.Node
+	in.Cluster.DeepCopyInto(&out.Cluster)
+	if in.NodeRoles != nil {
+		in, out := &in.NodeRoles, &out.NodeRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSettings.
+func (in *ElasticsearchSettings) DeepCopy() *ElasticsearchSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchSpec) DeepCopyInto(out *ElasticsearchSpec) {
+	*out = *in
+	in.HTTP.DeepCopyInto(&out.HTTP)
+	in.Transport.DeepCopyInto(&out.Transport)
+	if in.NodeSets != nil {
+		in, out := &in.NodeSets, &out.NodeSets
+		*out = make([]NodeSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(commonv1.PodDisruptionBudgetTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpread != nil {
+		in, out := &in.TopologySpread, &out.TopologySpread
+		*out = new(TopologySpread)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = new(LifecycleHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = new(Plugins)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AnalysisFiles != nil {
+		in, out := &in.AnalysisFiles, &out.AnalysisFiles
+		*out = make([]AnalysisFiles, len(*in))
+		copy(*out, *in)
+	}
+	if in.GeoIP != nil {
+		in, out := &in.GeoIP, &out.GeoIP
+		*out = new(GeoIP)
+		**out = **in
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+	if in.SecureSettings != nil {
+		in, out := &in.SecureSettings, &out.SecureSettings
+		*out = make([]commonv1.SecretSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemoteClusters != nil {
+		in, out := &in.RemoteClusters, &out.RemoteClusters
+		*out = make([]RemoteCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.RemoteClusterServer = in.RemoteClusterServer
+	if in.CrossClusterReplication != nil {
+		in, out := &in.CrossClusterReplication, &out.CrossClusterReplication
+		*out = new(CrossClusterReplication)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(AuditLogging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceMesh != nil {
+		in, out := &in.ServiceMesh, &out.ServiceMesh
+		*out = new(ServiceMesh)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(Logging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuntimeConfig != nil {
+		in, out := &in.RuntimeConfig, &out.RuntimeConfig
+		*out = (*in).DeepCopy()
+	}
+	if in.DiskPressure != nil {
+		in, out := &in.DiskPressure, &out.DiskPressure
+		*out = new(DiskPressure)
+		**out = **in
+	}
+	if in.CriticalIndices != nil {
+		in, out := &in.CriticalIndices, &out.CriticalIndices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeDebug != nil {
+		in, out := &in.NodeDebug, &out.NodeDebug
+		*out = new(NodeDebug)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(Maintenance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VirtualMemory != nil {
+		in, out := &in.VirtualMemory, &out.VirtualMemory
+		*out = new(VirtualMemory)
+		**out = **in
+	}
+	if in.PriorityClasses != nil {
+		in, out := &in.PriorityClasses, &out.PriorityClasses
+		*out = new(PriorityClasses)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSpec.
+func (in *ElasticsearchSpec) DeepCopy() *ElasticsearchSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchStatus) DeepCopyInto(out *ElasticsearchStatus) {
+	*out = *in
+	out.ReconcilerStatus = in.ReconcilerStatus
+	if in.ImageDigests != nil {
+		in, out := &in.ImageDigests, &out.ImageDigests
+		*out = make([]ImageDigest, len(*in))
+		copy(*out, *in)
+	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(LoggingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuntimeSettings != nil {
+		in, out := &in.RuntimeSettings, &out.RuntimeSettings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DataMigration != nil {
+		in, out := &in.DataMigration, &out.DataMigration
+		*out = make([]NodeDataMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SuspendedPods != nil {
+		in, out := &in.SuspendedPods, &out.SuspendedPods
+		*out = make([]SuspendedPodStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetentionJobs != nil {
+		in, out := &in.RetentionJobs, &out.RetentionJobs
+		*out = make([]RetentionJobStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(commonv1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchStatus.
+func (in *ElasticsearchStatus) DeepCopy() *ElasticsearchStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileRealmSource) DeepCopyInto(out *FileRealmSource) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileRealmSource.
+func (in *FileRealmSource) DeepCopy() *FileRealmSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FileRealmSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FollowerIndex) DeepCopyInto(out *FollowerIndex) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FollowerIndex.
+func (in *FollowerIndex) DeepCopy() *FollowerIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(FollowerIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForceMerge) DeepCopyInto(out *ForceMerge) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForceMerge.
+func (in *ForceMerge) DeepCopy() *ForceMerge {
+	if in == nil {
+		return nil
+	}
+	out := new(ForceMerge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GeoIP) DeepCopyInto(out *GeoIP) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeoIP.
+func (in *GeoIP) DeepCopy() *GeoIP {
+	if in == nil {
+		return nil
+	}
+	out := new(GeoIP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageDigest) DeepCopyInto(out *ImageDigest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageDigest.
+func (in *ImageDigest) DeepCopy() *ImageDigest {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageDigest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JVMReport) DeepCopyInto(out *JVMReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JVMReport.
+func (in *JVMReport) DeepCopy() *JVMReport {
+	if in == nil {
+		return nil
+	}
+	out := new(JVMReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LifecycleHooks) DeepCopyInto(out *LifecycleHooks) {
+	*out = *in
+	if in.PreStop != nil {
+		in, out := &in.PreStop, &out.PreStop
+		*out = new(PreStopHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostStart != nil {
+		in, out := &in.PostStart, &out.PostStart
+		*out = new(PostStartHook)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LifecycleHooks.
+func (in *LifecycleHooks) DeepCopy() *LifecycleHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(LifecycleHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Logging) DeepCopyInto(out *Logging) {
+	*out = *in
+	if in.Loggers != nil {
+		in, out := &in.Loggers, &out.Loggers
+		*out = make(map[string]LogLevel, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SlowLog != nil {
+		in, out := &in.SlowLog, &out.SlowLog
+		*out = make([]SlowLogThresholds, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Logging.
+func (in *Logging) DeepCopy() *Logging {
+	if in == nil {
+		return nil
+	}
+	out := new(Logging)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggingStatus) DeepCopyInto(out *LoggingStatus) {
+	*out = *in
+	if in.Loggers != nil {
+		in, out := &in.Loggers, &out.Loggers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggingStatus.
+func (in *LoggingStatus) DeepCopy() *LoggingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Maintenance) DeepCopyInto(out *Maintenance) {
+	*out = *in
+	if in.RetentionJobs != nil {
+		in, out := &in.RetentionJobs, &out.RetentionJobs
+		*out = make([]RetentionJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Maintenance.
+func (in *Maintenance) DeepCopy() *Maintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(Maintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDataMigration) DeepCopyInto(out *NodeDataMigration) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.LastProgressTime.DeepCopyInto(&out.LastProgressTime)
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDataMigration.
+func (in *NodeDataMigration) DeepCopy() *NodeDataMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDataMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDebug) DeepCopyInto(out *NodeDebug) {
+	*out = *in
+	if in.SuspendedPods != nil {
+		in, out := &in.SuspendedPods, &out.SuspendedPods
+		*out = make([]SuspendedPod, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDebug.
+func (in *NodeDebug) DeepCopy() *NodeDebug {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDebug)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReindexDestination) DeepCopyInto(out *ReindexDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReindexDestination.
+func (in *ReindexDestination) DeepCopy() *ReindexDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(ReindexDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReindexJob) DeepCopyInto(out *ReindexJob) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReindexJob.
+func (in *ReindexJob) DeepCopy() *ReindexJob {
+	if in == nil {
+		return nil
+	}
+	out := new(ReindexJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReindexJob) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReindexJobList) DeepCopyInto(out *ReindexJobList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReindexJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReindexJobList.
+func (in *ReindexJobList) DeepCopy() *ReindexJobList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReindexJobList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReindexJobList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReindexJobSpec) DeepCopyInto(out *ReindexJobSpec) {
+	*out = *in
+	out.ElasticsearchRef = in.ElasticsearchRef
+	in.Source.DeepCopyInto(&out.Source)
+	out.Destination = in.Destination
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReindexJobSpec.
+func (in *ReindexJobSpec) DeepCopy() *ReindexJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReindexJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReindexJobStatus) DeepCopyInto(out *ReindexJobStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReindexJobStatus.
+func (in *ReindexJobStatus) DeepCopy() *ReindexJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReindexJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReindexSource) DeepCopyInto(out *ReindexSource) {
+	*out = *in
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Query != nil {
+		in, out := &in.Query, &out.Query
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReindexSource.
+func (in *ReindexSource) DeepCopy() *ReindexSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ReindexSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDetection) DeepCopyInto(out *ResourceDetection) {
+	*out = *in
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Processors != nil {
+		in, out := &in.Processors, &out.Processors
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDetection.
+func (in *ResourceDetection) DeepCopy() *ResourceDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionJob) DeepCopyInto(out *RetentionJob) {
+	*out = *in
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.DeleteAfter != nil {
+		in, out := &in.DeleteAfter, &out.DeleteAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ForceMerge != nil {
+		in, out := &in.ForceMerge, &out.ForceMerge
+		*out = new(ForceMerge)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionJob.
+func (in *RetentionJob) DeepCopy() *RetentionJob {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionJobStatus) DeepCopyInto(out *RetentionJobStatus) {
+	*out = *in
+	in.LastRunTime.DeepCopyInto(&out.LastRunTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionJobStatus.
+func (in *RetentionJobStatus) DeepCopy() *RetentionJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingWindow) DeepCopyInto(out *ScalingWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingWindow.
+func (in *ScalingWindow) DeepCopy() *ScalingWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SizingRecommendation) DeepCopyInto(out *SizingRecommendation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SizingRecommendation.
+func (in *SizingRecommendation) DeepCopy() *SizingRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(SizingRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlowLogThresholds) DeepCopyInto(out *SlowLogThresholds) {
+	*out = *in
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlowLogThresholds.
+func (in *SlowLogThresholds) DeepCopy() *SlowLogThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(SlowLogThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotBeforeUpgradePolicy) DeepCopyInto(out *SnapshotBeforeUpgradePolicy) {
+	*out = *in
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotBeforeUpgradePolicy.
+func (in *SnapshotBeforeUpgradePolicy) DeepCopy() *SnapshotBeforeUpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotBeforeUpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Node) DeepCopyInto(out *Node) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Node.
+func (in *Node) DeepCopy() *Node {
+	if in == nil {
+		return nil
+	}
+	out := new(Node)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSet) DeepCopyInto(out *NodeSet) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = (*in).DeepCopy()
+	}
+	in.PodTemplate.DeepCopyInto(&out.PodTemplate)
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]corev1.PersistentVolumeClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ZoneSpread != nil {
+		in, out := &in.ZoneSpread, &out.ZoneSpread
+		*out = new(ZoneSpread)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JVMOptions != nil {
+		in, out := &in.JVMOptions, &out.JVMOptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Diagnostics != nil {
+		in, out := &in.Diagnostics, &out.Diagnostics
+		*out = new(Diagnostics)
+		**out = **in
+	}
+	if in.ScheduledScaling != nil {
+		in, out := &in.ScheduledScaling, &out.ScheduledScaling
+		*out = make([]ScalingWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceDetection != nil {
+		in, out := &in.ResourceDetection, &out.ResourceDetection
+		*out = new(ResourceDetection)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSet.
+func (in *NodeSet) DeepCopy() *NodeSet {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRealm) DeepCopyInto(out *OIDCRealm) {
+	*out = *in
+	in.ClientSecretKeyRef.DeepCopyInto(&out.ClientSecretKeyRef)
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRealm.
+func (in *OIDCRealm) DeepCopy() *OIDCRealm {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRealm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordRotation) DeepCopyInto(out *PasswordRotation) {
+	*out = *in
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordRotation.
+func (in *PasswordRotation) DeepCopy() *PasswordRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Plugin) DeepCopyInto(out *Plugin) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Plugin.
+func (in *Plugin) DeepCopy() *Plugin {
+	if in == nil {
+		return nil
+	}
+	out := new(Plugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginBundle) DeepCopyInto(out *PluginBundle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginBundle.
+func (in *PluginBundle) DeepCopy() *PluginBundle {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginBundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Plugins) DeepCopyInto(out *Plugins) {
+	*out = *in
+	if in.Install != nil {
+		in, out := &in.Install, &out.Install
+		*out = make([]Plugin, len(*in))
+		copy(*out, *in)
+	}
+	if in.Bundle != nil {
+		in, out := &in.Bundle, &out.Bundle
+		*out = new(PluginBundle)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Plugins.
+func (in *Plugins) DeepCopy() *Plugins {
+	if in == nil {
+		return nil
+	}
+	out := new(Plugins)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Auth.
-func (in *Auth) DeepCopy() *Auth {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostStartHook) DeepCopyInto(out *PostStartHook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostStartHook.
+func (in *PostStartHook) DeepCopy() *PostStartHook {
 	if in == nil {
 		return nil
 	}
-	out := new(Auth)
+	out := new(PostStartHook)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ChangeBudget) DeepCopyInto(out *ChangeBudget) {
+func (in *PreStopHook) DeepCopyInto(out *PreStopHook) {
 	*out = *in
-	if in.MaxUnavailable != nil {
-		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+	if in.MaxWaitSeconds != nil {
+		in, out := &in.MaxWaitSeconds, &out.MaxWaitSeconds
 		*out = new(int32)
 		**out = **in
 	}
-	if in.MaxSurge != nil {
-		in, out := &in.MaxSurge, &out.MaxSurge
+	if in.AdditionalWaitSeconds != nil {
+		in, out := &in.AdditionalWaitSeconds, &out.AdditionalWaitSeconds
 		*out = new(int32)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeBudget.
-func (in *ChangeBudget) DeepCopy() *ChangeBudget {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreStopHook.
+func (in *PreStopHook) DeepCopy() *PreStopHook {
 	if in == nil {
 		return nil
 	}
-	out := new(ChangeBudget)
+	out := new(PreStopHook)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterSettings) DeepCopyInto(out *ClusterSettings) {
+func (in *PriorityClasses) DeepCopyInto(out *PriorityClasses) {
 	*out = *in
-	if in.InitialMasterNodes != nil {
-		in, out := &in.InitialMasterNodes, &out.InitialMasterNodes
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.Master != nil {
+		in, out := &in.Master, &out.Master
+		*out = new(RolePriorityClass)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = new(RolePriorityClass)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Coordinating != nil {
+		in, out := &in.Coordinating, &out.Coordinating
+		*out = new(RolePriorityClass)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSettings.
-func (in *ClusterSettings) DeepCopy() *ClusterSettings {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityClasses.
+func (in *PriorityClasses) DeepCopy() *PriorityClasses {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterSettings)
+	out := new(PriorityClasses)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Elasticsearch) DeepCopyInto(out *Elasticsearch) {
+func (in *RealmFileSource) DeepCopyInto(out *RealmFileSource) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Elasticsearch.
-func (in *Elasticsearch) DeepCopy() *Elasticsearch {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmFileSource.
+func (in *RealmFileSource) DeepCopy() *RealmFileSource {
 	if in == nil {
 		return nil
 	}
-	out := new(Elasticsearch)
+	out := new(RealmFileSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Elasticsearch) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchList) DeepCopyInto(out *ElasticsearchList) {
+func (in *RemoteCluster) DeepCopyInto(out *RemoteCluster) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Elasticsearch, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out.ElasticsearchRef = in.ElasticsearchRef
+	if in.APIKey != nil {
+		in, out := &in.APIKey, &out.APIKey
+		*out = new(RemoteClusterAPIKey)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchList.
-func (in *ElasticsearchList) DeepCopy() *ElasticsearchList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteCluster.
+func (in *RemoteCluster) DeepCopy() *RemoteCluster {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchList)
+	out := new(RemoteCluster)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticsearchList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchSettings) DeepCopyInto(out *ElasticsearchSettings) {
+func (in *RemoteClusterAPIKey) DeepCopyInto(out *RemoteClusterAPIKey) {
 	*out = *in
-	out.Node = in.Node
-	in.Cluster.DeepCopyInto(&out.Cluster)
+	in.Access.DeepCopyInto(&out.Access)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSettings.
-func (in *ElasticsearchSettings) DeepCopy() *ElasticsearchSettings {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterAPIKey.
+func (in *RemoteClusterAPIKey) DeepCopy() *RemoteClusterAPIKey {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchSettings)
+	out := new(RemoteClusterAPIKey)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchSpec) DeepCopyInto(out *ElasticsearchSpec) {
+func (in *RemoteClusterAccess) DeepCopyInto(out *RemoteClusterAccess) {
 	*out = *in
-	in.HTTP.DeepCopyInto(&out.HTTP)
-	in.Transport.DeepCopyInto(&out.Transport)
-	if in.NodeSets != nil {
-		in, out := &in.NodeSets, &out.NodeSets
-		*out = make([]NodeSet, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Search != nil {
+		in, out := &in.Search, &out.Search
+		*out = new(RemoteClusterIndices)
+		(*in).DeepCopyInto(*out)
 	}
-	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
-	if in.PodDisruptionBudget != nil {
-		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
-		*out = new(commonv1.PodDisruptionBudgetTemplate)
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(RemoteClusterIndices)
 		(*in).DeepCopyInto(*out)
 	}
-	in.Auth.DeepCopyInto(&out.Auth)
-	if in.SecureSettings != nil {
-		in, out := &in.SecureSettings, &out.SecureSettings
-		*out = make([]commonv1.SecretSource, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterAccess.
+func (in *RemoteClusterAccess) DeepCopy() *RemoteClusterAccess {
+	if in == nil {
+		return nil
 	}
-	if in.RemoteClusters != nil {
-		in, out := &in.RemoteClusters, &out.RemoteClusters
-		*out = make([]RemoteCluster, len(*in))
+	out := new(RemoteClusterAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteClusterIndices) DeepCopyInto(out *RemoteClusterIndices) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchSpec.
-func (in *ElasticsearchSpec) DeepCopy() *ElasticsearchSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterIndices.
+func (in *RemoteClusterIndices) DeepCopy() *RemoteClusterIndices {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchSpec)
+	out := new(RemoteClusterIndices)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchStatus) DeepCopyInto(out *ElasticsearchStatus) {
+func (in *RemoteClusterServer) DeepCopyInto(out *RemoteClusterServer) {
 	*out = *in
-	out.ReconcilerStatus = in.ReconcilerStatus
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchStatus.
-func (in *ElasticsearchStatus) DeepCopy() *ElasticsearchStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterServer.
+func (in *RemoteClusterServer) DeepCopy() *RemoteClusterServer {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchStatus)
+	out := new(RemoteClusterServer)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FileRealmSource) DeepCopyInto(out *FileRealmSource) {
+func (in *ReplicationReport) DeepCopyInto(out *ReplicationReport) {
 	*out = *in
-	out.SecretRef = in.SecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileRealmSource.
-func (in *FileRealmSource) DeepCopy() *FileRealmSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationReport.
+func (in *ReplicationReport) DeepCopy() *ReplicationReport {
 	if in == nil {
 		return nil
 	}
-	out := new(FileRealmSource)
+	out := new(ReplicationReport)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Node) DeepCopyInto(out *Node) {
+func (in *RolePriorityClass) DeepCopyInto(out *RolePriorityClass) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreemptionPolicy != nil {
+		in, out := &in.PreemptionPolicy, &out.PreemptionPolicy
+		*out = new(corev1.PreemptionPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolePriorityClass.
+func (in *RolePriorityClass) DeepCopy() *RolePriorityClass {
+	if in == nil {
+		return nil
+	}
+	out := new(RolePriorityClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleSource) DeepCopyInto(out *RoleSource) {
 	*out = *in
+	out.SecretRef = in.SecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Node.
-func (in *Node) DeepCopy() *Node {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleSource.
+func (in *RoleSource) DeepCopy() *RoleSource {
 	if in == nil {
 		return nil
 	}
-	out := new(Node)
+	out := new(RoleSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeSet) DeepCopyInto(out *NodeSet) {
+func (in *SAMLRealm) DeepCopyInto(out *SAMLRealm) {
 	*out = *in
+	out.IdPMetadata = in.IdPMetadata
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = (*in).DeepCopy()
 	}
-	in.PodTemplate.DeepCopyInto(&out.PodTemplate)
-	if in.VolumeClaimTemplates != nil {
-		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
-		*out = make([]corev1.PersistentVolumeClaim, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SAMLRealm.
+func (in *SAMLRealm) DeepCopy() *SAMLRealm {
+	if in == nil {
+		return nil
 	}
+	out := new(SAMLRealm)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSet.
-func (in *NodeSet) DeepCopy() *NodeSet {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMesh) DeepCopyInto(out *ServiceMesh) {
+	*out = *in
+	if in.MTLS != nil {
+		in, out := &in.MTLS, &out.MTLS
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMesh.
+func (in *ServiceMesh) DeepCopy() *ServiceMesh {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeSet)
+	out := new(ServiceMesh)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RemoteCluster) DeepCopyInto(out *RemoteCluster) {
+func (in *ShardsReport) DeepCopyInto(out *ShardsReport) {
 	*out = *in
-	out.ElasticsearchRef = in.ElasticsearchRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteCluster.
-func (in *RemoteCluster) DeepCopy() *RemoteCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardsReport.
+func (in *ShardsReport) DeepCopy() *ShardsReport {
 	if in == nil {
 		return nil
 	}
-	out := new(RemoteCluster)
+	out := new(ShardsReport)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RoleSource) DeepCopyInto(out *RoleSource) {
+func (in *SuspendedPod) DeepCopyInto(out *SuspendedPod) {
 	*out = *in
-	out.SecretRef = in.SecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleSource.
-func (in *RoleSource) DeepCopy() *RoleSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SuspendedPod.
+func (in *SuspendedPod) DeepCopy() *SuspendedPod {
 	if in == nil {
 		return nil
 	}
-	out := new(RoleSource)
+	out := new(SuspendedPod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SuspendedPodStatus) DeepCopyInto(out *SuspendedPodStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SuspendedPodStatus.
+func (in *SuspendedPodStatus) DeepCopy() *SuspendedPodStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SuspendedPodStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpread) DeepCopyInto(out *TopologySpread) {
+	*out = *in
+	if in.MaxSkew != nil {
+		in, out := &in.MaxSkew, &out.MaxSkew
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpread.
+func (in *TopologySpread) DeepCopy() *TopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpread)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -329,6 +1950,21 @@ func (in *TransportConfig) DeepCopy() *TransportConfig {
 func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
 	*out = *in
 	in.ChangeBudget.DeepCopyInto(&out.ChangeBudget)
+	if in.DataMigration != nil {
+		in, out := &in.DataMigration, &out.DataMigration
+		*out = new(DataMigrationPolicy)
+		**out = **in
+	}
+	if in.CacheWarmup != nil {
+		in, out := &in.CacheWarmup, &out.CacheWarmup
+		*out = new(CacheWarmup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SnapshotBeforeUpgrade != nil {
+		in, out := &in.SnapshotBeforeUpgrade, &out.SnapshotBeforeUpgrade
+		*out = new(SnapshotBeforeUpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
@@ -341,6 +1977,45 @@ func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualMemory) DeepCopyInto(out *VirtualMemory) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualMemory.
+func (in *VirtualMemory) DeepCopy() *VirtualMemory {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualMemory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmupSearch) DeepCopyInto(out *WarmupSearch) {
+	*out = *in
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Body != nil {
+		in, out := &in.Body, &out.Body
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmupSearch.
+func (in *WarmupSearch) DeepCopy() *WarmupSearch {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmupSearch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZenDiscoveryStatus) DeepCopyInto(out *ZenDiscoveryStatus) {
 	*out = *in
@@ -355,3 +2030,23 @@ func (in *ZenDiscoveryStatus) DeepCopy() *ZenDiscoveryStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneSpread) DeepCopyInto(out *ZoneSpread) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneSpread.
+func (in *ZoneSpread) DeepCopy() *ZoneSpread {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneSpread)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,125 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// conversionData is the data of an Elasticsearch resource in the storage version, held by the conversion data
+// annotation.
+type conversionData struct {
+	Spec   esv1.ElasticsearchSpec   `json:"spec"`
+	Status esv1.ElasticsearchStatus `json:"status"`
+}
+
+// ConvertTo converts this Elasticsearch resource to the hub version, restoring the fields it cannot represent from its
+// conversion data.
+func (es *Elasticsearch) ConvertTo(hub conversion.Hub) error {
+	dst := hub.(*esv1.Elasticsearch)
+	dst.ObjectMeta = *es.ObjectMeta.DeepCopy()
+	if err := commonv1beta1.ConvertJSON(es.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(es.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	var restored conversionData
+	if ok, err := commonv1beta1.UnmarshalConversionData(dst, &restored); err != nil || !ok {
+		return err
+	}
+	restoreSpec(&dst.Spec, restored.Spec)
+	restoreStatus(&dst.Status, restored.Status)
+	return nil
+}
+
+// ConvertFrom converts the given Elasticsearch resource of the hub version to this version, keeping the fields this
+// version cannot represent in its conversion data.
+func (es *Elasticsearch) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*esv1.Elasticsearch)
+	es.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := commonv1beta1.ConvertJSON(src.Spec, &es.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(src.Status, &es.Status); err != nil {
+		return err
+	}
+
+	var convertedBack conversionData
+	if err := commonv1beta1.ConvertJSON(es.Spec, &convertedBack.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(es.Status, &convertedBack.Status); err != nil {
+		return err
+	}
+	return commonv1beta1.MarshalConversionData(conversionData{Spec: src.Spec, Status: src.Status}, convertedBack, es)
+}
+
+// restoreSpec restores the fields of the specification introduced in v1.
+func restoreSpec(dst *esv1.ElasticsearchSpec, restored esv1.ElasticsearchSpec) {
+	dst.ClassName = restored.ClassName
+	dst.Transport = restored.Transport
+	dst.UpdateStrategy.DataMigration = restored.UpdateStrategy.DataMigration
+	dst.UpdateStrategy.IndexingTasks = restored.UpdateStrategy.IndexingTasks
+	dst.UpdateStrategy.CacheWarmup = restored.UpdateStrategy.CacheWarmup
+	dst.UpdateStrategy.SnapshotBeforeUpgrade = restored.UpdateStrategy.SnapshotBeforeUpgrade
+	dst.TopologySpread = restored.TopologySpread
+	dst.LifecycleHooks = restored.LifecycleHooks
+	dst.Plugins = restored.Plugins
+	dst.AnalysisFiles = restored.AnalysisFiles
+	dst.GeoIP = restored.GeoIP
+	dst.Auth = restored.Auth
+	commonv1beta1.RestoreSecretSources(dst.SecureSettings, restored.SecureSettings)
+	dst.ServiceAccountName = restored.ServiceAccountName
+	dst.RemoteClusters = restored.RemoteClusters
+	dst.RemoteClusterServer = restored.RemoteClusterServer
+	dst.CrossClusterReplication = restored.CrossClusterReplication
+	dst.Audit = restored.Audit
+	dst.ServiceMesh = restored.ServiceMesh
+	dst.Logging = restored.Logging
+	dst.RuntimeConfig = restored.RuntimeConfig
+	dst.DiskPressure = restored.DiskPressure
+	dst.CriticalIndices = restored.CriticalIndices
+	dst.NodeDebug = restored.NodeDebug
+	dst.Maintenance = restored.Maintenance
+	dst.VirtualMemory = restored.VirtualMemory
+	dst.PriorityClasses = restored.PriorityClasses
+
+	// NodeSets are matched by name: the ones added in this version do not have any field introduced in v1
+	for i := range dst.NodeSets {
+		for _, nodeSet := range restored.NodeSets {
+			if nodeSet.Name != dst.NodeSets[i].Name {
+				continue
+			}
+			dst.NodeSets[i].ZoneSpread = nodeSet.ZoneSpread
+			dst.NodeSets[i].JVMOptions = nodeSet.JVMOptions
+			dst.NodeSets[i].Architecture = nodeSet.Architecture
+			dst.NodeSets[i].Image = nodeSet.Image
+			dst.NodeSets[i].Diagnostics = nodeSet.Diagnostics
+			dst.NodeSets[i].Profile = nodeSet.Profile
+			dst.NodeSets[i].ScheduledScaling = nodeSet.ScheduledScaling
+			dst.NodeSets[i].ResourceDetection = nodeSet.ResourceDetection
+			dst.NodeSets[i].Tier = nodeSet.Tier
+			dst.NodeSets[i].RuntimeClassName = nodeSet.RuntimeClassName
+			break
+		}
+	}
+}
+
+// restoreStatus restores the fields of the status introduced in v1.
+func restoreStatus(dst *esv1.ElasticsearchStatus, restored esv1.ElasticsearchStatus) {
+	dst.AuditAssociationStatus = restored.AuditAssociationStatus
+	dst.ImageDigests = restored.ImageDigests
+	dst.Logging = restored.Logging
+	dst.RuntimeSettings = restored.RuntimeSettings
+	dst.ReadOnlyIndices = restored.ReadOnlyIndices
+	dst.DataMigration = restored.DataMigration
+	dst.SuspendedPods = restored.SuspendedPods
+	dst.RetentionJobs = restored.RetentionJobs
+	dst.Conditions = restored.Conditions
+}
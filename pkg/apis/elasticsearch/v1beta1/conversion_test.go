@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/test/fill"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func requireJSONEqual(t *testing.T, expected, actual interface{}) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
+
+func TestElasticsearch_HubRoundTrip(t *testing.T) {
+	var hub esv1.Elasticsearch
+	fill.Fill(&hub)
+	hub.TypeMeta = metav1.TypeMeta{}
+	// metadata is copied as is
+	hub.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{"a": "b"}}
+
+	var es Elasticsearch
+	require.NoError(t, es.ConvertFrom(&hub))
+	require.Contains(t, es.Annotations, commonv1beta1.ConversionDataAnnotation)
+
+	var converted esv1.Elasticsearch
+	require.NoError(t, es.ConvertTo(&converted))
+	require.NotContains(t, converted.Annotations, commonv1beta1.ConversionDataAnnotation)
+	requireJSONEqual(t, hub, converted)
+}
+
+func TestElasticsearch_SpokeRoundTrip(t *testing.T) {
+	var es Elasticsearch
+	fill.Fill(&es)
+	es.TypeMeta = metav1.TypeMeta{}
+	es.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{"a": "b"}}
+
+	var hub esv1.Elasticsearch
+	require.NoError(t, es.ConvertTo(&hub))
+
+	var converted Elasticsearch
+	require.NoError(t, converted.ConvertFrom(&hub))
+	// all the fields can be represented in v1beta1
+	require.NotContains(t, converted.Annotations, commonv1beta1.ConversionDataAnnotation)
+	requireJSONEqual(t, es, converted)
+}
+
+func TestElasticsearch_ConvertTo_updated(t *testing.T) {
+	hub := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version:            "7.10.0",
+			ServiceAccountName: "sa",
+			NodeSets: []esv1.NodeSet{
+				{Name: "hot", Count: 3, Tier: esv1.HotTier},
+				{Name: "warm", Count: 3, Tier: esv1.WarmTier},
+			},
+			SecureSettings: []commonv1.SecretSource{{SecretName: "shared", Namespace: "other", Prefix: "s3.client."}},
+		},
+	}
+	var es Elasticsearch
+	require.NoError(t, es.ConvertFrom(&hub))
+
+	// update the resource in v1beta1
+	es.Spec.Version = "7.10.1"
+	es.Spec.NodeSets = []NodeSet{{Name: "hot", Count: 5}, {Name: "cold", Count: 1}}
+	es.Spec.SecureSettings = append(es.Spec.SecureSettings, commonv1beta1.SecretSource{SecretName: "local"})
+
+	var converted esv1.Elasticsearch
+	require.NoError(t, es.ConvertTo(&converted))
+	require.Equal(t, esv1.ElasticsearchSpec{
+		Version:            "7.10.1",
+		ServiceAccountName: "sa",
+		NodeSets: []esv1.NodeSet{
+			{Name: "hot", Count: 5, Tier: esv1.HotTier},
+			{Name: "cold", Count: 1},
+		},
+		SecureSettings: []commonv1.SecretSource{
+			{SecretName: "shared", Namespace: "other", Prefix: "s3.client."},
+			{SecretName: "local"},
+		},
+	}, converted.Spec)
+	require.Empty(t, converted.Annotations)
+}
+
+func TestElasticsearch_ConvertFrom_lossless(t *testing.T) {
+	hub := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{"a": "b"}},
+		Spec: esv1.ElasticsearchSpec{
+			Version:  "7.6.0",
+			NodeSets: []esv1.NodeSet{{Name: "default", Count: 3}},
+		},
+		Status: esv1.ElasticsearchStatus{Health: esv1.ElasticsearchGreenHealth},
+	}
+	var es Elasticsearch
+	require.NoError(t, es.ConvertFrom(&hub))
+	require.Equal(t, map[string]string{"a": "b"}, es.Annotations)
+	require.Equal(t, ElasticsearchSpec{Version: "7.6.0", NodeSets: []NodeSet{{Name: "default", Count: 3}}}, es.Spec)
+	require.Equal(t, ElasticsearchGreenHealth, es.Status.Health)
+}
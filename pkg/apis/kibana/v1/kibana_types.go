@@ -27,6 +27,11 @@ type KibanaSpec struct {
 	// ElasticsearchRef is a reference to an Elasticsearch cluster running in the same Kubernetes cluster.
 	ElasticsearchRef commonv1.ObjectSelector `json:"elasticsearchRef,omitempty"`
 
+	// ElasticsearchHosts selects the Elasticsearch endpoints Kibana connects to, instead of the HTTP service of the
+	// referenced Elasticsearch cluster.
+	// +optional
+	ElasticsearchHosts *ElasticsearchHostsSelector `json:"elasticsearchHosts,omitempty"`
+
 	// Config holds the Kibana configuration. See: https://www.elastic.co/guide/en/kibana/current/settings.html
 	Config *commonv1.Config `json:"config,omitempty"`
 
@@ -47,6 +52,20 @@ type KibanaSpec struct {
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
 
+// ElasticsearchHostsSelector selects a subset of the nodes of the referenced Elasticsearch cluster for Kibana to connect
+// to. ServiceName and NodeSets are mutually exclusive.
+type ElasticsearchHostsSelector struct {
+	// ServiceName is the name of a Service in the namespace of the Elasticsearch cluster, for example a Service selecting
+	// its coordinating-only nodes, Kibana connects to instead of the HTTP service of the cluster.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// NodeSets lists NodeSets of the Elasticsearch cluster whose nodes are each listed in elasticsearch.hosts. Sniffing
+	// is disabled, so that Kibana only sends its requests to these nodes.
+	// +optional
+	NodeSets []string `json:"nodeSets,omitempty"`
+}
+
 // KibanaHealth expresses the status of the Kibana instances.
 type KibanaHealth string
 
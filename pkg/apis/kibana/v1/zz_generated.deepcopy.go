@@ -13,6 +13,26 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchHostsSelector) DeepCopyInto(out *ElasticsearchHostsSelector) {
+	*out = *in
+	if in.NodeSets != nil {
+		in, out := &in.NodeSets, &out.NodeSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchHostsSelector.
+func (in *ElasticsearchHostsSelector) DeepCopy() *ElasticsearchHostsSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchHostsSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Kibana) DeepCopyInto(out *Kibana) {
 	*out = *in
@@ -23,7 +43,7 @@ func (in *Kibana) DeepCopyInto(out *Kibana) {
 	if in.assocConf != nil {
 		in, out := &in.assocConf, &out.assocConf
 		*out = new(commonv1.AssociationConf)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -81,6 +101,11 @@ func (in *KibanaList) DeepCopyObject() runtime.Object {
 func (in *KibanaSpec) DeepCopyInto(out *KibanaSpec) {
 	*out = *in
 	out.ElasticsearchRef = in.ElasticsearchRef
+	if in.ElasticsearchHosts != nil {
+		in, out := &in.ElasticsearchHosts, &out.ElasticsearchHosts
+		*out = new(ElasticsearchHostsSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = (*in).DeepCopy()
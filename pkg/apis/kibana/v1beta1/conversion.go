@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// conversionData is the data of a Kibana resource in the storage version, held by the conversion data annotation.
+type conversionData struct {
+	Spec   kbv1.KibanaSpec   `json:"spec"`
+	Status kbv1.KibanaStatus `json:"status"`
+}
+
+// ConvertTo converts this Kibana resource to the hub version, restoring the fields it cannot represent from its
+// conversion data.
+func (k *Kibana) ConvertTo(hub conversion.Hub) error {
+	dst := hub.(*kbv1.Kibana)
+	dst.ObjectMeta = *k.ObjectMeta.DeepCopy()
+	if err := commonv1beta1.ConvertJSON(k.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(k.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	var restored conversionData
+	if ok, err := commonv1beta1.UnmarshalConversionData(dst, &restored); err != nil || !ok {
+		return err
+	}
+	// fields introduced in v1
+	dst.Spec.ElasticsearchHosts = restored.Spec.ElasticsearchHosts
+	dst.Spec.ServiceAccountName = restored.Spec.ServiceAccountName
+	commonv1beta1.RestoreSecretSources(dst.Spec.SecureSettings, restored.Spec.SecureSettings)
+	return nil
+}
+
+// ConvertFrom converts the given Kibana resource of the hub version to this version, keeping the fields this version
+// cannot represent in its conversion data.
+func (k *Kibana) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*kbv1.Kibana)
+	k.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := commonv1beta1.ConvertJSON(src.Spec, &k.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(src.Status, &k.Status); err != nil {
+		return err
+	}
+
+	var convertedBack conversionData
+	if err := commonv1beta1.ConvertJSON(k.Spec, &convertedBack.Spec); err != nil {
+		return err
+	}
+	if err := commonv1beta1.ConvertJSON(k.Status, &convertedBack.Status); err != nil {
+		return err
+	}
+	return commonv1beta1.MarshalConversionData(conversionData{Spec: src.Spec, Status: src.Status}, convertedBack, k)
+}
@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/test/fill"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func requireJSONEqual(t *testing.T, expected, actual interface{}) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
+
+func TestKibana_HubRoundTrip(t *testing.T) {
+	var hub kbv1.Kibana
+	fill.Fill(&hub)
+	hub.TypeMeta = metav1.TypeMeta{}
+	hub.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "kb"}
+
+	var kb Kibana
+	require.NoError(t, kb.ConvertFrom(&hub))
+	require.Contains(t, kb.Annotations, commonv1beta1.ConversionDataAnnotation)
+
+	var converted kbv1.Kibana
+	require.NoError(t, kb.ConvertTo(&converted))
+	requireJSONEqual(t, hub, converted)
+}
+
+func TestKibana_SpokeRoundTrip(t *testing.T) {
+	var kb Kibana
+	fill.Fill(&kb)
+	kb.TypeMeta = metav1.TypeMeta{}
+	kb.ObjectMeta = metav1.ObjectMeta{Namespace: "ns", Name: "kb"}
+
+	var hub kbv1.Kibana
+	require.NoError(t, kb.ConvertTo(&hub))
+
+	var converted Kibana
+	require.NoError(t, converted.ConvertFrom(&hub))
+	require.NotContains(t, converted.Annotations, commonv1beta1.ConversionDataAnnotation)
+	requireJSONEqual(t, kb, converted)
+}
@@ -0,0 +1,131 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Kind is inferred from the struct name using reflection in SchemeBuilder.Register()
+	// we duplicate it as a constant here for practical purposes.
+	Kind = "ElasticStack"
+)
+
+// ElasticStackSpec holds the specification of an ElasticStack resource.
+type ElasticStackSpec struct {
+	// Version of the Elastic Stack the referenced resources are upgraded to.
+	Version string `json:"version"`
+	// Resources references the resources of the stack, in the namespace of the ElasticStack. They are upgraded in the
+	// documented order: Elasticsearch first, then Kibana, then APM Server and Enterprise Search. Each step starts once
+	// the resources of the previous steps run the new version and are healthy.
+	// +kubebuilder:validation:MinItems=1
+	Resources []StackResourceRef `json:"resources"`
+}
+
+// StackResourceKind is the kind of a resource of an Elastic Stack.
+type StackResourceKind string
+
+const (
+	ElasticsearchKind    StackResourceKind = "Elasticsearch"
+	KibanaKind           StackResourceKind = "Kibana"
+	ApmServerKind        StackResourceKind = "ApmServer"
+	EnterpriseSearchKind StackResourceKind = "EnterpriseSearch"
+)
+
+// StackResourceRef references a resource of an Elastic Stack.
+type StackResourceRef struct {
+	// Kind of the resource.
+	// +kubebuilder:validation:Enum=Elasticsearch;Kibana;ApmServer;EnterpriseSearch
+	Kind StackResourceKind `json:"kind"`
+	// Name of the resource.
+	Name string `json:"name"`
+	// Hold keeps the resource at its current version. The resources upgraded after it are held as well.
+	// +kubebuilder:validation:Optional
+	Hold bool `json:"hold,omitempty"`
+}
+
+// StackPhase is the overall phase of the upgrade of an Elastic Stack.
+type StackPhase string
+
+const (
+	// ReadyPhase means all the resources of the stack run the version of the stack.
+	ReadyPhase StackPhase = "Ready"
+	// UpgradingPhase means some resources of the stack are being upgraded.
+	UpgradingPhase StackPhase = "Upgrading"
+	// HeldPhase means the upgrade is held by a resource of the stack.
+	HeldPhase StackPhase = "Held"
+	// ErrorPhase means some resources of the stack cannot be upgraded.
+	ErrorPhase StackPhase = "Error"
+)
+
+// StackResourcePhase is the phase of the upgrade of a resource of an Elastic Stack.
+type StackResourcePhase string
+
+const (
+	// UpgradedPhase means the resource runs the version of the stack and is healthy.
+	UpgradedPhase StackResourcePhase = "Upgraded"
+	// ResourceUpgradingPhase means the version of the resource was bumped, and the resource is not running it yet or
+	// is not healthy.
+	ResourceUpgradingPhase StackResourcePhase = "Upgrading"
+	// PendingPhase means the resource waits for the resources of the previous steps to be upgraded.
+	PendingPhase StackResourcePhase = "Pending"
+	// ResourceHeldPhase means the resource is held at its current version.
+	ResourceHeldPhase StackResourcePhase = "Held"
+	// ResourceErrorPhase means the resource cannot be upgraded, for example because it does not exist.
+	ResourceErrorPhase StackResourcePhase = "Error"
+)
+
+// StackResourceStatus is the status of the upgrade of a resource of an Elastic Stack.
+type StackResourceStatus struct {
+	Kind StackResourceKind `json:"kind"`
+	Name string            `json:"name"`
+	// Version is the version all the Pods of the resource run, empty while they run different versions.
+	Version string             `json:"version,omitempty"`
+	Phase   StackResourcePhase `json:"phase,omitempty"`
+	// Message details why the resource is not upgraded.
+	Message string `json:"message,omitempty"`
+}
+
+// ElasticStackStatus defines the observed state of an ElasticStack.
+type ElasticStackStatus struct {
+	// Phase is the overall phase of the upgrade.
+	Phase StackPhase `json:"phase,omitempty"`
+	// Resources holds the status of the upgrade of each referenced resource, in the upgrade order.
+	Resources []StackResourceStatus `json:"resources,omitempty"`
+	// ObservedGeneration is the generation of the ElasticStack the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticStack coordinates the version upgrades of a set of Elasticsearch, Kibana, APM Server and Enterprise Search
+// resources.
+// +kubebuilder:resource:categories=elastic,shortName=stack
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="version",type="string",JSONPath=".spec.version",description="Elastic Stack version"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:storageversion
+type ElasticStack struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElasticStackSpec   `json:"spec,omitempty"`
+	Status ElasticStackStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ElasticStackList contains a list of ElasticStack resources.
+type ElasticStackList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticStack `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticStack{}, &ElasticStackList{})
+}
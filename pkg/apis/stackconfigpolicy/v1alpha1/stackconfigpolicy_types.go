@@ -0,0 +1,187 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+)
+
+const (
+	// Kind is inferred from the struct name using reflection in SchemeBuilder.Register()
+	// we duplicate it as a constant here for practical purposes.
+	Kind = "StackConfigPolicy"
+)
+
+// StackConfigPolicySpec holds the specification of a StackConfigPolicy resource.
+type StackConfigPolicySpec struct {
+	// ResourceSelector selects the Elasticsearch and Kibana resources the policy applies to.
+	// An empty selector selects all the resources managed by the operator.
+	ResourceSelector metav1.LabelSelector `json:"resourceSelector,omitempty"`
+	// Elasticsearch holds the configuration applied to the selected Elasticsearch clusters.
+	Elasticsearch ElasticsearchConfigPolicySpec `json:"elasticsearch,omitempty"`
+	// Kibana holds the configuration applied to the selected Kibana instances.
+	Kibana KibanaConfigPolicySpec `json:"kibana,omitempty"`
+}
+
+// ElasticsearchConfigPolicySpec holds the configuration applied to Elasticsearch clusters.
+type ElasticsearchConfigPolicySpec struct {
+	// SnapshotRepositories holds the snapshot repositories to register, keyed by repository name.
+	// Each repository is described as in the body of the Elasticsearch snapshot repository API (type and settings).
+	// +kubebuilder:validation:Optional
+	SnapshotRepositories *commonv1.Config `json:"snapshotRepositories,omitempty"`
+	// SecureSettings is a list of references to Kubernetes secrets in the namespace of the policy, containing
+	// sensitive configuration options to add to the keystore of the Elasticsearch nodes.
+	// +kubebuilder:validation:Optional
+	SecureSettings []commonv1.SecretSource `json:"secureSettings,omitempty"`
+	// ClusterSettings holds the persistent cluster settings to apply, as in the body of the Elasticsearch
+	// cluster update settings API.
+	// +kubebuilder:validation:Optional
+	ClusterSettings *commonv1.Config `json:"clusterSettings,omitempty"`
+	// IndexLifecyclePolicies holds the index lifecycle policies to create, keyed by policy name.
+	// Each policy is described as in the body of the Elasticsearch put lifecycle policy API.
+	// +kubebuilder:validation:Optional
+	IndexLifecyclePolicies *commonv1.Config `json:"indexLifecyclePolicies,omitempty"`
+	// IndexTemplates holds the index templates to create, keyed by template name.
+	// Each template is described as in the body of the Elasticsearch put index template API.
+	// +kubebuilder:validation:Optional
+	IndexTemplates *commonv1.Config `json:"indexTemplates,omitempty"`
+	// IngestPipelines holds the ingest pipelines to create, keyed by pipeline ID.
+	// Each pipeline is described as in the body of the Elasticsearch put pipeline API.
+	// +kubebuilder:validation:Optional
+	IngestPipelines *commonv1.Config `json:"ingestPipelines,omitempty"`
+	// IngestPipelineSamples references sample documents the ingest pipelines are simulated on before being applied.
+	// +kubebuilder:validation:Optional
+	IngestPipelineSamples *IngestPipelineSamples `json:"ingestPipelineSamples,omitempty"`
+	// Watches holds the Watcher watches to create, keyed by watch ID.
+	// Each watch is described as in the body of the Elasticsearch put watch API.
+	// +kubebuilder:validation:Optional
+	Watches *commonv1.Config `json:"watches,omitempty"`
+	// DriftMode defines how the operator handles changes made outside of the policy to the cluster settings,
+	// index lifecycle policies, index templates, ingest pipelines and watches of the policy: Enforce (default)
+	// reverts them, Report only reports them in the status of the policy.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Enforce;Report
+	DriftMode DriftMode `json:"driftMode,omitempty"`
+}
+
+// IngestPipelineSamples references a ConfigMap in the namespace of the policy holding sample documents, one entry per
+// pipeline ID. Each entry is a JSON array of document sources. A pipeline failing on any of its sample documents is not
+// applied, and the error is reported in the status of the policy.
+type IngestPipelineSamples struct {
+	// ConfigMapName is the name of the ConfigMap holding the sample documents.
+	ConfigMapName string `json:"configMapName"`
+}
+
+// DriftMode defines how the operator handles configuration drift in Elasticsearch clusters.
+type DriftMode string
+
+const (
+	// EnforceDriftMode reverts the configuration drift.
+	EnforceDriftMode DriftMode = "Enforce"
+	// ReportDriftMode reports the configuration drift without reverting it.
+	ReportDriftMode DriftMode = "Report"
+)
+
+// KibanaConfigPolicySpec holds the configuration applied to Kibana instances.
+type KibanaConfigPolicySpec struct {
+	// Config holds the Kibana settings merged into the configuration of the Kibana instances.
+	// They take precedence over the settings of the Kibana resources.
+	// +kubebuilder:validation:Optional
+	Config *commonv1.Config `json:"config,omitempty"`
+	// SecureSettings is a list of references to Kubernetes secrets in the namespace of the policy, containing
+	// sensitive configuration options to add to the keystore of the Kibana instances.
+	// +kubebuilder:validation:Optional
+	SecureSettings []commonv1.SecretSource `json:"secureSettings,omitempty"`
+}
+
+// PolicyPhase is the overall phase of a StackConfigPolicy.
+type PolicyPhase string
+
+const (
+	// ReadyPhase means the policy is applied to all the selected resources.
+	ReadyPhase PolicyPhase = "Ready"
+	// ApplyingChangesPhase means the policy is being applied to some of the selected resources.
+	ApplyingChangesPhase PolicyPhase = "ApplyingChanges"
+	// ErrorPhase means the policy could not be applied to some of the selected resources.
+	ErrorPhase PolicyPhase = "Error"
+	// DriftDetectedPhase means the configuration of some of the selected resources drifted from the policy.
+	DriftDetectedPhase PolicyPhase = "DriftDetected"
+	// InvalidPhase means the policy itself is invalid, for example because it references a missing secret.
+	InvalidPhase PolicyPhase = "Invalid"
+)
+
+// ResourcePolicyPhase is the phase of the application of the policy to a single resource.
+type ResourcePolicyPhase string
+
+const (
+	// AppliedPhase means the policy is applied to the resource.
+	AppliedPhase ResourcePolicyPhase = "Applied"
+	// ApplyingPhase means the policy is not applied to the resource yet.
+	ApplyingPhase ResourcePolicyPhase = "Applying"
+	// ResourceErrorPhase means the policy could not be applied to the resource.
+	ResourceErrorPhase ResourcePolicyPhase = "Error"
+	// DriftedPhase means the configuration of the resource was changed outside of the policy.
+	DriftedPhase ResourcePolicyPhase = "Drifted"
+	// ConflictPhase means the resource is selected by several policies, none of them is applied.
+	ConflictPhase ResourcePolicyPhase = "Conflict"
+)
+
+// ResourcePolicyStatus is the status of the application of the policy to a single resource.
+type ResourcePolicyStatus struct {
+	Phase ResourcePolicyPhase `json:"phase,omitempty"`
+	// Error describes why the policy could not be applied to the resource.
+	Error string `json:"error,omitempty"`
+	// Drift lists the configuration items of the resource that were changed outside of the policy.
+	Drift []string `json:"drift,omitempty"`
+}
+
+// StackConfigPolicyStatus defines the observed state of a StackConfigPolicy.
+type StackConfigPolicyStatus struct {
+	// Resources is the number of resources selected by the policy.
+	Resources int `json:"resources,omitempty"`
+	// Ready is the number of resources the policy is applied to.
+	Ready int `json:"ready,omitempty"`
+	// Errors is the number of resources the policy could not be applied to.
+	Errors int `json:"errors,omitempty"`
+	// Phase is the overall phase of the policy.
+	Phase PolicyPhase `json:"phase,omitempty"`
+	// ResourcesStatuses holds the status of the application of the policy to each selected resource,
+	// keyed by "<kind>/<namespace>/<name>".
+	ResourcesStatuses map[string]ResourcePolicyStatus `json:"resourcesStatuses,omitempty"`
+	// ObservedGeneration is the generation of the policy the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StackConfigPolicy represents a configuration applied to a set of Elasticsearch clusters and Kibana instances.
+// +kubebuilder:resource:categories=elastic,shortName=scp
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ready",type="string",JSONPath=".status.ready",description="Resources the policy is applied to"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:storageversion
+type StackConfigPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StackConfigPolicySpec   `json:"spec,omitempty"`
+	Status StackConfigPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StackConfigPolicyList contains a list of StackConfigPolicy resources.
+type StackConfigPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StackConfigPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StackConfigPolicy{}, &StackConfigPolicyList{})
+}
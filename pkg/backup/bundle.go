@@ -0,0 +1,125 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+	corev1 "k8s.io/api/core/v1"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+)
+
+// BundleVersion is the version of the format of the bundles.
+const BundleVersion = 1
+
+// scrypt parameters deriving the encryption key of a bundle from its passphrase.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	scryptMethod = "scrypt-aes256-gcm"
+)
+
+// Bundle holds the Elastic Stack resources managed by the operator, and the Secrets required to recreate them in
+// another Kubernetes cluster with the same certificate authorities, credentials and keys.
+type Bundle struct {
+	// Version of the format of the bundle.
+	Version            int                            `json:"version"`
+	Elasticsearches    []esv1.Elasticsearch           `json:"elasticsearches,omitempty"`
+	Kibanas            []kbv1.Kibana                  `json:"kibanas,omitempty"`
+	ApmServers         []apmv1.ApmServer              `json:"apmServers,omitempty"`
+	EnterpriseSearches []entsv1beta1.EnterpriseSearch `json:"enterpriseSearches,omitempty"`
+	Secrets            []corev1.Secret                `json:"secrets,omitempty"`
+}
+
+// envelope is the serialized form of an encrypted bundle.
+type envelope struct {
+	Version    int    `json:"version"`
+	Method     string `json:"method"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt serializes the bundle, encrypted with AES-256-GCM using a key derived from the given passphrase.
+func (b Bundle) Encrypt(passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("the passphrase of the bundle must not be empty")
+	}
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{
+		Version:    BundleVersion,
+		Method:     scryptMethod,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+}
+
+// Decrypt deserializes a bundle encrypted with the given passphrase.
+func Decrypt(data []byte, passphrase []byte) (Bundle, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Bundle{}, errors.Wrap(err, "invalid bundle")
+	}
+	if env.Version != BundleVersion || env.Method != scryptMethod {
+		return Bundle{}, errors.Errorf("unsupported bundle version %d with method %s", env.Version, env.Method)
+	}
+	aead, err := newAEAD(passphrase, env.Salt)
+	if err != nil {
+		return Bundle{}, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return Bundle{}, errors.New("invalid bundle nonce")
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return Bundle{}, errors.New("failed to decrypt the bundle: wrong passphrase or corrupted bundle")
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(plaintext, &bundle); err != nil {
+		return Bundle{}, errors.Wrap(err, "invalid bundle content")
+	}
+	return bundle, nil
+}
+
+func newAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,46 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package backup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+func TestBundle_Encrypt(t *testing.T) {
+	bundle := Bundle{
+		Version:         BundleVersion,
+		Elasticsearches: []esv1.Elasticsearch{{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}},
+		Secrets: []corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-es-elastic-user"},
+			Data:       map[string][]byte{"elastic": []byte("secret-password")},
+		}},
+	}
+	passphrase := []byte("passphrase")
+
+	data, err := bundle.Encrypt(passphrase)
+	require.NoError(t, err)
+	// the content of the bundle is not readable
+	require.False(t, bytes.Contains(data, []byte("es-es-elastic-user")))
+
+	decrypted, err := Decrypt(data, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, bundle, decrypted)
+
+	_, err = Decrypt(data, []byte("wrong passphrase"))
+	require.EqualError(t, err, "failed to decrypt the bundle: wrong passphrase or corrupted bundle")
+
+	_, err = Decrypt([]byte("not a bundle"), passphrase)
+	require.Error(t, err)
+
+	_, err = bundle.Encrypt(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,210 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package backup
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	apmname "github.com/elastic/cloud-on-k8s/pkg/controller/apmserver/name"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	entsname "github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch/name"
+	kbconfig "github.com/elastic/cloud-on-k8s/pkg/controller/kibana/config"
+	kbname "github.com/elastic/cloud-on-k8s/pkg/controller/kibana/name"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// Export returns a bundle of the Elastic Stack resources in the given namespace, or all namespaces if empty, with the
+// Secrets required to recreate them: the certificate authorities, the custom HTTP certificates, the credentials of the
+// Elasticsearch users, the secure settings and the generated keys. The metadata specific to the Kubernetes cluster,
+// such as UIDs and owner references, and the status of the resources are not exported. The annotations are kept, in
+// particular the UUID of the Elasticsearch clusters which marks them as already bootstrapped.
+func Export(c k8s.Client, namespace string) (Bundle, error) {
+	bundle := Bundle{Version: BundleVersion}
+	secrets := secretSet{}
+
+	var esList esv1.ElasticsearchList
+	if err := c.List(&esList, client.InNamespace(namespace)); err != nil {
+		return bundle, err
+	}
+	for _, es := range esList.Items {
+		bundle.Elasticsearches = append(bundle.Elasticsearches, esv1.Elasticsearch{
+			ObjectMeta: exportedMeta(es.ObjectMeta),
+			Spec:       es.Spec,
+		})
+		secrets.add(es.Namespace,
+			certificates.CAInternalSecretName(esv1.ESNamer, es.Name, certificates.HTTPCAType),
+			certificates.CAInternalSecretName(esv1.ESNamer, es.Name, certificates.TransportCAType),
+			esv1.ElasticUserSecret(es.Name),
+			esv1.InternalUsersSecret(es.Name),
+			es.Spec.HTTP.TLS.Certificate.SecretName,
+		)
+		secrets.addSources(es.Namespace, es.Spec.SecureSettings)
+	}
+
+	var kbList kbv1.KibanaList
+	if err := c.List(&kbList, client.InNamespace(namespace)); err != nil {
+		return bundle, err
+	}
+	for _, kb := range kbList.Items {
+		bundle.Kibanas = append(bundle.Kibanas, kbv1.Kibana{ObjectMeta: exportedMeta(kb.ObjectMeta), Spec: kb.Spec})
+		secrets.add(kb.Namespace,
+			certificates.CAInternalSecretName(kbname.KBNamer, kb.Name, certificates.HTTPCAType),
+			// holds the encryption key of Kibana
+			kbconfig.SecretName(kb),
+			kb.Spec.HTTP.TLS.Certificate.SecretName,
+		)
+		secrets.addSources(kb.Namespace, kb.Spec.SecureSettings)
+	}
+
+	var asList apmv1.ApmServerList
+	if err := c.List(&asList, client.InNamespace(namespace)); err != nil {
+		return bundle, err
+	}
+	for _, as := range asList.Items {
+		bundle.ApmServers = append(bundle.ApmServers, apmv1.ApmServer{ObjectMeta: exportedMeta(as.ObjectMeta), Spec: as.Spec})
+		secrets.add(as.Namespace,
+			certificates.CAInternalSecretName(apmname.APMNamer, as.Name, certificates.HTTPCAType),
+			apmname.SecretToken(as.Name),
+			as.Spec.HTTP.TLS.Certificate.SecretName,
+		)
+		secrets.addSources(as.Namespace, as.Spec.SecureSettings)
+	}
+
+	var entsList entsv1beta1.EnterpriseSearchList
+	if err := c.List(&entsList, client.InNamespace(namespace)); err != nil {
+		return bundle, err
+	}
+	for _, ents := range entsList.Items {
+		bundle.EnterpriseSearches = append(bundle.EnterpriseSearches, entsv1beta1.EnterpriseSearch{
+			ObjectMeta: exportedMeta(ents.ObjectMeta),
+			Spec:       ents.Spec,
+		})
+		secrets.add(ents.Namespace,
+			certificates.CAInternalSecretName(entsname.EntSearchNamer, ents.Name, certificates.HTTPCAType),
+			// holds the session and encryption keys of Enterprise Search
+			entsname.Config(ents.Name),
+			ents.Spec.HTTP.TLS.Certificate.SecretName,
+		)
+	}
+
+	for _, nsn := range secrets.names {
+		var secret corev1.Secret
+		if err := c.Get(nsn, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				// not generated yet, or not used, for example if TLS is disabled
+				continue
+			}
+			return bundle, err
+		}
+		bundle.Secrets = append(bundle.Secrets, corev1.Secret{
+			ObjectMeta: exportedMeta(secret.ObjectMeta),
+			Type:       secret.Type,
+			Data:       secret.Data,
+		})
+	}
+	return bundle, nil
+}
+
+// Import creates the resources of the given bundle which do not exist yet, the Secrets first for the operator to
+// reuse them instead of generating new ones. It returns the resources created, and the resources which already exist
+// and are left untouched, formatted as `<kind> <namespace>/<name>`.
+func Import(c k8s.Client, bundle Bundle) (created []string, existing []string, err error) {
+	if bundle.Version != BundleVersion {
+		return nil, nil, errors.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	var objects []runtime.Object
+	for i := range bundle.Secrets {
+		objects = append(objects, &bundle.Secrets[i])
+	}
+	// create Elasticsearch clusters before the resources associated to them
+	for i := range bundle.Elasticsearches {
+		objects = append(objects, &bundle.Elasticsearches[i])
+	}
+	for i := range bundle.Kibanas {
+		objects = append(objects, &bundle.Kibanas[i])
+	}
+	for i := range bundle.ApmServers {
+		objects = append(objects, &bundle.ApmServers[i])
+	}
+	for i := range bundle.EnterpriseSearches {
+		objects = append(objects, &bundle.EnterpriseSearches[i])
+	}
+	for _, obj := range objects {
+		description := describe(obj)
+		if err := c.Create(obj); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				existing = append(existing, description)
+				continue
+			}
+			return created, existing, errors.Wrapf(err, "failed to create %s", description)
+		}
+		created = append(created, description)
+	}
+	return created, existing, nil
+}
+
+// exportedMeta returns the metadata of an exported resource, without the fields specific to the Kubernetes cluster.
+func exportedMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:   meta.Namespace,
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
+
+func describe(obj runtime.Object) string {
+	kind := "Resource"
+	if gvk, err := apiutil.GVKForObject(obj, scheme.Scheme); err == nil {
+		kind = gvk.Kind
+	}
+	return fmt.Sprintf("%s %s", kind, k8s.ExtractNamespacedName(obj.(metav1.Object)))
+}
+
+// secretSet accumulates the names of the Secrets to export, without duplicates.
+type secretSet struct {
+	names []types.NamespacedName
+	seen  map[types.NamespacedName]bool
+}
+
+func (s *secretSet) add(namespace string, names ...string) {
+	if s.seen == nil {
+		s.seen = map[types.NamespacedName]bool{}
+	}
+	for _, name := range names {
+		nsn := types.NamespacedName{Namespace: namespace, Name: name}
+		if name == "" || s.seen[nsn] {
+			continue
+		}
+		s.seen[nsn] = true
+		s.names = append(s.names, nsn)
+	}
+}
+
+func (s *secretSet) addSources(namespace string, sources []commonv1.SecretSource) {
+	for _, source := range sources {
+		if source.Namespace != "" {
+			// shared from another namespace
+			s.add(source.Namespace, source.SecretName)
+			continue
+		}
+		s.add(namespace, source.SecretName)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func secret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			UID:             "uid",
+			ResourceVersion: "1",
+			OwnerReferences: []metav1.OwnerReference{{Name: "owner"}},
+		},
+		Data: map[string][]byte{"key": []byte(name)},
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	scheme.SetupScheme()
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "es",
+			UID:         "es-uid",
+			Annotations: map[string]string{"elasticsearch.k8s.elastic.co/cluster-uuid": "cluster-uuid"},
+		},
+		Spec: esv1.ElasticsearchSpec{
+			Version:        "7.6.2",
+			SecureSettings: []commonv1.SecretSource{{SecretName: "es-keystore"}},
+		},
+		Status: esv1.ElasticsearchStatus{Phase: esv1.ElasticsearchReadyPhase},
+	}
+	kb := kbv1.Kibana{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "kb"}}
+	source := k8s.WrappedFakeClient(
+		&es,
+		&kb,
+		secret("ns", "es-es-http-ca-internal"),
+		secret("ns", "es-es-transport-ca-internal"),
+		secret("ns", "es-es-elastic-user"),
+		secret("ns", "es-es-internal-users"),
+		secret("ns", "es-keystore"),
+		secret("other", "kb-kb-config"),
+		// not exported
+		secret("ns", "es-es-http-certs-internal"),
+		secret("ns", "unrelated"),
+	)
+
+	bundle, err := Export(source, "ns")
+	require.NoError(t, err)
+	require.Len(t, bundle.Elasticsearches, 1)
+	require.Empty(t, bundle.Kibanas)
+	exportedES := bundle.Elasticsearches[0]
+	require.Equal(t, es.Spec, exportedES.Spec)
+	require.Equal(t, esv1.ElasticsearchStatus{}, exportedES.Status)
+	require.Equal(t, types.UID(""), exportedES.UID)
+	require.Equal(t, "cluster-uuid", exportedES.Annotations["elasticsearch.k8s.elastic.co/cluster-uuid"])
+	var secretNames []string
+	for _, s := range bundle.Secrets {
+		secretNames = append(secretNames, s.Name)
+		require.Empty(t, s.OwnerReferences)
+		require.Empty(t, s.ResourceVersion)
+		require.Equal(t, []byte(s.Name), s.Data["key"])
+	}
+	require.ElementsMatch(t, []string{
+		"es-es-http-ca-internal", "es-es-transport-ca-internal", "es-es-elastic-user", "es-es-internal-users", "es-keystore",
+	}, secretNames)
+
+	bundle, err = Export(source, "")
+	require.NoError(t, err)
+	require.Len(t, bundle.Elasticsearches, 1)
+	require.Len(t, bundle.Kibanas, 1)
+	require.Len(t, bundle.Secrets, 6)
+
+	// import in a cluster where one of the Secrets already exists
+	target := k8s.WrappedFakeClient(secret("ns", "es-es-elastic-user"))
+	created, existing, err := Import(target, bundle)
+	require.NoError(t, err)
+	require.Len(t, created, 7)
+	require.Equal(t, []string{"Secret ns/es-es-elastic-user"}, existing)
+	require.Contains(t, created, "Elasticsearch ns/es")
+	var imported esv1.Elasticsearch
+	require.NoError(t, target.Get(k8s.ExtractNamespacedName(&es), &imported))
+	require.Equal(t, es.Spec, imported.Spec)
+	require.Equal(t, "cluster-uuid", imported.Annotations["elasticsearch.k8s.elastic.co/cluster-uuid"])
+	var keystore corev1.Secret
+	require.NoError(t, target.Get(types.NamespacedName{Namespace: "ns", Name: "es-keystore"}, &keystore))
+	require.Equal(t, []byte("es-keystore"), keystore.Data["key"])
+
+	_, _, err = Import(target, Bundle{Version: 42})
+	require.Error(t, err)
+}
@@ -29,8 +29,11 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/finalizer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/pod"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/profile"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
@@ -70,9 +73,11 @@ var (
 
 	initContainerParameters = keystore.InitContainerParameters{
 		KeystoreCreateCommand:         ApmServerBin + " keystore create --force",
-		KeystoreAddCommand:            ApmServerBin + ` keystore add "$key" --stdin < "$filename"`,
+		KeystoreAddCommand:            ApmServerBin + ` keystore add "$key" --force --stdin < "$filename"`,
+		KeystoreRemoveCommand:         ApmServerBin + ` keystore remove "$key"`,
 		SecureSettingsVolumeMountPath: keystore.SecureSettingsVolumeMountPath,
 		DataVolumePath:                DataVolumePath,
+		KeystorePath:                  filepath.Join(DataVolumePath, "apm-server.keystore"),
 	}
 )
 
@@ -80,7 +85,7 @@ var (
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, params operator.Parameters) error {
 	reconciler := newReconciler(mgr, params)
-	c, err := common.NewController(mgr, name, reconciler, params)
+	c, err := common.NewController(mgr, name, &apmv1.ApmServer{}, reconciler, params)
 	if err != nil {
 		return err
 	}
@@ -134,6 +139,11 @@ func addWatches(c controller.Controller, r *ReconcileApmServer) error {
 		return err
 	}
 
+	// watch the defaults profiles overriding the operator settings per namespace
+	if err := profile.WatchOverlays(c, r.Client, r.OperatorNamespace, &apmv1.ApmServerList{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -206,9 +216,17 @@ func (r *ReconcileApmServer) Reconcile(request reconcile.Request) (reconcile.Res
 		return reconcile.Result{}, tracing.CaptureError(ctx, err)
 	}
 
+	if err := config.ValidateOutput(as); err != nil {
+		k8s.EmitErrorEvent(r.recorder, err, &as, events.EventReasonValidation, "Invalid output: %v", err)
+		return reconcile.Result{}, err
+	}
+
 	if !association.IsConfiguredIfSet(&as, r.recorder) {
 		return reconcile.Result{}, nil
 	}
+	if allowed, err := association.AllowVersion(r.Client, &as, as.Spec.Version, r.recorder); err != nil || !allowed {
+		return association.VersionBlockedRequeue, tracing.CaptureError(ctx, err)
+	}
 
 	return r.doReconcile(ctx, request, &as)
 }
@@ -224,17 +242,28 @@ func (r *ReconcileApmServer) isCompatible(ctx context.Context, as *apmv1.ApmServ
 
 func (r *ReconcileApmServer) doReconcile(ctx context.Context, request reconcile.Request, as *apmv1.ApmServer) (reconcile.Result, error) {
 	state := NewState(request, as)
+	params := r.ForNamespace(as.Namespace)
 	svc, err := common.ReconcileService(ctx, r.Client, NewService(*as), as)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	results := apmcerts.Reconcile(ctx, r, as, []corev1.Service{*svc}, r.CACertRotation, r.CertRotation)
+	if r.OpenShift {
+		if err := openshift.ReconcileRoute(r.Client, *svc, as.Spec.HTTP.TLS.Enabled(), as); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	results := apmcerts.Reconcile(ctx, r, as, []corev1.Service{*svc}, params.GetCACertRotation(), params.GetCertRotation())
 	if results.HasError() {
 		res, err := results.Aggregate()
 		k8s.EmitErrorEvent(r.recorder, err, as, events.EventReconciliationError, "Certificate reconciliation error: %v", err)
 		return res, err
 	}
 
+	if err := params.Overlay.ValidateSecureSettings(as.Namespace, as.SecureSettings()); err != nil {
+		k8s.EmitErrorEvent(r.recorder, err, as, events.EventReasonValidation, "Invalid secure settings: %v", err)
+		return reconcile.Result{}, err
+	}
+
 	state, err = r.reconcileApmServerDeployment(ctx, state, as)
 	if err != nil {
 		if apierrors.IsConflict(err) {
@@ -261,6 +290,7 @@ func (r *ReconcileApmServer) doReconcile(ctx context.Context, request reconcile.
 func (r *ReconcileApmServer) onDelete(obj types.NamespacedName) {
 	// Clean up watches set on secure settings
 	r.dynamicWatches.Secrets.RemoveHandlerForKey(keystore.SecureSettingsWatchName(obj))
+	r.dynamicWatches.Secrets.RemoveHandlerForKey(outputSecretsWatchName(obj))
 }
 
 // reconcileApmServerToken reconciles a Secret containing the APM Server token.
@@ -295,6 +325,12 @@ func (r *ReconcileApmServer) deploymentParams(
 ) (deployment.Params, error) {
 
 	podSpec := newPodSpec(as, params)
+	if r.OpenShift {
+		openshift.SetRestrictedSecurityContext(&podSpec)
+	}
+	if err := podsecurity.Enforce(r.PodSecurityStandard, as, &podSpec); err != nil {
+		return deployment.Params{}, err
+	}
 	podLabels := labels.NewLabels(as.Name)
 
 	// Build a checksum of the configuration, add it to the pod labels so a change triggers a rolling update
@@ -361,6 +397,10 @@ func (r *ReconcileApmServer) deploymentParams(
 		apmServerContainer.VolumeMounts = append(apmServerContainer.VolumeMounts, httpCertsVolume.VolumeMount())
 	}
 
+	if err := r.withOutputSecrets(*as, &podSpec, configChecksum); err != nil {
+		return deployment.Params{}, err
+	}
+
 	podLabels[configChecksumLabelName] = fmt.Sprintf("%x", configChecksum.Sum(nil))
 	// TODO: also need to hash secret token?
 
@@ -374,6 +414,7 @@ func (r *ReconcileApmServer) deploymentParams(
 		Labels:          labels.NewLabels(as.Name),
 		PodTemplateSpec: podSpec,
 		Strategy:        appsv1.RollingUpdateDeploymentStrategyType,
+		Version:         as.Spec.Version,
 	}, nil
 }
 
@@ -385,6 +426,10 @@ func (r *ReconcileApmServer) reconcileApmServerDeployment(
 	span, _ := apm.StartSpan(ctx, "reconcile_deployment", tracing.SpanTypeApp)
 	defer span.End()
 
+	if err := r.reconcileOutputSecretsWatch(*as); err != nil {
+		return state, err
+	}
+
 	tokenSecret, err := reconcileApmServerToken(r.Client, as)
 	if err != nil {
 		return state, err
@@ -415,6 +460,7 @@ func (r *ReconcileApmServer) reconcileApmServerDeployment(
 		ConfigSecret: reconciledConfigSecret,
 
 		keystoreResources: keystoreResources,
+		openShift:         r.OpenShift,
 	}
 	params, err := r.deploymentParams(as, apmServerPodSpecParams)
 	if err != nil {
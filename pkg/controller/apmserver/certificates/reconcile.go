@@ -18,6 +18,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/apmserver/name"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/driver"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 )
@@ -44,6 +45,7 @@ func Reconcile(
 	// reconcile CA certs first
 	httpCa, err := certificates.ReconcileCAForOwner(
 		driver.K8sClient(),
+		credentials.Default,
 		name.APMNamer,
 		as,
 		labels,
@@ -63,6 +63,13 @@ func NewConfigFromSpec(c k8s.Client, as *apmv1.ApmServer) (*settings.CanonicalCo
 
 		outputCfg = settings.MustCanonicalConfig(tmpOutputCfg)
 	}
+	if as.Spec.Output != nil {
+		tmpOutputCfg, err := outputSettings(c, *as)
+		if err != nil {
+			return nil, err
+		}
+		outputCfg = settings.MustCanonicalConfig(tmpOutputCfg)
+	}
 
 	// Create a base configuration.
 
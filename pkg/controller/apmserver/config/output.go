@@ -0,0 +1,93 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// OutputCertificatesDir is the directory the certificates of the output are mounted in, relative to the APM Server
+	// home directory.
+	OutputCertificatesDir = "config/output-certs"
+
+	// OutputUsernameKey and OutputPasswordKey are the keys of the credentials in the output credentials secret.
+	OutputUsernameKey = "username"
+	OutputPasswordKey = "password"
+	// OutputUsernameEnvVar and OutputPasswordEnvVar are the environment variables the output credentials are exposed
+	// in to the APM Server, to not write them in the configuration secret.
+	OutputUsernameEnvVar = "OUTPUT_USERNAME"
+	OutputPasswordEnvVar = "OUTPUT_PASSWORD"
+)
+
+// ValidateOutput returns an error if the output of the given APM Server is not valid.
+func ValidateOutput(as apmv1.ApmServer) error {
+	output := as.Spec.Output
+	if output == nil {
+		return nil
+	}
+	if as.Spec.ElasticsearchRef.Name != "" {
+		return errors.New("spec.output and spec.elasticsearchRef are mutually exclusive")
+	}
+	if (output.Logstash == nil) == (output.Kafka == nil) {
+		return errors.New("exactly one of spec.output.logstash and spec.output.kafka must be set")
+	}
+	return nil
+}
+
+// outputSettings returns the settings of the output of the given APM Server other than Elasticsearch, if any.
+func outputSettings(c k8s.Client, as apmv1.ApmServer) (map[string]interface{}, error) {
+	var prefix string
+	var cfg map[string]interface{}
+	switch output := as.Spec.Output; {
+	case output == nil:
+		return nil, nil
+	case output.Logstash != nil:
+		prefix = "output.logstash."
+		cfg = map[string]interface{}{
+			prefix + "hosts": output.Logstash.Hosts,
+		}
+	case output.Kafka != nil:
+		prefix = "output.kafka."
+		cfg = map[string]interface{}{
+			prefix + "hosts": output.Kafka.Hosts,
+			prefix + "topic": output.Kafka.Topic,
+		}
+		if output.Kafka.CredentialsSecretName != "" {
+			cfg[prefix+"username"] = "${" + OutputUsernameEnvVar + "}"
+			cfg[prefix+"password"] = "${" + OutputPasswordEnvVar + "}"
+		}
+	default:
+		return nil, nil
+	}
+
+	tls := as.Spec.Output.TLS()
+	if tls == nil {
+		return cfg, nil
+	}
+	var secret corev1.Secret
+	if err := c.Get(types.NamespacedName{Namespace: as.Namespace, Name: tls.SecretName}, &secret); err != nil {
+		return nil, errors.Wrapf(err, "while getting output TLS secret %s/%s", as.Namespace, tls.SecretName)
+	}
+	cfg[prefix+"ssl.enabled"] = true
+	if _, exists := secret.Data[certificates.CAFileName]; exists {
+		cfg[prefix+"ssl.certificate_authorities"] = []string{filepath.Join(OutputCertificatesDir, certificates.CAFileName)}
+	}
+	_, hasCert := secret.Data[certificates.CertFileName]
+	_, hasKey := secret.Data[certificates.KeyFileName]
+	if hasCert && hasKey {
+		cfg[prefix+"ssl.certificate"] = filepath.Join(OutputCertificatesDir, certificates.CertFileName)
+		cfg[prefix+"ssl.key"] = filepath.Join(OutputCertificatesDir, certificates.KeyFileName)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,130 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestValidateOutput(t *testing.T) {
+	logstash := &apmv1.LogstashOutput{Hosts: []string{"logstash:5044"}}
+	kafka := &apmv1.KafkaOutput{Hosts: []string{"kafka:9092"}, Topic: "apm"}
+	tests := []struct {
+		name    string
+		spec    apmv1.ApmServerSpec
+		wantErr bool
+	}{
+		{
+			name: "no output",
+			spec: apmv1.ApmServerSpec{ElasticsearchRef: commonv1.ObjectSelector{Name: "es"}},
+		},
+		{
+			name: "Logstash output",
+			spec: apmv1.ApmServerSpec{Output: &apmv1.ApmServerOutput{Logstash: logstash}},
+		},
+		{
+			name:    "output and Elasticsearch reference",
+			spec:    apmv1.ApmServerSpec{Output: &apmv1.ApmServerOutput{Kafka: kafka}, ElasticsearchRef: commonv1.ObjectSelector{Name: "es"}},
+			wantErr: true,
+		},
+		{
+			name:    "both Logstash and Kafka",
+			spec:    apmv1.ApmServerSpec{Output: &apmv1.ApmServerOutput{Logstash: logstash, Kafka: kafka}},
+			wantErr: true,
+		},
+		{
+			name:    "empty output",
+			spec:    apmv1.ApmServerSpec{Output: &apmv1.ApmServerOutput{}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOutput(apmv1.ApmServer{Spec: tt.spec})
+			require.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_outputSettings(t *testing.T) {
+	tlsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "output-tls"},
+		Data: map[string][]byte{
+			"ca.crt":  []byte("ca"),
+			"tls.crt": []byte("cert"),
+			"tls.key": []byte("key"),
+		},
+	}
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "output-ca"},
+		Data:       map[string][]byte{"ca.crt": []byte("ca")},
+	}
+	tests := []struct {
+		name    string
+		output  *apmv1.ApmServerOutput
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "no output",
+		},
+		{
+			name:   "Logstash with a CA",
+			output: &apmv1.ApmServerOutput{Logstash: &apmv1.LogstashOutput{Hosts: []string{"logstash:5044"}, TLS: &apmv1.OutputTLS{SecretName: "output-ca"}}},
+			want: map[string]interface{}{
+				"output.logstash.hosts":                       []string{"logstash:5044"},
+				"output.logstash.ssl.enabled":                 true,
+				"output.logstash.ssl.certificate_authorities": []string{"config/output-certs/ca.crt"},
+			},
+		},
+		{
+			name: "Kafka with credentials and client certificate",
+			output: &apmv1.ApmServerOutput{Kafka: &apmv1.KafkaOutput{
+				Hosts:                 []string{"kafka-0:9092", "kafka-1:9092"},
+				Topic:                 "apm-%{[processor.event]}",
+				CredentialsSecretName: "kafka-credentials",
+				TLS:                   &apmv1.OutputTLS{SecretName: "output-tls"},
+			}},
+			want: map[string]interface{}{
+				"output.kafka.hosts":                       []string{"kafka-0:9092", "kafka-1:9092"},
+				"output.kafka.topic":                       "apm-%{[processor.event]}",
+				"output.kafka.username":                    "${OUTPUT_USERNAME}",
+				"output.kafka.password":                    "${OUTPUT_PASSWORD}",
+				"output.kafka.ssl.enabled":                 true,
+				"output.kafka.ssl.certificate_authorities": []string{"config/output-certs/ca.crt"},
+				"output.kafka.ssl.certificate":             "config/output-certs/tls.crt",
+				"output.kafka.ssl.key":                     "config/output-certs/tls.key",
+			},
+		},
+		{
+			name:    "missing TLS secret",
+			output:  &apmv1.ApmServerOutput{Logstash: &apmv1.LogstashOutput{Hosts: []string{"logstash:5044"}, TLS: &apmv1.OutputTLS{SecretName: "unknown"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := apmv1.ApmServer{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "apm"},
+				Spec:       apmv1.ApmServerSpec{Output: tt.output},
+			}
+			got, err := outputSettings(k8s.WrappedFakeClient(tlsSecret, caSecret), as)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
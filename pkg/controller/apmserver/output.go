@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package apmserver
+
+import (
+	"hash"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/apmserver/config"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/pod"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// outputSecretsWatchName returns the name of the watch on the secrets of the output of the given APM Server.
+func outputSecretsWatchName(as types.NamespacedName) string {
+	return as.Namespace + "-" + as.Name + "-output-secrets"
+}
+
+// outputSecretNames returns the names of the secrets the output of the given APM Server references.
+func outputSecretNames(as apmv1.ApmServer) []string {
+	var names []string
+	if tls := as.Spec.Output.TLS(); tls != nil {
+		names = append(names, tls.SecretName)
+	}
+	if output := as.Spec.Output; output != nil && output.Kafka != nil && output.Kafka.CredentialsSecretName != "" {
+		names = append(names, output.Kafka.CredentialsSecretName)
+	}
+	return names
+}
+
+// reconcileOutputSecretsWatch watches the secrets referenced by the output of the given APM Server, so that a change
+// of their content rolls the APM Server Pods.
+func (r *ReconcileApmServer) reconcileOutputSecretsWatch(as apmv1.ApmServer) error {
+	asKey := k8s.ExtractNamespacedName(&as)
+	names := outputSecretNames(as)
+	if len(names) == 0 {
+		r.dynamicWatches.Secrets.RemoveHandlerForKey(outputSecretsWatchName(asKey))
+		return nil
+	}
+	watched := make([]types.NamespacedName, 0, len(names))
+	for _, name := range names {
+		watched = append(watched, types.NamespacedName{Namespace: as.Namespace, Name: name})
+	}
+	return r.dynamicWatches.Secrets.AddHandler(watches.NamedWatch{
+		Name:    outputSecretsWatchName(asKey),
+		Watched: watched,
+		Watcher: asKey,
+	})
+}
+
+// withOutputSecrets mounts the TLS secret of the output of the given APM Server in the APM Server container and
+// exposes the output credentials to it, writing the content of both secrets to the given checksum.
+func (r *ReconcileApmServer) withOutputSecrets(as apmv1.ApmServer, podSpec *corev1.PodTemplateSpec, checksum hash.Hash) error {
+	if as.Spec.Output == nil {
+		return nil
+	}
+	container := pod.ContainerByName(podSpec.Spec, apmv1.ApmServerContainerName)
+	if container == nil {
+		return nil
+	}
+	for _, name := range outputSecretNames(as) {
+		var secret corev1.Secret
+		if err := r.Get(types.NamespacedName{Namespace: as.Namespace, Name: name}, &secret); err != nil {
+			return err
+		}
+		for _, key := range []string{certificates.CAFileName, certificates.CertFileName, certificates.KeyFileName,
+			config.OutputUsernameKey, config.OutputPasswordKey} {
+			_, _ = checksum.Write(secret.Data[key])
+		}
+	}
+
+	if tls := as.Spec.Output.TLS(); tls != nil {
+		certsVolume := volume.NewSecretVolumeWithMountPath(
+			tls.SecretName,
+			"output-certs",
+			filepath.Join(ApmBaseDir, config.OutputCertificatesDir),
+		)
+		podSpec.Spec.Volumes = append(podSpec.Spec.Volumes, certsVolume.Volume())
+		container.VolumeMounts = append(container.VolumeMounts, certsVolume.VolumeMount())
+	}
+	if kafka := as.Spec.Output.Kafka; kafka != nil && kafka.CredentialsSecretName != "" {
+		container.Env = append(container.Env,
+			secretKeyEnvVar(config.OutputUsernameEnvVar, kafka.CredentialsSecretName, config.OutputUsernameKey),
+			secretKeyEnvVar(config.OutputPasswordEnvVar, kafka.CredentialsSecretName, config.OutputPasswordKey),
+		)
+	}
+	return nil
+}
+
+func secretKeyEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package apmserver
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestReconcileApmServer_withOutputSecrets(t *testing.T) {
+	as := apmv1.ApmServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "apm"},
+		Spec: apmv1.ApmServerSpec{Output: &apmv1.ApmServerOutput{Kafka: &apmv1.KafkaOutput{
+			Hosts:                 []string{"kafka:9092"},
+			Topic:                 "apm",
+			CredentialsSecretName: "kafka-credentials",
+			TLS:                   &apmv1.OutputTLS{SecretName: "kafka-tls"},
+		}}},
+	}
+	secrets := func(password string) *ReconcileApmServer {
+		return &ReconcileApmServer{
+			Client: k8s.WrappedFakeClient(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "kafka-credentials"},
+					Data:       map[string][]byte{"username": []byte("apm"), "password": []byte(password)},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "kafka-tls"},
+					Data:       map[string][]byte{"ca.crt": []byte("ca")},
+				},
+			),
+			dynamicWatches: watches.NewDynamicWatches(),
+		}
+	}
+	podWithSecrets := func(r *ReconcileApmServer) (corev1.PodTemplateSpec, string) {
+		podSpec := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: apmv1.ApmServerContainerName}}}}
+		checksum := sha256.New224()
+		require.NoError(t, r.withOutputSecrets(as, &podSpec, checksum))
+		return podSpec, string(checksum.Sum(nil))
+	}
+
+	r := secrets("changeme")
+	podSpec, checksum := podWithSecrets(r)
+	require.Len(t, podSpec.Spec.Volumes, 1)
+	require.Equal(t, "kafka-tls", podSpec.Spec.Volumes[0].Secret.SecretName)
+	container := podSpec.Spec.Containers[0]
+	require.Equal(t, "/usr/share/apm-server/config/output-certs", container.VolumeMounts[0].MountPath)
+	require.Equal(t, []string{"OUTPUT_USERNAME", "OUTPUT_PASSWORD"}, []string{container.Env[0].Name, container.Env[1].Name})
+	require.Equal(t, "password", container.Env[1].ValueFrom.SecretKeyRef.Key)
+
+	// a change of the credentials rolls the Pods
+	_, otherChecksum := podWithSecrets(secrets("updated"))
+	require.NotEqual(t, checksum, otherChecksum)
+
+	// the referenced secrets are watched
+	require.NoError(t, r.reconcileOutputSecretsWatch(as))
+	require.Equal(t, []string{"ns-apm-output-secrets"}, r.dynamicWatches.Secrets.Registrations())
+	as.Spec.Output = nil
+	require.NoError(t, r.reconcileOutputSecretsWatch(as))
+	require.Empty(t, r.dynamicWatches.Secrets.Registrations())
+}
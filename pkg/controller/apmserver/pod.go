@@ -82,6 +82,9 @@ type PodSpecParams struct {
 	ConfigSecret corev1.Secret
 
 	keystoreResources *keystore.Resources
+	// openShift mounts an emptyDir data volume even with no keystore: the data directory of the image is not writable
+	// by the arbitrary user the restricted SCC runs the Pods as
+	openShift bool
 }
 
 func newPodSpec(as *apmv1.ApmServer, p PodSpecParams) corev1.PodTemplateSpec {
@@ -106,7 +109,7 @@ func newPodSpec(as *apmv1.ApmServer, p PodSpecParams) corev1.PodTemplateSpec {
 	builder := defaults.NewPodTemplateBuilder(
 		p.PodTemplate, apmv1.ApmServerContainerName).
 		WithResources(DefaultResources).
-		WithDockerImage(p.CustomImageName, container.ImageRepository(container.APMServerImage, p.Version)).
+		WithDockerImage(p.CustomImageName, container.ImageRepositoryForNamespace(container.APMServerImage, p.Version, as.Namespace, "")).
 		WithReadinessProbe(readinessProbe(as.Spec.HTTP.TLS.Enabled())).
 		WithPorts(ports).
 		WithCommand(command).
@@ -114,15 +117,18 @@ func newPodSpec(as *apmv1.ApmServer, p PodSpecParams) corev1.PodTemplateSpec {
 		WithVolumeMounts(configVolume.VolumeMount(), configSecretVolume.VolumeMount()).
 		WithEnv(env...)
 
+	dataVolume := keystore.DataVolume(
+		strings.ToLower(as.Kind),
+		DataVolumePath,
+	)
 	if p.keystoreResources != nil {
-		dataVolume := keystore.DataVolume(
-			strings.ToLower(as.Kind),
-			DataVolumePath,
-		)
 		builder.WithInitContainers(p.keystoreResources.InitContainer).
 			WithVolumes(p.keystoreResources.Volume, dataVolume.Volume()).
 			WithVolumeMounts(dataVolume.VolumeMount()).
 			WithInitContainerDefaults()
+	} else if p.openShift {
+		builder.WithVolumes(dataVolume.Volume()).
+			WithVolumeMounts(dataVolume.VolumeMount())
 	}
 
 	return builder.PodTemplate
@@ -54,7 +54,7 @@ var (
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
 	r := newReconciler(mgr, accessReviewer, params)
-	c, err := common.NewController(mgr, name, r, params)
+	c, err := common.NewController(mgr, name, &apmv1.ApmServer{}, r, params)
 	if err != nil {
 		return err
 	}
@@ -120,6 +120,10 @@ func (r *ReconcileApmServerElasticsearchAssociation) onDelete(obj types.Namespac
 	r.watches.Secrets.RemoveHandlerForKey(esCAWatchName(obj))
 	// Remove watcher on the user Secret in the Elasticsearch namespace
 	r.watches.Secrets.RemoveHandlerForKey(elasticsearchWatchName(obj))
+	// Delete the user password from the external credentials store, if any
+	if err := association.DeleteStoredCredentials(obj, apmUserSuffix); err != nil {
+		return err
+	}
 	// Delete user Secret in the Elasticsearch namespace
 	return k8s.DeleteSecretMatching(r.Client, newUserLabelSelector(obj))
 }
@@ -365,6 +369,9 @@ func (r *ReconcileApmServerElasticsearchAssociation) updateAssocConf(ctx context
 func (r *ReconcileApmServerElasticsearchAssociation) Unbind(apm commonv1.Associated) error {
 	apmKey := k8s.ExtractNamespacedName(apm)
 	// Ensure that user in Elasticsearch is deleted to prevent illegitimate access
+	if err := association.DeleteStoredCredentials(apmKey, apmUserSuffix); err != nil {
+		return err
+	}
 	if err := k8s.DeleteSecretMatching(r.Client, newUserLabelSelector(apmKey)); err != nil {
 		return err
 	}
@@ -88,13 +88,18 @@ func (ugc *UsersGarbageCollector) getUserSecrets() ([]v1.Secret, error) {
 	return userSecrets, nil
 }
 
+// getUserSecretsInNamespace returns the associated user and service account token secrets in the given namespace.
 func getUserSecretsInNamespace(c k8s.Client, namespace string) ([]v1.Secret, error) {
-	userSecrets := v1.SecretList{}
-	matchingLabels := client.MatchingLabels(map[string]string{common.TypeLabelName: esuser.AssociatedUserType})
-	if err := c.List(&userSecrets, client.InNamespace(namespace), matchingLabels); err != nil {
-		return nil, err
+	var secrets []v1.Secret
+	for _, secretType := range []string{esuser.AssociatedUserType, esuser.AssociatedServiceAccountTokenType} {
+		userSecrets := v1.SecretList{}
+		matchingLabels := client.MatchingLabels(map[string]string{common.TypeLabelName: secretType})
+		if err := c.List(&userSecrets, client.InNamespace(namespace), matchingLabels); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, userSecrets.Items...)
 	}
-	return userSecrets.Items, nil
+	return secrets, nil
 }
 
 // DoGarbageCollection runs the User garbage collector.
@@ -0,0 +1,223 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package association
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"go.elastic.co/apm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	esuser "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/cryptutil"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// ServiceAccountName is the qualified name of an Elasticsearch service account.
+type ServiceAccountName string
+
+const (
+	// KibanaServiceAccount is the service account used by Kibana.
+	KibanaServiceAccount ServiceAccountName = "elastic/kibana"
+
+	// ServiceAccountTokenKey is the key of the token in the service account token secret of the associated resource.
+	ServiceAccountTokenKey = "token"
+	// serviceAccountTokenNameKey is the key of the token name in the service account token secret of the associated resource.
+	serviceAccountTokenNameKey = "name"
+)
+
+var (
+	// ServiceAccountTokenMinVersion is the first version of the stack supporting service account tokens.
+	ServiceAccountTokenMinVersion = version.MustParse("7.17.0")
+
+	// serviceAccountTokenMagicBytes prefix the content of a service account token.
+	serviceAccountTokenMagicBytes = []byte{0, 1, 0, 1}
+)
+
+// serviceAccountTokenName identifies the token of the associated resource. It must be namespace-aware, since we might
+// have several associated instances running in different namespaces with the same name.
+func serviceAccountTokenName(associated commonv1.Associated) string {
+	// token names are restricted to alphanumeric characters, dashes and underscores
+	return strings.ReplaceAll(associated.GetNamespace()+"_"+associated.GetName(), ".", "-")
+}
+
+// ServiceAccountTokenSecretKeySelector creates a SecretKeySelector for the associated service account token secret.
+func ServiceAccountTokenSecretKeySelector(associated commonv1.Associated, tokenObjectSuffix string) *corev1.SecretKeySelector {
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: userSecretObjectName(associated, tokenObjectSuffix),
+		},
+		Key: ServiceAccountTokenKey,
+	}
+}
+
+// SupportsServiceAccountToken returns true if both the associated resource with the given version and all the nodes of
+// the Elasticsearch cluster support service account tokens.
+func SupportsServiceAccountToken(c k8s.Client, associatedVersion string, es esv1.Elasticsearch) (bool, error) {
+	versions := make([]version.Version, 0, 3)
+	for _, v := range []string{associatedVersion, es.Spec.Version} {
+		parsed, err := version.Parse(v)
+		if err != nil {
+			return false, err
+		}
+		versions = append(versions, *parsed)
+	}
+	// nodes still running an older version during an upgrade would reject the token
+	var pods corev1.PodList
+	if err := c.List(&pods, client.InNamespace(es.Namespace), label.NewLabelSelectorForElasticsearch(es)); err != nil {
+		return false, err
+	}
+	if len(pods.Items) > 0 {
+		running, err := label.MinVersion(pods.Items)
+		if err != nil {
+			return false, err
+		}
+		versions = append(versions, *running)
+	}
+	return version.Min(versions).IsSameOrAfter(ServiceAccountTokenMinVersion), nil
+}
+
+// ReconcileServiceAccountToken creates or updates the service account token of an associated resource.
+// The token is stored in a secret in the associated resource namespace, and its hash in a secret in the Elasticsearch
+// namespace, added to the service tokens file of the cluster. A new token is generated if the existing one is missing
+// or invalid, which allows to rotate the token by deleting its secret.
+func ReconcileServiceAccountToken(
+	ctx context.Context,
+	c k8s.Client,
+	associated commonv1.Associated,
+	labels map[string]string,
+	serviceAccount ServiceAccountName,
+	tokenObjectSuffix string,
+	es esv1.Elasticsearch,
+) error {
+	span, _ := apm.StartSpan(ctx, "reconcile_service_account_token", tracing.SpanTypeApp)
+	defer span.End()
+
+	tokenName := serviceAccountTokenName(associated)
+	secKey := secretKey(associated, tokenObjectSuffix)
+	expectedSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secKey.Name,
+			Namespace: secKey.Namespace,
+			Labels:    labels,
+		},
+	}
+
+	// reuse the existing token if valid
+	var existingSecret corev1.Secret
+	if err := c.Get(secKey, &existingSecret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	// the token held by an external credentials store takes precedence
+	if _, err := credentials.Load(secKey, &existingSecret); err != nil {
+		return err
+	}
+	token := existingSecret.Data[ServiceAccountTokenKey]
+	secret, err := parseServiceAccountToken(token, serviceAccount, tokenName)
+	if err != nil {
+		if len(token) > 0 {
+			log.Info("Rotating invalid service account token", "namespace", secKey.Namespace, "secret_name", secKey.Name, "reason", err.Error())
+		}
+		token, secret = newServiceAccountToken(serviceAccount, tokenName)
+	}
+	expectedSecret.Data = map[string][]byte{
+		ServiceAccountTokenKey:     token,
+		serviceAccountTokenNameKey: []byte(tokenName),
+	}
+	if err := credentials.Save(secKey, expectedSecret.Data); err != nil {
+		return err
+	}
+	if _, err := reconciler.ReconcileSecret(c, expectedSecret, associated); err != nil {
+		return err
+	}
+
+	// the token secret goes on the Elasticsearch side of the association, with the ES cluster labels
+	// and the association labels as for associated users
+	tokenLabels := esuser.AssociatedServiceAccountTokenLabels(es)
+	for key, value := range labels {
+		tokenLabels[key] = value
+	}
+	usrKey := UserKey(associated, tokenObjectSuffix)
+	expectedEsToken := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      usrKey.Name,
+			Namespace: usrKey.Namespace,
+			Labels:    tokenLabels,
+		},
+		Data: map[string][]byte{
+			esuser.ServiceAccountNameField:      []byte(serviceAccount),
+			esuser.ServiceAccountTokenNameField: []byte(tokenName),
+		},
+	}
+
+	// reuse the existing hash if valid
+	var existingEsToken corev1.Secret
+	if err := c.Get(usrKey, &existingEsToken); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	hash := existingEsToken.Data[esuser.ServiceAccountTokenHashField]
+	if cryptutil.ComparePBKDF2StretchHash(hash, secret) != nil {
+		if hash, err = cryptutil.PBKDF2Stretch(secret); err != nil {
+			return err
+		}
+	}
+	expectedEsToken.Data[esuser.ServiceAccountTokenHashField] = hash
+
+	owner := es // token is owned by the es resource in es namespace
+	_, err = reconciler.ReconcileSecret(c, expectedEsToken, &owner)
+	return err
+}
+
+// DeleteServiceAccountToken deletes the service account token secrets of an associated resource, which invalidates
+// the token.
+func DeleteServiceAccountToken(c k8s.Client, associated commonv1.Associated, tokenObjectSuffix string) error {
+	if err := credentials.Delete(secretKey(associated, tokenObjectSuffix)); err != nil {
+		return err
+	}
+	return deleteSecrets(c, secretKey(associated, tokenObjectSuffix), UserKey(associated, tokenObjectSuffix))
+}
+
+// newServiceAccountToken generates a new token for the given service account, returned with its secret part.
+func newServiceAccountToken(serviceAccount ServiceAccountName, tokenName string) ([]byte, []byte) {
+	secret := common.RandomPasswordBytes()
+	content := append(append([]byte{}, serviceAccountTokenMagicBytes...), []byte(fmt.Sprintf("%s/%s:%s", serviceAccount, tokenName, secret))...)
+	return []byte(base64.StdEncoding.EncodeToString(content)), secret
+}
+
+// parseServiceAccountToken returns the secret part of the given token, if it is a token for the given service account
+// and token name.
+func parseServiceAccountToken(token []byte, serviceAccount ServiceAccountName, tokenName string) ([]byte, error) {
+	if len(token) == 0 {
+		return nil, fmt.Errorf("token is empty")
+	}
+	content, err := base64.StdEncoding.DecodeString(string(token))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(content, serviceAccountTokenMagicBytes) {
+		return nil, fmt.Errorf("token is not a service account token")
+	}
+	prefix := []byte(fmt.Sprintf("%s/%s:", serviceAccount, tokenName))
+	content = bytes.TrimPrefix(content, serviceAccountTokenMagicBytes)
+	if !bytes.HasPrefix(content, prefix) || len(content) == len(prefix) {
+		return nil, fmt.Errorf("token is not a token %s for service account %s", tokenName, serviceAccount)
+	}
+	return content[len(prefix):], nil
+}
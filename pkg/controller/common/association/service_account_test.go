@@ -0,0 +1,179 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package association
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	esuser "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/cryptutil"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const tokenSuffix = "kibana-token"
+
+func Test_parseServiceAccountToken(t *testing.T) {
+	token, secret := newServiceAccountToken(KibanaServiceAccount, "default_kibana-foo")
+
+	parsed, err := parseServiceAccountToken(token, KibanaServiceAccount, "default_kibana-foo")
+	require.NoError(t, err)
+	require.Equal(t, secret, parsed)
+
+	for _, invalid := range [][]byte{nil, []byte("not base64"), []byte("Zm9v")} {
+		_, err = parseServiceAccountToken(invalid, KibanaServiceAccount, "default_kibana-foo")
+		require.Error(t, err)
+	}
+	// the token must match the service account and the token name
+	_, err = parseServiceAccountToken(token, "elastic/fleet-server", "default_kibana-foo")
+	require.Error(t, err)
+	_, err = parseServiceAccountToken(token, KibanaServiceAccount, "default_kibana-bar")
+	require.Error(t, err)
+}
+
+func Test_serviceAccountTokenName(t *testing.T) {
+	kb := kibanaFixture
+	kb.Name = "kibana.foo"
+	require.Equal(t, "default_kibana-foo", serviceAccountTokenName(&kb))
+}
+
+func TestSupportsServiceAccountToken(t *testing.T) {
+	es := esFixture
+	es.Spec.Version = "7.17.0"
+	pod := func(name, v string) runtime.Object {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: es.Namespace,
+			Name:      name,
+			Labels: map[string]string{
+				label.ClusterNameLabelName: es.Name,
+				label.VersionLabelName:     v,
+			},
+		}}
+	}
+	tests := []struct {
+		name              string
+		associatedVersion string
+		esVersion         string
+		pods              []runtime.Object
+		want              bool
+	}{
+		{
+			name:              "all versions support tokens",
+			associatedVersion: "7.17.0",
+			esVersion:         "7.17.0",
+			pods:              []runtime.Object{pod("a", "7.17.0"), pod("b", "7.17.1")},
+			want:              true,
+		},
+		{
+			name:              "no pods yet",
+			associatedVersion: "7.17.0",
+			esVersion:         "7.17.0",
+			want:              true,
+		},
+		{
+			name:              "associated version too old",
+			associatedVersion: "7.16.3",
+			esVersion:         "7.17.0",
+			want:              false,
+		},
+		{
+			name:              "Elasticsearch version too old",
+			associatedVersion: "7.17.0",
+			esVersion:         "7.16.3",
+			want:              false,
+		},
+		{
+			name:              "associated version just below the minimum",
+			associatedVersion: "7.16.99",
+			esVersion:         "7.17.0",
+			want:              false,
+		},
+		{
+			name:              "Elasticsearch version just below the minimum",
+			associatedVersion: "7.17.0",
+			esVersion:         "7.16.0",
+			want:              false,
+		},
+		{
+			name:              "minimum version",
+			associatedVersion: "7.17.0",
+			esVersion:         "7.17.0",
+			pods:              []runtime.Object{pod("a", "7.17.0")},
+			want:              true,
+		},
+		{
+			name:              "Elasticsearch upgrade in progress",
+			associatedVersion: "7.17.0",
+			esVersion:         "7.17.0",
+			pods:              []runtime.Object{pod("a", "7.17.0"), pod("b", "7.16.3")},
+			want:              false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es.Spec.Version = tt.esVersion
+			got, err := SupportsServiceAccountToken(k8s.WrappedFakeClient(tt.pods...), tt.associatedVersion, es)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcileServiceAccountToken(t *testing.T) {
+	c := k8s.WrappedFakeClient()
+	kb := kibanaFixture
+	labels := map[string]string{associationLabelName: kb.Name, associationLabelNamespace: kb.Namespace}
+
+	getSecrets := func() (corev1.Secret, corev1.Secret) {
+		var tokenSecret, esSecret corev1.Secret
+		require.NoError(t, c.Get(secretKey(&kb, tokenSuffix), &tokenSecret))
+		require.NoError(t, c.Get(UserKey(&kb, tokenSuffix), &esSecret))
+		return tokenSecret, esSecret
+	}
+
+	require.NoError(t, ReconcileServiceAccountToken(context.Background(), c, &kb, labels, KibanaServiceAccount, tokenSuffix, esFixture))
+	tokenSecret, esSecret := getSecrets()
+	token := tokenSecret.Data[ServiceAccountTokenKey]
+	secret, err := parseServiceAccountToken(token, KibanaServiceAccount, "default_kibana-foo")
+	require.NoError(t, err)
+	require.NoError(t, cryptutil.ComparePBKDF2StretchHash(esSecret.Data[esuser.ServiceAccountTokenHashField], secret))
+	require.Equal(t, "elastic/kibana", string(esSecret.Data[esuser.ServiceAccountNameField]))
+	require.Equal(t, "default_kibana-foo", string(esSecret.Data[esuser.ServiceAccountTokenNameField]))
+	require.Equal(t, esuser.AssociatedServiceAccountTokenType, esSecret.Labels["common.k8s.elastic.co/type"])
+	require.Equal(t, kb.Name, esSecret.Labels[associationLabelName])
+	hash := esSecret.Data[esuser.ServiceAccountTokenHashField]
+
+	// the token and its hash are reused
+	require.NoError(t, ReconcileServiceAccountToken(context.Background(), c, &kb, labels, KibanaServiceAccount, tokenSuffix, esFixture))
+	tokenSecret, esSecret = getSecrets()
+	require.Equal(t, token, tokenSecret.Data[ServiceAccountTokenKey])
+	require.Equal(t, hash, esSecret.Data[esuser.ServiceAccountTokenHashField])
+
+	// the token is rotated if invalid
+	tokenSecret.Data[ServiceAccountTokenKey] = []byte("invalid")
+	require.NoError(t, c.Update(&tokenSecret))
+	require.NoError(t, ReconcileServiceAccountToken(context.Background(), c, &kb, labels, KibanaServiceAccount, tokenSuffix, esFixture))
+	tokenSecret, esSecret = getSecrets()
+	require.NotEqual(t, token, tokenSecret.Data[ServiceAccountTokenKey])
+	secret, err = parseServiceAccountToken(tokenSecret.Data[ServiceAccountTokenKey], KibanaServiceAccount, "default_kibana-foo")
+	require.NoError(t, err)
+	require.NoError(t, cryptutil.ComparePBKDF2StretchHash(esSecret.Data[esuser.ServiceAccountTokenHashField], secret))
+
+	// both secrets are deleted on disassociation
+	require.NoError(t, DeleteServiceAccountToken(c, &kb, tokenSuffix))
+	err = c.Get(secretKey(&kb, tokenSuffix), &corev1.Secret{})
+	require.True(t, apierrors.IsNotFound(err))
+	err = c.Get(UserKey(&kb, tokenSuffix), &corev1.Secret{})
+	require.True(t, apierrors.IsNotFound(err))
+	// deleting missing secrets is a no-op
+	require.NoError(t, DeleteServiceAccountToken(c, &kb, tokenSuffix))
+}
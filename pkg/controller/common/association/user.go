@@ -17,6 +17,7 @@ import (
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	esuser "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
@@ -101,11 +102,18 @@ func ReconcileEsUser(
 	if err != nil && !apierrors.IsNotFound(err) {
 		return err
 	}
+	// the password held by an external credentials store takes precedence
+	if _, err := credentials.Load(secKey, &existingSecret); err != nil {
+		return err
+	}
 	if existingPassword, exists := existingSecret.Data[usrKey.Name]; exists {
 		password = existingPassword
 	}
 	expectedSecret.Data[usrKey.Name] = password
 
+	if err := credentials.Save(secKey, expectedSecret.Data); err != nil {
+		return err
+	}
 	if _, err := reconciler.ReconcileSecret(c, expectedSecret, associated); err != nil {
 		return err
 	}
@@ -152,3 +160,31 @@ func ReconcileEsUser(
 	_, err = reconciler.ReconcileSecret(c, expectedEsUser, &owner)
 	return err
 }
+
+// DeleteEsUser deletes the user secrets of an associated resource.
+func DeleteEsUser(c k8s.Client, associated commonv1.Associated, userObjectSuffix string) error {
+	if err := credentials.Delete(secretKey(associated, userObjectSuffix)); err != nil {
+		return err
+	}
+	return deleteSecrets(c, secretKey(associated, userObjectSuffix), UserKey(associated, userObjectSuffix))
+}
+
+// DeleteStoredCredentials deletes the credentials of the associated resource with the given suffixes from the external
+// credentials store, if any. The corresponding Kubernetes secrets are garbage collected or deleted separately.
+func DeleteStoredCredentials(associated types.NamespacedName, objectSuffixes ...string) error {
+	keys := make([]types.NamespacedName, 0, len(objectSuffixes))
+	for _, suffix := range objectSuffixes {
+		keys = append(keys, types.NamespacedName{Namespace: associated.Namespace, Name: associated.Name + "-" + suffix})
+	}
+	return credentials.Delete(keys...)
+}
+
+func deleteSecrets(c k8s.Client, keys ...types.NamespacedName) error {
+	for _, key := range keys {
+		secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+		if err := c.Delete(&secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
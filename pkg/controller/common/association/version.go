@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package association
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// VersionBlockedRequeue is the reconcile result of an associated resource whose version is blocked, to check again
+// later whether the Elasticsearch cluster was upgraded.
+var VersionBlockedRequeue = reconcile.Result{RequeueAfter: 30 * time.Second}
+
+// ElasticsearchVersion returns the lowest version among the version the Elasticsearch cluster is specified to run and
+// the versions its Pods run, which is the version the cluster runs once all its nodes are upgraded.
+func ElasticsearchVersion(c k8s.Client, es esv1.Elasticsearch) (*version.Version, error) {
+	specVersion, err := version.Parse(es.Spec.Version)
+	if err != nil {
+		return nil, err
+	}
+	var pods corev1.PodList
+	if err := c.List(&pods, client.InNamespace(es.Namespace), label.NewLabelSelectorForElasticsearch(es)); err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return specVersion, nil
+	}
+	running, err := label.MinVersion(pods.Items)
+	if err != nil {
+		return nil, err
+	}
+	return version.Min([]version.Version{*specVersion, *running}), nil
+}
+
+// AllowVersion returns true if the associated resource can be deployed with the given version, which must not be
+// more recent than the version of the Elasticsearch cluster it is associated with. This enforces the documented upgrade
+// order: a Kibana, APM Server or Enterprise Search upgrade is delayed until its Elasticsearch cluster is upgraded,
+// rather than crash looping against older Elasticsearch nodes. The reason why the resource is not reconciled, a delay
+// or an error while comparing the versions, is recorded in an event.
+func AllowVersion(c k8s.Client, associated commonv1.Associated, associatedVersion string, r record.EventRecorder) (bool, error) {
+	allowed, err := allowVersion(c, associated, associatedVersion, r)
+	if err != nil {
+		r.Eventf(associated, corev1.EventTypeWarning, events.EventReasonUnexpected,
+			"Failed to compare version %s to the version of the associated Elasticsearch cluster, not reconciling: %s",
+			associatedVersion, err.Error())
+	}
+	return allowed, err
+}
+
+func allowVersion(c k8s.Client, associated commonv1.Associated, associatedVersion string, r record.EventRecorder) (bool, error) {
+	esRef := associated.ElasticsearchRef()
+	if !(&esRef).IsDefined() {
+		return true, nil
+	}
+	expected, err := version.Parse(associatedVersion)
+	if err != nil {
+		return false, err
+	}
+	var es esv1.Elasticsearch
+	if err := c.Get(esRef.WithDefaultNamespace(associated.GetNamespace()).NamespacedName(), &es); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the association controller reports missing clusters
+			return true, nil
+		}
+		return false, err
+	}
+	esVersion, err := ElasticsearchVersion(c, es)
+	if err != nil {
+		return false, err
+	}
+	if esVersion.IsSameOrAfter(*expected) {
+		return true, nil
+	}
+	r.Eventf(associated, corev1.EventTypeWarning, events.EventReasonDelayed,
+		"Delaying the deployment of version %s until Elasticsearch %s runs it, its lowest version is %s",
+		expected, es.Name, esVersion)
+	log.Info("Associated resource version is more recent than the Elasticsearch version: delaying its deployment",
+		"kind", associated.GetObjectKind().GroupVersionKind().Kind,
+		"namespace", associated.GetNamespace(),
+		"name", associated.GetName(),
+		"version", expected.String(),
+		"es_version", esVersion.String(),
+	)
+	return false, nil
+}
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package association
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestAllowVersion(t *testing.T) {
+	es := func(version string) *esv1.Elasticsearch {
+		return &esv1.Elasticsearch{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+			Spec:       esv1.ElasticsearchSpec{Version: version},
+		}
+	}
+	esPod := func(name, version string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Labels: map[string]string{
+			label.ClusterNameLabelName: "es",
+			label.VersionLabelName:     version,
+		}}}
+	}
+	kb := &kbv1.Kibana{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "kb"},
+		Spec:       kbv1.KibanaSpec{Version: "7.7.0", ElasticsearchRef: commonv1.ObjectSelector{Name: "es"}},
+	}
+	tests := []struct {
+		name      string
+		kb        *kbv1.Kibana
+		objs      []runtime.Object
+		want      bool
+		wantErr   bool
+		wantEvent bool
+	}{
+		{
+			name: "no Elasticsearch reference",
+			kb:   &kbv1.Kibana{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "kb"}, Spec: kbv1.KibanaSpec{Version: "7.7.0"}},
+			want: true,
+		},
+		{
+			name: "Elasticsearch not found",
+			kb:   kb,
+			want: true,
+		},
+		{
+			name: "Elasticsearch runs the same version",
+			kb:   kb,
+			objs: []runtime.Object{es("7.7.0"), esPod("es-0", "7.7.0"), esPod("es-1", "7.7.0")},
+			want: true,
+		},
+		{
+			name: "Elasticsearch runs a more recent version",
+			kb:   kb,
+			objs: []runtime.Object{es("7.8.0"), esPod("es-0", "7.8.0")},
+			want: true,
+		},
+		{
+			name: "Elasticsearch without Pods yet",
+			kb:   kb,
+			objs: []runtime.Object{es("7.7.0")},
+			want: true,
+		},
+		{
+			name:      "Elasticsearch still upgrading",
+			kb:        kb,
+			objs:      []runtime.Object{es("7.7.0"), esPod("es-0", "7.7.0"), esPod("es-1", "7.6.0")},
+			want:      false,
+			wantEvent: true,
+		},
+		{
+			name:      "Elasticsearch specified with an older version",
+			kb:        kb,
+			objs:      []runtime.Object{es("7.6.0"), esPod("es-0", "7.6.0")},
+			want:      false,
+			wantEvent: true,
+		},
+		{
+			name:      "Elasticsearch version cannot be compared",
+			kb:        kb,
+			objs:      []runtime.Object{es("invalid")},
+			want:      false,
+			wantErr:   true,
+			wantEvent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			got, err := AllowVersion(k8s.WrappedFakeClient(tt.objs...), tt.kb, tt.kb.Spec.Version, recorder)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantEvent, len(recorder.Events) > 0)
+		})
+	}
+}
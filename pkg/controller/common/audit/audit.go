@@ -0,0 +1,128 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// StdoutDestination is the audit log destination writing to the standard output.
+const StdoutDestination = "stdout"
+
+const (
+	// KubernetesTarget is the target of the mutations of Kubernetes objects.
+	KubernetesTarget = "kubernetes"
+	// ElasticsearchTarget is the target of the state-changing Elasticsearch API calls.
+	ElasticsearchTarget = "elasticsearch"
+)
+
+// Actions recorded in the audit log.
+const (
+	CreateAction       = "create"
+	UpdateAction       = "update"
+	PatchAction        = "patch"
+	DeleteAction       = "delete"
+	DeleteAllOfAction  = "delete_all_of"
+	StatusUpdateAction = "status_update"
+	StatusPatchAction  = "status_patch"
+	RequestAction      = "request"
+)
+
+var log = logf.Log.WithName("audit")
+
+// Default is the audit logger of the operator, nil if the audit log is disabled.
+var Default *Logger
+
+// Event is a mutation performed by the operator, recorded as a JSON line in the audit log.
+type Event struct {
+	Timestamp time.Time `json:"@timestamp"`
+	Target    string    `json:"target"`
+	Action    string    `json:"action"`
+
+	// Kubernetes objects
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Selector   string `json:"selector,omitempty"`
+
+	// Elasticsearch API calls
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+
+	// Diff is the created object, the JSON merge patch of an update, the patch, or the body of an Elasticsearch request.
+	Diff  json.RawMessage `json:"diff,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// Logger writes audit events as JSON lines.
+type Logger struct {
+	mutex sync.Mutex
+	w     io.Writer
+	now   func() time.Time
+}
+
+// NewLogger returns a Logger writing to the given writer.
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{w: w, now: time.Now}
+}
+
+// Open returns a Logger writing to the given destination, which is either StdoutDestination or the path of a file
+// the events are appended to.
+func Open(destination string) (*Logger, error) {
+	if destination == StdoutDestination {
+		return NewLogger(os.Stdout), nil
+	}
+	f, err := os.OpenFile(destination, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the audit log")
+	}
+	return NewLogger(f), nil
+}
+
+// Record writes the given event with the given error, if any. It does nothing if the Logger is nil.
+func (l *Logger) Record(event Event, err error) {
+	if l == nil {
+		return
+	}
+	event.Timestamp = l.now().UTC()
+	if err != nil {
+		event.Error = err.Error()
+	}
+	line, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		log.Error(marshalErr, "Failed to marshal audit event", "action", event.Action, "kind", event.Kind,
+			"namespace", event.Namespace, "name", event.Name)
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, writeErr := l.w.Write(append(line, '\n')); writeErr != nil {
+		log.Error(writeErr, "Failed to write audit event", "action", event.Action, "kind", event.Kind,
+			"namespace", event.Namespace, "name", event.Name)
+	}
+}
+
+// RecordRequest records a state-changing call to the Elasticsearch API with the given JSON body, if any. The bodies of
+// the endpoints handling credentials are redacted, as well as the fields holding credentials in the other bodies.
+func (l *Logger) RecordRequest(method, url string, body []byte, err error) {
+	if l == nil {
+		return
+	}
+	l.Record(Event{
+		Target: ElasticsearchTarget,
+		Action: RequestAction,
+		Method: method,
+		URL:    url,
+		Diff:   requestDiff(url, body),
+	}, err)
+}
@@ -0,0 +1,90 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_RecordRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+	logger.RecordRequest("PUT", "https://es-es-http.ns.svc:9200/_cluster/settings", []byte(`{"transient":{}}`), nil)
+	got := events(t, &buf)
+	require.Len(t, got, 1)
+	require.Equal(t, ElasticsearchTarget, got[0].Target)
+	require.Equal(t, RequestAction, got[0].Action)
+	require.Equal(t, "PUT", got[0].Method)
+	require.Equal(t, "https://es-es-http.ns.svc:9200/_cluster/settings", got[0].URL)
+	require.JSONEq(t, `{"transient":{}}`, string(got[0].Diff))
+
+	// a nil Logger records nothing
+	var disabled *Logger
+	disabled.RecordRequest("PUT", "https://es-es-http.ns.svc:9200/_cluster/settings", nil, nil)
+}
+
+func TestLogger_RecordRequest_Redaction(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		body string
+		want string
+	}{
+		{
+			name: "license",
+			url:  "https://es-es-http.ns.svc:9200/_license?acknowledge=true",
+			body: `{"licenses":[{"uid":"1","signature":"sig"}]}`,
+			want: `"REDACTED"`,
+		},
+		{
+			name: "user password",
+			url:  "https://es-es-http.ns.svc:9200/_security/user/jdoe",
+			body: `{"password":"changeme","roles":["admin"]}`,
+			want: `"REDACTED"`,
+		},
+		{
+			name: "watch",
+			url:  "https://es-es-http.ns.svc:9200/_watcher/watch/w1",
+			body: `{"actions":{"webhook":{"auth":{"basic":{"username":"u","password":"p"}}}}}`,
+			want: `"REDACTED"`,
+		},
+		{
+			name: "snapshot repository",
+			url:  "https://es-es-http.ns.svc:9200/_snapshot/repo",
+			body: `{"type":"s3","settings":{"access_key":"a","secret_key":"s"}}`,
+			want: `"REDACTED"`,
+		},
+		{
+			name: "sensitive fields of other APIs",
+			url:  "https://es-es-http.ns.svc:9200/_nodes/reload_secure_settings",
+			body: `{"secure_settings_password":"p","nested":[{"auth.token":"t","bearer-token":"t"}]}`,
+			want: `{"secure_settings_password":"REDACTED","nested":[{"auth.token":"REDACTED","bearer-token":"REDACTED"}]}`,
+		},
+		{
+			name: "other fields are kept",
+			url:  "https://es-es-http.ns.svc:9200/index",
+			body: `{"settings":{"analysis":{"analyzer":{"a":{"tokenizer":"standard"}}}}}`,
+			want: `{"settings":{"analysis":{"analyzer":{"a":{"tokenizer":"standard"}}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewLogger(&buf).RecordRequest("PUT", tt.url, []byte(tt.body), nil)
+			got := events(t, &buf)
+			require.Len(t, got, 1)
+			require.Equal(t, tt.url, got[0].URL)
+			require.JSONEq(t, tt.want, string(got[0].Diff))
+		})
+	}
+
+	// bodies which are not a single JSON document are not recorded
+	var buf bytes.Buffer
+	NewLogger(&buf).RecordRequest("POST", "https://es-es-http.ns.svc:9200/_bulk", []byte("{}\n{}\n"), nil)
+	require.Empty(t, events(t, &buf)[0].Diff)
+}
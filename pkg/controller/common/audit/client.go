@@ -0,0 +1,180 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// redacted replaces the values of the Secrets in the audit log.
+const redacted = "REDACTED"
+
+// NewClient returns a client recording the mutations performed with the given client in the audit log of the given
+// Logger. Updates are recorded with the JSON merge patch from the object read with the client before the update, and
+// the values of Secrets are redacted.
+func NewClient(c client.Client, scheme *runtime.Scheme, l *Logger) client.Client {
+	return &auditClient{Client: c, scheme: scheme, logger: l}
+}
+
+type auditClient struct {
+	client.Client
+	scheme *runtime.Scheme
+	logger *Logger
+}
+
+var _ client.Client = &auditClient{}
+
+// event returns an event identifying the given object.
+func (a *auditClient) event(action string, obj runtime.Object) Event {
+	event := Event{Target: KubernetesTarget, Action: action}
+	if gvk, err := apiutil.GVKForObject(obj, a.scheme); err == nil {
+		event.APIVersion, event.Kind = gvk.GroupVersion().String(), gvk.Kind
+	}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		event.Namespace, event.Name = accessor.GetNamespace(), accessor.GetName()
+	}
+	return event
+}
+
+// diff returns the given JSON document, with the values of the Secrets redacted.
+func (a *auditClient) diff(event Event, data []byte, err error) json.RawMessage {
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	if event.APIVersion != "v1" || event.Kind != "Secret" {
+		return data
+	}
+	var secret map[string]interface{}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return nil
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := secret[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range values {
+			if value != nil {
+				values[key] = redacted
+			}
+		}
+	}
+	redactedData, err := json.Marshal(secret)
+	if err != nil {
+		return nil
+	}
+	return redactedData
+}
+
+// updateDiff returns the JSON merge patch from the current version of the given object to the given object.
+func (a *auditClient) updateDiff(ctx context.Context, event Event, obj runtime.Object) json.RawMessage {
+	gvk, err := apiutil.GVKForObject(obj, a.scheme)
+	if err != nil {
+		return nil
+	}
+	current, err := a.scheme.New(gvk)
+	if err != nil {
+		return nil
+	}
+	if err := a.Client.Get(ctx, types.NamespacedName{Namespace: event.Namespace, Name: event.Name}, current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.V(1).Info("Failed to get the object to audit its update", "error", err.Error(), "kind", event.Kind,
+				"namespace", event.Namespace, "name", event.Name)
+		}
+		return nil
+	}
+	// the type information is irrelevant to the diff
+	current.GetObjectKind().SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	data, err := client.MergeFrom(current).Data(obj)
+	return a.diff(event, data, err)
+}
+
+// Create records the creation of the given object, with its content.
+func (a *auditClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	event := a.event(CreateAction, obj)
+	data, err := json.Marshal(obj)
+	event.Diff = a.diff(event, data, err)
+	err = a.Client.Create(ctx, obj, opts...)
+	a.logger.Record(event, err)
+	return err
+}
+
+// Update records the update of the given object, with the JSON merge patch of the update.
+func (a *auditClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	event := a.event(UpdateAction, obj)
+	event.Diff = a.updateDiff(ctx, event, obj)
+	err := a.Client.Update(ctx, obj, opts...)
+	a.logger.Record(event, err)
+	return err
+}
+
+// Patch records the patch of the given object, with the patch.
+func (a *auditClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	event := a.event(PatchAction, obj)
+	data, err := patch.Data(obj)
+	event.Diff = a.diff(event, data, err)
+	err = a.Client.Patch(ctx, obj, patch, opts...)
+	a.logger.Record(event, err)
+	return err
+}
+
+// Delete records the deletion of the given object.
+func (a *auditClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	err := a.Client.Delete(ctx, obj, opts...)
+	a.logger.Record(a.event(DeleteAction, obj), err)
+	return err
+}
+
+// DeleteAllOf records the deletion of the objects of the given type, with the namespace and label selector
+// of the deletion.
+func (a *auditClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	event := a.event(DeleteAllOfAction, obj)
+	options := client.DeleteAllOfOptions{}
+	options.ApplyOptions(opts)
+	event.Namespace, event.Name = options.Namespace, ""
+	if options.LabelSelector != nil {
+		event.Selector = options.LabelSelector.String()
+	}
+	err := a.Client.DeleteAllOf(ctx, obj, opts...)
+	a.logger.Record(event, err)
+	return err
+}
+
+// Status returns a status writer recording the status updates.
+func (a *auditClient) Status() client.StatusWriter {
+	return &auditStatusWriter{StatusWriter: a.Client.Status(), a: a}
+}
+
+type auditStatusWriter struct {
+	client.StatusWriter
+	a *auditClient
+}
+
+// Update records the status update of the given object, with the JSON merge patch of the update.
+func (s *auditStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	event := s.a.event(StatusUpdateAction, obj)
+	event.Diff = s.a.updateDiff(ctx, event, obj)
+	err := s.StatusWriter.Update(ctx, obj, opts...)
+	s.a.logger.Record(event, err)
+	return err
+}
+
+// Patch records the status patch of the given object, with the patch.
+func (s *auditStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	event := s.a.event(StatusPatchAction, obj)
+	data, err := patch.Data(obj)
+	event.Diff = s.a.diff(event, data, err)
+	err = s.StatusWriter.Patch(ctx, obj, patch, opts...)
+	s.a.logger.Record(event, err)
+	return err
+}
@@ -0,0 +1,94 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// events returns the events written to the given buffer.
+func events(t *testing.T, buf *bytes.Buffer) []Event {
+	var result []Event
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event Event
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		result = append(result, event)
+	}
+	buf.Reset()
+	return result
+}
+
+func TestClient(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+	logger.now = func() time.Time { return now }
+	c := NewClient(fake.NewFakeClientWithScheme(scheme.Scheme), scheme.Scheme, logger)
+	ctx := context.Background()
+
+	// creations are recorded with the created object
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cm"}, Data: map[string]string{"a": "1"}}
+	require.NoError(t, c.Create(ctx, cm))
+	got := events(t, &buf)
+	require.Len(t, got, 1)
+	require.Equal(t, now, got[0].Timestamp)
+	require.Equal(t, Event{Timestamp: now, Target: KubernetesTarget, Action: CreateAction, APIVersion: "v1", Kind: "ConfigMap",
+		Namespace: "ns", Name: "cm", Diff: got[0].Diff}, got[0])
+	require.Contains(t, string(got[0].Diff), `"data":{"a":"1"}`)
+
+	// updates are recorded with the diff
+	cm.Data = map[string]string{"a": "1", "b": "2"}
+	require.NoError(t, c.Update(ctx, cm))
+	got = events(t, &buf)
+	require.Len(t, got, 1)
+	require.Equal(t, UpdateAction, got[0].Action)
+	require.JSONEq(t, `{"data":{"b":"2"}}`, string(got[0].Diff))
+
+	// so are patches
+	patched := cm.DeepCopy()
+	patched.Data["c"] = "3"
+	require.NoError(t, c.Patch(ctx, patched, client.MergeFrom(cm)))
+	got = events(t, &buf)
+	require.Len(t, got, 1)
+	require.Equal(t, PatchAction, got[0].Action)
+	require.JSONEq(t, `{"data":{"c":"3"}}`, string(got[0].Diff))
+
+	// failures are recorded with their error
+	require.Error(t, c.Create(ctx, cm.DeepCopy()))
+	got = events(t, &buf)
+	require.Len(t, got, 1)
+	require.Contains(t, got[0].Error, "already exists")
+
+	// deletions are recorded without diff
+	require.NoError(t, c.Delete(ctx, cm))
+	require.Equal(t, []Event{{Timestamp: now, Target: KubernetesTarget, Action: DeleteAction, APIVersion: "v1", Kind: "ConfigMap",
+		Namespace: "ns", Name: "cm"}}, events(t, &buf))
+
+	// the values of Secrets are redacted
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "s"}, Data: map[string][]byte{"password": []byte("secret")}}
+	require.NoError(t, c.Create(ctx, secret))
+	secret.Data["password"] = []byte("changed")
+	require.NoError(t, c.Update(ctx, secret))
+	got = events(t, &buf)
+	require.Len(t, got, 2)
+	require.Contains(t, string(got[0].Diff), `"data":{"password":"REDACTED"}`)
+	require.JSONEq(t, `{"data":{"password":"REDACTED"}}`, string(got[1].Diff))
+	require.NotContains(t, string(got[0].Diff)+string(got[1].Diff), "secret")
+}
@@ -0,0 +1,78 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// sensitiveAPIs are the Elasticsearch APIs whose request bodies are redacted as a whole, as they carry licenses,
+// passwords, API keys or the credentials of watches and snapshot repositories.
+var sensitiveAPIs = []string{"_license", "_security", "_watcher", "_snapshot", "_xpack"}
+
+// sensitiveWords are the words of the names of the fields redacted in the other request bodies, such as
+// secure_settings_password or secret_key.
+var sensitiveWords = []string{"password", "passwd", "secret", "token", "signature", "credentials", "apikey", "api_key", "access_key"}
+
+// requestDiff returns the given JSON body of a request to the given Elasticsearch URL, with the credentials redacted.
+func requestDiff(rawURL string, body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		for _, api := range sensitiveAPIs {
+			if segment == api {
+				return json.RawMessage(`"` + redacted + `"`)
+			}
+		}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		// not a single JSON document, such as a bulk request
+		return nil
+	}
+	redactedBody, err := json.Marshal(redactFields(doc))
+	if err != nil {
+		return nil
+	}
+	return redactedBody
+}
+
+// redactFields replaces the values of the sensitive fields of the given JSON value, recursively.
+func redactFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if isSensitiveField(field) {
+				v[field] = redacted
+				continue
+			}
+			v[field] = redactFields(fieldValue)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactFields(v[i])
+		}
+	}
+	return value
+}
+
+// isSensitiveField returns true if the given field name contains one of the sensitive words, delimited by dots,
+// dashes or underscores: the tokenizer of an analyzer is not redacted, unlike an access token.
+func isSensitiveField(field string) bool {
+	delimited := "_" + strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToLower(field)) + "_"
+	for _, word := range sensitiveWords {
+		if strings.Contains(delimited, "_"+word+"_") {
+			return true
+		}
+	}
+	return false
+}
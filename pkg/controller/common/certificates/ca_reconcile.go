@@ -9,6 +9,7 @@ import (
 	"crypto/x509/pkix"
 	"time"
 
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/name"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
@@ -41,10 +42,13 @@ func CAInternalSecretName(namer name.Namer, ownerName string, caType CAType) str
 //
 // The CA is persisted across operator restarts in the apiserver as a Secret for the CA certificate and private key:
 // `<clusterName>-<caType>-ca-internal`
+// If an external credentials store is configured, the CA it holds takes precedence over the Secret, and the CA is
+// persisted in it through the given Credentials.
 //
 // The CA cert and private key are rotated if they become invalid (or soon to expire).
 func ReconcileCAForOwner(
 	cl k8s.Client,
+	store credentials.Credentials,
 	namer name.Namer,
 	owner v1.Object,
 	labels map[string]string,
@@ -54,39 +58,54 @@ func ReconcileCAForOwner(
 
 	// retrieve current CA secret
 	caInternalSecret := corev1.Secret{}
-	err := cl.Get(types.NamespacedName{
+	caKey := types.NamespacedName{
 		Namespace: owner.GetNamespace(),
 		Name:      CAInternalSecretName(namer, owner.GetName(), caType),
-	}, &caInternalSecret)
+	}
+	err := cl.Get(caKey, &caInternalSecret)
 
 	if err != nil && !apierrors.IsNotFound(err) {
 		return nil, err
 	}
-	if apierrors.IsNotFound(err) {
+	stored, storeErr := store.Load(caKey, &caInternalSecret)
+	if storeErr != nil {
+		return nil, storeErr
+	}
+	if apierrors.IsNotFound(err) && !stored {
 		log.Info("No internal CA certificate Secret found, creating a new one", "owner_namespace", owner.GetNamespace(), "owner_name", owner.GetName(), "ca_type", caType)
-		return renewCA(cl, namer, owner, labels, rotationParams.Validity, caType)
+		return renewCA(cl, store, namer, owner, labels, rotationParams.Validity, caType)
 	}
 
 	// build CA
 	ca := BuildCAFromSecret(caInternalSecret)
 	if ca == nil {
 		log.Info("Cannot build CA from secret, creating a new one", "owner_namespace", owner.GetNamespace(), "owner_name", owner.GetName(), "ca_type", caType)
-		return renewCA(cl, namer, owner, labels, rotationParams.Validity, caType)
+		return renewCA(cl, store, namer, owner, labels, rotationParams.Validity, caType)
 	}
 
 	// renew if cannot reuse
 	if !CanReuseCA(ca, rotationParams.RotateBefore) {
 		log.Info("Cannot reuse existing CA, creating a new one", "owner_namespace", owner.GetNamespace(), "owner_name", owner.GetName(), "ca_type", caType)
-		return renewCA(cl, namer, owner, labels, rotationParams.Validity, caType)
+		return renewCA(cl, store, namer, owner, labels, rotationParams.Validity, caType)
 	}
 
-	// reuse existing CA
+	// reuse existing CA, persisting it in the external credentials store if not there yet
+	if err := store.Save(caKey, caInternalSecret.Data); err != nil {
+		return nil, err
+	}
+	if stored {
+		// make sure the Secret holds the CA from the store
+		if _, err := reconciler.ReconcileSecret(cl, internalSecretForCA(ca, namer, owner, labels, caType), owner); err != nil {
+			return nil, err
+		}
+	}
 	return ca, nil
 }
 
 // renewCA creates and stores a new CA to replace one that might exist
 func renewCA(
 	client k8s.Client,
+	store credentials.Credentials,
 	namer name.Namer,
 	owner v1.Object,
 	labels map[string]string,
@@ -105,6 +124,10 @@ func renewCA(
 	}
 	caInternalSecret := internalSecretForCA(ca, namer, owner, labels, caType)
 
+	// persist the CA in the external credentials store first, if any
+	if err := store.Save(k8s.ExtractNamespacedName(&caInternalSecret), caInternalSecret.Data); err != nil {
+		return nil, err
+	}
 	// create or update internal secret
 	if _, err := reconciler.ReconcileSecret(client, caInternalSecret, owner); err != nil {
 		return nil, err
@@ -20,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/name"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 )
@@ -214,7 +215,7 @@ func Test_renewCA(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ca, err := renewCA(tt.client, testNamer, &testCluster, nil, tt.expireIn, TransportCAType)
+			ca, err := renewCA(tt.client, credentials.Default, testNamer, &testCluster, nil, tt.expireIn, TransportCAType)
 			require.NoError(t, err)
 			require.NotNil(t, ca)
 			assert.Equal(t, ca.Cert.Issuer.CommonName, testName+"-"+string(TransportCAType))
@@ -285,7 +286,7 @@ func TestReconcileCAForCluster(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ca, err := ReconcileCAForOwner(
-				tt.cl, testNamer, &testCluster, nil, TransportCAType, RotationParams{
+				tt.cl, credentials.Default, testNamer, &testCluster, nil, TransportCAType, RotationParams{
 					Validity:     tt.caCertValidity,
 					RotateBefore: DefaultRotateBefore,
 				},
@@ -384,3 +385,47 @@ func Test_buildCAFromSecret(t *testing.T) {
 		})
 	}
 }
+
+// countingStore is a credentials store keeping credentials in memory, counting the requests.
+type countingStore struct {
+	data     map[string]map[string][]byte
+	requests int
+}
+
+func (s *countingStore) Get(key string) (map[string][]byte, error) {
+	s.requests++
+	return s.data[key], nil
+}
+
+func (s *countingStore) Put(key string, data map[string][]byte) error {
+	s.requests++
+	s.data[key] = data
+	return nil
+}
+
+func (s *countingStore) Delete(key string) error {
+	s.requests++
+	delete(s.data, key)
+	return nil
+}
+
+func TestReconcileCAForOwner_CredentialsStoreRequests(t *testing.T) {
+	store := &countingStore{data: map[string]map[string][]byte{}}
+	credentials.SetStore(store, "eck", time.Hour)
+	defer credentials.SetStore(nil, "", credentials.DefaultCacheTTL)
+
+	c := k8s.WrappedFakeClient()
+	rotation := RotationParams{Validity: DefaultCertValidity, RotateBefore: DefaultRotateBefore}
+	ca, err := ReconcileCAForOwner(c, credentials.Default, testNamer, &testCluster, nil, TransportCAType, rotation)
+	require.NoError(t, err)
+	// the CA is looked up in the store, then written to it
+	require.Equal(t, 2, store.requests)
+
+	// the store is not called again while the CA is cached and its secret exists
+	for i := 0; i < 10; i++ {
+		reused, err := ReconcileCAForOwner(c, credentials.Default, testNamer, &testCluster, nil, TransportCAType, rotation)
+		require.NoError(t, err)
+		require.Equal(t, ca.Cert, reused.Cert)
+	}
+	require.Equal(t, 2, store.requests)
+}
@@ -0,0 +1,213 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/sender"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// SpecVersion is the version of the CloudEvents specification the events comply with.
+	SpecVersion = "1.0"
+	// ContentType is the content type of the events sent in the structured mode of the HTTP and Kafka protocol bindings.
+	ContentType = "application/cloudevents+json"
+)
+
+// Types of the events published for the lifecycle milestones of the Elasticsearch clusters.
+const (
+	ElasticsearchCreated          = "co.elastic.eck.elasticsearch.created"
+	ElasticsearchUpgradeStarted   = "co.elastic.eck.elasticsearch.upgrade.started"
+	ElasticsearchUpgradeCompleted = "co.elastic.eck.elasticsearch.upgrade.completed"
+	ElasticsearchHealthDegraded   = "co.elastic.eck.elasticsearch.health.degraded"
+)
+
+// Types are all the types of the events published by the operator.
+var Types = []string{
+	ElasticsearchCreated,
+	ElasticsearchUpgradeStarted,
+	ElasticsearchUpgradeCompleted,
+	ElasticsearchHealthDegraded,
+}
+
+const (
+	// maxQueuedEvents bounds the memory used by the events waiting to be sent, newer events are dropped.
+	maxQueuedEvents = 1000
+	// maxAttempts is the number of times an event is sent before it is dropped.
+	maxAttempts    = 3
+	requestTimeout = 10 * time.Second
+)
+
+var log = logf.Log.WithName("cloudevents")
+
+// Event is a CloudEvent, serialized in the structured mode.
+type Event struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// ElasticsearchData is the data of the events of an Elasticsearch cluster.
+type ElasticsearchData struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Version is the version of the specification of the cluster.
+	Version string                   `json:"version"`
+	Health  esv1.ElasticsearchHealth `json:"health,omitempty"`
+	// PreviousHealth is the health of the cluster before it degraded.
+	PreviousHealth esv1.ElasticsearchHealth `json:"previousHealth,omitempty"`
+	// PreviousVersion is the version the cluster is upgraded from.
+	PreviousVersion string `json:"previousVersion,omitempty"`
+}
+
+// NewElasticsearchEvent returns an event of the given type for the given Elasticsearch cluster, with the given data.
+// The namespace, name and version of the cluster are set in the data.
+func NewElasticsearchEvent(eventType string, es esv1.Elasticsearch, data ElasticsearchData) Event {
+	data.Namespace = es.Namespace
+	data.Name = es.Name
+	data.Version = es.Spec.Version
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              string(uuid.NewUUID()),
+		Source:          fmt.Sprintf("/apis/elasticsearch.k8s.elastic.co/v1/namespaces/%s/elasticsearches/%s", es.Namespace, es.Name),
+		Type:            eventType,
+		Subject:         es.Name,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// sink sends the events to an endpoint with a protocol binding of CloudEvents.
+type sink interface {
+	// send sends the given event, structured as a single message.
+	send(ctx context.Context, event Event) error
+	// close releases the connections of the sink once the events are not published anymore.
+	close() error
+}
+
+// Publisher sends CloudEvents to an HTTP endpoint or a Kafka topic, in the background so that the reconciliations do
+// not wait for the endpoint. A nil Publisher publishes nothing.
+type Publisher struct {
+	endpoint string
+	types    []string
+	sink     sink
+	*sender.Sender
+}
+
+// NewPublisher returns a Publisher sending the events of the given types to the given endpoint, or all the events if
+// no type is given. The endpoint is an absolute http or https URL, or a kafka://<brokers>/<topic> or
+// kafka+tls://<brokers>/<topic> URL with a comma-separated list of brokers.
+func NewPublisher(endpoint string, types []string) (*Publisher, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Errorf("invalid CloudEvents endpoint %q: %v", endpoint, err)
+	}
+	var s sink
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return nil, errors.Errorf("invalid CloudEvents endpoint %q, expected an absolute http or https URL", endpoint)
+		}
+		s = &httpSink{endpoint: endpoint, client: &http.Client{Timeout: requestTimeout}}
+	case kafkaScheme, kafkaTLSScheme:
+		if s, err = newKafkaSink(u); err != nil {
+			return nil, errors.Wrapf(err, "invalid CloudEvents endpoint %q", endpoint)
+		}
+	default:
+		return nil, errors.Errorf("invalid CloudEvents endpoint %q, expected an absolute http, https, %s or %s URL",
+			endpoint, kafkaScheme, kafkaTLSScheme)
+	}
+	for _, t := range types {
+		if !stringsutil.StringInSlice(t, Types) {
+			return nil, errors.Errorf("unknown CloudEvents type %s, expected one of %v", t, Types)
+		}
+	}
+	send := func(ctx context.Context, item interface{}) error {
+		return s.send(ctx, item.(Event))
+	}
+	failed := func(item interface{}, err error) {
+		event := item.(Event)
+		log.Error(err, "Failed to send CloudEvent", "type", event.Type, "subject", event.Subject, "id", event.ID)
+	}
+	return &Publisher{
+		endpoint: endpoint,
+		types:    types,
+		sink:     s,
+		Sender:   sender.New(maxQueuedEvents, maxAttempts, send, failed),
+	}, nil
+}
+
+// Publish queues the given event to be sent, if its type is published. The event is dropped if the queue is full.
+func (p *Publisher) Publish(event Event) {
+	if p == nil || (len(p.types) > 0 && !stringsutil.StringInSlice(event.Type, p.types)) {
+		return
+	}
+	if !p.Queue(event) {
+		log.Info("Dropping CloudEvent, too many events waiting to be sent", "type", event.Type, "subject", event.Subject)
+	}
+}
+
+// Start sends the queued events until the stop channel is closed. It implements the controller-runtime Runnable
+// interface.
+func (p *Publisher) Start(stop <-chan struct{}) error {
+	log.Info("Publishing CloudEvents", "endpoint", p.endpoint)
+	defer func() {
+		if err := p.sink.close(); err != nil {
+			log.Error(err, "Failed to close the CloudEvents endpoint connections")
+		}
+	}()
+	return p.Sender.Start(stop)
+}
+
+// httpSink posts the events to an HTTP endpoint, in the structured mode of the HTTP protocol binding.
+type httpSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (h *httpSink) send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	resp, err := h.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *httpSink) close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
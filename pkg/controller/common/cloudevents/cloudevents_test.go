@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+func TestNewPublisher(t *testing.T) {
+	_, err := NewPublisher("http://broker.knative-eventing/default", nil)
+	require.NoError(t, err)
+	_, err = NewPublisher("https://broker.knative-eventing/default", []string{ElasticsearchHealthDegraded})
+	require.NoError(t, err)
+	_, err = NewPublisher("broker.knative-eventing/default", nil)
+	require.Error(t, err)
+	_, err = NewPublisher("kafka://broker-0:9092,broker-1:9092/eck-events", nil)
+	require.NoError(t, err)
+	_, err = NewPublisher("kafka+tls://broker:9093/eck-events", nil)
+	require.NoError(t, err)
+	_, err = NewPublisher("kafka://broker:9092", nil)
+	require.Error(t, err)
+	_, err = NewPublisher("kafka:///eck-events", nil)
+	require.Error(t, err)
+	_, err = NewPublisher("amqp://broker:5672/eck-events", nil)
+	require.Error(t, err)
+	_, err = NewPublisher("http://broker.knative-eventing/default", []string{"co.elastic.eck.kibana.created"})
+	require.Error(t, err)
+}
+
+func TestPublisher(t *testing.T) {
+	received := make(chan Event, 10)
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, ContentType, r.Header.Get("Content-Type"))
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	publisher, err := NewPublisher(server.URL, []string{ElasticsearchUpgradeStarted, ElasticsearchUpgradeCompleted})
+	require.NoError(t, err)
+	publisher.RetryDelay = time.Millisecond
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		require.NoError(t, publisher.Start(stop))
+	}()
+
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{Version: "7.10.0"},
+	}
+	// the creation is not published, the upgrade is sent again after the failure
+	publisher.Publish(NewElasticsearchEvent(ElasticsearchCreated, es, ElasticsearchData{}))
+	publisher.Publish(NewElasticsearchEvent(ElasticsearchUpgradeStarted, es, ElasticsearchData{PreviousVersion: "7.9.3"}))
+
+	select {
+	case event := <-received:
+		require.Equal(t, SpecVersion, event.SpecVersion)
+		require.Equal(t, ElasticsearchUpgradeStarted, event.Type)
+		require.Equal(t, "/apis/elasticsearch.k8s.elastic.co/v1/namespaces/ns/elasticsearches/es", event.Source)
+		require.Equal(t, "es", event.Subject)
+		require.NotEmpty(t, event.ID)
+		require.Equal(t, map[string]interface{}{
+			"namespace":       "ns",
+			"name":            "es",
+			"version":         "7.10.0",
+			"previousVersion": "7.9.3",
+		}, event.Data)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "event not received")
+	}
+	require.Empty(t, received)
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	// a nil publisher publishes nothing
+	var publisher *Publisher
+	publisher.Publish(Event{Type: ElasticsearchCreated})
+
+	// events are dropped when the queue is full
+	publisher, err := NewPublisher("http://localhost", nil)
+	require.NoError(t, err)
+	for i := 0; i < maxQueuedEvents+1; i++ {
+		publisher.Publish(Event{Type: ElasticsearchCreated})
+	}
+	require.Equal(t, maxQueuedEvents, publisher.Len())
+}
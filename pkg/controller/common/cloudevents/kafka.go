@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cloudevents
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// kafkaScheme is the scheme of the Kafka endpoints reached over plaintext connections.
+	kafkaScheme = "kafka"
+	// kafkaTLSScheme is the scheme of the Kafka endpoints reached over TLS connections, verified with the system
+	// certificate authorities.
+	kafkaTLSScheme = "kafka+tls"
+	// contentTypeHeader is the Kafka header holding the content type of the events sent in the structured mode.
+	contentTypeHeader = "content-type"
+)
+
+// messageWriter writes messages to a Kafka topic.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaSink writes the events to a Kafka topic, in the structured mode of the Kafka protocol binding. The events of
+// a resource are keyed by its source, so that they are written to the same partition and consumed in order.
+type kafkaSink struct {
+	config kafka.WriterConfig
+	// writer is created on the first event, since it connects to the brokers as soon as it is created
+	writer messageWriter
+}
+
+// newKafkaSink returns a sink writing to the brokers and the topic of the given kafka:// or kafka+tls:// URL.
+func newKafkaSink(u *url.URL) (*kafkaSink, error) {
+	var brokers []string
+	for _, broker := range strings.Split(u.Host, ",") {
+		if broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, errors.New("at least one Kafka broker is required")
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return nil, errors.New("expected a single Kafka topic as path")
+	}
+	dialer := &kafka.Dialer{Timeout: requestTimeout, DualStack: true}
+	if u.Scheme == kafkaTLSScheme {
+		dialer.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return &kafkaSink{config: kafka.WriterConfig{
+		Brokers:  brokers,
+		Topic:    topic,
+		Dialer:   dialer,
+		Balancer: &kafka.Hash{},
+		// the publisher sends the events again if they cannot be written
+		MaxAttempts: 1,
+		// write each event as soon as it is published
+		BatchSize:    1,
+		ReadTimeout:  requestTimeout,
+		WriteTimeout: requestTimeout,
+		// wait for the event to be replicated to all the in-sync replicas
+		RequiredAcks: -1,
+	}}, nil
+}
+
+func (k *kafkaSink) send(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if k.writer == nil {
+		k.writer = kafka.NewWriter(k.config)
+	}
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(event.Source),
+		Value:   value,
+		Headers: []kafka.Header{{Key: contentTypeHeader, Value: []byte(ContentType)}},
+	})
+}
+
+func (k *kafkaSink) close() error {
+	if k.writer == nil {
+		return nil
+	}
+	return k.writer.Close()
+}
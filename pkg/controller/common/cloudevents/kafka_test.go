@@ -0,0 +1,124 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+type fakeWriter struct {
+	mutex    sync.Mutex
+	failures int
+	written  []kafka.Message
+	closed   bool
+}
+
+func (f *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("leader not available")
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+func (f *fakeWriter) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeWriter) messages() []kafka.Message {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]kafka.Message(nil), f.written...)
+}
+
+func Test_newKafkaSink(t *testing.T) {
+	parse := func(endpoint string) *url.URL {
+		u, err := url.Parse(endpoint)
+		require.NoError(t, err)
+		return u
+	}
+	sink, err := newKafkaSink(parse("kafka://broker-0:9092,broker-1:9092/eck-events"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"broker-0:9092", "broker-1:9092"}, sink.config.Brokers)
+	require.Equal(t, "eck-events", sink.config.Topic)
+	require.Nil(t, sink.config.Dialer.TLS)
+	// the writer connects to the brokers: it is only created to send the first event
+	require.Nil(t, sink.writer)
+
+	sink, err = newKafkaSink(parse("kafka+tls://broker:9093/eck-events/"))
+	require.NoError(t, err)
+	require.Equal(t, "eck-events", sink.config.Topic)
+	require.NotNil(t, sink.config.Dialer.TLS)
+
+	_, err = newKafkaSink(parse("kafka://broker:9092/"))
+	require.EqualError(t, err, "expected a single Kafka topic as path")
+	_, err = newKafkaSink(parse("kafka://broker:9092/eck/events"))
+	require.EqualError(t, err, "expected a single Kafka topic as path")
+	_, err = newKafkaSink(parse("kafka://,/eck-events"))
+	require.EqualError(t, err, "at least one Kafka broker is required")
+}
+
+func TestPublisher_kafka(t *testing.T) {
+	publisher, err := NewPublisher("kafka://broker:9092/eck-events", nil)
+	require.NoError(t, err)
+	writer := &fakeWriter{failures: 1}
+	publisher.sink.(*kafkaSink).writer = writer
+	publisher.RetryDelay = time.Millisecond
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		require.NoError(t, publisher.Start(stop))
+		close(done)
+	}()
+
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{Version: "7.10.0"},
+	}
+	// the event is written again after the failure
+	publisher.Publish(NewElasticsearchEvent(ElasticsearchCreated, es, ElasticsearchData{Health: esv1.ElasticsearchGreenHealth}))
+	require.Eventually(t, func() bool {
+		return len(writer.messages()) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// the event is written in the structured mode, keyed by its source
+	message := writer.messages()[0]
+	require.Equal(t, "/apis/elasticsearch.k8s.elastic.co/v1/namespaces/ns/elasticsearches/es", string(message.Key))
+	require.Equal(t, []kafka.Header{{Key: "content-type", Value: []byte(ContentType)}}, message.Headers)
+	var event Event
+	require.NoError(t, json.Unmarshal(message.Value, &event))
+	require.Equal(t, ElasticsearchCreated, event.Type)
+	require.Equal(t, map[string]interface{}{
+		"namespace": "ns",
+		"name":      "es",
+		"version":   "7.10.0",
+		"health":    "green",
+	}, event.Data)
+
+	// the writer is closed once the publisher stops
+	close(stop)
+	<-done
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	require.True(t, writer.closed)
+}
@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package configmaps
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var log = logf.Log.WithName("configmaps")
+
+// ParseFunc parses the content of a ConfigMap, and returns an error if it is invalid.
+type ParseFunc func(cm corev1.ConfigMap) error
+
+// LoadTyped calls parse with each of the ConfigMaps of the given namespace labelled with the given type, such as the
+// ConfigMaps declaring the operator policies in the operator namespace. The ConfigMaps parse returns an error for are
+// logged and ignored: an invalid ConfigMap must not prevent the other ones from being enforced.
+func LoadTyped(c k8s.Client, namespace string, configMapType string, parse ParseFunc) error {
+	var configMaps corev1.ConfigMapList
+	if err := c.List(
+		&configMaps,
+		client.InNamespace(namespace),
+		client.MatchingLabels{common.TypeLabelName: configMapType},
+	); err != nil {
+		return err
+	}
+	for _, cm := range configMaps.Items {
+		if err := parse(cm); err != nil {
+			log.Error(err, "Ignoring invalid ConfigMap", "type", configMapType, "namespace", cm.Namespace, "configmap_name", cm.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package configmaps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestLoadTyped(t *testing.T) {
+	configMap := func(namespace, name, configMapType string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{common.TypeLabelName: configMapType},
+		}}
+	}
+	c := k8s.WrappedFakeClient(
+		configMap("elastic-system", "a", "quota"),
+		configMap("elastic-system", "invalid", "quota"),
+		configMap("elastic-system", "b", "quota"),
+		configMap("elastic-system", "other-type", "policy"),
+		configMap("other-ns", "c", "quota"),
+	)
+	var parsed []string
+	err := LoadTyped(c, "elastic-system", "quota", func(cm corev1.ConfigMap) error {
+		if cm.Name == "invalid" {
+			return errors.New("invalid")
+		}
+		parsed = append(parsed, cm.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	// the invalid ConfigMap does not prevent the other ones from being parsed
+	require.ElementsMatch(t, []string{"a", "b"}, parsed)
+}
@@ -6,6 +6,9 @@ package container
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 const DefaultContainerRegistry = "docker.elastic.co"
@@ -17,17 +20,98 @@ func SetContainerRegistry(registry string) {
 	containerRegistry = registry
 }
 
+// namespaceContainerRegistry returns the container registry used to download the Elastic stack images of the
+// resources of a namespace, or an empty string for the global container registry.
+var namespaceContainerRegistry = func(namespace string) string { return "" }
+
+// SetNamespaceContainerRegistry sets the function returning the container registry of the resources of a namespace,
+// overriding the global container registry when it returns a non-empty string.
+func SetNamespaceContainerRegistry(registry func(namespace string) string) {
+	namespaceContainerRegistry = registry
+}
+
+// Architecture is a CPU architecture of the Kubernetes nodes, as reported by their kubernetes.io/arch label.
+type Architecture string
+
+const (
+	AMD64 Architecture = "amd64"
+	ARM64 Architecture = "arm64"
+)
+
+// Architectures are the architectures Elastic stack images are published for.
+var Architectures = []Architecture{AMD64, ARM64}
+
+// archContainerRegistries are the container registries holding the images of specific architectures, overriding the
+// global container registry for these architectures.
+var archContainerRegistries = map[Architecture]string{}
+
+// SetArchContainerRegistries sets the container registries used to download Elastic stack images for specific
+// architectures. The global container registry is used for the other architectures.
+func SetArchContainerRegistries(registries map[Architecture]string) {
+	archContainerRegistries = registries
+}
+
+// ParseArchContainerRegistries parses container registries given as <architecture>=<registry>.
+func ParseArchContainerRegistries(values []string) (map[Architecture]string, error) {
+	registries := make(map[Architecture]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, errors.Errorf("invalid container registry %q, expected <architecture>=<registry>", value)
+		}
+		arch := Architecture(parts[0])
+		if !arch.IsSupported() {
+			return nil, errors.Errorf("unsupported architecture %q, expected one of %v", arch, Architectures)
+		}
+		registries[arch] = parts[1]
+	}
+	return registries, nil
+}
+
+// IsSupported returns true if Elastic stack images are published for this architecture.
+func (a Architecture) IsSupported() bool {
+	for _, arch := range Architectures {
+		if a == arch {
+			return true
+		}
+	}
+	return false
+}
+
 type Image string
 
 const (
 	APMServerImage     Image = "apm/apm-server"
 	ElasticsearchImage Image = "elasticsearch/elasticsearch"
+	FilebeatImage      Image = "beats/filebeat"
 	KibanaImage        Image = "kibana/kibana"
 	// TODO
 	EnterpriseSearchImage Image = "TODO"
 )
 
 // ImageRepository returns the full container image name by concatenating the current container registry and the image path with the given version.
+// The reference is rewritten to the mirror of the container registry, if any.
 func ImageRepository(img Image, version string) string {
-	return fmt.Sprintf("%s/%s:%s", containerRegistry, img, version)
+	return Mirrored(fmt.Sprintf("%s/%s:%s", containerRegistry, img, version))
+}
+
+// ImageRepositoryForArch returns the full container image name for the given architecture: the image of the
+// container registry of this architecture if any, or else the multi-architecture image of the current container
+// registry. An empty architecture selects the current container registry.
+func ImageRepositoryForArch(img Image, version string, arch Architecture) string {
+	registry, exists := archContainerRegistries[arch]
+	if !exists {
+		return ImageRepository(img, version)
+	}
+	return Mirrored(fmt.Sprintf("%s/%s:%s", registry, img, version))
+}
+
+// ImageRepositoryForNamespace returns the full container image name for the resources of the given namespace and
+// architecture: the image of the container registry of this namespace if any, or else the image returned by
+// ImageRepositoryForArch.
+func ImageRepositoryForNamespace(img Image, version string, namespace string, arch Architecture) string {
+	if registry := namespaceContainerRegistry(namespace); registry != "" {
+		return Mirrored(fmt.Sprintf("%s/%s:%s", registry, img, version))
+	}
+	return ImageRepositoryForArch(img, version, arch)
 }
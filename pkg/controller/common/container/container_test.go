@@ -52,3 +52,57 @@ func TestImageRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestImageRepositoryForArch(t *testing.T) {
+	// save and restore the current registry settings in case they have been modified
+	currentRegistry, currentArchRegistries := containerRegistry, archContainerRegistries
+	defer func() {
+		SetContainerRegistry(currentRegistry)
+		SetArchContainerRegistries(currentArchRegistries)
+	}()
+
+	SetContainerRegistry("my.docker.registry.com:8080")
+	SetArchContainerRegistries(map[Architecture]string{ARM64: "my.docker.registry.com:8080/arm64"})
+	assert.Equal(t, "my.docker.registry.com:8080/arm64/elasticsearch/elasticsearch:7.8.0",
+		ImageRepositoryForArch(ElasticsearchImage, "7.8.0", ARM64))
+	assert.Equal(t, "my.docker.registry.com:8080/elasticsearch/elasticsearch:7.8.0",
+		ImageRepositoryForArch(ElasticsearchImage, "7.8.0", AMD64))
+	assert.Equal(t, "my.docker.registry.com:8080/elasticsearch/elasticsearch:7.8.0",
+		ImageRepositoryForArch(ElasticsearchImage, "7.8.0", ""))
+}
+
+func TestParseArchContainerRegistries(t *testing.T) {
+	registries, err := ParseArchContainerRegistries([]string{"arm64=registry.example.com/arm64", "amd64=registry.example.com"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[Architecture]string{ARM64: "registry.example.com/arm64", AMD64: "registry.example.com"}, registries)
+
+	for _, invalid := range []string{"arm64", "arm64=", "s390x=registry.example.com"} {
+		_, err := ParseArchContainerRegistries([]string{invalid})
+		assert.Assert(t, err != nil, invalid)
+	}
+}
+
+func TestImageRepositoryForNamespace(t *testing.T) {
+	// save and restore the current registry settings in case they have been modified
+	currentRegistry, currentArchRegistries, currentNamespaceRegistry := containerRegistry, archContainerRegistries, namespaceContainerRegistry
+	defer func() {
+		SetContainerRegistry(currentRegistry)
+		SetArchContainerRegistries(currentArchRegistries)
+		SetNamespaceContainerRegistry(currentNamespaceRegistry)
+	}()
+
+	SetContainerRegistry("my.docker.registry.com:8080")
+	SetArchContainerRegistries(map[Architecture]string{ARM64: "my.docker.registry.com:8080/arm64"})
+	SetNamespaceContainerRegistry(func(namespace string) string {
+		if namespace == "team-a" {
+			return "registry.team-a.com"
+		}
+		return ""
+	})
+	assert.Equal(t, "registry.team-a.com/elasticsearch/elasticsearch:7.8.0",
+		ImageRepositoryForNamespace(ElasticsearchImage, "7.8.0", "team-a", ARM64))
+	assert.Equal(t, "my.docker.registry.com:8080/arm64/elasticsearch/elasticsearch:7.8.0",
+		ImageRepositoryForNamespace(ElasticsearchImage, "7.8.0", "team-b", ARM64))
+	assert.Equal(t, "my.docker.registry.com:8080/kibana/kibana:7.8.0",
+		ImageRepositoryForNamespace(KibanaImage, "7.8.0", "team-b", ""))
+}
@@ -0,0 +1,194 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ImageDigestPolicyNone deploys the images by tag.
+	ImageDigestPolicyNone = "none"
+	// ImageDigestPolicyPin resolves the tags of the images to digests once, and deploys the images by digest.
+	ImageDigestPolicyPin = "pin"
+
+	// dockerHubRegistry is the host serving the registry API of docker.io.
+	dockerHubRegistry = "registry-1.docker.io"
+	// digestHeader is the header in which the registry returns the digest of a manifest.
+	digestHeader = "Docker-Content-Digest"
+	// resolveTimeout is the maximum duration of the resolution of a digest.
+	resolveTimeout = 30 * time.Second
+)
+
+// manifestMediaTypes are the media types of the manifests accepted when resolving a digest. Image indexes come first,
+// for the digest of a multi-architecture image to be the one of its index.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// DigestResolver resolves the tag of an image to the digest of its manifest.
+type DigestResolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// NewDigestResolver returns the digest resolver of the given policy, or nil if images are deployed by tag.
+func NewDigestResolver(policy string) (DigestResolver, error) {
+	switch policy {
+	case "", ImageDigestPolicyNone:
+		return nil, nil
+	case ImageDigestPolicyPin:
+		return &RegistryResolver{Client: &http.Client{Timeout: resolveTimeout}}, nil
+	default:
+		return nil, errors.Errorf("unknown image digest policy %q, expected %s or %s", policy, ImageDigestPolicyNone, ImageDigestPolicyPin)
+	}
+}
+
+// RegistryResolver resolves digests through the HTTP API of the registries, anonymously.
+type RegistryResolver struct {
+	Client *http.Client
+}
+
+var _ DigestResolver = &RegistryResolver{}
+
+// Resolve returns the digest of the manifest of the given image, requesting an anonymous token if the registry
+// requires one.
+func (r *RegistryResolver) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+	host := ref.Domain
+	if host == defaultDomain {
+		host = dockerHubRegistry
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, ref.Path, ref.Tag)
+
+	resp, err := r.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := r.anonymousToken(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", errors.Wrapf(err, "while authenticating to registry %s", ref.Domain)
+		}
+		if resp, err = r.headManifest(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status %d for the manifest of image %s", resp.StatusCode, image)
+	}
+	digest := resp.Header.Get(digestHeader)
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", errors.Errorf("registry %s returned no digest for image %s", ref.Domain, image)
+	}
+	return digest, nil
+}
+
+func (r *RegistryResolver) headManifest(ctx context.Context, manifestURL string, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// anonymousToken requests an anonymous token from the authorization service of the given Bearer challenge.
+func (r *RegistryResolver) anonymousToken(ctx context.Context, challenge string) (string, error) {
+	params := parseBearerChallenge(challenge)
+	realm, exists := params["realm"]
+	if !exists {
+		return "", errors.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if value, exists := params[key]; exists {
+			query.Set(key, value)
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status %d from the authorization service", resp.StatusCode)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseBearerChallenge returns the parameters of a WWW-Authenticate Bearer challenge, such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull".
+func parseBearerChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return params
+	}
+	rest := strings.TrimSpace(challenge[len("bearer "):])
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.Index(rest, ",")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		params[key] = value
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return params
+}
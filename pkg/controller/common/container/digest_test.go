@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRegistryResolver_Resolve(t *testing.T) {
+	const digest = "sha256:2a9c7a3d2fd2c68b5b4d1e5b7e4fcb1d4f5a5f1f0b7d5e9e6c1f1f2a3b4c5d6e"
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.Equal(t, "repository:elasticsearch/elasticsearch:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"anonymous"}`)
+		case "/v2/elasticsearch/elasticsearch/manifests/7.8.0":
+			assert.Equal(t, http.MethodHead, r.Method)
+			assert.Assert(t, strings.HasPrefix(r.Header.Get("Accept"), "application/vnd.docker.distribution.manifest.list.v2+json"))
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+					`Bearer realm="%s/token",service="registry",scope="repository:elasticsearch/elasticsearch:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set(digestHeader, digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resolver := &RegistryResolver{Client: server.Client()}
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolved, err := resolver.Resolve(context.Background(), host+"/elasticsearch/elasticsearch:7.8.0")
+	assert.NilError(t, err)
+	assert.Equal(t, digest, resolved)
+
+	_, err = resolver.Resolve(context.Background(), host+"/elasticsearch/elasticsearch:0.0.0")
+	assert.Assert(t, err != nil)
+
+	// pinned references are not resolved
+	resolved, err = resolver.Resolve(context.Background(), "unreachable.example.com/elasticsearch@"+digest)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, resolved)
+}
+
+func TestNewDigestResolver(t *testing.T) {
+	resolver, err := NewDigestResolver(ImageDigestPolicyNone)
+	assert.NilError(t, err)
+	assert.Assert(t, resolver == nil)
+	resolver, err = NewDigestResolver(ImageDigestPolicyPin)
+	assert.NilError(t, err)
+	assert.Assert(t, resolver != nil)
+	_, err = NewDigestResolver("always")
+	assert.Assert(t, err != nil)
+}
+
+func Test_parseBearerChallenge(t *testing.T) {
+	assert.DeepEqual(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/nginx:pull",
+	}, parseBearerChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`))
+	assert.DeepEqual(t, map[string]string{}, parseBearerChallenge(`Basic realm="registry"`))
+}
@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package container
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultDomain is the registry of the images whose reference does not include one.
+	defaultDomain = "docker.io"
+	// defaultTag is the tag of the images whose reference does not include one.
+	defaultTag = "latest"
+)
+
+// Reference is a parsed container image reference: <domain>/<path>[:<tag>][@<digest>].
+type Reference struct {
+	// Domain is the registry of the image, docker.io if not part of the reference.
+	Domain string
+	// Path is the repository of the image in the registry, including the library/ prefix of the official images of
+	// docker.io.
+	Path string
+	// Tag of the image, latest if not part of the reference.
+	Tag string
+	// Digest of the image, if part of the reference.
+	Digest string
+}
+
+// ParseReference parses the given container image reference, following the conventions of the container runtimes.
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if ref.Tag == "" {
+		ref.Tag = defaultTag
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Domain, ref.Path = parts[0], parts[1]
+	} else {
+		ref.Domain, ref.Path = defaultDomain, name
+	}
+	if ref.Domain == defaultDomain && !strings.Contains(ref.Path, "/") {
+		ref.Path = "library/" + ref.Path
+	}
+	if ref.Path == "" || strings.HasSuffix(ref.Path, "/") {
+		return Reference{}, errors.Errorf("invalid image reference %q", image)
+	}
+	return ref, nil
+}
+
+// HasDigest returns true if the given image reference is pinned to a digest.
+func HasDigest(image string) bool {
+	return strings.Contains(image, "@")
+}
+
+// registryMirrors are the mirrors replacing the registries of the images deployed by the operator.
+var registryMirrors = map[string]string{}
+
+// SetRegistryMirrors sets the mirrors replacing the registries of the images deployed by the operator, indexed by
+// the domain of the registry they mirror.
+func SetRegistryMirrors(mirrors map[string]string) {
+	registryMirrors = mirrors
+}
+
+// ParseRegistryMirrors parses registry mirrors given as <registry>=<mirror>.
+func ParseRegistryMirrors(values []string) (map[string]string, error) {
+	mirrors := make(map[string]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("invalid registry mirror %q, expected <registry>=<mirror>", value)
+		}
+		mirrors[parts[0]] = strings.TrimSuffix(parts[1], "/")
+	}
+	return mirrors, nil
+}
+
+// Mirrored returns the given image reference, rewritten to reference the mirror of its registry if any.
+func Mirrored(image string) string {
+	if len(registryMirrors) == 0 {
+		return image
+	}
+	ref, err := ParseReference(image)
+	if err != nil {
+		return image
+	}
+	mirror, exists := registryMirrors[ref.Domain]
+	if !exists {
+		return image
+	}
+	mirrored := mirror + "/" + ref.Path + ":" + ref.Tag
+	if ref.Digest != "" {
+		mirrored += "@" + ref.Digest
+	}
+	return mirrored
+}
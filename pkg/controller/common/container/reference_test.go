@@ -0,0 +1,66 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package container
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	testCases := []struct {
+		image string
+		want  Reference
+	}{
+		{
+			image: "docker.elastic.co/elasticsearch/elasticsearch:7.8.0",
+			want:  Reference{Domain: "docker.elastic.co", Path: "elasticsearch/elasticsearch", Tag: "7.8.0"},
+		},
+		{
+			image: "nginx",
+			want:  Reference{Domain: "docker.io", Path: "library/nginx", Tag: "latest"},
+		},
+		{
+			image: "elastic/filebeat:7.8.0",
+			want:  Reference{Domain: "docker.io", Path: "elastic/filebeat", Tag: "7.8.0"},
+		},
+		{
+			image: "localhost:5000/elasticsearch",
+			want:  Reference{Domain: "localhost:5000", Path: "elasticsearch", Tag: "latest"},
+		},
+		{
+			image: "registry.example.com/elasticsearch:7.8.0@sha256:0123",
+			want:  Reference{Domain: "registry.example.com", Path: "elasticsearch", Tag: "7.8.0", Digest: "sha256:0123"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.image, func(t *testing.T) {
+			ref, err := ParseReference(tc.image)
+			assert.NilError(t, err)
+			assert.Equal(t, tc.want, ref)
+		})
+	}
+
+	_, err := ParseReference("registry.example.com/")
+	assert.Assert(t, err != nil)
+}
+
+func TestMirrored(t *testing.T) {
+	currentMirrors := registryMirrors
+	defer SetRegistryMirrors(currentMirrors)
+
+	assert.Equal(t, "docker.elastic.co/kibana/kibana:7.8.0", Mirrored("docker.elastic.co/kibana/kibana:7.8.0"))
+
+	mirrors, err := ParseRegistryMirrors([]string{"docker.elastic.co=registry.example.com/elastic/", "docker.io=registry.example.com/hub"})
+	assert.NilError(t, err)
+	SetRegistryMirrors(mirrors)
+	assert.Equal(t, "registry.example.com/elastic/kibana/kibana:7.8.0", Mirrored("docker.elastic.co/kibana/kibana:7.8.0"))
+	assert.Equal(t, "registry.example.com/hub/library/nginx:latest", Mirrored("nginx"))
+	assert.Equal(t, "quay.io/org/image:1.0", Mirrored("quay.io/org/image:1.0"))
+
+	_, err = ParseRegistryMirrors([]string{"docker.elastic.co"})
+	assert.Assert(t, err != nil)
+}
@@ -5,13 +5,30 @@
 package common
 
 import (
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/metrics"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // NewController creates a new controller with the given name, reconciler and parameters and registers it with the manager.
-func NewController(mgr manager.Manager, name string, r reconcile.Reconciler, p operator.Parameters) (controller.Controller, error) {
+// The controller reconciles resources of the type of the given object, which are the resources its reconcile metrics
+// are reported for.
+func NewController(
+	mgr manager.Manager,
+	name string,
+	reconciled runtime.Object,
+	r reconcile.Reconciler,
+	p operator.Parameters,
+) (controller.Controller, error) {
+	r, err := metrics.DefaultReconcileMetrics.Wrap(mgr.GetClient(), mgr.GetScheme(), name, reconciled, r)
+	if err != nil {
+		return nil, err
+	}
+	if p.Drainer != nil {
+		r = p.Drainer.Wrap(r)
+	}
 	return controller.New(name, mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: p.MaxConcurrentReconciles})
 }
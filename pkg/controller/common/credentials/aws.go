@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/pkg/errors"
+)
+
+const awsRegionEnv = "AWS_REGION"
+
+// awsSecretsManagerStore stores credentials in AWS Secrets Manager, one AWS secret holding a JSON object per
+// Kubernetes secret.
+type awsSecretsManagerStore struct {
+	client secretsmanageriface.SecretsManagerAPI
+}
+
+// newAWSSecretsManagerStore returns a store using the default credential chain of the AWS SDK: environment variables,
+// web identity tokens of IAM roles for service accounts, shared configuration files, and ECS or EC2 instance roles.
+// The credentials are refreshed by the SDK before they expire.
+func newAWSSecretsManagerStore(params Params) (*awsSecretsManagerStore, error) {
+	return newAWSSecretsManagerStoreWithConfig(params, aws.NewConfig())
+}
+
+func newAWSSecretsManagerStoreWithConfig(params Params, config *aws.Config) (*awsSecretsManagerStore, error) {
+	config = config.WithHTTPClient(&http.Client{Timeout: storeRequestTimeout})
+	if params.AWSRegion != "" {
+		config = config.WithRegion(params.AWSRegion)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "while setting up the AWS session")
+	}
+	if aws.StringValue(sess.Config.Region) == "" {
+		return nil, fmt.Errorf("the AWS region is required, or %s must be set", awsRegionEnv)
+	}
+	return &awsSecretsManagerStore{client: secretsmanager.New(sess)}, nil
+}
+
+func isAWSNotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException
+}
+
+func (a *awsSecretsManagerStore) Get(key string) (map[string][]byte, error) {
+	out, err := a.client.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(key)})
+	if isAWSNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var strs map[string]string
+	if err := json.Unmarshal([]byte(aws.StringValue(out.SecretString)), &strs); err != nil {
+		return nil, errors.Wrap(err, "while parsing the secret value")
+	}
+	return toBytes(strs), nil
+}
+
+func (a *awsSecretsManagerStore) Put(key string, data map[string][]byte) error {
+	value, err := json.Marshal(toStrings(data))
+	if err != nil {
+		return err
+	}
+	_, err = a.client.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(key),
+		SecretString: aws.String(string(value)),
+	})
+	if isAWSNotFound(err) {
+		_, err = a.client.CreateSecret(&secretsmanager.CreateSecretInput{
+			Name:         aws.String(key),
+			SecretString: aws.String(string(value)),
+		})
+	}
+	return err
+}
+
+func (a *awsSecretsManagerStore) Delete(key string) error {
+	_, err := a.client.DeleteSecret(&secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(key),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	if isAWSNotFound(err) {
+		return nil
+	}
+	return err
+}
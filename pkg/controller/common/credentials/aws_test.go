@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awscredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_awsSecretsManagerStore(t *testing.T) {
+	secrets := map[string]string{}
+	var targets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") ||
+			r.Header.Get("X-Amz-Security-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"__type":"UnrecognizedClientException","message":"invalid token"}`))
+			return
+		}
+		target := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.")
+		targets = append(targets, target)
+		var params map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		notFound := func() {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		}
+		switch target {
+		case "GetSecretValue":
+			value, exists := secrets[params["SecretId"].(string)]
+			if !exists {
+				notFound()
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": value})
+		case "PutSecretValue":
+			if _, exists := secrets[params["SecretId"].(string)]; !exists {
+				notFound()
+				return
+			}
+			secrets[params["SecretId"].(string)] = params["SecretString"].(string)
+		case "CreateSecret":
+			secrets[params["Name"].(string)] = params["SecretString"].(string)
+		case "DeleteSecret":
+			if _, exists := secrets[params["SecretId"].(string)]; !exists {
+				notFound()
+				return
+			}
+			delete(secrets, params["SecretId"].(string))
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	newStore := func(accessKeyID string) *awsSecretsManagerStore {
+		store, err := newAWSSecretsManagerStoreWithConfig(Params{AWSRegion: "eu-west-1"}, aws.NewConfig().
+			WithEndpoint(server.URL).
+			WithMaxRetries(0).
+			WithCredentials(awscredentials.NewStaticCredentials(accessKeyID, "secret", "token")))
+		require.NoError(t, err)
+		return store
+	}
+	store := newStore("key")
+
+	data, err := store.Get("eck/ns/es-elastic-user")
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	// the secret is created, then updated
+	require.NoError(t, store.Put("eck/ns/es-elastic-user", map[string][]byte{"elastic": []byte("password")}))
+	require.NoError(t, store.Put("eck/ns/es-elastic-user", map[string][]byte{"elastic": []byte("rotated")}))
+	require.Equal(t, []string{"GetSecretValue", "PutSecretValue", "CreateSecret", "PutSecretValue"}, targets)
+	data, err = store.Get("eck/ns/es-elastic-user")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"elastic": []byte("rotated")}, data)
+
+	require.NoError(t, store.Delete("eck/ns/es-elastic-user"))
+	require.Empty(t, secrets)
+	require.Equal(t, "DeleteSecret", targets[len(targets)-1])
+	// deleting a missing secret is a no-op
+	require.NoError(t, store.Delete("eck/ns/es-elastic-user"))
+
+	// other errors are returned
+	_, err = newStore("invalid").Get("eck/ns/es-elastic-user")
+	require.Error(t, err)
+}
+
+func Test_newAWSSecretsManagerStore(t *testing.T) {
+	region, regionSet := os.LookupEnv(awsRegionEnv)
+	defer func() {
+		if regionSet {
+			_ = os.Setenv(awsRegionEnv, region)
+		}
+	}()
+	require.NoError(t, os.Unsetenv(awsRegionEnv))
+	_, err := newAWSSecretsManagerStore(Params{})
+	require.EqualError(t, err, "the AWS region is required, or AWS_REGION must be set")
+
+	require.NoError(t, os.Setenv(awsRegionEnv, "eu-west-1"))
+	_, err = newAWSSecretsManagerStore(Params{})
+	require.NoError(t, err)
+	if !regionSet {
+		require.NoError(t, os.Unsetenv(awsRegionEnv))
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ChangeRecorder records the changes a dry-run reconciliation would perform.
+type ChangeRecorder interface {
+	RecordObject(action, kind, name string)
+}
+
+// NewDryRun returns Credentials loading the credentials from the global store, and recording the credentials that
+// would be written to it with the given recorder instead of writing them.
+func NewDryRun(recorder ChangeRecorder) Credentials {
+	return dryRunCredentials{recorder: recorder}
+}
+
+type dryRunCredentials struct {
+	recorder ChangeRecorder
+}
+
+func (d dryRunCredentials) Load(key types.NamespacedName, secret *corev1.Secret) (bool, error) {
+	return Load(key, secret)
+}
+
+func (d dryRunCredentials) Save(key types.NamespacedName, data map[string][]byte) error {
+	if store == nil || isStored(storeKey(key), data) {
+		return nil
+	}
+	// such as "store credentials of Secret es-es-elastic-user"
+	d.recorder.RecordObject("store", "credentials of Secret", key.Name)
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/dryrun"
+)
+
+func TestNewDryRun(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "es-es-elastic-user"}
+	changes := dryrun.NewChanges()
+	dryRun := NewDryRun(changes)
+
+	// nothing is recorded without a store
+	SetStore(nil, "", 0)
+	require.NoError(t, dryRun.Save(key, map[string][]byte{"elastic": []byte("new")}))
+	require.Empty(t, changes.List())
+
+	store := &memoryStore{data: map[string]map[string][]byte{"eck/ns/es-es-elastic-user": {"elastic": []byte("stored")}}}
+	SetStore(store, "eck", time.Hour)
+	defer SetStore(nil, "", DefaultCacheTTL)
+
+	// credentials are loaded from the store
+	secret := corev1.Secret{}
+	found, err := dryRun.Load(key, &secret)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, map[string][]byte{"elastic": []byte("stored")}, secret.Data)
+
+	// unchanged credentials are not recorded
+	require.NoError(t, dryRun.Save(key, secret.Data))
+	require.Empty(t, changes.List())
+
+	// new credentials are recorded instead of being written
+	require.NoError(t, dryRun.Save(key, map[string][]byte{"elastic": []byte("new")}))
+	require.Equal(t, []string{"store credentials of Secret es-es-elastic-user"}, changes.List())
+	require.Equal(t, 0, store.writes)
+	require.Equal(t, map[string][]byte{"elastic": []byte("stored")}, store.data["eck/ns/es-es-elastic-user"])
+}
@@ -0,0 +1,265 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+)
+
+var log = logf.Log.WithName("credentials")
+
+const (
+	// KubernetesStoreType keeps generated credentials in Kubernetes secrets only.
+	KubernetesStoreType = "kubernetes"
+	// VaultStoreType writes generated credentials to a HashiCorp Vault KV version 2 secrets engine.
+	VaultStoreType = "vault"
+	// AWSSecretsManagerStoreType writes generated credentials to AWS Secrets Manager.
+	AWSSecretsManagerStoreType = "aws-secrets-manager"
+)
+
+// Store persists generated credentials (passwords, CA private keys, tokens) in an external secret manager.
+// Credentials are stored under the key of the Kubernetes secret they are materialized into.
+type Store interface {
+	// Get returns the credentials stored under the given key, or nil if there are none.
+	Get(key string) (map[string][]byte, error)
+	// Put creates or replaces the credentials stored under the given key.
+	Put(key string, data map[string][]byte) error
+	// Delete deletes the credentials stored under the given key, if any.
+	Delete(key string) error
+}
+
+// Credentials loads and saves the credentials of Kubernetes secrets from and to the external store, if any.
+type Credentials interface {
+	// Load replaces the data of the given secret with the credentials held by the store, see Load.
+	Load(key types.NamespacedName, secret *corev1.Secret) (bool, error)
+	// Save writes the credentials of the given secret to the store, see Save.
+	Save(key types.NamespacedName, data map[string][]byte) error
+}
+
+// Default loads and saves the credentials with the global store set with SetStore.
+var Default Credentials = globalCredentials{}
+
+type globalCredentials struct{}
+
+func (globalCredentials) Load(key types.NamespacedName, secret *corev1.Secret) (bool, error) {
+	return Load(key, secret)
+}
+
+func (globalCredentials) Save(key types.NamespacedName, data map[string][]byte) error {
+	return Save(key, data)
+}
+
+// Params are the parameters used to create a Store.
+type Params struct {
+	// Type of the store, one of KubernetesStoreType, VaultStoreType or AWSSecretsManagerStoreType.
+	Type string
+	// VaultAddress is the address of the Vault server.
+	VaultAddress string
+	// VaultMount is the mount path of the KV version 2 secrets engine.
+	VaultMount string
+	// VaultTokenFile is the file holding the Vault token, read before each request to pick up renewed tokens.
+	// The Kubernetes auth method is used instead if VaultKubernetesAuthRole is set, and the VAULT_TOKEN environment
+	// variable otherwise.
+	VaultTokenFile string
+	// VaultKubernetesAuthRole is the role used to log in to Vault with the Kubernetes auth method.
+	VaultKubernetesAuthRole string
+	// VaultKubernetesAuthMount is the mount path of the Vault Kubernetes auth method.
+	VaultKubernetesAuthMount string
+	// VaultNamespace is the Vault Enterprise namespace of the secrets engine and auth method.
+	VaultNamespace string
+	// VaultCACert is the PEM-encoded CA certificate file used to verify the certificate of the Vault server.
+	VaultCACert string
+	// VaultClientCert and VaultClientKey are the PEM-encoded certificate and private key files used to authenticate
+	// to the Vault server with TLS.
+	VaultClientCert string
+	VaultClientKey  string
+	// AWSRegion is the region of AWS Secrets Manager.
+	AWSRegion string
+}
+
+// NewStore returns the store described by the given parameters, or nil if credentials are kept in Kubernetes secrets
+// only.
+func NewStore(params Params) (Store, error) {
+	switch params.Type {
+	case "", KubernetesStoreType:
+		return nil, nil
+	case VaultStoreType:
+		return newVaultStore(params)
+	case AWSSecretsManagerStoreType:
+		return newAWSSecretsManagerStore(params)
+	default:
+		return nil, fmt.Errorf("unsupported credentials store type %s", params.Type)
+	}
+}
+
+// DefaultCacheTTL is the default duration during which the credentials read from or written to the store are reused
+// without reading the store again.
+const DefaultCacheTTL = 10 * time.Minute
+
+// cached are credentials known to be in the store.
+type cached struct {
+	data    map[string][]byte
+	hash    string
+	expires time.Time
+}
+
+var (
+	store    Store
+	prefix   string
+	cacheTTL = DefaultCacheTTL
+	// cache holds the credentials known to be in the store, to save requests while the Kubernetes secrets exist
+	cache = map[string]cached{}
+	// fallbacks holds the hash of the credentials read from the Kubernetes secrets while the store was unavailable, to
+	// keep reconciling them without writing them to the store
+	fallbacks = map[string]string{}
+	cacheMu   sync.Mutex
+	now       = time.Now
+)
+
+// SetStore sets the global store used to persist generated credentials, with the prefix of the keys in the store and
+// the duration during which credentials are reused without reading the store again.
+func SetStore(s Store, keyPrefix string, ttl time.Duration) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	store = s
+	prefix = keyPrefix
+	cacheTTL = ttl
+	cache = map[string]cached{}
+	fallbacks = map[string]string{}
+}
+
+func storeKey(key types.NamespacedName) string {
+	return path.Join(prefix, key.Namespace, key.Name)
+}
+
+func setCached(key string, data map[string][]byte) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	delete(fallbacks, key)
+	if data == nil {
+		delete(cache, key)
+		return
+	}
+	cache[key] = cached{data: data, hash: hash.HashObject(data), expires: now().Add(cacheTTL)}
+}
+
+// getCached returns the credentials cached for the given key, if not expired.
+func getCached(key string) (map[string][]byte, bool) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	c, exists := cache[key]
+	if !exists || !now().Before(c.expires) {
+		return nil, false
+	}
+	data := make(map[string][]byte, len(c.data))
+	for k, v := range c.data {
+		data[k] = v
+	}
+	return data, true
+}
+
+func isStored(key string, data map[string][]byte) bool {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	c, exists := cache[key]
+	return exists && c.hash == hash.HashObject(data)
+}
+
+func setFallback(key string, data map[string][]byte) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	fallbacks[key] = hash.HashObject(data)
+}
+
+func isFallback(key string, data map[string][]byte) bool {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	h, exists := fallbacks[key]
+	return exists && h == hash.HashObject(data)
+}
+
+// Load replaces the data of the given secret with the credentials held by the store for that secret, if any. It
+// returns true if credentials were found in the store, which takes precedence over the Kubernetes secret.
+// The store is only read if the Kubernetes secret has no data, or once the credentials cached for that secret expire.
+// If the store cannot be read, an existing Kubernetes secret is used as is so that the reconciliation proceeds.
+func Load(key types.NamespacedName, secret *corev1.Secret) (bool, error) {
+	if store == nil {
+		return false, nil
+	}
+	k := storeKey(key)
+	exists := len(secret.Data) > 0
+	if exists {
+		if data, found := getCached(k); found {
+			secret.Data = data
+			return true, nil
+		}
+	}
+	data, err := store.Get(k)
+	if err != nil {
+		if exists {
+			log.Error(err, "Cannot read the credentials store, using the Kubernetes secret",
+				"namespace", key.Namespace, "secret_name", key.Name)
+			setFallback(k, secret.Data)
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "while reading credentials %s from the store", k)
+	}
+	if data == nil {
+		setCached(k, nil)
+		return false, nil
+	}
+	setCached(k, data)
+	secret.Data = data
+	return true, nil
+}
+
+// Save writes the credentials of the given secret to the store, if they are not already there. It must be called
+// before the Kubernetes secret is updated, for the store to remain the source of truth. The unchanged credentials of
+// a Kubernetes secret loaded while the store was unavailable are not written until the store is available again.
+func Save(key types.NamespacedName, data map[string][]byte) error {
+	if store == nil {
+		return nil
+	}
+	k := storeKey(key)
+	if isStored(k, data) {
+		return nil
+	}
+	log.V(1).Info("Writing credentials to the store", "namespace", key.Namespace, "secret_name", key.Name)
+	if err := store.Put(k, data); err != nil {
+		if isFallback(k, data) {
+			log.Error(err, "Cannot write the credentials store, keeping the Kubernetes secret",
+				"namespace", key.Namespace, "secret_name", key.Name)
+			return nil
+		}
+		return errors.Wrapf(err, "while writing credentials %s to the store", k)
+	}
+	setCached(k, data)
+	return nil
+}
+
+// Delete deletes the credentials of the given secrets from the store.
+func Delete(keys ...types.NamespacedName) error {
+	if store == nil {
+		return nil
+	}
+	for _, key := range keys {
+		k := storeKey(key)
+		if err := store.Delete(k); err != nil {
+			return errors.Wrapf(err, "while deleting credentials %s from the store", k)
+		}
+		setCached(k, nil)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// memoryStore is a Store keeping credentials in memory, counting the reads and writes.
+type memoryStore struct {
+	data        map[string]map[string][]byte
+	reads       int
+	writes      int
+	unavailable bool
+}
+
+func (m *memoryStore) Get(key string) (map[string][]byte, error) {
+	m.reads++
+	if m.unavailable {
+		return nil, errors.New("store unavailable")
+	}
+	return m.data[key], nil
+}
+
+func (m *memoryStore) Put(key string, data map[string][]byte) error {
+	m.writes++
+	if m.unavailable {
+		return errors.New("store unavailable")
+	}
+	m.data[key] = data
+	return nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func TestLoadSaveDelete(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "es-es-elastic-user"}
+	secret := corev1.Secret{Data: map[string][]byte{"elastic": []byte("k8s")}}
+
+	// no store: the Kubernetes secret is used as is
+	SetStore(nil, "", 0)
+	found, err := Load(key, &secret)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.NoError(t, Save(key, secret.Data))
+	require.NoError(t, Delete(key))
+
+	store := &memoryStore{data: map[string]map[string][]byte{}}
+	// credentials are not cached, to read the store on each load
+	SetStore(store, "eck", 0)
+	defer SetStore(nil, "", DefaultCacheTTL)
+
+	// nothing in the store yet: the existing credentials are migrated to the store
+	found, err = Load(key, &secret)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Equal(t, []byte("k8s"), secret.Data["elastic"])
+	require.NoError(t, Save(key, secret.Data))
+	require.Equal(t, map[string][]byte{"elastic": []byte("k8s")}, store.data["eck/ns/es-es-elastic-user"])
+
+	// credentials in the store take precedence
+	store.data["eck/ns/es-es-elastic-user"] = map[string][]byte{"elastic": []byte("stored")}
+	found, err = Load(key, &secret)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("stored"), secret.Data["elastic"])
+
+	// unchanged credentials are not written again
+	writes := store.writes
+	require.NoError(t, Save(key, map[string][]byte{"elastic": []byte("stored")}))
+	require.Equal(t, writes, store.writes)
+	require.NoError(t, Save(key, map[string][]byte{"elastic": []byte("rotated")}))
+	require.Equal(t, writes+1, store.writes)
+
+	require.NoError(t, Delete(key))
+	require.Empty(t, store.data)
+	// credentials are written again once deleted
+	require.NoError(t, Save(key, map[string][]byte{"elastic": []byte("rotated")}))
+	require.Equal(t, writes+2, store.writes)
+}
+
+func TestLoad_Cache(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "es-es-elastic-user"}
+	store := &memoryStore{data: map[string]map[string][]byte{}}
+	SetStore(store, "eck", time.Hour)
+	defer SetStore(nil, "", DefaultCacheTTL)
+	clock := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	// reconcile loads the credentials into the Kubernetes secret, and saves them
+	var k8sSecret corev1.Secret
+	reconcile := func() error {
+		secret := corev1.Secret{Data: k8sSecret.Data}
+		if _, err := Load(key, &secret); err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{"elastic": []byte("generated")}
+		}
+		if err := Save(key, secret.Data); err != nil {
+			return err
+		}
+		k8sSecret.Data = secret.Data
+		return nil
+	}
+
+	// the Kubernetes secret is missing: the store is read, and the generated credentials written
+	require.NoError(t, reconcile())
+	require.Equal(t, 1, store.reads)
+	require.Equal(t, 1, store.writes)
+
+	// the store is not called again while the credentials are cached
+	for i := 0; i < 10; i++ {
+		require.NoError(t, reconcile())
+	}
+	require.Equal(t, 1, store.reads)
+	require.Equal(t, 1, store.writes)
+	require.Equal(t, []byte("generated"), k8sSecret.Data["elastic"])
+
+	// the store is read again once the cache expires, picking up the credentials rotated in the store
+	store.data["eck/ns/es-es-elastic-user"] = map[string][]byte{"elastic": []byte("rotated")}
+	clock = clock.Add(time.Hour)
+	require.NoError(t, reconcile())
+	require.NoError(t, reconcile())
+	require.Equal(t, 2, store.reads)
+	require.Equal(t, 1, store.writes)
+	require.Equal(t, []byte("rotated"), k8sSecret.Data["elastic"])
+
+	// the store is read if the Kubernetes secret is missing, even if the credentials are cached
+	k8sSecret.Data = nil
+	require.NoError(t, reconcile())
+	require.Equal(t, 3, store.reads)
+	require.Equal(t, []byte("rotated"), k8sSecret.Data["elastic"])
+
+	// the store is unavailable: the existing Kubernetes secret is used
+	store.unavailable = true
+	clock = clock.Add(time.Hour)
+	require.NoError(t, reconcile())
+	require.Equal(t, 4, store.reads)
+	require.Equal(t, []byte("rotated"), k8sSecret.Data["elastic"])
+	// but missing credentials cannot be generated
+	k8sSecret.Data = nil
+	require.Error(t, reconcile())
+}
+
+func TestSave_Fallback(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "es-es-elastic-user"}
+	store := &memoryStore{data: map[string]map[string][]byte{}, unavailable: true}
+	SetStore(store, "eck", time.Hour)
+	defer SetStore(nil, "", DefaultCacheTTL)
+
+	// the credentials of the Kubernetes secret, unknown to the store, are kept while the store is unavailable
+	secret := corev1.Secret{Data: map[string][]byte{"elastic": []byte("k8s")}}
+	found, err := Load(key, &secret)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.NoError(t, Save(key, secret.Data))
+	// rotated credentials must be written to the store first
+	require.Error(t, Save(key, map[string][]byte{"elastic": []byte("rotated")}))
+
+	// the credentials are written once the store is available again
+	store.unavailable = false
+	require.NoError(t, Save(key, secret.Data))
+	require.Equal(t, secret.Data, store.data["eck/ns/es-es-elastic-user"])
+}
@@ -0,0 +1,252 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultVaultMount is the default mount path of the Vault KV version 2 secrets engine.
+	DefaultVaultMount = "secret"
+	// DefaultVaultKubernetesAuthMount is the default mount path of the Vault Kubernetes auth method.
+	DefaultVaultKubernetesAuthMount = "kubernetes"
+
+	storeRequestTimeout = 10 * time.Second
+)
+
+// serviceAccountTokenFile is the token of the service account of the operator, used to log in to Vault with the
+// Kubernetes auth method.
+var serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint:gosec
+
+// vaultStore stores credentials in a Vault KV version 2 secrets engine, one Vault secret per Kubernetes secret.
+type vaultStore struct {
+	client    *api.Client
+	mount     string
+	tokenFile string
+	authRole  string
+	authMount string
+
+	// mutex protects the login state below
+	mutex sync.Mutex
+	// renewer renews the token obtained with the Kubernetes auth method, if it can be renewed
+	renewer *api.Renewer
+	// loginExpiry is the time at which a token that cannot be renewed must be replaced
+	loginExpiry time.Time
+	// loggedIn is true while the token obtained with the Kubernetes auth method is valid
+	loggedIn bool
+}
+
+func newVaultStore(params Params) (*vaultStore, error) {
+	if params.VaultAddress == "" {
+		return nil, errors.New("the Vault address is required")
+	}
+	config := api.DefaultConfig()
+	if config.Error != nil {
+		return nil, errors.Wrap(config.Error, "while reading the Vault environment")
+	}
+	config.Address = params.VaultAddress
+	config.Timeout = storeRequestTimeout
+	if params.VaultCACert != "" || params.VaultClientCert != "" || params.VaultClientKey != "" {
+		if err := config.ConfigureTLS(&api.TLSConfig{
+			CACert:     params.VaultCACert,
+			ClientCert: params.VaultClientCert,
+			ClientKey:  params.VaultClientKey,
+		}); err != nil {
+			return nil, errors.Wrap(err, "while configuring TLS for Vault")
+		}
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "while creating the Vault client")
+	}
+	if params.VaultNamespace != "" {
+		client.SetNamespace(params.VaultNamespace)
+	}
+	mount := params.VaultMount
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	authMount := params.VaultKubernetesAuthMount
+	if authMount == "" {
+		authMount = DefaultVaultKubernetesAuthMount
+	}
+	return &vaultStore{
+		client:    client,
+		mount:     strings.Trim(mount, "/"),
+		tokenFile: params.VaultTokenFile,
+		authRole:  params.VaultKubernetesAuthRole,
+		authMount: strings.Trim(authMount, "/"),
+	}, nil
+}
+
+// authenticate sets the Vault token of the client, read from the token file before each request since it may be
+// renewed by a Vault agent, or obtained with the Kubernetes auth method. The VAULT_TOKEN environment variable is used
+// otherwise.
+func (v *vaultStore) authenticate() error {
+	switch {
+	case v.tokenFile != "":
+		token, err := ioutil.ReadFile(v.tokenFile)
+		if err != nil {
+			return err
+		}
+		v.client.SetToken(strings.TrimSpace(string(token)))
+		return nil
+	case v.authRole != "":
+		return v.login()
+	case v.client.Token() == "":
+		return fmt.Errorf("no Vault token file or Kubernetes auth role configured and %s is not set", api.EnvVaultToken)
+	default:
+		return nil
+	}
+}
+
+// login logs in with the Kubernetes auth method if the client has no valid token, and renews the token in the
+// background until it reaches its maximum TTL, after which it logs in again.
+func (v *vaultStore) login() error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.loggedIn && (v.loginExpiry.IsZero() || now().Before(v.loginExpiry)) {
+		return nil
+	}
+	jwt, err := ioutil.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return errors.Wrap(err, "while reading the service account token")
+	}
+	secret, err := v.client.Logical().Write(fmt.Sprintf("auth/%s/login", v.authMount), map[string]interface{}{
+		"role": v.authRole,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return errors.Wrap(err, "while logging in to Vault")
+	}
+	if secret == nil || secret.Auth == nil {
+		return errors.New("no auth info in the Vault login response")
+	}
+	if v.renewer != nil {
+		v.renewer.Stop()
+		v.renewer = nil
+	}
+	v.client.SetToken(secret.Auth.ClientToken)
+	v.loggedIn = true
+	v.loginExpiry = time.Time{}
+	if !secret.Auth.Renewable {
+		// log in again once two thirds of the lease duration elapsed
+		v.loginExpiry = now().Add(time.Duration(secret.Auth.LeaseDuration) * time.Second * 2 / 3)
+		return nil
+	}
+	renewer, err := v.client.NewRenewer(&api.RenewerInput{Secret: secret})
+	if err != nil {
+		return err
+	}
+	v.renewer = renewer
+	go renewer.Renew()
+	go v.watchRenewal(renewer)
+	return nil
+}
+
+// watchRenewal waits for the renewal of the token to stop, so that the next request logs in again.
+func (v *vaultStore) watchRenewal(renewer *api.Renewer) {
+	for {
+		select {
+		case err := <-renewer.DoneCh():
+			if err != nil {
+				log.Error(err, "Failed to renew the Vault token")
+			}
+			v.mutex.Lock()
+			if v.renewer == renewer {
+				v.loggedIn = false
+				v.renewer = nil
+			}
+			v.mutex.Unlock()
+			return
+		case <-renewer.RenewCh():
+		}
+	}
+}
+
+func (v *vaultStore) logout() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.loggedIn = false
+}
+
+// request authenticates and performs the given request, logging in again on the next request if the token is denied.
+func (v *vaultStore) request(fn func(logical *api.Logical) (*api.Secret, error)) (*api.Secret, error) {
+	if err := v.authenticate(); err != nil {
+		return nil, err
+	}
+	secret, err := fn(v.client.Logical())
+	if respErr, ok := err.(*api.ResponseError); ok && respErr.StatusCode == http.StatusForbidden && v.authRole != "" {
+		v.logout()
+	}
+	return secret, err
+}
+
+func (v *vaultStore) Get(key string) (map[string][]byte, error) {
+	secret, err := v.request(func(logical *api.Logical) (*api.Secret, error) {
+		return logical.Read(fmt.Sprintf("%s/data/%s", v.mount, key))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		// not found
+		return nil, nil
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		// latest version deleted
+		return nil, nil
+	}
+	strs := make(map[string]string, len(data))
+	for k, value := range data {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value of type %T for key %s in Vault secret %s", value, k, key)
+		}
+		strs[k] = str
+	}
+	return toBytes(strs), nil
+}
+
+func (v *vaultStore) Put(key string, data map[string][]byte) error {
+	_, err := v.request(func(logical *api.Logical) (*api.Secret, error) {
+		return logical.Write(fmt.Sprintf("%s/data/%s", v.mount, key), map[string]interface{}{"data": toStrings(data)})
+	})
+	return err
+}
+
+func (v *vaultStore) Delete(key string) error {
+	// deleting the metadata deletes all the versions of the secret
+	_, err := v.request(func(logical *api.Logical) (*api.Secret, error) {
+		return logical.Delete(fmt.Sprintf("%s/metadata/%s", v.mount, key))
+	})
+	return err
+}
+
+func toStrings(data map[string][]byte) map[string]string {
+	strs := make(map[string]string, len(data))
+	for k, v := range data {
+		strs[k] = string(v)
+	}
+	return strs
+}
+
+func toBytes(strs map[string]string) map[string][]byte {
+	data := make(map[string][]byte, len(strs))
+	for k, v := range strs {
+		data[k] = []byte(v)
+	}
+	return data
+}
@@ -0,0 +1,165 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_vaultStore(t *testing.T) {
+	secrets := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/kv/data/"):
+			data, exists := secrets[strings.TrimPrefix(r.URL.Path, "/v1/kv/data/")]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/kv/data/"):
+			var body struct {
+				Data map[string]string `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			secrets[strings.TrimPrefix(r.URL.Path, "/v1/kv/data/")] = body.Data
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/kv/metadata/"):
+			delete(secrets, strings.TrimPrefix(r.URL.Path, "/v1/kv/metadata/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("s.token\n"), 0600))
+
+	store, err := newVaultStore(Params{VaultAddress: server.URL + "/", VaultMount: "/kv/", VaultTokenFile: tokenFile,
+		VaultNamespace: "team-a",
+	})
+	require.NoError(t, err)
+
+	data, err := store.Get("eck/ns/es-elastic-user")
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	require.NoError(t, store.Put("eck/ns/es-elastic-user", map[string][]byte{"elastic": []byte("password")}))
+	require.Equal(t, map[string]string{"elastic": "password"}, secrets["eck/ns/es-elastic-user"])
+	data, err = store.Get("eck/ns/es-elastic-user")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"elastic": []byte("password")}, data)
+
+	require.NoError(t, store.Delete("eck/ns/es-elastic-user"))
+	require.Empty(t, secrets)
+
+	// the token is read again for every request
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("s.expired"), 0600))
+	_, err = store.Get("eck/ns/es-elastic-user")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "permission denied")
+}
+
+func Test_vaultStore_KubernetesAuth(t *testing.T) {
+	var mutex sync.Mutex
+	logins := 0
+	token := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.URL.Path {
+		case "/v1/auth/k8s/login":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, map[string]string{"role": "eck", "jwt": "service-account-token"}, body)
+			logins++
+			token = fmt.Sprintf("s.token-%d", logins)
+			_, _ = fmt.Fprintf(w, `{"auth":{"client_token":%q,"renewable":true,"lease_duration":3600}}`, token)
+		case "/v1/auth/token/renew-self":
+			_, _ = fmt.Fprintf(w, `{"auth":{"client_token":%q,"renewable":true,"lease_duration":3600}}`, token)
+		case "/v1/secret/data/eck/ns/es-elastic-user":
+			if r.Header.Get("X-Vault-Token") != token {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"data":{"elastic":"password"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(file string) { serviceAccountTokenFile = file }(serviceAccountTokenFile)
+	serviceAccountTokenFile = filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(serviceAccountTokenFile, []byte("service-account-token"), 0600))
+
+	store, err := newVaultStore(Params{VaultAddress: server.URL, VaultKubernetesAuthRole: "eck", VaultKubernetesAuthMount: "k8s"})
+	require.NoError(t, err)
+	defer func() { store.renewer.Stop() }()
+
+	// the operator logs in once, and the token is reused
+	for i := 0; i < 3; i++ {
+		data, err := store.Get("eck/ns/es-elastic-user")
+		require.NoError(t, err)
+		require.Equal(t, map[string][]byte{"elastic": []byte("password")}, data)
+	}
+	mutex.Lock()
+	require.Equal(t, 1, logins)
+	// a denied token is replaced on the next request
+	token = "s.revoked"
+	mutex.Unlock()
+	_, err = store.Get("eck/ns/es-elastic-user")
+	require.Error(t, err)
+	_, err = store.Get("eck/ns/es-elastic-user")
+	require.NoError(t, err)
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, 2, logins)
+}
+
+func TestNewStore(t *testing.T) {
+	for _, storeType := range []string{"", KubernetesStoreType} {
+		store, err := NewStore(Params{Type: storeType})
+		require.NoError(t, err)
+		require.Nil(t, store)
+	}
+	_, err := NewStore(Params{Type: VaultStoreType})
+	require.Error(t, err)
+	store, err := NewStore(Params{Type: VaultStoreType, VaultAddress: "https://vault:8200"})
+	require.NoError(t, err)
+	require.Equal(t, DefaultVaultMount, store.(*vaultStore).mount)
+	require.Equal(t, DefaultVaultKubernetesAuthMount, store.(*vaultStore).authMount)
+	_, err = NewStore(Params{Type: "unknown"})
+	require.Error(t, err)
+}
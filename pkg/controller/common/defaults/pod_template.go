@@ -9,6 +9,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/maps"
 )
@@ -97,8 +98,8 @@ func (b *PodTemplateBuilder) WithDockerImage(customImage string, defaultImage st
 	case b.Container.Image != "":
 		// keep user-provided Container image name
 	case customImage != "":
-		// use user-provided custom image
-		b.Container.Image = customImage
+		// use user-provided custom image, from the mirror of its registry if any
+		b.Container.Image = container.Mirrored(customImage)
 	default:
 		// use default image
 		b.Container.Image = defaultImage
@@ -124,6 +125,28 @@ func (b *PodTemplateBuilder) WithAffinity(affinity *corev1.Affinity) *PodTemplat
 	return b
 }
 
+// WithTopologySpreadConstraints sets default topology spread constraints, unless already provided in the template.
+func (b *PodTemplateBuilder) WithTopologySpreadConstraints(constraints ...corev1.TopologySpreadConstraint) *PodTemplateBuilder {
+	if len(b.PodTemplate.Spec.TopologySpreadConstraints) == 0 {
+		b.PodTemplate.Spec.TopologySpreadConstraints = constraints
+	}
+	return b
+}
+
+// WithNodeSelector adds the given node selector labels to the Pod, unless already provided in the template.
+func (b *PodTemplateBuilder) WithNodeSelector(selector map[string]string) *PodTemplateBuilder {
+	for k, v := range selector {
+		if _, exists := b.PodTemplate.Spec.NodeSelector[k]; exists {
+			continue
+		}
+		if b.PodTemplate.Spec.NodeSelector == nil {
+			b.PodTemplate.Spec.NodeSelector = make(map[string]string, len(selector))
+		}
+		b.PodTemplate.Spec.NodeSelector[k] = v
+	}
+	return b
+}
+
 // portExists checks if a port with the given name already exists in the Container.
 func (b *PodTemplateBuilder) portExists(name string) bool {
 	for _, p := range b.Container.Ports {
@@ -228,6 +251,22 @@ func (b *PodTemplateBuilder) WithTerminationGracePeriod(period int64) *PodTempla
 	return b
 }
 
+// WithPriorityClassName sets the given priority class name if not already specified in the template.
+func (b *PodTemplateBuilder) WithPriorityClassName(name string) *PodTemplateBuilder {
+	if b.PodTemplate.Spec.PriorityClassName == "" {
+		b.PodTemplate.Spec.PriorityClassName = name
+	}
+	return b
+}
+
+// WithRuntimeClassName sets the given runtime class name if not empty and not already specified in the template.
+func (b *PodTemplateBuilder) WithRuntimeClassName(name string) *PodTemplateBuilder {
+	if b.PodTemplate.Spec.RuntimeClassName == nil && name != "" {
+		b.PodTemplate.Spec.RuntimeClassName = &name
+	}
+	return b
+}
+
 // findVolumeMountByNameOrMountPath attempts to find a volume mount with the given name or mount path in the mounts
 // Returns the index of the volume mount or -1 if no volume mount by that name was found.
 func (b *PodTemplateBuilder) findVolumeMountByNameOrMountPath(
@@ -314,6 +353,68 @@ func (b *PodTemplateBuilder) WithInitContainers(initContainers ...corev1.Contain
 	return b
 }
 
+// findContainerByName attempts to find a container with the given name in the template
+// Returns the index of the container or -1 if no container by that name was found.
+func (b *PodTemplateBuilder) findContainerByName(name string) int {
+	for i, c := range b.PodTemplate.Spec.Containers {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// WithSidecars includes the given sidecar containers to the pod template, after the main container.
+//
+// Defaults:
+// - If a container by the same name already exists in the template, it is kept and inherits the image, command,
+//   arguments and resources of the provided sidecar if it does not specify them.
+// - Env and VolumeMounts of the provided sidecar are added to the ones of the container in the template, unless
+//   they would conflict by name (or mount path for VolumeMounts).
+func (b *PodTemplateBuilder) WithSidecars(sidecars ...corev1.Container) *PodTemplateBuilder {
+	for _, sidecar := range sidecars {
+		index := b.findContainerByName(sidecar.Name)
+		if index == -1 {
+			b.PodTemplate.Spec.Containers = append(b.PodTemplate.Spec.Containers, sidecar)
+			continue
+		}
+		c := &b.PodTemplate.Spec.Containers[index]
+		if c.Image == "" {
+			c.Image = sidecar.Image
+		}
+		if len(c.Command) == 0 && len(c.Args) == 0 {
+			c.Command = sidecar.Command
+			c.Args = sidecar.Args
+		}
+		if c.Resources.Requests == nil && c.Resources.Limits == nil {
+			c.Resources = sidecar.Resources
+		}
+		for _, env := range sidecar.Env {
+			if !envExistsIn(env.Name, c.Env) {
+				c.Env = append(c.Env, env)
+			}
+		}
+		for _, vm := range sidecar.VolumeMounts {
+			if b.findVolumeMountByNameOrMountPath(vm, c.VolumeMounts) == -1 {
+				c.VolumeMounts = append(c.VolumeMounts, vm)
+			}
+		}
+	}
+	// appending containers may have moved the main container in memory
+	b.Container = &b.PodTemplate.Spec.Containers[b.findContainerByName(b.containerName)]
+	return b
+}
+
+// envExistsIn checks if an env var with the given name exists in the given env vars.
+func envExistsIn(name string, vars []corev1.EnvVar) bool {
+	for _, v := range vars {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // WithResources sets up the given resource requirements if both resources limits and requests
 // are nil in the main container.
 // If a zero-value (empty map) for at least one of limits or request is provided, the given resource requirements
@@ -337,3 +438,17 @@ func (b *PodTemplateBuilder) WithPreStopHook(handler corev1.Handler) *PodTemplat
 
 	return b
 }
+
+// WithPostStartHook sets a post-start hook, unless already provided in the template.
+func (b *PodTemplateBuilder) WithPostStartHook(handler corev1.Handler) *PodTemplateBuilder {
+	if b.Container.Lifecycle == nil {
+		b.Container.Lifecycle = &corev1.Lifecycle{}
+	}
+
+	if b.Container.Lifecycle.PostStart == nil {
+		// no user-provided hook, we can use our own
+		b.Container.Lifecycle.PostStart = &handler
+	}
+
+	return b
+}
@@ -314,6 +314,95 @@ func TestPodTemplateBuilder_WithAffinity(t *testing.T) {
 	}
 }
 
+func TestPodTemplateBuilder_WithTopologySpreadConstraints(t *testing.T) {
+	defaultConstraints := []corev1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+	}
+	userConstraints := []corev1.TopologySpreadConstraint{
+		{MaxSkew: 2, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.DoNotSchedule},
+	}
+
+	containerName := "mycontainer"
+	tests := []struct {
+		name        string
+		PodTemplate corev1.PodTemplateSpec
+		constraints []corev1.TopologySpreadConstraint
+		want        []corev1.TopologySpreadConstraint
+	}{
+		{
+			name:        "set default constraints",
+			PodTemplate: corev1.PodTemplateSpec{},
+			constraints: defaultConstraints,
+			want:        defaultConstraints,
+		},
+		{
+			name:        "no default constraints",
+			PodTemplate: corev1.PodTemplateSpec{},
+			constraints: nil,
+			want:        nil,
+		},
+		{
+			name: "don't override user-provided constraints",
+			PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					TopologySpreadConstraints: userConstraints,
+				},
+			},
+			constraints: defaultConstraints,
+			want:        userConstraints,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewPodTemplateBuilder(tt.PodTemplate, containerName)
+			if got := b.WithTopologySpreadConstraints(tt.constraints...).PodTemplate.Spec.TopologySpreadConstraints; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PodTemplateBuilder.WithTopologySpreadConstraints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodTemplateBuilder_WithNodeSelector(t *testing.T) {
+	containerName := "mycontainer"
+	tests := []struct {
+		name        string
+		PodTemplate corev1.PodTemplateSpec
+		selector    map[string]string
+		want        map[string]string
+	}{
+		{
+			name:        "set default node selector",
+			PodTemplate: corev1.PodTemplateSpec{},
+			selector:    map[string]string{"kubernetes.io/arch": "arm64"},
+			want:        map[string]string{"kubernetes.io/arch": "arm64"},
+		},
+		{
+			name:        "no default node selector",
+			PodTemplate: corev1.PodTemplateSpec{},
+			selector:    nil,
+			want:        nil,
+		},
+		{
+			name: "merge with user-provided node selector, which takes precedence",
+			PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{"kubernetes.io/arch": "amd64", "disktype": "ssd"},
+				},
+			},
+			selector: map[string]string{"kubernetes.io/arch": "arm64", "kubernetes.io/os": "linux"},
+			want:     map[string]string{"kubernetes.io/arch": "amd64", "kubernetes.io/os": "linux", "disktype": "ssd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewPodTemplateBuilder(tt.PodTemplate, containerName)
+			if got := b.WithNodeSelector(tt.selector).PodTemplate.Spec.NodeSelector; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PodTemplateBuilder.WithNodeSelector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPodTemplateBuilder_WithPorts(t *testing.T) {
 	containerName := "mycontainer"
 	tests := []struct {
@@ -699,6 +788,79 @@ func TestPodTemplateBuilder_WithTerminationGracePeriod(t *testing.T) {
 	}
 }
 
+func TestPodTemplateBuilder_WithPriorityClassName(t *testing.T) {
+	tests := []struct {
+		name        string
+		PodTemplate corev1.PodTemplateSpec
+		want        string
+	}{
+		{
+			name:        "set default",
+			PodTemplate: corev1.PodTemplateSpec{},
+			want:        "default",
+		},
+		{
+			name: "don't override user-specified value",
+			PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					PriorityClassName: "user",
+				},
+			},
+			want: "user",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewPodTemplateBuilder(tt.PodTemplate, "")
+			if got := b.WithPriorityClassName("default").PodTemplate.Spec.PriorityClassName; got != tt.want {
+				t.Errorf("PodTemplateBuilder.WithPriorityClassName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodTemplateBuilder_WithRuntimeClassName(t *testing.T) {
+	kata := "kata"
+	userRuntimeClass := "user"
+	tests := []struct {
+		name         string
+		PodTemplate  corev1.PodTemplateSpec
+		runtimeClass string
+		want         *string
+	}{
+		{
+			name:         "no runtime class",
+			PodTemplate:  corev1.PodTemplateSpec{},
+			runtimeClass: "",
+			want:         nil,
+		},
+		{
+			name:         "set runtime class",
+			PodTemplate:  corev1.PodTemplateSpec{},
+			runtimeClass: "kata",
+			want:         &kata,
+		},
+		{
+			name: "don't override user-specified value",
+			PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RuntimeClassName: &userRuntimeClass,
+				},
+			},
+			runtimeClass: "kata",
+			want:         &userRuntimeClass,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewPodTemplateBuilder(tt.PodTemplate, "")
+			if got := b.WithRuntimeClassName(tt.runtimeClass).PodTemplate.Spec.RuntimeClassName; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PodTemplateBuilder.WithRuntimeClassName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPodTemplateBuilder_WithInitContainerDefaults(t *testing.T) {
 	defaultVolumeMount := corev1.VolumeMount{
 		Name:      "default-volume-mount",
@@ -907,6 +1069,82 @@ func TestPodTemplateBuilder_WithInitContainers(t *testing.T) {
 	}
 }
 
+func TestPodTemplateBuilder_WithSidecars(t *testing.T) {
+	sidecar := corev1.Container{
+		Name:         "sidecar",
+		Image:        "sidecar-image",
+		Args:         []string{"-e"},
+		Env:          []corev1.EnvVar{{Name: "A", Value: "sidecar"}, {Name: "B", Value: "sidecar"}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs"}},
+	}
+	tests := []struct {
+		name        string
+		PodTemplate corev1.PodTemplateSpec
+		want        []corev1.Container
+	}{
+		{
+			name:        "append the sidecar after the main container",
+			PodTemplate: corev1.PodTemplateSpec{},
+			want:        []corev1.Container{{Name: "main"}, sidecar},
+		},
+		{
+			name: "user-provided sidecar inherits unspecified fields",
+			PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "sidecar",
+							Env:  []corev1.EnvVar{{Name: "A", Value: "user"}},
+						},
+					},
+				},
+			},
+			want: []corev1.Container{
+				{
+					Name:         "sidecar",
+					Image:        "sidecar-image",
+					Args:         []string{"-e"},
+					Env:          []corev1.EnvVar{{Name: "A", Value: "user"}, {Name: "B", Value: "sidecar"}},
+					VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/logs"}},
+				},
+				{Name: "main"},
+			},
+		},
+		{
+			name: "don't override user-provided image and args",
+			PodTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "main"},
+						{Name: "sidecar", Image: "user-image", Args: []string{"-v"}},
+					},
+				},
+			},
+			want: []corev1.Container{
+				{Name: "main"},
+				{
+					Name:         "sidecar",
+					Image:        "user-image",
+					Args:         []string{"-v"},
+					Env:          sidecar.Env,
+					VolumeMounts: sidecar.VolumeMounts,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewPodTemplateBuilder(tt.PodTemplate, "main")
+
+			got := b.WithSidecars(sidecar).PodTemplate.Spec.Containers
+
+			require.Equal(t, tt.want, got)
+			// the main container is still referenced by the builder
+			require.Same(t, &got[b.findContainerByName("main")], b.Container)
+		})
+	}
+}
+
 func TestPodTemplateBuilder_WithDefaultResources(t *testing.T) {
 	containerName := "default-container"
 	tests := []struct {
@@ -1132,3 +1370,64 @@ func TestPodTemplateBuilder_WithPreStopHook(t *testing.T) {
 		})
 	}
 }
+
+func TestPodTemplateBuilder_WithPostStartHook(t *testing.T) {
+	containerName := "mycontainer"
+	defaultHook := corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"default", "command"}}}
+	userHook := &corev1.Handler{}
+	tests := []struct {
+		name          string
+		podTemplate   corev1.PodTemplateSpec
+		wantPostStart corev1.Handler
+		wantPreStop   *corev1.Handler
+	}{
+		{
+			name:          "no post start hook in pod template: use default one",
+			podTemplate:   corev1.PodTemplateSpec{},
+			wantPostStart: defaultHook,
+			wantPreStop:   nil,
+		},
+		{
+			name: "user provided pre stop hook, but no post start hook in pod template: use default one",
+			podTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:      containerName,
+							Lifecycle: &corev1.Lifecycle{PreStop: userHook},
+						},
+					},
+				},
+			},
+			wantPostStart: defaultHook,
+			wantPreStop:   userHook,
+		},
+		{
+			name: "post start hook in pod template: use provided one",
+			podTemplate: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:      containerName,
+							Lifecycle: &corev1.Lifecycle{PostStart: userHook},
+						},
+					},
+				},
+			},
+			wantPostStart: *userHook,
+			wantPreStop:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewPodTemplateBuilder(tt.podTemplate, containerName)
+			got := b.WithPostStartHook(defaultHook).Container.Lifecycle
+			if !reflect.DeepEqual(got.PostStart, &tt.wantPostStart) {
+				t.Errorf("PostStart after PodTemplateBuilder.WithPostStartHook() = %v, want %v", got.PostStart, tt.wantPostStart)
+			}
+			if !reflect.DeepEqual(got.PreStop, tt.wantPreStop) {
+				t.Errorf("PreStop after PodTemplateBuilder.WithPostStartHook() = %v, want %v", got.PreStop, tt.wantPreStop)
+			}
+		})
+	}
+}
@@ -19,6 +19,9 @@ var (
 	defaultRevisionHistoryLimit int32
 )
 
+// VersionLabelName is the label of a Deployment holding the version of the Elastic Stack application its Pods run.
+const VersionLabelName = "common.k8s.elastic.co/version"
+
 // Params to specify a Deployment specification.
 type Params struct {
 	Name            string
@@ -28,15 +31,25 @@ type Params struct {
 	PodTemplateSpec corev1.PodTemplateSpec
 	Replicas        int32
 	Strategy        appsv1.DeploymentStrategyType
+	// Version of the Elastic Stack application, set as the VersionLabelName label of the Deployment.
+	Version string
 }
 
 // New creates a Deployment from the given params.
 func New(params Params) appsv1.Deployment {
+	labels := params.Labels
+	if params.Version != "" {
+		labels = make(map[string]string, len(params.Labels)+1)
+		for k, v := range params.Labels {
+			labels[k] = v
+		}
+		labels[VersionLabelName] = params.Version
+	}
 	return appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      params.Name,
 			Namespace: params.Namespace,
-			Labels:    params.Labels,
+			Labels:    labels,
 		},
 		Spec: appsv1.DeploymentSpec{
 			RevisionHistoryLimit: pointer.Int32(defaultRevisionHistoryLimit),
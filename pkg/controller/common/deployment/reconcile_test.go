@@ -19,6 +19,20 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/utils/pointer"
 )
 
+func TestNew(t *testing.T) {
+	params := Params{
+		Name:      "dep",
+		Namespace: "ns",
+		Labels:    map[string]string{"a": "b"},
+	}
+	require.Equal(t, map[string]string{"a": "b"}, New(params).Labels)
+
+	params.Version = "7.6.0"
+	require.Equal(t, map[string]string{"a": "b", VersionLabelName: "7.6.0"}, New(params).Labels)
+	// the labels of the parameters are left untouched
+	require.Equal(t, map[string]string{"a": "b"}, params.Labels)
+}
+
 func TestWithTemplateHash(t *testing.T) {
 	d := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
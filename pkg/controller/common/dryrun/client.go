@@ -0,0 +1,161 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dryrun
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Actions recorded for the mutations of Kubernetes objects.
+const (
+	CreateAction       = "create"
+	UpdateAction       = "update"
+	DeleteAction       = "delete"
+	StatusUpdateAction = "update status of"
+)
+
+// NewClient returns a client recording the mutations in the given Changes instead of performing them.
+// The objects written are returned by subsequent gets, so that a reconciliation can proceed as if the mutations were
+// applied, but they are not listed.
+func NewClient(c client.Client, scheme *runtime.Scheme, changes *Changes) client.Client {
+	return &dryRunClient{
+		Client:  c,
+		scheme:  scheme,
+		changes: changes,
+		written: map[objectKey]runtime.Object{},
+	}
+}
+
+type objectKey struct {
+	schema.GroupVersionKind
+	types.NamespacedName
+}
+
+type dryRunClient struct {
+	client.Client
+	scheme  *runtime.Scheme
+	changes *Changes
+
+	mutex sync.RWMutex
+	// written holds the objects written during the dry run, nil for the deleted ones
+	written map[objectKey]runtime.Object
+}
+
+var _ client.Client = &dryRunClient{}
+
+func (d *dryRunClient) key(obj runtime.Object) (objectKey, bool) {
+	gvk, err := apiutil.GVKForObject(obj, d.scheme)
+	if err != nil {
+		return objectKey{}, false
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return objectKey{}, false
+	}
+	return objectKey{GroupVersionKind: gvk, NamespacedName: types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}}, true
+}
+
+// record records the given mutation of the given object, and the object as written unless deleted.
+func (d *dryRunClient) record(action string, obj runtime.Object, deleted bool) {
+	key, ok := d.key(obj)
+	if !ok {
+		d.changes.RecordObject(action, reflect.TypeOf(obj).String(), "")
+		return
+	}
+	d.changes.RecordObject(action, key.Kind, key.Name)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if deleted {
+		d.written[key] = nil
+	} else {
+		d.written[key] = obj.DeepCopyObject()
+	}
+}
+
+// Get returns the object written during the dry run if any, or the existing one.
+func (d *dryRunClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if gvk, err := apiutil.GVKForObject(obj, d.scheme); err == nil {
+		d.mutex.RLock()
+		written, exists := d.written[objectKey{GroupVersionKind: gvk, NamespacedName: key}]
+		d.mutex.RUnlock()
+		if exists {
+			if written == nil {
+				return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name)
+			}
+			target := reflect.ValueOf(obj)
+			source := reflect.ValueOf(written.DeepCopyObject())
+			if target.Kind() == reflect.Ptr && target.Type() == source.Type() {
+				target.Elem().Set(source.Elem())
+				return nil
+			}
+		}
+	}
+	return d.Client.Get(ctx, key, obj)
+}
+
+// Create records the creation of the given object.
+func (d *dryRunClient) Create(_ context.Context, obj runtime.Object, _ ...client.CreateOption) error {
+	d.record(CreateAction, obj, false)
+	return nil
+}
+
+// Update records the update of the given object.
+func (d *dryRunClient) Update(_ context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+	d.record(UpdateAction, obj, false)
+	return nil
+}
+
+// Patch records the patch of the given object, which is not modified.
+func (d *dryRunClient) Patch(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+	d.record(UpdateAction, obj, false)
+	return nil
+}
+
+// Delete records the deletion of the given object.
+func (d *dryRunClient) Delete(_ context.Context, obj runtime.Object, _ ...client.DeleteOption) error {
+	d.record(DeleteAction, obj, true)
+	return nil
+}
+
+// DeleteAllOf records the deletion of the objects of the given type.
+func (d *dryRunClient) DeleteAllOf(_ context.Context, obj runtime.Object, _ ...client.DeleteAllOfOption) error {
+	kind := reflect.TypeOf(obj).String()
+	if gvk, err := apiutil.GVKForObject(obj, d.scheme); err == nil {
+		kind = gvk.Kind
+	}
+	d.changes.RecordObject(DeleteAction, "all", kind)
+	return nil
+}
+
+// Status returns a status writer recording the status updates.
+func (d *dryRunClient) Status() client.StatusWriter {
+	return dryRunStatusWriter{d: d}
+}
+
+type dryRunStatusWriter struct {
+	d *dryRunClient
+}
+
+// Update records the status update of the given object.
+func (s dryRunStatusWriter) Update(_ context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+	s.d.record(StatusUpdateAction, obj, false)
+	return nil
+}
+
+// Patch records the status patch of the given object.
+func (s dryRunStatusWriter) Patch(_ context.Context, obj runtime.Object, _ client.Patch, _ ...client.PatchOption) error {
+	s.d.record(StatusUpdateAction, obj, false)
+	return nil
+}
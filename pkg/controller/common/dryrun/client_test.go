@@ -0,0 +1,58 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dryrun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClient(t *testing.T) {
+	existing := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-default-0"}}
+	actual := fake.NewFakeClientWithScheme(scheme.Scheme, existing)
+	changes := NewChanges()
+	c := NewClient(actual, scheme.Scheme, changes)
+	ctx := context.Background()
+
+	// creations are recorded and returned by gets, but not applied
+	created := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cm"}, Data: map[string]string{"a": "1"}}
+	require.NoError(t, c.Create(ctx, created))
+	var cm corev1.ConfigMap
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "cm"}, &cm))
+	require.Equal(t, created.Data, cm.Data)
+	require.True(t, apierrors.IsNotFound(actual.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "cm"}, &cm)))
+
+	// deletions are recorded, and the deleted objects are not returned anymore
+	require.NoError(t, c.Delete(ctx, existing))
+	var pod corev1.Pod
+	require.True(t, apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "es-default-0"}, &pod)))
+	require.NoError(t, actual.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "es-default-0"}, &pod))
+
+	// updates are recorded but not applied
+	pod.Labels = map[string]string{"a": "b"}
+	require.NoError(t, c.Update(ctx, &pod))
+	require.NoError(t, c.Status().Update(ctx, &pod))
+	require.NoError(t, c.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace("ns")))
+	var actualPod corev1.Pod
+	require.NoError(t, actual.Get(ctx, types.NamespacedName{Namespace: "ns", Name: "es-default-0"}, &actualPod))
+	require.Empty(t, actualPod.Labels)
+
+	require.Equal(t, []string{
+		"create ConfigMap cm",
+		"delete Pod es-default-0",
+		"update Pod es-default-0",
+		"update status of Pod es-default-0",
+		"delete all Secret",
+	}, changes.List())
+}
@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dryrun
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// AnnotationName is the annotation making the operator compute the changes to a resource without applying them.
+	AnnotationName = "common.k8s.elastic.co/dry-run"
+	// maxSummaryChanges is the maximum number of changes listed in a summary, to keep events short.
+	maxSummaryChanges = 20
+)
+
+var log = logf.Log.WithName("dry-run")
+
+// IsEnabled returns true if the dry-run annotation of the given resource is set to true.
+func IsEnabled(meta metav1.ObjectMeta) bool {
+	value, exists := meta.Annotations[AnnotationName]
+	if !exists {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Error(err, "Cannot parse the dry-run annotation as a bool, defaulting to false",
+			"namespace", meta.Namespace, "name", meta.Name, "value", value)
+		return false
+	}
+	return enabled
+}
+
+// Changes are the changes a reconciliation would perform, in the order they were computed.
+type Changes struct {
+	mutex   sync.Mutex
+	changes []string
+	seen    map[string]bool
+}
+
+// NewChanges returns empty Changes.
+func NewChanges() *Changes {
+	return &Changes{seen: map[string]bool{}}
+}
+
+// add records the given change, unless it was already recorded.
+func (c *Changes) add(change string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.seen[change] {
+		return
+	}
+	c.seen[change] = true
+	c.changes = append(c.changes, change)
+}
+
+// RecordObject records a change to a Kubernetes object, such as "delete Pod es-default-2".
+func (c *Changes) RecordObject(action, kind, name string) {
+	c.add(fmt.Sprintf("%s %s %s", action, kind, name))
+}
+
+// RecordRequest records a state-changing call to the Elasticsearch API, such as "PUT /_cluster/settings".
+func (c *Changes) RecordRequest(method, pathWithQuery string, _ []byte) {
+	c.add(fmt.Sprintf("%s %s", method, pathWithQuery))
+}
+
+// List returns the recorded changes.
+func (c *Changes) List() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.changes...)
+}
+
+// Summary returns a human-readable summary of the recorded changes.
+func (c *Changes) Summary() string {
+	changes := c.List()
+	if len(changes) == 0 {
+		return "Dry run: no changes to apply"
+	}
+	listed := changes
+	if len(listed) > maxSummaryChanges {
+		listed = listed[:maxSummaryChanges]
+	}
+	summary := fmt.Sprintf("Dry run: %d changes to apply: %s", len(changes), strings.Join(listed, "; "))
+	if len(changes) > len(listed) {
+		summary += fmt.Sprintf("; and %d more", len(changes)-len(listed))
+	}
+	return summary
+}
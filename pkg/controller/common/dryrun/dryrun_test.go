@@ -0,0 +1,48 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dryrun
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsEnabled(t *testing.T) {
+	for annotations, want := range map[string]bool{
+		"":      false,
+		"true":  true,
+		"1":     true,
+		"false": false,
+		"XXXX":  false,
+	} {
+		meta := metav1.ObjectMeta{}
+		if annotations != "" {
+			meta.Annotations = map[string]string{AnnotationName: annotations}
+		}
+		require.Equal(t, want, IsEnabled(meta), annotations)
+	}
+}
+
+func TestChanges_Summary(t *testing.T) {
+	changes := NewChanges()
+	require.Equal(t, "Dry run: no changes to apply", changes.Summary())
+
+	changes.RecordObject(DeleteAction, "Pod", "es-default-2")
+	changes.RecordRequest("PUT", "/_cluster/settings", []byte(`{}`))
+	// changes are only listed once
+	changes.RecordRequest("PUT", "/_cluster/settings", []byte(`{}`))
+	require.Equal(t, []string{"delete Pod es-default-2", "PUT /_cluster/settings"}, changes.List())
+	require.Equal(t, "Dry run: 2 changes to apply: delete Pod es-default-2; PUT /_cluster/settings", changes.Summary())
+
+	// long summaries are truncated
+	for i := 0; i < maxSummaryChanges; i++ {
+		changes.RecordObject(UpdateAction, "PersistentVolumeClaim", fmt.Sprintf("data-%d", i))
+	}
+	require.Contains(t, changes.Summary(), "Dry run: 22 changes to apply: ")
+	require.Contains(t, changes.Summary(), "update PersistentVolumeClaim data-17; and 2 more")
+}
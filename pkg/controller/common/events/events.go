@@ -22,6 +22,12 @@ const (
 	EventReasonStateChange = "StateChange"
 	// EventReasonRestart describes events where one or multiple Elasticsearch nodes are scheduled for a restart.
 	EventReasonRestart = "Restart"
+	// EventReasonConfigurationDrift describes events where the configuration of a resource drifted from its declared state.
+	EventReasonConfigurationDrift = "ConfigurationDrift"
+	// EventReasonDryRun describes events summarizing the changes a dry-run reconciliation would apply.
+	EventReasonDryRun = "DryRun"
+	// EventReasonAdopted describes events where existing resources were adopted by the operator.
+	EventReasonAdopted = "Adopted"
 )
 
 // Event reasons for Association controllers
@@ -38,6 +44,10 @@ const (
 	EventReconciliationError = "ReconciliationError"
 	// EventCompatCheckError describes an error during the check for compatibility between operator version and managed resources.
 	EventCompatCheckError = "CompatibilityCheckError"
+	// EventDiagnosticsError describes an error during the generation of a diagnostics bundle.
+	EventDiagnosticsError = "DiagnosticsError"
+	// EventClassError describes an error while applying the ElasticsearchClass referenced by a resource.
+	EventClassError = "ClassError"
 )
 
 // Event is a k8s event that can be recorded via an event recorder.
@@ -48,9 +48,13 @@ Things that could happen without this mechanism in place:
 
 ## What if the operator restarts?
 
-All in-memory expectations are lost if the operator restarts. This is fine, because the operator re-populates its cache
-with the current resources in the apiserver. These resources do take into account any create/update/delete operation
-that was performed before the operator restarted.
+All in-memory expectations are lost if the operator restarts. This is mostly fine, because the operator re-populates
+its cache with the current resources in the apiserver. These resources do take into account any create/update/delete
+operation that was performed before the operator restarted.
+To avoid considering Pods that are still terminating as running members of the cluster, the expectations that are not
+satisfied yet are persisted in annotations of the StatefulSets they relate to at the end of each reconciliation, and
+when the operator is stopped gracefully. They are restored by the next operator instance, which resumes the
+orchestration without waiting for a full cycle to observe them again.
 
 ## Don't we need that for... basically everything?
 
@@ -78,6 +82,8 @@ technically less efficient.
 type Expectations struct {
 	*ExpectedStatefulSetUpdates
 	*ExpectedPodDeletions
+	// restored is true once the expectations persisted by a previous operator instance were restored
+	restored bool
 }
 
 // NewExpectations returns an initialized Expectations.
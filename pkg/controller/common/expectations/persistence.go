@@ -0,0 +1,186 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package expectations
+
+import (
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// ExpectedGenerationAnnotation holds the generation of the StatefulSet that was expected in the cache when the
+	// operator stopped.
+	ExpectedGenerationAnnotation = "common.k8s.elastic.co/expected-generation"
+	// ExpectedPodDeletionsAnnotation holds the Pods of the StatefulSet whose deletion was expected when the operator
+	// stopped, as a JSON map of Pod names to UIDs.
+	ExpectedPodDeletionsAnnotation = "common.k8s.elastic.co/expected-pod-deletions"
+)
+
+var log = logf.Log.WithName("expectations")
+
+type persistedGeneration struct {
+	UID        types.UID `json:"uid"`
+	Generation int64     `json:"generation"`
+}
+
+// pendingExpectations are the expectations related to a single StatefulSet.
+type pendingExpectations struct {
+	generation   *persistedGeneration
+	podDeletions map[string]types.UID
+}
+
+// Persist stores the expectations of all clusters not satisfied yet in annotations of the StatefulSets they relate
+// to, so that the next operator instance resumes from them. It must not be called while reconciliations are running,
+// since the per-cluster Expectations are not thread-safe.
+func (c *ClustersExpectation) Persist() error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for cluster, expectations := range c.clusters {
+		// clear the satisfied expectations first
+		if _, err := expectations.Satisfied(); err != nil {
+			return err
+		}
+		pending, err := expectations.pending(c.client)
+		if err != nil {
+			return err
+		}
+		for statefulSet, p := range pending {
+			log.Info("Persisting pending expectations", "namespace", cluster.Namespace, "es_name", cluster.Name, "statefulset_name", statefulSet.Name)
+			var sset appsv1.StatefulSet
+			if err := c.client.Get(statefulSet, &sset); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			if err := persist(c.client, sset, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PersistTo stores the expectations not cleared yet in annotations of the given StatefulSets of the cluster, and
+// removes the annotations of the StatefulSets without pending expectations. Annotations are only patched if they
+// changed.
+func (e *Expectations) PersistTo(c k8s.Client, statefulSets []appsv1.StatefulSet) error {
+	pending, err := e.pending(c)
+	if err != nil {
+		return err
+	}
+	for _, sset := range statefulSets {
+		if err := persist(c, sset, pending[k8s.ExtractNamespacedName(&sset)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pending groups the expectations not cleared yet by StatefulSet.
+func (e *Expectations) pending(c k8s.Client) (map[types.NamespacedName]*pendingExpectations, error) {
+	pending := make(map[types.NamespacedName]*pendingExpectations)
+	forStatefulSet := func(statefulSet types.NamespacedName) *pendingExpectations {
+		if _, exists := pending[statefulSet]; !exists {
+			pending[statefulSet] = &pendingExpectations{podDeletions: map[string]types.UID{}}
+		}
+		return pending[statefulSet]
+	}
+	for statefulSet, generation := range e.generations {
+		forStatefulSet(statefulSet).generation = &persistedGeneration{UID: generation.UID, Generation: generation.Generation}
+	}
+	for pod, uid := range e.podDeletions {
+		var p corev1.Pod
+		if err := c.Get(pod, &p); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		owner := metav1.GetControllerOf(&p)
+		if owner == nil || owner.Kind != "StatefulSet" {
+			continue
+		}
+		forStatefulSet(types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}).podDeletions[pod.Name] = uid
+	}
+	return pending, nil
+}
+
+// persist patches the expectations annotations of the given StatefulSet if they changed. pending may be nil if the
+// StatefulSet has no pending expectations.
+func persist(c k8s.Client, sset appsv1.StatefulSet, pending *pendingExpectations) error {
+	annotations := map[string]string{}
+	if pending != nil && pending.generation != nil {
+		value, err := json.Marshal(pending.generation)
+		if err != nil {
+			return err
+		}
+		annotations[ExpectedGenerationAnnotation] = string(value)
+	}
+	if pending != nil && len(pending.podDeletions) > 0 {
+		value, err := json.Marshal(pending.podDeletions)
+		if err != nil {
+			return err
+		}
+		annotations[ExpectedPodDeletionsAnnotation] = string(value)
+	}
+	if annotations[ExpectedGenerationAnnotation] == sset.Annotations[ExpectedGenerationAnnotation] &&
+		annotations[ExpectedPodDeletionsAnnotation] == sset.Annotations[ExpectedPodDeletionsAnnotation] {
+		return nil
+	}
+
+	// patch the annotations only, since the cached StatefulSet may not be up-to-date
+	patched := sset.DeepCopy()
+	if patched.Annotations == nil {
+		patched.Annotations = map[string]string{}
+	}
+	for _, annotation := range []string{ExpectedGenerationAnnotation, ExpectedPodDeletionsAnnotation} {
+		if value, exists := annotations[annotation]; exists {
+			patched.Annotations[annotation] = value
+		} else {
+			delete(patched.Annotations, annotation)
+		}
+	}
+	return c.Patch(patched, client.MergeFrom(&sset))
+}
+
+// RestoreOnce registers the expectations persisted in annotations of the given StatefulSets by a previous operator
+// instance. It only has an effect the first time it is called. Restored expectations that are already satisfied are
+// cleared by the next check.
+func (e *Expectations) RestoreOnce(statefulSets []appsv1.StatefulSet) {
+	if e.restored {
+		return
+	}
+	e.restored = true
+	for _, sset := range statefulSets {
+		if value, exists := sset.Annotations[ExpectedGenerationAnnotation]; exists {
+			var generation persistedGeneration
+			if err := json.Unmarshal([]byte(value), &generation); err != nil {
+				log.Error(err, "Ignoring invalid expectations annotation", "namespace", sset.Namespace, "statefulset_name", sset.Name)
+			} else if _, registered := e.generations[k8s.ExtractNamespacedName(&sset)]; !registered {
+				e.generations[k8s.ExtractNamespacedName(&sset)] = ResourceGeneration{UID: generation.UID, Generation: generation.Generation}
+			}
+		}
+		if value, exists := sset.Annotations[ExpectedPodDeletionsAnnotation]; exists {
+			var podDeletions map[string]types.UID
+			if err := json.Unmarshal([]byte(value), &podDeletions); err != nil {
+				log.Error(err, "Ignoring invalid expectations annotation", "namespace", sset.Namespace, "statefulset_name", sset.Name)
+				continue
+			}
+			for pod, uid := range podDeletions {
+				e.podDeletions[types.NamespacedName{Namespace: sset.Namespace, Name: pod}] = uid
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package expectations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestClustersExpectation_Persist(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns", Name: "es"}
+	sset1 := newStatefulSet("sset1", uuid.NewUUID(), 3)
+	sset2 := newStatefulSet("sset2", uuid.NewUUID(), 5)
+	isController := true
+	pod := newPod("sset1-0", uuid.NewUUID())
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "StatefulSet", Name: "sset1", Controller: &isController}}
+	deletedPod := newPod("sset2-0", uuid.NewUUID())
+	c := k8s.WrappedFakeClient(&sset1, &sset2, &pod)
+
+	// the cache is not up-to-date with the next sset1 generation, and the sset1 Pod is being deleted
+	expectations := NewClustersExpectations(c)
+	updatedSset1 := newStatefulSet("sset1", sset1.UID, 4)
+	expectations.ForCluster(cluster).ExpectGeneration(updatedSset1)
+	expectations.ForCluster(cluster).ExpectDeletion(pod)
+	// sset2 generation and pod deletion are already satisfied
+	expectations.ForCluster(cluster).ExpectGeneration(sset2)
+	expectations.ForCluster(cluster).ExpectDeletion(deletedPod)
+	require.NoError(t, expectations.Persist())
+
+	var actual appsv1.StatefulSetList
+	require.NoError(t, c.List(&actual))
+	require.Len(t, actual.Items, 2)
+	for _, sset := range actual.Items {
+		if sset.Name == "sset1" {
+			require.Equal(t, map[string]string{
+				ExpectedGenerationAnnotation:   `{"uid":"` + string(sset1.UID) + `","generation":4}`,
+				ExpectedPodDeletionsAnnotation: `{"sset1-0":"` + string(pod.UID) + `"}`,
+			}, sset.Annotations)
+		} else {
+			require.Empty(t, sset.Annotations)
+		}
+	}
+
+	// a new operator instance resumes from the persisted expectations
+	restored := NewClustersExpectations(c).ForCluster(cluster)
+	restored.RestoreOnce(actual.Items)
+	require.Equal(t, map[types.NamespacedName]ResourceGeneration{
+		k8s.ExtractNamespacedName(&sset1): {UID: sset1.UID, Generation: 4},
+	}, restored.GetGenerations())
+	require.Equal(t, map[types.NamespacedName]types.UID{
+		k8s.ExtractNamespacedName(&pod): pod.UID,
+	}, restored.podDeletions)
+	satisfied, err := restored.Satisfied()
+	require.NoError(t, err)
+	require.False(t, satisfied)
+
+	// expectations are only restored once
+	restored.ExpectGeneration(newStatefulSet("sset1", sset1.UID, 3))
+	restored.RestoreOnce(actual.Items)
+	require.Equal(t, int64(3), restored.GetGenerations()[k8s.ExtractNamespacedName(&sset1)].Generation)
+}
+
+func TestExpectations_PersistTo(t *testing.T) {
+	sset := newStatefulSet("sset", uuid.NewUUID(), 3)
+	c := k8s.WrappedFakeClient(&sset)
+	getAnnotations := func() map[string]string {
+		var actual appsv1.StatefulSet
+		require.NoError(t, c.Get(k8s.ExtractNamespacedName(&sset), &actual))
+		return actual.Annotations
+	}
+
+	// pending expectations are persisted
+	expectations := NewExpectations(c)
+	expectations.ExpectGeneration(newStatefulSet("sset", sset.UID, 4))
+	require.NoError(t, expectations.PersistTo(c, []appsv1.StatefulSet{sset}))
+	require.Equal(t, map[string]string{
+		ExpectedGenerationAnnotation: `{"uid":"` + string(sset.UID) + `","generation":4}`,
+	}, getAnnotations())
+
+	// annotations are removed once the expectations are satisfied
+	var updated appsv1.StatefulSet
+	require.NoError(t, c.Get(k8s.ExtractNamespacedName(&sset), &updated))
+	updated.Generation = 4
+	require.NoError(t, c.Update(&updated))
+	satisfied, err := expectations.Satisfied()
+	require.NoError(t, err)
+	require.True(t, satisfied)
+	require.NoError(t, expectations.PersistTo(c, []appsv1.StatefulSet{updated}))
+	require.Empty(t, getAnnotations())
+}
@@ -0,0 +1,147 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package footprint
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/apmserver"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/audit"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/nodespec"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/pod"
+)
+
+// Footprint is the amount of resources the Pods of a resource consume.
+type Footprint struct {
+	Pods    int32
+	CPU     resource.Quantity
+	Memory  resource.Quantity
+	Storage resource.Quantity
+}
+
+func (f Footprint) String() string {
+	return fmt.Sprintf("%d Pods, %s CPU, %s of memory, %s of storage", f.Pods, f.CPU.String(), f.Memory.String(), f.Storage.String())
+}
+
+// add adds count Pods of the given footprint to this one.
+func (f *Footprint) add(pod Footprint, count int32) {
+	f.Pods += count
+	f.CPU.Add(multiply(pod.CPU, count))
+	f.Memory.Add(multiply(pod.Memory, count))
+	f.Storage.Add(multiply(pod.Storage, count))
+}
+
+// Estimate returns the footprint of the Pods the operator deploys for the given resource, including the containers
+// added by the user and the monitoring sidecars of the operator. Returns false if the kind of the resource is not
+// supported.
+func Estimate(obj runtime.Object) (Footprint, bool) {
+	var f Footprint
+	switch o := obj.(type) {
+	case *esv1.Elasticsearch:
+		for _, nodeSet := range o.Spec.NodeSets {
+			f.add(elasticsearchPod(*o, nodeSet), nodeSet.TotalCount())
+		}
+	case *kbv1.Kibana:
+		f.add(podFootprint(o.Spec.PodTemplate.Spec, kbv1.KibanaContainerName, pod.DefaultResources), o.Spec.Count)
+	case *apmv1.ApmServer:
+		f.add(podFootprint(o.Spec.PodTemplate.Spec, apmv1.ApmServerContainerName, apmserver.DefaultResources), o.Spec.Count)
+	case *entsv1beta1.EnterpriseSearch:
+		f.add(podFootprint(o.Spec.PodTemplate.Spec, entsv1beta1.EnterpriseSearchContainerName, enterprisesearch.DefaultResources), o.Spec.Count)
+	default:
+		return f, false
+	}
+	return f, true
+}
+
+// elasticsearchPod returns the footprint of a Pod of the given node set.
+func elasticsearchPod(es esv1.Elasticsearch, nodeSet esv1.NodeSet) Footprint {
+	spec := *nodeSet.PodTemplate.Spec.DeepCopy()
+	if es.Spec.Audit.ShippingEnabled() {
+		spec.Containers = withDefaultContainer(spec.Containers, audit.BeatContainerName, audit.DefaultBeatResources)
+	}
+	f := podFootprint(spec, esv1.ElasticsearchContainerName, nodespec.DefaultResources)
+	claims := nodeSet.VolumeClaimTemplates
+	if len(claims) == 0 {
+		// the default data volume claim is used
+		claims = volume.DefaultVolumeClaimTemplates
+	}
+	for _, claim := range claims {
+		if storage, exists := claim.Spec.Resources.Requests[corev1.ResourceStorage]; exists {
+			f.Storage.Add(storage)
+		}
+	}
+	return f
+}
+
+// podFootprint returns the CPU and memory consumed by a Pod with the given spec, where the main container gets
+// the default resources of the operator if it does not specify any.
+func podFootprint(spec corev1.PodSpec, mainContainer string, defaults corev1.ResourceRequirements) Footprint {
+	containers := withDefaultContainer(spec.Containers, mainContainer, defaults)
+	// as computed by the scheduler: the init containers run one after the other, before the containers
+	var f Footprint
+	for _, c := range containers {
+		f.CPU.Add(quantity(c.Resources, corev1.ResourceCPU))
+		f.Memory.Add(quantity(c.Resources, corev1.ResourceMemory))
+	}
+	for _, c := range spec.InitContainers {
+		if cpu := quantity(c.Resources, corev1.ResourceCPU); cpu.Cmp(f.CPU) > 0 {
+			f.CPU = cpu
+		}
+		if memory := quantity(c.Resources, corev1.ResourceMemory); memory.Cmp(f.Memory) > 0 {
+			f.Memory = memory
+		}
+	}
+	return f
+}
+
+// withDefaultContainer returns the given containers, with the container of the given name added or given the default
+// resources if it does not specify any.
+func withDefaultContainer(containers []corev1.Container, name string, defaults corev1.ResourceRequirements) []corev1.Container {
+	result := make([]corev1.Container, 0, len(containers)+1)
+	found := false
+	for _, c := range containers {
+		if c.Name == name {
+			found = true
+			if len(c.Resources.Limits) == 0 && len(c.Resources.Requests) == 0 {
+				c.Resources = defaults
+			}
+		}
+		result = append(result, c)
+	}
+	if !found {
+		result = append(result, corev1.Container{Name: name, Resources: defaults})
+	}
+	return result
+}
+
+// quantity returns the limit of the given resource, or its request if there is no limit.
+func quantity(resources corev1.ResourceRequirements, name corev1.ResourceName) resource.Quantity {
+	if q, exists := resources.Limits[name]; exists {
+		return q.DeepCopy()
+	}
+	if q, exists := resources.Requests[name]; exists {
+		return q.DeepCopy()
+	}
+	return resource.Quantity{}
+}
+
+func multiply(q resource.Quantity, count int32) resource.Quantity {
+	format := q.Format
+	if format == "" {
+		format = resource.DecimalSI
+	}
+	// milli-units to not lose the precision of CPU quantities
+	return *resource.NewMilliQuantity(q.MilliValue()*int64(count), format)
+}
@@ -0,0 +1,111 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package footprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+)
+
+func resources(cpu, memory string) corev1.ResourceRequirements {
+	limits := corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)}
+	if cpu != "" {
+		limits[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	return corev1.ResourceRequirements{Limits: limits}
+}
+
+func requireFootprint(t *testing.T, f Footprint, pods int32, cpu, memory, storage string) {
+	t.Helper()
+	require.Equal(t, pods, f.Pods)
+	require.Equal(t, 0, f.CPU.Cmp(resource.MustParse(cpu)), "CPU %s", f.CPU.String())
+	require.Equal(t, 0, f.Memory.Cmp(resource.MustParse(memory)), "memory %s", f.Memory.String())
+	require.Equal(t, 0, f.Storage.Cmp(resource.MustParse(storage)), "storage %s", f.Storage.String())
+}
+
+func TestEstimate(t *testing.T) {
+	es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+		{
+			Name:  "hot",
+			Count: 3,
+			PodTemplate: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: esv1.ElasticsearchContainerName, Resources: resources("2", "8Gi")},
+					{Name: "sidecar", Resources: resources("500m", "1Gi")},
+				},
+				// smaller than the containers: not counted
+				InitContainers: []corev1.Container{{Name: "init", Resources: resources("1", "1Gi")}},
+			}},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")}},
+			}}},
+		},
+		// default resources and volume claim
+		{Name: "default", Count: 2},
+	}}}
+	f, supported := Estimate(&es)
+	require.True(t, supported)
+	requireFootprint(t, f, 5, "7500m", "31Gi", "302Gi")
+
+	// the audit logs shipping sidecar is part of the footprint
+	es.Spec.Audit = &esv1.AuditLogging{Shipping: &esv1.AuditShipping{ElasticsearchRef: commonv1.ObjectSelector{Name: "monitoring"}}}
+	f, _ = Estimate(&es)
+	requireFootprint(t, f, 5, "8", "32744Mi", "302Gi")
+
+	// NodeSets spread across zones deploy their count of Pods in each zone
+	zoned := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+		{Name: "default", Count: 2, ZoneSpread: &esv1.ZoneSpread{Zones: []string{"a", "b", "c"}}},
+	}}}
+	f, _ = Estimate(&zoned)
+	requireFootprint(t, f, 6, "0", "12Gi", "6Gi")
+
+	f, supported = Estimate(&kbv1.Kibana{Spec: kbv1.KibanaSpec{Count: 2}})
+	require.True(t, supported)
+	requireFootprint(t, f, 2, "0", "2Gi", "0")
+
+	_, supported = Estimate(&corev1.Pod{})
+	require.False(t, supported)
+}
+
+func TestParse(t *testing.T) {
+	thresholds, err := Parse(map[string]string{
+		"thresholds.yml": `[{name: large, kinds: [Elasticsearch], action: Deny, maxPods: 30}, {name: big, maxMemory: 64Gi}]`,
+	})
+	require.NoError(t, err)
+	require.Len(t, thresholds, 2)
+	require.Equal(t, ActionDeny, thresholds[0].Action)
+	// warn by default
+	require.Equal(t, ActionWarn, thresholds[1].Action)
+
+	for _, invalid := range []string{
+		`[{maxPods: 3}]`,
+		`[{name: a}]`,
+		`[{name: a, action: Ignore, maxPods: 3}]`,
+		`{name: a}`,
+	} {
+		_, err := Parse(map[string]string{"thresholds.yml": invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestThreshold_Exceeded(t *testing.T) {
+	maxPods := int32(10)
+	maxCPU := resource.MustParse("16")
+	maxMemory := resource.MustParse("64Gi")
+	threshold := Threshold{Name: "large", MaxPods: &maxPods, MaxCPU: &maxCPU, MaxMemory: &maxMemory}
+
+	f := Footprint{Pods: 10, CPU: resource.MustParse("16"), Memory: resource.MustParse("64Gi")}
+	require.Empty(t, threshold.Exceeded(f))
+
+	f = Footprint{Pods: 12, CPU: resource.MustParse("24"), Memory: resource.MustParse("64Gi"), Storage: resource.MustParse("1Ti")}
+	require.Equal(t, []string{"large: 12 Pods, 10 allowed", "large: 24 of CPU, 16 allowed"}, threshold.Exceeded(f))
+}
@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package footprint
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/configmaps"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// WebhookPath is the path on which the footprint validating webhook is served.
+	WebhookPath = "/validate-footprint-k8s-elastic-co"
+	// ConfigMapType is the value of the type label identifying the ConfigMaps holding footprint thresholds.
+	ConfigMapType = "footprint-threshold"
+	// FootprintAuditAnnotation is the key of the audit annotation holding the estimated footprint of the resource.
+	FootprintAuditAnnotation = "footprint"
+	// WarningAuditAnnotation is the key of the audit annotation holding the exceeded thresholds with the Warn action.
+	WarningAuditAnnotation = "warning"
+)
+
+var log = logf.Log.WithName("footprint")
+
+// Handler is an admission handler estimating the footprint of the resources submitted to the webhook, and comparing
+// it to the thresholds declared in ConfigMaps of the operator namespace.
+type Handler struct {
+	client    k8s.Client
+	recorder  record.EventRecorder
+	namespace string
+}
+
+var _ admission.Handler = &Handler{}
+
+// NewHandler returns a Handler reading thresholds from the given namespace, and recording the exceeded thresholds
+// with the Warn action as events of the resources.
+func NewHandler(c k8s.Client, recorder record.EventRecorder, namespace string) *Handler {
+	return &Handler{client: c, recorder: recorder, namespace: namespace}
+}
+
+// Handle implements admission.Handler.
+func (h *Handler) Handle(_ context.Context, req admission.Request) admission.Response {
+	thresholds, err := h.thresholds(req.Kind.Kind)
+	if err != nil {
+		log.Error(err, "Failed to load footprint thresholds, skipping footprint estimation", "namespace", h.namespace)
+		return admission.Allowed("")
+	}
+	if len(thresholds) == 0 {
+		return admission.Allowed("")
+	}
+
+	obj := newObject(req.Kind.Kind)
+	if obj == nil {
+		return admission.Allowed("")
+	}
+	if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	f, _ := Estimate(obj)
+
+	var denied, warnings []string
+	for _, t := range thresholds {
+		exceeded := t.Exceeded(f)
+		if t.Action == ActionDeny {
+			denied = append(denied, exceeded...)
+		} else {
+			warnings = append(warnings, exceeded...)
+		}
+	}
+	if len(denied) > 0 {
+		log.V(1).Info("Resource rejected by footprint thresholds",
+			"kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name, "footprint", f.String(), "exceeded", denied)
+		return admission.Denied("estimated footprint of " + f.String() + " exceeds thresholds: " + strings.Join(denied, "; "))
+	}
+	resp := admission.Allowed("")
+	resp.AuditAnnotations = map[string]string{FootprintAuditAnnotation: f.String()}
+	if len(warnings) > 0 {
+		log.Info("Resource exceeds footprint thresholds",
+			"kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name, "footprint", f.String(), "exceeded", warnings)
+		resp.AuditAnnotations[WarningAuditAnnotation] = strings.Join(warnings, "; ")
+		h.warn(obj, req.Namespace, "Estimated footprint of "+f.String()+" exceeds thresholds: "+strings.Join(warnings, "; "))
+	}
+	return resp
+}
+
+// warn records a warning event with the given message for the given resource of the given namespace. The resource may
+// not have been created yet, in which case the event is only listed with the events of its namespace.
+func (h *Handler) warn(obj runtime.Object, namespace string, message string) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	if accessor.GetNamespace() == "" {
+		accessor.SetNamespace(namespace)
+	}
+	h.recorder.Event(obj, corev1.EventTypeWarning, events.EventReasonValidation, message)
+}
+
+// thresholds returns the thresholds declared in the operator namespace, applying to the given kind.
+func (h *Handler) thresholds(kind string) ([]Threshold, error) {
+	var thresholds []Threshold
+	err := configmaps.LoadTyped(h.client, h.namespace, ConfigMapType, func(cm corev1.ConfigMap) error {
+		t, err := Parse(cm.Data)
+		if err != nil {
+			return err
+		}
+		for _, threshold := range t {
+			if threshold.AppliesTo(kind) {
+				thresholds = append(thresholds, threshold)
+			}
+		}
+		return nil
+	})
+	return thresholds, err
+}
+
+// newObject returns an empty resource of the given kind, or nil if its footprint cannot be estimated.
+func newObject(kind string) runtime.Object {
+	switch kind {
+	case "Elasticsearch":
+		return &esv1.Elasticsearch{}
+	case "Kibana":
+		return &kbv1.Kibana{}
+	case "ApmServer":
+		return &apmv1.ApmServer{}
+	case "EnterpriseSearch":
+		return &entsv1beta1.EnterpriseSearch{}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package footprint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const esManifest = `{
+	"apiVersion": "elasticsearch.k8s.elastic.co/v1",
+	"kind": "Elasticsearch",
+	"metadata": {"name": "es", "namespace": "ns"},
+	"spec": {"nodeSets": [{"name": "default", "count": 100}]}
+}`
+
+func thresholdConfigMap(namespace, name, thresholds string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{common.TypeLabelName: ConfigMapType},
+		},
+		Data: map[string]string{"thresholds.yml": thresholds},
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Group: "elasticsearch.k8s.elastic.co", Version: "v1", Kind: "Elasticsearch"},
+		Object: runtime.RawExtension{Raw: []byte(esManifest)},
+	}}
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantAllowed bool
+		wantWarning string
+	}{
+		{
+			name:        "no thresholds",
+			wantAllowed: true,
+		},
+		{
+			name: "within thresholds",
+			objs: []runtime.Object{
+				thresholdConfigMap("elastic-system", "t", `[{name: t, action: Deny, maxPods: 100, maxMemory: 200Gi}]`),
+			},
+			wantAllowed: true,
+		},
+		{
+			name: "threshold exceeded with the warn action",
+			objs: []runtime.Object{
+				thresholdConfigMap("elastic-system", "t", `[{name: t, maxPods: 50}]`),
+			},
+			wantAllowed: true,
+			wantWarning: "t: 100 Pods, 50 allowed",
+		},
+		{
+			name: "threshold exceeded with the deny action",
+			objs: []runtime.Object{
+				thresholdConfigMap("elastic-system", "t", `[{name: t, action: Deny, maxStorage: 50Gi}]`),
+			},
+			wantAllowed: false,
+		},
+		{
+			name: "threshold of another kind",
+			objs: []runtime.Object{
+				thresholdConfigMap("elastic-system", "t", `[{name: t, kinds: [Kibana], action: Deny, maxPods: 1}]`),
+			},
+			wantAllowed: true,
+		},
+		{
+			name: "thresholds from another namespace are ignored",
+			objs: []runtime.Object{
+				thresholdConfigMap("default", "t", `[{name: t, action: Deny, maxPods: 1}]`),
+			},
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			h := NewHandler(k8s.WrappedFakeClient(tt.objs...), recorder, "elastic-system")
+			resp := h.Handle(context.Background(), req)
+			require.Equal(t, tt.wantAllowed, resp.Allowed)
+			require.Equal(t, tt.wantWarning, resp.AuditAnnotations[WarningAuditAnnotation])
+			// exceeded thresholds with the warn action are reported as events of the resource
+			if tt.wantWarning != "" {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, "Warning Validation Estimated footprint of")
+			} else {
+				require.Len(t, recorder.Events, 0)
+			}
+		})
+	}
+}
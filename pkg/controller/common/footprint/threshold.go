@@ -0,0 +1,131 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package footprint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Action is what the webhook does with the resources exceeding a Threshold.
+type Action string
+
+const (
+	// ActionWarn accepts the resource, and reports the exceeded threshold.
+	ActionWarn Action = "Warn"
+	// ActionDeny rejects the resource.
+	ActionDeny Action = "Deny"
+)
+
+// Threshold is a limit on the footprint of a single resource, declared by a cluster administrator.
+//
+// Example:
+//
+//   - name: large-clusters
+//     kinds: [Elasticsearch]
+//     action: Deny
+//     maxPods: 30
+//     maxCPU: 64
+//     maxMemory: 256Gi
+//     maxStorage: 20Ti
+type Threshold struct {
+	// Name identifies the threshold in messages.
+	Name string `json:"name"`
+	// Kinds the threshold applies to (eg. Elasticsearch, Kibana). Applies to all kinds if empty.
+	Kinds []string `json:"kinds,omitempty"`
+	// Action taken when the threshold is exceeded. Defaults to Warn.
+	Action Action `json:"action,omitempty"`
+	// MaxPods is the maximum number of Pods of the resource.
+	MaxPods *int32 `json:"maxPods,omitempty"`
+	// MaxCPU is the maximum CPU of the Pods of the resource.
+	MaxCPU *resource.Quantity `json:"maxCPU,omitempty"`
+	// MaxMemory is the maximum memory of the Pods of the resource.
+	MaxMemory *resource.Quantity `json:"maxMemory,omitempty"`
+	// MaxStorage is the maximum storage requested by the volume claims of the resource.
+	MaxStorage *resource.Quantity `json:"maxStorage,omitempty"`
+}
+
+// Parse decodes the thresholds declared in the given ConfigMap data.
+// Each entry must hold a YAML list of thresholds.
+func Parse(data map[string]string) ([]Threshold, error) {
+	// iterate in a stable order to get reproducible results
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var thresholds []Threshold
+	for _, k := range keys {
+		var entries []Threshold
+		if err := yaml.Unmarshal([]byte(data[k]), &entries); err != nil {
+			return nil, errors.Wrapf(err, "invalid footprint thresholds in %s", k)
+		}
+		for _, t := range entries {
+			if t.Action == "" {
+				t.Action = ActionWarn
+			}
+			if err := t.validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid footprint threshold %s in %s", t.Name, k)
+			}
+			thresholds = append(thresholds, t)
+		}
+	}
+	return thresholds, nil
+}
+
+func (t Threshold) validate() error {
+	if t.Name == "" {
+		return errors.New("name is required")
+	}
+	if t.Action != ActionWarn && t.Action != ActionDeny {
+		return errors.Errorf("unknown action %s, must be %s or %s", t.Action, ActionWarn, ActionDeny)
+	}
+	if t.MaxPods == nil && t.MaxCPU == nil && t.MaxMemory == nil && t.MaxStorage == nil {
+		return errors.New("at least one of maxPods, maxCPU, maxMemory or maxStorage is required")
+	}
+	return nil
+}
+
+// AppliesTo returns true if the threshold limits the resources of the given kind.
+func (t Threshold) AppliesTo(kind string) bool {
+	if len(t.Kinds) == 0 {
+		return true
+	}
+	for _, k := range t.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Exceeded returns the limits of the threshold exceeded by the given footprint.
+func (t Threshold) Exceeded(f Footprint) []string {
+	var exceeded []string
+	if t.MaxPods != nil && f.Pods > *t.MaxPods {
+		exceeded = append(exceeded, fmt.Sprintf("%s: %d Pods, %d allowed", t.Name, f.Pods, *t.MaxPods))
+	}
+	for _, limit := range []struct {
+		name     string
+		value    resource.Quantity
+		maxValue *resource.Quantity
+	}{
+		{name: "CPU", value: f.CPU, maxValue: t.MaxCPU},
+		{name: "memory", value: f.Memory, maxValue: t.MaxMemory},
+		{name: "storage", value: f.Storage, maxValue: t.MaxStorage},
+	} {
+		if limit.maxValue != nil && limit.value.Cmp(*limit.maxValue) > 0 {
+			exceeded = append(exceeded,
+				fmt.Sprintf("%s: %s of %s, %s allowed", t.Name, limit.value.String(), limit.name, limit.maxValue.String()))
+		}
+	}
+	return exceeded
+}
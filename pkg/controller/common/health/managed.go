@@ -0,0 +1,129 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// Kinds of the managed resources whose health is checked.
+const (
+	ElasticsearchKind    = "Elasticsearch"
+	KibanaKind           = "Kibana"
+	ApmServerKind        = "ApmServer"
+	EnterpriseSearchKind = "EnterpriseSearch"
+)
+
+// errorCounters count the managed resources of a kind in an error state.
+var errorCounters = map[string]func(c k8s.Client) (int, error){
+	ElasticsearchKind: func(c k8s.Client) (int, error) {
+		var list esv1.ElasticsearchList
+		if err := c.List(&list); err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, es := range list.Items {
+			if es.Status.Health == esv1.ElasticsearchRedHealth || es.Status.Phase == esv1.ElasticsearchResourceInvalid {
+				count++
+			}
+		}
+		return count, nil
+	},
+	KibanaKind: func(c k8s.Client) (int, error) {
+		var list kbv1.KibanaList
+		if err := c.List(&list); err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, kb := range list.Items {
+			if kb.Status.Health == kbv1.KibanaRed {
+				count++
+			}
+		}
+		return count, nil
+	},
+	ApmServerKind: func(c k8s.Client) (int, error) {
+		var list apmv1.ApmServerList
+		if err := c.List(&list); err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, as := range list.Items {
+			if as.Status.Health == apmv1.ApmServerRed {
+				count++
+			}
+		}
+		return count, nil
+	},
+	EnterpriseSearchKind: func(c k8s.Client) (int, error) {
+		var list entsv1beta1.EnterpriseSearchList
+		if err := c.List(&list); err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, ents := range list.Items {
+			if ents.Status.Health == entsv1beta1.EnterpriseSearchRed {
+				count++
+			}
+		}
+		return count, nil
+	},
+}
+
+// CountErrors returns the number of managed resources in an error state, for each of the given kinds which has some.
+// Elasticsearch clusters are in error if their health is red or their specification is invalid, other resources if
+// their health is red.
+func CountErrors(c k8s.Client, kinds []string) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, kind := range kinds {
+		counter, ok := errorCounters[kind]
+		if !ok {
+			return nil, errors.Errorf("unsupported kind %s", kind)
+		}
+		count, err := counter(c)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			counts[kind] = count
+		}
+	}
+	return counts, nil
+}
+
+// ManagedResourcesCheck returns a health check failing if some managed resources of the given kinds are in an error
+// state. The error summarizes the number of resources in error by kind, for example
+// "3 managed resources in error: Elasticsearch=1, Kibana=2".
+func ManagedResourcesCheck(c k8s.Client, kinds []string) healthz.Checker {
+	return func(_ *http.Request) error {
+		counts, err := CountErrors(c, kinds)
+		if err != nil {
+			return errors.Wrap(err, "failed to check the managed resources")
+		}
+		if len(counts) == 0 {
+			return nil
+		}
+		total := 0
+		parts := make([]string, 0, len(counts))
+		for kind, count := range counts {
+			total += count
+			parts = append(parts, fmt.Sprintf("%s=%d", kind, count))
+		}
+		sort.Strings(parts)
+		return errors.Errorf("%d managed resources in error: %s", total, strings.Join(parts, ", "))
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var allKinds = []string{ElasticsearchKind, KibanaKind, ApmServerKind, EnterpriseSearchKind}
+
+func TestManagedResourcesCheck(t *testing.T) {
+	require.NoError(t, scheme.SetupScheme())
+	meta := func(name string) metav1.ObjectMeta { return metav1.ObjectMeta{Namespace: "ns", Name: name} }
+	healthy := []runtime.Object{
+		&esv1.Elasticsearch{ObjectMeta: meta("es-green"), Status: esv1.ElasticsearchStatus{Health: esv1.ElasticsearchGreenHealth}},
+		&kbv1.Kibana{ObjectMeta: meta("kb-green"), Status: kbv1.KibanaStatus{Health: kbv1.KibanaGreen}},
+		&apmv1.ApmServer{ObjectMeta: meta("apm-green"), Status: apmv1.ApmServerStatus{Health: apmv1.ApmServerGreen}},
+		&entsv1beta1.EnterpriseSearch{ObjectMeta: meta("ents-green"), Status: entsv1beta1.EnterpriseSearchStatus{Health: entsv1beta1.EnterpriseSearchGreen}},
+	}
+	unhealthy := []runtime.Object{
+		&esv1.Elasticsearch{ObjectMeta: meta("es-red"), Status: esv1.ElasticsearchStatus{Health: esv1.ElasticsearchRedHealth}},
+		&esv1.Elasticsearch{ObjectMeta: meta("es-invalid"), Status: esv1.ElasticsearchStatus{Phase: esv1.ElasticsearchResourceInvalid}},
+		&kbv1.Kibana{ObjectMeta: meta("kb-red"), Status: kbv1.KibanaStatus{Health: kbv1.KibanaRed}},
+	}
+	tests := []struct {
+		name    string
+		objs    []runtime.Object
+		kinds   []string
+		wantErr string
+	}{
+		{
+			name:  "no managed resources",
+			kinds: allKinds,
+		},
+		{
+			name:  "healthy managed resources",
+			objs:  healthy,
+			kinds: allKinds,
+		},
+		{
+			name:    "managed resources in error",
+			objs:    append(healthy, unhealthy...),
+			kinds:   allKinds,
+			wantErr: "3 managed resources in error: Elasticsearch=2, Kibana=1",
+		},
+		{
+			name:    "only the given kinds are checked",
+			objs:    append(healthy, unhealthy...),
+			kinds:   []string{KibanaKind, ApmServerKind},
+			wantErr: "1 managed resources in error: Kibana=1",
+		},
+		{
+			name:    "unsupported kind",
+			kinds:   []string{"Beat"},
+			wantErr: "failed to check the managed resources: unsupported kind Beat",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ManagedResourcesCheck(k8s.WrappedFakeClient(tt.objs...), tt.kinds)(nil)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
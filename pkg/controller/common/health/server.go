@@ -0,0 +1,61 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package health
+
+import (
+	"context"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	// LivenessPath is the path of the liveness probe of the operator.
+	LivenessPath = "/healthz"
+	// ReadinessPath is the path of the readiness probe of the operator.
+	ReadinessPath = "/readyz"
+	// ManagedPath is the path of the health of the resources managed by the operator.
+	ManagedPath = "/managed"
+)
+
+// ProbesServer serves the liveness and readiness probes of the operator, and the health of the managed resources on
+// a separate endpoint. The health of the managed resources does not depend on the operator process: it is kept out of
+// the probes, for a red cluster not to restart the operator nor to remove it from the endpoints of the webhook service.
+type ProbesServer struct {
+	server *http.Server
+}
+
+// NewProbesServer returns a ProbesServer listening on the given address, serving the given check of the managed
+// resources.
+func NewProbesServer(addr string, managed healthz.Checker) *ProbesServer {
+	mux := http.NewServeMux()
+	probes := &healthz.Handler{Checks: map[string]healthz.Checker{"ping": healthz.Ping}}
+	for _, path := range []string{LivenessPath, ReadinessPath} {
+		// serve the aggregated checks on the path, and the individual checks below it
+		mux.Handle(path, http.StripPrefix(path, probes))
+		mux.Handle(path+"/", http.StripPrefix(path, probes))
+	}
+	mux.Handle(ManagedPath, healthz.CheckHandler{Checker: managed})
+	return &ProbesServer{server: &http.Server{Addr: addr, Handler: mux}}
+}
+
+// Start serves the probes until the stop channel is closed. It implements the controller-runtime Runnable interface.
+func (s *ProbesServer) Start(stop <-chan struct{}) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.server.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-stop:
+		return s.server.Shutdown(context.Background())
+	}
+}
+
+// NeedLeaderElection returns false: each operator replica serves its own probes.
+func (s *ProbesServer) NeedLeaderElection() bool {
+	return false
+}
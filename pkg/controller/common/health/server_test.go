@@ -0,0 +1,33 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbesServer(t *testing.T) {
+	s := NewProbesServer(":0", func(_ *http.Request) error {
+		return errors.New("1 managed resources in error: Elasticsearch=1")
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		return resp
+	}
+	// the probes do not depend on the health of the managed resources
+	for _, path := range []string{"/healthz", "/healthz/ping", "/readyz", "/readyz/ping"} {
+		require.Equal(t, http.StatusOK, get(path).Code, path)
+	}
+	require.Equal(t, http.StatusNotFound, get("/healthz/managed").Code)
+	managed := get("/managed")
+	require.Equal(t, http.StatusInternalServerError, managed.Code)
+	require.Contains(t, managed.Body.String(), "1 managed resources in error: Elasticsearch=1")
+}
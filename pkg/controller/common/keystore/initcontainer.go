@@ -22,35 +22,68 @@ type InitContainerParameters struct {
 	SecureSettingsVolumeMountPath string
 	// Where the data will be copied
 	DataVolumePath string
-	// Keystore add command
+	// Path of the keystore file, next to which the checksums of its entries are kept
+	KeystorePath string
+	// Keystore add command, overwriting the existing entry
 	KeystoreAddCommand string
 	// Keystore create command
 	KeystoreCreateCommand string
+	// Keystore remove command
+	KeystoreRemoveCommand string
 }
 
-// script is a small bash script to create a Kibana or APM keystore,
-// then add all entries from the secure settings secret volume into it.
-const script = `#!/usr/bin/env bash
+// syncFunction is a bash function updating the keystore with the entries of the secure settings volume, creating the
+// keystore if it does not exist. The checksums of the entries are kept next to the keystore: only the entries whose
+// content changed are added again, and the ones not in the volume anymore are removed.
+const syncFunction = `sync_keystore() {
+	local keystore="{{ .KeystorePath }}"
+	local checksums="${keystore}.checksums"
+	if [[ ! -f "$keystore" ]]; then
+		echo "Creating the keystore."
+		{{ .KeystoreCreateCommand }}
+		rm -f "$checksums"
+	fi
+	touch "$checksums"
 
-set -eux
+	# add the new and changed secret entries
+	: > "${checksums}.new"
+	for filename in {{ .SecureSettingsVolumeMountPath }}/*; do
+		[[ -e "$filename" ]] || continue # glob does not match
+		key=$(basename "$filename")
+		checksum=$(sha256sum "$filename" | cut -d ' ' -f 1)
+		if ! grep -qxF "$key $checksum" "$checksums"; then
+			echo "Adding $key to the keystore."
+			{{ .KeystoreAddCommand }}
+		fi
+		echo "$key $checksum" >> "${checksums}.new"
+	done
 
-echo "Initializing keystore."
+	# remove the deleted ones
+	while read -r key _; do
+		if [[ ! -e "{{ .SecureSettingsVolumeMountPath }}/$key" ]]; then
+			echo "Removing $key from the keystore."
+			{{ .KeystoreRemoveCommand }}
+		fi
+	done < "$checksums"
+	mv "${checksums}.new" "$checksums"
+}`
+
+// script is a small bash script to create or update a keystore with all entries from the secure settings secret volume.
+const script = `#!/usr/bin/env bash
 
-# create a keystore in the default data path
-{{ .KeystoreCreateCommand }}
+set -eu
 
-# add all existing secret entries into it
-for filename in  {{ .SecureSettingsVolumeMountPath }}/*; do
-	[[ -e "$filename" ]] || continue # glob does not match
-	key=$(basename "$filename")
-	echo "Adding "$key" to the keystore."
-	{{ .KeystoreAddCommand }}
-done
+{{ template "sync" . }}
 
+echo "Initializing keystore."
+sync_keystore
 echo "Keystore initialization successful."
 `
 
-var scriptTemplate = template.Must(template.New("").Parse(script))
+var (
+	syncTemplate   = template.Must(template.New("sync").Parse(syncFunction))
+	scriptTemplate = template.Must(template.Must(syncTemplate.Clone()).New("").Parse(script))
+)
 
 // initContainer returns an init container that executes a bash script
 // to load secure settings in a Keystore.
@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
@@ -27,6 +28,8 @@ type Resources struct {
 	InitContainer corev1.Container
 	// version of the secret provided by the user
 	Version string
+	// optional sidecar container used to update the keystore of the running Pods
+	Sidecar *corev1.Container
 }
 
 // HasKeystore interface represents an Elastic Stack application that offers a keystore which in ECK
@@ -37,13 +40,23 @@ type HasKeystore interface {
 	SecureSettings() []commonv1.SecretSource
 }
 
-// WatchedSecretNames returns the name of all secure settings secrets to watch.
-func WatchedSecretNames(hasKeystore HasKeystore) []string {
-	names := make([]string, 0, len(hasKeystore.SecureSettings()))
+// WatchedSecrets returns all the secure settings secrets to watch, including the ones shared from other namespaces.
+func WatchedSecrets(hasKeystore HasKeystore) []types.NamespacedName {
+	secrets := make([]types.NamespacedName, 0, len(hasKeystore.SecureSettings()))
 	for _, s := range hasKeystore.SecureSettings() {
-		names = append(names, s.SecretName)
+		secrets = append(secrets, secretSourceName(hasKeystore, s))
 	}
-	return names
+	return secrets
+}
+
+// secretSourceName returns the namespace and name of the secret of the given source, which defaults to the namespace
+// of the resource.
+func secretSourceName(hasKeystore HasKeystore, source commonv1.SecretSource) types.NamespacedName {
+	namespace := source.Namespace
+	if namespace == "" {
+		namespace = hasKeystore.GetNamespace()
+	}
+	return types.NamespacedName{Namespace: namespace, Name: source.SecretName}
 }
 
 // NewResources optionally returns a volume and init container to include in pods,
@@ -6,6 +6,7 @@ package keystore
 
 import (
 	"testing"
+	"time"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
@@ -24,8 +25,10 @@ var (
 	initContainersParameters = InitContainerParameters{
 		KeystoreCreateCommand:         "/keystore/bin/keystore create",
 		KeystoreAddCommand:            `/keystore/bin/keystore add "$key" "$filename"`,
+		KeystoreRemoveCommand:         `/keystore/bin/keystore remove "$key"`,
 		SecureSettingsVolumeMountPath: "/foo/secret",
 		DataVolumePath:                "/bar/data",
+		KeystorePath:                  "/bar/data/keystore",
 	}
 
 	testSecureSettingsSecretName = "secure-settings-secret"
@@ -87,21 +90,43 @@ func TestResources(t *testing.T) {
 					"-c",
 					`#!/usr/bin/env bash
 
-set -eux
+set -eu
 
-echo "Initializing keystore."
+sync_keystore() {
+	local keystore="/bar/data/keystore"
+	local checksums="${keystore}.checksums"
+	if [[ ! -f "$keystore" ]]; then
+		echo "Creating the keystore."
+		/keystore/bin/keystore create
+		rm -f "$checksums"
+	fi
+	touch "$checksums"
 
-# create a keystore in the default data path
-/keystore/bin/keystore create
+	# add the new and changed secret entries
+	: > "${checksums}.new"
+	for filename in /foo/secret/*; do
+		[[ -e "$filename" ]] || continue # glob does not match
+		key=$(basename "$filename")
+		checksum=$(sha256sum "$filename" | cut -d ' ' -f 1)
+		if ! grep -qxF "$key $checksum" "$checksums"; then
+			echo "Adding $key to the keystore."
+			/keystore/bin/keystore add "$key" "$filename"
+		fi
+		echo "$key $checksum" >> "${checksums}.new"
+	done
 
-# add all existing secret entries into it
-for filename in  /foo/secret/*; do
-	[[ -e "$filename" ]] || continue # glob does not match
-	key=$(basename "$filename")
-	echo "Adding "$key" to the keystore."
-	/keystore/bin/keystore add "$key" "$filename"
-done
+	# remove the deleted ones
+	while read -r key _; do
+		if [[ ! -e "/foo/secret/$key" ]]; then
+			echo "Removing $key from the keystore."
+			/keystore/bin/keystore remove "$key"
+		fi
+	done < "$checksums"
+	mv "${checksums}.new" "$checksums"
+}
 
+echo "Initializing keystore."
+sync_keystore
 echo "Keystore initialization successful."
 `,
 				},
@@ -157,3 +182,33 @@ echo "Keystore initialization successful."
 		})
 	}
 }
+
+func TestResources_WithSidecar(t *testing.T) {
+	testDriver := driver.TestDriver{
+		Client:       k8s.WrappedFakeClient(&testSecureSettingsSecret),
+		Watches:      watches2.NewDynamicWatches(),
+		FakeRecorder: record.NewFakeRecorder(1000),
+	}
+	resources, err := NewResources(testDriver, &testKibanaWithSecureSettings, name.KBNamer, nil, initContainersParameters)
+	require.NoError(t, err)
+	require.Nil(t, resources.Sidecar)
+	require.Equal(t, "1", resources.PodTemplateVersion())
+
+	withSidecar, err := resources.WithSidecar(initContainersParameters, 30*time.Second)
+	require.NoError(t, err)
+	// the original resources are not modified
+	require.Nil(t, resources.Sidecar)
+	require.NotNil(t, withSidecar.Sidecar)
+	require.Equal(t, SidecarContainerName, withSidecar.Sidecar.Name)
+	require.Equal(t, resources.InitContainer.VolumeMounts, withSidecar.Sidecar.VolumeMounts)
+	script := withSidecar.Sidecar.Command[3]
+	require.Contains(t, script, "sync_keystore() {")
+	// a failed update is retried at the next period rather than stopping the container
+	require.Contains(t, script, "\t(set -e; sync_keystore)\n\tif [[ $? -ne 0 ]]; then")
+	require.Contains(t, script, "\tsleep 30\ndone")
+	require.NotContains(t, script, "set -eu")
+	require.Equal(t, "512Mi", withSidecar.Sidecar.Resources.Limits.Memory().String())
+	// the Pods are not rotated when the secure settings change
+	require.Equal(t, "1", withSidecar.Version)
+	require.Equal(t, "", withSidecar.PodTemplateVersion())
+}
@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package keystore
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	SidecarContainerName = "elastic-internal-keystore-sync"
+)
+
+// sidecarScript is a small bash script updating the keystore with the secure settings secret volume periodically, as
+// the kubelet propagates the changes of the secret to the running Pod. Each update runs in a subshell exiting at the
+// first error: a failed update is logged and retried at the next period, rather than stopping the container. The
+// checksums of the entries are only updated by a successful update, so that the failed entries are added again.
+const sidecarScript = `#!/usr/bin/env bash
+
+set -u
+
+{{ template "sync" .InitContainerParameters }}
+
+echo "Updating the keystore every {{ .PeriodSeconds }} seconds."
+while true; do
+	# not run as a condition, which would disable the exit on error in the subshell
+	(set -e; sync_keystore)
+	if [[ $? -ne 0 ]]; then
+		echo "Failed to update the keystore, retrying in {{ .PeriodSeconds }} seconds."
+	fi
+	sleep {{ .PeriodSeconds }}
+done
+`
+
+var sidecarScriptTemplate = template.Must(template.Must(syncTemplate.Clone()).New("").Parse(sidecarScript))
+
+// sidecarResources are the default resources of the sidecar, which only runs the keystore command when an entry changes.
+// The limit leaves room for the JVM of the Elasticsearch keystore command.
+var sidecarResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	},
+	Limits: corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	},
+}
+
+// WithSidecar returns a copy of the resources with a sidecar container, which keeps the keystore of the running Pods
+// up-to-date with the secure settings every given period, instead of rotating the Pods when they change.
+func (r Resources) WithSidecar(parameters InitContainerParameters, period time.Duration) (*Resources, error) {
+	tplBuffer := bytes.Buffer{}
+	if err := sidecarScriptTemplate.Execute(&tplBuffer, struct {
+		InitContainerParameters
+		PeriodSeconds int64
+	}{
+		InitContainerParameters: parameters,
+		PeriodSeconds:           int64(period / time.Second),
+	}); err != nil {
+		return nil, err
+	}
+
+	sidecar := r.InitContainer
+	sidecar.Name = SidecarContainerName
+	sidecar.Command = []string{"/usr/bin/env", "bash", "-c", tplBuffer.String()}
+	sidecar.VolumeMounts = append([]corev1.VolumeMount{}, r.InitContainer.VolumeMounts...)
+	sidecar.Resources = sidecarResources
+	r.Sidecar = &sidecar
+	return &r, nil
+}
+
+// PodTemplateVersion returns the version of the secure settings to include in the Pod template, to rotate the Pods when
+// the secure settings change. It is empty if a sidecar container updates the keystore of the running Pods.
+func (r Resources) PodTemplateVersion() string {
+	if r.Sidecar != nil {
+		return ""
+	}
+	return r.Version
+}
@@ -5,7 +5,10 @@
 package keystore
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
+	"strings"
 
 	pkgerrors "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -24,14 +27,25 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 )
 
-const secureSettingsSecretSuffix = "secure-settings"
+const (
+	// SharedWithNamespacesAnnotation lists the namespaces, comma-separated, whose resources can reference a secure
+	// settings secret of another namespace, or * for all namespaces.
+	SharedWithNamespacesAnnotation = "common.k8s.elastic.co/shared-with-namespaces"
+
+	secureSettingsSecretSuffix       = "secure-settings"
+	policySecureSettingsSecretSuffix = "policy-secure-settings"
+	remoteAPIKeysSecretSuffix        = "remote-api-keys"
+)
 
 // secureSettingsVolume creates a volume from the optional user-provided secure settings secrets.
 //
 // Secure settings are provided by the user in the resource Spec through secret references.
-// The user provided secrets are then aggregated into a single secret.
+// The user provided secrets, which may be shared from other namespaces, are then aggregated into a single secret.
 // This secret is mounted into the pods for secure settings to be injected into a keystore.
 // The user-provided secrets are watched to reconcile on any change.
+// Secure settings distributed by a StackConfigPolicy are also aggregated, and take precedence over the user ones, as
+// well as the credentials of the remote clusters reached with API keys.
+// The secrets holding them are owned by the resource, which is enough to reconcile on any change.
 // The user secret resource version is returned along with the volume, so that
 // any change in the user secret leads to pod rotation.
 func secureSettingsVolume(
@@ -42,11 +56,11 @@ func secureSettingsVolume(
 ) (*volume.SecretVolume, string, error) {
 	// setup (or remove) watches for the user-provided secret to reconcile on any change
 	watcher := k8s.ExtractNamespacedName(hasKeystore)
-	if err := watches.WatchUserProvidedSecrets(
+	if err := watches.WatchSecrets(
 		watcher,
 		r.DynamicWatches(),
 		SecureSettingsWatchName(watcher),
-		WatchedSecretNames(hasKeystore),
+		WatchedSecrets(hasKeystore),
 	); err != nil {
 		return nil, "", err
 	}
@@ -55,6 +69,18 @@ func secureSettingsVolume(
 	if err != nil {
 		return nil, "", err
 	}
+	for _, name := range []string{
+		PolicySecureSettingsSecretName(namer, hasKeystore.GetName()),
+		RemoteAPIKeysSecretName(namer, hasKeystore.GetName()),
+	} {
+		operatorSecret, err := retrieveOperatorSecret(r.K8sClient(), hasKeystore.GetNamespace(), name)
+		if err != nil {
+			return nil, "", err
+		}
+		if operatorSecret != nil {
+			secrets = append(secrets, *operatorSecret)
+		}
+	}
 	secret, err := reconcileSecureSettings(r.K8sClient(), hasKeystore, secrets, namer, labels)
 	if err != nil {
 		return nil, "", err
@@ -120,7 +146,7 @@ func reconcileSecureSettings(
 func retrieveUserSecrets(c k8s.Client, recorder record.EventRecorder, hasKeystore HasKeystore) ([]corev1.Secret, error) {
 	userSecrets := make([]corev1.Secret, 0, len(hasKeystore.SecureSettings()))
 	for _, userSecretsRef := range hasKeystore.SecureSettings() {
-		// retrieve the secret referenced by the user in the same namespace
+		// retrieve the secret referenced by the user, in the same namespace unless shared from another one
 		userSecret, exists, err := retrieveUserSecret(c, recorder, hasKeystore, userSecretsRef)
 		if err != nil {
 			return nil, err
@@ -131,15 +157,42 @@ func retrieveUserSecrets(c k8s.Client, recorder record.EventRecorder, hasKeystor
 		}
 		userSecrets = append(userSecrets, *userSecret)
 	}
+	if conflicts := conflictingKeys(userSecrets); len(conflicts) > 0 {
+		msg := "Secure settings set with different values in several secrets, using the last one"
+		log.Info(msg, "namespace", hasKeystore.GetNamespace(), "name", hasKeystore.GetName(), "keys", conflicts)
+		recorder.Event(hasKeystore, corev1.EventTypeWarning, events.EventReasonUnexpected, msg+": "+strings.Join(conflicts, ", "))
+	}
 	return userSecrets, nil
 }
 
+// conflictingKeys returns the sorted keys set with different values in the given secrets. Keys set with the same
+// value, such as the ones of a secret referenced several times, are only added once to the keystore.
+func conflictingKeys(secrets []corev1.Secret) []string {
+	values := map[string][]byte{}
+	conflicts := map[string]struct{}{}
+	for _, secret := range secrets {
+		for k, v := range secret.Data {
+			if previous, exists := values[k]; exists && !bytes.Equal(previous, v) {
+				conflicts[k] = struct{}{}
+			}
+			values[k] = v
+		}
+	}
+	keys := make([]string, 0, len(conflicts))
+	for k := range conflicts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func retrieveUserSecret(c k8s.Client, recorder record.EventRecorder, hasKeystore HasKeystore, secretSrc commonv1.SecretSource) (*corev1.Secret, bool, error) {
-	namespace := hasKeystore.GetNamespace()
-	secretName := secretSrc.SecretName
+	secretRef := secretSourceName(hasKeystore, secretSrc)
+	namespace := secretRef.Namespace
+	secretName := secretRef.Name
 
 	var userSecret corev1.Secret
-	err := c.Get(types.NamespacedName{Namespace: namespace, Name: secretName}, &userSecret)
+	err := c.Get(secretRef, &userSecret)
 	if err != nil && apierrors.IsNotFound(err) {
 		msg := "Secure settings secret not found"
 		log.Info(msg, "namespace", namespace, "secret_name", secretName)
@@ -149,20 +202,34 @@ func retrieveUserSecret(c k8s.Client, recorder record.EventRecorder, hasKeystore
 		return nil, false, err
 	}
 
-	// If no entries, return the whole user secret
-	if secretSrc.Entries == nil {
-		return &userSecret, true, nil
+	if !isSharedWith(userSecret, hasKeystore.GetNamespace()) {
+		msg := "Secure settings secret not shared with this namespace"
+		log.Info(msg, "namespace", namespace, "secret_name", secretName, "resource_namespace", hasKeystore.GetNamespace())
+		recorder.Event(hasKeystore, corev1.EventTypeWarning, events.EventReasonUnexpected, msg+": "+secretRef.String())
+		return nil, false, nil
 	}
 
-	if len(secretSrc.Entries) == 0 {
-		return nil, false, pkgerrors.Errorf("set is empty in secure settings secret %s", secretName)
+	// If no entries and no prefix, return the whole user secret
+	if secretSrc.Entries == nil && secretSrc.Prefix == "" {
+		return &userSecret, true, nil
 	}
 
-	// Else if entries is defined, return only a subset of the user secret
+	// Else return a projection of the user secret
 	projectionSecret := corev1.Secret{
 		ObjectMeta: userSecret.ObjectMeta,
 		Data:       map[string][]byte{},
 	}
+	if secretSrc.Entries == nil {
+		for k, v := range userSecret.Data {
+			projectionSecret.Data[secretSrc.Prefix+k] = v
+		}
+		return &projectionSecret, true, nil
+	}
+
+	if len(secretSrc.Entries) == 0 {
+		return nil, false, pkgerrors.Errorf("set is empty in secure settings secret %s", secretName)
+	}
+
 	for _, entry := range secretSrc.Entries {
 		if entry.Key == "" {
 			return nil, false, pkgerrors.Errorf("key is empty in secure settings secret %s", secretName)
@@ -178,12 +245,51 @@ func retrieveUserSecret(c k8s.Client, recorder record.EventRecorder, hasKeystore
 			return nil, false, pkgerrors.Errorf("key %s not found in secure settings secret %s", entry.Key, secretName)
 		}
 
-		projectionSecret.Data[newKey] = value
+		projectionSecret.Data[secretSrc.Prefix+newKey] = value
 	}
 
 	return &projectionSecret, true, nil
 }
 
+// isSharedWith returns true if the given secure settings secret can be used by the resources of the given namespace:
+// either it is in this namespace, or its SharedWithNamespacesAnnotation lists this namespace or *.
+func isSharedWith(secret corev1.Secret, namespace string) bool {
+	if secret.Namespace == namespace {
+		return true
+	}
+	for _, shared := range strings.Split(secret.Annotations[SharedWithNamespacesAnnotation], ",") {
+		if shared = strings.TrimSpace(shared); shared == "*" || shared == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// retrieveOperatorSecret returns the secret holding secure settings managed by the operator, if any.
+func retrieveOperatorSecret(c k8s.Client, namespace string, name string) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	err := c.Get(types.NamespacedName{Namespace: namespace, Name: name}, &secret)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// PolicySecureSettingsSecretName returns the name of the secret holding the secure settings distributed by a
+// StackConfigPolicy to the resource with the given name.
+func PolicySecureSettingsSecretName(namer name.Namer, resourceName string) string {
+	return namer.Suffix(resourceName, policySecureSettingsSecretSuffix)
+}
+
+// RemoteAPIKeysSecretName returns the name of the secret holding the credentials of the remote clusters the resource
+// with the given name connects to with API keys.
+func RemoteAPIKeysSecretName(namer name.Namer, resourceName string) string {
+	return namer.Suffix(resourceName, remoteAPIKeysSecretSuffix)
+}
+
 func secureSettingsSecretName(namer name.Namer, hasKeystore HasKeystore) string {
 	return namer.Suffix(hasKeystore.GetName(), secureSettingsSecretSuffix)
 }
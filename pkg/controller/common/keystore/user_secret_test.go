@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 
@@ -83,6 +84,30 @@ func Test_secureSettingsVolume(t *testing.T) {
 			wantWatches: []string{SecureSettingsWatchName(k8s.ExtractNamespacedName(&testKibanaWithSecureSettings))},
 			wantEvent:   "Warning Unexpected Secure settings secret not found: secure-settings-secret",
 		},
+		{
+			name: "secure settings distributed by a policy: should return volume with version",
+			c: k8s.WrappedFakeClient(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testKibana.Namespace, Name: "kibana-kb-policy-secure-settings"},
+				Data:       map[string][]byte{"key": []byte("value")},
+			}),
+			w:           createWatches(""),
+			kb:          testKibana,
+			wantVolume:  &expectedSecretVolume,
+			wantVersion: "1",
+			wantWatches: []string{},
+		},
+		{
+			name: "credentials of remote clusters: should return volume with version",
+			c: k8s.WrappedFakeClient(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testKibana.Namespace, Name: "kibana-kb-remote-api-keys"},
+				Data:       map[string][]byte{"cluster.remote.leader.credentials": []byte("encoded")},
+			}),
+			w:           createWatches(""),
+			kb:          testKibana,
+			wantVolume:  &expectedSecretVolume,
+			wantVersion: "1",
+			wantWatches: []string{},
+		},
 		{
 			name:        "secure settings removed (was set before): should remove watch",
 			c:           k8s.WrappedFakeClient(&testSecureSettingsSecret),
@@ -417,6 +442,45 @@ func Test_retrieveUserSecrets(t *testing.T) {
 			}},
 			wantErr: false,
 		},
+		{
+			name: "secure settings secret with a prefix should be retrieved with prefixed keys",
+			args: []commonv1.SecretSource{
+				{
+					SecretName: testSecretName,
+					Prefix:     "s3.client.default.",
+					Entries: []commonv1.KeyToPath{
+						{Key: "key1", Path: "access_key"},
+					},
+				},
+				{
+					SecretName: testSecretName,
+					Prefix:     "prefix.",
+				},
+			},
+			want: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns",
+						Name:      testSecretName,
+					},
+					Data: map[string][]byte{
+						"s3.client.default.access_key": []byte("value1"),
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns",
+						Name:      testSecretName,
+					},
+					Data: map[string][]byte{
+						"prefix.key1": []byte("value1"),
+						"prefix.key2": []byte("value2"),
+						"prefix.key3": []byte("value3"),
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	recorder := record.NewFakeRecorder(100)
@@ -438,3 +502,79 @@ func Test_retrieveUserSecrets(t *testing.T) {
 		})
 	}
 }
+
+func Test_retrieveUserSecrets_sharedSecrets(t *testing.T) {
+	sharedSecret := func(name string, sharedWith string, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "shared",
+				Name:        name,
+				Annotations: map[string]string{SharedWithNamespacesAnnotation: sharedWith},
+			},
+			Data: data,
+		}
+	}
+	s3 := map[string][]byte{"s3.client.default.access_key": []byte("id")}
+	tests := []struct {
+		name      string
+		secrets   []runtime.Object
+		sources   []commonv1.SecretSource
+		want      []map[string][]byte
+		wantEvent string
+	}{
+		{
+			name:    "secret shared with the namespace",
+			secrets: []runtime.Object{sharedSecret("s3", "other, ns", s3)},
+			sources: []commonv1.SecretSource{{SecretName: "s3", Namespace: "shared"}},
+			want:    []map[string][]byte{s3},
+		},
+		{
+			name:    "secret shared with all namespaces",
+			secrets: []runtime.Object{sharedSecret("s3", "*", s3)},
+			sources: []commonv1.SecretSource{{SecretName: "s3", Namespace: "shared"}},
+			want:    []map[string][]byte{s3},
+		},
+		{
+			name:      "secret not shared with the namespace",
+			secrets:   []runtime.Object{sharedSecret("s3", "other", s3)},
+			sources:   []commonv1.SecretSource{{SecretName: "s3", Namespace: "shared"}},
+			want:      []map[string][]byte{},
+			wantEvent: "Warning Unexpected Secure settings secret not shared with this namespace: shared/s3",
+		},
+		{
+			name: "same keys with different values",
+			secrets: []runtime.Object{
+				sharedSecret("s3", "*", s3),
+				sharedSecret("s3-other", "*", map[string][]byte{"s3.client.default.access_key": []byte("other-id")}),
+			},
+			sources: []commonv1.SecretSource{
+				{SecretName: "s3", Namespace: "shared"},
+				{SecretName: "s3", Namespace: "shared"},
+				{SecretName: "s3-other", Namespace: "shared"},
+			},
+			want: []map[string][]byte{s3, s3, {"s3.client.default.access_key": []byte("other-id")}},
+			wantEvent: "Warning Unexpected Secure settings set with different values in several secrets, using the last one: " +
+				"s3.client.default.access_key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(100)
+			kb := &kbv1.Kibana{
+				ObjectMeta: metav1.ObjectMeta{Name: "kb", Namespace: "ns"},
+				Spec:       kbv1.KibanaSpec{SecureSettings: tt.sources},
+			}
+			got, err := retrieveUserSecrets(k8s.WrappedFakeClient(tt.secrets...), recorder, kb)
+			require.NoError(t, err)
+			gotData := make([]map[string][]byte, 0, len(got))
+			for _, secret := range got {
+				gotData = append(gotData, secret.Data)
+			}
+			require.Equal(t, tt.want, gotData)
+			if tt.wantEvent != "" {
+				require.Equal(t, tt.wantEvent, <-recorder.Events)
+			}
+			require.Empty(t, recorder.Events)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultElasticsearchRequestMetrics are the metrics of the requests of the operator to the Elasticsearch clusters.
+var DefaultElasticsearchRequestMetrics = NewElasticsearchRequestMetrics()
+
+func init() {
+	metrics.Registry.MustRegister(DefaultElasticsearchRequestMetrics)
+}
+
+// requestCounts holds the number of requests to a cluster, and the number of failed ones per error type.
+type requestCounts struct {
+	requests float64
+	errors   map[string]float64
+}
+
+// ElasticsearchRequestMetrics is a Prometheus collector of the number of requests to each Elasticsearch cluster, and
+// of the number of errors per error type, to identify the noisy clusters through their error rate.
+type ElasticsearchRequestMetrics struct {
+	requestsDesc *prometheus.Desc
+	errorsDesc   *prometheus.Desc
+
+	mutex    sync.RWMutex
+	clusters map[types.NamespacedName]*requestCounts
+}
+
+// NewElasticsearchRequestMetrics returns new ElasticsearchRequestMetrics.
+func NewElasticsearchRequestMetrics() *ElasticsearchRequestMetrics {
+	return &ElasticsearchRequestMetrics{
+		requestsDesc: prometheus.NewDesc(
+			"eck_elasticsearch_requests_total",
+			"Number of requests of the operator to an Elasticsearch cluster.",
+			[]string{"namespace", "name"},
+			nil,
+		),
+		errorsDesc: prometheus.NewDesc(
+			"eck_elasticsearch_request_errors_total",
+			"Number of requests of the operator to an Elasticsearch cluster which failed, per error type.",
+			[]string{"namespace", "name", "type"},
+			nil,
+		),
+		clusters: map[types.NamespacedName]*requestCounts{},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *ElasticsearchRequestMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.requestsDesc
+	ch <- m.errorsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (m *ElasticsearchRequestMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for cluster, counts := range m.clusters {
+		ch <- prometheus.MustNewConstMetric(m.requestsDesc, prometheus.CounterValue, counts.requests, cluster.Namespace, cluster.Name)
+		for errorType, count := range counts.errors {
+			ch <- prometheus.MustNewConstMetric(m.errorsDesc, prometheus.CounterValue, count, cluster.Namespace, cluster.Name, errorType)
+		}
+	}
+}
+
+// Observe records a request to the given cluster, which failed with the given error type if not empty.
+func (m *ElasticsearchRequestMetrics) Observe(cluster types.NamespacedName, errorType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	counts, exists := m.clusters[cluster]
+	if !exists {
+		counts = &requestCounts{errors: map[string]float64{}}
+		m.clusters[cluster] = counts
+	}
+	counts.requests++
+	if errorType != "" {
+		counts.errors[errorType]++
+	}
+}
+
+// Forget stops reporting the metrics of the given cluster, when it is deleted.
+func (m *ElasticsearchRequestMetrics) Forget(cluster types.NamespacedName) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.clusters, cluster)
+}
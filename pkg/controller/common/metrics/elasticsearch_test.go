@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestElasticsearchRequestMetrics(t *testing.T) {
+	m := NewElasticsearchRequestMetrics()
+	es1 := types.NamespacedName{Namespace: "ns", Name: "es1"}
+	es2 := types.NamespacedName{Namespace: "ns", Name: "es2"}
+
+	m.Observe(es1, "")
+	m.Observe(es1, "Unavailable")
+	m.Observe(es1, "Unavailable")
+	m.Observe(es1, "Conflict")
+	m.Observe(es2, "")
+	require.Equal(t, map[string]float64{
+		"name=es1,namespace=ns,": 4,
+		"name=es2,namespace=ns,": 1,
+	}, gather(t, m, "eck_elasticsearch_requests_total"))
+	require.Equal(t, map[string]float64{
+		"name=es1,namespace=ns,type=Unavailable,": 2,
+		"name=es1,namespace=ns,type=Conflict,":    1,
+	}, gather(t, m, "eck_elasticsearch_request_errors_total"))
+
+	// deleted clusters are not reported anymore
+	m.Forget(es1)
+	require.Equal(t, map[string]float64{
+		"name=es2,namespace=ns,": 1,
+	}, gather(t, m, "eck_elasticsearch_requests_total"))
+	require.Empty(t, gather(t, m, "eck_elasticsearch_request_errors_total"))
+}
@@ -0,0 +1,154 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// SuccessResult is the result of a reconciliation which completed without error nor requeue.
+	SuccessResult = "success"
+	// RequeueResult is the result of a reconciliation which completed without error, and requested a requeue.
+	RequeueResult = "requeue"
+	// ErrorResult is the result of a reconciliation which returned an error.
+	ErrorResult = "error"
+)
+
+var log = logf.Log.WithName("metrics")
+
+// DefaultReconcileMetrics are the reconcile metrics exposed by the operator.
+var DefaultReconcileMetrics = NewReconcileMetrics()
+
+func init() {
+	metrics.Registry.MustRegister(DefaultReconcileMetrics)
+}
+
+// resourceKey identifies a resource reconciled by a controller.
+type resourceKey struct {
+	controller string
+	kind       string
+	types.NamespacedName
+}
+
+// ReconcileMetrics is a Prometheus collector of the reconcile durations and results per controller and resource kind,
+// and of the time since the last successful reconciliation of each resource. The latter is computed when the metrics
+// are collected, which allows to alert on resources not reconciled successfully for a given duration.
+type ReconcileMetrics struct {
+	duration         *prometheus.HistogramVec
+	sinceSuccessDesc *prometheus.Desc
+
+	mutex sync.RWMutex
+	// lastSuccess holds the time of the last successful reconciliation of each resource, or the time of its first
+	// reconciliation until it succeeds
+	lastSuccess map[resourceKey]time.Time
+	now         func() time.Time
+}
+
+// NewReconcileMetrics returns new ReconcileMetrics.
+func NewReconcileMetrics() *ReconcileMetrics {
+	return &ReconcileMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "eck",
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of the reconciliations per controller, resource kind and result.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"controller", "kind", "result"}),
+		sinceSuccessDesc: prometheus.NewDesc(
+			"eck_reconcile_seconds_since_last_success",
+			"Seconds since the last successful reconciliation of a resource, or since its first reconciliation by the operator if none succeeded.",
+			[]string{"controller", "kind", "namespace", "name"},
+			nil,
+		),
+		lastSuccess: map[resourceKey]time.Time{},
+		now:         time.Now,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *ReconcileMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	ch <- m.sinceSuccessDesc
+}
+
+// Collect implements prometheus.Collector.
+func (m *ReconcileMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	now := m.now()
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for key, t := range m.lastSuccess {
+		ch <- prometheus.MustNewConstMetric(
+			m.sinceSuccessDesc, prometheus.GaugeValue, now.Sub(t).Seconds(), key.controller, key.kind, key.Namespace, key.Name,
+		)
+	}
+}
+
+// observe records a reconciliation of the given resource which started at the given time.
+func (m *ReconcileMetrics) observe(key resourceKey, start time.Time, res reconcile.Result, err error, exists bool) {
+	result := SuccessResult
+	switch {
+	case err != nil:
+		result = ErrorResult
+	case res.Requeue || res.RequeueAfter > 0:
+		result = RequeueResult
+	}
+	m.duration.WithLabelValues(key.controller, key.kind, result).Observe(m.now().Sub(start).Seconds())
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	switch {
+	case !exists:
+		delete(m.lastSuccess, key)
+	case err == nil:
+		m.lastSuccess[key] = m.now()
+	default:
+		if _, seen := m.lastSuccess[key]; !seen {
+			m.lastSuccess[key] = start
+		}
+	}
+}
+
+// Wrap returns a reconciler recording the metrics of the reconciliations of the given controller, which reconciles
+// resources of the type of the given object. The resources are fetched with the given client after each
+// reconciliation, to stop reporting the resources which were deleted.
+func (m *ReconcileMetrics) Wrap(
+	c client.Reader,
+	scheme *runtime.Scheme,
+	controller string,
+	reconciled runtime.Object,
+	r reconcile.Reconciler,
+) (reconcile.Reconciler, error) {
+	gvk, err := apiutil.GVKForObject(reconciled, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return reconcile.Func(func(request reconcile.Request) (reconcile.Result, error) {
+		start := m.now()
+		res, err := r.Reconcile(request)
+		exists := true
+		if getErr := c.Get(context.Background(), request.NamespacedName, reconciled.DeepCopyObject()); getErr != nil {
+			if !apierrors.IsNotFound(getErr) {
+				log.V(1).Info("Failed to get reconciled resource", "error", getErr.Error(), "kind", gvk.Kind,
+					"namespace", request.Namespace, "name", request.Name)
+			}
+			exists = !apierrors.IsNotFound(getErr)
+		}
+		m.observe(resourceKey{controller: controller, kind: gvk.Kind, NamespacedName: request.NamespacedName}, start, res, err, exists)
+		return res, err
+	}), nil
+}
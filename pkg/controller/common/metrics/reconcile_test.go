@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// gather returns the values of the metrics of the given family, keyed by the values of their labels.
+func gather(t *testing.T, m prometheus.Collector, family string) map[string]float64 {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(m))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, f := range families {
+		if f.GetName() != family {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			var key string
+			for _, l := range metric.GetLabel() {
+				key += l.GetName() + "=" + l.GetValue() + ","
+			}
+			switch {
+			case metric.GetHistogram() != nil:
+				values[key] = float64(metric.GetHistogram().GetSampleCount())
+			case metric.GetCounter() != nil:
+				values[key] = metric.GetCounter().GetValue()
+			default:
+				values[key] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+func TestReconcileMetrics_Wrap(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewReconcileMetrics()
+	m.now = func() time.Time { return now }
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "s"}}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, secret)
+	var err error
+	var res reconcile.Result
+	r, wrapErr := m.Wrap(c, scheme.Scheme, "test-controller", &corev1.Secret{}, reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+		return res, err
+	}))
+	require.NoError(t, wrapErr)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "s"}}
+	sinceSuccess := func() map[string]float64 { return gather(t, m, "eck_reconcile_seconds_since_last_success") }
+	resourceLabels := "controller=test-controller,kind=Secret,name=s,namespace=ns,"
+
+	// a failing reconciliation is reported since the first reconciliation
+	err = errors.New("failure")
+	_, _ = r.Reconcile(request)
+	now = now.Add(time.Minute)
+	_, _ = r.Reconcile(request)
+	require.Equal(t, map[string]float64{resourceLabels: 60}, sinceSuccess())
+
+	// a successful reconciliation resets the duration
+	err = nil
+	_, _ = r.Reconcile(request)
+	now = now.Add(10 * time.Second)
+	require.Equal(t, map[string]float64{resourceLabels: 10}, sinceSuccess())
+
+	// so does a reconciliation requesting a requeue
+	res = reconcile.Result{RequeueAfter: time.Minute}
+	_, _ = r.Reconcile(request)
+	require.Equal(t, map[string]float64{resourceLabels: 0}, sinceSuccess())
+
+	require.Equal(t, map[string]float64{
+		"controller=test-controller,kind=Secret,result=error,":   2,
+		"controller=test-controller,kind=Secret,result=success,": 1,
+		"controller=test-controller,kind=Secret,result=requeue,": 1,
+	}, gather(t, m, "eck_reconcile_duration_seconds"))
+
+	// deleted resources are not reported anymore
+	require.NoError(t, c.Delete(context.Background(), secret))
+	_, _ = r.Reconcile(request)
+	require.Empty(t, sinceSuccess())
+}
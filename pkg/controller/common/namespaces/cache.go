@@ -0,0 +1,414 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package namespaces
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/elastic/cloud-on-k8s/pkg/utils/set"
+)
+
+var log = logf.Log.WithName("namespaces")
+
+// OnRemoved is called when a namespace is not managed anymore.
+type OnRemoved func(namespace string)
+
+// DynamicCache is a cache.Cache scoped to a set of namespaces that can be changed at runtime. It behaves like the
+// controller-runtime multi-namespace cache, but also records the event handlers and indexes registered by the
+// controllers to apply them to the namespaces added later on.
+type DynamicCache struct {
+	// static namespaces are always managed
+	static set.StringSet
+	// newCache creates the cache of a single namespace
+	newCache cache.NewCacheFunc
+
+	lock       sync.RWMutex
+	config     *rest.Config
+	opts       cache.Options
+	namespaces map[string]*namespaceCache
+	informers  map[schema.GroupVersionKind]*dynamicInformer
+	indexes    []index
+	listeners  []OnRemoved
+	// stop is the channel given to Start, nil if the cache is not started yet
+	stop <-chan struct{}
+}
+
+var _ cache.Cache = &DynamicCache{}
+
+type namespaceCache struct {
+	cache.Cache
+	stop chan struct{}
+}
+
+type index struct {
+	obj          runtime.Object
+	field        string
+	extractValue client.IndexerFunc
+}
+
+// NewDynamicCache returns a DynamicCache always managing the given static namespaces.
+func NewDynamicCache(static []string) *DynamicCache {
+	return &DynamicCache{
+		static:     set.Make(static...),
+		newCache:   cache.New,
+		namespaces: map[string]*namespaceCache{},
+		informers:  map[schema.GroupVersionKind]*dynamicInformer{},
+	}
+}
+
+// New initializes the cache for the static namespaces. It implements cache.NewCacheFunc, to be used as the manager
+// cache builder.
+func (c *DynamicCache) New(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.config = config
+	c.opts = opts
+	for _, ns := range c.static.AsSlice() {
+		if err := c.add(ns); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// OnRemoved registers a function called each time a namespace is not managed anymore.
+func (c *DynamicCache) OnRemoved(listener OnRemoved) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// Namespaces returns the sorted managed namespaces.
+func (c *DynamicCache) Namespaces() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	namespaces := make([]string, 0, len(c.namespaces))
+	for ns := range c.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// SetNamespaces updates the managed namespaces to the given ones, in addition to the static namespaces. Informers
+// are started for the added namespaces and stopped for the removed ones.
+func (c *DynamicCache) SetNamespaces(namespaces []string) error {
+	expected := set.Make(append(namespaces, c.static.AsSlice()...)...)
+
+	c.lock.Lock()
+	var removed, added []string
+	for ns := range c.namespaces {
+		if !expected.Has(ns) {
+			removed = append(removed, ns)
+		}
+	}
+	for _, ns := range expected.AsSlice() {
+		if _, exists := c.namespaces[ns]; !exists {
+			added = append(added, ns)
+		}
+	}
+	for _, ns := range removed {
+		log.Info("Stopping to manage namespace", "namespace", ns)
+		c.remove(ns)
+	}
+	for _, ns := range added {
+		log.Info("Starting to manage namespace", "namespace", ns)
+		if err := c.add(ns); err != nil {
+			c.lock.Unlock()
+			return err
+		}
+	}
+	listeners := c.listeners
+	c.lock.Unlock()
+
+	for _, ns := range removed {
+		for _, listener := range listeners {
+			listener(ns)
+		}
+	}
+	return nil
+}
+
+// add creates the cache of the given namespace, with the informers, handlers and indexes registered so far, and starts
+// it if the DynamicCache is already started. It must be called with the lock held.
+func (c *DynamicCache) add(ns string) error {
+	opts := c.opts
+	opts.Namespace = ns
+	nsCache, err := c.newCache(c.config, opts)
+	if err != nil {
+		return err
+	}
+	for _, idx := range c.indexes {
+		if err := nsCache.IndexField(idx.obj, idx.field, idx.extractValue); err != nil {
+			return err
+		}
+	}
+	for gvk, informer := range c.informers {
+		i, err := nsCache.GetInformerForKind(gvk)
+		if err != nil {
+			return err
+		}
+		informer.register(ns, i)
+	}
+	entry := &namespaceCache{Cache: nsCache, stop: make(chan struct{})}
+	c.namespaces[ns] = entry
+	if c.stop != nil {
+		c.start(ns, entry)
+	}
+	return nil
+}
+
+// remove stops the informers of the given namespace. It must be called with the lock held.
+func (c *DynamicCache) remove(ns string) {
+	close(c.namespaces[ns].stop)
+	delete(c.namespaces, ns)
+	for _, informer := range c.informers {
+		delete(informer.namespaces, ns)
+	}
+}
+
+func (c *DynamicCache) start(ns string, entry *namespaceCache) {
+	go func() {
+		if err := entry.Start(entry.stop); err != nil {
+			log.Error(err, "Failed to start namespaced informers", "namespace", ns)
+		}
+	}()
+}
+
+func (c *DynamicCache) gvkFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	return apiutil.GVKForObject(obj, c.opts.Scheme)
+}
+
+// GetInformer implements cache.Informers.
+func (c *DynamicCache) GetInformer(obj runtime.Object) (cache.Informer, error) {
+	gvk, err := c.gvkFor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetInformerForKind(gvk)
+}
+
+// GetInformerForKind implements cache.Informers.
+func (c *DynamicCache) GetInformerForKind(gvk schema.GroupVersionKind) (cache.Informer, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if informer, exists := c.informers[gvk]; exists {
+		return informer, nil
+	}
+	informer := &dynamicInformer{cache: c, namespaces: map[string]cache.Informer{}}
+	for ns, nsCache := range c.namespaces {
+		i, err := nsCache.GetInformerForKind(gvk)
+		if err != nil {
+			return nil, err
+		}
+		informer.namespaces[ns] = i
+	}
+	c.informers[gvk] = informer
+	return informer, nil
+}
+
+// Start implements cache.Informers.
+func (c *DynamicCache) Start(stopCh <-chan struct{}) error {
+	c.lock.Lock()
+	c.stop = stopCh
+	for ns, entry := range c.namespaces {
+		c.start(ns, entry)
+	}
+	c.lock.Unlock()
+
+	<-stopCh
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for ns := range c.namespaces {
+		c.remove(ns)
+	}
+	return nil
+}
+
+// WaitForCacheSync implements cache.Informers.
+func (c *DynamicCache) WaitForCacheSync(stop <-chan struct{}) bool {
+	synced := true
+	for _, nsCache := range c.caches() {
+		if !nsCache.WaitForCacheSync(stop) {
+			synced = false
+		}
+	}
+	return synced
+}
+
+// IndexField implements client.FieldIndexer.
+func (c *DynamicCache) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, nsCache := range c.namespaces {
+		if err := nsCache.IndexField(obj, field, extractValue); err != nil {
+			return err
+		}
+	}
+	c.indexes = append(c.indexes, index{obj: obj, field: field, extractValue: extractValue})
+	return nil
+}
+
+// Get implements client.Reader. Objects in namespaces that are not managed are not found.
+func (c *DynamicCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	c.lock.RLock()
+	nsCache, exists := c.namespaces[key.Namespace]
+	c.lock.RUnlock()
+	if !exists {
+		gvk, err := c.gvkFor(obj)
+		if err != nil {
+			return err
+		}
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name)
+	}
+	return nsCache.Get(ctx, key, obj)
+}
+
+// List implements client.Reader. Objects of all managed namespaces are listed if no namespace is specified.
+func (c *DynamicCache) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Namespace != corev1.NamespaceAll {
+		c.lock.RLock()
+		nsCache, exists := c.namespaces[listOpts.Namespace]
+		c.lock.RUnlock()
+		if !exists {
+			// nothing to list in a namespace that is not managed
+			return meta.SetList(list, nil)
+		}
+		return nsCache.List(ctx, list, opts...)
+	}
+
+	listAccessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	var allItems []runtime.Object
+	var resourceVersion string
+	for _, nsCache := range c.caches() {
+		listObj := list.DeepCopyObject()
+		if err := nsCache.List(ctx, listObj, opts...); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(listObj)
+		if err != nil {
+			return err
+		}
+		accessor, err := meta.ListAccessor(listObj)
+		if err != nil {
+			return fmt.Errorf("object: %T must be a list type", list)
+		}
+		allItems = append(allItems, items...)
+		resourceVersion = accessor.GetResourceVersion()
+	}
+	listAccessor.SetResourceVersion(resourceVersion)
+	return meta.SetList(list, allItems)
+}
+
+func (c *DynamicCache) caches() []cache.Cache {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	caches := make([]cache.Cache, 0, len(c.namespaces))
+	for _, nsCache := range c.namespaces {
+		caches = append(caches, nsCache)
+	}
+	return caches
+}
+
+type eventHandler struct {
+	handler      toolscache.ResourceEventHandler
+	resyncPeriod *time.Duration
+}
+
+// dynamicInformer is an informer for all the managed namespaces, including the ones added once handlers are
+// registered.
+type dynamicInformer struct {
+	cache *DynamicCache
+	// namespaces informers and handlers are protected by the cache lock
+	namespaces map[string]cache.Informer
+	handlers   []eventHandler
+	indexers   []toolscache.Indexers
+}
+
+var _ cache.Informer = &dynamicInformer{}
+
+// register adds the recorded handlers and indexers to the informer of a new namespace. It must be called with the
+// cache lock held.
+func (i *dynamicInformer) register(ns string, informer cache.Informer) {
+	i.namespaces[ns] = informer
+	for _, indexers := range i.indexers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			log.Error(err, "Failed to add indexers to a namespaced informer")
+		}
+	}
+	for _, h := range i.handlers {
+		if h.resyncPeriod != nil {
+			informer.AddEventHandlerWithResyncPeriod(h.handler, *h.resyncPeriod)
+		} else {
+			informer.AddEventHandler(h.handler)
+		}
+	}
+}
+
+// AddEventHandler implements cache.Informer.
+func (i *dynamicInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.cache.lock.Lock()
+	defer i.cache.lock.Unlock()
+	i.handlers = append(i.handlers, eventHandler{handler: handler})
+	for _, informer := range i.namespaces {
+		informer.AddEventHandler(handler)
+	}
+}
+
+// AddEventHandlerWithResyncPeriod implements cache.Informer.
+func (i *dynamicInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.cache.lock.Lock()
+	defer i.cache.lock.Unlock()
+	i.handlers = append(i.handlers, eventHandler{handler: handler, resyncPeriod: &resyncPeriod})
+	for _, informer := range i.namespaces {
+		informer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+}
+
+// AddIndexers implements cache.Informer.
+func (i *dynamicInformer) AddIndexers(indexers toolscache.Indexers) error {
+	i.cache.lock.Lock()
+	defer i.cache.lock.Unlock()
+	for _, informer := range i.namespaces {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	i.indexers = append(i.indexers, indexers)
+	return nil
+}
+
+// HasSynced implements cache.Informer.
+func (i *dynamicInformer) HasSynced() bool {
+	i.cache.lock.RLock()
+	defer i.cache.lock.RUnlock()
+	for _, informer := range i.namespaces {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
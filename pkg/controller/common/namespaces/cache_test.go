@@ -0,0 +1,70 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package namespaces
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDynamicCache_SetNamespaces(t *testing.T) {
+	fakes := map[string]*informertest.FakeInformers{}
+	c := NewDynamicCache([]string{"operator"})
+	c.newCache = func(_ *rest.Config, opts cache.Options) (cache.Cache, error) {
+		fakes[opts.Namespace] = &informertest.FakeInformers{Scheme: opts.Scheme}
+		return fakes[opts.Namespace], nil
+	}
+	_, err := c.New(nil, cache.Options{Scheme: scheme.Scheme})
+	require.NoError(t, err)
+	require.Equal(t, []string{"operator"}, c.Namespaces())
+
+	// register a handler before the namespace is added
+	var added []string
+	informer, err := c.GetInformer(&corev1.Pod{})
+	require.NoError(t, err)
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			added = append(added, obj.(*corev1.Pod).Namespace)
+		},
+	})
+	var removed []string
+	c.OnRemoved(func(namespace string) {
+		removed = append(removed, namespace)
+	})
+
+	require.NoError(t, c.SetNamespaces([]string{"a", "b"}))
+	require.Equal(t, []string{"a", "b", "operator"}, c.Namespaces())
+	// the handler receives the events of the added namespace
+	fakeInformer, err := fakes["a"].FakeInformerFor(&corev1.Pod{})
+	require.NoError(t, err)
+	fakeInformer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "pod"}})
+	require.Equal(t, []string{"a"}, added)
+
+	// the static namespaces cannot be removed
+	require.NoError(t, c.SetNamespaces([]string{"b"}))
+	require.Equal(t, []string{"b", "operator"}, c.Namespaces())
+	require.Equal(t, []string{"a"}, removed)
+	require.NoError(t, c.SetNamespaces(nil))
+	require.Equal(t, []string{"operator"}, c.Namespaces())
+	require.Equal(t, []string{"a", "b"}, removed)
+
+	// objects of namespaces not managed are not found
+	err = c.Get(context.Background(), client.ObjectKey{Namespace: "a", Name: "pod"}, &corev1.Pod{})
+	require.True(t, apierrors.IsNotFound(err))
+	var pods corev1.PodList
+	require.NoError(t, c.List(context.Background(), &pods, client.InNamespace("a")))
+	require.Empty(t, pods.Items)
+}
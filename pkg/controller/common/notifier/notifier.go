@@ -0,0 +1,235 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/sender"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// WebhookSecretAnnotationName is the annotation of a resource naming the Secret, in the namespace of the resource,
+	// holding the URL of the webhook its notifications are posted to instead of the webhook of the operator. It is
+	// ignored unless the operator allows the host of the webhook.
+	WebhookSecretAnnotationName = "elasticsearch.k8s.elastic.co/notification-webhook-secret"
+	// WebhookURLKey is the key of the webhook URL in the Secret.
+	WebhookURLKey = "url"
+	// UpgradeStallThresholdAnnotationName is the annotation of a resource overriding the duration after which an
+	// upgrade in progress is notified as stalled.
+	UpgradeStallThresholdAnnotationName = "elasticsearch.k8s.elastic.co/notification-upgrade-stall-threshold"
+
+	// DefaultUpgradeStallThreshold is the default duration after which an upgrade in progress is notified as stalled.
+	DefaultUpgradeStallThreshold = 2 * time.Hour
+
+	// maxQueuedNotifications bounds the memory used by the notifications waiting to be posted.
+	maxQueuedNotifications = 100
+	// maxAttempts is the number of times a notification is posted before it is dropped.
+	maxAttempts    = 3
+	requestTimeout = 5 * time.Second
+)
+
+// Reasons of the notifications.
+const (
+	ReasonClusterRed     = "ClusterRed"
+	ReasonUpgradeStalled = "UpgradeStalled"
+)
+
+var log = logf.Log.WithName("notifier")
+
+// Notification is posted as JSON to the webhooks. The text field is compatible with the incoming webhooks of Slack and
+// Microsoft Teams.
+type Notification struct {
+	Text      string `json:"text"`
+	Reason    string `json:"reason"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// delivery is a notification waiting to be posted to a webhook.
+type delivery struct {
+	webhook      string
+	notification Notification
+}
+
+// Notifier posts the notifications of the resources to the webhook set in their annotations, or to the webhook of the
+// operator, in the background so that the reconciliations do not wait for the webhooks. A nil Notifier posts nothing.
+type Notifier struct {
+	webhook               string
+	allowedHosts          []string
+	upgradeStallThreshold time.Duration
+	client                *http.Client
+	*sender.Sender
+}
+
+// New returns a Notifier posting to the given webhook, and to the webhooks set in the annotations of the resources
+// if their host is one of the given allowed hosts, and notifying the upgrades in progress for longer than the given
+// threshold as stalled. The webhooks of the resources are ignored if no host is allowed.
+func New(webhook string, allowedHosts []string, upgradeStallThreshold time.Duration) (*Notifier, error) {
+	if webhook != "" {
+		if err := validateURL(webhook); err != nil {
+			return nil, err
+		}
+	}
+	if upgradeStallThreshold <= 0 {
+		return nil, errors.Errorf("invalid upgrade stall threshold %s, expected a positive duration", upgradeStallThreshold)
+	}
+	n := &Notifier{
+		webhook:               webhook,
+		allowedHosts:          allowedHosts,
+		upgradeStallThreshold: upgradeStallThreshold,
+		client:                &http.Client{Timeout: requestTimeout},
+	}
+	n.Sender = sender.New(maxQueuedNotifications, maxAttempts, n.send, failed)
+	return n, nil
+}
+
+func validateURL(webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// the URL is not included in the error as it often embeds a token
+		return errors.New("invalid webhook URL, expected an absolute http or https URL")
+	}
+	return nil
+}
+
+// isAllowed returns true if the given webhook URL of a resource targets one of the allowed hosts.
+func (n *Notifier) isAllowed(webhook string) bool {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return false
+	}
+	for _, host := range n.allowedHosts {
+		if strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOwnWebhook returns true if the notifications of the given resource are posted to its own webhook.
+func (n *Notifier) hasOwnWebhook(obj metav1.Object) bool {
+	_, annotated := obj.GetAnnotations()[WebhookSecretAnnotationName]
+	return annotated && len(n.allowedHosts) > 0
+}
+
+// Enabled returns true if the notifications of the given resource are posted to a webhook. A resource whose webhook
+// Secret cannot be read is considered enabled for Notify to report the error.
+func (n *Notifier) Enabled(c k8s.Client, obj metav1.Object) bool {
+	if n == nil {
+		return false
+	}
+	webhook, err := n.webhookOf(c, obj)
+	return err != nil || webhook != ""
+}
+
+// UpgradeStallThreshold returns the duration after which an upgrade of the given resource is notified as stalled.
+func (n *Notifier) UpgradeStallThreshold(obj metav1.Object) time.Duration {
+	if threshold, exists := obj.GetAnnotations()[UpgradeStallThresholdAnnotationName]; exists {
+		d, err := time.ParseDuration(threshold)
+		if err == nil && d > 0 {
+			return d
+		}
+		log.Info("Ignoring invalid upgrade stall threshold", "namespace", obj.GetNamespace(), "name", obj.GetName(),
+			"annotation", UpgradeStallThresholdAnnotationName, "value", threshold)
+	}
+	if n == nil {
+		return DefaultUpgradeStallThreshold
+	}
+	return n.upgradeStallThreshold
+}
+
+// Notify queues the given notification of the given resource to be posted to its webhook, if any. It returns an error
+// if the webhook of the resource is invalid or if too many notifications are waiting to be posted.
+func (n *Notifier) Notify(c k8s.Client, obj metav1.Object, notification Notification) error {
+	if n == nil {
+		return nil
+	}
+	webhook, err := n.webhookOf(c, obj)
+	if err != nil || webhook == "" {
+		return err
+	}
+	notification.Namespace = obj.GetNamespace()
+	notification.Name = obj.GetName()
+	if !n.Queue(delivery{webhook: webhook, notification: notification}) {
+		return errors.Errorf("failed to queue the %s notification, too many notifications waiting to be posted", notification.Reason)
+	}
+	return nil
+}
+
+// send posts the given delivery, it is called again by the sender if the post fails.
+func (n *Notifier) send(ctx context.Context, item interface{}) error {
+	d := item.(delivery)
+	if err := n.post(ctx, d); err != nil {
+		return err
+	}
+	log.Info("Notification posted", "namespace", d.notification.Namespace, "name", d.notification.Name,
+		"reason", d.notification.Reason)
+	return nil
+}
+
+func failed(item interface{}, err error) {
+	d := item.(delivery)
+	log.Error(err, "Failed to post notification", "namespace", d.notification.Namespace, "name", d.notification.Name)
+}
+
+func (n *Notifier) post(ctx context.Context, d delivery) error {
+	body, err := json.Marshal(d.notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, d.webhook, bytes.NewReader(body))
+	if err != nil {
+		// the URL is not included in the error as it often embeds a token
+		return errors.Errorf("failed to post the %s notification to the webhook", d.notification.Reason)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Errorf("failed to post the %s notification to the webhook", d.notification.Reason)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("failed to post the %s notification to the webhook: status code %d", d.notification.Reason, resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookOf returns the webhook URL of the given resource, read from the Secret set in its annotations if any. The
+// webhook of the resource is ignored, as when no host is allowed, if its host is not allowed: the error would
+// otherwise be reported at each reconciliation of the resource until its annotations change.
+func (n *Notifier) webhookOf(c k8s.Client, obj metav1.Object) (string, error) {
+	if !n.hasOwnWebhook(obj) {
+		return n.webhook, nil
+	}
+	secretName := obj.GetAnnotations()[WebhookSecretAnnotationName]
+	var secret corev1.Secret
+	if err := c.Get(types.NamespacedName{Namespace: obj.GetNamespace(), Name: secretName}, &secret); err != nil {
+		return "", errors.Wrapf(err, "failed to get the notification webhook secret %s", secretName)
+	}
+	webhook := string(secret.Data[WebhookURLKey])
+	if err := validateURL(webhook); err != nil {
+		return "", errors.Wrapf(err, "notification webhook secret %s", secretName)
+	}
+	if !n.isAllowed(webhook) {
+		log.V(1).Info("Ignoring notification webhook with a host not allowed by the operator",
+			"namespace", obj.GetNamespace(), "name", obj.GetName(), "secret", secretName)
+		return n.webhook, nil
+	}
+	return webhook, nil
+}
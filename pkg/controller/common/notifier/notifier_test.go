@@ -0,0 +1,167 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New("", nil, DefaultUpgradeStallThreshold)
+	require.NoError(t, err)
+	_, err = New("https://hooks.slack.com/services/T0/B0/secret", []string{"hooks.slack.com"}, time.Hour)
+	require.NoError(t, err)
+	_, err = New("hooks.slack.com/services/T0/B0/secret", nil, time.Hour)
+	require.EqualError(t, err, "invalid webhook URL, expected an absolute http or https URL")
+	_, err = New("", nil, 0)
+	require.Error(t, err)
+}
+
+func TestNotifier_UpgradeStallThreshold(t *testing.T) {
+	n, err := New("", nil, time.Hour)
+	require.NoError(t, err)
+	es := esv1.Elasticsearch{}
+	require.Equal(t, time.Hour, n.UpgradeStallThreshold(&es))
+	es.Annotations = map[string]string{UpgradeStallThresholdAnnotationName: "30m"}
+	require.Equal(t, 30*time.Minute, n.UpgradeStallThreshold(&es))
+	es.Annotations[UpgradeStallThresholdAnnotationName] = "-30m"
+	require.Equal(t, time.Hour, n.UpgradeStallThreshold(&es))
+	es.Annotations[UpgradeStallThresholdAnnotationName] = "half an hour"
+	require.Equal(t, time.Hour, n.UpgradeStallThreshold(&es))
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	received := make(chan string, 10)
+	handler := func(target string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			var notification Notification
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+			require.Equal(t, Notification{Text: "red", Reason: ReasonClusterRed, Namespace: "ns", Name: "es"}, notification)
+			received <- target
+		}
+	}
+	operatorWebhook := httptest.NewServer(handler("operator"))
+	defer operatorWebhook.Close()
+	clusterWebhook := httptest.NewServer(handler("cluster"))
+	defer clusterWebhook.Close()
+	clusterURL, err := url.Parse(clusterWebhook.URL)
+	require.NoError(t, err)
+
+	secret := func(name, url string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Data:       map[string][]byte{WebhookURLKey: []byte(url)},
+		}
+	}
+	c := k8s.WrappedFakeClient(
+		secret("cluster-webhook", clusterWebhook.URL),
+		secret("internal-webhook", "http://169.254.169.254/latest/meta-data"),
+		secret("invalid-webhook", "not a URL"),
+	)
+	es := func(webhookSecret string) *esv1.Elasticsearch {
+		es := &esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+		if webhookSecret != "" {
+			es.Annotations = map[string]string{WebhookSecretAnnotationName: webhookSecret}
+		}
+		return es
+	}
+	notification := Notification{Text: "red", Reason: ReasonClusterRed}
+	requireReceived := func(target string) {
+		select {
+		case got := <-received:
+			require.Equal(t, target, got)
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "notification not received", target)
+		}
+	}
+
+	// nothing is posted without a webhook
+	var n *Notifier
+	require.False(t, n.Enabled(c, es("cluster-webhook")))
+	require.NoError(t, n.Notify(c, es("cluster-webhook"), notification))
+	n, err = New("", nil, time.Hour)
+	require.NoError(t, err)
+	require.False(t, n.Enabled(c, es("")))
+	require.NoError(t, n.Notify(c, es(""), notification))
+	// the webhooks of the clusters are ignored unless their host is allowed
+	require.False(t, n.Enabled(c, es("cluster-webhook")))
+	require.NoError(t, n.Notify(c, es("cluster-webhook"), notification))
+	require.Zero(t, n.Len())
+
+	// the webhook of the cluster overrides the webhook of the operator
+	n, err = New(operatorWebhook.URL, []string{clusterURL.Hostname()}, time.Hour)
+	require.NoError(t, err)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		require.NoError(t, n.Start(stop))
+	}()
+	require.NoError(t, n.Notify(c, es("cluster-webhook"), notification))
+	requireReceived("cluster")
+	require.NoError(t, n.Notify(c, es(""), notification))
+	requireReceived("operator")
+
+	// the webhooks with a host not allowed are ignored
+	require.True(t, n.Enabled(c, es("internal-webhook")))
+	require.NoError(t, n.Notify(c, es("internal-webhook"), notification))
+	requireReceived("operator")
+
+	require.True(t, n.Enabled(c, es("missing-webhook")))
+	require.EqualError(t, n.Notify(c, es("invalid-webhook"), notification),
+		"notification webhook secret invalid-webhook: invalid webhook URL, expected an absolute http or https URL")
+	require.Error(t, n.Notify(c, es("missing-webhook"), notification))
+	require.Empty(t, received)
+
+	// a cluster with a webhook whose host is not allowed is not notified without a webhook of the operator
+	n, err = New("", []string{clusterURL.Hostname()}, time.Hour)
+	require.NoError(t, err)
+	require.True(t, n.Enabled(c, es("cluster-webhook")))
+	require.False(t, n.Enabled(c, es("internal-webhook")))
+	require.NoError(t, n.Notify(c, es("internal-webhook"), notification))
+	require.Zero(t, n.Len())
+}
+
+func TestNotifier_post(t *testing.T) {
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	n, err := New(server.URL, nil, time.Hour)
+	require.NoError(t, err)
+	d := delivery{webhook: server.URL, notification: Notification{Reason: ReasonClusterRed}}
+
+	require.EqualError(t, n.post(context.Background(), d),
+		"failed to post the ClusterRed notification to the webhook: status code 403")
+	require.NoError(t, n.post(context.Background(), d))
+}
+
+func TestNotifier_Notify_QueueFull(t *testing.T) {
+	n, err := New("http://localhost", nil, time.Hour)
+	require.NoError(t, err)
+	es := &esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	for i := 0; i < maxQueuedNotifications; i++ {
+		require.NoError(t, n.Notify(k8s.WrappedFakeClient(), es, Notification{Reason: ReasonClusterRed}))
+	}
+	require.EqualError(t, n.Notify(k8s.WrappedFakeClient(), es, Notification{Reason: ReasonClusterRed}),
+		"failed to queue the ClusterRed notification, too many notifications waiting to be posted")
+}
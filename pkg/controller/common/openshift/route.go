@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package openshift
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/maps"
+)
+
+// RouteGVK is the GroupVersionKind of the OpenShift Routes.
+var RouteGVK = schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+
+// routeManagedFields are the fields of the Routes managed by the operator. Other fields, such as the host generated by
+// OpenShift or set by the user, are left untouched.
+var routeManagedFields = [][]string{
+	{"spec", "to", "kind"},
+	{"spec", "to", "name"},
+	{"spec", "port", "targetPort"},
+	{"spec", "tls", "termination"},
+	{"spec", "tls", "insecureEdgeTerminationPolicy"},
+}
+
+// NewRoute returns the Route exposing the given HTTP Service outside of the OpenShift cluster, with the same name.
+// TLS is passed through to the application if it serves HTTPS, or else terminated by the router. Plain HTTP requests
+// are redirected to HTTPS.
+func NewRoute(svc corev1.Service, tls bool) *unstructured.Unstructured {
+	termination := "edge"
+	if tls {
+		termination = "passthrough"
+	}
+	spec := map[string]interface{}{
+		"to": map[string]interface{}{
+			"kind": "Service",
+			"name": svc.Name,
+		},
+		"tls": map[string]interface{}{
+			"termination":                   termination,
+			"insecureEdgeTerminationPolicy": "Redirect",
+		},
+	}
+	if len(svc.Spec.Ports) > 0 {
+		spec["port"] = map[string]interface{}{"targetPort": svc.Spec.Ports[0].Name}
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	route.SetGroupVersionKind(RouteGVK)
+	route.SetNamespace(svc.Namespace)
+	route.SetName(svc.Name)
+	route.SetLabels(svc.Labels)
+	return route
+}
+
+// ReconcileRoute reconciles the Route exposing the given HTTP Service.
+func ReconcileRoute(c k8s.Client, svc corev1.Service, tls bool, owner metav1.Object) error {
+	expected := NewRoute(svc, tls)
+	reconciled := &unstructured.Unstructured{}
+	reconciled.SetGroupVersionKind(RouteGVK)
+	return reconciler.ReconcileResource(reconciler.Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   expected,
+		Reconciled: reconciled,
+		NeedsUpdate: func() bool {
+			if !maps.IsSubset(expected.GetLabels(), reconciled.GetLabels()) {
+				return true
+			}
+			for _, field := range routeManagedFields {
+				expectedValue, _, _ := unstructured.NestedString(expected.Object, field...)
+				reconciledValue, _, _ := unstructured.NestedString(reconciled.Object, field...)
+				if expectedValue != reconciledValue {
+					return true
+				}
+			}
+			return false
+		},
+		UpdateReconciled: func() {
+			reconciled.SetLabels(maps.Merge(reconciled.GetLabels(), expected.GetLabels()))
+			for _, field := range routeManagedFields {
+				value, exists, _ := unstructured.NestedString(expected.Object, field...)
+				if !exists {
+					unstructured.RemoveNestedField(reconciled.Object, field...)
+					continue
+				}
+				_ = unstructured.SetNestedField(reconciled.Object, value, field...)
+			}
+		},
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package openshift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var httpService = corev1.Service{
+	ObjectMeta: metav1.ObjectMeta{
+		Namespace: "ns",
+		Name:      "es-es-http",
+		Labels:    map[string]string{"elasticsearch.k8s.elastic.co/cluster-name": "es"},
+	},
+	Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "https", Port: 9200}}},
+}
+
+func TestNewRoute(t *testing.T) {
+	route := NewRoute(httpService, true)
+	require.Equal(t, RouteGVK, route.GroupVersionKind())
+	require.Equal(t, "es-es-http", route.GetName())
+	require.Equal(t, httpService.Labels, route.GetLabels())
+	require.Equal(t, map[string]interface{}{
+		"to":   map[string]interface{}{"kind": "Service", "name": "es-es-http"},
+		"port": map[string]interface{}{"targetPort": "https"},
+		"tls":  map[string]interface{}{"termination": "passthrough", "insecureEdgeTerminationPolicy": "Redirect"},
+	}, route.Object["spec"])
+
+	// TLS is terminated by the router if the application serves plain HTTP
+	termination, _, _ := unstructured.NestedString(NewRoute(httpService, false).Object, "spec", "tls", "termination")
+	require.Equal(t, "edge", termination)
+}
+
+func TestReconcileRoute(t *testing.T) {
+	require.NoError(t, scheme.SetupScheme())
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", UID: "uid"}}
+	c := k8s.WrappedFakeClient()
+	getRoute := func() *unstructured.Unstructured {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(RouteGVK)
+		require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "es-es-http"}, route))
+		return route
+	}
+
+	require.NoError(t, ReconcileRoute(c, httpService, true, &es))
+	route := getRoute()
+	require.Equal(t, "es", route.GetOwnerReferences()[0].Name)
+
+	// the host generated by OpenShift is preserved when TLS is disabled
+	require.NoError(t, unstructured.SetNestedField(route.Object, "es.apps.example.com", "spec", "host"))
+	require.NoError(t, c.Update(route))
+	require.NoError(t, ReconcileRoute(c, httpService, false, &es))
+	route = getRoute()
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	require.Equal(t, "es.apps.example.com", host)
+	termination, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "termination")
+	require.Equal(t, "edge", termination)
+}
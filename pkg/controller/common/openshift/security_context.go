@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package openshift
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SetRestrictedSecurityContext completes the security context of the containers of the given Pod template for the
+// Pods to be admitted by the restricted Security Context Constraints: no privilege escalation, and no capabilities.
+// The user and group the containers run as are left to the SCC, which assigns them from the range of the namespace.
+// Containers explicitly set as privileged by the user, and fields set by the user, are left untouched.
+func SetRestrictedSecurityContext(podTemplate *corev1.PodTemplateSpec) {
+	for _, containers := range [][]corev1.Container{podTemplate.Spec.InitContainers, podTemplate.Spec.Containers} {
+		for i := range containers {
+			setRestricted(&containers[i])
+		}
+	}
+}
+
+func setRestricted(c *corev1.Container) {
+	if c.SecurityContext == nil {
+		c.SecurityContext = &corev1.SecurityContext{}
+	}
+	sc := c.SecurityContext
+	if sc.Privileged != nil && *sc.Privileged {
+		return
+	}
+	if sc.AllowPrivilegeEscalation == nil {
+		allowPrivilegeEscalation := false
+		sc.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	}
+	if sc.Capabilities == nil {
+		sc.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+	}
+}
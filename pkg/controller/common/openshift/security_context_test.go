@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package openshift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetRestrictedSecurityContext(t *testing.T) {
+	privileged, notPrivileged, allowPrivilegeEscalation := true, false, true
+	podTemplate := corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{Name: "prepare-fs", SecurityContext: &corev1.SecurityContext{Privileged: &notPrivileged}},
+			{Name: "sysctl", SecurityContext: &corev1.SecurityContext{Privileged: &privileged}},
+		},
+		Containers: []corev1.Container{
+			{Name: "elasticsearch"},
+			{Name: "sidecar", SecurityContext: &corev1.SecurityContext{AllowPrivilegeEscalation: &allowPrivilegeEscalation}},
+		},
+	}}
+	SetRestrictedSecurityContext(&podTemplate)
+
+	restricted := func(c corev1.Container) {
+		require.False(t, *c.SecurityContext.AllowPrivilegeEscalation, c.Name)
+		require.Equal(t, &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}, c.SecurityContext.Capabilities, c.Name)
+	}
+	restricted(podTemplate.Spec.InitContainers[0])
+	restricted(podTemplate.Spec.Containers[0])
+	// containers explicitly privileged are left to the user
+	require.Equal(t, &corev1.SecurityContext{Privileged: &privileged}, podTemplate.Spec.InitContainers[1].SecurityContext)
+	// fields set by the user are left untouched
+	require.True(t, *podTemplate.Spec.Containers[1].SecurityContext.AllowPrivilegeEscalation)
+	require.NotNil(t, podTemplate.Spec.Containers[1].SecurityContext.Capabilities)
+}
@@ -0,0 +1,67 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package operator
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+)
+
+// Settings are the operator settings that can be updated without restarting the operator.
+type Settings struct {
+	// CACertRotation defines the rotation params for CA certificates.
+	CACertRotation certificates.RotationParams
+	// CertRotation defines the rotation params for non-CA certificates.
+	CertRotation certificates.RotationParams
+	// ObservationInterval is the interval between two observations of an Elasticsearch cluster.
+	ObservationInterval time.Duration
+	// LogVerbosity is the verbosity level of the operator logs.
+	LogVerbosity int
+}
+
+// Config holds the current operator Settings, and notifies the registered listeners of their updates.
+type Config struct {
+	lock      sync.RWMutex
+	settings  Settings
+	listeners []func(Settings)
+}
+
+// NewConfig returns a Config with the given initial settings.
+func NewConfig(settings Settings) *Config {
+	return &Config{settings: settings}
+}
+
+// Get returns the current settings.
+func (c *Config) Get() Settings {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.settings
+}
+
+// Set updates the current settings, and calls the listeners if they changed.
+func (c *Config) Set(settings Settings) {
+	c.lock.Lock()
+	if reflect.DeepEqual(c.settings, settings) {
+		c.lock.Unlock()
+		return
+	}
+	c.settings = settings
+	listeners := c.listeners
+	c.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener(settings)
+	}
+}
+
+// OnChange registers a function called with the new settings each time they are updated.
+func (c *Config) OnChange(listener func(Settings)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
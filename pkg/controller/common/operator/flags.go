@@ -5,21 +5,69 @@
 package operator
 
 const (
-	AutoPortForwardFlag         = "auto-port-forward"
-	CACertRotateBeforeFlag      = "ca-cert-rotate-before"
-	CACertValidityFlag          = "ca-cert-validity"
-	CertRotateBeforeFlag        = "cert-rotate-before"
-	CertValidityFlag            = "cert-validity"
-	ContainerRegistryFlag       = "container-registry"
-	DebugHTTPListenFlag         = "debug-http-listen"
-	EnableTracingFlag           = "enable-tracing"
-	EnableWebhookFlag           = "enable-webhook"
-	EnforceRBACOnRefsFlag       = "enforce-rbac-on-refs"
-	ManageWebhookCertsFlag      = "manage-webhook-certs"
-	MaxConcurrentReconcilesFlag = "max-concurrent-reconciles"
-	MetricsPortFlag             = "metrics-port"
-	NamespacesFlag              = "namespaces"
-	OperatorNamespaceFlag       = "operator-namespace"
-	WebhookCertDirFlag          = "webhook-cert-dir"
-	WebhookSecretFlag           = "webhook-secret"
+	AWSRegionFlag                        = "aws-region"
+	AuditLogFlag                         = "audit-log"
+	AutoPortForwardFlag                  = "auto-port-forward"
+	CACertRotateBeforeFlag               = "ca-cert-rotate-before"
+	CACertValidityFlag                   = "ca-cert-validity"
+	CertRotateBeforeFlag                 = "cert-rotate-before"
+	CertValidityFlag                     = "cert-validity"
+	CloudEventsEndpointFlag              = "cloudevents-endpoint"
+	CloudEventsTypesFlag                 = "cloudevents-types"
+	ContainerRegistriesByArchFlag        = "container-registries-by-arch"
+	ContainerRegistryFlag                = "container-registry"
+	ContainerRegistryMirrorsFlag         = "container-registry-mirrors"
+	ControllersFlag                      = "controllers"
+	CredentialsStoreFlag                 = "credentials-store"
+	CredentialsStoreCacheTTLFlag         = "credentials-store-cache-ttl"
+	CredentialsStorePrefixFlag           = "credentials-store-prefix"
+	DebugHTTPListenFlag                  = "debug-http-listen"
+	ElasticsearchClientCompressionFlag   = "elasticsearch-client-compression"
+	ElasticsearchClientReadTimeoutFlag   = "elasticsearch-client-read-timeout"
+	ElasticsearchClientWriteTimeoutFlag  = "elasticsearch-client-write-timeout"
+	ElasticsearchObservationIntervalFlag = "elasticsearch-observation-interval"
+	ElasticsearchObserverProbesFlag      = "elasticsearch-observer-probes"
+	ElasticsearchObserverWorkersFlag     = "elasticsearch-observer-workers"
+	EnableTracingFlag                    = "enable-tracing"
+	EnableWebhookFlag                    = "enable-webhook"
+	EnforceRBACOnRefsFlag                = "enforce-rbac-on-refs"
+	FederationClusterNameFlag            = "federation-cluster-name"
+	FederationKubeconfigFlag             = "federation-kubeconfig"
+	FederationNamespaceFlag              = "federation-namespace"
+	ForeignFieldsFlag                    = "foreign-fields"
+	GCDryRunFlag                         = "gc-dry-run"
+	GCGracePeriodFlag                    = "gc-grace-period"
+	GeoIPDownloaderEndpointFlag          = "geoip-downloader-endpoint"
+	HealthProbePortFlag                  = "health-probe-port"
+	ImageDigestPolicyFlag                = "image-digest-policy"
+	ManageWebhookCertsFlag               = "manage-webhook-certs"
+	MaxConcurrentReconcilesFlag          = "max-concurrent-reconciles"
+	MetricsPortFlag                      = "metrics-port"
+	MonitoringElasticsearchFlag          = "monitoring-elasticsearch"
+	MonitoringIntervalFlag               = "monitoring-interval"
+	NamespacesFlag                       = "namespaces"
+	NamespacesConfigMapFlag              = "namespaces-config-map"
+	NodeDrainHandlingFlag                = "node-drain-handling"
+	NotificationUpgradeStallFlag         = "notification-upgrade-stall-threshold"
+	NotificationWebhookFlag              = "notification-webhook"
+	NotificationWebhookAllowedHostsFlag  = "notification-webhook-allowed-hosts"
+	OpenShiftFlag                        = "openshift"
+	OperatorConfigMapFlag                = "operator-config-map"
+	OperatorNamespaceFlag                = "operator-namespace"
+	PodSecurityStandardFlag              = "pod-security-standard"
+	PreemptionTaintsFlag                 = "preemption-taints"
+	PriorityClassMaxValueFlag            = "priority-class-max-value"
+	RightSizingRecommendationsFlag       = "right-sizing-recommendations"
+	ShutdownDrainTimeoutFlag             = "shutdown-drain-timeout"
+	VaultAddressFlag                     = "vault-address"
+	VaultCACertFlag                      = "vault-ca-cert"
+	VaultClientCertFlag                  = "vault-client-cert"
+	VaultClientKeyFlag                   = "vault-client-key"
+	VaultKubernetesAuthMountFlag         = "vault-kubernetes-auth-mount"
+	VaultKubernetesAuthRoleFlag          = "vault-kubernetes-auth-role"
+	VaultMountFlag                       = "vault-mount"
+	VaultNamespaceFlag                   = "vault-namespace"
+	VaultTokenFileFlag                   = "vault-token-file"
+	WebhookCertDirFlag                   = "webhook-cert-dir"
+	WebhookSecretFlag                    = "webhook-secret"
 )
@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package operator
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+)
+
+// Overlay holds operator settings overriding the ones of the operator for the resources of a namespace, so that a
+// single operator can serve tenants with different requirements.
+type Overlay struct {
+	// ContainerRegistry is the container registry of the Elastic stack images, or empty for the global one.
+	ContainerRegistry string
+	// CACertRotation overrides the rotation params for CA certificates, if not nil.
+	CACertRotation *certificates.RotationParams
+	// CertRotation overrides the rotation params for non-CA certificates, if not nil.
+	CertRotation *certificates.RotationParams
+	// AllowedSecureSettings are the patterns the names of the secure settings Secrets must match, nil if any Secret
+	// is allowed. Secrets of another namespace are identified by their namespace and name, separated by a slash.
+	AllowedSecureSettings []string
+}
+
+// OverlayResolver returns the overlay of the resources of a namespace.
+type OverlayResolver interface {
+	// Overlay returns the overlay of the given namespace, or nil if it has none.
+	Overlay(namespace string) *Overlay
+}
+
+// ValidateSecureSettings returns an error if some of the given secure settings Secrets of a resource of the given
+// namespace are not allowed by the overlay. The Secrets of other namespaces must match a namespace/name pattern, so
+// that a pattern allowing the Secrets of the namespace does not allow the Secrets of the same name in other namespaces.
+func (o *Overlay) ValidateSecureSettings(namespace string, sources []commonv1.SecretSource) error {
+	if o == nil || o.AllowedSecureSettings == nil {
+		return nil
+	}
+	var denied []string
+	for _, s := range sources {
+		name := s.SecretName
+		if s.Namespace != "" && s.Namespace != namespace {
+			name = s.Namespace + "/" + s.SecretName
+		}
+		if !o.allowsSecureSettings(name) {
+			denied = append(denied, name)
+		}
+	}
+	if len(denied) > 0 {
+		return errors.Errorf("secure settings secrets %s not allowed in this namespace, allowed names: %s",
+			strings.Join(denied, ", "), strings.Join(o.AllowedSecureSettings, ", "))
+	}
+	return nil
+}
+
+func (o *Overlay) allowsSecureSettings(secretName string) bool {
+	for _, pattern := range o.AllowedSecureSettings {
+		if matched, err := path.Match(pattern, secretName); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+)
+
+func TestOverlay_ValidateSecureSettings(t *testing.T) {
+	sources := []commonv1.SecretSource{{SecretName: "team-a-s3"}, {SecretName: "shared-gcs"}}
+
+	var noOverlay *Overlay
+	require.NoError(t, noOverlay.ValidateSecureSettings("team-a", sources))
+	require.NoError(t, (&Overlay{}).ValidateSecureSettings("team-a", sources))
+	require.NoError(t, (&Overlay{AllowedSecureSettings: []string{"team-a-*", "shared-gcs"}}).ValidateSecureSettings("team-a", sources))
+	require.EqualError(t, (&Overlay{AllowedSecureSettings: []string{"team-a-*"}}).ValidateSecureSettings("team-a", sources),
+		"secure settings secrets shared-gcs not allowed in this namespace, allowed names: team-a-*")
+	// an empty list allows no secret
+	require.Error(t, (&Overlay{AllowedSecureSettings: []string{}}).ValidateSecureSettings("team-a", sources))
+	require.NoError(t, (&Overlay{AllowedSecureSettings: []string{}}).ValidateSecureSettings("team-a", nil))
+
+	// the Secrets of other namespaces must match a namespace/name pattern
+	shared := []commonv1.SecretSource{
+		{SecretName: "team-a-s3", Namespace: "team-a"},
+		{SecretName: "team-a-s3", Namespace: "team-b"},
+	}
+	require.EqualError(t, (&Overlay{AllowedSecureSettings: []string{"team-a-*"}}).ValidateSecureSettings("team-a", shared),
+		"secure settings secrets team-b/team-a-s3 not allowed in this namespace, allowed names: team-a-*")
+	require.NoError(t, (&Overlay{AllowedSecureSettings: []string{"team-a-*", "team-b/*"}}).ValidateSecureSettings("team-a", shared))
+}
@@ -5,10 +5,20 @@
 package operator
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/elastic/cloud-on-k8s/pkg/about"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/cloudevents"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/namespaces"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/notifier"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/shutdown"
+	logutil "github.com/elastic/cloud-on-k8s/pkg/utils/log"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
 	"go.elastic.co/apm"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Parameters contain parameters to create new operators.
@@ -17,14 +27,105 @@ type Parameters struct {
 	OperatorNamespace string
 	// OperatorInfo is information about the operator
 	OperatorInfo about.OperatorInfo
+	// APIReader reads the cluster-scoped resources directly from the API server, as the cache of the operator may be
+	// restricted to the managed namespaces, or nil to read them from the cache
+	APIReader client.Reader
 	// Dialer is used to create the Elasticsearch HTTP client.
 	Dialer net.Dialer
+	// ElasticsearchClientCompression compresses the bodies of the requests to Elasticsearch with gzip
+	ElasticsearchClientCompression bool
+	// ElasticsearchClientReadTimeout is the timeout of the requests retrieving the state of Elasticsearch, or 0
+	ElasticsearchClientReadTimeout time.Duration
+	// ElasticsearchClientWriteTimeout is the timeout of the requests changing the state of Elasticsearch, or 0
+	ElasticsearchClientWriteTimeout time.Duration
 	// CACertRotation defines the rotation params for CA certificates.
 	CACertRotation certificates.RotationParams
 	// CertRotation defines the rotation params for non-CA certificates.
 	CertRotation certificates.RotationParams
+	// CloudEvents publishes the lifecycle milestones of the Elasticsearch clusters as CloudEvents, or nil
+	CloudEvents *cloudevents.Publisher
 	// MaxConcurrentReconciles controls the number of goroutines per controller.
 	MaxConcurrentReconciles int
 	// Tracer is a shared APM tracer instance or nil
 	Tracer *apm.Tracer
+	// DebugMux is the request multiplexer of the debug HTTP server, on which the controllers register their debug
+	// endpoints, or nil if the debug HTTP server is disabled
+	DebugMux *http.ServeMux
+	// Drainer tracks the in-flight reconciliations to complete on shutdown, or nil
+	Drainer *shutdown.Drainer
+	// Config holds the settings that can be updated at runtime, or nil if they are set once with the fields above
+	Config *Config
+	// ManagedNamespaces is the cache of the namespaces managed by the operator if they can change at runtime, or nil
+	ManagedNamespaces *namespaces.DynamicCache
+	// GCGracePeriod is how long after creation an orphaned resource can be garbage collected
+	GCGracePeriod time.Duration
+	// GCDryRun logs the orphaned resources instead of deleting them
+	GCDryRun bool
+	// GeoIPDownloaderEndpoint is the endpoint from which the managed Elasticsearch clusters download the GeoIP database
+	// updates, unless set in their specification, or empty for the Elastic GeoIP endpoint
+	GeoIPDownloaderEndpoint string
+	// ImageDigestResolver resolves the tags of the images to the digests they are pinned to, or nil if images are
+	// deployed by tag
+	ImageDigestResolver container.DigestResolver
+	// Overlays resolves the settings overriding the ones above for the resources of a namespace, or nil
+	Overlays OverlayResolver
+	// Overlay holds the settings overriding the ones above for the namespace of the reconciled resource, or nil
+	Overlay *Overlay
+	// NodeDrainHandling migrates the data of the Elasticsearch nodes away from the Kubernetes nodes cordoned for a
+	// drain, before their Pods are evicted
+	NodeDrainHandling bool
+	// Notifier posts the notifications of the degraded Elasticsearch clusters to webhooks, or nil
+	Notifier *notifier.Notifier
+	// ObserverProbes are the specs of the probes run at each observation of an Elasticsearch cluster, in addition to
+	// the retrieval of its health
+	ObserverProbes []string
+	// ObserverBatchWorkers is the number of workers observing the Elasticsearch clusters in batches, or 0 to observe
+	// each cluster in its own goroutine
+	ObserverBatchWorkers int
+	// OpenShift enables the OpenShift profile: Routes exposing the HTTP services, security contexts compatible with the
+	// restricted Security Context Constraints, and no ownership change of the volumes by the init containers
+	OpenShift bool
+	// PodSecurityStandard is the Pod Security Standard the generated Pods comply with, unless their resource is exempt
+	PodSecurityStandard string
+	// PreemptionTaints are the keys of the taints set by the node termination handlers on the Kubernetes nodes about to
+	// be reclaimed, whose Elasticsearch nodes are migrated away
+	PreemptionTaints []string
+	// PriorityClassMaxValue is the maximum value of the priority classes created for the Elasticsearch node roles, or 0
+	// if the operator does not create priority classes
+	PriorityClassMaxValue int32
+	// RecentLogs holds the recent operator logs to include in diagnostics bundles, or nil
+	RecentLogs *logutil.RecentLogs
+	// RightSizingRecommendations adds right-sizing recommendations derived from the observed usage of the nodes to the
+	// Elasticsearch reports
+	RightSizingRecommendations bool
+}
+
+// ForNamespace returns the parameters with the overlay of the given namespace, if any.
+func (p Parameters) ForNamespace(namespace string) Parameters {
+	if p.Overlays != nil {
+		p.Overlay = p.Overlays.Overlay(namespace)
+	}
+	return p
+}
+
+// GetCACertRotation returns the current rotation params for CA certificates.
+func (p Parameters) GetCACertRotation() certificates.RotationParams {
+	if p.Overlay != nil && p.Overlay.CACertRotation != nil {
+		return *p.Overlay.CACertRotation
+	}
+	if p.Config != nil {
+		return p.Config.Get().CACertRotation
+	}
+	return p.CACertRotation
+}
+
+// GetCertRotation returns the current rotation params for non-CA certificates.
+func (p Parameters) GetCertRotation() certificates.RotationParams {
+	if p.Overlay != nil && p.Overlay.CertRotation != nil {
+		return *p.Overlay.CertRotation
+	}
+	if p.Config != nil {
+		return p.Config.Get().CertRotation
+	}
+	return p.CertRotation
 }
@@ -0,0 +1,178 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package placement
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/configmaps"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// ConfigMapType is the value of the type label identifying the ConfigMaps holding placement policies.
+const ConfigMapType = "placement-policy"
+
+// CoordinatingRole is the role of the Elasticsearch nodes that have none of the master, data, ingest and ml roles.
+const CoordinatingRole = "coordinating"
+
+// Roles are the node roles the placement policies can select.
+var Roles = []string{"master", "data", "ingest", "ml", CoordinatingRole}
+
+// Policy constrains the placement of the Elasticsearch Pods of a set of namespaces and node roles, for platform teams
+// to dedicate Kubernetes nodes to Elasticsearch without editing every resource. Policies are applied to the Pods when
+// they are rendered, and are not written to the resources.
+//
+// Example:
+//
+//   - name: dedicated-data
+//     roles: [data]
+//     nodeSelector: {dedicated: elasticsearch}
+//     tolerations: [{key: dedicated, operator: Equal, value: elasticsearch, effect: NoSchedule}]
+type Policy struct {
+	// Name identifies the policy in error messages. Policies are applied in the order of their names.
+	Name string `json:"name"`
+	// Namespaces the policy applies to. Applies to all namespaces if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Roles of the Elasticsearch nodes the policy applies to, among master, data, ingest, ml and coordinating for
+	// the nodes with none of the other roles. Applies to all the nodes if empty.
+	Roles []string `json:"roles,omitempty"`
+	// NodeSelector labels set on the Pods, overriding the values of the same labels in their Pod template.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations added to the Pods, in addition to the ones of their Pod template.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// Parse decodes the placement policies declared in the given ConfigMap data.
+// Each entry must hold a YAML list of policies.
+func Parse(data map[string]string) ([]Policy, error) {
+	// iterate in a stable order to get reproducible results
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var policies []Policy
+	for _, k := range keys {
+		var entries []Policy
+		if err := yaml.Unmarshal([]byte(data[k]), &entries); err != nil {
+			return nil, errors.Wrapf(err, "invalid placement policies in %s", k)
+		}
+		for _, p := range entries {
+			if err := p.validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid placement policy %s in %s", p.Name, k)
+			}
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+func (p Policy) validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.NodeSelector) == 0 && len(p.Tolerations) == 0 {
+		return errors.New("at least one of nodeSelector or tolerations is required")
+	}
+	for _, role := range p.Roles {
+		if !stringsutil.StringInSlice(role, Roles) {
+			return errors.Errorf("unknown role %s, expected one of %v", role, Roles)
+		}
+	}
+	return nil
+}
+
+// Load returns all the placement policies declared in the given namespace, in the order of their names.
+func Load(c k8s.Client, namespace string) ([]Policy, error) {
+	var policies []Policy
+	if err := configmaps.LoadTyped(c, namespace, ConfigMapType, func(cm corev1.ConfigMap) error {
+		p, err := Parse(cm.Data)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, p...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return policies, nil
+}
+
+// AppliesTo returns true if the policy applies to the Pods of the given namespace with the given labels.
+func (p Policy) AppliesTo(namespace string, podLabels map[string]string) bool {
+	if len(p.Namespaces) > 0 && !stringsutil.StringInSlice(namespace, p.Namespaces) {
+		return false
+	}
+	if len(p.Roles) == 0 {
+		return true
+	}
+	for _, role := range nodeRoles(podLabels) {
+		if stringsutil.StringInSlice(role, p.Roles) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeRoles returns the roles of the Elasticsearch node of the Pod with the given labels.
+func nodeRoles(podLabels map[string]string) []string {
+	var roles []string
+	for role, l := range map[string]common.TrueFalseLabel{
+		"master": label.NodeTypesMasterLabelName,
+		"data":   label.NodeTypesDataLabelName,
+		"ingest": label.NodeTypesIngestLabelName,
+		"ml":     label.NodeTypesMLLabelName,
+	} {
+		if l.HasValue(true, podLabels) {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 {
+		return []string{CoordinatingRole}
+	}
+	return roles
+}
+
+// Apply applies the given policies to the given Pod template of an Elasticsearch node of the given namespace: the
+// node selector labels of the policies override the ones of the template, and their tolerations are added to it.
+func Apply(policies []Policy, namespace string, podTemplate *corev1.PodTemplateSpec) {
+	for _, p := range policies {
+		if !p.AppliesTo(namespace, podTemplate.Labels) {
+			continue
+		}
+		for k, v := range p.NodeSelector {
+			if podTemplate.Spec.NodeSelector == nil {
+				podTemplate.Spec.NodeSelector = make(map[string]string, len(p.NodeSelector))
+			}
+			podTemplate.Spec.NodeSelector[k] = v
+		}
+		for _, toleration := range p.Tolerations {
+			if !hasToleration(podTemplate.Spec.Tolerations, toleration) {
+				podTemplate.Spec.Tolerations = append(podTemplate.Spec.Tolerations, toleration)
+			}
+		}
+	}
+}
+
+func hasToleration(tolerations []corev1.Toleration, toleration corev1.Toleration) bool {
+	for _, t := range tolerations {
+		if reflect.DeepEqual(t, toleration) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package placement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var dedicated = corev1.Toleration{
+	Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "elasticsearch", Effect: corev1.TaintEffectNoSchedule,
+}
+
+func podTemplate(master, data bool) corev1.PodTemplateSpec {
+	labels := map[string]string{}
+	label.NodeTypesMasterLabelName.Set(master, labels)
+	label.NodeTypesDataLabelName.Set(data, labels)
+	label.NodeTypesIngestLabelName.Set(false, labels)
+	label.NodeTypesMLLabelName.Set(false, labels)
+	return corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+}
+
+func TestParse(t *testing.T) {
+	policies, err := Parse(map[string]string{
+		"b.yml": `[{name: b, nodeSelector: {pool: es}}]`,
+		"a.yml": `[{name: a, namespaces: [team-a], roles: [data, coordinating], tolerations: [{key: dedicated, operator: Exists}]}]`,
+	})
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	require.Equal(t, "a", policies[0].Name)
+	require.Equal(t, []string{"data", "coordinating"}, policies[0].Roles)
+	require.Equal(t, map[string]string{"pool": "es"}, policies[1].NodeSelector)
+
+	for _, invalid := range []string{
+		`[{nodeSelector: {pool: es}}]`,
+		`[{name: a}]`,
+		`[{name: a, roles: [voting_only], nodeSelector: {pool: es}}]`,
+		`not a list`,
+	} {
+		_, err := Parse(map[string]string{"policies.yml": invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c := k8s.WrappedFakeClient(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "elastic-system", Name: "valid", Labels: map[string]string{
+				common.TypeLabelName: ConfigMapType,
+			}},
+			Data: map[string]string{"policies.yml": `[{name: z, nodeSelector: {pool: es}}, {name: a, nodeSelector: {pool: es}}]`},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "elastic-system", Name: "invalid", Labels: map[string]string{
+				common.TypeLabelName: ConfigMapType,
+			}},
+			Data: map[string]string{"policies.yml": `[{name: invalid}]`},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "elastic-system", Name: "unlabelled"},
+			Data:       map[string]string{"policies.yml": `[{name: unlabelled, nodeSelector: {pool: es}}]`},
+		},
+	)
+	policies, err := Load(c, "elastic-system")
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	require.Equal(t, "a", policies[0].Name)
+	require.Equal(t, "z", policies[1].Name)
+}
+
+func TestPolicy_AppliesTo(t *testing.T) {
+	coordinating := podTemplate(false, false)
+	tests := []struct {
+		name      string
+		policy    Policy
+		namespace string
+		labels    map[string]string
+		want      bool
+	}{
+		{
+			name:      "all namespaces and roles",
+			policy:    Policy{Name: "p"},
+			namespace: "team-a",
+			labels:    podTemplate(true, false).Labels,
+			want:      true,
+		},
+		{
+			name:      "other namespace",
+			policy:    Policy{Name: "p", Namespaces: []string{"team-b"}},
+			namespace: "team-a",
+			labels:    podTemplate(true, false).Labels,
+			want:      false,
+		},
+		{
+			name:      "one of the roles of the node",
+			policy:    Policy{Name: "p", Roles: []string{"data"}},
+			namespace: "team-a",
+			labels:    podTemplate(true, true).Labels,
+			want:      true,
+		},
+		{
+			name:      "none of the roles of the node",
+			policy:    Policy{Name: "p", Roles: []string{"data"}},
+			namespace: "team-a",
+			labels:    podTemplate(true, false).Labels,
+			want:      false,
+		},
+		{
+			name:      "coordinating node",
+			policy:    Policy{Name: "p", Roles: []string{CoordinatingRole}},
+			namespace: "team-a",
+			labels:    coordinating.Labels,
+			want:      true,
+		},
+		{
+			name:      "coordinating role of a master node",
+			policy:    Policy{Name: "p", Roles: []string{CoordinatingRole}},
+			namespace: "team-a",
+			labels:    podTemplate(true, false).Labels,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.policy.AppliesTo(tt.namespace, tt.labels))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	policies := []Policy{
+		{Name: "a-all", NodeSelector: map[string]string{"pool": "elasticsearch"}},
+		{
+			Name:         "b-data",
+			Roles:        []string{"data"},
+			NodeSelector: map[string]string{"dedicated": "elasticsearch", "pool": "elasticsearch-data"},
+			Tolerations:  []corev1.Toleration{dedicated},
+		},
+	}
+
+	master := podTemplate(true, false)
+	Apply(policies, "team-a", &master)
+	require.Equal(t, map[string]string{"pool": "elasticsearch"}, master.Spec.NodeSelector)
+	require.Empty(t, master.Spec.Tolerations)
+
+	// the policies override the node selector of the template, and complete its tolerations
+	data := podTemplate(false, true)
+	data.Spec.NodeSelector = map[string]string{"pool": "user", "disk": "ssd"}
+	data.Spec.Tolerations = []corev1.Toleration{dedicated, {Key: "user", Operator: corev1.TolerationOpExists}}
+	Apply(policies, "team-a", &data)
+	require.Equal(t, map[string]string{"pool": "elasticsearch-data", "dedicated": "elasticsearch", "disk": "ssd"}, data.Spec.NodeSelector)
+	require.Equal(t, []corev1.Toleration{dedicated, {Key: "user", Operator: corev1.TolerationOpExists}}, data.Spec.Tolerations)
+
+	// no policies
+	unchanged := podTemplate(false, true)
+	Apply(nil, "team-a", &unchanged)
+	require.Equal(t, podTemplate(false, true), unchanged)
+}
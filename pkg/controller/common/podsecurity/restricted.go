@@ -0,0 +1,241 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package podsecurity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// StandardNone leaves the security context of the generated Pods to the user.
+	StandardNone = "none"
+	// StandardRestricted makes the generated Pods comply with the restricted Pod Security Standard.
+	StandardRestricted = "restricted"
+
+	// ExemptAnnotationName exempts a resource from the Pod Security Standard enforced by the operator, when set to
+	// "true". Its Pods are then generated as if no standard was enforced.
+	ExemptAnnotationName = "common.k8s.elastic.co/pod-security-exempt"
+
+	// DefaultUserID is the ID of the non-root user of the Elastic Stack images, the generated Pods run as.
+	DefaultUserID int64 = 1000
+
+	// TmpVolumeName is the name of the volume mounted on /tmp in the containers with a read-only root filesystem.
+	TmpVolumeName = "elastic-internal-tmp"
+	// TmpVolumeMountPath is the path of the temporary directory of the containers.
+	TmpVolumeMountPath = "/tmp"
+)
+
+// allowedVolumeSources are the volume types allowed by the restricted Pod Security Standard.
+var allowedVolumeSources = []func(corev1.VolumeSource) bool{
+	func(v corev1.VolumeSource) bool { return v.ConfigMap != nil },
+	func(v corev1.VolumeSource) bool { return v.CSI != nil },
+	func(v corev1.VolumeSource) bool { return v.DownwardAPI != nil },
+	func(v corev1.VolumeSource) bool { return v.EmptyDir != nil },
+	func(v corev1.VolumeSource) bool { return v.PersistentVolumeClaim != nil },
+	func(v corev1.VolumeSource) bool { return v.Projected != nil },
+	func(v corev1.VolumeSource) bool { return v.Secret != nil },
+}
+
+// ValidateStandard returns an error if the given Pod Security Standard is not supported by the operator.
+func ValidateStandard(standard string) error {
+	switch standard {
+	case StandardNone, StandardRestricted:
+		return nil
+	default:
+		return errors.Errorf("unsupported Pod Security Standard %q, must be %s or %s", standard, StandardNone, StandardRestricted)
+	}
+}
+
+// IsRestricted returns true if the Pods of the given resource must comply with the restricted Pod Security Standard.
+func IsRestricted(standard string, obj metav1.Object) bool {
+	return standard == StandardRestricted && obj.GetAnnotations()[ExemptAnnotationName] != "true"
+}
+
+// Enforce completes the security context of the given Pod template for the Pods of the resource to comply with the
+// given Pod Security Standard, then returns an error listing the settings of the user which still violate it.
+// The root filesystem of the containers with the given names is made read-only, with a writable /tmp directory.
+func Enforce(standard string, obj metav1.Object, podTemplate *corev1.PodTemplateSpec, readOnlyRootContainers ...string) error {
+	if !IsRestricted(standard, obj) {
+		return nil
+	}
+	SetRestrictedSecurityContext(podTemplate, readOnlyRootContainers...)
+	if violations := Violations(*podTemplate); len(violations) > 0 {
+		return errors.Errorf(
+			"Pod template violates the restricted Pod Security Standard: %s. Fix the Pod template, or exempt the resource with the %s annotation",
+			strings.Join(violations, ", "), ExemptAnnotationName,
+		)
+	}
+	return nil
+}
+
+// SetRestrictedSecurityContext completes the security context of the given Pod template with the settings required by
+// the restricted Pod Security Standard: containers running as the non-root user of the Elastic Stack images, with the
+// runtime default seccomp profile, no privilege escalation and no capabilities. Fields set by the user are left
+// untouched.
+func SetRestrictedSecurityContext(podTemplate *corev1.PodTemplateSpec, readOnlyRootContainers ...string) {
+	openshift.SetRestrictedSecurityContext(podTemplate)
+
+	spec := &podTemplate.Spec
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	sc := spec.SecurityContext
+	if sc.RunAsNonRoot == nil {
+		runAsNonRoot := true
+		sc.RunAsNonRoot = &runAsNonRoot
+	}
+	if sc.RunAsUser == nil {
+		// the images are built with a named user, which the kubelet cannot check is not root
+		userID := DefaultUserID
+		sc.RunAsUser = &userID
+	}
+	if sc.FSGroup == nil {
+		// the volumes are not chowned by the init containers, which do not run as root
+		fsGroup := DefaultUserID
+		sc.FSGroup = &fsGroup
+	}
+	if _, exists := podTemplate.Annotations[corev1.SeccompPodAnnotationKey]; !exists {
+		if podTemplate.Annotations == nil {
+			podTemplate.Annotations = map[string]string{}
+		}
+		podTemplate.Annotations[corev1.SeccompPodAnnotationKey] = corev1.SeccompProfileRuntimeDefault
+	}
+
+	setReadOnlyRootFilesystem(spec, readOnlyRootContainers)
+}
+
+// setReadOnlyRootFilesystem makes the root filesystem of the given containers read-only, unless set by the user, and
+// mounts an emptyDir volume for their temporary files.
+func setReadOnlyRootFilesystem(spec *corev1.PodSpec, containerNames []string) {
+	var mountTmp bool
+	for i, c := range spec.Containers {
+		if !stringsutil.StringInSlice(c.Name, containerNames) || c.SecurityContext.ReadOnlyRootFilesystem != nil {
+			continue
+		}
+		readOnly := true
+		spec.Containers[i].SecurityContext.ReadOnlyRootFilesystem = &readOnly
+		if !hasMountPath(c, TmpVolumeMountPath) {
+			spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      TmpVolumeName,
+				MountPath: TmpVolumeMountPath,
+			})
+			mountTmp = true
+		}
+	}
+	if mountTmp {
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name:         TmpVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+	}
+}
+
+// Violations returns the settings of the given Pod template violating the restricted Pod Security Standard.
+func Violations(podTemplate corev1.PodTemplateSpec) []string {
+	spec := podTemplate.Spec
+	var violations []string
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "host namespaces are not allowed")
+	}
+	for _, v := range spec.Volumes {
+		if !isAllowedVolume(v.VolumeSource) {
+			violations = append(violations, fmt.Sprintf("volume %s has a type which is not allowed", v.Name))
+		}
+	}
+	podSC := spec.SecurityContext
+	if podSC == nil {
+		podSC = &corev1.PodSecurityContext{}
+	}
+	for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		violations = append(violations, containerViolations(c, *podSC, podTemplate.Annotations)...)
+	}
+	return violations
+}
+
+func containerViolations(c corev1.Container, podSC corev1.PodSecurityContext, annotations map[string]string) []string {
+	sc := c.SecurityContext
+	if sc == nil {
+		sc = &corev1.SecurityContext{}
+	}
+	var violations []string
+	violation := func(msg string) {
+		violations = append(violations, fmt.Sprintf("container %s %s", c.Name, msg))
+	}
+	if sc.Privileged != nil && *sc.Privileged {
+		violation("is privileged")
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		violation("allows privilege escalation")
+	}
+	if sc.Capabilities == nil || !containsCapability(sc.Capabilities.Drop, "ALL") {
+		violation("does not drop all capabilities")
+	} else {
+		for _, capability := range sc.Capabilities.Add {
+			if capability != "NET_BIND_SERVICE" {
+				violation(fmt.Sprintf("adds capability %s", capability))
+			}
+		}
+	}
+	runAsNonRoot := podSC.RunAsNonRoot
+	if sc.RunAsNonRoot != nil {
+		runAsNonRoot = sc.RunAsNonRoot
+	}
+	runAsUser := podSC.RunAsUser
+	if sc.RunAsUser != nil {
+		runAsUser = sc.RunAsUser
+	}
+	if runAsNonRoot == nil || !*runAsNonRoot || (runAsUser != nil && *runAsUser == 0) {
+		violation("may run as root")
+	}
+	seccompProfile, exists := annotations[corev1.SeccompContainerAnnotationKeyPrefix+c.Name]
+	if !exists {
+		seccompProfile = annotations[corev1.SeccompPodAnnotationKey]
+	}
+	if seccompProfile != corev1.SeccompProfileRuntimeDefault && seccompProfile != corev1.DeprecatedSeccompProfileDockerDefault &&
+		!strings.HasPrefix(seccompProfile, "localhost/") {
+		violation("does not run with the runtime default or a local seccomp profile")
+	}
+	for _, port := range c.Ports {
+		if port.HostPort != 0 {
+			violation(fmt.Sprintf("uses host port %d", port.HostPort))
+		}
+	}
+	return violations
+}
+
+func isAllowedVolume(v corev1.VolumeSource) bool {
+	for _, allowed := range allowedVolumeSources {
+		if allowed(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCapability(capabilities []corev1.Capability, capability corev1.Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMountPath(c corev1.Container, mountPath string) bool {
+	for _, m := range c.VolumeMounts {
+		if m.MountPath == mountPath {
+			return true
+		}
+	}
+	return false
+}
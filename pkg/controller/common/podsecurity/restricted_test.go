@@ -0,0 +1,160 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package podsecurity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func podTemplate() corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "prepare-fs"}},
+		Containers:     []corev1.Container{{Name: "elasticsearch"}, {Name: "sidecar"}},
+		Volumes: []corev1.Volume{
+			{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+		},
+	}}
+}
+
+func TestValidateStandard(t *testing.T) {
+	require.NoError(t, ValidateStandard(StandardNone))
+	require.NoError(t, ValidateStandard(StandardRestricted))
+	require.Error(t, ValidateStandard("baseline"))
+}
+
+func TestIsRestricted(t *testing.T) {
+	exempt := &metav1.ObjectMeta{Annotations: map[string]string{ExemptAnnotationName: "true"}}
+	require.True(t, IsRestricted(StandardRestricted, &metav1.ObjectMeta{}))
+	require.False(t, IsRestricted(StandardRestricted, exempt))
+	require.False(t, IsRestricted(StandardNone, &metav1.ObjectMeta{}))
+}
+
+func TestSetRestrictedSecurityContext(t *testing.T) {
+	tmpl := podTemplate()
+	SetRestrictedSecurityContext(&tmpl, "elasticsearch")
+
+	require.Empty(t, Violations(tmpl))
+	require.Equal(t, DefaultUserID, *tmpl.Spec.SecurityContext.RunAsUser)
+	require.Equal(t, DefaultUserID, *tmpl.Spec.SecurityContext.FSGroup)
+	require.Equal(t, corev1.SeccompProfileRuntimeDefault, tmpl.Annotations[corev1.SeccompPodAnnotationKey])
+	// only the given containers get a read-only root filesystem, with a writable /tmp
+	require.True(t, *tmpl.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	require.Equal(t, []corev1.VolumeMount{{Name: TmpVolumeName, MountPath: TmpVolumeMountPath}}, tmpl.Spec.Containers[0].VolumeMounts)
+	require.Nil(t, tmpl.Spec.Containers[1].SecurityContext.ReadOnlyRootFilesystem)
+	require.Len(t, tmpl.Spec.Volumes, 2)
+
+	// settings of the user are left untouched
+	userID := int64(2000)
+	readOnly := false
+	tmpl = podTemplate()
+	tmpl.Annotations = map[string]string{corev1.SeccompPodAnnotationKey: "localhost/profile.json"}
+	tmpl.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsUser: &userID}
+	tmpl.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{ReadOnlyRootFilesystem: &readOnly}
+	SetRestrictedSecurityContext(&tmpl, "elasticsearch")
+
+	require.Empty(t, Violations(tmpl))
+	require.Equal(t, userID, *tmpl.Spec.SecurityContext.RunAsUser)
+	require.Equal(t, "localhost/profile.json", tmpl.Annotations[corev1.SeccompPodAnnotationKey])
+	require.False(t, *tmpl.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	require.Len(t, tmpl.Spec.Volumes, 1)
+}
+
+func TestEnforce(t *testing.T) {
+	privileged := true
+	rootUser := int64(0)
+	tests := []struct {
+		name       string
+		standard   string
+		obj        metav1.ObjectMeta
+		mutate     func(*corev1.PodTemplateSpec)
+		wantErr    bool
+		restricted bool
+	}{
+		{
+			name:     "no standard enforced",
+			standard: StandardNone,
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Spec.InitContainers[0].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+			},
+		},
+		{
+			name:       "restricted",
+			standard:   StandardRestricted,
+			mutate:     func(tmpl *corev1.PodTemplateSpec) {},
+			restricted: true,
+		},
+		{
+			name:     "exempt resource",
+			standard: StandardRestricted,
+			obj:      metav1.ObjectMeta{Annotations: map[string]string{ExemptAnnotationName: "true"}},
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Spec.HostNetwork = true
+			},
+		},
+		{
+			name:     "privileged container",
+			standard: StandardRestricted,
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Spec.InitContainers[0].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+			},
+			wantErr:    true,
+			restricted: true,
+		},
+		{
+			name:     "container running as root",
+			standard: StandardRestricted,
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Spec.Containers[1].SecurityContext = &corev1.SecurityContext{RunAsUser: &rootUser}
+			},
+			wantErr:    true,
+			restricted: true,
+		},
+		{
+			name:     "hostPath volume",
+			standard: StandardRestricted,
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Spec.Volumes = append(tmpl.Spec.Volumes, corev1.Volume{
+					Name:         "host",
+					VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}},
+				})
+			},
+			wantErr:    true,
+			restricted: true,
+		},
+		{
+			name:     "added capability",
+			standard: StandardRestricted,
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+					Capabilities: &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}, Add: []corev1.Capability{"SYS_RESOURCE"}},
+				}
+			},
+			wantErr:    true,
+			restricted: true,
+		},
+		{
+			name:     "unconfined seccomp profile of a container",
+			standard: StandardRestricted,
+			mutate: func(tmpl *corev1.PodTemplateSpec) {
+				tmpl.Annotations = map[string]string{corev1.SeccompContainerAnnotationKeyPrefix + "sidecar": "unconfined"}
+			},
+			wantErr:    true,
+			restricted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := podTemplate()
+			tt.mutate(&tmpl)
+			err := Enforce(tt.standard, &tt.obj, &tmpl, "elasticsearch")
+			require.Equal(t, tt.wantErr, err != nil, err)
+			require.Equal(t, tt.restricted, tmpl.Spec.SecurityContext != nil)
+		})
+	}
+}
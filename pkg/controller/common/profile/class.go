@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// ApplyClass copies the template of the ElasticsearchClass referenced by the given Elasticsearch resource to its empty
+// fields, and records the class in the resource annotations. The class is applied again only if the referenced class
+// changes: updating a class does not modify the resources created from it. Returns true if the resource was modified.
+func ApplyClass(c k8s.Client, es *esv1.Elasticsearch) (bool, error) {
+	if es.Spec.ClassName == "" || es.Annotations[esv1.ClassAppliedAnnotation] == es.Spec.ClassName {
+		return false, nil
+	}
+	var class esv1.ElasticsearchClass
+	err := c.Get(types.NamespacedName{Name: es.Spec.ClassName}, &class)
+	if apierrors.IsNotFound(err) {
+		return false, errors.Errorf("ElasticsearchClass %s not found", es.Spec.ClassName)
+	}
+	if err != nil {
+		return false, err
+	}
+	MergeClassTemplate(&es.Spec, class.Spec.Template)
+	if es.Annotations == nil {
+		es.Annotations = map[string]string{}
+	}
+	es.Annotations[esv1.ClassAppliedAnnotation] = class.Name
+	return true, nil
+}
+
+// MergeClassTemplate sets the fields of the template in the fields of the specification left empty. NodeSets are
+// merged by name: the empty fields of a NodeSet are set from the template NodeSet of the same name, and the template
+// NodeSets the specification does not list are appended to it.
+func MergeClassTemplate(spec *esv1.ElasticsearchSpec, template esv1.ElasticsearchSpec) {
+	template = *template.DeepCopy()
+	template.ClassName = ""
+	nodeSets := mergeNodeSets(spec.NodeSets, template.NodeSets)
+	fillEmptyFields(reflect.ValueOf(spec).Elem(), reflect.ValueOf(template))
+	spec.NodeSets = nodeSets
+}
+
+func mergeNodeSets(nodeSets []esv1.NodeSet, templates []esv1.NodeSet) []esv1.NodeSet {
+	if len(nodeSets) == 0 {
+		return templates
+	}
+	templatesByName := make(map[string]esv1.NodeSet, len(templates))
+	for _, t := range templates {
+		templatesByName[t.Name] = t
+	}
+	merged := make([]esv1.NodeSet, 0, len(nodeSets)+len(templates))
+	listed := make(map[string]bool, len(nodeSets))
+	for _, nodeSet := range nodeSets {
+		nodeSet := *nodeSet.DeepCopy()
+		if t, exists := templatesByName[nodeSet.Name]; exists {
+			fillEmptyFields(reflect.ValueOf(&nodeSet).Elem(), reflect.ValueOf(t))
+		}
+		listed[nodeSet.Name] = true
+		merged = append(merged, nodeSet)
+	}
+	for _, t := range templates {
+		if !listed[t.Name] {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+// fillEmptyFields sets the zero fields of the dst struct to the value of the same field of the src struct.
+func fillEmptyFields(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
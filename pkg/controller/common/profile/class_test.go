@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var productionClass = esv1.ElasticsearchClass{
+	ObjectMeta: metav1.ObjectMeta{Name: "production"},
+	Spec: esv1.ElasticsearchClassSpec{Template: esv1.ElasticsearchSpec{
+		Version: "7.6.0",
+		Image:   "registry.example.com/elasticsearch:7.6.0",
+		NodeSets: []esv1.NodeSet{
+			{Name: "master", Count: 3, Config: &commonv1.Config{Data: map[string]interface{}{"node.data": false}}},
+			{Name: "data", Count: 3, JVMOptions: []string{"-XX:+AlwaysPreTouch"}},
+		},
+	}},
+}
+
+func TestMergeClassTemplate(t *testing.T) {
+	spec := esv1.ElasticsearchSpec{
+		ClassName: "production",
+		Version:   "7.6.2",
+		NodeSets: []esv1.NodeSet{
+			{Name: "data", Count: 5},
+			{Name: "ingest", Count: 2},
+		},
+	}
+	MergeClassTemplate(&spec, productionClass.Spec.Template)
+
+	require.Equal(t, "production", spec.ClassName)
+	require.Equal(t, "7.6.2", spec.Version)
+	require.Equal(t, "registry.example.com/elasticsearch:7.6.0", spec.Image)
+	require.Equal(t, []esv1.NodeSet{
+		{Name: "data", Count: 5, JVMOptions: []string{"-XX:+AlwaysPreTouch"}},
+		{Name: "ingest", Count: 2},
+		{Name: "master", Count: 3, Config: &commonv1.Config{Data: map[string]interface{}{"node.data": false}}},
+	}, spec.NodeSets)
+
+	// the class template is not modified
+	spec.NodeSets[0].JVMOptions[0] = "-Xss1m"
+	require.Equal(t, []string{"-XX:+AlwaysPreTouch"}, productionClass.Spec.Template.NodeSets[1].JVMOptions)
+}
+
+func TestApplyClass(t *testing.T) {
+	c := k8s.WrappedFakeClient(&productionClass)
+	tests := []struct {
+		name        string
+		es          esv1.Elasticsearch
+		wantApplied bool
+		wantErr     bool
+	}{
+		{
+			name: "no class",
+			es:   esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Version: "7.6.0"}},
+		},
+		{
+			name:        "class applied",
+			es:          esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{ClassName: "production"}},
+			wantApplied: true,
+		},
+		{
+			name: "class already applied",
+			es: esv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{esv1.ClassAppliedAnnotation: "production"}},
+				Spec:       esv1.ElasticsearchSpec{ClassName: "production"},
+			},
+		},
+		{
+			name: "class changed",
+			es: esv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{esv1.ClassAppliedAnnotation: "development"}},
+				Spec:       esv1.ElasticsearchSpec{ClassName: "production"},
+			},
+			wantApplied: true,
+		},
+		{
+			name:    "class not found",
+			es:      esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{ClassName: "unknown"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := tt.es
+			applied, err := ApplyClass(c, &es)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.True(t, es.ClassPending())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantApplied, applied)
+			if tt.wantApplied {
+				require.Equal(t, "production", es.Annotations[esv1.ClassAppliedAnnotation])
+				require.Equal(t, "7.6.0", es.Spec.Version)
+				require.Len(t, es.Spec.NodeSets, 2)
+				require.False(t, es.ClassPending())
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+)
+
+const defaultMasterNodeSetName = "master"
+
+var nodeRoles = []string{esv1.NodeMaster, esv1.NodeData, esv1.NodeIngest, esv1.NodeML}
+
+// ApplyToElasticsearch sets the defaults of the profile in the fields left empty in es.
+// When es is an update of an existing resource, old is its current version: the volume claim templates of existing
+// node sets can't be modified, so they are carried over from old rather than defaulted.
+func (d ElasticsearchDefaults) ApplyToElasticsearch(es *esv1.Elasticsearch, old *esv1.Elasticsearch) {
+	if es.Spec.Version == "" {
+		es.Spec.Version = d.Version
+	}
+	if es.Spec.PodDisruptionBudget == nil && d.PodDisruptionBudget != nil {
+		es.Spec.PodDisruptionBudget = d.PodDisruptionBudget.DeepCopy()
+	}
+	d.applyDedicatedMasters(es, old)
+	if es.Spec.Audit != nil && es.Spec.Audit.Shipping == nil && d.MonitoringRef != nil {
+		es.Spec.Audit.Shipping = &esv1.AuditShipping{ElasticsearchRef: *d.MonitoringRef}
+	}
+
+	oldNodeSets := map[string]esv1.NodeSet{}
+	if old != nil {
+		for _, nodeSet := range old.Spec.NodeSets {
+			oldNodeSets[nodeSet.Name] = nodeSet
+		}
+	}
+	for i := range es.Spec.NodeSets {
+		applyResources(&es.Spec.NodeSets[i], d.Resources)
+		if oldNodeSet, exists := oldNodeSets[es.Spec.NodeSets[i].Name]; exists {
+			preserveStorage(&es.Spec.NodeSets[i], oldNodeSet)
+			continue
+		}
+		applyStorage(&es.Spec.NodeSets[i], d.StorageClassName, d.StorageSize)
+	}
+}
+
+// applyDedicatedMasters turns a cluster made of a single node set with default roles into a cluster with dedicated
+// master nodes. Existing clusters are only considered if they were given dedicated masters when created, to not
+// change the topology of clusters created before the profile.
+func (d ElasticsearchDefaults) applyDedicatedMasters(es *esv1.Elasticsearch, old *esv1.Elasticsearch) {
+	if d.DedicatedMasters == nil || len(es.Spec.NodeSets) != 1 {
+		return
+	}
+	name := d.DedicatedMasters.Name
+	if name == "" {
+		name = defaultMasterNodeSetName
+	}
+	nodeSet := &es.Spec.NodeSets[0]
+	if nodeSet.Name == name || hasRoles(nodeSet.Config) {
+		return
+	}
+	if old != nil && !hasNodeSet(*old, name) {
+		return
+	}
+	if nodeSet.Config == nil {
+		nodeSet.Config = &commonv1.Config{}
+	}
+	if nodeSet.Config.Data == nil {
+		nodeSet.Config.Data = map[string]interface{}{}
+	}
+	nodeSet.Config.Data[esv1.NodeMaster] = false
+	es.Spec.NodeSets = append(es.Spec.NodeSets, esv1.NodeSet{
+		Name:  name,
+		Count: d.DedicatedMasters.Count,
+		Config: &commonv1.Config{Data: map[string]interface{}{
+			esv1.NodeMaster: true,
+			esv1.NodeData:   false,
+			esv1.NodeIngest: false,
+			esv1.NodeML:     false,
+		}},
+	})
+}
+
+func hasNodeSet(es esv1.Elasticsearch, name string) bool {
+	for _, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRoles returns true if the given node set configuration sets any node role.
+func hasRoles(cfg *commonv1.Config) bool {
+	if cfg == nil {
+		return false
+	}
+	canonical, err := settings.NewCanonicalConfigFrom(cfg.Data)
+	if err != nil {
+		// let the validation report the invalid configuration, and don't touch it in the meantime
+		return true
+	}
+	return len(canonical.HasKeys(nodeRoles)) > 0
+}
+
+// applyResources sets the resources of the Elasticsearch container if it does not specify any.
+func applyResources(nodeSet *esv1.NodeSet, resources *corev1.ResourceRequirements) {
+	if resources == nil {
+		return
+	}
+	containers := nodeSet.PodTemplate.Spec.Containers
+	for i := range containers {
+		if containers[i].Name != esv1.ElasticsearchContainerName {
+			continue
+		}
+		if len(containers[i].Resources.Requests) == 0 && len(containers[i].Resources.Limits) == 0 {
+			containers[i].Resources = *resources.DeepCopy()
+		}
+		return
+	}
+	nodeSet.PodTemplate.Spec.Containers = append(containers, corev1.Container{
+		Name:      esv1.ElasticsearchContainerName,
+		Resources: *resources.DeepCopy(),
+	})
+}
+
+// preserveStorage carries over the volume claim templates of an existing node set, which can't be updated.
+func preserveStorage(nodeSet *esv1.NodeSet, old esv1.NodeSet) {
+	if len(nodeSet.VolumeClaimTemplates) == 0 {
+		nodeSet.VolumeClaimTemplates = old.VolumeClaimTemplates
+		return
+	}
+	for i, claim := range nodeSet.VolumeClaimTemplates {
+		if claim.Spec.StorageClassName != nil {
+			continue
+		}
+		for _, oldClaim := range old.VolumeClaimTemplates {
+			if oldClaim.Name == claim.Name {
+				nodeSet.VolumeClaimTemplates[i].Spec.StorageClassName = oldClaim.Spec.StorageClassName
+			}
+		}
+	}
+}
+
+// applyStorage sets the storage class and size of the data volume claim.
+func applyStorage(nodeSet *esv1.NodeSet, storageClassName *string, storageSize *resource.Quantity) {
+	if storageClassName == nil && storageSize == nil {
+		return
+	}
+	if len(nodeSet.VolumeClaimTemplates) == 0 {
+		claim := volume.DefaultDataVolumeClaim.DeepCopy()
+		if storageSize != nil {
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = *storageSize
+		}
+		nodeSet.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{*claim}
+	}
+	if storageClassName == nil {
+		return
+	}
+	for i, claim := range nodeSet.VolumeClaimTemplates {
+		if claim.Name == volume.ElasticsearchDataVolumeName && claim.Spec.StorageClassName == nil {
+			className := *storageClassName
+			nodeSet.VolumeClaimTemplates[i].Spec.StorageClassName = &className
+		}
+	}
+}
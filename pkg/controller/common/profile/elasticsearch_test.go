@@ -0,0 +1,132 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+)
+
+var (
+	fast = "fast"
+	slow = "slow"
+
+	testResources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	testDefaults = ElasticsearchDefaults{
+		Version:             "7.6.0",
+		Resources:           &testResources,
+		StorageClassName:    &fast,
+		PodDisruptionBudget: &commonv1.PodDisruptionBudgetTemplate{},
+		DedicatedMasters:    &DedicatedMasters{Count: 3},
+	}
+)
+
+func TestElasticsearchDefaults_ApplyToElasticsearch(t *testing.T) {
+	t.Run("bare minimum manifest", func(t *testing.T) {
+		es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{{Name: "default", Count: 3}}}}
+		testDefaults.ApplyToElasticsearch(&es, nil)
+
+		require.Equal(t, "7.6.0", es.Spec.Version)
+		require.NotNil(t, es.Spec.PodDisruptionBudget)
+		require.Len(t, es.Spec.NodeSets, 2)
+		require.Equal(t, false, es.Spec.NodeSets[0].Config.Data[esv1.NodeMaster])
+		require.Equal(t, "master", es.Spec.NodeSets[1].Name)
+		require.Equal(t, int32(3), es.Spec.NodeSets[1].Count)
+		require.Equal(t, true, es.Spec.NodeSets[1].Config.Data[esv1.NodeMaster])
+		for _, nodeSet := range es.Spec.NodeSets {
+			require.Equal(t, testResources, nodeSet.GetESContainerTemplate().Resources)
+			require.Len(t, nodeSet.VolumeClaimTemplates, 1)
+			require.Equal(t, volume.ElasticsearchDataVolumeName, nodeSet.VolumeClaimTemplates[0].Name)
+			require.Equal(t, &fast, nodeSet.VolumeClaimTemplates[0].Spec.StorageClassName)
+		}
+	})
+
+	t.Run("user values are preserved", func(t *testing.T) {
+		userResources := corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+		}
+		es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{
+			Version: "7.5.0",
+			NodeSets: []esv1.NodeSet{{
+				Name:   "default",
+				Count:  3,
+				Config: &commonv1.Config{Data: map[string]interface{}{"node": map[string]interface{}{"ml": false}}},
+				PodTemplate: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: esv1.ElasticsearchContainerName, Resources: userResources},
+				}}},
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+					{Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: &slow}},
+				},
+			}},
+		}}
+		expected := es.DeepCopy()
+		expected.Spec.PodDisruptionBudget = &commonv1.PodDisruptionBudgetTemplate{}
+		testDefaults.ApplyToElasticsearch(&es, nil)
+		require.Equal(t, *expected, es)
+	})
+
+	t.Run("audit logs are shipped to the monitoring cluster", func(t *testing.T) {
+		monitoring := commonv1.ObjectSelector{Name: "monitoring", Namespace: "observability"}
+		defaults := ElasticsearchDefaults{MonitoringRef: &monitoring}
+
+		es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Audit: &esv1.AuditLogging{}}}
+		defaults.ApplyToElasticsearch(&es, nil)
+		require.Equal(t, &esv1.AuditShipping{ElasticsearchRef: monitoring}, es.Spec.Audit.Shipping)
+
+		// audit logging is not enabled by the profile
+		es = esv1.Elasticsearch{}
+		defaults.ApplyToElasticsearch(&es, nil)
+		require.Nil(t, es.Spec.Audit)
+
+		// the shipping destination of the user is preserved
+		userShipping := &esv1.AuditShipping{ElasticsearchRef: commonv1.ObjectSelector{Name: "other"}}
+		es = esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Audit: &esv1.AuditLogging{Shipping: userShipping}}}
+		defaults.ApplyToElasticsearch(&es, nil)
+		require.Equal(t, userShipping, es.Spec.Audit.Shipping)
+	})
+
+	t.Run("existing node sets keep their volume claim templates", func(t *testing.T) {
+		old := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Version: "7.6.0", NodeSets: []esv1.NodeSet{
+			{Name: "existing", Count: 3, VolumeClaimTemplates: []corev1.PersistentVolumeClaim{*volume.DefaultDataVolumeClaim.DeepCopy()}},
+		}}}
+		old.Spec.NodeSets[0].VolumeClaimTemplates[0].Spec.StorageClassName = &slow
+		es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Version: "7.6.0", NodeSets: []esv1.NodeSet{
+			{Name: "existing", Count: 3},
+			{Name: "new", Count: 3},
+		}}}
+		testDefaults.ApplyToElasticsearch(&es, &old)
+
+		require.Len(t, es.Spec.NodeSets, 2)
+		require.Equal(t, old.Spec.NodeSets[0].VolumeClaimTemplates, es.Spec.NodeSets[0].VolumeClaimTemplates)
+		require.Equal(t, &fast, es.Spec.NodeSets[1].VolumeClaimTemplates[0].Spec.StorageClassName)
+	})
+
+	t.Run("no dedicated masters for existing clusters", func(t *testing.T) {
+		old := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Version: "7.6.0", NodeSets: []esv1.NodeSet{{Name: "default", Count: 3}}}}
+		es := old.DeepCopy()
+		testDefaults.ApplyToElasticsearch(es, &old)
+		require.Len(t, es.Spec.NodeSets, 1)
+		require.Nil(t, es.Spec.NodeSets[0].Config)
+	})
+
+	t.Run("dedicated masters are applied again to clusters created with them", func(t *testing.T) {
+		created := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{{Name: "default", Count: 3}}}}
+		update := created.DeepCopy()
+		testDefaults.ApplyToElasticsearch(&created, nil)
+		update.Spec.NodeSets[0].Count = 5
+		testDefaults.ApplyToElasticsearch(update, &created)
+		require.Len(t, update.Spec.NodeSets, 2)
+		require.Equal(t, created.Spec.NodeSets[1], update.Spec.NodeSets[1])
+	})
+}
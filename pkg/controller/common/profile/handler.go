@@ -0,0 +1,89 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// ElasticsearchWebhookPath is the path on which the Elasticsearch defaulting webhook is served.
+const ElasticsearchWebhookPath = "/mutate-elasticsearch-k8s-elastic-co-v1-elasticsearch"
+
+var log = logf.Log.WithName("defaults-profile")
+
+// Handler is an admission handler applying the ElasticsearchClass referenced by the resources submitted to the
+// webhook, then setting the defaults of the profile matching their namespace. Profiles are declared in ConfigMaps of
+// the operator namespace.
+type Handler struct {
+	client    k8s.Client
+	namespace string
+}
+
+var _ admission.Handler = &Handler{}
+
+// NewHandler returns a Handler reading profiles from the given namespace.
+func NewHandler(c k8s.Client, namespace string) *Handler {
+	return &Handler{client: c, namespace: namespace}
+}
+
+// Handle implements admission.Handler.
+func (h *Handler) Handle(_ context.Context, req admission.Request) admission.Response {
+	var es esv1.Elasticsearch
+	if err := json.Unmarshal(req.Object.Raw, &es); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var old *esv1.Elasticsearch
+	if req.Operation == admissionv1beta1.Update {
+		old = &esv1.Elasticsearch{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	// the operator applies the class again at reconciliation if it fails here
+	classApplied, err := ApplyClass(h.client, &es)
+	if err != nil {
+		log.Error(err, "Failed to apply Elasticsearch class, skipping", "namespace", req.Namespace, "es_name", req.Name)
+	}
+	if classApplied {
+		log.V(1).Info("Applying Elasticsearch class", "class", es.Spec.ClassName, "namespace", req.Namespace, "es_name", req.Name)
+	}
+
+	var p *Profile
+	profiles, err := h.profiles()
+	if err != nil {
+		log.Error(err, "Failed to load defaults profiles, skipping defaulting", "namespace", h.namespace)
+	} else {
+		p = ForNamespace(profiles, req.Namespace)
+	}
+	profileApplied := p != nil && p.Elasticsearch != nil
+	if !classApplied && !profileApplied {
+		return admission.Allowed("")
+	}
+
+	if profileApplied {
+		p.Elasticsearch.ApplyToElasticsearch(&es, old)
+		log.V(1).Info("Applying defaults profile", "profile", p.Name, "namespace", req.Namespace, "es_name", req.Name)
+	}
+	mutated, err := json.Marshal(es)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, mutated)
+}
+
+// profiles returns all the profiles declared in the operator namespace.
+func (h *Handler) profiles() ([]Profile, error) {
+	return LoadProfiles(h.client, h.namespace)
+}
@@ -0,0 +1,121 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const esManifest = `{
+	"apiVersion": "elasticsearch.k8s.elastic.co/v1",
+	"kind": "Elasticsearch",
+	"metadata": {"name": "es", "namespace": "ns"},
+	"spec": {"nodeSets": [{"name": "default", "count": 3}]}
+}`
+
+func profileConfigMap(namespace, name, profile string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{common.TypeLabelName: ConfigMapType},
+		},
+		Data: map[string]string{ConfigMapKey: profile},
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Namespace: "ns",
+		Name:      "es",
+		Object:    runtime.RawExtension{Raw: []byte(esManifest)},
+	}}
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantPatches bool
+	}{
+		{
+			name: "no profile",
+		},
+		{
+			name: "profile from another namespace is ignored",
+			objs: []runtime.Object{profileConfigMap("default", "p", `{elasticsearch: {version: 7.6.0}}`)},
+		},
+		{
+			name: "profile for another namespace",
+			objs: []runtime.Object{profileConfigMap("elastic-system", "p", `{namespaces: [other], elasticsearch: {version: 7.6.0}}`)},
+		},
+		{
+			name: "invalid profile is ignored",
+			objs: []runtime.Object{profileConfigMap("elastic-system", "p", `{elasticsearch: {version: latest}}`)},
+		},
+		{
+			name:        "profile is applied",
+			objs:        []runtime.Object{profileConfigMap("elastic-system", "p", `{elasticsearch: {version: 7.6.0}}`)},
+			wantPatches: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(k8s.WrappedFakeClient(tt.objs...), "elastic-system")
+			resp := h.Handle(context.Background(), req)
+			require.True(t, resp.Allowed)
+			if !tt.wantPatches {
+				require.Empty(t, resp.Patches)
+				return
+			}
+			var versionPatched bool
+			for _, p := range resp.Patches {
+				if p.Path == "/spec/version" && p.Value == "7.6.0" {
+					versionPatched = true
+				}
+			}
+			require.True(t, versionPatched)
+		})
+	}
+}
+
+func TestHandler_Handle_Class(t *testing.T) {
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Namespace: "ns",
+		Name:      "es",
+		Object: runtime.RawExtension{Raw: []byte(`{
+			"apiVersion": "elasticsearch.k8s.elastic.co/v1",
+			"kind": "Elasticsearch",
+			"metadata": {"name": "es", "namespace": "ns"},
+			"spec": {"className": "production"}
+		}`)},
+	}}
+	h := NewHandler(k8s.WrappedFakeClient(&productionClass), "elastic-system")
+	resp := h.Handle(context.Background(), req)
+	require.True(t, resp.Allowed)
+	patched := map[string]bool{}
+	for _, p := range resp.Patches {
+		patched[p.Path] = true
+	}
+	require.True(t, patched["/spec/version"])
+	require.True(t, patched["/spec/nodeSets"])
+	require.True(t, patched["/metadata/annotations"])
+
+	// unknown classes are applied by the operator once created
+	h = NewHandler(k8s.WrappedFakeClient(), "elastic-system")
+	resp = h.Handle(context.Background(), req)
+	require.True(t, resp.Allowed)
+	require.Empty(t, resp.Patches)
+}
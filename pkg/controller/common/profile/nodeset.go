@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// NodeSetConfigMapType is the value of the type label identifying the ConfigMaps holding NodeSet profiles.
+const NodeSetConfigMapType = "nodeset-profile"
+
+// NodeSetProfile holds the sizing standards of the NodeSets referencing it by name, in their profile field. Unlike
+// defaults profiles, NodeSet profiles are not written to the resources: the operator expands them at each
+// reconciliation, so that updating a profile updates all the NodeSets referencing it.
+//
+// Example:
+//
+//	resources:
+//	  requests: {memory: 16Gi, cpu: 4}
+//	  limits: {memory: 16Gi}
+//	jvmOptions: ["-XX:+AlwaysPreTouch"]
+//	storageClassName: fast-ssd
+//	storageSize: 1Ti
+type NodeSetProfile struct {
+	// Name of the profile, set from the name of the ConfigMap it was declared in.
+	Name string `json:"-"`
+	// Resources of the Elasticsearch container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// JVMOptions prepended to the JVM options of the NodeSet, which take precedence over them.
+	JVMOptions []string `json:"jvmOptions,omitempty"`
+	// StorageClassName of the data volume claim.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// StorageSize of the data volume claim added to the NodeSets that do not specify any.
+	StorageSize *resource.Quantity `json:"storageSize,omitempty"`
+}
+
+// ParseNodeSetProfile decodes the NodeSet profile declared in the data of the given ConfigMap.
+func ParseNodeSetProfile(name string, data map[string]string) (NodeSetProfile, error) {
+	p := NodeSetProfile{Name: name}
+	raw, exists := data[ConfigMapKey]
+	if !exists {
+		return p, errors.Errorf("missing %s entry", ConfigMapKey)
+	}
+	if err := yaml.Unmarshal([]byte(raw), &p); err != nil {
+		return p, errors.Wrapf(err, "invalid NodeSet profile %s", name)
+	}
+	return p, nil
+}
+
+// GetNodeSetProfile returns the NodeSet profile declared in the ConfigMap of the given name and namespace.
+func GetNodeSetProfile(c k8s.Client, namespace string, name string) (NodeSetProfile, error) {
+	var cm corev1.ConfigMap
+	err := c.Get(types.NamespacedName{Namespace: namespace, Name: name}, &cm)
+	if apierrors.IsNotFound(err) || (err == nil && cm.Labels[common.TypeLabelName] != NodeSetConfigMapType) {
+		return NodeSetProfile{}, errors.Errorf("NodeSet profile %s not found in namespace %s", name, namespace)
+	}
+	if err != nil {
+		return NodeSetProfile{}, err
+	}
+	return ParseNodeSetProfile(cm.Name, cm.Data)
+}
+
+// ApplyToNodeSet sets the values of the profile in the fields of the NodeSet left empty. The volume claim templates
+// of the existing StatefulSets of the NodeSet can't be modified: existingClaims are carried over rather than defaulted
+// when not empty.
+func (p NodeSetProfile) ApplyToNodeSet(nodeSet *esv1.NodeSet, existingClaims []corev1.PersistentVolumeClaim) {
+	applyResources(nodeSet, p.Resources)
+	if len(p.JVMOptions) > 0 {
+		nodeSet.JVMOptions = append(append([]string{}, p.JVMOptions...), nodeSet.JVMOptions...)
+	}
+	if len(existingClaims) > 0 {
+		preserveStorage(nodeSet, esv1.NodeSet{VolumeClaimTemplates: existingClaims})
+		return
+	}
+	applyStorage(nodeSet, p.StorageClassName, p.StorageSize)
+}
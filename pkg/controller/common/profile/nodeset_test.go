@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const largeHotProfile = `
+resources: {requests: {memory: 16Gi}}
+jvmOptions: ["-XX:+AlwaysPreTouch"]
+storageClassName: fast
+storageSize: 1Ti`
+
+func TestGetNodeSetProfile(t *testing.T) {
+	nodeSetProfile := profileConfigMap("elastic-system", "large-hot", largeHotProfile)
+	nodeSetProfile.Labels[common.TypeLabelName] = NodeSetConfigMapType
+	c := k8s.WrappedFakeClient(
+		nodeSetProfile,
+		profileConfigMap("elastic-system", "defaults", `elasticsearch: {version: 7.6.0}`),
+	)
+
+	p, err := GetNodeSetProfile(c, "elastic-system", "large-hot")
+	require.NoError(t, err)
+	require.Equal(t, "large-hot", p.Name)
+	require.Equal(t, []string{"-XX:+AlwaysPreTouch"}, p.JVMOptions)
+	require.Equal(t, resource.MustParse("1Ti"), *p.StorageSize)
+
+	// defaults profiles are not NodeSet profiles
+	_, err = GetNodeSetProfile(c, "elastic-system", "defaults")
+	require.Error(t, err)
+	_, err = GetNodeSetProfile(c, "elastic-system", "unknown")
+	require.Error(t, err)
+}
+
+func TestNodeSetProfile_ApplyToNodeSet(t *testing.T) {
+	p, err := ParseNodeSetProfile("large-hot", map[string]string{ConfigMapKey: largeHotProfile})
+	require.NoError(t, err)
+
+	t.Run("new NodeSet", func(t *testing.T) {
+		nodeSet := esv1.NodeSet{Name: "hot", Count: 3, JVMOptions: []string{"-XX:-AlwaysPreTouch"}}
+		p.ApplyToNodeSet(&nodeSet, nil)
+
+		require.Equal(t, *p.Resources, nodeSet.GetESContainerTemplate().Resources)
+		require.Equal(t, []string{"-XX:+AlwaysPreTouch", "-XX:-AlwaysPreTouch"}, nodeSet.JVMOptions)
+		require.Len(t, nodeSet.VolumeClaimTemplates, 1)
+		require.Equal(t, &fast, nodeSet.VolumeClaimTemplates[0].Spec.StorageClassName)
+		require.Equal(t, resource.MustParse("1Ti"), nodeSet.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage])
+	})
+
+	t.Run("existing NodeSet keeps its volume claim templates", func(t *testing.T) {
+		existing := volume.DefaultDataVolumeClaim.DeepCopy()
+		existing.Spec.StorageClassName = &slow
+		nodeSet := esv1.NodeSet{Name: "hot", Count: 3}
+		p.ApplyToNodeSet(&nodeSet, []corev1.PersistentVolumeClaim{*existing})
+
+		require.Equal(t, []corev1.PersistentVolumeClaim{*existing}, nodeSet.VolumeClaimTemplates)
+	})
+
+	t.Run("user values are preserved", func(t *testing.T) {
+		userResources := corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")}}
+		nodeSet := esv1.NodeSet{
+			Name:  "hot",
+			Count: 3,
+			PodTemplate: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: esv1.ElasticsearchContainerName, Resources: userResources},
+			}}},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: &slow}},
+			},
+		}
+		p.ApplyToNodeSet(&nodeSet, nil)
+
+		require.Equal(t, userResources, nodeSet.GetESContainerTemplate().Resources)
+		require.Equal(t, &slow, nodeSet.VolumeClaimTemplates[0].Spec.StorageClassName)
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/configmaps"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// OperatorOverlay holds the operator settings overriding the flags of the operator for the resources of the
+// namespaces of a profile.
+//
+// Example:
+//
+//	operator:
+//	  containerRegistry: registry.team-a.example.com
+//	  caCertificates: {validity: 8760h, rotateBefore: 720h}
+//	  certificates: {validity: 2160h, rotateBefore: 24h}
+//	  secureSettings:
+//	    allowedSecretNames: ["team-a-*"]
+type OperatorOverlay struct {
+	// ContainerRegistry from which the Elastic Stack images are downloaded.
+	ContainerRegistry string `json:"containerRegistry,omitempty"`
+	// CACertificates rotation parameters of the self-signed certificate authorities.
+	CACertificates *CertificateRotation `json:"caCertificates,omitempty"`
+	// Certificates rotation parameters of the certificates issued by the self-signed certificate authorities.
+	Certificates *CertificateRotation `json:"certificates,omitempty"`
+	// SecureSettings restricts the Secrets the resources can use as secure settings.
+	SecureSettings *SecureSettingsPolicy `json:"secureSettings,omitempty"`
+}
+
+// CertificateRotation holds the validity of the certificates, and how long before expiration they are rotated.
+type CertificateRotation struct {
+	Validity     metav1.Duration `json:"validity"`
+	RotateBefore metav1.Duration `json:"rotateBefore"`
+}
+
+// SecureSettingsPolicy restricts the Secrets the resources can use as secure settings.
+type SecureSettingsPolicy struct {
+	// AllowedSecretNames are glob patterns the names of the secure settings Secrets must match. The Secrets of other
+	// namespaces must match a pattern of their namespace and name, separated by a slash, such as shared/s3-*.
+	AllowedSecretNames []string `json:"allowedSecretNames"`
+}
+
+func (o OperatorOverlay) validate() error {
+	for name, r := range map[string]*CertificateRotation{"caCertificates": o.CACertificates, "certificates": o.Certificates} {
+		if r != nil && (r.RotateBefore.Duration <= 0 || r.Validity.Duration <= r.RotateBefore.Duration) {
+			return errors.Errorf("%s.validity must be larger than %s.rotateBefore, which must be positive", name, name)
+		}
+	}
+	if o.SecureSettings != nil {
+		for _, pattern := range o.SecureSettings.AllowedSecretNames {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return errors.Wrapf(err, "invalid secure settings pattern %q", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// ToOverlay returns the operator overlay described by the profile.
+func (o OperatorOverlay) ToOverlay() *operator.Overlay {
+	overlay := operator.Overlay{
+		ContainerRegistry: o.ContainerRegistry,
+		CACertRotation:    o.CACertificates.toRotationParams(),
+		CertRotation:      o.Certificates.toRotationParams(),
+	}
+	if o.SecureSettings != nil {
+		// an empty list allows no Secret
+		overlay.AllowedSecureSettings = append([]string{}, o.SecureSettings.AllowedSecretNames...)
+	}
+	return &overlay
+}
+
+func (r *CertificateRotation) toRotationParams() *certificates.RotationParams {
+	if r == nil {
+		return nil
+	}
+	return &certificates.RotationParams{Validity: r.Validity.Duration, RotateBefore: r.RotateBefore.Duration}
+}
+
+// Overlays resolves the operator overlays of the namespaces from the profiles declared in the operator namespace.
+type Overlays struct {
+	client    k8s.Client
+	namespace string
+}
+
+var _ operator.OverlayResolver = &Overlays{}
+
+// NewOverlays returns Overlays reading profiles from the given namespace.
+func NewOverlays(c k8s.Client, namespace string) *Overlays {
+	return &Overlays{client: c, namespace: namespace}
+}
+
+// Overlay implements operator.OverlayResolver. The profile applying to the namespace is the one applying the
+// defaults of its resources: its operator overlay is used, if any.
+func (o *Overlays) Overlay(namespace string) *operator.Overlay {
+	profiles, err := LoadProfiles(o.client, o.namespace)
+	if err != nil {
+		log.Error(err, "Failed to load defaults profiles, skipping operator overlay", "namespace", o.namespace)
+		return nil
+	}
+	p := ForNamespace(profiles, namespace)
+	if p == nil || p.Operator == nil {
+		return nil
+	}
+	return p.Operator.ToOverlay()
+}
+
+// LoadProfiles returns all the valid profiles declared in the given namespace.
+func LoadProfiles(c k8s.Client, namespace string) ([]Profile, error) {
+	var profiles []Profile
+	err := configmaps.LoadTyped(c, namespace, ConfigMapType, func(cm corev1.ConfigMap) error {
+		p, err := Parse(cm.Name, cm.Data)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, p)
+		return nil
+	})
+	return profiles, err
+}
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestOverlays_Overlay(t *testing.T) {
+	overlays := NewOverlays(k8s.WrappedFakeClient([]runtime.Object{
+		profileConfigMap("elastic-system", "team-a", `
+namespaces: [team-a]
+operator:
+  containerRegistry: registry.team-a.com
+  caCertificates: {validity: 8760h, rotateBefore: 720h}
+  secureSettings: {allowedSecretNames: []}`),
+		profileConfigMap("elastic-system", "team-b", `
+namespaces: [team-b]
+elasticsearch: {version: 7.6.0}`),
+		profileConfigMap("elastic-system", "default", `
+operator:
+  certificates: {validity: 2160h, rotateBefore: 24h}`),
+	}...), "elastic-system")
+
+	require.Equal(t, &operator.Overlay{
+		ContainerRegistry:     "registry.team-a.com",
+		CACertRotation:        &certificates.RotationParams{Validity: 8760 * time.Hour, RotateBefore: 720 * time.Hour},
+		AllowedSecureSettings: []string{},
+	}, overlays.Overlay("team-a"))
+	// the profile of the namespace has no operator overlay
+	require.Nil(t, overlays.Overlay("team-b"))
+	require.Equal(t, &operator.Overlay{
+		CertRotation: &certificates.RotationParams{Validity: 2160 * time.Hour, RotateBefore: 24 * time.Hour},
+	}, overlays.Overlay("team-c"))
+}
+
+func TestOverlays_ParametersForNamespace(t *testing.T) {
+	overlays := NewOverlays(k8s.WrappedFakeClient(profileConfigMap("elastic-system", "team-a", `
+namespaces: [team-a]
+operator:
+  certificates: {validity: 2160h, rotateBefore: 24h}`)), "elastic-system")
+	defaultRotation := certificates.RotationParams{Validity: certificates.DefaultCertValidity, RotateBefore: certificates.DefaultRotateBefore}
+	params := operator.Parameters{
+		CACertRotation: defaultRotation,
+		CertRotation:   defaultRotation,
+		Config:         operator.NewConfig(operator.Settings{CACertRotation: defaultRotation, CertRotation: defaultRotation}),
+		Overlays:       overlays,
+	}
+
+	teamA := params.ForNamespace("team-a")
+	require.Equal(t, certificates.RotationParams{Validity: 2160 * time.Hour, RotateBefore: 24 * time.Hour}, teamA.GetCertRotation())
+	require.Equal(t, defaultRotation, teamA.GetCACertRotation())
+	require.Equal(t, defaultRotation, params.ForNamespace("team-b").GetCertRotation())
+}
@@ -0,0 +1,147 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// ConfigMapType is the value of the type label identifying the ConfigMaps holding defaults profiles.
+	ConfigMapType = "defaults-profile"
+	// ConfigMapKey is the ConfigMap entry holding the profile.
+	ConfigMapKey = "profile.yml"
+)
+
+// Profile holds the defaults applied to the resources created in a set of namespaces.
+//
+// Example:
+//
+//	namespaces: [team-a, team-b]
+//	elasticsearch:
+//	  version: 7.6.0
+//	  storageClassName: fast-ssd
+//	  resources:
+//	    requests: {memory: 4Gi, cpu: 1}
+//	    limits: {memory: 4Gi}
+//	  podDisruptionBudget:
+//	    spec: {maxUnavailable: 1}
+//	  dedicatedMasters:
+//	    count: 3
+//	  monitoringRef:
+//	    name: monitoring
+//	    namespace: observability
+//	operator:
+//	  containerRegistry: registry.team-a.example.com
+type Profile struct {
+	// Name of the profile, set from the name of the ConfigMap it was declared in.
+	Name string `json:"-"`
+	// Namespaces the profile applies to. Applies to all namespaces if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Elasticsearch defaults.
+	Elasticsearch *ElasticsearchDefaults `json:"elasticsearch,omitempty"`
+	// Operator settings overriding the flags of the operator for the resources of the namespaces of the profile.
+	Operator *OperatorOverlay `json:"operator,omitempty"`
+}
+
+// ElasticsearchDefaults are the values set in Elasticsearch resources when left empty by the user.
+type ElasticsearchDefaults struct {
+	// Version of Elasticsearch.
+	Version string `json:"version,omitempty"`
+	// Resources of the Elasticsearch container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// StorageClassName of the data volume claims.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// StorageSize of the data volume claims added to the node sets that do not specify any.
+	StorageSize *resource.Quantity `json:"storageSize,omitempty"`
+	// PodDisruptionBudget of the cluster.
+	PodDisruptionBudget *commonv1.PodDisruptionBudgetTemplate `json:"podDisruptionBudget,omitempty"`
+	// DedicatedMasters adds a set of dedicated master nodes to clusters declaring a single node set with no roles.
+	DedicatedMasters *DedicatedMasters `json:"dedicatedMasters,omitempty"`
+	// MonitoringRef is the monitoring cluster receiving the audit logs of the clusters enabling audit logging
+	// without specifying where to ship them.
+	MonitoringRef *commonv1.ObjectSelector `json:"monitoringRef,omitempty"`
+}
+
+// DedicatedMasters describes the dedicated master node set added by a profile.
+type DedicatedMasters struct {
+	// Name of the node set. Defaults to "master".
+	Name string `json:"name,omitempty"`
+	// Count of master nodes.
+	Count int32 `json:"count"`
+}
+
+// Parse decodes the profile declared in the data of the given ConfigMap.
+func Parse(name string, data map[string]string) (Profile, error) {
+	p := Profile{Name: name}
+	raw, exists := data[ConfigMapKey]
+	if !exists {
+		return p, errors.Errorf("missing %s entry", ConfigMapKey)
+	}
+	if err := yaml.Unmarshal([]byte(raw), &p); err != nil {
+		return p, errors.Wrapf(err, "invalid defaults profile %s", name)
+	}
+	if err := p.validate(); err != nil {
+		return p, errors.Wrapf(err, "invalid defaults profile %s", name)
+	}
+	return p, nil
+}
+
+func (p Profile) validate() error {
+	if p.Operator != nil {
+		if err := p.Operator.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Elasticsearch == nil {
+		return nil
+	}
+	if p.Elasticsearch.Version != "" {
+		if _, err := version.Parse(p.Elasticsearch.Version); err != nil {
+			return err
+		}
+	}
+	if p.Elasticsearch.DedicatedMasters != nil && p.Elasticsearch.DedicatedMasters.Count < 1 {
+		return errors.New("dedicatedMasters.count must be at least 1")
+	}
+	return nil
+}
+
+// AppliesTo returns true if the profile applies to resources of the given namespace.
+func (p Profile) AppliesTo(namespace string) bool {
+	return len(p.Namespaces) == 0 || stringsutil.StringInSlice(namespace, p.Namespaces)
+}
+
+// ForNamespace returns the profile to apply to resources of the given namespace, if any.
+// Profiles explicitly listing the namespace take precedence over profiles applying to all namespaces.
+// Ties are broken by profile name.
+func ForNamespace(profiles []Profile, namespace string) *Profile {
+	var candidates []Profile
+	for _, p := range profiles {
+		if p.AppliesTo(namespace) {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iSpecific, jSpecific := len(candidates[i].Namespaces) > 0, len(candidates[j].Namespaces) > 0
+		if iSpecific != jSpecific {
+			return iSpecific
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return &candidates[0]
+}
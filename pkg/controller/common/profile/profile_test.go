@@ -0,0 +1,138 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "valid profile",
+			data: map[string]string{ConfigMapKey: `
+namespaces: [a]
+elasticsearch:
+  version: 7.6.0
+  storageClassName: fast
+  storageSize: 10Gi
+  resources: {requests: {memory: 4Gi}}
+  dedicatedMasters: {count: 3}`},
+		},
+		{
+			name:    "missing entry",
+			data:    map[string]string{"other.yml": `namespaces: [a]`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			data:    map[string]string{ConfigMapKey: `[a, b]`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			data:    map[string]string{ConfigMapKey: `{elasticsearch: {version: latest}}`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid quantity",
+			data:    map[string]string{ConfigMapKey: `{elasticsearch: {storageSize: large}}`},
+			wantErr: true,
+		},
+		{
+			name: "valid operator overlay",
+			data: map[string]string{ConfigMapKey: `
+namespaces: [a]
+operator:
+  containerRegistry: registry.example.com
+  certificates: {validity: 2160h, rotateBefore: 24h}
+  secureSettings: {allowedSecretNames: ["a-*"]}`},
+		},
+		{
+			name:    "certificates rotated before they are issued",
+			data:    map[string]string{ConfigMapKey: `{operator: {caCertificates: {validity: 1h, rotateBefore: 2h}}}`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid secure settings pattern",
+			data:    map[string]string{ConfigMapKey: `{operator: {secureSettings: {allowedSecretNames: ["[a-"]}}}`},
+			wantErr: true,
+		},
+		{
+			name:    "no master nodes",
+			data:    map[string]string{ConfigMapKey: `{elasticsearch: {dedicatedMasters: {count: 0}}}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse("profile", tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "profile", p.Name)
+		})
+	}
+}
+
+func TestForNamespace(t *testing.T) {
+	all := Profile{Name: "all"}
+	allToo := Profile{Name: "all-too"}
+	teamA := Profile{Name: "team-a", Namespaces: []string{"a"}}
+	teamB := Profile{Name: "team-b", Namespaces: []string{"b", "c"}}
+	tests := []struct {
+		name      string
+		profiles  []Profile
+		namespace string
+		want      string
+	}{
+		{
+			name:      "no profiles",
+			namespace: "a",
+		},
+		{
+			name:      "no matching profile",
+			profiles:  []Profile{teamA, teamB},
+			namespace: "d",
+		},
+		{
+			name:      "profile for all namespaces",
+			profiles:  []Profile{teamA, all},
+			namespace: "d",
+			want:      "all",
+		},
+		{
+			name:      "specific profile takes precedence",
+			profiles:  []Profile{all, teamA, teamB},
+			namespace: "c",
+			want:      "team-b",
+		},
+		{
+			name:      "ties are broken by name",
+			profiles:  []Profile{all, allToo},
+			namespace: "d",
+			want:      "all",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForNamespace(tt.profiles, tt.namespace)
+			if tt.want == "" {
+				require.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			require.Equal(t, tt.want, got.Name)
+		})
+	}
+}
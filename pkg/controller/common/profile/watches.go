@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// WatchOverlays triggers the reconciliation of the resources of the given list type in the namespaces of the defaults
+// profiles of the operator namespace when they change, for the operator overlays of the profiles to be applied.
+func WatchOverlays(c controller.Controller, k8sClient k8s.Client, operatorNamespace string, list runtime.Object) error {
+	isProfile := func(meta metav1.Object) bool {
+		return meta.GetNamespace() == operatorNamespace && meta.GetLabels()[common.TypeLabelName] == ConfigMapType
+	}
+	return c.Watch(
+		&source.Kind{Type: &corev1.ConfigMap{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			// on updates, both the old and the new profiles are mapped: the resources losing the overlay are reconciled
+			ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+				cm, ok := object.Object.(*corev1.ConfigMap)
+				if !ok || !isProfile(object.Meta) {
+					return nil
+				}
+				p, err := Parse(cm.Name, cm.Data)
+				if err != nil {
+					// invalid profiles are ignored
+					return nil
+				}
+				return requestsInNamespaces(k8sClient, list, p.Namespaces)
+			}),
+		},
+		predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return isProfile(e.Meta) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return isProfile(e.Meta) },
+			GenericFunc: func(e event.GenericEvent) bool { return isProfile(e.Meta) },
+			// also catch the ConfigMaps that stop holding defaults profiles
+			UpdateFunc: func(e event.UpdateEvent) bool { return isProfile(e.MetaOld) || isProfile(e.MetaNew) },
+		},
+	)
+}
+
+// requestsInNamespaces returns the requests to reconcile the resources of the given list type in the given namespaces,
+// or in all namespaces if empty.
+func requestsInNamespaces(k8sClient k8s.Client, list runtime.Object, namespaces []string) []reconcile.Request {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	var requests []reconcile.Request
+	for _, namespace := range namespaces {
+		resources := list.DeepCopyObject()
+		if err := k8sClient.List(resources, client.InNamespace(namespace)); err != nil {
+			log.Error(err, "Failed to list the resources to apply the operator overlays to", "namespace", namespace)
+			continue
+		}
+		items, err := meta.ExtractList(resources)
+		if err != nil {
+			log.Error(err, "Failed to list the resources to apply the operator overlays to", "namespace", namespace)
+			continue
+		}
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()},
+			})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_requestsInNamespaces(t *testing.T) {
+	controllerscheme.SetupScheme()
+	c := k8s.WrappedFakeClient(
+		&esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "es"}},
+		&esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "es"}},
+		&esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "team-c", Name: "es"}},
+	)
+	request := func(namespace string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "es"}}
+	}
+
+	// only the resources of the namespaces of the profile are reconciled
+	require.ElementsMatch(t, []reconcile.Request{request("team-a"), request("team-b")},
+		requestsInNamespaces(c, &esv1.ElasticsearchList{}, []string{"team-a", "team-b", "team-d"}))
+	// a profile without namespaces applies to all of them
+	require.ElementsMatch(t, []reconcile.Request{request("team-a"), request("team-b"), request("team-c")},
+		requestsInNamespaces(c, &esv1.ElasticsearchList{}, nil))
+}
@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/configmaps"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// WebhookPath is the path on which the resource quotas validating webhook is served.
+	WebhookPath = "/validate-quotas-k8s-elastic-co"
+	// ConfigMapType is the value of the type label identifying the ConfigMaps holding resource quotas.
+	ConfigMapType = "resource-quota"
+)
+
+var log = logf.Log.WithName("resource-quota")
+
+// Handler is an admission handler that rejects the Elasticsearch resources which would make their namespace exceed
+// the resource quotas declared in ConfigMaps of the operator namespace.
+type Handler struct {
+	client    k8s.Client
+	apiReader client.Reader
+	namespace string
+}
+
+var _ admission.Handler = &Handler{}
+
+// NewHandler returns a Handler reading quotas from the given namespace, and the RuntimeClasses of the clusters with the
+// given API reader.
+func NewHandler(c k8s.Client, apiReader client.Reader, namespace string) *Handler {
+	return &Handler{client: c, apiReader: apiReader, namespace: namespace}
+}
+
+// Handle implements admission.Handler.
+func (h *Handler) Handle(_ context.Context, req admission.Request) admission.Response {
+	quotas, err := Load(h.client, h.namespace)
+	if err != nil {
+		log.Error(err, "Failed to load resource quotas, skipping quotas enforcement", "namespace", h.namespace)
+		return admission.Allowed("")
+	}
+	quotas = ForNamespace(quotas, req.Namespace)
+	if len(quotas) == 0 {
+		return admission.Allowed("")
+	}
+
+	var es esv1.Elasticsearch
+	if err := json.Unmarshal(req.Object.Raw, &es); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// the object of the request may not have its namespace set yet
+	es.Namespace = req.Namespace
+
+	violations, err := Violations(h.client, h.apiReader, quotas, es)
+	if err != nil {
+		log.Error(err, "Failed to compute resource usage, skipping quotas enforcement", "namespace", req.Namespace)
+		return admission.Allowed("")
+	}
+	if len(violations) == 0 {
+		return admission.Allowed("")
+	}
+	if req.Operation == admissionv1beta1.Update {
+		var old esv1.Elasticsearch
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// do not prevent the tenant from scaling down, or from updating a cluster which exceeded the quota
+		// before it was declared
+		overheads := newOverheads(h.client, h.apiReader)
+		if !increases(overheads.usageOf(old), overheads.usageOf(es)) {
+			return admission.Allowed("")
+		}
+	}
+	log.V(1).Info("Resource rejected by resource quotas",
+		"namespace", req.Namespace, "es_name", req.Name, "violations", violations)
+	return admission.Denied("resource quota exceeded: " + strings.Join(violations, "; "))
+}
+
+// Load returns all the quotas declared in the given namespace.
+func Load(c k8s.Client, namespace string) ([]Quota, error) {
+	var quotas []Quota
+	err := configmaps.LoadTyped(c, namespace, ConfigMapType, func(cm corev1.ConfigMap) error {
+		q, err := Parse(cm.Data)
+		if err != nil {
+			return err
+		}
+		quotas = append(quotas, q...)
+		return nil
+	})
+	return quotas, err
+}
+
+// Violations returns the limits of the given quotas exceeded by the Elasticsearch clusters of the namespace of the
+// given cluster, with the given cluster replacing its current version. The pod overhead of the RuntimeClasses of the
+// clusters, read with the given API reader or with the client if nil, is included in their memory usage.
+func Violations(c k8s.Client, apiReader client.Reader, quotas []Quota, es esv1.Elasticsearch) ([]string, error) {
+	var clusters esv1.ElasticsearchList
+	if err := c.List(&clusters, client.InNamespace(es.Namespace)); err != nil {
+		return nil, err
+	}
+	overheads := newOverheads(c, apiReader)
+	usage := overheads.usageOf(es)
+	for _, cluster := range clusters.Items {
+		if cluster.Name == es.Name || !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		usage.Add(overheads.usageOf(cluster))
+	}
+	var violations []string
+	for _, q := range quotas {
+		violations = append(violations, q.Violations(usage)...)
+	}
+	return violations, nil
+}
+
+// increases returns true if the second usage requests more of any resource than the first one.
+func increases(before, after Usage) bool {
+	return after.Nodes > before.Nodes || after.Storage.Cmp(before.Storage) > 0 || after.Memory.Cmp(before.Memory) > 0
+}
@@ -0,0 +1,168 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func quotaConfigMap(namespace, name, quotas string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{common.TypeLabelName: ConfigMapType},
+		},
+		Data: map[string]string{"quotas.yml": quotas},
+	}
+}
+
+func request(t *testing.T, operation admissionv1beta1.Operation, obj esv1.Elasticsearch, old *esv1.Elasticsearch) admission.Request {
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "elasticsearch.k8s.elastic.co", Version: "v1", Kind: "Elasticsearch"},
+		Operation: operation,
+		Namespace: obj.Namespace,
+		Name:      obj.Name,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+	if old != nil {
+		oldRaw, err := json.Marshal(old)
+		require.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: oldRaw}
+	}
+	return req
+}
+
+func TestHandler_Handle(t *testing.T) {
+	require.NoError(t, controllerscheme.SetupScheme())
+	existing := es("existing", nodeSet("default", 3, "", ""))
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		operation   admissionv1beta1.Operation
+		obj         esv1.Elasticsearch
+		old         *esv1.Elasticsearch
+		wantAllowed bool
+	}{
+		{
+			name:        "no quotas",
+			operation:   admissionv1beta1.Create,
+			obj:         es("es", nodeSet("default", 10, "", "")),
+			wantAllowed: true,
+		},
+		{
+			name: "within quota",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, namespaces: [team-a], maxNodes: 5}]`), &existing,
+			},
+			operation:   admissionv1beta1.Create,
+			obj:         es("es", nodeSet("default", 2, "", "")),
+			wantAllowed: true,
+		},
+		{
+			name: "quota exceeded with the other clusters of the namespace",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, namespaces: [team-a], maxNodes: 5}]`), &existing,
+			},
+			operation:   admissionv1beta1.Create,
+			obj:         es("es", nodeSet("default", 3, "", "")),
+			wantAllowed: false,
+		},
+		{
+			name: "quota of another namespace",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, namespaces: [team-b], maxNodes: 5}]`), &existing,
+			},
+			operation:   admissionv1beta1.Create,
+			obj:         es("es", nodeSet("default", 3, "", "")),
+			wantAllowed: true,
+		},
+		{
+			name: "quotas from another namespace are ignored",
+			objs: []runtime.Object{
+				quotaConfigMap("default", "q", `[{name: q, maxNodes: 1}]`),
+			},
+			operation:   admissionv1beta1.Create,
+			obj:         es("es", nodeSet("default", 3, "", "")),
+			wantAllowed: true,
+		},
+		{
+			name: "the updated cluster replaces its current version",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, maxMemory: 10Gi}]`), &existing,
+			},
+			operation:   admissionv1beta1.Update,
+			obj:         es("existing", nodeSet("default", 5, "", "")),
+			old:         &existing,
+			wantAllowed: true,
+		},
+		{
+			name: "scaling up over the quota",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, maxMemory: 10Gi}]`), &existing,
+			},
+			operation:   admissionv1beta1.Update,
+			obj:         es("existing", nodeSet("default", 6, "", "")),
+			old:         &existing,
+			wantAllowed: false,
+		},
+		{
+			name: "pod overhead of the runtime class over the quota",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, maxMemory: 6Gi}]`), runtimeClass("kata", "256Mi"),
+			},
+			operation: admissionv1beta1.Create,
+			obj: func() esv1.Elasticsearch {
+				sandboxed := nodeSet("default", 3, "2Gi", "")
+				sandboxed.RuntimeClassName = "kata"
+				return es("es", sandboxed)
+			}(),
+			wantAllowed: false,
+		},
+		{
+			name: "scaling down a cluster already over the quota",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "q", `[{name: q, maxNodes: 1}]`), &existing,
+			},
+			operation:   admissionv1beta1.Update,
+			obj:         es("existing", nodeSet("default", 2, "", "")),
+			old:         &existing,
+			wantAllowed: true,
+		},
+		{
+			name: "invalid quotas do not prevent valid ones from being enforced",
+			objs: []runtime.Object{
+				quotaConfigMap("elastic-system", "invalid", `[{name: invalid}]`),
+				quotaConfigMap("elastic-system", "valid", `[{name: valid, maxNodes: 1}]`),
+			},
+			operation:   admissionv1beta1.Create,
+			obj:         es("es", nodeSet("default", 3, "", "")),
+			wantAllowed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(k8s.WrappedFakeClient(tt.objs...), nil, "elastic-system")
+			resp := h.Handle(context.Background(), request(t, tt.operation, tt.obj, tt.old))
+			require.Equal(t, tt.wantAllowed, resp.Allowed, resp.Result)
+		})
+	}
+}
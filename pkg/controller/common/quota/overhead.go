@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package quota
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	nodev1beta1 "k8s.io/api/node/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// overheads computes the usage of Elasticsearch clusters including the pod overhead of the RuntimeClasses of their
+// NodeSets, which Kubernetes charges on top of the resources of the containers. The overheads are read once.
+type overheads struct {
+	client k8s.Client
+	// apiReader reads the RuntimeClasses directly from the API server, since the operator is only allowed to get them
+	// and cannot cache them, or is nil to read them with the client
+	apiReader client.Reader
+	memory    map[string]resource.Quantity
+}
+
+func newOverheads(c k8s.Client, apiReader client.Reader) *overheads {
+	return &overheads{client: c, apiReader: apiReader, memory: map[string]resource.Quantity{}}
+}
+
+// usageOf returns the resources requested by the node sets of the given Elasticsearch cluster, including the memory
+// overhead of their RuntimeClasses.
+func (o *overheads) usageOf(es esv1.Elasticsearch) Usage {
+	u := UsageOf(es)
+	for _, nodeSet := range es.Spec.NodeSets {
+		runtimeClass := nodeSet.RuntimeClass()
+		if runtimeClass == "" {
+			continue
+		}
+		memory := o.podMemory(runtimeClass)
+		memory.Set(memory.Value() * int64(nodeSet.TotalCount()))
+		u.Memory.Add(memory)
+	}
+	return u
+}
+
+// podMemory returns the memory overhead of the Pods of the given RuntimeClass. RuntimeClasses that do not exist, or
+// cannot be read, have no overhead: the Pods cannot be created in the first case.
+func (o *overheads) podMemory(runtimeClass string) resource.Quantity {
+	if memory, exists := o.memory[runtimeClass]; exists {
+		return memory.DeepCopy()
+	}
+	memory := resource.MustParse("0")
+	var rc nodev1beta1.RuntimeClass
+	if err := o.getRuntimeClass(runtimeClass, &rc); err != nil {
+		log.V(1).Info("Ignoring the overhead of the runtime class", "runtime_class", runtimeClass, "error", err.Error())
+	} else if rc.Overhead != nil {
+		if podFixed, exists := rc.Overhead.PodFixed[corev1.ResourceMemory]; exists {
+			memory = podFixed.DeepCopy()
+		}
+	}
+	o.memory[runtimeClass] = memory
+	return memory.DeepCopy()
+}
+
+func (o *overheads) getRuntimeClass(name string, rc *nodev1beta1.RuntimeClass) error {
+	if o.apiReader == nil {
+		return o.client.Get(types.NamespacedName{Name: name}, rc)
+	}
+	return o.apiReader.Get(context.Background(), types.NamespacedName{Name: name}, rc)
+}
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	nodev1beta1 "k8s.io/api/node/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func runtimeClass(name string, memoryOverhead string) *nodev1beta1.RuntimeClass {
+	rc := &nodev1beta1.RuntimeClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Handler: name}
+	if memoryOverhead != "" {
+		rc.Overhead = &nodev1beta1.Overhead{PodFixed: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse(memoryOverhead),
+		}}
+	}
+	return rc
+}
+
+func Test_overheads_usageOf(t *testing.T) {
+	// the runtime classes are read from the API server, out of the cache of the client
+	apiReader := k8s.FakeClient(runtimeClass("kata", "256Mi"), runtimeClass("gvisor", ""))
+	kata := nodeSet("kata", 3, "2Gi", "")
+	kata.RuntimeClassName = "kata"
+	// the runtime class can be set in the Pod template
+	fromPodTemplate := nodeSet("from-pod-template", 1, "2Gi", "")
+	fromPodTemplate.PodTemplate.Spec.RuntimeClassName = &kata.RuntimeClassName
+	gvisor := nodeSet("gvisor", 2, "2Gi", "")
+	gvisor.RuntimeClassName = "gvisor"
+	missing := nodeSet("missing", 2, "2Gi", "")
+	missing.RuntimeClassName = "missing"
+	zoned := nodeSet("zoned", 1, "2Gi", "")
+	zoned.RuntimeClassName = "kata"
+	zoned.ZoneSpread = &esv1.ZoneSpread{Zones: []string{"a", "b", "c"}}
+
+	o := newOverheads(k8s.WrappedFakeClient(), apiReader)
+	memory := func(nodeSet esv1.NodeSet) string {
+		u := o.usageOf(es("es", nodeSet))
+		return u.Memory.String()
+	}
+	require.Equal(t, "6912Mi", memory(kata))
+	require.Equal(t, "2304Mi", memory(fromPodTemplate))
+	require.Equal(t, "4Gi", memory(gvisor))
+	require.Equal(t, "4Gi", memory(missing))
+	require.Equal(t, "4Gi", memory(nodeSet("default", 2, "2Gi", "")))
+	// NodeSets spread across zones deploy their count of Pods in each zone
+	require.Equal(t, "6912Mi", memory(zoned))
+	// the cached overhead is not modified
+	require.Equal(t, "6912Mi", memory(kata))
+
+	// the runtime classes are read with the client without API reader
+	o = newOverheads(k8s.WrappedFakeClient(runtimeClass("kata", "256Mi")), nil)
+	require.Equal(t, "6912Mi", memory(kata))
+}
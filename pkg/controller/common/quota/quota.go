@@ -0,0 +1,187 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package quota
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/nodespec"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// Quota limits the resources the Elasticsearch clusters of a namespace can request, for platform teams sharing
+// the operator between tenants. Each namespace the quota applies to gets its own allowance.
+//
+// Example:
+//
+//   - name: team-a
+//     namespaces: [team-a]
+//     maxNodes: 12
+//     maxStorage: 2Ti
+//     maxMemory: 96Gi
+type Quota struct {
+	// Name identifies the quota in error messages.
+	Name string `json:"name"`
+	// Namespaces the quota applies to. Applies to all namespaces if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// MaxNodes is the maximum number of Elasticsearch nodes in the namespace.
+	MaxNodes *int32 `json:"maxNodes,omitempty"`
+	// MaxStorage is the maximum storage requested by the volume claims of the Elasticsearch nodes in the namespace.
+	MaxStorage *resource.Quantity `json:"maxStorage,omitempty"`
+	// MaxMemory is the maximum memory of the Elasticsearch containers in the namespace.
+	MaxMemory *resource.Quantity `json:"maxMemory,omitempty"`
+}
+
+// Parse decodes the quotas declared in the given ConfigMap data.
+// Each entry must hold a YAML list of quotas.
+func Parse(data map[string]string) ([]Quota, error) {
+	// iterate in a stable order to get reproducible results
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var quotas []Quota
+	for _, k := range keys {
+		var entries []Quota
+		if err := yaml.Unmarshal([]byte(data[k]), &entries); err != nil {
+			return nil, errors.Wrapf(err, "invalid resource quotas in %s", k)
+		}
+		for _, q := range entries {
+			if err := q.validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid resource quota %s in %s", q.Name, k)
+			}
+			quotas = append(quotas, q)
+		}
+	}
+	return quotas, nil
+}
+
+func (q Quota) validate() error {
+	if q.Name == "" {
+		return errors.New("name is required")
+	}
+	if q.MaxNodes == nil && q.MaxStorage == nil && q.MaxMemory == nil {
+		return errors.New("at least one of maxNodes, maxStorage or maxMemory is required")
+	}
+	if q.MaxNodes != nil && *q.MaxNodes < 0 {
+		return errors.New("maxNodes must not be negative")
+	}
+	if q.MaxStorage != nil && q.MaxStorage.Sign() < 0 {
+		return errors.New("maxStorage must not be negative")
+	}
+	if q.MaxMemory != nil && q.MaxMemory.Sign() < 0 {
+		return errors.New("maxMemory must not be negative")
+	}
+	return nil
+}
+
+// AppliesTo returns true if the quota limits the resources of the given namespace.
+func (q Quota) AppliesTo(namespace string) bool {
+	return len(q.Namespaces) == 0 || stringsutil.StringInSlice(namespace, q.Namespaces)
+}
+
+// ForNamespace returns the quotas applying to the given namespace.
+func ForNamespace(quotas []Quota, namespace string) []Quota {
+	var applying []Quota
+	for _, q := range quotas {
+		if q.AppliesTo(namespace) {
+			applying = append(applying, q)
+		}
+	}
+	return applying
+}
+
+// Violations returns the limits of the quota exceeded by the given usage.
+func (q Quota) Violations(u Usage) []string {
+	var violations []string
+	if q.MaxNodes != nil && u.Nodes > *q.MaxNodes {
+		violations = append(violations, fmt.Sprintf("%s: %d nodes requested, %d allowed", q.Name, u.Nodes, *q.MaxNodes))
+	}
+	if q.MaxStorage != nil && u.Storage.Cmp(*q.MaxStorage) > 0 {
+		violations = append(violations,
+			fmt.Sprintf("%s: %s of storage requested, %s allowed", q.Name, u.Storage.String(), q.MaxStorage.String()))
+	}
+	if q.MaxMemory != nil && u.Memory.Cmp(*q.MaxMemory) > 0 {
+		violations = append(violations,
+			fmt.Sprintf("%s: %s of memory requested, %s allowed", q.Name, u.Memory.String(), q.MaxMemory.String()))
+	}
+	return violations
+}
+
+// Usage is the amount of resources requested by Elasticsearch clusters.
+type Usage struct {
+	Nodes   int32
+	Storage resource.Quantity
+	Memory  resource.Quantity
+}
+
+// Add adds the given usage to this one.
+func (u *Usage) Add(other Usage) {
+	u.Nodes += other.Nodes
+	u.Storage.Add(other.Storage)
+	u.Memory.Add(other.Memory)
+}
+
+// UsageOf returns the resources requested by the node sets of the given Elasticsearch cluster.
+func UsageOf(es esv1.Elasticsearch) Usage {
+	var u Usage
+	for _, nodeSet := range es.Spec.NodeSets {
+		// NodeSets spread across zones deploy their count of nodes in each zone
+		count := int64(nodeSet.TotalCount())
+		u.Nodes += nodeSet.TotalCount()
+
+		storage := nodeStorage(nodeSet)
+		storage.Set(storage.Value() * count)
+		u.Storage.Add(storage)
+
+		memory := nodeMemory(nodeSet)
+		memory.Set(memory.Value() * count)
+		u.Memory.Add(memory)
+	}
+	return u
+}
+
+// nodeStorage returns the storage requested by the volume claims of a node of the node set.
+func nodeStorage(nodeSet esv1.NodeSet) resource.Quantity {
+	claims := nodeSet.VolumeClaimTemplates
+	if len(claims) == 0 {
+		// the default data volume claim is used
+		claims = volume.DefaultVolumeClaimTemplates
+	}
+	total := resource.MustParse("0")
+	for _, claim := range claims {
+		if storage, exists := claim.Spec.Resources.Requests[corev1.ResourceStorage]; exists {
+			total.Add(storage)
+		}
+	}
+	return total
+}
+
+// nodeMemory returns the memory of the Elasticsearch container of a node of the node set.
+func nodeMemory(nodeSet esv1.NodeSet) resource.Quantity {
+	for _, c := range nodeSet.PodTemplate.Spec.Containers {
+		if c.Name != esv1.ElasticsearchContainerName {
+			continue
+		}
+		if memory, exists := c.Resources.Limits[corev1.ResourceMemory]; exists {
+			return memory.DeepCopy()
+		}
+		if memory, exists := c.Resources.Requests[corev1.ResourceMemory]; exists {
+			return memory.DeepCopy()
+		}
+	}
+	// the default resources are used
+	return nodespec.DefaultMemoryLimits.DeepCopy()
+}
@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+func nodeSet(name string, count int32, memory, storage string) esv1.NodeSet {
+	nodeSet := esv1.NodeSet{Name: name, Count: count}
+	if memory != "" {
+		nodeSet.PodTemplate.Spec.Containers = []corev1.Container{{
+			Name: esv1.ElasticsearchContainerName,
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+			},
+		}}
+	}
+	if storage != "" {
+		nodeSet.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-data"},
+			Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)},
+			}},
+		}}
+	}
+	return nodeSet
+}
+
+func es(name string, nodeSets ...esv1.NodeSet) esv1.Elasticsearch {
+	return esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name},
+		Spec:       esv1.ElasticsearchSpec{NodeSets: nodeSets},
+	}
+}
+
+func TestParse(t *testing.T) {
+	quotas, err := Parse(map[string]string{
+		"b.yml": `[{name: b, maxNodes: 3}]`,
+		"a.yml": `[{name: a, namespaces: [team-a], maxStorage: 1Ti, maxMemory: 64Gi}]`,
+	})
+	require.NoError(t, err)
+	require.Len(t, quotas, 2)
+	require.Equal(t, "a", quotas[0].Name)
+	require.Equal(t, resource.MustParse("1Ti"), *quotas[0].MaxStorage)
+	require.Equal(t, int32(3), *quotas[1].MaxNodes)
+
+	for _, invalid := range []string{
+		`[{maxNodes: 3}]`,
+		`[{name: a}]`,
+		`[{name: a, maxNodes: -1}]`,
+		`[{name: a, maxMemory: -1Gi}]`,
+		`{name: a}`,
+	} {
+		_, err := Parse(map[string]string{"quotas.yml": invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestForNamespace(t *testing.T) {
+	quotas := []Quota{{Name: "all"}, {Name: "a", Namespaces: []string{"team-a"}}, {Name: "b", Namespaces: []string{"team-b"}}}
+	require.Equal(t, []Quota{quotas[0], quotas[1]}, ForNamespace(quotas, "team-a"))
+	require.Equal(t, []Quota{quotas[0]}, ForNamespace(quotas, "team-c"))
+}
+
+func TestUsageOf(t *testing.T) {
+	u := UsageOf(es("es",
+		nodeSet("master", 3, "4Gi", "10Gi"),
+		// default resources and volume claim
+		nodeSet("data", 2, "", ""),
+	))
+	require.Equal(t, int32(5), u.Nodes)
+	require.Equal(t, 0, u.Memory.Cmp(resource.MustParse("16Gi")), u.Memory.String())
+	require.Equal(t, 0, u.Storage.Cmp(resource.MustParse("32Gi")), u.Storage.String())
+
+	// NodeSets spread across zones deploy their count of nodes in each zone
+	zoned := nodeSet("data", 3, "4Gi", "10Gi")
+	zoned.ZoneSpread = &esv1.ZoneSpread{Zones: []string{"a", "b", "c"}}
+	u = UsageOf(es("es", zoned))
+	require.Equal(t, int32(9), u.Nodes)
+	require.Equal(t, 0, u.Memory.Cmp(resource.MustParse("36Gi")), u.Memory.String())
+	require.Equal(t, 0, u.Storage.Cmp(resource.MustParse("90Gi")), u.Storage.String())
+}
+
+func TestQuota_Violations(t *testing.T) {
+	maxNodes := int32(4)
+	maxStorage := resource.MustParse("100Gi")
+	maxMemory := resource.MustParse("8Gi")
+	q := Quota{Name: "team-a", MaxNodes: &maxNodes, MaxStorage: &maxStorage, MaxMemory: &maxMemory}
+
+	require.Empty(t, q.Violations(UsageOf(es("es", nodeSet("default", 4, "2Gi", "25Gi")))))
+	require.Equal(t, []string{
+		"team-a: 5 nodes requested, 4 allowed",
+		"team-a: 125Gi of storage requested, 100Gi allowed",
+		"team-a: 10Gi of memory requested, 8Gi allowed",
+	}, q.Violations(UsageOf(es("es", nodeSet("default", 5, "2Gi", "25Gi")))))
+}
@@ -0,0 +1,169 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package reconciler
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AnyKind matches the objects of any kind in a foreign field.
+const AnyKind = "*"
+
+// ForeignField is a field of the objects generated by the operator which is managed by external mutators, such as
+// an annotation injected by a service mesh, and ignored by the operator.
+type ForeignField struct {
+	// Kind of the generated objects, or AnyKind.
+	Kind string
+	// Path of the field. The last segment may end with a wildcard, to match all the keys of a map with the given prefix.
+	Path []string
+}
+
+// ForeignFields is a field ownership policy listing the foreign fields of the generated objects.
+type ForeignFields []ForeignField
+
+// foreignFields is the field ownership policy of the operator.
+var foreignFields ForeignFields
+
+// SetForeignFields sets the field ownership policy of the operator. It must be called before the controllers start.
+func SetForeignFields(fields ForeignFields) {
+	foreignFields = fields
+}
+
+// ParseForeignFields parses foreign fields declared as <kind>:<path>, where the path is made of dot-separated
+// segments, or of segments within brackets if they contain dots, such as
+// "Service:metadata.annotations[service.beta.kubernetes.io/aws-load-balancer-*]" or "*:metadata.labels[istio.io/rev]".
+func ParseForeignFields(declarations []string) (ForeignFields, error) {
+	fields := make(ForeignFields, 0, len(declarations))
+	for _, declaration := range declarations {
+		parts := strings.SplitN(declaration, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid foreign field %s: expected <kind>:<path>", declaration)
+		}
+		path, err := parsePath(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid foreign field %s", declaration)
+		}
+		fields = append(fields, ForeignField{Kind: parts[0], Path: path})
+	}
+	return fields, nil
+}
+
+func parsePath(path string) ([]string, error) {
+	var segments []string
+	for path != "" {
+		switch {
+		case path[0] == '[':
+			end := strings.Index(path, "]")
+			if end < 2 {
+				return nil, errors.New("unterminated or empty bracketed segment")
+			}
+			segments = append(segments, path[1:end])
+			path = strings.TrimPrefix(path[end+1:], ".")
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			if end == 0 {
+				return nil, errors.New("empty segment")
+			}
+			segments = append(segments, path[:end])
+			path = strings.TrimPrefix(path[end:], ".")
+		}
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("empty path")
+	}
+	for _, segment := range segments[:len(segments)-1] {
+		if strings.HasSuffix(segment, "*") {
+			return nil, errors.New("wildcards are only supported in the last segment")
+		}
+	}
+	return segments, nil
+}
+
+// forKind returns the foreign fields of the objects of the given kind.
+func (f ForeignFields) forKind(kind string) ForeignFields {
+	var fields ForeignFields
+	for _, field := range f {
+		if field.Kind == kind || field.Kind == AnyKind {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Apply sets the foreign fields of the expected object of the given kind to their values in the reconciled object, so
+// that they are ignored when comparing both objects, and preserved when updating the reconciled object.
+func (f ForeignFields) Apply(kind string, expected, reconciled runtime.Object) error {
+	fields := f.forKind(kind)
+	if len(fields) == 0 {
+		return nil
+	}
+	expectedMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(expected)
+	if err != nil {
+		return err
+	}
+	reconciledMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(reconciled)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if err := copyField(field.Path, reconciledMap, expectedMap); err != nil {
+			return errors.Wrapf(err, "failed to ignore foreign field %s", strings.Join(field.Path, "."))
+		}
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(expectedMap, expected)
+}
+
+// copyField sets the field at the given path in the destination to its value in the source, or removes it if the
+// source does not have it.
+func copyField(path []string, source, destination map[string]interface{}) error {
+	last := path[len(path)-1]
+	if !strings.HasSuffix(last, "*") {
+		value, found, err := unstructured.NestedFieldCopy(source, path...)
+		if err != nil {
+			return err
+		}
+		if !found {
+			unstructured.RemoveNestedField(destination, path...)
+			return nil
+		}
+		return unstructured.SetNestedField(destination, value, path...)
+	}
+
+	prefix := strings.TrimSuffix(last, "*")
+	parent := path[:len(path)-1]
+	sourceValues, _, err := unstructured.NestedMap(source, parent...)
+	if err != nil {
+		return err
+	}
+	destinationValues, _, err := unstructured.NestedMap(destination, parent...)
+	if err != nil {
+		return err
+	}
+	if destinationValues == nil {
+		destinationValues = map[string]interface{}{}
+	}
+	for key := range destinationValues {
+		if strings.HasPrefix(key, prefix) {
+			delete(destinationValues, key)
+		}
+	}
+	for key, value := range sourceValues {
+		if strings.HasPrefix(key, prefix) {
+			destinationValues[key] = value
+		}
+	}
+	if len(destinationValues) == 0 {
+		unstructured.RemoveNestedField(destination, parent...)
+		return nil
+	}
+	return unstructured.SetNestedMap(destination, destinationValues, parent...)
+}
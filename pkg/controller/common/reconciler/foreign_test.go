@@ -0,0 +1,139 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package reconciler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestParseForeignFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		declarations []string
+		want         ForeignFields
+		wantErr      bool
+	}{
+		{
+			name: "no foreign fields",
+			want: ForeignFields{},
+		},
+		{
+			name: "dot-separated and bracketed segments",
+			declarations: []string{
+				"Service:spec.externalTrafficPolicy",
+				"*:metadata.annotations[sidecar.istio.io/*]",
+				"Deployment:spec.template.metadata.labels[istio.io/rev]",
+			},
+			want: ForeignFields{
+				{Kind: "Service", Path: []string{"spec", "externalTrafficPolicy"}},
+				{Kind: AnyKind, Path: []string{"metadata", "annotations", "sidecar.istio.io/*"}},
+				{Kind: "Deployment", Path: []string{"spec", "template", "metadata", "labels", "istio.io/rev"}},
+			},
+		},
+		{
+			name:         "missing kind",
+			declarations: []string{"spec.type"},
+			wantErr:      true,
+		},
+		{
+			name:         "empty path",
+			declarations: []string{"Service:"},
+			wantErr:      true,
+		},
+		{
+			name:         "unterminated bracket",
+			declarations: []string{"Service:metadata.annotations[a.b"},
+			wantErr:      true,
+		},
+		{
+			name:         "wildcard before the last segment",
+			declarations: []string{"Service:metadata.*.a"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseForeignFields(tt.declarations)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestForeignFields_Apply(t *testing.T) {
+	fields, err := ParseForeignFields([]string{
+		"Service:spec.externalTrafficPolicy",
+		"*:metadata.annotations[mesh.io/*]",
+		"Secret:metadata.labels[owner]",
+	})
+	require.NoError(t, err)
+
+	expected := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"a": "b", "mesh.io/stale": "expected"}},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	reconciled := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"a": "c", "mesh.io/injected": "true"}},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal},
+	}
+	require.NoError(t, fields.Apply("Service", expected, reconciled))
+	require.Equal(t, &corev1.Service{
+		// foreign fields are taken from the reconciled object, other fields are left untouched
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"a": "b", "mesh.io/injected": "true"}},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal},
+	}, expected)
+
+	// foreign fields missing from the reconciled object are removed from the expected one
+	expectedSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"owner": "eck", "a": "b"}}}
+	require.NoError(t, fields.Apply("Secret", expectedSecret, &corev1.Secret{}))
+	require.Equal(t, map[string]string{"a": "b"}, expectedSecret.Labels)
+}
+
+func TestReconcileResource_ForeignFields(t *testing.T) {
+	fields, err := ParseForeignFields([]string{"Secret:metadata.annotations[mesh.io/*]"})
+	require.NoError(t, err)
+	SetForeignFields(fields)
+	defer SetForeignFields(nil)
+
+	key := types.NamespacedName{Namespace: "ns", Name: "secret"}
+	// an external mutator changed an annotation set by the operator
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "secret", Annotations: map[string]string{"mesh.io/inject": "false"}},
+	}
+	c := k8s.WrappedFakeClient(existing)
+	expected := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "secret", Annotations: map[string]string{"mesh.io/inject": "true"}},
+	}
+	var reconciled corev1.Secret
+	updated := false
+	require.NoError(t, ReconcileResource(Params{
+		Client:     c,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return !reflect.DeepEqual(expected.Annotations, reconciled.Annotations)
+		},
+		UpdateReconciled: func() {
+			updated = true
+			reconciled.Annotations = expected.Annotations
+		},
+	}))
+	require.False(t, updated)
+	var actual corev1.Secret
+	require.NoError(t, c.Get(key, &actual))
+	require.Equal(t, "false", actual.Annotations["mesh.io/inject"])
+}
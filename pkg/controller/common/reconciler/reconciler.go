@@ -122,6 +122,11 @@ func ReconcileResource(params Params) error {
 		return fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, err)
 	}
 
+	// ignore the fields managed by external mutators, to not revert their changes at each reconciliation
+	if err := foreignFields.Apply(kind, params.Expected, params.Reconciled); err != nil {
+		return err
+	}
+
 	if params.NeedsRecreate != nil && params.NeedsRecreate() {
 		log.Info("Resource cannot be updated, hence will be deleted and then recreated", "kind", kind, "namespace", namespace, "name", name)
 		log.Info("Deleting resource", "kind", kind, "namespace", namespace, "name", name)
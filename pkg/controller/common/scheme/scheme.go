@@ -9,6 +9,7 @@ import (
 
 	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
 	apmv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1beta1"
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	commonv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1beta1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
@@ -16,6 +17,8 @@ import (
 	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
 	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
 	kbv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1beta1"
+	stackv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stack/v1alpha1"
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
 )
 
 // SetupScheme sets up a scheme with all of the relevant types. This is only needed once for the manager but is often used for tests
@@ -42,11 +45,23 @@ func SetupScheme() error {
 		return err
 	}
 	err = entsv1beta1.AddToScheme(clientgoscheme.Scheme)
+	if err != nil {
+		return err
+	}
+	err = policyv1alpha1.AddToScheme(clientgoscheme.Scheme)
+	if err != nil {
+		return err
+	}
+	err = stackv1alpha1.AddToScheme(clientgoscheme.Scheme)
+	if err != nil {
+		return err
+	}
+	err = authv1alpha1.AddToScheme(clientgoscheme.Scheme)
 	return err
 }
 
 // SetupV1beta1Scheme sets up a scheme with v1beta1 resources.
-// Should only be used for the v1beta1 admission webhook.
+// Should only be used for the v1beta1 admission and conversion webhooks.
 // The operator should otherwise deal with a single resource version.
 func SetupV1beta1Scheme() error {
 	err := clientgoscheme.AddToScheme(clientgoscheme.Scheme)
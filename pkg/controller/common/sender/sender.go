@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sender
+
+import (
+	"context"
+	"time"
+)
+
+// SendFunc sends the given item, it is called again for the same item if it returns an error.
+type SendFunc func(ctx context.Context, item interface{}) error
+
+// FailureFunc is called with the error of the last attempt of an item that could not be sent.
+type FailureFunc func(item interface{}, err error)
+
+// Sender sends the items queued by the reconciliations in the background, so that the reconciliations do not wait for
+// the remote endpoints, and sends the items again a few times if they fail to be sent.
+type Sender struct {
+	send        SendFunc
+	failed      FailureFunc
+	maxAttempts int
+	queue       chan interface{}
+	// RetryDelay is the delay before an item is sent again, multiplied by the number of attempts.
+	RetryDelay time.Duration
+}
+
+// New returns a Sender queuing at most maxQueued items, and sending each of them at most maxAttempts times.
+func New(maxQueued int, maxAttempts int, send SendFunc, failed FailureFunc) *Sender {
+	return &Sender{
+		send:        send,
+		failed:      failed,
+		maxAttempts: maxAttempts,
+		queue:       make(chan interface{}, maxQueued),
+		RetryDelay:  time.Second,
+	}
+}
+
+// Queue queues the given item to be sent. It returns false if the queue is full, in which case the item is dropped.
+func (s *Sender) Queue(item interface{}) bool {
+	select {
+	case s.queue <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// Len returns the number of items waiting to be sent.
+func (s *Sender) Len() int {
+	return len(s.queue)
+}
+
+// Start sends the queued items until the stop channel is closed. It implements the controller-runtime Runnable
+// interface.
+func (s *Sender) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-s.queue:
+			s.sendWithRetries(ctx, item)
+		}
+	}
+}
+
+// NeedLeaderElection returns false: items are only queued by the reconciliations, which run on the leader.
+func (s *Sender) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Sender) sendWithRetries(ctx context.Context, item interface{}) {
+	var err error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		if err = s.send(ctx, item); err == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt) * s.RetryDelay):
+		}
+	}
+	s.failed(item, err)
+}
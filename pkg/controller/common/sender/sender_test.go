@@ -0,0 +1,60 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender(t *testing.T) {
+	sent := make(chan string, 10)
+	failures := map[string]int{"flaky": 1, "broken": 3}
+	send := func(_ context.Context, item interface{}) error {
+		if failures[item.(string)] > 0 {
+			failures[item.(string)]--
+			return errors.New("failed")
+		}
+		sent <- item.(string)
+		return nil
+	}
+	dropped := make(chan string, 10)
+	failed := func(item interface{}, err error) {
+		require.EqualError(t, err, "failed")
+		dropped <- item.(string)
+	}
+	s := New(3, 3, send, failed)
+	s.RetryDelay = time.Millisecond
+
+	// items are dropped when the queue is full
+	require.True(t, s.Queue("flaky"))
+	require.True(t, s.Queue("broken"))
+	require.True(t, s.Queue("ok"))
+	require.False(t, s.Queue("dropped"))
+	require.Equal(t, 3, s.Len())
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		require.NoError(t, s.Start(stop))
+	}()
+	receive := func(c chan string) string {
+		select {
+		case item := <-c:
+			return item
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "item not received")
+			return ""
+		}
+	}
+	// the flaky item is sent again, the broken one is given up after the last attempt
+	require.Equal(t, "flaky", receive(sent))
+	require.Equal(t, "broken", receive(dropped))
+	require.Equal(t, "ok", receive(sent))
+}
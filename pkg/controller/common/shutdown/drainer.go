@@ -0,0 +1,101 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package shutdown
+
+import (
+	"sync"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DefaultDrainTimeout is the default maximum duration to wait for in-flight reconciliations when the operator stops.
+const DefaultDrainTimeout = 20 * time.Second
+
+var log = logf.Log.WithName("shutdown")
+
+// Hook is called once in-flight reconciliations are drained, or the drain timed out. drained is false in the latter
+// case: reconciliations may still be running, and the state they use should not be accessed.
+type Hook func(drained bool)
+
+// Drainer tracks in-flight reconciliations so that the operator can let them complete before exiting.
+type Drainer struct {
+	mutex    sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{}
+	hooks    []Hook
+}
+
+// NewDrainer returns a new Drainer.
+func NewDrainer() *Drainer {
+	return &Drainer{idle: make(chan struct{})}
+}
+
+// Wrap returns a reconciler tracked by the drainer. Reconciliations starting once the drain is started are skipped:
+// they are left to the next operator instance.
+func (d *Drainer) Wrap(r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(request reconcile.Request) (reconcile.Result, error) {
+		if !d.start() {
+			log.V(1).Info("Skipping reconciliation during shutdown", "namespace", request.Namespace, "name", request.Name)
+			return reconcile.Result{}, nil
+		}
+		defer d.done()
+		return r.Reconcile(request)
+	})
+}
+
+// OnShutdown registers a hook called once the drain is over.
+func (d *Drainer) OnShutdown(hook Hook) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.hooks = append(d.hooks, hook)
+}
+
+func (d *Drainer) start() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.draining {
+		return false
+	}
+	d.inFlight++
+	return true
+}
+
+func (d *Drainer) done() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.inFlight--
+	if d.draining && d.inFlight == 0 {
+		close(d.idle)
+	}
+}
+
+// Drain prevents new reconciliations from starting, waits for the in-flight ones up to the given timeout, then calls
+// the registered hooks. It returns true if all reconciliations completed. It must be called only once.
+func (d *Drainer) Drain(timeout time.Duration) bool {
+	d.mutex.Lock()
+	d.draining = true
+	inFlight := d.inFlight
+	if inFlight == 0 {
+		close(d.idle)
+	}
+	hooks := d.hooks
+	d.mutex.Unlock()
+
+	log.Info("Draining in-flight reconciliations", "count", inFlight, "timeout", timeout)
+	drained := true
+	select {
+	case <-d.idle:
+	case <-time.After(timeout):
+		drained = false
+		log.Info("Timed out draining in-flight reconciliations", "timeout", timeout)
+	}
+	for _, hook := range hooks {
+		hook(drained)
+	}
+	return drained
+}
@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package shutdown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// blockingReconciler blocks each reconciliation until released.
+type blockingReconciler struct {
+	started  chan struct{}
+	release  chan struct{}
+	complete int
+}
+
+func newBlockingReconciler() *blockingReconciler {
+	return &blockingReconciler{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (r *blockingReconciler) Reconcile(_ reconcile.Request) (reconcile.Result, error) {
+	r.started <- struct{}{}
+	<-r.release
+	r.complete++
+	return reconcile.Result{Requeue: true}, nil
+}
+
+func TestDrainer_Drain(t *testing.T) {
+	d := NewDrainer()
+	r := newBlockingReconciler()
+	wrapped := d.Wrap(r)
+	var hookCalls []bool
+	d.OnShutdown(func(drained bool) { hookCalls = append(hookCalls, drained) })
+
+	// start a reconciliation that completes once the drain is started
+	go func() {
+		_, _ = wrapped.Reconcile(reconcile.Request{})
+	}()
+	<-r.started
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(r.release)
+	}()
+	require.True(t, d.Drain(time.Minute))
+	require.Equal(t, 1, r.complete)
+	require.Equal(t, []bool{true}, hookCalls)
+
+	// new reconciliations are skipped
+	res, err := wrapped.Reconcile(reconcile.Request{})
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{}, res)
+	require.Equal(t, 1, r.complete)
+}
+
+func TestDrainer_Drain_Timeout(t *testing.T) {
+	d := NewDrainer()
+	r := newBlockingReconciler()
+	wrapped := d.Wrap(r)
+	var hookCalls []bool
+	d.OnShutdown(func(drained bool) { hookCalls = append(hookCalls, drained) })
+
+	go func() {
+		_, _ = wrapped.Reconcile(reconcile.Request{})
+	}()
+	<-r.started
+	require.False(t, d.Drain(10*time.Millisecond))
+	require.Equal(t, []bool{false}, hookCalls)
+	// the late reconciliation completes without closing the idle channel twice
+	close(r.release)
+}
+
+func TestDrainer_Drain_NoReconciliation(t *testing.T) {
+	d := NewDrainer()
+	require.True(t, d.Drain(time.Minute))
+}
@@ -0,0 +1,226 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package stackconfigpolicy holds the resources shared by the StackConfigPolicy controller, which distributes the
+// configuration of the policies, and the Elasticsearch and Kibana controllers, which apply it.
+//
+// For each resource selected by a policy, the StackConfigPolicy controller reconciles in the namespace of the resource:
+//   - a config Secret holding the non-sensitive configuration (snapshot repositories, cluster settings, index
+//     lifecycle policies, index templates, ingest pipelines and their sample documents, watches, Kibana settings)
+//   - a secure settings Secret, aggregated with the user secure settings into the keystore
+//
+// Both Secrets are owned by the selected resource. The controller of the resource annotates the config Secret once
+// its content has been applied. The Elasticsearch controller then periodically checks the cluster settings, index
+// lifecycle policies, index templates, ingest pipelines and watches of the cluster for drift, and either reverts it or reports it in an
+// annotation of the config Secret, depending on the drift mode of the policy.
+package stackconfigpolicy
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/name"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// PolicyNameLabelName is the label holding the name of the policy a Secret was reconciled for.
+	PolicyNameLabelName = "stackconfigpolicy.k8s.elastic.co/name"
+	// PolicyNamespaceLabelName is the label holding the namespace of the policy a Secret was reconciled for.
+	PolicyNamespaceLabelName = "stackconfigpolicy.k8s.elastic.co/namespace"
+	// SecretType is the value of the type label of the Secrets reconciled for a policy.
+	SecretType = "stack-config-policy"
+
+	// ConfigHashAnnotation holds the hash of the content of the config Secret.
+	ConfigHashAnnotation = "stackconfigpolicy.k8s.elastic.co/config-hash"
+	// AppliedHashAnnotation is set by the controller of the selected resource to the hash of the applied content.
+	AppliedHashAnnotation = "stackconfigpolicy.k8s.elastic.co/applied-hash"
+	// ApplyErrorAnnotation is set by the controller of the selected resource when the content can't be applied.
+	ApplyErrorAnnotation = "stackconfigpolicy.k8s.elastic.co/apply-error"
+	// DriftModeAnnotation holds the drift mode of the policy.
+	DriftModeAnnotation = "stackconfigpolicy.k8s.elastic.co/drift-mode"
+	// DriftAnnotation is set by the Elasticsearch controller to the comma-separated list of drifted items.
+	DriftAnnotation = "stackconfigpolicy.k8s.elastic.co/drift"
+
+	// SnapshotRepositoriesKey is the config Secret entry holding the Elasticsearch snapshot repositories.
+	SnapshotRepositoriesKey = "snapshot_repositories.json"
+	// ClusterSettingsKey is the config Secret entry holding the Elasticsearch cluster settings.
+	ClusterSettingsKey = "cluster_settings.json"
+	// IndexLifecyclePoliciesKey is the config Secret entry holding the Elasticsearch index lifecycle policies.
+	IndexLifecyclePoliciesKey = "index_lifecycle_policies.json"
+	// IndexTemplatesKey is the config Secret entry holding the Elasticsearch index templates.
+	IndexTemplatesKey = "index_templates.json"
+	// IngestPipelinesKey is the config Secret entry holding the Elasticsearch ingest pipelines.
+	IngestPipelinesKey = "ingest_pipelines.json"
+	// IngestPipelineSamplesKey is the config Secret entry holding the sample documents of the ingest pipelines.
+	IngestPipelineSamplesKey = "ingest_pipeline_samples.json"
+	// WatchesKey is the config Secret entry holding the Elasticsearch watches.
+	WatchesKey = "watches.json"
+	// KibanaConfigKey is the config Secret entry holding the Kibana settings.
+	KibanaConfigKey = "kibana.yml"
+
+	configSecretSuffix = "policy-config"
+)
+
+// ConfigSecretName returns the name of the config Secret of the resource with the given name.
+func ConfigSecretName(namer name.Namer, resourceName string) string {
+	return namer.Suffix(resourceName, configSecretSuffix)
+}
+
+// GetConfigSecret returns the config Secret of the given resource, or nil if it is not selected by any policy.
+func GetConfigSecret(c k8s.Client, namer name.Namer, resource types.NamespacedName) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	err := c.Get(types.NamespacedName{Namespace: resource.Namespace, Name: ConfigSecretName(namer, resource.Name)}, &secret)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func unmarshalEntry(secret corev1.Secret, key string, out interface{}) error {
+	raw, exists := secret.Data[key]
+	if !exists {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// SnapshotRepositories returns the snapshot repositories held in the given config Secret.
+func SnapshotRepositories(secret corev1.Secret) (map[string]esclient.SnapshotRepository, error) {
+	var repositories map[string]esclient.SnapshotRepository
+	err := unmarshalEntry(secret, SnapshotRepositoriesKey, &repositories)
+	return repositories, err
+}
+
+// ClusterSettings returns the cluster settings held in the given config Secret.
+func ClusterSettings(secret corev1.Secret) (map[string]interface{}, error) {
+	var clusterSettings map[string]interface{}
+	err := unmarshalEntry(secret, ClusterSettingsKey, &clusterSettings)
+	return clusterSettings, err
+}
+
+// IndexLifecyclePolicies returns the index lifecycle policies held in the given config Secret, keyed by name.
+func IndexLifecyclePolicies(secret corev1.Secret) (map[string]map[string]interface{}, error) {
+	var policies map[string]map[string]interface{}
+	err := unmarshalEntry(secret, IndexLifecyclePoliciesKey, &policies)
+	return policies, err
+}
+
+// IndexTemplates returns the index templates held in the given config Secret, keyed by name.
+func IndexTemplates(secret corev1.Secret) (map[string]map[string]interface{}, error) {
+	var templates map[string]map[string]interface{}
+	err := unmarshalEntry(secret, IndexTemplatesKey, &templates)
+	return templates, err
+}
+
+// IngestPipelines returns the ingest pipelines held in the given config Secret, keyed by ID.
+func IngestPipelines(secret corev1.Secret) (map[string]map[string]interface{}, error) {
+	var pipelines map[string]map[string]interface{}
+	err := unmarshalEntry(secret, IngestPipelinesKey, &pipelines)
+	return pipelines, err
+}
+
+// IngestPipelineSamples returns the sample documents of the ingest pipelines held in the given config Secret, keyed by
+// pipeline ID.
+func IngestPipelineSamples(secret corev1.Secret) (map[string][]map[string]interface{}, error) {
+	var samples map[string][]map[string]interface{}
+	err := unmarshalEntry(secret, IngestPipelineSamplesKey, &samples)
+	return samples, err
+}
+
+// Watches returns the watches held in the given config Secret, keyed by ID.
+func Watches(secret corev1.Secret) (map[string]map[string]interface{}, error) {
+	var watches map[string]map[string]interface{}
+	err := unmarshalEntry(secret, WatchesKey, &watches)
+	return watches, err
+}
+
+// DriftMode returns the drift mode of the policy the given config Secret was reconciled for.
+func DriftMode(secret corev1.Secret) policyv1alpha1.DriftMode {
+	if mode := policyv1alpha1.DriftMode(secret.Annotations[DriftModeAnnotation]); mode != "" {
+		return mode
+	}
+	return policyv1alpha1.EnforceDriftMode
+}
+
+// Drift returns the drifted items reported in the given config Secret.
+func Drift(secret corev1.Secret) []string {
+	drift := secret.Annotations[DriftAnnotation]
+	if drift == "" {
+		return nil
+	}
+	return strings.Split(drift, ",")
+}
+
+// KibanaConfig returns the Kibana settings held in the given config Secret.
+func KibanaConfig(secret corev1.Secret) (*settings.CanonicalConfig, error) {
+	raw, exists := secret.Data[KibanaConfigKey]
+	if !exists {
+		return nil, nil
+	}
+	return settings.ParseConfig(raw)
+}
+
+// IsApplied returns true if the content of the given config Secret has been applied.
+func IsApplied(secret corev1.Secret) bool {
+	return secret.Annotations[ConfigHashAnnotation] != "" &&
+		secret.Annotations[AppliedHashAnnotation] == secret.Annotations[ConfigHashAnnotation]
+}
+
+// UpdateAppliedStatus records whether the content of the given config Secret has been applied, and updates
+// the Secret if needed.
+func UpdateAppliedStatus(c k8s.Client, secret corev1.Secret, applyErr error) error {
+	appliedHash := secret.Annotations[ConfigHashAnnotation]
+	var errMsg string
+	if applyErr != nil {
+		appliedHash = secret.Annotations[AppliedHashAnnotation]
+		errMsg = applyErr.Error()
+	}
+	if secret.Annotations[AppliedHashAnnotation] == appliedHash && secret.Annotations[ApplyErrorAnnotation] == errMsg {
+		return nil
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[AppliedHashAnnotation] = appliedHash
+	if errMsg == "" {
+		delete(secret.Annotations, ApplyErrorAnnotation)
+	} else {
+		secret.Annotations[ApplyErrorAnnotation] = errMsg
+	}
+	if applyErr == nil {
+		// the drift of the previous content is not relevant anymore
+		delete(secret.Annotations, DriftAnnotation)
+	}
+	return c.Update(&secret)
+}
+
+// UpdateDriftStatus records the given drifted items in the given config Secret, and updates the Secret if needed.
+func UpdateDriftStatus(c k8s.Client, secret corev1.Secret, drift []string) error {
+	sort.Strings(drift)
+	value := strings.Join(drift, ",")
+	if secret.Annotations[DriftAnnotation] == value {
+		return nil
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	if value == "" {
+		delete(secret.Annotations, DriftAnnotation)
+	} else {
+		secret.Annotations[DriftAnnotation] = value
+	}
+	return c.Update(&secret)
+}
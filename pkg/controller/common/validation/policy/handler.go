@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/configmaps"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// WebhookPath is the path on which the policies validating webhook is served.
+	WebhookPath = "/validate-policies-k8s-elastic-co"
+	// ConfigMapType is the value of the type label identifying the ConfigMaps holding validation policies.
+	ConfigMapType = "validation-policy"
+)
+
+var log = logf.Log.WithName("policy-validation")
+
+// Handler is an admission handler that evaluates the resources submitted to the webhook against the validation
+// policies declared in ConfigMaps of the operator namespace.
+type Handler struct {
+	client    k8s.Client
+	namespace string
+}
+
+var _ admission.Handler = &Handler{}
+
+// NewHandler returns a Handler reading policies from the given namespace.
+func NewHandler(c k8s.Client, namespace string) *Handler {
+	return &Handler{client: c, namespace: namespace}
+}
+
+// Handle implements admission.Handler.
+func (h *Handler) Handle(_ context.Context, req admission.Request) admission.Response {
+	policies, err := h.policies()
+	if err != nil {
+		log.Error(err, "Failed to load validation policies, skipping policies evaluation", "namespace", h.namespace)
+		return admission.Allowed("")
+	}
+	if len(policies) == 0 {
+		return admission.Allowed("")
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	violations := Evaluate(policies, req.Kind.Kind, obj)
+	if len(violations) == 0 {
+		return admission.Allowed("")
+	}
+	reasons := make([]string, len(violations))
+	for i, v := range violations {
+		reasons[i] = v.String()
+	}
+	log.V(1).Info("Resource rejected by validation policies",
+		"kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name, "violations", reasons)
+	return admission.Denied(strings.Join(reasons, "; "))
+}
+
+// policies returns all the policies declared in the operator namespace.
+func (h *Handler) policies() ([]Policy, error) {
+	var policies []Policy
+	err := configmaps.LoadTyped(h.client, h.namespace, ConfigMapType, func(cm corev1.ConfigMap) error {
+		p, err := Parse(cm.Data)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, p...)
+		return nil
+	})
+	return policies, err
+}
+
+// Evaluate returns the violations of the policies applying to the given resource kind.
+func Evaluate(policies []Policy, kind string, obj map[string]interface{}) []Violation {
+	var violations []Violation
+	for _, p := range policies {
+		if !p.AppliesTo(kind) {
+			continue
+		}
+		v, err := p.Evaluate(obj)
+		if err != nil {
+			log.Error(err, "Failed to evaluate validation policy", "policy", p.Name)
+			continue
+		}
+		if v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func policyConfigMap(namespace, name, policies string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{common.TypeLabelName: ConfigMapType},
+		},
+		Data: map[string]string{"policies.yml": policies},
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Group: "elasticsearch.k8s.elastic.co", Version: "v1", Kind: "Elasticsearch"},
+		Object: runtime.RawExtension{Raw: []byte(esManifest)},
+	}}
+	tests := []struct {
+		name        string
+		objs        []runtime.Object
+		wantAllowed bool
+	}{
+		{
+			name:        "no policies",
+			wantAllowed: true,
+		},
+		{
+			name: "satisfied policy",
+			objs: []runtime.Object{
+				policyConfigMap("elastic-system", "p", `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: ["7.0.0"]}]`),
+			},
+			wantAllowed: true,
+		},
+		{
+			name: "violated policy",
+			objs: []runtime.Object{
+				policyConfigMap("elastic-system", "p", `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: ["8.6.0"]}]`),
+			},
+			wantAllowed: false,
+		},
+		{
+			name: "policies from another namespace are ignored",
+			objs: []runtime.Object{
+				policyConfigMap("default", "p", `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: ["8.6.0"]}]`),
+			},
+			wantAllowed: true,
+		},
+		{
+			name: "invalid policies do not prevent valid ones from being enforced",
+			objs: []runtime.Object{
+				policyConfigMap("elastic-system", "invalid", `[{name: p, operator: Unknown}]`),
+				policyConfigMap("elastic-system", "valid", `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: ["8.6.0"]}]`),
+			},
+			wantAllowed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(k8s.WrappedFakeClient(tt.objs...), "elastic-system")
+			resp := h.Handle(context.Background(), req)
+			require.Equal(t, tt.wantAllowed, resp.Allowed)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// Operator is the comparison applied by a Policy to the values it selects.
+type Operator string
+
+const (
+	// OperatorExists requires the path to match at least one value.
+	OperatorExists Operator = "Exists"
+	// OperatorNotExists requires the path to match no value.
+	OperatorNotExists Operator = "NotExists"
+	// OperatorIn requires every selected value to be one of the policy values.
+	OperatorIn Operator = "In"
+	// OperatorNotIn requires every selected value to be different from all the policy values.
+	OperatorNotIn Operator = "NotIn"
+	// OperatorMatches requires every selected value to match the regular expression given as the single policy value.
+	OperatorMatches Operator = "Matches"
+	// OperatorVersionAtLeast requires every selected value to be a version greater or equal to the single policy value.
+	OperatorVersionAtLeast Operator = "VersionAtLeast"
+)
+
+// Policy is a validation rule declared by a cluster administrator, evaluated against the resources
+// submitted to the validating webhook.
+//
+// Example:
+//
+//   - name: es-min-version
+//     kinds: [Elasticsearch]
+//     path: "{.spec.version}"
+//     operator: VersionAtLeast
+//     values: ["7.6.0"]
+//     message: "Elasticsearch clusters must run at least 7.6.0"
+type Policy struct {
+	// Name identifies the policy in error messages.
+	Name string `json:"name"`
+	// Kinds the policy applies to (eg. Elasticsearch, Kibana). Applies to all kinds if empty.
+	Kinds []string `json:"kinds,omitempty"`
+	// Path is a JSONPath template (eg. `{.spec.nodeSets[*].count}`) selecting the values to check.
+	Path string `json:"path"`
+	// Operator is the comparison applied to the selected values.
+	Operator Operator `json:"operator"`
+	// Values are the operands of the comparison.
+	Values []string `json:"values,omitempty"`
+	// Message is returned to the user when the policy is violated.
+	Message string `json:"message,omitempty"`
+
+	parser *jsonpath.JSONPath
+	regexp *regexp.Regexp
+	minVer *version.Version
+}
+
+// Violation describes a policy that is not satisfied by a resource.
+type Violation struct {
+	Policy  string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Policy, v.Message)
+}
+
+// Parse decodes and compiles the policies declared in the given ConfigMap data.
+// Each entry must hold a YAML list of policies.
+func Parse(data map[string]string) ([]Policy, error) {
+	// iterate in a stable order to get reproducible results
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var policies []Policy
+	for _, k := range keys {
+		var entries []Policy
+		if err := yaml.Unmarshal([]byte(data[k]), &entries); err != nil {
+			return nil, errors.Wrapf(err, "invalid validation policies in %s", k)
+		}
+		for _, p := range entries {
+			if err := p.compile(); err != nil {
+				return nil, errors.Wrapf(err, "invalid validation policy %s in %s", p.Name, k)
+			}
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+// compile prepares the policy for evaluation, and returns an error if it is malformed.
+func (p *Policy) compile() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	p.parser = jsonpath.New(p.Name).AllowMissingKeys(true)
+	if err := p.parser.Parse(p.Path); err != nil {
+		return err
+	}
+	switch p.Operator {
+	case OperatorExists, OperatorNotExists, OperatorIn, OperatorNotIn:
+	case OperatorMatches:
+		if len(p.Values) != 1 {
+			return fmt.Errorf("operator %s expects exactly one value", p.Operator)
+		}
+		r, err := regexp.Compile(p.Values[0])
+		if err != nil {
+			return err
+		}
+		p.regexp = r
+	case OperatorVersionAtLeast:
+		if len(p.Values) != 1 {
+			return fmt.Errorf("operator %s expects exactly one value", p.Operator)
+		}
+		v, err := version.Parse(p.Values[0])
+		if err != nil {
+			return err
+		}
+		p.minVer = v
+	default:
+		return fmt.Errorf("unknown operator %s", p.Operator)
+	}
+	return nil
+}
+
+// AppliesTo returns true if the policy should be evaluated for the given resource kind.
+func (p Policy) AppliesTo(kind string) bool {
+	if len(p.Kinds) == 0 {
+		return true
+	}
+	for _, k := range p.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks the given resource, in its unstructured JSON form, against the policy.
+// It returns nil if the policy is satisfied.
+func (p Policy) Evaluate(obj map[string]interface{}) (*Violation, error) {
+	values, err := p.selectValues(obj)
+	if err != nil {
+		return nil, err
+	}
+	if p.check(values) {
+		return nil, nil
+	}
+	msg := p.Message
+	if msg == "" {
+		msg = fmt.Sprintf("%s %s %v not satisfied by %v", p.Path, p.Operator, p.Values, values)
+	}
+	return &Violation{Policy: p.Name, Message: msg}, nil
+}
+
+// selectValues returns the string representation of all values matched by the policy path.
+func (p Policy) selectValues(obj map[string]interface{}) ([]string, error) {
+	results, err := p.parser.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, r := range results {
+		for _, v := range r {
+			if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := p.parser.PrintResults(&buf, []reflect.Value{v}); err != nil {
+				return nil, err
+			}
+			values = append(values, buf.String())
+		}
+	}
+	return values, nil
+}
+
+func (p Policy) check(values []string) bool {
+	switch p.Operator {
+	case OperatorExists:
+		return len(values) > 0
+	case OperatorNotExists:
+		return len(values) == 0
+	}
+	for _, v := range values {
+		switch p.Operator {
+		case OperatorIn:
+			if !stringsutil.StringInSlice(v, p.Values) {
+				return false
+			}
+		case OperatorNotIn:
+			if stringsutil.StringInSlice(v, p.Values) {
+				return false
+			}
+		case OperatorMatches:
+			if !p.regexp.MatchString(v) {
+				return false
+			}
+		case OperatorVersionAtLeast:
+			actual, err := version.Parse(v)
+			if err != nil || !actual.IsSameOrAfter(*p.minVer) {
+				return false
+			}
+		}
+	}
+	return true
+}
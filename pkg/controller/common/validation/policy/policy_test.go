@@ -0,0 +1,173 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const esManifest = `{
+	"apiVersion": "elasticsearch.k8s.elastic.co/v1",
+	"kind": "Elasticsearch",
+	"metadata": {"name": "es", "namespace": "ns"},
+	"spec": {
+		"version": "7.6.0",
+		"nodeSets": [
+			{"name": "a", "count": 3, "volumeClaimTemplates": [{"spec": {"storageClassName": "fast"}}]},
+			{"name": "b", "count": 1, "volumeClaimTemplates": [{"spec": {"storageClassName": "slow"}}]}
+		]
+	}
+}`
+
+func parseManifest(t *testing.T) map[string]interface{} {
+	var obj map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(esManifest), &obj))
+	return obj
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "no data",
+			data: nil,
+			want: 0,
+		},
+		{
+			name: "valid policies across several entries",
+			data: map[string]string{
+				"version.yml": `[{name: v, path: "{.spec.version}", operator: VersionAtLeast, values: ["7.0.0"]}]`,
+				"storage.yml": `
+- name: s
+  kinds: [Elasticsearch]
+  path: "{.spec.nodeSets[*].volumeClaimTemplates[*].spec.storageClassName}"
+  operator: In
+  values: [fast]`,
+			},
+			want: 2,
+		},
+		{
+			name:    "missing name",
+			data:    map[string]string{"p": `[{path: "{.spec.version}", operator: Exists}]`},
+			wantErr: true,
+		},
+		{
+			name:    "unknown operator",
+			data:    map[string]string{"p": `[{name: p, path: "{.spec.version}", operator: GreaterThan}]`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid path",
+			data:    map[string]string{"p": `[{name: p, path: "{.spec.version", operator: Exists}]`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version operand",
+			data:    map[string]string{"p": `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: [latest]}]`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regular expression",
+			data:    map[string]string{"p": `[{name: p, path: "{.spec.version}", operator: Matches, values: ["("]}]`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			data:    map[string]string{"p": `name: p`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.data)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, tt.want)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		kind           string
+		wantViolations []string
+	}{
+		{
+			name:   "version at least satisfied",
+			policy: `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: ["7.6.0"]}]`,
+			kind:   "Elasticsearch",
+		},
+		{
+			name:           "version at least violated",
+			policy:         `[{name: p, path: "{.spec.version}", operator: VersionAtLeast, values: ["8.6.0"], message: "too old"}]`,
+			kind:           "Elasticsearch",
+			wantViolations: []string{"p: too old"},
+		},
+		{
+			name:           "one of the storage classes is not allowed",
+			policy:         `[{name: p, path: "{.spec.nodeSets[*].volumeClaimTemplates[*].spec.storageClassName}", operator: In, values: [fast], message: "use fast"}]`,
+			kind:           "Elasticsearch",
+			wantViolations: []string{"p: use fast"},
+		},
+		{
+			name:   "forbidden storage class is not used",
+			policy: `[{name: p, path: "{.spec.nodeSets[*].volumeClaimTemplates[*].spec.storageClassName}", operator: NotIn, values: [standard]}]`,
+			kind:   "Elasticsearch",
+		},
+		{
+			name:           "required field missing",
+			policy:         `[{name: p, path: "{.spec.http.tls.certificate.secretName}", operator: Exists, message: "custom certificate required"}]`,
+			kind:           "Elasticsearch",
+			wantViolations: []string{"p: custom certificate required"},
+		},
+		{
+			name:   "forbidden field missing",
+			policy: `[{name: p, path: "{.spec.http.tls.selfSignedCertificate.disabled}", operator: NotExists}]`,
+			kind:   "Elasticsearch",
+		},
+		{
+			name:           "name does not match",
+			policy:         `[{name: p, path: "{.spec.nodeSets[*].name}", operator: Matches, values: ["^(a|c)$"], message: "bad name"}]`,
+			kind:           "Elasticsearch",
+			wantViolations: []string{"p: bad name"},
+		},
+		{
+			name:   "policy does not apply to this kind",
+			policy: `[{name: p, kinds: [Kibana], path: "{.spec.version}", operator: VersionAtLeast, values: ["8.6.0"]}]`,
+			kind:   "Elasticsearch",
+		},
+		{
+			name: "multiple violations",
+			policy: `
+- {name: p1, path: "{.spec.version}", operator: VersionAtLeast, values: ["8.6.0"], message: "too old"}
+- {name: p2, path: "{.spec.nodeSets[*].count}", operator: In, values: ["3"], message: "3 nodes per set"}`,
+			kind:           "Elasticsearch",
+			wantViolations: []string{"p1: too old", "p2: 3 nodes per set"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := Parse(map[string]string{"policies.yml": tt.policy})
+			require.NoError(t, err)
+			var got []string
+			for _, v := range Evaluate(policies, tt.kind, parseManifest(t)) {
+				got = append(got, v.String())
+			}
+			require.Equal(t, tt.wantViolations, got)
+		})
+	}
+}
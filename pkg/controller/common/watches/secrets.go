@@ -18,10 +18,6 @@ func WatchUserProvidedSecrets(
 	watchName string, // dynamic watch to register
 	secrets []string, // user-provided secrets to watch
 ) error {
-	if len(secrets) == 0 {
-		watched.Secrets.RemoveHandlerForKey(watchName)
-		return nil
-	}
 	userSecretNsns := make([]types.NamespacedName, 0, len(secrets))
 	for _, secretName := range secrets {
 		userSecretNsns = append(userSecretNsns, types.NamespacedName{
@@ -29,9 +25,24 @@ func WatchUserProvidedSecrets(
 			Name:      secretName,
 		})
 	}
+	return WatchSecrets(watcher, watched, watchName, userSecretNsns)
+}
+
+// WatchSecrets registers a watch for the given secrets, which may be in other namespaces than the watcher, in the same
+// way as WatchUserProvidedSecrets.
+func WatchSecrets(
+	watcher types.NamespacedName,
+	watched DynamicWatches,
+	watchName string,
+	secrets []types.NamespacedName,
+) error {
+	if len(secrets) == 0 {
+		watched.Secrets.RemoveHandlerForKey(watchName)
+		return nil
+	}
 	return watched.Secrets.AddHandler(NamedWatch{
 		Name:    watchName,
-		Watched: userSecretNsns,
+		Watched: secrets,
 		Watcher: watcher,
 	})
 }
@@ -0,0 +1,179 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package adoption
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var log = logf.Log.WithName("adoption")
+
+const (
+	// AnnotationName is the annotation requesting the operator to adopt the existing resources of an Elasticsearch
+	// cluster, for example restored from a backup or left behind by the deletion of the CRDs.
+	AnnotationName = "elasticsearch.k8s.elastic.co/adopt"
+
+	// ConditionType is the type of the condition reporting the adoption of the existing resources of a cluster.
+	ConditionType commonv1.ConditionType = "Adopted"
+	// ReasonInProgress is the reason of the adoption condition while the adoption is not complete.
+	ReasonInProgress = "AdoptionInProgress"
+	// ReasonAdopted is the reason of the adoption condition once the existing resources are adopted.
+	ReasonAdopted = "Adopted"
+)
+
+// IsRequested returns true if the adoption of the existing resources of the given cluster is requested.
+func IsRequested(es esv1.Elasticsearch) bool {
+	return es.Annotations[AnnotationName] == "true"
+}
+
+// adoptedTypes are the types of the resources of a cluster adopted by the operator.
+var adoptedTypes = []struct {
+	kind    string
+	newList func() runtime.Object
+}{
+	{kind: "StatefulSet", newList: func() runtime.Object { return &appsv1.StatefulSetList{} }},
+	{kind: "PersistentVolumeClaim", newList: func() runtime.Object { return &corev1.PersistentVolumeClaimList{} }},
+	{kind: "Service", newList: func() runtime.Object { return &corev1.ServiceList{} }},
+	{kind: "Secret", newList: func() runtime.Object { return &corev1.SecretList{} }},
+	{kind: "ConfigMap", newList: func() runtime.Object { return &corev1.ConfigMapList{} }},
+	{kind: "PodDisruptionBudget", newList: func() runtime.Object { return &v1beta1.PodDisruptionBudgetList{} }},
+}
+
+// Result summarizes the adoption of the existing resources of a cluster.
+type Result struct {
+	// Adopted is the number of resources whose owner references were updated to the cluster, by kind.
+	Adopted map[string]int
+	// StatefulSets is the number of existing StatefulSets of the cluster.
+	StatefulSets int
+}
+
+// Total returns the number of resources adopted.
+func (r Result) Total() int {
+	total := 0
+	for _, count := range r.Adopted {
+		total += count
+	}
+	return total
+}
+
+// String summarizes the adopted resources, for example "StatefulSet=2, Secret=5".
+func (r Result) String() string {
+	parts := make([]string, 0, len(r.Adopted))
+	for kind, count := range r.Adopted {
+		parts = append(parts, fmt.Sprintf("%s=%d", kind, count))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// AdoptResources sets the given cluster as the controller owner of its existing resources, labeled with its name,
+// whose owner references are missing, for example because the previous Elasticsearch resource was deleted while
+// orphaning them or they were restored from a backup without their owner references. The owner references to a
+// previous Elasticsearch resource of the same name are replaced, in case the Kubernetes garbage collector, which
+// deletes such resources, did not process them yet. Resources controlled by another owner are left untouched.
+// Since the volume claim templates of the existing StatefulSets cannot be updated, the PersistentVolumeClaims created
+// from them keep being adopted for as long as the adoption is requested.
+func AdoptResources(c k8s.Client, es esv1.Elasticsearch) (Result, error) {
+	result := Result{Adopted: map[string]int{}}
+	for _, t := range adoptedTypes {
+		list := t.newList()
+		if err := c.List(list, client.InNamespace(es.Namespace), label.NewLabelSelectorForElasticsearch(es)); err != nil {
+			return result, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return result, err
+		}
+		if t.kind == "StatefulSet" {
+			result.StatefulSets = len(items)
+		}
+		for _, item := range items {
+			adopted, err := adopt(c, es, t.kind, item)
+			if err != nil {
+				return result, err
+			}
+			if adopted {
+				result.Adopted[t.kind]++
+			}
+		}
+	}
+	return result, nil
+}
+
+// adopt sets the given cluster as the controller owner of the given resource, replacing the owner references to
+// previous Elasticsearch resources of the same name. It returns true if the resource was updated.
+func adopt(c k8s.Client, es esv1.Elasticsearch, kind string, runtimeObj runtime.Object) (bool, error) {
+	obj, err := meta.Accessor(runtimeObj)
+	if err != nil {
+		return false, err
+	}
+	refs := make([]metav1.OwnerReference, 0, len(obj.GetOwnerReferences()))
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == es.UID {
+			// already adopted
+			return false, nil
+		}
+		if isPreviousOwner(ref, es) {
+			continue
+		}
+		if ref.Controller != nil && *ref.Controller {
+			// controlled by another resource
+			return false, nil
+		}
+		refs = append(refs, ref)
+	}
+	obj.SetOwnerReferences(refs)
+	if err := controllerutil.SetControllerReference(&es, obj, scheme.Scheme); err != nil {
+		return false, err
+	}
+	log.Info("Adopting resource", "namespace", obj.GetNamespace(), "es_name", es.Name, "kind", kind, "name", obj.GetName())
+	return true, c.Update(runtimeObj)
+}
+
+// isPreviousOwner returns true if the given owner reference points to an Elasticsearch resource with the same name
+// as the given cluster.
+func isPreviousOwner(ref metav1.OwnerReference, es esv1.Elasticsearch) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && gv.Group == esv1.GroupVersion.Group && ref.Kind == "Elasticsearch" && ref.Name == es.Name
+}
+
+// Condition returns the adoption condition of a cluster given the result of the adoption, and whether the nodes of
+// the cluster can be reconciled: the adoption is complete once the existing StatefulSets can be taken over without
+// recreating their Pods, which requires the cluster to be annotated with its UUID.
+func Condition(result Result, bootstrapped bool) commonv1.Condition {
+	if result.StatefulSets > 0 && !bootstrapped {
+		return commonv1.Condition{
+			Type:    ConditionType,
+			Status:  corev1.ConditionFalse,
+			Reason:  ReasonInProgress,
+			Message: fmt.Sprintf("Waiting for the UUID of the cluster to be retrieved from the %d existing StatefulSets before reconciling the nodes", result.StatefulSets),
+		}
+	}
+	return commonv1.Condition{
+		Type:    ConditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  ReasonAdopted,
+		Message: fmt.Sprintf("Existing resources adopted, with %d existing StatefulSets", result.StatefulSets),
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package adoption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestIsRequested(t *testing.T) {
+	require.False(t, IsRequested(esv1.Elasticsearch{}))
+	require.False(t, IsRequested(esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AnnotationName: "false"}}}))
+	require.True(t, IsRequested(esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AnnotationName: "true"}}}))
+}
+
+func TestAdoptResources(t *testing.T) {
+	scheme.SetupScheme()
+	truePtr := true
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", UID: "new-uid"}}
+	ref := func(apiVersion, kind, name string, uid types.UID) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, UID: uid, Controller: &truePtr}
+	}
+	objectMeta := func(name string, refs ...metav1.OwnerReference) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Namespace:       "ns",
+			Name:            name,
+			Labels:          map[string]string{label.ClusterNameLabelName: "es"},
+			OwnerReferences: refs,
+		}
+	}
+	previousOwner := ref("elasticsearch.k8s.elastic.co/v1beta1", "Elasticsearch", "es", "old-uid")
+	currentOwner := ref("elasticsearch.k8s.elastic.co/v1", "Elasticsearch", "es", "new-uid")
+	otherOwner := ref("kibana.k8s.elastic.co/v1", "Kibana", "kb", "kb-uid")
+
+	c := k8s.WrappedFakeClient(
+		&appsv1.StatefulSet{ObjectMeta: objectMeta("es-es-default", previousOwner)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("data-es-es-default-0", previousOwner)},
+		&corev1.Secret{ObjectMeta: objectMeta("es-es-elastic-user")},
+		&corev1.Service{ObjectMeta: objectMeta("es-es-http", currentOwner)},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("kb-config", otherOwner)},
+		// resources of another cluster are not adopted
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other", Labels: map[string]string{label.ClusterNameLabelName: "other"}}},
+	)
+
+	result, err := AdoptResources(c, es)
+	require.NoError(t, err)
+	require.Equal(t, Result{
+		Adopted:      map[string]int{"StatefulSet": 1, "PersistentVolumeClaim": 1, "Secret": 1},
+		StatefulSets: 1,
+	}, result)
+	require.Equal(t, 3, result.Total())
+	require.Equal(t, "PersistentVolumeClaim=1, Secret=1, StatefulSet=1", result.String())
+
+	assertOwners := func(obj runtime.Object, name string, uids ...types.UID) {
+		require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: name}, obj))
+		accessor, err := meta.Accessor(obj)
+		require.NoError(t, err)
+		actual := make([]types.UID, 0, len(accessor.GetOwnerReferences()))
+		for _, ref := range accessor.GetOwnerReferences() {
+			actual = append(actual, ref.UID)
+		}
+		require.Equal(t, uids, actual)
+	}
+	assertOwners(&appsv1.StatefulSet{}, "es-es-default", "new-uid")
+	assertOwners(&corev1.PersistentVolumeClaim{}, "data-es-es-default-0", "new-uid")
+	assertOwners(&corev1.Secret{}, "es-es-elastic-user", "new-uid")
+	assertOwners(&corev1.Service{}, "es-es-http", "new-uid")
+	assertOwners(&corev1.ConfigMap{}, "kb-config", "kb-uid")
+
+	// adopting again is a no-op
+	result, err = AdoptResources(c, es)
+	require.NoError(t, err)
+	require.Equal(t, 0, result.Total())
+	require.Equal(t, 1, result.StatefulSets)
+}
+
+func TestCondition(t *testing.T) {
+	tests := []struct {
+		name         string
+		result       Result
+		bootstrapped bool
+		wantStatus   corev1.ConditionStatus
+	}{
+		{
+			name:       "no existing StatefulSets",
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "existing StatefulSets of a cluster not annotated with its UUID yet",
+			result:     Result{StatefulSets: 2},
+			wantStatus: corev1.ConditionFalse,
+		},
+		{
+			name:         "existing StatefulSets of a bootstrapped cluster",
+			result:       Result{StatefulSets: 2},
+			bootstrapped: true,
+			wantStatus:   corev1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := Condition(tt.result, tt.bootstrapped)
+			require.Equal(t, ConditionType, condition.Type)
+			require.Equal(t, tt.wantStatus, condition.Status)
+		})
+	}
+}
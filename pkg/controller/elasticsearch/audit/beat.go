@@ -0,0 +1,191 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"context"
+	"path"
+
+	"go.elastic.co/apm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// BeatContainerName is the name of the sidecar container shipping the audit logs.
+	BeatContainerName = "audit-beat"
+	// BeatConfigHashAnnotationName is set on the Pods to rotate them when the sidecar configuration changes.
+	BeatConfigHashAnnotationName = "elasticsearch.k8s.elastic.co/audit-beat-config-hash"
+
+	beatConfigFileName        = "filebeat.yml"
+	beatConfigVolumeName      = "elastic-internal-audit-beat-config"
+	beatConfigVolumeMountPath = "/mnt/elastic-internal/audit-beat-config"
+	beatCAVolumeName          = "elastic-internal-audit-es-ca"
+	beatCAVolumeMountPath     = "/mnt/elastic-internal/audit-es-ca"
+	beatDataVolumeName        = "audit-beat-data"
+	beatDataVolumeMountPath   = "/usr/share/filebeat/data"
+
+	beatPasswordEnv = "AUDIT_ES_PASSWORD"
+)
+
+var (
+	defaultBeatMemory = resource.MustParse("200Mi")
+	defaultBeatCPU    = resource.MustParse("100m")
+	// DefaultBeatResources are the default resources of the audit logs shipping sidecar.
+	DefaultBeatResources = corev1.ResourceRequirements{
+		Requests: map[corev1.ResourceName]resource.Quantity{
+			corev1.ResourceMemory: defaultBeatMemory,
+			corev1.ResourceCPU:    defaultBeatCPU,
+		},
+		Limits: map[corev1.ResourceName]resource.Quantity{
+			corev1.ResourceMemory: defaultBeatMemory,
+		},
+	}
+)
+
+// ShippingEnabled returns true if audit logs must be shipped and the association with the monitoring cluster is
+// established.
+func ShippingEnabled(es esv1.Elasticsearch) bool {
+	return es.Spec.Audit.ShippingEnabled() && es.AssociationConf().IsConfigured()
+}
+
+// beatConfig renders the Filebeat configuration reading the audit logs files and shipping them to the
+// monitoring cluster.
+func beatConfig(es esv1.Elasticsearch) ([]byte, error) {
+	assocConf := es.AssociationConf()
+	output := map[string]interface{}{
+		"hosts":    []string{assocConf.GetURL()},
+		"username": assocConf.GetAuthSecretKey(),
+		"password": "${" + beatPasswordEnv + "}",
+	}
+	if assocConf.GetCACertProvided() {
+		output["ssl.certificate_authorities"] = []string{path.Join(beatCAVolumeMountPath, certificates.CAFileName)}
+	}
+	cfg, err := common.NewCanonicalConfigFrom(map[string]interface{}{
+		"filebeat.inputs": []map[string]interface{}{
+			{
+				"type":  "log",
+				"paths": []string{path.Join(esvolume.ElasticsearchLogsMountPath, settings.AuditLogsFilePattern)},
+			},
+		},
+		"processors": []map[string]interface{}{
+			{
+				"decode_json_fields": map[string]interface{}{
+					"fields": []string{"message"},
+					"target": "elasticsearch.audit",
+				},
+			},
+			{
+				"add_fields": map[string]interface{}{
+					"target": "elasticsearch.cluster",
+					"fields": map[string]interface{}{
+						"name":      es.Name,
+						"namespace": es.Namespace,
+					},
+				},
+			},
+		},
+		"output.elasticsearch": output,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Render()
+}
+
+// ReconcileBeatConfig reconciles the secret holding the configuration of the audit logs shipping sidecar, or deletes
+// it if audit logs are not shipped.
+func ReconcileBeatConfig(ctx context.Context, c k8s.Client, es esv1.Elasticsearch) error {
+	span, _ := apm.StartSpan(ctx, "reconcile_audit_beat_config", tracing.SpanTypeApp)
+	defer span.End()
+
+	meta := metav1.ObjectMeta{
+		Namespace: es.Namespace,
+		Name:      esv1.AuditBeatConfigSecret(es.Name),
+		Labels:    label.NewLabels(k8s.ExtractNamespacedName(&es)),
+	}
+	if !ShippingEnabled(es) {
+		err := c.Delete(&corev1.Secret{ObjectMeta: meta})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	cfg, err := beatConfig(es)
+	if err != nil {
+		return err
+	}
+	expected := corev1.Secret{
+		ObjectMeta: meta,
+		Data:       map[string][]byte{beatConfigFileName: cfg},
+	}
+	_, err = reconciler.ReconcileSecret(c, expected, &es)
+	return err
+}
+
+// BeatSidecar returns the sidecar container shipping the audit logs of nodes of the given architecture, the volumes
+// it relies on, and the hash of its configuration.
+func BeatSidecar(es esv1.Elasticsearch, arch container.Architecture) (corev1.Container, []corev1.Volume, string, error) {
+	cfg, err := beatConfig(es)
+	if err != nil {
+		return corev1.Container{}, nil, "", err
+	}
+	assocConf := es.AssociationConf()
+
+	configVolume := volume.NewSecretVolumeWithMountPath(
+		esv1.AuditBeatConfigSecret(es.Name),
+		beatConfigVolumeName,
+		beatConfigVolumeMountPath,
+	)
+	dataVolume := volume.NewEmptyDirVolume(beatDataVolumeName, beatDataVolumeMountPath)
+	volumes := []corev1.Volume{configVolume.Volume(), dataVolume.Volume()}
+	logsMount := esvolume.DefaultLogsVolumeMount
+	logsMount.ReadOnly = true
+	mounts := []corev1.VolumeMount{configVolume.VolumeMount(), dataVolume.VolumeMount(), logsMount}
+	if assocConf.GetCACertProvided() {
+		caVolume := volume.NewSecretVolumeWithMountPath(assocConf.GetCASecretName(), beatCAVolumeName, beatCAVolumeMountPath)
+		volumes = append(volumes, caVolume.Volume())
+		mounts = append(mounts, caVolume.VolumeMount())
+	}
+
+	image := es.Spec.Audit.Shipping.Image
+	if image == "" {
+		image = container.ImageRepositoryForNamespace(container.FilebeatImage, es.Spec.Version, es.Namespace, arch)
+	}
+	sidecar := corev1.Container{
+		Name:  BeatContainerName,
+		Image: image,
+		Args:  []string{"-e", "-c", path.Join(beatConfigVolumeMountPath, beatConfigFileName)},
+		Env: []corev1.EnvVar{
+			{
+				Name: beatPasswordEnv,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: assocConf.GetAuthSecretName()},
+						Key:                  assocConf.GetAuthSecretKey(),
+					},
+				},
+			},
+		},
+		VolumeMounts: mounts,
+		Resources:    DefaultBeatResources,
+	}
+	return sidecar, volumes, hash.HashObject(cfg), nil
+}
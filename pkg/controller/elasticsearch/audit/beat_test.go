@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func auditedES(withAssoc bool) esv1.Elasticsearch {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version: "7.6.0",
+			Audit: &esv1.AuditLogging{
+				Shipping: &esv1.AuditShipping{ElasticsearchRef: commonv1.ObjectSelector{Name: "monitoring"}},
+			},
+		},
+	}
+	if withAssoc {
+		es.SetAssociationConf(&commonv1.AssociationConf{
+			AuthSecretName: "es-audit-beat-user",
+			AuthSecretKey:  "ns-es-audit-beat-user",
+			CACertProvided: true,
+			CASecretName:   "es-audit-es-ca",
+			URL:            "https://monitoring-es-http.ns.svc:9200",
+		})
+	}
+	return es
+}
+
+func Test_beatConfig(t *testing.T) {
+	cfg, err := beatConfig(auditedES(true))
+	require.NoError(t, err)
+	parsed, err := common.ParseConfig(cfg)
+	require.NoError(t, err)
+	var output struct {
+		Output struct {
+			Elasticsearch struct {
+				Hosts    []string `config:"hosts"`
+				Username string   `config:"username"`
+				Password string   `config:"password"`
+				SSL      struct {
+					CertificateAuthorities []string `config:"certificate_authorities"`
+				} `config:"ssl"`
+			} `config:"elasticsearch"`
+		} `config:"output"`
+	}
+	require.NoError(t, parsed.Unpack(&output))
+	es := output.Output.Elasticsearch
+	require.Equal(t, []string{"https://monitoring-es-http.ns.svc:9200"}, es.Hosts)
+	require.Equal(t, "ns-es-audit-beat-user", es.Username)
+	require.Equal(t, "${AUDIT_ES_PASSWORD}", es.Password)
+	require.Equal(t, []string{"/mnt/elastic-internal/audit-es-ca/ca.crt"}, es.SSL.CertificateAuthorities)
+	require.Contains(t, string(cfg), "/usr/share/elasticsearch/logs/*_audit*.json")
+}
+
+func TestBeatSidecar(t *testing.T) {
+	es := auditedES(true)
+	sidecar, volumes, configHash, err := BeatSidecar(es, "")
+	require.NoError(t, err)
+	require.Equal(t, BeatContainerName, sidecar.Name)
+	require.Equal(t, "docker.elastic.co/beats/filebeat:7.6.0", sidecar.Image)
+	require.Equal(t, "es-audit-beat-user", sidecar.Env[0].ValueFrom.SecretKeyRef.Name)
+	require.Len(t, volumes, 3)
+	require.Len(t, sidecar.VolumeMounts, 4)
+	require.NotEmpty(t, configHash)
+
+	// a custom image takes precedence
+	es.Spec.Audit.Shipping.Image = "my-filebeat"
+	sidecar, _, _, err = BeatSidecar(es, "")
+	require.NoError(t, err)
+	require.Equal(t, "my-filebeat", sidecar.Image)
+
+	// the hash changes with the configuration
+	es.SetAssociationConf(&commonv1.AssociationConf{
+		AuthSecretName: "es-audit-beat-user",
+		AuthSecretKey:  "ns-es-audit-beat-user",
+		URL:            "http://monitoring-es-http.ns.svc:9200",
+	})
+	_, volumes, newHash, err := BeatSidecar(es, "")
+	require.NoError(t, err)
+	require.NotEqual(t, configHash, newHash)
+	// no CA volume
+	require.Len(t, volumes, 2)
+}
+
+func TestReconcileBeatConfig(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: esv1.AuditBeatConfigSecret("es")}
+	c := k8s.WrappedFakeClient()
+
+	// association not configured yet
+	require.NoError(t, ReconcileBeatConfig(context.Background(), c, auditedES(false)))
+	require.True(t, apierrors.IsNotFound(c.Get(key, &corev1.Secret{})))
+
+	es := auditedES(true)
+	require.NoError(t, ReconcileBeatConfig(context.Background(), c, es))
+	var secret corev1.Secret
+	require.NoError(t, c.Get(key, &secret))
+	require.NotEmpty(t, secret.Data[beatConfigFileName])
+
+	// shipping disabled
+	es.Spec.Audit.Shipping = nil
+	require.NoError(t, ReconcileBeatConfig(context.Background(), c, es))
+	require.True(t, apierrors.IsNotFound(c.Get(key, &corev1.Secret{})))
+}
@@ -12,6 +12,7 @@ import (
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/driver"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
@@ -39,6 +40,7 @@ type CertificateResources struct {
 func Reconcile(
 	ctx context.Context,
 	driver driver.Interface,
+	store credentials.Credentials,
 	es esv1.Elasticsearch,
 	services []corev1.Service,
 	caRotation certificates.RotationParams,
@@ -58,6 +60,7 @@ func Reconcile(
 
 	httpCA, err := certificates.ReconcileCAForOwner(
 		driver.K8sClient(),
+		store,
 		esv1.ESNamer,
 		&es,
 		labels,
@@ -79,7 +82,7 @@ func Reconcile(
 		&es,
 		esv1.ESNamer,
 		httpCA,
-		es.Spec.HTTP.TLS,
+		es.Spec.EffectiveHTTP().TLS,
 		labels,
 		services,
 		certRotation,
@@ -103,6 +106,7 @@ func Reconcile(
 
 	transportCA, err := certificates.ReconcileCAForOwner(
 		driver.K8sClient(),
+		store,
 		esv1.ESNamer,
 		&es,
 		labels,
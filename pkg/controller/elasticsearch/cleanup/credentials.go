@@ -0,0 +1,36 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+)
+
+// storedCredentialsKeys returns the keys of the secrets of the given cluster whose credentials are persisted in the
+// external credentials store, if any.
+func storedCredentialsKeys(es types.NamespacedName) []types.NamespacedName {
+	names := []string{
+		esv1.ElasticUserSecret(es.Name),
+		esv1.InternalUsersSecret(es.Name),
+		certificates.CAInternalSecretName(esv1.ESNamer, es.Name, certificates.HTTPCAType),
+		certificates.CAInternalSecretName(esv1.ESNamer, es.Name, certificates.TransportCAType),
+	}
+	keys := make([]types.NamespacedName, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, types.NamespacedName{Namespace: es.Namespace, Name: name})
+	}
+	return keys
+}
+
+// DeleteStoredCredentials deletes the passwords and CA private keys of the given deleted cluster from the external
+// credentials store, if any, for a cluster recreated with the same name not to reuse them. The corresponding Kubernetes
+// secrets are garbage collected with the cluster.
+func DeleteStoredCredentials(es types.NamespacedName) error {
+	return credentials.Delete(storedCredentialsKeys(es)...)
+}
@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+)
+
+// memoryStore is a credentials store keeping credentials in memory.
+type memoryStore map[string]map[string][]byte
+
+func (m memoryStore) Get(key string) (map[string][]byte, error) {
+	return m[key], nil
+}
+
+func (m memoryStore) Put(key string, data map[string][]byte) error {
+	m[key] = data
+	return nil
+}
+
+func (m memoryStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestDeleteStoredCredentials(t *testing.T) {
+	store := memoryStore{}
+	credentials.SetStore(store, "eck", time.Hour)
+	defer credentials.SetStore(nil, "", credentials.DefaultCacheTTL)
+
+	deleted := types.NamespacedName{Namespace: "ns", Name: "es"}
+	other := types.NamespacedName{Namespace: "ns", Name: "other"}
+	for _, key := range append(storedCredentialsKeys(deleted), storedCredentialsKeys(other)...) {
+		require.NoError(t, credentials.Save(key, map[string][]byte{"key": []byte(key.Name)}))
+	}
+	require.Len(t, store, 8)
+	require.Contains(t, store, "eck/ns/es-es-elastic-user")
+	require.Contains(t, store, "eck/ns/es-es-transport-ca-internal")
+
+	require.NoError(t, DeleteStoredCredentials(deleted))
+	// only the credentials of the other cluster are left
+	require.Len(t, store, 4)
+	for key := range store {
+		require.Contains(t, key, "eck/ns/other-")
+	}
+}
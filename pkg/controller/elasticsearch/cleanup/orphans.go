@@ -0,0 +1,132 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/adoption"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// softOwnedTypes are the types of the resources of an Elasticsearch cluster which are garbage collected if they have
+// no owner reference and the cluster does not exist anymore.
+var softOwnedTypes = []func() runtime.Object{
+	func() runtime.Object { return &corev1.SecretList{} },
+	func() runtime.Object { return &corev1.ServiceList{} },
+	func() runtime.Object { return &corev1.ConfigMapList{} },
+	func() runtime.Object { return &v1beta1.PodDisruptionBudgetList{} },
+}
+
+// dataTypes are the types of the resources holding the nodes and the data of an Elasticsearch cluster. The cluster
+// may be recreated to adopt them as long as they exist, for example after the Elasticsearch resource was deleted while
+// orphaning its resources, and it would need its other resources such as its certificate authorities and the password
+// of the elastic user.
+var dataTypes = []func() runtime.Object{
+	func() runtime.Object { return &appsv1.StatefulSetList{} },
+	func() runtime.Object { return &corev1.PersistentVolumeClaimList{} },
+}
+
+// DeleteSoftOwnedOrphans deletes the resources created by the operator for an Elasticsearch cluster which does not
+// exist anymore, in the given namespaces or all namespaces if empty. Resources which have owner references are left
+// to the Kubernetes garbage collector: this only handles the resources whose owner references are missing, for
+// example they were removed or the resources were restored from a backup.
+// Only the resources labeled with the name of the cluster and named after the cluster as the operator names them are
+// garbage collected, not to delete the resources created by the users with the same label. The resources of a cluster
+// whose StatefulSets or PersistentVolumeClaims still exist, and the resources of the namespaces with an Elasticsearch
+// resource requesting the adoption of existing resources, are kept for the cluster to be adopted when recreated.
+// This is intended to be run during startup, to catch the clusters deleted while the operator was not running.
+func DeleteSoftOwnedOrphans(c k8s.Client, namespaces []string, params Params) error {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	hasClusterName, err := labels.NewRequirement(label.ClusterNameLabelName, selection.Exists, nil)
+	if err != nil {
+		return err
+	}
+	selector := client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*hasClusterName)}
+	for _, namespace := range namespaces {
+		var clusters esv1.ElasticsearchList
+		if err := c.List(&clusters, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		existing := make(map[string]bool, len(clusters.Items))
+		adopting := make(map[string]bool)
+		for _, es := range clusters.Items {
+			existing[es.Namespace+"/"+es.Name] = true
+			if adoption.IsRequested(es) {
+				adopting[es.Namespace] = true
+			}
+		}
+		withData, err := clustersWithData(c, namespace, selector)
+		if err != nil {
+			return err
+		}
+		for _, newList := range softOwnedTypes {
+			list := newList()
+			if err := c.List(list, client.InNamespace(namespace), selector); err != nil {
+				return err
+			}
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				obj, err := meta.Accessor(item)
+				if err != nil {
+					return err
+				}
+				clusterName := obj.GetLabels()[label.ClusterNameLabelName]
+				cluster := obj.GetNamespace() + "/" + clusterName
+				if len(obj.GetOwnerReferences()) > 0 || existing[cluster] || withData[cluster] || adopting[obj.GetNamespace()] {
+					continue
+				}
+				if !strings.HasPrefix(obj.GetName(), esv1.ESNamer.Suffix(clusterName)+"-") {
+					// not created by the operator
+					continue
+				}
+				if err := params.delete(c, item, "elasticsearch "+clusterName+" does not exist"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// clustersWithData returns the <namespace>/<name> of the clusters whose StatefulSets or PersistentVolumeClaims exist
+// in the given namespace.
+func clustersWithData(c k8s.Client, namespace string, selector client.MatchingLabelsSelector) (map[string]bool, error) {
+	clusters := make(map[string]bool)
+	for _, newList := range dataTypes {
+		list := newList()
+		if err := c.List(list, client.InNamespace(namespace), selector); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj, err := meta.Accessor(item)
+			if err != nil {
+				return nil, err
+			}
+			clusters[obj.GetNamespace()+"/"+obj.GetLabels()[label.ClusterNameLabelName]] = true
+		}
+	}
+	return clusters, nil
+}
@@ -0,0 +1,170 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/adoption"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestDeleteSoftOwnedOrphans(t *testing.T) {
+	scheme.SetupScheme()
+	whileAgo := time.Now().Add(-DeleteAfter).Add(-1 * time.Minute)
+	objectMeta := func(ns, name, clusterName string, creationTime time.Time, owned bool) metav1.ObjectMeta {
+		objMeta := metav1.ObjectMeta{
+			Namespace:         ns,
+			Name:              name,
+			Labels:            map[string]string{label.ClusterNameLabelName: clusterName},
+			CreationTimestamp: metav1.NewTime(creationTime),
+		}
+		if owned {
+			objMeta.OwnerReferences = []metav1.OwnerReference{{Kind: "Elasticsearch", Name: clusterName}}
+		}
+		return objMeta
+	}
+	existing := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "es1"}}
+	adopting := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns3",
+		Name:        "es1",
+		Annotations: map[string]string{adoption.AnnotationName: "true"},
+	}}
+	objs := []runtime.Object{
+		&existing,
+		&adopting,
+		// resources of the existing cluster
+		&corev1.Secret{ObjectMeta: objectMeta("ns1", "es1-es-elastic-user", "es1", whileAgo, false)},
+		&corev1.Service{ObjectMeta: objectMeta("ns1", "es1-es-http", "es1", whileAgo, false)},
+		// resources of a deleted cluster with owner references, left to the Kubernetes garbage collector
+		&corev1.Secret{ObjectMeta: objectMeta("ns1", "es2-es-owned", "es2", whileAgo, true)},
+		// resources of a deleted cluster without owner references
+		&corev1.Secret{ObjectMeta: objectMeta("ns1", "es2-es-elastic-user", "es2", whileAgo, false)},
+		&corev1.Service{ObjectMeta: objectMeta("ns1", "es2-es-http", "es2", whileAgo, false)},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("ns1", "es2-es-scripts", "es2", whileAgo, false)},
+		&v1beta1.PodDisruptionBudget{ObjectMeta: objectMeta("ns1", "es2-es-default", "es2", whileAgo, false)},
+		&corev1.Secret{ObjectMeta: objectMeta("ns1", "es2-es-young", "es2", time.Now(), false)},
+		// resources created by the users with the label of a deleted cluster
+		&corev1.ConfigMap{ObjectMeta: objectMeta("ns1", "es2-dashboards", "es2", whileAgo, false)},
+		// resources of a deleted cluster whose StatefulSets or PersistentVolumeClaims still exist
+		&appsv1.StatefulSet{ObjectMeta: objectMeta("ns1", "es3-es-default", "es3", whileAgo, false)},
+		&corev1.Secret{ObjectMeta: objectMeta("ns1", "es3-es-elastic-user", "es3", whileAgo, false)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("ns1", "elasticsearch-data-es4-es-default-0", "es4", whileAgo, false)},
+		&corev1.Secret{ObjectMeta: objectMeta("ns1", "es4-es-elastic-user", "es4", whileAgo, false)},
+		// resources of a cluster with the same name in another namespace
+		&corev1.Secret{ObjectMeta: objectMeta("ns2", "es1-es-elastic-user", "es1", whileAgo, false)},
+		// resources of a namespace where a cluster requests the adoption of existing resources
+		&corev1.Secret{ObjectMeta: objectMeta("ns3", "es2-es-elastic-user", "es2", whileAgo, false)},
+		// resources not labeled with a cluster name
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "other", CreationTimestamp: metav1.NewTime(whileAgo)}},
+	}
+	kept := []string{
+		"ns1/es1-es-elastic-user", "ns1/es1-es-http", "ns1/es2-es-owned", "ns1/es2-es-young", "ns1/es2-dashboards",
+		"ns1/es3-es-elastic-user", "ns1/es4-es-elastic-user", "ns3/es2-es-elastic-user", "ns1/other",
+	}
+	tests := []struct {
+		name       string
+		namespaces []string
+		params     Params
+		want       []string
+	}{
+		{
+			name: "all namespaces",
+			want: kept,
+		},
+		{
+			name:       "managed namespaces only",
+			namespaces: []string{"ns1", "ns3"},
+			want:       append([]string{"ns2/es1-es-elastic-user"}, kept...),
+		},
+		{
+			name:   "dry run",
+			params: Params{DryRun: true},
+			want: append([]string{
+				"ns1/es2-es-elastic-user", "ns1/es2-es-http", "ns1/es2-es-scripts", "ns1/es2-es-default",
+				"ns2/es1-es-elastic-user",
+			}, kept...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := k8s.WrappedFakeClient(objs...)
+			require.NoError(t, DeleteSoftOwnedOrphans(c, tt.namespaces, tt.params))
+
+			var remaining []string
+			for _, newList := range softOwnedTypes {
+				list := newList()
+				require.NoError(t, c.List(list))
+				items, err := meta.ExtractList(list)
+				require.NoError(t, err)
+				for _, item := range items {
+					obj, err := meta.Accessor(item)
+					require.NoError(t, err)
+					remaining = append(remaining, obj.GetNamespace()+"/"+obj.GetName())
+				}
+			}
+			require.ElementsMatch(t, tt.want, remaining)
+		})
+	}
+}
+
+func TestDeleteSoftOwnedOrphans_Adoption(t *testing.T) {
+	scheme.SetupScheme()
+	whileAgo := metav1.NewTime(time.Now().Add(-DeleteAfter).Add(-1 * time.Minute))
+	// resources left behind by the deletion of the Elasticsearch resource while orphaning its resources
+	resourceMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Namespace:         "ns",
+			Name:              name,
+			Labels:            map[string]string{label.ClusterNameLabelName: "es"},
+			CreationTimestamp: whileAgo,
+		}
+	}
+	sset := appsv1.StatefulSet{ObjectMeta: resourceMeta("es-es-default")}
+	elasticUser := corev1.Secret{ObjectMeta: resourceMeta("es-es-elastic-user")}
+	transportCA := corev1.Secret{ObjectMeta: resourceMeta("es-es-transport-ca-internal")}
+	c := k8s.WrappedFakeClient(&sset, &elasticUser, &transportCA)
+
+	// the operator starts before the cluster is recreated
+	require.NoError(t, DeleteSoftOwnedOrphans(c, nil, Params{}))
+	var secrets corev1.SecretList
+	require.NoError(t, c.List(&secrets))
+	require.Len(t, secrets.Items, 2)
+
+	// the recreated cluster adopts them
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "es",
+		UID:         "es-uid",
+		Annotations: map[string]string{adoption.AnnotationName: "true"},
+	}}
+	require.NoError(t, c.Create(&es))
+	result, err := adoption.AdoptResources(c, es)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"StatefulSet": 1, "Secret": 2}, result.Adopted)
+
+	// and they are still there on the next start of the operator, even after the annotation is removed
+	es.Annotations = nil
+	require.NoError(t, c.Update(&es))
+	require.NoError(t, DeleteSoftOwnedOrphans(c, nil, Params{}))
+	require.NoError(t, c.List(&secrets))
+	require.Len(t, secrets.Items, 2)
+	for _, secret := range secrets.Items {
+		require.Len(t, secret.OwnerReferences, 1)
+		require.Equal(t, es.UID, secret.OwnerReferences[0].UID)
+	}
+}
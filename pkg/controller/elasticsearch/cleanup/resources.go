@@ -6,10 +6,12 @@ package cleanup
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"go.elastic.co/apm"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -34,13 +36,57 @@ const DeleteAfter = 10 * time.Minute
 // This is to avoid situations where we would delete an object that was just created,
 // or delete an object due to a (temporary) out-of-sync cache.
 func IsTooYoungForGC(object metav1.Object) bool {
+	return Params{}.isTooYoung(object)
+}
+
+// Params configure the garbage collection of orphaned resources.
+type Params struct {
+	// GracePeriod is how long after creation an orphaned resource can be garbage collected, DeleteAfter if zero.
+	GracePeriod time.Duration
+	// DryRun logs the orphaned resources instead of deleting them.
+	DryRun bool
+}
+
+func (p Params) isTooYoung(object metav1.Object) bool {
+	gracePeriod := p.GracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = DeleteAfter
+	}
 	creationTime := object.GetCreationTimestamp()
-	return time.Since(creationTime.Time) < DeleteAfter
+	return time.Since(creationTime.Time) < gracePeriod
 }
 
-// DeleteOrphanedSecrets cleans up secrets that are not needed anymore for the given es cluster.
-func DeleteOrphanedSecrets(ctx context.Context, c k8s.Client, es esv1.Elasticsearch) error {
-	span, _ := apm.StartSpan(ctx, "delete_orphaned_secrets", tracing.SpanTypeApp)
+// delete deletes the given orphaned object, unless too young or in dry-run mode.
+func (p Params) delete(c k8s.Client, runtimeObj runtime.Object, reason string) error {
+	obj, err := meta.Accessor(runtimeObj)
+	if err != nil {
+		return err
+	}
+	if p.isTooYoung(obj) {
+		// the owner might not be there in the cache yet, skip deletion
+		return nil
+	}
+	kind := runtimeObj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		kind = reflect.TypeOf(runtimeObj).Elem().Name()
+	}
+	if p.DryRun {
+		log.Info("Dry run: orphaned resource would be garbage-collected", "kind", kind,
+			"namespace", obj.GetNamespace(), "name", obj.GetName(), "reason", reason)
+		return nil
+	}
+	log.Info("Garbage-collecting resource", "kind", kind, "namespace", obj.GetNamespace(), "name", obj.GetName(), "reason", reason)
+	if err := c.Delete(runtimeObj); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// DeleteOrphanedResources cleans up resources that are not needed anymore for the given es cluster:
+// the secrets of Pods which do not exist anymore, and the configuration secrets and headless services of
+// StatefulSets which do not exist anymore and are not expected, for example following the renaming of a NodeSet.
+func DeleteOrphanedResources(ctx context.Context, c k8s.Client, es esv1.Elasticsearch, params Params) error {
+	span, _ := apm.StartSpan(ctx, "delete_orphaned_resources", tracing.SpanTypeApp)
 	defer span.End()
 
 	var secrets corev1.SecretList
@@ -49,16 +95,27 @@ func DeleteOrphanedSecrets(ctx context.Context, c k8s.Client, es esv1.Elasticsea
 	if err := c.List(&secrets, ns, matchLabels); err != nil {
 		return err
 	}
-	resources := make([]runtime.Object, len(secrets.Items))
+	var services corev1.ServiceList
+	if err := c.List(&services, ns, matchLabels); err != nil {
+		return err
+	}
+	secretResources := make([]runtime.Object, len(secrets.Items))
 	for i := range secrets.Items {
-		resources[i] = &secrets.Items[i]
+		secretResources[i] = &secrets.Items[i]
+	}
+	if err := cleanupFromPodReference(c, es.Namespace, secretResources, params); err != nil {
+		return err
 	}
-	return cleanupFromPodReference(c, es.Namespace, resources)
+	resources := secretResources
+	for i := range services.Items {
+		resources = append(resources, &services.Items[i])
+	}
+	return cleanupFromStatefulSetReference(c, es, resources, params)
 }
 
 // cleanupFromPodReference deletes objects having a reference to
 // a pod which does not exist anymore.
-func cleanupFromPodReference(c k8s.Client, namespace string, objects []runtime.Object) error {
+func cleanupFromPodReference(c k8s.Client, namespace string, objects []runtime.Object, params Params) error {
 	for _, runtimeObj := range objects {
 		obj, err := meta.Accessor(runtimeObj)
 		if err != nil {
@@ -76,15 +133,44 @@ func cleanupFromPodReference(c k8s.Client, namespace string, objects []runtime.O
 			Name:      podName,
 		}, &pod)
 		if apierrors.IsNotFound(err) {
-			if IsTooYoungForGC(obj) {
-				// pod might not be there in the cache yet,
-				// skip deletion
-				continue
-			}
 			// pod does not exist anymore, delete the object
-			log.Info("Garbage-collecting resource", "namespace", namespace, "name", obj.GetName())
-			if deleteErr := c.Delete(runtimeObj); deleteErr != nil {
-				return deleteErr
+			if err := params.delete(c, runtimeObj, "pod "+podName+" does not exist"); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupFromStatefulSetReference deletes objects having a reference to a StatefulSet which does not exist anymore,
+// and is not expected to be created for a NodeSet of the given cluster.
+func cleanupFromStatefulSetReference(c k8s.Client, es esv1.Elasticsearch, objects []runtime.Object, params Params) error {
+	expected := make(map[string]bool, len(es.Spec.NodeSets))
+	for _, nodeSet := range es.Spec.NodeSets {
+		for _, name := range nodeSet.ExpandedNames() {
+			expected[esv1.StatefulSet(es.Name, name)] = true
+		}
+	}
+	for _, runtimeObj := range objects {
+		obj, err := meta.Accessor(runtimeObj)
+		if err != nil {
+			return err
+		}
+		ssetName, hasStatefulSetReference := obj.GetLabels()[label.StatefulSetNameLabelName]
+		if !hasStatefulSetReference || expected[ssetName] || obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if _, hasPodReference := obj.GetLabels()[label.PodNameLabelName]; hasPodReference {
+			// handled from the pod reference
+			continue
+		}
+		var statefulSet appsv1.StatefulSet
+		err = c.Get(types.NamespacedName{Namespace: es.Namespace, Name: ssetName}, &statefulSet)
+		if apierrors.IsNotFound(err) {
+			if err := params.delete(c, runtimeObj, "statefulset "+ssetName+" does not exist"); err != nil {
+				return err
 			}
 		} else if err != nil {
 			return err
@@ -13,8 +13,10 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestIsTooYoungForGC(t *testing.T) {
@@ -67,7 +69,7 @@ func secret(name string, clusterName string, podRef string, creationTime time.Ti
 	}
 }
 
-func TestDeleteOrphanedSecrets(t *testing.T) {
+func TestDeleteOrphanedResources_PodReference(t *testing.T) {
 	now := time.Now()
 	whileAgo := time.Now().Add(-DeleteAfter).Add(-1 * time.Minute)
 
@@ -143,7 +145,7 @@ func TestDeleteOrphanedSecrets(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := DeleteOrphanedSecrets(context.Background(), tt.client, tt.es)
+			err := DeleteOrphanedResources(context.Background(), tt.client, tt.es, Params{})
 			require.NoError(t, err)
 			// the correct number of secrets should remain in the cache
 			var secrets corev1.SecretList
@@ -159,3 +161,99 @@ func TestDeleteOrphanedSecrets(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteOrphanedResources_StatefulSetReference(t *testing.T) {
+	whileAgo := time.Now().Add(-DeleteAfter).Add(-1 * time.Minute)
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "es1"},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+			{Name: "default"},
+			{Name: "zoned", ZoneSpread: &esv1.ZoneSpread{Zones: []string{"a", "b"}}},
+		}},
+	}
+	ssetResource := func(obj metav1.Object, ssetName string, creationTime time.Time) {
+		obj.SetNamespace("ns1")
+		obj.SetLabels(map[string]string{
+			label.ClusterNameLabelName:     es.Name,
+			label.StatefulSetNameLabelName: ssetName,
+		})
+		obj.SetCreationTimestamp(metav1.NewTime(creationTime))
+	}
+	configSecret := func(ssetName string, creationTime time.Time) *corev1.Secret {
+		var s corev1.Secret
+		ssetResource(&s, ssetName, creationTime)
+		s.Name = esv1.ConfigSecret(ssetName)
+		return &s
+	}
+	headlessService := func(ssetName string, creationTime time.Time) *corev1.Service {
+		var s corev1.Service
+		ssetResource(&s, ssetName, creationTime)
+		s.Name = ssetName
+		return &s
+	}
+	existingSset := appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "es1-es-old"}}
+
+	tests := []struct {
+		name         string
+		objs         []runtime.Object
+		params       Params
+		wantSecrets  int
+		wantServices int
+	}{
+		{
+			name:         "resources of an expected NodeSet are kept",
+			objs:         []runtime.Object{configSecret("es1-es-default", whileAgo), headlessService("es1-es-default", whileAgo)},
+			wantSecrets:  1,
+			wantServices: 1,
+		},
+		{
+			name: "resources of the StatefulSets of a NodeSet spread across zones are kept",
+			objs: []runtime.Object{
+				configSecret("es1-es-zoned-a", whileAgo), headlessService("es1-es-zoned-a", whileAgo),
+				configSecret("es1-es-zoned-b", whileAgo), headlessService("es1-es-zoned-b", whileAgo),
+			},
+			wantSecrets:  2,
+			wantServices: 2,
+		},
+		{
+			name:         "resources of a renamed NodeSet are deleted",
+			objs:         []runtime.Object{configSecret("es1-es-old", whileAgo), headlessService("es1-es-old", whileAgo)},
+			wantSecrets:  0,
+			wantServices: 0,
+		},
+		{
+			name: "resources of a StatefulSet still being downscaled are kept",
+			objs: []runtime.Object{
+				&existingSset, configSecret("es1-es-old", whileAgo), headlessService("es1-es-old", whileAgo),
+			},
+			wantSecrets:  1,
+			wantServices: 1,
+		},
+		{
+			name:         "resources younger than the grace period are kept",
+			objs:         []runtime.Object{configSecret("es1-es-old", time.Now().Add(-time.Hour))},
+			params:       Params{GracePeriod: 2 * time.Hour},
+			wantSecrets:  1,
+			wantServices: 0,
+		},
+		{
+			name:         "resources are kept in dry-run mode",
+			objs:         []runtime.Object{configSecret("es1-es-old", whileAgo), headlessService("es1-es-old", whileAgo)},
+			params:       Params{DryRun: true},
+			wantSecrets:  1,
+			wantServices: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := k8s.WrappedFakeClient(tt.objs...)
+			require.NoError(t, DeleteOrphanedResources(context.Background(), c, es, tt.params))
+			var secrets corev1.SecretList
+			require.NoError(t, c.List(&secrets))
+			require.Len(t, secrets.Items, tt.wantSecrets)
+			var services corev1.ServiceList
+			require.NoError(t, c.List(&services))
+			require.Len(t, services.Items, tt.wantServices)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// CrossClusterAPIKeyIndices are the indices a cross-cluster API key grants access to.
+type CrossClusterAPIKeyIndices struct {
+	Names []string `json:"names"`
+}
+
+// CrossClusterAPIKeyAccess is the access granted by a cross-cluster API key.
+type CrossClusterAPIKeyAccess struct {
+	Search      []CrossClusterAPIKeyIndices `json:"search,omitempty"`
+	Replication []CrossClusterAPIKeyIndices `json:"replication,omitempty"`
+}
+
+// CrossClusterAPIKeyRequest is the body of a request creating or updating a cross-cluster API key.
+type CrossClusterAPIKeyRequest struct {
+	// Name is ignored when updating an API key.
+	Name     string                   `json:"name,omitempty"`
+	Access   CrossClusterAPIKeyAccess `json:"access"`
+	Metadata map[string]interface{}   `json:"metadata,omitempty"`
+}
+
+// CrossClusterAPIKey is a cross-cluster API key as returned on creation.
+type CrossClusterAPIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Encoded are the credentials to set in the keystore of the clusters connecting with the API key.
+	Encoded string `json:"encoded"`
+}
+
+// APIKey is an API key as returned by the get API key API.
+type APIKey struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Invalidated bool                   `json:"invalidated"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// APIKeyClient manages the cross-cluster API keys of a cluster.
+//
+// Introduced in: Elasticsearch 8.10.0
+type APIKeyClient interface {
+	// CreateCrossClusterAPIKey creates a cross-cluster API key.
+	CreateCrossClusterAPIKey(ctx context.Context, request CrossClusterAPIKeyRequest) (CrossClusterAPIKey, error)
+	// UpdateCrossClusterAPIKey updates the access and the metadata of the cross-cluster API key with the given ID.
+	UpdateCrossClusterAPIKey(ctx context.Context, id string, request CrossClusterAPIKeyRequest) error
+	// GetAPIKeysByName returns the active API keys with the given name.
+	GetAPIKeysByName(ctx context.Context, name string) ([]APIKey, error)
+	// InvalidateAPIKeysByName invalidates the API keys with the given name.
+	InvalidateAPIKeysByName(ctx context.Context, name string) error
+}
+
+func (c *clientV6) CreateCrossClusterAPIKey(ctx context.Context, request CrossClusterAPIKeyRequest) (CrossClusterAPIKey, error) {
+	var key CrossClusterAPIKey
+	err := c.post(ctx, "/_security/cross_cluster/api_key", request, &key)
+	return key, err
+}
+
+func (c *clientV6) UpdateCrossClusterAPIKey(ctx context.Context, id string, request CrossClusterAPIKeyRequest) error {
+	request.Name = ""
+	return c.put(ctx, "/_security/cross_cluster/api_key/"+url.PathEscape(id), request, nil)
+}
+
+func (c *clientV6) GetAPIKeysByName(ctx context.Context, name string) ([]APIKey, error) {
+	var response struct {
+		APIKeys []APIKey `json:"api_keys"`
+	}
+	err := c.get(ctx, "/_security/api_key?active_only=true&name="+url.QueryEscape(name), &response)
+	if IsNotFound(err) {
+		// returned when no API key matches
+		return nil, nil
+	}
+	return response.APIKeys, err
+}
+
+func (c *clientV6) InvalidateAPIKeysByName(ctx context.Context, name string) error {
+	return c.delete(ctx, "/_security/api_key", map[string]string{"name": name}, nil)
+}
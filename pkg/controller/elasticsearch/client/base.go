@@ -6,14 +6,19 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/x509"
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/audit"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/metrics"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type baseClient struct {
@@ -22,6 +27,11 @@ type baseClient struct {
 	transport *http.Transport
 	Endpoint  string
 	caCerts   []*x509.Certificate
+	// dryRun records the state-changing requests instead of performing them, if set
+	dryRun func(method, pathWithQuery string, body []byte)
+	// cluster is the cluster the requests and their errors are reported for in the metrics, if set
+	cluster *types.NamespacedName
+	options Options
 }
 
 // Close idle connections in the underlying http client.
@@ -64,10 +74,12 @@ func (c *baseClient) doRequest(context context.Context, request *http.Request) (
 	}
 
 	response, err := c.HTTP.Do(withContext)
-	if err != nil {
-		return response, err
+	if err == nil {
+		err = checkError(response)
+	}
+	if c.cluster != nil {
+		metrics.DefaultElasticsearchRequestMetrics.Observe(*c.cluster, string(TypeOf(err)))
 	}
-	err = checkError(response)
 	return response, err
 }
 
@@ -75,6 +87,11 @@ func (c *baseClient) get(ctx context.Context, pathWithQuery string, out interfac
 	return c.request(ctx, http.MethodGet, pathWithQuery, nil, out)
 }
 
+// query performs a POST request which does not change the state of the cluster, such as a search.
+func (c *baseClient) query(ctx context.Context, pathWithQuery string, in, out interface{}) error {
+	return c.doJSON(ctx, http.MethodPost, pathWithQuery, in, out, false)
+}
+
 func (c *baseClient) put(ctx context.Context, pathWithQuery string, in, out interface{}) error {
 	return c.request(ctx, http.MethodPut, pathWithQuery, in, out)
 }
@@ -92,28 +109,79 @@ func (c *baseClient) delete(ctx context.Context, pathWithQuery string, in, out i
 // if requestObj is not nil, it's marshalled as JSON and used as the request body
 // if responseObj is not nil, it should be a pointer to an struct. the response body will be unmarshalled from JSON
 // into this struct.
+// requests other than GET are recorded in the audit log.
 func (c *baseClient) request(
 	ctx context.Context,
 	method string,
 	pathWithQuery string,
 	requestObj,
 	responseObj interface{},
+) error {
+	return c.doJSON(ctx, method, pathWithQuery, requestObj, responseObj, method != http.MethodGet)
+}
+
+func (c *baseClient) doJSON(
+	ctx context.Context,
+	method string,
+	pathWithQuery string,
+	requestObj,
+	responseObj interface{},
+	audited bool,
 ) error {
 	var body io.Reader = http.NoBody
+	var outData []byte
 	if requestObj != nil {
-		outData, err := json.Marshal(requestObj)
+		var err error
+		outData, err = json.Marshal(requestObj)
 		if err != nil {
 			return err
 		}
 		body = bytes.NewBuffer(outData)
 	}
 
-	request, err := http.NewRequest(method, stringsutil.Concat(c.Endpoint, pathWithQuery), body)
+	if audited && c.dryRun != nil {
+		c.dryRun(method, pathWithQuery, outData)
+		return nil
+	}
+
+	compressed := c.options.Compression && outData != nil
+	if compressed {
+		var err error
+		if body, err = compress(outData); err != nil {
+			return err
+		}
+	}
+
+	url := stringsutil.Concat(c.Endpoint, pathWithQuery)
+	request, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return err
 	}
+	if compressed {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+
+	// the audited requests are the ones changing the state of the cluster
+	timeout := c.options.ReadTimeout
+	if audited {
+		timeout = c.options.WriteTimeout
+	}
+	if audited && timeout > 0 {
+		// the callers usually bound the requests with the default request timeout: the write timeout must apply
+		// regardless, as it may be longer for the large clusters
+		ctx = detachedContext{parent: ctx}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		// the response body is read before returning
+		defer cancel()
+	}
 
 	resp, err := c.doRequest(ctx, request)
+	if audited {
+		audit.Default.RecordRequest(method, url, outData, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -129,6 +197,41 @@ func (c *baseClient) request(
 	return nil
 }
 
+// detachedContext carries the values of its parent context, such as the tracing transaction, without its deadline and
+// cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// compress returns the given data compressed with gzip.
+func compress(data []byte) (io.Reader, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
 func versioned(b *baseClient, v version.Version) Client {
 	v6 := clientV6{
 		baseClient: *b,
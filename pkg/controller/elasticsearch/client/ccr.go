@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// FollowRequest is the body of a request creating a follower index.
+type FollowRequest struct {
+	RemoteCluster string `json:"remote_cluster"`
+	LeaderIndex   string `json:"leader_index"`
+}
+
+// FollowerInfo describes a follower index as returned by the follow info API.
+type FollowerInfo struct {
+	FollowerIndex string `json:"follower_index"`
+	RemoteCluster string `json:"remote_cluster"`
+	LeaderIndex   string `json:"leader_index"`
+	Status        string `json:"status"`
+}
+
+// AutoFollowPattern is an auto-follow pattern as expected and returned by the auto-follow API.
+type AutoFollowPattern struct {
+	RemoteCluster       string   `json:"remote_cluster"`
+	LeaderIndexPatterns []string `json:"leader_index_patterns"`
+	FollowIndexPattern  string   `json:"follow_index_pattern,omitempty"`
+}
+
+// FollowShardStats are the replication stats of a shard of a follower index.
+type FollowShardStats struct {
+	FollowerIndex            string `json:"follower_index"`
+	ShardID                  int    `json:"shard_id"`
+	LeaderGlobalCheckpoint   int64  `json:"leader_global_checkpoint"`
+	FollowerGlobalCheckpoint int64  `json:"follower_global_checkpoint"`
+	// ReadExceptions are the errors of the reads from the leader shard that are being retried.
+	ReadExceptions []struct {
+		FromSeqNo int64 `json:"from_seq_no"`
+	} `json:"read_exceptions"`
+}
+
+// FollowIndexStats are the replication stats of a follower index.
+type FollowIndexStats struct {
+	Index  string             `json:"index"`
+	Shards []FollowShardStats `json:"shards"`
+}
+
+// CCRStats are the cross-cluster replication stats of a cluster.
+type CCRStats struct {
+	FollowStats struct {
+		Indices []FollowIndexStats `json:"indices"`
+	} `json:"follow_stats"`
+}
+
+// CCRClient manages the cross-cluster replication of the follower indices of a cluster.
+type CCRClient interface {
+	// GetFollowerIndices returns the follower indices of the cluster.
+	GetFollowerIndices(ctx context.Context) ([]FollowerInfo, error)
+	// FollowIndex creates a follower index replicating the given leader index.
+	FollowIndex(ctx context.Context, index string, request FollowRequest) error
+	// GetAutoFollowPatterns returns the auto-follow patterns of the cluster, keyed by name.
+	GetAutoFollowPatterns(ctx context.Context) (map[string]AutoFollowPattern, error)
+	// UpdateAutoFollowPattern creates or updates the auto-follow pattern with the given name.
+	UpdateAutoFollowPattern(ctx context.Context, name string, pattern AutoFollowPattern) error
+	// DeleteAutoFollowPattern deletes the auto-follow pattern with the given name.
+	DeleteAutoFollowPattern(ctx context.Context, name string) error
+	// GetCCRStats returns the cross-cluster replication stats of the cluster.
+	GetCCRStats(ctx context.Context) (CCRStats, error)
+}
+
+func (c *clientV6) GetFollowerIndices(ctx context.Context) ([]FollowerInfo, error) {
+	var info struct {
+		FollowerIndices []FollowerInfo `json:"follower_indices"`
+	}
+	err := c.get(ctx, "/_all/_ccr/info", &info)
+	return info.FollowerIndices, err
+}
+
+func (c *clientV6) FollowIndex(ctx context.Context, index string, request FollowRequest) error {
+	return c.put(ctx, "/"+url.PathEscape(index)+"/_ccr/follow", request, nil)
+}
+
+func (c *clientV6) GetAutoFollowPatterns(ctx context.Context) (map[string]AutoFollowPattern, error) {
+	var response struct {
+		Patterns []struct {
+			Name    string            `json:"name"`
+			Pattern AutoFollowPattern `json:"pattern"`
+		} `json:"patterns"`
+	}
+	if err := c.get(ctx, "/_ccr/auto_follow", &response); err != nil {
+		if IsNotFound(err) {
+			// returned by some versions when there are no patterns
+			return map[string]AutoFollowPattern{}, nil
+		}
+		return nil, err
+	}
+	patterns := make(map[string]AutoFollowPattern, len(response.Patterns))
+	for _, p := range response.Patterns {
+		patterns[p.Name] = p.Pattern
+	}
+	return patterns, nil
+}
+
+func (c *clientV6) UpdateAutoFollowPattern(ctx context.Context, name string, pattern AutoFollowPattern) error {
+	return c.put(ctx, "/_ccr/auto_follow/"+url.PathEscape(name), pattern, nil)
+}
+
+func (c *clientV6) DeleteAutoFollowPattern(ctx context.Context, name string) error {
+	return c.delete(ctx, "/_ccr/auto_follow/"+url.PathEscape(name), nil, nil)
+}
+
+func (c *clientV6) GetCCRStats(ctx context.Context) (CCRStats, error) {
+	var stats CCRStats
+	err := c.get(ctx, "/_ccr/stats", &stats)
+	return stats, err
+}
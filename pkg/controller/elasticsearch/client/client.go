@@ -8,9 +8,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -18,6 +16,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/utils/cryptutil"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
 	"go.elastic.co/apm/module/apmelasticsearch"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
@@ -35,12 +34,9 @@ type BasicAuth struct {
 
 // Role represents an Elasticsearch role.
 type Role struct {
-	Cluster []string `json:"cluster,omitempty"`
-	/*Indices []struct {
-		Names      []string `json:"names,omitempty"`
-		Privileges []string `json:",omitempty"`
-	} `json:"indices,omitempty"`
-	Applications []struct {
+	Cluster []string            `json:"cluster,omitempty"`
+	Indices []IndicesPrivileges `json:"indices,omitempty" yaml:"indices,omitempty"`
+	/*Applications []struct {
 		Application string   `json:"application"`
 		Privileges  []string `json:"privileges"`
 		Resources   []string `json:"resources,omitempty"`
@@ -54,11 +50,30 @@ type Role struct {
 	} `json:"transient_metadata,omitempty"`*/
 }
 
+// IndicesPrivileges are the privileges of a role on a set of indices.
+type IndicesPrivileges struct {
+	Names      []string `json:"names"`
+	Privileges []string `json:"privileges"`
+}
+
 // Client captures the information needed to interact with an Elasticsearch cluster via HTTP
 type Client interface {
 	AllocationSetter
 	ShardLister
 	LicenseClient
+	SnapshotRepositoryClient
+	SnapshotClient
+	CCRClient
+	APIKeyClient
+	ClusterConfigClient
+	IndicesClient
+	ReindexClient
+	WatcherClient
+	IngestPipelineClient
+	MLClient
+	IndexingTasksClient
+	SearchClient
+	NodeShutdownClient
 	// Close idle connections in the underlying http client.
 	Close()
 	// Equal returns true if other can be considered as the same client.
@@ -76,15 +91,27 @@ type Client interface {
 	SyncedFlush(ctx context.Context) error
 	// GetClusterHealth calls the _cluster/health api.
 	GetClusterHealth(ctx context.Context) (Health, error)
+	// GetIndicesHealth returns the health of the indices matching the given names or wildcard patterns, sorted by
+	// name, computed from the _cat/shards api. Missing indices are ignored.
+	GetIndicesHealth(ctx context.Context, indices []string) ([]IndexHealth, error)
 	// SetMinimumMasterNodes sets the transient and persistent setting of the same name in cluster settings.
 	SetMinimumMasterNodes(ctx context.Context, n int) error
 	// ReloadSecureSettings will decrypt and re-read the entire keystore, on every cluster node,
 	// but only the reloadable secure settings will be applied
 	ReloadSecureSettings(ctx context.Context) error
+	// ReloadSearchAnalyzers reloads the search analyzers of all the indices, for them to pick up the latest content of
+	// their synonym files.
+	//
+	// Introduced in: Elasticsearch 7.3.0
+	ReloadSearchAnalyzers(ctx context.Context) error
 	// GetNodes calls the _nodes api to return a map(nodeName -> Node)
 	GetNodes(ctx context.Context) (Nodes, error)
 	// GetNodesStats calls the _nodes/stats api to return a map(nodeName -> NodeStats)
 	GetNodesStats(ctx context.Context) (NodesStats, error)
+	// GetPendingTasks calls the _cluster/pending_tasks api to return the cluster-level changes not executed yet.
+	GetPendingTasks(ctx context.Context) (PendingTasks, error)
+	// GetCircuitBreakersStats calls the _nodes/stats/breaker api to return the circuit breakers stats of the nodes.
+	GetCircuitBreakersStats(ctx context.Context) (CircuitBreakersStats, error)
 	// ClusterBootstrappedForZen2 returns true if the cluster is relying on zen2 orchestration.
 	ClusterBootstrappedForZen2(ctx context.Context) (bool, error)
 	// UpdateRemoteClusterSettings updates the remote clusters of a cluster.
@@ -163,50 +190,57 @@ func NewElasticsearchClient(
 	return versioned(base, v)
 }
 
-// APIError is a non 2xx response from the Elasticsearch API
-type APIError struct {
-	response *http.Response
-}
-
-// Error() implements the error interface.
-func (e *APIError) Error() string {
-	defer e.response.Body.Close()
-	reason := "unknown"
-	// Elasticsearch has a detailed error message in the response body
-	var errMsg ErrorResponse
-	err := json.NewDecoder(e.response.Body).Decode(&errMsg)
-	if err == nil {
-		reason = errMsg.Error.Reason
+// DryRun returns the given client, recording the calls which change the state of the cluster with the given function
+// instead of performing them. Such calls return no error and leave their response untouched.
+func DryRun(c Client, record func(method, pathWithQuery string, body []byte)) Client {
+	switch typed := c.(type) {
+	case *clientV6:
+		typed.dryRun = record
+	case *clientV7:
+		typed.dryRun = record
+	case *clientV8:
+		typed.dryRun = record
 	}
-	return fmt.Sprintf("%s: %s", e.response.Status, reason)
+	return c
 }
 
-// IsNotFound checks whether the error was an HTTP 404 error.
-func IsNotFound(err error) bool {
-	switch err := err.(type) {
-	case *APIError:
-		return err.response.StatusCode == http.StatusNotFound
-	default:
-		return false
-	}
+// Options tune the requests of a client to Elasticsearch.
+type Options struct {
+	// Compression compresses the bodies of the requests with gzip. The responses are compressed if the cluster enables
+	// http.compression, since the client always accepts gzip encoded responses.
+	Compression bool
+	// ReadTimeout is the timeout of the requests retrieving the state of the cluster, such as its health, or 0 for no
+	// timeout other than the one of the request context.
+	ReadTimeout time.Duration
+	// WriteTimeout is the timeout of the requests changing the state of the cluster, such as its settings or the
+	// shutdown of its nodes, or 0 for no timeout other than the one of the request context. If set, it replaces the
+	// deadline of the request context.
+	WriteTimeout time.Duration
 }
 
-// IsConflict checks whether the error was an HTTP 409 error.
-func IsConflict(err error) bool {
-	switch err := err.(type) {
-	case *APIError:
-		return err.response.StatusCode == http.StatusConflict
-	default:
-		return false
+// WithOptions returns the given client, performing its requests with the given options.
+func WithOptions(c Client, opts Options) Client {
+	switch typed := c.(type) {
+	case *clientV6:
+		typed.options = opts
+	case *clientV7:
+		typed.options = opts
+	case *clientV8:
+		typed.options = opts
 	}
+	return c
 }
 
-// IsForbidden checks whether the error was an HTTP 403 error.
-func IsForbidden(err error) bool {
-	switch err := err.(type) {
-	case *APIError:
-		return err.response.StatusCode == http.StatusForbidden
-	default:
-		return false
+// WithCluster returns the given client, reporting its requests to the given cluster and their errors in the
+// Elasticsearch request metrics.
+func WithCluster(c Client, cluster types.NamespacedName) Client {
+	switch typed := c.(type) {
+	case *clientV6:
+		typed.cluster = &cluster
+	case *clientV7:
+		typed.cluster = &cluster
+	case *clientV8:
+		typed.cluster = &cluster
 	}
+	return c
 }
@@ -6,21 +6,28 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/audit"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	fixtures "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client/test_fixtures"
 	"github.com/elastic/cloud-on-k8s/pkg/dev/portforward"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -261,6 +268,103 @@ func TestClient_request(t *testing.T) {
 	}
 }
 
+func TestClient_request_audit(t *testing.T) {
+	var buf bytes.Buffer
+	audit.Default = audit.NewLogger(&buf)
+	defer func() { audit.Default = nil }()
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		return NewMockResponse(200, req, `{}`)
+	})
+
+	// reads and searches are not recorded
+	_, err := testClient.GetClusterInfo(context.Background())
+	require.NoError(t, err)
+	_, err = testClient.Search(context.Background(), []string{"logs-*"}, nil)
+	require.NoError(t, err)
+	require.Empty(t, buf.String())
+
+	// state-changing calls are recorded with their body
+	require.NoError(t, testClient.UpdateClusterSettings(context.Background(), ClusterSettings{}))
+	var event audit.Event
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &event))
+	require.Equal(t, audit.ElasticsearchTarget, event.Target)
+	require.Equal(t, http.MethodPut, event.Method)
+	require.Equal(t, "http://example.com/_cluster/settings", event.URL)
+	require.NotEmpty(t, event.Diff)
+}
+
+func TestDryRun(t *testing.T) {
+	var requests []string
+	testClient := DryRun(NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		return NewMockResponse(200, req, `{}`)
+	}), func(method, pathWithQuery string, body []byte) {
+		requests = append(requests, "dry-run "+method+" "+pathWithQuery+" "+string(body))
+	})
+
+	_, err := testClient.GetClusterInfo(context.Background())
+	require.NoError(t, err)
+	_, err = testClient.Search(context.Background(), []string{"logs-*"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, testClient.UpdateClusterSettings(context.Background(), ClusterSettings{}))
+	require.Equal(t, []string{
+		"GET /",
+		"POST /logs-*/_search",
+		"dry-run PUT /_cluster/settings {}",
+	}, requests)
+}
+
+func TestWithOptions_Compression(t *testing.T) {
+	var encoding string
+	var body []byte
+	testClient := WithOptions(NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		encoding = req.Header.Get("Content-Encoding")
+		r, err := gzip.NewReader(req.Body)
+		require.NoError(t, err)
+		body, err = ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return NewMockResponse(200, req, `{}`)
+	}), Options{Compression: true})
+
+	require.NoError(t, testClient.UpdateClusterSettings(context.Background(), ClusterSettings{}))
+	require.Equal(t, "gzip", encoding)
+	require.Equal(t, `{}`, string(body))
+}
+
+func TestWithOptions_Timeouts(t *testing.T) {
+	var timeout time.Duration
+	testClient := WithOptions(NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		deadline, ok := req.Context().Deadline()
+		require.True(t, ok)
+		// round up to the timeout
+		timeout = time.Until(deadline).Round(time.Minute)
+		return NewMockResponse(200, req, `{}`)
+	}), Options{ReadTimeout: time.Minute, WriteTimeout: 5 * time.Minute})
+
+	_, err := testClient.GetClusterHealth(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, timeout)
+	_, err = testClient.Search(context.Background(), []string{"logs-*"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, timeout)
+	require.NoError(t, testClient.UpdateClusterSettings(context.Background(), ClusterSettings{}))
+	require.Equal(t, 5*time.Minute, timeout)
+
+	// the request context can have a shorter timeout for the reads
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	_, err = testClient.GetClusterHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, timeout)
+	_, err = WithOptions(testClient, Options{ReadTimeout: 5 * time.Minute}).GetClusterHealth(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Minute, timeout)
+	// but not for the writes, the write timeout applies regardless of the deadline of the caller
+	testClient = WithOptions(testClient, Options{WriteTimeout: 5 * time.Minute})
+	require.NoError(t, testClient.UpdateClusterSettings(ctx, ClusterSettings{}))
+	require.Equal(t, 5*time.Minute, timeout)
+}
+
 func TestAPIError_Error(t *testing.T) {
 	type fields struct {
 		response *http.Response
@@ -292,8 +396,11 @@ func TestAPIError_Error(t *testing.T) {
 			e := &APIError{
 				response: tt.fields.response,
 			}
-			if got := e.Error(); got != tt.want {
-				t.Errorf("APIError.Error() = %v, want %v", got, tt.want)
+			// the body of the response can only be read once
+			for i := 0; i < 2; i++ {
+				if got := e.Error(); got != tt.want {
+					t.Errorf("APIError.Error() = %v, want %v", got, tt.want)
+				}
 			}
 		})
 	}
@@ -319,7 +426,7 @@ func TestClientGetNodes(t *testing.T) {
 }
 
 func TestClientGetNodesStats(t *testing.T) {
-	expectedPath := "/_nodes/_all/stats/os"
+	expectedPath := "/_nodes/_all/stats/os,jvm,fs,indices/indexing,search"
 	testClient := NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
 		require.Equal(t, expectedPath, req.URL.Path)
 		return &http.Response{
@@ -334,6 +441,89 @@ func TestClientGetNodesStats(t *testing.T) {
 	require.Equal(t, 1, len(resp.Nodes))
 	require.Contains(t, resp.Nodes, "Rt-o5-ZBQaq-Nkhhy0p7JA")
 	require.Equal(t, "3221225472", resp.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].OS.CGroup.Memory.LimitInBytes)
+	require.Equal(t, 46, resp.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].JVM.Mem.HeapUsedPercent)
+	require.Equal(t, int64(895094837248), resp.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].FS.Total.AvailableInBytes)
+	require.Equal(t, int64(38267), resp.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].Indices.Indexing.IndexTotal)
+	require.Equal(t, int64(5112), resp.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].Indices.Search.QueryTotal)
+}
+
+func TestClientGetPendingTasks(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_cluster/pending_tasks", req.URL.Path)
+		return NewMockResponse(200, req, `{"tasks":[{"insert_order":101,"priority":"URGENT","source":"create-index [foo_9], cause [api]","time_in_queue_millis":86}]}`)
+	})
+	pendingTasks, err := testClient.GetPendingTasks(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, PendingTasks{Tasks: []PendingTask{
+		{InsertOrder: 101, Priority: "URGENT", Source: "create-index [foo_9], cause [api]", TimeInQueueMillis: 86},
+	}}, pendingTasks)
+}
+
+func TestClientGetCircuitBreakersStats(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_nodes/_all/stats/breaker", req.URL.Path)
+		return NewMockResponse(200, req, `{"nodes":{"Rt-o5-ZBQaq-Nkhhy0p7JA":{"name":"es-default-0","breakers":{"request":{"tripped":2},"parent":{"tripped":1}}}}}`)
+	})
+	stats, err := testClient.GetCircuitBreakersStats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].Breakers["request"].Tripped)
+	require.Equal(t, "es-default-0", stats.Nodes["Rt-o5-ZBQaq-Nkhhy0p7JA"].Name)
+}
+
+func TestClientGetCapacitySettings(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_cluster/settings", req.URL.Path)
+		require.Equal(t, "true", req.URL.Query().Get("include_defaults"))
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(fixtures.CapacitySettingsSample)),
+			Header:     make(http.Header),
+			Request:    req,
+		}
+	})
+	settings, err := testClient.GetCapacitySettings(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, CapacitySettings{
+		// transient settings take precedence
+		DiskWatermarkLow:        "75%",
+		DiskWatermarkHigh:       "90%",
+		DiskWatermarkFloodStage: "95%",
+		MaxShardsPerNode:        "1000",
+	}, settings)
+}
+
+func TestClientGetLoggingSettings(t *testing.T) {
+	responses := map[string]string{
+		"/_cluster/settings":                           `{"persistent":{"logger.org.elasticsearch.discovery":"DEBUG","logger._root":"INFO","cluster.max_shards_per_node":"2000"},"transient":{"logger._root":"WARN"}}`,
+		"/_all/_settings/" + slowLogThresholdsSettings: `{"logs-app":{"settings":{"index.search.slowlog.threshold.query.warn":"10s"}},"metrics":{"settings":{}}}`,
+	}
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "true", req.URL.Query().Get("flat_settings"))
+		body, exists := responses[req.URL.Path]
+		require.True(t, exists, req.URL.Path)
+		return NewMockResponse(200, req, body)
+	})
+	settings, err := testClient.GetLoggingSettings(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, LoggingSettings{
+		// transient levels take precedence
+		Loggers: map[string]string{"logger.org.elasticsearch.discovery": "DEBUG", "logger._root": "WARN"},
+		SlowLogThresholds: map[string]map[string]string{
+			"logs-app": {"index.search.slowlog.threshold.query.warn": "10s"},
+			"metrics":  {},
+		},
+	}, settings)
+}
+
+func TestClientGetIndexBlocks(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_all/_settings/"+ReadOnlyAllowDeleteSetting, req.URL.Path)
+		require.Equal(t, "true", req.URL.Query().Get("flat_settings"))
+		return NewMockResponse(200, req, `{"logs-b":{"settings":{"index.blocks.read_only_allow_delete":"true"}},"logs-a":{"settings":{"index.blocks.read_only_allow_delete":"true"}},"unblocked":{"settings":{"index.blocks.read_only_allow_delete":"false"}},"metrics":{"settings":{}}}`)
+	})
+	blocks, err := testClient.GetIndexBlocks(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, IndexBlocks{ReadOnlyAllowDelete: []string{"logs-a", "logs-b"}}, blocks)
 }
 
 func TestGetInfo(t *testing.T) {
@@ -547,11 +737,12 @@ func TestAPIError_Types(t *testing.T) {
 		err error
 	}
 	tests := []struct {
-		name          string
-		args          args
-		wantConflict  bool
-		wantForbidden bool
-		wantNotFound  bool
+		name             string
+		args             args
+		wantConflict     bool
+		wantForbidden    bool
+		wantNotFound     bool
+		wantUnauthorized bool
 	}{
 		{
 			name: "500 is not any of the explicitly supported error types",
@@ -573,6 +764,13 @@ func TestAPIError_Types(t *testing.T) {
 			},
 			wantForbidden: true,
 		},
+		{
+			name: "401 is unauthorized",
+			args: args{
+				err: &APIError{response: NewMockResponse(401, nil, "")}, // nolint
+			},
+			wantUnauthorized: true,
+		},
 		{
 			name: "404 is not found",
 			args: args{
@@ -599,6 +797,48 @@ func TestAPIError_Types(t *testing.T) {
 			if got := IsConflict(tt.args.err); got != tt.wantConflict {
 				t.Errorf("IsConflict() = %v, want %v", got, tt.wantConflict)
 			}
+			if got := IsUnauthorized(tt.args.err); got != tt.wantUnauthorized {
+				t.Errorf("IsUnauthorized() = %v, want %v", got, tt.wantUnauthorized)
+			}
+		})
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	apiError := func(statusCode int, body string) error {
+		return &APIError{response: NewMockResponse(statusCode, nil, body)} // nolint
+	}
+	tests := []struct {
+		name string
+		err  error
+		want ErrorType
+	}{
+		{name: "no error", err: nil, want: ""},
+		{name: "404", err: apiError(404, ""), want: NotFoundError},
+		{name: "409", err: apiError(409, ""), want: ConflictError},
+		{name: "401", err: apiError(401, ""), want: SecurityExceptionError},
+		{name: "403", err: apiError(403, ""), want: SecurityExceptionError},
+		{name: "429", err: apiError(429, ""), want: UnavailableError},
+		{name: "503", err: apiError(503, ""), want: UnavailableError},
+		{name: "500", err: apiError(500, ""), want: OtherError},
+		{
+			name: "security exception in the body",
+			err:  apiError(400, `{"error":{"type":"security_exception","reason":"action is unauthorized"}}`),
+			want: SecurityExceptionError,
+		},
+		{name: "400", err: apiError(400, fixtures.ErrorSample), want: OtherError},
+		{name: "wrapped", err: pkgerrors.Wrap(apiError(409, ""), "while updating"), want: ConflictError},
+		{
+			name: "connection refused",
+			err:  &url.Error{Op: "Get", URL: "https://es:9200", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			want: UnavailableError,
+		},
+		{name: "timeout", err: context.DeadlineExceeded, want: UnavailableError},
+		{name: "other error", err: errors.New("failure"), want: OtherError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, TypeOf(tt.err))
 		})
 	}
 }
@@ -652,3 +892,236 @@ func TestClient_ClusterBootstrappedForZen2(t *testing.T) {
 		})
 	}
 }
+
+func TestClientGetIndices(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/logs-*,metrics/_settings/index.creation_date,index.blocks.write,index.blocks.read_only", req.URL.Path)
+		require.Equal(t, "open", req.URL.Query().Get("expand_wildcards"))
+		return NewMockResponse(200, req, `{
+			"logs-b":{"settings":{"index.creation_date":"1583298000000","index.blocks.write":"true"}},
+			"logs-a":{"settings":{"index.creation_date":"1583294400000"}},
+			"metrics":{"settings":{"index.creation_date":"1583294400000","index.blocks.read_only":"true"}}
+		}`)
+	})
+	indices, err := testClient.GetIndices(context.Background(), []string{"logs-*", "metrics"})
+	require.NoError(t, err)
+	require.Equal(t, []IndexInfo{
+		{Name: "logs-a", CreationDate: time.Date(2020, 3, 4, 4, 0, 0, 0, time.UTC)},
+		{Name: "logs-b", CreationDate: time.Date(2020, 3, 4, 5, 0, 0, 0, time.UTC), ReadOnly: true},
+		{Name: "metrics", CreationDate: time.Date(2020, 3, 4, 4, 0, 0, 0, time.UTC), ReadOnly: true},
+	}, indices)
+}
+
+func TestClientGetIndicesHealth(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "json", req.URL.Query().Get("format"))
+		switch req.URL.Path {
+		case "/_cat/shards/logs-*":
+			return NewMockResponse(200, req, `[
+				{"index":"logs-b","prirep":"p","state":"STARTED","unassigned.reason":null},
+				{"index":"logs-b","prirep":"r","state":"UNASSIGNED","unassigned.reason":"NODE_LEFT"},
+				{"index":"logs-a","prirep":"p","state":"RELOCATING","unassigned.reason":null},
+				{"index":"logs-a","prirep":"r","state":"STARTED","unassigned.reason":null}
+			]`)
+		case "/_cat/shards/users":
+			return NewMockResponse(200, req, `[
+				{"index":"users","prirep":"p","state":"UNASSIGNED","unassigned.reason":"NODE_LEFT"},
+				{"index":"users","prirep":"r","state":"UNASSIGNED","unassigned.reason":"NODE_LEFT"},
+				{"index":"users","prirep":"p","state":"UNASSIGNED","unassigned.reason":"ALLOCATION_FAILED"},
+				{"index":"users","prirep":"p","state":"INITIALIZING","unassigned.reason":null}
+			]`)
+		case "/_cat/shards/missing":
+			return NewMockResponse(404, req, `{"error":{"type":"index_not_found_exception"}}`)
+		}
+		require.Fail(t, "unexpected request", req.URL.Path)
+		return nil
+	})
+	indices, err := testClient.GetIndicesHealth(context.Background(), []string{"logs-*", "users", "missing"})
+	require.NoError(t, err)
+	require.Equal(t, []IndexHealth{
+		{Index: "logs-a", Status: esv1.ElasticsearchGreenHealth},
+		{Index: "logs-b", Status: esv1.ElasticsearchYellowHealth},
+		{Index: "users", Status: esv1.ElasticsearchRedHealth, UnassignedReasons: []string{"ALLOCATION_FAILED", "NODE_LEFT"}},
+	}, indices)
+}
+
+func TestClientDeleteIndices_Batches(t *testing.T) {
+	indices := make([]string, 0, 300)
+	for i := 0; i < 300; i++ {
+		indices = append(indices, fmt.Sprintf("logs-2020.01.01-%06d", i))
+	}
+	var deleted []string
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, http.MethodDelete, req.Method)
+		require.LessOrEqual(t, len(req.URL.Path), maxIndicesPathLength+1)
+		deleted = append(deleted, strings.Split(strings.TrimPrefix(req.URL.Path, "/"), ",")...)
+		return NewMockResponse(200, req, `{"acknowledged":true}`)
+	})
+	require.NoError(t, testClient.DeleteIndices(context.Background(), indices))
+	// all the indices are deleted, in several requests
+	require.Equal(t, indices, deleted)
+}
+
+func TestClientStartReindex(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_reindex", req.URL.Path)
+		require.Equal(t, "false", req.URL.Query().Get("wait_for_completion"))
+		require.Equal(t, "auto", req.URL.Query().Get("slices"))
+		require.Equal(t, "500", req.URL.Query().Get("requests_per_second"))
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"source":{"index":["logs-v1"]},"dest":{"index":"logs-v2"},"conflicts":"proceed"}`, string(body))
+		return NewMockResponse(200, req, `{"task":"oTUltX4IQMOUUVeiohTt8A:12345"}`)
+	})
+	taskID, err := testClient.StartReindex(context.Background(), ReindexRequest{
+		Source:    ReindexSource{Index: []string{"logs-v1"}},
+		Dest:      ReindexDestination{Index: "logs-v2"},
+		Conflicts: "proceed",
+	}, ReindexOptions{RequestsPerSecond: 500})
+	require.NoError(t, err)
+	require.Equal(t, "oTUltX4IQMOUUVeiohTt8A:12345", taskID)
+}
+
+func TestClientGetReindexTask(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_tasks/oTUltX4IQMOUUVeiohTt8A:12345", req.URL.Path)
+		return NewMockResponse(200, req, `{
+			"completed": true,
+			"task": {"status": {"total": 10, "created": 8, "updated": 0, "deleted": 0, "version_conflicts": 0}},
+			"response": {"failures": [
+				{"index": "logs-v2", "cause": {"type": "mapper_parsing_exception", "reason": "failed to parse field [status]"}},
+				{"index": "logs-v2", "cause": {"type": "mapper_parsing_exception", "reason": "failed to parse field [status]"}}
+			]}
+		}`)
+	})
+	task, err := testClient.GetReindexTask(context.Background(), "oTUltX4IQMOUUVeiohTt8A:12345")
+	require.NoError(t, err)
+	require.True(t, task.Completed)
+	require.Equal(t, ReindexTaskStatus{Total: 10, Created: 8}, task.Task.Status)
+	require.Equal(t, "2 documents failed, first failure in index logs-v2: mapper_parsing_exception: failed to parse field [status]",
+		task.FailureReason())
+}
+
+func TestClientGetWatch(t *testing.T) {
+	tests := []struct {
+		version string
+		path    string
+	}{
+		{version: "6.8.0", path: "/_xpack/watcher/watch/cluster_health"},
+		{version: "7.6.0", path: "/_watcher/watch/cluster_health"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			testClient := NewMockClient(version.MustParse(tt.version), func(req *http.Request) *http.Response {
+				require.Equal(t, tt.path, req.URL.Path)
+				return NewMockResponse(200, req, `{
+					"found": true, "_id": "cluster_health", "_version": 2,
+					"status": {"state": {"active": true}},
+					"watch": {"trigger": {"schedule": {"interval": "10m"}}}
+				}`)
+			})
+			watch, err := testClient.GetWatch(context.Background(), "cluster_health")
+			require.NoError(t, err)
+			require.Equal(t, map[string]interface{}{
+				"trigger": map[string]interface{}{"schedule": map[string]interface{}{"interval": "10m"}},
+			}, watch)
+		})
+	}
+}
+
+func TestClientSimulateIngestPipeline(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_ingest/pipeline/_simulate", req.URL.Path)
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"pipeline": {"processors": [{"date": {"field": "ts", "formats": ["ISO8601"]}}]},
+			"docs": [{"_source": {"ts": "2020-03-04T05:00:00Z"}}, {"_source": {"ts": "yesterday"}}]
+		}`, string(body))
+		return NewMockResponse(200, req, `{"docs": [
+			{"doc": {"_index": "_index", "_source": {"ts": "2020-03-04T05:00:00Z", "@timestamp": "2020-03-04T05:00:00.000Z"}}},
+			{"error": {"type": "illegal_argument_exception", "reason": "unable to parse date [yesterday]"}}
+		]}`)
+	})
+	docs, err := testClient.SimulateIngestPipeline(context.Background(),
+		map[string]interface{}{"processors": []interface{}{
+			map[string]interface{}{"date": map[string]interface{}{"field": "ts", "formats": []interface{}{"ISO8601"}}},
+		}},
+		[]map[string]interface{}{{"ts": "2020-03-04T05:00:00Z"}, {"ts": "yesterday"}},
+	)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	require.NotNil(t, docs[0].Doc)
+	require.Nil(t, docs[0].Error)
+	require.Nil(t, docs[1].Doc)
+	require.Equal(t, "unable to parse date [yesterday]", docs[1].Error.Reason)
+}
+
+func TestClientSetMLUpgradeMode(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, http.MethodPost, req.Method)
+		require.Equal(t, "/_ml/set_upgrade_mode", req.URL.Path)
+		require.Equal(t, "enabled=true", req.URL.RawQuery)
+		return NewMockResponse(200, req, `{"acknowledged": true}`)
+	})
+	require.NoError(t, testClient.SetMLUpgradeMode(context.Background(), true))
+}
+
+func TestClientGetIndexingTasks(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_tasks", req.URL.Path)
+		require.Equal(t, "data_frame/transforms*,xpack/rollup/job*", req.URL.Query().Get("actions"))
+		return NewMockResponse(200, req, `{"nodes": {
+			"n1": {"name": "es-default-1", "tasks": {
+				"n1:12": {"action": "xpack/rollup/job[c]", "description": "rollup_sensors", "status": {"job_state": "started"}},
+				"n1:13": {"action": "data_frame/transforms[c]", "description": "data_frame_ecommerce", "status": {"task_state": "started", "indexer_state": "indexing"}}
+			}},
+			"n0": {"name": "es-default-0", "tasks": {
+				"n0:7": {"action": "xpack/rollup/job[c]", "description": "rollup_logs", "status": {"job_state": "indexing"}}
+			}}
+		}}`)
+	})
+	tasks, err := testClient.GetIndexingTasks(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []IndexingTask{
+		{Type: RollupJobTask, ID: "logs", Node: "es-default-0", Indexing: true},
+		{Type: RollupJobTask, ID: "sensors", Node: "es-default-1"},
+		{Type: TransformTask, ID: "ecommerce", Node: "es-default-1", Indexing: true},
+	}, tasks)
+}
+
+func TestClientStopIndexingTask(t *testing.T) {
+	tests := []struct {
+		version string
+		task    IndexingTask
+		path    string
+	}{
+		{version: "6.8.0", task: IndexingTask{Type: RollupJobTask, ID: "logs"}, path: "/_xpack/rollup/job/logs/_stop"},
+		{version: "7.6.0", task: IndexingTask{Type: RollupJobTask, ID: "logs"}, path: "/_rollup/job/logs/_stop"},
+		{version: "7.6.0", task: IndexingTask{Type: TransformTask, ID: "ecommerce"}, path: "/_transform/ecommerce/_stop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			testClient := NewMockClient(version.MustParse(tt.version), func(req *http.Request) *http.Response {
+				require.Equal(t, tt.path, req.URL.Path)
+				require.Equal(t, "true", req.URL.Query().Get("wait_for_completion"))
+				return NewMockResponse(200, req, `{"stopped": true}`)
+			})
+			require.NoError(t, testClient.StopIndexingTask(context.Background(), tt.task))
+		})
+	}
+}
+
+func TestClientSearch(t *testing.T) {
+	testClient := NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/logs-*,metrics/_search", req.URL.Path)
+		require.Equal(t, "false", req.URL.Query().Get("request_cache"))
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"size": 0}`, string(body))
+		return NewMockResponse(200, req, `{"took": 12, "hits": {"total": {"value": 3}, "hits": []}}`)
+	})
+	results, err := testClient.Search(context.Background(), []string{"logs-*", "metrics"}, map[string]interface{}{"size": 0})
+	require.NoError(t, err)
+	require.Equal(t, 12, results.Took)
+}
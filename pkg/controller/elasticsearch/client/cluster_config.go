@@ -0,0 +1,274 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ClusterSettings holds the flat persistent and transient settings of a cluster.
+type ClusterSettings struct {
+	Persistent map[string]interface{} `json:"persistent,omitempty"`
+	Transient  map[string]interface{} `json:"transient,omitempty"`
+}
+
+// ClusterMetadata is the metadata of a cluster as returned by the Elasticsearch API: its settings, index templates and
+// aliases, without any data.
+type ClusterMetadata struct {
+	Settings           json.RawMessage `json:"settings"`
+	Templates          json.RawMessage `json:"templates"`
+	IndexTemplates     json.RawMessage `json:"index_templates,omitempty"`
+	ComponentTemplates json.RawMessage `json:"component_templates,omitempty"`
+	Aliases            json.RawMessage `json:"aliases"`
+}
+
+// IndexLifecyclePolicy is an index lifecycle policy as returned by the get lifecycle policy API.
+type IndexLifecyclePolicy struct {
+	Version int                    `json:"version,omitempty"`
+	Policy  map[string]interface{} `json:"policy"`
+}
+
+// CapacitySettings are the effective settings bounding the disk usage and the number of shards of a cluster.
+// Values are empty if not supported by the version of the cluster.
+type CapacitySettings struct {
+	DiskWatermarkLow        string
+	DiskWatermarkHigh       string
+	DiskWatermarkFloodStage string
+	MaxShardsPerNode        string
+}
+
+// LoggingSettings are the logger levels and the slow log thresholds set in a cluster.
+type LoggingSettings struct {
+	// Loggers are the levels of the loggers set in the cluster settings, indexed by their logger.<name> setting.
+	// Transient levels take precedence over persistent levels.
+	Loggers map[string]string
+	// SlowLogThresholds are the slow log thresholds set on the indices, indexed by index name then by setting.
+	SlowLogThresholds map[string]map[string]string
+}
+
+// IndexBlocks are the indices blocked by a write block of the cluster.
+type IndexBlocks struct {
+	// ReadOnlyAllowDelete are the names of the indices with the index.blocks.read_only_allow_delete block, set by the
+	// flood-stage disk watermark, sorted by name.
+	ReadOnlyAllowDelete []string
+}
+
+// ReadOnlyAllowDeleteSetting is the index setting blocking writes to an index, except for deletions.
+const ReadOnlyAllowDeleteSetting = "index.blocks.read_only_allow_delete"
+
+// slowLogThresholdsSettings filters the index settings to the slow log thresholds.
+const slowLogThresholdsSettings = "index.search.slowlog.threshold.*,index.indexing.slowlog.threshold.*"
+
+const (
+	DiskWatermarkLowSetting        = "cluster.routing.allocation.disk.watermark.low"
+	DiskWatermarkHighSetting       = "cluster.routing.allocation.disk.watermark.high"
+	DiskWatermarkFloodStageSetting = "cluster.routing.allocation.disk.watermark.flood_stage"
+	MaxShardsPerNodeSetting        = "cluster.max_shards_per_node"
+)
+
+type ClusterConfigClient interface {
+	// GetClusterSettings returns the flat cluster settings explicitly set in the cluster.
+	GetClusterSettings(ctx context.Context) (ClusterSettings, error)
+	// GetCapacitySettings returns the effective disk watermarks and shards limit of the cluster, including defaults.
+	GetCapacitySettings(ctx context.Context) (CapacitySettings, error)
+	// UpdateClusterSettings updates the given cluster settings.
+	UpdateClusterSettings(ctx context.Context, settings ClusterSettings) error
+	// GetLoggingSettings returns the logger levels and the slow log thresholds of the indices set in the cluster.
+	GetLoggingSettings(ctx context.Context) (LoggingSettings, error)
+	// GetIndexBlocks returns the indices blocked as read-only by the flood-stage disk watermark.
+	GetIndexBlocks(ctx context.Context) (IndexBlocks, error)
+	// UpdateIndexSettings updates the given settings of the indices matching the given names or wildcard patterns.
+	// Missing indices are ignored.
+	UpdateIndexSettings(ctx context.Context, indices []string, settings map[string]interface{}) error
+	// GetIndexLifecyclePolicy returns the index lifecycle policy with the given name.
+	GetIndexLifecyclePolicy(ctx context.Context, name string) (IndexLifecyclePolicy, error)
+	// UpdateIndexLifecyclePolicy creates or updates the index lifecycle policy with the given name.
+	UpdateIndexLifecyclePolicy(ctx context.Context, name string, policy map[string]interface{}) error
+	// GetIndexTemplate returns the index template with the given name, with flat settings.
+	GetIndexTemplate(ctx context.Context, name string) (map[string]interface{}, error)
+	// UpdateIndexTemplate creates or updates the index template with the given name.
+	UpdateIndexTemplate(ctx context.Context, name string, template map[string]interface{}) error
+	// GetClusterMetadata returns the persistent and transient settings, the legacy index templates and the aliases of
+	// the cluster.
+	GetClusterMetadata(ctx context.Context) (ClusterMetadata, error)
+	// GetComposableTemplates returns the composable index templates and the component templates of the cluster.
+	//
+	// Introduced in: Elasticsearch 7.8.0
+	GetComposableTemplates(ctx context.Context) (indexTemplates json.RawMessage, componentTemplates json.RawMessage, err error)
+}
+
+func (c *clientV6) GetClusterSettings(ctx context.Context) (ClusterSettings, error) {
+	var settings ClusterSettings
+	err := c.get(ctx, "/_cluster/settings?flat_settings=true", &settings)
+	return settings, err
+}
+
+func (c *clientV6) GetCapacitySettings(ctx context.Context) (CapacitySettings, error) {
+	// restrict the response to the relevant settings, the defaults are large
+	path := "/_cluster/settings?include_defaults=true&filter_path=*.cluster.routing.allocation.disk.watermark,*.cluster.max_shards_per_node"
+	var levels struct {
+		Persistent map[string]interface{} `json:"persistent"`
+		Transient  map[string]interface{} `json:"transient"`
+		Defaults   map[string]interface{} `json:"defaults"`
+	}
+	if err := c.get(ctx, path, &levels); err != nil {
+		return CapacitySettings{}, err
+	}
+	// transient settings take precedence over persistent settings, which take precedence over defaults
+	effective := func(key string) string {
+		for _, level := range []map[string]interface{}{levels.Transient, levels.Persistent, levels.Defaults} {
+			if value, exists := lookupSetting(level, key); exists {
+				return value
+			}
+		}
+		return ""
+	}
+	return CapacitySettings{
+		DiskWatermarkLow:        effective(DiskWatermarkLowSetting),
+		DiskWatermarkHigh:       effective(DiskWatermarkHighSetting),
+		DiskWatermarkFloodStage: effective(DiskWatermarkFloodStageSetting),
+		MaxShardsPerNode:        effective(MaxShardsPerNodeSetting),
+	}, nil
+}
+
+// lookupSetting returns the value of the given setting in settings returned as nested objects, where some keys may
+// still contain dots if they conflict with other settings.
+func lookupSetting(settings map[string]interface{}, key string) (string, bool) {
+	parts := strings.Split(key, ".")
+	for i := 1; i <= len(parts); i++ {
+		value, exists := settings[strings.Join(parts[:i], ".")]
+		if !exists {
+			continue
+		}
+		if i == len(parts) {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				return "", false
+			default:
+				return fmt.Sprintf("%v", value), true
+			}
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if v, found := lookupSetting(nested, strings.Join(parts[i:], ".")); found {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (c *clientV6) UpdateClusterSettings(ctx context.Context, settings ClusterSettings) error {
+	return c.put(ctx, "/_cluster/settings", settings, nil)
+}
+
+func (c *clientV6) GetLoggingSettings(ctx context.Context) (LoggingSettings, error) {
+	clusterSettings, err := c.GetClusterSettings(ctx)
+	if err != nil {
+		return LoggingSettings{}, err
+	}
+	loggers := map[string]string{}
+	for _, level := range []map[string]interface{}{clusterSettings.Persistent, clusterSettings.Transient} {
+		for k, v := range level {
+			if strings.HasPrefix(k, "logger.") {
+				loggers[k] = fmt.Sprintf("%v", v)
+			}
+		}
+	}
+
+	var indices map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := c.get(ctx, "/_all/_settings/"+slowLogThresholdsSettings+"?flat_settings=true", &indices); err != nil {
+		return LoggingSettings{}, err
+	}
+	thresholds := make(map[string]map[string]string, len(indices))
+	for index, response := range indices {
+		settings := make(map[string]string, len(response.Settings))
+		for k, v := range response.Settings {
+			settings[k] = fmt.Sprintf("%v", v)
+		}
+		thresholds[index] = settings
+	}
+	return LoggingSettings{Loggers: loggers, SlowLogThresholds: thresholds}, nil
+}
+
+func (c *clientV6) GetIndexBlocks(ctx context.Context) (IndexBlocks, error) {
+	var indices map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	if err := c.get(ctx, "/_all/_settings/"+ReadOnlyAllowDeleteSetting+"?flat_settings=true", &indices); err != nil {
+		return IndexBlocks{}, err
+	}
+	var blocks IndexBlocks
+	for index, response := range indices {
+		if fmt.Sprintf("%v", response.Settings[ReadOnlyAllowDeleteSetting]) == "true" {
+			blocks.ReadOnlyAllowDelete = append(blocks.ReadOnlyAllowDelete, index)
+		}
+	}
+	sort.Strings(blocks.ReadOnlyAllowDelete)
+	return blocks, nil
+}
+
+func (c *clientV6) UpdateIndexSettings(ctx context.Context, indices []string, settings map[string]interface{}) error {
+	escaped := make([]string, len(indices))
+	for i, index := range indices {
+		escaped[i] = url.PathEscape(index)
+	}
+	return c.put(ctx, "/"+strings.Join(escaped, ",")+"/_settings?ignore_unavailable=true", settings, nil)
+}
+
+func (c *clientV6) GetIndexLifecyclePolicy(ctx context.Context, name string) (IndexLifecyclePolicy, error) {
+	var policies map[string]IndexLifecyclePolicy
+	if err := c.get(ctx, "/_ilm/policy/"+url.PathEscape(name), &policies); err != nil {
+		return IndexLifecyclePolicy{}, err
+	}
+	return policies[name], nil
+}
+
+func (c *clientV6) UpdateIndexLifecyclePolicy(ctx context.Context, name string, policy map[string]interface{}) error {
+	return c.put(ctx, "/_ilm/policy/"+url.PathEscape(name), policy, nil)
+}
+
+func (c *clientV6) GetIndexTemplate(ctx context.Context, name string) (map[string]interface{}, error) {
+	var templates map[string]map[string]interface{}
+	if err := c.get(ctx, "/_template/"+url.PathEscape(name)+"?flat_settings=true", &templates); err != nil {
+		return nil, err
+	}
+	return templates[name], nil
+}
+
+func (c *clientV6) UpdateIndexTemplate(ctx context.Context, name string, template map[string]interface{}) error {
+	return c.put(ctx, "/_template/"+url.PathEscape(name), template, nil)
+}
+
+func (c *clientV6) GetClusterMetadata(ctx context.Context) (ClusterMetadata, error) {
+	var metadata ClusterMetadata
+	if err := c.get(ctx, "/_cluster/settings?flat_settings=true", &metadata.Settings); err != nil {
+		return metadata, err
+	}
+	if err := c.get(ctx, "/_template?flat_settings=true", &metadata.Templates); err != nil {
+		return metadata, err
+	}
+	return metadata, c.get(ctx, "/_alias", &metadata.Aliases)
+}
+
+func (c *clientV6) GetComposableTemplates(_ context.Context) (json.RawMessage, json.RawMessage, error) {
+	return nil, nil, errors.New("Not supported in Elasticsearch 6.x")
+}
+
+func (c *clientV7) GetComposableTemplates(ctx context.Context) (json.RawMessage, json.RawMessage, error) {
+	var indexTemplates, componentTemplates json.RawMessage
+	if err := c.get(ctx, "/_index_template?flat_settings=true", &indexTemplates); err != nil {
+		return nil, nil, err
+	}
+	return indexTemplates, componentTemplates, c.get(ctx, "/_component_template?flat_settings=true", &componentTemplates)
+}
@@ -0,0 +1,143 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorType classifies the errors returned by the client, for the callers to decide whether and when to retry.
+type ErrorType string
+
+const (
+	// NotFoundError is a 404 response.
+	NotFoundError ErrorType = "NotFound"
+	// ConflictError is a 409 response, usually caused by a concurrent change, worth retrying.
+	ConflictError ErrorType = "Conflict"
+	// UnavailableError means the cluster cannot serve the request for now: it cannot be reached, did not respond in
+	// time, or responded with a 429, 502, 503 or 504 error. Worth retrying later.
+	UnavailableError ErrorType = "Unavailable"
+	// SecurityExceptionError means the request was not authenticated or not authorized. Retrying does not help until
+	// the users or roles are fixed.
+	SecurityExceptionError ErrorType = "SecurityException"
+	// OtherError is any other error.
+	OtherError ErrorType = "Other"
+
+	// securityExceptionType is the type of the Elasticsearch errors caused by the authentication or authorization of
+	// the request.
+	securityExceptionType = "security_exception"
+)
+
+// APIError is a non 2xx response from the Elasticsearch API
+type APIError struct {
+	response *http.Response
+
+	// the body of the response is decoded once, on first use
+	decodeOnce sync.Once
+	body       *ErrorResponse
+}
+
+// errorResponse returns the detailed error returned by Elasticsearch in the body of the response, or nil if the body
+// is not a JSON error.
+func (e *APIError) errorResponse() *ErrorResponse {
+	e.decodeOnce.Do(func() {
+		if e.response.Body == nil {
+			return
+		}
+		defer e.response.Body.Close()
+		var errMsg ErrorResponse
+		if err := json.NewDecoder(e.response.Body).Decode(&errMsg); err == nil {
+			e.body = &errMsg
+		}
+	})
+	return e.body
+}
+
+// Error() implements the error interface.
+func (e *APIError) Error() string {
+	reason := "unknown"
+	// Elasticsearch has a detailed error message in the response body
+	if errMsg := e.errorResponse(); errMsg != nil {
+		reason = errMsg.Error.Reason
+	}
+	return fmt.Sprintf("%s: %s", e.response.Status, reason)
+}
+
+// TypeOf returns the type of the given error returned by the client, or the empty string if err is nil. The error may
+// be wrapped.
+func TypeOf(err error) ErrorType {
+	if err == nil {
+		return ""
+	}
+	cause := errors.Cause(err)
+	if apiErr, ok := cause.(*APIError); ok {
+		switch apiErr.response.StatusCode {
+		case http.StatusNotFound:
+			return NotFoundError
+		case http.StatusConflict:
+			return ConflictError
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return SecurityExceptionError
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return UnavailableError
+		}
+		if errMsg := apiErr.errorResponse(); errMsg != nil && errMsg.Error.Type == securityExceptionType {
+			return SecurityExceptionError
+		}
+		return OtherError
+	}
+	// the errors of the HTTP client are wrapped in a url.Error, which is a net.Error
+	var netErr net.Error
+	if stderrors.As(cause, &netErr) || stderrors.Is(cause, context.DeadlineExceeded) {
+		return UnavailableError
+	}
+	return OtherError
+}
+
+// StatusCode returns the HTTP status code of the response if the error was a non 2xx response, or 0.
+func StatusCode(err error) int {
+	if apiErr, ok := errors.Cause(err).(*APIError); ok {
+		return apiErr.response.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound checks whether the error was an HTTP 404 error.
+func IsNotFound(err error) bool {
+	return TypeOf(err) == NotFoundError
+}
+
+// IsConflict checks whether the error was an HTTP 409 error.
+func IsConflict(err error) bool {
+	return TypeOf(err) == ConflictError
+}
+
+// IsUnavailable checks whether the error means the cluster cannot serve requests for now.
+func IsUnavailable(err error) bool {
+	return TypeOf(err) == UnavailableError
+}
+
+// IsSecurityException checks whether the error was caused by the authentication or authorization of the request.
+func IsSecurityException(err error) bool {
+	return TypeOf(err) == SecurityExceptionError
+}
+
+// IsUnauthorized checks whether the error was an HTTP 401 error.
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden checks whether the error was an HTTP 403 error.
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
@@ -0,0 +1,139 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IndexingTaskType is the type of a long running indexing task.
+type IndexingTaskType string
+
+const (
+	// TransformTask is a transform.
+	TransformTask IndexingTaskType = "transform"
+	// RollupJobTask is a rollup job.
+	RollupJobTask IndexingTaskType = "rollup"
+
+	// indexingTasksActions are the actions of the persistent tasks running the transforms and rollup jobs.
+	indexingTasksActions = "data_frame/transforms*,xpack/rollup/job*"
+	// indexerStateIndexing is the state of an indexer between the start and the end of a checkpoint.
+	indexerStateIndexing = "indexing"
+)
+
+// indexingTaskDescriptionPrefixes maps the prefix of the description of the persistent tasks to their type. The
+// remainder of the description is the ID of the transform or rollup job.
+var indexingTaskDescriptionPrefixes = map[string]IndexingTaskType{
+	"data_frame_": TransformTask,
+	"rollup_":     RollupJobTask,
+}
+
+// IndexingTask is a transform or a rollup job running on a node.
+type IndexingTask struct {
+	Type IndexingTaskType `json:"type"`
+	ID   string           `json:"id"`
+	// Node is the name of the node running the task.
+	Node string `json:"-"`
+	// Indexing is true if the task is between the start and the end of a checkpoint.
+	Indexing bool `json:"-"`
+}
+
+// IndexingTasksClient manages the transforms and rollup jobs of a cluster.
+type IndexingTasksClient interface {
+	// GetIndexingTasks returns the transforms and rollup jobs running in the cluster.
+	GetIndexingTasks(ctx context.Context) ([]IndexingTask, error)
+	// StopIndexingTask stops the given transform or rollup job, letting it complete the documents in progress.
+	//
+	// Transforms introduced in: Elasticsearch 7.5.0
+	StopIndexingTask(ctx context.Context, task IndexingTask) error
+	// StartIndexingTask starts the given transform or rollup job.
+	//
+	// Transforms introduced in: Elasticsearch 7.5.0
+	StartIndexingTask(ctx context.Context, task IndexingTask) error
+}
+
+type indexingTasksResponse struct {
+	Nodes map[string]struct {
+		Name  string `json:"name"`
+		Tasks map[string]struct {
+			Description string `json:"description"`
+			Status      struct {
+				// JobState is the state of a rollup job.
+				JobState string `json:"job_state"`
+				// IndexerState is the state of a transform.
+				IndexerState string `json:"indexer_state"`
+			} `json:"status"`
+		} `json:"tasks"`
+	} `json:"nodes"`
+}
+
+// indexingTasks returns the transforms and rollup jobs in the response, sorted by node, type and ID.
+func (r indexingTasksResponse) indexingTasks() []IndexingTask {
+	var tasks []IndexingTask
+	for _, node := range r.Nodes {
+		for _, t := range node.Tasks {
+			for prefix, taskType := range indexingTaskDescriptionPrefixes {
+				if !strings.HasPrefix(t.Description, prefix) {
+					continue
+				}
+				tasks = append(tasks, IndexingTask{
+					Type:     taskType,
+					ID:       strings.TrimPrefix(t.Description, prefix),
+					Node:     node.Name,
+					Indexing: t.Status.JobState == indexerStateIndexing || t.Status.IndexerState == indexerStateIndexing,
+				})
+			}
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Node != tasks[j].Node {
+			return tasks[i].Node < tasks[j].Node
+		}
+		if tasks[i].Type != tasks[j].Type {
+			return tasks[i].Type < tasks[j].Type
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks
+}
+
+func (c *clientV6) GetIndexingTasks(ctx context.Context) ([]IndexingTask, error) {
+	var response indexingTasksResponse
+	err := c.get(ctx, "/_tasks?detailed=true&actions="+url.QueryEscape(indexingTasksActions), &response)
+	return response.indexingTasks(), err
+}
+
+func (c *clientV6) StopIndexingTask(ctx context.Context, task IndexingTask) error {
+	if task.Type != RollupJobTask {
+		return errors.Errorf("%s tasks not supported in Elasticsearch 6.x", task.Type)
+	}
+	return c.post(ctx, "/_xpack/rollup/job/"+url.PathEscape(task.ID)+"/_stop?wait_for_completion=true", nil, nil)
+}
+
+func (c *clientV6) StartIndexingTask(ctx context.Context, task IndexingTask) error {
+	if task.Type != RollupJobTask {
+		return errors.Errorf("%s tasks not supported in Elasticsearch 6.x", task.Type)
+	}
+	return c.post(ctx, "/_xpack/rollup/job/"+url.PathEscape(task.ID)+"/_start", nil, nil)
+}
+
+func (c *clientV7) StopIndexingTask(ctx context.Context, task IndexingTask) error {
+	if task.Type == TransformTask {
+		return c.post(ctx, "/_transform/"+url.PathEscape(task.ID)+"/_stop?wait_for_completion=true", nil, nil)
+	}
+	return c.post(ctx, "/_rollup/job/"+url.PathEscape(task.ID)+"/_stop?wait_for_completion=true", nil, nil)
+}
+
+func (c *clientV7) StartIndexingTask(ctx context.Context, task IndexingTask) error {
+	if task.Type == TransformTask {
+		return c.post(ctx, "/_transform/"+url.PathEscape(task.ID)+"/_start", nil, nil)
+	}
+	return c.post(ctx, "/_rollup/job/"+url.PathEscape(task.ID)+"/_start", nil, nil)
+}
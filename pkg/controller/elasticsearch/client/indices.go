@@ -0,0 +1,183 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// creationDateSetting is the index setting holding the creation date of the index, in milliseconds since epoch.
+	creationDateSetting = "index.creation_date"
+	// writeBlockSetting is the index setting preventing writes to the index.
+	writeBlockSetting = "index.blocks.write"
+	// readOnlySetting is the index setting preventing writes and metadata changes to the index.
+	readOnlySetting = "index.blocks.read_only"
+
+	// maxIndicesPathLength is the maximum length of the comma-separated index names sent in the path of a request,
+	// to stay below the 4kb limit of the initial HTTP line of Elasticsearch.
+	maxIndicesPathLength = 3000
+)
+
+// IndexInfo describes an index for the maintenance jobs of the operator.
+type IndexInfo struct {
+	Name         string
+	CreationDate time.Time
+	// ReadOnly is true if writes to the index are blocked.
+	ReadOnly bool
+}
+
+// IndexHealth is the health of an index, computed from the state of its shards.
+type IndexHealth struct {
+	Index  string                   `json:"index"`
+	Status esv1.ElasticsearchHealth `json:"status"`
+	// UnassignedReasons are the reasons why the primary shards of a red index are not assigned, as reported by
+	// Elasticsearch, such as NODE_LEFT or ALLOCATION_FAILED.
+	UnassignedReasons []string `json:"unassignedReasons,omitempty"`
+}
+
+// IndicesClient lists and maintains the indices of a cluster.
+type IndicesClient interface {
+	// GetIndices returns the open indices matching the given names or wildcard patterns, sorted by name.
+	GetIndices(ctx context.Context, indices []string) ([]IndexInfo, error)
+	// DeleteIndices deletes the indices with the given names, in several requests if there are many of them. Missing
+	// indices are ignored.
+	DeleteIndices(ctx context.Context, indices []string) error
+	// ForceMerge merges the segments of the indices with the given names down to the given number of segments, in
+	// several requests if there are many of them.
+	// The requests return once the merge completes.
+	ForceMerge(ctx context.Context, indices []string, maxNumSegments int) error
+}
+
+func joinIndices(indices []string) string {
+	escaped := make([]string, len(indices))
+	for i, index := range indices {
+		escaped[i] = url.PathEscape(index)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// batchIndices joins the escaped names of the given indices in batches whose length does not exceed
+// maxIndicesPathLength, to be sent in several requests.
+func batchIndices(indices []string) []string {
+	var batches []string
+	var batch strings.Builder
+	for _, index := range indices {
+		escaped := url.PathEscape(index)
+		if batch.Len() > 0 && batch.Len()+1+len(escaped) > maxIndicesPathLength {
+			batches = append(batches, batch.String())
+			batch.Reset()
+		}
+		if batch.Len() > 0 {
+			batch.WriteString(",")
+		}
+		batch.WriteString(escaped)
+	}
+	if batch.Len() > 0 {
+		batches = append(batches, batch.String())
+	}
+	return batches
+}
+
+func (c *clientV6) GetIndices(ctx context.Context, indices []string) ([]IndexInfo, error) {
+	var response map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+	path := fmt.Sprintf("/%s/_settings/%s,%s,%s?flat_settings=true&expand_wildcards=open&allow_no_indices=true&ignore_unavailable=true",
+		joinIndices(indices), creationDateSetting, writeBlockSetting, readOnlySetting)
+	if err := c.get(ctx, path, &response); err != nil {
+		return nil, err
+	}
+	result := make([]IndexInfo, 0, len(response))
+	for name, index := range response {
+		millis, err := strconv.ParseInt(fmt.Sprintf("%v", index.Settings[creationDateSetting]), 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid creation date of index %s", name)
+		}
+		result = append(result, IndexInfo{
+			Name:         name,
+			CreationDate: time.Unix(0, millis*int64(time.Millisecond)).UTC(),
+			ReadOnly: fmt.Sprintf("%v", index.Settings[writeBlockSetting]) == "true" ||
+				fmt.Sprintf("%v", index.Settings[readOnlySetting]) == "true",
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (c *clientV6) DeleteIndices(ctx context.Context, indices []string) error {
+	for _, batch := range batchIndices(indices) {
+		if err := c.delete(ctx, "/"+batch+"?ignore_unavailable=true", nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *clientV6) ForceMerge(ctx context.Context, indices []string, maxNumSegments int) error {
+	for _, batch := range batchIndices(indices) {
+		if err := c.post(ctx, fmt.Sprintf("/%s/_forcemerge?max_num_segments=%d", batch, maxNumSegments), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *clientV6) GetIndicesHealth(ctx context.Context, indices []string) ([]IndexHealth, error) {
+	type shard struct {
+		Index            string     `json:"index"`
+		PrimaryOrReplica ShardType  `json:"prirep"`
+		State            ShardState `json:"state"`
+		UnassignedReason string     `json:"unassigned.reason"`
+	}
+	byIndex := make(map[string]*IndexHealth)
+	// one request per pattern: a missing index fails the whole request
+	for _, pattern := range indices {
+		var shards []shard
+		path := "/_cat/shards/" + url.PathEscape(pattern) + "?format=json&h=index,prirep,state,unassigned.reason"
+		if err := c.get(ctx, path, &shards); err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, s := range shards {
+			health, exists := byIndex[s.Index]
+			if !exists {
+				health = &IndexHealth{Index: s.Index, Status: esv1.ElasticsearchGreenHealth}
+				byIndex[s.Index] = health
+			}
+			// indices matching several patterns are computed again from the same shards
+			switch {
+			case s.State == STARTED || s.State == RELOCATING:
+			case s.PrimaryOrReplica == Primary:
+				health.Status = esv1.ElasticsearchRedHealth
+				if s.UnassignedReason != "" && !stringsutil.StringInSlice(s.UnassignedReason, health.UnassignedReasons) {
+					health.UnassignedReasons = append(health.UnassignedReasons, s.UnassignedReason)
+				}
+			case health.Status != esv1.ElasticsearchRedHealth:
+				health.Status = esv1.ElasticsearchYellowHealth
+			}
+		}
+	}
+	result := make([]IndexHealth, 0, len(byIndex))
+	for _, health := range byIndex {
+		sort.Strings(health.UnassignedReasons)
+		result = append(result, *health)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
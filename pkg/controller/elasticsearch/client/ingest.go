@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// SimulatedDocument is the result of the simulation of an ingest pipeline on a sample document.
+type SimulatedDocument struct {
+	// Doc is the document transformed by the pipeline, nil if the pipeline failed.
+	Doc   map[string]interface{} `json:"doc,omitempty"`
+	Error *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+// IngestPipelineClient manages the ingest pipelines of a cluster.
+type IngestPipelineClient interface {
+	// GetIngestPipeline returns the definition of the ingest pipeline with the given ID.
+	GetIngestPipeline(ctx context.Context, id string) (map[string]interface{}, error)
+	// UpdateIngestPipeline creates or updates the ingest pipeline with the given ID.
+	UpdateIngestPipeline(ctx context.Context, id string, pipeline map[string]interface{}) error
+	// SimulateIngestPipeline runs the given pipeline definition on the given document sources, without indexing them.
+	SimulateIngestPipeline(ctx context.Context, pipeline map[string]interface{}, sources []map[string]interface{}) ([]SimulatedDocument, error)
+}
+
+func (c *clientV6) GetIngestPipeline(ctx context.Context, id string) (map[string]interface{}, error) {
+	var pipelines map[string]map[string]interface{}
+	if err := c.get(ctx, "/_ingest/pipeline/"+url.PathEscape(id), &pipelines); err != nil {
+		return nil, err
+	}
+	return pipelines[id], nil
+}
+
+func (c *clientV6) UpdateIngestPipeline(ctx context.Context, id string, pipeline map[string]interface{}) error {
+	return c.put(ctx, "/_ingest/pipeline/"+url.PathEscape(id), pipeline, nil)
+}
+
+func (c *clientV6) SimulateIngestPipeline(
+	ctx context.Context,
+	pipeline map[string]interface{},
+	sources []map[string]interface{},
+) ([]SimulatedDocument, error) {
+	docs := make([]map[string]interface{}, len(sources))
+	for i, source := range sources {
+		docs[i] = map[string]interface{}{"_source": source}
+	}
+	request := map[string]interface{}{"pipeline": pipeline, "docs": docs}
+	var response struct {
+		Docs []SimulatedDocument `json:"docs"`
+	}
+	err := c.query(ctx, "/_ingest/pipeline/_simulate", request, &response)
+	return response.Docs, err
+}
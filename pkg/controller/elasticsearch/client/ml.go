@@ -0,0 +1,24 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"strconv"
+)
+
+// MLClient manages the machine learning features of a cluster.
+type MLClient interface {
+	// SetMLUpgradeMode enables or disables the machine learning upgrade mode. In upgrade mode, the datafeeds and
+	// anomaly detection jobs are paused and their persistent tasks are not reassigned when the nodes running them leave
+	// the cluster.
+	//
+	// Introduced in: Elasticsearch 6.7.0
+	SetMLUpgradeMode(ctx context.Context, enabled bool) error
+}
+
+func (c *clientV6) SetMLUpgradeMode(ctx context.Context, enabled bool) error {
+	return c.post(ctx, "/_ml/set_upgrade_mode?enabled="+strconv.FormatBool(enabled), nil, nil)
+}
@@ -7,6 +7,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -102,14 +103,66 @@ type NodesStats struct {
 
 // NodeStats partially models an Elasticsearch node retrieved from /_nodes/stats
 type NodeStats struct {
-	Name string `json:"name"`
-	OS   struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+	OS    struct {
 		CGroup struct {
 			Memory struct {
 				LimitInBytes string `json:"limit_in_bytes"`
 			} `json:"memory"`
 		} `json:"cgroup"`
 	} `json:"os"`
+	JVM struct {
+		Mem struct {
+			HeapUsedPercent int `json:"heap_used_percent"`
+		} `json:"mem"`
+	} `json:"jvm"`
+	FS struct {
+		Total struct {
+			TotalInBytes     int64 `json:"total_in_bytes"`
+			AvailableInBytes int64 `json:"available_in_bytes"`
+		} `json:"total"`
+	} `json:"fs"`
+	Indices struct {
+		Indexing struct {
+			// IndexTotal is the number of documents indexed by the node since it started.
+			IndexTotal int64 `json:"index_total"`
+		} `json:"indexing"`
+		Search struct {
+			// QueryTotal is the number of search queries run by the node since it started, one per shard searched.
+			QueryTotal int64 `json:"query_total"`
+		} `json:"search"`
+	} `json:"indices"`
+}
+
+// PendingTasks partially models the response from a request to /_cluster/pending_tasks
+type PendingTasks struct {
+	Tasks []PendingTask `json:"tasks"`
+}
+
+// PendingTask is a cluster-level change waiting to be executed by the elected master node.
+type PendingTask struct {
+	InsertOrder       int64  `json:"insert_order"`
+	Priority          string `json:"priority"`
+	Source            string `json:"source"`
+	TimeInQueueMillis int64  `json:"time_in_queue_millis"`
+}
+
+// CircuitBreakersStats partially models the response from a request to /_nodes/stats/breaker
+type CircuitBreakersStats struct {
+	Nodes map[string]NodeCircuitBreakers `json:"nodes"`
+}
+
+// NodeCircuitBreakers holds the stats of the circuit breakers of a node, by breaker name.
+type NodeCircuitBreakers struct {
+	Name     string                         `json:"name"`
+	Breakers map[string]CircuitBreakerStats `json:"breakers"`
+}
+
+// CircuitBreakerStats partially models the stats of a circuit breaker.
+type CircuitBreakerStats struct {
+	// Tripped is the number of times the breaker was tripped since the node started.
+	Tripped int64 `json:"tripped"`
 }
 
 // ClusterStateNode represents an element in the `node` structure in
@@ -136,6 +189,17 @@ type Shard struct {
 	State    ShardState `json:"state"`
 	NodeName string     `json:"node"`
 	Type     ShardType  `json:"prirep"`
+	// Store is the size of the shard in bytes, empty if the shard is not allocated.
+	Store string `json:"store,omitempty"`
+}
+
+// StoreBytes returns the size of the shard in bytes, or 0 if not known.
+func (s Shard) StoreBytes() int64 {
+	size, err := strconv.ParseInt(s.Store, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
 }
 
 type RoutingTable struct {
@@ -382,6 +446,21 @@ type RemoteClusters struct {
 // RemoteClusterSeeds is the set of seeds to use in a remote cluster setting.
 type RemoteCluster struct {
 	Seeds []string `json:"seeds"`
+	// ConnectionMode is only serialized if set, for versions of Elasticsearch not supporting the proxy mode.
+	*ConnectionMode
+}
+
+// ConnectionMode is the connection mode of a remote cluster. Nil values reset the settings to their default, which is
+// the sniff mode.
+type ConnectionMode struct {
+	Mode         *string `json:"mode"`
+	ProxyAddress *string `json:"proxy_address"`
+}
+
+// ProxyMode returns the connection mode of a remote cluster connecting to a single proxy address.
+func ProxyMode(address string) *ConnectionMode {
+	mode := "proxy"
+	return &ConnectionMode{Mode: &mode, ProxyAddress: &address}
 }
 
 // Hit represents a single search hit.
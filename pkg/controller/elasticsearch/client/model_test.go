@@ -48,6 +48,37 @@ func TestModel_RemoteCluster(t *testing.T) {
 			},
 			want: `{"persistent":{"cluster":{"remote":{"leader":{"seeds":null}}}}}`,
 		},
+		{
+			name: "Remote cluster in proxy mode",
+			arg: RemoteClustersSettings{
+				PersistentSettings: &SettingsGroup{
+					Cluster: RemoteClusters{
+						RemoteClusters: map[string]RemoteCluster{
+							"leader": {
+								ConnectionMode: ProxyMode("leader-es-transport.ns.svc:9443"),
+							},
+						},
+					},
+				},
+			},
+			want: `{"persistent":{"cluster":{"remote":{"leader":{"seeds":null,"mode":"proxy","proxy_address":"leader-es-transport.ns.svc:9443"}}}}}`,
+		},
+		{
+			name: "Remote cluster back to the sniff mode",
+			arg: RemoteClustersSettings{
+				PersistentSettings: &SettingsGroup{
+					Cluster: RemoteClusters{
+						RemoteClusters: map[string]RemoteCluster{
+							"leader": {
+								Seeds:          []string{"127.0.0.1:9300"},
+								ConnectionMode: &ConnectionMode{},
+							},
+						},
+					},
+				},
+			},
+			want: `{"persistent":{"cluster":{"remote":{"leader":{"seeds":["127.0.0.1:9300"],"mode":null,"proxy_address":null}}}}}`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
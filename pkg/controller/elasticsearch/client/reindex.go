@@ -0,0 +1,115 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ReindexRequest is the body of a request to the reindex API.
+type ReindexRequest struct {
+	Source    ReindexSource      `json:"source"`
+	Dest      ReindexDestination `json:"dest"`
+	Conflicts string             `json:"conflicts,omitempty"`
+}
+
+// ReindexSource selects the documents copied by a reindex request.
+type ReindexSource struct {
+	Index []string               `json:"index"`
+	Query map[string]interface{} `json:"query,omitempty"`
+}
+
+// ReindexDestination is the index the documents are copied to by a reindex request.
+type ReindexDestination struct {
+	Index    string `json:"index"`
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// ReindexOptions are the query parameters of a reindex request.
+type ReindexOptions struct {
+	// Slices is the number of slices the reindex operation is split into, 0 to pick one slice per shard.
+	Slices int
+	// RequestsPerSecond throttles the reindex operation, 0 to disable throttling.
+	RequestsPerSecond int
+}
+
+// ReindexTaskStatus is the progress of a reindex task.
+type ReindexTaskStatus struct {
+	Total            int64 `json:"total"`
+	Created          int64 `json:"created"`
+	Updated          int64 `json:"updated"`
+	Deleted          int64 `json:"deleted"`
+	VersionConflicts int64 `json:"version_conflicts"`
+}
+
+// ReindexTask describes a reindex task as returned by the task management API.
+type ReindexTask struct {
+	Completed bool `json:"completed"`
+	Task      struct {
+		Status ReindexTaskStatus `json:"status"`
+	} `json:"task"`
+	Response struct {
+		Failures []struct {
+			Index string `json:"index"`
+			Cause struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"cause"`
+		} `json:"failures"`
+	} `json:"response"`
+	Error *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+// FailureReason returns why the completed reindex task failed, or an empty string if it succeeded.
+func (t ReindexTask) FailureReason() string {
+	if t.Error != nil {
+		return fmt.Sprintf("%s: %s", t.Error.Type, t.Error.Reason)
+	}
+	if len(t.Response.Failures) > 0 {
+		first := t.Response.Failures[0]
+		return fmt.Sprintf("%d documents failed, first failure in index %s: %s: %s",
+			len(t.Response.Failures), first.Index, first.Cause.Type, first.Cause.Reason)
+	}
+	return ""
+}
+
+// ReindexClient copies documents between indices.
+type ReindexClient interface {
+	// StartReindex starts a reindex task in the background and returns its identifier.
+	StartReindex(ctx context.Context, request ReindexRequest, options ReindexOptions) (string, error)
+	// GetReindexTask returns the reindex task with the given identifier.
+	GetReindexTask(ctx context.Context, taskID string) (ReindexTask, error)
+}
+
+func (c *clientV6) StartReindex(ctx context.Context, request ReindexRequest, options ReindexOptions) (string, error) {
+	slices := "auto"
+	if options.Slices > 0 {
+		slices = strconv.Itoa(options.Slices)
+	}
+	requestsPerSecond := "-1"
+	if options.RequestsPerSecond > 0 {
+		requestsPerSecond = strconv.Itoa(options.RequestsPerSecond)
+	}
+	var response struct {
+		Task string `json:"task"`
+	}
+	path := fmt.Sprintf("/_reindex?wait_for_completion=false&slices=%s&requests_per_second=%s", slices, requestsPerSecond)
+	if err := c.post(ctx, path, request, &response); err != nil {
+		return "", err
+	}
+	return response.Task, nil
+}
+
+func (c *clientV6) GetReindexTask(ctx context.Context, taskID string) (ReindexTask, error) {
+	var task ReindexTask
+	err := c.get(ctx, "/_tasks/"+url.PathEscape(taskID), &task)
+	return task, err
+}
@@ -0,0 +1,31 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// SearchClient searches the indices of a cluster.
+type SearchClient interface {
+	// Search runs the search described by the given body on the given indices or wildcard patterns. Unavailable
+	// indices are ignored, and the results are never served from the shard request cache.
+	Search(ctx context.Context, indices []string, body map[string]interface{}) (SearchResults, error)
+}
+
+func (c *clientV6) Search(ctx context.Context, indices []string, body map[string]interface{}) (SearchResults, error) {
+	escaped := make([]string, len(indices))
+	for i, index := range indices {
+		escaped[i] = url.PathEscape(index)
+	}
+	if body == nil {
+		body = map[string]interface{}{}
+	}
+	var results SearchResults
+	path := "/" + strings.Join(escaped, ",") + "/_search?ignore_unavailable=true&request_cache=false"
+	return results, c.query(ctx, path, body, &results)
+}
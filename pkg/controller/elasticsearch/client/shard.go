@@ -43,7 +43,7 @@ func (c *clientV6) GetShards(ctx context.Context) (Shards, error) {
 	ctx, cancel := context.WithTimeout(ctx, DefaultReqTimeout)
 	defer cancel()
 	var shards Shards
-	if err := c.get(ctx, "/_cat/shards?format=json", &shards); err != nil {
+	if err := c.get(ctx, "/_cat/shards?format=json&bytes=b", &shards); err != nil {
 		return shards, err
 	}
 	return shards, nil
@@ -0,0 +1,60 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// NodeShutdownRemove is the type of the shutdown of a node leaving the cluster for good: Elasticsearch migrates its
+// shards away and does not allocate shards to it anymore.
+const NodeShutdownRemove = "remove"
+
+// NodeShutdown is the registration of the shutdown of a node.
+type NodeShutdown struct {
+	NodeID string `json:"node_id"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// NodeShutdownClient registers the nodes about to shut down, for Elasticsearch to prepare their shutdown.
+type NodeShutdownClient interface {
+	// GetNodesShutdown returns the shutdown registrations of the nodes of the cluster.
+	//
+	// Introduced in: Elasticsearch 7.15.0
+	GetNodesShutdown(ctx context.Context) ([]NodeShutdown, error)
+	// PutNodeShutdown registers the shutdown of the node with the given ID, of the given type and for the given reason.
+	//
+	// Introduced in: Elasticsearch 7.15.0
+	PutNodeShutdown(ctx context.Context, nodeID string, shutdownType string, reason string) error
+	// DeleteNodeShutdown removes the shutdown registration of the node with the given ID.
+	//
+	// Introduced in: Elasticsearch 7.15.0
+	DeleteNodeShutdown(ctx context.Context, nodeID string) error
+}
+
+type nodesShutdownResponse struct {
+	Nodes []NodeShutdown `json:"nodes"`
+}
+
+type nodeShutdownRequest struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (c *clientV6) GetNodesShutdown(ctx context.Context) ([]NodeShutdown, error) {
+	var response nodesShutdownResponse
+	return response.Nodes, c.get(ctx, "/_nodes/shutdown", &response)
+}
+
+func (c *clientV6) PutNodeShutdown(ctx context.Context, nodeID string, shutdownType string, reason string) error {
+	request := nodeShutdownRequest{Type: shutdownType, Reason: reason}
+	return c.put(ctx, "/_nodes/"+url.PathEscape(nodeID)+"/shutdown", request, nil)
+}
+
+func (c *clientV6) DeleteNodeShutdown(ctx context.Context, nodeID string) error {
+	return c.delete(ctx, "/_nodes/"+url.PathEscape(nodeID)+"/shutdown", nil, nil)
+}
@@ -0,0 +1,166 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// SnapshotRepository is a snapshot repository as described in the body of the snapshot repository API.
+type SnapshotRepository struct {
+	Type     string                 `json:"type"`
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+type SnapshotRepositoryClient interface {
+	// UpdateSnapshotRepository creates or updates the snapshot repository with the given name.
+	UpdateSnapshotRepository(ctx context.Context, name string, repository SnapshotRepository) error
+	// GetSnapshotRepository returns the snapshot repository with the given name.
+	GetSnapshotRepository(ctx context.Context, name string) (SnapshotRepository, error)
+}
+
+// Snapshot states as reported by the snapshot API.
+const (
+	SnapshotStateInProgress = "IN_PROGRESS"
+	SnapshotStateSuccess    = "SUCCESS"
+	SnapshotStateFailed     = "FAILED"
+	SnapshotStatePartial    = "PARTIAL"
+)
+
+// SnapshotRequest is the body of a request creating a snapshot.
+type SnapshotRequest struct {
+	Indices            string `json:"indices,omitempty"`
+	IncludeGlobalState bool   `json:"include_global_state"`
+}
+
+// Snapshot describes a snapshot as returned by the snapshot API.
+type Snapshot struct {
+	Snapshot string   `json:"snapshot"`
+	State    string   `json:"state"`
+	Indices  []string `json:"indices"`
+	// StartTimeInMillis and EndTimeInMillis are the times the snapshot started and completed at, as Unix times in
+	// milliseconds. EndTimeInMillis is 0 while the snapshot is in progress.
+	StartTimeInMillis int64 `json:"start_time_in_millis"`
+	EndTimeInMillis   int64 `json:"end_time_in_millis"`
+	Shards            struct {
+		Total      int `json:"total"`
+		Failed     int `json:"failed"`
+		Successful int `json:"successful"`
+	} `json:"shards"`
+}
+
+// RestoreRequest is the body of a request restoring a snapshot.
+type RestoreRequest struct {
+	Indices            string `json:"indices,omitempty"`
+	IncludeGlobalState bool   `json:"include_global_state"`
+}
+
+// ShardRecovery describes the recovery of a shard as returned by the recovery API.
+type ShardRecovery struct {
+	ID      int    `json:"id"`
+	Type    string `json:"type"`
+	Stage   string `json:"stage"`
+	Primary bool   `json:"primary"`
+	Source  struct {
+		Repository string `json:"repository"`
+		Snapshot   string `json:"snapshot"`
+		Index      string `json:"index"`
+	} `json:"source"`
+}
+
+// Recoveries are the shard recoveries of each index.
+type Recoveries map[string]struct {
+	Shards []ShardRecovery `json:"shards"`
+}
+
+// Values of ShardRecovery fields.
+const (
+	RecoveryTypeSnapshot = "SNAPSHOT"
+	RecoveryStageDone    = "DONE"
+)
+
+// SnapshotClient takes and restores snapshots.
+type SnapshotClient interface {
+	// CreateSnapshot starts a snapshot with the given name, without waiting for its completion.
+	CreateSnapshot(ctx context.Context, repository string, name string, request SnapshotRequest) error
+	// GetSnapshot returns the snapshot with the given name.
+	GetSnapshot(ctx context.Context, repository string, name string) (Snapshot, error)
+	// GetSnapshots returns all the snapshots of the given repository, including the ones in progress.
+	GetSnapshots(ctx context.Context, repository string) ([]Snapshot, error)
+	// ExecuteSnapshotLifecyclePolicy starts a snapshot with the given snapshot lifecycle management policy, without
+	// waiting for its completion, and returns the name of the snapshot.
+	ExecuteSnapshotLifecyclePolicy(ctx context.Context, policy string) (string, error)
+	// RestoreSnapshot starts the restore of the given snapshot, without waiting for its completion.
+	RestoreSnapshot(ctx context.Context, repository string, name string, request RestoreRequest) error
+	// GetRecoveries returns the ongoing and completed recoveries of all the shards.
+	GetRecoveries(ctx context.Context) (Recoveries, error)
+}
+
+func (c *clientV6) UpdateSnapshotRepository(ctx context.Context, name string, repository SnapshotRepository) error {
+	return c.put(ctx, "/_snapshot/"+url.PathEscape(name), repository, nil)
+}
+
+func (c *clientV6) GetSnapshotRepository(ctx context.Context, name string) (SnapshotRepository, error) {
+	var repositories map[string]SnapshotRepository
+	if err := c.get(ctx, "/_snapshot/"+url.PathEscape(name), &repositories); err != nil {
+		return SnapshotRepository{}, err
+	}
+	repository, exists := repositories[name]
+	if !exists {
+		return SnapshotRepository{}, fmt.Errorf("snapshot repository %s not found", name)
+	}
+	return repository, nil
+}
+
+func (c *clientV6) CreateSnapshot(ctx context.Context, repository string, name string, request SnapshotRequest) error {
+	return c.put(ctx, "/_snapshot/"+url.PathEscape(repository)+"/"+url.PathEscape(name), request, nil)
+}
+
+func (c *clientV6) GetSnapshot(ctx context.Context, repository string, name string) (Snapshot, error) {
+	var snapshots struct {
+		Snapshots []Snapshot `json:"snapshots"`
+	}
+	if err := c.get(ctx, "/_snapshot/"+url.PathEscape(repository)+"/"+url.PathEscape(name), &snapshots); err != nil {
+		return Snapshot{}, err
+	}
+	if len(snapshots.Snapshots) != 1 {
+		return Snapshot{}, fmt.Errorf("snapshot %s not found in repository %s", name, repository)
+	}
+	return snapshots.Snapshots[0], nil
+}
+
+func (c *clientV6) GetSnapshots(ctx context.Context, repository string) ([]Snapshot, error) {
+	var snapshots struct {
+		Snapshots []Snapshot `json:"snapshots"`
+	}
+	err := c.get(ctx, "/_snapshot/"+url.PathEscape(repository)+"/_all", &snapshots)
+	return snapshots.Snapshots, err
+}
+
+func (c *clientV6) ExecuteSnapshotLifecyclePolicy(_ context.Context, _ string) (string, error) {
+	return "", errors.New("Not supported in Elasticsearch 6.x")
+}
+
+func (c *clientV7) ExecuteSnapshotLifecyclePolicy(ctx context.Context, policy string) (string, error) {
+	var response struct {
+		SnapshotName string `json:"snapshot_name"`
+	}
+	err := c.post(ctx, "/_slm/policy/"+url.PathEscape(policy)+"/_execute", nil, &response)
+	return response.SnapshotName, err
+}
+
+func (c *clientV6) RestoreSnapshot(ctx context.Context, repository string, name string, request RestoreRequest) error {
+	return c.post(ctx, "/_snapshot/"+url.PathEscape(repository)+"/"+url.PathEscape(name)+"/_restore", request, nil)
+}
+
+func (c *clientV6) GetRecoveries(ctx context.Context) (Recoveries, error) {
+	var recoveries Recoveries
+	err := c.get(ctx, "/_recovery", &recoveries)
+	return recoveries, err
+}
@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fixtures
+
+const (
+	CapacitySettingsSample = `
+{
+  "persistent" : {
+    "cluster" : {
+      "routing" : {
+        "allocation" : {
+          "disk" : {
+            "watermark" : {
+              "low" : "80%"
+            }
+          }
+        }
+      }
+    }
+  },
+  "transient" : {
+    "cluster" : {
+      "routing" : {
+        "allocation" : {
+          "disk" : {
+            "watermark" : {
+              "low" : "75%"
+            }
+          }
+        }
+      }
+    }
+  },
+  "defaults" : {
+    "cluster" : {
+      "max_shards_per_node" : "1000",
+      "routing" : {
+        "allocation" : {
+          "disk" : {
+            "watermark" : {
+              "low" : "85%",
+              "flood_stage" : "95%",
+              "flood_stage.frozen" : "97%",
+              "high" : "90%"
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+)
@@ -30,6 +30,35 @@ const (
         "xpack.installed" : "true",
         "ml.max_open_jobs" : "20"
       },
+      "indices" : {
+        "indexing" : {
+          "index_total" : 38267,
+          "index_time_in_millis" : 10162,
+          "index_current" : 0,
+          "index_failed" : 0,
+          "delete_total" : 12,
+          "delete_time_in_millis" : 8,
+          "delete_current" : 0,
+          "noop_update_total" : 0,
+          "is_throttled" : false,
+          "throttle_time_in_millis" : 0
+        },
+        "search" : {
+          "open_contexts" : 0,
+          "query_total" : 5112,
+          "query_time_in_millis" : 2370,
+          "query_current" : 0,
+          "fetch_total" : 4871,
+          "fetch_time_in_millis" : 389,
+          "fetch_current" : 0,
+          "scroll_total" : 0,
+          "scroll_time_in_millis" : 0,
+          "scroll_current" : 0,
+          "suggest_total" : 0,
+          "suggest_time_in_millis" : 0,
+          "suggest_current" : 0
+        }
+      },
       "os" : {
         "timestamp" : 1560016895152,
         "cpu" : {
@@ -73,6 +102,24 @@ const (
             "usage_in_bytes" : "2926161920"
           }
         }
+      },
+      "jvm" : {
+        "timestamp" : 1560016895153,
+        "uptime_in_millis" : 1172275,
+        "mem" : {
+          "heap_used_in_bytes" : 741397792,
+          "heap_used_percent" : 46,
+          "heap_committed_in_bytes" : 1590427648,
+          "heap_max_in_bytes" : 1590427648
+        }
+      },
+      "fs" : {
+        "timestamp" : 1560016895153,
+        "total" : {
+          "total_in_bytes" : 1056759873536,
+          "free_in_bytes" : 948837429248,
+          "available_in_bytes" : 895094837248
+        }
       }
     }
   }
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/audit"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
 	"github.com/pkg/errors"
 )
@@ -72,6 +73,10 @@ func (c *clientV6) ReloadSecureSettings(ctx context.Context) error {
 	return c.post(ctx, "/_nodes/reload_secure_settings", nil, nil)
 }
 
+func (c *clientV6) ReloadSearchAnalyzers(_ context.Context) error {
+	return errors.New("Not supported in Elasticsearch 6.x")
+}
+
 func (c *clientV6) GetNodes(ctx context.Context) (Nodes, error) {
 	var nodes Nodes
 	// restrict call to basic node info only
@@ -80,8 +85,18 @@ func (c *clientV6) GetNodes(ctx context.Context) (Nodes, error) {
 
 func (c *clientV6) GetNodesStats(ctx context.Context) (NodesStats, error) {
 	var nodesStats NodesStats
-	// restrict call to basic node info only
-	return nodesStats, c.get(ctx, "/_nodes/_all/stats/os", &nodesStats)
+	// restrict call to the os, jvm and fs metrics, and to the indexing and search indices metrics only
+	return nodesStats, c.get(ctx, "/_nodes/_all/stats/os,jvm,fs,indices/indexing,search", &nodesStats)
+}
+
+func (c *clientV6) GetPendingTasks(ctx context.Context) (PendingTasks, error) {
+	var pendingTasks PendingTasks
+	return pendingTasks, c.get(ctx, "/_cluster/pending_tasks", &pendingTasks)
+}
+
+func (c *clientV6) GetCircuitBreakersStats(ctx context.Context) (CircuitBreakersStats, error) {
+	var stats CircuitBreakersStats
+	return stats, c.get(ctx, "/_nodes/_all/stats/breaker", &stats)
 }
 
 func (c *clientV6) UpdateRemoteClusterSettings(ctx context.Context, settings RemoteClustersSettings) error {
@@ -136,12 +151,20 @@ func (c *clientV6) ClusterBootstrappedForZen2(ctx context.Context) (bool, error)
 }
 
 func (c *clientV6) Request(ctx context.Context, r *http.Request) (*http.Response, error) {
+	if c.dryRun != nil && r.Method != http.MethodGet {
+		c.dryRun(r.Method, r.URL.String(), nil)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	}
 	newURL, err := url.Parse(stringsutil.Concat(c.Endpoint, r.URL.String()))
 	if err != nil {
 		return nil, err
 	}
 	r.URL = newURL
-	return c.doRequest(ctx, r)
+	resp, err := c.doRequest(ctx, r)
+	if r.Method != http.MethodGet {
+		audit.Default.RecordRequest(r.Method, newURL.String(), nil, err)
+	}
+	return resp, err
 }
 
 // Equal returns true if c2 can be considered the same as c
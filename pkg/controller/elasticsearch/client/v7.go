@@ -37,6 +37,10 @@ func (c *clientV7) StartTrial(ctx context.Context) (StartTrialResponse, error) {
 	return response, c.post(ctx, "/_license/start_trial?acknowledge=true", nil, &response)
 }
 
+func (c *clientV7) ReloadSearchAnalyzers(ctx context.Context) error {
+	return c.post(ctx, "/_all/_reload_search_analyzers", nil, nil)
+}
+
 func (c *clientV7) AddVotingConfigExclusions(ctx context.Context, nodeNames []string, timeout string) error {
 	if timeout == "" {
 		timeout = DefaultVotingConfigExclusionsTimeout
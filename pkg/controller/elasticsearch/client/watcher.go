@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// WatcherClient manages the watches of a cluster.
+type WatcherClient interface {
+	// GetWatch returns the definition of the watch with the given ID.
+	GetWatch(ctx context.Context, id string) (map[string]interface{}, error)
+	// UpdateWatch creates or updates the watch with the given ID.
+	UpdateWatch(ctx context.Context, id string, watch map[string]interface{}) error
+}
+
+type watchResponse struct {
+	Watch map[string]interface{} `json:"watch"`
+}
+
+func (c *clientV6) GetWatch(ctx context.Context, id string) (map[string]interface{}, error) {
+	var response watchResponse
+	err := c.get(ctx, "/_xpack/watcher/watch/"+url.PathEscape(id), &response)
+	return response.Watch, err
+}
+
+func (c *clientV6) UpdateWatch(ctx context.Context, id string, watch map[string]interface{}) error {
+	return c.put(ctx, "/_xpack/watcher/watch/"+url.PathEscape(id), watch, nil)
+}
+
+func (c *clientV7) GetWatch(ctx context.Context, id string) (map[string]interface{}, error) {
+	var response watchResponse
+	err := c.get(ctx, "/_watcher/watch/"+url.PathEscape(id), &response)
+	return response.Watch, err
+}
+
+func (c *clientV7) UpdateWatch(ctx context.Context, id string, watch map[string]interface{}) error {
+	return c.put(ctx, "/_watcher/watch/"+url.PathEscape(id), watch, nil)
+}
@@ -33,23 +33,31 @@ func NewConfigMapWithData(es types.NamespacedName, data map[string]string) corev
 }
 
 // ReconcileScriptsConfigMap reconciles a configmap containing scripts used by
-// init containers and readiness probe.
-func ReconcileScriptsConfigMap(ctx context.Context, c k8s.Client, es esv1.Elasticsearch) error {
+// init containers and readiness probe. The prepare-fs script chowns the data and logs volumes if chownVolumes is true.
+// The names of the suspended Pods are part of the ConfigMap, updating them does not restart the Pods.
+func ReconcileScriptsConfigMap(ctx context.Context, c k8s.Client, es esv1.Elasticsearch, chownVolumes bool) error {
 	span, _ := apm.StartSpan(ctx, "reconcile_scripts", tracing.SpanTypeApp)
 	defer span.End()
 
-	fsScript, err := initcontainer.RenderPrepareFsScript()
+	fsScript, err := initcontainer.RenderPrepareFsScript(chownVolumes)
 	if err != nil {
 		return err
 	}
 
+	scripts := map[string]string{
+		nodespec.ReadinessProbeScriptConfigKey: nodespec.ReadinessProbeScript,
+		nodespec.PreStopHookScriptConfigKey:    nodespec.PreStopHookScript,
+		initcontainer.PrepareFsScriptConfigKey: fsScript,
+		initcontainer.SuspendScriptConfigKey:   initcontainer.SuspendScript,
+		initcontainer.SuspendedPodsConfigKey:   initcontainer.RenderSuspendedPods(es.Spec.NodeDebug),
+	}
+	for key, script := range nodespec.LifecycleHooksUserScripts(es.Spec.LifecycleHooks) {
+		scripts[key] = script
+	}
+
 	scriptsConfigMap := NewConfigMapWithData(
 		types.NamespacedName{Namespace: es.Namespace, Name: esv1.ScriptsConfigMap(es.Name)},
-		map[string]string{
-			nodespec.ReadinessProbeScriptConfigKey: nodespec.ReadinessProbeScript,
-			nodespec.PreStopHookScriptConfigKey:    nodespec.PreStopHookScript,
-			initcontainer.PrepareFsScriptConfigKey: fsScript,
-		},
+		scripts,
 	)
 
 	return ReconcileConfigMap(c, es, scriptsConfigMap)
@@ -0,0 +1,52 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
+)
+
+// referencedClusterHandler enqueues the Elasticsearch cluster referenced by the ElasticsearchUser or
+// ElasticsearchRole of the event. When the reference changes, both the previously and the newly referenced clusters
+// are enqueued, for the previous one to remove the user or role.
+func referencedClusterHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			enqueueReferencedCluster(q, e.Meta.GetNamespace(), e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			enqueueReferencedCluster(q, e.MetaOld.GetNamespace(), e.ObjectOld)
+			enqueueReferencedCluster(q, e.MetaNew.GetNamespace(), e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			enqueueReferencedCluster(q, e.Meta.GetNamespace(), e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+			enqueueReferencedCluster(q, e.Meta.GetNamespace(), e.Object)
+		},
+	}
+}
+
+func enqueueReferencedCluster(q workqueue.RateLimitingInterface, namespace string, object runtime.Object) {
+	var esName string
+	switch typed := object.(type) {
+	case *authv1alpha1.ElasticsearchUser:
+		esName = typed.Spec.ElasticsearchRef.Name
+	case *authv1alpha1.ElasticsearchRole:
+		esName = typed.Spec.ElasticsearchRef.Name
+	}
+	if esName == "" {
+		return
+	}
+	// the work queue deduplicates the requests for the same cluster
+	q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: esName}})
+}
@@ -0,0 +1,67 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
+)
+
+func declaredUser(esName string) *authv1alpha1.ElasticsearchUser {
+	return &authv1alpha1.ElasticsearchUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "user"},
+		Spec: authv1alpha1.ElasticsearchUserSpec{
+			ElasticsearchRef: corev1.LocalObjectReference{Name: esName},
+		},
+	}
+}
+
+func queuedRequests(q workqueue.RateLimitingInterface) []reconcile.Request {
+	var requests []reconcile.Request
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		q.Done(item)
+		requests = append(requests, item.(reconcile.Request))
+	}
+	return requests
+}
+
+func Test_referencedClusterHandler(t *testing.T) {
+	h := referencedClusterHandler()
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: name}}
+	}
+
+	created := declaredUser("es1")
+	h.Create(event.CreateEvent{Meta: created, Object: created}, q)
+	require.Equal(t, []reconcile.Request{request("es1")}, queuedRequests(q))
+
+	// both the previously and the newly referenced clusters are enqueued when the reference changes
+	updated := declaredUser("es2")
+	h.Update(event.UpdateEvent{MetaOld: created, ObjectOld: created, MetaNew: updated, ObjectNew: updated}, q)
+	require.ElementsMatch(t, []reconcile.Request{request("es1"), request("es2")}, queuedRequests(q))
+
+	// the referenced cluster is enqueued once if the reference does not change
+	h.Update(event.UpdateEvent{MetaOld: updated, ObjectOld: updated, MetaNew: updated, ObjectNew: updated}, q)
+	require.Equal(t, []reconcile.Request{request("es2")}, queuedRequests(q))
+
+	h.Delete(event.DeleteEvent{Meta: updated, Object: updated}, q)
+	require.Equal(t, []reconcile.Request{request("es2")}, queuedRequests(q))
+
+	// no cluster is referenced
+	unreferenced := declaredUser("")
+	h.Create(event.CreateEvent{Meta: unreferenced, Object: unreferenced}, q)
+	require.Empty(t, queuedRequests(q))
+}
@@ -0,0 +1,190 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	logutil "github.com/elastic/cloud-on-k8s/pkg/utils/log"
+)
+
+const (
+	// Redacted replaces the sensitive values in diagnostics bundles.
+	Redacted = "[REDACTED]"
+
+	lastAppliedConfigurationAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// sensitiveName matches the names of the settings and environment variables whose value is redacted.
+var sensitiveName = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|key)`)
+
+// Bundle is the content of a diagnostics bundle, as files indexed by their name. No Secret is ever included, and the
+// sensitive values of the other resources are redacted.
+type Bundle map[string][]byte
+
+// Collect gathers the diagnostics of the given cluster: the Elasticsearch resource with its StatefulSets, Pods and
+// events, the recently observed states, and the operator logs mentioning the cluster.
+func Collect(c k8s.Client, es esv1.Elasticsearch, states []observer.State, logs *logutil.RecentLogs) (Bundle, error) {
+	var statefulSets appsv1.StatefulSetList
+	if err := c.List(&statefulSets, client.InNamespace(es.Namespace), label.NewLabelSelectorForElasticsearch(es)); err != nil {
+		return nil, err
+	}
+	var pods corev1.PodList
+	if err := c.List(&pods, client.InNamespace(es.Namespace), label.NewLabelSelectorForElasticsearch(es)); err != nil {
+		return nil, err
+	}
+	var events corev1.EventList
+	if err := c.List(&events, client.InNamespace(es.Namespace)); err != nil {
+		return nil, err
+	}
+
+	involved := map[string]bool{es.Name: true}
+	for i := range statefulSets.Items {
+		involved[statefulSets.Items[i].Name] = true
+		redactMeta(statefulSets.Items[i].Annotations)
+		redactPodSpec(&statefulSets.Items[i].Spec.Template.Spec)
+	}
+	for i := range pods.Items {
+		involved[pods.Items[i].Name] = true
+		redactMeta(pods.Items[i].Annotations)
+		redactPodSpec(&pods.Items[i].Spec)
+	}
+	var clusterEvents []corev1.Event
+	for _, event := range events.Items {
+		if involved[event.InvolvedObject.Name] {
+			clusterEvents = append(clusterEvents, event)
+		}
+	}
+	sort.Slice(clusterEvents, func(i, j int) bool {
+		return clusterEvents[i].LastTimestamp.Before(&clusterEvents[j].LastTimestamp)
+	})
+
+	bundle := Bundle{}
+	for name, content := range map[string]interface{}{
+		"elasticsearch.json":   redactElasticsearch(es),
+		"statefulsets.json":    statefulSets.Items,
+		"pods.json":            pods.Items,
+		"events.json":          clusterEvents,
+		"observed-states.json": redactStates(states),
+	} {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		bundle[name] = data
+	}
+	if logs != nil {
+		// log fields are quoted strings with both the JSON and the development encoders
+		namespace, name := strconv.Quote(es.Namespace), strconv.Quote(es.Name)
+		lines := logs.Lines(func(line string) bool {
+			return strings.Contains(line, namespace) && strings.Contains(line, name)
+		})
+		bundle["operator.log"] = []byte(strings.Join(lines, "\n"))
+	}
+	return bundle, nil
+}
+
+// Archive returns the bundle as a gzipped tarball, with the files in the given directory.
+func (b Bundle) Archive(dir string, modTime time.Time) ([]byte, error) {
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		header := tar.Header{Name: dir + "/" + name, Mode: 0644, Size: int64(len(b[name])), ModTime: modTime}
+		if err := tw.WriteHeader(&header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(b[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// redactElasticsearch returns a copy of the given Elasticsearch resource without sensitive settings.
+func redactElasticsearch(es esv1.Elasticsearch) esv1.Elasticsearch {
+	redacted := es.DeepCopy()
+	redactMeta(redacted.Annotations)
+	for i := range redacted.Spec.NodeSets {
+		nodeSet := &redacted.Spec.NodeSets[i]
+		if nodeSet.Config != nil {
+			redactSettings(nodeSet.Config.Data)
+		}
+		redactPodSpec(&nodeSet.PodTemplate.Spec)
+	}
+	return *redacted
+}
+
+// redactMeta removes the last applied configuration, which holds the resource as specified by the user.
+func redactMeta(annotations map[string]string) {
+	delete(annotations, lastAppliedConfigurationAnnotation)
+}
+
+func redactSettings(settings map[string]interface{}) {
+	for key, value := range settings {
+		if nested, isMap := value.(map[string]interface{}); isMap {
+			redactSettings(nested)
+			continue
+		}
+		if sensitiveName.MatchString(key) {
+			settings[key] = Redacted
+		}
+	}
+}
+
+func redactPodSpec(spec *corev1.PodSpec) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Env {
+				env := &containers[i].Env[j]
+				if env.Value != "" && sensitiveName.MatchString(env.Name) {
+					env.Value = Redacted
+				}
+			}
+		}
+	}
+}
+
+// redactStates returns the given states without license signatures.
+func redactStates(states []observer.State) []observer.State {
+	redacted := make([]observer.State, len(states))
+	for i, state := range states {
+		if state.ClusterLicense != nil {
+			license := *state.ClusterLicense
+			license.Signature = ""
+			state.ClusterLicense = &license
+		}
+		redacted[i] = state
+	}
+	return redacted
+}
@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+)
+
+// DefaultHistorySize is the default number of observed states kept for each cluster.
+const DefaultHistorySize = 10
+
+// History keeps the most recent states observed for each cluster, to be included in diagnostics bundles.
+type History struct {
+	size   int
+	mutex  sync.RWMutex
+	states map[types.NamespacedName][]observer.State
+}
+
+// NewHistory returns a History keeping the given number of states per cluster.
+func NewHistory(size int) *History {
+	return &History{size: size, states: make(map[types.NamespacedName][]observer.State)}
+}
+
+// OnObservation records the new state of the given cluster. It implements observer.OnObservation.
+func (h *History) OnObservation(cluster types.NamespacedName, _ observer.State, newState observer.State) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	states := append(h.states[cluster], newState)
+	if len(states) > h.size {
+		states = states[len(states)-h.size:]
+	}
+	h.states[cluster] = states
+}
+
+// States returns the states recorded for the given cluster, from the oldest to the most recent one.
+func (h *History) States(cluster types.NamespacedName) []observer.State {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return append([]observer.State{}, h.states[cluster]...)
+}
+
+// Forget removes the states of the given cluster.
+func (h *History) Forget(cluster types.NamespacedName) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.states, cluster)
+}
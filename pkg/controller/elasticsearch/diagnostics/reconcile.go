@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	logutil "github.com/elastic/cloud-on-k8s/pkg/utils/log"
+)
+
+const (
+	// DiagnosticsAnnotation can be set on the Elasticsearch resource to generate a diagnostics bundle on demand. A new
+	// bundle is generated every time the annotation value changes.
+	DiagnosticsAnnotation = "elasticsearch.k8s.elastic.co/diagnostics"
+	// DiagnosticsRequestAnnotation records the value of DiagnosticsAnnotation the bundle was generated for.
+	DiagnosticsRequestAnnotation = "elasticsearch.k8s.elastic.co/diagnostics-request"
+	// DiagnosticsGeneratedAtAnnotation records when the bundle was generated.
+	DiagnosticsGeneratedAtAnnotation = "elasticsearch.k8s.elastic.co/diagnostics-generated-at"
+
+	// BundleKey is the key of the gzipped tarball in the diagnostics Secret.
+	BundleKey = "diagnostics.tar.gz"
+
+	// maxBundleSize keeps the bundle below the maximum size of a Secret.
+	maxBundleSize = 1000 * 1000
+)
+
+var log = logf.Log.WithName("elasticsearch-diagnostics")
+
+// Reconcile generates a diagnostics bundle for the given cluster if requested through DiagnosticsAnnotation and not
+// generated yet for the current request. The bundle is stored in a Secret owned by the cluster.
+func Reconcile(c k8s.Client, es esv1.Elasticsearch, history *History, logs *logutil.RecentLogs, now time.Time) error {
+	request, requested := es.Annotations[DiagnosticsAnnotation]
+	if !requested || es.IsMarkedForDeletion() {
+		return nil
+	}
+
+	secret := corev1.Secret{}
+	nsn := types.NamespacedName{Namespace: es.Namespace, Name: esv1.DiagnosticsSecret(es.Name)}
+	err := c.Get(nsn, &secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists && secret.Annotations[DiagnosticsRequestAnnotation] == request {
+		// already generated
+		return nil
+	}
+
+	log.Info("Generating diagnostics bundle", "namespace", es.Namespace, "es_name", es.Name, "request", request)
+	bundle, err := Collect(c, es, history.States(k8s.ExtractNamespacedName(&es)), logs)
+	if err != nil {
+		return err
+	}
+	archive, err := bundle.Archive(fmt.Sprintf("%s-%s-diagnostics-%s", es.Namespace, es.Name, now.UTC().Format("20060102-150405")), now)
+	if err != nil {
+		return err
+	}
+	if len(archive) > maxBundleSize {
+		return fmt.Errorf("diagnostics bundle of %d bytes exceeds the maximum size of a secret", len(archive))
+	}
+
+	expected := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: nsn.Namespace,
+			Name:      nsn.Name,
+			Labels:    label.NewLabels(k8s.ExtractNamespacedName(&es)),
+			Annotations: map[string]string{
+				DiagnosticsRequestAnnotation:     request,
+				DiagnosticsGeneratedAtAnnotation: now.UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string][]byte{BundleKey: archive},
+	}
+	if err := controllerutil.SetControllerReference(&es, &expected, scheme.Scheme); err != nil {
+		return err
+	}
+	if !exists {
+		return c.Create(&expected)
+	}
+	secret.Labels = expected.Labels
+	secret.Annotations = expected.Annotations
+	secret.OwnerReferences = expected.OwnerReferences
+	secret.Data = expected.Data
+	return c.Update(&secret)
+}
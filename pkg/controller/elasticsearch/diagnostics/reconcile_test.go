@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	logutil "github.com/elastic/cloud-on-k8s/pkg/utils/log"
+)
+
+// extract returns the files of the given archive, indexed by their name.
+func extract(t *testing.T, archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns", Name: "es"}
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{
+			DiagnosticsAnnotation:              "1",
+			lastAppliedConfigurationAnnotation: "{}",
+		}},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{{
+			Name: "default",
+			Config: &commonv1.Config{Data: map[string]interface{}{
+				"node.master": true,
+				"xpack.security.authc.realms.ldap.ldap1": map[string]interface{}{
+					"bind_password": "changeme",
+				},
+			}},
+			PodTemplate: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "elasticsearch",
+				Env:  []corev1.EnvVar{{Name: "S3_SECRET_KEY", Value: "s3cr3t"}, {Name: "ES_JAVA_OPTS", Value: "-Xmx1g"}},
+			}}}},
+		}}},
+	}
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-es-default-0", Labels: label.NewLabels(cluster)}}
+	events := []corev1.Event{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "e1"}, InvolvedObject: corev1.ObjectReference{Name: "es-es-default-0"}, Message: "pod event"},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "e2"}, InvolvedObject: corev1.ObjectReference{Name: "other"}, Message: "other event"},
+	}
+	c := k8s.WrappedFakeClient(&es, &pod, &events[0], &events[1])
+
+	history := NewHistory(2)
+	for i := 0; i < 3; i++ {
+		history.OnObservation(cluster, observer.State{}, observer.State{
+			ClusterHealth:  &esclient.Health{NumberOfNodes: i},
+			ClusterLicense: &esclient.License{Type: "platinum", Signature: "signature"},
+		})
+	}
+	logs := logutil.NewRecentLogs(10)
+	_, err := logs.Write([]byte(`{"message":"about es","namespace":"ns","es_name":"es"}` + "\n" + `{"message":"about another cluster","namespace":"ns","es_name":"other"}` + "\n"))
+	require.NoError(t, err)
+
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	require.NoError(t, Reconcile(c, es, history, logs, now))
+
+	var secret corev1.Secret
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "es-es-diagnostics"}, &secret))
+	require.Equal(t, "1", secret.Annotations[DiagnosticsRequestAnnotation])
+	require.Equal(t, "2020-03-04T05:06:07Z", secret.Annotations[DiagnosticsGeneratedAtAnnotation])
+	require.Len(t, secret.OwnerReferences, 1)
+
+	files := extract(t, secret.Data[BundleKey])
+	dir := "ns-es-diagnostics-20200304-050607/"
+	require.Len(t, files, 6)
+	// sensitive values are redacted
+	require.NotContains(t, files[dir+"elasticsearch.json"], "changeme")
+	require.NotContains(t, files[dir+"elasticsearch.json"], "s3cr3t")
+	require.NotContains(t, files[dir+"elasticsearch.json"], lastAppliedConfigurationAnnotation)
+	require.Contains(t, files[dir+"elasticsearch.json"], "-Xmx1g")
+	require.NotContains(t, files[dir+"observed-states.json"], "signature")
+	// only the most recent states and the resources of the cluster are included
+	require.NotContains(t, files[dir+"observed-states.json"], `"number_of_nodes": 0`)
+	require.Contains(t, files[dir+"observed-states.json"], `"number_of_nodes": 2`)
+	require.Contains(t, files[dir+"pods.json"], "es-es-default-0")
+	require.Contains(t, files[dir+"events.json"], "pod event")
+	require.NotContains(t, files[dir+"events.json"], "other event")
+	require.Equal(t, `{"message":"about es","namespace":"ns","es_name":"es"}`, files[dir+"operator.log"])
+
+	// the bundle is not generated again for the same request
+	later := now.Add(time.Hour)
+	require.NoError(t, Reconcile(c, es, history, logs, later))
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "es-es-diagnostics"}, &secret))
+	require.Equal(t, "2020-03-04T05:06:07Z", secret.Annotations[DiagnosticsGeneratedAtAnnotation])
+
+	// but is for a new request
+	es.Annotations[DiagnosticsAnnotation] = "2"
+	require.NoError(t, Reconcile(c, es, history, logs, later))
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "es-es-diagnostics"}, &secret))
+	require.Equal(t, "2", secret.Annotations[DiagnosticsRequestAnnotation])
+	require.Equal(t, "2020-03-04T06:06:07Z", secret.Annotations[DiagnosticsGeneratedAtAnnotation])
+}
+
+func TestReconcile_NotRequested(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	c := k8s.WrappedFakeClient(&es)
+	require.NoError(t, Reconcile(c, es, NewHistory(1), nil, time.Now()))
+	var secrets corev1.SecretList
+	require.NoError(t, c.List(&secrets))
+	require.Empty(t, secrets.Items)
+}
@@ -0,0 +1,29 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/adoption"
+)
+
+// adoptResources adopts the existing resources of the cluster if requested by the adoption annotation.
+// It returns the result of the adoption, or nil if not requested.
+func (d *defaultDriver) adoptResources() (*adoption.Result, error) {
+	if !adoption.IsRequested(d.ES) {
+		return nil, nil
+	}
+	result, err := adoption.AdoptResources(d.Client, d.ES)
+	if err != nil {
+		return nil, err
+	}
+	if result.Total() > 0 {
+		d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonAdopted,
+			"Adopted existing resources: "+result.String())
+	}
+	return &result, nil
+}
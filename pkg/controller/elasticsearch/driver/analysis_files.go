@@ -0,0 +1,152 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// AnalysisFilesAnnotationName holds the state of the analysis files of the cluster, serialized as JSON.
+	AnalysisFilesAnnotationName = "elasticsearch.k8s.elastic.co/analysis-files"
+	// analysisFilesPropagationDelay is the time to wait for a change of the analysis files to be propagated to the
+	// Pods by the kubelets, before reloading the search analyzers.
+	analysisFilesPropagationDelay = 2 * time.Minute
+)
+
+// reloadSearchAnalyzersMinVersion is the first version of Elasticsearch able to reload its search analyzers.
+var reloadSearchAnalyzersMinVersion = version.MustParse("7.3.0")
+
+// AnalysisFilesWatchName returns the watch registered on the ConfigMaps holding the analysis files of a cluster.
+func AnalysisFilesWatchName(es types.NamespacedName) string {
+	return fmt.Sprintf("%s-%s-analysis-files", es.Namespace, es.Name)
+}
+
+// analysisFilesState is the state of the analysis files of a cluster.
+type analysisFilesState struct {
+	// Hash of the latest content of the analysis files.
+	Hash string `json:"hash"`
+	// ReloadAfter is the time after which the latest content is propagated to the Pods, and the search analyzers can
+	// be reloaded. Not set once they are reloaded.
+	ReloadAfter *metav1.Time `json:"reloadAfter,omitempty"`
+}
+
+// reconcileAnalysisFiles watches the ConfigMaps holding the analysis files, and reloads the search analyzers once a
+// change of their content is propagated to the Pods. The files are read by the nodes when they start: the first
+// content observed, or the one of a rolling upgrade, does not need to be reloaded.
+func (d *defaultDriver) reconcileAnalysisFiles(
+	ctx context.Context,
+	esClient esclient.Client,
+	esReachable bool,
+	esVersion version.Version,
+) (reconcile.Result, error) {
+	esKey := k8s.ExtractNamespacedName(&d.ES)
+	files := d.ES.Spec.AnalysisFiles
+	if len(files) == 0 {
+		d.DynamicWatches().ConfigMaps.RemoveHandlerForKey(AnalysisFilesWatchName(esKey))
+		if _, exists := d.ES.Annotations[AnalysisFilesAnnotationName]; !exists {
+			return reconcile.Result{}, nil
+		}
+		delete(d.ES.Annotations, AnalysisFilesAnnotationName)
+		return reconcile.Result{}, d.Client.Update(&d.ES)
+	}
+
+	watched := make([]types.NamespacedName, 0, len(files))
+	for _, f := range files {
+		watched = append(watched, types.NamespacedName{Namespace: d.ES.Namespace, Name: f.ConfigMapName})
+	}
+	if err := d.DynamicWatches().ConfigMaps.AddHandler(watches.NamedWatch{
+		Name:    AnalysisFilesWatchName(esKey),
+		Watched: watched,
+		Watcher: esKey,
+	}); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	filesHash, err := analysisFilesHash(d.Client, watched)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	var state analysisFilesState
+	serialized, exists := d.ES.Annotations[AnalysisFilesAnnotationName]
+	if exists {
+		if err := json.Unmarshal([]byte(serialized), &state); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	now := time.Now()
+	switch {
+	case !exists:
+		return reconcile.Result{}, d.updateAnalysisFilesState(analysisFilesState{Hash: filesHash})
+	case state.Hash != filesHash:
+		log.Info("Analysis files changed", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		reloadAfter := metav1.NewTime(now.Add(analysisFilesPropagationDelay))
+		err := d.updateAnalysisFilesState(analysisFilesState{Hash: filesHash, ReloadAfter: &reloadAfter})
+		return reconcile.Result{RequeueAfter: analysisFilesPropagationDelay}, err
+	case state.ReloadAfter == nil:
+		// already reloaded
+		return reconcile.Result{}, nil
+	case now.Before(state.ReloadAfter.Time):
+		return reconcile.Result{RequeueAfter: state.ReloadAfter.Sub(now)}, nil
+	case !esReachable:
+		return defaultRequeue, nil
+	}
+
+	if esVersion.IsSameOrAfter(reloadSearchAnalyzersMinVersion) {
+		log.Info("Reloading search analyzers", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		if err := esClient.ReloadSearchAnalyzers(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else {
+		d.ReconcileState.AddEvent(
+			corev1.EventTypeWarning,
+			events.EventReasonUnexpected,
+			fmt.Sprintf("Elasticsearch %s cannot reload its search analyzers: restart the nodes to use the latest analysis files", esVersion),
+		)
+	}
+	return reconcile.Result{}, d.updateAnalysisFilesState(analysisFilesState{Hash: filesHash})
+}
+
+// updateAnalysisFilesState stores the given state of the analysis files in the annotation of the cluster.
+func (d *defaultDriver) updateAnalysisFilesState(state analysisFilesState) error {
+	serialized, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if d.ES.Annotations == nil {
+		d.ES.Annotations = map[string]string{}
+	}
+	d.ES.Annotations[AnalysisFilesAnnotationName] = string(serialized)
+	return d.Client.Update(&d.ES)
+}
+
+// analysisFilesHash returns the hash of the content of the given ConfigMaps.
+func analysisFilesHash(c k8s.Client, configMaps []types.NamespacedName) (string, error) {
+	contents := make([]interface{}, 0, 2*len(configMaps))
+	for _, key := range configMaps {
+		var configMap corev1.ConfigMap
+		if err := c.Get(key, &configMap); err != nil {
+			return "", errors.Wrapf(err, "while reading the analysis files of ConfigMap %s", key.Name)
+		}
+		contents = append(contents, configMap.Data, configMap.BinaryData)
+	}
+	return hash.HashObject(contents), nil
+}
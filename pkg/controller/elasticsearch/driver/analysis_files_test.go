@@ -0,0 +1,89 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_reconcileAnalysisFiles(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{AnalysisFiles: []esv1.AnalysisFiles{{ConfigMapName: "synonyms"}}},
+	}
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "synonyms"},
+		Data:       map[string]string{"synonyms.txt": "laptop, notebook"},
+	}
+	k8sClient := k8s.WrappedFakeClient(&es, &configMap)
+	reloads := 0
+	esClient := esclient.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_all/_reload_search_analyzers", req.URL.Path)
+		reloads++
+		return esclient.NewMockResponse(200, req, `{}`)
+	})
+	d := &defaultDriver{DefaultDriverParameters{
+		Client:         k8sClient,
+		ES:             es,
+		DynamicWatches: watches.NewDynamicWatches(),
+	}}
+	reconcileAnalysisFiles := func() reconcile.Result {
+		result, err := d.reconcileAnalysisFiles(context.Background(), esClient, true, version.MustParse("7.6.0"))
+		require.NoError(t, err)
+		return result
+	}
+	state := func() analysisFilesState {
+		var state analysisFilesState
+		require.NoError(t, json.Unmarshal([]byte(d.ES.Annotations[AnalysisFilesAnnotationName]), &state))
+		return state
+	}
+
+	// the files are loaded by the nodes when they start: no reload
+	require.Equal(t, reconcile.Result{}, reconcileAnalysisFiles())
+	require.Equal(t, []string{AnalysisFilesWatchName(k8s.ExtractNamespacedName(&es))}, d.DynamicWatches().ConfigMaps.Registrations())
+	initialHash := state().Hash
+	require.Nil(t, state().ReloadAfter)
+	require.Equal(t, 0, reloads)
+
+	// the files change: the reload waits for them to be propagated to the Pods
+	configMap.Data["synonyms.txt"] = "laptop, notebook, portable"
+	require.NoError(t, k8sClient.Update(&configMap))
+	require.Equal(t, reconcile.Result{RequeueAfter: analysisFilesPropagationDelay}, reconcileAnalysisFiles())
+	require.NotEqual(t, initialHash, state().Hash)
+	require.NotNil(t, state().ReloadAfter)
+	require.NotZero(t, reconcileAnalysisFiles().RequeueAfter)
+	require.Equal(t, 0, reloads)
+
+	// the files are propagated: the search analyzers are reloaded once
+	propagated := state()
+	propagated.ReloadAfter = &metav1.Time{Time: time.Now().Add(-time.Second)}
+	require.NoError(t, d.updateAnalysisFilesState(propagated))
+	require.Equal(t, reconcile.Result{}, reconcileAnalysisFiles())
+	require.Equal(t, 1, reloads)
+	require.Nil(t, state().ReloadAfter)
+	require.Equal(t, reconcile.Result{}, reconcileAnalysisFiles())
+	require.Equal(t, 1, reloads)
+
+	// the files are removed from the specification: the watch and the annotation are removed
+	d.ES.Spec.AnalysisFiles = nil
+	require.Equal(t, reconcile.Result{}, reconcileAnalysisFiles())
+	require.Empty(t, d.DynamicWatches().ConfigMaps.Registrations())
+	require.NotContains(t, d.ES.Annotations, AnalysisFilesAnnotationName)
+}
@@ -0,0 +1,46 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+)
+
+// warmUpCaches runs the cache warm-up searches of the update strategy before removing the leaving nodes of the given
+// downscale, once their data is migrated away. The warm-up is best effort: failed searches are reported as events,
+// and do not prevent the removal of the nodes.
+func warmUpCaches(ctx downscaleContext, downscale ssetDownscale) {
+	warmup := ctx.es.Spec.UpdateStrategy.CacheWarmup
+	leavingNodes := downscale.leavingNodeNames()
+	if warmup == nil || len(leavingNodes) == 0 || !label.IsDataNodeSet(downscale.statefulSet) {
+		return
+	}
+	for _, search := range warmup.Searches {
+		var body map[string]interface{}
+		if search.Body != nil {
+			body = search.Body.Data
+		}
+		ssetLogger(downscale.statefulSet).Info("Warming up caches before node deletion",
+			"nodes", leavingNodes, "indices", search.Indices)
+		searchCtx, cancel := context.WithTimeout(ctx.parentCtx, warmup.TimeoutOrDefault())
+		_, err := ctx.esClient.Search(searchCtx, search.Indices, body)
+		cancel()
+		if err != nil {
+			ctx.reconcileState.AddEvent(
+				corev1.EventTypeWarning,
+				events.EventReasonUnexpected,
+				fmt.Sprintf("Cache warm-up search on %s failed before removing nodes %s: %s",
+					strings.Join(search.Indices, ","), strings.Join(leavingNodes, ","), err),
+			)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+)
+
+func Test_warmUpCaches(t *testing.T) {
+	warmup := &esv1.CacheWarmup{Searches: []esv1.WarmupSearch{{Indices: []string{"logs-*"}}, {Indices: []string{"metrics"}}}}
+	dataDownscale := ssetDownscale{
+		statefulSet:     sset.TestSset{Name: "frozen", Data: true, Replicas: 3}.Build(),
+		initialReplicas: 3,
+		targetReplicas:  2,
+	}
+	tests := []struct {
+		name       string
+		warmup     *esv1.CacheWarmup
+		downscale  ssetDownscale
+		searchErr  error
+		wantSearch [][]string
+		wantEvents int
+	}{
+		{
+			name:      "no warm-up",
+			downscale: dataDownscale,
+		},
+		{
+			name:   "no node removed",
+			warmup: warmup,
+			downscale: ssetDownscale{
+				statefulSet:     dataDownscale.statefulSet,
+				initialReplicas: 3,
+				targetReplicas:  3,
+			},
+		},
+		{
+			name:   "no data node removed",
+			warmup: warmup,
+			downscale: ssetDownscale{
+				statefulSet:     sset.TestSset{Name: "masters", Master: true, Replicas: 3}.Build(),
+				initialReplicas: 3,
+				targetReplicas:  2,
+			},
+		},
+		{
+			name:       "data node removed",
+			warmup:     warmup,
+			downscale:  dataDownscale,
+			wantSearch: [][]string{{"logs-*"}, {"metrics"}},
+		},
+		{
+			name:       "failed searches are reported",
+			warmup:     warmup,
+			downscale:  dataDownscale,
+			searchErr:  errors.New("search_phase_execution_exception"),
+			wantSearch: [][]string{{"logs-*"}, {"metrics"}},
+			wantEvents: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{UpdateStrategy: esv1.UpdateStrategy{CacheWarmup: tt.warmup}}}
+			esClient := &fakeESClient{searchErr: tt.searchErr}
+			ctx := downscaleContext{
+				esClient:       esClient,
+				reconcileState: reconcile.NewState(es),
+				es:             es,
+				parentCtx:      context.Background(),
+			}
+			warmUpCaches(ctx, tt.downscale)
+			require.Equal(t, tt.wantSearch, esClient.SearchCalledWith)
+			require.Len(t, ctx.reconcileState.Events(), tt.wantEvents)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+)
+
+const (
+	// CriticalIndicesConditionType is the type of the condition reporting whether the indices matching
+	// spec.criticalIndices are available, i.e. none of them is red.
+	CriticalIndicesConditionType commonv1.ConditionType = "CriticalIndicesAvailable"
+	// ReasonCriticalIndicesAvailable is the reason of the condition when no critical index is red.
+	ReasonCriticalIndicesAvailable = "Available"
+	// ReasonCriticalIndicesRed is the reason of the condition when some critical indices are red.
+	ReasonCriticalIndicesRed = "IndicesRed"
+)
+
+// reconcileCriticalIndicesCondition reports the health of the critical indices of the cluster in a status condition,
+// with a warning event each time the set of red critical indices changes, and an event once they are all recovered.
+func (d *defaultDriver) reconcileCriticalIndicesCondition(observedState observer.State) {
+	if len(d.ES.Spec.CriticalIndices) == 0 {
+		d.ReconcileState.RemoveCondition(CriticalIndicesConditionType)
+		return
+	}
+	if observedState.CriticalIndices == nil {
+		// not observed yet or cannot be observed, keep reporting the last observation
+		return
+	}
+	condition := criticalIndicesCondition(observedState.CriticalIndices)
+	previous := d.ReconcileState.Conditions().Get(CriticalIndicesConditionType)
+	switch {
+	case condition.Status == corev1.ConditionFalse && (previous == nil || previous.Message != condition.Message):
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnhealthy, condition.Message)
+	case condition.Status == corev1.ConditionTrue && previous != nil && previous.Status == corev1.ConditionFalse:
+		d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonStateChange, "No critical index is red anymore")
+	}
+	d.ReconcileState.UpdateCondition(condition)
+}
+
+// criticalIndicesCondition returns the condition reporting the red indices among the given ones, with the reasons
+// why their primary shards are not assigned.
+func criticalIndicesCondition(indices []esclient.IndexHealth) commonv1.Condition {
+	var red []string
+	for _, index := range indices {
+		if index.Status != esv1.ElasticsearchRedHealth {
+			continue
+		}
+		if len(index.UnassignedReasons) == 0 {
+			red = append(red, index.Index)
+			continue
+		}
+		red = append(red, fmt.Sprintf("%s (%s)", index.Index, strings.Join(index.UnassignedReasons, ", ")))
+	}
+	if len(red) == 0 {
+		return commonv1.Condition{
+			Type:    CriticalIndicesConditionType,
+			Status:  corev1.ConditionTrue,
+			Reason:  ReasonCriticalIndicesAvailable,
+			Message: fmt.Sprintf("None of the %d critical indices is red", len(indices)),
+		}
+	}
+	return commonv1.Condition{
+		Type:    CriticalIndicesConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  ReasonCriticalIndicesRed,
+		Message: "Critical indices are red, some of their primary shards are not assigned: " + strings.Join(red, "; "),
+	}
+}
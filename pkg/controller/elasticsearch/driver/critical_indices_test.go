@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+)
+
+func Test_defaultDriver_reconcileCriticalIndicesCondition(t *testing.T) {
+	green := esclient.IndexHealth{Index: "logs-1", Status: esv1.ElasticsearchGreenHealth}
+	yellow := esclient.IndexHealth{Index: "logs-2", Status: esv1.ElasticsearchYellowHealth}
+	red := esclient.IndexHealth{Index: "users", Status: esv1.ElasticsearchRedHealth, UnassignedReasons: []string{"ALLOCATION_FAILED", "NODE_LEFT"}}
+	otherRed := esclient.IndexHealth{Index: "orders", Status: esv1.ElasticsearchRedHealth}
+	es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{CriticalIndices: []string{"logs-*", "users", "orders"}}}
+	d := &defaultDriver{DefaultDriverParameters{ES: es, ReconcileState: reconcile.NewState(es)}}
+
+	// not observed yet
+	d.reconcileCriticalIndicesCondition(observer.State{})
+	require.Nil(t, d.ReconcileState.Conditions().Get(CriticalIndicesConditionType))
+
+	steps := []struct {
+		indices     []esclient.IndexHealth
+		wantStatus  corev1.ConditionStatus
+		wantReason  string
+		wantMessage string
+		wantEvents  int
+	}{
+		{
+			indices:     []esclient.IndexHealth{green, yellow},
+			wantStatus:  corev1.ConditionTrue,
+			wantReason:  ReasonCriticalIndicesAvailable,
+			wantMessage: "None of the 2 critical indices is red",
+		},
+		{
+			indices:     []esclient.IndexHealth{green, yellow, red},
+			wantStatus:  corev1.ConditionFalse,
+			wantReason:  ReasonCriticalIndicesRed,
+			wantMessage: "Critical indices are red, some of their primary shards are not assigned: users (ALLOCATION_FAILED, NODE_LEFT)",
+			wantEvents:  1,
+		},
+		// same red indices: no new event
+		{indices: []esclient.IndexHealth{green, yellow, red}, wantStatus: corev1.ConditionFalse, wantReason: ReasonCriticalIndicesRed, wantEvents: 1},
+		// cannot be observed: the last observation is kept
+		{wantStatus: corev1.ConditionFalse, wantReason: ReasonCriticalIndicesRed, wantEvents: 1},
+		{
+			indices:     []esclient.IndexHealth{otherRed, green, red},
+			wantStatus:  corev1.ConditionFalse,
+			wantReason:  ReasonCriticalIndicesRed,
+			wantMessage: "Critical indices are red, some of their primary shards are not assigned: orders; users (ALLOCATION_FAILED, NODE_LEFT)",
+			wantEvents:  2,
+		},
+		// recovered
+		{indices: []esclient.IndexHealth{green}, wantStatus: corev1.ConditionTrue, wantReason: ReasonCriticalIndicesAvailable, wantEvents: 3},
+		{indices: []esclient.IndexHealth{}, wantStatus: corev1.ConditionTrue, wantReason: ReasonCriticalIndicesAvailable, wantEvents: 3},
+	}
+	for i, step := range steps {
+		d.reconcileCriticalIndicesCondition(observer.State{CriticalIndices: step.indices})
+		condition := d.ReconcileState.Conditions().Get(CriticalIndicesConditionType)
+		require.NotNil(t, condition, i)
+		require.Equal(t, step.wantStatus, condition.Status, i)
+		require.Equal(t, step.wantReason, condition.Reason, i)
+		if step.wantMessage != "" {
+			require.Equal(t, step.wantMessage, condition.Message, i)
+		}
+		require.Len(t, d.ReconcileState.Events(), step.wantEvents, i)
+	}
+
+	// no critical indices anymore: the condition is removed
+	d.ES.Spec.CriticalIndices = nil
+	d.reconcileCriticalIndicesCondition(observer.State{CriticalIndices: []esclient.IndexHealth{red}})
+	require.Nil(t, d.ReconcileState.Conditions().Get(CriticalIndicesConditionType))
+}
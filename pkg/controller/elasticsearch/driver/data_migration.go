@@ -0,0 +1,292 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+)
+
+const (
+	// stalledDataMigrationTimeout is the duration without any shard moving away from a node after which its data
+	// migration is reported as stalled.
+	stalledDataMigrationTimeout = 10 * time.Minute
+
+	// DataMigrationConditionType is the type of the condition reporting the action taken for the data migrations that
+	// timed out, while they are recorded in the status.
+	DataMigrationConditionType commonv1.ConditionType = "DataMigration"
+	// ReasonDataMigrationAborted is the reason of the condition when the nodes whose data migration timed out are kept.
+	ReasonDataMigrationAborted = "DataMigrationAborted"
+	// ReasonDataMigrationForced is the reason of the condition when the nodes whose data migration timed out are
+	// removed regardless of the shards still allocated to them.
+	ReasonDataMigrationForced = "DataMigrationForced"
+	// ReasonDataMigrationTimedOut is the reason of the condition when some nodes whose data migration timed out are
+	// kept, and others removed.
+	ReasonDataMigrationTimedOut = "DataMigrationTimedOut"
+)
+
+// reportDataMigration reports the progress of the data migration away from the given leaving nodes in the status,
+// and as events. Failing to retrieve the progress does not prevent the downscale: it is reported again at the next
+// reconciliation.
+func reportDataMigration(ctx downscaleContext, leavingNodes []string) {
+	if len(leavingNodes) == 0 {
+		ctx.reconcileState.UpdateDataMigration(nil)
+		ctx.reconcileState.RemoveCondition(DataMigrationConditionType)
+		return
+	}
+	remaining, err := migration.RemainingDataByNode(ctx.parentCtx, ctx.shardLister, leavingNodes)
+	if err != nil {
+		log.V(1).Info("Unable to retrieve the data migration progress",
+			"error", err, "namespace", ctx.es.Namespace, "es_name", ctx.es.Name)
+		return
+	}
+	now := time.Now()
+	previous := ctx.reconcileState.DataMigration()
+	status := dataMigrationStatus(previous, leavingNodes, remaining, now)
+	reported := dataMigrationEvents(previous, status, now)
+	status, timeoutEvents := applyDataMigrationTimeout(status, ctx.es.Spec.UpdateStrategy.DataMigration, ctx.es.Generation, now)
+	for _, event := range append(reported, timeoutEvents...) {
+		ctx.reconcileState.AddEvent(event.EventType, event.Reason, event.Message)
+	}
+	ctx.reconcileState.UpdateDataMigration(status)
+	if condition := dataMigrationCondition(status); condition != nil {
+		ctx.reconcileState.UpdateCondition(*condition)
+	} else {
+		ctx.reconcileState.RemoveCondition(DataMigrationConditionType)
+	}
+}
+
+// dataMigrationCondition returns the condition reporting the action taken for the timed out data migrations, or nil
+// if none timed out.
+func dataMigrationCondition(status []esv1.NodeDataMigration) *commonv1.Condition {
+	var aborted, forced []string
+	for _, progress := range status {
+		switch progress.TimeoutAction {
+		case esv1.AbortDataMigration:
+			aborted = append(aborted, progress.Node)
+		case esv1.ProceedWithDataMigration:
+			forced = append(forced, progress.Node)
+		}
+	}
+	var messages []string
+	if len(aborted) > 0 {
+		messages = append(messages, "Data migration timed out, keeping the nodes until the specification changes: "+
+			strings.Join(aborted, ", "))
+	}
+	if len(forced) > 0 {
+		messages = append(messages, "Data migration timed out, removing the nodes with shards remaining: "+
+			strings.Join(forced, ", "))
+	}
+	var reason string
+	switch {
+	case len(aborted) > 0 && len(forced) > 0:
+		reason = ReasonDataMigrationTimedOut
+	case len(aborted) > 0:
+		reason = ReasonDataMigrationAborted
+	case len(forced) > 0:
+		reason = ReasonDataMigrationForced
+	default:
+		return nil
+	}
+	return &commonv1.Condition{
+		Type:    DataMigrationConditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: strings.Join(messages, ". "),
+	}
+}
+
+// applyDataMigrationTimeout records the action taken for the data migrations lasting longer than the timeout of the
+// given policy, and returns the events reporting them. Aborted data migrations start again once the generation of the
+// Elasticsearch resource changes.
+func applyDataMigrationTimeout(
+	status []esv1.NodeDataMigration,
+	policy *esv1.DataMigrationPolicy,
+	generation int64,
+	now time.Time,
+) ([]esv1.NodeDataMigration, []events.Event) {
+	var result []events.Event
+	for i := range status {
+		progress := &status[i]
+		if progress.TimeoutAction == esv1.AbortDataMigration && progress.TimeoutGeneration != generation {
+			observedAt := metav1.NewTime(now.Truncate(time.Second))
+			*progress = esv1.NodeDataMigration{
+				Node:             progress.Node,
+				RemainingShards:  progress.RemainingShards,
+				RemainingBytes:   progress.RemainingBytes,
+				InitialBytes:     progress.RemainingBytes,
+				StartTime:        observedAt,
+				LastProgressTime: observedAt,
+			}
+			result = append(result, events.Event{
+				EventType: corev1.EventTypeNormal,
+				Reason:    events.EventReasonStateChange,
+				Message:   fmt.Sprintf("Specification changed, retrying the data migration away from node %s", progress.Node),
+			})
+			continue
+		}
+		if policy == nil || progress.TimeoutAction != "" || now.Sub(progress.StartTime.Time) < policy.Timeout.Duration {
+			continue
+		}
+		progress.TimeoutAction = policy.TimeoutAction()
+		progress.TimeoutGeneration = generation
+		msg := fmt.Sprintf("Data migration away from node %s timed out after %s with %d shards remaining, keeping the node "+
+			"until the specification changes", progress.Node, policy.Timeout.Duration, progress.RemainingShards)
+		if progress.TimeoutAction == esv1.ProceedWithDataMigration {
+			msg = fmt.Sprintf("Data migration away from node %s timed out after %s, removing the node with %d shards remaining",
+				progress.Node, policy.Timeout.Duration, progress.RemainingShards)
+		}
+		result = append(result, events.Event{EventType: corev1.EventTypeWarning, Reason: events.EventReasonDelayed, Message: msg})
+	}
+	return status, result
+}
+
+// dataMigrationTimeoutAction returns the action taken for the timed out data migration away from the given node, or an
+// empty action if it did not time out.
+func dataMigrationTimeoutAction(status []esv1.NodeDataMigration, node string) esv1.DataMigrationTimeoutAction {
+	for _, progress := range status {
+		if progress.Node == node {
+			return progress.TimeoutAction
+		}
+	}
+	return ""
+}
+
+// withoutAbortedDataMigrations returns the given leaving nodes, except for the nodes whose data migration was aborted.
+func withoutAbortedDataMigrations(status []esv1.NodeDataMigration, leavingNodes []string) []string {
+	var result []string
+	for _, node := range leavingNodes {
+		if dataMigrationTimeoutAction(status, node) != esv1.AbortDataMigration {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// dataMigrationStatus returns the progress of the data migration away from the given nodes holding data, updated
+// from the previous status with the data remaining on the nodes. Nodes are listed in the given order.
+func dataMigrationStatus(
+	previous []esv1.NodeDataMigration,
+	nodes []string,
+	remaining map[string]migration.RemainingData,
+	now time.Time,
+) []esv1.NodeDataMigration {
+	previousByNode := make(map[string]esv1.NodeDataMigration, len(previous))
+	for _, p := range previous {
+		previousByNode[p.Node] = p
+	}
+	observedAt := metav1.NewTime(now.Truncate(time.Second))
+	var status []esv1.NodeDataMigration
+	for _, node := range nodes {
+		data := remaining[node]
+		if data.Shards == 0 {
+			// the node can be removed
+			continue
+		}
+		progress, exists := previousByNode[node]
+		switch {
+		case !exists:
+			progress = esv1.NodeDataMigration{
+				Node:             node,
+				InitialBytes:     data.Bytes,
+				StartTime:        observedAt,
+				LastProgressTime: observedAt,
+			}
+		case data.Shards < progress.RemainingShards:
+			progress.LastProgressTime = observedAt
+		}
+		if data.Bytes > progress.InitialBytes {
+			// shards still being written to grow during the migration
+			progress.InitialBytes = data.Bytes
+		}
+		progress.RemainingShards = data.Shards
+		progress.RemainingBytes = data.Bytes
+		progress.EstimatedCompletionTime = estimatedCompletionTime(progress, now)
+		status = append(status, progress)
+	}
+	return status
+}
+
+// estimatedCompletionTime extrapolates the average migration rate since the start of the data migration, or returns
+// nil if no data moved yet.
+func estimatedCompletionTime(progress esv1.NodeDataMigration, now time.Time) *metav1.Time {
+	moved := progress.InitialBytes - progress.RemainingBytes
+	elapsed := now.Sub(progress.StartTime.Time)
+	if moved <= 0 || elapsed <= 0 {
+		return nil
+	}
+	remainingDuration := time.Duration(float64(elapsed) * float64(progress.RemainingBytes) / float64(moved))
+	eta := metav1.NewTime(now.Add(remainingDuration).Truncate(time.Second))
+	return &eta
+}
+
+// dataMigrationEvents returns the events reporting the start and the progress of the data migrations, and the data
+// migrations not making any progress.
+func dataMigrationEvents(previous, current []esv1.NodeDataMigration, now time.Time) []events.Event {
+	previousByNode := make(map[string]esv1.NodeDataMigration, len(previous))
+	for _, p := range previous {
+		previousByNode[p.Node] = p
+	}
+	var result []events.Event
+	for _, progress := range current {
+		p, exists := previousByNode[progress.Node]
+		switch {
+		case !exists:
+			result = append(result, events.Event{
+				EventType: corev1.EventTypeNormal,
+				Reason:    events.EventReasonStateChange,
+				Message: fmt.Sprintf("Migrating %d shards (%s) away from node %s",
+					progress.RemainingShards, formatByteSize(progress.RemainingBytes), progress.Node),
+			})
+		case progress.RemainingShards < p.RemainingShards:
+			eta := "unknown"
+			if progress.EstimatedCompletionTime != nil {
+				eta = progress.EstimatedCompletionTime.UTC().Format(time.RFC3339)
+			}
+			result = append(result, events.Event{
+				EventType: corev1.EventTypeNormal,
+				Reason:    events.EventReasonStateChange,
+				Message: fmt.Sprintf("Data migration away from node %s: %d shards (%s) remaining, estimated completion at %s",
+					progress.Node, progress.RemainingShards, formatByteSize(progress.RemainingBytes), eta),
+			})
+		case progress.TimeoutAction == "" && now.Sub(progress.LastProgressTime.Time) >= stalledDataMigrationTimeout:
+			result = append(result, events.Event{
+				EventType: corev1.EventTypeWarning,
+				Reason:    events.EventReasonDelayed,
+				Message: fmt.Sprintf("No shard moved away from node %s since %s, check the allocation explanation of its %d shards",
+					progress.Node, progress.LastProgressTime.UTC().Format(time.RFC3339), progress.RemainingShards),
+			})
+		}
+	}
+	return result
+}
+
+var byteSizeUnits = []string{"kb", "mb", "gb", "tb", "pb"}
+
+// formatByteSize formats the given number of bytes in the Elasticsearch format, such as 1.5gb.
+func formatByteSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%db", bytes)
+	}
+	size := float64(bytes)
+	unit := ""
+	for _, u := range byteSizeUnits {
+		if size < 1024 {
+			break
+		}
+		size /= 1024
+		unit = u
+	}
+	return fmt.Sprintf("%.1f%s", size, unit)
+}
@@ -0,0 +1,277 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+)
+
+func Test_dataMigrationStatus(t *testing.T) {
+	start := time.Date(2020, 3, 4, 5, 0, 0, 0, time.UTC)
+	now := start.Add(10 * time.Minute)
+	eta := metav1.NewTime(now.Add(30 * time.Minute))
+	tests := []struct {
+		name      string
+		previous  []esv1.NodeDataMigration
+		remaining map[string]migration.RemainingData
+		want      []esv1.NodeDataMigration
+	}{
+		{
+			name:      "migration starts",
+			remaining: map[string]migration.RemainingData{"b": {Shards: 4, Bytes: 400}, "a": {Shards: 2, Bytes: 200}},
+			want: []esv1.NodeDataMigration{
+				{Node: "b", RemainingShards: 4, RemainingBytes: 400, InitialBytes: 400, StartTime: metav1.NewTime(now), LastProgressTime: metav1.NewTime(now)},
+				{Node: "a", RemainingShards: 2, RemainingBytes: 200, InitialBytes: 200, StartTime: metav1.NewTime(now), LastProgressTime: metav1.NewTime(now)},
+			},
+		},
+		{
+			name: "migration progresses",
+			previous: []esv1.NodeDataMigration{
+				{Node: "b", RemainingShards: 4, RemainingBytes: 400, InitialBytes: 400, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+			},
+			remaining: map[string]migration.RemainingData{"b": {Shards: 3, Bytes: 300}},
+			want: []esv1.NodeDataMigration{
+				{Node: "b", RemainingShards: 3, RemainingBytes: 300, InitialBytes: 400, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(now), EstimatedCompletionTime: &eta},
+			},
+		},
+		{
+			name: "migration stalled",
+			previous: []esv1.NodeDataMigration{
+				{Node: "b", RemainingShards: 4, RemainingBytes: 400, InitialBytes: 400, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+			},
+			remaining: map[string]migration.RemainingData{"b": {Shards: 4, Bytes: 450}},
+			want: []esv1.NodeDataMigration{
+				{Node: "b", RemainingShards: 4, RemainingBytes: 450, InitialBytes: 450, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+			},
+		},
+		{
+			name: "migration over",
+			previous: []esv1.NodeDataMigration{
+				{Node: "b", RemainingShards: 1, RemainingBytes: 100, InitialBytes: 400, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+			},
+			remaining: map[string]migration.RemainingData{"b": {}},
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dataMigrationStatus(tt.previous, []string{"b", "a"}, tt.remaining, now)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_dataMigrationEvents(t *testing.T) {
+	start := time.Date(2020, 3, 4, 5, 0, 0, 0, time.UTC)
+	now := start.Add(15 * time.Minute)
+	eta := metav1.NewTime(now.Add(30 * time.Minute))
+	previous := []esv1.NodeDataMigration{
+		{Node: "progressing", RemainingShards: 4, RemainingBytes: 4 << 30, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+		{Node: "stalled", RemainingShards: 2, RemainingBytes: 2048, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+		{Node: "slow", RemainingShards: 2, RemainingBytes: 2048, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(now.Add(-time.Minute))},
+	}
+	current := []esv1.NodeDataMigration{
+		{Node: "progressing", RemainingShards: 3, RemainingBytes: 3 << 29, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(now), EstimatedCompletionTime: &eta},
+		previous[1],
+		previous[2],
+		{Node: "new", RemainingShards: 1, RemainingBytes: 512, StartTime: metav1.NewTime(now), LastProgressTime: metav1.NewTime(now)},
+	}
+	got := dataMigrationEvents(previous, current, now)
+	require.Len(t, got, 3)
+	require.Equal(t, corev1.EventTypeNormal, got[0].EventType)
+	require.Equal(t, "Data migration away from node progressing: 3 shards (1.5gb) remaining, estimated completion at 2020-03-04T05:45:00Z", got[0].Message)
+	require.Equal(t, corev1.EventTypeWarning, got[1].EventType)
+	require.Equal(t, "No shard moved away from node stalled since 2020-03-04T05:00:00Z, check the allocation explanation of its 2 shards", got[1].Message)
+	require.Equal(t, corev1.EventTypeNormal, got[2].EventType)
+	require.Equal(t, "Migrating 1 shards (512b) away from node new", got[2].Message)
+}
+
+func Test_applyDataMigrationTimeout(t *testing.T) {
+	start := time.Date(2020, 3, 4, 5, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+	inProgress := esv1.NodeDataMigration{Node: "a", RemainingShards: 2, RemainingBytes: 200, InitialBytes: 400, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)}
+	withAction := func(action esv1.DataMigrationTimeoutAction, generation int64) esv1.NodeDataMigration {
+		progress := inProgress
+		progress.TimeoutAction = action
+		progress.TimeoutGeneration = generation
+		return progress
+	}
+	tests := []struct {
+		name          string
+		status        esv1.NodeDataMigration
+		policy        *esv1.DataMigrationPolicy
+		want          esv1.NodeDataMigration
+		wantEventType string
+		wantReason    string
+	}{
+		{
+			name:   "no policy",
+			status: inProgress,
+			want:   inProgress,
+		},
+		{
+			name:   "timeout not reached",
+			status: inProgress,
+			policy: &esv1.DataMigrationPolicy{Timeout: metav1.Duration{Duration: 2 * time.Hour}},
+			want:   inProgress,
+		},
+		{
+			name:          "timeout reached: abort by default",
+			status:        inProgress,
+			policy:        &esv1.DataMigrationPolicy{Timeout: metav1.Duration{Duration: time.Hour}},
+			want:          withAction(esv1.AbortDataMigration, 3),
+			wantEventType: corev1.EventTypeWarning,
+			wantReason:    ReasonDataMigrationAborted,
+		},
+		{
+			name:          "timeout reached: proceed",
+			status:        inProgress,
+			policy:        &esv1.DataMigrationPolicy{Timeout: metav1.Duration{Duration: time.Hour}, OnTimeout: esv1.ProceedWithDataMigration},
+			want:          withAction(esv1.ProceedWithDataMigration, 3),
+			wantEventType: corev1.EventTypeWarning,
+			wantReason:    ReasonDataMigrationForced,
+		},
+		{
+			name:       "already aborted for the current generation",
+			status:     withAction(esv1.AbortDataMigration, 3),
+			policy:     &esv1.DataMigrationPolicy{Timeout: metav1.Duration{Duration: time.Hour}},
+			want:       withAction(esv1.AbortDataMigration, 3),
+			wantReason: ReasonDataMigrationAborted,
+		},
+		{
+			name:   "aborted for a previous generation: start again",
+			status: withAction(esv1.AbortDataMigration, 2),
+			policy: &esv1.DataMigrationPolicy{Timeout: metav1.Duration{Duration: time.Hour}},
+			want: esv1.NodeDataMigration{
+				Node: "a", RemainingShards: 2, RemainingBytes: 200, InitialBytes: 200, StartTime: metav1.NewTime(now), LastProgressTime: metav1.NewTime(now),
+			},
+			wantEventType: corev1.EventTypeNormal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotEvents := applyDataMigrationTimeout([]esv1.NodeDataMigration{tt.status}, tt.policy, 3, now)
+			require.Equal(t, []esv1.NodeDataMigration{tt.want}, got)
+			condition := dataMigrationCondition(got)
+			if tt.wantReason == "" {
+				require.Nil(t, condition)
+			} else {
+				require.NotNil(t, condition)
+				require.Equal(t, DataMigrationConditionType, condition.Type)
+				require.Equal(t, corev1.ConditionTrue, condition.Status)
+				require.Equal(t, tt.wantReason, condition.Reason)
+			}
+			if tt.wantEventType == "" {
+				require.Empty(t, gotEvents)
+				return
+			}
+			require.Len(t, gotEvents, 1)
+			require.Equal(t, tt.wantEventType, gotEvents[0].EventType)
+		})
+	}
+}
+
+func Test_dataMigrationCondition_mixedActions(t *testing.T) {
+	condition := dataMigrationCondition([]esv1.NodeDataMigration{
+		{Node: "a", TimeoutAction: esv1.AbortDataMigration},
+		{Node: "b"},
+		{Node: "c", TimeoutAction: esv1.ProceedWithDataMigration},
+	})
+	require.NotNil(t, condition)
+	require.Equal(t, ReasonDataMigrationTimedOut, condition.Reason)
+	require.Equal(t, "Data migration timed out, keeping the nodes until the specification changes: a. "+
+		"Data migration timed out, removing the nodes with shards remaining: c", condition.Message)
+}
+
+func Test_reportDataMigration_condition(t *testing.T) {
+	start := time.Date(2020, 3, 4, 5, 0, 0, 0, time.UTC)
+	es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{UpdateStrategy: esv1.UpdateStrategy{
+		DataMigration: &esv1.DataMigrationPolicy{Timeout: metav1.Duration{Duration: time.Hour}},
+	}}}
+	state := reconcile.NewState(es)
+	state.UpdateDataMigration([]esv1.NodeDataMigration{
+		{Node: "a", RemainingShards: 1, StartTime: metav1.NewTime(start), LastProgressTime: metav1.NewTime(start)},
+	})
+	ctx := downscaleContext{
+		es:             es,
+		reconcileState: state,
+		shardLister:    migration.NewFakeShardLister(esclient.Shards{{Index: "index-1", Shard: "0", State: esclient.STARTED, NodeName: "a"}}),
+		parentCtx:      context.Background(),
+	}
+
+	// the data migration timed out and is aborted
+	reportDataMigration(ctx, []string{"a"})
+	condition := state.Conditions().Get(DataMigrationConditionType)
+	require.NotNil(t, condition)
+	require.Equal(t, ReasonDataMigrationAborted, condition.Reason)
+
+	// the condition is removed once no node is leaving
+	reportDataMigration(ctx, nil)
+	require.Nil(t, state.Conditions().Get(DataMigrationConditionType))
+}
+
+func Test_calculatePerformableDownscale_DataMigrationTimeout(t *testing.T) {
+	shardLister := migration.NewFakeShardLister(esclient.Shards{
+		{Index: "index-1", Shard: "0", State: esclient.STARTED, NodeName: "ssetData4Replicas-3"},
+		{Index: "index-1", Shard: "1", State: esclient.STARTED, NodeName: "ssetData4Replicas-2"},
+	})
+	downscale := ssetDownscale{statefulSet: ssetData4Replicas, initialReplicas: 4, targetReplicas: 2, finalReplicas: 2}
+	tests := []struct {
+		name    string
+		actions map[string]esv1.DataMigrationTimeoutAction
+		want    int32
+	}{
+		{
+			name: "data migration in progress",
+			want: 4,
+		},
+		{
+			name:    "data migration aborted",
+			actions: map[string]esv1.DataMigrationTimeoutAction{"ssetData4Replicas-3": esv1.AbortDataMigration},
+			want:    4,
+		},
+		{
+			name: "proceed with the first node, abort the second one",
+			actions: map[string]esv1.DataMigrationTimeoutAction{
+				"ssetData4Replicas-3": esv1.ProceedWithDataMigration,
+				"ssetData4Replicas-2": esv1.AbortDataMigration,
+			},
+			want: 3,
+		},
+		{
+			name: "proceed with both nodes",
+			actions: map[string]esv1.DataMigrationTimeoutAction{
+				"ssetData4Replicas-3": esv1.ProceedWithDataMigration,
+				"ssetData4Replicas-2": esv1.ProceedWithDataMigration,
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status []esv1.NodeDataMigration
+			for node, action := range tt.actions {
+				status = append(status, esv1.NodeDataMigration{Node: node, RemainingShards: 1, TimeoutAction: action})
+			}
+			state := reconcile.NewState(esv1.Elasticsearch{})
+			state.UpdateDataMigration(status)
+			ctx := downscaleContext{shardLister: shardLister, reconcileState: state}
+			got, err := calculatePerformableDownscale(ctx, downscale)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.targetReplicas)
+		})
+	}
+}
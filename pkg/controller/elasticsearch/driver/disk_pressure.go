@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/report"
+)
+
+// maxIndicesPathLength bounds the length of the comma-separated index names of a request releasing read-only blocks,
+// to stay below the 4kb default of http.max_initial_line_length in Elasticsearch.
+const maxIndicesPathLength = 3000
+
+// diskPressureRelieved returns true if no data node of the observed cluster exceeds the high disk watermark, which is
+// the condition Elasticsearch 7.4 and later release the read-only blocks under. It is false if the disk usage could
+// not be observed.
+func diskPressureRelieved(state observer.State) bool {
+	disk := report.DiskUsage(state)
+	return disk != nil && disk.Watermark.Less(esv1.DiskWatermarkHigh)
+}
+
+// reconcileDiskPressure reports the indices made read-only by the flood-stage disk watermark in the status, with an
+// event each time their number changes, and releases the read-only blocks once the disk pressure is relieved if the
+// specification allows it.
+func (d *defaultDriver) reconcileDiskPressure(ctx context.Context, esClient esclient.Client, state observer.State) error {
+	if state.IndexBlocks == nil {
+		// keep reporting the last observation
+		return nil
+	}
+	blocked := len(state.IndexBlocks.ReadOnlyAllowDelete)
+	previous := d.ReconcileState.ReadOnlyIndices()
+	d.ReconcileState.UpdateReadOnlyIndices(blocked)
+
+	if blocked == 0 {
+		if previous > 0 {
+			d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonStateChange, "No index is read-only because of the flood-stage disk watermark anymore")
+		}
+		return nil
+	}
+
+	relieved := diskPressureRelieved(state)
+	if relieved && d.ES.Spec.DiskPressure.ReleasesReadOnlyBlocks() {
+		log.Info("Releasing read-only index blocks",
+			"namespace", d.ES.Namespace, "es_name", d.ES.Name, "indices", blocked)
+		// only release the blocks observed, rather than resetting the setting of all the indices
+		for _, indices := range indexBatches(state.IndexBlocks.ReadOnlyAllowDelete, maxIndicesPathLength) {
+			err := esClient.UpdateIndexSettings(ctx, indices, map[string]interface{}{esclient.ReadOnlyAllowDeleteSetting: nil})
+			if err != nil {
+				return errors.Wrap(err, "while releasing the read-only index blocks")
+			}
+		}
+		d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonStateChange,
+			fmt.Sprintf("Released the read-only block of %d indices, the disk pressure is relieved", blocked))
+		d.ReconcileState.UpdateReadOnlyIndices(0)
+		return nil
+	}
+
+	if blocked == previous {
+		return nil
+	}
+	msg := fmt.Sprintf("%d indices are read-only because a data node exceeds the flood-stage disk watermark, "+
+		"free up disk space or increase the storage of the data nodes", blocked)
+	if relieved {
+		msg = fmt.Sprintf("%d indices are read-only although no data node exceeds the high disk watermark anymore, "+
+			"remove the %s index setting or enable spec.diskPressure.releaseReadOnlyBlocks", blocked, esclient.ReadOnlyAllowDeleteSetting)
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnhealthy, msg)
+	return nil
+}
+
+// indexBatches splits the given index names into batches whose comma-separated names do not exceed the given
+// length, unless a single name does.
+func indexBatches(indices []string, maxLength int) [][]string {
+	var batches [][]string
+	var batch []string
+	length := 0
+	for _, index := range indices {
+		if len(batch) > 0 && length+1+len(index) > maxLength {
+			batches = append(batches, batch)
+			batch = nil
+			length = 0
+		}
+		if len(batch) > 0 {
+			length++
+		}
+		batch = append(batch, index)
+		length += len(index)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
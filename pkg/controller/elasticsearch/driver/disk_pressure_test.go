@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+)
+
+func diskPressureState(blocked []string, availablePercent int64) observer.State {
+	var node esclient.NodeStats
+	node.Name = "data"
+	node.Roles = []string{"data"}
+	node.FS.Total.TotalInBytes = 100
+	node.FS.Total.AvailableInBytes = availablePercent
+	return observer.State{
+		IndexBlocks: &esclient.IndexBlocks{ReadOnlyAllowDelete: blocked},
+		NodesStats:  &esclient.NodesStats{Nodes: map[string]esclient.NodeStats{"data": node}},
+		CapacitySettings: &esclient.CapacitySettings{
+			DiskWatermarkLow:        "85%",
+			DiskWatermarkHigh:       "90%",
+			DiskWatermarkFloodStage: "95%",
+		},
+	}
+}
+
+func Test_defaultDriver_reconcileDiskPressure(t *testing.T) {
+	tests := []struct {
+		name          string
+		release       bool
+		previous      int
+		state         observer.State
+		wantReleased  bool
+		wantReadOnly  int
+		wantEventType string
+	}{
+		{
+			name:         "index blocks not observed: keep the previous status",
+			previous:     2,
+			state:        observer.State{},
+			wantReadOnly: 2,
+		},
+		{
+			name:  "no blocked index",
+			state: diskPressureState(nil, 50),
+		},
+		{
+			name:          "blocked indices are not read-only anymore",
+			previous:      2,
+			state:         diskPressureState(nil, 50),
+			wantEventType: corev1.EventTypeNormal,
+		},
+		{
+			name:          "flood-stage disk watermark exceeded",
+			release:       true,
+			state:         diskPressureState([]string{"logs"}, 3),
+			wantReadOnly:  1,
+			wantEventType: corev1.EventTypeWarning,
+		},
+		{
+			name:         "number of blocked indices unchanged: no event",
+			release:      true,
+			previous:     1,
+			state:        diskPressureState([]string{"logs"}, 3),
+			wantReadOnly: 1,
+		},
+		{
+			name:         "high disk watermark still exceeded: keep the blocks",
+			release:      true,
+			previous:     1,
+			state:        diskPressureState([]string{"logs"}, 8),
+			wantReadOnly: 1,
+		},
+		{
+			name:          "disk pressure relieved: release the blocks",
+			release:       true,
+			previous:      1,
+			state:         diskPressureState([]string{"logs", "metrics"}, 20),
+			wantReleased:  true,
+			wantEventType: corev1.EventTypeNormal,
+		},
+		{
+			name:          "disk pressure relieved but release disabled",
+			state:         diskPressureState([]string{"logs", "metrics"}, 20),
+			wantReadOnly:  2,
+			wantEventType: corev1.EventTypeWarning,
+		},
+		{
+			name:         "disk usage not observed: keep the blocks",
+			release:      true,
+			previous:     1,
+			state:        observer.State{IndexBlocks: &esclient.IndexBlocks{ReadOnlyAllowDelete: []string{"logs"}}},
+			wantReadOnly: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{
+				Spec:   esv1.ElasticsearchSpec{DiskPressure: &esv1.DiskPressure{ReleaseReadOnlyBlocks: tt.release}},
+				Status: esv1.ElasticsearchStatus{ReadOnlyIndices: tt.previous},
+			}
+			released := false
+			esClient := esclient.NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
+				require.Equal(t, http.MethodPut, req.Method)
+				// only the blocked indices are released
+				require.Equal(t, "/logs,metrics/_settings", req.URL.Path)
+				body, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, `{"index.blocks.read_only_allow_delete":null}`, string(body))
+				released = true
+				return esclient.NewMockResponse(200, req, `{}`)
+			})
+			d := &defaultDriver{DefaultDriverParameters{ES: es, ReconcileState: reconcile.NewState(es)}}
+
+			require.NoError(t, d.reconcileDiskPressure(context.Background(), esClient, tt.state))
+			require.Equal(t, tt.wantReleased, released)
+			require.Equal(t, tt.wantReadOnly, d.ReconcileState.ReadOnlyIndices())
+			recorded := d.ReconcileState.Events()
+			if tt.wantEventType == "" {
+				require.Empty(t, recorded)
+				return
+			}
+			require.Len(t, recorded, 1)
+			require.Equal(t, tt.wantEventType, recorded[0].EventType)
+		})
+	}
+}
+
+func Test_indexBatches(t *testing.T) {
+	require.Nil(t, indexBatches(nil, 10))
+	require.Equal(t, [][]string{{"a", "bb", "ccc"}}, indexBatches([]string{"a", "bb", "ccc"}, 8))
+	require.Equal(t, [][]string{{"a", "bb"}, {"ccc"}}, indexBatches([]string{"a", "bb", "ccc"}, 7))
+	// a name longer than the maximum length gets its own batch
+	require.Equal(t, [][]string{{"a"}, {"longer-than-max"}, {"b"}}, indexBatches([]string{"a", "longer-than-max", "b"}, 5))
+}
@@ -33,8 +33,9 @@ func HandleDownscale(
 ) *reconciler.Results {
 	results := &reconciler.Results{}
 
-	// make sure we only downscale nodes we're allowed to
-	downscaleState, err := newDownscaleState(downscaleCtx.k8sClient, downscaleCtx.es)
+	// make sure we only downscale nodes we're allowed to, relative to the expected node count rather than the one of the
+	// specification, which scheduled scaling windows override
+	downscaleState, err := newDownscaleState(downscaleCtx.k8sClient, downscaleCtx.es, expectedStatefulSets.ExpectedNodeCount())
 	if err != nil {
 		return results.WithError(err)
 	}
@@ -51,6 +52,18 @@ func HandleDownscale(
 	// migrate data away from nodes that should be removed
 	// if leavingNodes is empty, it clears any existing settings
 	leavingNodes := leavingNodeNames(downscales)
+	reportDataMigration(downscaleCtx, leavingNodes)
+	// shards can be allocated again to the nodes whose data migration was aborted
+	leavingNodes = withoutAbortedDataMigrations(downscaleCtx.reconcileState.DataMigration(), leavingNodes)
+	// so is the data of the nodes to replace
+	leavingNodes = withNodesToReplace(leavingNodes, downscaleCtx.resourcesState.CurrentPods)
+	// and of the nodes on Kubernetes nodes about to be drained or preempted
+	if downscaleCtx.leavingHosts.Enabled() {
+		leavingNodes, err = withNodesOnLeavingHosts(downscaleCtx, leavingNodes)
+		if err != nil {
+			return results.WithError(err)
+		}
+	}
 	if err := migration.MigrateData(downscaleCtx.parentCtx, downscaleCtx.k8sClient, downscaleCtx.es, downscaleCtx.esClient, leavingNodes); err != nil {
 		return results.WithError(err)
 	}
@@ -142,6 +155,8 @@ func attemptDownscale(
 		// no downscale can be performed for now, let's requeue
 		return true, nil
 	}
+	// warm up the caches of the nodes the data was migrated to before removing the nodes
+	warmUpCaches(ctx, performable)
 	// do performable downscale, and requeue if needed
 	shouldRequeue := performable.targetReplicas != downscale.finalReplicas
 	return shouldRequeue, doDownscale(ctx, performable, statefulSets)
@@ -181,6 +196,15 @@ func calculatePerformableDownscale(
 	}
 	// iterate on all leaving nodes (ordered by highest ordinal first)
 	for _, node := range downscale.leavingNodeNames() {
+		switch dataMigrationTimeoutAction(ctx.reconcileState.DataMigration(), node) {
+		case esv1.AbortDataMigration:
+			ssetLogger(downscale.statefulSet).Info("Data migration timed out, keeping the node", "node", node)
+			return performableDownscale, nil
+		case esv1.ProceedWithDataMigration:
+			ssetLogger(downscale.statefulSet).Info("Data migration timed out, starting node deletion", "node", node)
+			performableDownscale.targetReplicas--
+			continue
+		}
 		migrating, err := migration.IsMigratingData(ctx.parentCtx, ctx.shardLister, node)
 		if err != nil {
 			return performableDownscale, err
@@ -52,8 +52,8 @@ type downscaleState struct {
 	masterRemovalInProgress bool
 }
 
-// newDownscaleState creates a new downscaleState.
-func newDownscaleState(c k8s.Client, es esv1.Elasticsearch) (*downscaleState, error) {
+// newDownscaleState creates a new downscaleState, for a cluster expected to run the given number of nodes.
+func newDownscaleState(c k8s.Client, es esv1.Elasticsearch, expectedNodes int32) (*downscaleState, error) {
 	// retrieve the number of masters running ready
 	actualPods, err := sset.GetActualPodsForCluster(c, es)
 	if err != nil {
@@ -67,7 +67,7 @@ func newDownscaleState(c k8s.Client, es esv1.Elasticsearch) (*downscaleState, er
 		runningMasters:          len(mastersReady),
 		removalsAllowed: calculateRemovalsAllowed(
 			int32(len(nodesReady)),
-			es.Spec.NodeCount(),
+			expectedNodes,
 			es.Spec.UpdateStrategy.ChangeBudget.GetMaxUnavailableOrDefault()),
 	}, nil
 }
@@ -10,6 +10,7 @@ import (
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/pointer"
 	"github.com/stretchr/testify/require"
@@ -127,7 +128,7 @@ func Test_newDownscaleState(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			k8sClient := k8s.WrappedFakeClient(tt.initialResources...)
-			got, err := newDownscaleState(k8sClient, es)
+			got, err := newDownscaleState(k8sClient, es, es.Spec.NodeCount())
 			require.NoError(t, err)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewDownscaleInvariants() got = %v, want %v", got, tt.want)
@@ -136,6 +137,31 @@ func Test_newDownscaleState(t *testing.T) {
 	}
 }
 
+func Test_newDownscaleState_expectedNodes(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: clusterName},
+		Spec:       esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{{Count: 4}}},
+	}
+	var pods []runtime.Object
+	for i := int32(0); i < 4; i++ {
+		pod := sset.TestPod{
+			Namespace:       "ns",
+			Name:            sset.PodName("data", i),
+			StatefulSetName: "data",
+			ClusterName:     clusterName,
+			Ready:           true,
+		}.Build()
+		pods = append(pods, &pod)
+	}
+	k8sClient := k8s.WrappedFakeClient(pods...)
+
+	// the removals are allowed relative to the expected nodes, such as the count of a scaling window, rather than the
+	// count of the specification
+	got, err := newDownscaleState(k8sClient, es, 2)
+	require.NoError(t, err)
+	require.Equal(t, pointer.Int32(3), got.removalsAllowed)
+}
+
 func Test_calculateRemovalsAllowed(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -144,6 +144,9 @@ func TestHandleDownscale(t *testing.T) {
 	k8sClient := k8s.WrappedFakeClient(runtimeObjs...)
 	esClient := &fakeESClient{}
 	actualStatefulSets := sset.StatefulSetList{ssetMaster3Replicas, ssetData4Replicas}
+	// the downscale is not limited by the change budget here, which is tested with the downscale invariants
+	unboundedES := *es.DeepCopy()
+	unboundedES.Spec.UpdateStrategy.ChangeBudget.MaxUnavailable = pointer.Int32(-1)
 	downscaleCtx := downscaleContext{
 		k8sClient:      k8sClient,
 		expectations:   expectations.NewExpectations(k8sClient),
@@ -154,7 +157,7 @@ func TestHandleDownscale(t *testing.T) {
 			},
 		),
 		esClient:  esClient,
-		es:        es,
+		es:        unboundedES,
 		parentCtx: context.Background(),
 	}
 
@@ -668,6 +671,9 @@ func Test_calculatePerformableDownscale(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.ctx.reconcileState == nil {
+				tt.args.ctx.reconcileState = reconcile.NewState(esv1.Elasticsearch{})
+			}
 			got, err := calculatePerformableDownscale(tt.args.ctx, tt.args.downscale)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("calculatePerformableDownscale() error = %v, wantErr %v", err, tt.wantErr)
@@ -977,7 +983,7 @@ func Test_doDownscale_zen1MinimumMasterNodes(t *testing.T) {
 func Test_deleteStatefulSetResources(t *testing.T) {
 	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "cluster"}}
 	sset := sset.TestSset{Namespace: "ns", Name: "sset", ClusterName: es.Name}.Build()
-	cfg := settings.ConfigSecret(es, sset.Name, []byte("fake config data"))
+	cfg := settings.ConfigSecret(es, sset.Name, []byte("fake config data"), nil)
 	svc := nodespec.HeadlessService(k8s.ExtractNamespacedName(&es), sset.Name)
 
 	tests := []struct {
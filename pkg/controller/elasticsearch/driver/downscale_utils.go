@@ -9,6 +9,7 @@ import (
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
@@ -31,6 +32,10 @@ type downscaleContext struct {
 	expectations   *expectations.Expectations
 	// ES cluster
 	es esv1.Elasticsearch
+	// esVersion is the lowest version of the running nodes
+	esVersion version.Version
+	// leavingHosts identifies the Kubernetes nodes to migrate the data of the nodes away from
+	leavingHosts LeavingHosts
 
 	parentCtx context.Context
 }
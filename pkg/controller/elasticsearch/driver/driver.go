@@ -16,15 +16,21 @@ import (
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	commondriver "github.com/elastic/cloud-on-k8s/pkg/controller/common/driver"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/dryrun"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	commonlicense "github.com/elastic/cloud-on-k8s/pkg/controller/common/license"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/adoption"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/audit"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/bootstrap"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/cleanup"
@@ -41,6 +47,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
 	esversion "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/version"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
 )
 
 var (
@@ -77,6 +84,8 @@ type DefaultDriverParameters struct {
 
 	// LicenseChecker is used for some features to check if an appropriate license is setup
 	LicenseChecker commonlicense.Checker
+	// AccessReviewer checks that the cross-namespace renames are allowed
+	AccessReviewer rbac.AccessReviewer
 
 	// State holds the accumulated state during the reconcile loop
 	ReconcileState *reconcile.State
@@ -87,6 +96,11 @@ type DefaultDriverParameters struct {
 	// Expectations control some expectations set on resources in the cache, in order to
 	// avoid doing certain operations if the cache hasn't seen an up-to-date resource yet.
 	Expectations *expectations.Expectations
+	// DryRun records the state-changing Elasticsearch requests instead of performing them, if set.
+	// The Client and Credentials are expected to record their mutations in the same Changes.
+	DryRun *dryrun.Changes
+	// Credentials persist the generated passwords and CAs in the external credentials store, credentials.Default if nil.
+	Credentials credentials.Credentials
 }
 
 // defaultDriver is the default Driver implementation
@@ -106,22 +120,60 @@ func (d *defaultDriver) Recorder() record.EventRecorder {
 	return d.DefaultDriverParameters.Recorder
 }
 
+// credentials returns the Credentials persisting the generated passwords and CAs.
+func (d *defaultDriver) credentials() credentials.Credentials {
+	if d.Credentials == nil {
+		return credentials.Default
+	}
+	return d.Credentials
+}
+
 var _ commondriver.Interface = &defaultDriver{}
 
 // Reconcile fulfills the Driver interface and reconciles the cluster resources.
 func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 	results := reconciler.NewResult(ctx)
 
-	// garbage collect secrets attached to this cluster that we don't need anymore
-	if err := cleanup.DeleteOrphanedSecrets(ctx, d.Client, d.ES); err != nil {
+	// adopt the existing resources of the cluster first, before the Kubernetes garbage collector deletes them
+	adoptionResult, err := d.adoptResources()
+	if err != nil {
 		return results.WithError(err)
 	}
 
-	if err := configmap.ReconcileScriptsConfigMap(ctx, d.Client, d.ES); err != nil {
+	// take over the volumes of a renamed cluster before creating any resource, not to create a new empty cluster
+	waitingForRename, err := d.reconcileRename()
+	if err != nil {
 		return results.WithError(err)
 	}
+	if waitingForRename {
+		return results.WithResult(defaultRequeue)
+	}
 
-	_, err := common.ReconcileService(ctx, d.Client, services.NewTransportService(d.ES), &d.ES)
+	// garbage collect resources attached to this cluster that we don't need anymore
+	if err := cleanup.DeleteOrphanedResources(ctx, d.Client, d.ES, cleanup.Params{
+		GracePeriod: d.OperatorParameters.GCGracePeriod,
+		DryRun:      d.OperatorParameters.GCDryRun,
+	}); err != nil {
+		return results.WithError(err)
+	}
+
+	// the restricted SCC of OpenShift, and the restricted Pod Security Standard, run the Pods as a non-root user, which
+	// cannot chown the volumes
+	chownVolumes := !d.OperatorParameters.OpenShift && !podsecurity.IsRestricted(d.OperatorParameters.PodSecurityStandard, &d.ES)
+	if err := configmap.ReconcileScriptsConfigMap(ctx, d.Client, d.ES, chownVolumes); err != nil {
+		return results.WithError(err)
+	}
+
+	if err := audit.ReconcileBeatConfig(ctx, d.Client, d.ES); err != nil {
+		return results.WithError(err)
+	}
+
+	// Pods referencing a missing priority class are rejected
+	if err := d.reconcilePriorityClasses(); err != nil {
+		return results.WithError(err)
+	}
+
+	_, err = common.ReconcileService(ctx, d.Client, services.NewTransportService(d.ES), &d.ES)
 	if err != nil {
 		return results.WithError(err)
 	}
@@ -130,24 +182,25 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 	if err != nil {
 		return results.WithError(err)
 	}
+	if d.OperatorParameters.OpenShift {
+		if err := openshift.ReconcileRoute(d.Client, *externalService, d.ES.Spec.EffectiveHTTP().TLS.Enabled(), &d.ES); err != nil {
+			return results.WithError(err)
+		}
+	}
 
 	certificateResources, res := certificates.Reconcile(
 		ctx,
 		d,
+		d.credentials(),
 		d.ES,
 		[]corev1.Service{*externalService},
-		d.OperatorParameters.CACertRotation,
-		d.OperatorParameters.CertRotation,
+		d.OperatorParameters.GetCACertRotation(),
+		d.OperatorParameters.GetCertRotation(),
 	)
 	if results.WithResults(res).HasError() {
 		return results
 	}
 
-	controllerUser, err := user.ReconcileUsersAndRoles(ctx, d.Client, d.ES, d.DynamicWatches(), d.Recorder())
-	if err != nil {
-		return results.WithError(err)
-	}
-
 	resourcesState, err := reconcile.NewResourcesStateFromAPI(d.Client, d.ES)
 	if err != nil {
 		return results.WithError(err)
@@ -160,9 +213,32 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 		min = &d.Version
 	}
 
+	controllerUser, usersResult, err := user.ReconcileUsersAndRoles(
+		ctx,
+		d.Client,
+		d.credentials(),
+		d.ES,
+		d.DynamicWatches(),
+		d.Recorder(),
+		d.passwordVerifier(ctx, resourcesState, *min, certificateResources.TrustedHTTPCertificates),
+	)
+	if err != nil {
+		return results.WithError(err)
+	}
+	// requeue for the passwords to be rotated
+	results.WithResult(usersResult)
+	results.WithResults(d.trackUpgrade(*min, time.Now()))
+
 	warnUnsupportedDistro(resourcesState.AllPods, d.ReconcileState.Recorder)
+	d.reconcileVirtualMemoryCondition(resourcesState.CurrentPods)
+	d.reconcileQuotaCondition()
 
-	observedState := d.Observers.ObservedStateResolver(
+	if err := d.reconcileSuspendedPods(resourcesState.CurrentPods); err != nil {
+		return results.WithError(err)
+	}
+	results.WithResults(d.reconcileUnsafeRecovery(*min, resourcesState.CurrentPods))
+
+	clusterObserver := d.Observers.Observe(
 		k8s.ExtractNamespacedName(&d.ES),
 		d.newElasticsearchClient(
 			resourcesState,
@@ -171,9 +247,22 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 			certificateResources.TrustedHTTPCertificates,
 		),
 	)
+	clusterObserver.SetCriticalIndices(d.ES.Spec.CriticalIndices)
+	replicationObserved, err := remotecluster.ReplicationEnabled(d.LicenseChecker, d.ES)
+	if err != nil {
+		// the replication stats are optional, do not block the reconciliation of the cluster on the license check
+		log.Error(err, "Failed to check whether cross-cluster replication is enabled, not observing the replication stats",
+			"namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		replicationObserved = false
+	}
+	clusterObserver.SetReplicationObserved(replicationObserved)
+	clusterObserver.SetLoggingObserved(loggingObserved(d.ES.Spec.Logging, d.ReconcileState.Logging()))
+	observedState := clusterObserver.LastState()
 
 	// always update the elasticsearch state bits
 	d.ReconcileState.UpdateElasticsearchState(*resourcesState, observedState)
+	d.reconcileReachableCondition(observedState)
+	d.reconcileCriticalIndicesCondition(observedState)
 
 	if err := d.verifySupportsExistingPods(resourcesState.CurrentPods); err != nil {
 		return results.WithError(err)
@@ -187,6 +276,9 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 		certificateResources.TrustedHTTPCertificates,
 	)
 	defer esClient.Close()
+	if d.DryRun != nil {
+		esClient = esclient.DryRun(esClient, d.DryRun.RecordRequest)
+	}
 
 	esReachable, err := services.IsServiceReady(d.Client, *externalService)
 	if err != nil {
@@ -228,14 +320,69 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 			log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
 			results.WithResult(defaultRequeue)
 		}
+
+		err = remotecluster.UpdateReplication(ctx, d.Client, esClient, d.Recorder(), d.LicenseChecker, d.ES)
+		if err != nil {
+			msg := "Could not update cross-cluster replication"
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+			log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+			results.WithResult(defaultRequeue)
+		}
+
+		policyResult, err := d.reconcileStackConfigPolicy(ctx, esClient)
+		if err != nil {
+			msg := "Could not apply StackConfigPolicy"
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+			log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+			results.WithResult(defaultRequeue)
+		}
+		results.WithResult(policyResult)
+
+		loggingResult, err := d.reconcileLogging(ctx, esClient, observedState.LoggingSettings)
+		if err != nil {
+			msg := "Could not apply the logging settings"
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+			log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+			results.WithResult(defaultRequeue)
+		}
+		results.WithResult(loggingResult)
+
+		if err := d.reconcileRuntimeConfig(ctx, esClient); err != nil {
+			msg := "Could not apply the runtime configuration"
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+			log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+			results.WithResult(defaultRequeue)
+		}
+
+		if err := d.reconcileDiskPressure(ctx, esClient, observedState); err != nil {
+			msg := "Could not release the read-only index blocks"
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+			log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+			results.WithResult(defaultRequeue)
+		}
+
+		results.WithResult(d.reconcileRetentionJobs(ctx, esClient))
 	}
 
+	analysisFilesResult, err := d.reconcileAnalysisFiles(ctx, esClient, esReachable, *min)
+	if err != nil {
+		msg := "Could not reload the analysis files"
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+		log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		results.WithResult(defaultRequeue)
+	}
+	results.WithResult(analysisFilesResult)
+
 	// Compute seed hosts based on current masters with a podIP
 	if err := settings.UpdateSeedHostsConfigMap(ctx, d.Client, d.ES, resourcesState.AllPods); err != nil {
 		return results.WithError(err)
 	}
 
 	// setup a keystore with secure settings in an init container, if specified by the user
+	if err := d.OperatorParameters.Overlay.ValidateSecureSettings(d.ES.Namespace, d.ES.SecureSettings()); err != nil {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonValidation, err.Error())
+		return results.WithError(err)
+	}
 	keystoreResources, err := keystore.NewResources(
 		d,
 		&d.ES,
@@ -246,6 +393,18 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 	if err != nil {
 		return results.WithError(err)
 	}
+	keystoreResources, err = withKeystoreSidecar(d.ES, keystoreResources)
+	if err != nil {
+		return results.WithError(err)
+	}
+	secureSettingsResult, err := d.reconcileSecureSettingsReload(ctx, esClient, esReachable, keystoreResources)
+	if err != nil {
+		msg := "Could not reload the secure settings"
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("%s: %s", msg, err.Error()))
+		log.Error(err, msg, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		results.WithResult(defaultRequeue)
+	}
+	results.WithResult(secureSettingsResult)
 
 	// set an annotation with the ClusterUUID, if bootstrapped
 	requeue, err := bootstrap.ReconcileClusterUUID(ctx, d.Client, &d.ES, esClient, esReachable)
@@ -256,9 +415,23 @@ func (d *defaultDriver) Reconcile(ctx context.Context) *reconciler.Results {
 		results = results.WithResult(defaultRequeue)
 	}
 
+	if adoptionResult != nil {
+		condition := adoption.Condition(*adoptionResult, bootstrap.AnnotatedForBootstrap(d.ES))
+		d.ReconcileState.UpdateCondition(condition)
+		if condition.Status != corev1.ConditionTrue {
+			// the nodes of an adopted cluster would be set up to bootstrap a new cluster, and restarted, if reconciled
+			// before the cluster is known to be bootstrapped
+			return results.WithResult(defaultRequeue)
+		}
+	}
+
 	// reconcile StatefulSets and nodes configuration
-	res = d.reconcileNodeSpecs(ctx, esReachable, esClient, d.ReconcileState, observedState, *resourcesState, keystoreResources, certificateResources)
+	res = d.reconcileNodeSpecs(ctx, esReachable, esClient, *min, d.ReconcileState, observedState, *resourcesState, keystoreResources, certificateResources)
 	results = results.WithResults(res)
+	// persist the expectations set while reconciling, so that a restarted operator does not lose them
+	if err := d.persistExpectations(); err != nil {
+		results = results.WithError(err)
+	}
 
 	if res.HasError() {
 		return results
@@ -276,7 +449,23 @@ func (d *defaultDriver) newElasticsearchClient(
 	caCerts []*x509.Certificate,
 ) esclient.Client {
 	url := services.ElasticsearchURL(d.ES, state.CurrentPodsByPhase[corev1.PodRunning])
-	return esclient.NewElasticsearchClient(d.OperatorParameters.Dialer, url, user, v, caCerts)
+	return d.newElasticsearchClientForURL(url, user, v, caCerts)
+}
+
+// newElasticsearchClientForURL creates a new Elasticsearch HTTP client for this cluster using the provided URL and user
+func (d *defaultDriver) newElasticsearchClientForURL(
+	url string,
+	user esclient.BasicAuth,
+	v version.Version,
+	caCerts []*x509.Certificate,
+) esclient.Client {
+	esClient := esclient.NewElasticsearchClient(d.OperatorParameters.Dialer, url, user, v, caCerts)
+	esClient = esclient.WithOptions(esClient, esclient.Options{
+		Compression:  d.OperatorParameters.ElasticsearchClientCompression,
+		ReadTimeout:  d.OperatorParameters.ElasticsearchClientReadTimeout,
+		WriteTimeout: d.OperatorParameters.ElasticsearchClientWriteTimeout,
+	})
+	return esclient.WithCluster(esClient, k8s.ExtractNamespacedName(&d.ES))
 }
 
 // warnUnsupportedDistro sends an event of type warning if the Elasticsearch Docker image is not a supported
@@ -0,0 +1,32 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	controller "sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+// withESError adds the given error returned by the Elasticsearch client to the results, depending on its type.
+// An unavailable cluster is expected while it starts or restarts: the reconciliation is retried later without reporting
+// an error. A conflict is caused by a concurrent change: the reconciliation is retried immediately. Any other error is
+// reported, and retried with the usual backoff.
+func withESError(results *reconciler.Results, es esv1.Elasticsearch, err error) *reconciler.Results {
+	switch esclient.TypeOf(err) {
+	case esclient.UnavailableError:
+		log.Info("Elasticsearch cannot be reached yet, re-queuing",
+			"error", err.Error(), "namespace", es.Namespace, "es_name", es.Name)
+		return results.WithResult(defaultRequeue)
+	case esclient.ConflictError:
+		log.V(1).Info("Conflicting Elasticsearch request, re-queuing",
+			"error", err.Error(), "namespace", es.Namespace, "es_name", es.Name)
+		return results.WithResult(controller.Result{Requeue: true})
+	default:
+		return results.WithError(err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	controller "sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+// esError returns the error of a request to a cluster responding with the given status code.
+func esError(t *testing.T, statusCode int) error {
+	c := esclient.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		return esclient.NewMockResponse(statusCode, req, "")
+	})
+	_, err := c.GetClusterInfo(context.Background())
+	require.Error(t, err)
+	return err
+}
+
+func Test_withESError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantResult controller.Result
+		wantErr    bool
+	}{
+		{name: "unavailable", err: esError(t, 503), wantResult: defaultRequeue},
+		{name: "conflict", err: esError(t, 409), wantResult: controller.Result{Requeue: true}},
+		{name: "security exception", err: esError(t, 401), wantErr: true},
+		{name: "other error", err: errors.New("failure"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := withESError(reconciler.NewResult(context.Background()), esv1.Elasticsearch{}, tt.err).Aggregate()
+			require.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				require.Equal(t, tt.wantResult, result)
+			}
+		})
+	}
+}
@@ -21,6 +21,8 @@ type ESState interface {
 	ShardAllocationsEnabled() (bool, error)
 	// Health returns the health of the Elasticsearch cluster.
 	Health() (esv1.ElasticsearchHealth, error)
+	// IndexingTasks returns the transforms and rollup jobs running in the Elasticsearch cluster.
+	IndexingTasks() ([]esclient.IndexingTask, error)
 }
 
 // MemoizingESState requests Elasticsearch for the requested information only once, at first call.
@@ -30,6 +32,7 @@ type MemoizingESState struct {
 	*memoizingNodes
 	*memoizingShardsAllocationEnabled
 	*memoizingHealth
+	*memoizingIndexingTasks
 }
 
 // NewMemoizingESState returns an initialized MemoizingESState.
@@ -39,6 +42,7 @@ func NewMemoizingESState(ctx context.Context, esClient esclient.Client) ESState
 		memoizingNodes:                   &memoizingNodes{esClient: esClient, ctx: ctx},
 		memoizingShardsAllocationEnabled: &memoizingShardsAllocationEnabled{esClient: esClient, ctx: ctx},
 		memoizingHealth:                  &memoizingHealth{esClient: esClient, ctx: ctx},
+		memoizingIndexingTasks:           &memoizingIndexingTasks{esClient: esClient, ctx: ctx},
 	}
 }
 
@@ -140,3 +144,33 @@ func (h *memoizingHealth) Health() (esv1.ElasticsearchHealth, error) {
 	}
 	return h.health, nil
 }
+
+// -- Indexing tasks
+
+// memoizingIndexingTasks provides the transforms and rollup jobs running in the cluster.
+type memoizingIndexingTasks struct {
+	tasks    []esclient.IndexingTask
+	once     sync.Once
+	esClient esclient.Client
+	ctx      context.Context
+}
+
+// initialize requests Elasticsearch for the transforms and rollup jobs, only once.
+func (t *memoizingIndexingTasks) initialize() error {
+	ctx, cancel := context.WithTimeout(t.ctx, esclient.DefaultReqTimeout)
+	defer cancel()
+	tasks, err := t.esClient.GetIndexingTasks(ctx)
+	if err != nil {
+		return err
+	}
+	t.tasks = tasks
+	return nil
+}
+
+// IndexingTasks returns the transforms and rollup jobs running in the cluster.
+func (t *memoizingIndexingTasks) IndexingTasks() ([]esclient.IndexingTask, error) {
+	if err := initOnce(&t.once, t.initialize); err != nil {
+		return nil, err
+	}
+	return t.tasks, nil
+}
@@ -36,9 +36,21 @@ type fakeESClient struct { //nolint:maligned
 
 	SyncedFlushCalled bool
 
+	SetMLUpgradeModeCalledWith []bool
+
+	StopIndexingTaskCalledWith  []esclient.IndexingTask
+	StartIndexingTaskCalledWith []esclient.IndexingTask
+
+	SearchCalledWith [][]string
+	searchErr        error
+
 	nodes             esclient.Nodes
 	GetNodesCallCount int
 
+	nodesShutdown                []esclient.NodeShutdown
+	PutNodeShutdownCalledWith    []string
+	DeleteNodeShutdownCalledWith []string
+
 	clusterRoutingAllocation             esclient.ClusterRoutingAllocation
 	GetClusterRoutingAllocationCallCount int
 
@@ -79,11 +91,46 @@ func (f *fakeESClient) SyncedFlush(_ context.Context) error {
 	return nil
 }
 
+func (f *fakeESClient) SetMLUpgradeMode(_ context.Context, enabled bool) error {
+	f.SetMLUpgradeModeCalledWith = append(f.SetMLUpgradeModeCalledWith, enabled)
+	return nil
+}
+
+func (f *fakeESClient) StopIndexingTask(_ context.Context, task esclient.IndexingTask) error {
+	f.StopIndexingTaskCalledWith = append(f.StopIndexingTaskCalledWith, task)
+	return nil
+}
+
+func (f *fakeESClient) StartIndexingTask(_ context.Context, task esclient.IndexingTask) error {
+	f.StartIndexingTaskCalledWith = append(f.StartIndexingTaskCalledWith, task)
+	return nil
+}
+
+func (f *fakeESClient) Search(_ context.Context, indices []string, _ map[string]interface{}) (esclient.SearchResults, error) {
+	f.SearchCalledWith = append(f.SearchCalledWith, indices)
+	return esclient.SearchResults{}, f.searchErr
+}
+
 func (f *fakeESClient) GetNodes(_ context.Context) (esclient.Nodes, error) {
 	f.GetNodesCallCount++
 	return f.nodes, nil
 }
 
+func (f *fakeESClient) GetNodesShutdown(_ context.Context) ([]esclient.NodeShutdown, error) {
+	return f.nodesShutdown, nil
+}
+
+func (f *fakeESClient) PutNodeShutdown(_ context.Context, nodeID string, shutdownType string, reason string) error {
+	f.PutNodeShutdownCalledWith = append(f.PutNodeShutdownCalledWith, nodeID)
+	f.nodesShutdown = append(f.nodesShutdown, esclient.NodeShutdown{NodeID: nodeID, Type: shutdownType, Reason: reason})
+	return nil
+}
+
+func (f *fakeESClient) DeleteNodeShutdown(_ context.Context, nodeID string) error {
+	f.DeleteNodeShutdownCalledWith = append(f.DeleteNodeShutdownCalledWith, nodeID)
+	return nil
+}
+
 func (f *fakeESClient) GetClusterRoutingAllocation(_ context.Context) (esclient.ClusterRoutingAllocation, error) {
 	f.GetClusterRoutingAllocationCallCount++
 	return f.clusterRoutingAllocation, nil
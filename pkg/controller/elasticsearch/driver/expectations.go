@@ -16,6 +16,12 @@ import (
 // (eg. incorrect number of nodes or master-eligible nodes topology)
 // - create or delete more than one master node at once
 func (d *defaultDriver) expectationsSatisfied() (bool, error) {
+	actualStatefulSets, err := sset.RetrieveActualStatefulSets(d.Client, k8s.ExtractNamespacedName(&d.ES))
+	if err != nil {
+		return false, err
+	}
+	// resume from the expectations persisted by the previous operator instance, if any
+	d.Expectations.RestoreOnce(actualStatefulSets)
 	// make sure the cache is up-to-date
 	expectationsOK, err := d.Expectations.Satisfied()
 	if err != nil {
@@ -25,10 +31,6 @@ func (d *defaultDriver) expectationsSatisfied() (bool, error) {
 		log.V(1).Info("Cache expectations are not satisfied yet, re-queueing", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
 		return false, nil
 	}
-	actualStatefulSets, err := sset.RetrieveActualStatefulSets(d.Client, k8s.ExtractNamespacedName(&d.ES))
-	if err != nil {
-		return false, err
-	}
 	// make sure StatefulSet statuses have been reconciled by the StatefulSet controller
 	if !actualStatefulSets.StatusReconciliationDone() {
 		log.V(1).Info("StatefulSets observedGeneration is not reconciled yet, re-queueing", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
@@ -37,3 +39,13 @@ func (d *defaultDriver) expectationsSatisfied() (bool, error) {
 	// make sure pods have been reconciled by the StatefulSet controller
 	return actualStatefulSets.PodReconciliationDone(d.Client)
 }
+
+// persistExpectations stores the pending expectations in annotations of the StatefulSets, to be restored by the next
+// operator instance if the operator restarts before they are satisfied.
+func (d *defaultDriver) persistExpectations() error {
+	actualStatefulSets, err := sset.RetrieveActualStatefulSets(d.Client, k8s.ExtractNamespacedName(&d.ES))
+	if err != nil {
+		return err
+	}
+	return d.Expectations.PersistTo(d.Client, actualStatefulSets)
+}
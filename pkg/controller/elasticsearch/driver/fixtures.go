@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/uuid"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
@@ -270,11 +271,16 @@ func (t testPod) toPodPtr() *corev1.Pod {
 }
 
 type testESState struct {
-	inCluster []string
-	health    esv1.ElasticsearchHealth
+	inCluster     []string
+	health        esv1.ElasticsearchHealth
+	indexingTasks []esclient.IndexingTask
 	ESState
 }
 
+func (t *testESState) IndexingTasks() ([]esclient.IndexingTask, error) {
+	return t.indexingTasks, nil
+}
+
 func (t *testESState) ShardAllocationsEnabled() (bool, error) {
 	return true, nil
 }
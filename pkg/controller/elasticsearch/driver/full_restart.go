@@ -0,0 +1,216 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// FullRestartAnnotationName can be set on the Elasticsearch resource to restart all its nodes at once, for the
+	// settings which cannot be changed with a rolling upgrade. A full restart is triggered every time the annotation
+	// value changes. Pending changes of the specification are applied by the restart.
+	FullRestartAnnotationName = "elasticsearch.k8s.elastic.co/full-restart"
+	// FullRestartRequestAnnotationName records the last value of FullRestartAnnotationName handled by the operator.
+	FullRestartRequestAnnotationName = "elasticsearch.k8s.elastic.co/full-restart-request"
+	// FullRestartStartedAtAnnotationName records when the nodes were stopped for a full restart, until they are all
+	// back in the cluster.
+	FullRestartStartedAtAnnotationName = "elasticsearch.k8s.elastic.co/full-restart-started-at"
+)
+
+// fullRestartRequested returns true if a full restart of the given cluster was requested and not handled yet.
+func fullRestartRequested(es esv1.Elasticsearch) bool {
+	request := es.Annotations[FullRestartAnnotationName]
+	return request != "" && request != es.Annotations[FullRestartRequestAnnotationName]
+}
+
+// fullRestartInProgress returns true if the nodes of the given cluster were stopped for a full restart, and are not
+// all back in the cluster yet.
+func fullRestartInProgress(es esv1.Elasticsearch) bool {
+	_, exists := es.Annotations[FullRestartStartedAtAnnotationName]
+	return exists
+}
+
+// handleFullRestart restarts all the nodes of the cluster at once if requested through FullRestartAnnotationName.
+// It returns false if no full restart is requested or in progress, in which case the nodes can be upgraded one by one.
+//
+// Once the cluster is green with all its nodes, the replica shards allocation is disabled and the indices are flushed
+// before deleting all the Pods. The StatefulSets recreate them with their current specification. The shards allocation
+// is enabled again once all the nodes are back in the cluster. A full restart upgrading the nodes from the given
+// version waits for the snapshot required by the update strategy, as a rolling upgrade does.
+func (d *defaultDriver) handleFullRestart(
+	ctx context.Context,
+	esClient esclient.Client,
+	esVersion version.Version,
+	esState ESState,
+	observedState observer.State,
+) (bool, *reconciler.Results) {
+	results := &reconciler.Results{}
+	inProgress := fullRestartInProgress(d.ES)
+	if !inProgress && !fullRestartRequested(d.ES) {
+		return false, results
+	}
+
+	statefulSets, err := sset.RetrieveActualStatefulSets(d.Client, k8s.ExtractNamespacedName(&d.ES))
+	if err != nil {
+		return true, results.WithError(err)
+	}
+	if inProgress {
+		return true, d.completeFullRestart(ctx, esClient, esState, statefulSets)
+	}
+
+	pods, err := statefulSets.GetActualPods(d.Client)
+	if err != nil {
+		return true, results.WithError(err)
+	}
+	healthyPods, err := healthyPods(d.Client, statefulSets, esState)
+	if err != nil {
+		return true, results.WithError(err)
+	}
+	if reason := fullRestartBlocker(observedState, statefulSets, pods, healthyPods); reason != "" {
+		log.Info("Delaying the full cluster restart", "reason", reason, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		return true, results.WithResult(defaultRequeue)
+	}
+
+	// the Pods are recreated with the version of the specification
+	proceed, err := d.reconcilePreUpgradeSnapshot(ctx, esClient, esVersion, time.Now())
+	if err != nil {
+		return true, withESError(results, d.ES, err)
+	}
+	if !proceed {
+		return true, results.WithResult(defaultRequeue)
+	}
+
+	shardsAllocationEnabled, err := esState.ShardAllocationsEnabled()
+	if err != nil {
+		return true, results.WithError(err)
+	}
+	if shardsAllocationEnabled {
+		log.Info("Disabling shards allocation", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		if err := disableShardsAllocation(ctx, esClient); err != nil {
+			return true, withESError(results, d.ES, err)
+		}
+	}
+	if err := doSyncFlush(ctx, d.ES, esClient); err != nil {
+		return true, withESError(results, d.ES, err)
+	}
+
+	// record the restart before stopping the nodes, so that they are not stopped twice
+	if d.ES.Annotations == nil {
+		d.ES.Annotations = map[string]string{}
+	}
+	d.ES.Annotations[FullRestartRequestAnnotationName] = d.ES.Annotations[FullRestartAnnotationName]
+	d.ES.Annotations[FullRestartStartedAtAnnotationName] = time.Now().Format(time.RFC3339)
+	if err := d.Client.Update(&d.ES); err != nil {
+		return true, results.WithError(err)
+	}
+
+	log.Info("Performing a full cluster restart", "namespace", d.ES.Namespace, "es_name", d.ES.Name, "pod_count", len(pods))
+	d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonRestart,
+		fmt.Sprintf("Stopping the %d nodes of the cluster for a full restart", len(pods)))
+	for _, pod := range pods {
+		if err := deletePod(d.Client, d.ES, pod, d.Expectations); err != nil {
+			return true, results.WithError(err)
+		}
+	}
+	return true, results.WithResult(defaultRequeue)
+}
+
+// fullRestartBlocker returns why the nodes cannot be stopped for a full restart yet, or the empty string if they can.
+// The nodes of a NodeSet without a data volume claim template store their data in an emptyDir volume, which would be
+// lost with all the copies of the shards when all the Pods are deleted at once.
+func fullRestartBlocker(
+	observedState observer.State,
+	statefulSets sset.StatefulSetList,
+	pods []corev1.Pod,
+	healthyPods map[string]corev1.Pod,
+) string {
+	for _, statefulSet := range statefulSets {
+		if !hasDataVolumeClaim(statefulSet) {
+			return fmt.Sprintf("StatefulSet %s has no %s volume claim template", statefulSet.Name, esvolume.ElasticsearchDataVolumeName)
+		}
+	}
+	if observedState.ClusterHealth == nil || observedState.ClusterHealth.Status != esv1.ElasticsearchGreenHealth {
+		return "cluster health is not green"
+	}
+	var replicas int32
+	for _, statefulSet := range statefulSets {
+		replicas += sset.GetReplicas(statefulSet)
+	}
+	if int(replicas) != len(pods) || len(healthyPods) != len(pods) {
+		return fmt.Sprintf("%d nodes out of %d are ready and in the cluster", len(healthyPods), replicas)
+	}
+	return ""
+}
+
+// hasDataVolumeClaim returns true if the data of the nodes of the given StatefulSet is persisted in a
+// PersistentVolumeClaim.
+func hasDataVolumeClaim(statefulSet appsv1.StatefulSet) bool {
+	for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		if claim.Name == esvolume.ElasticsearchDataVolumeName {
+			return true
+		}
+	}
+	return false
+}
+
+// completeFullRestart enables the shards allocation again once all the nodes are back in the cluster after a full
+// restart.
+func (d *defaultDriver) completeFullRestart(
+	ctx context.Context,
+	esClient esclient.Client,
+	esState ESState,
+	statefulSets sset.StatefulSetList,
+) *reconciler.Results {
+	results := &reconciler.Results{}
+	// make sure the Pods deleted for the restart are not in the cache anymore
+	done, err := d.expectationsSatisfied()
+	if err != nil {
+		return results.WithError(err)
+	}
+	if !done {
+		return results.WithResult(defaultRequeue)
+	}
+	nodesInCluster, err := esState.NodesInCluster(statefulSets.PodNames())
+	if err != nil {
+		return withESError(results, d.ES, err)
+	}
+	if !nodesInCluster {
+		log.V(1).Info(
+			"Some restarted nodes are not back in the cluster yet, keeping shard allocations disabled",
+			"namespace", d.ES.Namespace,
+			"es_name", d.ES.Name,
+		)
+		return results.WithResult(defaultRequeue)
+	}
+
+	log.Info("Enabling shards allocation after the full cluster restart", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+	ctx, cancel := context.WithTimeout(ctx, esclient.DefaultReqTimeout)
+	defer cancel()
+	if err := esClient.EnableShardAllocation(ctx); err != nil {
+		return withESError(results, d.ES, err)
+	}
+	delete(d.ES.Annotations, FullRestartStartedAtAnnotationName)
+	if err := d.Client.Update(&d.ES); err != nil {
+		return results.WithError(err)
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonRestart, "Full cluster restart completed")
+	return results
+}
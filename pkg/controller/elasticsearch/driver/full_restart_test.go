@@ -0,0 +1,210 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_defaultDriver_handleFullRestart(t *testing.T) {
+	statefulSet := sset.TestSset{Namespace: "ns", Name: "default", ClusterName: "es", Replicas: 2}
+	green := observer.State{ClusterHealth: &esclient.Health{Status: esv1.ElasticsearchGreenHealth}}
+	yellow := observer.State{ClusterHealth: &esclient.Health{Status: esv1.ElasticsearchYellowHealth}}
+	allNodes := []string{"default-0", "default-1"}
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		emptyDir      bool
+		observedState observer.State
+		inCluster     []string
+		wantHandled   bool
+		wantRequeue   bool
+		wantStopped   bool
+		wantStarted   bool
+		// wantInProgress is whether the restart is still in progress after the reconciliation
+		wantInProgress bool
+	}{
+		{
+			name:          "no full restart requested",
+			observedState: green,
+			inCluster:     allNodes,
+		},
+		{
+			name:          "full restart already handled",
+			annotations:   map[string]string{FullRestartAnnotationName: "1", FullRestartRequestAnnotationName: "1"},
+			observedState: green,
+			inCluster:     allNodes,
+		},
+		{
+			name:          "full restart delayed while the cluster is not green",
+			annotations:   map[string]string{FullRestartAnnotationName: "1"},
+			observedState: yellow,
+			inCluster:     allNodes,
+			wantHandled:   true,
+			wantRequeue:   true,
+		},
+		{
+			name:          "full restart delayed while some nodes are not in the cluster",
+			annotations:   map[string]string{FullRestartAnnotationName: "1"},
+			observedState: green,
+			wantHandled:   true,
+			wantRequeue:   true,
+		},
+		{
+			name:          "full restart blocked while the data of the nodes is not persisted",
+			annotations:   map[string]string{FullRestartAnnotationName: "1"},
+			emptyDir:      true,
+			observedState: green,
+			inCluster:     allNodes,
+			wantHandled:   true,
+			wantRequeue:   true,
+		},
+		{
+			name:           "nodes stopped for the full restart",
+			annotations:    map[string]string{FullRestartAnnotationName: "2", FullRestartRequestAnnotationName: "1"},
+			observedState:  green,
+			inCluster:      allNodes,
+			wantHandled:    true,
+			wantRequeue:    true,
+			wantStopped:    true,
+			wantInProgress: true,
+		},
+		{
+			name: "restarted nodes not back in the cluster",
+			annotations: map[string]string{
+				FullRestartAnnotationName: "1", FullRestartRequestAnnotationName: "1", FullRestartStartedAtAnnotationName: "2020-01-01T00:00:00Z",
+			},
+			wantHandled:    true,
+			wantRequeue:    true,
+			wantInProgress: true,
+		},
+		{
+			name: "restarted nodes back in the cluster",
+			annotations: map[string]string{
+				FullRestartAnnotationName: "1", FullRestartRequestAnnotationName: "1", FullRestartStartedAtAnnotationName: "2020-01-01T00:00:00Z",
+			},
+			inCluster:   allNodes,
+			wantHandled: true,
+			wantStarted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: tt.annotations}}
+			built := statefulSet.Build()
+			if !tt.emptyDir {
+				built.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{esvolume.DefaultDataVolumeClaim}
+			}
+			objects := []runtime.Object{&es, &built}
+			for _, podName := range sset.PodNames(statefulSet.Build()) {
+				objects = append(objects, sset.TestPod{
+					Namespace: "ns", Name: podName, ClusterName: "es", StatefulSetName: statefulSet.Name, Ready: true,
+				}.BuildPtr())
+			}
+			c := k8s.WrappedFakeClient(objects...)
+			esClient := &fakeESClient{}
+			d := &defaultDriver{DefaultDriverParameters{
+				ES:             es,
+				Client:         c,
+				Expectations:   expectations.NewExpectations(c),
+				ReconcileState: reconcile.NewState(es),
+			}}
+
+			handled, results := d.handleFullRestart(
+				context.Background(), esClient, version.MustParse("7.10.0"), &testESState{inCluster: tt.inCluster}, tt.observedState,
+			)
+			require.Equal(t, tt.wantHandled, handled)
+			res, err := results.Aggregate()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequeue, res.Requeue || res.RequeueAfter > 0)
+
+			var pods corev1.PodList
+			require.NoError(t, c.List(&pods))
+			if tt.wantStopped {
+				require.Empty(t, pods.Items)
+				require.True(t, esClient.SyncedFlushCalled)
+			} else {
+				require.Len(t, pods.Items, 2)
+			}
+			require.Equal(t, tt.wantStarted, esClient.EnableShardAllocationCalled)
+
+			var updated esv1.Elasticsearch
+			require.NoError(t, c.Get(k8s.ExtractNamespacedName(&es), &updated))
+			require.Equal(t, tt.wantInProgress, fullRestartInProgress(updated))
+			// the request is only handled once the nodes are stopped
+			require.Equal(t, fullRestartRequested(es) && !tt.wantStopped, fullRestartRequested(updated))
+		})
+	}
+}
+
+func Test_defaultDriver_handleFullRestart_PreUpgradeSnapshot(t *testing.T) {
+	statefulSet := sset.TestSset{Namespace: "ns", Name: "default", ClusterName: "es", Replicas: 2}.Build()
+	statefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{esvolume.DefaultDataVolumeClaim}
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{FullRestartAnnotationName: "1"}},
+		Spec: esv1.ElasticsearchSpec{Version: "7.10.0", UpdateStrategy: esv1.UpdateStrategy{
+			SnapshotBeforeUpgrade: &esv1.SnapshotBeforeUpgradePolicy{Repository: "backups"},
+		}},
+	}
+	objects := []runtime.Object{&es, &statefulSet}
+	for _, podName := range sset.PodNames(statefulSet) {
+		objects = append(objects, sset.TestPod{
+			Namespace: "ns", Name: podName, ClusterName: "es", StatefulSetName: statefulSet.Name, Ready: true,
+		}.BuildPtr())
+	}
+	c := k8s.WrappedFakeClient(objects...)
+	var requests []string
+	esClient := esclient.NewMockClient(version.MustParse("7.9.3"), func(req *http.Request) *http.Response {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		if req.Method == http.MethodGet {
+			return esclient.NewMockResponse(200, req, `{"snapshots":[]}`)
+		}
+		return esclient.NewMockResponse(200, req, `{}`)
+	})
+	d := &defaultDriver{DefaultDriverParameters{
+		ES:             es,
+		Client:         c,
+		Expectations:   expectations.NewExpectations(c),
+		ReconcileState: reconcile.NewState(es),
+	}}
+	green := observer.State{ClusterHealth: &esclient.Health{Status: esv1.ElasticsearchGreenHealth}}
+
+	// the nodes are not stopped to be upgraded until the snapshot completes
+	handled, results := d.handleFullRestart(
+		context.Background(), esClient, version.MustParse("7.9.3"), &testESState{inCluster: sset.PodNames(statefulSet)}, green,
+	)
+	require.True(t, handled)
+	res, err := results.Aggregate()
+	require.NoError(t, err)
+	require.True(t, res.Requeue || res.RequeueAfter > 0)
+	require.Len(t, requests, 2)
+	require.Equal(t, "PUT", strings.Fields(requests[1])[0])
+	require.NotNil(t, d.ReconcileState.Conditions().Get(PreUpgradeSnapshotConditionType))
+
+	var pods corev1.PodList
+	require.NoError(t, c.List(&pods))
+	require.Len(t, pods.Items, 2)
+	var updated esv1.Elasticsearch
+	require.NoError(t, c.Get(k8s.ExtractNamespacedName(&es), &updated))
+	require.True(t, fullRestartRequested(updated))
+}
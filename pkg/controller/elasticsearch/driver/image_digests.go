@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/nodespec"
+)
+
+const (
+	// ImageDigestsConditionType is the type of the condition reporting whether the images of the Elasticsearch Pods
+	// are pinned to digests, if the operator resolves digests.
+	ImageDigestsConditionType commonv1.ConditionType = "ImageDigestsPinned"
+	// ReasonImageDigestsPinned is the reason of the condition when all the images are pinned.
+	ReasonImageDigestsPinned = "ImageDigestsPinned"
+	// ReasonImageDigestResolutionFailed is the reason of the condition when the digests of some images could not be
+	// resolved, and these images are deployed by tag.
+	ReasonImageDigestResolutionFailed = "ImageDigestResolutionFailed"
+)
+
+// pinImageDigests pins the images of the containers of the expected StatefulSets to the digests recorded in the
+// status of the cluster, resolving the tags of the images not recorded yet, and records the digests of the images in
+// use, then updates the template hash of the StatefulSets accordingly. Images are deployed by tag, and no digest is
+// recorded, if the operator does not resolve digests. The images whose digest cannot be resolved, for example while
+// their registry is unavailable, are also deployed by tag not to block the reconciliation, and are reported in the
+// status condition of the cluster until their digest is resolved.
+func (d *defaultDriver) pinImageDigests(ctx context.Context, resources nodespec.ResourcesList) {
+	resolver := d.OperatorParameters.ImageDigestResolver
+	if resolver == nil {
+		d.ReconcileState.RemoveCondition(ImageDigestsConditionType)
+		return
+	}
+	known := make(map[string]string, len(d.ReconcileState.ImageDigests()))
+	for _, digest := range d.ReconcileState.ImageDigests() {
+		known[digest.Image] = digest.Digest
+	}
+	inUse := map[string]string{}
+	failures := map[string]error{}
+	for i := range resources {
+		spec := &resources[i].StatefulSet.Spec.Template.Spec
+		for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for j := range containers {
+				image := containers[j].Image
+				if container.HasDigest(image) {
+					continue
+				}
+				digest, exists := known[image]
+				if !exists {
+					if _, failed := failures[image]; failed {
+						continue
+					}
+					log.Info("Resolving image digest", "namespace", d.ES.Namespace, "es_name", d.ES.Name, "image", image)
+					var err error
+					if digest, err = resolver.Resolve(ctx, image); err != nil {
+						log.Error(err, "Failed to resolve image digest, deploying the image by tag",
+							"namespace", d.ES.Namespace, "es_name", d.ES.Name, "image", image)
+						failures[image] = err
+						continue
+					}
+					known[image] = digest
+				}
+				inUse[image] = digest
+				containers[j].Image = image + "@" + digest
+			}
+		}
+		// the StatefulSets are built before their images are pinned, update their template hash for the Pods to be
+		// rotated to the pinned images
+		sset := &resources[i].StatefulSet
+		sset.Labels = hash.SetTemplateHashLabel(sset.Labels, sset.Spec)
+	}
+
+	digests := make([]esv1.ImageDigest, 0, len(inUse))
+	for image, digest := range inUse {
+		digests = append(digests, esv1.ImageDigest{Image: image, Digest: digest})
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i].Image < digests[j].Image })
+	d.ReconcileState.UpdateImageDigests(digests)
+
+	condition := imageDigestsCondition(failures)
+	previous := d.ReconcileState.Conditions().Get(ImageDigestsConditionType)
+	if condition.Status == corev1.ConditionFalse && (previous == nil || previous.Status != corev1.ConditionFalse) {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, condition.Message)
+	}
+	d.ReconcileState.UpdateCondition(condition)
+}
+
+// imageDigestsCondition returns the condition reporting the images whose digest could not be resolved.
+func imageDigestsCondition(failures map[string]error) commonv1.Condition {
+	if len(failures) == 0 {
+		return commonv1.Condition{
+			Type:   ImageDigestsConditionType,
+			Status: corev1.ConditionTrue,
+			Reason: ReasonImageDigestsPinned,
+		}
+	}
+	messages := make([]string, 0, len(failures))
+	for image, err := range failures {
+		messages = append(messages, fmt.Sprintf("%s: %s", image, err.Error()))
+	}
+	sort.Strings(messages)
+	return commonv1.Condition{
+		Type:    ImageDigestsConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  ReasonImageDigestResolutionFailed,
+		Message: "Failed to resolve the digests of images deployed by tag. " + strings.Join(messages, "; "),
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/nodespec"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+)
+
+type fakeDigestResolver map[string]string
+
+func (f fakeDigestResolver) Resolve(_ context.Context, image string) (string, error) {
+	digest, exists := f[image]
+	if !exists {
+		return "", errors.New("registry unavailable")
+	}
+	return digest, nil
+}
+
+func resourcesWithImages(images ...string) nodespec.ResourcesList {
+	containers := make([]corev1.Container, 0, len(images))
+	for _, image := range images {
+		containers = append(containers, corev1.Container{Image: image})
+	}
+	return nodespec.ResourcesList{{StatefulSet: appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{
+		Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{InitContainers: containers[:1], Containers: containers}},
+	}}}}
+}
+
+func Test_pinImageDigests(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Status: esv1.ElasticsearchStatus{ImageDigests: []esv1.ImageDigest{
+			{Image: "docker.elastic.co/elasticsearch/elasticsearch:7.8.0", Digest: "sha256:recorded"},
+			{Image: "docker.elastic.co/elasticsearch/elasticsearch:7.7.0", Digest: "sha256:previous"},
+		}},
+	}
+	resolver := fakeDigestResolver{
+		"docker.elastic.co/elasticsearch/elasticsearch:7.8.0": "sha256:mutated",
+		"docker.elastic.co/beats/filebeat:7.8.0":              "sha256:filebeat",
+	}
+	newDriver := func(resolver container.DigestResolver) *defaultDriver {
+		return &defaultDriver{DefaultDriverParameters{
+			ES:                 es,
+			ReconcileState:     reconcile.NewState(es),
+			OperatorParameters: operator.Parameters{ImageDigestResolver: resolver},
+		}}
+	}
+
+	// recorded digests are reused, others are resolved, the ones no longer in use are dropped
+	d := newDriver(resolver)
+	resources := resourcesWithImages(
+		"docker.elastic.co/elasticsearch/elasticsearch:7.8.0",
+		"docker.elastic.co/beats/filebeat:7.8.0",
+		"registry.example.com/sidecar@sha256:pinned",
+	)
+	unpinnedHash := hash.HashObject(resources[0].StatefulSet.Spec)
+	d.pinImageDigests(context.Background(), resources)
+	spec := resources[0].StatefulSet.Spec.Template.Spec
+	// the template hash accounts for the pinned images
+	require.NotEqual(t, unpinnedHash, hash.GetTemplateHashLabel(resources[0].StatefulSet.Labels))
+	require.Equal(t, hash.HashObject(resources[0].StatefulSet.Spec), hash.GetTemplateHashLabel(resources[0].StatefulSet.Labels))
+	require.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:7.8.0@sha256:recorded", spec.InitContainers[0].Image)
+	require.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:7.8.0@sha256:recorded", spec.Containers[0].Image)
+	require.Equal(t, "docker.elastic.co/beats/filebeat:7.8.0@sha256:filebeat", spec.Containers[1].Image)
+	require.Equal(t, "registry.example.com/sidecar@sha256:pinned", spec.Containers[2].Image)
+	require.Equal(t, []esv1.ImageDigest{
+		{Image: "docker.elastic.co/beats/filebeat:7.8.0", Digest: "sha256:filebeat"},
+		{Image: "docker.elastic.co/elasticsearch/elasticsearch:7.8.0", Digest: "sha256:recorded"},
+	}, d.ReconcileState.ImageDigests())
+	require.Equal(t, corev1.ConditionTrue, d.ReconcileState.Conditions().Get(ImageDigestsConditionType).Status)
+
+	// images whose digest cannot be resolved are deployed by tag, and reported in the condition
+	d = newDriver(resolver)
+	resources = resourcesWithImages(
+		"docker.elastic.co/elasticsearch/elasticsearch:7.8.0",
+		"docker.elastic.co/elasticsearch/elasticsearch:7.9.0",
+	)
+	d.pinImageDigests(context.Background(), resources)
+	spec = resources[0].StatefulSet.Spec.Template.Spec
+	require.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:7.8.0@sha256:recorded", spec.Containers[0].Image)
+	require.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:7.9.0", spec.Containers[1].Image)
+	require.Equal(t, []esv1.ImageDigest{
+		{Image: "docker.elastic.co/elasticsearch/elasticsearch:7.8.0", Digest: "sha256:recorded"},
+	}, d.ReconcileState.ImageDigests())
+	condition := d.ReconcileState.Conditions().Get(ImageDigestsConditionType)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, ReasonImageDigestResolutionFailed, condition.Reason)
+	require.Contains(t, condition.Message, "docker.elastic.co/elasticsearch/elasticsearch:7.9.0: registry unavailable")
+	require.Len(t, d.ReconcileState.Events(), 1)
+
+	// images are deployed by tag without a resolver
+	d = newDriver(nil)
+	resources = resourcesWithImages("docker.elastic.co/elasticsearch/elasticsearch:7.8.0")
+	d.pinImageDigests(context.Background(), resources)
+	require.Nil(t, d.ReconcileState.Conditions().Get(ImageDigestsConditionType))
+	require.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:7.8.0", resources[0].StatefulSet.Spec.Template.Spec.Containers[0].Image)
+}
@@ -0,0 +1,168 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// StoppedIndexingTasksAnnotationName holds the transforms and rollup jobs stopped by the operator during a rolling
+// upgrade, serialized as JSON, to start them again once all the nodes are upgraded.
+const StoppedIndexingTasksAnnotationName = "elasticsearch.k8s.elastic.co/stopped-indexing-tasks"
+
+// transformsMinVersion is the first version of Elasticsearch exposing the transform APIs.
+var transformsMinVersion = version.MustParse("7.5.0")
+
+// stoppedIndexingTasks returns the transforms and rollup jobs stopped by the operator.
+func stoppedIndexingTasks(es esv1.Elasticsearch) ([]esclient.IndexingTask, error) {
+	serialized, exists := es.Annotations[StoppedIndexingTasksAnnotationName]
+	if !exists {
+		return nil, nil
+	}
+	var tasks []esclient.IndexingTask
+	err := json.Unmarshal([]byte(serialized), &tasks)
+	return tasks, err
+}
+
+// updateStoppedIndexingTasks stores the given stopped tasks in the annotation of the cluster, or removes the
+// annotation if there is none.
+func (d *defaultDriver) updateStoppedIndexingTasks(tasks []esclient.IndexingTask) error {
+	if len(tasks) == 0 {
+		delete(d.ES.Annotations, StoppedIndexingTasksAnnotationName)
+		return d.Client.Update(&d.ES)
+	}
+	serialized, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+	if d.ES.Annotations == nil {
+		d.ES.Annotations = map[string]string{}
+	}
+	d.ES.Annotations[StoppedIndexingTasksAnnotationName] = string(serialized)
+	return d.Client.Update(&d.ES)
+}
+
+func containsIndexingTask(tasks []esclient.IndexingTask, task esclient.IndexingTask) bool {
+	for _, t := range tasks {
+		if t.Type == task.Type && t.ID == task.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// maybeStopIndexingTasks stops the transforms and rollup jobs running on the nodes to upgrade, if the update strategy
+// of the cluster requires it. The tasks stay stopped until all the nodes are upgraded, rather than being moved to
+// nodes about to be restarted as well.
+func (d *defaultDriver) maybeStopIndexingTasks(
+	ctx context.Context,
+	esClient esclient.Client,
+	esState ESState,
+	esVersion version.Version,
+	podsToUpgrade []corev1.Pod,
+) error {
+	if d.ES.Spec.UpdateStrategy.IndexingTasksOrDefault() != esv1.StopIndexingTasks || len(podsToUpgrade) == 0 {
+		return nil
+	}
+	tasks, err := esState.IndexingTasks()
+	if err != nil {
+		return err
+	}
+	stopped, err := stoppedIndexingTasks(d.ES)
+	if err != nil {
+		return err
+	}
+	nodes := k8s.PodNames(podsToUpgrade)
+	stoppedCount := len(stopped)
+	for _, task := range tasks {
+		if !stringsutil.StringInSlice(task.Node, nodes) || containsIndexingTask(stopped, task) {
+			continue
+		}
+		if task.Type == esclient.TransformTask && !esVersion.IsSameOrAfter(transformsMinVersion) {
+			// transforms can't be stopped: the node is restarted once the transform completes its checkpoint
+			continue
+		}
+		log.Info("Stopping indexing task before node restart",
+			"namespace", d.ES.Namespace, "es_name", d.ES.Name, "type", task.Type, "id", task.ID, "node", task.Node)
+		if err := stopIndexingTask(ctx, esClient, task); err != nil {
+			if len(stopped) > stoppedCount {
+				// record the tasks already stopped, to start them again later
+				_ = d.updateStoppedIndexingTasks(stopped)
+			}
+			return err
+		}
+		stopped = append(stopped, task)
+	}
+	if len(stopped) == stoppedCount {
+		return nil
+	}
+	return d.updateStoppedIndexingTasks(stopped)
+}
+
+// MaybeStartIndexingTasks starts the transforms and rollup jobs stopped by the operator, once all the nodes are
+// upgraded and back in the cluster.
+func (d *defaultDriver) MaybeStartIndexingTasks(
+	ctx context.Context,
+	esClient esclient.Client,
+	esState ESState,
+	statefulSets sset.StatefulSetList,
+	podsToUpgrade []corev1.Pod,
+) *reconciler.Results {
+	results := &reconciler.Results{}
+	stopped, err := stoppedIndexingTasks(d.ES)
+	if err != nil {
+		return results.WithError(err)
+	}
+	if len(stopped) == 0 || len(podsToUpgrade) > 0 {
+		return results
+	}
+
+	nodesInCluster, err := esState.NodesInCluster(statefulSets.PodNames())
+	if err != nil {
+		return results.WithError(err)
+	}
+	if !nodesInCluster {
+		log.V(1).Info(
+			"Some upgraded nodes are not back in the cluster yet, keeping indexing tasks stopped",
+			"namespace", d.ES.Namespace,
+			"es_name", d.ES.Name,
+		)
+		return results.WithResult(defaultRequeue)
+	}
+
+	for i, task := range stopped {
+		log.Info("Starting indexing task stopped for the upgrade",
+			"namespace", d.ES.Namespace, "es_name", d.ES.Name, "type", task.Type, "id", task.ID)
+		err := startIndexingTask(ctx, esClient, task)
+		if err != nil && !esclient.IsNotFound(err) && !esclient.IsConflict(err) {
+			// deleted or already started tasks are ignored, other errors are retried with the remaining tasks
+			return results.WithError(err).WithError(d.updateStoppedIndexingTasks(stopped[i:]))
+		}
+	}
+	return results.WithError(d.updateStoppedIndexingTasks(nil))
+}
+
+func stopIndexingTask(ctx context.Context, esClient esclient.Client, task esclient.IndexingTask) error {
+	ctx, cancel := context.WithTimeout(ctx, esclient.DefaultReqTimeout)
+	defer cancel()
+	return esClient.StopIndexingTask(ctx, task)
+}
+
+func startIndexingTask(ctx context.Context, esClient esclient.Client, task esclient.IndexingTask) error {
+	ctx, cancel := context.WithTimeout(ctx, esclient.DefaultReqTimeout)
+	defer cancel()
+	return esClient.StartIndexingTask(ctx, task)
+}
@@ -0,0 +1,195 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var (
+	rollupOnNode0    = esclient.IndexingTask{Type: esclient.RollupJobTask, ID: "logs", Node: "es-default-0"}
+	transformOnNode0 = esclient.IndexingTask{Type: esclient.TransformTask, ID: "ecommerce", Node: "es-default-0", Indexing: true}
+	rollupOnNode1    = esclient.IndexingTask{Type: esclient.RollupJobTask, ID: "sensors", Node: "es-default-1", Indexing: true}
+)
+
+func indexingTasksES(strategy esv1.IndexingTasksStrategy, stopped string) esv1.Elasticsearch {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{UpdateStrategy: esv1.UpdateStrategy{IndexingTasks: strategy}},
+	}
+	if stopped != "" {
+		es.Annotations = map[string]string{StoppedIndexingTasksAnnotationName: stopped}
+	}
+	return es
+}
+
+func Test_indexingTasksPredicate(t *testing.T) {
+	var predicate Predicate
+	for _, p := range predicates {
+		if p.name == "do_not_restart_node_with_indexing_tasks_in_checkpoint" {
+			predicate = p
+		}
+	}
+	tasks := []esclient.IndexingTask{rollupOnNode0, rollupOnNode1}
+	tests := []struct {
+		name      string
+		strategy  esv1.IndexingTasksStrategy
+		candidate string
+		want      bool
+	}{
+		{name: "indexing tasks ignored by default", candidate: "es-default-1", want: true},
+		{name: "task between checkpoints", strategy: esv1.WaitForIndexingTasksCheckpoint, candidate: "es-default-0", want: true},
+		{name: "task in checkpoint", strategy: esv1.WaitForIndexingTasksCheckpoint, candidate: "es-default-1", want: false},
+		{name: "task in checkpoint being stopped", strategy: esv1.StopIndexingTasks, candidate: "es-default-1", want: false},
+		{name: "no task", strategy: esv1.StopIndexingTasks, candidate: "es-default-2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := PredicateContext{
+				es:      indexingTasksES(tt.strategy, ""),
+				esState: &testESState{indexingTasks: tasks},
+			}
+			candidate := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: tt.candidate}}
+			got, err := predicate.fn(ctx, candidate, nil, false)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_defaultDriver_maybeStopIndexingTasks(t *testing.T) {
+	tasks := []esclient.IndexingTask{rollupOnNode0, transformOnNode0, rollupOnNode1}
+	tests := []struct {
+		name          string
+		strategy      esv1.IndexingTasksStrategy
+		stopped       string
+		esVersion     string
+		podsToUpgrade []string
+		wantStopped   []esclient.IndexingTask
+		wantRecorded  string
+	}{
+		{
+			name:          "tasks left running",
+			strategy:      esv1.WaitForIndexingTasksCheckpoint,
+			esVersion:     "7.6.0",
+			podsToUpgrade: []string{"es-default-0"},
+		},
+		{
+			name:          "stop the tasks on the nodes to upgrade",
+			strategy:      esv1.StopIndexingTasks,
+			esVersion:     "7.6.0",
+			podsToUpgrade: []string{"es-default-0"},
+			wantStopped:   []esclient.IndexingTask{rollupOnNode0, transformOnNode0},
+			wantRecorded:  `[{"type":"rollup","id":"logs"},{"type":"transform","id":"ecommerce"}]`,
+		},
+		{
+			name:          "tasks already stopped",
+			strategy:      esv1.StopIndexingTasks,
+			stopped:       `[{"type":"rollup","id":"logs"}]`,
+			esVersion:     "7.6.0",
+			podsToUpgrade: []string{"es-default-0", "es-default-1"},
+			wantStopped:   []esclient.IndexingTask{transformOnNode0, rollupOnNode1},
+			wantRecorded:  `[{"type":"rollup","id":"logs"},{"type":"transform","id":"ecommerce"},{"type":"rollup","id":"sensors"}]`,
+		},
+		{
+			name:          "transforms not supported",
+			strategy:      esv1.StopIndexingTasks,
+			esVersion:     "7.4.2",
+			podsToUpgrade: []string{"es-default-0"},
+			wantStopped:   []esclient.IndexingTask{rollupOnNode0},
+			wantRecorded:  `[{"type":"rollup","id":"logs"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := indexingTasksES(tt.strategy, tt.stopped)
+			esClient := &fakeESClient{}
+			d := &defaultDriver{DefaultDriverParameters{ES: es, Client: k8s.WrappedFakeClient(&es)}}
+			podsToUpgrade := make([]corev1.Pod, 0, len(tt.podsToUpgrade))
+			for _, name := range tt.podsToUpgrade {
+				podsToUpgrade = append(podsToUpgrade, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}})
+			}
+			err := d.maybeStopIndexingTasks(context.Background(), esClient,
+				&testESState{indexingTasks: tasks}, version.MustParse(tt.esVersion), podsToUpgrade)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStopped, esClient.StopIndexingTaskCalledWith)
+			var updated esv1.Elasticsearch
+			require.NoError(t, d.Client.Get(k8s.ExtractNamespacedName(&es), &updated))
+			want := tt.wantRecorded
+			if want == "" {
+				want = tt.stopped
+			}
+			require.Equal(t, want, updated.Annotations[StoppedIndexingTasksAnnotationName])
+		})
+	}
+}
+
+func Test_defaultDriver_MaybeStartIndexingTasks(t *testing.T) {
+	statefulSets := sset.StatefulSetList{sset.TestSset{Namespace: "ns", Name: "default", ClusterName: "es", Replicas: 1}.Build()}
+	stopped := `[{"type":"rollup","id":"logs"},{"type":"transform","id":"ecommerce"}]`
+	tests := []struct {
+		name          string
+		stopped       string
+		inCluster     []string
+		podsToUpgrade []corev1.Pod
+		wantStarted   []esclient.IndexingTask
+		wantRecorded  string
+		wantRequeue   bool
+	}{
+		{
+			name:      "no stopped task",
+			inCluster: []string{"default-0"},
+		},
+		{
+			name:          "nodes still to upgrade",
+			stopped:       stopped,
+			inCluster:     []string{"default-0"},
+			podsToUpgrade: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "default-0"}}},
+			wantRecorded:  stopped,
+		},
+		{
+			name:         "upgraded nodes not back in the cluster",
+			stopped:      stopped,
+			wantRecorded: stopped,
+			wantRequeue:  true,
+		},
+		{
+			name:      "all nodes upgraded",
+			stopped:   stopped,
+			inCluster: []string{"default-0"},
+			wantStarted: []esclient.IndexingTask{
+				{Type: esclient.RollupJobTask, ID: "logs"},
+				{Type: esclient.TransformTask, ID: "ecommerce"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := indexingTasksES(esv1.StopIndexingTasks, tt.stopped)
+			esClient := &fakeESClient{}
+			d := &defaultDriver{DefaultDriverParameters{ES: es, Client: k8s.WrappedFakeClient(&es)}}
+			results := d.MaybeStartIndexingTasks(
+				context.Background(), esClient, &testESState{inCluster: tt.inCluster}, statefulSets, tt.podsToUpgrade)
+			res, err := results.Aggregate()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequeue, res.Requeue || res.RequeueAfter > 0)
+			require.Equal(t, tt.wantStarted, esClient.StartIndexingTaskCalledWith)
+			var updated esv1.Elasticsearch
+			require.NoError(t, d.Client.Get(k8s.ExtractNamespacedName(&es), &updated))
+			require.Equal(t, tt.wantRecorded, updated.Annotations[StoppedIndexingTasksAnnotationName])
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// nodeShutdownMinVersion is the first version of Elasticsearch with the node shutdown API.
+var nodeShutdownMinVersion = version.MustParse("7.15.0")
+
+// leavingHostShutdownReason is the reason of the shutdown registrations of the nodes on leaving Kubernetes nodes, which
+// identifies the registrations managed by the operator.
+const leavingHostShutdownReason = "ECK: Kubernetes node about to leave"
+
+// LeavingHosts identifies the Kubernetes nodes about to leave, whose Elasticsearch nodes must be migrated away before
+// their Pods are evicted: migrating their data beforehand spares the cluster the recovery of the shards of the nodes
+// that left abruptly.
+type LeavingHosts struct {
+	// Cordoned includes the Kubernetes nodes marked unschedulable, which are about to be drained.
+	Cordoned bool
+	// PreemptionTaints are the keys of the taints set by the node termination handlers on the Kubernetes nodes about
+	// to be reclaimed by their cloud provider, such as spot or preemptible instances.
+	PreemptionTaints []string
+}
+
+// NewLeavingHosts returns the Kubernetes nodes to migrate the Elasticsearch nodes away from, given the operator
+// parameters.
+func NewLeavingHosts(params operator.Parameters) LeavingHosts {
+	return LeavingHosts{Cordoned: params.NodeDrainHandling, PreemptionTaints: params.PreemptionTaints}
+}
+
+// Enabled returns true if some Kubernetes nodes may be identified as leaving.
+func (h LeavingHosts) Enabled() bool {
+	return h.Cordoned || len(h.PreemptionTaints) > 0
+}
+
+// Preempted returns true if the given Kubernetes node carries one of the preemption taints.
+func (h LeavingHosts) Preempted(node corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if stringsutil.StringInSlice(taint.Key, h.PreemptionTaints) {
+			return true
+		}
+	}
+	return false
+}
+
+// Leaving returns true if the given Kubernetes node is about to be drained or reclaimed.
+func (h LeavingHosts) Leaving(node corev1.Node) bool {
+	return (h.Cordoned && node.Spec.Unschedulable) || h.Preempted(node)
+}
+
+// nodesOnLeavingHosts returns the sorted names of the given Pods scheduled on leaving Kubernetes nodes, and the sorted
+// names of the ones among them scheduled on preempted Kubernetes nodes.
+func (h LeavingHosts) nodesOnLeavingHosts(c k8s.Client, pods []corev1.Pod) (leaving []string, preempted []string, err error) {
+	hosts := map[string]*corev1.Node{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		host, checked := hosts[pod.Spec.NodeName]
+		if !checked {
+			var node corev1.Node
+			err := c.Get(types.NamespacedName{Name: pod.Spec.NodeName}, &node)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, nil, err
+			}
+			// the Pods of a deleted Kubernetes node are gone already
+			if err == nil {
+				host = &node
+			}
+			hosts[pod.Spec.NodeName] = host
+		}
+		if host == nil || !h.Leaving(*host) {
+			continue
+		}
+		leaving = append(leaving, pod.Name)
+		if h.Preempted(*host) {
+			preempted = append(preempted, pod.Name)
+		}
+	}
+	sort.Strings(leaving)
+	sort.Strings(preempted)
+	return leaving, preempted, nil
+}
+
+// withNodesOnLeavingHosts returns the given leaving nodes with the nodes whose Pod runs on a leaving Kubernetes node,
+// whose data must be migrated away too. The data can be allocated to the nodes again once their Pod is recreated on
+// another Kubernetes node, or once the Kubernetes node is not leaving anymore. A warning event reports the nodes on
+// preempted Kubernetes nodes that were not excluded from the shard allocation yet. The shutdown of the nodes on leaving
+// Kubernetes nodes is also registered in Elasticsearch 7.15 and later.
+func withNodesOnLeavingHosts(ctx downscaleContext, leavingNodes []string) ([]string, error) {
+	leaving, preempted, err := ctx.leavingHosts.nodesOnLeavingHosts(ctx.k8sClient, ctx.resourcesState.CurrentPods)
+	if err != nil {
+		return nil, err
+	}
+	if err := reconcileNodesShutdown(ctx, leaving); err != nil {
+		// the data is migrated away through the shard allocation filtering anyway
+		log.Error(err, "Failed to register the shutdown of the nodes on leaving Kubernetes nodes",
+			"namespace", ctx.es.Namespace, "es_name", ctx.es.Name)
+	}
+	if newlyPreempted := notExcluded(ctx.es, preempted); len(newlyPreempted) > 0 {
+		ctx.reconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnhealthy, fmt.Sprintf(
+			"Migrating the data of nodes %s away from Kubernetes nodes about to be preempted",
+			strings.Join(newlyPreempted, ", "),
+		))
+	}
+	for _, name := range leaving {
+		if !stringsutil.StringInSlice(name, leavingNodes) {
+			leavingNodes = append(leavingNodes, name)
+		}
+	}
+	return leavingNodes, nil
+}
+
+// notExcluded returns the given nodes which are not excluded from the shard allocation of the given cluster yet.
+func notExcluded(es esv1.Elasticsearch, nodes []string) []string {
+	excluded := strings.Split(es.Annotations[migration.AllocationExcludeAnnotationName], ",")
+	var result []string
+	for _, name := range nodes {
+		if !stringsutil.StringInSlice(name, excluded) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// reconcileNodesShutdown registers the shutdown of the given nodes on leaving Kubernetes nodes, for Elasticsearch to
+// prepare it as soon as the Kubernetes node is known to leave, and removes the registrations of the nodes which are not
+// leaving anymore, such as the nodes whose Pod was recreated on another Kubernetes node. The registrations made by
+// others are left untouched.
+func reconcileNodesShutdown(ctx downscaleContext, leaving []string) error {
+	if !ctx.esVersion.IsSameOrAfter(nodeShutdownMinVersion) {
+		return nil
+	}
+	nodes, err := ctx.esClient.GetNodes(ctx.parentCtx)
+	if err != nil {
+		return err
+	}
+	var expected []string
+	for id, node := range nodes.Nodes {
+		if stringsutil.StringInSlice(node.Name, leaving) {
+			expected = append(expected, id)
+		}
+	}
+	sort.Strings(expected)
+	shutdowns, err := ctx.esClient.GetNodesShutdown(ctx.parentCtx)
+	if err != nil {
+		return err
+	}
+	var registered []string
+	for _, shutdown := range shutdowns {
+		if shutdown.Reason != leavingHostShutdownReason {
+			continue
+		}
+		if stringsutil.StringInSlice(shutdown.NodeID, expected) {
+			registered = append(registered, shutdown.NodeID)
+			continue
+		}
+		log.Info("Removing the shutdown registration of a node not leaving anymore",
+			"namespace", ctx.es.Namespace, "es_name", ctx.es.Name, "node_id", shutdown.NodeID)
+		if err := ctx.esClient.DeleteNodeShutdown(ctx.parentCtx, shutdown.NodeID); err != nil {
+			return err
+		}
+	}
+	for _, id := range expected {
+		if stringsutil.StringInSlice(id, registered) {
+			continue
+		}
+		log.Info("Registering the shutdown of a node on a leaving Kubernetes node",
+			"namespace", ctx.es.Namespace, "es_name", ctx.es.Name, "node_id", id, "node_name", nodes.Nodes[id].Name)
+		if err := ctx.esClient.PutNodeShutdown(ctx.parentCtx, id, esclient.NodeShutdownRemove, leavingHostShutdownReason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const spotTaint = "aws-node-termination-handler/spot-itn"
+
+func host(name string, unschedulable bool, taints ...string) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: corev1.NodeSpec{Unschedulable: unschedulable}}
+	for _, key := range taints {
+		node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{Key: key, Effect: corev1.TaintEffectNoSchedule})
+	}
+	return node
+}
+
+func podOnHost(name, nodeName string) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}, Spec: corev1.PodSpec{NodeName: nodeName}}
+}
+
+func TestLeavingHosts_Leaving(t *testing.T) {
+	cordoned := host("cordoned", true)
+	preempted := host("preempted", false, "other", spotTaint)
+	schedulable := host("schedulable", false, "other")
+
+	drains := LeavingHosts{Cordoned: true}
+	require.True(t, drains.Enabled())
+	require.True(t, drains.Leaving(*cordoned))
+	require.False(t, drains.Leaving(*preempted))
+	require.False(t, drains.Leaving(*schedulable))
+
+	preemptions := LeavingHosts{PreemptionTaints: []string{spotTaint}}
+	require.True(t, preemptions.Enabled())
+	require.False(t, preemptions.Leaving(*cordoned))
+	require.True(t, preemptions.Leaving(*preempted))
+	require.True(t, preemptions.Preempted(*preempted))
+	require.False(t, preemptions.Leaving(*schedulable))
+
+	require.False(t, LeavingHosts{}.Enabled())
+}
+
+func Test_withNodesOnLeavingHosts(t *testing.T) {
+	now := metav1.Now()
+	deleted := podOnHost("es-default-4", "cordoned")
+	deleted.DeletionTimestamp = &now
+	pods := []corev1.Pod{
+		podOnHost("es-default-3", "cordoned"),
+		podOnHost("es-default-0", "cordoned"),
+		podOnHost("es-default-1", "schedulable"),
+		podOnHost("es-default-2", "deleted"),
+		podOnHost("es-default-5", ""),
+		podOnHost("es-default-7", "preempted"),
+		podOnHost("es-default-6", "preempted"),
+		deleted,
+	}
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{
+		migration.AllocationExcludeAnnotationName: "es-default-7",
+	}}}
+	ctx := downscaleContext{
+		k8sClient: k8s.WrappedFakeClient(
+			host("cordoned", true), host("schedulable", false), host("preempted", false, spotTaint),
+		),
+		resourcesState: reconcile.ResourcesState{CurrentPods: pods},
+		reconcileState: reconcile.NewState(es),
+		es:             es,
+		leavingHosts:   LeavingHosts{Cordoned: true, PreemptionTaints: []string{spotTaint}},
+	}
+
+	leavingNodes, err := withNodesOnLeavingHosts(ctx, []string{"es-default-3", "es-other-0"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"es-default-3", "es-other-0", "es-default-0", "es-default-6", "es-default-7"}, leavingNodes)
+	// the nodes already excluded from the shard allocation are not reported again
+	events := ctx.reconcileState.Events()
+	require.Len(t, events, 1)
+	require.Equal(t, corev1.EventTypeWarning, events[0].EventType)
+	require.Equal(t, "Migrating the data of nodes es-default-6 away from Kubernetes nodes about to be preempted", events[0].Message)
+
+	// only the preempted Kubernetes nodes are leaving
+	ctx.leavingHosts.Cordoned = false
+	leavingNodes, err = withNodesOnLeavingHosts(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"es-default-6", "es-default-7"}, leavingNodes)
+
+	// no Pod on a leaving Kubernetes node
+	ctx.resourcesState.CurrentPods = pods[2:5]
+	leavingNodes, err = withNodesOnLeavingHosts(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, leavingNodes)
+}
+
+func Test_reconcileNodesShutdown(t *testing.T) {
+	esClient := &fakeESClient{
+		nodes: esclient.Nodes{Nodes: map[string]esclient.Node{
+			"id-0": {Name: "es-default-0"},
+			"id-1": {Name: "es-default-1"},
+			"id-2": {Name: "es-default-2"},
+		}},
+		nodesShutdown: []esclient.NodeShutdown{
+			{NodeID: "id-1", Type: "REMOVE", Reason: leavingHostShutdownReason},
+			{NodeID: "id-2", Type: "REMOVE", Reason: leavingHostShutdownReason},
+			{NodeID: "id-3", Type: "RESTART", Reason: "maintenance"},
+		},
+	}
+	ctx := downscaleContext{
+		esClient:  esClient,
+		esVersion: version.MustParse("7.15.0"),
+		parentCtx: context.Background(),
+	}
+
+	// the shutdown of the newly leaving node is registered, and the registration of the node not leaving anymore is
+	// removed, unlike the registrations made by others
+	require.NoError(t, reconcileNodesShutdown(ctx, []string{"es-default-0", "es-default-1"}))
+	require.Equal(t, []string{"id-0"}, esClient.PutNodeShutdownCalledWith)
+	require.Equal(t, []string{"id-2"}, esClient.DeleteNodeShutdownCalledWith)
+
+	// the node shutdown API is not available before 7.15
+	esClient = &fakeESClient{nodes: esClient.nodes}
+	ctx.esClient = esClient
+	ctx.esVersion = version.MustParse("7.14.0")
+	require.NoError(t, reconcileNodesShutdown(ctx, []string{"es-default-0"}))
+	require.Empty(t, esClient.PutNodeShutdownCalledWith)
+	require.Zero(t, esClient.GetNodesCallCount)
+}
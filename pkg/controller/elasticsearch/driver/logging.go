@@ -0,0 +1,183 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+const loggerSettingPrefix = "logger."
+
+// loggingPlan holds the logging settings to apply to the cluster, and the status reporting them.
+type loggingPlan struct {
+	// loggers are the logger levels to update, nil to reset a logger to its default level.
+	loggers map[string]interface{}
+	// slowLog are the slow log thresholds to update.
+	slowLog []esv1.SlowLogThresholds
+	status  *esv1.LoggingStatus
+}
+
+// newLoggingPlan compares the logging settings of the specification, and the loggers previously set by the operator,
+// to the settings observed in the cluster. Settings are applied again until an observation confirms them, and all of
+// them are considered pending if the cluster has not been observed.
+func newLoggingPlan(spec *esv1.Logging, previous *esv1.LoggingStatus, observed *esclient.LoggingSettings) loggingPlan {
+	var specLoggers map[string]esv1.LogLevel
+	var slowLog []esv1.SlowLogThresholds
+	if spec != nil {
+		specLoggers = spec.Loggers
+		slowLog = spec.SlowLog
+	}
+
+	expected := make(map[string]interface{}, len(specLoggers))
+	for name, level := range specLoggers {
+		expected[loggerSettingPrefix+name] = string(level)
+	}
+	if previous != nil {
+		for _, name := range previous.Loggers {
+			if _, exists := specLoggers[name]; !exists {
+				expected[loggerSettingPrefix+name] = nil
+			}
+		}
+	}
+
+	var plan loggingPlan
+	var managed, pending []string
+	for key, level := range expected {
+		if !loggerConfirmed(key, level, observed) {
+			pending = append(pending, key)
+		} else if level == nil {
+			// reset to its default level, the logger is not managed anymore
+			continue
+		}
+		managed = append(managed, strings.TrimPrefix(key, loggerSettingPrefix))
+	}
+	if len(pending) > 0 {
+		plan.loggers = expected
+	}
+
+	slowLogPending := pendingSlowLogThresholds(slowLog, observed)
+	if len(slowLogPending) > 0 {
+		plan.slowLog = slowLog
+	}
+	pending = append(pending, slowLogPending...)
+
+	if len(managed) == 0 && len(slowLog) == 0 {
+		return plan
+	}
+	sort.Strings(managed)
+	sort.Strings(pending)
+	plan.status = &esv1.LoggingStatus{Loggers: managed, Applied: len(pending) == 0, Pending: pending}
+	return plan
+}
+
+// loggingObserved returns true if the logging settings of the cluster must be observed, to confirm the logger levels
+// and slow log thresholds of the specification, or the reset of the loggers previously set by the operator.
+func loggingObserved(spec *esv1.Logging, previous *esv1.LoggingStatus) bool {
+	if spec != nil && (len(spec.Loggers) > 0 || len(spec.SlowLog) > 0) {
+		return true
+	}
+	return previous != nil && len(previous.Loggers) > 0
+}
+
+// loggerConfirmed returns true if the observed level of the logger matches the expected one, or if the observed
+// logger is not set when expected to be reset.
+func loggerConfirmed(key string, level interface{}, observed *esclient.LoggingSettings) bool {
+	if observed == nil {
+		return false
+	}
+	observedLevel, set := observed.Loggers[key]
+	if level == nil {
+		return !set
+	}
+	return set && strings.EqualFold(observedLevel, level.(string))
+}
+
+// pendingSlowLogThresholds returns the slow log thresholds not set on the observed indices, as <index>/<setting>.
+// The thresholds of the entries listed last take precedence for the indices matched by several entries.
+func pendingSlowLogThresholds(slowLog []esv1.SlowLogThresholds, observed *esclient.LoggingSettings) []string {
+	var pending []string
+	if observed == nil {
+		for _, entry := range slowLog {
+			for _, index := range entry.Indices {
+				for key := range entry.Thresholds {
+					pending = append(pending, index+"/"+entry.IndexSetting(key))
+				}
+			}
+		}
+		return pending
+	}
+	for index, settings := range observed.SlowLogThresholds {
+		expected := map[string]string{}
+		for _, entry := range slowLog {
+			if !matchesAnyIndex(entry.Indices, index) {
+				continue
+			}
+			for key, value := range entry.Thresholds {
+				expected[entry.IndexSetting(key)] = value
+			}
+		}
+		for setting, value := range expected {
+			if settings[setting] != value {
+				pending = append(pending, index+"/"+setting)
+			}
+		}
+	}
+	return pending
+}
+
+func matchesAnyIndex(patterns []string, index string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, index); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// apply updates the logger levels and the slow log thresholds of the plan.
+func (p loggingPlan) apply(ctx context.Context, esClient esclient.Client) error {
+	if len(p.loggers) > 0 {
+		if err := esClient.UpdateClusterSettings(ctx, esclient.ClusterSettings{Persistent: p.loggers}); err != nil {
+			return errors.Wrap(err, "while updating logger levels")
+		}
+	}
+	for _, entry := range p.slowLog {
+		settings := make(map[string]interface{}, len(entry.Thresholds))
+		for key, value := range entry.Thresholds {
+			settings[entry.IndexSetting(key)] = value
+		}
+		if err := esClient.UpdateIndexSettings(ctx, entry.Indices, settings); err != nil {
+			return errors.Wrapf(err, "while updating slow log thresholds of indices %s", strings.Join(entry.Indices, ","))
+		}
+	}
+	return nil
+}
+
+// reconcileLogging applies the logger levels and slow log thresholds of the specification at runtime, and reports
+// in the status whether the last observation of the cluster confirms them. Reconciliation is requeued until it does.
+func (d *defaultDriver) reconcileLogging(
+	ctx context.Context,
+	esClient esclient.Client,
+	observed *esclient.LoggingSettings,
+) (reconcile.Result, error) {
+	plan := newLoggingPlan(d.ES.Spec.Logging, d.ReconcileState.Logging(), observed)
+	d.ReconcileState.UpdateLogging(plan.status)
+	if err := plan.apply(ctx, esClient); err != nil {
+		return reconcile.Result{}, err
+	}
+	if plan.status != nil && !plan.status.Applied {
+		return defaultRequeue, nil
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,115 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+func Test_newLoggingPlan(t *testing.T) {
+	spec := &esv1.Logging{
+		Loggers: map[string]esv1.LogLevel{"org.elasticsearch.discovery": "DEBUG"},
+		SlowLog: []esv1.SlowLogThresholds{
+			{Indices: []string{"logs-*"}, Thresholds: map[string]string{"search.query.warn": "10s"}},
+			{Indices: []string{"logs-app"}, Thresholds: map[string]string{"search.query.warn": "1s"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		spec     *esv1.Logging
+		previous *esv1.LoggingStatus
+		observed *esclient.LoggingSettings
+		want     loggingPlan
+	}{
+		{
+			name: "no logging settings",
+			want: loggingPlan{},
+		},
+		{
+			name: "not observed yet: everything is pending",
+			spec: spec,
+			want: loggingPlan{
+				loggers: map[string]interface{}{"logger.org.elasticsearch.discovery": "DEBUG"},
+				slowLog: spec.SlowLog,
+				status: &esv1.LoggingStatus{
+					Loggers: []string{"org.elasticsearch.discovery"},
+					Pending: []string{
+						"logger.org.elasticsearch.discovery",
+						"logs-*/index.search.slowlog.threshold.query.warn",
+						"logs-app/index.search.slowlog.threshold.query.warn",
+					},
+				},
+			},
+		},
+		{
+			name: "applied",
+			spec: spec,
+			observed: &esclient.LoggingSettings{
+				Loggers: map[string]string{"logger.org.elasticsearch.discovery": "debug"},
+				SlowLogThresholds: map[string]map[string]string{
+					"logs-app": {"index.search.slowlog.threshold.query.warn": "1s"},
+					"logs-db":  {"index.search.slowlog.threshold.query.warn": "10s"},
+					"metrics":  {},
+				},
+			},
+			want: loggingPlan{
+				status: &esv1.LoggingStatus{Loggers: []string{"org.elasticsearch.discovery"}, Applied: true},
+			},
+		},
+		{
+			name: "threshold not applied to an index, logger removed from the spec",
+			spec: &esv1.Logging{SlowLog: spec.SlowLog},
+			previous: &esv1.LoggingStatus{
+				Loggers: []string{"org.elasticsearch.discovery"},
+				Applied: true,
+			},
+			observed: &esclient.LoggingSettings{
+				Loggers: map[string]string{"logger.org.elasticsearch.discovery": "DEBUG"},
+				SlowLogThresholds: map[string]map[string]string{
+					"logs-app": {"index.search.slowlog.threshold.query.warn": "10s"},
+					"logs-db":  {"index.search.slowlog.threshold.query.warn": "10s"},
+				},
+			},
+			want: loggingPlan{
+				loggers: map[string]interface{}{"logger.org.elasticsearch.discovery": nil},
+				slowLog: spec.SlowLog,
+				status: &esv1.LoggingStatus{
+					Loggers: []string{"org.elasticsearch.discovery"},
+					Pending: []string{
+						"logger.org.elasticsearch.discovery",
+						"logs-app/index.search.slowlog.threshold.query.warn",
+					},
+				},
+			},
+		},
+		{
+			name:     "logger reset",
+			previous: &esv1.LoggingStatus{Loggers: []string{"org.elasticsearch.discovery"}, Applied: false},
+			observed: &esclient.LoggingSettings{},
+			want:     loggingPlan{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, newLoggingPlan(tt.spec, tt.previous, tt.observed))
+		})
+	}
+}
+
+func Test_loggingObserved(t *testing.T) {
+	// not observed if the operator does not manage the logging settings
+	require.False(t, loggingObserved(nil, nil))
+	require.False(t, loggingObserved(&esv1.Logging{}, &esv1.LoggingStatus{Applied: true}))
+	// observed to confirm the settings of the specification
+	require.True(t, loggingObserved(&esv1.Logging{Loggers: map[string]esv1.LogLevel{"org.elasticsearch.discovery": "DEBUG"}}, nil))
+	require.True(t, loggingObserved(&esv1.Logging{SlowLog: []esv1.SlowLogThresholds{{Indices: []string{"logs-*"}}}}, nil))
+	// observed to confirm the reset of the loggers previously set
+	require.True(t, loggingObserved(nil, &esv1.LoggingStatus{Loggers: []string{"org.elasticsearch.discovery"}}))
+}
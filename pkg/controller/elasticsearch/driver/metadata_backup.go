@@ -0,0 +1,160 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// MetadataBackupOperationAnnotation records on the metadata backup Secret the operation the backup was taken for.
+	MetadataBackupOperationAnnotation = "elasticsearch.k8s.elastic.co/metadata-backup-operation"
+	// MetadataBackupTakenAtAnnotation records on the metadata backup Secret when the backup was taken.
+	MetadataBackupTakenAtAnnotation = "elasticsearch.k8s.elastic.co/metadata-backup-taken-at"
+
+	// MetadataBackupKey is the key of the gzipped JSON document holding the metadata in the backup Secret.
+	MetadataBackupKey = "metadata.json.gz"
+
+	// maxMetadataBackupSize keeps the backup below the maximum size of a Secret.
+	maxMetadataBackupSize = 1000 * 1000
+)
+
+// composableTemplatesMinVersion is the first version of Elasticsearch with composable index templates.
+var composableTemplatesMinVersion = version.MustParse("7.8.0")
+
+// destructiveOperation returns a description of the destructive operation about to be performed on the cluster, or the
+// empty string if there is none: the deletion of the StatefulSets of NodeSets removed from the specification, or an
+// upgrade to a new major version.
+func destructiveOperation(es esv1.Elasticsearch, esVersion version.Version, actual, expected sset.StatefulSetList) (string, error) {
+	var deleted []string
+	for _, statefulSet := range actual {
+		if _, exists := expected.GetByName(statefulSet.Name); !exists {
+			deleted = append(deleted, statefulSet.Name)
+		}
+	}
+	if len(deleted) > 0 {
+		sort.Strings(deleted)
+		return "deletion of StatefulSets " + strings.Join(deleted, ", "), nil
+	}
+	target, err := version.Parse(es.Spec.Version)
+	if err != nil {
+		return "", err
+	}
+	if target.Major > esVersion.Major {
+		return fmt.Sprintf("upgrade from %s to %s", esVersion, target), nil
+	}
+	return "", nil
+}
+
+// backupMetadata stores the settings, index templates and aliases of the cluster in a Secret owned by the cluster,
+// before a destructive operation, for post-incident analysis or to apply them again. A backup is taken once per
+// operation, and replaces the previous one.
+func (d *defaultDriver) backupMetadata(
+	ctx context.Context,
+	esClient esclient.Client,
+	esVersion version.Version,
+	actual, expected sset.StatefulSetList,
+) error {
+	operation, err := destructiveOperation(d.ES, esVersion, actual, expected)
+	if err != nil || operation == "" {
+		return err
+	}
+
+	secret := corev1.Secret{}
+	nsn := types.NamespacedName{Namespace: d.ES.Namespace, Name: esv1.MetadataBackupSecret(d.ES.Name)}
+	err = d.Client.Get(nsn, &secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists && secret.Annotations[MetadataBackupOperationAnnotation] == operation {
+		// already backed up
+		return nil
+	}
+
+	log.Info("Backing up the cluster metadata", "namespace", d.ES.Namespace, "es_name", d.ES.Name, "operation", operation)
+	metadata, err := esClient.GetClusterMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	if esVersion.IsSameOrAfter(composableTemplatesMinVersion) {
+		if metadata.IndexTemplates, metadata.ComponentTemplates, err = esClient.GetComposableTemplates(ctx); err != nil {
+			return err
+		}
+	}
+	backup, err := compressMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	if len(backup) > maxMetadataBackupSize {
+		return fmt.Errorf("metadata backup of %d bytes exceeds the maximum size of a secret", len(backup))
+	}
+
+	expectedSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: nsn.Namespace,
+			Name:      nsn.Name,
+			Labels:    label.NewLabels(k8s.ExtractNamespacedName(&d.ES)),
+			Annotations: map[string]string{
+				MetadataBackupOperationAnnotation: operation,
+				MetadataBackupTakenAtAnnotation:   time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string][]byte{MetadataBackupKey: backup},
+	}
+	if err := controllerutil.SetControllerReference(&d.ES, &expectedSecret, scheme.Scheme); err != nil {
+		return err
+	}
+	if !exists {
+		err = d.Client.Create(&expectedSecret)
+	} else {
+		secret.Labels = expectedSecret.Labels
+		secret.Annotations = expectedSecret.Annotations
+		secret.OwnerReferences = expectedSecret.OwnerReferences
+		secret.Data = expectedSecret.Data
+		err = d.Client.Update(&secret)
+	}
+	if err != nil {
+		return err
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonCreated,
+		fmt.Sprintf("Backed up the cluster metadata in secret %s before the %s", nsn.Name, operation))
+	return nil
+}
+
+// compressMetadata returns the given metadata as a gzipped JSON document.
+func compressMetadata(metadata esclient.ClusterMetadata) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if err := json.NewEncoder(writer).Encode(metadata); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
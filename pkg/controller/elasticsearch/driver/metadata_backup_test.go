@@ -0,0 +1,153 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_destructiveOperation(t *testing.T) {
+	hot := sset.TestSset{Namespace: "ns", Name: "hot", ClusterName: "es"}.Build()
+	warm := sset.TestSset{Namespace: "ns", Name: "warm", ClusterName: "es"}.Build()
+	cold := sset.TestSset{Namespace: "ns", Name: "cold", ClusterName: "es"}.Build()
+	tests := []struct {
+		name        string
+		esVersion   string
+		specVersion string
+		actual      sset.StatefulSetList
+		expected    sset.StatefulSetList
+		want        string
+	}{
+		{
+			name:        "no change",
+			esVersion:   "7.10.0",
+			specVersion: "7.10.0",
+			actual:      sset.StatefulSetList{hot, warm},
+			expected:    sset.StatefulSetList{hot, warm},
+		},
+		{
+			name:        "new NodeSet and minor upgrade",
+			esVersion:   "7.10.0",
+			specVersion: "7.17.0",
+			actual:      sset.StatefulSetList{hot},
+			expected:    sset.StatefulSetList{hot, warm},
+		},
+		{
+			name:        "NodeSets deleted",
+			esVersion:   "7.10.0",
+			specVersion: "7.10.0",
+			actual:      sset.StatefulSetList{warm, hot, cold},
+			expected:    sset.StatefulSetList{hot},
+			want:        "deletion of StatefulSets cold, warm",
+		},
+		{
+			name:        "major upgrade",
+			esVersion:   "7.17.0",
+			specVersion: "8.1.0",
+			actual:      sset.StatefulSetList{hot},
+			expected:    sset.StatefulSetList{hot},
+			want:        "upgrade from 7.17.0 to 8.1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{Version: tt.specVersion}}
+			got, err := destructiveOperation(es, version.MustParse(tt.esVersion), tt.actual, tt.expected)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_defaultDriver_backupMetadata(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{Version: "7.10.0"},
+	}
+	hot := sset.TestSset{Namespace: "ns", Name: "es-es-hot", ClusterName: "es"}.Build()
+	warm := sset.TestSset{Namespace: "ns", Name: "es-es-warm", ClusterName: "es"}.Build()
+	responses := map[string]string{
+		"/_cluster/settings":   `{"persistent":{"action.auto_create_index":"false"},"transient":{}}`,
+		"/_template":           `{"logs":{"index_patterns":["logs-*"]}}`,
+		"/_alias":              `{"logs-1":{"aliases":{"logs":{}}}}`,
+		"/_index_template":     `{"index_templates":[]}`,
+		"/_component_template": `{"component_templates":[]}`,
+	}
+	requests := 0
+	esClient := esclient.NewMockClient(version.MustParse("7.10.0"), func(req *http.Request) *http.Response {
+		response, exists := responses[req.URL.Path]
+		require.True(t, exists, req.URL.Path)
+		requests++
+		return esclient.NewMockResponse(200, req, response)
+	})
+	k8sClient := k8s.WrappedFakeClient(&es)
+	d := &defaultDriver{DefaultDriverParameters{
+		Client:         k8sClient,
+		ES:             es,
+		ReconcileState: reconcile.NewState(es),
+	}}
+	backupMetadata := func(actual, expected sset.StatefulSetList) {
+		require.NoError(t, d.backupMetadata(context.Background(), esClient, version.MustParse("7.10.0"), actual, expected))
+	}
+	backupSecret := func() (corev1.Secret, error) {
+		var secret corev1.Secret
+		err := k8sClient.Get(types.NamespacedName{Namespace: "ns", Name: esv1.MetadataBackupSecret("es")}, &secret)
+		return secret, err
+	}
+
+	// no destructive operation: no backup
+	backupMetadata(sset.StatefulSetList{hot, warm}, sset.StatefulSetList{hot, warm})
+	_, err := backupSecret()
+	require.Error(t, err)
+	require.Equal(t, 0, requests)
+
+	// NodeSet deletion: the metadata is backed up once
+	backupMetadata(sset.StatefulSetList{hot, warm}, sset.StatefulSetList{hot})
+	secret, err := backupSecret()
+	require.NoError(t, err)
+	require.Equal(t, "deletion of StatefulSets es-es-warm", secret.Annotations[MetadataBackupOperationAnnotation])
+	require.NotEmpty(t, secret.Annotations[MetadataBackupTakenAtAnnotation])
+	require.Equal(t, "es", secret.OwnerReferences[0].Name)
+	require.Equal(t, len(responses), requests)
+	require.Len(t, d.ReconcileState.Events(), 1)
+
+	reader, err := gzip.NewReader(bytes.NewReader(secret.Data[MetadataBackupKey]))
+	require.NoError(t, err)
+	var metadata esclient.ClusterMetadata
+	require.NoError(t, json.NewDecoder(reader).Decode(&metadata))
+	require.JSONEq(t, responses["/_cluster/settings"], string(metadata.Settings))
+	require.JSONEq(t, responses["/_template"], string(metadata.Templates))
+	require.JSONEq(t, responses["/_alias"], string(metadata.Aliases))
+	require.JSONEq(t, responses["/_index_template"], string(metadata.IndexTemplates))
+	require.JSONEq(t, responses["/_component_template"], string(metadata.ComponentTemplates))
+
+	backupMetadata(sset.StatefulSetList{hot, warm}, sset.StatefulSetList{hot})
+	require.Equal(t, len(responses), requests)
+
+	// another destructive operation replaces the backup
+	d.ES.Spec.Version = "8.0.0"
+	backupMetadata(sset.StatefulSetList{hot}, sset.StatefulSetList{hot})
+	secret, err = backupSecret()
+	require.NoError(t, err)
+	require.Equal(t, "upgrade from 7.10.0 to 8.0.0", secret.Annotations[MetadataBackupOperationAnnotation])
+	require.Equal(t, 2*len(responses), requests)
+}
@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+)
+
+// MLUpgradeModeAnnotationName is set on the Elasticsearch resource while the operator keeps the machine learning
+// upgrade mode enabled. Upgrade mode enabled by users through the API, without the annotation, is left untouched.
+const MLUpgradeModeAnnotationName = "elasticsearch.k8s.elastic.co/ml-upgrade-mode"
+
+// mlUpgradeModeMinVersion is the first version of Elasticsearch supporting the machine learning upgrade mode.
+var mlUpgradeModeMinVersion = version.MustParse("6.7.0")
+
+// hasMLNode returns true if one of the given Pods is a machine learning node.
+func hasMLNode(pods []corev1.Pod) bool {
+	for _, pod := range pods {
+		if label.IsMLNode(pod) {
+			return true
+		}
+	}
+	return false
+}
+
+// maybeEnableMLUpgradeMode enables the machine learning upgrade mode before the first machine learning node to
+// upgrade is restarted. Datafeeds and anomaly detection jobs are paused, rather than failing or being reassigned to
+// other nodes which are about to be restarted as well.
+func (d *defaultDriver) maybeEnableMLUpgradeMode(
+	ctx context.Context,
+	esClient esclient.Client,
+	esVersion version.Version,
+	podsToUpgrade []corev1.Pod,
+) error {
+	if _, enabled := d.ES.Annotations[MLUpgradeModeAnnotationName]; enabled ||
+		!hasMLNode(podsToUpgrade) || !esVersion.IsSameOrAfter(mlUpgradeModeMinVersion) {
+		return nil
+	}
+	log.Info("Enabling machine learning upgrade mode", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+	if err := setMLUpgradeMode(ctx, esClient, true); err != nil {
+		if esclient.IsForbidden(err) {
+			// machine learning is not available with the current license, there is no job to pause
+			log.V(1).Info("Machine learning upgrade mode not available", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+			return nil
+		}
+		return err
+	}
+	if d.ES.Annotations == nil {
+		d.ES.Annotations = map[string]string{}
+	}
+	d.ES.Annotations[MLUpgradeModeAnnotationName] = "true"
+	return d.Client.Update(&d.ES)
+}
+
+// MaybeDisableMLUpgradeMode disables the machine learning upgrade mode enabled by the operator, once all the machine
+// learning nodes are upgraded and back in the cluster. Datafeeds and anomaly detection jobs then resume.
+func (d *defaultDriver) MaybeDisableMLUpgradeMode(
+	ctx context.Context,
+	esClient esclient.Client,
+	esState ESState,
+	statefulSets sset.StatefulSetList,
+	podsToUpgrade []corev1.Pod,
+) *reconciler.Results {
+	results := &reconciler.Results{}
+	if _, enabled := d.ES.Annotations[MLUpgradeModeAnnotationName]; !enabled || hasMLNode(podsToUpgrade) {
+		return results
+	}
+
+	nodesInCluster, err := esState.NodesInCluster(statefulSets.PodNames())
+	if err != nil {
+		return results.WithError(err)
+	}
+	if !nodesInCluster {
+		log.V(1).Info(
+			"Some upgraded nodes are not back in the cluster yet, keeping machine learning upgrade mode enabled",
+			"namespace", d.ES.Namespace,
+			"es_name", d.ES.Name,
+		)
+		return results.WithResult(defaultRequeue)
+	}
+
+	log.Info("Disabling machine learning upgrade mode", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+	if err := setMLUpgradeMode(ctx, esClient, false); err != nil {
+		return results.WithError(err)
+	}
+	delete(d.ES.Annotations, MLUpgradeModeAnnotationName)
+	return results.WithError(d.Client.Update(&d.ES))
+}
+
+func setMLUpgradeMode(ctx context.Context, esClient esclient.Client, enabled bool) error {
+	ctx, cancel := context.WithTimeout(ctx, esclient.DefaultReqTimeout)
+	defer cancel()
+	return esClient.SetMLUpgradeMode(ctx, enabled)
+}
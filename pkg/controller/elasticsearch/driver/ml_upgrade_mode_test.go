@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func podWithMLRole(name string, ml bool) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Labels: label.NodeTypesMLLabelName.AsMap(ml)}}
+}
+
+func mlUpgradeModeES(enabled bool) esv1.Elasticsearch {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	if enabled {
+		es.Annotations = map[string]string{MLUpgradeModeAnnotationName: "true"}
+	}
+	return es
+}
+
+func Test_defaultDriver_maybeEnableMLUpgradeMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		esVersion      string
+		podsToUpgrade  []corev1.Pod
+		wantCalledWith []bool
+		wantEnabled    bool
+	}{
+		{
+			name:          "no machine learning node to upgrade",
+			esVersion:     "7.6.0",
+			podsToUpgrade: []corev1.Pod{podWithMLRole("data-0", false)},
+		},
+		{
+			name:           "machine learning node to upgrade",
+			esVersion:      "7.6.0",
+			podsToUpgrade:  []corev1.Pod{podWithMLRole("data-0", false), podWithMLRole("ml-0", true)},
+			wantCalledWith: []bool{true},
+			wantEnabled:    true,
+		},
+		{
+			name:          "upgrade mode already enabled",
+			enabled:       true,
+			esVersion:     "7.6.0",
+			podsToUpgrade: []corev1.Pod{podWithMLRole("ml-0", true)},
+			wantEnabled:   true,
+		},
+		{
+			name:          "upgrade mode not supported",
+			esVersion:     "6.6.2",
+			podsToUpgrade: []corev1.Pod{podWithMLRole("ml-0", true)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := mlUpgradeModeES(tt.enabled)
+			esClient := &fakeESClient{}
+			d := &defaultDriver{DefaultDriverParameters{ES: es, Client: k8s.WrappedFakeClient(&es)}}
+			err := d.maybeEnableMLUpgradeMode(context.Background(), esClient, version.MustParse(tt.esVersion), tt.podsToUpgrade)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCalledWith, esClient.SetMLUpgradeModeCalledWith)
+			var updated esv1.Elasticsearch
+			require.NoError(t, d.Client.Get(k8s.ExtractNamespacedName(&es), &updated))
+			_, enabled := updated.Annotations[MLUpgradeModeAnnotationName]
+			require.Equal(t, tt.wantEnabled, enabled)
+		})
+	}
+}
+
+func Test_defaultDriver_MaybeDisableMLUpgradeMode(t *testing.T) {
+	statefulSets := sset.StatefulSetList{sset.TestSset{Namespace: "ns", Name: "ml", ClusterName: "es", Replicas: 1}.Build()}
+	tests := []struct {
+		name           string
+		enabled        bool
+		inCluster      []string
+		podsToUpgrade  []corev1.Pod
+		wantCalledWith []bool
+		wantEnabled    bool
+		wantRequeue    bool
+	}{
+		{
+			name:      "upgrade mode not enabled by the operator",
+			inCluster: []string{"ml-0"},
+		},
+		{
+			name:          "machine learning nodes still to upgrade",
+			enabled:       true,
+			inCluster:     []string{"ml-0"},
+			podsToUpgrade: []corev1.Pod{podWithMLRole("ml-0", true)},
+			wantEnabled:   true,
+		},
+		{
+			name:        "upgraded nodes not back in the cluster",
+			enabled:     true,
+			wantEnabled: true,
+			wantRequeue: true,
+		},
+		{
+			name:           "machine learning nodes upgraded",
+			enabled:        true,
+			inCluster:      []string{"ml-0"},
+			podsToUpgrade:  []corev1.Pod{podWithMLRole("data-0", false)},
+			wantCalledWith: []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := mlUpgradeModeES(tt.enabled)
+			esClient := &fakeESClient{}
+			d := &defaultDriver{DefaultDriverParameters{ES: es, Client: k8s.WrappedFakeClient(&es)}}
+			results := d.MaybeDisableMLUpgradeMode(
+				context.Background(), esClient, &testESState{inCluster: tt.inCluster}, statefulSets, tt.podsToUpgrade)
+			res, err := results.Aggregate()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequeue, res.Requeue || res.RequeueAfter > 0)
+			require.Equal(t, tt.wantCalledWith, esClient.SetMLUpgradeModeCalledWith)
+			var updated esv1.Elasticsearch
+			require.NoError(t, d.Client.Get(k8s.ExtractNamespacedName(&es), &updated))
+			_, enabled := updated.Annotations[MLUpgradeModeAnnotationName]
+			require.Equal(t, tt.wantEnabled, enabled)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// reconcileSuspendedPods reports the state of the Pods listed in spec.nodeDebug.suspendedPods in the status, and
+// restarts the listed Pods running Elasticsearch for them to be held in the suspend init container. Pods created from
+// a specification without the suspend init container, before spec.nodeDebug was set, are left untouched until they
+// are upgraded, unless an unsafe recovery is confirmed: the cluster lost its quorum and cannot be upgraded, they are
+// then restarted once their StatefulSet runs the suspend init container.
+func (d *defaultDriver) reconcileSuspendedPods(pods []corev1.Pod) error {
+	suspended := d.ES.Spec.NodeDebug
+	if suspended == nil || len(suspended.SuspendedPods) == 0 {
+		d.ReconcileState.UpdateSuspendedPods(nil)
+		return nil
+	}
+	var suspendingStatefulSets map[string]bool
+	if d.isUnsafeRecoveryConfirmed() {
+		var err error
+		if suspendingStatefulSets, err = d.statefulSetsWithSuspendInitContainer(); err != nil {
+			return err
+		}
+	}
+	podsByName := make(map[string]corev1.Pod, len(pods))
+	for _, pod := range pods {
+		podsByName[pod.Name] = pod
+	}
+	status := make([]esv1.SuspendedPodStatus, 0, len(suspended.SuspendedPods))
+	for _, suspendedPod := range suspended.SuspendedPods {
+		pod, exists := podsByName[suspendedPod.Name]
+		status = append(status, esv1.SuspendedPodStatus{
+			Name:      suspendedPod.Name,
+			Reason:    suspendedPod.Reason,
+			Suspended: exists && isSuspended(pod),
+		})
+		if !exists || pod.DeletionTimestamp != nil {
+			continue
+		}
+		// Elasticsearch may not be running in a Pod without the suspend init container, if it cannot join the cluster
+		outdated := !hasSuspendInitContainer(pod) && suspendingStatefulSets[pod.Labels[label.StatefulSetNameLabelName]]
+		if !outdated && (!hasSuspendInitContainer(pod) || !isElasticsearchRunning(pod)) {
+			continue
+		}
+		log.Info("Restarting Pod to suspend it", "es_name", d.ES.Name, "namespace", d.ES.Namespace, "pod_name", pod.Name)
+		// make sure to restart the observed Pod, not a Pod recreated in the meantime
+		opt := client.Preconditions{UID: &pod.UID, ResourceVersion: &pod.ResourceVersion}
+		if err := d.Client.Delete(&pod, opt); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		d.Expectations.ExpectDeletion(pod)
+		msg := fmt.Sprintf("Restarting Pod %s to suspend it", pod.Name)
+		if suspendedPod.Reason != "" {
+			msg = fmt.Sprintf("%s: %s", msg, suspendedPod.Reason)
+		}
+		d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonRestart, msg)
+	}
+	d.ReconcileState.UpdateSuspendedPods(status)
+	return nil
+}
+
+// statefulSetsWithSuspendInitContainer returns the names of the StatefulSets of the cluster whose Pod template runs the
+// suspend init container.
+func (d *defaultDriver) statefulSetsWithSuspendInitContainer() (map[string]bool, error) {
+	statefulSets, err := sset.RetrieveActualStatefulSets(d.Client, k8s.ExtractNamespacedName(&d.ES))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(statefulSets))
+	for _, statefulSet := range statefulSets {
+		for _, c := range statefulSet.Spec.Template.Spec.InitContainers {
+			if c.Name == initcontainer.SuspendContainerName {
+				names[statefulSet.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// hasSuspendInitContainer returns true if the given Pod runs the suspend init container before Elasticsearch.
+func hasSuspendInitContainer(pod corev1.Pod) bool {
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == initcontainer.SuspendContainerName {
+			return true
+		}
+	}
+	return false
+}
+
+// isSuspended returns true if the given Pod is held in the suspend init container.
+func isSuspended(pod corev1.Pod) bool {
+	for _, s := range pod.Status.InitContainerStatuses {
+		if s.Name == initcontainer.SuspendContainerName {
+			return s.State.Running != nil
+		}
+	}
+	return false
+}
+
+// isElasticsearchRunning returns true if the Elasticsearch container of the given Pod is started.
+func isElasticsearchRunning(pod corev1.Pod) bool {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == esv1.ElasticsearchContainerName {
+			return s.State.Running != nil
+		}
+	}
+	return false
+}
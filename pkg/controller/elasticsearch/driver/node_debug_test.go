@@ -0,0 +1,187 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+func suspendablePod(name string, initContainerRunning bool, esRunning bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: initcontainer.SuspendContainerName}},
+		},
+	}
+	if initContainerRunning {
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+			{Name: initcontainer.SuspendContainerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		}
+	}
+	if esRunning {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{Name: esv1.ElasticsearchContainerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		}
+	}
+	return pod
+}
+
+func Test_defaultDriver_reconcileSuspendedPods(t *testing.T) {
+	withoutSuspendInitContainer := suspendablePod("es-default-3", false, true)
+	withoutSuspendInitContainer.Spec.InitContainers = nil
+	pods := []corev1.Pod{
+		suspendablePod("es-default-0", false, true),
+		suspendablePod("es-default-1", true, false),
+		suspendablePod("es-default-2", false, true),
+		withoutSuspendInitContainer,
+	}
+	tests := []struct {
+		name          string
+		nodeDebug     *esv1.NodeDebug
+		wantStatus    []esv1.SuspendedPodStatus
+		wantRestarted []string
+	}{
+		{
+			name: "no suspended Pod",
+		},
+		{
+			name: "suspend Pods",
+			nodeDebug: &esv1.NodeDebug{SuspendedPods: []esv1.SuspendedPod{
+				{Name: "es-default-0", Reason: "fsck"},
+				{Name: "es-default-1", Reason: "fsck"},
+				{Name: "es-default-3"},
+				{Name: "es-default-4"},
+			}},
+			wantStatus: []esv1.SuspendedPodStatus{
+				{Name: "es-default-0", Reason: "fsck", Suspended: false},
+				{Name: "es-default-1", Reason: "fsck", Suspended: true},
+				{Name: "es-default-3", Suspended: false},
+				{Name: "es-default-4", Suspended: false},
+			},
+			wantRestarted: []string{"es-default-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []runtime.Object
+			for i := range pods {
+				objs = append(objs, &pods[i])
+			}
+			c := k8s.WrappedFakeClient(objs...)
+			es := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{NodeDebug: tt.nodeDebug}}
+			d := &defaultDriver{DefaultDriverParameters{
+				ES:             es,
+				Client:         c,
+				Expectations:   expectations.NewExpectations(c),
+				ReconcileState: reconcile.NewState(es),
+			}}
+
+			require.NoError(t, d.reconcileSuspendedPods(pods))
+			require.Equal(t, tt.wantStatus, d.ReconcileState.SuspendedPods())
+			require.Len(t, d.ReconcileState.Events(), len(tt.wantRestarted))
+			for _, pod := range pods {
+				var current corev1.Pod
+				err := c.Get(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}, &current)
+				restarted := apierrors.IsNotFound(err)
+				require.Equal(t, stringsutil.StringInSlice(pod.Name, tt.wantRestarted), restarted, pod.Name)
+			}
+		})
+	}
+}
+
+func Test_defaultDriver_reconcileSuspendedPods_UnsafeRecovery(t *testing.T) {
+	// Pods created before spec.nodeDebug was set, in a cluster that lost its quorum
+	outdatedPod := func(name string, ssetName string) corev1.Pod {
+		pod := suspendablePod(name, false, false)
+		pod.Labels = map[string]string{label.StatefulSetNameLabelName: ssetName}
+		pod.Spec.InitContainers = nil
+		return pod
+	}
+	pods := []corev1.Pod{
+		outdatedPod("es-es-master-0", "es-es-master"),
+		outdatedPod("es-es-master-1", "es-es-master"),
+		outdatedPod("es-es-data-0", "es-es-data"),
+	}
+	// the StatefulSet of the master nodes already runs the suspend init container, not the one of the data nodes
+	masterSset := sset.TestSset{Namespace: "ns", Name: "es-es-master", ClusterName: "es"}.Build()
+	masterSset.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: initcontainer.SuspendContainerName}}
+	dataSset := sset.TestSset{Namespace: "ns", Name: "es-es-data", ClusterName: "es"}.Build()
+	nodeDebug := &esv1.NodeDebug{SuspendedPods: []esv1.SuspendedPod{
+		{Name: "es-es-master-0"}, {Name: "es-es-master-1"}, {Name: "es-es-data-0"},
+	}}
+
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		wantRestarted []string
+	}{
+		{
+			name: "no unsafe recovery: the Pods are restarted by the rolling upgrade",
+		},
+		{
+			name:        "unconfirmed unsafe recovery",
+			annotations: map[string]string{UnsafeRecoveryAnnotationName: "es-es-master-0"},
+		},
+		{
+			name: "confirmed unsafe recovery: the Pods of the StatefulSets with the suspend init container are restarted",
+			annotations: map[string]string{
+				UnsafeRecoveryAnnotationName:             "es-es-master-0",
+				UnsafeRecoveryConfirmationAnnotationName: UnsafeRecoveryConfirmation,
+			},
+			wantRestarted: []string{"es-es-master-0", "es-es-master-1"},
+		},
+		{
+			name: "completed unsafe recovery",
+			annotations: map[string]string{
+				UnsafeRecoveryAnnotationName:             "es-es-master-0",
+				UnsafeRecoveryConfirmationAnnotationName: UnsafeRecoveryConfirmation,
+				UnsafeRecoveryCompletedAnnotationName:    "es-es-master-0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{&masterSset, &dataSset}
+			for i := range pods {
+				objs = append(objs, &pods[i])
+			}
+			c := k8s.WrappedFakeClient(objs...)
+			es := esv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: tt.annotations},
+				Spec:       esv1.ElasticsearchSpec{NodeDebug: nodeDebug},
+			}
+			d := &defaultDriver{DefaultDriverParameters{
+				ES:             es,
+				Client:         c,
+				Expectations:   expectations.NewExpectations(c),
+				ReconcileState: reconcile.NewState(es),
+			}}
+
+			require.NoError(t, d.reconcileSuspendedPods(pods))
+			for _, pod := range pods {
+				var current corev1.Pod
+				err := c.Get(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}, &current)
+				restarted := apierrors.IsNotFound(err)
+				require.Equal(t, stringsutil.StringInSlice(pod.Name, tt.wantRestarted), restarted, pod.Name)
+			}
+		})
+	}
+}
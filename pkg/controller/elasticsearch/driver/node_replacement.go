@@ -0,0 +1,282 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// ReplaceNodeAnnotationName can be set to "true" on an Elasticsearch Pod to replace the node with a new one on
+	// fresh storage, for example when its disk is faulty. The data of the node is migrated away, then the Pod and its
+	// PersistentVolumeClaims are deleted, and recreated by the StatefulSet.
+	ReplaceNodeAnnotationName = "elasticsearch.k8s.elastic.co/replace-node"
+	// NodeReplacementsAnnotationName records on the Elasticsearch resource the nodes whose Pod and
+	// PersistentVolumeClaims were deleted, until they are recreated and back in the cluster.
+	NodeReplacementsAnnotationName = "elasticsearch.k8s.elastic.co/node-replacements"
+
+	// NodeReplacementConditionType is the type of the condition reporting the progress of the replacement of the
+	// nodes, while some are replaced.
+	NodeReplacementConditionType commonv1.ConditionType = "NodeReplacement"
+	// ReasonDrainingNodes is the reason of the condition while the data of the nodes to replace is migrated away.
+	ReasonDrainingNodes = "DrainingNodes"
+	// ReasonReplacingNodes is the reason of the condition while some replaced nodes are not back in the cluster.
+	ReasonReplacingNodes = "ReplacingNodes"
+)
+
+// nodesToReplace returns the sorted names of the given Pods annotated for replacement.
+func nodesToReplace(pods []corev1.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		if pod.Annotations[ReplaceNodeAnnotationName] == "true" && pod.DeletionTimestamp.IsZero() {
+			names = append(names, pod.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withNodesToReplace returns the given leaving nodes with the nodes to replace, whose data must be migrated away too.
+func withNodesToReplace(leavingNodes []string, pods []corev1.Pod) []string {
+	for _, name := range nodesToReplace(pods) {
+		if !stringsutil.StringInSlice(name, leavingNodes) {
+			leavingNodes = append(leavingNodes, name)
+		}
+	}
+	return leavingNodes
+}
+
+// replacedNodes returns the nodes recorded as being replaced in the annotations of the given cluster.
+func replacedNodes(es esv1.Elasticsearch) []string {
+	value := es.Annotations[NodeReplacementsAnnotationName]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// reconcileNodeReplacements replaces the nodes whose Pod is annotated with ReplaceNodeAnnotationName, one at a time,
+// once their data is migrated away by the allocation filters set during the downscale phase. The PersistentVolumeClaims
+// of the Pod are deleted before the Pod itself, so that the StatefulSet recreates both.
+func (d *defaultDriver) reconcileNodeReplacements(
+	ctx context.Context,
+	shardLister esclient.ShardLister,
+	esState ESState,
+	pods []corev1.Pod,
+) *reconciler.Results {
+	results := &reconciler.Results{}
+	replaced := replacedNodes(d.ES)
+	var toReplace []string
+	for _, name := range nodesToReplace(pods) {
+		if !stringsutil.StringInSlice(name, replaced) {
+			toReplace = append(toReplace, name)
+		}
+	}
+	if len(replaced) == 0 && len(toReplace) == 0 {
+		d.ReconcileState.RemoveCondition(NodeReplacementConditionType)
+		return results
+	}
+	podsByName := make(map[string]corev1.Pod, len(pods))
+	for _, pod := range pods {
+		podsByName[pod.Name] = pod
+	}
+
+	// follow up the replacements in progress
+	var replacing []string
+	for _, name := range replaced {
+		pod, exists := podsByName[name]
+		done, err := d.nodeReplaced(esState, pod, exists)
+		if err != nil {
+			return withESError(results, d.ES, err)
+		}
+		if !done {
+			replacing = append(replacing, name)
+		}
+	}
+
+	// replace the next drained node, if no other replacement is in progress
+	remaining := map[string]migration.RemainingData{}
+	if len(toReplace) > 0 {
+		var err error
+		if remaining, err = migration.RemainingDataByNode(ctx, shardLister, toReplace); err != nil {
+			return withESError(results, d.ES, err)
+		}
+	}
+	var draining []string
+	for _, name := range toReplace {
+		if reason := nodeReplacementBlocker(podsByName[name], pods); reason != "" {
+			draining = append(draining, fmt.Sprintf("%s (%s)", name, reason))
+			continue
+		}
+		if remaining[name].Shards > 0 || len(replacing) > 0 {
+			draining = append(draining, fmt.Sprintf("%s (%d shards remaining)", name, remaining[name].Shards))
+			continue
+		}
+		replacing = append(replacing, name)
+		if err := d.updateNodeReplacements(replacing); err != nil {
+			return results.WithError(err)
+		}
+		if err := d.replaceNode(podsByName[name]); err != nil {
+			return results.WithError(err)
+		}
+	}
+	if err := d.updateNodeReplacements(replacing); err != nil {
+		return results.WithError(err)
+	}
+	if len(draining) == 0 && len(replacing) == 0 {
+		d.ReconcileState.RemoveCondition(NodeReplacementConditionType)
+		return results
+	}
+
+	d.ReconcileState.UpdateCondition(nodeReplacementCondition(draining, replacing))
+	return results.WithResult(defaultRequeue)
+}
+
+// nodeReplacementBlocker returns why the given Pod cannot be replaced, or the empty string if it can. Master nodes are
+// only replaced if at least two other master nodes keep the cluster state while the node starts on fresh storage.
+func nodeReplacementBlocker(pod corev1.Pod, pods []corev1.Pod) string {
+	if !label.IsMasterNode(pod) {
+		return ""
+	}
+	var masters int
+	for _, p := range pods {
+		if label.IsMasterNode(p) {
+			masters++
+		}
+	}
+	if masters < 3 {
+		return fmt.Sprintf("master node, %d master nodes in the cluster, at least 3 required", masters)
+	}
+	return ""
+}
+
+// nodeReplaced returns true if the given replaced node was recreated and is back in the cluster. A Pod recreated by
+// the StatefulSet before the deletion of its previous PersistentVolumeClaims completed is deleted again.
+func (d *defaultDriver) nodeReplaced(esState ESState, pod corev1.Pod, exists bool) (bool, error) {
+	if !exists || !pod.DeletionTimestamp.IsZero() || pod.Annotations[ReplaceNodeAnnotationName] == "true" {
+		// not recreated yet
+		return false, nil
+	}
+	claims, err := d.claimNames(pod)
+	if err != nil {
+		return false, err
+	}
+	for _, claim := range claims {
+		var pvc corev1.PersistentVolumeClaim
+		err := d.Client.Get(types.NamespacedName{Namespace: pod.Namespace, Name: claim}, &pvc)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if (errors.IsNotFound(err) || !pvc.DeletionTimestamp.IsZero()) && pod.Status.Phase == corev1.PodPending {
+			log.Info("Deleting pod recreated before its volume was replaced",
+				"namespace", pod.Namespace, "es_name", d.ES.Name, "pod_name", pod.Name, "pvc_name", claim)
+			return false, deletePod(d.Client, d.ES, pod, d.Expectations)
+		}
+	}
+	if !k8s.IsPodReady(pod) {
+		return false, nil
+	}
+	return esState.NodesInCluster([]string{pod.Name})
+}
+
+// replaceNode deletes the PersistentVolumeClaims of the given Pod created from the volume claim templates of its
+// StatefulSet, then the Pod itself. The claims are only removed
+// once the Pod is deleted, since they are in use.
+func (d *defaultDriver) replaceNode(pod corev1.Pod) error {
+	log.Info("Replacing node", "namespace", pod.Namespace, "es_name", d.ES.Name, "pod_name", pod.Name)
+	claims, err := d.claimNames(pod)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		var pvc corev1.PersistentVolumeClaim
+		err := d.Client.Get(types.NamespacedName{Namespace: pod.Namespace, Name: claim}, &pvc)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.Client.Delete(&pvc); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonDeleted,
+		fmt.Sprintf("Replacing node %s with a new one on fresh storage", pod.Name))
+	return deletePod(d.Client, d.ES, pod, d.Expectations)
+}
+
+// updateNodeReplacements records the given nodes being replaced in the annotations of the cluster, if they changed.
+func (d *defaultDriver) updateNodeReplacements(replacing []string) error {
+	value := strings.Join(replacing, ",")
+	if value == d.ES.Annotations[NodeReplacementsAnnotationName] {
+		return nil
+	}
+	if value == "" {
+		delete(d.ES.Annotations, NodeReplacementsAnnotationName)
+	} else {
+		if d.ES.Annotations == nil {
+			d.ES.Annotations = map[string]string{}
+		}
+		d.ES.Annotations[NodeReplacementsAnnotationName] = value
+	}
+	return d.Client.Update(&d.ES)
+}
+
+// nodeReplacementCondition returns the condition reporting the progress of the replacement of the given nodes.
+func nodeReplacementCondition(draining, replacing []string) commonv1.Condition {
+	reason := ReasonDrainingNodes
+	var messages []string
+	if len(replacing) > 0 {
+		reason = ReasonReplacingNodes
+		messages = append(messages, "Waiting for the replaced nodes to join the cluster: "+strings.Join(replacing, ", "))
+	}
+	if len(draining) > 0 {
+		messages = append(messages, "Migrating data away from the nodes to replace: "+strings.Join(draining, ", "))
+	}
+	return commonv1.Condition{
+		Type:    NodeReplacementConditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: strings.Join(messages, ". "),
+	}
+}
+
+// claimNames returns the names of the PersistentVolumeClaims of the given Pod created from the volume claim templates
+// of its StatefulSet. The other claims mounted by the Pod, such as the claims shared by all the nodes for the plugins,
+// the GeoIP databases or the diagnostics, are not specific to the node and must not be replaced with it.
+func (d *defaultDriver) claimNames(pod corev1.Pod) ([]string, error) {
+	var statefulSet appsv1.StatefulSet
+	err := d.Client.Get(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[label.StatefulSetNameLabelName]}, &statefulSet)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(statefulSet.Spec.VolumeClaimTemplates))
+	for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		names = append(names, fmt.Sprintf("%s-%s", claim.Name, pod.Name))
+	}
+	return names, nil
+}
@@ -0,0 +1,178 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// replacementPod returns a ready Pod using the PersistentVolumeClaim of its StatefulSet and a PersistentVolumeClaim
+// shared by all the Pods, annotated for replacement if replace is true.
+func replacementPod(name string, master, replace bool) *corev1.Pod {
+	pod := sset.TestPod{Namespace: "ns", Name: name, ClusterName: "es", StatefulSetName: "default", Master: master, Ready: true}.Build()
+	pod.Spec.Volumes = []corev1.Volume{
+		{
+			Name: "elasticsearch-data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "elasticsearch-data-" + name},
+			},
+		},
+		{
+			Name: "plugins",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: sharedPVC.Name, ReadOnly: true},
+			},
+		},
+	}
+	if replace {
+		pod.Annotations = map[string]string{ReplaceNodeAnnotationName: "true"}
+	}
+	return &pod
+}
+
+// sharedPVC is a PersistentVolumeClaim mounted by all the Pods, which is not replaced with the nodes.
+var sharedPVC = corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-plugins"}}
+
+func replacementPVC(podName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "elasticsearch-data-" + podName}}
+}
+
+func Test_defaultDriver_reconcileNodeReplacements(t *testing.T) {
+	tests := []struct {
+		name          string
+		replacements  string
+		pods          []*corev1.Pod
+		shards        esclient.Shards
+		inCluster     []string
+		wantDeleted   []string
+		wantReplacing string
+		wantReason    string
+	}{
+		{
+			name: "no node to replace",
+			pods: []*corev1.Pod{replacementPod("default-0", false, false)},
+		},
+		{
+			name:       "data still on the node to replace",
+			pods:       []*corev1.Pod{replacementPod("default-0", false, true), replacementPod("default-1", false, false)},
+			shards:     esclient.Shards{{Index: "index", NodeName: "default-0"}},
+			wantReason: ReasonDrainingNodes,
+		},
+		{
+			name:          "drained node replaced",
+			pods:          []*corev1.Pod{replacementPod("default-0", false, true), replacementPod("default-1", false, false)},
+			shards:        esclient.Shards{{Index: "index", NodeName: "default-1"}},
+			wantDeleted:   []string{"default-0"},
+			wantReplacing: "default-0",
+			wantReason:    ReasonReplacingNodes,
+		},
+		{
+			name:          "only one node replaced at a time",
+			pods:          []*corev1.Pod{replacementPod("default-0", false, true), replacementPod("default-1", false, true)},
+			wantDeleted:   []string{"default-0"},
+			wantReplacing: "default-0",
+			wantReason:    ReasonReplacingNodes,
+		},
+		{
+			name:          "replaced node not back in the cluster",
+			replacements:  "default-0",
+			pods:          []*corev1.Pod{replacementPod("default-0", false, false), replacementPod("default-1", false, false)},
+			inCluster:     []string{"default-1"},
+			wantReplacing: "default-0",
+			wantReason:    ReasonReplacingNodes,
+		},
+		{
+			name:         "replaced node back in the cluster",
+			replacements: "default-0",
+			pods:         []*corev1.Pod{replacementPod("default-0", false, false), replacementPod("default-1", false, false)},
+			inCluster:    []string{"default-0"},
+		},
+		{
+			name:       "not enough master nodes to replace a master node",
+			pods:       []*corev1.Pod{replacementPod("default-0", true, true), replacementPod("default-1", true, false)},
+			wantReason: ReasonDrainingNodes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+			if tt.replacements != "" {
+				es.Annotations = map[string]string{NodeReplacementsAnnotationName: tt.replacements}
+			}
+			statefulSet := sset.TestSset{Namespace: "ns", Name: "default", ClusterName: "es"}.Build()
+			statefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-data"}}}
+			shared := sharedPVC
+			objects := []runtime.Object{&es, &statefulSet, &shared}
+			pods := make([]corev1.Pod, 0, len(tt.pods))
+			for _, pod := range tt.pods {
+				objects = append(objects, pod, replacementPVC(pod.Name))
+				pods = append(pods, *pod)
+			}
+			c := k8s.WrappedFakeClient(objects...)
+			d := &defaultDriver{DefaultDriverParameters{
+				ES:             es,
+				Client:         c,
+				Expectations:   expectations.NewExpectations(c),
+				ReconcileState: reconcile.NewState(es),
+			}}
+
+			results := d.reconcileNodeReplacements(
+				context.Background(), migration.NewFakeShardLister(tt.shards), &testESState{inCluster: tt.inCluster}, pods)
+			res, err := results.Aggregate()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReason != "", res.Requeue || res.RequeueAfter > 0)
+
+			for _, pod := range tt.pods {
+				podErr := c.Get(k8s.ExtractNamespacedName(pod), &corev1.Pod{})
+				pvcErr := c.Get(types.NamespacedName{Namespace: "ns", Name: "elasticsearch-data-" + pod.Name}, &corev1.PersistentVolumeClaim{})
+				deleted := false
+				for _, name := range tt.wantDeleted {
+					deleted = deleted || name == pod.Name
+				}
+				require.Equal(t, deleted, errors.IsNotFound(podErr), pod.Name)
+				require.Equal(t, deleted, errors.IsNotFound(pvcErr), pod.Name)
+			}
+
+			require.NoError(t, c.Get(k8s.ExtractNamespacedName(&sharedPVC), &corev1.PersistentVolumeClaim{}))
+
+			var updated esv1.Elasticsearch
+			require.NoError(t, c.Get(k8s.ExtractNamespacedName(&es), &updated))
+			require.Equal(t, tt.wantReplacing, updated.Annotations[NodeReplacementsAnnotationName])
+			condition := d.ReconcileState.Conditions().Get(NodeReplacementConditionType)
+			if tt.wantReason == "" {
+				require.Nil(t, condition)
+			} else {
+				require.NotNil(t, condition)
+				require.Equal(t, tt.wantReason, condition.Reason)
+			}
+		})
+	}
+}
+
+func Test_withNodesToReplace(t *testing.T) {
+	pods := []corev1.Pod{
+		*replacementPod("default-0", false, false),
+		*replacementPod("default-1", false, true),
+		*replacementPod("default-2", false, true),
+	}
+	require.Equal(t, []string{"default-2", "default-1"}, withNodesToReplace([]string{"default-2"}, pods))
+	require.Nil(t, withNodesToReplace(nil, pods[:1]))
+}
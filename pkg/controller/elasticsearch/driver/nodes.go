@@ -7,11 +7,14 @@ package driver
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/placement"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates"
 	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/nodespec"
@@ -24,12 +27,14 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"go.elastic.co/apm"
 	corev1 "k8s.io/api/core/v1"
+	controller "sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func (d *defaultDriver) reconcileNodeSpecs(
 	ctx context.Context,
 	esReachable bool,
 	esClient esclient.Client,
+	esVersion version.Version,
 	reconcileState *reconcile.State,
 	observedState observer.State,
 	resourcesState reconcile.ResourcesState,
@@ -55,10 +60,35 @@ func (d *defaultDriver) reconcileNodeSpecs(
 		return results.WithError(err)
 	}
 
-	expectedResources, err := nodespec.BuildExpectedResources(d.ES, keystoreResources, certResources, actualStatefulSets)
+	if err := d.reconcileNodeSetProfilesWatch(); err != nil {
+		return results.WithError(err)
+	}
+	es, err := withNodeSetProfiles(d.Client, d.OperatorParameters.OperatorNamespace, d.ES, actualStatefulSets)
+	if err != nil {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, err.Error())
+		return results.WithError(err)
+	}
+	es, scalingTransition := withScheduledScaling(es, time.Now())
+	if scalingTransition > 0 {
+		results.WithResult(controller.Result{RequeueAfter: scalingTransition})
+	}
+	placementPolicies, err := placement.Load(d.Client, d.OperatorParameters.OperatorNamespace)
 	if err != nil {
 		return results.WithError(err)
 	}
+	expectedResources, err := nodespec.BuildExpectedResources(
+		es, keystoreResources, certResources, actualStatefulSets, d.OperatorParameters.GeoIPDownloaderEndpoint,
+		nodespec.OperatorSettings{
+			OpenShift:           d.OperatorParameters.OpenShift,
+			PlacementPolicies:   placementPolicies,
+			PodSecurityStandard: d.OperatorParameters.PodSecurityStandard,
+		},
+	)
+	if err != nil {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonValidation, err.Error())
+		return results.WithError(err)
+	}
+	d.pinImageDigests(ctx, expectedResources)
 
 	if err := GarbageCollectPVCs(d.K8sClient(), d.ES, actualStatefulSets, expectedResources.StatefulSets()); err != nil {
 		return results.WithError(err)
@@ -106,7 +136,7 @@ func (d *defaultDriver) reconcileNodeSpecs(
 	// Maybe update Zen1 minimum master nodes through the API, corresponding to the current nodes we have.
 	requeue, err := zen1.UpdateMinimumMasterNodes(ctx, d.Client, d.ES, esClient, actualStatefulSets)
 	if err != nil {
-		return results.WithError(err)
+		return withESError(results, d.ES, err)
 	}
 	if requeue {
 		results.WithResult(defaultRequeue)
@@ -114,7 +144,7 @@ func (d *defaultDriver) reconcileNodeSpecs(
 	// Remove the zen2 bootstrap annotation if bootstrap is over.
 	requeue, err = zen2.RemoveZen2BootstrapAnnotation(ctx, d.Client, d.ES, esClient)
 	if err != nil {
-		return results.WithError(err)
+		return withESError(results, d.ES, err)
 	}
 	if requeue {
 		results.WithResult(defaultRequeue)
@@ -122,12 +152,18 @@ func (d *defaultDriver) reconcileNodeSpecs(
 	// Maybe clear zen2 voting config exclusions.
 	requeue, err = zen2.ClearVotingConfigExclusions(ctx, d.ES, d.Client, esClient, actualStatefulSets)
 	if err != nil {
-		return results.WithError(err)
+		return withESError(results, d.ES, err)
 	}
 	if requeue {
 		results.WithResult(defaultRequeue)
 	}
 
+	// Back up the cluster metadata before deleting NodeSets or upgrading to a new major version.
+	if err := d.backupMetadata(ctx, esClient, esVersion, actualStatefulSets, expectedResources.StatefulSets()); err != nil {
+		reconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnexpected, fmt.Sprintf("Failed to back up the cluster metadata: %v", err))
+		return withESError(results, d.ES, err)
+	}
+
 	// Phase 2: handle sset scale down.
 	// We want to safely remove nodes from the cluster, either because the sset requires less replicas,
 	// or because it should be removed entirely.
@@ -141,14 +177,28 @@ func (d *defaultDriver) reconcileNodeSpecs(
 		d.Expectations,
 		d.ES,
 	)
+	downscaleCtx.esVersion = esVersion
+	downscaleCtx.leavingHosts = NewLeavingHosts(d.OperatorParameters)
 	downscaleRes := HandleDownscale(downscaleCtx, expectedResources.StatefulSets(), actualStatefulSets)
 	results.WithResults(downscaleRes)
 	if downscaleRes.HasError() {
 		return results
 	}
 
-	// Phase 3: handle rolling upgrades.
-	rollingUpgradesRes := d.handleRollingUpgrades(ctx, esClient, esState, expectedResources.MasterNodesNames())
+	// Replace the nodes annotated for replacement, once their data is migrated away.
+	replacementRes := d.reconcileNodeReplacements(ctx, esClient, esState, resourcesState.CurrentPods)
+	results.WithResults(replacementRes)
+	if replacementRes.HasError() {
+		return results
+	}
+
+	// Phase 3: handle a full cluster restart if requested, otherwise rolling upgrades.
+	if handled, fullRestartRes := d.handleFullRestart(ctx, esClient, esVersion, esState, observedState); handled {
+		results.WithResults(fullRestartRes)
+		reconcileState.UpdateElasticsearchApplyingChanges(resourcesState.CurrentPods)
+		return results
+	}
+	rollingUpgradesRes := d.handleRollingUpgrades(ctx, esClient, esVersion, esState, observedState, expectedResources.MasterNodesNames())
 	results.WithResults(rollingUpgradesRes)
 	if rollingUpgradesRes.HasError() {
 		return results
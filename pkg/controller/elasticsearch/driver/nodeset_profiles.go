@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/profile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// NodeSetProfilesWatchName returns the watch registered on the ConfigMaps holding the NodeSet profiles referenced by a
+// cluster.
+func NodeSetProfilesWatchName(es types.NamespacedName) string {
+	return fmt.Sprintf("%s-%s-nodeset-profiles", es.Namespace, es.Name)
+}
+
+// reconcileNodeSetProfilesWatch watches the ConfigMaps holding the NodeSet profiles referenced by the cluster, for its
+// NodeSets to be expanded again when a profile is updated, or created if it did not exist yet.
+func (d *defaultDriver) reconcileNodeSetProfilesWatch() error {
+	esKey := k8s.ExtractNamespacedName(&d.ES)
+	var watched []types.NamespacedName
+	for _, nodeSet := range d.ES.Spec.NodeSets {
+		if nodeSet.Profile != "" {
+			watched = append(watched, types.NamespacedName{Namespace: d.OperatorParameters.OperatorNamespace, Name: nodeSet.Profile})
+		}
+	}
+	if len(watched) == 0 {
+		d.DynamicWatches().ConfigMaps.RemoveHandlerForKey(NodeSetProfilesWatchName(esKey))
+		return nil
+	}
+	return d.DynamicWatches().ConfigMaps.AddHandler(watches.NamedWatch{
+		Name:    NodeSetProfilesWatchName(esKey),
+		Watched: watched,
+		Watcher: esKey,
+	})
+}
+
+// withNodeSetProfiles returns a copy of the given Elasticsearch resource where the NodeSets referencing a profile
+// declared in the operator namespace are expanded with the values of the profile. The resource is returned as is if
+// no NodeSet references a profile.
+func withNodeSetProfiles(
+	c k8s.Client,
+	operatorNamespace string,
+	es esv1.Elasticsearch,
+	actualStatefulSets sset.StatefulSetList,
+) (esv1.Elasticsearch, error) {
+	expanded := es
+	copied := false
+	for i, nodeSet := range es.Spec.NodeSets {
+		if nodeSet.Profile == "" {
+			continue
+		}
+		p, err := profile.GetNodeSetProfile(c, operatorNamespace, nodeSet.Profile)
+		if err != nil {
+			return es, err
+		}
+		if !copied {
+			expanded = *es.DeepCopy()
+			copied = true
+		}
+		p.ApplyToNodeSet(&expanded.Spec.NodeSets[i], existingVolumeClaims(es, nodeSet, actualStatefulSets))
+	}
+	return expanded, nil
+}
+
+// existingVolumeClaims returns the volume claim templates of the existing StatefulSets of the given NodeSet, or nil if
+// none exists yet. All the StatefulSets of a NodeSet spread across zones share the same claims.
+func existingVolumeClaims(es esv1.Elasticsearch, nodeSet esv1.NodeSet, actualStatefulSets sset.StatefulSetList) []corev1.PersistentVolumeClaim {
+	names := []string{esv1.StatefulSet(es.Name, nodeSet.Name)}
+	if nodeSet.ZoneSpread != nil {
+		for _, zone := range nodeSet.ZoneSpread.Zones {
+			names = append(names, esv1.StatefulSet(es.Name, nodeSet.ZoneNodeSetName(zone)))
+		}
+	}
+	for _, name := range names {
+		if existing, exists := actualStatefulSets.GetByName(name); exists {
+			claims := make([]corev1.PersistentVolumeClaim, len(existing.Spec.VolumeClaimTemplates))
+			for i, claim := range existing.Spec.VolumeClaimTemplates {
+				claims[i] = corev1.PersistentVolumeClaim{
+					ObjectMeta: claim.ObjectMeta,
+					Spec:       claim.Spec,
+				}
+			}
+			return claims
+		}
+	}
+	return nil
+}
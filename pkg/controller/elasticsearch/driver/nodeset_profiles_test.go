@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_reconcileNodeSetProfilesWatch(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+			{Name: "hot", Profile: "large"},
+			{Name: "warm"},
+		}},
+	}
+	d := &defaultDriver{DefaultDriverParameters{
+		OperatorParameters: operator.Parameters{OperatorNamespace: "elastic-system"},
+		Client:             k8s.WrappedFakeClient(),
+		ES:                 es,
+		DynamicWatches:     watches.NewDynamicWatches(),
+	}}
+
+	// the referenced profiles are watched
+	require.NoError(t, d.reconcileNodeSetProfilesWatch())
+	require.Equal(t, []string{NodeSetProfilesWatchName(k8s.ExtractNamespacedName(&es))}, d.DynamicWatches().ConfigMaps.Registrations())
+
+	// the watch is removed once no NodeSet references a profile
+	d.ES.Spec.NodeSets[0].Profile = ""
+	require.NoError(t, d.reconcileNodeSetProfilesWatch())
+	require.Empty(t, d.DynamicWatches().ConfigMaps.Registrations())
+}
@@ -0,0 +1,66 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+)
+
+const (
+	// ReachableConditionType is the type of the condition reporting whether the operator can observe the cluster
+	// through its HTTP API, and why not.
+	ReachableConditionType commonv1.ConditionType = "ElasticsearchReachable"
+	// ReasonObserved is the reason of the condition when the cluster health was retrieved. The reason of the
+	// condition is the type of the failure otherwise.
+	ReasonObserved = "Observed"
+)
+
+// failureHints tells users where to look for each type of failure.
+var failureHints = map[observer.FailureType]string{
+	observer.FailureUnreachable:  "Elasticsearch cannot be reached over the network, check the Pods, the HTTP service and network policies",
+	observer.FailureTLS:          "TLS handshake with Elasticsearch failed, check the HTTP certificates",
+	observer.FailureUnauthorized: "Elasticsearch rejected the credentials of the operator, check the operator user and file realm",
+	observer.FailureServerError:  "Elasticsearch returned a server error",
+	observer.FailureUnknown:      "Elasticsearch cannot be observed",
+}
+
+// reconcileReachableCondition reports in the status condition of the cluster whether its last observation
+// succeeded, or the type of the failure, and warns when the type of the failure changes.
+func (d *defaultDriver) reconcileReachableCondition(observedState observer.State) {
+	if observedState.ObservedAt.IsZero() {
+		// not observed yet
+		return
+	}
+	condition := reachableCondition(observedState.Failure)
+	previous := d.ReconcileState.Conditions().Get(ReachableConditionType)
+	if condition.Status == corev1.ConditionFalse && (previous == nil || previous.Reason != condition.Reason) {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnhealthy, condition.Message)
+	}
+	d.ReconcileState.UpdateCondition(condition)
+}
+
+func reachableCondition(failure *observer.Failure) commonv1.Condition {
+	if failure == nil {
+		return commonv1.Condition{
+			Type:   ReachableConditionType,
+			Status: corev1.ConditionTrue,
+			Reason: ReasonObserved,
+		}
+	}
+	hint, exists := failureHints[failure.Type]
+	if !exists {
+		hint = failureHints[observer.FailureUnknown]
+	}
+	return commonv1.Condition{
+		Type:    ReachableConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  string(failure.Type),
+		Message: hint + ": " + failure.Message,
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+)
+
+func Test_defaultDriver_reconcileReachableCondition(t *testing.T) {
+	unauthorized := &observer.Failure{Type: observer.FailureUnauthorized, Message: "401 Unauthorized: unable to authenticate user"}
+	unreachable := &observer.Failure{Type: observer.FailureUnreachable, Message: "dial tcp: connection refused"}
+	d := &defaultDriver{DefaultDriverParameters{ES: esv1.Elasticsearch{}, ReconcileState: reconcile.NewState(esv1.Elasticsearch{})}}
+
+	// not observed yet
+	d.reconcileReachableCondition(observer.State{})
+	require.Nil(t, d.ReconcileState.Conditions().Get(ReachableConditionType))
+
+	steps := []struct {
+		failure     *observer.Failure
+		wantStatus  corev1.ConditionStatus
+		wantReason  string
+		wantMessage string
+		wantEvents  int
+	}{
+		{failure: nil, wantStatus: corev1.ConditionTrue, wantReason: ReasonObserved},
+		{
+			failure:     unauthorized,
+			wantStatus:  corev1.ConditionFalse,
+			wantReason:  "Unauthorized",
+			wantMessage: "Elasticsearch rejected the credentials of the operator, check the operator user and file realm: 401 Unauthorized: unable to authenticate user",
+			wantEvents:  1,
+		},
+		// same failure: no new event
+		{failure: unauthorized, wantStatus: corev1.ConditionFalse, wantReason: "Unauthorized", wantEvents: 1},
+		{failure: unreachable, wantStatus: corev1.ConditionFalse, wantReason: "Unreachable", wantEvents: 2},
+		{failure: nil, wantStatus: corev1.ConditionTrue, wantReason: ReasonObserved, wantEvents: 2},
+	}
+	for i, step := range steps {
+		d.reconcileReachableCondition(observer.State{Failure: step.failure, ObservedAt: time.Now()})
+		condition := d.ReconcileState.Conditions().Get(ReachableConditionType)
+		require.NotNil(t, condition, i)
+		require.Equal(t, step.wantStatus, condition.Status, i)
+		require.Equal(t, step.wantReason, condition.Reason, i)
+		if step.wantMessage != "" {
+			require.Equal(t, step.wantMessage, condition.Message, i)
+		}
+		require.Len(t, d.ReconcileState.Events(), step.wantEvents, i)
+	}
+}
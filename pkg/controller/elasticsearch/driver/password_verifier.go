@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"crypto/x509"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+)
+
+// passwordVerifier returns a verifier of the passwords accepted by all the running Elasticsearch nodes of the cluster.
+// Each node is requested directly, since the nodes reload the file realm independently of each other once the kubelet
+// updates it in their Pod.
+func (d *defaultDriver) passwordVerifier(
+	ctx context.Context,
+	state *reconcile.ResourcesState,
+	v version.Version,
+	caCerts []*x509.Certificate,
+) user.PasswordVerifier {
+	return func(username string, password []byte) (bool, error) {
+		pods := state.CurrentPodsByPhase[corev1.PodRunning]
+		if len(pods) == 0 {
+			return false, nil
+		}
+		for _, pod := range pods {
+			accepted, err := d.passwordAccepted(ctx, pod, esclient.BasicAuth{Name: username, Password: string(password)}, v, caCerts)
+			if err != nil || !accepted {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// passwordAccepted returns true if the Elasticsearch node of the given Pod authenticates the given user.
+func (d *defaultDriver) passwordAccepted(
+	ctx context.Context,
+	pod corev1.Pod,
+	user esclient.BasicAuth,
+	v version.Version,
+	caCerts []*x509.Certificate,
+) (bool, error) {
+	esClient := d.newElasticsearchClientForURL(services.ElasticsearchPodURL(d.ES, pod), user, v, caCerts)
+	defer esClient.Close()
+	_, err := esClient.GetClusterInfo(ctx)
+	if esclient.IsUnauthorized(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,169 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+const (
+	// PreUpgradeSnapshotAnnotationName records on the Elasticsearch resource the version the cluster is upgraded to
+	// once a recent snapshot is verified, so that the upgrade is not delayed again until it completes.
+	PreUpgradeSnapshotAnnotationName = "elasticsearch.k8s.elastic.co/pre-upgrade-snapshot"
+
+	// PreUpgradeSnapshotConditionType is the type of the condition reporting why a version upgrade is delayed until a
+	// recent snapshot succeeds.
+	PreUpgradeSnapshotConditionType commonv1.ConditionType = "PreUpgradeSnapshot"
+	// ReasonSnapshotInProgress is the reason of the condition while the snapshot is taken.
+	ReasonSnapshotInProgress = "SnapshotInProgress"
+	// ReasonSnapshotFailed is the reason of the condition when the most recent snapshot failed.
+	ReasonSnapshotFailed = "SnapshotFailed"
+)
+
+// preUpgradeSnapshot is the state of the snapshots of a repository with regard to a version upgrade.
+type preUpgradeSnapshot struct {
+	// succeeded is the name of a successful snapshot completed recently enough.
+	succeeded string
+	// inProgress is the name of a snapshot in progress.
+	inProgress string
+	// failed is the name of the most recent snapshot, started recently enough, if it failed.
+	failed string
+}
+
+// preUpgradeSnapshotState returns the state of the given snapshots with regard to an upgrade requiring a successful
+// snapshot completed within the given maximum age.
+func preUpgradeSnapshotState(snapshots []esclient.Snapshot, maxAge time.Duration, now time.Time) preUpgradeSnapshot {
+	oldest := now.Add(-maxAge).UnixNano() / int64(time.Millisecond)
+	var state preUpgradeSnapshot
+	var latest *esclient.Snapshot
+	for i, snapshot := range snapshots {
+		switch {
+		case snapshot.State == esclient.SnapshotStateSuccess && snapshot.EndTimeInMillis >= oldest:
+			state.succeeded = snapshot.Snapshot
+		case snapshot.State == esclient.SnapshotStateInProgress:
+			state.inProgress = snapshot.Snapshot
+		}
+		if latest == nil || snapshot.StartTimeInMillis > latest.StartTimeInMillis {
+			latest = &snapshots[i]
+		}
+	}
+	if latest != nil && latest.StartTimeInMillis >= oldest &&
+		(latest.State == esclient.SnapshotStateFailed || latest.State == esclient.SnapshotStatePartial) {
+		state.failed = latest.Snapshot
+	}
+	return state
+}
+
+// preUpgradeSnapshotName returns the name of the ad-hoc snapshot taken before upgrading the cluster.
+func preUpgradeSnapshotName(es esv1.Elasticsearch, now time.Time) string {
+	return strings.ToLower(fmt.Sprintf("%s-pre-upgrade-%s-%d", es.Name, es.Spec.Version, now.Unix()))
+}
+
+// reconcilePreUpgradeSnapshot returns true if the cluster can be upgraded to the version of its specification, given
+// the version of its nodes. If the update strategy requires it, a version upgrade only proceeds once a successful
+// snapshot completed within the maximum age is found in the repository: the operator takes the snapshot if there is
+// none, and reports the reason the upgrade is delayed in the status condition of the cluster. A failed snapshot is
+// not taken again before the maximum age, for the cause of the failure to be investigated first.
+func (d *defaultDriver) reconcilePreUpgradeSnapshot(
+	ctx context.Context,
+	esClient esclient.Client,
+	esVersion version.Version,
+	now time.Time,
+) (bool, error) {
+	policy := d.ES.Spec.UpdateStrategy.SnapshotBeforeUpgrade
+	upgrading := false
+	if policy != nil {
+		target, err := version.Parse(d.ES.Spec.Version)
+		if err != nil {
+			return false, err
+		}
+		upgrading = !esVersion.IsSameOrAfter(*target)
+	}
+	if !upgrading {
+		d.ReconcileState.RemoveCondition(PreUpgradeSnapshotConditionType)
+		return true, d.updatePreUpgradeSnapshot("")
+	}
+	if d.ES.Annotations[PreUpgradeSnapshotAnnotationName] == d.ES.Spec.Version {
+		return true, nil
+	}
+
+	snapshots, err := esClient.GetSnapshots(ctx, policy.Repository)
+	if err != nil {
+		return false, err
+	}
+	state := preUpgradeSnapshotState(snapshots, policy.MaxAgeOrDefault(), now)
+	switch {
+	case state.succeeded != "":
+		log.Info("Recent snapshot found, proceeding with the version upgrade", "namespace", d.ES.Namespace,
+			"es_name", d.ES.Name, "snapshot", state.succeeded, "version", d.ES.Spec.Version)
+		d.ReconcileState.RemoveCondition(PreUpgradeSnapshotConditionType)
+		return true, d.updatePreUpgradeSnapshot(d.ES.Spec.Version)
+	case state.inProgress != "":
+		d.ReconcileState.UpdateCondition(preUpgradeSnapshotCondition(ReasonSnapshotInProgress, fmt.Sprintf(
+			"Upgrade to %s delayed until snapshot %s in repository %s completes",
+			d.ES.Spec.Version, state.inProgress, policy.Repository,
+		)))
+		return false, nil
+	case state.failed != "":
+		d.ReconcileState.UpdateCondition(preUpgradeSnapshotCondition(ReasonSnapshotFailed, fmt.Sprintf(
+			"Upgrade to %s delayed: the most recent snapshot %s in repository %s failed, take a successful snapshot to proceed",
+			d.ES.Spec.Version, state.failed, policy.Repository,
+		)))
+		return false, nil
+	}
+
+	name := preUpgradeSnapshotName(d.ES, now)
+	if policy.SLMPolicy != "" {
+		name, err = esClient.ExecuteSnapshotLifecyclePolicy(ctx, policy.SLMPolicy)
+	} else {
+		err = esClient.CreateSnapshot(ctx, policy.Repository, name, esclient.SnapshotRequest{IncludeGlobalState: true})
+	}
+	if err != nil {
+		return false, err
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonDelayed, fmt.Sprintf(
+		"Taking snapshot %s in repository %s before upgrading to %s", name, policy.Repository, d.ES.Spec.Version,
+	))
+	d.ReconcileState.UpdateCondition(preUpgradeSnapshotCondition(ReasonSnapshotInProgress, fmt.Sprintf(
+		"Upgrade to %s delayed until snapshot %s in repository %s completes", d.ES.Spec.Version, name, policy.Repository,
+	)))
+	return false, nil
+}
+
+// updatePreUpgradeSnapshot records the given version in the annotations of the cluster, if it changed.
+func (d *defaultDriver) updatePreUpgradeSnapshot(upgradeVersion string) error {
+	if upgradeVersion == d.ES.Annotations[PreUpgradeSnapshotAnnotationName] {
+		return nil
+	}
+	if upgradeVersion == "" {
+		delete(d.ES.Annotations, PreUpgradeSnapshotAnnotationName)
+	} else {
+		if d.ES.Annotations == nil {
+			d.ES.Annotations = map[string]string{}
+		}
+		d.ES.Annotations[PreUpgradeSnapshotAnnotationName] = upgradeVersion
+	}
+	return d.Client.Update(&d.ES)
+}
+
+func preUpgradeSnapshotCondition(reason, message string) commonv1.Condition {
+	return commonv1.Condition{
+		Type:    PreUpgradeSnapshotConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_preUpgradeSnapshotState(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	millis := func(ago time.Duration) int64 {
+		return now.Add(-ago).UnixNano() / int64(time.Millisecond)
+	}
+	snapshot := func(name, state string, startedAgo, endedAgo time.Duration) esclient.Snapshot {
+		s := esclient.Snapshot{Snapshot: name, State: state, StartTimeInMillis: millis(startedAgo)}
+		if state != esclient.SnapshotStateInProgress {
+			s.EndTimeInMillis = millis(endedAgo)
+		}
+		return s
+	}
+	tests := []struct {
+		name      string
+		snapshots []esclient.Snapshot
+		want      preUpgradeSnapshot
+	}{
+		{
+			name: "no snapshot",
+		},
+		{
+			name:      "only old snapshots",
+			snapshots: []esclient.Snapshot{snapshot("old", esclient.SnapshotStateSuccess, 30*time.Hour, 29*time.Hour)},
+		},
+		{
+			name: "recent successful snapshot",
+			snapshots: []esclient.Snapshot{
+				snapshot("old", esclient.SnapshotStateSuccess, 30*time.Hour, 29*time.Hour),
+				snapshot("recent", esclient.SnapshotStateSuccess, 3*time.Hour, 2*time.Hour),
+				snapshot("failed", esclient.SnapshotStateFailed, time.Hour, time.Hour),
+			},
+			want: preUpgradeSnapshot{succeeded: "recent", failed: "failed"},
+		},
+		{
+			name: "snapshot in progress",
+			snapshots: []esclient.Snapshot{
+				snapshot("old", esclient.SnapshotStateSuccess, 30*time.Hour, 29*time.Hour),
+				snapshot("running", esclient.SnapshotStateInProgress, time.Hour, 0),
+			},
+			want: preUpgradeSnapshot{inProgress: "running"},
+		},
+		{
+			name: "most recent snapshot partial",
+			snapshots: []esclient.Snapshot{
+				snapshot("failed", esclient.SnapshotStateFailed, 25*time.Hour, 25*time.Hour),
+				snapshot("partial", esclient.SnapshotStatePartial, time.Hour, time.Hour),
+			},
+			want: preUpgradeSnapshot{failed: "partial"},
+		},
+		{
+			name: "old failed snapshot",
+			snapshots: []esclient.Snapshot{
+				snapshot("failed", esclient.SnapshotStateFailed, 25*time.Hour, 25*time.Hour),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, preUpgradeSnapshotState(tt.snapshots, esv1.DefaultSnapshotMaxAge, now))
+		})
+	}
+}
+
+func Test_defaultDriver_reconcilePreUpgradeSnapshot(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{Version: "7.10.0", UpdateStrategy: esv1.UpdateStrategy{
+			SnapshotBeforeUpgrade: &esv1.SnapshotBeforeUpgradePolicy{Repository: "backups"},
+		}},
+	}
+	snapshots := `{"snapshots":[]}`
+	var requests []string
+	esClient := esclient.NewMockClient(version.MustParse("7.9.3"), func(req *http.Request) *http.Response {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		if req.Method == http.MethodGet {
+			return esclient.NewMockResponse(200, req, snapshots)
+		}
+		return esclient.NewMockResponse(200, req, `{"snapshot_name":"nightly-2020.06.01"}`)
+	})
+	d := &defaultDriver{DefaultDriverParameters{
+		Client:         k8s.WrappedFakeClient(&es),
+		ES:             es,
+		ReconcileState: reconcile.NewState(es),
+	}}
+	reconcilePreUpgradeSnapshot := func(esVersion string) bool {
+		proceed, err := d.reconcilePreUpgradeSnapshot(context.Background(), esClient, version.MustParse(esVersion), now)
+		require.NoError(t, err)
+		return proceed
+	}
+
+	// no upgrade
+	require.True(t, reconcilePreUpgradeSnapshot("7.10.0"))
+	require.Empty(t, requests)
+
+	// no recent snapshot: an ad-hoc snapshot is taken
+	require.False(t, reconcilePreUpgradeSnapshot("7.9.3"))
+	require.Equal(t, []string{"GET /_snapshot/backups/_all", "PUT /_snapshot/backups/es-pre-upgrade-7.10.0-1591012800"}, requests)
+	condition := d.ReconcileState.Conditions().Get(PreUpgradeSnapshotConditionType)
+	require.NotNil(t, condition)
+	require.Equal(t, ReasonSnapshotInProgress, condition.Reason)
+	require.Len(t, d.ReconcileState.Events(), 1)
+
+	// the snapshot failed
+	snapshots = fmt.Sprintf(
+		`{"snapshots":[{"snapshot":"es-pre-upgrade-7.10.0-1591012800","state":"FAILED","start_time_in_millis":%d}]}`,
+		now.Unix()*1000,
+	)
+	requests = nil
+	require.False(t, reconcilePreUpgradeSnapshot("7.9.3"))
+	require.Equal(t, []string{"GET /_snapshot/backups/_all"}, requests)
+	condition = d.ReconcileState.Conditions().Get(PreUpgradeSnapshotConditionType)
+	require.Equal(t, ReasonSnapshotFailed, condition.Reason)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+
+	// the failed snapshot is old, the SLM policy is executed
+	snapshots = fmt.Sprintf(
+		`{"snapshots":[{"snapshot":"es-pre-upgrade-7.10.0-1591012800","state":"FAILED","start_time_in_millis":%d}]}`,
+		now.Add(-25*time.Hour).Unix()*1000,
+	)
+	d.ES.Spec.UpdateStrategy.SnapshotBeforeUpgrade.SLMPolicy = "nightly"
+	requests = nil
+	require.False(t, reconcilePreUpgradeSnapshot("7.9.3"))
+	require.Equal(t, []string{"GET /_snapshot/backups/_all", "POST /_slm/policy/nightly/_execute"}, requests)
+	require.Equal(t, "Taking snapshot nightly-2020.06.01 in repository backups before upgrading to 7.10.0", d.ReconcileState.Events()[1].Message)
+
+	// the snapshot succeeded: the upgrade proceeds, without checking the snapshots again
+	snapshots = fmt.Sprintf(
+		`{"snapshots":[{"snapshot":"nightly-2020.06.01","state":"SUCCESS","start_time_in_millis":%d,"end_time_in_millis":%d}]}`,
+		now.Unix()*1000, now.Unix()*1000,
+	)
+	require.True(t, reconcilePreUpgradeSnapshot("7.9.3"))
+	require.Nil(t, d.ReconcileState.Conditions().Get(PreUpgradeSnapshotConditionType))
+	require.Equal(t, "7.10.0", d.ES.Annotations[PreUpgradeSnapshotAnnotationName])
+	requests = nil
+	require.True(t, reconcilePreUpgradeSnapshot("7.9.3"))
+	require.Empty(t, requests)
+
+	// the upgrade is over
+	require.True(t, reconcilePreUpgradeSnapshot("7.10.0"))
+	require.NotContains(t, d.ES.Annotations, PreUpgradeSnapshotAnnotationName)
+}
@@ -0,0 +1,151 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+)
+
+const (
+	// PriorityClassesConditionType is the type of the condition reporting whether the priority classes of the node
+	// roles give the master nodes the highest priority, then the data nodes, if priority classes are specified.
+	PriorityClassesConditionType commonv1.ConditionType = "PriorityClassesOrdered"
+	// ReasonPriorityInverted is the reason of the condition when a role has a higher priority than the roles above.
+	ReasonPriorityInverted = "PriorityInverted"
+	// ReasonPriorityOrdered is the reason of the condition when the priorities follow the order of the roles.
+	ReasonPriorityOrdered = "PriorityOrdered"
+)
+
+// reconcilePriorityClasses creates the priority classes of the node roles specified with a value that do not exist,
+// before the Pods using them, and reports in the status condition of the cluster the existing priority classes giving
+// a role a higher priority than the roles above. Priority classes are cluster-scoped and may be shared by several
+// clusters: they are neither owned by, nor deleted with, the cluster.
+func (d *defaultDriver) reconcilePriorityClasses() error {
+	if d.ES.Spec.PriorityClasses == nil {
+		d.ReconcileState.RemoveCondition(PriorityClassesConditionType)
+		return nil
+	}
+	values := make(map[string]int32, len(esv1.PriorityClassRoles))
+	for _, role := range esv1.PriorityClassRoles {
+		class := d.ES.Spec.PriorityClasses.ForRole(role)
+		if class == nil {
+			continue
+		}
+		value, err := d.reconcilePriorityClass(role, *class)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			values[role] = *value
+		}
+	}
+
+	condition := priorityClassesCondition(d.ES.Spec.PriorityClasses, values)
+	previous := d.ReconcileState.Conditions().Get(PriorityClassesConditionType)
+	if condition.Status == corev1.ConditionFalse && (previous == nil || previous.Status != corev1.ConditionFalse) {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonValidation, condition.Message)
+	}
+	d.ReconcileState.UpdateCondition(condition)
+	return nil
+}
+
+// reconcilePriorityClass creates the priority class of the given role if it is specified with a value and does not
+// exist. It returns the value of the existing priority class, or nil if it cannot be read.
+// Priority classes preempt the Pods of all namespaces: the operator only creates the ones whose value does not exceed
+// the maximum value set in its flags, and none by default.
+func (d *defaultDriver) reconcilePriorityClass(role string, class esv1.RolePriorityClass) (*int32, error) {
+	var existing schedulingv1.PriorityClass
+	err := d.getPriorityClass(class.Name, &existing)
+	switch {
+	case err == nil:
+		return &existing.Value, nil
+	case class.Value == nil:
+		// the priority class is managed outside of the operator, which may not be allowed to read it
+		log.V(1).Info("Cannot read priority class", "error", err.Error(), "priority_class", class.Name,
+			"namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		return nil, nil
+	case !apierrors.IsNotFound(err):
+		return nil, err
+	}
+
+	if maxValue := d.OperatorParameters.PriorityClassMaxValue; *class.Value > maxValue {
+		msg := fmt.Sprintf("Priority class %s of the %s nodes does not exist, create it beforehand", class.Name, role)
+		if maxValue > 0 {
+			msg = fmt.Sprintf("%s: its value %d exceeds the maximum value %d of the priority classes the operator creates",
+				msg, *class.Value, maxValue)
+		}
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonValidation, msg)
+		return nil, nil
+	}
+
+	created := schedulingv1.PriorityClass{
+		ObjectMeta:       metav1.ObjectMeta{Name: class.Name},
+		Value:            *class.Value,
+		PreemptionPolicy: class.PreemptionPolicy,
+		Description: fmt.Sprintf(
+			"Priority of the Elasticsearch %s nodes, created for the cluster %s/%s", role, d.ES.Namespace, d.ES.Name,
+		),
+	}
+	log.Info("Creating priority class", "priority_class", class.Name, "value", *class.Value,
+		"namespace", d.ES.Namespace, "es_name", d.ES.Name)
+	if err := d.Client.Create(&created); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	return class.Value, nil
+}
+
+// getPriorityClass reads the given cluster-scoped priority class from the API server, out of the cache restricted to
+// the managed namespaces.
+func (d *defaultDriver) getPriorityClass(name string, class *schedulingv1.PriorityClass) error {
+	if d.OperatorParameters.APIReader == nil {
+		return d.Client.Get(types.NamespacedName{Name: name}, class)
+	}
+	return d.OperatorParameters.APIReader.Get(context.Background(), types.NamespacedName{Name: name}, class)
+}
+
+// priorityClassesCondition returns the condition reporting the roles whose priority class has a higher value than the
+// priority class of a role above, given the values of the existing priority classes by role.
+func priorityClassesCondition(classes *esv1.PriorityClasses, values map[string]int32) commonv1.Condition {
+	var inversions []string
+	for i, role := range esv1.PriorityClassRoles {
+		value, exists := values[role]
+		if !exists {
+			continue
+		}
+		for _, higherRole := range esv1.PriorityClassRoles[:i] {
+			if higherValue, exists := values[higherRole]; exists && value > higherValue {
+				inversions = append(inversions, fmt.Sprintf(
+					"priority class %s of the %s nodes has value %d, higher than priority class %s of the %s nodes (%d)",
+					classes.ForRole(role).Name, role, value, classes.ForRole(higherRole).Name, higherRole, higherValue,
+				))
+			}
+		}
+	}
+	if len(inversions) == 0 {
+		return commonv1.Condition{
+			Type:   PriorityClassesConditionType,
+			Status: corev1.ConditionTrue,
+			Reason: ReasonPriorityOrdered,
+		}
+	}
+	return commonv1.Condition{
+		Type:    PriorityClassesConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  ReasonPriorityInverted,
+		Message: "Master nodes may be preempted or evicted first: " + strings.Join(inversions, "; "),
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_defaultDriver_reconcilePriorityClasses(t *testing.T) {
+	highest := int32(1000000)
+	never := corev1.PreemptNever
+	// the existing priority class is not updated
+	existing := &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "es-data"}, Value: 2000000}
+	c := k8s.WrappedFakeClient(existing)
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{PriorityClasses: &esv1.PriorityClasses{
+			Master:       &esv1.RolePriorityClass{Name: "es-master", Value: &highest, PreemptionPolicy: &never},
+			Data:         &esv1.RolePriorityClass{Name: "es-data", Value: &highest},
+			Coordinating: &esv1.RolePriorityClass{Name: "missing"},
+		}},
+	}
+	d := &defaultDriver{DefaultDriverParameters{
+		ES:                 es,
+		Client:             c,
+		ReconcileState:     reconcile.NewState(es),
+		OperatorParameters: operator.Parameters{PriorityClassMaxValue: highest},
+	}}
+
+	require.NoError(t, d.reconcilePriorityClasses())
+	var created schedulingv1.PriorityClass
+	require.NoError(t, c.Get(types.NamespacedName{Name: "es-master"}, &created))
+	require.Equal(t, highest, created.Value)
+	require.Equal(t, &never, created.PreemptionPolicy)
+	require.Equal(t, "Priority of the Elasticsearch master nodes, created for the cluster ns/es", created.Description)
+
+	var data schedulingv1.PriorityClass
+	require.NoError(t, c.Get(types.NamespacedName{Name: "es-data"}, &data))
+	require.Equal(t, int32(2000000), data.Value)
+
+	condition := d.ReconcileState.Conditions().Get(PriorityClassesConditionType)
+	require.NotNil(t, condition)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, "Master nodes may be preempted or evicted first: priority class es-data of the data nodes has "+
+		"value 2000000, higher than priority class es-master of the master nodes (1000000)", condition.Message)
+	require.Len(t, d.ReconcileState.Events(), 1)
+
+	// warn only once
+	require.NoError(t, d.reconcilePriorityClasses())
+	require.Len(t, d.ReconcileState.Events(), 1)
+
+	// no priority classes
+	d.ES.Spec.PriorityClasses = nil
+	require.NoError(t, d.reconcilePriorityClasses())
+	require.Nil(t, d.ReconcileState.Conditions().Get(PriorityClassesConditionType))
+}
+
+func Test_defaultDriver_reconcilePriorityClasses_MaxValue(t *testing.T) {
+	value := int32(1000000)
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{PriorityClasses: &esv1.PriorityClasses{
+			Master: &esv1.RolePriorityClass{Name: "es-master", Value: &value},
+		}},
+	}
+	for _, tt := range []struct {
+		name      string
+		maxValue  int32
+		wantEvent string
+	}{
+		{
+			name:      "priority classes are not created by default",
+			wantEvent: "Priority class es-master of the master nodes does not exist, create it beforehand",
+		},
+		{
+			name:     "priority classes above the maximum value are not created",
+			maxValue: 1000,
+			wantEvent: "Priority class es-master of the master nodes does not exist, create it beforehand: its value " +
+				"1000000 exceeds the maximum value 1000 of the priority classes the operator creates",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := k8s.WrappedFakeClient()
+			d := &defaultDriver{DefaultDriverParameters{
+				ES:                 es,
+				Client:             c,
+				ReconcileState:     reconcile.NewState(es),
+				OperatorParameters: operator.Parameters{PriorityClassMaxValue: tt.maxValue},
+			}}
+			require.NoError(t, d.reconcilePriorityClasses())
+			var classes schedulingv1.PriorityClassList
+			require.NoError(t, c.List(&classes))
+			require.Empty(t, classes.Items)
+			events := d.ReconcileState.Events()
+			require.Len(t, events, 1)
+			require.Equal(t, tt.wantEvent, events[0].Message)
+		})
+	}
+}
+
+func Test_priorityClassesCondition(t *testing.T) {
+	classes := &esv1.PriorityClasses{
+		Master:       &esv1.RolePriorityClass{Name: "master"},
+		Data:         &esv1.RolePriorityClass{Name: "data"},
+		Coordinating: &esv1.RolePriorityClass{Name: "coordinating"},
+	}
+	tests := []struct {
+		name        string
+		values      map[string]int32
+		wantStatus  corev1.ConditionStatus
+		wantMessage string
+	}{
+		{
+			name:       "no priority class read",
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "ordered, with equal values",
+			values:     map[string]int32{"master": 3, "data": 2, "coordinating": 2},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:       "coordinating nodes above the master nodes, data nodes unknown",
+			values:     map[string]int32{"master": 1, "coordinating": 2},
+			wantStatus: corev1.ConditionFalse,
+			wantMessage: "Master nodes may be preempted or evicted first: priority class coordinating of the " +
+				"coordinating nodes has value 2, higher than priority class master of the master nodes (1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := priorityClassesCondition(classes, tt.values)
+			require.Equal(t, PriorityClassesConditionType, condition.Type)
+			require.Equal(t, tt.wantStatus, condition.Status)
+			require.Equal(t, tt.wantMessage, condition.Message)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/quota"
+)
+
+const (
+	// QuotaConditionType is the type of the condition reporting whether the Elasticsearch clusters of the namespace
+	// stay within the resource quotas applying to it, if any.
+	QuotaConditionType commonv1.ConditionType = "WithinQuota"
+	// ReasonQuotaExceeded is the reason of the condition when some limits of the quotas are exceeded.
+	ReasonQuotaExceeded = "QuotaExceeded"
+	// ReasonWithinQuota is the reason of the condition when no limit is exceeded.
+	ReasonWithinQuota = "WithinQuota"
+)
+
+// reconcileQuotaCondition reports in the status condition of the cluster the limits of the resource quotas exceeded
+// by the Elasticsearch clusters of its namespace. Clusters created while the webhook was unavailable, or before the
+// quotas were declared, are not rejected, but reported.
+func (d *defaultDriver) reconcileQuotaCondition() {
+	quotas, err := quota.Load(d.Client, d.OperatorParameters.OperatorNamespace)
+	if err != nil {
+		log.Error(err, "Failed to load resource quotas", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		return
+	}
+	quotas = quota.ForNamespace(quotas, d.ES.Namespace)
+	if len(quotas) == 0 {
+		d.ReconcileState.RemoveCondition(QuotaConditionType)
+		return
+	}
+	violations, err := quota.Violations(d.Client, d.OperatorParameters.APIReader, quotas, d.ES)
+	if err != nil {
+		log.Error(err, "Failed to compute resource usage", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		return
+	}
+	d.ReconcileState.UpdateCondition(quotaCondition(violations))
+}
+
+func quotaCondition(violations []string) commonv1.Condition {
+	if len(violations) == 0 {
+		return commonv1.Condition{
+			Type:   QuotaConditionType,
+			Status: corev1.ConditionTrue,
+			Reason: ReasonWithinQuota,
+		}
+	}
+	return commonv1.Condition{
+		Type:    QuotaConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  ReasonQuotaExceeded,
+		Message: "Elasticsearch clusters of the namespace exceed the resource quota. " + strings.Join(violations, "; "),
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/rename"
+)
+
+// reconcileRename prepares the rename of the cluster if requested by the rename-to annotation, or takes over the
+// volumes and credentials of the cluster it is renamed from if declared by the renamed-from annotation. It returns
+// true while the cluster must not be reconciled further, until the renamed cluster is deleted and its volumes are
+// taken over: reconciling it would create a new empty cluster. A cluster can only be renamed to another namespace if
+// the service accounts of both clusters are allowed to access the other cluster.
+func (d *defaultDriver) reconcileRename() (bool, error) {
+	operatorNamespace := d.OperatorParameters.OperatorNamespace
+	if value, requested := d.ES.Annotations[esv1.RenameToAnnotation]; requested {
+		target, err := esv1.ParseRenameReference(value, d.ES.Namespace)
+		if err != nil {
+			return false, err
+		}
+		allowed, err := d.renameAllowed(target)
+		if err != nil {
+			return false, err
+		}
+		if !allowed {
+			d.updateRenameCondition(rename.Condition(rename.ReasonBlocked, fmt.Sprintf("Rename to %s is not allowed", target)))
+			return false, nil
+		}
+		condition, err := rename.PrepareSource(d.Client, operatorNamespace, d.ES, target)
+		if err != nil {
+			return false, err
+		}
+		d.updateRenameCondition(condition)
+		return false, nil
+	}
+
+	value, renamed := d.ES.Annotations[esv1.RenamedFromAnnotation]
+	if !renamed {
+		d.ReconcileState.RemoveCondition(rename.ConditionType)
+		if _, exists := d.ES.Annotations[rename.CompletedAnnotationName]; !exists {
+			return false, nil
+		}
+		delete(d.ES.Annotations, rename.CompletedAnnotationName)
+		return false, d.Client.Update(&d.ES)
+	}
+	source, err := esv1.ParseRenameReference(value, d.ES.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if d.ES.Annotations[rename.CompletedAnnotationName] == source.String() {
+		d.updateRenameCondition(rename.CompletedCondition(source))
+		return false, nil
+	}
+	allowed, err := d.renameAllowed(source)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		d.updateRenameCondition(rename.Condition(rename.ReasonBlocked, fmt.Sprintf("Rename from %s is not allowed", source)))
+		return true, nil
+	}
+	condition, completed, err := rename.TakeOver(d.Client, operatorNamespace, &d.ES, source)
+	if err != nil {
+		return false, err
+	}
+	d.updateRenameCondition(condition)
+	return !completed, nil
+}
+
+// renameAllowed returns true if the service account of the cluster is allowed to access the other Elasticsearch
+// resource of the rename, which may not exist, as for the cross-namespace associations.
+func (d *defaultDriver) renameAllowed(other types.NamespacedName) (bool, error) {
+	otherES := esv1.Elasticsearch{
+		TypeMeta:   metav1.TypeMeta{Kind: "Elasticsearch", APIVersion: esv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: other.Namespace, Name: other.Name},
+	}
+	allowed, err := d.AccessReviewer.AccessAllowed(d.ES.Spec.ServiceAccountName, d.ES.Namespace, &otherES)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		log.Info("Rename not allowed",
+			"namespace", d.ES.Namespace,
+			"es_name", d.ES.Name,
+			"service_account", d.ES.Spec.ServiceAccountName,
+			"remote_namespace", other.Namespace,
+			"remote_name", other.Name,
+		)
+	}
+	return allowed, nil
+}
+
+// updateRenameCondition updates the rename condition, with an event when its reason changes.
+func (d *defaultDriver) updateRenameCondition(condition commonv1.Condition) {
+	previous := d.ReconcileState.Conditions().Get(rename.ConditionType)
+	if previous == nil || previous.Reason != condition.Reason {
+		eventType := corev1.EventTypeNormal
+		if condition.Reason == rename.ReasonBlocked {
+			eventType = corev1.EventTypeWarning
+		}
+		d.ReconcileState.AddEvent(eventType, events.EventReasonStateChange, condition.Message)
+	}
+	d.ReconcileState.UpdateCondition(condition)
+}
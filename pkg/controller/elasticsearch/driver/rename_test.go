@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/bootstrap"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/rename"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+type fakeAccessReviewer struct {
+	allowed bool
+	// reviewed are the <namespace>/<name> of the reviewed Elasticsearch resources
+	reviewed []string
+}
+
+func (f *fakeAccessReviewer) AccessAllowed(_ string, _ string, object runtime.Object) (bool, error) {
+	es := object.(*esv1.Elasticsearch)
+	f.reviewed = append(f.reviewed, k8s.ExtractNamespacedName(es).String())
+	return f.allowed, nil
+}
+
+func Test_defaultDriver_reconcileRename_notAllowed(t *testing.T) {
+	scheme.SetupScheme()
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		wantWaiting  bool
+		wantReviewed string
+		wantMessage  string
+	}{
+		{
+			name:         "rename to another namespace",
+			annotations:  map[string]string{esv1.RenameToAnnotation: "other/new", bootstrap.ClusterUUIDAnnotationName: "uuid"},
+			wantWaiting:  false,
+			wantReviewed: "other/new",
+			wantMessage:  "Rename to other/new is not allowed",
+		},
+		{
+			name:         "rename from another namespace",
+			annotations:  map[string]string{esv1.RenamedFromAnnotation: "other/old"},
+			wantWaiting:  true,
+			wantReviewed: "other/old",
+			wantMessage:  "Rename from other/old is not allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: tt.annotations}}
+			c := k8s.WrappedFakeClient(&es)
+			accessReviewer := &fakeAccessReviewer{allowed: false}
+			d := &defaultDriver{DefaultDriverParameters{
+				OperatorParameters: operator.Parameters{OperatorNamespace: "elastic-system"},
+				ES:                 es,
+				Client:             c,
+				AccessReviewer:     accessReviewer,
+				ReconcileState:     reconcile.NewState(es),
+			}}
+
+			waiting, err := d.reconcileRename()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantWaiting, waiting)
+			require.Equal(t, []string{tt.wantReviewed}, accessReviewer.reviewed)
+			condition := d.ReconcileState.Conditions().Get(rename.ConditionType)
+			require.NotNil(t, condition)
+			require.Equal(t, rename.ReasonBlocked, condition.Reason)
+			require.Equal(t, tt.wantMessage, condition.Message)
+
+			// nothing is handed over
+			var secrets corev1.SecretList
+			require.NoError(t, c.List(&secrets))
+			require.Empty(t, secrets.Items)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+const (
+	// retentionJobRetryDelay is the delay after which a failed retention job runs again, if shorter than its interval.
+	retentionJobRetryDelay = 5 * time.Minute
+	// forceMergeRequestTimeout bounds the duration of the force merge requests. Elasticsearch carries on merging the
+	// segments once the request times out: reconciliations are not held by long merges.
+	forceMergeRequestTimeout = 10 * time.Second
+)
+
+// reconcileRetentionJobs runs the retention jobs due since their last run, reports them in the status, and returns a
+// result requeuing the reconciliation at the next run.
+func (d *defaultDriver) reconcileRetentionJobs(ctx context.Context, esClient esclient.Client) reconcile.Result {
+	var jobs []esv1.RetentionJob
+	if d.ES.Spec.Maintenance != nil {
+		jobs = d.ES.Spec.Maintenance.RetentionJobs
+	}
+	status, reported, nextRun := runRetentionJobs(ctx, esClient, jobs, d.ReconcileState.RetentionJobs(), time.Now())
+	for _, event := range reported {
+		d.ReconcileState.AddEvent(event.EventType, event.Reason, event.Message)
+	}
+	d.ReconcileState.UpdateRetentionJobs(status)
+	if nextRun == 0 {
+		return reconcile.Result{}
+	}
+	return reconcile.Result{RequeueAfter: nextRun}
+}
+
+// runRetentionJobs runs the jobs due at the given time, given the status of their previous run. It returns the status
+// of the jobs, the events reporting their runs, and the delay until the next job is due, or 0 if there is no job.
+func runRetentionJobs(
+	ctx context.Context,
+	esClient esclient.IndicesClient,
+	jobs []esv1.RetentionJob,
+	previous []esv1.RetentionJobStatus,
+	now time.Time,
+) ([]esv1.RetentionJobStatus, []events.Event, time.Duration) {
+	previousByName := make(map[string]esv1.RetentionJobStatus, len(previous))
+	for _, p := range previous {
+		previousByName[p.Name] = p
+	}
+	var status []esv1.RetentionJobStatus
+	var reported []events.Event
+	var nextRun time.Duration
+	for _, job := range jobs {
+		jobStatus, exists := previousByName[job.Name]
+		if !exists || now.Sub(jobStatus.LastRunTime.Time) >= retentionJobDelay(job, jobStatus) {
+			var jobEvents []events.Event
+			jobStatus, jobEvents = runRetentionJob(ctx, esClient, job, now)
+			reported = append(reported, jobEvents...)
+		}
+		status = append(status, jobStatus)
+		wait := jobStatus.LastRunTime.Add(retentionJobDelay(job, jobStatus)).Sub(now)
+		if nextRun == 0 || wait < nextRun {
+			nextRun = wait
+		}
+	}
+	return status, reported, nextRun
+}
+
+// retentionJobDelay returns the delay between the last run of the given job and its next run.
+func retentionJobDelay(job esv1.RetentionJob, status esv1.RetentionJobStatus) time.Duration {
+	interval := job.IntervalOrDefault()
+	if status.Error != "" && retentionJobRetryDelay < interval {
+		return retentionJobRetryDelay
+	}
+	return interval
+}
+
+// runRetentionJob deletes the indices of the job older than its retention, then force-merges its read-only indices.
+func runRetentionJob(
+	ctx context.Context,
+	esClient esclient.IndicesClient,
+	job esv1.RetentionJob,
+	now time.Time,
+) (esv1.RetentionJobStatus, []events.Event) {
+	status := esv1.RetentionJobStatus{Name: job.Name, LastRunTime: metav1.NewTime(now.Truncate(time.Second))}
+	failed := func(err error) (esv1.RetentionJobStatus, []events.Event) {
+		status.Error = err.Error()
+		return status, []events.Event{{
+			EventType: corev1.EventTypeWarning,
+			Reason:    events.EventReasonUnexpected,
+			Message:   fmt.Sprintf("Retention job %s failed: %s", job.Name, err),
+		}}
+	}
+
+	indices, err := esClient.GetIndices(ctx, job.Indices)
+	if err != nil {
+		return failed(err)
+	}
+	var toDelete, toMerge []string
+	for _, index := range indices {
+		if isHiddenIndex(index.Name) && !matchesHiddenIndices(job.Indices) {
+			continue
+		}
+		switch {
+		case job.DeleteAfter != nil && now.Sub(index.CreationDate) >= job.DeleteAfter.Duration:
+			toDelete = append(toDelete, index.Name)
+		case job.ForceMerge != nil && index.ReadOnly:
+			toMerge = append(toMerge, index.Name)
+		}
+	}
+
+	var reported []events.Event
+	if len(toDelete) > 0 {
+		if err := esClient.DeleteIndices(ctx, toDelete); err != nil {
+			return failed(err)
+		}
+		status.DeletedIndices = len(toDelete)
+		reported = append(reported, events.Event{
+			EventType: corev1.EventTypeNormal,
+			Reason:    events.EventReasonDeleted,
+			Message:   fmt.Sprintf("Retention job %s deleted %d indices: %s", job.Name, len(toDelete), strings.Join(toDelete, ", ")),
+		})
+	}
+	if len(toMerge) > 0 {
+		mergeCtx, cancel := context.WithTimeout(ctx, forceMergeRequestTimeout)
+		err := esClient.ForceMerge(mergeCtx, toMerge, job.ForceMerge.SegmentsOrDefault())
+		timedOut := mergeCtx.Err() == context.DeadlineExceeded
+		cancel()
+		if err != nil && !timedOut {
+			failedStatus, failedEvents := failed(err)
+			return failedStatus, append(reported, failedEvents...)
+		}
+		status.ForceMergedIndices = len(toMerge)
+	}
+	return status, reported
+}
+
+// isHiddenIndex returns true if the name of the index starts with a dot, as the system indices.
+func isHiddenIndex(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// matchesHiddenIndices returns true if one of the given patterns explicitly targets hidden indices.
+func matchesHiddenIndices(patterns []string) bool {
+	for _, pattern := range patterns {
+		if isHiddenIndex(pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+type fakeIndicesClient struct {
+	indices   []esclient.IndexInfo
+	getErr    error
+	deleted   []string
+	merged    []string
+	segments  int
+	listCalls int
+}
+
+func (f *fakeIndicesClient) GetIndices(_ context.Context, _ []string) ([]esclient.IndexInfo, error) {
+	f.listCalls++
+	return f.indices, f.getErr
+}
+
+func (f *fakeIndicesClient) DeleteIndices(_ context.Context, indices []string) error {
+	f.deleted = append(f.deleted, indices...)
+	return nil
+}
+
+func (f *fakeIndicesClient) ForceMerge(_ context.Context, indices []string, maxNumSegments int) error {
+	f.merged = append(f.merged, indices...)
+	f.segments = maxNumSegments
+	return nil
+}
+
+func Test_runRetentionJobs(t *testing.T) {
+	now := time.Date(2020, 3, 4, 5, 0, 0, 0, time.UTC)
+	indices := []esclient.IndexInfo{
+		{Name: ".security-7", CreationDate: now.Add(-60 * 24 * time.Hour)},
+		{Name: "logs-2020.01.01", CreationDate: now.Add(-60 * 24 * time.Hour), ReadOnly: true},
+		{Name: "logs-2020.03.01", CreationDate: now.Add(-3 * 24 * time.Hour), ReadOnly: true},
+		{Name: "logs-2020.03.04", CreationDate: now.Add(-time.Hour)},
+	}
+	job := esv1.RetentionJob{
+		Name:        "logs",
+		Indices:     []string{"logs-*", "*"},
+		DeleteAfter: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+		ForceMerge:  &esv1.ForceMerge{MaxNumSegments: 2},
+	}
+
+	t.Run("first run", func(t *testing.T) {
+		esClient := &fakeIndicesClient{indices: indices}
+		status, reported, nextRun := runRetentionJobs(context.Background(), esClient, []esv1.RetentionJob{job}, nil, now)
+		require.Equal(t, []esv1.RetentionJobStatus{
+			{Name: "logs", LastRunTime: metav1.NewTime(now), DeletedIndices: 1, ForceMergedIndices: 1},
+		}, status)
+		// hidden indices are not matched by the wildcard
+		require.Equal(t, []string{"logs-2020.01.01"}, esClient.deleted)
+		require.Equal(t, []string{"logs-2020.03.01"}, esClient.merged)
+		require.Equal(t, 2, esClient.segments)
+		require.Len(t, reported, 1)
+		require.Equal(t, "Retention job logs deleted 1 indices: logs-2020.01.01", reported[0].Message)
+		require.Equal(t, 24*time.Hour, nextRun)
+	})
+
+	t.Run("job not due", func(t *testing.T) {
+		esClient := &fakeIndicesClient{indices: indices}
+		previous := []esv1.RetentionJobStatus{{Name: "logs", LastRunTime: metav1.NewTime(now.Add(-10 * time.Hour)), DeletedIndices: 3}}
+		status, reported, nextRun := runRetentionJobs(context.Background(), esClient, []esv1.RetentionJob{job}, previous, now)
+		require.Equal(t, previous, status)
+		require.Empty(t, reported)
+		require.Equal(t, 0, esClient.listCalls)
+		require.Equal(t, 14*time.Hour, nextRun)
+	})
+
+	t.Run("failed job is retried sooner", func(t *testing.T) {
+		esClient := &fakeIndicesClient{getErr: errors.New("unavailable")}
+		status, reported, nextRun := runRetentionJobs(context.Background(), esClient, []esv1.RetentionJob{job}, nil, now)
+		require.Len(t, status, 1)
+		require.Equal(t, "unavailable", status[0].Error)
+		require.Len(t, reported, 1)
+		require.Equal(t, corev1.EventTypeWarning, reported[0].EventType)
+		require.Equal(t, retentionJobRetryDelay, nextRun)
+	})
+
+	t.Run("removed jobs are not reported", func(t *testing.T) {
+		previous := []esv1.RetentionJobStatus{{Name: "logs", LastRunTime: metav1.NewTime(now)}}
+		status, _, nextRun := runRetentionJobs(context.Background(), &fakeIndicesClient{}, nil, previous, now)
+		require.Empty(t, status)
+		require.Equal(t, time.Duration(0), nextRun)
+	})
+}
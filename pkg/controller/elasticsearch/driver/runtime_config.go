@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+// runtimeSettingsUpdate returns the persistent cluster settings to update for the cluster settings to match the
+// runtime configuration: the settings of the configuration that differ from the live settings, and the previously
+// applied settings removed from the configuration, reset with a nil value.
+func runtimeSettingsUpdate(config map[string]interface{}, previous []string, live map[string]interface{}) map[string]interface{} {
+	expected := flatten(config)
+	liveSettings := flatten(live)
+	values := flattenValues(config)
+	update := map[string]interface{}{}
+	for k, v := range expected {
+		if liveValue, exists := liveSettings[k]; !exists || liveValue != v {
+			update[k] = values[k]
+		}
+	}
+	for _, k := range previous {
+		if _, exists := expected[k]; exists {
+			continue
+		}
+		if _, exists := liveSettings[k]; exists {
+			update[k] = nil
+		}
+	}
+	return update
+}
+
+// flattenValues returns the leaves of the given nested map keyed by their dotted path.
+func flattenValues(m map[string]interface{}) map[string]interface{} {
+	flat := map[string]interface{}{}
+	for k, v := range m {
+		nested, ok := v.(map[string]interface{})
+		if !ok {
+			flat[k] = v
+			continue
+		}
+		for nestedKey, nestedValue := range flattenValues(nested) {
+			flat[k+"."+nestedKey] = nestedValue
+		}
+	}
+	return flat
+}
+
+// reconcileRuntimeConfig applies the runtime configuration of the cluster as persistent cluster settings, and resets
+// the settings removed from it. Only the settings that differ from the live settings are updated, for changes made
+// through the API to the other cluster settings to be left untouched.
+func (d *defaultDriver) reconcileRuntimeConfig(ctx context.Context, esClient esclient.Client) error {
+	var config map[string]interface{}
+	if d.ES.Spec.RuntimeConfig != nil {
+		config = d.ES.Spec.RuntimeConfig.Data
+	}
+	previous := d.ReconcileState.RuntimeSettings()
+	if len(config) == 0 && len(previous) == 0 {
+		return nil
+	}
+	live, err := esClient.GetClusterSettings(ctx)
+	if err != nil {
+		return err
+	}
+	update := runtimeSettingsUpdate(config, previous, live.Persistent)
+	if len(update) > 0 {
+		log.Info("Updating runtime settings", "namespace", d.ES.Namespace, "es_name", d.ES.Name, "settings", sortedKeys(flatten(update)))
+		if err := esClient.UpdateClusterSettings(ctx, esclient.ClusterSettings{Persistent: update}); err != nil {
+			return errors.Wrap(err, "while updating runtime settings")
+		}
+	}
+	d.ReconcileState.UpdateRuntimeSettings(sortedKeys(flatten(config)))
+	return nil
+}
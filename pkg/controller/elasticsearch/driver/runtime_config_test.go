@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runtimeSettingsUpdate(t *testing.T) {
+	config := map[string]interface{}{
+		"cluster": map[string]interface{}{
+			"max_shards_per_node":                     2000,
+			"routing.allocation.awareness.attributes": []interface{}{"zone"},
+		},
+		"indices.recovery.max_bytes_per_sec": "100mb",
+	}
+	tests := []struct {
+		name     string
+		previous []string
+		live     map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{
+			name: "nothing applied yet",
+			live: map[string]interface{}{"action.auto_create_index": "false"},
+			want: map[string]interface{}{
+				"cluster.max_shards_per_node":                     2000,
+				"cluster.routing.allocation.awareness.attributes": []interface{}{"zone"},
+				"indices.recovery.max_bytes_per_sec":              "100mb",
+			},
+		},
+		{
+			name: "applied, with a setting changed through the API",
+			previous: []string{
+				"cluster.max_shards_per_node",
+				"cluster.routing.allocation.awareness.attributes",
+				"indices.recovery.max_bytes_per_sec",
+			},
+			live: map[string]interface{}{
+				"cluster.max_shards_per_node":                     "2000",
+				"cluster.routing.allocation.awareness.attributes": []interface{}{"zone"},
+				"indices.recovery.max_bytes_per_sec":              "40mb",
+				"action.auto_create_index":                        "false",
+			},
+			want: map[string]interface{}{"indices.recovery.max_bytes_per_sec": "100mb"},
+		},
+		{
+			name: "settings removed from the configuration are reset",
+			previous: []string{
+				"cluster.max_shards_per_node",
+				"cluster.routing.allocation.awareness.attributes",
+				"indices.recovery.max_bytes_per_sec",
+				"search.max_buckets",
+				"action.destructive_requires_name",
+			},
+			live: map[string]interface{}{
+				"cluster.max_shards_per_node":                     "2000",
+				"cluster.routing.allocation.awareness.attributes": []interface{}{"zone"},
+				"indices.recovery.max_bytes_per_sec":              "100mb",
+				"search.max_buckets":                              "20000",
+			},
+			// action.destructive_requires_name has already been reset
+			want: map[string]interface{}{"search.max_buckets": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, runtimeSettingsUpdate(config, tt.previous, tt.live))
+		})
+	}
+}
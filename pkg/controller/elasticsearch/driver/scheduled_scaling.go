@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/chrono"
+)
+
+// activeScalingWindow returns the first scaling window of the given NodeSet active at the given time, with the time
+// it ends at, or false if none is active.
+func activeScalingWindow(nodeSet esv1.NodeSet, now time.Time) (esv1.ScalingWindow, time.Time, bool) {
+	for _, window := range nodeSet.ScheduledScaling {
+		schedule, err := chrono.ParseCronSchedule(window.Schedule)
+		if err != nil {
+			// rejected by the validation
+			continue
+		}
+		if start, active := schedule.Previous(now, window.Duration.Duration-time.Minute); active {
+			return window, start.Add(window.Duration.Duration), true
+		}
+	}
+	return esv1.ScalingWindow{}, time.Time{}, false
+}
+
+// nextScalingTransition returns the delay until the next start or end of a scaling window of the given NodeSets, or
+// 0 if none starts or ends within the longest window duration.
+func nextScalingTransition(nodeSets []esv1.NodeSet, now time.Time) time.Duration {
+	var next time.Duration
+	earliest := func(at time.Time) {
+		if delay := at.Sub(now); delay > 0 && (next == 0 || delay < next) {
+			next = delay
+		}
+	}
+	for _, nodeSet := range nodeSets {
+		for _, window := range nodeSet.ScheduledScaling {
+			schedule, err := chrono.ParseCronSchedule(window.Schedule)
+			if err != nil {
+				continue
+			}
+			if start, active := schedule.Previous(now, window.Duration.Duration-time.Minute); active {
+				earliest(start.Add(window.Duration.Duration))
+			}
+			if start, exists := schedule.Next(now, esv1.MaxScalingWindowDuration); exists {
+				earliest(start)
+			}
+		}
+	}
+	return next
+}
+
+// withScheduledScaling returns a copy of the given Elasticsearch resource where the NodeSets in a scaling window run
+// the count and resources of the window, along with the delay until the next start or end of a window. The resource is
+// returned as is if no NodeSet is in a scaling window.
+func withScheduledScaling(es esv1.Elasticsearch, now time.Time) (esv1.Elasticsearch, time.Duration) {
+	now = now.UTC()
+	scaled := es
+	copied := false
+	for i, nodeSet := range es.Spec.NodeSets {
+		window, end, active := activeScalingWindow(nodeSet, now)
+		if !active {
+			continue
+		}
+		if !copied {
+			scaled = *es.DeepCopy()
+			copied = true
+		}
+		log.V(1).Info("Applying scheduled scaling window",
+			"namespace", es.Namespace, "es_name", es.Name, "node_set", nodeSet.Name, "window", window.Name, "until", end)
+		applyScalingWindow(&scaled.Spec.NodeSets[i], window)
+	}
+	return scaled, nextScalingTransition(es.Spec.NodeSets, now)
+}
+
+// applyScalingWindow sets the count and the resources of the Elasticsearch container of the window to the NodeSet.
+func applyScalingWindow(nodeSet *esv1.NodeSet, window esv1.ScalingWindow) {
+	if window.Count != nil {
+		nodeSet.Count = *window.Count
+	}
+	if window.Resources == nil {
+		return
+	}
+	containers := nodeSet.PodTemplate.Spec.Containers
+	for i := range containers {
+		if containers[i].Name == esv1.ElasticsearchContainerName {
+			containers[i].Resources = *window.Resources.DeepCopy()
+			return
+		}
+	}
+	nodeSet.PodTemplate.Spec.Containers = append(containers, corev1.Container{
+		Name:      esv1.ElasticsearchContainerName,
+		Resources: *window.Resources.DeepCopy(),
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/pointer"
+)
+
+func Test_withScheduledScaling(t *testing.T) {
+	peakResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+	}
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+			{Name: "master", Count: 3},
+			{
+				Name:  "hot",
+				Count: 3,
+				ScheduledScaling: []esv1.ScalingWindow{
+					{
+						Name:      "business-hours",
+						Schedule:  "0 8 * * 1-5",
+						Duration:  metav1.Duration{Duration: 10 * time.Hour},
+						Count:     pointer.Int32(6),
+						Resources: &peakResources,
+					},
+					{
+						Name:     "weekend",
+						Schedule: "0 0 * * 6",
+						Duration: metav1.Duration{Duration: 48 * time.Hour},
+						Count:    pointer.Int32(1),
+					},
+				},
+			},
+		}},
+	}
+	tests := []struct {
+		name          string
+		now           time.Time
+		wantCount     int32
+		wantResources *corev1.ResourceRequirements
+		wantNext      time.Duration
+	}{
+		{
+			name:      "before the business hours",
+			now:       time.Date(2020, 3, 4, 7, 30, 0, 0, time.UTC),
+			wantCount: 3,
+			wantNext:  30 * time.Minute,
+		},
+		{
+			name:          "start of the business hours",
+			now:           time.Date(2020, 3, 4, 8, 0, 0, 0, time.UTC),
+			wantCount:     6,
+			wantResources: &peakResources,
+			wantNext:      10 * time.Hour,
+		},
+		{
+			name:          "during the business hours, in another time zone",
+			now:           time.Date(2020, 3, 4, 17, 59, 30, 0, time.FixedZone("CET", 3600)),
+			wantCount:     6,
+			wantResources: &peakResources,
+			wantNext:      time.Hour + 30*time.Second,
+		},
+		{
+			name:      "end of the business hours",
+			now:       time.Date(2020, 3, 4, 18, 0, 0, 0, time.UTC),
+			wantCount: 3,
+			wantNext:  14 * time.Hour,
+		},
+		{
+			name:      "weekend",
+			now:       time.Date(2020, 3, 8, 12, 0, 0, 0, time.UTC),
+			wantCount: 1,
+			wantNext:  12 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaled, next := withScheduledScaling(es, tt.now)
+			require.Equal(t, tt.wantNext, next)
+			require.Equal(t, int32(3), scaled.Spec.NodeSets[0].Count)
+			require.Equal(t, tt.wantCount, scaled.Spec.NodeSets[1].Count)
+			container := scaled.Spec.NodeSets[1].GetESContainerTemplate()
+			if tt.wantResources == nil {
+				require.Nil(t, container)
+			} else {
+				require.Equal(t, *tt.wantResources, container.Resources)
+			}
+			// the original resource is left untouched
+			require.Equal(t, int32(3), es.Spec.NodeSets[1].Count)
+			require.Empty(t, es.Spec.NodeSets[1].PodTemplate.Spec.Containers)
+		})
+	}
+}
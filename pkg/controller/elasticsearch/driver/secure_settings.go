@@ -0,0 +1,129 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+)
+
+const (
+	// KeystoreSidecarAnnotationName can be set to "true" on the Elasticsearch resource to update the keystore of the
+	// running nodes with a sidecar container when the secure settings change, instead of restarting the nodes. The
+	// reloadable secure settings are then reloaded by the operator.
+	KeystoreSidecarAnnotationName = "elasticsearch.k8s.elastic.co/keystore-sidecar"
+	// SecureSettingsAnnotationName holds the state of the secure settings updated by the keystore sidecar, serialized
+	// as JSON.
+	SecureSettingsAnnotationName = "elasticsearch.k8s.elastic.co/secure-settings"
+	// keystoreSyncPeriod is the period at which the sidecar updates the keystore with the secure settings.
+	keystoreSyncPeriod = 30 * time.Second
+	// secureSettingsPropagationDelay is the time to wait for a change of the secure settings to be propagated to the
+	// Pods by the kubelets and added to the keystore by the sidecar, before reloading the secure settings.
+	secureSettingsPropagationDelay = analysisFilesPropagationDelay + keystoreSyncPeriod
+)
+
+// keystoreSidecarEnabled returns true if the keystore of the given cluster is updated by a sidecar container. It is
+// always the case for the clusters reaching remote clusters with API keys, for the updates of the API keys stored in
+// the keystore to be reloaded rather than to restart the nodes.
+func keystoreSidecarEnabled(es esv1.Elasticsearch) bool {
+	if es.Annotations[KeystoreSidecarAnnotationName] == "true" {
+		return true
+	}
+	for _, remoteCluster := range es.Spec.RemoteClusters {
+		if remoteCluster.HasAPIKey() {
+			return true
+		}
+	}
+	return false
+}
+
+// withKeystoreSidecar returns the given keystore resources with the sidecar container updating the keystore, if
+// enabled for the cluster.
+func withKeystoreSidecar(es esv1.Elasticsearch, keystoreResources *keystore.Resources) (*keystore.Resources, error) {
+	if keystoreResources == nil || !keystoreSidecarEnabled(es) {
+		return keystoreResources, nil
+	}
+	return keystoreResources.WithSidecar(initcontainer.KeystoreParams, keystoreSyncPeriod)
+}
+
+// secureSettingsState is the state of the secure settings updated by the keystore sidecar.
+type secureSettingsState struct {
+	// Version of the latest secure settings.
+	Version string `json:"version"`
+	// ReloadAfter is the time after which the latest secure settings are in the keystore of the Pods, and can be
+	// reloaded. Not set once they are reloaded.
+	ReloadAfter *metav1.Time `json:"reloadAfter,omitempty"`
+}
+
+// reconcileSecureSettingsReload reloads the secure settings of the nodes once a change is propagated to their keystore
+// by the sidecar container. The keystore is read by the nodes when they start: the first version observed does not
+// need to be reloaded.
+func (d *defaultDriver) reconcileSecureSettingsReload(
+	ctx context.Context,
+	esClient esclient.Client,
+	esReachable bool,
+	keystoreResources *keystore.Resources,
+) (reconcile.Result, error) {
+	if keystoreResources == nil || keystoreResources.Sidecar == nil {
+		if _, exists := d.ES.Annotations[SecureSettingsAnnotationName]; !exists {
+			return reconcile.Result{}, nil
+		}
+		delete(d.ES.Annotations, SecureSettingsAnnotationName)
+		return reconcile.Result{}, d.Client.Update(&d.ES)
+	}
+
+	var state secureSettingsState
+	serialized, exists := d.ES.Annotations[SecureSettingsAnnotationName]
+	if exists {
+		if err := json.Unmarshal([]byte(serialized), &state); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	now := time.Now()
+	switch {
+	case !exists:
+		return reconcile.Result{}, d.updateSecureSettingsState(secureSettingsState{Version: keystoreResources.Version})
+	case state.Version != keystoreResources.Version:
+		log.Info("Secure settings changed", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		reloadAfter := metav1.NewTime(now.Add(secureSettingsPropagationDelay))
+		err := d.updateSecureSettingsState(secureSettingsState{Version: keystoreResources.Version, ReloadAfter: &reloadAfter})
+		return reconcile.Result{RequeueAfter: secureSettingsPropagationDelay}, err
+	case state.ReloadAfter == nil:
+		// already reloaded
+		return reconcile.Result{}, nil
+	case now.Before(state.ReloadAfter.Time):
+		return reconcile.Result{RequeueAfter: state.ReloadAfter.Sub(now)}, nil
+	case !esReachable:
+		return defaultRequeue, nil
+	}
+
+	log.Info("Reloading secure settings", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+	if err := esClient.ReloadSecureSettings(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, d.updateSecureSettingsState(secureSettingsState{Version: keystoreResources.Version})
+}
+
+// updateSecureSettingsState stores the given state of the secure settings in the annotation of the cluster.
+func (d *defaultDriver) updateSecureSettingsState(state secureSettingsState) error {
+	serialized, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if d.ES.Annotations == nil {
+		d.ES.Annotations = map[string]string{}
+	}
+	d.ES.Annotations[SecureSettingsAnnotationName] = string(serialized)
+	return d.Client.Update(&d.ES)
+}
@@ -0,0 +1,114 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_withKeystoreSidecar(t *testing.T) {
+	resources := &keystore.Resources{InitContainer: corev1.Container{Name: keystore.InitContainerName}, Version: "1"}
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+
+	// no secure settings
+	withSidecar, err := withKeystoreSidecar(es, nil)
+	require.NoError(t, err)
+	require.Nil(t, withSidecar)
+
+	// sidecar not enabled
+	withSidecar, err = withKeystoreSidecar(es, resources)
+	require.NoError(t, err)
+	require.Nil(t, withSidecar.Sidecar)
+
+	es.Annotations = map[string]string{KeystoreSidecarAnnotationName: "true"}
+	withSidecar, err = withKeystoreSidecar(es, resources)
+	require.NoError(t, err)
+	require.NotNil(t, withSidecar.Sidecar)
+	require.Equal(t, keystore.SidecarContainerName, withSidecar.Sidecar.Name)
+	require.Equal(t, "", withSidecar.PodTemplateVersion())
+
+	// the API keys of the remote clusters are updated by the sidecar without restarting the nodes
+	es.Annotations = nil
+	es.Spec.RemoteClusters = []esv1.RemoteCluster{{Name: "remote", APIKey: &esv1.RemoteClusterAPIKey{}}}
+	withSidecar, err = withKeystoreSidecar(es, resources)
+	require.NoError(t, err)
+	require.NotNil(t, withSidecar.Sidecar)
+	require.Equal(t, "", withSidecar.PodTemplateVersion())
+}
+
+func Test_reconcileSecureSettingsReload(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "es",
+			Annotations: map[string]string{KeystoreSidecarAnnotationName: "true"},
+		},
+	}
+	k8sClient := k8s.WrappedFakeClient(&es)
+	reloads := 0
+	esClient := esclient.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_nodes/reload_secure_settings", req.URL.Path)
+		reloads++
+		return esclient.NewMockResponse(200, req, `{}`)
+	})
+	d := &defaultDriver{DefaultDriverParameters{
+		Client: k8sClient,
+		ES:     es,
+	}}
+	resources := &keystore.Resources{Version: "1", Sidecar: &corev1.Container{Name: keystore.SidecarContainerName}}
+	reconcileSecureSettingsReload := func() reconcile.Result {
+		result, err := d.reconcileSecureSettingsReload(context.Background(), esClient, true, resources)
+		require.NoError(t, err)
+		return result
+	}
+	state := func() secureSettingsState {
+		var state secureSettingsState
+		require.NoError(t, json.Unmarshal([]byte(d.ES.Annotations[SecureSettingsAnnotationName]), &state))
+		return state
+	}
+
+	// the keystore is loaded by the nodes when they start: no reload
+	require.Equal(t, reconcile.Result{}, reconcileSecureSettingsReload())
+	require.Equal(t, secureSettingsState{Version: "1"}, state())
+	require.Equal(t, 0, reloads)
+
+	// the secure settings change: the reload waits for them to be added to the keystore by the sidecar
+	resources.Version = "2"
+	require.Equal(t, reconcile.Result{RequeueAfter: secureSettingsPropagationDelay}, reconcileSecureSettingsReload())
+	require.Equal(t, "2", state().Version)
+	require.NotNil(t, state().ReloadAfter)
+	require.NotZero(t, reconcileSecureSettingsReload().RequeueAfter)
+	require.Equal(t, 0, reloads)
+
+	// the secure settings are propagated: they are reloaded once
+	propagated := state()
+	propagated.ReloadAfter = &metav1.Time{Time: time.Now().Add(-time.Second)}
+	require.NoError(t, d.updateSecureSettingsState(propagated))
+	require.Equal(t, reconcile.Result{}, reconcileSecureSettingsReload())
+	require.Equal(t, 1, reloads)
+	require.Nil(t, state().ReloadAfter)
+	require.Equal(t, reconcile.Result{}, reconcileSecureSettingsReload())
+	require.Equal(t, 1, reloads)
+
+	// the sidecar is disabled: the Pods are rotated on changes again, the annotation is removed
+	resources.Sidecar = nil
+	require.Equal(t, reconcile.Result{}, reconcileSecureSettingsReload())
+	require.NotContains(t, d.ES.Annotations, SecureSettingsAnnotationName)
+}
@@ -0,0 +1,398 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/stackconfigpolicy"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// driftCheckInterval is the interval between two checks for configuration drift.
+	driftCheckInterval = 5 * time.Minute
+
+	clusterSettingsItem      = "cluster_settings"
+	indexLifecyclePolicyItem = "index_lifecycle_policies"
+	indexTemplateItem        = "index_templates"
+	ingestPipelineItem       = "ingest_pipelines"
+	watchItem                = "watches"
+)
+
+// policyConfig is the configuration distributed to the cluster by a StackConfigPolicy.
+type policyConfig struct {
+	repositories    map[string]esclient.SnapshotRepository
+	clusterSettings map[string]string
+	ilmPolicies     map[string]map[string]interface{}
+	templates       map[string]map[string]interface{}
+	pipelines       map[string]map[string]interface{}
+	pipelineSamples map[string][]map[string]interface{}
+	watches         map[string]map[string]interface{}
+}
+
+func newPolicyConfig(secret corev1.Secret) (policyConfig, error) {
+	var config policyConfig
+	var err error
+	if config.repositories, err = stackconfigpolicy.SnapshotRepositories(secret); err != nil {
+		return config, err
+	}
+	clusterSettings, err := stackconfigpolicy.ClusterSettings(secret)
+	if err != nil {
+		return config, err
+	}
+	config.clusterSettings = flatten(clusterSettings)
+	if config.ilmPolicies, err = stackconfigpolicy.IndexLifecyclePolicies(secret); err != nil {
+		return config, err
+	}
+	if config.templates, err = stackconfigpolicy.IndexTemplates(secret); err != nil {
+		return config, err
+	}
+	if config.pipelines, err = stackconfigpolicy.IngestPipelines(secret); err != nil {
+		return config, err
+	}
+	if config.pipelineSamples, err = stackconfigpolicy.IngestPipelineSamples(secret); err != nil {
+		return config, err
+	}
+	config.watches, err = stackconfigpolicy.Watches(secret)
+	return config, err
+}
+
+// checksDrift returns true if the config holds items that must be checked for drift.
+func (c policyConfig) checksDrift() bool {
+	return len(c.clusterSettings) > 0 || len(c.ilmPolicies) > 0 || len(c.templates) > 0 || len(c.pipelines) > 0 ||
+		len(c.watches) > 0
+}
+
+// reconcileStackConfigPolicy applies the configuration distributed to the cluster by a StackConfigPolicy, if it has
+// not been applied yet. Once applied, the cluster settings, index lifecycle policies, index templates, ingest pipelines
+// and watches of the configuration are periodically checked for drift, which is either reverted or reported depending on the policy.
+func (d *defaultDriver) reconcileStackConfigPolicy(ctx context.Context, esClient esclient.Client) (reconcile.Result, error) {
+	secret, err := stackconfigpolicy.GetConfigSecret(d.Client, esv1.ESNamer, k8s.ExtractNamespacedName(&d.ES))
+	if err != nil || secret == nil {
+		return reconcile.Result{}, err
+	}
+	config, err := newPolicyConfig(*secret)
+	if err == nil {
+		err = config.validateDataTiers(d.ES.Spec.NodeSets)
+	}
+	if err != nil {
+		if updateErr := stackconfigpolicy.UpdateAppliedStatus(d.Client, *secret, err); updateErr != nil {
+			return reconcile.Result{}, updateErr
+		}
+		return reconcile.Result{}, err
+	}
+	var requeue reconcile.Result
+	if config.checksDrift() {
+		requeue = reconcile.Result{RequeueAfter: driftCheckInterval}
+	}
+
+	if !stackconfigpolicy.IsApplied(*secret) {
+		applyErr := config.apply(ctx, esClient)
+		if err := stackconfigpolicy.UpdateAppliedStatus(d.Client, *secret, applyErr); err != nil {
+			return requeue, err
+		}
+		return requeue, applyErr
+	}
+
+	if !config.checksDrift() {
+		return requeue, nil
+	}
+	drift, err := config.drift(ctx, esClient)
+	if err != nil {
+		return requeue, err
+	}
+	if len(drift) > 0 {
+		if stackconfigpolicy.DriftMode(*secret) == policyv1alpha1.EnforceDriftMode {
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonConfigurationDrift,
+				fmt.Sprintf("Reverting configuration drift: %s", strings.Join(drift, ", ")))
+			if err := config.apply(ctx, esClient); err != nil {
+				return requeue, err
+			}
+			drift = nil
+		} else if strings.Join(drift, ",") != strings.Join(stackconfigpolicy.Drift(*secret), ",") {
+			d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonConfigurationDrift,
+				fmt.Sprintf("Configuration drift detected: %s", strings.Join(drift, ", ")))
+		}
+	}
+	return requeue, stackconfigpolicy.UpdateDriftStatus(d.Client, *secret, drift)
+}
+
+// validateDataTiers returns an error if an index lifecycle policy of the config allocates indices to a data tier, through
+// the data attribute of its allocate actions, which no NodeSet of the cluster holds. Tiers are only validated if the
+// cluster declares some, other clusters may set the attribute to any value.
+func (c policyConfig) validateDataTiers(nodeSets []esv1.NodeSet) error {
+	declared := map[string]bool{}
+	for _, nodeSet := range nodeSets {
+		if nodeSet.Tier != "" {
+			declared[string(nodeSet.Tier)] = true
+		}
+		if nodeSet.Config != nil {
+			if attr, ok := nodeSet.Config.Data[esv1.NodeAttrData].(string); ok {
+				declared[attr] = true
+			}
+		}
+	}
+	if len(declared) == 0 {
+		return nil
+	}
+	for _, name := range sortedKeys(c.ilmPolicies) {
+		for _, reference := range tierReferences(c.ilmPolicies[name]) {
+			if !declared[reference.tier] {
+				return errors.Errorf("index lifecycle policy %s allocates indices to the %s tier in its %s phase, but no NodeSet declares this tier",
+					name, reference.tier, reference.phase)
+			}
+		}
+	}
+	return nil
+}
+
+// tierReference is a reference to a data tier in a phase of an index lifecycle policy.
+type tierReference struct {
+	phase string
+	tier  string
+}
+
+// tierReferences returns the data tiers referenced by the data attribute of the allocate actions of the given index
+// lifecycle policy, sorted by phase.
+func tierReferences(policy map[string]interface{}) []tierReference {
+	flat := flatten(policy)
+	var references []tierReference
+	for _, key := range sortedKeys(flat) {
+		// policy.phases.<phase>.actions.allocate.<require|include>.data
+		parts := strings.Split(key, ".")
+		if len(parts) != 7 || parts[0] != "policy" || parts[1] != "phases" || parts[3] != "actions" ||
+			parts[4] != "allocate" || (parts[5] != "require" && parts[5] != "include") || parts[6] != esv1.DataTierAttributeName {
+			continue
+		}
+		for _, value := range strings.Split(flat[key], ",") {
+			if tier := esv1.DataTier(strings.TrimSpace(value)); tier.IsValid() {
+				references = append(references, tierReference{phase: parts[2], tier: string(tier)})
+			}
+		}
+	}
+	return references
+}
+
+// apply creates or updates all the items of the config, once the ingest pipelines are validated against their sample
+// documents. Items removed from the policy are left untouched: snapshot repositories may still hold snapshots in use, index
+// lifecycle policies and index templates may still be used by existing indices, watches may have been adopted by
+// users.
+func (c policyConfig) apply(ctx context.Context, esClient esclient.Client) error {
+	if err := c.simulatePipelines(ctx, esClient); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(c.repositories) {
+		if err := esClient.UpdateSnapshotRepository(ctx, name, c.repositories[name]); err != nil {
+			return errors.Wrapf(err, "while updating snapshot repository %s", name)
+		}
+	}
+	if len(c.clusterSettings) > 0 {
+		persistent := make(map[string]interface{}, len(c.clusterSettings))
+		for k, v := range c.clusterSettings {
+			persistent[k] = v
+		}
+		if err := esClient.UpdateClusterSettings(ctx, esclient.ClusterSettings{Persistent: persistent}); err != nil {
+			return errors.Wrap(err, "while updating cluster settings")
+		}
+	}
+	for _, name := range sortedKeys(c.ilmPolicies) {
+		if err := esClient.UpdateIndexLifecyclePolicy(ctx, name, c.ilmPolicies[name]); err != nil {
+			return errors.Wrapf(err, "while updating index lifecycle policy %s", name)
+		}
+	}
+	for _, name := range sortedKeys(c.templates) {
+		if err := esClient.UpdateIndexTemplate(ctx, name, c.templates[name]); err != nil {
+			return errors.Wrapf(err, "while updating index template %s", name)
+		}
+	}
+	for _, id := range sortedKeys(c.pipelines) {
+		if err := esClient.UpdateIngestPipeline(ctx, id, c.pipelines[id]); err != nil {
+			return errors.Wrapf(err, "while updating ingest pipeline %s", id)
+		}
+	}
+	for _, id := range sortedKeys(c.watches) {
+		if err := esClient.UpdateWatch(ctx, id, c.watches[id]); err != nil {
+			return errors.Wrapf(err, "while updating watch %s", id)
+		}
+	}
+	return nil
+}
+
+// simulatePipelines runs the ingest pipelines of the config on their sample documents, and returns an error describing
+// the first failure.
+func (c policyConfig) simulatePipelines(ctx context.Context, esClient esclient.Client) error {
+	for _, id := range sortedKeys(c.pipelines) {
+		samples := c.pipelineSamples[id]
+		if len(samples) == 0 {
+			continue
+		}
+		docs, err := esClient.SimulateIngestPipeline(ctx, c.pipelines[id], samples)
+		if err != nil {
+			return errors.Wrapf(err, "while simulating ingest pipeline %s", id)
+		}
+		for i, doc := range docs {
+			if doc.Error != nil {
+				return errors.Errorf("ingest pipeline %s failed on sample document %d: %s: %s", id, i, doc.Error.Type, doc.Error.Reason)
+			}
+		}
+	}
+	return nil
+}
+
+// drift returns the items of the config that differ from the live configuration of the cluster, sorted.
+// Values set in the cluster but not declared in the config, such as defaults, are ignored.
+func (c policyConfig) drift(ctx context.Context, esClient esclient.Client) ([]string, error) {
+	var drift []string
+	if len(c.clusterSettings) > 0 {
+		live, err := esClient.GetClusterSettings(ctx)
+		if err != nil {
+			return nil, err
+		}
+		liveSettings := flatten(live.Persistent)
+		for _, k := range sortedKeys(c.clusterSettings) {
+			if liveValue, exists := liveSettings[k]; !exists || liveValue != c.clusterSettings[k] {
+				drift = append(drift, clusterSettingsItem+":"+k)
+			}
+		}
+	}
+	for _, name := range sortedKeys(c.ilmPolicies) {
+		live, err := esClient.GetIndexLifecyclePolicy(ctx, name)
+		if err != nil && !esclient.IsNotFound(err) {
+			return nil, err
+		}
+		if err != nil || !isSubset(flatten(c.ilmPolicies[name]), flatten(map[string]interface{}{"policy": live.Policy})) {
+			drift = append(drift, indexLifecyclePolicyItem+":"+name)
+		}
+	}
+	for _, name := range sortedKeys(c.templates) {
+		live, err := esClient.GetIndexTemplate(ctx, name)
+		if err != nil && !esclient.IsNotFound(err) {
+			return nil, err
+		}
+		if err != nil || live == nil || !isSubset(normalizeTemplate(flatten(c.templates[name])), flatten(live)) {
+			drift = append(drift, indexTemplateItem+":"+name)
+		}
+	}
+	for _, id := range sortedKeys(c.pipelines) {
+		live, err := esClient.GetIngestPipeline(ctx, id)
+		if err != nil && !esclient.IsNotFound(err) {
+			return nil, err
+		}
+		if err != nil || !isSubset(flatten(c.pipelines[id]), flatten(live)) {
+			drift = append(drift, ingestPipelineItem+":"+id)
+		}
+	}
+	for _, id := range sortedKeys(c.watches) {
+		live, err := esClient.GetWatch(ctx, id)
+		if err != nil && !esclient.IsNotFound(err) {
+			return nil, err
+		}
+		if err != nil || !isSubset(withoutRedacted(flatten(c.watches[id]), flatten(live)), flatten(live)) {
+			drift = append(drift, watchItem+":"+id)
+		}
+	}
+	return drift, nil
+}
+
+// flatten returns the leaves of the given nested map keyed by their dotted path. Leaves are rendered as strings,
+// as Elasticsearch returns flat settings as strings.
+func flatten(m map[string]interface{}) map[string]string {
+	flat := map[string]string{}
+	flattenInto(flat, "", m)
+	return flat
+}
+
+func flattenInto(flat map[string]string, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch value := v.(type) {
+		case map[string]interface{}:
+			flattenInto(flat, key, value)
+		case string:
+			flat[key] = value
+		default:
+			raw, err := json.Marshal(value)
+			if err != nil {
+				flat[key] = fmt.Sprintf("%v", value)
+				continue
+			}
+			flat[key] = string(raw)
+		}
+	}
+}
+
+// normalizeTemplate prefixes the settings of a flattened index template with "index.", as returned by Elasticsearch.
+func normalizeTemplate(flat map[string]string) map[string]string {
+	normalized := make(map[string]string, len(flat))
+	for k, v := range flat {
+		if strings.HasPrefix(k, "settings.") && !strings.HasPrefix(k, "settings.index.") {
+			k = "settings.index." + strings.TrimPrefix(k, "settings.")
+		}
+		normalized[k] = v
+	}
+	return normalized
+}
+
+// redactedValue replaces the secrets, such as the passwords of the HTTP inputs and webhook actions, in the watches
+// returned by Elasticsearch.
+const redactedValue = "::es_redacted::"
+
+// withoutRedacted returns the expected entries whose value is not redacted in actual, as they cannot be compared.
+func withoutRedacted(expected, actual map[string]string) map[string]string {
+	filtered := make(map[string]string, len(expected))
+	for k, v := range expected {
+		if actual[k] != redactedValue {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// isSubset returns true if all the entries of expected are in actual.
+func isSubset(expected, actual map[string]string) bool {
+	for k, v := range expected {
+		if actualValue, exists := actual[k]; !exists || actualValue != v {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch typed := m.(type) {
+	case map[string]string:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]esclient.SnapshotRepository:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]map[string]interface{}:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
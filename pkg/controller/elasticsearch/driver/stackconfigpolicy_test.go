@@ -0,0 +1,204 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+func Test_flatten(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"indices.recovery.max_bytes_per_sec": "100mb",
+		"cluster.routing.allocation.enable":  "all",
+		"action.auto_create_index":           "false",
+		"cluster.max_shards_per_node":        "2000",
+		"index_patterns":                     `["logs-*"]`,
+	}, flatten(map[string]interface{}{
+		"indices.recovery.max_bytes_per_sec": "100mb",
+		"cluster": map[string]interface{}{
+			"routing":             map[string]interface{}{"allocation.enable": "all"},
+			"max_shards_per_node": 2000,
+		},
+		"action.auto_create_index": false,
+		"index_patterns":           []interface{}{"logs-*"},
+	}))
+}
+
+func Test_normalizeTemplate(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"settings.index.number_of_shards":   "1",
+		"settings.index.number_of_replicas": "0",
+		"index_patterns":                    `["logs-*"]`,
+	}, normalizeTemplate(map[string]string{
+		"settings.number_of_shards":         "1",
+		"settings.index.number_of_replicas": "0",
+		"index_patterns":                    `["logs-*"]`,
+	}))
+}
+
+func Test_policyConfig_drift(t *testing.T) {
+	config := policyConfig{
+		clusterSettings: map[string]string{
+			"indices.recovery.max_bytes_per_sec": "100mb",
+			"cluster.max_shards_per_node":        "2000",
+		},
+		ilmPolicies: map[string]map[string]interface{}{
+			"logs": {"policy": map[string]interface{}{"phases": map[string]interface{}{
+				"delete": map[string]interface{}{"min_age": "30d", "actions": map[string]interface{}{"delete": map[string]interface{}{}}},
+			}}},
+			"metrics": {"policy": map[string]interface{}{"phases": map[string]interface{}{
+				"delete": map[string]interface{}{"min_age": "7d"},
+			}}},
+		},
+		templates: map[string]map[string]interface{}{
+			"logs": {
+				"index_patterns": []interface{}{"logs-*"},
+				"settings":       map[string]interface{}{"number_of_shards": 1},
+			},
+			"metrics": {
+				"index_patterns": []interface{}{"metrics-*"},
+			},
+		},
+		pipelines: map[string]map[string]interface{}{
+			"logs": {"processors": []interface{}{map[string]interface{}{"lowercase": map[string]interface{}{"field": "level"}}}},
+		},
+		watches: map[string]map[string]interface{}{
+			"cluster_health": {"trigger": map[string]interface{}{"schedule": map[string]interface{}{"interval": "10m"}}},
+			"disk_usage":     {"trigger": map[string]interface{}{"schedule": map[string]interface{}{"interval": "1h"}}},
+			"notify": {"actions": map[string]interface{}{"webhook": map[string]interface{}{"webhook": map[string]interface{}{
+				"host": "alerts.example.com",
+				"auth": map[string]interface{}{"basic": map[string]interface{}{"username": "eck", "password": "secret"}},
+			}}}},
+		},
+	}
+	responses := map[string]string{
+		"/_cluster/settings?flat_settings=true": `{"persistent":{"indices.recovery.max_bytes_per_sec":"100mb","cluster.max_shards_per_node":"1000","other":"value"},"transient":{}}`,
+		// the delete phase of the logs policy has been defaulted with an additional field
+		"/_ilm/policy/logs": `{"logs":{"version":1,"policy":{"phases":{"delete":{"min_age":"30d","actions":{"delete":{"delete_searchable_snapshot":true}}}}}}}`,
+		// the metrics policy has been modified
+		"/_ilm/policy/metrics": `{"metrics":{"version":2,"policy":{"phases":{"delete":{"min_age":"1d"}}}}}`,
+		// the logs template has additional defaults
+		"/_template/logs?flat_settings=true": `{"logs":{"order":0,"index_patterns":["logs-*"],"settings":{"index.number_of_shards":"1"},"mappings":{},"aliases":{}}}`,
+		// the logs pipeline has been replaced
+		"/_ingest/pipeline/logs": `{"logs":{"processors":[{"uppercase":{"field":"level"}}]}}`,
+		// the cluster_health watch has been deactivated, which is not part of its definition
+		"/_watcher/watch/cluster_health": `{"found":true,"_id":"cluster_health","status":{"state":{"active":false}},"watch":{"trigger":{"schedule":{"interval":"10m"}},"actions":{}}}`,
+		// the disk_usage watch has been modified
+		"/_watcher/watch/disk_usage": `{"found":true,"_id":"disk_usage","watch":{"trigger":{"schedule":{"interval":"1d"}}}}`,
+		// the password of the notify watch is redacted
+		"/_watcher/watch/notify": `{"found":true,"_id":"notify","watch":{"actions":{"webhook":{"webhook":{"host":"alerts.example.com","auth":{"basic":{"username":"eck","password":"::es_redacted::"}}}}}}}`,
+	}
+	esClient := esclient.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, http.MethodGet, req.Method)
+		body, exists := responses[req.URL.RequestURI()]
+		if !exists {
+			// the metrics template has been deleted
+			return esclient.NewMockResponse(404, req, `{}`)
+		}
+		return esclient.NewMockResponse(200, req, body)
+	})
+
+	drift, err := config.drift(context.Background(), esClient)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"cluster_settings:cluster.max_shards_per_node",
+		"index_lifecycle_policies:metrics",
+		"index_templates:metrics",
+		"ingest_pipelines:logs",
+		"watches:disk_usage",
+	}, drift)
+}
+
+func Test_policyConfig_simulatePipelines(t *testing.T) {
+	config := policyConfig{
+		pipelines: map[string]map[string]interface{}{
+			"logs":    {"processors": []interface{}{map[string]interface{}{"date": map[string]interface{}{"field": "ts", "formats": []interface{}{"ISO8601"}}}}},
+			"metrics": {"processors": []interface{}{}},
+		},
+		pipelineSamples: map[string][]map[string]interface{}{
+			"logs": {{"ts": "2020-03-04T05:00:00Z"}, {"ts": "yesterday"}},
+		},
+	}
+	var simulated int
+	esClient := esclient.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		require.Equal(t, "/_ingest/pipeline/_simulate", req.URL.Path)
+		simulated++
+		return esclient.NewMockResponse(200, req, `{"docs": [
+			{"doc": {"_source": {"ts": "2020-03-04T05:00:00Z"}}},
+			{"error": {"type": "illegal_argument_exception", "reason": "unable to parse date [yesterday]"}}
+		]}`)
+	})
+
+	err := config.simulatePipelines(context.Background(), esClient)
+	require.EqualError(t, err, "ingest pipeline logs failed on sample document 1: illegal_argument_exception: unable to parse date [yesterday]")
+	// the metrics pipeline has no sample documents
+	require.Equal(t, 1, simulated)
+
+	// a failed simulation prevents the config from being applied
+	require.Error(t, config.apply(context.Background(), esClient))
+	require.Equal(t, 2, simulated)
+}
+
+func Test_policyConfig_validateDataTiers(t *testing.T) {
+	allocate := func(filter string, tiers string) map[string]interface{} {
+		return map[string]interface{}{"policy": map[string]interface{}{"phases": map[string]interface{}{
+			"warm": map[string]interface{}{"actions": map[string]interface{}{
+				"allocate": map[string]interface{}{filter: map[string]interface{}{"data": tiers}},
+			}},
+		}}}
+	}
+	tieredNodeSets := []esv1.NodeSet{
+		{Name: "hot", Tier: esv1.HotTier},
+		{Name: "archive", Config: &commonv1.Config{Data: map[string]interface{}{"node.attr.data": "cold"}}},
+	}
+	tests := []struct {
+		name     string
+		policy   map[string]interface{}
+		nodeSets []esv1.NodeSet
+		wantErr  string
+	}{
+		{
+			name:     "tiers of the NodeSets",
+			policy:   allocate("include", "hot, cold"),
+			nodeSets: tieredNodeSets,
+		},
+		{
+			name:     "undeclared tier",
+			policy:   allocate("require", "warm"),
+			nodeSets: tieredNodeSets,
+			wantErr:  "index lifecycle policy logs allocates indices to the warm tier in its warm phase, but no NodeSet declares this tier",
+		},
+		{
+			name:     "custom attribute values are not tiers",
+			policy:   allocate("require", "ssd"),
+			nodeSets: tieredNodeSets,
+		},
+		{
+			name:     "no tier declared in the cluster",
+			policy:   allocate("require", "warm"),
+			nodeSets: []esv1.NodeSet{{Name: "default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := policyConfig{ilmPolicies: map[string]map[string]interface{}{"logs": tt.policy}}
+			err := config.validateDataTiers(tt.nodeSets)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
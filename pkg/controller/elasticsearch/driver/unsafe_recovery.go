@@ -0,0 +1,376 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// UnsafeRecoveryAnnotationName can be set on the Elasticsearch resource to the name of the Pod of a surviving
+	// master node, to form a new cluster from its cluster state when a majority of the master nodes is permanently
+	// lost. The cluster metadata more recent than the one of this node is lost, and so may be some data.
+	UnsafeRecoveryAnnotationName = "elasticsearch.k8s.elastic.co/unsafe-recovery"
+	// UnsafeRecoveryConfirmationAnnotationName must be set to UnsafeRecoveryConfirmation for the unsafe recovery to
+	// start.
+	UnsafeRecoveryConfirmationAnnotationName = "elasticsearch.k8s.elastic.co/unsafe-recovery-confirmation"
+	// UnsafeRecoveryConfirmation is the value of UnsafeRecoveryConfirmationAnnotationName confirming the unsafe recovery.
+	UnsafeRecoveryConfirmation = "I understand that unsafe recovery may lose data"
+	// UnsafeRecoveryCompletedAnnotationName records the Pod the new cluster was formed from, once all the nodes are
+	// recovered, so that the recovery is not performed twice.
+	UnsafeRecoveryCompletedAnnotationName = "elasticsearch.k8s.elastic.co/unsafe-recovery-completed"
+	// UnsafeRecoveryJobLabelName marks the Jobs running the elasticsearch-node tool on the data of the nodes, and their Pods.
+	UnsafeRecoveryJobLabelName = "elasticsearch.k8s.elastic.co/unsafe-recovery-job"
+
+	// UnsafeRecoveryConditionType is the type of the condition reporting the progress of the unsafe recovery.
+	UnsafeRecoveryConditionType commonv1.ConditionType = "UnsafeRecovery"
+	// ReasonRecoveryNotConfirmed is the reason of the condition while the unsafe recovery is not confirmed.
+	ReasonRecoveryNotConfirmed = "NotConfirmed"
+	// ReasonRecoveryBlocked is the reason of the condition while a precondition of the unsafe recovery is not met.
+	ReasonRecoveryBlocked = "Blocked"
+	// ReasonBootstrappingCluster is the reason of the condition while the new cluster is bootstrapped.
+	ReasonBootstrappingCluster = "BootstrappingCluster"
+	// ReasonDetachingNodes is the reason of the condition while the other nodes are detached from the lost cluster.
+	ReasonDetachingNodes = "DetachingNodes"
+	// ReasonRecoveryFailed is the reason of the condition once a Job of the unsafe recovery failed.
+	ReasonRecoveryFailed = "Failed"
+	// ReasonRecoveryCompleted is the reason of the condition once the unsafe recovery completed.
+	ReasonRecoveryCompleted = "Completed"
+
+	elasticsearchNodeBinPath = "/usr/share/elasticsearch/bin/elasticsearch-node"
+)
+
+// unsafeRecoveryMinVersion is the first version of Elasticsearch with the elasticsearch-node tool.
+var unsafeRecoveryMinVersion = version.MustParse("7.0.0")
+
+// unsafeBootstrapScript forms a new cluster from the cluster state of the node.
+var unsafeBootstrapScript = fmt.Sprintf(`#!/usr/bin/env bash
+
+set -eu
+
+echo "Bootstrapping a new cluster from the cluster state of ${POD_NAME}"
+echo y | %s unsafe-bootstrap
+`, elasticsearchNodeBinPath)
+
+// detachClusterScript detaches the node from the lost cluster, for it to join the new one. Nodes without cluster
+// state, such as the replacements of the lost nodes, are left untouched.
+var detachClusterScript = fmt.Sprintf(`#!/usr/bin/env bash
+
+set -eu
+
+if [[ ! -d %[1]s/nodes && ! -d %[1]s/_state ]]; then
+	echo "No cluster state for ${POD_NAME}, nothing to detach"
+	exit 0
+fi
+echo "Detaching ${POD_NAME} from the lost cluster"
+echo y | %[2]s detach-cluster
+`, esvolume.ElasticsearchDataMountPath, elasticsearchNodeBinPath)
+
+// jobStatus is the state of a Job of the unsafe recovery.
+type jobStatus int
+
+const (
+	jobRunning jobStatus = iota
+	jobSucceeded
+	jobFailed
+)
+
+// reconcileUnsafeRecovery forms a new cluster from the cluster state of the master node requested through
+// UnsafeRecoveryAnnotationName, once confirmed and once all the Pods of the cluster are suspended through
+// spec.nodeDebug.suspendedPods: Elasticsearch must not run while the elasticsearch-node tool modifies its data.
+//
+// A Job runs elasticsearch-node unsafe-bootstrap on the node, on the Kubernetes node and with the data volume of its
+// Pod. Jobs then run elasticsearch-node detach-cluster on the data of all the other nodes, for them to join the new
+// cluster. The Pods can be resumed once the recovery is completed.
+func (d *defaultDriver) reconcileUnsafeRecovery(esVersion version.Version, pods []corev1.Pod) *reconciler.Results {
+	results := &reconciler.Results{}
+	recoveryPod, requested := d.ES.Annotations[UnsafeRecoveryAnnotationName]
+	if !requested {
+		return results.WithError(d.cleanUpUnsafeRecovery())
+	}
+	if d.ES.Annotations[UnsafeRecoveryCompletedAnnotationName] == recoveryPod {
+		d.ReconcileState.UpdateCondition(completedUnsafeRecoveryCondition(recoveryPod))
+		return results
+	}
+	if d.ES.Annotations[UnsafeRecoveryConfirmationAnnotationName] != UnsafeRecoveryConfirmation {
+		d.ReconcileState.UpdateCondition(unsafeRecoveryCondition(ReasonRecoveryNotConfirmed, fmt.Sprintf(
+			"Set the %s annotation to %q to form a new cluster from %s",
+			UnsafeRecoveryConfirmationAnnotationName, UnsafeRecoveryConfirmation, recoveryPod,
+		)))
+		return results
+	}
+	survivor, blocker := unsafeRecoveryBlocker(esVersion, recoveryPod, pods)
+	if blocker != "" {
+		log.Info("Delaying the unsafe recovery", "reason", blocker, "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		d.ReconcileState.UpdateCondition(unsafeRecoveryCondition(ReasonRecoveryBlocked, blocker))
+		return results.WithResult(defaultRequeue)
+	}
+
+	// bootstrap a new cluster from the surviving master node
+	status, err := d.reconcileUnsafeRecoveryJob(survivor, "unsafe-bootstrap", unsafeBootstrapScript)
+	if err != nil {
+		return results.WithError(err)
+	}
+	switch status {
+	case jobFailed:
+		d.ReconcileState.UpdateCondition(unsafeRecoveryCondition(ReasonRecoveryFailed, fmt.Sprintf(
+			"Failed to bootstrap a new cluster from %s, see the logs of Job %s",
+			recoveryPod, unsafeRecoveryJobName(survivor, "unsafe-bootstrap"),
+		)))
+		return results
+	case jobRunning:
+		d.ReconcileState.UpdateCondition(unsafeRecoveryCondition(ReasonBootstrappingCluster,
+			"Bootstrapping a new cluster from "+recoveryPod))
+		return results.WithResult(defaultRequeue)
+	}
+
+	// detach the other nodes from the lost cluster
+	var running, failed []string
+	for _, pod := range pods {
+		if pod.Name == survivor.Name {
+			continue
+		}
+		status, err := d.reconcileUnsafeRecoveryJob(pod, "detach-cluster", detachClusterScript)
+		if err != nil {
+			return results.WithError(err)
+		}
+		switch status {
+		case jobRunning:
+			running = append(running, pod.Name)
+		case jobFailed:
+			failed = append(failed, pod.Name)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		d.ReconcileState.UpdateCondition(unsafeRecoveryCondition(ReasonRecoveryFailed,
+			"Failed to detach nodes from the lost cluster, see the logs of their detach-cluster Job: "+strings.Join(failed, ", ")))
+		return results
+	}
+	if len(running) > 0 {
+		sort.Strings(running)
+		d.ReconcileState.UpdateCondition(unsafeRecoveryCondition(ReasonDetachingNodes,
+			"Detaching nodes from the lost cluster: "+strings.Join(running, ", ")))
+		return results.WithResult(defaultRequeue)
+	}
+
+	log.Info("Unsafe recovery completed", "namespace", d.ES.Namespace, "es_name", d.ES.Name, "pod_name", recoveryPod)
+	d.ES.Annotations[UnsafeRecoveryCompletedAnnotationName] = recoveryPod
+	if err := d.Client.Update(&d.ES); err != nil {
+		return results.WithError(err)
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeNormal, events.EventReasonStateChange,
+		fmt.Sprintf("Unsafe recovery completed: new cluster formed from %s, resume the suspended Pods", recoveryPod))
+	d.ReconcileState.UpdateCondition(completedUnsafeRecoveryCondition(recoveryPod))
+	return results
+}
+
+// isUnsafeRecoveryConfirmed returns true if an unsafe recovery is requested and confirmed, and not completed yet.
+func (d *defaultDriver) isUnsafeRecoveryConfirmed() bool {
+	recoveryPod, requested := d.ES.Annotations[UnsafeRecoveryAnnotationName]
+	return requested &&
+		d.ES.Annotations[UnsafeRecoveryConfirmationAnnotationName] == UnsafeRecoveryConfirmation &&
+		d.ES.Annotations[UnsafeRecoveryCompletedAnnotationName] != recoveryPod
+}
+
+// completedUnsafeRecoveryCondition returns the condition reporting that the new cluster was formed from the given Pod.
+func completedUnsafeRecoveryCondition(recoveryPod string) commonv1.Condition {
+	return unsafeRecoveryCondition(ReasonRecoveryCompleted, fmt.Sprintf(
+		"New cluster formed from %s: remove the Pods from spec.nodeDebug.suspendedPods, then the %s annotation",
+		recoveryPod, UnsafeRecoveryAnnotationName,
+	))
+}
+
+// unsafeRecoveryBlocker returns the Pod of the surviving master node, and why the unsafe recovery cannot be performed
+// yet, or the empty string if it can.
+func unsafeRecoveryBlocker(esVersion version.Version, recoveryPod string, pods []corev1.Pod) (corev1.Pod, string) {
+	if !esVersion.IsSameOrAfter(unsafeRecoveryMinVersion) {
+		return corev1.Pod{}, fmt.Sprintf("unsafe recovery requires Elasticsearch %s or later", unsafeRecoveryMinVersion)
+	}
+	var survivor *corev1.Pod
+	var running []string
+	for i, pod := range pods {
+		if pod.Name == recoveryPod {
+			survivor = &pods[i]
+		}
+		if !isSuspended(pod) {
+			running = append(running, pod.Name)
+		}
+	}
+	switch {
+	case survivor == nil:
+		return corev1.Pod{}, fmt.Sprintf("pod %s does not exist", recoveryPod)
+	case !label.IsMasterNode(*survivor):
+		return corev1.Pod{}, fmt.Sprintf("pod %s is not a master node", recoveryPod)
+	case survivor.Spec.NodeName == "":
+		return corev1.Pod{}, fmt.Sprintf("pod %s is not scheduled", recoveryPod)
+	case len(running) > 0:
+		sort.Strings(running)
+		return corev1.Pod{}, "add all the Pods to spec.nodeDebug.suspendedPods, waiting for them to be suspended: " +
+			strings.Join(running, ", ")
+	}
+	return *survivor, ""
+}
+
+// unsafeRecoveryJobName returns the name of the Job running the given elasticsearch-node command on the data of the
+// given Pod.
+func unsafeRecoveryJobName(pod corev1.Pod, command string) string {
+	return esv1.ESNamer.Suffix(pod.Name, command)
+}
+
+// reconcileUnsafeRecoveryJob creates the Job running the given elasticsearch-node command on the data of the given
+// Pod if it does not exist, and returns its status.
+func (d *defaultDriver) reconcileUnsafeRecoveryJob(pod corev1.Pod, command string, script string) (jobStatus, error) {
+	var job batchv1.Job
+	err := d.Client.Get(types.NamespacedName{Namespace: pod.Namespace, Name: unsafeRecoveryJobName(pod, command)}, &job)
+	if err == nil {
+		switch {
+		case job.Status.Succeeded > 0:
+			return jobSucceeded, nil
+		case job.Status.Failed > 0:
+			return jobFailed, nil
+		}
+		return jobRunning, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return jobRunning, err
+	}
+
+	expected, err := unsafeRecoveryJob(d.ES, pod, command, script)
+	if err != nil {
+		return jobRunning, err
+	}
+	log.Info("Running elasticsearch-node on the data of a node",
+		"namespace", pod.Namespace, "es_name", d.ES.Name, "pod_name", pod.Name, "command", command, "job_name", expected.Name)
+	if err := d.Client.Create(&expected); err != nil {
+		return jobRunning, err
+	}
+	d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonStateChange,
+		fmt.Sprintf("Running elasticsearch-node %s on the data of %s for the unsafe recovery", command, pod.Name))
+	return jobRunning, nil
+}
+
+// unsafeRecoveryJob returns the Job running the given script on the data volume of the given Pod, on its Kubernetes
+// node for the volume to be attached. The Pod of the Job is not labeled as part of the cluster.
+func unsafeRecoveryJob(es esv1.Elasticsearch, pod corev1.Pod, command string, script string) (batchv1.Job, error) {
+	var esContainer *corev1.Container
+	for i, c := range pod.Spec.Containers {
+		if c.Name == esv1.ElasticsearchContainerName {
+			esContainer = &pod.Spec.Containers[i]
+		}
+	}
+	if esContainer == nil {
+		return batchv1.Job{}, fmt.Errorf("no %s container in pod %s", esv1.ElasticsearchContainerName, pod.Name)
+	}
+	var dataMount *corev1.VolumeMount
+	for i, m := range esContainer.VolumeMounts {
+		if m.MountPath == esvolume.ElasticsearchDataMountPath {
+			dataMount = &esContainer.VolumeMounts[i]
+		}
+	}
+	var dataVolume *corev1.Volume
+	for i, v := range pod.Spec.Volumes {
+		if dataMount != nil && v.Name == dataMount.Name {
+			dataVolume = &pod.Spec.Volumes[i]
+		}
+	}
+	if dataVolume == nil {
+		return batchv1.Job{}, fmt.Errorf("no data volume mounted at %s in pod %s", esvolume.ElasticsearchDataMountPath, pod.Name)
+	}
+
+	backoffLimit := int32(0)
+	jobLabels := map[string]string{UnsafeRecoveryJobLabelName: "true"}
+	labels := label.NewLabels(k8s.ExtractNamespacedName(&es))
+	labels[UnsafeRecoveryJobLabelName] = "true"
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      unsafeRecoveryJobName(pod, command),
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: jobLabels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					NodeName:         pod.Spec.NodeName,
+					Tolerations:      pod.Spec.Tolerations,
+					SecurityContext:  pod.Spec.SecurityContext,
+					ImagePullSecrets: pod.Spec.ImagePullSecrets,
+					Volumes:          []corev1.Volume{*dataVolume},
+					Containers: []corev1.Container{{
+						Name:            "elasticsearch-node",
+						Image:           esContainer.Image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"bash", "-c", script},
+						Env:             []corev1.EnvVar{{Name: "POD_NAME", Value: pod.Name}},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      dataVolume.Name,
+							MountPath: esvolume.ElasticsearchDataMountPath,
+						}},
+						SecurityContext: esContainer.SecurityContext,
+					}},
+				},
+			},
+		},
+	}
+	return job, controllerutil.SetControllerReference(&es, &job, scheme.Scheme)
+}
+
+// cleanUpUnsafeRecovery deletes the Jobs of the unsafe recovery and its state once the recovery is not requested anymore.
+func (d *defaultDriver) cleanUpUnsafeRecovery() error {
+	if d.ES.Status.Conditions.Get(UnsafeRecoveryConditionType) == nil {
+		// no recovery was requested
+		return nil
+	}
+	d.ReconcileState.RemoveCondition(UnsafeRecoveryConditionType)
+	var jobs batchv1.JobList
+	matchLabels := label.NewLabels(k8s.ExtractNamespacedName(&d.ES))
+	matchLabels[UnsafeRecoveryJobLabelName] = "true"
+	if err := d.Client.List(&jobs, client.InNamespace(d.ES.Namespace), client.MatchingLabels(matchLabels)); err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		if err := d.Client.Delete(&jobs.Items[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if _, exists := d.ES.Annotations[UnsafeRecoveryCompletedAnnotationName]; !exists {
+		return nil
+	}
+	delete(d.ES.Annotations, UnsafeRecoveryCompletedAnnotationName)
+	return d.Client.Update(&d.ES)
+}
+
+// unsafeRecoveryCondition returns the condition reporting the progress of the unsafe recovery.
+func unsafeRecoveryCondition(reason, message string) commonv1.Condition {
+	return commonv1.Condition{
+		Type:    UnsafeRecoveryConditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// recoveryTestPod returns a Pod of the cluster with its data volume, held in the suspend init container if suspended.
+func recoveryTestPod(name string, master bool, suspended bool) corev1.Pod {
+	pod := sset.TestPod{Namespace: "ns", Name: name, ClusterName: "es", Master: master, Data: true}.Build()
+	pod.Spec.NodeName = "k8s-node-" + name
+	pod.Spec.Volumes = []corev1.Volume{{
+		Name: esvolume.ElasticsearchDataVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "elasticsearch-data-" + name},
+		},
+	}}
+	pod.Spec.Containers = []corev1.Container{{
+		Name:  esv1.ElasticsearchContainerName,
+		Image: "docker.elastic.co/elasticsearch/elasticsearch:7.10.0",
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      esvolume.ElasticsearchDataVolumeName,
+			MountPath: esvolume.ElasticsearchDataMountPath,
+		}},
+	}}
+	if suspended {
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+			Name:  initcontainer.SuspendContainerName,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}}
+	}
+	return pod
+}
+
+func Test_unsafeRecoveryBlocker(t *testing.T) {
+	suspendedPods := []corev1.Pod{
+		recoveryTestPod("master-0", true, true),
+		recoveryTestPod("data-0", false, true),
+	}
+	tests := []struct {
+		name        string
+		esVersion   string
+		recoveryPod string
+		pods        []corev1.Pod
+		wantBlocker string
+	}{
+		{
+			name:        "all Pods suspended",
+			esVersion:   "7.10.0",
+			recoveryPod: "master-0",
+			pods:        suspendedPods,
+		},
+		{
+			name:        "Elasticsearch 6.x",
+			esVersion:   "6.8.0",
+			recoveryPod: "master-0",
+			pods:        suspendedPods,
+			wantBlocker: "unsafe recovery requires Elasticsearch 7.0.0 or later",
+		},
+		{
+			name:        "missing Pod",
+			esVersion:   "7.10.0",
+			recoveryPod: "master-1",
+			pods:        suspendedPods,
+			wantBlocker: "pod master-1 does not exist",
+		},
+		{
+			name:        "not a master node",
+			esVersion:   "7.10.0",
+			recoveryPod: "data-0",
+			pods:        suspendedPods,
+			wantBlocker: "pod data-0 is not a master node",
+		},
+		{
+			name:        "some Pods not suspended",
+			esVersion:   "7.10.0",
+			recoveryPod: "master-0",
+			pods:        []corev1.Pod{recoveryTestPod("master-0", true, true), recoveryTestPod("data-0", false, false)},
+			wantBlocker: "add all the Pods to spec.nodeDebug.suspendedPods, waiting for them to be suspended: data-0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survivor, blocker := unsafeRecoveryBlocker(version.MustParse(tt.esVersion), tt.recoveryPod, tt.pods)
+			require.Equal(t, tt.wantBlocker, blocker)
+			if blocker == "" {
+				require.Equal(t, tt.recoveryPod, survivor.Name)
+			}
+		})
+	}
+}
+
+func Test_defaultDriver_reconcileUnsafeRecovery(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "es",
+			Annotations: map[string]string{UnsafeRecoveryAnnotationName: "master-0"},
+		},
+	}
+	pods := []corev1.Pod{
+		recoveryTestPod("master-0", true, true),
+		recoveryTestPod("master-1", true, true),
+		recoveryTestPod("data-0", false, true),
+	}
+	k8sClient := k8s.WrappedFakeClient(&es)
+	d := &defaultDriver{DefaultDriverParameters{
+		Client:         k8sClient,
+		ES:             es,
+		ReconcileState: reconcile.NewState(es),
+	}}
+	reconcileUnsafeRecovery := func(wantReason string) {
+		d.ReconcileState = reconcile.NewState(d.ES)
+		results := d.reconcileUnsafeRecovery(version.MustParse("7.10.0"), pods)
+		require.False(t, results.HasError())
+		conditions := d.ReconcileState.Conditions()
+		condition := conditions.Get(UnsafeRecoveryConditionType)
+		require.NotNil(t, condition)
+		require.Equal(t, wantReason, condition.Reason)
+		d.ES.Status.Conditions = conditions
+	}
+	job := func(pod string, command string) (batchv1.Job, error) {
+		var job batchv1.Job
+		err := k8sClient.Get(types.NamespacedName{Namespace: "ns", Name: pod + "-es-" + command}, &job)
+		return job, err
+	}
+	completeJob := func(pod string, command string, succeeded bool) {
+		j, err := job(pod, command)
+		require.NoError(t, err)
+		if succeeded {
+			j.Status.Succeeded = 1
+		} else {
+			j.Status.Failed = 1
+		}
+		require.NoError(t, k8sClient.Update(&j))
+	}
+
+	// not confirmed: nothing happens
+	reconcileUnsafeRecovery(ReasonRecoveryNotConfirmed)
+	_, err := job("master-0", "unsafe-bootstrap")
+	require.Error(t, err)
+
+	// confirmed: the new cluster is bootstrapped from the surviving master node, with its data volume
+	d.ES.Annotations[UnsafeRecoveryConfirmationAnnotationName] = UnsafeRecoveryConfirmation
+	reconcileUnsafeRecovery(ReasonBootstrappingCluster)
+	bootstrapJob, err := job("master-0", "unsafe-bootstrap")
+	require.NoError(t, err)
+	podSpec := bootstrapJob.Spec.Template.Spec
+	require.Equal(t, "k8s-node-master-0", podSpec.NodeName)
+	require.Equal(t, pods[0].Spec.Volumes, podSpec.Volumes)
+	require.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:7.10.0", podSpec.Containers[0].Image)
+	require.Contains(t, podSpec.Containers[0].Command[2], "unsafe-bootstrap")
+	// the Pod of the Job is not part of the cluster
+	require.Equal(t, map[string]string{UnsafeRecoveryJobLabelName: "true"}, bootstrapJob.Spec.Template.Labels)
+	_, err = job("master-1", "detach-cluster")
+	require.Error(t, err)
+
+	// the other nodes are detached from the lost cluster once bootstrapped
+	completeJob("master-0", "unsafe-bootstrap", true)
+	reconcileUnsafeRecovery(ReasonDetachingNodes)
+	for _, pod := range []string{"master-1", "data-0"} {
+		detachJob, err := job(pod, "detach-cluster")
+		require.NoError(t, err)
+		require.Contains(t, detachJob.Spec.Template.Spec.Containers[0].Command[2], "detach-cluster")
+	}
+	completeJob("master-1", "detach-cluster", true)
+	completeJob("data-0", "detach-cluster", false)
+	reconcileUnsafeRecovery(ReasonRecoveryFailed)
+
+	// completed once all the Jobs succeeded
+	completeJob("data-0", "detach-cluster", true)
+	reconcileUnsafeRecovery(ReasonRecoveryCompleted)
+	require.Equal(t, "master-0", d.ES.Annotations[UnsafeRecoveryCompletedAnnotationName])
+	reconcileUnsafeRecovery(ReasonRecoveryCompleted)
+
+	// the annotation is removed: the Jobs and the state are cleaned up
+	delete(d.ES.Annotations, UnsafeRecoveryAnnotationName)
+	d.ReconcileState = reconcile.NewState(d.ES)
+	require.False(t, d.reconcileUnsafeRecovery(version.MustParse("7.10.0"), pods).HasError())
+	require.Nil(t, d.ReconcileState.Conditions().Get(UnsafeRecoveryConditionType))
+	require.NotContains(t, d.ES.Annotations, UnsafeRecoveryCompletedAnnotationName)
+	var jobs batchv1.JobList
+	require.NoError(t, k8sClient.List(&jobs))
+	require.Empty(t, jobs.Items)
+}
@@ -6,11 +6,14 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
@@ -22,7 +25,9 @@ import (
 func (d *defaultDriver) handleRollingUpgrades(
 	ctx context.Context,
 	esClient esclient.Client,
+	esVersion version.Version,
 	esState ESState,
+	observedState observer.State,
 	expectedMaster []string,
 ) *reconciler.Results {
 	results := &reconciler.Results{}
@@ -58,6 +63,25 @@ func (d *defaultDriver) handleRollingUpgrades(
 		return results.WithError(err)
 	}
 
+	// Delay the version upgrade until a recent snapshot succeeds, if required by the update strategy.
+	proceed, err := d.reconcilePreUpgradeSnapshot(ctx, esClient, esVersion, time.Now())
+	if err != nil {
+		return withESError(results, d.ES, err)
+	}
+	if !proceed {
+		return results.WithResult(defaultRequeue)
+	}
+
+	// Pause the machine learning jobs before restarting the machine learning nodes.
+	if err := d.maybeEnableMLUpgradeMode(ctx, esClient, esVersion, podsToUpgrade); err != nil {
+		return results.WithError(err)
+	}
+
+	// Stop the transforms and rollup jobs running on the nodes to upgrade, if required by the update strategy.
+	if err := d.maybeStopIndexingTasks(ctx, esClient, esState, esVersion, podsToUpgrade); err != nil {
+		return results.WithError(err)
+	}
+
 	// Maybe upgrade some of the nodes.
 	deletedPods, err := newRollingUpgrade(
 		ctx,
@@ -65,13 +89,14 @@ func (d *defaultDriver) handleRollingUpgrades(
 		statefulSets,
 		esClient,
 		esState,
+		observedState,
 		expectedMaster,
 		actualMasters,
 		podsToUpgrade,
 		healthyPods,
 	).run()
 	if err != nil {
-		return results.WithError(err)
+		return withESError(results, d.ES, err)
 	}
 	if len(deletedPods) > 0 {
 		// Some Pods have just been deleted, we don't need to try to enable shards allocation.
@@ -86,6 +111,12 @@ func (d *defaultDriver) handleRollingUpgrades(
 	res := d.MaybeEnableShardsAllocation(ctx, esClient, esState)
 	results.WithResults(res)
 
+	// Maybe resume the machine learning jobs if upgraded machine learning nodes are back into the cluster.
+	results.WithResults(d.MaybeDisableMLUpgradeMode(ctx, esClient, esState, statefulSets, podsToUpgrade))
+
+	// Maybe start the transforms and rollup jobs stopped for the upgrade once all the nodes are upgraded.
+	results.WithResults(d.MaybeStartIndexingTasks(ctx, esClient, esState, statefulSets, podsToUpgrade))
+
 	return results
 }
 
@@ -97,6 +128,7 @@ type rollingUpgradeCtx struct {
 	esClient        esclient.Client
 	shardLister     esclient.ShardLister
 	esState         ESState
+	observedState   observer.State
 	expectations    *expectations.Expectations
 	reconcileState  *reconcile.State
 	expectedMasters []string
@@ -111,6 +143,7 @@ func newRollingUpgrade(
 	statefulSets sset.StatefulSetList,
 	esClient esclient.Client,
 	esState ESState,
+	observedState observer.State,
 	expectedMaster []string,
 	actualMasters []corev1.Pod,
 	podsToUpgrade []corev1.Pod,
@@ -124,6 +157,7 @@ func newRollingUpgrade(
 		esClient:        esClient,
 		shardLister:     esClient,
 		esState:         esState,
+		observedState:   observedState,
 		expectations:    d.Expectations,
 		reconcileState:  d.ReconcileState,
 		expectedMasters: expectedMaster,
@@ -296,6 +330,5 @@ func (ctx *rollingUpgradeCtx) prepareClusterForNodeRestart(esClient esclient.Cli
 		return err
 	}
 
-	// TODO: halt ML jobs on that node
 	return nil
 }
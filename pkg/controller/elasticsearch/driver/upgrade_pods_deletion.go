@@ -38,6 +38,7 @@ func (ctx *rollingUpgradeCtx) Delete() ([]corev1.Pod, error) {
 		ctx.parentCtx,
 		ctx.ES,
 		ctx.esState,
+		ctx.observedState,
 		ctx.shardLister,
 		ctx.healthyPods,
 		ctx.podsToUpgrade,
@@ -10,6 +10,7 @@ import (
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -21,6 +22,7 @@ type PredicateContext struct {
 	healthyPods            map[string]corev1.Pod
 	toUpdate               []corev1.Pod
 	esState                ESState
+	observedState          observer.State
 	shardLister            client.ShardLister
 	masterUpdateInProgress bool
 	ctx                    context.Context
@@ -54,6 +56,7 @@ func NewPredicateContext(
 	ctx context.Context,
 	es esv1.Elasticsearch,
 	state ESState,
+	observedState observer.State,
 	shardLister client.ShardLister,
 	healthyPods map[string]corev1.Pod,
 	podsToUpgrade []corev1.Pod,
@@ -67,6 +70,7 @@ func NewPredicateContext(
 		healthyPods:      healthyPods,
 		toUpdate:         podsToUpgrade,
 		esState:          state,
+		observedState:    observedState,
 		shardLister:      shardLister,
 		ctx:              ctx,
 	}
@@ -173,6 +177,23 @@ var predicates = [...]Predicate{
 			return false, nil
 		},
 	},
+	{
+		// If some of the additional probes of the observer fail (eg. too many pending tasks, circuit breakers
+		// tripping), only allow unhealthy Pods to be restarted, as for a red cluster.
+		name: "only_restart_healthy_node_if_observer_probes_pass",
+		fn: func(
+			context PredicateContext,
+			candidate corev1.Pod,
+			deletedPods []corev1.Pod,
+			maxUnavailableReached bool,
+		) (b bool, e error) {
+			if len(context.observedState.FailedProbes()) == 0 {
+				return true, nil
+			}
+			_, healthy := context.healthyPods[candidate.Name]
+			return !healthy, nil
+		},
+	},
 	{
 		// During a rolling upgrade, primary shards assigned to a node running a new version cannot have their
 		// replicas assigned to a node with the old version. Therefore we must allow some Pods to be restarted
@@ -419,6 +440,31 @@ var predicates = [...]Predicate{
 			return true, nil
 		},
 	},
+	{
+		// Do not restart a node while the transforms or rollup jobs it runs are in the middle of a checkpoint,
+		// unless the update strategy ignores them.
+		name: "do_not_restart_node_with_indexing_tasks_in_checkpoint",
+		fn: func(
+			context PredicateContext,
+			candidate corev1.Pod,
+			deletedPods []corev1.Pod,
+			maxUnavailableReached bool,
+		) (b bool, e error) {
+			if context.es.Spec.UpdateStrategy.IndexingTasksOrDefault() == esv1.IgnoreIndexingTasks {
+				return true, nil
+			}
+			tasks, err := context.esState.IndexingTasks()
+			if err != nil {
+				return false, err
+			}
+			for _, task := range tasks {
+				if task.Node == candidate.Name && task.Indexing {
+					return false, nil
+				}
+			}
+			return true, nil
+		},
+	},
 }
 
 func willBecomeMasterNode(name string, masters []string) bool {
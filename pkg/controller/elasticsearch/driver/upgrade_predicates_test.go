@@ -13,10 +13,13 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/migration"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // These tests are focused on "type changes", i.e. when the type of a nodeSet is changed.
@@ -777,3 +780,51 @@ func Test_groupByPredicates(t *testing.T) {
 		})
 	}
 }
+
+func TestOnlyRestartHealthyNodeIfObserverProbesPass(t *testing.T) {
+	var predicate Predicate
+	for _, p := range predicates {
+		if p.name == "only_restart_healthy_node_if_observer_probes_pass" {
+			predicate = p
+		}
+	}
+	healthyPod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "es-default-0"}}
+	unhealthyPod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "es-default-1"}}
+	tests := []struct {
+		name      string
+		probes    map[string]observer.ProbeResult
+		candidate corev1.Pod
+		want      bool
+	}{
+		{name: "no probes", candidate: healthyPod, want: true},
+		{
+			name:      "probes pass",
+			probes:    map[string]observer.ProbeResult{observer.PendingTasksProbeName: {Healthy: true}},
+			candidate: healthyPod,
+			want:      true,
+		},
+		{
+			name:      "probe fails, healthy node",
+			probes:    map[string]observer.ProbeResult{observer.PendingTasksProbeName: {Healthy: false}},
+			candidate: healthyPod,
+			want:      false,
+		},
+		{
+			name:      "probe fails, unhealthy node",
+			probes:    map[string]observer.ProbeResult{observer.PendingTasksProbeName: {Healthy: false}},
+			candidate: unhealthyPod,
+			want:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := PredicateContext{
+				healthyPods:   map[string]corev1.Pod{healthyPod.Name: healthyPod},
+				observedState: observer.State{Probes: tt.probes},
+			}
+			got, err := predicate.fn(ctx, tt.candidate, nil, false)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	controller "sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/cloudevents"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/notifier"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+// UpgradeAnnotationName records on the Elasticsearch resource the version upgrade in progress, to publish its start
+// and completion once, and to notify it once if it stalls.
+const UpgradeAnnotationName = "elasticsearch.k8s.elastic.co/upgrade"
+
+// Upgrade is a version upgrade of an Elasticsearch cluster.
+type Upgrade struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	StartTime time.Time `json:"startTime"`
+	// StallNotified is true once the upgrade is notified as stalled.
+	StallNotified bool `json:"stallNotified,omitempty"`
+}
+
+// trackedUpgrade returns the upgrade recorded in the annotations of the cluster, or nil.
+func (d *defaultDriver) trackedUpgrade() *Upgrade {
+	serialized, exists := d.ES.Annotations[UpgradeAnnotationName]
+	if !exists {
+		return nil
+	}
+	var upgrade Upgrade
+	if err := json.Unmarshal([]byte(serialized), &upgrade); err != nil {
+		log.Error(err, "Ignoring invalid upgrade annotation", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		return nil
+	}
+	return &upgrade
+}
+
+// trackUpgrade records the start of an upgrade of the nodes of the cluster, of the given minimum version, to the
+// version of its specification, and its completion once all the nodes are upgraded. The milestones are published as
+// CloudEvents, and the upgrade is notified if it does not complete within the stall threshold of the cluster.
+func (d *defaultDriver) trackUpgrade(minVersion version.Version, now time.Time) *reconciler.Results {
+	results := &reconciler.Results{}
+	if d.OperatorParameters.CloudEvents == nil && !d.OperatorParameters.Notifier.Enabled(d.Client, &d.ES) {
+		return results
+	}
+	tracked := d.trackedUpgrade()
+	if !minVersion.IsSameOrAfter(d.Version) {
+		if tracked != nil && tracked.To == d.ES.Spec.Version {
+			return results.WithResults(d.notifyStalledUpgrade(*tracked, now))
+		}
+		upgrade := Upgrade{From: minVersion.String(), To: d.ES.Spec.Version, StartTime: now.UTC()}
+		if err := d.updateTrackedUpgrade(&upgrade); err != nil {
+			return results.WithError(err)
+		}
+		d.publishUpgradeEvent(cloudevents.ElasticsearchUpgradeStarted, upgrade)
+		return results.WithResults(d.notifyStalledUpgrade(upgrade, now))
+	}
+	if _, exists := d.ES.Annotations[UpgradeAnnotationName]; !exists {
+		return results
+	}
+	if err := d.updateTrackedUpgrade(nil); err != nil {
+		return results.WithError(err)
+	}
+	if tracked != nil {
+		d.publishUpgradeEvent(cloudevents.ElasticsearchUpgradeCompleted, *tracked)
+	}
+	return results
+}
+
+// publishUpgradeEvent publishes the given milestone of the given upgrade as a CloudEvent. Nothing is published during a
+// dry run, since the tracked upgrade is not persisted and the milestone would be published again at each reconciliation.
+func (d *defaultDriver) publishUpgradeEvent(eventType string, upgrade Upgrade) {
+	if d.DryRun != nil {
+		return
+	}
+	d.OperatorParameters.CloudEvents.Publish(cloudevents.NewElasticsearchEvent(
+		eventType, d.ES, cloudevents.ElasticsearchData{PreviousVersion: upgrade.From},
+	))
+}
+
+// notifyStalledUpgrade notifies the given upgrade once it is in progress for longer than the stall threshold of the
+// cluster, or requeues the reconciliation until then. Nothing is notified during a dry run, since the notification
+// would not be recorded and would be posted again at each reconciliation.
+func (d *defaultDriver) notifyStalledUpgrade(upgrade Upgrade, now time.Time) *reconciler.Results {
+	results := &reconciler.Results{}
+	n := d.OperatorParameters.Notifier
+	if d.DryRun != nil || upgrade.StallNotified || !n.Enabled(d.Client, &d.ES) {
+		return results
+	}
+	threshold := n.UpgradeStallThreshold(&d.ES)
+	elapsed := now.Sub(upgrade.StartTime)
+	if elapsed < threshold {
+		return results.WithResult(controller.Result{Requeue: true, RequeueAfter: threshold - elapsed})
+	}
+	err := n.Notify(d.Client, &d.ES, notifier.Notification{
+		Reason: notifier.ReasonUpgradeStalled,
+		Text: fmt.Sprintf(
+			"The upgrade of Elasticsearch cluster %s/%s from %s to %s has not completed after %s",
+			d.ES.Namespace, d.ES.Name, upgrade.From, upgrade.To, elapsed.Round(time.Minute),
+		),
+	})
+	if err != nil {
+		// a failure to queue the notification does not fail the reconciliation, it is queued again later
+		log.Error(err, "Failed to notify the stalled upgrade", "namespace", d.ES.Namespace, "es_name", d.ES.Name)
+		return results.WithResult(defaultRequeue)
+	}
+	upgrade.StallNotified = true
+	return results.WithError(d.updateTrackedUpgrade(&upgrade))
+}
+
+// updateTrackedUpgrade records the given upgrade in the annotations of the cluster, or removes it if nil.
+func (d *defaultDriver) updateTrackedUpgrade(upgrade *Upgrade) error {
+	if upgrade == nil {
+		delete(d.ES.Annotations, UpgradeAnnotationName)
+		return d.Client.Update(&d.ES)
+	}
+	serialized, err := json.Marshal(upgrade)
+	if err != nil {
+		return err
+	}
+	if d.ES.Annotations == nil {
+		d.ES.Annotations = map[string]string{}
+	}
+	d.ES.Annotations[UpgradeAnnotationName] = string(serialized)
+	return d.Client.Update(&d.ES)
+}
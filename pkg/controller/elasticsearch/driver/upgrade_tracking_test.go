@@ -0,0 +1,158 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/cloudevents"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/dryrun"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/notifier"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_defaultDriver_trackUpgrade(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{Version: "7.10.0"},
+	}
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event cloudevents.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event.Type
+	}))
+	defer server.Close()
+	publisher, err := cloudevents.NewPublisher(server.URL, nil)
+	require.NoError(t, err)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		require.NoError(t, publisher.Start(stop))
+	}()
+	d := &defaultDriver{DefaultDriverParameters{
+		OperatorParameters: operator.Parameters{CloudEvents: publisher},
+		Client:             k8s.WrappedFakeClient(&es),
+		ES:                 es,
+		Version:            version.MustParse("7.10.0"),
+		ReconcileState:     reconcile.NewState(es),
+	}}
+	published := func() string {
+		select {
+		case eventType := <-received:
+			return eventType
+		case <-time.After(10 * time.Second):
+			return ""
+		}
+	}
+
+	// no upgrade
+	require.False(t, d.trackUpgrade(version.MustParse("7.10.0"), now).HasError())
+	require.Nil(t, d.trackedUpgrade())
+
+	// the upgrade starts, and is published once
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now).HasError())
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now.Add(time.Minute)).HasError())
+	require.Equal(t, cloudevents.ElasticsearchUpgradeStarted, published())
+	require.Equal(t, &Upgrade{From: "7.9.3", To: "7.10.0", StartTime: now}, d.trackedUpgrade())
+
+	// the upgrade completes
+	require.False(t, d.trackUpgrade(version.MustParse("7.10.0"), now.Add(time.Hour)).HasError())
+	require.Equal(t, cloudevents.ElasticsearchUpgradeCompleted, published())
+	require.NotContains(t, d.ES.Annotations, UpgradeAnnotationName)
+	require.Empty(t, received)
+
+	// nothing is published during a dry run: the events published afterwards are the next ones received
+	d.DryRun = dryrun.NewChanges()
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now).HasError())
+	require.False(t, d.trackUpgrade(version.MustParse("7.10.0"), now.Add(time.Hour)).HasError())
+	d.DryRun = nil
+	publisher.Publish(cloudevents.NewElasticsearchEvent(cloudevents.ElasticsearchCreated, es, cloudevents.ElasticsearchData{}))
+	require.Equal(t, cloudevents.ElasticsearchCreated, published())
+
+	// nothing is tracked if CloudEvents are not published and notifications are disabled
+	d.OperatorParameters.CloudEvents = nil
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now).HasError())
+	require.Nil(t, d.trackedUpgrade())
+}
+
+func Test_defaultDriver_notifyStalledUpgrade(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: map[string]string{
+			notifier.UpgradeStallThresholdAnnotationName: "30m",
+		}},
+		Spec: esv1.ElasticsearchSpec{Version: "7.10.0"},
+	}
+	received := make(chan notifier.Notification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification notifier.Notification
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		received <- notification
+	}))
+	defer server.Close()
+	n, err := notifier.New(server.URL, nil, time.Hour)
+	require.NoError(t, err)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		require.NoError(t, n.Start(stop))
+	}()
+	d := &defaultDriver{DefaultDriverParameters{
+		OperatorParameters: operator.Parameters{Notifier: n},
+		Client:             k8s.WrappedFakeClient(&es),
+		ES:                 es,
+		Version:            version.MustParse("7.10.0"),
+		ReconcileState:     reconcile.NewState(es),
+	}}
+
+	// the upgrade starts, the reconciliation is requeued until the stall threshold of the cluster
+	result, err := d.trackUpgrade(version.MustParse("7.9.3"), now).Aggregate()
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, result.RequeueAfter)
+	result, err = d.trackUpgrade(version.MustParse("7.9.3"), now.Add(10*time.Minute)).Aggregate()
+	require.NoError(t, err)
+	require.Equal(t, 20*time.Minute, result.RequeueAfter)
+	require.Empty(t, received)
+
+	// nothing is notified during a dry run
+	d.DryRun = dryrun.NewChanges()
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now.Add(45*time.Minute)).HasError())
+	require.False(t, d.trackedUpgrade().StallNotified)
+	d.DryRun = nil
+
+	// the stalled upgrade is notified once
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now.Add(45*time.Minute)).HasError())
+	require.False(t, d.trackUpgrade(version.MustParse("7.9.3"), now.Add(50*time.Minute)).HasError())
+	select {
+	case notification := <-received:
+		require.Equal(t, notifier.Notification{
+			Text:      "The upgrade of Elasticsearch cluster ns/es from 7.9.3 to 7.10.0 has not completed after 45m0s",
+			Reason:    notifier.ReasonUpgradeStalled,
+			Namespace: "ns",
+			Name:      "es",
+		}, notification)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "notification not received")
+	}
+	require.Empty(t, received)
+	require.True(t, d.trackedUpgrade().StallNotified)
+
+	// the upgrade completes
+	require.False(t, d.trackUpgrade(version.MustParse("7.10.0"), now.Add(time.Hour)).HasError())
+	require.Nil(t, d.trackedUpgrade())
+}
@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+)
+
+const (
+	// VirtualMemoryConditionType is the type of the condition reporting whether the hosts of the Elasticsearch Pods
+	// set the vm.max_map_count kernel setting high enough, if checked.
+	VirtualMemoryConditionType commonv1.ConditionType = "VirtualMemorySufficient"
+	// ReasonMaxMapCountTooLow is the reason of the condition when the setting of some hosts is too low.
+	ReasonMaxMapCountTooLow = "MaxMapCountTooLow"
+	// ReasonMaxMapCountChecked is the reason of the condition when no check failed.
+	ReasonMaxMapCountChecked = "MaxMapCountChecked"
+)
+
+// reconcileVirtualMemoryCondition reports the Pods whose check of the vm.max_map_count kernel setting of the host
+// failed in the status condition of the cluster, and warns when it first happens.
+func (d *defaultDriver) reconcileVirtualMemoryCondition(pods []corev1.Pod) {
+	if !d.ES.Spec.VirtualMemory.IsMaxMapCountChecked() {
+		d.ReconcileState.RemoveCondition(VirtualMemoryConditionType)
+		return
+	}
+	condition := virtualMemoryCondition(pods)
+	previous := d.ReconcileState.Conditions().Get(VirtualMemoryConditionType)
+	if condition.Status == corev1.ConditionFalse && (previous == nil || previous.Status != corev1.ConditionFalse) {
+		d.ReconcileState.AddEvent(corev1.EventTypeWarning, events.EventReasonUnhealthy, condition.Message)
+	}
+	d.ReconcileState.UpdateCondition(condition)
+}
+
+// virtualMemoryCondition returns the condition reporting the hosts of the given Pods whose vm.max_map_count kernel
+// setting is too low.
+func virtualMemoryCondition(pods []corev1.Pod) commonv1.Condition {
+	var failures []string
+	for _, pod := range pods {
+		if msg, failed := maxMapCountCheckFailure(pod); failed {
+			failures = append(failures, fmt.Sprintf("Pod %s on host %s: %s", pod.Name, pod.Spec.NodeName, msg))
+		}
+	}
+	if len(failures) == 0 {
+		return commonv1.Condition{
+			Type:   VirtualMemoryConditionType,
+			Status: corev1.ConditionTrue,
+			Reason: ReasonMaxMapCountChecked,
+		}
+	}
+	sort.Strings(failures)
+	return commonv1.Condition{
+		Type:    VirtualMemoryConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  ReasonMaxMapCountTooLow,
+		Message: "vm.max_map_count kernel setting too low on the hosts of the Elasticsearch Pods. " + strings.Join(failures, "; "),
+	}
+}
+
+// maxMapCountCheckFailure returns the termination message of the failed check of the vm.max_map_count kernel setting
+// of the host of the Pod, if its latest run failed.
+func maxMapCountCheckFailure(pod corev1.Pod) (string, bool) {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != initcontainer.CheckVMContainerName {
+			continue
+		}
+		terminated := status.State.Terminated
+		if terminated == nil {
+			// waiting to be restarted, or running again
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated == nil || terminated.ExitCode == 0 {
+			return "", false
+		}
+		return strings.TrimSpace(terminated.Message), true
+	}
+	return "", false
+}
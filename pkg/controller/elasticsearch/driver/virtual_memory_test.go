@@ -0,0 +1,81 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+)
+
+func checkVMPod(name string, status corev1.ContainerStatus) corev1.Pod {
+	status.Name = initcontainer.CheckVMContainerName
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PodSpec{NodeName: "host-" + name},
+		Status:     corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{status}},
+	}
+}
+
+func Test_virtualMemoryCondition(t *testing.T) {
+	failed := &corev1.ContainerStateTerminated{ExitCode: 1, Message: "vm.max_map_count [65530] is lower than 262144\n"}
+	succeeded := &corev1.ContainerStateTerminated{ExitCode: 0}
+	tests := []struct {
+		name        string
+		pods        []corev1.Pod
+		wantStatus  corev1.ConditionStatus
+		wantMessage string
+	}{
+		{
+			name:       "no Pods",
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name: "checks succeeded, or not run yet",
+			pods: []corev1.Pod{
+				checkVMPod("es-0", corev1.ContainerStatus{State: corev1.ContainerState{Terminated: succeeded}}),
+				checkVMPod("es-1", corev1.ContainerStatus{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}}),
+				{ObjectMeta: metav1.ObjectMeta{Name: "es-2"}},
+			},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name: "succeeded after a failure",
+			pods: []corev1.Pod{
+				checkVMPod("es-0", corev1.ContainerStatus{
+					State:                corev1.ContainerState{Terminated: succeeded},
+					LastTerminationState: corev1.ContainerState{Terminated: failed},
+				}),
+			},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name: "checks failed",
+			pods: []corev1.Pod{
+				checkVMPod("es-1", corev1.ContainerStatus{
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: failed},
+				}),
+				checkVMPod("es-0", corev1.ContainerStatus{State: corev1.ContainerState{Terminated: failed}}),
+			},
+			wantStatus: corev1.ConditionFalse,
+			wantMessage: "vm.max_map_count kernel setting too low on the hosts of the Elasticsearch Pods. " +
+				"Pod es-0 on host host-es-0: vm.max_map_count [65530] is lower than 262144; " +
+				"Pod es-1 on host host-es-1: vm.max_map_count [65530] is lower than 262144",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := virtualMemoryCondition(tt.pods)
+			require.Equal(t, VirtualMemoryConditionType, condition.Type)
+			require.Equal(t, tt.wantStatus, condition.Status)
+			require.Equal(t, tt.wantMessage, condition.Message)
+		})
+	}
+}
@@ -6,36 +6,52 @@ package elasticsearch
 
 import (
 	"context"
+	"fmt"
 	"sync/atomic"
+	"time"
 
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/annotation"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/association"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/cloudevents"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/dryrun"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/expectations"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/finalizer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/license"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/metrics"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/notifier"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/profile"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	commonversion "github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/cleanup"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/diagnostics"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/driver"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
 	esreconcile "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/reconcile"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/report"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
 	esversion "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/version"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
 	pkgerrors "github.com/pkg/errors"
 	"go.elastic.co/apm"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -51,9 +67,15 @@ var log = logf.Log.WithName(name)
 // Add creates a new Elasticsearch Controller and adds it to the Manager with default RBAC. The Manager will set fields
 // on the Controller and Start it when the Manager is Started.
 // this is also called by cmd/main.go
-func Add(mgr manager.Manager, params operator.Parameters) error {
-	reconciler := newReconciler(mgr, params)
-	c, err := common.NewController(mgr, name, reconciler, params)
+func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
+	reconciler := newReconciler(mgr, accessReviewer, params)
+	if params.Drainer != nil {
+		params.Drainer.OnShutdown(reconciler.onShutdown)
+	}
+	if params.ManagedNamespaces != nil {
+		params.ManagedNamespaces.OnRemoved(reconciler.onNamespaceRemoved)
+	}
+	c, err := common.NewController(mgr, name, &esv1.Elasticsearch{}, reconciler, params)
 	if err != nil {
 		return err
 	}
@@ -61,15 +83,41 @@ func Add(mgr manager.Manager, params operator.Parameters) error {
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, params operator.Parameters) *ReconcileElasticsearch {
+func newReconciler(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) *ReconcileElasticsearch {
 	client := k8s.WrapClient(mgr.GetClient())
 	observerSettings := observer.DefaultSettings
 	observerSettings.Tracer = params.Tracer
+	probes, err := observer.ParseProbes(params.ObserverProbes)
+	if err != nil {
+		// validated when the operator starts
+		log.Error(err, "Ignoring invalid observer probes")
+	}
+	observerSettings.Probes = probes
+	observerSettings.BatchWorkers = params.ObserverBatchWorkers
+	if params.Config != nil {
+		observerSettings.ObservationInterval = params.Config.Get().ObservationInterval
+	}
+	esObservers := observer.NewManager(observerSettings)
+	if params.Config != nil {
+		params.Config.OnChange(func(settings operator.Settings) {
+			esObservers.SetObservationInterval(settings.ObservationInterval)
+		})
+	}
+	if params.DebugMux != nil {
+		params.DebugMux.Handle(observer.DebugPath, observer.DebugHandler(esObservers))
+	}
+	// maintain an ElasticsearchReport from the observed states
+	esObservers.AddObservationListener(report.NewReporter(client, report.DefaultInterval, params.RightSizingRecommendations).OnObservation)
+	// keep the recent states for diagnostics bundles
+	history := diagnostics.NewHistory(diagnostics.DefaultHistorySize)
+	esObservers.AddObservationListener(history.OnObservation)
 	return &ReconcileElasticsearch{
 		Client:         client,
 		recorder:       mgr.GetEventRecorderFor(name),
 		licenseChecker: license.NewLicenseChecker(client, params.OperatorNamespace),
-		esObservers:    observer.NewManager(observerSettings),
+		accessReviewer: accessReviewer,
+		esObservers:    esObservers,
+		history:        history,
 
 		dynamicWatches: watches.NewDynamicWatches(),
 		expectations:   expectations.NewClustersExpectations(client),
@@ -140,6 +188,37 @@ func addWatches(c controller.Controller, r *ReconcileElasticsearch) error {
 		return err
 	}
 
+	// Watch user-provided ConfigMaps
+	if err := c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, r.dynamicWatches.ConfigMaps); err != nil {
+		return err
+	}
+
+	// Watch users and roles declared for ES clusters
+	referencedCluster := referencedClusterHandler()
+	if err := c.Watch(&source.Kind{Type: &authv1alpha1.ElasticsearchUser{}}, referencedCluster); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Kind{Type: &authv1alpha1.ElasticsearchRole{}}, referencedCluster); err != nil {
+		return err
+	}
+
+	// Watch the placement policies applied to the Pods
+	if err := watchPlacementPolicies(c, r.Client, r.Parameters.OperatorNamespace); err != nil {
+		return err
+	}
+
+	// Watch the defaults profiles overriding the operator settings per namespace
+	if err := profile.WatchOverlays(c, r.Client, r.Parameters.OperatorNamespace, &esv1.ElasticsearchList{}); err != nil {
+		return err
+	}
+
+	// Watch the Kubernetes nodes about to be drained or preempted
+	if hosts := driver.NewLeavingHosts(r.Parameters); hosts.Enabled() {
+		if err := watchLeavingHosts(c, r.Client, hosts); err != nil {
+			return err
+		}
+	}
+
 	// Trigger a reconciliation when observers report a cluster health change
 	if err := c.Watch(observer.WatchClusterHealthChange(r.esObservers), reconciler.GenericEventHandler()); err != nil {
 		return err
@@ -156,8 +235,11 @@ type ReconcileElasticsearch struct {
 	operator.Parameters
 	recorder       record.EventRecorder
 	licenseChecker license.Checker
+	accessReviewer rbac.AccessReviewer
 
 	esObservers *observer.Manager
+	// history holds the recent observed states of each cluster
+	history *diagnostics.History
 
 	dynamicWatches watches.DynamicWatches
 
@@ -188,6 +270,10 @@ func (r *ReconcileElasticsearch) Reconcile(request reconcile.Request) (reconcile
 		return common.PauseRequeue, nil
 	}
 
+	if dryrun.IsEnabled(es.ObjectMeta) {
+		return r.dryRunReconcile(ctx, es)
+	}
+
 	selector := map[string]string{label.ClusterNameLabelName: es.Name}
 	compat, err := annotation.ReconcileCompatibility(ctx, r.Client, &es, selector, r.OperatorInfo.BuildInfo.Version)
 	if err != nil {
@@ -210,8 +296,18 @@ func (r *ReconcileElasticsearch) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, tracing.CaptureError(ctx, err)
 	}
 
+	// apply the referenced ElasticsearchClass, in case the webhook has not been configured
+	if err := r.applyClass(&es); err != nil {
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+
+	// generate a diagnostics bundle if requested, regardless of the outcome of the reconciliation
+	if err := diagnostics.Reconcile(r.Client, es, r.history, r.RecentLogs, time.Now()); err != nil {
+		k8s.EmitErrorEvent(r.recorder, err, &es, events.EventDiagnosticsError, "Failed to generate diagnostics bundle: %v", err)
+	}
+
 	state := esreconcile.NewState(es)
-	results := r.internalReconcile(ctx, es, state)
+	results := r.internalReconcile(ctx, es, state, nil)
 	err = r.updateStatus(ctx, es, state)
 	if err != nil {
 		if apierrors.IsConflict(err) {
@@ -223,11 +319,55 @@ func (r *ReconcileElasticsearch) Reconcile(request reconcile.Request) (reconcile
 	return results.WithError(err).Aggregate()
 }
 
+// dryRunReconcile runs the reconciliation of the given Elasticsearch resource with clients recording the changes instead
+// of applying them, and emits an event summarizing the changes. The status is not updated.
+func (r *ReconcileElasticsearch) dryRunReconcile(ctx context.Context, es esv1.Elasticsearch) (reconcile.Result, error) {
+	changes := dryrun.NewChanges()
+	c := r.Client.WithInterceptor(func(c client.Client) client.Client {
+		return dryrun.NewClient(c, scheme.Scheme, changes)
+	})
+	results := r.internalReconcile(ctx, es, esreconcile.NewState(es), &dryRunParameters{client: c, changes: changes})
+	res, err := results.Aggregate()
+	if err != nil {
+		// the reconciliation could not proceed further, report the changes computed so far
+		log.Info("Dry-run reconciliation stopped on error", "namespace", es.Namespace, "es_name", es.Name, "error", err.Error())
+	}
+	log.Info("Dry-run reconciliation", "namespace", es.Namespace, "es_name", es.Name, "changes", changes.List())
+	r.recorder.Event(&es, corev1.EventTypeNormal, events.EventReasonDryRun, changes.Summary())
+	return res, nil
+}
+
+// dryRunParameters are the parameters of a dry-run reconciliation.
+type dryRunParameters struct {
+	client  k8s.Client
+	changes *dryrun.Changes
+}
+
+// applyClass applies the ElasticsearchClass referenced by the given Elasticsearch resource, and persists the resulting
+// specification. Failing to apply the class is only an error if no class was applied yet, the specification being
+// incomplete.
+func (r *ReconcileElasticsearch) applyClass(es *esv1.Elasticsearch) error {
+	applied, err := profile.ApplyClass(r.Client, es)
+	if err != nil {
+		k8s.EmitErrorEvent(r.recorder, err, es, events.EventClassError, "Failed to apply Elasticsearch class: %v", err)
+		if es.ClassPending() {
+			return err
+		}
+		return nil
+	}
+	if !applied {
+		return nil
+	}
+	log.Info("Applying Elasticsearch class", "namespace", es.Namespace, "es_name", es.Name, "class", es.Spec.ClassName)
+	return r.Client.Update(es)
+}
+
 func (r *ReconcileElasticsearch) fetchElasticsearch(ctx context.Context, request reconcile.Request, es *esv1.Elasticsearch) (bool, error) {
 	span, _ := apm.StartSpan(ctx, "fetch_elasticsearch", tracing.SpanTypeApp)
 	defer span.End()
 
-	err := r.Get(request.NamespacedName, es)
+	// the association configuration holds the monitoring cluster audit logs are shipped to
+	err := association.FetchWithAssociation(ctx, r.Client, request, es)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
@@ -236,7 +376,8 @@ func (r *ReconcileElasticsearch) fetchElasticsearch(ctx context.Context, request
 				Namespace: request.Namespace,
 				Name:      request.Name,
 			})
-			return true, nil
+			// the credentials of the deleted cluster must not be reused by a cluster recreated with the same name
+			return true, cleanup.DeleteStoredCredentials(request.NamespacedName)
 		}
 		// Error reading the object - requeue the request.
 		return true, err
@@ -248,6 +389,7 @@ func (r *ReconcileElasticsearch) internalReconcile(
 	ctx context.Context,
 	es esv1.Elasticsearch,
 	reconcileState *esreconcile.State,
+	dryRun *dryRunParameters,
 ) *reconciler.Results {
 	results := reconciler.NewResult(ctx)
 
@@ -292,8 +434,8 @@ func (r *ReconcileElasticsearch) internalReconcile(
 		return results.WithError(pkgerrors.Errorf("unsupported version: %s", ver))
 	}
 
-	return driver.NewDefaultDriver(driver.DefaultDriverParameters{
-		OperatorParameters: r.Parameters,
+	params := driver.DefaultDriverParameters{
+		OperatorParameters: r.Parameters.ForNamespace(es.Namespace),
 		ES:                 es,
 		ReconcileState:     reconcileState,
 		Client:             r.Client,
@@ -304,7 +446,17 @@ func (r *ReconcileElasticsearch) internalReconcile(
 		DynamicWatches:     r.dynamicWatches,
 		SupportedVersions:  *supported,
 		LicenseChecker:     r.licenseChecker,
-	}).Reconcile(ctx)
+		AccessReviewer:     r.accessReviewer,
+	}
+	if dryRun != nil {
+		// changes are not applied: do not emit events about them, nor expect to observe them in the cache
+		params.Client = dryRun.client
+		params.Recorder = &record.FakeRecorder{}
+		params.Expectations = expectations.NewExpectations(dryRun.client)
+		params.DryRun = dryRun.changes
+		params.Credentials = credentials.NewDryRun(dryRun.changes)
+	}
+	return driver.NewDefaultDriver(params).Reconcile(ctx)
 }
 
 func (r *ReconcileElasticsearch) updateStatus(
@@ -329,15 +481,84 @@ func (r *ReconcileElasticsearch) updateStatus(
 		"es_name", es.Name,
 		"status", cluster.Status,
 	)
-	return common.UpdateStatus(r.Client, cluster)
+	if err := common.UpdateStatus(r.Client, cluster); err != nil {
+		return err
+	}
+	publishStatusEvents(r.CloudEvents, es, *cluster)
+	notifyRedHealth(r.Client, r.Notifier, es, *cluster)
+	return nil
+}
+
+// publishStatusEvents publishes the creation of the given cluster on its first status update, and the degradations of
+// its health.
+func publishStatusEvents(publisher *cloudevents.Publisher, previous esv1.Elasticsearch, current esv1.Elasticsearch) {
+	if previous.Status.Phase == "" {
+		publisher.Publish(cloudevents.NewElasticsearchEvent(
+			cloudevents.ElasticsearchCreated, current, cloudevents.ElasticsearchData{Health: current.Status.Health},
+		))
+	}
+	if current.Status.IsDegraded(previous.Status) {
+		publisher.Publish(cloudevents.NewElasticsearchEvent(
+			cloudevents.ElasticsearchHealthDegraded, current, cloudevents.ElasticsearchData{
+				Health:         current.Status.Health,
+				PreviousHealth: previous.Status.Health,
+			},
+		))
+	}
+}
+
+// notifyRedHealth notifies the given cluster turning red. The notification is posted in the background, a failure to
+// queue it does not fail the reconciliation.
+func notifyRedHealth(c k8s.Client, n *notifier.Notifier, previous esv1.Elasticsearch, current esv1.Elasticsearch) {
+	if current.Status.Health != esv1.ElasticsearchRedHealth || previous.Status.Health == esv1.ElasticsearchRedHealth ||
+		previous.Status.Health == "" {
+		return
+	}
+	err := n.Notify(c, &current, notifier.Notification{
+		Reason: notifier.ReasonClusterRed,
+		Text: fmt.Sprintf(
+			"Elasticsearch cluster %s/%s health changed from %s to red", current.Namespace, current.Name, previous.Status.Health,
+		),
+	})
+	if err != nil {
+		log.Error(err, "Failed to notify the red health", "namespace", current.Namespace, "es_name", current.Name)
+	}
 }
 
 // onDelete garbage collect resources when a Elasticsearch cluster is deleted
 func (r *ReconcileElasticsearch) onDelete(es types.NamespacedName) {
 	r.expectations.RemoveCluster(es)
 	r.esObservers.StopObserving(es)
+	r.history.Forget(es)
+	metrics.DefaultElasticsearchRequestMetrics.Forget(es)
 	r.dynamicWatches.Secrets.RemoveHandlerForKey(keystore.SecureSettingsWatchName(es))
 	r.dynamicWatches.Secrets.RemoveHandlerForKey(http.CertificateWatchKey(esv1.ESNamer, es.Name))
 	r.dynamicWatches.Secrets.RemoveHandlerForKey(user.UserProvidedRolesWatchName(es))
 	r.dynamicWatches.Secrets.RemoveHandlerForKey(user.UserProvidedFileRealmWatchName(es))
+	r.dynamicWatches.Secrets.RemoveHandlerForKey(user.DeclaredUsersWatchName(es))
+	r.dynamicWatches.ConfigMaps.RemoveHandlerForKey(driver.AnalysisFilesWatchName(es))
+	r.dynamicWatches.ConfigMaps.RemoveHandlerForKey(driver.NodeSetProfilesWatchName(es))
+}
+
+// onShutdown stops the observers, and persists the pending expectations if no reconciliation is running anymore.
+func (r *ReconcileElasticsearch) onShutdown(drained bool) {
+	for _, cluster := range r.esObservers.List() {
+		r.esObservers.StopObserving(cluster)
+	}
+	if !drained {
+		log.Info("Reconciliations still in progress, not persisting expectations")
+		return
+	}
+	if err := r.expectations.Persist(); err != nil {
+		log.Error(err, "Failed to persist expectations")
+	}
+}
+
+// onNamespaceRemoved cleans up the resources of the clusters in a namespace not managed anymore.
+func (r *ReconcileElasticsearch) onNamespaceRemoved(namespace string) {
+	for _, cluster := range r.esObservers.List() {
+		if cluster.Namespace == namespace {
+			r.onDelete(cluster)
+		}
+	}
 }
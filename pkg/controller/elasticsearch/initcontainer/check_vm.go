@@ -0,0 +1,45 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package initcontainer
+
+import (
+	"fmt"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// CheckVMContainerName is the name of the init container checking the vm.max_map_count kernel setting of the host.
+const CheckVMContainerName = "elastic-internal-check-vm"
+
+// CheckVMScript fails if the vm.max_map_count kernel setting of the host is too low for Elasticsearch to memory map
+// the indices. The setting is not namespaced: it is read from the host without privileges. The failure is reported
+// in the termination message of the container, for the operator to surface it in the status of the cluster.
+var CheckVMScript = fmt.Sprintf(`#!/usr/bin/env bash
+
+set -eu
+
+max_map_count=$(cat /proc/sys/vm/max_map_count)
+if [[ "${max_map_count}" -lt %[1]d ]]; then
+	echo "vm.max_map_count [${max_map_count}] is lower than %[1]d" | tee /dev/termination-log
+	exit 1
+fi
+`, esv1.MinMaxMapCount)
+
+// NewCheckVMInitContainer creates the init container checking the vm.max_map_count kernel setting of the host. It runs
+// after the init containers of the user, which may set it. This container does not need to be privileged.
+func NewCheckVMInitContainer(imageName string) corev1.Container {
+	privileged := false
+	return corev1.Container{
+		Image:           imageName,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Name:            CheckVMContainerName,
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: &privileged,
+		},
+		Command:   []string{"bash", "-c", CheckVMScript},
+		Resources: defaultResources,
+	}
+}
@@ -5,6 +5,7 @@
 package initcontainer
 
 import (
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
 	corev1 "k8s.io/api/core/v1"
@@ -19,6 +20,7 @@ func NewInitContainers(
 	transportCertificatesVolume volume.SecretVolume,
 	clusterName string,
 	keystoreResources *keystore.Resources,
+	nodeDebug *esv1.NodeDebug,
 ) ([]corev1.Container, error) {
 	var containers []corev1.Container
 	prepareFsContainer, err := NewPrepareFSInitContainer(elasticsearchImage, transportCertificatesVolume, clusterName)
@@ -31,5 +33,11 @@ func NewInitContainers(
 		containers = append(containers, keystoreResources.InitContainer)
 	}
 
+	// hold the suspended Pods once everything else is ready for Elasticsearch to start. The init container is only
+	// added once spec.nodeDebug is set, not to restart the Pods of the clusters which never suspend any.
+	if nodeDebug != nil {
+		containers = append(containers, NewSuspendInitContainer(elasticsearchImage, clusterName))
+	}
+
 	return containers, nil
 }
@@ -7,6 +7,7 @@ package initcontainer
 import (
 	"testing"
 
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +18,7 @@ func TestNewInitContainers(t *testing.T) {
 		elasticsearchImage string
 		operatorImage      string
 		keystoreResources  *keystore.Resources
+		nodeDebug          *esv1.NodeDebug
 	}
 	tests := []struct {
 		name                       string
@@ -32,6 +34,23 @@ func TestNewInitContainers(t *testing.T) {
 			},
 			expectedNumberOfContainers: 2,
 		},
+		{
+			name: "without keystore resources",
+			args: args{
+				elasticsearchImage: "es-image",
+				operatorImage:      "op-image",
+			},
+			expectedNumberOfContainers: 1,
+		},
+		{
+			name: "with node debug",
+			args: args{
+				elasticsearchImage: "es-image",
+				operatorImage:      "op-image",
+				nodeDebug:          &esv1.NodeDebug{},
+			},
+			expectedNumberOfContainers: 2,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -40,6 +59,7 @@ func TestNewInitContainers(t *testing.T) {
 				volume.SecretVolume{},
 				"clustername",
 				tt.args.keystoreResources,
+				tt.args.nodeDebug,
 			)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedNumberOfContainers, len(containers))
@@ -16,7 +16,9 @@ const (
 // KeystoreParams is used to generate the init container that will load the secure settings into a keystore.
 var KeystoreParams = keystore.InitContainerParameters{
 	KeystoreCreateCommand:         KeystoreBinPath + " create",
-	KeystoreAddCommand:            KeystoreBinPath + ` add-file "$key" "$filename"`,
+	KeystoreAddCommand:            KeystoreBinPath + ` add-file --force "$key" "$filename"`,
+	KeystoreRemoveCommand:         KeystoreBinPath + ` remove "$key"`,
 	SecureSettingsVolumeMountPath: keystore.SecureSettingsVolumeMountPath,
 	DataVolumePath:                esvolume.ElasticsearchDataMountPath,
+	KeystorePath:                  esvolume.ConfigVolumeMountPath + "/elasticsearch.keystore",
 }
@@ -8,6 +8,8 @@ package initcontainer
 type LinkedFile struct {
 	Source string
 	Target string
+	// Optional files are only linked if the source exists, creating the target directory if needed.
+	Optional bool
 }
 
 // LinkedFilesArray contains all files to be linked in the init container.
@@ -0,0 +1,138 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package initcontainer
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+const (
+	// PluginsInitContainerName is the name of the container that installs the plugins.
+	PluginsInitContainerName = "elastic-internal-install-plugins"
+	// PluginsBundleVolumeName is the name of the volume holding the plugin archives in air-gapped mode.
+	PluginsBundleVolumeName = "elastic-internal-plugins-bundle"
+	// PluginsBundleVolumeMountPath is where the plugin archives are mounted in air-gapped mode.
+	PluginsBundleVolumeMountPath = "/mnt/elastic-internal/plugins-bundle"
+
+	pluginBinPath = "/usr/share/elasticsearch/bin/elasticsearch-plugin"
+	// pluginsDownloadDir is where the plugin archives are downloaded before being installed.
+	pluginsDownloadDir = "/tmp/elastic-internal-plugins"
+)
+
+// pluginsScript installs the plugins not installed yet, from a downloaded archive or from the bundle, once their
+// checksum is verified. The plugins are installed in the plugins/ directory shared with the Elasticsearch container.
+const pluginsScript = `#!/usr/bin/env bash
+
+set -eu
+
+mkdir -p {{ .DownloadDir }}
+installed=$({{ .PluginBin }} list)
+{{ range .Plugins }}
+if echo "$installed" | grep -qx {{ quote .Name }}; then
+	echo "Plugin "{{ quote .Name }}" already installed."
+else
+	archive={{ quote .Archive }}
+	{{- if .URL }}
+	echo "Downloading plugin "{{ quote .Name }}" from "{{ quote .URL }}"."
+	curl -fsSL --retry 3 -o "$archive" {{ quote .URL }}
+	{{- end }}
+	{{- if .SHA512 }}
+	echo {{ quote .SHA512 }}"  $archive" | sha512sum -c -
+	{{- end }}
+	echo "Installing plugin "{{ quote .Name }}"."
+	{{ $.PluginBin }} install --batch "file://$archive"
+fi
+{{ end }}
+echo "Plugins installation successful."
+`
+
+var pluginsScriptTemplate = template.Must(template.New("").Funcs(template.FuncMap{"quote": shellQuote}).Parse(pluginsScript))
+
+// pluginInstallation is a plugin to install, from its archive downloaded from URL, or else from the bundle.
+type pluginInstallation struct {
+	Name    string
+	Archive string
+	URL     string
+	SHA512  string
+}
+
+// shellQuote quotes the given string for it to be interpreted literally by bash.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// pluginInstallations returns how to install each of the given plugins, at the given version of Elasticsearch.
+func pluginInstallations(esVersion string, plugins esv1.Plugins) []pluginInstallation {
+	registryURL := plugins.RegistryURL
+	if registryURL == "" {
+		registryURL = esv1.DefaultPluginsRegistryURL
+	}
+	installations := make([]pluginInstallation, 0, len(plugins.Install))
+	for _, plugin := range plugins.Install {
+		archiveName := plugin.ArchiveName(plugin.VersionOrDefault(esVersion))
+		installation := pluginInstallation{Name: plugin.Name, SHA512: plugin.SHA512}
+		switch {
+		case plugins.Bundle != nil:
+			installation.Archive = path.Join(PluginsBundleVolumeMountPath, archiveName)
+		case plugin.URL != "":
+			installation.Archive = path.Join(pluginsDownloadDir, archiveName)
+			installation.URL = plugin.URL
+		default:
+			installation.Archive = path.Join(pluginsDownloadDir, archiveName)
+			installation.URL = strings.TrimSuffix(registryURL, "/") + "/" + plugin.Name + "/" + archiveName
+		}
+		installations = append(installations, installation)
+	}
+	return installations
+}
+
+// NewPluginsInitContainer creates an init container installing the given plugins, and the volumes it requires.
+// The init container inherits the image and the volume mounts of the Elasticsearch container, including the
+// plugins/ directory shared with it.
+func NewPluginsInitContainer(esVersion string, plugins esv1.Plugins) (corev1.Container, []corev1.Volume, error) {
+	tplBuffer := bytes.Buffer{}
+	if err := pluginsScriptTemplate.Execute(&tplBuffer, map[string]interface{}{
+		"DownloadDir": pluginsDownloadDir,
+		"PluginBin":   pluginBinPath,
+		"Plugins":     pluginInstallations(esVersion, plugins),
+	}); err != nil {
+		return corev1.Container{}, nil, err
+	}
+
+	privileged := false
+	container := corev1.Container{
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Name:            PluginsInitContainerName,
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: &privileged,
+		},
+		Command: []string{"/usr/bin/env", "bash", "-c", tplBuffer.String()},
+	}
+
+	bundle := plugins.Bundle
+	if bundle == nil {
+		return container, nil, nil
+	}
+	bundleVolume := corev1.Volume{Name: PluginsBundleVolumeName}
+	if bundle.SecretName != "" {
+		bundleVolume.VolumeSource.Secret = &corev1.SecretVolumeSource{SecretName: bundle.SecretName}
+	} else {
+		bundleVolume.VolumeSource.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: bundle.PersistentVolumeClaimName,
+			ReadOnly:  true,
+		}
+	}
+	container.VolumeMounts = []corev1.VolumeMount{
+		{Name: PluginsBundleVolumeName, MountPath: PluginsBundleVolumeMountPath, ReadOnly: true},
+	}
+	return container, []corev1.Volume{bundleVolume}, nil
+}
@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package initcontainer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+func Test_pluginInstallations(t *testing.T) {
+	plugins := []esv1.Plugin{
+		{Name: "analysis-icu"},
+		{Name: "repository-gcs", Version: "7.6.1", SHA512: "abcd"},
+		{Name: "custom", Version: "1.0.0", URL: "https://example.com/custom.zip"},
+	}
+	tests := []struct {
+		name    string
+		plugins esv1.Plugins
+		want    []pluginInstallation
+	}{
+		{
+			name:    "download from the default registry or from a URL",
+			plugins: esv1.Plugins{Install: plugins},
+			want: []pluginInstallation{
+				{
+					Name:    "analysis-icu",
+					Archive: "/tmp/elastic-internal-plugins/analysis-icu-7.6.0.zip",
+					URL:     "https://artifacts.elastic.co/downloads/elasticsearch-plugins/analysis-icu/analysis-icu-7.6.0.zip",
+				},
+				{
+					Name:    "repository-gcs",
+					Archive: "/tmp/elastic-internal-plugins/repository-gcs-7.6.1.zip",
+					URL:     "https://artifacts.elastic.co/downloads/elasticsearch-plugins/repository-gcs/repository-gcs-7.6.1.zip",
+					SHA512:  "abcd",
+				},
+				{
+					Name:    "custom",
+					Archive: "/tmp/elastic-internal-plugins/custom-1.0.0.zip",
+					URL:     "https://example.com/custom.zip",
+				},
+			},
+		},
+		{
+			name:    "download from a mirror of the registry",
+			plugins: esv1.Plugins{Install: plugins[:1], RegistryURL: "https://mirror.example.com/plugins/"},
+			want: []pluginInstallation{
+				{
+					Name:    "analysis-icu",
+					Archive: "/tmp/elastic-internal-plugins/analysis-icu-7.6.0.zip",
+					URL:     "https://mirror.example.com/plugins/analysis-icu/analysis-icu-7.6.0.zip",
+				},
+			},
+		},
+		{
+			name:    "install from the bundle",
+			plugins: esv1.Plugins{Install: plugins[:2], Bundle: &esv1.PluginBundle{SecretName: "plugins"}},
+			want: []pluginInstallation{
+				{Name: "analysis-icu", Archive: "/mnt/elastic-internal/plugins-bundle/analysis-icu-7.6.0.zip"},
+				{Name: "repository-gcs", Archive: "/mnt/elastic-internal/plugins-bundle/repository-gcs-7.6.1.zip", SHA512: "abcd"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, pluginInstallations("7.6.0", tt.plugins))
+		})
+	}
+}
+
+func TestNewPluginsInitContainer(t *testing.T) {
+	container, volumes, err := NewPluginsInitContainer("7.6.0", esv1.Plugins{
+		Install: []esv1.Plugin{{Name: "analysis-icu", SHA512: "abcd"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, PluginsInitContainerName, container.Name)
+	require.Empty(t, volumes)
+	require.Empty(t, container.VolumeMounts)
+	script := container.Command[len(container.Command)-1]
+	require.Contains(t, script, `curl -fsSL --retry 3 -o "$archive" 'https://artifacts.elastic.co/downloads/elasticsearch-plugins/analysis-icu/analysis-icu-7.6.0.zip'`)
+	require.Contains(t, script, `echo 'abcd'"  $archive" | sha512sum -c -`)
+	require.Contains(t, script, `/usr/share/elasticsearch/bin/elasticsearch-plugin install --batch "file://$archive"`)
+
+	container, volumes, err = NewPluginsInitContainer("7.6.0", esv1.Plugins{
+		Install: []esv1.Plugin{{Name: "analysis-icu"}},
+		Bundle:  &esv1.PluginBundle{PersistentVolumeClaimName: "plugins"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []corev1.Volume{{
+		Name: PluginsBundleVolumeName,
+		VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: "plugins",
+			ReadOnly:  true,
+		}},
+	}}, volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: PluginsBundleVolumeName, MountPath: PluginsBundleVolumeMountPath, ReadOnly: true},
+	}, container.VolumeMounts)
+	script = container.Command[len(container.Command)-1]
+	require.NotContains(t, script, "curl")
+	require.NotContains(t, script, "sha512sum")
+}
+
+func Test_shellQuote(t *testing.T) {
+	require.Equal(t, `'https://example.com/a.zip'`, shellQuote("https://example.com/a.zip"))
+	require.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
@@ -21,6 +21,12 @@ import (
 
 const (
 	initContainerTransportCertificatesVolumeMountPath = "/mnt/elastic-internal/transport-certificates"
+	// auditLog4j2ConfigDir is the directory of the config dir holding the additional audit log4j2 configuration
+	auditLog4j2ConfigDir = "eck-audit"
+	// jvmOptionsDir is the directory of the config dir from which Elasticsearch reads additional JVM options files
+	jvmOptionsDir = "jvm.options.d"
+	// jvmOptionsFileName is the name of the JVM options file of the NodeSet in jvmOptionsDir
+	jvmOptionsFileName = "eck-node-set.options"
 )
 
 // Volumes that are shared between the prepare-fs init container and the ES container
@@ -69,6 +75,10 @@ var (
 				Source: stringsutil.Concat(esvolume.XPackFileRealmVolumeMountPath, "/", filerealm.UsersRolesFile),
 				Target: stringsutil.Concat(EsConfigSharedVolume.EsContainerMountPath, "/", filerealm.UsersRolesFile),
 			},
+			{
+				Source: stringsutil.Concat(esvolume.XPackFileRealmVolumeMountPath, "/", user.ServiceTokensFile),
+				Target: stringsutil.Concat(EsConfigSharedVolume.EsContainerMountPath, "/", user.ServiceTokensFile),
+			},
 			{
 				Source: stringsutil.Concat(settings.ConfigVolumeMountPath, "/", settings.ConfigFileName),
 				Target: stringsutil.Concat(EsConfigSharedVolume.EsContainerMountPath, "/", settings.ConfigFileName),
@@ -77,6 +87,17 @@ var (
 				Source: stringsutil.Concat(esvolume.UnicastHostsVolumeMountPath, "/", esvolume.UnicastHostsFile),
 				Target: stringsutil.Concat(EsConfigSharedVolume.EsContainerMountPath, "/", esvolume.UnicastHostsFile),
 			},
+			{
+				Source:   stringsutil.Concat(settings.ConfigVolumeMountPath, "/", settings.JVMOptionsFileName),
+				Target:   stringsutil.Concat(EsConfigSharedVolume.EsContainerMountPath, "/", jvmOptionsDir, "/", jvmOptionsFileName),
+				Optional: true,
+			},
+			{
+				// Elasticsearch merges all the log4j2.properties files found in its config directory
+				Source:   stringsutil.Concat(settings.ConfigVolumeMountPath, "/", settings.AuditLog4j2ConfigFileName),
+				Target:   stringsutil.Concat(EsConfigSharedVolume.EsContainerMountPath, "/", auditLog4j2ConfigDir, "/log4j2.properties"),
+				Optional: true,
+			},
 		},
 	}
 	// defaultResources are the default request and limits for the init container.
@@ -137,14 +158,23 @@ func NewPrepareFSInitContainer(
 	return container, nil
 }
 
-func RenderPrepareFsScript() (string, error) {
-	return RenderScriptTemplate(TemplateParams{
-		PluginVolumes: PluginVolumes,
-		LinkedFiles:   linkedFiles,
-		ChownToElasticsearch: []string{
+// RenderPrepareFsScript renders the script of the prepare-fs init container. The data, logs and diagnostics volumes
+// are chowned to the Elasticsearch user if chownVolumes is true, when the init container runs as root: the ownership
+// of the volumes is otherwise left to the security context of the Pods, or to the Security Context Constraints on
+// OpenShift.
+func RenderPrepareFsScript(chownVolumes bool) (string, error) {
+	var chownToElasticsearch []string
+	if chownVolumes {
+		chownToElasticsearch = []string{
 			esvolume.ElasticsearchDataMountPath,
 			esvolume.ElasticsearchLogsMountPath,
-		},
+			esvolume.DiagnosticsVolumeMountPath,
+		}
+	}
+	return RenderScriptTemplate(TemplateParams{
+		PluginVolumes:        PluginVolumes,
+		LinkedFiles:          linkedFiles,
+		ChownToElasticsearch: chownToElasticsearch,
 		InitContainerTransportCertificatesSecretVolumeMountPath: initContainerTransportCertificatesVolumeMountPath,
 		InitContainerNodeTransportCertificatesKeyPath: path.Join(
 			EsConfigSharedVolume.InitContainerMountPath,
@@ -16,7 +16,7 @@ type TemplateParams struct {
 	PluginVolumes SharedVolumeArray
 	// LinkedFiles are files to link individually
 	LinkedFiles LinkedFilesArray
-	// ChownToElasticsearch are paths that need to be chowned to the Elasticsearch user/group.
+	// ChownToElasticsearch are paths that need to be chowned to the Elasticsearch user/group, if any.
 	ChownToElasticsearch []string
 
 	// InitContainerTransportCertificatesSecretVolumeMountPath is the path to the volume in the init container that
@@ -86,8 +86,16 @@ var scriptTemplate = template.Must(template.New("").Parse(
 	# to a volume, to be used by the ES container
 	ln_start=$(date +%s)
 	{{range .LinkedFiles.Array}}
+		{{if .Optional}}
+		if [[ -e {{.Source}} ]]; then
+			echo "Linking {{.Source}} to {{.Target}}"
+			mkdir -p $(dirname {{.Target}})
+			ln -sf {{.Source}} {{.Target}}
+		fi
+		{{else}}
 		echo "Linking {{.Source}} to {{.Target}}"
 		ln -sf {{.Source}} {{.Target}}
+		{{end}}
 	{{end}}
 	echo "File linking duration: $(duration $ln_start) sec."
 
@@ -109,21 +117,26 @@ var scriptTemplate = template.Must(template.New("").Parse(
 	{{end}}
 	echo "Files copy duration: $(duration $mv_start) sec."
 
+	{{if .ChownToElasticsearch}}
 	######################
 	#  Volumes chown     #
 	######################
 
-	# chown the data and logs volume to the elasticsearch user
+	# chown the data, logs and diagnostics volumes to the elasticsearch user
 	# only done when running as root, other cases should be handled
 	# with a proper security context
 	chown_start=$(date +%s)
 	if [[ $EUID -eq 0 ]]; then
 		{{range .ChownToElasticsearch}}
-			echo "chowning {{.}} to elasticsearch:elasticsearch"
-			chown -v elasticsearch:elasticsearch {{.}}
+			# skip the volumes not mounted in this Pod, such as the diagnostics volume
+			if [[ -e {{.}} ]]; then
+				echo "chowning {{.}} to elasticsearch:elasticsearch"
+				chown -v elasticsearch:elasticsearch {{.}}
+			fi
 		{{end}}
 	fi
 	echo "chown duration: $(duration $chown_start) sec."
+	{{end}}
 
 	######################
 	#  Wait for certs    #
@@ -33,6 +33,45 @@ func TestRenderScriptTemplate(t *testing.T) {
 				"cp -av /usr/share/elasticsearch/plugins/* /mnt/elastic-internal/elasticsearch-plugins-local/",
 				"ln -sf /secrets/users /usr/share/elasticsearch/users",
 			},
+			dontWantSubstr: []string{
+				"mkdir -p $(dirname /usr/share/elasticsearch/users)",
+			},
+		},
+		{
+			name: "Optional linked file",
+			params: TemplateParams{
+				PluginVolumes: PluginVolumes,
+				LinkedFiles: LinkedFilesArray{
+					Array: []LinkedFile{
+						{
+							Source:   "/config/audit-log4j2.properties",
+							Target:   "/usr/share/elasticsearch/config/audit/log4j2.properties",
+							Optional: true}}},
+			},
+			wantSubstr: []string{
+				"if [[ -e /config/audit-log4j2.properties ]]; then",
+				"mkdir -p $(dirname /usr/share/elasticsearch/config/audit/log4j2.properties)",
+				"ln -sf /config/audit-log4j2.properties /usr/share/elasticsearch/config/audit/log4j2.properties",
+			},
+		},
+		{
+			name: "Volumes chown",
+			params: TemplateParams{
+				PluginVolumes:        PluginVolumes,
+				ChownToElasticsearch: []string{"/usr/share/elasticsearch/data"},
+			},
+			wantSubstr: []string{
+				"chown -v elasticsearch:elasticsearch /usr/share/elasticsearch/data",
+			},
+		},
+		{
+			name: "No volumes chown",
+			params: TemplateParams{
+				PluginVolumes: PluginVolumes,
+			},
+			dontWantSubstr: []string{
+				"chown",
+			},
 		},
 	}
 	for _, tt := range tests {
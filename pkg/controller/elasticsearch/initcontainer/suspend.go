@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package initcontainer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/defaults"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SuspendContainerName is the name of the init container holding the suspended Pods.
+	SuspendContainerName = "elastic-internal-suspend"
+	// SuspendScriptConfigKey is the key of the suspend script in the scripts ConfigMap.
+	SuspendScriptConfigKey = "suspend.sh"
+	// SuspendedPodsConfigKey is the key of the names of the suspended Pods in the scripts ConfigMap, one per line.
+	SuspendedPodsConfigKey = "suspended_pods.txt"
+)
+
+// SuspendScript waits as long as the name of the Pod is listed in the suspended Pods file. The file is read again at
+// each iteration: Kubernetes eventually propagates the updates of the ConfigMap to the mounted volume.
+var SuspendScript = fmt.Sprintf(`#!/usr/bin/env bash
+
+set -eu
+
+while grep -Fxq "${POD_NAME}" %s; do
+	echo "Pod suspended, waiting for ${POD_NAME} to be removed from spec.nodeDebug.suspendedPods"
+	sleep 10
+done
+`, path.Join(esvolume.ScriptsVolumeMountPath, SuspendedPodsConfigKey))
+
+// RenderSuspendedPods renders the content of the suspended Pods file.
+func RenderSuspendedPods(nodeDebug *esv1.NodeDebug) string {
+	names := nodeDebug.SuspendedPodNames()
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.Join(names, "\n") + "\n"
+}
+
+// NewSuspendInitContainer creates the init container holding the Pods listed in spec.nodeDebug.suspendedPods before
+// Elasticsearch starts. It runs last, once the filesystem is prepared, and is given the volume mounts of the
+// Elasticsearch container. This container does not need to be privileged.
+func NewSuspendInitContainer(imageName string, clusterName string) corev1.Container {
+	scriptsVolume := volume.NewConfigMapVolumeWithMode(
+		esv1.ScriptsConfigMap(clusterName),
+		esvolume.ScriptsVolumeName,
+		esvolume.ScriptsVolumeMountPath,
+		0755)
+
+	privileged := false
+	return corev1.Container{
+		Image:           imageName,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Name:            SuspendContainerName,
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: &privileged,
+		},
+		Env:          defaults.PodDownwardEnvVars(),
+		Command:      []string{"bash", "-c", path.Join(esvolume.ScriptsVolumeMountPath, SuspendScriptConfigKey)},
+		VolumeMounts: []corev1.VolumeMount{scriptsVolume.VolumeMount()},
+		Resources:    defaultResources,
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/driver"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// watchLeavingHosts triggers the reconciliation of the Elasticsearch clusters with Pods on the Kubernetes nodes starting
+// or ceasing to leave, to migrate the data of their nodes away before they are drained or preempted, or to allocate it
+// to them again.
+func watchLeavingHosts(c controller.Controller, k8sClient k8s.Client, hosts driver.LeavingHosts) error {
+	return c.Watch(
+		&source.Kind{Type: &corev1.Node{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+				return clustersOnNode(k8sClient, object.Meta.GetName())
+			}),
+		},
+		predicate.Funcs{
+			CreateFunc:  func(event.CreateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldNode, isNode := e.ObjectOld.(*corev1.Node)
+				if !isNode {
+					return false
+				}
+				newNode, isNode := e.ObjectNew.(*corev1.Node)
+				return isNode && hosts.Leaving(*oldNode) != hosts.Leaving(*newNode)
+			},
+		},
+	)
+}
+
+// clustersOnNode returns the requests to reconcile the Elasticsearch clusters with Pods on the given Kubernetes node.
+func clustersOnNode(k8sClient k8s.Client, nodeName string) []reconcile.Request {
+	var pods corev1.PodList
+	if err := k8sClient.List(&pods, client.HasLabels{label.ClusterNameLabelName}); err != nil {
+		log.Error(err, "Failed to list the Elasticsearch Pods of a leaving node", "node_name", nodeName)
+		return nil
+	}
+	seen := map[types.NamespacedName]bool{}
+	var requests []reconcile.Request
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName {
+			continue
+		}
+		cluster := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[label.ClusterNameLabelName]}
+		if !seen[cluster] {
+			seen[cluster] = true
+			requests = append(requests, reconcile.Request{NamespacedName: cluster})
+		}
+	}
+	return requests
+}
@@ -40,6 +40,36 @@ func IsMigratingData(ctx context.Context, shardLister esclient.ShardLister, podN
 	return false, nil
 }
 
+// RemainingData is the data still allocated to a node being removed from the cluster.
+type RemainingData struct {
+	// Shards is the number of shards allocated to the node, including the shards relocating away from it.
+	Shards int
+	// Bytes is the size of these shards.
+	Bytes int64
+}
+
+// RemainingDataByNode returns the data still allocated to each of the given nodes.
+func RemainingDataByNode(ctx context.Context, shardLister esclient.ShardLister, nodes []string) (map[string]RemainingData, error) {
+	shards, err := shardLister.GetShards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make(map[string]RemainingData, len(nodes))
+	for _, node := range nodes {
+		remaining[node] = RemainingData{}
+	}
+	for _, shard := range shards {
+		data, leaving := remaining[shard.NodeName]
+		if !leaving {
+			continue
+		}
+		data.Shards++
+		data.Bytes += shard.StoreBytes()
+		remaining[shard.NodeName] = data
+	}
+	return remaining, nil
+}
+
 // allocationExcludeFromAnnotation returns the allocation exclude value stored in an annotation.
 // May be empty if not set.
 func allocationExcludeFromAnnotation(es esv1.Elasticsearch) string {
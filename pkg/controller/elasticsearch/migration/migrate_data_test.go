@@ -113,6 +113,24 @@ func TestIsMigratingData(t *testing.T) {
 	}
 }
 
+func TestRemainingDataByNode(t *testing.T) {
+	shardLister := NewFakeShardLister(client.Shards{
+		{Index: "logs", Shard: "0", State: client.STARTED, NodeName: "leaving-1", Store: "1024"},
+		{Index: "logs", Shard: "1", State: client.RELOCATING, NodeName: "leaving-1", Store: "2048"},
+		{Index: "logs", Shard: "0", State: client.STARTED, NodeName: "staying", Store: "1024"},
+		{Index: "metrics", Shard: "0", State: client.UNASSIGNED},
+	})
+	remaining, err := RemainingDataByNode(context.Background(), shardLister, []string{"leaving-1", "leaving-2"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]RemainingData{
+		"leaving-1": {Shards: 2, Bytes: 3072},
+		"leaving-2": {},
+	}, remaining)
+
+	_, err = RemainingDataByNode(context.Background(), NewFakeShardListerWithError(nil, fmt.Errorf("boom")), []string{"leaving-1"})
+	require.Error(t, err)
+}
+
 func TestMigrateData(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -9,4 +9,6 @@ const (
 	HTTPPort = 9200
 	// TransportPort used by Elasticsearch for the Transport protocol in node to node communication
 	TransportPort = 9300
+	// RemoteClusterPort used by the remote cluster server, to which remote clusters authenticated with API keys connect
+	RemoteClusterPort = 9443
 )
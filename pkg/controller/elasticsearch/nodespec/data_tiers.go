@@ -0,0 +1,21 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+// dataTierConfig returns the user configuration completed with the allocation attribute and the roles of the data
+// tier of the NodeSet, unless explicitly set by the user.
+func dataTierConfig(userConfig *commonv1.Config, nodeSet esv1.NodeSet, ver version.Version) (*commonv1.Config, error) {
+	settings, err := nodeSet.TierSettings(ver)
+	if err != nil || len(settings) == 0 {
+		return userConfig, err
+	}
+	return withDefaultSettings(userConfig, settings)
+}
@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+)
+
+func Test_dataTierConfig(t *testing.T) {
+	userConfig := &commonv1.Config{Data: map[string]interface{}{"node.store.allow_mmap": false}}
+	nodeSet := esv1.NodeSet{Name: "warm", Tier: esv1.WarmTier, Config: userConfig}
+
+	got, err := dataTierConfig(userConfig, nodeSet, version.MustParse("7.10.0"))
+	require.NoError(t, err)
+	require.Equal(t, &commonv1.Config{Data: map[string]interface{}{
+		"node.store.allow_mmap": false,
+		"node.attr.data":        "warm",
+		"node.roles":            []string{"data_warm"},
+	}}, got)
+
+	// the roles of the tier are reflected in the roles labels of the nodes
+	cfg, err := common.NewCanonicalConfigFrom(got.Data)
+	require.NoError(t, err)
+	unpacked, err := settings.CanonicalConfig{CanonicalConfig: cfg}.Unpack()
+	require.NoError(t, err)
+	require.Equal(t, esv1.Node{Data: true}, unpacked.Node)
+
+	// no tier
+	got, err = dataTierConfig(userConfig, esv1.NodeSet{Name: "default", Config: userConfig}, version.MustParse("7.10.0"))
+	require.NoError(t, err)
+	require.Equal(t, userConfig, got)
+}
@@ -13,6 +13,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/defaults"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
@@ -93,3 +95,27 @@ func DefaultAffinity(esName string) *corev1.Affinity {
 		},
 	}
 }
+
+// DefaultTopologySpreadConstraints returns the default topology spread constraints for the Pods with the given labels,
+// if the cluster specifies a topology spread: master and data nodes are spread separately.
+func DefaultTopologySpreadConstraints(es esv1.Elasticsearch, podLabels map[string]string) []corev1.TopologySpreadConstraint {
+	if es.Spec.TopologySpread == nil {
+		return nil
+	}
+	spread := *es.Spec.TopologySpread
+	var constraints []corev1.TopologySpreadConstraint
+	for _, nodeType := range []common.TrueFalseLabel{label.NodeTypesMasterLabelName, label.NodeTypesDataLabelName} {
+		if !nodeType.HasValue(true, podLabels) {
+			continue
+		}
+		matchLabels := map[string]string{label.ClusterNameLabelName: es.Name}
+		nodeType.Set(true, matchLabels)
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           spread.MaxSkewOrDefault(),
+			TopologyKey:       spread.TopologyKeyOrDefault(),
+			WhenUnsatisfiable: spread.WhenUnsatisfiableOrDefault(),
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: matchLabels},
+		})
+	}
+	return constraints
+}
@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"path"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+)
+
+// DiagnosticsJVMOptions returns the JVM options writing the heap dumps and the fatal error logs of the nodes to the
+// diagnostics volume. They are listed before the JVM options of the user, which take precedence.
+func DiagnosticsJVMOptions(diagnostics *esv1.Diagnostics) []string {
+	if diagnostics == nil {
+		return nil
+	}
+	return []string{
+		"-XX:+HeapDumpOnOutOfMemoryError",
+		"-XX:HeapDumpPath=" + esvolume.DiagnosticsVolumeMountPath,
+		"-XX:ErrorFile=" + path.Join(esvolume.DiagnosticsVolumeMountPath, "hs_err_pid%p.log"),
+	}
+}
+
+// diagnosticsVolume returns the volume the dumps are written to, if any, mounted in a directory per Pod for the dumps
+// of the Pods of the NodeSet not to overwrite each other. The directory is chowned by the prepare-fs init container.
+func diagnosticsVolume(diagnostics *esv1.Diagnostics) (corev1.Volume, corev1.VolumeMount, bool) {
+	if diagnostics == nil {
+		return corev1.Volume{}, corev1.VolumeMount{}, false
+	}
+	vol := corev1.Volume{
+		Name: esvolume.DiagnosticsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: diagnostics.PersistentVolumeClaimName,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:        esvolume.DiagnosticsVolumeName,
+		MountPath:   esvolume.DiagnosticsVolumeMountPath,
+		SubPathExpr: "$(" + settings.EnvPodName + ")",
+	}
+	return vol, mount, true
+}
+
+// diagnosticsEnvVars returns the environment variables enabling the capture of thread dumps by the readiness probe.
+func diagnosticsEnvVars(diagnostics *esv1.Diagnostics) []corev1.EnvVar {
+	if diagnostics == nil || diagnostics.ThreadDumpAfterFailures == 0 {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: settings.EnvDiagnosticsPath, Value: esvolume.DiagnosticsVolumeMountPath},
+		{Name: settings.EnvThreadDumpAfterFailures, Value: strconv.Itoa(int(diagnostics.ThreadDumpAfterFailures))},
+	}
+}
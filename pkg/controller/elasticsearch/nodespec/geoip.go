@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+)
+
+// geoIPConfig returns the user configuration completed with the GeoIP downloader settings: the endpoint of the
+// cluster, or else the default endpoint of the operator, and the downloader disabled if the databases are supplied
+// through a PersistentVolumeClaim with no endpoint to download their updates from. Settings explicitly set by the
+// user are left untouched.
+func geoIPConfig(
+	userConfig *commonv1.Config,
+	ver version.Version,
+	geoIP *esv1.GeoIP,
+	defaultEndpoint string,
+) (*commonv1.Config, error) {
+	if !ver.IsSameOrAfter(esv1.GeoIPDownloaderMinVersion) {
+		// the settings are unknown to older versions, which would fail to start
+		return userConfig, nil
+	}
+	endpoint := defaultEndpoint
+	if geoIP != nil && geoIP.Endpoint != "" {
+		endpoint = geoIP.Endpoint
+	}
+	defaults := map[string]interface{}{}
+	switch {
+	case endpoint != "":
+		defaults[esv1.IngestGeoIPDownloaderEndpoint] = endpoint
+	case geoIP != nil && geoIP.PersistentVolumeClaimName != "":
+		defaults[esv1.IngestGeoIPDownloaderEnabled] = false
+	default:
+		return userConfig, nil
+	}
+	return withDefaultSettings(userConfig, defaults)
+}
+
+// geoIPDatabasesVolume returns the volume holding the GeoIP databases supplied through a PersistentVolumeClaim, if any.
+func geoIPDatabasesVolume(geoIP *esv1.GeoIP) (corev1.Volume, corev1.VolumeMount, bool) {
+	if geoIP == nil || geoIP.PersistentVolumeClaimName == "" {
+		return corev1.Volume{}, corev1.VolumeMount{}, false
+	}
+	vol := corev1.Volume{
+		Name: esvolume.GeoIPDatabasesVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: geoIP.PersistentVolumeClaimName,
+				ReadOnly:  true,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      esvolume.GeoIPDatabasesVolumeName,
+		MountPath: path.Join(esvolume.ConfigVolumeMountPath, esv1.GeoIPDatabasesDir),
+		ReadOnly:  true,
+	}
+	return vol, mount, true
+}
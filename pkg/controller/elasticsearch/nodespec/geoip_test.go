@@ -0,0 +1,101 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+func Test_geoIPConfig(t *testing.T) {
+	userConfig := &commonv1.Config{Data: map[string]interface{}{"node.data": false}}
+	tests := []struct {
+		name            string
+		userConfig      *commonv1.Config
+		version         string
+		geoIP           *esv1.GeoIP
+		defaultEndpoint string
+		want            *commonv1.Config
+	}{
+		{
+			name:       "no GeoIP configuration",
+			userConfig: userConfig,
+			version:    "7.14.0",
+			want:       userConfig,
+		},
+		{
+			name:            "default endpoint of the operator",
+			version:         "7.14.0",
+			defaultEndpoint: "http://geoip-mirror.elastic-system.svc:8080",
+			want: &commonv1.Config{Data: map[string]interface{}{
+				"ingest.geoip.downloader.endpoint": "http://geoip-mirror.elastic-system.svc:8080",
+			}},
+		},
+		{
+			name:            "default endpoint ignored before 7.14.0",
+			userConfig:      userConfig,
+			version:         "7.13.4",
+			defaultEndpoint: "http://geoip-mirror.elastic-system.svc:8080",
+			want:            userConfig,
+		},
+		{
+			name:            "endpoint of the cluster takes precedence",
+			userConfig:      userConfig,
+			version:         "7.14.0",
+			geoIP:           &esv1.GeoIP{Endpoint: "https://geoip.example.com", PersistentVolumeClaimName: "geoip"},
+			defaultEndpoint: "http://geoip-mirror.elastic-system.svc:8080",
+			want: &commonv1.Config{Data: map[string]interface{}{
+				"node.data":                        false,
+				"ingest.geoip.downloader.endpoint": "https://geoip.example.com",
+			}},
+		},
+		{
+			name:    "databases supplied with no endpoint: downloader disabled",
+			version: "7.14.0",
+			geoIP:   &esv1.GeoIP{PersistentVolumeClaimName: "geoip"},
+			want: &commonv1.Config{Data: map[string]interface{}{
+				"ingest.geoip.downloader.enabled": false,
+			}},
+		},
+		{
+			name: "user settings take precedence",
+			userConfig: &commonv1.Config{Data: map[string]interface{}{
+				"ingest": map[string]interface{}{"geoip.downloader.enabled": true},
+			}},
+			version: "7.14.0",
+			geoIP:   &esv1.GeoIP{PersistentVolumeClaimName: "geoip"},
+			want: &commonv1.Config{Data: map[string]interface{}{
+				"ingest": map[string]interface{}{"geoip.downloader.enabled": true},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := geoIPConfig(tt.userConfig, version.MustParse(tt.version), tt.geoIP, tt.defaultEndpoint)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_geoIPDatabasesVolume(t *testing.T) {
+	_, _, exists := geoIPDatabasesVolume(&esv1.GeoIP{Endpoint: "https://geoip.example.com"})
+	require.False(t, exists)
+
+	vol, mount, exists := geoIPDatabasesVolume(&esv1.GeoIP{PersistentVolumeClaimName: "geoip"})
+	require.True(t, exists)
+	require.Equal(t, &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "geoip", ReadOnly: true}, vol.PersistentVolumeClaim)
+	require.Equal(t, corev1.VolumeMount{
+		Name:      "elastic-internal-geoip-databases",
+		MountPath: "/usr/share/elasticsearch/config/ingest-geoip",
+		ReadOnly:  true,
+	}, mount)
+}
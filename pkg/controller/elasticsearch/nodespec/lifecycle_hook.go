@@ -6,7 +6,9 @@ package nodespec
 
 import (
 	"path"
+	"strconv"
 
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
 	v1 "k8s.io/api/core/v1"
 )
@@ -18,6 +20,51 @@ func NewPreStopHook() *v1.Handler {
 	}
 }
 
+// NewPostStartHook returns the post-start hook running the user-provided post-start script.
+func NewPostStartHook() *v1.Handler {
+	return &v1.Handler{
+		Exec: &v1.ExecAction{
+			Command: []string{"bash", "-c", path.Join(volume.ScriptsVolumeMountPath, PostStartHookUserScriptConfigKey)}},
+	}
+}
+
+// LifecycleHooksEnvVars returns the environment variables configuring the pre-stop hook script.
+func LifecycleHooksEnvVars(hooks *esv1.LifecycleHooks) []v1.EnvVar {
+	preStop := hooks.PreStopHook()
+	if preStop == nil {
+		return nil
+	}
+	var vars []v1.EnvVar
+	if preStop.MaxWaitSeconds != nil {
+		vars = append(vars, v1.EnvVar{Name: "PRE_STOP_MAX_WAIT_SECONDS", Value: strconv.Itoa(int(*preStop.MaxWaitSeconds))})
+	}
+	if preStop.AdditionalWaitSeconds != nil {
+		vars = append(vars, v1.EnvVar{Name: "PRE_STOP_ADDITIONAL_WAIT_SECONDS", Value: strconv.Itoa(int(*preStop.AdditionalWaitSeconds))})
+	}
+	if preStop.SkipCondition != "" {
+		vars = append(vars, v1.EnvVar{Name: "PRE_STOP_SKIP_CONDITION", Value: preStop.SkipCondition})
+	}
+	return vars
+}
+
+// LifecycleHooksUserScripts returns the user-provided lifecycle hook scripts, indexed by their key in the scripts
+// ConfigMap.
+func LifecycleHooksUserScripts(hooks *esv1.LifecycleHooks) map[string]string {
+	scripts := map[string]string{}
+	if preStop := hooks.PreStopHook(); preStop != nil && preStop.Script != "" {
+		scripts[PreStopHookUserScriptConfigKey] = preStop.Script
+	}
+	if postStart := hooks.PostStartHook(); postStart != nil {
+		scripts[PostStartHookUserScriptConfigKey] = postStart.Script
+	}
+	return scripts
+}
+
+const (
+	PreStopHookUserScriptConfigKey   = "pre-stop-hook-user-script.sh"
+	PostStartHookUserScriptConfigKey = "post-start-hook-user-script.sh"
+)
+
 const PreStopHookScriptConfigKey = "pre-stop-hook-script.sh"
 const PreStopHookScript = `#!/usr/bin/env bash
 
@@ -41,6 +88,17 @@ PRE_STOP_MAX_WAIT_SECONDS=${PRE_STOP_MAX_WAIT_SECONDS:=20}
 # target the Pod IP before Elasticsearch stops.
 PRE_STOP_ADDITIONAL_WAIT_SECONDS=${PRE_STOP_ADDITIONAL_WAIT_SECONDS:=30}
 
+# Skip the wait if the user-provided condition is met.
+if [[ -n "${PRE_STOP_SKIP_CONDITION:-}" ]] && bash -c "$PRE_STOP_SKIP_CONDITION"; then
+   exit 0
+fi
+
+# Run the user-provided script, if any. Its failure does not prevent the wait.
+PRE_STOP_USER_SCRIPT=` + volume.ScriptsVolumeMountPath + "/" + PreStopHookUserScriptConfigKey + `
+if [[ -f $PRE_STOP_USER_SCRIPT ]] && ! bash $PRE_STOP_USER_SCRIPT; then
+   echo "pre-stop user script failed"
+fi
+
 START_TIME=$(date +%s)
 while true; do
    ELAPSED_TIME=$(($(date +%s) - $START_TIME))
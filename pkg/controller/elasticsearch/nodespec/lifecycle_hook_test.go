@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	maxWait := int32(60)
+	additionalWait := int32(0)
+	tests := []struct {
+		name        string
+		hooks       *esv1.LifecycleHooks
+		wantEnv     []corev1.EnvVar
+		wantScripts map[string]string
+	}{
+		{
+			name:        "no lifecycle hooks",
+			hooks:       nil,
+			wantEnv:     nil,
+			wantScripts: map[string]string{},
+		},
+		{
+			name:        "empty pre-stop hook",
+			hooks:       &esv1.LifecycleHooks{PreStop: &esv1.PreStopHook{}},
+			wantEnv:     nil,
+			wantScripts: map[string]string{},
+		},
+		{
+			name: "customized pre-stop hook and post-start hook",
+			hooks: &esv1.LifecycleHooks{
+				PreStop: &esv1.PreStopHook{
+					MaxWaitSeconds:        &maxWait,
+					AdditionalWaitSeconds: &additionalWait,
+					SkipCondition:         "test -f /tmp/skip",
+					Script:                "echo stopping",
+				},
+				PostStart: &esv1.PostStartHook{Script: "echo started"},
+			},
+			wantEnv: []corev1.EnvVar{
+				{Name: "PRE_STOP_MAX_WAIT_SECONDS", Value: "60"},
+				{Name: "PRE_STOP_ADDITIONAL_WAIT_SECONDS", Value: "0"},
+				{Name: "PRE_STOP_SKIP_CONDITION", Value: "test -f /tmp/skip"},
+			},
+			wantScripts: map[string]string{
+				PreStopHookUserScriptConfigKey:   "echo stopping",
+				PostStartHookUserScriptConfigKey: "echo started",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantEnv, LifecycleHooksEnvVars(tt.hooks))
+			require.Equal(t, tt.wantScripts, LifecycleHooksUserScripts(tt.hooks))
+		})
+	}
+}
+
+func TestBuildPodTemplateSpec_PostStartHook(t *testing.T) {
+	es := *sampleES.DeepCopy()
+	nodeSet := es.Spec.NodeSets[0]
+	ver, err := version.Parse(es.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.HTTP, es.Spec.Auth, es.Spec.Audit, es.Spec.RemoteClusterServer, es.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	postStartHook := func() *corev1.Handler {
+		podTemplate, err := BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+		require.NoError(t, err)
+		for _, c := range podTemplate.Spec.Containers {
+			if c.Name == esv1.ElasticsearchContainerName {
+				return c.Lifecycle.PostStart
+			}
+		}
+		return nil
+	}
+	require.Nil(t, postStartHook())
+
+	es.Spec.LifecycleHooks = &esv1.LifecycleHooks{PostStart: &esv1.PostStartHook{Script: "echo started"}}
+	require.Equal(t, NewPostStartHook(), postStartHook())
+}
@@ -0,0 +1,35 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/placement"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
+)
+
+// OperatorSettings are the operator-level settings applied to the Pod templates of all the NodeSets. They are applied
+// before the template hash of the StatefulSets is computed, for a change of these settings to rotate the Pods.
+type OperatorSettings struct {
+	// OpenShift completes the security context of the Pods for the restricted SCC of OpenShift.
+	OpenShift bool
+	// PlacementPolicies constrain the Kubernetes nodes the Pods are scheduled on.
+	PlacementPolicies []placement.Policy
+	// PodSecurityStandard is the Pod Security Standard the Pods must comply with.
+	PodSecurityStandard string
+}
+
+// applyOperatorSettings applies the given operator settings to the Pod template of a NodeSet of the given cluster.
+// It returns an error if the Pod template violates the enforced Pod Security Standard.
+func applyOperatorSettings(settings OperatorSettings, es esv1.Elasticsearch, podTemplate *corev1.PodTemplateSpec) error {
+	if settings.OpenShift {
+		openshift.SetRestrictedSecurityContext(podTemplate)
+	}
+	placement.Apply(settings.PlacementPolicies, es.Namespace, podTemplate)
+	return podsecurity.Enforce(settings.PodSecurityStandard, &es, podTemplate, esv1.ElasticsearchContainerName)
+}
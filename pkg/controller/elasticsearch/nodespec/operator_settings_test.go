@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/placement"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
+	commonscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates"
+)
+
+func TestBuildExpectedResources_OperatorSettings(t *testing.T) {
+	require.NoError(t, commonscheme.SetupScheme())
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version:  "7.6.0",
+			NodeSets: []esv1.NodeSet{{Name: "default", Count: 1}},
+		},
+	}
+	build := func(settings OperatorSettings) ResourcesList {
+		resources, err := BuildExpectedResources(es, nil, &certificates.CertificateResources{}, nil, "", settings)
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		return resources
+	}
+
+	defaultResources := build(OperatorSettings{PodSecurityStandard: podsecurity.StandardNone})
+	openShiftResources := build(OperatorSettings{OpenShift: true, PodSecurityStandard: podsecurity.StandardNone})
+	require.Nil(t, defaultResources[0].StatefulSet.Spec.Template.Spec.Containers[0].SecurityContext)
+	require.Equal(t,
+		&corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		openShiftResources[0].StatefulSet.Spec.Template.Spec.Containers[0].SecurityContext.Capabilities,
+	)
+	// the template hash accounts for the operator settings, for the Pods to be rotated when they change
+	require.Equal(t,
+		hash.HashObject(openShiftResources[0].StatefulSet.Spec),
+		hash.GetTemplateHashLabel(openShiftResources[0].StatefulSet.Labels),
+	)
+	require.NotEqual(t,
+		hash.GetTemplateHashLabel(defaultResources[0].StatefulSet.Labels),
+		hash.GetTemplateHashLabel(openShiftResources[0].StatefulSet.Labels),
+	)
+}
+
+func TestBuildExpectedResources_PlacementPolicies(t *testing.T) {
+	require.NoError(t, commonscheme.SetupScheme())
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version:  "7.6.0",
+			NodeSets: []esv1.NodeSet{{Name: "default", Count: 1}},
+		},
+	}
+	settings := OperatorSettings{
+		PodSecurityStandard: podsecurity.StandardNone,
+		PlacementPolicies: []placement.Policy{{
+			Name:         "dedicated",
+			NodeSelector: map[string]string{"dedicated": "elasticsearch"},
+		}},
+	}
+
+	withoutPolicies, err := BuildExpectedResources(es, nil, &certificates.CertificateResources{}, nil, "", OperatorSettings{PodSecurityStandard: podsecurity.StandardNone})
+	require.NoError(t, err)
+	withPolicies, err := BuildExpectedResources(es, nil, &certificates.CertificateResources{}, nil, "", settings)
+	require.NoError(t, err)
+	sset := withPolicies[0].StatefulSet
+	require.Equal(t, map[string]string{"dedicated": "elasticsearch"}, sset.Spec.Template.Spec.NodeSelector)
+	// the template hash accounts for the policies, for the Pods to be rotated when they change
+	require.Equal(t, hash.HashObject(sset.Spec), hash.GetTemplateHashLabel(sset.Labels))
+	require.NotEqual(t,
+		hash.GetTemplateHashLabel(withoutPolicies[0].StatefulSet.Labels),
+		hash.GetTemplateHashLabel(sset.Labels),
+	)
+}
+
+func TestBuildExpectedResources_PodSecurityStandard(t *testing.T) {
+	require.NoError(t, commonscheme.SetupScheme())
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version:  "7.6.0",
+			NodeSets: []esv1.NodeSet{{Name: "default", Count: 1}},
+		},
+	}
+	restricted := OperatorSettings{PodSecurityStandard: podsecurity.StandardRestricted}
+
+	resources, err := BuildExpectedResources(es, nil, &certificates.CertificateResources{}, nil, "", restricted)
+	require.NoError(t, err)
+	sset := resources[0].StatefulSet
+	require.True(t, *sset.Spec.Template.Spec.SecurityContext.RunAsNonRoot)
+	// the template hash accounts for the enforced standard, for the Pods to be rotated when it is enabled
+	require.Equal(t, hash.HashObject(sset.Spec), hash.GetTemplateHashLabel(sset.Labels))
+
+	// Pod templates violating the standard are rejected
+	es.Spec.NodeSets[0].PodTemplate.Spec.HostNetwork = true
+	_, err = BuildExpectedResources(es, nil, &certificates.CertificateResources{}, nil, "", restricted)
+	require.Error(t, err)
+}
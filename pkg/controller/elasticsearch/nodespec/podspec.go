@@ -17,6 +17,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/audit"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/network"
@@ -25,6 +26,9 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 )
 
+// JVMOptionsHashAnnotationName holds the hash of the JVM options of the NodeSet, to restart its Pods when they change.
+const JVMOptionsHashAnnotationName = "elasticsearch.k8s.elastic.co/jvm-options-hash"
+
 // BuildPodTemplateSpec builds a new PodTemplateSpec for an Elasticsearch node.
 func BuildPodTemplateSpec(
 	es esv1.Elasticsearch,
@@ -32,24 +36,41 @@ func BuildPodTemplateSpec(
 	cfg settings.CanonicalConfig,
 	keystoreResources *keystore.Resources,
 ) (corev1.PodTemplateSpec, error) {
-	volumes, volumeMounts := buildVolumes(es.Name, nodeSet, keystoreResources)
+	volumes, volumeMounts := buildVolumes(es, nodeSet, keystoreResources)
 	labels, err := buildLabels(es, cfg, nodeSet, keystoreResources)
 	if err != nil {
 		return corev1.PodTemplateSpec{}, err
 	}
 
+	arch := container.Architecture(nodeSet.Architecture)
 	builder := defaults.NewPodTemplateBuilder(nodeSet.PodTemplate, esv1.ElasticsearchContainerName).
-		WithDockerImage(es.Spec.Image, container.ImageRepository(container.ElasticsearchImage, es.Spec.Version))
+		WithDockerImage(
+			nodeSet.ImageOrDefault(es.Spec.Image),
+			container.ImageRepositoryForNamespace(container.ElasticsearchImage, es.Spec.Version, es.Namespace, arch),
+		)
 
 	initContainers, err := initcontainer.NewInitContainers(
 		builder.Container.Image,
 		transportCertificatesVolume(es.Name),
 		es.Name,
 		keystoreResources,
+		es.Spec.NodeDebug,
 	)
 	if err != nil {
 		return corev1.PodTemplateSpec{}, err
 	}
+	if es.Spec.Plugins != nil {
+		pluginsContainer, pluginsVolumes, err := initcontainer.NewPluginsInitContainer(es.Spec.Version, *es.Spec.Plugins)
+		if err != nil {
+			return corev1.PodTemplateSpec{}, err
+		}
+		// install the plugins once the filesystem is prepared
+		initContainers = append(
+			[]corev1.Container{initContainers[0], pluginsContainer},
+			initContainers[1:]...,
+		)
+		volumes = append(volumes, pluginsVolumes...)
+	}
 	defaultContainerPorts := getDefaultContainerPorts(es)
 
 	builder = builder.
@@ -58,23 +79,94 @@ func BuildPodTemplateSpec(
 		WithPorts(defaultContainerPorts).
 		WithReadinessProbe(*NewReadinessProbe()).
 		WithAffinity(DefaultAffinity(es.Name)).
-		WithEnv(DefaultEnvVars(es.Spec.HTTP, HeadlessServiceName(esv1.StatefulSet(es.Name, nodeSet.Name)))...).
+		WithTopologySpreadConstraints(DefaultTopologySpreadConstraints(es, labels)...).
+		WithEnv(DefaultEnvVars(es.Spec.EffectiveHTTP(), HeadlessServiceName(esv1.StatefulSet(es.Name, nodeSet.Name)))...).
+		WithEnv(LifecycleHooksEnvVars(es.Spec.LifecycleHooks)...).
+		WithEnv(diagnosticsEnvVars(nodeSet.Diagnostics)...).
+		WithEnv(secretRefsEnvVars(nodeSet.Config)...).
 		WithVolumes(volumes...).
 		WithVolumeMounts(volumeMounts...).
 		WithLabels(labels).
 		WithAnnotations(DefaultAnnotations).
+		WithAnnotations(serviceMeshAnnotations(es)).
 		WithInitContainers(initContainers...).
 		WithPreStopHook(*NewPreStopHook()).
+		WithRuntimeClassName(nodeSet.RuntimeClassName).
 		WithInitContainerDefaults()
 
+	if arch != "" {
+		// restrict the Pods to the Linux nodes of the architecture, in clusters mixing architectures or operating systems
+		builder = builder.WithNodeSelector(map[string]string{
+			corev1.LabelOSStable:   "linux",
+			corev1.LabelArchStable: string(arch),
+		})
+	}
+
+	priorityClass := es.Spec.PriorityClasses.ForRoles(
+		label.NodeTypesMasterLabelName.HasValue(true, labels),
+		label.NodeTypesDataLabelName.HasValue(true, labels),
+	)
+	if priorityClass != nil {
+		builder = builder.WithPriorityClassName(priorityClass.Name)
+	}
+
+	if len(nodeSet.JVMOptions) > 0 {
+		builder = builder.WithAnnotations(map[string]string{JVMOptionsHashAnnotationName: hash.HashObject(nodeSet.JVMOptions)})
+	}
+
+	if nodeSet.ResourceDetection != nil {
+		ver, err := version.Parse(es.Spec.Version)
+		if err != nil {
+			return corev1.PodTemplateSpec{}, err
+		}
+		builder = builder.WithAnnotations(map[string]string{
+			ResourceDetectionHashAnnotationName: hash.HashObject(ResourceDetectionJVMOptions(nodeSet, *ver)),
+		})
+	}
+
+	if es.Spec.LifecycleHooks.PostStartHook() != nil {
+		builder = builder.WithPostStartHook(*NewPostStartHook())
+	}
+
+	if audit.ShippingEnabled(es) {
+		sidecar, sidecarVolumes, configHash, err := audit.BeatSidecar(es, arch)
+		if err != nil {
+			return corev1.PodTemplateSpec{}, err
+		}
+		builder = builder.
+			WithVolumes(sidecarVolumes...).
+			WithSidecars(sidecar).
+			WithAnnotations(map[string]string{audit.BeatConfigHashAnnotationName: configHash})
+	}
+
+	if keystoreResources != nil && keystoreResources.Sidecar != nil {
+		// the keystore is in the config directory of the Elasticsearch container
+		sidecar := *keystoreResources.Sidecar
+		sidecar.Image = builder.Container.Image
+		sidecar.VolumeMounts = append(sidecar.VolumeMounts, initcontainer.EsConfigSharedVolume.EsContainerVolumeMount())
+		builder = builder.WithSidecars(sidecar)
+	}
+
+	checkVM, err := checkMaxMapCount(es, cfg)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	if checkVM {
+		return withCheckVMInitContainer(builder.PodTemplate, builder.Container.Image), nil
+	}
+
 	return builder.PodTemplate, nil
 }
 
 func getDefaultContainerPorts(es esv1.Elasticsearch) []corev1.ContainerPort {
-	return []corev1.ContainerPort{
-		{Name: es.Spec.HTTP.Protocol(), ContainerPort: network.HTTPPort, Protocol: corev1.ProtocolTCP},
+	ports := []corev1.ContainerPort{
+		{Name: es.Spec.EffectiveHTTP().Protocol(), ContainerPort: network.HTTPPort, Protocol: corev1.ProtocolTCP},
 		{Name: "transport", ContainerPort: network.TransportPort, Protocol: corev1.ProtocolTCP},
 	}
+	if es.Spec.RemoteClusterServer.Enabled {
+		ports = append(ports, corev1.ContainerPort{Name: "remote-cluster", ContainerPort: network.RemoteClusterPort, Protocol: corev1.ProtocolTCP})
+	}
+	return ports
 }
 
 func transportCertificatesVolume(esName string) volume.SecretVolume {
@@ -108,7 +200,7 @@ func buildLabels(
 	podLabels, err := label.NewPodLabels(
 		k8s.ExtractNamespacedName(&es),
 		esv1.StatefulSet(es.Name, nodeSet.Name),
-		*ver, nodeRoles, cfgHash, es.Spec.HTTP.Protocol(),
+		*ver, nodeRoles, cfgHash, es.Spec.EffectiveHTTP().Protocol(),
 	)
 	if err != nil {
 		return nil, err
@@ -118,7 +210,7 @@ func buildLabels(
 		// label with a checksum of the secure settings to rotate the pod on secure settings change
 		// TODO: use hash.HashObject instead && fix the config checksum label name?
 		configChecksum := sha256.New224()
-		_, _ = configChecksum.Write([]byte(keystoreResources.Version))
+		_, _ = configChecksum.Write([]byte(keystoreResources.PodTemplateVersion()))
 		podLabels[label.SecureSettingsHashLabelName] = fmt.Sprintf("%x", configChecksum.Sum(nil))
 	}
 
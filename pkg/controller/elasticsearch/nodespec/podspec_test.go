@@ -7,14 +7,19 @@ package nodespec
 import (
 	"sort"
 	"testing"
+	"time"
 
 	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/defaults"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/audit"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+	esvolume "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
 	"github.com/go-test/deep"
 
 	"github.com/stretchr/testify/assert"
@@ -93,7 +98,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 	nodeSet := sampleES.Spec.NodeSets[0]
 	ver, err := version.Parse(sampleES.Spec.Version)
 	require.NoError(t, err)
-	cfg, err := settings.NewMergedESConfig(sampleES.Name, *ver, sampleES.Spec.HTTP, *nodeSet.Config, &certResources)
+	cfg, err := settings.NewMergedESConfig(sampleES.Name, *ver, sampleES.Spec.HTTP, sampleES.Spec.Auth, sampleES.Spec.Audit, sampleES.Spec.RemoteClusterServer, sampleES.Spec.RemoteClusters, *nodeSet.Config, &certResources)
 	require.NoError(t, err)
 
 	actual, err := BuildPodTemplateSpec(sampleES, sampleES.Spec.NodeSets[0], cfg, nil)
@@ -104,7 +109,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 	terminationGracePeriodSeconds := DefaultTerminationGracePeriodSeconds
 	varFalse := false
 
-	volumes, volumeMounts := buildVolumes(sampleES.Name, nodeSet, nil)
+	volumes, volumeMounts := buildVolumes(sampleES, nodeSet, nil)
 	// should be sorted
 	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
 	sort.Slice(volumeMounts, func(i, j int) bool { return volumeMounts[i].Name < volumeMounts[j].Name })
@@ -114,6 +119,7 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 		transportCertificatesVolume(sampleES.Name),
 		sampleES.Name,
 		nil,
+		sampleES.Spec.NodeDebug,
 	)
 	require.NoError(t, err)
 	// should be patched with volume and env
@@ -122,18 +128,19 @@ func TestBuildPodTemplateSpec(t *testing.T) {
 		initContainers[i].VolumeMounts = append(initContainers[i].VolumeMounts, volumeMounts...)
 	}
 
-	// remove the prepare-fs init-container from comparison, it has its own volume mount logic
+	// remove the prepare-fs and suspend init-containers from comparison, they have their own volume mount logic
 	// that is harder to test
-	for i, c := range initContainers {
-		if c.Name == initcontainer.PrepareFilesystemContainerName {
-			initContainers = append(initContainers[:i], initContainers[i+1:]...)
-		}
-	}
-	for i, c := range actual.Spec.InitContainers {
-		if c.Name == initcontainer.PrepareFilesystemContainerName {
-			actual.Spec.InitContainers = append(actual.Spec.InitContainers[:i], actual.Spec.InitContainers[i+1:]...)
+	withoutInternalMounts := func(containers []corev1.Container) []corev1.Container {
+		var result []corev1.Container
+		for _, c := range containers {
+			if c.Name != initcontainer.PrepareFilesystemContainerName && c.Name != initcontainer.SuspendContainerName {
+				result = append(result, c)
+			}
 		}
+		return result
 	}
+	initContainers = withoutInternalMounts(initContainers)
+	actual.Spec.InitContainers = withoutInternalMounts(actual.Spec.InitContainers)
 
 	expected := corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -235,3 +242,322 @@ func Test_getDefaultContainerPorts(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultTopologySpreadConstraints(t *testing.T) {
+	masterDataLabels := map[string]string{
+		"elasticsearch.k8s.elastic.co/node-master": "true",
+		"elasticsearch.k8s.elastic.co/node-data":   "true",
+	}
+	coordinatingLabels := map[string]string{
+		"elasticsearch.k8s.elastic.co/node-master": "false",
+		"elasticsearch.k8s.elastic.co/node-data":   "false",
+	}
+	selector := func(nodeType string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{
+			"elasticsearch.k8s.elastic.co/cluster-name": "name",
+			nodeType: "true",
+		}}
+	}
+	maxSkew := int32(2)
+	tests := []struct {
+		name      string
+		spread    *esv1.TopologySpread
+		podLabels map[string]string
+		want      []corev1.TopologySpreadConstraint
+	}{
+		{
+			name:      "no topology spread",
+			podLabels: masterDataLabels,
+			want:      nil,
+		},
+		{
+			name:      "default topology spread of master and data nodes",
+			spread:    &esv1.TopologySpread{},
+			podLabels: masterDataLabels,
+			want: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector:     selector("elasticsearch.k8s.elastic.co/node-master"),
+				},
+				{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector:     selector("elasticsearch.k8s.elastic.co/node-data"),
+				},
+			},
+		},
+		{
+			name: "custom topology spread of data nodes",
+			spread: &esv1.TopologySpread{
+				TopologyKey:       "rack",
+				MaxSkew:           &maxSkew,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+			},
+			podLabels: map[string]string{"elasticsearch.k8s.elastic.co/node-data": "true"},
+			want: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           2,
+					TopologyKey:       "rack",
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+					LabelSelector:     selector("elasticsearch.k8s.elastic.co/node-data"),
+				},
+			},
+		},
+		{
+			name:      "coordinating nodes are not spread",
+			spread:    &esv1.TopologySpread{},
+			podLabels: coordinatingLabels,
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := *sampleES.DeepCopy()
+			es.Spec.TopologySpread = tt.spread
+			require.Equal(t, tt.want, DefaultTopologySpreadConstraints(es, tt.podLabels))
+		})
+	}
+}
+
+func TestBuildPodTemplateSpec_JVMOptions(t *testing.T) {
+	nodeSet := *sampleES.Spec.NodeSets[0].DeepCopy()
+	ver, err := version.Parse(sampleES.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(sampleES.Name, *ver, sampleES.Spec.HTTP, sampleES.Spec.Auth, sampleES.Spec.Audit, sampleES.Spec.RemoteClusterServer, sampleES.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	jvmOptionsHash := func() string {
+		podTemplate, err := BuildPodTemplateSpec(sampleES, nodeSet, cfg, nil)
+		require.NoError(t, err)
+		return podTemplate.Annotations[JVMOptionsHashAnnotationName]
+	}
+	// no JVM options: no annotation, for existing Pods not to be restarted
+	require.Empty(t, jvmOptionsHash())
+
+	nodeSet.JVMOptions = []string{"-XX:+UseG1GC"}
+	initialHash := jvmOptionsHash()
+	require.NotEmpty(t, initialHash)
+
+	nodeSet.JVMOptions = append(nodeSet.JVMOptions, "-XX:HeapDumpPath=/usr/share/elasticsearch/data")
+	require.NotEqual(t, initialHash, jvmOptionsHash())
+}
+
+func TestBuildPodTemplateSpec_Architecture(t *testing.T) {
+	nodeSet := *sampleES.Spec.NodeSets[0].DeepCopy()
+	ver, err := version.Parse(sampleES.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(sampleES.Name, *ver, sampleES.Spec.HTTP, sampleES.Spec.Auth, sampleES.Spec.Audit, sampleES.Spec.RemoteClusterServer, sampleES.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	// no architecture: no node selector, for existing Pods not to be restarted
+	podTemplate, err := BuildPodTemplateSpec(sampleES, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.NotContains(t, podTemplate.Spec.NodeSelector, corev1.LabelArchStable)
+
+	nodeSet.Architecture = "arm64"
+	podTemplate, err = BuildPodTemplateSpec(sampleES, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, "linux", podTemplate.Spec.NodeSelector[corev1.LabelOSStable])
+	require.Equal(t, "arm64", podTemplate.Spec.NodeSelector[corev1.LabelArchStable])
+
+	// the image of the NodeSet overrides the image of the cluster
+	es := *sampleES.DeepCopy()
+	es.Spec.Image = "registry.example.com/elasticsearch:7.2.0"
+	nodeSet.Image = "registry.example.com/elasticsearch-arm64:7.2.0"
+	podTemplate, err = BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name == esv1.ElasticsearchContainerName {
+			require.Equal(t, nodeSet.Image, c.Image)
+		}
+	}
+}
+
+func TestBuildPodTemplateSpec_KeystoreSidecar(t *testing.T) {
+	nodeSet := *sampleES.Spec.NodeSets[0].DeepCopy()
+	ver, err := version.Parse(sampleES.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(sampleES.Name, *ver, sampleES.Spec.HTTP, sampleES.Spec.Auth, sampleES.Spec.Audit, sampleES.Spec.RemoteClusterServer, sampleES.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	resources := keystore.Resources{InitContainer: corev1.Container{Name: keystore.InitContainerName}, Version: "1"}
+	withSidecar, err := resources.WithSidecar(initcontainer.KeystoreParams, 30*time.Second)
+	require.NoError(t, err)
+	podTemplate, err := BuildPodTemplateSpec(sampleES, nodeSet, cfg, withSidecar)
+	require.NoError(t, err)
+	var esContainer, sidecar *corev1.Container
+	for i, c := range podTemplate.Spec.Containers {
+		switch c.Name {
+		case esv1.ElasticsearchContainerName:
+			esContainer = &podTemplate.Spec.Containers[i]
+		case keystore.SidecarContainerName:
+			sidecar = &podTemplate.Spec.Containers[i]
+		}
+	}
+	require.NotNil(t, sidecar)
+	require.Equal(t, esContainer.Image, sidecar.Image)
+	require.Contains(t, sidecar.VolumeMounts, initcontainer.EsConfigSharedVolume.EsContainerVolumeMount())
+
+	// the Pods are not rotated when the secure settings change
+	hash := podTemplate.Labels[label.SecureSettingsHashLabelName]
+	withSidecar.Version = "2"
+	podTemplate, err = BuildPodTemplateSpec(sampleES, nodeSet, cfg, withSidecar)
+	require.NoError(t, err)
+	require.Equal(t, hash, podTemplate.Labels[label.SecureSettingsHashLabelName])
+}
+
+func TestBuildPodTemplateSpec_ServiceMesh(t *testing.T) {
+	es := *sampleES.DeepCopy()
+	es.Spec.ServiceMesh = &esv1.ServiceMesh{Provider: esv1.IstioServiceMesh}
+	nodeSet := *es.Spec.NodeSets[0].DeepCopy()
+	// the transport layer is excluded from the mesh, unless the user decides otherwise
+	nodeSet.PodTemplate.Annotations = map[string]string{IstioExcludeOutboundPortsAnnotation: "9300,9443,5044"}
+	ver, err := version.Parse(es.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.EffectiveHTTP(), es.Spec.Auth, es.Spec.Audit, es.Spec.RemoteClusterServer, es.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	podTemplate, err := BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, "9300", podTemplate.Annotations[IstioExcludeInboundPortsAnnotation])
+	require.Equal(t, "9300,9443,5044", podTemplate.Annotations[IstioExcludeOutboundPortsAnnotation])
+	require.Equal(t, "true", podTemplate.Annotations[IstioRewriteAppHTTPProbersAnnotation])
+	require.Equal(t, `{"holdApplicationUntilProxyStarts": true}`, podTemplate.Annotations[IstioProxyConfigAnnotation])
+	// HTTP is served in plain text, behind the mutual TLS of the mesh
+	require.Equal(t, "http", podTemplate.Labels[label.HTTPSchemeLabelName])
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name == esv1.ElasticsearchContainerName {
+			require.Contains(t, c.Env, corev1.EnvVar{Name: settings.EnvReadinessProbeProtocol, Value: "http"})
+			require.Equal(t, "http", c.Ports[0].Name)
+		}
+	}
+}
+
+func TestBuildPodTemplateSpec_Diagnostics(t *testing.T) {
+	es := *sampleES.DeepCopy()
+	nodeSet := *es.Spec.NodeSets[0].DeepCopy()
+	nodeSet.Diagnostics = &esv1.Diagnostics{PersistentVolumeClaimName: "dumps", ThreadDumpAfterFailures: 6}
+	ver, err := version.Parse(es.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.EffectiveHTTP(), es.Spec.Auth, es.Spec.Audit, es.Spec.RemoteClusterServer, es.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	podTemplate, err := BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.Contains(t, podTemplate.Spec.Volumes, corev1.Volume{
+		Name: esvolume.DiagnosticsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "dumps"},
+		},
+	})
+	// the dumps of each Pod are written to their own directory, chowned by the prepare-fs init container
+	mount := corev1.VolumeMount{
+		Name:        esvolume.DiagnosticsVolumeName,
+		MountPath:   esvolume.DiagnosticsVolumeMountPath,
+		SubPathExpr: "$(POD_NAME)",
+	}
+	for _, c := range podTemplate.Spec.InitContainers {
+		require.Contains(t, c.VolumeMounts, mount, c.Name)
+	}
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name == esv1.ElasticsearchContainerName {
+			require.Contains(t, c.VolumeMounts, mount)
+			require.Contains(t, c.Env, corev1.EnvVar{Name: settings.EnvDiagnosticsPath, Value: esvolume.DiagnosticsVolumeMountPath})
+			require.Contains(t, c.Env, corev1.EnvVar{Name: settings.EnvThreadDumpAfterFailures, Value: "6"})
+		}
+	}
+	require.Equal(t, []string{
+		"-XX:+HeapDumpOnOutOfMemoryError",
+		"-XX:HeapDumpPath=/usr/share/elasticsearch/diagnostics",
+		"-XX:ErrorFile=/usr/share/elasticsearch/diagnostics/hs_err_pid%p.log",
+	}, DiagnosticsJVMOptions(nodeSet.Diagnostics))
+}
+
+func TestBuildPodTemplateSpec_PriorityClasses(t *testing.T) {
+	es := *sampleES.DeepCopy()
+	es.Spec.PriorityClasses = &esv1.PriorityClasses{
+		Master:       &esv1.RolePriorityClass{Name: "es-master"},
+		Coordinating: &esv1.RolePriorityClass{Name: "es-coordinating"},
+	}
+	ver, err := version.Parse(es.Spec.Version)
+	require.NoError(t, err)
+	priorityClassName := func(nodeSet esv1.NodeSet, roles map[string]interface{}) string {
+		nodeSet.Config = &commonv1.Config{Data: roles}
+		cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.EffectiveHTTP(), es.Spec.Auth, es.Spec.Audit, es.Spec.RemoteClusterServer, es.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+		require.NoError(t, err)
+		podTemplate, err := BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+		require.NoError(t, err)
+		return podTemplate.Spec.PriorityClassName
+	}
+	nodeSet := *es.Spec.NodeSets[0].DeepCopy()
+
+	require.Equal(t, "es-master", priorityClassName(nodeSet, map[string]interface{}{"node.master": "true", "node.data": "true"}))
+	// no priority class specified for the data nodes
+	require.Empty(t, priorityClassName(nodeSet, map[string]interface{}{"node.master": "false", "node.data": "true"}))
+	require.Equal(t, "es-coordinating", priorityClassName(nodeSet, map[string]interface{}{
+		"node.master": "false", "node.data": "false", "node.ingest": "true",
+	}))
+
+	// the priority class of the Pod template takes precedence
+	nodeSet.PodTemplate.Spec.PriorityClassName = "custom"
+	require.Equal(t, "custom", priorityClassName(nodeSet, map[string]interface{}{"node.master": "true"}))
+}
+
+func TestBuildPodTemplateSpec_RuntimeClassName(t *testing.T) {
+	nodeSet := *sampleES.Spec.NodeSets[0].DeepCopy()
+	ver, err := version.Parse(sampleES.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(sampleES.Name, *ver, sampleES.Spec.HTTP, sampleES.Spec.Auth, sampleES.Spec.Audit, sampleES.Spec.RemoteClusterServer, sampleES.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+
+	// no runtime class: the default runtime is used
+	podTemplate, err := BuildPodTemplateSpec(sampleES, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.Nil(t, podTemplate.Spec.RuntimeClassName)
+
+	nodeSet.RuntimeClassName = "kata"
+	podTemplate, err = BuildPodTemplateSpec(sampleES, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, "kata", *podTemplate.Spec.RuntimeClassName)
+}
+
+func TestBuildPodTemplateSpec_AuditBeatSidecar(t *testing.T) {
+	es := *sampleES.DeepCopy()
+	es.Spec.Audit = &esv1.AuditLogging{
+		Shipping: &esv1.AuditShipping{ElasticsearchRef: commonv1.ObjectSelector{Name: "monitoring"}},
+	}
+	nodeSet := *es.Spec.NodeSets[0].DeepCopy()
+	ver, err := version.Parse(es.Spec.Version)
+	require.NoError(t, err)
+	cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.HTTP, es.Spec.Auth, es.Spec.Audit, es.Spec.RemoteClusterServer, es.Spec.RemoteClusters, *nodeSet.Config, &certificates.CertificateResources{})
+	require.NoError(t, err)
+	hasSidecar := func(podTemplate corev1.PodTemplateSpec) bool {
+		for _, c := range podTemplate.Spec.Containers {
+			if c.Name == audit.BeatContainerName {
+				return true
+			}
+		}
+		return false
+	}
+
+	// no sidecar until the association with the monitoring cluster is established
+	podTemplate, err := BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.False(t, hasSidecar(podTemplate))
+	require.NotContains(t, podTemplate.Annotations, audit.BeatConfigHashAnnotationName)
+
+	es.SetAssociationConf(&commonv1.AssociationConf{
+		AuthSecretName: "name-audit-beat-user",
+		AuthSecretKey:  "namespace-name-audit-beat-user",
+		CASecretName:   "name-audit-es-ca",
+		URL:            "https://monitoring-es-http.namespace.svc:9200",
+	})
+	podTemplate, err = BuildPodTemplateSpec(es, nodeSet, cfg, nil)
+	require.NoError(t, err)
+	require.True(t, hasSidecar(podTemplate))
+	// the Pods are rotated when the configuration of the sidecar changes
+	require.NotEmpty(t, podTemplate.Annotations[audit.BeatConfigHashAnnotationName])
+}
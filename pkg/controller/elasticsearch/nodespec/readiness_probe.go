@@ -8,6 +8,7 @@ import (
 	"path"
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -27,11 +28,32 @@ func NewReadinessProbe() *corev1.Probe {
 	}
 }
 
+// JDKPath is the path of the JDK bundled with Elasticsearch in its Docker image.
+const JDKPath = "/usr/share/elasticsearch/jdk"
+
 const ReadinessProbeScriptConfigKey = "readiness-probe-script.sh"
 const ReadinessProbeScript = `#!/usr/bin/env bash
 
+# capture_thread_dump captures a thread dump of Elasticsearch in the background, once the probe fails the given
+# number of consecutive times, if enabled
+function capture_thread_dump {
+  if [[ -z "${` + settings.EnvThreadDumpAfterFailures + `}" ]] || [[ ! -d "${` + settings.EnvDiagnosticsPath + `}" ]]; then
+    return
+  fi
+  failures_file="${` + settings.EnvDiagnosticsPath + `}/.readiness-probe-failures"
+  failures=$(( $(cat "${failures_file}" 2> /dev/null || echo 0) + 1 ))
+  echo "${failures}" > "${failures_file}"
+  if [[ ${failures} -eq ${` + settings.EnvThreadDumpAfterFailures + `} ]]; then
+    pid=$(grep -l "[o]rg.elasticsearch.bootstrap.Elasticsearch" /proc/[0-9]*/cmdline 2> /dev/null | head -n 1 | cut -d / -f 3)
+    if [[ -n "${pid}" ]]; then
+      timeout 60 ` + JDKPath + `/bin/jcmd "${pid}" Thread.print > "${` + settings.EnvDiagnosticsPath + `}/thread-dump-$(date +%s).txt" 2>&1 &
+    fi
+  fi
+}
+
 # fail should be called as a last resort to help the user to understand why the probe failed
 function fail {
+  capture_thread_dump
   timestamp=$(date --iso-8601=seconds)
   echo "{\"timestamp\": \"${timestamp}\", \"message\": \"readiness probe failed\", "$1"}" | tee /proc/1/fd/2 2> /dev/null
   exit 1
@@ -75,6 +97,9 @@ fi
 
 # ready if status code 200, 503 is tolerable if ES version is 6.x
 if [[ ${status} == "200" ]] || [[ ${status} == "503" && ${version:0:2} == "6." ]]; then
+  if [[ -n "${` + settings.EnvDiagnosticsPath + `}" ]]; then
+    rm -f "${` + settings.EnvDiagnosticsPath + `}/.readiness-probe-failures"
+  fi
   exit 0
 else
   fail " \"status\": \"${status}\", \"version\":\"${version}\" "
@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+// ResourceDetectionHashAnnotationName holds the hash of the resources detected by the nodes of the NodeSet, to
+// restart its Pods when they change.
+const ResourceDetectionHashAnnotationName = "elasticsearch.k8s.elastic.co/resource-detection-hash"
+
+// totalMemoryMinVersion is the first version of Elasticsearch reading the memory of the node from the
+// es.total_memory_bytes system property, instead of the operating system.
+var totalMemoryMinVersion = version.MustParse("7.16.0")
+
+// detectedResources are the memory and processors the nodes of a NodeSet are told about.
+type detectedResources struct {
+	memoryBytes int64
+	processors  int64
+}
+
+// resourcesToDetect returns the resources the nodes of the NodeSet should detect: the overrides of the NodeSet, or
+// else the limits of the Elasticsearch container. It returns false if the NodeSet does not override the resource
+// detection, leaving it to Elasticsearch and the JVM.
+func resourcesToDetect(nodeSet esv1.NodeSet) (detectedResources, bool) {
+	if nodeSet.ResourceDetection == nil {
+		return detectedResources{}, false
+	}
+	limits := containerResources(nodeSet.PodTemplate).Limits
+	var detected detectedResources
+	if nodeSet.ResourceDetection.Memory != nil {
+		detected.memoryBytes = nodeSet.ResourceDetection.Memory.Value()
+	} else if memory, exists := limits[corev1.ResourceMemory]; exists {
+		detected.memoryBytes = memory.Value()
+	}
+	if nodeSet.ResourceDetection.Processors != nil {
+		detected.processors = int64(*nodeSet.ResourceDetection.Processors)
+	} else if cpu, exists := limits[corev1.ResourceCPU]; exists {
+		// round up fractional CPU limits, as the JVM and Elasticsearch only deal with whole processors
+		detected.processors = (cpu.MilliValue() + 999) / 1000
+	}
+	return detected, true
+}
+
+// containerResources returns the resources of the Elasticsearch container of the Pod template, or the default
+// resources if none are set.
+func containerResources(podTemplate corev1.PodTemplateSpec) corev1.ResourceRequirements {
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name == esv1.ElasticsearchContainerName && (c.Resources.Requests != nil || c.Resources.Limits != nil) {
+			return c.Resources
+		}
+	}
+	return DefaultResources
+}
+
+// ResourceDetectionJVMOptions returns the JVM options setting the memory and processors detected by the JVM, and by
+// Elasticsearch for the versions reading the memory from a system property. They are listed before the JVM options of
+// the user, which take precedence.
+func ResourceDetectionJVMOptions(nodeSet esv1.NodeSet, ver version.Version) []string {
+	detected, overridden := resourcesToDetect(nodeSet)
+	if !overridden {
+		return nil
+	}
+	var options []string
+	if detected.memoryBytes > 0 {
+		memory := strconv.FormatInt(detected.memoryBytes, 10)
+		options = append(options, "-XX:MaxRAM="+memory)
+		if ver.IsSameOrAfter(totalMemoryMinVersion) {
+			options = append(options, "-Des.total_memory_bytes="+memory)
+		}
+	}
+	if detected.processors > 0 {
+		options = append(options, "-XX:ActiveProcessorCount="+strconv.FormatInt(detected.processors, 10))
+	}
+	return options
+}
+
+// resourceDetectionConfig returns the user configuration completed with the number of processors detected by
+// Elasticsearch, unless explicitly set by the user.
+func resourceDetectionConfig(userConfig *commonv1.Config, nodeSet esv1.NodeSet) (*commonv1.Config, error) {
+	detected, overridden := resourcesToDetect(nodeSet)
+	if !overridden || detected.processors == 0 {
+		return userConfig, nil
+	}
+	return withDefaultSettings(userConfig, map[string]interface{}{
+		esv1.NodeProcessors: detected.processors,
+	})
+}
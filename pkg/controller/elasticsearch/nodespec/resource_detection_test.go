@@ -0,0 +1,122 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/pointer"
+)
+
+func nodeSetWithResources(detection *esv1.ResourceDetection, limits corev1.ResourceList) esv1.NodeSet {
+	nodeSet := esv1.NodeSet{Name: "ml", ResourceDetection: detection}
+	if limits != nil {
+		nodeSet.PodTemplate.Spec.Containers = []corev1.Container{{
+			Name:      esv1.ElasticsearchContainerName,
+			Resources: corev1.ResourceRequirements{Limits: limits},
+		}}
+	}
+	return nodeSet
+}
+
+func TestResourceDetectionJVMOptions(t *testing.T) {
+	memory := resource.MustParse("8Gi")
+	limits := corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+		corev1.ResourceCPU:    resource.MustParse("1500m"),
+	}
+	tests := []struct {
+		name    string
+		nodeSet esv1.NodeSet
+		version string
+		want    []string
+	}{
+		{
+			name:    "no resource detection",
+			nodeSet: nodeSetWithResources(nil, limits),
+			version: "7.16.0",
+			want:    nil,
+		},
+		{
+			name:    "container limits, with the fractional CPU rounded up",
+			nodeSet: nodeSetWithResources(&esv1.ResourceDetection{}, limits),
+			version: "7.16.0",
+			want:    []string{"-XX:MaxRAM=4294967296", "-Des.total_memory_bytes=4294967296", "-XX:ActiveProcessorCount=2"},
+		},
+		{
+			name:    "default resources",
+			nodeSet: nodeSetWithResources(&esv1.ResourceDetection{}, nil),
+			version: "7.16.0",
+			want:    []string{"-XX:MaxRAM=2147483648", "-Des.total_memory_bytes=2147483648"},
+		},
+		{
+			name:    "overrides take precedence",
+			nodeSet: nodeSetWithResources(&esv1.ResourceDetection{Memory: &memory, Processors: pointer.Int32(4)}, limits),
+			version: "7.16.0",
+			want:    []string{"-XX:MaxRAM=8589934592", "-Des.total_memory_bytes=8589934592", "-XX:ActiveProcessorCount=4"},
+		},
+		{
+			name:    "memory only detected by the JVM before 7.16.0",
+			nodeSet: nodeSetWithResources(&esv1.ResourceDetection{Memory: &memory}, nil),
+			version: "7.15.2",
+			want:    []string{"-XX:MaxRAM=8589934592"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ResourceDetectionJVMOptions(tt.nodeSet, version.MustParse(tt.version)))
+		})
+	}
+}
+
+func Test_resourceDetectionConfig(t *testing.T) {
+	userConfig := &commonv1.Config{Data: map[string]interface{}{"node.data": false}}
+	limits := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}
+	tests := []struct {
+		name       string
+		userConfig *commonv1.Config
+		nodeSet    esv1.NodeSet
+		want       *commonv1.Config
+	}{
+		{
+			name:       "no resource detection",
+			userConfig: userConfig,
+			nodeSet:    nodeSetWithResources(nil, limits),
+			want:       userConfig,
+		},
+		{
+			name:       "no CPU limit",
+			userConfig: userConfig,
+			nodeSet:    nodeSetWithResources(&esv1.ResourceDetection{}, nil),
+			want:       userConfig,
+		},
+		{
+			name:       "processors of the CPU limit",
+			userConfig: userConfig,
+			nodeSet:    nodeSetWithResources(&esv1.ResourceDetection{}, limits),
+			want:       &commonv1.Config{Data: map[string]interface{}{"node.data": false, "node.processors": int64(3)}},
+		},
+		{
+			name:       "user settings take precedence",
+			userConfig: &commonv1.Config{Data: map[string]interface{}{"node": map[string]interface{}{"processors": 1}}},
+			nodeSet:    nodeSetWithResources(&esv1.ResourceDetection{Processors: pointer.Int32(2)}, nil),
+			want:       &commonv1.Config{Data: map[string]interface{}{"node": map[string]interface{}{"processors": 1}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resourceDetectionConfig(tt.userConfig, tt.nodeSet)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
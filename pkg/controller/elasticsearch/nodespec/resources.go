@@ -24,6 +24,7 @@ type Resources struct {
 	StatefulSet     appsv1.StatefulSet
 	HeadlessService corev1.Service
 	Config          settings.CanonicalConfig
+	JVMOptions      []string
 }
 
 type ResourcesList []Resources
@@ -41,6 +42,8 @@ func BuildExpectedResources(
 	keystoreResources *keystore.Resources,
 	certResources *certificates.CertificateResources,
 	existingStatefulSets sset.StatefulSetList,
+	defaultGeoIPEndpoint string,
+	operatorSettings OperatorSettings,
 ) (ResourcesList, error) {
 	nodesResources := make(ResourcesList, 0, len(es.Spec.NodeSets))
 
@@ -49,28 +52,48 @@ func BuildExpectedResources(
 		return nil, err
 	}
 
-	for _, nodeSpec := range es.Spec.NodeSets {
+	nodeSets, err := expandNodeSets(es.Spec.NodeSets)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, nodeSpec := range nodeSets {
 		// build es config
+		nodeCfg, err := geoIPConfig(nodeSpec.Config, *ver, es.Spec.GeoIP, defaultGeoIPEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		nodeCfg, err = resourceDetectionConfig(nodeCfg, nodeSpec)
+		if err != nil {
+			return nil, err
+		}
+		nodeCfg, err = dataTierConfig(nodeCfg, nodeSpec, *ver)
+		if err != nil {
+			return nil, err
+		}
+		nodeCfg = interpolateSecretRefs(nodeCfg)
 		userCfg := commonv1.Config{}
-		if nodeSpec.Config != nil {
-			userCfg = *nodeSpec.Config
+		if nodeCfg != nil {
+			userCfg = *nodeCfg
 		}
-		cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.HTTP, userCfg, certResources)
+		cfg, err := settings.NewMergedESConfig(es.Name, *ver, es.Spec.EffectiveHTTP(), es.Spec.Auth, es.Spec.Audit, es.Spec.RemoteClusterServer, es.Spec.RemoteClusters, userCfg, certResources)
 		if err != nil {
 			return nil, err
 		}
 
 		// build stateful set and associated headless service
-		statefulSet, err := BuildStatefulSet(es, nodeSpec, cfg, keystoreResources, existingStatefulSets)
+		statefulSet, err := BuildStatefulSet(es, nodeSpec, cfg, keystoreResources, existingStatefulSets, operatorSettings)
 		if err != nil {
 			return nil, err
 		}
 		headlessSvc := HeadlessService(k8s.ExtractNamespacedName(&es), statefulSet.Name)
 
+		jvmOptions := append(DiagnosticsJVMOptions(nodeSpec.Diagnostics), ResourceDetectionJVMOptions(nodeSpec, *ver)...)
 		nodesResources = append(nodesResources, Resources{
 			StatefulSet:     statefulSet,
 			HeadlessService: headlessSvc,
 			Config:          cfg,
+			JVMOptions:      append(jvmOptions, nodeSpec.JVMOptions...),
 		})
 	}
 
@@ -0,0 +1,52 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+)
+
+// secretRefEnvVarPrefix prefixes the environment variables holding the Secret keys referenced in the configuration.
+const secretRefEnvVarPrefix = "ECK_SECRET_"
+
+// secretRefEnvVarName returns the name of the environment variable holding the given Secret key.
+func secretRefEnvVarName(ref esv1.ConfigSecretRef) string {
+	return secretRefEnvVarPrefix + hash.HashObject(ref)
+}
+
+// interpolateSecretRefs replaces the references to Secret keys in the given user configuration by the environment
+// variables holding them, which Elasticsearch substitutes when it reads elasticsearch.yml. The Secret values never
+// appear in the rendered configuration.
+func interpolateSecretRefs(userConfig *commonv1.Config) *commonv1.Config {
+	if len(esv1.ConfigSecretRefs(userConfig)) == 0 {
+		return userConfig
+	}
+	return esv1.InterpolateConfigSecretRefs(userConfig, func(ref esv1.ConfigSecretRef) string {
+		return "${" + secretRefEnvVarName(ref) + "}"
+	})
+}
+
+// secretRefsEnvVars returns the environment variables holding the Secret keys referenced in the given user
+// configuration. The Pods must be restarted for an updated Secret value to be used.
+func secretRefsEnvVars(userConfig *commonv1.Config) []corev1.EnvVar {
+	refs := esv1.ConfigSecretRefs(userConfig)
+	vars := make([]corev1.EnvVar, 0, len(refs))
+	for _, ref := range refs {
+		vars = append(vars, corev1.EnvVar{
+			Name: secretRefEnvVarName(ref),
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+					Key:                  ref.Key,
+				},
+			},
+		})
+	}
+	return vars
+}
@@ -0,0 +1,60 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	commonscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates"
+)
+
+func TestBuildExpectedResources_SecretRefs(t *testing.T) {
+	require.NoError(t, commonscheme.SetupScheme())
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version: "7.6.0",
+			NodeSets: []esv1.NodeSet{{
+				Name:  "default",
+				Count: 1,
+				Config: &commonv1.Config{Data: map[string]interface{}{
+					"xpack.notification.email.account.work.smtp.password": "${secret:ns/smtp/password}",
+				}},
+			}},
+		},
+	}
+	resources, err := BuildExpectedResources(es, nil, &certificates.CertificateResources{}, nil, "", OperatorSettings{})
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	envVarName := secretRefEnvVarName(esv1.ConfigSecretRef{Namespace: "ns", Name: "smtp", Key: "password"})
+	rendered, err := resources[0].Config.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), "password: ${"+envVarName+"}")
+	require.NotContains(t, string(rendered), "secret:")
+
+	var esContainer corev1.Container
+	for _, c := range resources[0].StatefulSet.Spec.Template.Spec.Containers {
+		if c.Name == esv1.ElasticsearchContainerName {
+			esContainer = c
+		}
+	}
+	require.Contains(t, esContainer.Env, corev1.EnvVar{
+		Name: envVarName,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "smtp"},
+				Key:                  "password",
+			},
+		},
+	})
+}
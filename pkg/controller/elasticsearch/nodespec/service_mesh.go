@@ -0,0 +1,58 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"strconv"
+	"strings"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/network"
+)
+
+const (
+	// IstioExcludeInboundPortsAnnotation lists the ports whose inbound traffic is not intercepted by the sidecar.
+	IstioExcludeInboundPortsAnnotation = "traffic.sidecar.istio.io/excludeInboundPorts"
+	// IstioExcludeOutboundPortsAnnotation lists the ports whose outbound traffic is not intercepted by the sidecar.
+	IstioExcludeOutboundPortsAnnotation = "traffic.sidecar.istio.io/excludeOutboundPorts"
+	// IstioRewriteAppHTTPProbersAnnotation has the HTTP probes of the containers sent through the sidecar.
+	IstioRewriteAppHTTPProbersAnnotation = "sidecar.istio.io/rewriteAppHTTPProbers"
+	// IstioProxyConfigAnnotation overrides the configuration of the sidecar of the Pod.
+	IstioProxyConfigAnnotation = "proxy.istio.io/config"
+
+	// istioProxyConfig starts Elasticsearch once the sidecar is ready to route its traffic, for the HTTP requests
+	// sent by the nodes on startup, and for the readiness probe not to succeed before the Pod is reachable.
+	istioProxyConfig = `{"holdApplicationUntilProxyStarts": true}`
+)
+
+// serviceMeshAnnotations returns the annotations of the Pods of the given cluster for the sidecar of its service mesh,
+// if any. The transport layer, and the remote cluster server, are excluded from the mesh: the nodes connect to each
+// other by Pod IP over the TLS set up by the operator, which the mesh cannot route.
+func serviceMeshAnnotations(es esv1.Elasticsearch) map[string]string {
+	if es.Spec.ServiceMesh == nil {
+		return nil
+	}
+	inbound := []int{network.TransportPort}
+	if es.Spec.RemoteClusterServer.Enabled {
+		inbound = append(inbound, network.RemoteClusterPort)
+	}
+	// connections to remote clusters target their transport or remote cluster server ports
+	outbound := []int{network.TransportPort, network.RemoteClusterPort}
+
+	return map[string]string{
+		IstioExcludeInboundPortsAnnotation:   joinPorts(inbound),
+		IstioExcludeOutboundPortsAnnotation:  joinPorts(outbound),
+		IstioRewriteAppHTTPProbersAnnotation: "true",
+		IstioProxyConfigAnnotation:           istioProxyConfig,
+	}
+}
+
+func joinPorts(ports []int) string {
+	strs := make([]string, len(ports))
+	for i, port := range ports {
+		strs[i] = strconv.Itoa(port)
+	}
+	return strings.Join(strs, ",")
+}
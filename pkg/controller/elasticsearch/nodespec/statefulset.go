@@ -55,6 +55,7 @@ func BuildStatefulSet(
 	cfg settings.CanonicalConfig,
 	keystoreResources *keystore.Resources,
 	existingStatefulSets sset.StatefulSetList,
+	operatorSettings OperatorSettings,
 ) (appsv1.StatefulSet, error) {
 	statefulSetName := esv1.StatefulSet(es.Name, nodeSet.Name)
 
@@ -70,6 +71,9 @@ func BuildStatefulSet(
 	if err != nil {
 		return appsv1.StatefulSet{}, err
 	}
+	if err := applyOperatorSettings(operatorSettings, es, &podTemplate); err != nil {
+		return appsv1.StatefulSet{}, err
+	}
 
 	// build sset labels on top of the selector
 	// TODO: inherit user-provided labels and annotations from the CRD?
@@ -0,0 +1,48 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+)
+
+// mmapSettings is the subset of the node settings disabling memory mapping.
+type mmapSettings struct {
+	Node struct {
+		Store struct {
+			AllowMmap *bool `config:"allow_mmap"`
+		} `config:"store"`
+	} `config:"node"`
+}
+
+// checkMaxMapCount returns true if the vm.max_map_count kernel setting of the hosts of the nodes with the given
+// configuration must be checked: if requested for the cluster, unless the nodes do not memory map the indices.
+func checkMaxMapCount(es esv1.Elasticsearch, cfg settings.CanonicalConfig) (bool, error) {
+	if !es.Spec.VirtualMemory.IsMaxMapCountChecked() {
+		return false, nil
+	}
+	var mmap mmapSettings
+	if err := cfg.CanonicalConfig.Unpack(&mmap); err != nil {
+		return false, err
+	}
+	allowMmap := mmap.Node.Store.AllowMmap
+	return allowMmap == nil || *allowMmap, nil
+}
+
+// withCheckVMInitContainer appends the init container checking the vm.max_map_count kernel setting to the given Pod
+// template, after the init containers of the user, unless the user already set a container with the same name.
+func withCheckVMInitContainer(podTemplate corev1.PodTemplateSpec, image string) corev1.PodTemplateSpec {
+	for _, c := range podTemplate.Spec.InitContainers {
+		if c.Name == initcontainer.CheckVMContainerName {
+			return podTemplate
+		}
+	}
+	podTemplate.Spec.InitContainers = append(podTemplate.Spec.InitContainers, initcontainer.NewCheckVMInitContainer(image))
+	return podTemplate
+}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/initcontainer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/settings"
+)
+
+func Test_checkMaxMapCount(t *testing.T) {
+	checked := esv1.Elasticsearch{Spec: esv1.ElasticsearchSpec{VirtualMemory: &esv1.VirtualMemory{CheckMaxMapCount: true}}}
+	tests := []struct {
+		name   string
+		es     esv1.Elasticsearch
+		config map[string]interface{}
+		want   bool
+	}{
+		{
+			name:   "not checked",
+			es:     esv1.Elasticsearch{},
+			config: map[string]interface{}{},
+			want:   false,
+		},
+		{
+			name:   "checked",
+			es:     checked,
+			config: map[string]interface{}{"node.data": true},
+			want:   true,
+		},
+		{
+			name:   "checked with memory mapping enabled",
+			es:     checked,
+			config: map[string]interface{}{"node.store.allow_mmap": true},
+			want:   true,
+		},
+		{
+			name:   "memory mapping disabled",
+			es:     checked,
+			config: map[string]interface{}{"node": map[string]interface{}{"store.allow_mmap": false}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkMaxMapCount(tt.es, settings.CanonicalConfig{CanonicalConfig: common.MustCanonicalConfig(tt.config)})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_withCheckVMInitContainer(t *testing.T) {
+	podTemplate := corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: initcontainer.PrepareFilesystemContainerName}, {Name: "sysctl"}},
+	}}
+	got := withCheckVMInitContainer(podTemplate, "elasticsearch:7.7.0")
+	// after the init containers of the user
+	require.Len(t, got.Spec.InitContainers, 3)
+	require.Equal(t, initcontainer.CheckVMContainerName, got.Spec.InitContainers[2].Name)
+	require.Equal(t, "elasticsearch:7.7.0", got.Spec.InitContainers[2].Image)
+
+	// container of the user left untouched
+	podTemplate.Spec.InitContainers[1].Name = initcontainer.CheckVMContainerName
+	got = withCheckVMInitContainer(podTemplate, "elasticsearch:7.7.0")
+	require.Equal(t, podTemplate, got)
+}
@@ -5,6 +5,9 @@
 package nodespec
 
 import (
+	"fmt"
+	"path"
+
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
@@ -18,8 +21,12 @@ import (
 
 var downwardAPIVolume = volume.DownwardAPI{}
 
-func buildVolumes(esName string, nodeSpec esv1.NodeSet, keystoreResources *keystore.Resources) ([]corev1.Volume, []corev1.VolumeMount) {
-
+func buildVolumes(
+	es esv1.Elasticsearch,
+	nodeSpec esv1.NodeSet,
+	keystoreResources *keystore.Resources,
+) ([]corev1.Volume, []corev1.VolumeMount) {
+	esName := es.Name
 	configVolume := settings.ConfigSecretVolume(esv1.StatefulSet(esName, nodeSpec.Name))
 	probeSecret := volume.NewSelectiveSecretVolumeWithMountPath(
 		esv1.InternalUsersSecret(esName), esvolume.ProbeUserVolumeName,
@@ -82,6 +89,22 @@ func buildVolumes(esName string, nodeSpec esv1.NodeSet, keystoreResources *keyst
 	if keystoreResources != nil {
 		volumes = append(volumes, keystoreResources.Volume)
 	}
+	samlMetadataVolumes := samlMetadataVolumes(es.Spec.Auth)
+	for _, v := range samlMetadataVolumes {
+		volumes = append(volumes, v.Volume())
+	}
+	analysisFilesVolumes := analysisFilesVolumes(es.Spec.AnalysisFiles)
+	for _, v := range analysisFilesVolumes {
+		volumes = append(volumes, v.Volume())
+	}
+	geoIPVolume, geoIPVolumeMount, hasGeoIPDatabases := geoIPDatabasesVolume(es.Spec.GeoIP)
+	if hasGeoIPDatabases {
+		volumes = append(volumes, geoIPVolume)
+	}
+	diagnosticsVol, diagnosticsMount, hasDiagnostics := diagnosticsVolume(nodeSpec.Diagnostics)
+	if hasDiagnostics {
+		volumes = append(volumes, diagnosticsVol)
+	}
 
 	volumeMounts := append(
 		initcontainer.PluginVolumes.EsContainerVolumeMounts(),
@@ -97,6 +120,48 @@ func buildVolumes(esName string, nodeSpec esv1.NodeSet, keystoreResources *keyst
 		configVolume.VolumeMount(),
 		downwardAPIVolume.VolumeMount(),
 	)
+	for _, v := range samlMetadataVolumes {
+		volumeMounts = append(volumeMounts, v.VolumeMount())
+	}
+	for _, v := range analysisFilesVolumes {
+		volumeMounts = append(volumeMounts, v.VolumeMount())
+	}
+	if hasGeoIPDatabases {
+		volumeMounts = append(volumeMounts, geoIPVolumeMount)
+	}
+	if hasDiagnostics {
+		volumeMounts = append(volumeMounts, diagnosticsMount)
+	}
 
 	return volumes, volumeMounts
 }
+
+// samlMetadataVolumes returns the volumes holding the IdP metadata of the SAML realms, sourced either from a Secret
+// or from a ConfigMap.
+func samlMetadataVolumes(auth esv1.Auth) []volume.VolumeLike {
+	volumes := make([]volume.VolumeLike, 0, len(auth.SAML))
+	for i, realm := range auth.SAML {
+		name := fmt.Sprintf("%s%d", esvolume.SAMLMetadataVolumeNamePrefix, i)
+		mountPath := path.Join(esvolume.SAMLMetadataVolumeMountPath, realm.Name)
+		if realm.IdPMetadata.SecretName != "" {
+			volumes = append(volumes, volume.NewSelectiveSecretVolumeWithMountPath(
+				realm.IdPMetadata.SecretName, name, mountPath, []string{realm.IdPMetadata.FileKey()},
+			))
+			continue
+		}
+		volumes = append(volumes, volume.NewConfigMapVolume(realm.IdPMetadata.ConfigMapName, name, mountPath))
+	}
+	return volumes
+}
+
+// analysisFilesVolumes returns the volumes holding the analysis files, mounted in the configuration directory.
+// The ConfigMaps are not mounted with a sub-path, for changes to their content to be propagated to the running Pods.
+func analysisFilesVolumes(analysisFiles []esv1.AnalysisFiles) []volume.VolumeLike {
+	volumes := make([]volume.VolumeLike, 0, len(analysisFiles))
+	for i, files := range analysisFiles {
+		name := fmt.Sprintf("%s%d", esvolume.AnalysisFilesVolumeNamePrefix, i)
+		mountPath := path.Join(esvolume.ConfigVolumeMountPath, files.PathOrDefault())
+		volumes = append(volumes, volume.NewConfigMapVolume(files.ConfigMapName, name, mountPath))
+	}
+	return volumes
+}
@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+)
+
+// ZoneAttributeName is the node attribute holding the zone of the nodes of a NodeSet spread across zones.
+const ZoneAttributeName = "zone"
+
+// expandNodeSets returns the NodeSets to build the StatefulSets from: each NodeSet spread across zones is replaced by
+// one NodeSet per zone. The shard allocation awareness based on the zone is then set on all the NodeSets, for it to be
+// enforced whichever node is the elected master.
+func expandNodeSets(nodeSets []esv1.NodeSet) ([]esv1.NodeSet, error) {
+	spread := false
+	for _, nodeSet := range nodeSets {
+		spread = spread || nodeSet.ZoneSpread != nil
+	}
+	expanded := make([]esv1.NodeSet, 0, len(nodeSets))
+	for _, nodeSet := range nodeSets {
+		if nodeSet.ZoneSpread == nil {
+			if spread {
+				cfg, err := withDefaultSettings(nodeSet.Config, map[string]interface{}{
+					esv1.ClusterRoutingAllocationAwarenessAttributes: ZoneAttributeName,
+				})
+				if err != nil {
+					return nil, err
+				}
+				nodeSet.Config = cfg
+			}
+			expanded = append(expanded, nodeSet)
+			continue
+		}
+		for _, zone := range nodeSet.ZoneSpread.Zones {
+			zoned := nodeSet.ForZone(zone)
+			cfg, err := zoneConfig(zoned.Config, zone)
+			if err != nil {
+				return nil, err
+			}
+			zoned.Config = cfg
+			expanded = append(expanded, zoned)
+		}
+	}
+	return expanded, nil
+}
+
+// zoneConfig returns the user configuration completed with the zone attribute of the nodes, and with the shard
+// allocation awareness based on it. Settings explicitly set by the user are left untouched.
+func zoneConfig(userConfig *commonv1.Config, zone string) (*commonv1.Config, error) {
+	return withDefaultSettings(userConfig, map[string]interface{}{
+		esv1.NodeAttrZone: zone,
+		esv1.ClusterRoutingAllocationAwarenessAttributes: ZoneAttributeName,
+	})
+}
+
+// withDefaultSettings returns the user configuration completed with the given settings, unless explicitly set by
+// the user.
+func withDefaultSettings(userConfig *commonv1.Config, defaults map[string]interface{}) (*commonv1.Config, error) {
+	data := map[string]interface{}{}
+	if userConfig != nil {
+		for k, v := range userConfig.Data {
+			data[k] = v
+		}
+	}
+	userCfg, err := common.NewCanonicalConfigFrom(data)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range defaults {
+		if len(userCfg.HasKeys([]string{key})) == 0 {
+			data[key] = value
+		}
+	}
+	return &commonv1.Config{Data: data}, nil
+}
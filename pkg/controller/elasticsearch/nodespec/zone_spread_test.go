@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package nodespec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+)
+
+func podTemplateWithNodeSelector(topologyKey string, zone string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{Spec: corev1.PodSpec{NodeSelector: map[string]string{topologyKey: zone}}}
+}
+
+func Test_expandNodeSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeSets []esv1.NodeSet
+		want     []esv1.NodeSet
+	}{
+		{
+			name:     "no zone spread",
+			nodeSets: []esv1.NodeSet{{Name: "default", Count: 3}},
+			want:     []esv1.NodeSet{{Name: "default", Count: 3}},
+		},
+		{
+			name: "one NodeSet per zone",
+			nodeSets: []esv1.NodeSet{
+				{Name: "master", Count: 1},
+				{
+					Name:       "data",
+					Count:      2,
+					Config:     &commonv1.Config{Data: map[string]interface{}{"node.master": false}},
+					ZoneSpread: &esv1.ZoneSpread{Zones: []string{"a", "b"}},
+				},
+			},
+			want: []esv1.NodeSet{
+				// the allocation awareness is enforced by the master nodes
+				{Name: "master", Count: 1, Config: &commonv1.Config{Data: map[string]interface{}{
+					"cluster.routing.allocation.awareness.attributes": "zone",
+				}}},
+				{
+					Name:  "data-a",
+					Count: 2,
+					Config: &commonv1.Config{Data: map[string]interface{}{
+						"node.master":    false,
+						"node.attr.zone": "a",
+						"cluster.routing.allocation.awareness.attributes": "zone",
+					}},
+					PodTemplate: podTemplateWithNodeSelector(esv1.DefaultZoneTopologyKey, "a"),
+				},
+				{
+					Name:  "data-b",
+					Count: 2,
+					Config: &commonv1.Config{Data: map[string]interface{}{
+						"node.master":    false,
+						"node.attr.zone": "b",
+						"cluster.routing.allocation.awareness.attributes": "zone",
+					}},
+					PodTemplate: podTemplateWithNodeSelector(esv1.DefaultZoneTopologyKey, "b"),
+				},
+			},
+		},
+		{
+			name: "user settings take precedence",
+			nodeSets: []esv1.NodeSet{
+				{
+					Name:  "data",
+					Count: 1,
+					Config: &commonv1.Config{Data: map[string]interface{}{
+						"cluster": map[string]interface{}{
+							"routing.allocation.awareness.attributes": "zone,k8s_node_name",
+						},
+					}},
+					ZoneSpread: &esv1.ZoneSpread{Zones: []string{"a"}, TopologyKey: "failure-domain.beta.kubernetes.io/zone"},
+				},
+			},
+			want: []esv1.NodeSet{
+				{
+					Name:  "data-a",
+					Count: 1,
+					Config: &commonv1.Config{Data: map[string]interface{}{
+						"cluster": map[string]interface{}{
+							"routing.allocation.awareness.attributes": "zone,k8s_node_name",
+						},
+						"node.attr.zone": "a",
+					}},
+					PodTemplate: podTemplateWithNodeSelector("failure-domain.beta.kubernetes.io/zone", "a"),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandNodeSets(tt.nodeSets)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
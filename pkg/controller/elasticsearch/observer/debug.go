@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DebugPath is the path of the debug HTTP endpoint describing the observers.
+const DebugPath = "/debug/elasticsearch-observers"
+
+// DebugHandler returns an HTTP handler describing the observers of the given manager, as JSON. The namespace and name
+// query parameters restrict the response to the description of a single cluster.
+func DebugHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var response interface{}
+		if name := r.URL.Query().Get("name"); name != "" {
+			description, exists := m.Describe(types.NamespacedName{Namespace: r.URL.Query().Get("namespace"), Name: name})
+			if !exists {
+				http.Error(w, "cluster not observed", http.StatusNotFound)
+				return
+			}
+			response = description
+		} else {
+			clusters := m.List()
+			sort.Slice(clusters, func(i, j int) bool {
+				return clusters[i].String() < clusters[j].String()
+			})
+			descriptions := make([]Description, 0, len(clusters))
+			for _, cluster := range clusters {
+				// the observer may have been stopped in the meantime
+				if description, exists := m.Describe(cluster); exists {
+					descriptions = append(descriptions, description)
+				}
+			}
+			response = descriptions
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error(err, "Failed to write the observers description")
+		}
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	m := NewManager(DefaultSettings)
+	m.observers[cluster("b")] = &Observer{cluster: cluster("b"), interval: 10 * time.Second}
+	m.observers[cluster("a")] = &Observer{cluster: cluster("a"), interval: 10 * time.Second, consecutiveFailures: 2}
+	handler := DebugHandler(m)
+
+	// all the observers, sorted by cluster
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var descriptions []Description
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &descriptions))
+	require.Len(t, descriptions, 2)
+	require.Equal(t, cluster("a"), descriptions[0].Cluster)
+	require.Equal(t, 2, descriptions[0].ConsecutiveFailures)
+	require.Equal(t, cluster("b"), descriptions[1].Cluster)
+
+	// a single cluster
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugPath+"?namespace=ns&name=b", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var description Description
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &description))
+	require.Equal(t, cluster("b"), description.Cluster)
+	require.Equal(t, "10s", description.Interval)
+
+	// unknown cluster
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DebugPath+"?namespace=ns&name=c", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,69 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+// FailureType classifies the reason why a cluster could not be observed.
+type FailureType string
+
+const (
+	// FailureUnreachable means the cluster could not be reached over the network, or did not respond in time.
+	FailureUnreachable FailureType = "Unreachable"
+	// FailureTLS means the TLS handshake with the cluster failed, eg. because its certificate is not trusted.
+	FailureTLS FailureType = "TLSFailure"
+	// FailureUnauthorized means the cluster rejected the credentials of the operator, with a 401 or 403 response.
+	FailureUnauthorized FailureType = "Unauthorized"
+	// FailureServerError means the cluster responded with a 5xx error.
+	FailureServerError FailureType = "ServerError"
+	// FailureUnknown means the failure does not match any of the other types.
+	FailureUnknown FailureType = "Unknown"
+)
+
+// Failure describes why a cluster could not be observed.
+type Failure struct {
+	Type    FailureType `json:"type"`
+	Message string      `json:"message"`
+}
+
+// ClassifyFailure returns the failure corresponding to the given error returned by the Elasticsearch client.
+func ClassifyFailure(err error) Failure {
+	return Failure{Type: failureType(err), Message: err.Error()}
+}
+
+func failureType(err error) FailureType {
+	switch statusCode := esclient.StatusCode(err); {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return FailureUnauthorized
+	case statusCode >= http.StatusInternalServerError:
+		return FailureServerError
+	case statusCode != 0:
+		return FailureUnknown
+	}
+
+	// the errors of the HTTP client are wrapped in a url.Error
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalidCertificate x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalidCertificate) || errors.As(err, &recordHeader) {
+		return FailureTLS
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return FailureUnreachable
+	}
+	return FailureUnknown
+}
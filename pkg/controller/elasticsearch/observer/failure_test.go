@@ -0,0 +1,61 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+func healthError(t *testing.T, statusCode int) error {
+	t.Helper()
+	esClient := client.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		return client.NewMockResponse(statusCode, req, `{"error":{"reason":"denied"}}`)
+	})
+	_, err := esClient.GetClusterHealth(context.Background())
+	require.Error(t, err)
+	return err
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want FailureType
+	}{
+		{name: "401", err: healthError(t, 401), want: FailureUnauthorized},
+		{name: "403", err: healthError(t, 403), want: FailureUnauthorized},
+		{name: "503", err: healthError(t, 503), want: FailureServerError},
+		{name: "404", err: healthError(t, 404), want: FailureUnknown},
+		{
+			name: "untrusted certificate",
+			err:  &url.Error{Op: "Get", URL: "https://es:9200", Err: x509.UnknownAuthorityError{}},
+			want: FailureTLS,
+		},
+		{
+			name: "connection refused",
+			err:  &url.Error{Op: "Get", URL: "https://es:9200", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			want: FailureUnreachable,
+		},
+		{name: "timeout", err: context.DeadlineExceeded, want: FailureUnreachable},
+		{name: "other", err: errors.New("invalid character"), want: FailureUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ClassifyFailure(tt.err).Type)
+		})
+	}
+	require.Contains(t, ClassifyFailure(healthError(t, 401)).Message, "denied")
+}
@@ -6,6 +6,7 @@ package observer
 
 import (
 	"sync"
+	"time"
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"k8s.io/apimachinery/pkg/types"
@@ -17,15 +18,22 @@ type Manager struct {
 	listeners []OnObservation // invoked on each observation event
 	lock      sync.RWMutex
 	settings  Settings
+	// scheduler observes the clusters in batches if settings.BatchWorkers is set, nil otherwise
+	scheduler *BatchScheduler
 }
 
 // NewManager returns a new manager
 func NewManager(settings Settings) *Manager {
-	return &Manager{
+	m := &Manager{
 		observers: make(map[types.NamespacedName]*Observer),
 		lock:      sync.RWMutex{},
 		settings:  settings,
 	}
+	if settings.BatchWorkers > 0 {
+		m.scheduler = NewBatchScheduler(settings.BatchWorkers, settings.ObservationInterval)
+		m.scheduler.Start()
+	}
+	return m
 }
 
 // ObservedStateResolver returns the last known state of the given cluster,
@@ -53,11 +61,34 @@ func (m *Manager) Observe(cluster types.NamespacedName, esClient client.Client)
 	}
 }
 
+// SetObservationInterval changes the observation interval of the current and future observers.
+func (m *Manager) SetObservationInterval(interval time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.settings.ObservationInterval == interval {
+		return
+	}
+	m.settings.ObservationInterval = interval
+	if m.scheduler != nil {
+		m.scheduler.SetObservationInterval(interval)
+	}
+	for _, observer := range m.observers {
+		observer.SetObservationInterval(interval)
+	}
+}
+
 // createObserver creates a new observer according to the given arguments,
 // and create/replace its entry in the observers map
 func (m *Manager) createObserver(cluster types.NamespacedName, esClient client.Client) *Observer {
-	observer := NewObserver(cluster, esClient, m.settings, m.notifyListeners)
-	observer.Start()
+	m.lock.RLock()
+	settings := m.settings
+	m.lock.RUnlock()
+	observer := NewObserver(cluster, esClient, settings, m.notifyListeners)
+	if m.scheduler != nil {
+		m.scheduler.Add(observer)
+	} else {
+		observer.Start()
+	}
 	m.lock.Lock()
 	m.observers[cluster] = observer
 	m.lock.Unlock()
@@ -74,6 +105,9 @@ func (m *Manager) StopObserving(cluster types.NamespacedName) {
 		return
 	}
 	observer.Stop()
+	if m.scheduler != nil {
+		m.scheduler.Remove(cluster)
+	}
 	m.lock.Lock()
 	delete(m.observers, cluster)
 	m.lock.Unlock()
@@ -92,6 +126,17 @@ func (m *Manager) List() []types.NamespacedName {
 	return names
 }
 
+// Describe returns metadata about the observations of the given cluster, or false if it is not observed.
+func (m *Manager) Describe(cluster types.NamespacedName) (Description, bool) {
+	m.lock.RLock()
+	observer, exists := m.observers[cluster]
+	m.lock.RUnlock()
+	if !exists {
+		return Description{}, false
+	}
+	return observer.Describe(), true
+}
+
 // AddObservationListener adds the given listener to the list of listeners notified
 // on every observation.
 func (m *Manager) AddObservationListener(listener OnObservation) {
@@ -199,3 +199,48 @@ func TestManager_AddObservationListener(t *testing.T) {
 	<-eventsCluster1
 	<-eventsCluster2
 }
+
+func TestManager_SetObservationInterval(t *testing.T) {
+	m := NewManager(Settings{
+		ObservationInterval: 1 * time.Hour,
+		RequestTimeout:      1 * time.Second,
+	})
+	observations := make(chan types.NamespacedName, 10)
+	m.AddObservationListener(func(cluster types.NamespacedName, previousState State, newState State) {
+		observations <- cluster
+	})
+	obs := m.Observe(cluster("cluster"), fakeEsClient200(client.BasicAuth{}))
+	defer obs.Stop()
+	// first observation on start
+	<-observations
+
+	// the next observations happen at the new interval
+	m.SetObservationInterval(1 * time.Millisecond)
+	<-observations
+	<-observations
+	require.Equal(t, 1*time.Millisecond, m.settings.ObservationInterval)
+}
+
+func TestManager_Describe(t *testing.T) {
+	m := NewManager(DefaultSettings)
+	_, exists := m.Describe(cluster("cluster"))
+	require.False(t, exists)
+
+	observedAt := time.Now()
+	failure := &Failure{Type: FailureUnauthorized, Message: "401 Unauthorized"}
+	m.observers[cluster("cluster")] = &Observer{
+		cluster:             cluster("cluster"),
+		interval:            30 * time.Second,
+		lastState:           State{Failure: failure, ObservedAt: observedAt},
+		consecutiveFailures: 3,
+	}
+	description, exists := m.Describe(cluster("cluster"))
+	require.True(t, exists)
+	require.Equal(t, Description{
+		Cluster:             cluster("cluster"),
+		LastObservationAt:   &observedAt,
+		ConsecutiveFailures: 3,
+		LastFailure:         failure,
+		Interval:            "30s",
+	}, description)
+}
@@ -22,6 +22,14 @@ type Settings struct {
 	ObservationInterval time.Duration
 	RequestTimeout      time.Duration
 	Tracer              *apm.Tracer
+	// StatsInterval is the minimum interval between two retrievals of the nodes stats and index blocks, which are
+	// retrieved at each observation while indices are blocked.
+	StatsInterval time.Duration
+	// Probes are run at each observation in addition to the retrieval of the cluster state.
+	Probes []Probe
+	// BatchWorkers is the number of workers of the BatchScheduler observing all the clusters, or 0 to run a goroutine
+	// per observer.
+	BatchWorkers int
 }
 
 // Default values:
@@ -30,12 +38,14 @@ type Settings struct {
 const (
 	DefaultObservationInterval = 10 * time.Second
 	DefaultRequestTimeout      = 1 * time.Minute
+	DefaultStatsInterval       = 1 * time.Minute
 )
 
 // DefaultSettings is an observer's Params with default values
 var DefaultSettings = Settings{
 	ObservationInterval: DefaultObservationInterval,
 	RequestTimeout:      DefaultRequestTimeout,
+	StatsInterval:       DefaultStatsInterval,
 }
 
 // OnObservation is a function that gets executed when a new state is observed
@@ -53,24 +63,40 @@ type Observer struct {
 
 	stopChan chan struct{}
 	stopOnce sync.Once
+	// intervalUpdates receives the new observation interval when it is updated
+	intervalUpdates chan time.Duration
+	// interval is the current observation interval
+	interval time.Duration
+	// criticalIndices are the patterns of the indices whose health is retrieved at each observation
+	criticalIndices []string
+	// replication is true if the replication stats are retrieved at each observation
+	replication bool
+	// logging is true if the logging settings are retrieved at each observation
+	logging bool
+	// statsRetrievedAt is the time the nodes stats and index blocks were last retrieved
+	statsRetrievedAt time.Time
 
 	onObservation OnObservation
 
 	lastState State
-	mutex     sync.RWMutex
+	// consecutiveFailures is the number of observations in a row that could not retrieve the cluster health
+	consecutiveFailures int
+	mutex               sync.RWMutex
 }
 
 // NewObserver creates and starts an Observer
 func NewObserver(cluster types.NamespacedName, esClient client.Client, settings Settings, onObservation OnObservation) *Observer {
 	observer := Observer{
-		cluster:       cluster,
-		esClient:      esClient,
-		creationTime:  time.Now(),
-		settings:      settings,
-		stopChan:      make(chan struct{}),
-		stopOnce:      sync.Once{},
-		onObservation: onObservation,
-		mutex:         sync.RWMutex{},
+		cluster:         cluster,
+		esClient:        esClient,
+		creationTime:    time.Now(),
+		settings:        settings,
+		stopChan:        make(chan struct{}),
+		stopOnce:        sync.Once{},
+		intervalUpdates: make(chan time.Duration, 1),
+		interval:        settings.ObservationInterval,
+		onObservation:   onObservation,
+		mutex:           sync.RWMutex{},
 	}
 
 	log.Info("Creating observer for cluster", "namespace", cluster.Namespace, "es_name", cluster.Name)
@@ -93,6 +119,52 @@ func (o *Observer) Stop() {
 	})
 }
 
+// stopped returns true if the observer was stopped.
+func (o *Observer) stopped() bool {
+	select {
+	case <-o.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetObservationInterval changes the interval between two observations, starting from the next one.
+func (o *Observer) SetObservationInterval(interval time.Duration) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	// only keep the latest update if the previous one was not processed yet
+	select {
+	case <-o.intervalUpdates:
+	default:
+	}
+	o.intervalUpdates <- interval
+	o.interval = interval
+}
+
+// SetCriticalIndices changes the patterns of the indices whose health is retrieved, starting from the next observation.
+func (o *Observer) SetCriticalIndices(patterns []string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.criticalIndices = patterns
+}
+
+// SetReplicationObserved sets whether the replication stats of the follower indices are retrieved, starting from the
+// next observation. They should only be retrieved if cross-cluster replication is declared and allowed by the license.
+func (o *Observer) SetReplicationObserved(observed bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.replication = observed
+}
+
+// SetLoggingObserved sets whether the logging settings are retrieved, starting from the next observation. They should
+// only be retrieved while the operator manages the logger levels or the slow log thresholds of the cluster.
+func (o *Observer) SetLoggingObserved(observed bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.logging = observed
+}
+
 // LastState returns the last observed state
 func (o *Observer) LastState() State {
 	o.mutex.RLock()
@@ -114,11 +186,16 @@ func (o *Observer) runUntilStopped() {
 func (o *Observer) runPeriodically(ctx context.Context) {
 	o.retrieveState(ctx)
 	ticker := time.NewTicker(o.settings.ObservationInterval)
-	defer ticker.Stop()
+	defer func() {
+		ticker.Stop()
+	}()
 	for {
 		select {
 		case <-ticker.C:
 			o.retrieveState(ctx)
+		case interval := <-o.intervalUpdates:
+			ticker.Stop()
+			ticker = time.NewTicker(interval)
 		case <-ctx.Done():
 			log.Info("Stopping observer for cluster", "namespace", o.cluster.Namespace, "es_name", o.cluster.Name)
 			return
@@ -139,7 +216,27 @@ func (o *Observer) retrieveState(ctx context.Context) {
 		timeoutCtx = apm.ContextWithTransaction(timeoutCtx, tx)
 	}
 
-	newState := RetrieveState(timeoutCtx, o.cluster, o.esClient)
+	o.mutex.RLock()
+	lastState := o.lastState
+	criticalIndices := o.criticalIndices
+	retrievals := Retrievals{
+		Stats:           o.statsDue(time.Now()),
+		CCRStats:        o.replication,
+		LoggingSettings: o.logging,
+	}
+	o.mutex.RUnlock()
+
+	newState := RetrieveState(timeoutCtx, o.cluster, o.esClient, retrievals)
+	if !retrievals.Stats {
+		// reuse the stats of the last observation until the stats interval elapses
+		newState.NodesStats = lastState.NodesStats
+		newState.StatsObservedAt = lastState.StatsObservedAt
+		newState.IndexBlocks = lastState.IndexBlocks
+	}
+	newState.Probes = RunProbes(timeoutCtx, o.cluster, o.esClient, o.settings.Probes, lastState.Probes)
+	if newState.Failure == nil {
+		newState.CriticalIndices = RetrieveCriticalIndices(timeoutCtx, o.cluster, o.esClient, criticalIndices)
+	}
 
 	if o.onObservation != nil {
 		o.onObservation(o.cluster, o.LastState(), newState)
@@ -147,5 +244,56 @@ func (o *Observer) retrieveState(ctx context.Context) {
 
 	o.mutex.Lock()
 	o.lastState = newState
+	if retrievals.Stats && newState.NodesStats != nil && newState.IndexBlocks != nil {
+		o.statsRetrievedAt = newState.ObservedAt
+	}
+	if newState.Failure != nil {
+		o.consecutiveFailures++
+	} else {
+		o.consecutiveFailures = 0
+	}
 	o.mutex.Unlock()
 }
+
+// statsDue returns true if the nodes stats and index blocks must be retrieved: at most every stats interval, or at
+// each observation while indices are blocked, to release the blocks as soon as the disk pressure is relieved.
+func (o *Observer) statsDue(now time.Time) bool {
+	if o.lastState.IndexBlocks != nil && len(o.lastState.IndexBlocks.ReadOnlyAllowDelete) > 0 {
+		return true
+	}
+	return now.Sub(o.statsRetrievedAt) >= o.settings.StatsInterval
+}
+
+// Description holds metadata about the observations of a cluster, to diagnose why the operator considers it
+// unavailable.
+type Description struct {
+	Cluster types.NamespacedName `json:"cluster"`
+	// CreatedAt is the time the observer was created.
+	CreatedAt time.Time `json:"createdAt"`
+	// LastObservationAt is the time of the last observation, nil if the cluster was not observed yet.
+	LastObservationAt *time.Time `json:"lastObservationAt,omitempty"`
+	// ConsecutiveFailures is the number of the last observations in a row that failed to retrieve the cluster health.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// LastFailure is the failure of the last observation, if it failed.
+	LastFailure *Failure `json:"lastFailure,omitempty"`
+	// Interval is the current interval between two observations.
+	Interval string `json:"interval"`
+}
+
+// Describe returns metadata about the observations of the cluster.
+func (o *Observer) Describe() Description {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	description := Description{
+		Cluster:             o.cluster,
+		CreatedAt:           o.creationTime,
+		ConsecutiveFailures: o.consecutiveFailures,
+		LastFailure:         o.lastState.Failure,
+		Interval:            o.interval.String(),
+	}
+	if !o.lastState.ObservedAt.IsZero() {
+		observedAt := o.lastState.ObservedAt
+		description.LastObservationAt = &observedAt
+	}
+	return description
+}
@@ -10,6 +10,8 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -72,6 +74,85 @@ func TestObserver_retrieveState_nilFunction(t *testing.T) {
 	observer.retrieveState(context.Background())
 }
 
+func TestObserver_retrieveState_criticalIndices(t *testing.T) {
+	esClient := client.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		if req.URL.Path == "/_cat/shards/users" {
+			return client.NewMockResponse(200, req, `[{"index":"users","prirep":"p","state":"UNASSIGNED","unassigned.reason":"NODE_LEFT"}]`)
+		}
+		return client.NewMockResponse(200, req, `{}`)
+	})
+	observer := Observer{esClient: esClient, settings: Settings{RequestTimeout: time.Second}}
+
+	// no critical indices
+	observer.retrieveState(context.Background())
+	require.Nil(t, observer.LastState().CriticalIndices)
+
+	observer.SetCriticalIndices([]string{"users"})
+	observer.retrieveState(context.Background())
+	require.Equal(t, []client.IndexHealth{
+		{Index: "users", Status: "red", UnassignedReasons: []string{"NODE_LEFT"}},
+	}, observer.LastState().CriticalIndices)
+}
+
+func TestObserver_retrieveState_optionalRetrievals(t *testing.T) {
+	var mutex sync.Mutex
+	requests := map[string]int{}
+	blocked := `{}`
+	esClient := client.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/_nodes/_all/stats/"):
+			requests["nodes_stats"]++
+			return client.NewMockResponse(200, req, `{"nodes":{}}`)
+		case req.URL.Path == "/_all/_settings/"+client.ReadOnlyAllowDeleteSetting:
+			requests["index_blocks"]++
+			return client.NewMockResponse(200, req, blocked)
+		case req.URL.Path == "/_ccr/stats":
+			requests["ccr_stats"]++
+		}
+		return client.NewMockResponse(200, req, `{}`)
+	})
+	observer := Observer{esClient: esClient, settings: Settings{RequestTimeout: time.Second, StatsInterval: time.Hour}}
+	count := func(request string) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests[request]
+	}
+
+	// the stats are retrieved once per stats interval and reused in between, with the time they were retrieved at,
+	// the replication stats are not retrieved
+	observer.retrieveState(context.Background())
+	statsObservedAt := observer.LastState().StatsObservedAt
+	require.Equal(t, observer.LastState().ObservedAt, statsObservedAt)
+	for i := 0; i < 2; i++ {
+		observer.retrieveState(context.Background())
+		require.NotNil(t, observer.LastState().NodesStats)
+		require.NotNil(t, observer.LastState().IndexBlocks)
+		require.Equal(t, statsObservedAt, observer.LastState().StatsObservedAt)
+		require.True(t, observer.LastState().ObservedAt.After(statsObservedAt))
+	}
+	require.Equal(t, 1, count("nodes_stats"))
+	require.Equal(t, 1, count("index_blocks"))
+	require.Equal(t, 0, count("ccr_stats"))
+
+	// the replication stats are retrieved once replication is observed
+	observer.SetReplicationObserved(true)
+	observer.retrieveState(context.Background())
+	require.Equal(t, 1, count("ccr_stats"))
+
+	// the stats are retrieved at each observation while indices are blocked
+	observer.statsRetrievedAt = time.Time{}
+	mutex.Lock()
+	blocked = `{"logs":{"settings":{"index.blocks.read_only_allow_delete":"true"}}}`
+	mutex.Unlock()
+	observer.retrieveState(context.Background())
+	observer.retrieveState(context.Background())
+	require.Equal(t, []string{"logs"}, observer.LastState().IndexBlocks.ReadOnlyAllowDelete)
+	require.Equal(t, 3, count("nodes_stats"))
+	require.Equal(t, 3, count("index_blocks"))
+}
+
 func TestNewObserver(t *testing.T) {
 	events := make(chan types.NamespacedName)
 	onObservation := func(cluster types.NamespacedName, previousState State, newState State) {
@@ -108,3 +189,30 @@ func TestObserver_Stop(t *testing.T) {
 		return nil
 	})
 }
+
+func TestObserver_retrieveState_consecutiveFailures(t *testing.T) {
+	healthy := true
+	esClient := client.NewMockClient(version.MustParse("6.8.0"), func(req *http.Request) *http.Response {
+		if healthy {
+			return client.NewMockResponse(200, req, fixtures.HealthSample)
+		}
+		return client.NewMockResponse(503, req, "")
+	})
+	observer := Observer{cluster: cluster("cluster"), esClient: esClient}
+	require.Nil(t, observer.Describe().LastObservationAt)
+
+	healthy = false
+	observer.retrieveState(context.Background())
+	observer.retrieveState(context.Background())
+	description := observer.Describe()
+	require.Equal(t, 2, description.ConsecutiveFailures)
+	require.NotNil(t, description.LastFailure)
+	require.Equal(t, FailureServerError, description.LastFailure.Type)
+	require.NotNil(t, description.LastObservationAt)
+
+	healthy = true
+	observer.retrieveState(context.Background())
+	description = observer.Describe()
+	require.Equal(t, 0, description.ConsecutiveFailures)
+	require.Nil(t, description.LastFailure)
+}
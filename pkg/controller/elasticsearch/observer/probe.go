@@ -0,0 +1,208 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+const (
+	// PendingTasksProbeName is the name of the probe checking the depth of the cluster pending tasks queue.
+	PendingTasksProbeName = "pending-tasks"
+	// CircuitBreakerTripsProbeName is the name of the probe checking the circuit breakers tripped between two
+	// observations.
+	CircuitBreakerTripsProbeName = "circuit-breaker-trips"
+
+	// DefaultMaxPendingTasks is the number of pending tasks above which the pending tasks probe fails, if not specified.
+	DefaultMaxPendingTasks = 100
+	// DefaultMaxCircuitBreakerTrips is the number of trips between two observations above which the circuit breaker
+	// trips probe fails, if not specified.
+	DefaultMaxCircuitBreakerTrips = 0
+)
+
+// Probe checks an aspect of the health of an Elasticsearch cluster not covered by the cluster health, at each
+// observation.
+type Probe interface {
+	// Name identifies the probe in the observed state.
+	Name() string
+	// Run requests the cluster and returns the result of the probe. previous is the result of the previous
+	// observation, or nil.
+	Run(ctx context.Context, esClient esclient.Client, previous *ProbeResult) (ProbeResult, error)
+}
+
+// ProbeResult is the outcome of a Probe.
+type ProbeResult struct {
+	// Value is the value measured by the probe.
+	Value int64 `json:"value"`
+	// Healthy is false if the value exceeds the threshold of the probe.
+	Healthy bool `json:"healthy"`
+	// Message describes the result.
+	Message string `json:"message,omitempty"`
+}
+
+// PendingTasksProbe fails if the cluster-level changes waiting for the master node exceed a maximum.
+type PendingTasksProbe struct {
+	MaxPendingTasks int64
+}
+
+var _ Probe = PendingTasksProbe{}
+
+// Name implements Probe.
+func (p PendingTasksProbe) Name() string {
+	return PendingTasksProbeName
+}
+
+// Run implements Probe.
+func (p PendingTasksProbe) Run(ctx context.Context, esClient esclient.Client, _ *ProbeResult) (ProbeResult, error) {
+	pendingTasks, err := esClient.GetPendingTasks(ctx)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	depth := int64(len(pendingTasks.Tasks))
+	return ProbeResult{
+		Value:   depth,
+		Healthy: depth <= p.MaxPendingTasks,
+		Message: fmt.Sprintf("%d pending tasks, %d allowed", depth, p.MaxPendingTasks),
+	}, nil
+}
+
+// CircuitBreakerTripsProbe fails if the circuit breakers of the nodes tripped more than a maximum number of times
+// since the previous observation.
+type CircuitBreakerTripsProbe struct {
+	MaxTrips int64
+}
+
+var _ Probe = CircuitBreakerTripsProbe{}
+
+// Name implements Probe.
+func (p CircuitBreakerTripsProbe) Name() string {
+	return CircuitBreakerTripsProbeName
+}
+
+// Run implements Probe. The value of the result is the number of trips since the nodes started.
+func (p CircuitBreakerTripsProbe) Run(ctx context.Context, esClient esclient.Client, previous *ProbeResult) (ProbeResult, error) {
+	stats, err := esClient.GetCircuitBreakersStats(ctx)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	var total int64
+	for _, node := range stats.Nodes {
+		for _, breaker := range node.Breakers {
+			total += breaker.Tripped
+		}
+	}
+	var trips int64
+	// the counters are reset when the nodes restart: only count the trips if the total increased
+	if previous != nil && total > previous.Value {
+		trips = total - previous.Value
+	}
+	return ProbeResult{
+		Value:   total,
+		Healthy: trips <= p.MaxTrips,
+		Message: fmt.Sprintf("%d circuit breaker trips since the previous observation, %d allowed", trips, p.MaxTrips),
+	}, nil
+}
+
+// ParseProbes returns the built-in probes described by the given specs, of the form <name>[=<max>].
+func ParseProbes(specs []string) ([]Probe, error) {
+	probes := make([]Probe, 0, len(specs))
+	for _, spec := range specs {
+		name, maxValue, err := parseProbeSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case PendingTasksProbeName:
+			if maxValue < 0 {
+				maxValue = DefaultMaxPendingTasks
+			}
+			probes = append(probes, PendingTasksProbe{MaxPendingTasks: maxValue})
+		case CircuitBreakerTripsProbeName:
+			if maxValue < 0 {
+				maxValue = DefaultMaxCircuitBreakerTrips
+			}
+			probes = append(probes, CircuitBreakerTripsProbe{MaxTrips: maxValue})
+		default:
+			return nil, errors.Errorf("unknown observer probe %s, must be one of %s, %s", name, PendingTasksProbeName, CircuitBreakerTripsProbeName)
+		}
+	}
+	return probes, nil
+}
+
+// parseProbeSpec returns the name and the maximum of the given probe spec, or -1 if the maximum is not specified.
+func parseProbeSpec(spec string) (string, int64, error) {
+	parts := strings.SplitN(strings.TrimSpace(spec), "=", 2)
+	if len(parts) == 1 {
+		return parts[0], -1, nil
+	}
+	maxValue, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || maxValue < 0 {
+		return "", 0, errors.Errorf("invalid maximum %s for observer probe %s, must be a positive integer", parts[1], parts[0])
+	}
+	return parts[0], maxValue, nil
+}
+
+// RunProbes runs the given probes in parallel and returns their results by name, given the results of the previous
+// observation. The probes that cannot be run are absent from the results.
+func RunProbes(
+	ctx context.Context,
+	cluster types.NamespacedName,
+	esClient esclient.Client,
+	probes []Probe,
+	previous map[string]ProbeResult,
+) map[string]ProbeResult {
+	if len(probes) == 0 {
+		return nil
+	}
+	type namedResult struct {
+		name   string
+		result *ProbeResult
+	}
+	resultsChan := make(chan namedResult, len(probes))
+	for _, probe := range probes {
+		go func(probe Probe) {
+			var prev *ProbeResult
+			if result, exists := previous[probe.Name()]; exists {
+				prev = &result
+			}
+			result, err := probe.Run(ctx, esClient, prev)
+			if err != nil {
+				log.V(1).Info("Unable to run observer probe", "probe", probe.Name(), "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+				resultsChan <- namedResult{name: probe.Name()}
+				return
+			}
+			resultsChan <- namedResult{name: probe.Name(), result: &result}
+		}(probe)
+	}
+	results := make(map[string]ProbeResult, len(probes))
+	for range probes {
+		r := <-resultsChan
+		if r.result != nil {
+			results[r.name] = *r.result
+		}
+	}
+	return results
+}
+
+// FailedProbes returns the sorted names of the probes that failed in this state.
+func (s State) FailedProbes() []string {
+	var failed []string
+	for name, result := range s.Probes {
+		if !result.Healthy {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
@@ -0,0 +1,95 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+func fakeProbesClient(pendingTasks, breakers string) client.Client {
+	return client.NewMockClient(version.MustParse("7.6.0"), func(req *http.Request) *http.Response {
+		switch req.URL.Path {
+		case "/_cluster/pending_tasks":
+			return client.NewMockResponse(200, req, pendingTasks)
+		case "/_nodes/_all/stats/breaker":
+			return client.NewMockResponse(200, req, breakers)
+		default:
+			return client.NewMockResponse(500, req, "")
+		}
+	})
+}
+
+func TestParseProbes(t *testing.T) {
+	probes, err := ParseProbes([]string{"pending-tasks", "circuit-breaker-trips=3"})
+	require.NoError(t, err)
+	require.Equal(t, []Probe{
+		PendingTasksProbe{MaxPendingTasks: DefaultMaxPendingTasks},
+		CircuitBreakerTripsProbe{MaxTrips: 3},
+	}, probes)
+
+	for _, invalid := range []string{"unknown", "pending-tasks=-1", "pending-tasks=many"} {
+		_, err := ParseProbes([]string{invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestPendingTasksProbe_Run(t *testing.T) {
+	esClient := fakeProbesClient(`{"tasks":[{"insert_order":1},{"insert_order":2},{"insert_order":3}]}`, "")
+	result, err := PendingTasksProbe{MaxPendingTasks: 3}.Run(context.Background(), esClient, nil)
+	require.NoError(t, err)
+	require.Equal(t, ProbeResult{Value: 3, Healthy: true, Message: "3 pending tasks, 3 allowed"}, result)
+
+	result, err = PendingTasksProbe{MaxPendingTasks: 2}.Run(context.Background(), esClient, nil)
+	require.NoError(t, err)
+	require.False(t, result.Healthy)
+}
+
+func TestCircuitBreakerTripsProbe_Run(t *testing.T) {
+	esClient := fakeProbesClient("", `{"nodes":{"a":{"breakers":{"request":{"tripped":2},"parent":{"tripped":1}}},"b":{"breakers":{"fielddata":{"tripped":2}}}}}`)
+	probe := CircuitBreakerTripsProbe{MaxTrips: 1}
+	tests := []struct {
+		name        string
+		previous    *ProbeResult
+		wantHealthy bool
+	}{
+		{name: "first observation", previous: nil, wantHealthy: true},
+		{name: "no new trips", previous: &ProbeResult{Value: 5}, wantHealthy: true},
+		{name: "trips below the maximum", previous: &ProbeResult{Value: 4}, wantHealthy: true},
+		{name: "trips above the maximum", previous: &ProbeResult{Value: 2}, wantHealthy: false},
+		{name: "nodes restarted", previous: &ProbeResult{Value: 20}, wantHealthy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := probe.Run(context.Background(), esClient, tt.previous)
+			require.NoError(t, err)
+			require.Equal(t, int64(5), result.Value)
+			require.Equal(t, tt.wantHealthy, result.Healthy)
+		})
+	}
+}
+
+func TestRunProbes(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns", Name: "es"}
+	require.Nil(t, RunProbes(context.Background(), cluster, fakeProbesClient("", ""), nil, nil))
+
+	// the circuit breakers stats cannot be retrieved
+	esClient := fakeProbesClient(`{"tasks":[{"insert_order":1}]}`, "not json")
+	results := RunProbes(context.Background(), cluster, esClient, []Probe{
+		PendingTasksProbe{MaxPendingTasks: 0},
+		CircuitBreakerTripsProbe{MaxTrips: 0},
+	}, nil)
+	require.Equal(t, map[string]ProbeResult{
+		PendingTasksProbeName: {Value: 1, Healthy: false, Message: "1 pending tasks, 0 allowed"},
+	}, results)
+	require.Equal(t, []string{PendingTasksProbeName}, State{Probes: results}.FailedProbes())
+}
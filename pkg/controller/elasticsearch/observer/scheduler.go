@@ -0,0 +1,196 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// BatchScheduler observes the clusters of its observers in batches processed by a fixed pool of workers, at each
+// tick of a single timer, instead of running a goroutine and a timer per observer. The state of a cluster is
+// retrieved at most once per interval: a tick is skipped while the batches of the previous one are still processed.
+type BatchScheduler struct {
+	workers int
+
+	mutex     sync.Mutex
+	observers map[types.NamespacedName]*Observer
+	// pending are the observers added since the last dispatch, observed as soon as possible
+	pending  []*Observer
+	interval time.Duration
+
+	batches chan []*Observer
+	// inFlight is the number of dispatched batches not processed yet
+	inFlight int32
+	// wake is notified when observers are added
+	wake chan struct{}
+	// intervalUpdates receives the new observation interval when it is updated
+	intervalUpdates chan time.Duration
+	stopChan        chan struct{}
+	stopOnce        sync.Once
+}
+
+// NewBatchScheduler returns a BatchScheduler observing the clusters at the given interval with the given number of
+// workers.
+func NewBatchScheduler(workers int, interval time.Duration) *BatchScheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &BatchScheduler{
+		workers:         workers,
+		observers:       make(map[types.NamespacedName]*Observer),
+		interval:        interval,
+		batches:         make(chan []*Observer, workers),
+		wake:            make(chan struct{}, 1),
+		intervalUpdates: make(chan time.Duration, 1),
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start the workers and the scheduling loop in separate goroutines.
+func (s *BatchScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < s.workers; i++ {
+		go s.work(ctx)
+	}
+	go func() {
+		defer cancel()
+		s.run(ctx)
+	}()
+}
+
+// Stop the scheduling loop and the workers.
+func (s *BatchScheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+// Add schedules the observations of the given observer, starting with an immediate one.
+func (s *BatchScheduler) Add(observer *Observer) {
+	s.mutex.Lock()
+	s.observers[observer.cluster] = observer
+	s.pending = append(s.pending, observer)
+	s.mutex.Unlock()
+	// do not block if a wake up is already pending
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Remove stops scheduling the observations of the given cluster.
+func (s *BatchScheduler) Remove(cluster types.NamespacedName) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.observers, cluster)
+}
+
+// SetObservationInterval changes the interval between two observations, starting from the next one.
+func (s *BatchScheduler) SetObservationInterval(interval time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// only keep the latest update if the previous one was not processed yet
+	select {
+	case <-s.intervalUpdates:
+	default:
+	}
+	s.intervalUpdates <- interval
+	s.interval = interval
+}
+
+// run dispatches the batches of observers to the workers, until stopped.
+func (s *BatchScheduler) run(ctx context.Context) {
+	s.mutex.Lock()
+	ticker := time.NewTicker(s.interval)
+	s.mutex.Unlock()
+	defer func() {
+		ticker.Stop()
+	}()
+	for {
+		select {
+		case <-s.wake:
+			s.mutex.Lock()
+			pending := s.pending
+			s.pending = nil
+			s.mutex.Unlock()
+			s.dispatch(ctx, pending)
+		case <-ticker.C:
+			if atomic.LoadInt32(&s.inFlight) > 0 {
+				log.V(1).Info("Skipping observations, the previous ones are still in progress", "batches", atomic.LoadInt32(&s.inFlight))
+				continue
+			}
+			s.dispatch(ctx, s.list())
+		case interval := <-s.intervalUpdates:
+			ticker.Stop()
+			ticker = time.NewTicker(interval)
+		case <-s.stopChan:
+			log.Info("Stopping observation scheduler")
+			return
+		}
+	}
+}
+
+// list returns the observers currently scheduled.
+func (s *BatchScheduler) list() []*Observer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	observers := make([]*Observer, 0, len(s.observers))
+	for _, observer := range s.observers {
+		observers = append(observers, observer)
+	}
+	return observers
+}
+
+// dispatch splits the given observers in a batch per worker, and queues them.
+func (s *BatchScheduler) dispatch(ctx context.Context, observers []*Observer) {
+	for _, batch := range splitInBatches(observers, s.workers) {
+		atomic.AddInt32(&s.inFlight, 1)
+		select {
+		case s.batches <- batch:
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// work observes the clusters of the queued batches one after the other, until the given context is cancelled.
+func (s *BatchScheduler) work(ctx context.Context) {
+	for {
+		select {
+		case batch := <-s.batches:
+			for _, observer := range batch {
+				if observer.stopped() {
+					continue
+				}
+				observer.retrieveState(ctx)
+			}
+			atomic.AddInt32(&s.inFlight, -1)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// splitInBatches splits the given observers in at most n batches of similar sizes.
+func splitInBatches(observers []*Observer, n int) [][]*Observer {
+	if len(observers) == 0 {
+		return nil
+	}
+	if n > len(observers) {
+		n = len(observers)
+	}
+	batches := make([][]*Observer, n)
+	for i, observer := range observers {
+		batches[i%n] = append(batches[i%n], observer)
+	}
+	return batches
+}
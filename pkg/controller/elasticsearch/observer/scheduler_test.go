@@ -0,0 +1,56 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package observer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+func Test_splitInBatches(t *testing.T) {
+	observers := []*Observer{{cluster: cluster("a")}, {cluster: cluster("b")}, {cluster: cluster("c")}}
+	require.Nil(t, splitInBatches(nil, 2))
+	require.Equal(t, [][]*Observer{{observers[0], observers[2]}, {observers[1]}}, splitInBatches(observers, 2))
+	// no empty batch
+	require.Len(t, splitInBatches(observers, 10), 3)
+}
+
+func TestManager_BatchWorkers(t *testing.T) {
+	m := NewManager(Settings{
+		ObservationInterval: 1 * time.Hour,
+		RequestTimeout:      1 * time.Second,
+		BatchWorkers:        2,
+	})
+	defer m.scheduler.Stop()
+	observations := make(chan types.NamespacedName, 100)
+	m.AddObservationListener(func(cluster types.NamespacedName, previousState State, newState State) {
+		observations <- cluster
+	})
+
+	// the clusters are observed as soon as they are added
+	for _, name := range []string{"a", "b", "c"} {
+		m.Observe(cluster(name), fakeEsClient200(client.BasicAuth{}))
+	}
+	var observed []types.NamespacedName
+	for range []string{"a", "b", "c"} {
+		observed = append(observed, <-observations)
+	}
+	require.ElementsMatch(t, []types.NamespacedName{cluster("a"), cluster("b"), cluster("c")}, observed)
+	description, exists := m.Describe(cluster("a"))
+	require.True(t, exists)
+	require.NotNil(t, description.LastObservationAt)
+
+	// then at each interval, except the ones no longer observed
+	m.StopObserving(cluster("c"))
+	m.SetObservationInterval(1 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		require.NotEqual(t, cluster("c"), <-observations)
+	}
+}
@@ -6,6 +6,7 @@ package observer
 
 import (
 	"context"
+	"time"
 
 	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"k8s.io/apimachinery/pkg/types"
@@ -19,18 +20,61 @@ type State struct {
 	// TODO should probably be a separate observer
 	// ClusterLicense is the current license applied to this cluster
 	ClusterLicense *esclient.License
+	// NodesStats holds the disk and JVM usage of the nodes of the cluster.
+	NodesStats *esclient.NodesStats
+	// CapacitySettings holds the effective disk watermarks and shards limit of the cluster.
+	CapacitySettings *esclient.CapacitySettings
+	// CCRStats holds the replication stats of the follower indices of the cluster.
+	CCRStats *esclient.CCRStats
+	// LoggingSettings holds the logger levels and slow log thresholds set in the cluster.
+	LoggingSettings *esclient.LoggingSettings
+	// IndexBlocks holds the indices blocked as read-only by the flood-stage disk watermark.
+	IndexBlocks *esclient.IndexBlocks
+	// CriticalIndices holds the health of the indices matching the critical indices patterns of the cluster, nil if
+	// there are none or if it could not be retrieved.
+	CriticalIndices []esclient.IndexHealth
+	// Failure classifies the reason why the cluster health could not be retrieved, nil if it was.
+	Failure *Failure
+	// Probes holds the results of the additional probes of the observer, by probe name.
+	Probes map[string]ProbeResult
+	// ObservedAt is the time the state was retrieved.
+	ObservedAt time.Time
+	// StatsObservedAt is the time the nodes stats were retrieved, before ObservedAt if they are reused from a previous
+	// observation. Rates must be computed from it.
+	StatsObservedAt time.Time
+}
+
+// Retrievals selects the optional parts of the cluster state retrieved in addition to the cluster health, license
+// and capacity settings.
+type Retrievals struct {
+	// Stats retrieves the nodes stats and the index blocks, used for the reports and the handling of disk pressure.
+	Stats bool
+	// CCRStats retrieves the replication stats of the follower indices.
+	CCRStats bool
+	// LoggingSettings retrieves the logger levels and the slow log thresholds of the indices.
+	LoggingSettings bool
 }
 
 // RetrieveState returns the current Elasticsearch cluster state
-func RetrieveState(ctx context.Context, cluster types.NamespacedName, esClient esclient.Client) State {
-	// retrieve cluster health and license in parallel
+func RetrieveState(ctx context.Context, cluster types.NamespacedName, esClient esclient.Client, retrievals Retrievals) State {
+	// retrieve cluster health, license, nodes stats, capacity settings, replication stats, logging settings and index blocks
+	// in parallel
 	healthChan := make(chan *esclient.Health)
 	licenseChan := make(chan *esclient.License)
+	nodesStatsChan := make(chan *esclient.NodesStats)
+	capacitySettingsChan := make(chan *esclient.CapacitySettings)
+	ccrStatsChan := make(chan *esclient.CCRStats)
+	loggingSettingsChan := make(chan *esclient.LoggingSettings)
+	indexBlocksChan := make(chan *esclient.IndexBlocks)
 
+	// set before the health is sent to the channel
+	var failure *Failure
 	go func() {
 		health, err := esClient.GetClusterHealth(ctx)
 		if err != nil {
-			log.V(1).Info("Unable to retrieve cluster health", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+			f := ClassifyFailure(err)
+			failure = &f
+			log.V(1).Info("Unable to retrieve cluster health", "error", err, "failure", failure.Type, "namespace", cluster.Namespace, "es_name", cluster.Name)
 			healthChan <- nil
 			return
 		}
@@ -47,9 +91,102 @@ func RetrieveState(ctx context.Context, cluster types.NamespacedName, esClient e
 		licenseChan <- &license
 	}()
 
+	go func() {
+		if !retrievals.Stats {
+			nodesStatsChan <- nil
+			return
+		}
+		nodesStats, err := esClient.GetNodesStats(ctx)
+		if err != nil {
+			log.V(1).Info("Unable to retrieve nodes stats", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+			nodesStatsChan <- nil
+			return
+		}
+		nodesStatsChan <- &nodesStats
+	}()
+
+	go func() {
+		capacitySettings, err := esClient.GetCapacitySettings(ctx)
+		if err != nil {
+			log.V(1).Info("Unable to retrieve capacity settings", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+			capacitySettingsChan <- nil
+			return
+		}
+		capacitySettingsChan <- &capacitySettings
+	}()
+
+	go func() {
+		if !retrievals.CCRStats {
+			ccrStatsChan <- nil
+			return
+		}
+		ccrStats, err := esClient.GetCCRStats(ctx)
+		if err != nil {
+			// expected if the license does not allow cross-cluster replication
+			log.V(1).Info("Unable to retrieve replication stats", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+			ccrStatsChan <- nil
+			return
+		}
+		ccrStatsChan <- &ccrStats
+	}()
+
+	go func() {
+		if !retrievals.LoggingSettings {
+			loggingSettingsChan <- nil
+			return
+		}
+		loggingSettings, err := esClient.GetLoggingSettings(ctx)
+		if err != nil {
+			log.V(1).Info("Unable to retrieve logging settings", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+			loggingSettingsChan <- nil
+			return
+		}
+		loggingSettingsChan <- &loggingSettings
+	}()
+
+	go func() {
+		if !retrievals.Stats {
+			indexBlocksChan <- nil
+			return
+		}
+		indexBlocks, err := esClient.GetIndexBlocks(ctx)
+		if err != nil {
+			log.V(1).Info("Unable to retrieve index blocks", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+			indexBlocksChan <- nil
+			return
+		}
+		indexBlocksChan <- &indexBlocks
+	}()
+
 	// return the state when ready, may contain nil values
-	return State{
-		ClusterHealth:  <-healthChan,
-		ClusterLicense: <-licenseChan,
+	health := <-healthChan
+	state := State{
+		ClusterHealth:    health,
+		Failure:          failure,
+		ClusterLicense:   <-licenseChan,
+		NodesStats:       <-nodesStatsChan,
+		CapacitySettings: <-capacitySettingsChan,
+		CCRStats:         <-ccrStatsChan,
+		LoggingSettings:  <-loggingSettingsChan,
+		IndexBlocks:      <-indexBlocksChan,
+		ObservedAt:       time.Now(),
+	}
+	if state.NodesStats != nil {
+		state.StatsObservedAt = state.ObservedAt
+	}
+	return state
+}
+
+// RetrieveCriticalIndices returns the health of the indices matching the given patterns, or nil if there are no
+// patterns or if it could not be retrieved.
+func RetrieveCriticalIndices(ctx context.Context, cluster types.NamespacedName, esClient esclient.Client, patterns []string) []esclient.IndexHealth {
+	if len(patterns) == 0 {
+		return nil
+	}
+	indices, err := esClient.GetIndicesHealth(ctx, patterns)
+	if err != nil {
+		log.V(1).Info("Unable to retrieve the health of critical indices", "error", err, "namespace", cluster.Namespace, "es_name", cluster.Name)
+		return nil
 	}
+	return indices
 }
@@ -40,6 +40,18 @@ func fakeEsClient(healthRespErr, licenseRespErr bool) client.Client {
 
 		}
 
+		if strings.Contains(req.URL.RequestURI(), "_nodes") {
+			respBody = ioutil.NopCloser(bytes.NewBufferString(fixtures.NodesStatsSample))
+		}
+
+		if strings.Contains(req.URL.RequestURI(), "_cluster/settings") {
+			respBody = ioutil.NopCloser(bytes.NewBufferString(fixtures.CapacitySettingsSample))
+		}
+
+		if strings.Contains(req.URL.RequestURI(), "_all/_settings") {
+			respBody = ioutil.NopCloser(bytes.NewBufferString("{}"))
+		}
+
 		return &http.Response{
 			StatusCode: statusCode,
 			Body:       respBody,
@@ -91,7 +103,7 @@ func TestRetrieveState(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			cluster := types.NamespacedName{Namespace: "ns1", Name: "es1"}
 			esClient := fakeEsClient(!tt.wantHealth, !tt.wantLicense)
-			state := RetrieveState(context.Background(), cluster, esClient)
+			state := RetrieveState(context.Background(), cluster, esClient, Retrievals{Stats: true, CCRStats: true})
 			if tt.wantHealth {
 				require.NotNil(t, state.ClusterHealth)
 				require.Equal(t, 3, state.ClusterHealth.NumberOfNodes)
@@ -100,6 +112,19 @@ func TestRetrieveState(t *testing.T) {
 				require.NotNil(t, state.ClusterLicense)
 				require.Equal(t, "893361dc-9749-4997-93cb-802e3d7fa4xx", state.ClusterLicense.UID)
 			}
+			require.NotNil(t, state.NodesStats)
+			require.Len(t, state.NodesStats.Nodes, 1)
+			require.NotNil(t, state.CapacitySettings)
+			require.Equal(t, "1000", state.CapacitySettings.MaxShardsPerNode)
 		})
 	}
 }
+
+func TestRetrieveState_LoggingSettings(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns1", Name: "es1"}
+	// the logging settings are only retrieved when requested
+	state := RetrieveState(context.Background(), cluster, fakeEsClient(false, false), Retrievals{})
+	require.Nil(t, state.LoggingSettings)
+	state = RetrieveState(context.Background(), cluster, fakeEsClient(false, false), Retrievals{LoggingSettings: true})
+	require.NotNil(t, state.LoggingSettings)
+}
@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/placement"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// watchPlacementPolicies triggers the reconciliation of all the Elasticsearch clusters when the ConfigMaps holding
+// the placement policies in the operator namespace change, for their Pods to be rendered with the new policies.
+func watchPlacementPolicies(c controller.Controller, k8sClient k8s.Client, operatorNamespace string) error {
+	isPolicies := func(meta metav1.Object) bool {
+		return meta.GetNamespace() == operatorNamespace && meta.GetLabels()[common.TypeLabelName] == placement.ConfigMapType
+	}
+	return c.Watch(
+		&source.Kind{Type: &corev1.ConfigMap{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(func(handler.MapObject) []reconcile.Request {
+				return allClusters(k8sClient)
+			}),
+		},
+		predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return isPolicies(e.Meta) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return isPolicies(e.Meta) },
+			GenericFunc: func(e event.GenericEvent) bool { return isPolicies(e.Meta) },
+			// also catch the ConfigMaps that stop holding placement policies
+			UpdateFunc: func(e event.UpdateEvent) bool { return isPolicies(e.MetaOld) || isPolicies(e.MetaNew) },
+		},
+	)
+}
+
+// allClusters returns the requests to reconcile all the Elasticsearch clusters.
+func allClusters(k8sClient k8s.Client) []reconcile.Request {
+	var clusters esv1.ElasticsearchList
+	if err := k8sClient.List(&clusters); err != nil {
+		log.Error(err, "Failed to list the Elasticsearch clusters to apply the placement policies to")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(clusters.Items))
+	for _, es := range clusters.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: es.Namespace, Name: es.Name}})
+	}
+	return requests
+}
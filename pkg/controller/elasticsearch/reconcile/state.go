@@ -9,6 +9,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
@@ -112,6 +113,104 @@ func (s *State) Apply() ([]events.Event, *esv1.Elasticsearch) {
 	return s.Events(), &s.cluster
 }
 
+// ImageDigests returns the digests the images of the nodes are pinned to.
+func (s *State) ImageDigests() []esv1.ImageDigest {
+	return s.status.ImageDigests
+}
+
+// UpdateImageDigests records the digests the images of the nodes are pinned to in the resource status.
+func (s *State) UpdateImageDigests(digests []esv1.ImageDigest) *State {
+	s.status.ImageDigests = digests
+	return s
+}
+
+// Logging returns the logging settings applied at runtime, as reported in the resource status.
+func (s *State) Logging() *esv1.LoggingStatus {
+	return s.status.Logging
+}
+
+// UpdateLogging records the logging settings applied at runtime in the resource status.
+func (s *State) UpdateLogging(status *esv1.LoggingStatus) *State {
+	s.status.Logging = status
+	return s
+}
+
+// RuntimeSettings returns the names of the cluster settings applied from the runtime configuration.
+func (s *State) RuntimeSettings() []string {
+	return s.status.RuntimeSettings
+}
+
+// UpdateRuntimeSettings records the names of the cluster settings applied from the runtime configuration.
+func (s *State) UpdateRuntimeSettings(settings []string) *State {
+	s.status.RuntimeSettings = settings
+	return s
+}
+
+// ReadOnlyIndices returns the number of indices made read-only by the flood-stage disk watermark, as reported in the
+// resource status.
+func (s *State) ReadOnlyIndices() int {
+	return s.status.ReadOnlyIndices
+}
+
+// UpdateReadOnlyIndices records the number of indices made read-only by the flood-stage disk watermark in the resource
+// status.
+func (s *State) UpdateReadOnlyIndices(count int) *State {
+	s.status.ReadOnlyIndices = count
+	return s
+}
+
+// DataMigration returns the progress of the data migration away from the nodes being removed, as reported in the
+// resource status.
+func (s *State) DataMigration() []esv1.NodeDataMigration {
+	return s.status.DataMigration
+}
+
+// UpdateDataMigration records the progress of the data migration away from the nodes being removed in the resource
+// status.
+func (s *State) UpdateDataMigration(status []esv1.NodeDataMigration) *State {
+	s.status.DataMigration = status
+	return s
+}
+
+// SuspendedPods returns the state of the Pods to suspend, as reported in the resource status.
+func (s *State) SuspendedPods() []esv1.SuspendedPodStatus {
+	return s.status.SuspendedPods
+}
+
+// UpdateSuspendedPods records the state of the Pods to suspend in the resource status.
+func (s *State) UpdateSuspendedPods(status []esv1.SuspendedPodStatus) *State {
+	s.status.SuspendedPods = status
+	return s
+}
+
+// RetentionJobs returns the last run of the retention jobs, as reported in the resource status.
+func (s *State) RetentionJobs() []esv1.RetentionJobStatus {
+	return s.status.RetentionJobs
+}
+
+// UpdateRetentionJobs records the last run of the retention jobs in the resource status.
+func (s *State) UpdateRetentionJobs(status []esv1.RetentionJobStatus) *State {
+	s.status.RetentionJobs = status
+	return s
+}
+
+// Conditions returns the conditions reported in the resource status.
+func (s *State) Conditions() commonv1.Conditions {
+	return s.status.Conditions
+}
+
+// UpdateCondition records the given condition in the resource status, replacing the condition of the same type.
+func (s *State) UpdateCondition(condition commonv1.Condition) *State {
+	s.status.Conditions = s.status.Conditions.MergeWith(condition)
+	return s
+}
+
+// RemoveCondition removes the condition of the given type from the resource status.
+func (s *State) RemoveCondition(t commonv1.ConditionType) *State {
+	s.status.Conditions = s.status.Conditions.Remove(t)
+	return s
+}
+
 func (s *State) UpdateElasticsearchInvalid(err error) {
 	s.status.Phase = esv1.ElasticsearchResourceInvalid
 	s.AddEvent(corev1.EventTypeWarning, events.EventReasonValidation, err.Error())
@@ -21,6 +21,10 @@ type expectedRemoteClusterConfiguration struct {
 
 	// ConfigHash is the hash of the remote cluster configuration. It is used to detect when the settings must be updated.
 	ConfigHash string `json:"configHash"`
+
+	// TransportAddress is the transport address of a remote cluster running in another Kubernetes cluster of the
+	// operator federation, empty otherwise.
+	TransportAddress string `json:"transportAddress,omitempty"`
 }
 
 // getCurrentRemoteClusters returns a map with the current configuration hash of the remote clusters declared in Elasticsearch.
@@ -44,7 +48,7 @@ func annotateWithRemoteClusters(c k8s.Client, es esv1.Elasticsearch, remoteClust
 	remoteClusterConfigurations := make(map[string]string, len(remoteClusters))
 	for _, remoteCluster := range remoteClusters {
 		// remoteCluster.Name is set by the user, it is supposed to be unique
-		remoteClusterConfigurations[remoteCluster.Name] = remoteCluster.RemoteCluster.ConfigHash()
+		remoteClusterConfigurations[remoteCluster.Name] = remoteCluster.ConfigHash
 	}
 
 	// serialize the remote clusters list and update the object
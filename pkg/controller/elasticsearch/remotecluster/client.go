@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package remotecluster
+
+import (
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	certhttp "github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+)
+
+// NewESClient returns a client for the given Elasticsearch cluster, authenticated as the controller user. It is used
+// to call the API of a remote cluster, or of any cluster not being reconciled by the caller.
+func NewESClient(c k8s.Client, dialer net.Dialer, es esv1.Elasticsearch) (esclient.Client, error) {
+	v, err := version.Parse(es.Spec.Version)
+	if err != nil {
+		return nil, err
+	}
+	var usersSecret corev1.Secret
+	usersSecretRef := types.NamespacedName{Namespace: es.Namespace, Name: esv1.InternalUsersSecret(es.Name)}
+	if err := c.Get(usersSecretRef, &usersSecret); err != nil {
+		return nil, errors.Wrap(err, "while retrieving the internal users")
+	}
+	auth, err := user.ControllerCredentials(usersSecret.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "in secret %s", usersSecret.Name)
+	}
+	var caCerts []*x509.Certificate
+	if es.Spec.EffectiveHTTP().TLS.Enabled() {
+		var certsSecret corev1.Secret
+		if err := c.Get(certhttp.PublicCertsSecretRef(esv1.ESNamer, k8s.ExtractNamespacedName(&es)), &certsSecret); err != nil {
+			return nil, errors.Wrap(err, "while retrieving the http certificates")
+		}
+		caCerts, err = certificates.ParsePEMCerts(certsSecret.Data[certificates.CAFileName])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return esclient.NewElasticsearchClient(dialer, services.ExternalServiceURL(es), auth, *v, caCerts), nil
+}
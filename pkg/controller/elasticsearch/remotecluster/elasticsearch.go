@@ -9,13 +9,18 @@ import (
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/license"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster/remoteca"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"go.elastic.co/apm"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -53,21 +58,41 @@ func UpdateSettings(
 	if err != nil {
 		return err
 	}
-	expectedRemoteClusters := getExpectedRemoteClusters(es)
+	expectedRemoteClusters, err := getExpectedRemoteClusters(c, es)
+	if err != nil {
+		return err
+	}
+	// the connection mode is reset for versions in which remote clusters may have been configured in proxy mode
+	apiKeysSupported := supportsAPIKeys(es)
 
 	remoteClusters := make(map[string]esclient.RemoteCluster)
 	// RemoteClusters to add or update
 	for name, remoteCluster := range expectedRemoteClusters {
 		if currentConfigHash, ok := currentRemoteClusters[name]; !ok || currentConfigHash != remoteCluster.ConfigHash {
 			// Declare remote cluster in ES
+			if remoteCluster.HasAPIKey() && apiKeysSupported {
+				// connect to the remote cluster server, the API key is set in the keystore
+				proxyAddress := services.RemoteClusterServerHost(remoteCluster.ElasticsearchRef.NamespacedName())
+				log.Info("Adding or updating remote cluster",
+					"namespace", es.Namespace,
+					"es_name", es.Name,
+					"remote_cluster", remoteCluster.Name,
+					"proxy_address", proxyAddress,
+				)
+				remoteClusters[name] = esclient.RemoteCluster{Seeds: nil, ConnectionMode: esclient.ProxyMode(proxyAddress)}
+				continue
+			}
 			seedHosts := []string{services.ExternalTransportServiceHost(remoteCluster.ElasticsearchRef.NamespacedName())}
+			if remoteCluster.IsFederated() {
+				seedHosts = []string{remoteCluster.TransportAddress}
+			}
 			log.Info("Adding or updating remote cluster",
 				"namespace", es.Namespace,
 				"es_name", es.Name,
 				"remote_cluster", remoteCluster.Name,
 				"seeds", seedHosts,
 			)
-			remoteClusters[name] = esclient.RemoteCluster{Seeds: seedHosts}
+			remoteClusters[name] = esclient.RemoteCluster{Seeds: seedHosts, ConnectionMode: resetConnectionMode(apiKeysSupported)}
 		}
 	}
 
@@ -79,7 +104,7 @@ func UpdateSettings(
 				"es_name", es.Name,
 				"remote_cluster", name,
 			)
-			remoteClusters[name] = esclient.RemoteCluster{Seeds: nil}
+			remoteClusters[name] = esclient.RemoteCluster{Seeds: nil, ConnectionMode: resetConnectionMode(apiKeysSupported)}
 		}
 	}
 
@@ -94,21 +119,70 @@ func UpdateSettings(
 	return nil
 }
 
+// supportsAPIKeys returns true if the version of the cluster supports remote clusters with API keys.
+func supportsAPIKeys(es esv1.Elasticsearch) bool {
+	v, err := version.Parse(es.Spec.Version)
+	return err == nil && v.IsSameOrAfter(esv1.RemoteClusterAPIKeyMinVersion)
+}
+
+// resetConnectionMode returns the connection mode resetting a remote cluster to the sniff mode, or nil if the
+// connection mode settings must not be set.
+func resetConnectionMode(apiKeysSupported bool) *esclient.ConnectionMode {
+	if !apiKeysSupported {
+		return nil
+	}
+	return &esclient.ConnectionMode{}
+}
+
 // getExpectedRemoteClusters returns a map with the expected remote clusters
 // A map is returned here because it will be used to quickly compare with the ones that are new or missing.
-func getExpectedRemoteClusters(es esv1.Elasticsearch) map[string]expectedRemoteClusterConfiguration {
+// Remote clusters running in another Kubernetes cluster are only expected once their transport address has been
+// retrieved by the federation controller.
+func getExpectedRemoteClusters(c k8s.Client, es esv1.Elasticsearch) (map[string]expectedRemoteClusterConfiguration, error) {
 	remoteClusters := make(map[string]expectedRemoteClusterConfiguration)
 	for _, remoteCluster := range es.Spec.RemoteClusters {
 		if !remoteCluster.ElasticsearchRef.IsDefined() {
 			continue
 		}
 		remoteCluster.ElasticsearchRef = remoteCluster.ElasticsearchRef.WithDefaultNamespace(es.Namespace)
-		remoteClusters[remoteCluster.Name] = expectedRemoteClusterConfiguration{
+		expected := expectedRemoteClusterConfiguration{
 			RemoteCluster: remoteCluster,
 			ConfigHash:    remoteCluster.ConfigHash(),
 		}
+		if remoteCluster.IsFederated() {
+			address, err := federatedTransportAddress(c, es, remoteCluster)
+			if err != nil {
+				return nil, err
+			}
+			if address == "" {
+				log.V(1).Info("Transport address of federated remote cluster not available yet",
+					"namespace", es.Namespace, "es_name", es.Name, "remote_cluster", remoteCluster.Name)
+				continue
+			}
+			expected.TransportAddress = address
+			// the settings must be updated if the remote cluster moves to another address
+			expected.ConfigHash = hash.HashObject(expected)
+		}
+		remoteClusters[remoteCluster.Name] = expected
+	}
+	return remoteClusters, nil
+}
+
+// federatedTransportAddress returns the transport address of a remote cluster running in another Kubernetes cluster,
+// as copied by the federation controller with its CA, or an empty string if it is not known yet.
+func federatedTransportAddress(c k8s.Client, es esv1.Elasticsearch, remoteCluster esv1.RemoteCluster) (string, error) {
+	var remoteCA corev1.Secret
+	key := types.NamespacedName{
+		Namespace: es.Namespace,
+		Name:      remoteca.FederatedRemoteCASecretName(es.Name, remoteCluster.KubernetesCluster, remoteCluster.ElasticsearchRef.NamespacedName()),
+	}
+	if err := c.Get(key, &remoteCA); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
 	}
-	return remoteClusters
+	return remoteCA.Annotations[remoteca.TransportAddressAnnotationName], nil
 }
 
 // updateSettings makes a call to an Elasticsearch cluster to apply a persistent setting.
@@ -16,7 +16,9 @@ import (
 	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func Test_getCurrentRemoteClusters(t *testing.T) {
@@ -118,6 +120,7 @@ func TestUpdateSettings(t *testing.T) {
 		esClient       *fakeESClient
 		es             *esv1.Elasticsearch
 		licenseChecker license.Checker
+		remoteCAs      []runtime.Object
 	}
 	tests := []struct {
 		name         string
@@ -186,7 +189,7 @@ func TestUpdateSettings(t *testing.T) {
 					"ns1",
 					"es1",
 					map[string]string{
-						"elasticsearch.k8s.elastic.co/remote-clusters": `{"ns1-es2":"2221154215"}`,
+						"elasticsearch.k8s.elastic.co/remote-clusters": `{"ns1-es2":"1912682536"}`,
 					},
 					esv1.RemoteCluster{
 						Name:             "ns1-es2",
@@ -231,7 +234,7 @@ func TestUpdateSettings(t *testing.T) {
 					"ns1",
 					"es1",
 					map[string]string{
-						"elasticsearch.k8s.elastic.co/remote-clusters": `{"to-be-deleted":"8538658922","ns1-es2":"2221154215"}`,
+						"elasticsearch.k8s.elastic.co/remote-clusters": `{"to-be-deleted":"8538658922","ns1-es2":"1912682536"}`,
 					},
 					esv1.RemoteCluster{
 						Name:             "ns1-es2",
@@ -299,10 +302,90 @@ func TestUpdateSettings(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Connect to remote clusters with API keys in proxy mode, reset the mode of the others",
+			args: args{
+				esClient:       &fakeESClient{},
+				licenseChecker: &fakeLicenseChecker{true},
+				es: func() *esv1.Elasticsearch {
+					es := newEsWithRemoteClusters(
+						"ns1",
+						"es1",
+						map[string]string{
+							"elasticsearch.k8s.elastic.co/remote-clusters": `{"ns1-es5":"8851644973"}`,
+						},
+						esv1.RemoteCluster{
+							Name:             "ns1-es2",
+							ElasticsearchRef: commonv1.ObjectSelector{Name: "es2"},
+							APIKey:           &esv1.RemoteClusterAPIKey{},
+						},
+						esv1.RemoteCluster{
+							Name:             "ns1-es4",
+							ElasticsearchRef: commonv1.ObjectSelector{Name: "es4"},
+						},
+					)
+					es.Spec.Version = "8.10.0"
+					return es
+				}(),
+			},
+			wantEsCalled: true,
+			wantSettings: esclient.RemoteClustersSettings{
+				PersistentSettings: &esclient.SettingsGroup{
+					Cluster: esclient.RemoteClusters{
+						RemoteClusters: map[string]esclient.RemoteCluster{
+							"ns1-es2": {Seeds: nil, ConnectionMode: esclient.ProxyMode("es2-es-transport.ns1.svc:9443")},
+							"ns1-es4": {Seeds: []string{"es4-es-transport.ns1.svc:9300"}, ConnectionMode: &esclient.ConnectionMode{}},
+							"ns1-es5": {Seeds: nil, ConnectionMode: &esclient.ConnectionMode{}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Connect to remote clusters running in another Kubernetes cluster once their address is known",
+			args: args{
+				esClient:       &fakeESClient{},
+				licenseChecker: &fakeLicenseChecker{true},
+				es: newEsWithRemoteClusters(
+					"ns1",
+					"es1",
+					nil,
+					esv1.RemoteCluster{
+						Name:              "east-es2",
+						ElasticsearchRef:  commonv1.ObjectSelector{Name: "es2", Namespace: "ns2"},
+						KubernetesCluster: "east",
+					},
+					esv1.RemoteCluster{
+						Name:              "east-es3",
+						ElasticsearchRef:  commonv1.ObjectSelector{Name: "es3", Namespace: "ns2"},
+						KubernetesCluster: "east",
+					},
+				),
+				remoteCAs: []runtime.Object{
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace:   "ns1",
+							Name:        "es1-east-ns2-es2-es-remote-ca",
+							Annotations: map[string]string{"elasticsearch.k8s.elastic.co/remote-cluster-transport-address": "10.0.0.1:9300"},
+						},
+					},
+				},
+			},
+			wantEsCalled: true,
+			wantSettings: esclient.RemoteClustersSettings{
+				PersistentSettings: &esclient.SettingsGroup{
+					Cluster: esclient.RemoteClusters{
+						RemoteClusters: map[string]esclient.RemoteCluster{
+							"east-es2": {Seeds: []string{"10.0.0.1:9300"}},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := k8s.WrappedFakeClient(tt.args.es)
+			client := k8s.WrappedFakeClient(append(tt.args.remoteCAs, tt.args.es)...)
 			if err := UpdateSettings(
 				context.Background(),
 				client,
@@ -0,0 +1,236 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package remoteca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.elastic.co/apm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+)
+
+const (
+	// APIKeyRemoteClustersAnnotationName holds the remote cluster of each of the credentials stored in the secret
+	// of the API keys, so that the API keys can be invalidated once the remote clusters are removed.
+	APIKeyRemoteClustersAnnotationName = "elasticsearch.k8s.elastic.co/api-key-remote-clusters"
+	// configHashMetadataKey is the key of the API key metadata holding the hash of the access it grants.
+	configHashMetadataKey = "elasticsearch.k8s.elastic.co/config-hash"
+	// remoteClusterServerDisabledMsg is emitted when the remote cluster is not ready to accept API keys.
+	remoteClusterServerDisabledMsg = "Remote cluster %s does not have its remote cluster server enabled"
+)
+
+// ESClientProvider returns a client for the given Elasticsearch cluster.
+type ESClientProvider func(c k8s.Client, dialer net.Dialer, es esv1.Elasticsearch) (esclient.Client, error)
+
+// credentialsSettingName returns the name of the secure setting holding the API key of the given remote cluster.
+func credentialsSettingName(remoteClusterName string) string {
+	return fmt.Sprintf("cluster.remote.%s.credentials", remoteClusterName)
+}
+
+// apiKeyName returns the name of the API key created on a remote cluster for the given local cluster.
+func apiKeyName(local types.NamespacedName, remoteClusterName string) string {
+	return fmt.Sprintf("eck-%s-%s-%s", local.Namespace, local.Name, remoteClusterName)
+}
+
+// reconcileAPIKeys creates the cross-cluster API keys of the remote clusters of the local cluster connecting with API
+// keys, and stores them in the secret included in the keystore of the local cluster. The API keys of the remote
+// clusters that are removed, or that no longer use an API key, are invalidated.
+func reconcileAPIKeys(
+	ctx context.Context,
+	r *ReconcileRemoteCa,
+	localEs *esv1.Elasticsearch,
+	remoteClusters map[types.NamespacedName]*esv1.Elasticsearch,
+) error {
+	span, _ := apm.StartSpan(ctx, "reconcile_api_keys", tracing.SpanTypeApp)
+	defer span.End()
+
+	localClusterKey := k8s.ExtractNamespacedName(localEs)
+	secretKey := types.NamespacedName{
+		Namespace: localEs.Namespace,
+		Name:      keystore.RemoteAPIKeysSecretName(esv1.ESNamer, localEs.Name),
+	}
+	var current corev1.Secret
+	if err := r.Client.Get(secretKey, &current); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	currentRemotes := map[string]string{}
+	if serialized, exists := current.Annotations[APIKeyRemoteClustersAnnotationName]; exists {
+		if err := json.Unmarshal([]byte(serialized), &currentRemotes); err != nil {
+			return err
+		}
+	}
+
+	data := map[string][]byte{}
+	expectedRemotes := map[string]string{}
+	for _, remoteCluster := range localEs.Spec.RemoteClusters {
+		if !remoteCluster.HasAPIKey() || !remoteCluster.ElasticsearchRef.IsDefined() || remoteCluster.IsFederated() {
+			continue
+		}
+		remoteClusterKey := remoteCluster.ElasticsearchRef.WithDefaultNamespace(localEs.Namespace).NamespacedName()
+		remoteEs, exists := remoteClusters[remoteClusterKey]
+		if !exists {
+			// the remote cluster does not exist or the association is not allowed
+			continue
+		}
+		if !remoteEs.Spec.RemoteClusterServer.Enabled {
+			r.recorder.Eventf(localEs, corev1.EventTypeWarning, events.EventReasonUnexpected, remoteClusterServerDisabledMsg, remoteClusterKey)
+		}
+		settingName := credentialsSettingName(remoteCluster.Name)
+		credentials, err := reconcileAPIKey(ctx, r, localClusterKey, remoteCluster, *remoteEs, current.Data[settingName])
+		if err != nil {
+			return pkgerrors.Wrapf(err, "while reconciling the API key of remote cluster %s", remoteCluster.Name)
+		}
+		data[settingName] = credentials
+		expectedRemotes[remoteCluster.Name] = remoteClusterKey.String()
+	}
+
+	for remoteClusterName, remote := range currentRemotes {
+		if _, expected := expectedRemotes[remoteClusterName]; expected {
+			continue
+		}
+		if err := invalidateAPIKey(ctx, r, localClusterKey, remoteClusterName, remote); err != nil {
+			return err
+		}
+	}
+
+	if len(data) == 0 {
+		if current.Name == "" {
+			return nil
+		}
+		err := r.Client.Delete(&current)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	serialized, err := json.Marshal(expectedRemotes)
+	if err != nil {
+		return err
+	}
+	expected := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   secretKey.Namespace,
+			Name:        secretKey.Name,
+			Labels:      label.NewLabels(localClusterKey),
+			Annotations: map[string]string{APIKeyRemoteClustersAnnotationName: string(serialized)},
+		},
+		Data: data,
+	}
+	_, err = reconciler.ReconcileSecret(r.Client, expected, localEs)
+	return err
+}
+
+// reconcileAPIKey creates the API key of the local cluster on the remote cluster if the current credentials are
+// missing or no longer valid, updates its access otherwise, and returns the credentials.
+func reconcileAPIKey(
+	ctx context.Context,
+	r *ReconcileRemoteCa,
+	local types.NamespacedName,
+	remoteCluster esv1.RemoteCluster,
+	remoteEs esv1.Elasticsearch,
+	currentCredentials []byte,
+) ([]byte, error) {
+	remoteClient, err := r.esClientProvider(r.Client, r.Dialer, remoteEs)
+	if err != nil {
+		return nil, err
+	}
+	defer remoteClient.Close()
+
+	name := apiKeyName(local, remoteCluster.Name)
+	configHash := hash.HashObject(remoteCluster.APIKey.Access)
+	request := esclient.CrossClusterAPIKeyRequest{
+		Name:     name,
+		Access:   apiKeyAccess(remoteCluster.APIKey.Access),
+		Metadata: map[string]interface{}{configHashMetadataKey: configHash},
+	}
+	keys, err := remoteClient.GetAPIKeysByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(currentCredentials) > 0 && len(keys) == 1 {
+		if keys[0].Metadata[configHashMetadataKey] != configHash {
+			log.Info("Updating API key", "local_namespace", local.Namespace, "local_name", local.Name, "remote_cluster", remoteCluster.Name)
+			if err := remoteClient.UpdateCrossClusterAPIKey(ctx, keys[0].ID, request); err != nil {
+				return nil, err
+			}
+		}
+		return currentCredentials, nil
+	}
+
+	// the credentials are lost or the API key was invalidated: replace any previous API key by a new one
+	if len(keys) > 0 {
+		if err := remoteClient.InvalidateAPIKeysByName(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("Creating API key", "local_namespace", local.Namespace, "local_name", local.Name, "remote_cluster", remoteCluster.Name)
+	key, err := remoteClient.CreateCrossClusterAPIKey(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(key.Encoded), nil
+}
+
+// invalidateAPIKey invalidates the API key of the local cluster on a remote cluster it no longer connects to with it.
+// There is nothing to do if the remote cluster does not exist anymore.
+func invalidateAPIKey(ctx context.Context, r *ReconcileRemoteCa, local types.NamespacedName, remoteClusterName string, remote string) error {
+	remoteClusterKey, err := parseNamespacedName(remote)
+	if err != nil {
+		return err
+	}
+	var remoteEs esv1.Elasticsearch
+	if err := r.Client.Get(remoteClusterKey, &remoteEs); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	remoteClient, err := r.esClientProvider(r.Client, r.Dialer, remoteEs)
+	if err != nil {
+		return err
+	}
+	defer remoteClient.Close()
+	log.Info("Invalidating API key", "local_namespace", local.Namespace, "local_name", local.Name, "remote_cluster", remoteClusterName)
+	return remoteClient.InvalidateAPIKeysByName(ctx, apiKeyName(local, remoteClusterName))
+}
+
+// apiKeyAccess converts the access declared in the specification to the one of the API key request.
+func apiKeyAccess(access esv1.RemoteClusterAccess) esclient.CrossClusterAPIKeyAccess {
+	var apiKeyAccess esclient.CrossClusterAPIKeyAccess
+	if access.Search != nil {
+		apiKeyAccess.Search = []esclient.CrossClusterAPIKeyIndices{{Names: access.Search.Names}}
+	}
+	if access.Replication != nil {
+		apiKeyAccess.Replication = []esclient.CrossClusterAPIKeyIndices{{Names: access.Replication.Names}}
+	}
+	return apiKeyAccess
+}
+
+// parseNamespacedName parses a namespaced name formatted as namespace/name.
+func parseNamespacedName(s string) (types.NamespacedName, error) {
+	parts := strings.Split(s, string(types.Separator))
+	if len(parts) != 2 {
+		return types.NamespacedName{}, fmt.Errorf("invalid namespaced name %s", s)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
@@ -0,0 +1,143 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package remoteca
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+)
+
+// fakeAPIKeyClient stores the API keys of a remote cluster in memory.
+type fakeAPIKeyClient struct {
+	esclient.Client
+	keys        map[string]esclient.APIKey
+	created     int
+	updated     int
+	invalidated int
+}
+
+func (f *fakeAPIKeyClient) CreateCrossClusterAPIKey(_ context.Context, request esclient.CrossClusterAPIKeyRequest) (esclient.CrossClusterAPIKey, error) {
+	f.created++
+	id := fmt.Sprintf("id-%d", f.created)
+	f.keys[request.Name] = esclient.APIKey{ID: id, Name: request.Name, Metadata: request.Metadata}
+	return esclient.CrossClusterAPIKey{ID: id, Name: request.Name, Encoded: "encoded-" + id}, nil
+}
+
+func (f *fakeAPIKeyClient) UpdateCrossClusterAPIKey(_ context.Context, id string, request esclient.CrossClusterAPIKeyRequest) error {
+	f.updated++
+	for name, key := range f.keys {
+		if key.ID == id {
+			key.Metadata = request.Metadata
+			f.keys[name] = key
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPIKeyClient) GetAPIKeysByName(_ context.Context, name string) ([]esclient.APIKey, error) {
+	if key, exists := f.keys[name]; exists {
+		return []esclient.APIKey{key}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeAPIKeyClient) InvalidateAPIKeysByName(_ context.Context, name string) error {
+	f.invalidated++
+	delete(f.keys, name)
+	return nil
+}
+
+func (f *fakeAPIKeyClient) Close() {}
+
+func Test_reconcileAPIKeys(t *testing.T) {
+	require.NoError(t, controllerscheme.SetupScheme())
+
+	remoteEs := &esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "remote"},
+		Spec:       esv1.ElasticsearchSpec{RemoteClusterServer: esv1.RemoteClusterServer{Enabled: true}},
+	}
+	localEs := &esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "local", UID: "uid"},
+		Spec: esv1.ElasticsearchSpec{
+			RemoteClusters: []esv1.RemoteCluster{
+				{
+					Name:             "remote-search",
+					ElasticsearchRef: commonv1.ObjectSelector{Namespace: "ns2", Name: "remote"},
+					APIKey: &esv1.RemoteClusterAPIKey{
+						Access: esv1.RemoteClusterAccess{Search: &esv1.RemoteClusterIndices{Names: []string{"logs-*"}}},
+					},
+				},
+				{
+					// connected with certificates, no API key
+					Name:             "remote-certs",
+					ElasticsearchRef: commonv1.ObjectSelector{Namespace: "ns2", Name: "remote"},
+				},
+			},
+		},
+	}
+	remoteClusters := map[types.NamespacedName]*esv1.Elasticsearch{k8s.ExtractNamespacedName(remoteEs): remoteEs}
+	esClient := &fakeAPIKeyClient{keys: map[string]esclient.APIKey{}}
+	r := &ReconcileRemoteCa{
+		Client:   k8s.WrappedFakeClient(localEs, remoteEs),
+		recorder: record.NewFakeRecorder(10),
+		esClientProvider: func(_ k8s.Client, _ net.Dialer, _ esv1.Elasticsearch) (esclient.Client, error) {
+			return esClient, nil
+		},
+	}
+	secretKey := types.NamespacedName{Namespace: "ns1", Name: "local-es-remote-api-keys"}
+
+	// the API key is created and stored in the secret
+	require.NoError(t, reconcileAPIKeys(context.Background(), r, localEs, remoteClusters))
+	require.Equal(t, 1, esClient.created)
+	require.Contains(t, esClient.keys, "eck-ns1-local-remote-search")
+	var secret corev1.Secret
+	require.NoError(t, r.Client.Get(secretKey, &secret))
+	require.Equal(t, map[string][]byte{"cluster.remote.remote-search.credentials": []byte("encoded-id-1")}, secret.Data)
+	require.Equal(t, `{"remote-search":"ns2/remote"}`, secret.Annotations[APIKeyRemoteClustersAnnotationName])
+	require.True(t, metav1.IsControlledBy(&secret, localEs))
+
+	// nothing changes: the API key is left untouched
+	require.NoError(t, reconcileAPIKeys(context.Background(), r, localEs, remoteClusters))
+	require.Equal(t, 1, esClient.created)
+	require.Equal(t, 0, esClient.updated)
+
+	// the access changes: the API key is updated, the credentials remain the same
+	localEs.Spec.RemoteClusters[0].APIKey.Access.Replication = &esv1.RemoteClusterIndices{Names: []string{"metrics-*"}}
+	require.NoError(t, reconcileAPIKeys(context.Background(), r, localEs, remoteClusters))
+	require.Equal(t, 1, esClient.created)
+	require.Equal(t, 1, esClient.updated)
+	require.NoError(t, r.Client.Get(secretKey, &secret))
+	require.Equal(t, []byte("encoded-id-1"), secret.Data["cluster.remote.remote-search.credentials"])
+
+	// the API key is invalidated on the remote cluster: a new one is created
+	delete(esClient.keys, "eck-ns1-local-remote-search")
+	require.NoError(t, reconcileAPIKeys(context.Background(), r, localEs, remoteClusters))
+	require.Equal(t, 2, esClient.created)
+	require.NoError(t, r.Client.Get(secretKey, &secret))
+	require.Equal(t, []byte("encoded-id-2"), secret.Data["cluster.remote.remote-search.credentials"])
+
+	// the remote cluster is removed: the API key is invalidated and the secret deleted
+	localEs.Spec.RemoteClusters = localEs.Spec.RemoteClusters[1:]
+	require.NoError(t, reconcileAPIKeys(context.Background(), r, localEs, remoteClusters))
+	require.Equal(t, 1, esClient.invalidated)
+	require.Empty(t, esClient.keys)
+	err := r.Client.Get(secretKey, &corev1.Secret{})
+	require.True(t, apierrors.IsNotFound(err))
+}
@@ -41,15 +41,16 @@ var (
 )
 
 // NewReconciler returns a new reconcile.Reconciler
-func NewReconciler(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) *ReconcileRemoteCa {
+func NewReconciler(mgr manager.Manager, accessReviewer rbac.AccessReviewer, esClientProvider ESClientProvider, params operator.Parameters) *ReconcileRemoteCa {
 	c := k8s.WrapClient(mgr.GetClient())
 	return &ReconcileRemoteCa{
-		Client:         c,
-		accessReviewer: accessReviewer,
-		watches:        watches.NewDynamicWatches(),
-		recorder:       mgr.GetEventRecorderFor(name),
-		licenseChecker: license.NewLicenseChecker(c, params.OperatorNamespace),
-		Parameters:     params,
+		Client:           c,
+		accessReviewer:   accessReviewer,
+		watches:          watches.NewDynamicWatches(),
+		recorder:         mgr.GetEventRecorderFor(name),
+		licenseChecker:   license.NewLicenseChecker(c, params.OperatorNamespace),
+		esClientProvider: esClientProvider,
+		Parameters:       params,
 	}
 }
 
@@ -63,6 +64,8 @@ type ReconcileRemoteCa struct {
 	recorder       record.EventRecorder
 	watches        watches.DynamicWatches
 	licenseChecker license.Checker
+	// esClientProvider returns a client to create the API keys of the remote clusters
+	esClientProvider ESClientProvider
 
 	// iteration is the number of times this controller has run its Reconcile method
 	iteration uint64
@@ -144,6 +147,7 @@ func doReconcile(
 	}
 
 	results := &reconciler.Results{}
+	allowedRemoteClusters := make(map[types.NamespacedName]*esv1.Elasticsearch, len(expectedRemoteClusters))
 	// Create or update expected remote CA
 	for remoteEsKey := range expectedRemoteClusters {
 		// Get the remote Elasticsearch cluster associated with this remote CA
@@ -164,12 +168,16 @@ func doReconcile(
 			continue
 		}
 		delete(remoteClustersInvolved, remoteEsKey)
+		allowedRemoteClusters[remoteEsKey] = remoteEs
 		results.WithResults(createOrUpdateCertificateAuthorities(ctx, r, localEs, remoteEs))
 		if results.HasError() {
 			return results.Aggregate()
 		}
 	}
 
+	// Create the API keys of the remote clusters connected with API keys, and invalidate the ones no longer used
+	results.WithError(reconcileAPIKeys(ctx, r, localEs, allowedRemoteClusters))
+
 	// Delete existing but not expected remote CA
 	for toDelete := range remoteClustersInvolved {
 		log.V(1).Info("Deleting remote CA",
@@ -200,7 +208,7 @@ func getExpectedRemoteClusters(
 
 	// Add remote clusters declared in the Spec
 	for _, remoteCluster := range associatedEs.Spec.RemoteClusters {
-		if !remoteCluster.ElasticsearchRef.IsDefined() {
+		if !remoteCluster.ElasticsearchRef.IsDefined() || remoteCluster.IsFederated() {
 			continue
 		}
 		esRef := remoteCluster.ElasticsearchRef.WithDefaultNamespace(associatedEs.Namespace)
@@ -215,7 +223,7 @@ func getExpectedRemoteClusters(
 	// Seek for Elasticsearch resources where this cluster is declared as a remote cluster
 	for _, es := range list.Items {
 		for _, remoteCluster := range es.Spec.RemoteClusters {
-			if !remoteCluster.ElasticsearchRef.IsDefined() {
+			if !remoteCluster.ElasticsearchRef.IsDefined() || remoteCluster.IsFederated() {
 				continue
 			}
 			esRef := remoteCluster.ElasticsearchRef.WithDefaultNamespace(es.Namespace)
@@ -255,6 +263,10 @@ func remoteClustersInvolvedWith(
 		return nil, err
 	}
 	for _, remoteCA := range remoteCAList.Items {
+		if IsFederated(remoteCA) {
+			// managed by the federation controller
+			continue
+		}
 		remoteNs := remoteCA.Labels[RemoteClusterNamespaceLabelName]
 		remoteEs := remoteCA.Labels[RemoteClusterNameLabelName]
 		currentRemoteClusters[types.NamespacedName{
@@ -275,6 +287,9 @@ func remoteClustersInvolvedWith(
 		return nil, err
 	}
 	for _, remoteCA := range remoteCAList.Items {
+		if IsFederated(remoteCA) {
+			continue
+		}
 		remoteEs := remoteCA.Labels[label.ClusterNameLabelName]
 		currentRemoteClusters[types.NamespacedName{
 			Namespace: remoteCA.Namespace,
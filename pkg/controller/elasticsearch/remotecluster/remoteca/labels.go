@@ -10,6 +10,7 @@ import (
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,6 +21,12 @@ const (
 	RemoteClusterNamespaceLabelName = "elasticsearch.k8s.elastic.co/remote-cluster-namespace"
 	// RemoteClusterNameLabelName used to represent the name of the RemoteCluster in a TrustRelationship.
 	RemoteClusterNameLabelName = "elasticsearch.k8s.elastic.co/remote-cluster-name"
+	// RemoteClusterKubernetesClusterLabelName used to represent the Kubernetes cluster of a RemoteCluster running in
+	// another Kubernetes cluster of the operator federation.
+	RemoteClusterKubernetesClusterLabelName = "elasticsearch.k8s.elastic.co/remote-cluster-kubernetes-cluster"
+	// TransportAddressAnnotationName holds the transport address of a RemoteCluster running in another Kubernetes
+	// cluster of the operator federation.
+	TransportAddressAnnotationName = "elasticsearch.k8s.elastic.co/remote-cluster-transport-address"
 	// TypeLabelValue is a type used to identify a Secret which contains the CA of a remote cluster.
 	TypeLabelValue = "remote-ca"
 	// remoteCASecretSuffix is the suffix added to the aforementioned Secret.
@@ -54,6 +61,38 @@ func remoteCASecretName(
 	)
 }
 
+// FederatedRemoteCAObjectMeta returns the metadata of the Secret that contains the transport CA of a remote cluster
+// running in another Kubernetes cluster of the operator federation.
+func FederatedRemoteCAObjectMeta(
+	owner *esv1.Elasticsearch,
+	kubernetesCluster string,
+	remote types.NamespacedName,
+) metav1.ObjectMeta {
+	meta := remoteCAObjectMeta(FederatedRemoteCASecretName(owner.Name, kubernetesCluster, remote), owner, remote)
+	meta.Labels[RemoteClusterKubernetesClusterLabelName] = kubernetesCluster
+	return meta
+}
+
+// FederatedRemoteCASecretName returns the name of the Secret that contains the transport CA of a remote cluster
+// running in another Kubernetes cluster of the operator federation.
+func FederatedRemoteCASecretName(
+	localClusterName string,
+	kubernetesCluster string,
+	remoteCluster types.NamespacedName,
+) string {
+	return esv1.ESNamer.Suffix(
+		fmt.Sprintf("%s-%s-%s-%s", localClusterName, kubernetesCluster, remoteCluster.Namespace, remoteCluster.Name),
+		remoteCASecretSuffix,
+	)
+}
+
+// IsFederated returns true if the given remote CA Secret holds the CA of a remote cluster running in another
+// Kubernetes cluster of the operator federation.
+func IsFederated(remoteCA corev1.Secret) bool {
+	_, exists := remoteCA.Labels[RemoteClusterKubernetesClusterLabelName]
+	return exists
+}
+
 func LabelSelector(esName string) client.MatchingLabels {
 	return map[string]string{
 		label.ClusterNameLabelName: esName,
@@ -61,7 +61,7 @@ func newToRequestsFuncFromSecret() handler.ToRequestsFunc {
 		if !maps.ContainsKeys(labels, RemoteClusterNameLabelName, RemoteClusterNamespaceLabelName, common.TypeLabelName) {
 			return nil
 		}
-		if labels[common.TypeLabelName] != TypeLabelValue {
+		if labels[common.TypeLabelName] != TypeLabelValue || maps.ContainsKeys(labels, RemoteClusterKubernetesClusterLabelName) {
 			return nil
 		}
 		return []reconcile.Request{
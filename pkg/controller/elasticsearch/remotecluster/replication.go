@@ -0,0 +1,208 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package remotecluster
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.elastic.co/apm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/license"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// AutoFollowPatternsAnnotationName holds the names of the auto-follow patterns created by the operator, so that they
+// can be deleted once removed from the specification.
+const AutoFollowPatternsAnnotationName = "elasticsearch.k8s.elastic.co/auto-follow-patterns"
+
+const replicationDisabledMsg = "Cross-cluster replication is an enterprise feature. Enterprise features are disabled"
+
+// ReplicationEnabled returns true if follower indices or auto-follow patterns are declared in the specification of the
+// given cluster and allowed by the license.
+func ReplicationEnabled(licenseChecker license.Checker, es esv1.Elasticsearch) (bool, error) {
+	replication := es.Spec.CrossClusterReplication
+	if replication == nil || (len(replication.FollowerIndices) == 0 && len(replication.AutoFollowPatterns) == 0) {
+		return false, nil
+	}
+	return licenseChecker.EnterpriseFeaturesEnabled()
+}
+
+// UpdateReplication creates the follower indices declared in the specification that do not exist yet, and
+// reconciles the auto-follow patterns by calling the Elasticsearch API. Follower indices are left untouched once
+// created, since converting them back to regular indices requires to close them.
+func UpdateReplication(
+	ctx context.Context,
+	c k8s.Client,
+	esClient esclient.Client,
+	eventRecorder record.EventRecorder,
+	licenseChecker license.Checker,
+	es esv1.Elasticsearch,
+) error {
+	span, _ := apm.StartSpan(ctx, "update_cross_cluster_replication", tracing.SpanTypeApp)
+	defer span.End()
+
+	replication := es.Spec.CrossClusterReplication
+	if replication == nil {
+		replication = &esv1.CrossClusterReplication{}
+	}
+	managedPatterns, err := getManagedAutoFollowPatterns(es)
+	if err != nil {
+		return err
+	}
+	if len(replication.FollowerIndices) == 0 && len(replication.AutoFollowPatterns) == 0 && len(managedPatterns) == 0 {
+		return nil
+	}
+
+	enabled, err := licenseChecker.EnterpriseFeaturesEnabled()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		log.Info(replicationDisabledMsg, "namespace", es.Namespace, "es_name", es.Name)
+		eventRecorder.Eventf(&es, corev1.EventTypeWarning, events.EventAssociationError, replicationDisabledMsg)
+		return nil
+	}
+
+	if err := updateFollowerIndices(ctx, esClient, es, replication.FollowerIndices); err != nil {
+		return err
+	}
+	return updateAutoFollowPatterns(ctx, c, esClient, es, replication.AutoFollowPatterns, managedPatterns)
+}
+
+func updateFollowerIndices(ctx context.Context, esClient esclient.Client, es esv1.Elasticsearch, followerIndices []esv1.FollowerIndex) error {
+	if len(followerIndices) == 0 {
+		return nil
+	}
+	current, err := esClient.GetFollowerIndices(ctx)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]struct{}, len(current))
+	for _, follower := range current {
+		existing[follower.FollowerIndex] = struct{}{}
+	}
+	for _, follower := range followerIndices {
+		if _, exists := existing[follower.Name]; exists {
+			continue
+		}
+		log.Info("Creating follower index",
+			"namespace", es.Namespace,
+			"es_name", es.Name,
+			"index", follower.Name,
+			"remote_cluster", follower.RemoteCluster,
+			"leader_index", follower.LeaderIndex,
+		)
+		if err := esClient.FollowIndex(ctx, follower.Name, esclient.FollowRequest{
+			RemoteCluster: follower.RemoteCluster,
+			LeaderIndex:   follower.LeaderIndex,
+		}); err != nil {
+			return errors.Wrapf(err, "while creating follower index %s", follower.Name)
+		}
+	}
+	return nil
+}
+
+func updateAutoFollowPatterns(
+	ctx context.Context,
+	c k8s.Client,
+	esClient esclient.Client,
+	es esv1.Elasticsearch,
+	patterns []esv1.AutoFollowPattern,
+	managedPatterns []string,
+) error {
+	current, err := esClient.GetAutoFollowPatterns(ctx)
+	if err != nil {
+		return err
+	}
+
+	expected := make(map[string]esclient.AutoFollowPattern, len(patterns))
+	for _, p := range patterns {
+		expected[p.Name] = esclient.AutoFollowPattern{
+			RemoteCluster:       p.RemoteCluster,
+			LeaderIndexPatterns: p.LeaderIndexPatterns,
+			FollowIndexPattern:  p.FollowIndexPattern,
+		}
+	}
+	// auto-follow patterns to add or update
+	for name, pattern := range expected {
+		if existing, exists := current[name]; exists && reflect.DeepEqual(existing, pattern) {
+			continue
+		}
+		log.Info("Adding or updating auto-follow pattern", "namespace", es.Namespace, "es_name", es.Name, "pattern", name)
+		if err := esClient.UpdateAutoFollowPattern(ctx, name, pattern); err != nil {
+			return errors.Wrapf(err, "while updating auto-follow pattern %s", name)
+		}
+	}
+	// auto-follow patterns to remove, patterns not created by the operator are left untouched
+	for _, name := range managedPatterns {
+		if _, isExpected := expected[name]; isExpected {
+			continue
+		}
+		if _, exists := current[name]; !exists {
+			continue
+		}
+		log.Info("Removing auto-follow pattern", "namespace", es.Namespace, "es_name", es.Name, "pattern", name)
+		if err := esClient.DeleteAutoFollowPattern(ctx, name); err != nil && !esclient.IsNotFound(err) {
+			return errors.Wrapf(err, "while deleting auto-follow pattern %s", name)
+		}
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return annotateWithAutoFollowPatterns(c, es, names)
+}
+
+// getManagedAutoFollowPatterns returns the names of the auto-follow patterns created by the operator.
+func getManagedAutoFollowPatterns(es esv1.Elasticsearch) ([]string, error) {
+	serialized, ok := es.Annotations[AutoFollowPatternsAnnotationName]
+	if !ok {
+		return nil, nil
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(serialized), &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// annotateWithAutoFollowPatterns patches the annotation holding the names of the auto-follow patterns created by the
+// operator if they changed, or removes it if there are none.
+func annotateWithAutoFollowPatterns(c k8s.Client, es esv1.Elasticsearch, names []string) error {
+	patched := es.DeepCopy()
+	if len(names) == 0 {
+		if _, exists := es.Annotations[AutoFollowPatternsAnnotationName]; !exists {
+			return nil
+		}
+		delete(patched.Annotations, AutoFollowPatternsAnnotationName)
+	} else {
+		serialized, err := json.Marshal(names)
+		if err != nil {
+			return errors.Wrapf(err, "failed to serialize auto-follow patterns")
+		}
+		if es.Annotations[AutoFollowPatternsAnnotationName] == string(serialized) {
+			return nil
+		}
+		if patched.Annotations == nil {
+			patched.Annotations = make(map[string]string)
+		}
+		patched.Annotations[AutoFollowPatternsAnnotationName] = string(serialized)
+	}
+	// patch the annotation only, the resource is also updated with the remote clusters annotation
+	return c.Patch(patched, client.MergeFrom(&es))
+}
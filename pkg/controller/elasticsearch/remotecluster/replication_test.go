@@ -0,0 +1,141 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package remotecluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+type fakeCCRClient struct {
+	esclient.Client
+	followerIndices []esclient.FollowerInfo
+	patterns        map[string]esclient.AutoFollowPattern
+	followed        []string
+	updated         []string
+	deleted         []string
+}
+
+func (f *fakeCCRClient) GetFollowerIndices(_ context.Context) ([]esclient.FollowerInfo, error) {
+	return f.followerIndices, nil
+}
+
+func (f *fakeCCRClient) FollowIndex(_ context.Context, index string, _ esclient.FollowRequest) error {
+	f.followed = append(f.followed, index)
+	return nil
+}
+
+func (f *fakeCCRClient) GetAutoFollowPatterns(_ context.Context) (map[string]esclient.AutoFollowPattern, error) {
+	return f.patterns, nil
+}
+
+func (f *fakeCCRClient) UpdateAutoFollowPattern(_ context.Context, name string, _ esclient.AutoFollowPattern) error {
+	f.updated = append(f.updated, name)
+	return nil
+}
+
+func (f *fakeCCRClient) DeleteAutoFollowPattern(_ context.Context, name string) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func TestUpdateReplication(t *testing.T) {
+	logs := esclient.AutoFollowPattern{RemoteCluster: "leader", LeaderIndexPatterns: []string{"logs-*"}}
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		replication      *esv1.CrossClusterReplication
+		esClient         *fakeCCRClient
+		license          bool
+		wantFollowed     []string
+		wantUpdated      []string
+		wantDeleted      []string
+		wantAnnotation   string
+		wantNoAnnotation bool
+	}{
+		{
+			name:             "nothing declared",
+			esClient:         &fakeCCRClient{},
+			license:          true,
+			wantNoAnnotation: true,
+		},
+		{
+			name: "create the missing follower indices",
+			replication: &esv1.CrossClusterReplication{FollowerIndices: []esv1.FollowerIndex{
+				{Name: "a", RemoteCluster: "leader", LeaderIndex: "a"},
+				{Name: "b", RemoteCluster: "leader", LeaderIndex: "b"},
+			}},
+			esClient:         &fakeCCRClient{followerIndices: []esclient.FollowerInfo{{FollowerIndex: "a"}}},
+			license:          true,
+			wantFollowed:     []string{"b"},
+			wantNoAnnotation: true,
+		},
+		{
+			name: "no enterprise license",
+			replication: &esv1.CrossClusterReplication{FollowerIndices: []esv1.FollowerIndex{
+				{Name: "a", RemoteCluster: "leader", LeaderIndex: "a"},
+			}},
+			esClient:         &fakeCCRClient{},
+			license:          false,
+			wantNoAnnotation: true,
+		},
+		{
+			name:        "add, update and remove auto-follow patterns",
+			annotations: map[string]string{AutoFollowPatternsAnnotationName: `["logs","metrics","removed"]`},
+			replication: &esv1.CrossClusterReplication{AutoFollowPatterns: []esv1.AutoFollowPattern{
+				{Name: "logs", RemoteCluster: "leader", LeaderIndexPatterns: []string{"logs-*"}},
+				{Name: "metrics", RemoteCluster: "leader", LeaderIndexPatterns: []string{"metrics-*"}},
+				{Name: "new", RemoteCluster: "leader", LeaderIndexPatterns: []string{"new-*"}},
+			}},
+			esClient: &fakeCCRClient{patterns: map[string]esclient.AutoFollowPattern{
+				"logs":    logs,
+				"metrics": {RemoteCluster: "leader", LeaderIndexPatterns: []string{"old-*"}},
+				"removed": logs,
+				// not created by the operator
+				"other": logs,
+			}},
+			license:        true,
+			wantUpdated:    []string{"metrics", "new"},
+			wantDeleted:    []string{"removed"},
+			wantAnnotation: `["logs","metrics","new"]`,
+		},
+		{
+			name:             "remove the last auto-follow pattern",
+			annotations:      map[string]string{AutoFollowPatternsAnnotationName: `["logs"]`},
+			esClient:         &fakeCCRClient{patterns: map[string]esclient.AutoFollowPattern{"logs": logs}},
+			license:          true,
+			wantDeleted:      []string{"logs"},
+			wantNoAnnotation: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", Annotations: tt.annotations},
+				Spec:       esv1.ElasticsearchSpec{CrossClusterReplication: tt.replication},
+			}
+			c := k8s.WrappedFakeClient(&es)
+			err := UpdateReplication(context.Background(), c, tt.esClient, record.NewFakeRecorder(10), &fakeLicenseChecker{tt.license}, es)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFollowed, tt.esClient.followed)
+			require.ElementsMatch(t, tt.wantUpdated, tt.esClient.updated)
+			require.Equal(t, tt.wantDeleted, tt.esClient.deleted)
+
+			var updated esv1.Elasticsearch
+			require.NoError(t, c.Get(k8s.ExtractNamespacedName(&es), &updated))
+			annotation, exists := updated.Annotations[AutoFollowPatternsAnnotationName]
+			require.Equal(t, !tt.wantNoAnnotation, exists)
+			require.Equal(t, tt.wantAnnotation, annotation)
+		})
+	}
+}
@@ -0,0 +1,464 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package rename
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/bootstrap"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var log = logf.Log.WithName("rename")
+
+const (
+	// CompletedAnnotationName records on a renamed cluster the Elasticsearch resource it was renamed from, once its
+	// volumes and credentials are taken over.
+	CompletedAnnotationName = "elasticsearch.k8s.elastic.co/rename-completed"
+	// ReclaimPolicyAnnotationName records on a PersistentVolume retained for a rename its reclaim policy before the
+	// rename, restored once the volume is taken over.
+	ReclaimPolicyAnnotationName = "elasticsearch.k8s.elastic.co/reclaim-policy"
+
+	// ConditionType is the type of the condition reporting the progress of the rename of a cluster.
+	ConditionType commonv1.ConditionType = "Renamed"
+	// ReasonBlocked is the reason of the condition while the rename cannot progress.
+	ReasonBlocked = "RenameBlocked"
+	// ReasonReady is the reason of the condition of the renamed cluster once it can be deleted.
+	ReasonReady = "ReadyForRename"
+	// ReasonInProgress is the reason of the condition of the new cluster while it waits for the volumes of the
+	// renamed cluster.
+	ReasonInProgress = "RenameInProgress"
+	// ReasonCompleted is the reason of the condition of the new cluster once the volumes are taken over.
+	ReasonCompleted = "Renamed"
+
+	sourceKey      = "source"
+	sourceUIDKey   = "source-uid"
+	targetKey      = "target"
+	clusterUUIDKey = "cluster-uuid"
+	volumesKey     = "volumes.json"
+	elasticUserKey = "elastic-user.json"
+)
+
+// Volume is a volume of a renamed cluster handed over to the new cluster.
+type Volume struct {
+	// ClaimName is the name of the PersistentVolumeClaim of the volume in the new cluster.
+	ClaimName string `json:"claimName"`
+	// VolumeName is the name of the PersistentVolume.
+	VolumeName string `json:"volumeName"`
+	// SourceClaimName is the name of the PersistentVolumeClaim of the volume in the renamed cluster.
+	SourceClaimName string `json:"sourceClaimName"`
+	// SourceClaimUID is the UID of the PersistentVolumeClaim of the volume in the renamed cluster.
+	SourceClaimUID types.UID `json:"sourceClaimUID"`
+	// Labels are the labels of the PersistentVolumeClaim in the new cluster.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Spec is the specification of the PersistentVolumeClaim of the renamed cluster.
+	Spec corev1.PersistentVolumeClaimSpec `json:"spec"`
+}
+
+// Handover holds what the new cluster takes over from the renamed cluster, stored in a Secret of the operator
+// namespace which cannot be written by the users of the namespaces of the clusters.
+type Handover struct {
+	Source      types.NamespacedName
+	SourceUID   types.UID
+	Target      types.NamespacedName
+	ClusterUUID string
+	Volumes     []Volume
+	ElasticUser map[string][]byte
+}
+
+// Condition returns the rename condition with the given reason and message.
+func Condition(reason, message string) commonv1.Condition {
+	status := corev1.ConditionFalse
+	if reason == ReasonReady || reason == ReasonCompleted {
+		status = corev1.ConditionTrue
+	}
+	return commonv1.Condition{Type: ConditionType, Status: status, Reason: reason, Message: message}
+}
+
+// PrepareSource prepares the rename of the given cluster to the target: the PersistentVolumes of the cluster are
+// retained, so that they are not deleted along with their claims when the cluster is deleted, and handed over to the
+// target through a Secret in the operator namespace, along with the UUID of the cluster and the password of the
+// elastic user. The handover is updated as long as the rename is requested, to follow the changes of the cluster.
+func PrepareSource(c k8s.Client, operatorNamespace string, es esv1.Elasticsearch, target types.NamespacedName) (commonv1.Condition, error) {
+	uuid := es.Annotations[bootstrap.ClusterUUIDAnnotationName]
+	if uuid == "" {
+		return Condition(ReasonBlocked, "Waiting for the cluster to be bootstrapped before preparing the rename"), nil
+	}
+	existing, err := getHandover(c, operatorNamespace, target)
+	if err != nil {
+		return commonv1.Condition{}, err
+	}
+	if existing != nil && (existing.Source != k8s.ExtractNamespacedName(&es) || existing.SourceUID != es.UID) {
+		return Condition(ReasonBlocked, fmt.Sprintf("%s is already the target of the rename of %s", target, existing.Source)), nil
+	}
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := c.List(&pvcs, client.InNamespace(es.Namespace), label.NewLabelSelectorForElasticsearch(es)); err != nil {
+		return commonv1.Condition{}, err
+	}
+	sort.Slice(pvcs.Items, func(i, j int) bool { return pvcs.Items[i].Name < pvcs.Items[j].Name })
+	handover := Handover{
+		Source:      k8s.ExtractNamespacedName(&es),
+		SourceUID:   es.UID,
+		Target:      target,
+		ClusterUUID: uuid,
+		Volumes:     make([]Volume, 0, len(pvcs.Items)),
+	}
+	for _, pvc := range pvcs.Items {
+		ssetName := pvc.Labels[label.StatefulSetNameLabelName]
+		targetSset, exists := renamedStatefulSet(es, target, ssetName)
+		if !exists {
+			// not a volume of a NodeSet of the cluster
+			continue
+		}
+		claimName, exists := renamedClaim(pvc.Name, ssetName, targetSset)
+		if !exists {
+			continue
+		}
+		if pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.VolumeName == "" {
+			return Condition(ReasonBlocked, fmt.Sprintf("Waiting for PersistentVolumeClaim %s to be bound", pvc.Name)), nil
+		}
+		if err := retainVolume(c, pvc.Spec.VolumeName); err != nil {
+			return commonv1.Condition{}, err
+		}
+		labels := make(map[string]string, len(pvc.Labels))
+		for k, v := range pvc.Labels {
+			labels[k] = v
+		}
+		labels[label.ClusterNameLabelName] = target.Name
+		labels[label.StatefulSetNameLabelName] = targetSset
+		handover.Volumes = append(handover.Volumes, Volume{
+			ClaimName:       claimName,
+			VolumeName:      pvc.Spec.VolumeName,
+			SourceClaimName: pvc.Name,
+			SourceClaimUID:  pvc.UID,
+			Labels:          labels,
+			Spec:            *pvc.Spec.DeepCopy(),
+		})
+	}
+
+	var elasticUser corev1.Secret
+	if err := c.Get(types.NamespacedName{Namespace: es.Namespace, Name: esv1.ElasticUserSecret(es.Name)}, &elasticUser); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return commonv1.Condition{}, err
+		}
+		return Condition(ReasonBlocked, "Waiting for the elastic user to be created before preparing the rename"), nil
+	}
+	handover.ElasticUser = elasticUser.Data
+
+	if err := reconcileHandover(c, operatorNamespace, handover); err != nil {
+		return commonv1.Condition{}, err
+	}
+	return Condition(ReasonReady, fmt.Sprintf(
+		"%d volumes retained for the rename to %s: delete this resource, then create %s with the %s annotation set to %s",
+		len(handover.Volumes), target, target, esv1.RenamedFromAnnotation, handover.Source,
+	)), nil
+}
+
+// renamedStatefulSet returns the name of the StatefulSet of the target cluster matching the given StatefulSet of the
+// renamed cluster, and false if it does not belong to a NodeSet of the renamed cluster. NodeSets spread across zones
+// have one StatefulSet per zone.
+func renamedStatefulSet(es esv1.Elasticsearch, target types.NamespacedName, ssetName string) (string, bool) {
+	for _, nodeSet := range es.Spec.NodeSets {
+		for _, name := range nodeSet.ExpandedNames() {
+			if esv1.StatefulSet(es.Name, name) == ssetName {
+				return esv1.StatefulSet(target.Name, name), true
+			}
+		}
+	}
+	return "", false
+}
+
+// renamedClaim returns the name of the claim created by the StatefulSet controller for the target StatefulSet
+// matching the given claim of the renamed StatefulSet, of the form <claim template>-<StatefulSet>-<ordinal>.
+func renamedClaim(claimName, ssetName, targetSset string) (string, bool) {
+	i := strings.LastIndex(claimName, "-"+ssetName+"-")
+	if i < 0 {
+		return "", false
+	}
+	return claimName[:i] + "-" + targetSset + claimName[i+len(ssetName)+1:], true
+}
+
+// retainVolume sets the reclaim policy of the given PersistentVolume to Retain, recording its previous policy.
+func retainVolume(c k8s.Client, volumeName string) error {
+	var pv corev1.PersistentVolume
+	if err := c.Get(types.NamespacedName{Name: volumeName}, &pv); err != nil {
+		return err
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain {
+		return nil
+	}
+	if pv.Annotations == nil {
+		pv.Annotations = map[string]string{}
+	}
+	pv.Annotations[ReclaimPolicyAnnotationName] = string(pv.Spec.PersistentVolumeReclaimPolicy)
+	pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+	log.Info("Retaining volume for rename", "volume_name", volumeName, "previous_policy", pv.Annotations[ReclaimPolicyAnnotationName])
+	return c.Update(&pv)
+}
+
+// restoreVolume restores the reclaim policy of the given PersistentVolume before it was retained for a rename.
+func restoreVolume(c k8s.Client, volumeName string) error {
+	var pv corev1.PersistentVolume
+	if err := c.Get(types.NamespacedName{Name: volumeName}, &pv); err != nil {
+		return err
+	}
+	policy, exists := pv.Annotations[ReclaimPolicyAnnotationName]
+	if !exists {
+		return nil
+	}
+	pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimPolicy(policy)
+	delete(pv.Annotations, ReclaimPolicyAnnotationName)
+	return c.Update(&pv)
+}
+
+// reconcileHandover creates or updates the handover Secret of the target cluster in the operator namespace.
+func reconcileHandover(c k8s.Client, operatorNamespace string, handover Handover) error {
+	volumes, err := json.Marshal(handover.Volumes)
+	if err != nil {
+		return err
+	}
+	elasticUser, err := json.Marshal(handover.ElasticUser)
+	if err != nil {
+		return err
+	}
+	data := map[string][]byte{
+		sourceKey:      []byte(handover.Source.String()),
+		sourceUIDKey:   []byte(handover.SourceUID),
+		targetKey:      []byte(handover.Target.String()),
+		clusterUUIDKey: []byte(handover.ClusterUUID),
+		volumesKey:     volumes,
+		elasticUserKey: elasticUser,
+	}
+	nsn := types.NamespacedName{Namespace: operatorNamespace, Name: esv1.RenameSecret(handover.Target)}
+	var secret corev1.Secret
+	err = c.Get(nsn, &secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		// not owned by the renamed cluster, whose deletion would delete it, nor labelled with its name, not to be
+		// garbage collected with its other resources
+		return c.Create(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: nsn.Namespace, Name: nsn.Name}, Data: data})
+	case err != nil:
+		return err
+	case reflect.DeepEqual(secret.Data, data):
+		return nil
+	}
+	secret.Data = data
+	return c.Update(&secret)
+}
+
+// getHandover returns the handover Secret of the given target cluster, or nil if it does not exist.
+func getHandover(c k8s.Client, operatorNamespace string, target types.NamespacedName) (*Handover, error) {
+	var secret corev1.Secret
+	if err := c.Get(types.NamespacedName{Namespace: operatorNamespace, Name: esv1.RenameSecret(target)}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if string(secret.Data[targetKey]) != target.String() {
+		return nil, fmt.Errorf("rename Secret %s/%s is not the handover to %s", secret.Namespace, secret.Name, target)
+	}
+	source, err := esv1.ParseRenameReference(string(secret.Data[sourceKey]), target.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	handover := Handover{
+		Source:      source,
+		SourceUID:   types.UID(secret.Data[sourceUIDKey]),
+		Target:      target,
+		ClusterUUID: string(secret.Data[clusterUUIDKey]),
+	}
+	if err := json.Unmarshal(secret.Data[volumesKey], &handover.Volumes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(secret.Data[elasticUserKey], &handover.ElasticUser); err != nil {
+		return nil, err
+	}
+	return &handover, nil
+}
+
+// TakeOver takes over the volumes and credentials of the cluster the given cluster is renamed from, once it is
+// deleted: its PersistentVolumes are bound to PersistentVolumeClaims named after the StatefulSets of the new cluster,
+// the password of the elastic user is reused, and the new cluster is annotated with the UUID of the cluster for the
+// existing nodes not to be bootstrapped again. Only the volumes still claimed by the claims of the renamed cluster
+// listed in the handover are taken over. It returns true once the rename is completed, and the cluster must not be
+// reconciled until then.
+func TakeOver(c k8s.Client, operatorNamespace string, es *esv1.Elasticsearch, source types.NamespacedName) (commonv1.Condition, bool, error) {
+	var sourceES esv1.Elasticsearch
+	err := c.Get(source, &sourceES)
+	if err == nil {
+		return Condition(ReasonInProgress, fmt.Sprintf(
+			"Waiting for %s to be deleted: annotate it with %s set to %s, and delete it once its %s condition is %s",
+			source, esv1.RenameToAnnotation, k8s.ExtractNamespacedName(es), ConditionType, ReasonReady,
+		)), false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return commonv1.Condition{}, false, err
+	}
+	handover, err := getHandover(c, operatorNamespace, k8s.ExtractNamespacedName(es))
+	if err != nil {
+		return commonv1.Condition{}, false, err
+	}
+	if handover == nil || handover.Source != source {
+		return Condition(ReasonBlocked, fmt.Sprintf(
+			"No volumes handed over by %s: it must be annotated with %s set to %s before it is deleted",
+			source, esv1.RenameToAnnotation, k8s.ExtractNamespacedName(es),
+		)), false, nil
+	}
+
+	for _, volume := range handover.Volumes {
+		condition, err := takeOverVolume(c, *es, source, volume)
+		if err != nil {
+			return commonv1.Condition{}, false, err
+		}
+		if condition != nil {
+			return *condition, false, nil
+		}
+	}
+	if err := reuseElasticUser(c, *es, handover.ElasticUser); err != nil {
+		return commonv1.Condition{}, false, err
+	}
+	for _, volume := range handover.Volumes {
+		if err := restoreVolume(c, volume.VolumeName); err != nil {
+			return commonv1.Condition{}, false, err
+		}
+	}
+
+	if es.Annotations == nil {
+		es.Annotations = map[string]string{}
+	}
+	es.Annotations[bootstrap.ClusterUUIDAnnotationName] = handover.ClusterUUID
+	es.Annotations[CompletedAnnotationName] = source.String()
+	if err := c.Update(es); err != nil {
+		return commonv1.Condition{}, false, err
+	}
+	log.Info("Rename completed", "namespace", es.Namespace, "es_name", es.Name, "source", source.String(), "volumes", len(handover.Volumes))
+	return CompletedCondition(source), true, DeleteHandover(c, operatorNamespace, k8s.ExtractNamespacedName(es))
+}
+
+// CompletedCondition returns the rename condition of a cluster renamed from the given source.
+func CompletedCondition(source types.NamespacedName) commonv1.Condition {
+	return Condition(ReasonCompleted, fmt.Sprintf("Renamed from %s: its volumes and credentials are taken over", source))
+}
+
+// takeOverVolume binds the given PersistentVolume to a new PersistentVolumeClaim of the given cluster. It returns
+// the condition reporting what the take-over is waiting for, or nil once the claim is created.
+func takeOverVolume(c k8s.Client, es esv1.Elasticsearch, source types.NamespacedName, volume Volume) (*commonv1.Condition, error) {
+	var pvc corev1.PersistentVolumeClaim
+	err := c.Get(types.NamespacedName{Namespace: es.Namespace, Name: volume.ClaimName}, &pvc)
+	if err == nil {
+		if pvc.Spec.VolumeName != volume.VolumeName {
+			condition := Condition(ReasonInProgress, fmt.Sprintf(
+				"PersistentVolumeClaim %s already exists, but does not claim PersistentVolume %s: delete it",
+				volume.ClaimName, volume.VolumeName,
+			))
+			return &condition, nil
+		}
+		return nil, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var pv corev1.PersistentVolume
+	if err := c.Get(types.NamespacedName{Name: volume.VolumeName}, &pv); err != nil {
+		return nil, err
+	}
+	ref := pv.Spec.ClaimRef
+	switch {
+	case ref != nil && ref.Namespace == es.Namespace && ref.Name == volume.ClaimName:
+		// already reserved for the new claim
+	case ref != nil && ref.Namespace == source.Namespace && ref.Name == volume.SourceClaimName && ref.UID == volume.SourceClaimUID:
+		// still claimed by the renamed cluster until its claim is deleted
+		var claim corev1.PersistentVolumeClaim
+		err := c.Get(types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &claim)
+		if err == nil {
+			condition := Condition(ReasonInProgress, fmt.Sprintf("Waiting for PersistentVolumeClaim %s/%s to be deleted", ref.Namespace, ref.Name))
+			return &condition, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	default:
+		// never take over a volume which was not claimed by the renamed cluster
+		condition := Condition(ReasonBlocked, fmt.Sprintf(
+			"PersistentVolume %s is not claimed by PersistentVolumeClaim %s/%s of %s",
+			volume.VolumeName, source.Namespace, volume.SourceClaimName, source,
+		))
+		return &condition, nil
+	}
+	// reserve the volume for the new claim
+	pv.Spec.ClaimRef = &corev1.ObjectReference{
+		Kind:       "PersistentVolumeClaim",
+		APIVersion: "v1",
+		Namespace:  es.Namespace,
+		Name:       volume.ClaimName,
+	}
+	if err := c.Update(&pv); err != nil {
+		return nil, err
+	}
+
+	spec := volume.Spec
+	spec.VolumeName = volume.VolumeName
+	claim := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: es.Namespace,
+			Name:      volume.ClaimName,
+			Labels:    volume.Labels,
+		},
+		Spec: spec,
+	}
+	if err := controllerutil.SetControllerReference(&es, &claim, scheme.Scheme); err != nil {
+		return nil, err
+	}
+	log.Info("Taking over volume", "namespace", es.Namespace, "es_name", es.Name, "volume_name", volume.VolumeName, "claim_name", volume.ClaimName)
+	return nil, c.Create(&claim)
+}
+
+// reuseElasticUser creates the Secret of the elastic user of the given cluster with the given data, unless it exists.
+func reuseElasticUser(c k8s.Client, es esv1.Elasticsearch, data map[string][]byte) error {
+	nsn := types.NamespacedName{Namespace: es.Namespace, Name: esv1.ElasticUserSecret(es.Name)}
+	err := c.Get(nsn, &corev1.Secret{})
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: nsn.Namespace,
+			Name:      nsn.Name,
+			Labels:    label.NewLabels(k8s.ExtractNamespacedName(&es)),
+		},
+		Data: data,
+	}
+	if err := controllerutil.SetControllerReference(&es, &secret, scheme.Scheme); err != nil {
+		return err
+	}
+	return c.Create(&secret)
+}
+
+// DeleteHandover deletes the handover Secret of the given target cluster, if any.
+func DeleteHandover(c k8s.Client, operatorNamespace string, target types.NamespacedName) error {
+	secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: operatorNamespace, Name: esv1.RenameSecret(target)}}
+	if err := c.Delete(&secret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
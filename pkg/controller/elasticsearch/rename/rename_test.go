@@ -0,0 +1,337 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package rename
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/bootstrap"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_renamedClaim(t *testing.T) {
+	tests := []struct {
+		claimName string
+		want      string
+		wantOk    bool
+	}{
+		{claimName: "elasticsearch-data-old-es-default-0", want: "elasticsearch-data-new-es-default-0", wantOk: true},
+		{claimName: "old-es-default-old-es-default-12", want: "old-es-default-new-es-default-12", wantOk: true},
+		{claimName: "elasticsearch-data-old-es-other-0", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.claimName, func(t *testing.T) {
+			got, ok := renamedClaim(tt.claimName, "old-es-default", "new-es-default")
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrepareSource_TakeOver(t *testing.T) {
+	scheme.SetupScheme()
+	source := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "old",
+			UID:         "old-uid",
+			Annotations: map[string]string{esv1.RenameToAnnotation: "other/new"},
+		},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{{Name: "default", Count: 1}}},
+	}
+	target := types.NamespacedName{Namespace: "other", Name: "new"}
+	operatorNamespace := "elastic-system"
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "elasticsearch-data-old-es-default-0",
+			UID:       "pvc-uid",
+			Labels: map[string]string{
+				label.ClusterNameLabelName:     "old",
+				label.StatefulSetNameLabelName: "old-es-default",
+			},
+		},
+		Spec:   corev1.PersistentVolumeClaimSpec{VolumeName: "pv-0"},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	}
+	pv := corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-0"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			ClaimRef:                      &corev1.ObjectReference{Namespace: "ns", Name: pvc.Name, UID: pvc.UID},
+		},
+	}
+	elasticUser := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: esv1.ElasticUserSecret("old")},
+		Data:       map[string][]byte{"elastic": []byte("password")},
+	}
+	c := k8s.WrappedFakeClient(&source, &pvc, &pv, &elasticUser)
+
+	// the rename cannot be prepared before the cluster is bootstrapped
+	condition, err := PrepareSource(c, operatorNamespace, source, target)
+	require.NoError(t, err)
+	require.Equal(t, ReasonBlocked, condition.Reason)
+
+	source.Annotations[bootstrap.ClusterUUIDAnnotationName] = "uuid"
+	condition, err = PrepareSource(c, operatorNamespace, source, target)
+	require.NoError(t, err)
+	require.Equal(t, ReasonReady, condition.Reason)
+	require.Equal(t, corev1.ConditionTrue, condition.Status)
+
+	var retained corev1.PersistentVolume
+	require.NoError(t, c.Get(types.NamespacedName{Name: "pv-0"}, &retained))
+	require.Equal(t, corev1.PersistentVolumeReclaimRetain, retained.Spec.PersistentVolumeReclaimPolicy)
+	require.Equal(t, "Delete", retained.Annotations[ReclaimPolicyAnnotationName])
+
+	// the handover is kept in the operator namespace, out of reach of the users of the target namespace
+	var secrets corev1.SecretList
+	require.NoError(t, c.List(&secrets))
+	require.Len(t, secrets.Items, 2)
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: operatorNamespace, Name: esv1.RenameSecret(target)}, &corev1.Secret{}))
+
+	handover, err := getHandover(c, operatorNamespace, target)
+	require.NoError(t, err)
+	require.NotNil(t, handover)
+	require.Equal(t, types.NamespacedName{Namespace: "ns", Name: "old"}, handover.Source)
+	require.Equal(t, source.UID, handover.SourceUID)
+	require.Equal(t, target, handover.Target)
+	require.Equal(t, "uuid", handover.ClusterUUID)
+	require.Equal(t, elasticUser.Data, handover.ElasticUser)
+	require.Len(t, handover.Volumes, 1)
+	require.Equal(t, "elasticsearch-data-new-es-default-0", handover.Volumes[0].ClaimName)
+	require.Equal(t, "pv-0", handover.Volumes[0].VolumeName)
+	require.Equal(t, pvc.Name, handover.Volumes[0].SourceClaimName)
+	require.Equal(t, pvc.UID, handover.Volumes[0].SourceClaimUID)
+	require.Equal(t, "new", handover.Volumes[0].Labels[label.ClusterNameLabelName])
+	require.Equal(t, "new-es-default", handover.Volumes[0].Labels[label.StatefulSetNameLabelName])
+
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "other",
+			Name:        "new",
+			UID:         "new-uid",
+			Annotations: map[string]string{esv1.RenamedFromAnnotation: "ns/old"},
+		},
+		Spec: source.Spec,
+	}
+	require.NoError(t, c.Create(&es))
+	sourceNsn := k8s.ExtractNamespacedName(&source)
+
+	// the source cluster still exists
+	condition, completed, err := TakeOver(c, operatorNamespace, &es, sourceNsn)
+	require.NoError(t, err)
+	require.False(t, completed)
+	require.Equal(t, ReasonInProgress, condition.Reason)
+
+	// the claim of the source cluster still exists
+	require.NoError(t, c.Delete(&source))
+	condition, completed, err = TakeOver(c, operatorNamespace, &es, sourceNsn)
+	require.NoError(t, err)
+	require.False(t, completed)
+	require.Equal(t, ReasonInProgress, condition.Reason)
+	require.Contains(t, condition.Message, "ns/elasticsearch-data-old-es-default-0")
+
+	require.NoError(t, c.Delete(&pvc))
+	condition, completed, err = TakeOver(c, operatorNamespace, &es, sourceNsn)
+	require.NoError(t, err)
+	require.True(t, completed)
+	require.Equal(t, CompletedCondition(sourceNsn), condition)
+
+	var claim corev1.PersistentVolumeClaim
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "other", Name: "elasticsearch-data-new-es-default-0"}, &claim))
+	require.Equal(t, "pv-0", claim.Spec.VolumeName)
+	require.Equal(t, "new-es-default", claim.Labels[label.StatefulSetNameLabelName])
+	require.Len(t, claim.OwnerReferences, 1)
+	require.Equal(t, "new", claim.OwnerReferences[0].Name)
+
+	var restored corev1.PersistentVolume
+	require.NoError(t, c.Get(types.NamespacedName{Name: "pv-0"}, &restored))
+	require.Equal(t, corev1.PersistentVolumeReclaimDelete, restored.Spec.PersistentVolumeReclaimPolicy)
+	require.NotContains(t, restored.Annotations, ReclaimPolicyAnnotationName)
+	require.Equal(t, "other", restored.Spec.ClaimRef.Namespace)
+	require.Equal(t, claim.Name, restored.Spec.ClaimRef.Name)
+
+	var reused corev1.Secret
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "other", Name: esv1.ElasticUserSecret("new")}, &reused))
+	require.Equal(t, elasticUser.Data, reused.Data)
+
+	var updated esv1.Elasticsearch
+	require.NoError(t, c.Get(k8s.ExtractNamespacedName(&es), &updated))
+	require.Equal(t, "uuid", updated.Annotations[bootstrap.ClusterUUIDAnnotationName])
+	require.Equal(t, "ns/old", updated.Annotations[CompletedAnnotationName])
+
+	handover, err = getHandover(c, operatorNamespace, target)
+	require.NoError(t, err)
+	require.Nil(t, handover)
+}
+
+func TestPrepareSource_ZoneSpread(t *testing.T) {
+	scheme.SetupScheme()
+	source := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "old",
+			UID:       "old-uid",
+			Annotations: map[string]string{
+				esv1.RenameToAnnotation:             "ns/new",
+				bootstrap.ClusterUUIDAnnotationName: "uuid",
+			},
+		},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+			{Name: "default", Count: 1, ZoneSpread: &esv1.ZoneSpread{Zones: []string{"a", "b"}}},
+		}},
+	}
+	target := types.NamespacedName{Namespace: "ns", Name: "new"}
+	objs := []runtime.Object{&source, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: esv1.ElasticUserSecret("old")},
+		Data:       map[string][]byte{"elastic": []byte("password")},
+	}}
+	// one StatefulSet per zone
+	for _, ssetName := range []string{"old-es-default-a", "old-es-default-b"} {
+		pvc := corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      "elasticsearch-data-" + ssetName + "-0",
+				UID:       types.UID(ssetName + "-uid"),
+				Labels: map[string]string{
+					label.ClusterNameLabelName:     "old",
+					label.StatefulSetNameLabelName: ssetName,
+				},
+			},
+			Spec:   corev1.PersistentVolumeClaimSpec{VolumeName: "pv-" + ssetName},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		}
+		pv := corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvc.Spec.VolumeName},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "ns", Name: pvc.Name, UID: pvc.UID},
+			},
+		}
+		objs = append(objs, &pvc, &pv)
+	}
+	c := k8s.WrappedFakeClient(objs...)
+
+	condition, err := PrepareSource(c, "elastic-system", source, target)
+	require.NoError(t, err)
+	require.Equal(t, ReasonReady, condition.Reason)
+
+	handover, err := getHandover(c, "elastic-system", target)
+	require.NoError(t, err)
+	require.NotNil(t, handover)
+	claimNames := make([]string, 0, len(handover.Volumes))
+	for _, v := range handover.Volumes {
+		claimNames = append(claimNames, v.ClaimName)
+	}
+	require.ElementsMatch(t, []string{"elasticsearch-data-new-es-default-a-0", "elasticsearch-data-new-es-default-b-0"}, claimNames)
+	for _, volumeName := range []string{"pv-old-es-default-a", "pv-old-es-default-b"} {
+		var retained corev1.PersistentVolume
+		require.NoError(t, c.Get(types.NamespacedName{Name: volumeName}, &retained))
+		require.Equal(t, corev1.PersistentVolumeReclaimRetain, retained.Spec.PersistentVolumeReclaimPolicy, volumeName)
+	}
+}
+
+func TestPrepareSource_TargetOfAnotherRename(t *testing.T) {
+	scheme.SetupScheme()
+	target := types.NamespacedName{Namespace: "other", Name: "new"}
+	c := k8s.WrappedFakeClient()
+	require.NoError(t, reconcileHandover(c, "elastic-system", Handover{
+		Source:    types.NamespacedName{Namespace: "ns", Name: "first"},
+		SourceUID: "first-uid",
+		Target:    target,
+	}))
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "second",
+		UID:         "second-uid",
+		Annotations: map[string]string{bootstrap.ClusterUUIDAnnotationName: "uuid"},
+	}}
+	condition, err := PrepareSource(c, "elastic-system", es, target)
+	require.NoError(t, err)
+	require.Equal(t, ReasonBlocked, condition.Reason)
+	handover, err := getHandover(c, "elastic-system", target)
+	require.NoError(t, err)
+	require.Equal(t, "first", handover.Source.Name)
+}
+
+func TestTakeOver_VolumeNotClaimedBySource(t *testing.T) {
+	scheme.SetupScheme()
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "new"}}
+	source := types.NamespacedName{Namespace: "ns", Name: "old"}
+	handover := Handover{
+		Source: source,
+		Target: k8s.ExtractNamespacedName(&es),
+		Volumes: []Volume{{
+			ClaimName:       "elasticsearch-data-new-es-default-0",
+			VolumeName:      "pv-0",
+			SourceClaimName: "elasticsearch-data-old-es-default-0",
+			SourceClaimUID:  "pvc-uid",
+		}},
+	}
+	tests := []struct {
+		name     string
+		claimRef *corev1.ObjectReference
+	}{
+		{
+			name:     "volume of another namespace",
+			claimRef: &corev1.ObjectReference{Namespace: "tenant", Name: "elasticsearch-data-old-es-default-0", UID: "pvc-uid"},
+		},
+		{
+			name:     "volume of another claim",
+			claimRef: &corev1.ObjectReference{Namespace: "ns", Name: "data", UID: "pvc-uid"},
+		},
+		{
+			name:     "volume of a previous claim with the same name",
+			claimRef: &corev1.ObjectReference{Namespace: "ns", Name: "elasticsearch-data-old-es-default-0", UID: "previous-uid"},
+		},
+		{
+			name: "volume without claim",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pv := corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-0"},
+				Spec: corev1.PersistentVolumeSpec{
+					PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+					ClaimRef:                      tt.claimRef,
+				},
+			}
+			c := k8s.WrappedFakeClient(&es, &pv)
+			require.NoError(t, reconcileHandover(c, "elastic-system", handover))
+
+			condition, completed, err := TakeOver(c, "elastic-system", &es, source)
+			require.NoError(t, err)
+			require.False(t, completed)
+			require.Equal(t, ReasonBlocked, condition.Reason)
+
+			// the volume is left untouched
+			var actual corev1.PersistentVolume
+			require.NoError(t, c.Get(types.NamespacedName{Name: "pv-0"}, &actual))
+			require.Equal(t, tt.claimRef, actual.Spec.ClaimRef)
+			var claims corev1.PersistentVolumeClaimList
+			require.NoError(t, c.List(&claims))
+			require.Empty(t, claims.Items)
+		})
+	}
+}
+
+func TestTakeOver_NoHandover(t *testing.T) {
+	scheme.SetupScheme()
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "new"}}
+	condition, completed, err := TakeOver(k8s.WrappedFakeClient(&es), "elastic-system", &es, types.NamespacedName{Namespace: "ns", Name: "old"})
+	require.NoError(t, err)
+	require.False(t, completed)
+	require.Equal(t, ReasonBlocked, condition.Reason)
+}
@@ -0,0 +1,165 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// targetHeapUsedPercent is the average heap usage the recommendations aim at, leaving room for the garbage
+	// collections and the load peaks.
+	targetHeapUsedPercent = 60
+	// targetDiskUsedPercent is the disk usage the recommendations aim at, below the default low disk watermark.
+	targetDiskUsedPercent = 70
+	// minOverProvisionedPercent is the share of nodes that must be superfluous for a NodeSet to be reported as
+	// over-provisioned, to not report small fluctuations of the usage.
+	minOverProvisionedPercent = 20
+	// minUnderProvisionedRatio is the usage relative to the target above which a NodeSet is reported as
+	// under-provisioned.
+	minUnderProvisionedRatio = 1.25
+)
+
+// nodeSetUsage is the observed usage of the nodes of a NodeSet.
+type nodeSetUsage struct {
+	nodes           int
+	data            bool
+	masterOnly      bool
+	heapUsedPercent int
+	diskUsedPercent int
+	indexingRate    int64
+	searchRate      int64
+}
+
+// Recommendations returns right-sizing hints for the NodeSets of the given cluster whose observed usage departs from
+// the target usage, sorted by NodeSet. Rates are computed from the previous state if it holds the same nodes.
+// Dedicated master NodeSets are ignored: their size depends on the cluster state, and their count on the quorum.
+func Recommendations(cluster types.NamespacedName, previous observer.State, current observer.State) []esv1.SizingRecommendation {
+	if current.NodesStats == nil {
+		return nil
+	}
+	usages := nodeSetUsages(cluster, previous, current)
+	recommendations := make([]esv1.SizingRecommendation, 0, len(usages))
+	for nodeSet, usage := range usages {
+		if usage.masterOnly {
+			continue
+		}
+		if recommendation, ok := recommend(nodeSet, usage); ok {
+			recommendations = append(recommendations, recommendation)
+		}
+	}
+	if len(recommendations) == 0 {
+		return nil
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].NodeSet < recommendations[j].NodeSet
+	})
+	return recommendations
+}
+
+// nodeSetUsages aggregates the usage of the observed nodes by NodeSet.
+func nodeSetUsages(cluster types.NamespacedName, previous observer.State, current observer.State) map[string]nodeSetUsage {
+	previousNodes := map[string]esclient.NodeStats{}
+	if previous.NodesStats != nil {
+		for _, node := range previous.NodesStats.Nodes {
+			previousNodes[node.Name] = node
+		}
+	}
+	elapsed := current.StatsObservedAt.Sub(previous.StatsObservedAt).Seconds()
+	prefix := esv1.ESNamer.Suffix(cluster.Name) + "-"
+
+	heapSums := map[string]int{}
+	usages := map[string]nodeSetUsage{}
+	for _, node := range current.NodesStats.Nodes {
+		if !strings.HasPrefix(node.Name, prefix) {
+			// not a node managed by the operator
+			continue
+		}
+		ssetName, _, err := sset.StatefulSetName(node.Name)
+		if err != nil {
+			continue
+		}
+		nodeSet := strings.TrimPrefix(ssetName, prefix)
+		usage, exists := usages[nodeSet]
+		if !exists {
+			usage.masterOnly = true
+		}
+		usage.nodes++
+		heapSums[nodeSet] += node.JVM.Mem.HeapUsedPercent
+		usage.masterOnly = usage.masterOnly && isMasterOnlyNode(node)
+		if total, available := node.FS.Total.TotalInBytes, node.FS.Total.AvailableInBytes; isDataNode(node) && total > 0 {
+			usage.data = true
+			if usedPercent := int((total - available) * 100 / total); usedPercent > usage.diskUsedPercent {
+				usage.diskUsedPercent = usedPercent
+			}
+		}
+		if before, exists := previousNodes[node.Name]; exists && elapsed > 0 {
+			// counters are reset when the node restarts
+			if indexed := node.Indices.Indexing.IndexTotal - before.Indices.Indexing.IndexTotal; indexed > 0 {
+				usage.indexingRate += int64(float64(indexed) / elapsed)
+			}
+			if queries := node.Indices.Search.QueryTotal - before.Indices.Search.QueryTotal; queries > 0 {
+				usage.searchRate += int64(float64(queries) / elapsed)
+			}
+		}
+		usages[nodeSet] = usage
+	}
+	for nodeSet, usage := range usages {
+		usage.heapUsedPercent = heapSums[nodeSet] / usage.nodes
+		usages[nodeSet] = usage
+	}
+	return usages
+}
+
+// recommend returns the recommendation for the given NodeSet usage, or false if its capacity matches the usage.
+func recommend(nodeSet string, usage nodeSetUsage) (esv1.SizingRecommendation, bool) {
+	ratio := float64(usage.heapUsedPercent) / targetHeapUsedPercent
+	if usage.data {
+		ratio = math.Max(ratio, float64(usage.diskUsedPercent)/targetDiskUsedPercent)
+	}
+	recommendedNodes := int(math.Ceil(float64(usage.nodes) * ratio))
+	if recommendedNodes < 1 {
+		recommendedNodes = 1
+	}
+	recommendation := esv1.SizingRecommendation{
+		NodeSet:          nodeSet,
+		Nodes:            usage.nodes,
+		RecommendedNodes: recommendedNodes,
+		HeapUsedPercent:  usage.heapUsedPercent,
+		DiskUsedPercent:  usage.diskUsedPercent,
+		IndexingRate:     usage.indexingRate,
+		SearchRate:       usage.searchRate,
+	}
+	switch {
+	case (usage.nodes-recommendedNodes)*100/usage.nodes >= minOverProvisionedPercent:
+		recommendation.Assessment = esv1.OverProvisioned
+		recommendation.Message = fmt.Sprintf("%s over-provisioned by ~%d%%: %d of %d nodes would keep the usage under target",
+			nodeSet, (usage.nodes-recommendedNodes)*100/usage.nodes, recommendedNodes, usage.nodes)
+	case ratio >= minUnderProvisionedRatio:
+		recommendation.Assessment = esv1.UnderProvisioned
+		recommendation.Message = fmt.Sprintf("%s under-provisioned by ~%d%%: %d nodes needed to bring the usage under target",
+			nodeSet, (recommendedNodes-usage.nodes)*100/usage.nodes, recommendedNodes)
+	default:
+		return esv1.SizingRecommendation{}, false
+	}
+	return recommendation, true
+}
+
+// isMasterOnlyNode returns true if the node is a dedicated master node.
+func isMasterOnlyNode(node esclient.NodeStats) bool {
+	return stringsutil.StringInSlice("master", node.Roles) && !isDataNode(node) &&
+		!stringsutil.StringInSlice("ingest", node.Roles) && !stringsutil.StringInSlice("ml", node.Roles)
+}
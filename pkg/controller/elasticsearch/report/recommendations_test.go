@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+)
+
+func nodeStatsWithCounters(name string, roles []string, heapUsedPercent int, diskUsedPercent int64, indexed, queries int64) esclient.NodeStats {
+	node := nodeStats(name, roles, 100, 100-diskUsedPercent, heapUsedPercent)
+	node.Indices.Indexing.IndexTotal = indexed
+	node.Indices.Search.QueryTotal = queries
+	return node
+}
+
+func TestRecommendations(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns", Name: "es"}
+	observedAt := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	previous := observer.State{
+		StatsObservedAt: observedAt.Add(-10 * time.Second),
+		NodesStats: &esclient.NodesStats{Nodes: map[string]esclient.NodeStats{
+			"w0": nodeStatsWithCounters("es-es-warm-0", []string{"data"}, 30, 30, 1000, 100),
+			"w1": nodeStatsWithCounters("es-es-warm-1", []string{"data"}, 30, 30, 1000, 100),
+		}},
+	}
+	current := observer.State{
+		StatsObservedAt: observedAt,
+		NodesStats: &esclient.NodesStats{Nodes: map[string]esclient.NodeStats{
+			// dedicated master nodes are not assessed
+			"m0": nodeStatsWithCounters("es-es-master-0", []string{"master"}, 10, 1, 0, 0),
+			// heap and disk usage well under target
+			"w0": nodeStatsWithCounters("es-es-warm-0", []string{"data"}, 30, 38, 2000, 140),
+			"w1": nodeStatsWithCounters("es-es-warm-1", []string{"data"}, 32, 30, 1600, 120),
+			"w2": nodeStatsWithCounters("es-es-warm-2", []string{"data"}, 31, 30, 10, 1),
+			"w3": nodeStatsWithCounters("es-es-warm-3", []string{"data"}, 29, 30, 0, 0),
+			"w4": nodeStatsWithCounters("es-es-warm-4", []string{"data"}, 33, 30, 0, 0),
+			// disk usage over target
+			"h0": nodeStatsWithCounters("es-es-hot-0", []string{"data", "ingest"}, 50, 80, 0, 0),
+			"h1": nodeStatsWithCounters("es-es-hot-1", []string{"data", "ingest"}, 50, 95, 0, 0),
+			// usage close to target
+			"i0": nodeStatsWithCounters("es-es-ingest-0", []string{"ingest"}, 60, 90, 0, 0),
+			// nodes not managed by the operator
+			"x0": nodeStatsWithCounters("other", []string{"data"}, 99, 99, 0, 0),
+		}},
+	}
+
+	recommendations := Recommendations(cluster, previous, current)
+	require.Equal(t, []esv1.SizingRecommendation{
+		{
+			NodeSet:          "hot",
+			Assessment:       esv1.UnderProvisioned,
+			Nodes:            2,
+			RecommendedNodes: 3,
+			HeapUsedPercent:  50,
+			DiskUsedPercent:  95,
+			Message:          "hot under-provisioned by ~50%: 3 nodes needed to bring the usage under target",
+		},
+		{
+			NodeSet:          "warm",
+			Assessment:       esv1.OverProvisioned,
+			Nodes:            5,
+			RecommendedNodes: 3,
+			HeapUsedPercent:  31,
+			DiskUsedPercent:  38,
+			// the counters of the nodes observed twice, over 10 seconds
+			IndexingRate: 160,
+			SearchRate:   6,
+			Message:      "warm over-provisioned by ~40%: 3 of 5 nodes would keep the usage under target",
+		},
+	}, recommendations)
+
+	// nothing to recommend without nodes stats
+	require.Nil(t, Recommendations(cluster, previous, observer.State{ObservedAt: observedAt}))
+}
@@ -0,0 +1,159 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package report
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// DefaultInterval is the default minimum interval between two updates of a report, unless the health, the exceeded
+// disk watermark or the license of the cluster change.
+const DefaultInterval = 1 * time.Minute
+
+var log = logf.Log.WithName("elasticsearch-report")
+
+type writtenReport struct {
+	status    esv1.ElasticsearchReportStatus
+	writtenAt time.Time
+}
+
+// Reporter maintains an ElasticsearchReport for each observed cluster, from the states retrieved by the observers.
+type Reporter struct {
+	client   k8s.Client
+	interval time.Duration
+	now      func() time.Time
+	// recommend adds right-sizing recommendations to the reports
+	recommend bool
+
+	mutex   sync.Mutex
+	written map[types.NamespacedName]writtenReport
+}
+
+// NewReporter returns a Reporter updating reports at most every interval, with right-sizing recommendations if
+// recommend is true.
+func NewReporter(client k8s.Client, interval time.Duration, recommend bool) *Reporter {
+	return &Reporter{
+		client:    client,
+		interval:  interval,
+		now:       time.Now,
+		recommend: recommend,
+		written:   make(map[types.NamespacedName]writtenReport),
+	}
+}
+
+// OnObservation updates the report of the given cluster if needed. It implements observer.OnObservation.
+func (r *Reporter) OnObservation(cluster types.NamespacedName, previousState observer.State, newState observer.State) {
+	status := NewStatus(newState)
+	if r.recommend {
+		status.Recommendations = r.recommendations(cluster, previousState, newState)
+	}
+	if !r.needsUpdate(cluster, status) {
+		return
+	}
+	if err := r.update(cluster, status); err != nil {
+		log.Error(err, "Failed to update the Elasticsearch report", "namespace", cluster.Namespace, "es_name", cluster.Name)
+	}
+}
+
+// recommendations returns the right-sizing recommendations of the given cluster. The nodes stats are retrieved less
+// often than the cluster is observed: the last recommendations are kept until they are retrieved again, as rates cannot
+// be computed from the same stats.
+func (r *Reporter) recommendations(cluster types.NamespacedName, previousState observer.State, newState observer.State) []esv1.SizingRecommendation {
+	if newState.NodesStats != nil && newState.StatsObservedAt.Equal(previousState.StatsObservedAt) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		return r.written[cluster].status.Recommendations
+	}
+	return Recommendations(cluster, previousState, newState)
+}
+
+// needsUpdate returns true if the report was never written, if a significant field changed or if the last update
+// is older than the interval.
+func (r *Reporter) needsUpdate(cluster types.NamespacedName, status esv1.ElasticsearchReportStatus) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	last, exists := r.written[cluster]
+	switch {
+	case !exists:
+		return true
+	case last.status.Health != status.Health || last.status.License != status.License:
+		return true
+	case watermark(last.status) != watermark(status):
+		return true
+	default:
+		return r.now().Sub(last.writtenAt) >= r.interval
+	}
+}
+
+func watermark(status esv1.ElasticsearchReportStatus) esv1.DiskWatermark {
+	if status.Disk == nil {
+		return ""
+	}
+	return status.Disk.Watermark
+}
+
+// update creates or updates the report of the given cluster, owned by the cluster.
+func (r *Reporter) update(cluster types.NamespacedName, status esv1.ElasticsearchReportStatus) error {
+	var es esv1.Elasticsearch
+	if err := r.client.Get(cluster, &es); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(cluster)
+			return nil
+		}
+		return err
+	}
+	if es.IsMarkedForDeletion() {
+		r.forget(cluster)
+		return nil
+	}
+
+	var report esv1.ElasticsearchReport
+	err := r.client.Get(cluster, &report)
+	switch {
+	case apierrors.IsNotFound(err):
+		report = esv1.ElasticsearchReport{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: cluster.Name},
+			Status:     status,
+		}
+		if err := controllerutil.SetControllerReference(&es, &report, scheme.Scheme); err != nil {
+			return err
+		}
+		log.V(1).Info("Creating Elasticsearch report", "namespace", cluster.Namespace, "es_name", cluster.Name)
+		err = r.client.Create(&report)
+	case err != nil:
+		return err
+	case !reflect.DeepEqual(report.Status, status):
+		report.Status = status
+		log.V(1).Info("Updating Elasticsearch report", "namespace", cluster.Namespace, "es_name", cluster.Name)
+		err = r.client.Update(&report)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.written[cluster] = writtenReport{status: status, writtenAt: r.now()}
+	return nil
+}
+
+func (r *Reporter) forget(cluster types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.written, cluster)
+}
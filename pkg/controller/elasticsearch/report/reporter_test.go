@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func TestReporter_OnObservation(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns", Name: "es"}
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", UID: "uid"}}
+	c := k8s.WrappedFakeClient(&es)
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	r := NewReporter(c, time.Minute, false)
+	r.now = func() time.Time { return now }
+
+	observe := func(health esv1.ElasticsearchHealth, shards int) {
+		r.OnObservation(cluster, observer.State{}, observer.State{
+			ObservedAt:    now,
+			ClusterHealth: &esclient.Health{Status: health, ActiveShards: shards},
+		})
+	}
+	getReport := func() esv1.ElasticsearchReport {
+		var report esv1.ElasticsearchReport
+		require.NoError(t, c.Get(cluster, &report))
+		return report
+	}
+
+	// the report is created, owned by the cluster
+	observe(esv1.ElasticsearchGreenHealth, 10)
+	report := getReport()
+	require.Equal(t, esv1.ElasticsearchGreenHealth, report.Status.Health)
+	require.Equal(t, 10, report.Status.Shards.Total)
+	require.Len(t, report.OwnerReferences, 1)
+	require.Equal(t, "es", report.OwnerReferences[0].Name)
+
+	// minor changes are not written before the interval
+	now = now.Add(10 * time.Second)
+	observe(esv1.ElasticsearchGreenHealth, 11)
+	require.Equal(t, 10, getReport().Status.Shards.Total)
+
+	// health changes are written immediately
+	now = now.Add(10 * time.Second)
+	observe(esv1.ElasticsearchYellowHealth, 11)
+	require.Equal(t, esv1.ElasticsearchYellowHealth, getReport().Status.Health)
+	require.Equal(t, 11, getReport().Status.Shards.Total)
+
+	// minor changes are written after the interval
+	now = now.Add(time.Minute)
+	observe(esv1.ElasticsearchYellowHealth, 12)
+	require.Equal(t, 12, getReport().Status.Shards.Total)
+
+	// no report for a deleted cluster
+	require.NoError(t, c.Delete(&es))
+	require.NoError(t, c.Delete(&report))
+	now = now.Add(time.Minute)
+	observe(esv1.ElasticsearchRedHealth, 12)
+	require.True(t, apierrors.IsNotFound(c.Get(cluster, &esv1.ElasticsearchReport{})))
+	require.Empty(t, r.written)
+}
+
+func TestReporter_OnObservation_ReusedStats(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "ns", Name: "es"}
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", UID: "uid"}}
+	c := k8s.WrappedFakeClient(&es)
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	r := NewReporter(c, time.Minute, true)
+	r.now = func() time.Time { return now }
+
+	stats := func(indexed int64) *esclient.NodesStats {
+		nodes := map[string]esclient.NodeStats{}
+		for i := 0; i < 5; i++ {
+			name := fmt.Sprintf("es-es-warm-%d", i)
+			nodes[name] = nodeStatsWithCounters(name, []string{"data"}, 30, 30, indexed, 0)
+		}
+		return &esclient.NodesStats{Nodes: nodes}
+	}
+	previous := observer.State{ObservedAt: now.Add(-10 * time.Second), StatsObservedAt: now.Add(-10 * time.Second), NodesStats: stats(0)}
+	current := observer.State{ObservedAt: now, StatsObservedAt: now, NodesStats: stats(1000)}
+	indexingRate := func() int64 {
+		var report esv1.ElasticsearchReport
+		require.NoError(t, c.Get(cluster, &report))
+		require.Len(t, report.Status.Recommendations, 1)
+		return report.Status.Recommendations[0].IndexingRate
+	}
+
+	r.OnObservation(cluster, previous, current)
+	require.Equal(t, int64(500), indexingRate())
+
+	// the rates are kept while the stats are reused by the next observations
+	now = now.Add(time.Minute)
+	reused := current
+	reused.ObservedAt = now
+	r.OnObservation(cluster, current, reused)
+	require.Equal(t, int64(500), indexingRate())
+}
@@ -0,0 +1,201 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package report
+
+import (
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+)
+
+// NewStatus summarizes the given observed state. Parts of the state that could not be observed are left empty.
+func NewStatus(state observer.State) esv1.ElasticsearchReportStatus {
+	status := esv1.ElasticsearchReportStatus{
+		ObservedAt: metav1.NewTime(state.ObservedAt),
+		Health:     esv1.ElasticsearchUnknownHealth,
+	}
+	if state.ClusterLicense != nil {
+		status.License = state.ClusterLicense.Type
+	}
+	if health := state.ClusterHealth; health != nil {
+		status.Health = health.Status
+		status.Nodes = health.NumberOfNodes
+		status.Shards = shardsReport(*health, state.CapacitySettings)
+	}
+	if state.NodesStats != nil {
+		status.Disk = DiskUsage(state)
+		status.JVM = jvmReport(*state.NodesStats)
+	}
+	if state.CCRStats != nil {
+		status.Replication = replicationReport(*state.CCRStats)
+	}
+	return status
+}
+
+// DiskUsage returns the disk usage of the data nodes of the observed cluster, or nil if it could not be observed.
+func DiskUsage(state observer.State) *esv1.DiskReport {
+	if state.NodesStats == nil {
+		return nil
+	}
+	return diskReport(*state.NodesStats, state.CapacitySettings)
+}
+
+func shardsReport(health esclient.Health, settings *esclient.CapacitySettings) *esv1.ShardsReport {
+	report := esv1.ShardsReport{
+		// active shards include relocating shards
+		Total:      health.ActiveShards + health.InitializingShards + health.UnassignedShards,
+		Unassigned: health.UnassignedShards,
+	}
+	if settings == nil {
+		return &report
+	}
+	// the limit applies to the number of data nodes, not set before 6.5
+	if maxShardsPerNode, err := strconv.Atoi(settings.MaxShardsPerNode); err == nil && health.NumberOfDataNodes > 0 {
+		report.Limit = maxShardsPerNode * health.NumberOfDataNodes
+		report.UsedPercent = report.Total * 100 / report.Limit
+	}
+	return &report
+}
+
+func diskReport(stats esclient.NodesStats, settings *esclient.CapacitySettings) *esv1.DiskReport {
+	var report *esv1.DiskReport
+	for _, node := range stats.Nodes {
+		total, available := node.FS.Total.TotalInBytes, node.FS.Total.AvailableInBytes
+		if !isDataNode(node) || total <= 0 {
+			continue
+		}
+		usedPercent := int((total - available) * 100 / total)
+		watermark := exceededWatermark(settings, total, available)
+		if report == nil {
+			report = &esv1.DiskReport{Watermark: esv1.DiskWatermarkNone}
+		}
+		if report.Watermark.Less(watermark) {
+			report.Watermark = watermark
+		}
+		if report.Node == "" || usedPercent > report.MaxUsedPercent {
+			report.MaxUsedPercent = usedPercent
+			report.Node = node.Name
+		}
+	}
+	return report
+}
+
+func jvmReport(stats esclient.NodesStats) *esv1.JVMReport {
+	var report *esv1.JVMReport
+	for _, node := range stats.Nodes {
+		heapUsedPercent := node.JVM.Mem.HeapUsedPercent
+		if report == nil || heapUsedPercent > report.MaxHeapUsedPercent {
+			report = &esv1.JVMReport{MaxHeapUsedPercent: heapUsedPercent, Node: node.Name}
+		}
+	}
+	return report
+}
+
+func replicationReport(stats esclient.CCRStats) *esv1.ReplicationReport {
+	if len(stats.FollowStats.Indices) == 0 {
+		return nil
+	}
+	report := esv1.ReplicationReport{FollowerIndices: len(stats.FollowStats.Indices)}
+	for _, index := range stats.FollowStats.Indices {
+		var lag int64
+		failing := false
+		for _, shard := range index.Shards {
+			if shardLag := shard.LeaderGlobalCheckpoint - shard.FollowerGlobalCheckpoint; shardLag > 0 {
+				lag += shardLag
+			}
+			failing = failing || len(shard.ReadExceptions) > 0
+		}
+		if failing {
+			report.FailingIndices++
+		}
+		if report.Index == "" || lag > report.MaxOperationsLag {
+			report.MaxOperationsLag = lag
+			report.Index = index.Index
+		}
+	}
+	return &report
+}
+
+// isDataNode returns true if the node holds data, including data tiers roles.
+func isDataNode(node esclient.NodeStats) bool {
+	for _, role := range node.Roles {
+		if strings.HasPrefix(role, "data") {
+			return true
+		}
+	}
+	return false
+}
+
+// exceededWatermark returns the highest disk watermark exceeded by the given disk usage. Watermarks are ignored if
+// the settings could not be observed.
+func exceededWatermark(settings *esclient.CapacitySettings, total, available int64) esv1.DiskWatermark {
+	if settings == nil {
+		return esv1.DiskWatermarkNone
+	}
+	for _, w := range []struct {
+		watermark esv1.DiskWatermark
+		value     string
+	}{
+		{watermark: esv1.DiskWatermarkFloodStage, value: settings.DiskWatermarkFloodStage},
+		{watermark: esv1.DiskWatermarkHigh, value: settings.DiskWatermarkHigh},
+		{watermark: esv1.DiskWatermarkLow, value: settings.DiskWatermarkLow},
+	} {
+		if exceeds(w.value, total, available) {
+			return w.watermark
+		}
+	}
+	return esv1.DiskWatermarkNone
+}
+
+// exceeds returns true if the given disk usage exceeds the given watermark, expressed either as a percentage or ratio
+// of used disk, or as an absolute amount of free disk.
+func exceeds(watermark string, total, available int64) bool {
+	watermark = strings.TrimSpace(strings.ToLower(watermark))
+	used := float64(total-available) / float64(total)
+	if strings.HasSuffix(watermark, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(watermark, "%"), 64)
+		return err == nil && used*100 >= percent
+	}
+	if ratio, err := strconv.ParseFloat(watermark, 64); err == nil {
+		return used >= ratio
+	}
+	if freeBytes, ok := parseByteSize(watermark); ok {
+		return available <= freeBytes
+	}
+	return false
+}
+
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// longer suffixes first
+	{suffix: "kb", multiplier: 1 << 10},
+	{suffix: "mb", multiplier: 1 << 20},
+	{suffix: "gb", multiplier: 1 << 30},
+	{suffix: "tb", multiplier: 1 << 40},
+	{suffix: "pb", multiplier: 1 << 50},
+	{suffix: "b", multiplier: 1},
+}
+
+// parseByteSize parses a byte size value in the Elasticsearch format, such as 500mb.
+func parseByteSize(value string) (int64, bool) {
+	for _, unit := range byteUnits {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+		size, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+		if err != nil {
+			return 0, false
+		}
+		return int64(size * float64(unit.multiplier)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,192 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/observer"
+)
+
+const gb = int64(1 << 30)
+
+func nodeStats(name string, roles []string, totalGB, availableGB int64, heapUsedPercent int) esclient.NodeStats {
+	var node esclient.NodeStats
+	node.Name = name
+	node.Roles = roles
+	node.FS.Total.TotalInBytes = totalGB * gb
+	node.FS.Total.AvailableInBytes = availableGB * gb
+	node.JVM.Mem.HeapUsedPercent = heapUsedPercent
+	return node
+}
+
+var defaultCapacitySettings = esclient.CapacitySettings{
+	DiskWatermarkLow:        "85%",
+	DiskWatermarkHigh:       "90%",
+	DiskWatermarkFloodStage: "95%",
+	MaxShardsPerNode:        "1000",
+}
+
+func TestNewStatus(t *testing.T) {
+	observedAt := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name  string
+		state observer.State
+		want  esv1.ElasticsearchReportStatus
+	}{
+		{
+			name:  "nothing observed",
+			state: observer.State{ObservedAt: observedAt},
+			want: esv1.ElasticsearchReportStatus{
+				ObservedAt: metav1.NewTime(observedAt),
+				Health:     esv1.ElasticsearchUnknownHealth,
+			},
+		},
+		{
+			name: "everything observed",
+			state: observer.State{
+				ObservedAt: observedAt,
+				ClusterHealth: &esclient.Health{
+					Status:             esv1.ElasticsearchYellowHealth,
+					NumberOfNodes:      3,
+					NumberOfDataNodes:  2,
+					ActiveShards:       500,
+					InitializingShards: 1,
+					UnassignedShards:   99,
+				},
+				ClusterLicense: &esclient.License{Type: "platinum"},
+				NodesStats: &esclient.NodesStats{Nodes: map[string]esclient.NodeStats{
+					"a": nodeStats("master", []string{"master"}, 100, 1, 80),
+					"b": nodeStats("data-1", []string{"data", "ingest"}, 100, 12, 40),
+					"c": nodeStats("data-2", []string{"data_hot"}, 100, 50, 30),
+				}},
+				CapacitySettings: &defaultCapacitySettings,
+			},
+			want: esv1.ElasticsearchReportStatus{
+				ObservedAt: metav1.NewTime(observedAt),
+				Health:     esv1.ElasticsearchYellowHealth,
+				Nodes:      3,
+				License:    "platinum",
+				// the master node is not a data node
+				Disk:   &esv1.DiskReport{Watermark: esv1.DiskWatermarkLow, MaxUsedPercent: 88, Node: "data-1"},
+				Shards: &esv1.ShardsReport{Total: 600, Unassigned: 99, Limit: 2000, UsedPercent: 30},
+				JVM:    &esv1.JVMReport{MaxHeapUsedPercent: 80, Node: "master"},
+			},
+		},
+		{
+			name: "capacity settings not observed",
+			state: observer.State{
+				ObservedAt:    observedAt,
+				ClusterHealth: &esclient.Health{Status: esv1.ElasticsearchGreenHealth, NumberOfNodes: 1, NumberOfDataNodes: 1, ActiveShards: 10},
+				NodesStats: &esclient.NodesStats{Nodes: map[string]esclient.NodeStats{
+					"a": nodeStats("node", []string{"master", "data"}, 100, 1, 10),
+				}},
+			},
+			want: esv1.ElasticsearchReportStatus{
+				ObservedAt: metav1.NewTime(observedAt),
+				Health:     esv1.ElasticsearchGreenHealth,
+				Nodes:      1,
+				Disk:       &esv1.DiskReport{Watermark: esv1.DiskWatermarkNone, MaxUsedPercent: 99, Node: "node"},
+				Shards:     &esv1.ShardsReport{Total: 10},
+				JVM:        &esv1.JVMReport{MaxHeapUsedPercent: 10, Node: "node"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, NewStatus(tt.state))
+		})
+	}
+}
+
+func Test_exceededWatermark(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    esclient.CapacitySettings
+		availableGB int64
+		want        esv1.DiskWatermark
+	}{
+		{name: "below all watermarks", settings: defaultCapacitySettings, availableGB: 50, want: esv1.DiskWatermarkNone},
+		{name: "low", settings: defaultCapacitySettings, availableGB: 15, want: esv1.DiskWatermarkLow},
+		{name: "high", settings: defaultCapacitySettings, availableGB: 8, want: esv1.DiskWatermarkHigh},
+		{name: "flood stage", settings: defaultCapacitySettings, availableGB: 2, want: esv1.DiskWatermarkFloodStage},
+		{
+			name:        "ratios",
+			settings:    esclient.CapacitySettings{DiskWatermarkLow: "0.5", DiskWatermarkHigh: "0.7"},
+			availableGB: 40,
+			want:        esv1.DiskWatermarkLow,
+		},
+		{
+			name:        "absolute free disk",
+			settings:    esclient.CapacitySettings{DiskWatermarkLow: "50gb", DiskWatermarkHigh: "20gb", DiskWatermarkFloodStage: "10GB"},
+			availableGB: 10,
+			want:        esv1.DiskWatermarkFloodStage,
+		},
+		{
+			name:        "invalid values are ignored",
+			settings:    esclient.CapacitySettings{DiskWatermarkLow: "lots"},
+			availableGB: 1,
+			want:        esv1.DiskWatermarkNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, exceededWatermark(&tt.settings, 100*gb, tt.availableGB*gb))
+		})
+	}
+}
+
+func Test_replicationReport(t *testing.T) {
+	shard := func(index string, leader, follower int64, failing bool) esclient.FollowShardStats {
+		s := esclient.FollowShardStats{FollowerIndex: index, LeaderGlobalCheckpoint: leader, FollowerGlobalCheckpoint: follower}
+		if failing {
+			s.ReadExceptions = append(s.ReadExceptions, struct {
+				FromSeqNo int64 `json:"from_seq_no"`
+			}{FromSeqNo: follower})
+		}
+		return s
+	}
+	tests := []struct {
+		name    string
+		indices []esclient.FollowIndexStats
+		want    *esv1.ReplicationReport
+	}{
+		{
+			name: "no follower index",
+			want: nil,
+		},
+		{
+			name: "follower indices up to date",
+			indices: []esclient.FollowIndexStats{
+				{Index: "a", Shards: []esclient.FollowShardStats{shard("a", 10, 10, false)}},
+				// the follower checkpoint may be ahead of the last leader checkpoint it knows of
+				{Index: "b", Shards: []esclient.FollowShardStats{shard("b", 4, 5, false)}},
+			},
+			want: &esv1.ReplicationReport{FollowerIndices: 2, Index: "a"},
+		},
+		{
+			name: "lag of all the shards of the index",
+			indices: []esclient.FollowIndexStats{
+				{Index: "a", Shards: []esclient.FollowShardStats{shard("a", 100, 90, false)}},
+				{Index: "b", Shards: []esclient.FollowShardStats{shard("b", 100, 95, false), shard("b", 100, 90, true)}},
+				{Index: "c", Shards: []esclient.FollowShardStats{shard("c", 10, 0, true)}},
+			},
+			want: &esv1.ReplicationReport{FollowerIndices: 3, MaxOperationsLag: 15, Index: "b", FailingIndices: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stats esclient.CCRStats
+			stats.FollowStats.Indices = tt.indices
+			require.Equal(t, tt.want, replicationReport(stats))
+		})
+	}
+}
@@ -54,6 +54,15 @@ func NewTransportService(es esv1.Elasticsearch) *corev1.Service {
 			Port:     network.TransportPort,
 		},
 	}
+	if es.Spec.RemoteClusterServer.Enabled {
+		// ports must be named once there are several of them
+		ports[0].Name = "transport"
+		ports = append(ports, corev1.ServicePort{
+			Name:     "remote-cluster",
+			Protocol: corev1.ProtocolTCP,
+			Port:     network.RemoteClusterPort,
+		})
+	}
 
 	return defaults.SetServiceDefaults(&svc, labels, labels, ports)
 }
@@ -69,9 +78,15 @@ func ExternalTransportServiceHost(es types.NamespacedName) string {
 	return stringsutil.Concat(TransportServiceName(es.Name), ".", es.Namespace, globalServiceSuffix, ":", strconv.Itoa(network.TransportPort))
 }
 
+// RemoteClusterServerHost returns the address of the remote cluster server of the given cluster, exposed by its transport
+// service.
+func RemoteClusterServerHost(es types.NamespacedName) string {
+	return stringsutil.Concat(TransportServiceName(es.Name), ".", es.Namespace, globalServiceSuffix, ":", strconv.Itoa(network.RemoteClusterPort))
+}
+
 // ExternalServiceURL returns the URL used to reach Elasticsearch's external endpoint
 func ExternalServiceURL(es esv1.Elasticsearch) string {
-	return stringsutil.Concat(es.Spec.HTTP.Protocol(), "://", ExternalServiceName(es.Name), ".", es.Namespace, globalServiceSuffix, ":", strconv.Itoa(network.HTTPPort))
+	return stringsutil.Concat(es.Spec.EffectiveHTTP().Protocol(), "://", ExternalServiceName(es.Name), ".", es.Namespace, globalServiceSuffix, ":", strconv.Itoa(network.HTTPPort))
 }
 
 // NewExternalService returns the external service associated to the given cluster
@@ -90,7 +105,7 @@ func NewExternalService(es esv1.Elasticsearch) *corev1.Service {
 	labels := label.NewLabels(nsn)
 	ports := []corev1.ServicePort{
 		{
-			Name:     es.Spec.HTTP.Protocol(),
+			Name:     es.Spec.EffectiveHTTP().Protocol(),
 			Protocol: corev1.ProtocolTCP,
 			Port:     network.HTTPPort,
 		},
@@ -138,7 +153,7 @@ func ElasticsearchURL(es esv1.Elasticsearch, pods []corev1.Pod) string {
 	var schemeChange bool
 	for _, p := range pods {
 		scheme, exists := p.Labels[label.HTTPSchemeLabelName]
-		if exists && scheme != es.Spec.HTTP.Protocol() {
+		if exists && scheme != es.Spec.EffectiveHTTP().Protocol() {
 			// scheme in existing pods does not match scheme in spec, user toggled HTTP(S)
 			schemeChange = true
 		}
@@ -146,11 +161,22 @@ func ElasticsearchURL(es esv1.Elasticsearch, pods []corev1.Pod) string {
 	if schemeChange {
 		// switch to sending requests directly to a random pod instead of going through the service
 		randomPod := pods[rand.Intn(len(pods))]
-		scheme, hasScheme := randomPod.Labels[label.HTTPSchemeLabelName]
-		sset, hasSset := randomPod.Labels[label.StatefulSetNameLabelName]
+		_, hasScheme := randomPod.Labels[label.HTTPSchemeLabelName]
+		_, hasSset := randomPod.Labels[label.StatefulSetNameLabelName]
 		if hasScheme && hasSset {
-			return fmt.Sprintf("%s://%s.%s.%s:%d", scheme, randomPod.Name, sset, randomPod.Namespace, network.HTTPPort)
+			return ElasticsearchPodURL(es, randomPod)
 		}
 	}
 	return ExternalServiceURL(es)
 }
+
+// ElasticsearchPodURL returns the URL of the given Pod of the given cluster, through the headless service of its
+// StatefulSet. The HTTP scheme of the Pod is used, or the one of the specification if unknown.
+func ElasticsearchPodURL(es esv1.Elasticsearch, pod corev1.Pod) string {
+	scheme, exists := pod.Labels[label.HTTPSchemeLabelName]
+	if !exists {
+		scheme = es.Spec.EffectiveHTTP().Protocol()
+	}
+	sset := pod.Labels[label.StatefulSetNameLabelName]
+	return fmt.Sprintf("%s://%s.%s.%s:%d", scheme, pod.Name, sset, pod.Namespace, network.HTTPPort)
+}
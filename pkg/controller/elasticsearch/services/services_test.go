@@ -159,6 +159,19 @@ func TestElasticsearchURL(t *testing.T) {
 	}
 }
 
+func TestElasticsearchPodURL(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "ns",
+		Name:      "es-es-default-0",
+		Labels:    map[string]string{label.StatefulSetNameLabelName: "es-es-default", label.HTTPSchemeLabelName: "http"},
+	}}
+	require.Equal(t, "http://es-es-default-0.es-es-default.ns:9200", ElasticsearchPodURL(es, pod))
+	// the scheme of the specification is used if the Pod is not labeled with its scheme
+	delete(pod.Labels, label.HTTPSchemeLabelName)
+	require.Equal(t, "https://es-es-default-0.es-es-default.ns:9200", ElasticsearchPodURL(es, pod))
+}
+
 func TestNewExternalService(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -289,9 +302,10 @@ func mkElasticsearch(httpConf commonv1.HTTPConfig) esv1.Elasticsearch {
 
 func TestNewTransportService(t *testing.T) {
 	tests := []struct {
-		name         string
-		transportCfg esv1.TransportConfig
-		want         func() corev1.Service
+		name                string
+		transportCfg        esv1.TransportConfig
+		remoteClusterServer bool
+		want                func() corev1.Service
 	}{
 		{
 			name: "Sets defaults",
@@ -321,6 +335,18 @@ func TestNewTransportService(t *testing.T) {
 				return svc
 			},
 		},
+		{
+			name:                "Exposes the remote cluster server",
+			remoteClusterServer: true,
+			want: func() corev1.Service {
+				svc := mkTransportService()
+				svc.Spec.Ports = []corev1.ServicePort{
+					{Name: "transport", Protocol: corev1.ProtocolTCP, Port: network.TransportPort},
+					{Name: "remote-cluster", Protocol: corev1.ProtocolTCP, Port: network.RemoteClusterPort},
+				}
+				return svc
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -330,7 +356,8 @@ func TestNewTransportService(t *testing.T) {
 					Namespace: "test",
 				},
 				Spec: esv1.ElasticsearchSpec{
-					Transport: tt.transportCfg,
+					Transport:           tt.transportCfg,
+					RemoteClusterServer: esv1.RemoteClusterServer{Enabled: tt.remoteClusterServer},
 				},
 			}
 			want := tt.want()
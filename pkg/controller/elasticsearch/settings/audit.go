@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package settings
+
+import (
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+)
+
+const (
+	// AuditLog4j2ConfigFileName is the key of the additional log4j2 configuration in the config secret, writing the
+	// audit events to a file for the audit logs to be shipped.
+	AuditLog4j2ConfigFileName = "audit-log4j2.properties"
+	// AuditLogsFilePattern matches the audit logs files written in the logs directory.
+	AuditLogsFilePattern = "*_audit*.json"
+)
+
+// auditLog4j2Config adds a rolling file appender to the audit logger. Elasticsearch loads all the log4j2.properties
+// files of its config directory and merges them, so that audit events are still written to the console as well.
+// The layout is the JSON layout of the audit events in Elasticsearch 7.x.
+const auditLog4j2Config = `appender.eck_audit_file.type = RollingFile
+appender.eck_audit_file.name = eck_audit_file
+appender.eck_audit_file.fileName = ${sys:es.logs.base_path}${sys:file.separator}${sys:es.logs.cluster_name}_audit.json
+appender.eck_audit_file.filePattern = ${sys:es.logs.base_path}${sys:file.separator}${sys:es.logs.cluster_name}_audit-%i.json
+appender.eck_audit_file.layout.type = PatternLayout
+appender.eck_audit_file.layout.pattern = {\
+                "type":"audit", \
+                "timestamp":"%d{yyyy-MM-dd'T'HH:mm:ss,SSSZ}"\
+                %varsNotEmpty{, "node.name":"%enc{%map{node.name}}{JSON}"}\
+                %varsNotEmpty{, "node.id":"%enc{%map{node.id}}{JSON}"}\
+                %varsNotEmpty{, "host.name":"%enc{%map{host.name}}{JSON}"}\
+                %varsNotEmpty{, "host.ip":"%enc{%map{host.ip}}{JSON}"}\
+                %varsNotEmpty{, "event.type":"%enc{%map{event.type}}{JSON}"}\
+                %varsNotEmpty{, "event.action":"%enc{%map{event.action}}{JSON}"}\
+                %varsNotEmpty{, "user.name":"%enc{%map{user.name}}{JSON}"}\
+                %varsNotEmpty{, "user.run_by.name":"%enc{%map{user.run_by.name}}{JSON}"}\
+                %varsNotEmpty{, "user.run_as.name":"%enc{%map{user.run_as.name}}{JSON}"}\
+                %varsNotEmpty{, "user.realm":"%enc{%map{user.realm}}{JSON}"}\
+                %varsNotEmpty{, "user.run_by.realm":"%enc{%map{user.run_by.realm}}{JSON}"}\
+                %varsNotEmpty{, "user.run_as.realm":"%enc{%map{user.run_as.realm}}{JSON}"}\
+                %varsNotEmpty{, "user.roles":%map{user.roles}}\
+                %varsNotEmpty{, "origin.type":"%enc{%map{origin.type}}{JSON}"}\
+                %varsNotEmpty{, "origin.address":"%enc{%map{origin.address}}{JSON}"}\
+                %varsNotEmpty{, "realm":"%enc{%map{realm}}{JSON}"}\
+                %varsNotEmpty{, "url.path":"%enc{%map{url.path}}{JSON}"}\
+                %varsNotEmpty{, "url.query":"%enc{%map{url.query}}{JSON}"}\
+                %varsNotEmpty{, "request.method":"%enc{%map{request.method}}{JSON}"}\
+                %varsNotEmpty{, "request.body":"%enc{%map{request.body}}{JSON}"}\
+                %varsNotEmpty{, "request.id":"%enc{%map{request.id}}{JSON}"}\
+                %varsNotEmpty{, "action":"%enc{%map{action}}{JSON}"}\
+                %varsNotEmpty{, "request.name":"%enc{%map{request.name}}{JSON}"}\
+                %varsNotEmpty{, "indices":%map{indices}}\
+                %varsNotEmpty{, "opaque_id":"%enc{%map{opaque_id}}{JSON}"}\
+                %varsNotEmpty{, "x_forwarded_for":"%enc{%map{x_forwarded_for}}{JSON}"}\
+                %varsNotEmpty{, "transport.profile":"%enc{%map{transport.profile}}{JSON}"}\
+                %varsNotEmpty{, "rule":"%enc{%map{rule}}{JSON}"}\
+                %varsNotEmpty{, "event.category":"%enc{%map{event.category}}{JSON}"}\
+                }%n
+appender.eck_audit_file.policies.type = Policies
+appender.eck_audit_file.policies.size.type = SizeBasedTriggeringPolicy
+appender.eck_audit_file.policies.size.size = 100MB
+appender.eck_audit_file.strategy.type = DefaultRolloverStrategy
+appender.eck_audit_file.strategy.max = 2
+
+logger.xpack_security_audit_logfile.name = org.elasticsearch.xpack.security.audit.logfile.LoggingAuditTrail
+logger.xpack_security_audit_logfile.appenderRef.eck_audit_file.ref = eck_audit_file
+`
+
+// auditConfig returns the audit logging settings specified in the audit section of the Elasticsearch spec.
+func auditConfig(audit *esv1.AuditLogging) (*CanonicalConfig, error) {
+	if audit == nil {
+		return &CanonicalConfig{common.NewCanonicalConfig()}, nil
+	}
+	cfg := map[string]interface{}{
+		esv1.XPackSecurityAuditEnabled: true,
+	}
+	if len(audit.IncludeEvents) > 0 {
+		cfg[esv1.XPackSecurityAuditLogfileEventsInclude] = audit.IncludeEvents
+	}
+	if len(audit.ExcludeEvents) > 0 {
+		cfg[esv1.XPackSecurityAuditLogfileEventsExclude] = audit.ExcludeEvents
+	}
+	if audit.EmitRequestBody {
+		cfg[esv1.XPackSecurityAuditLogfileEventsEmitRequestBody] = true
+	}
+	for _, filter := range audit.IgnoreFilters {
+		prefix := esv1.XPackSecurityAuditLogfileEventsIgnoreFilters + "." + filter.Name + "."
+		for attribute, values := range map[string][]string{
+			"users":   filter.Users,
+			"realms":  filter.Realms,
+			"roles":   filter.Roles,
+			"indices": filter.Indices,
+		} {
+			if len(values) > 0 {
+				cfg[prefix+attribute] = values
+			}
+		}
+	}
+	parsed, err := common.NewCanonicalConfigFrom(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &CanonicalConfig{parsed}, nil
+}
@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+)
+
+func Test_auditConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		audit *esv1.AuditLogging
+		want  map[string]interface{}
+	}{
+		{
+			name:  "no audit logging",
+			audit: nil,
+			want:  map[string]interface{}{},
+		},
+		{
+			name:  "default audit logging",
+			audit: &esv1.AuditLogging{},
+			want:  map[string]interface{}{"xpack.security.audit.enabled": true},
+		},
+		{
+			name: "audit logging with events and ignore filters",
+			audit: &esv1.AuditLogging{
+				IncludeEvents:   []string{"access_denied", "authentication_failed"},
+				ExcludeEvents:   []string{"access_granted"},
+				EmitRequestBody: true,
+				IgnoreFilters: []esv1.AuditIgnoreFilter{
+					{Name: "probes", Users: []string{"elastic-internal-probe"}, Realms: []string{"file1"}},
+					{Name: "monitoring", Indices: []string{".monitoring-*"}, Roles: []string{"remote_monitoring_agent"}},
+				},
+			},
+			want: map[string]interface{}{
+				"xpack.security.audit.enabled":                          true,
+				"xpack.security.audit.logfile.events.include":           []string{"access_denied", "authentication_failed"},
+				"xpack.security.audit.logfile.events.exclude":           []string{"access_granted"},
+				"xpack.security.audit.logfile.events.emit_request_body": true,
+				"xpack.security.audit.logfile.events.ignore_filters": map[string]interface{}{
+					"probes": map[string]interface{}{
+						"users":  []string{"elastic-internal-probe"},
+						"realms": []string{"file1"},
+					},
+					"monitoring": map[string]interface{}{
+						"indices": []string{".monitoring-*"},
+						"roles":   []string{"remote_monitoring_agent"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := auditConfig(tt.audit)
+			require.NoError(t, err)
+			require.Empty(t, cfg.Diff(common.MustCanonicalConfig(tt.want), nil))
+		})
+	}
+}
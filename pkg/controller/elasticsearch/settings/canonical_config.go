@@ -23,5 +23,6 @@ func NewCanonicalConfig() CanonicalConfig {
 func (c CanonicalConfig) Unpack() (esv1.ElasticsearchSettings, error) {
 	cfg := esv1.DefaultCfg
 	err := c.CanonicalConfig.Unpack(&cfg)
+	cfg.ApplyRoles()
 	return cfg, err
 }
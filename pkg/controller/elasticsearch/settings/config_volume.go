@@ -5,6 +5,8 @@
 package settings
 
 import (
+	"strings"
+
 	pkgerrors "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,6 +25,9 @@ const (
 	ConfigFileName        = "elasticsearch.yml"
 	ConfigVolumeName      = "elastic-internal-elasticsearch-config"
 	ConfigVolumeMountPath = "/mnt/elastic-internal/elasticsearch-config"
+	// JVMOptionsFileName is the key of the config secret holding the JVM options of the NodeSet, linked into the
+	// jvm.options.d directory.
+	JVMOptionsFileName = "jvm-options.options"
 )
 
 // ConfigSecretName is the name of the secret that holds the ES config for the given StatefulSet.
@@ -73,26 +78,34 @@ func GetESConfigSecret(client k8s.Client, namespace string, ssetName string) (co
 	return secret, nil
 }
 
-func ConfigSecret(es esv1.Elasticsearch, ssetName string, configData []byte) corev1.Secret {
+func ConfigSecret(es esv1.Elasticsearch, ssetName string, configData []byte, jvmOptions []string) corev1.Secret {
+	data := map[string][]byte{
+		ConfigFileName: configData,
+	}
+	if len(jvmOptions) > 0 {
+		data[JVMOptionsFileName] = []byte(strings.Join(jvmOptions, "\n") + "\n")
+	}
+	if es.Spec.Audit.ShippingEnabled() {
+		// write audit events to a file as well, to be shipped by the audit beat sidecar
+		data[AuditLog4j2ConfigFileName] = []byte(auditLog4j2Config)
+	}
 	return corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: es.Namespace,
 			Name:      ConfigSecretName(ssetName),
 			Labels:    label.NewConfigLabels(k8s.ExtractNamespacedName(&es), ssetName),
 		},
-		Data: map[string][]byte{
-			ConfigFileName: configData,
-		},
+		Data: data,
 	}
 }
 
-// ReconcileConfig ensures the ES config for the pod is set in the apiserver.
-func ReconcileConfig(client k8s.Client, es esv1.Elasticsearch, ssetName string, config CanonicalConfig) error {
+// ReconcileConfig ensures the ES config and JVM options for the pod are set in the apiserver.
+func ReconcileConfig(client k8s.Client, es esv1.Elasticsearch, ssetName string, config CanonicalConfig, jvmOptions []string) error {
 	rendered, err := config.Render()
 	if err != nil {
 		return err
 	}
-	expected := ConfigSecret(es, ssetName, rendered)
+	expected := ConfigSecret(es, ssetName, rendered, jvmOptions)
 	_, err = reconciler.ReconcileSecret(client, expected, &es)
 	return err
 }
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"testing"
 
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
@@ -89,6 +90,29 @@ func TestGetESConfigContent(t *testing.T) {
 	}
 }
 
+func TestConfigSecret_JVMOptions(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	secret := ConfigSecret(es, "es-es-default", []byte("config"), nil)
+	require.NotContains(t, secret.Data, JVMOptionsFileName)
+
+	secret = ConfigSecret(es, "es-es-default", []byte("config"), []string{"-XX:+UseG1GC", "-XX:HeapDumpPath=/tmp"})
+	require.Equal(t, "-XX:+UseG1GC\n-XX:HeapDumpPath=/tmp\n", string(secret.Data[JVMOptionsFileName]))
+}
+
+func TestConfigSecret_AuditShipping(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	secret := ConfigSecret(es, "es-es-default", []byte("config"), nil)
+	require.NotContains(t, secret.Data, AuditLog4j2ConfigFileName)
+
+	// the audit events are written to a file to be shipped
+	es.Spec.Audit = &esv1.AuditLogging{
+		Shipping: &esv1.AuditShipping{ElasticsearchRef: commonv1.ObjectSelector{Name: "monitoring"}},
+	}
+	secret = ConfigSecret(es, "es-es-default", []byte("config"), nil)
+	require.Equal(t, auditLog4j2Config, string(secret.Data[AuditLog4j2ConfigFileName]))
+	require.Equal(t, "config", string(secret.Data[ConfigFileName]))
+}
+
 func TestReconcileConfig(t *testing.T) {
 	es := esv1.Elasticsearch{
 		ObjectMeta: metav1.ObjectMeta{
@@ -148,7 +172,7 @@ func TestReconcileConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := ReconcileConfig(tt.client, tt.es, tt.ssetName, tt.config); (err != nil) != tt.wantErr {
+			if err := ReconcileConfig(tt.client, tt.es, tt.ssetName, tt.config, nil); (err != nil) != tt.wantErr {
 				t.Errorf("ReconcileConfig() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			// config in the apiserver should be the expected one
@@ -13,6 +13,10 @@ const (
 	EnvReadinessProbeProtocol = "READINESS_PROBE_PROTOCOL"
 	HeadlessServiceName       = "HEADLESS_SERVICE_NAME"
 
+	// EnvDiagnosticsPath and EnvThreadDumpAfterFailures enable the capture of thread dumps by the readiness probe
+	EnvDiagnosticsPath         = "DIAGNOSTICS_PATH"
+	EnvThreadDumpAfterFailures = "READINESS_PROBE_THREAD_DUMP_AFTER_FAILURES"
+
 	// EnvPodName and EnvPodIP are injected as env var into the ES pod at runtime,
 	// to be referenced in ES configuration file
 	EnvPodName = "POD_NAME"
@@ -23,6 +23,10 @@ func NewMergedESConfig(
 	clusterName string,
 	ver version.Version,
 	httpConfig commonv1.HTTPConfig,
+	auth esv1.Auth,
+	audit *esv1.AuditLogging,
+	remoteClusterServer esv1.RemoteClusterServer,
+	remoteClusters []esv1.RemoteCluster,
 	userConfig commonv1.Config,
 	certResources *escerts.CertificateResources,
 ) (CanonicalConfig, error) {
@@ -30,9 +34,20 @@ func NewMergedESConfig(
 	if err != nil {
 		return CanonicalConfig{}, err
 	}
+	realmsCfg, err := realmsConfig(ver, auth)
+	if err != nil {
+		return CanonicalConfig{}, err
+	}
+	auditCfg, err := auditConfig(audit)
+	if err != nil {
+		return CanonicalConfig{}, err
+	}
 	config := baseConfig(clusterName, ver).CanonicalConfig
 	err = config.MergeWith(
 		xpackConfig(ver, httpConfig, certResources).CanonicalConfig,
+		realmsCfg.CanonicalConfig,
+		auditCfg.CanonicalConfig,
+		remoteClusterConfig(ver, remoteClusterServer, remoteClusters).CanonicalConfig,
 		userCfg,
 	)
 	if err != nil {
@@ -167,6 +167,10 @@ func TestNewMergedESConfig(t *testing.T) {
 				"clusterName",
 				*ver,
 				commonv1.HTTPConfig{},
+				esv1.Auth{},
+				nil,
+				esv1.RemoteClusterServer{},
+				nil,
 				commonv1.Config{Data: tt.cfgData},
 				&certificates.CertificateResources{},
 			)
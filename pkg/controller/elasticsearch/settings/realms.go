@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package settings
+
+import (
+	"path"
+	"strings"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+)
+
+const (
+	defaultSAMLAttributesPrincipal = "nameid:persistent"
+	defaultOIDCClaimsPrincipal     = "sub"
+)
+
+// SAMLMetadataPath returns the path of the IdP metadata file of the given SAML realm in the Elasticsearch Pods.
+func SAMLMetadataPath(realm esv1.SAMLRealm) string {
+	return path.Join(volume.SAMLMetadataVolumeMountPath, realm.Name, realm.IdPMetadata.FileKey())
+}
+
+// realmsConfig returns the settings of the SAML and OIDC realms specified in the auth section of the Elasticsearch
+// spec. Realms are ordered as specified, SAML realms first, after the built-in file and native realms.
+func realmsConfig(ver version.Version, auth esv1.Auth) (*CanonicalConfig, error) {
+	if len(auth.SAML) == 0 && len(auth.OIDC) == 0 {
+		return &CanonicalConfig{common.NewCanonicalConfig()}, nil
+	}
+	cfg := map[string]interface{}{
+		// SAML and OIDC realms rely on the token service
+		esv1.XPackSecurityAuthcTokenEnabled: true,
+	}
+	// additional realm settings are merged last, so they take precedence
+	var additional []*common.CanonicalConfig
+	addRealmSettings := func(prefix string, settings map[string]interface{}, realmCfg *commonv1.Config) error {
+		for k, v := range settings {
+			if v == "" {
+				continue
+			}
+			cfg[prefix+k] = v
+		}
+		if realmCfg == nil {
+			return nil
+		}
+		realmSettings, err := common.NewCanonicalConfigFrom(map[string]interface{}{
+			strings.TrimSuffix(prefix, "."): realmCfg.Data,
+		})
+		if err != nil {
+			return err
+		}
+		additional = append(additional, realmSettings)
+		return nil
+	}
+	order := 0
+	for _, realm := range auth.SAML {
+		prefix := esv1.SAMLRealmSettingsPrefix(realm.Name)
+		if ver.Major < 7 {
+			// 6.x syntax
+			prefix = esv1.XPackSecurityAuthcRealms + "." + realm.Name + "."
+			cfg[prefix+"type"] = "saml"
+		}
+		principal := realm.AttributesPrincipal
+		if principal == "" {
+			principal = defaultSAMLAttributesPrincipal
+		}
+		settings := map[string]interface{}{
+			"order":                order,
+			"idp.metadata.path":    SAMLMetadataPath(realm),
+			"idp.entity_id":        realm.IdPEntityID,
+			"sp.entity_id":         realm.SPEntityID,
+			"sp.acs":               realm.SPACS,
+			"sp.logout":            realm.SPLogout,
+			"attributes.principal": principal,
+			"attributes.groups":    realm.AttributesGroups,
+		}
+		if err := addRealmSettings(prefix, settings, realm.Config); err != nil {
+			return nil, err
+		}
+		order++
+	}
+	for _, realm := range auth.OIDC {
+		principal := realm.ClaimsPrincipal
+		if principal == "" {
+			principal = defaultOIDCClaimsPrincipal
+		}
+		settings := map[string]interface{}{
+			"order":                       order,
+			"rp.client_id":                realm.ClientID,
+			"rp.response_type":            "code",
+			"rp.redirect_uri":             realm.RedirectURI,
+			"rp.post_logout_redirect_uri": realm.PostLogoutRedirectURI,
+			"op.issuer":                   realm.Issuer,
+			"op.authorization_endpoint":   realm.AuthorizationEndpoint,
+			"op.token_endpoint":           realm.TokenEndpoint,
+			"op.userinfo_endpoint":        realm.UserinfoEndpoint,
+			"op.endsession_endpoint":      realm.EndSessionEndpoint,
+			"op.jwkset_path":              realm.JWKSetPath,
+			"claims.principal":            principal,
+			"claims.groups":               realm.ClaimsGroups,
+		}
+		if err := addRealmSettings(esv1.OIDCRealmSettingsPrefix(realm.Name), settings, realm.Config); err != nil {
+			return nil, err
+		}
+		order++
+	}
+	canonicalCfg, err := common.NewCanonicalConfigFrom(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := canonicalCfg.MergeWith(additional...); err != nil {
+		return nil, err
+	}
+	return &CanonicalConfig{canonicalCfg}, nil
+}
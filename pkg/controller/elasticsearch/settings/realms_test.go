@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+func Test_realmsConfig(t *testing.T) {
+	samlRealm := esv1.SAMLRealm{
+		Name:        "saml1",
+		IdPMetadata: esv1.RealmFileSource{ConfigMapName: "idp-metadata"},
+		IdPEntityID: "https://idp.example.com",
+		SPEntityID:  "https://kibana.example.com",
+		SPACS:       "https://kibana.example.com/api/security/saml/callback",
+		Config: &commonv1.Config{Data: map[string]interface{}{
+			"attributes.groups": "groups",
+			"sp":                map[string]interface{}{"logout": "https://kibana.example.com/logout"},
+		}},
+	}
+	oidcRealm := esv1.OIDCRealm{
+		Name:                  "oidc1",
+		ClientID:              "elasticsearch",
+		ClientSecretKeyRef:    corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "oidc"}, Key: "secret"},
+		RedirectURI:           "https://kibana.example.com/api/security/oidc/callback",
+		Issuer:                "https://op.example.com",
+		AuthorizationEndpoint: "https://op.example.com/auth",
+		TokenEndpoint:         "https://op.example.com/token",
+		JWKSetPath:            "https://op.example.com/jwks",
+		ClaimsGroups:          "groups",
+	}
+	tests := []struct {
+		name    string
+		version string
+		auth    esv1.Auth
+		want    map[string]interface{}
+	}{
+		{
+			name:    "no realms",
+			version: "7.6.0",
+			auth:    esv1.Auth{},
+			want:    map[string]interface{}{},
+		},
+		{
+			name:    "SAML and OIDC realms",
+			version: "7.6.0",
+			auth:    esv1.Auth{SAML: []esv1.SAMLRealm{samlRealm}, OIDC: []esv1.OIDCRealm{oidcRealm}},
+			want: map[string]interface{}{
+				"xpack.security.authc.token.enabled": true,
+				"xpack.security.authc.realms.saml.saml1": map[string]interface{}{
+					"order":                0,
+					"idp.metadata.path":    "/usr/share/elasticsearch/config/saml-metadata/saml1/metadata.xml",
+					"idp.entity_id":        "https://idp.example.com",
+					"sp.entity_id":         "https://kibana.example.com",
+					"sp.acs":               "https://kibana.example.com/api/security/saml/callback",
+					"sp.logout":            "https://kibana.example.com/logout",
+					"attributes.principal": "nameid:persistent",
+					"attributes.groups":    "groups",
+				},
+				"xpack.security.authc.realms.oidc.oidc1": map[string]interface{}{
+					"order":                     1,
+					"rp.client_id":              "elasticsearch",
+					"rp.response_type":          "code",
+					"rp.redirect_uri":           "https://kibana.example.com/api/security/oidc/callback",
+					"op.issuer":                 "https://op.example.com",
+					"op.authorization_endpoint": "https://op.example.com/auth",
+					"op.token_endpoint":         "https://op.example.com/token",
+					"op.jwkset_path":            "https://op.example.com/jwks",
+					"claims.principal":          "sub",
+					"claims.groups":             "groups",
+				},
+			},
+		},
+		{
+			name:    "SAML realm with the 6.x syntax",
+			version: "6.8.0",
+			auth:    esv1.Auth{SAML: []esv1.SAMLRealm{{Name: "saml1", IdPMetadata: esv1.RealmFileSource{SecretName: "idp", Key: "idp.xml"}}}},
+			want: map[string]interface{}{
+				"xpack.security.authc.token.enabled": true,
+				"xpack.security.authc.realms.saml1": map[string]interface{}{
+					"type":                 "saml",
+					"order":                0,
+					"idp.metadata.path":    "/usr/share/elasticsearch/config/saml-metadata/saml1/idp.xml",
+					"attributes.principal": "nameid:persistent",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := realmsConfig(version.MustParse(tt.version), tt.auth)
+			require.NoError(t, err)
+			require.Empty(t, cfg.Diff(common.MustCanonicalConfig(tt.want), nil))
+		})
+	}
+}
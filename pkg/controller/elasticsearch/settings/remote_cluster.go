@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package settings
+
+import (
+	"path"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/volume"
+)
+
+// remoteClusterConfig returns the configuration of the remote cluster server and of the client connecting to remote
+// clusters with API keys. Both reuse the transport certificates: the remote cluster server presents the node
+// certificate, and the client trusts the transport CAs of the remote clusters.
+func remoteClusterConfig(ver version.Version, server esv1.RemoteClusterServer, remoteClusters []esv1.RemoteCluster) *CanonicalConfig {
+	cfg := map[string]interface{}{}
+	if !ver.IsSameOrAfter(esv1.RemoteClusterAPIKeyMinVersion) {
+		return &CanonicalConfig{common.MustCanonicalConfig(cfg)}
+	}
+
+	if server.Enabled {
+		cfg[esv1.RemoteClusterServerEnabled] = true
+		cfg[esv1.XPackSecurityRemoteClusterServerSslKey] = path.Join(
+			volume.ConfigVolumeMountPath,
+			volume.NodeTransportCertificatePathSegment,
+			volume.NodeTransportCertificateKeyFile,
+		)
+		cfg[esv1.XPackSecurityRemoteClusterServerSslCertificate] = path.Join(
+			volume.ConfigVolumeMountPath,
+			volume.NodeTransportCertificatePathSegment,
+			volume.NodeTransportCertificateCertFile,
+		)
+	}
+
+	for _, remoteCluster := range remoteClusters {
+		if !remoteCluster.HasAPIKey() {
+			continue
+		}
+		cfg[esv1.XPackSecurityRemoteClusterClientSslEnabled] = true
+		cfg[esv1.XPackSecurityRemoteClusterClientSslCertificateAuthorities] = []string{
+			path.Join(volume.RemoteCertificateAuthoritiesSecretVolumeMountPath, certificates.CAFileName),
+		}
+		// remote clusters are reached through their transport service, whose name is not part of the node certificates
+		cfg[esv1.XPackSecurityRemoteClusterClientSslVerificationMode] = "certificate"
+		break
+	}
+
+	return &CanonicalConfig{common.MustCanonicalConfig(cfg)}
+}
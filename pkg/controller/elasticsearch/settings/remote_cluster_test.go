@@ -0,0 +1,69 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	common "github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+func Test_remoteClusterConfig(t *testing.T) {
+	withAPIKey := []esv1.RemoteCluster{
+		{Name: "certificates"},
+		{Name: "api-key", APIKey: &esv1.RemoteClusterAPIKey{}},
+	}
+	tests := []struct {
+		name           string
+		version        string
+		server         esv1.RemoteClusterServer
+		remoteClusters []esv1.RemoteCluster
+		want           map[string]interface{}
+	}{
+		{
+			name:           "certificate based remote clusters",
+			version:        "8.10.0",
+			remoteClusters: []esv1.RemoteCluster{{Name: "certificates"}},
+			want:           map[string]interface{}{},
+		},
+		{
+			name:           "version without remote cluster server",
+			version:        "8.9.0",
+			server:         esv1.RemoteClusterServer{Enabled: true},
+			remoteClusters: withAPIKey,
+			want:           map[string]interface{}{},
+		},
+		{
+			name:    "remote cluster server",
+			version: "8.10.0",
+			server:  esv1.RemoteClusterServer{Enabled: true},
+			want: map[string]interface{}{
+				"remote_cluster_server.enabled":                        true,
+				"xpack.security.remote_cluster_server.ssl.key":         "/usr/share/elasticsearch/config/node-transport-cert/transport.tls.key",
+				"xpack.security.remote_cluster_server.ssl.certificate": "/usr/share/elasticsearch/config/node-transport-cert/transport.tls.crt",
+			},
+		},
+		{
+			name:           "remote clusters with API keys",
+			version:        "8.11.0",
+			remoteClusters: withAPIKey,
+			want: map[string]interface{}{
+				"xpack.security.remote_cluster_client.ssl.enabled":                 true,
+				"xpack.security.remote_cluster_client.ssl.certificate_authorities": []string{"/usr/share/elasticsearch/config/transport-remote-certs/ca.crt"},
+				"xpack.security.remote_cluster_client.ssl.verification_mode":       "certificate",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := remoteClusterConfig(version.MustParse(tt.version), tt.server, tt.remoteClusters)
+			require.Empty(t, cfg.Diff(common.MustCanonicalConfig(tt.want), nil))
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/maps"
 )
 
 // ReconcileStatefulSet creates or updates the expected StatefulSet.
@@ -29,7 +30,11 @@ func ReconcileStatefulSet(c k8s.Client, es esv1.Elasticsearch, expected appsv1.S
 			return !EqualTemplateHashLabels(expected, reconciled)
 		},
 		UpdateReconciled: func() {
+			// keep the existing annotations, such as the persisted expectations, which are not part of the expected
+			// StatefulSet
+			annotations := reconciled.Annotations
 			expected.DeepCopyInto(&reconciled)
+			reconciled.Annotations = maps.Merge(annotations, expected.Annotations)
 		},
 		PostUpdate: func() {
 			if expectations != nil {
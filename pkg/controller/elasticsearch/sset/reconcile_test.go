@@ -99,3 +99,39 @@ func TestReconcileStatefulSet(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileStatefulSet_KeepsAnnotations(t *testing.T) {
+	require.NoError(t, commonscheme.SetupScheme())
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es", UID: types.UID("uid")}}
+	existing := appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: es.Namespace,
+			Name:      "sset",
+			Labels:    map[string]string{hash.TemplateHashLabelName: "hash-value"},
+			Annotations: map[string]string{
+				expectations.ExpectedGenerationAnnotation: `{"uid":"uid","generation":2}`,
+				"foo": "bar",
+			},
+		},
+	}
+	expected := appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   es.Namespace,
+			Name:        "sset",
+			Labels:      map[string]string{hash.TemplateHashLabelName: "updated"},
+			Annotations: map[string]string{"foo": "baz"},
+		},
+	}
+	c := k8s.WrappedFakeClient(&existing)
+	_, err := ReconcileStatefulSet(c, es, expected, expectations.NewExpectations(c))
+	require.NoError(t, err)
+
+	var retrieved appsv1.StatefulSet
+	require.NoError(t, c.Get(k8s.ExtractNamespacedName(&existing), &retrieved))
+	require.Equal(t, "updated", retrieved.Labels[hash.TemplateHashLabelName])
+	// the persisted expectations are kept, the expected annotations are updated
+	require.Equal(t, map[string]string{
+		expectations.ExpectedGenerationAnnotation: `{"uid":"uid","generation":2}`,
+		"foo": "baz",
+	}, retrieved.Annotations)
+}
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package user
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user/filerealm"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// reservedUserNames cannot be used by declared users, as they are managed by the operator.
+var reservedUserNames = []string{ElasticUserName, ControllerUserName, ControllerTransitionUserName, ProbeUserName}
+
+// DeclaredUsersWatchName returns the watch registered for the password secrets of the ElasticsearchUser resources.
+func DeclaredUsersWatchName(es types.NamespacedName) string {
+	return fmt.Sprintf("%s-%s-declared-users", es.Namespace, es.Name)
+}
+
+// reconcileDeclaredUsers returns the users declared with ElasticsearchUser resources referencing the es cluster.
+// Password hashes are reused from the existing file realm if the passwords did not change.
+// It also ensures the password secrets are watched for future reconciliations to be triggered on any change.
+func reconcileDeclaredUsers(
+	c k8s.Client,
+	es esv1.Elasticsearch,
+	existingFileRealm filerealm.Realm,
+	watched watches.DynamicWatches,
+	recorder record.EventRecorder,
+) (users, error) {
+	var list authv1alpha1.ElasticsearchUserList
+	if err := c.List(&list, client.InNamespace(es.Namespace)); err != nil {
+		return nil, err
+	}
+	var declared []authv1alpha1.ElasticsearchUser
+	for _, u := range list.Items {
+		if u.Spec.ElasticsearchRef.Name == es.Name {
+			declared = append(declared, u)
+		}
+	}
+
+	esKey := k8s.ExtractNamespacedName(&es)
+	secretNames := make([]string, 0, len(declared))
+	for _, u := range declared {
+		secretNames = append(secretNames, u.Spec.PasswordSecretKeyRef.Name)
+	}
+	if err := watches.WatchUserProvidedSecrets(esKey, watched, DeclaredUsersWatchName(esKey), secretNames); err != nil {
+		return nil, err
+	}
+
+	result := make(users, 0, len(declared))
+	for i := range declared {
+		u := declared[i]
+		if stringsutil.StringInSlice(u.UserName(), reservedUserNames) {
+			handleInvalidUser(recorder, &u, fmt.Sprintf("user name %s is reserved", u.UserName()))
+			continue
+		}
+		ref := u.Spec.PasswordSecretKeyRef
+		var secret corev1.Secret
+		if err := c.Get(types.NamespacedName{Namespace: u.Namespace, Name: ref.Name}, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				handleInvalidUser(recorder, &u, "password secret not found: "+ref.Name)
+				continue
+			}
+			return nil, err
+		}
+		password, exists := secret.Data[ref.Key]
+		if !exists || len(password) == 0 {
+			handleInvalidUser(recorder, &u, fmt.Sprintf("key %s not found in password secret %s", ref.Key, ref.Name))
+			continue
+		}
+		result = append(result, user{Name: u.UserName(), Password: password, Roles: u.Spec.Roles})
+	}
+	return reuseOrGenerateHash(result, existingFileRealm)
+}
+
+// retrieveDeclaredRoles returns the roles declared with ElasticsearchRole resources referencing the es cluster.
+func retrieveDeclaredRoles(c k8s.Client, es esv1.Elasticsearch) (RolesFileContent, error) {
+	var list authv1alpha1.ElasticsearchRoleList
+	if err := c.List(&list, client.InNamespace(es.Namespace)); err != nil {
+		return nil, err
+	}
+	roles := make(RolesFileContent)
+	for _, r := range list.Items {
+		if r.Spec.ElasticsearchRef.Name != es.Name {
+			continue
+		}
+		roles[r.RoleName()] = r.Spec.Definition.Data
+	}
+	return roles, nil
+}
+
+func handleInvalidUser(recorder record.EventRecorder, u *authv1alpha1.ElasticsearchUser, msg string) {
+	log.Info("Ignoring invalid user", "namespace", u.Namespace, "user_name", u.Name, "reason", msg)
+	recorder.Event(u, corev1.EventTypeWarning, events.EventReasonValidation, msg)
+}
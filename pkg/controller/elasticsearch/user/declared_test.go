@@ -0,0 +1,98 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	authv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/auth/v1alpha1"
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user/filerealm"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var declaredEs = esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "es"}}
+
+func declaredUser(name, esName, username, secretName string, roles ...string) *authv1alpha1.ElasticsearchUser {
+	return &authv1alpha1.ElasticsearchUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name},
+		Spec: authv1alpha1.ElasticsearchUserSpec{
+			ElasticsearchRef: corev1.LocalObjectReference{Name: esName},
+			Username:         username,
+			PasswordSecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  "password",
+			},
+			Roles: roles,
+		},
+	}
+}
+
+func Test_reconcileDeclaredUsers(t *testing.T) {
+	passwordSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "app-password"},
+		Data:       map[string][]byte{"password": []byte("changeme")},
+	}
+	c := k8s.WrappedFakeClient(
+		passwordSecret,
+		declaredUser("app", "es", "", "app-password", "app_writer"),
+		declaredUser("named", "es", "my-user", "app-password"),
+		declaredUser("other-cluster", "other", "", "app-password"),
+		declaredUser("missing-secret", "es", "", "missing"),
+		declaredUser("reserved", "es", ElasticUserName, "app-password"),
+	)
+	watched := initDynamicWatches()
+
+	declared, err := reconcileDeclaredUsers(c, declaredEs, filerealm.New(), watched, record.NewFakeRecorder(10))
+	require.NoError(t, err)
+	require.Len(t, declared, 2)
+	require.Equal(t, "app", declared[0].Name)
+	require.Equal(t, []string{"app_writer"}, declared[0].Roles)
+	require.NoError(t, bcrypt.CompareHashAndPassword(declared[0].PasswordHash, []byte("changeme")))
+	require.Equal(t, "my-user", declared[1].Name)
+	require.Contains(t, watched.Secrets.Registrations(), DeclaredUsersWatchName(k8s.ExtractNamespacedName(&declaredEs)))
+
+	// the hash is reused from the existing file realm if the password did not change
+	existing := declared.fileRealm()
+	reconciled, err := reconcileDeclaredUsers(c, declaredEs, existing, watched, record.NewFakeRecorder(10))
+	require.NoError(t, err)
+	require.Equal(t, declared[0].PasswordHash, reconciled[0].PasswordHash)
+
+	// a new hash is generated if the password changed
+	passwordSecret.Data["password"] = []byte("updated")
+	require.NoError(t, c.Update(passwordSecret))
+	reconciled, err = reconcileDeclaredUsers(c, declaredEs, existing, watched, record.NewFakeRecorder(10))
+	require.NoError(t, err)
+	require.NotEqual(t, declared[0].PasswordHash, reconciled[0].PasswordHash)
+	require.NoError(t, bcrypt.CompareHashAndPassword(reconciled[0].PasswordHash, []byte("updated")))
+}
+
+func Test_retrieveDeclaredRoles(t *testing.T) {
+	role := func(name, esName, roleName string) *authv1alpha1.ElasticsearchRole {
+		return &authv1alpha1.ElasticsearchRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name},
+			Spec: authv1alpha1.ElasticsearchRoleSpec{
+				ElasticsearchRef: corev1.LocalObjectReference{Name: esName},
+				RoleName:         roleName,
+				Definition:       commonv1.Config{Data: map[string]interface{}{"cluster": []interface{}{"monitor"}}},
+			},
+		}
+	}
+	c := k8s.WrappedFakeClient(role("app-writer", "es", "app_writer"), role("reader", "es", ""), role("other", "other", ""))
+
+	roles, err := retrieveDeclaredRoles(c, declaredEs)
+	require.NoError(t, err)
+	require.Equal(t, RolesFileContent{
+		"app_writer": map[string]interface{}{"cluster": []interface{}{"monitor"}},
+		"reader":     map[string]interface{}{"cluster": []interface{}{"monitor"}},
+	}, roles)
+}
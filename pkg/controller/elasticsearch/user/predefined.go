@@ -6,6 +6,7 @@ package user
 
 import (
 	"reflect"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
@@ -14,8 +15,9 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user/filerealm"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
@@ -33,71 +35,125 @@ const (
 )
 
 // reconcileElasticUser reconciles a single secret holding the "elastic" user password.
-func reconcileElasticUser(c k8s.Client, es esv1.Elasticsearch, existingFileRealm filerealm.Realm) (users, error) {
-	return reconcilePredefinedUsers(
+// The password is rotated in stages, since the secret is consumed by clients outside of the Elasticsearch Pods.
+func reconcileElasticUser(
+	c k8s.Client,
+	store credentials.Credentials,
+	es esv1.Elasticsearch,
+	existingFileRealm filerealm.Realm,
+	verify PasswordVerifier,
+	now time.Time,
+) (users, time.Duration, error) {
+	rotation, err := reconcilePredefinedUsers(
 		c,
+		store,
 		es,
 		existingFileRealm,
 		users{
 			{Name: ElasticUserName, Roles: []string{SuperUserBuiltinRole}},
 		},
 		esv1.ElasticUserSecret(es.Name),
+		rotateStaged,
+		verify,
+		now,
 	)
+	return rotation.users, rotation.requeueAfter, err
 }
 
-// reconcileInternalUsers reconciles a single secret holding the internal users passwords.
-func reconcileInternalUsers(c k8s.Client, es esv1.Elasticsearch, existingFileRealm filerealm.Realm) (users, error) {
-	return reconcilePredefinedUsers(
+// reconcileInternalUsers reconciles a single secret holding the internal users passwords, and returns the credentials
+// the operator must authenticate with.
+// The probe user password is rotated at once, since the secret is propagated to the Elasticsearch Pods along with the
+// file realm. The controller user password is rotated through a transition user, so that the requests of the operator
+// keep being accepted while the new password propagates.
+func reconcileInternalUsers(
+	c k8s.Client,
+	store credentials.Credentials,
+	es esv1.Elasticsearch,
+	existingFileRealm filerealm.Realm,
+	verify PasswordVerifier,
+	now time.Time,
+) (users, esclient.BasicAuth, time.Duration, error) {
+	rotation, err := reconcilePredefinedUsers(
 		c,
+		store,
 		es,
 		existingFileRealm,
 		users{
 			{Name: ControllerUserName, Roles: []string{SuperUserBuiltinRole}},
 			{Name: ProbeUserName, Roles: []string{ProbeUserRole}},
 		},
-		esv1.InternalUsersSecret(es.Name))
+		esv1.InternalUsersSecret(es.Name),
+		rotateThroughTransitionUser,
+		verify,
+		now,
+	)
+	if err != nil {
+		return nil, esclient.BasicAuth{}, 0, err
+	}
+	controllerCreds, err := ControllerCredentials(rotation.data)
+	return rotation.users, controllerCreds, rotation.requeueAfter, err
 }
 
 // reconcilePredefinedUsers reconciles a secret with the given name holding the given users.
 // It attempts to reuse passwords from pre-existing secrets, and reuse hashes from pre-existing file realms.
+// Passwords are rotated with the given strategy according to the rotation policy of the cluster, and published once
+// accepted by Elasticsearch according to the given verifier. The returned rotation holds the time after which the
+// secret must be reconciled again for the rotation to progress, zero if not needed.
 func reconcilePredefinedUsers(
 	c k8s.Client,
+	store credentials.Credentials,
 	es esv1.Elasticsearch,
 	existingFileRealm filerealm.Realm,
 	users users,
 	secretName string,
-) (users, error) {
+	strategy rotationStrategy,
+	verify PasswordVerifier,
+	now time.Time,
+) (passwordRotation, error) {
 	secretNsn := types.NamespacedName{Namespace: es.Namespace, Name: secretName}
 
+	var existing corev1.Secret
+	if err := c.Get(secretNsn, &existing); err != nil && !apierrors.IsNotFound(err) {
+		return passwordRotation{}, err
+	}
+	// passwords held by an external credentials store take precedence
+	if _, err := store.Load(secretNsn, &existing); err != nil {
+		return passwordRotation{}, err
+	}
+
 	// build users, reusing existing passwords and bcrypt hashes if possible
-	var err error
-	users, err = reuseOrGeneratePassword(c, users, secretNsn)
-	if err != nil {
-		return nil, err
+	accepted := func(username string, password []byte) bool {
+		ok, err := verify(username, password)
+		if err != nil {
+			log.Info("Failed to verify the new password of a predefined user", "namespace", es.Namespace,
+				"es_name", es.Name, "user_name", username, "error", err.Error())
+		}
+		return ok
 	}
-	users, err = reuseOrGenerateHash(users, existingFileRealm)
+	rotation := rotatePasswords(es, existing, users, strategy, now, accepted)
+	users, err := reuseOrGenerateHash(rotation.users, existingFileRealm)
 	if err != nil {
-		return nil, err
+		return passwordRotation{}, err
 	}
+	rotation.users = users
 
-	// reconcile secret
-	secretData := make(map[string][]byte, len(users))
-	for _, u := range users {
-		secretData[u.Name] = u.Password
+	// reconcile secret, once the passwords are persisted in the external credentials store if any
+	if err := store.Save(secretNsn, rotation.data); err != nil {
+		return passwordRotation{}, err
 	}
-
 	expected := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: secretNsn.Namespace,
-			Name:      secretNsn.Name,
-			Labels:    label.NewLabels(k8s.ExtractNamespacedName(&es)),
+			Namespace:   secretNsn.Namespace,
+			Name:        secretNsn.Name,
+			Labels:      label.NewLabels(k8s.ExtractNamespacedName(&es)),
+			Annotations: rotation.annotations,
 		},
-		Data: secretData,
+		Data: rotation.data,
 	}
 
 	var reconciled corev1.Secret
 	// TODO: factorize with https://github.com/elastic/cloud-on-k8s/issues/2626
-	return users, reconciler.ReconcileResource(reconciler.Params{
+	return rotation, reconciler.ReconcileResource(reconciler.Params{
 		Client:     c,
 		Owner:      &es,
 		Expected:   &expected,
@@ -106,40 +162,30 @@ func reconcilePredefinedUsers(
 			// update if secret content is different
 			return !reflect.DeepEqual(expected.Data, reconciled.Data) ||
 				// or expected labels are not there
-				!maps.IsSubset(expected.Labels, reconciled.Labels)
+				!maps.IsSubset(expected.Labels, reconciled.Labels) ||
+				// or the rotation annotations are different
+				!reflect.DeepEqual(expected.Annotations, rotationAnnotationsOf(reconciled))
 		},
 		UpdateReconciled: func() {
 			reconciled.Data = expected.Data
 			maps.Merge(reconciled.Labels, expected.Labels)
-			maps.Merge(reconciled.Annotations, expected.Annotations)
+			for _, annotation := range rotationAnnotations {
+				delete(reconciled.Annotations, annotation)
+			}
+			reconciled.Annotations = maps.Merge(reconciled.Annotations, expected.Annotations)
 		},
 	})
 }
 
-// reuseOrGeneratePassword updates the users with existing passwords reused from the existing K8s secret,
-// or generates new passwords.
-func reuseOrGeneratePassword(c k8s.Client, users users, secretRef types.NamespacedName) (users, error) {
-	var secret corev1.Secret
-	err := c.Get(secretRef, &secret)
-	if err != nil && !apierrors.IsNotFound(err) {
-		return nil, err
-	}
-	// default to an empty secret
-	if apierrors.IsNotFound(err) {
-		secret = corev1.Secret{}
-	}
-	if secret.Data == nil {
-		secret.Data = map[string][]byte{}
-	}
-	// either reuse the password or generate a new one
-	for i, u := range users {
-		if password, exists := secret.Data[u.Name]; exists {
-			users[i].Password = password
-		} else {
-			users[i].Password = common.RandomPasswordBytes()
+// rotationAnnotationsOf returns the rotation annotations of the given secret.
+func rotationAnnotationsOf(secret corev1.Secret) map[string]string {
+	annotations := make(map[string]string, len(rotationAnnotations))
+	for _, annotation := range rotationAnnotations {
+		if value, exists := secret.Annotations[annotation]; exists {
+			annotations[annotation] = value
 		}
 	}
-	return users, nil
+	return annotations
 }
 
 // reuseOrGenerateHash updates the users with existing hashes from the given file realm, or generates new ones.
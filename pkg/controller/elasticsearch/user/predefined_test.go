@@ -6,6 +6,7 @@ package user
 
 import (
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
@@ -13,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user/filerealm"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 
@@ -105,7 +107,7 @@ func Test_reconcileElasticUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := k8s.WrappedFakeClient(tt.existingSecrets...)
-			got, err := reconcileElasticUser(c, es, tt.existingFileRealm)
+			got, _, err := reconcileElasticUser(c, credentials.Default, es, tt.existingFileRealm, acceptAll, time.Now())
 			require.NoError(t, err)
 			// check returned user
 			require.Len(t, got, 1)
@@ -216,7 +218,7 @@ func Test_reconcileInternalUsers(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := k8s.WrappedFakeClient(tt.existingSecrets...)
-			got, err := reconcileInternalUsers(c, es, tt.existingFileRealm)
+			got, _, _, err := reconcileInternalUsers(c, credentials.Default, es, tt.existingFileRealm, acceptAll, time.Now())
 			require.NoError(t, err)
 			// check returned users
 			require.Len(t, got, 2)
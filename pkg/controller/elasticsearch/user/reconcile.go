@@ -7,6 +7,7 @@ package user
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"go.elastic.co/apm"
 	corev1 "k8s.io/api/core/v1"
@@ -15,8 +16,10 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
@@ -31,41 +34,54 @@ var log = logf.Log.WithName("elasticsearch-user")
 
 // ReconcileUsersAndRoles fetches all users and roles and aggregates them into a single
 // Kubernetes secret mounted in the Elasticsearch Pods.
-// That secret contains the file realm files (`users` and `users_roles`) and the file roles (`roles.yml`).
+// That secret contains the file realm files (`users` and `users_roles`), the file roles (`roles.yml`) and the
+// service account tokens (`service_tokens`).
 // Users are aggregated from various sources:
 // - predefined users include the controller user, the probe user, and the public-facing elastic user
 // - associated users come from resource associations (eg. Kibana or APMServer)
 // - user-provided users from file realms referenced in the Elasticsearch spec
+// - declared users from ElasticsearchUser resources referencing the cluster
 // Roles are aggregated from:
 // - predefined roles (for the probe user)
 // - user-provided roles referenced in the Elasticsearch spec
+// - declared roles from ElasticsearchRole resources referencing the cluster
+// Service account tokens come from resource associations (eg. Kibana).
+// The returned result requests a new reconciliation when the passwords of the predefined users must be rotated, the new
+// passwords being published once accepted by Elasticsearch according to the given verifier.
 func ReconcileUsersAndRoles(
 	ctx context.Context,
 	c k8s.Client,
+	store credentials.Credentials,
 	es esv1.Elasticsearch,
 	watched watches.DynamicWatches,
 	recorder record.EventRecorder,
-) (esclient.BasicAuth, error) {
+	verify PasswordVerifier,
+) (esclient.BasicAuth, reconcile.Result, error) {
 	span, _ := apm.StartSpan(ctx, "reconcile_users", tracing.SpanTypeApp)
 	defer span.End()
 
 	// build aggregate roles and file realms
 	roles, err := aggregateRoles(c, es, watched, recorder)
 	if err != nil {
-		return esclient.BasicAuth{}, err
+		return esclient.BasicAuth{}, reconcile.Result{}, err
 	}
-	fileRealm, controllerUser, err := aggregateFileRealm(c, es, watched, recorder)
+	fileRealm, controllerUser, requeueAfter, err := aggregateFileRealm(c, store, es, watched, recorder, verify, time.Now())
 	if err != nil {
-		return esclient.BasicAuth{}, err
+		return esclient.BasicAuth{}, reconcile.Result{}, err
+	}
+
+	serviceAccountTokens, err := retrieveServiceAccountTokens(c, es)
+	if err != nil {
+		return esclient.BasicAuth{}, reconcile.Result{}, err
 	}
 
 	// reconcile the aggregate secret
-	if err := reconcileRolesFileRealmSecret(c, es, roles, fileRealm); err != nil {
-		return esclient.BasicAuth{}, err
+	if err := reconcileRolesFileRealmSecret(c, es, roles, fileRealm, serviceAccountTokens); err != nil {
+		return esclient.BasicAuth{}, reconcile.Result{}, err
 	}
 
 	// return the controller user for next reconciliation steps to interact with Elasticsearch
-	return controllerUser, nil
+	return controllerUser, reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func getExistingFileRealm(c k8s.Client, es esv1.Elasticsearch) (filerealm.Realm, error) {
@@ -76,58 +92,68 @@ func getExistingFileRealm(c k8s.Client, es esv1.Elasticsearch) (filerealm.Realm,
 	return filerealm.FromSecret(secret)
 }
 
-// aggregateFileRealm builds a single file realm from multiple ones, and returns the controller user credentials.
+// aggregateFileRealm builds a single file realm from multiple ones, and returns the controller user credentials along
+// with the duration after which the predefined users passwords must be rotated, zero if not needed.
 func aggregateFileRealm(
 	c k8s.Client,
+	store credentials.Credentials,
 	es esv1.Elasticsearch,
 	watched watches.DynamicWatches,
 	recorder record.EventRecorder,
-) (filerealm.Realm, esclient.BasicAuth, error) {
+	verify PasswordVerifier,
+	now time.Time,
+) (filerealm.Realm, esclient.BasicAuth, time.Duration, error) {
 	// retrieve existing file realm to reuse predefined users password hashes if possible
 	existingFileRealm, err := getExistingFileRealm(c, es)
 	if err != nil && apierrors.IsNotFound(err) {
 		// no secret yet, work with an empty file realm
 		existingFileRealm = filerealm.New()
 	} else if err != nil {
-		return filerealm.Realm{}, esclient.BasicAuth{}, err
+		return filerealm.Realm{}, esclient.BasicAuth{}, 0, err
 	}
 
 	// reconcile predefined users
-	elasticUser, err := reconcileElasticUser(c, es, existingFileRealm)
+	elasticUser, elasticRequeueAfter, err := reconcileElasticUser(c, store, es, existingFileRealm, verify, now)
 	if err != nil {
-		return filerealm.Realm{}, esclient.BasicAuth{}, err
+		return filerealm.Realm{}, esclient.BasicAuth{}, 0, err
 	}
-	internalUsers, err := reconcileInternalUsers(c, es, existingFileRealm)
+	internalUsers, controllerCreds, internalRequeueAfter, err := reconcileInternalUsers(c, store, es, existingFileRealm, verify, now)
 	if err != nil {
-		return filerealm.Realm{}, esclient.BasicAuth{}, err
+		return filerealm.Realm{}, esclient.BasicAuth{}, 0, err
+	}
+	requeueAfter := elasticRequeueAfter
+	if internalRequeueAfter > 0 && (requeueAfter == 0 || internalRequeueAfter < requeueAfter) {
+		requeueAfter = internalRequeueAfter
 	}
 
 	// fetch associated users
 	associatedUsers, err := retrieveAssociatedUsers(c, es)
 	if err != nil {
-		return filerealm.Realm{}, esclient.BasicAuth{}, err
+		return filerealm.Realm{}, esclient.BasicAuth{}, 0, err
 	}
 
 	// watch & fetch user-provided file realm & roles
 	userProvidedFileRealm, err := reconcileUserProvidedFileRealm(c, es, watched, recorder)
 	if err != nil {
-		return filerealm.Realm{}, esclient.BasicAuth{}, err
+		return filerealm.Realm{}, esclient.BasicAuth{}, 0, err
+	}
+
+	// fetch users declared with ElasticsearchUser resources
+	declaredUsers, err := reconcileDeclaredUsers(c, es, existingFileRealm, watched, recorder)
+	if err != nil {
+		return filerealm.Realm{}, esclient.BasicAuth{}, 0, err
 	}
 
 	// merge all file realms together, the last one having precedence
 	fileRealm := filerealm.MergedFrom(
+		declaredUsers.fileRealm(),
 		internalUsers.fileRealm(),
 		elasticUser.fileRealm(),
 		associatedUsers.fileRealm(),
 		userProvidedFileRealm,
 	)
 
-	// grab the controller user credentials for later use
-	controllerCreds, err := internalUsers.credentialsFor(ControllerUserName)
-	if err != nil {
-		return filerealm.Realm{}, esclient.BasicAuth{}, err
-	}
-	return fileRealm, controllerCreds, nil
+	return fileRealm, controllerCreds, requeueAfter, nil
 }
 
 func aggregateRoles(
@@ -140,8 +166,12 @@ func aggregateRoles(
 	if err != nil {
 		return RolesFileContent{}, err
 	}
+	declared, err := retrieveDeclaredRoles(c, es)
+	if err != nil {
+		return RolesFileContent{}, err
+	}
 	// merge all roles together, the last one having precedence
-	return PredefinedRoles.MergeWith(userProvided), nil
+	return make(RolesFileContent).MergeWith(declared).MergeWith(PredefinedRoles).MergeWith(userProvided), nil
 }
 
 // RolesFileRealmSecretKey returns a reference to the K8s secret holding the roles and file realm data.
@@ -149,14 +179,23 @@ func RolesFileRealmSecretKey(es esv1.Elasticsearch) types.NamespacedName {
 	return types.NamespacedName{Namespace: es.Namespace, Name: esv1.RolesAndFileRealmSecret(es.Name)}
 }
 
-// reconcileRolesFileRealmSecret creates or updates the single secret holding the file realm and the file-based roles.
-func reconcileRolesFileRealmSecret(c k8s.Client, es esv1.Elasticsearch, roles RolesFileContent, fileRealm filerealm.Realm) error {
+// reconcileRolesFileRealmSecret creates or updates the single secret holding the file realm, the file-based roles and
+// the service account tokens.
+func reconcileRolesFileRealmSecret(
+	c k8s.Client,
+	es esv1.Elasticsearch,
+	roles RolesFileContent,
+	fileRealm filerealm.Realm,
+	serviceAccountTokens ServiceAccountTokens,
+) error {
 	secretData := fileRealm.FileBytes()
 	rolesBytes, err := roles.FileBytes()
 	if err != nil {
 		return err
 	}
 	secretData[RolesFile] = rolesBytes
+	// always set, even if empty, for the file to exist in the Elasticsearch config dir
+	secretData[ServiceTokensFile] = serviceAccountTokens.FileBytes()
 
 	expected := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -7,27 +7,33 @@ package user
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user/filerealm"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 )
 
 func TestReconcileUsersAndRoles(t *testing.T) {
 	c := k8s.WrappedFakeClient(append(sampleUserProvidedFileRealmSecrets, sampleUserProvidedRolesSecret...)...)
-	controllerUser, err := ReconcileUsersAndRoles(context.Background(), c, sampleEsWithAuth, initDynamicWatches(), record.NewFakeRecorder(10))
+	controllerUser, result, err := ReconcileUsersAndRoles(context.Background(), c, credentials.Default, sampleEsWithAuth, initDynamicWatches(), record.NewFakeRecorder(10), acceptAll)
 	require.NoError(t, err)
 	require.NotEmpty(t, controllerUser.Password)
+	// no rotation policy, no need to requeue
+	require.Equal(t, reconcile.Result{}, result)
 	var reconciledSecret corev1.Secret
 	err = c.Get(RolesFileRealmSecretKey(sampleEsWithAuth), &reconciledSecret)
 	require.NoError(t, err)
-	require.Len(t, reconciledSecret.Data, 3)
+	require.Len(t, reconciledSecret.Data, 4)
+	require.Contains(t, reconciledSecret.Data, ServiceTokensFile)
 	require.NotEmpty(t, reconciledSecret.Data[RolesFile])
 	require.NotEmpty(t, reconciledSecret.Data[filerealm.UsersRolesFile])
 	require.NotEmpty(t, reconciledSecret.Data[filerealm.UsersFile])
@@ -50,21 +56,24 @@ func Test_ReconcileRolesFileRealmSecret(t *testing.T) {
 		WithRole("role1", []string{"user1"}).
 		WithRole("role2", []string{"user2"})
 
-	err := reconcileRolesFileRealmSecret(c, es, roles, realm)
+	tokens := ServiceAccountTokens{{ServiceAccount: "elastic/kibana", Name: "ns_kb", Hash: []byte("hash")}}
+
+	err := reconcileRolesFileRealmSecret(c, es, roles, realm, tokens)
 	require.NoError(t, err)
 	// retrieve reconciled secret
 	var secret corev1.Secret
 	err = c.Get(types.NamespacedName{Namespace: es.Namespace, Name: esv1.RolesAndFileRealmSecret(es.Name)}, &secret)
 	require.NoError(t, err)
-	require.Len(t, secret.Data, 3)
+	require.Len(t, secret.Data, 4)
 	require.Contains(t, string(secret.Data[RolesFile]), "click_admins")
+	require.Equal(t, "elastic/kibana/ns_kb:hash\n", string(secret.Data[ServiceTokensFile]))
 	require.Contains(t, string(secret.Data[filerealm.UsersRolesFile]), "role1:user1")
 	require.Contains(t, string(secret.Data[filerealm.UsersFile]), "user1:hash1")
 }
 
 func Test_aggregateFileRealm(t *testing.T) {
 	c := k8s.WrappedFakeClient(sampleUserProvidedFileRealmSecrets...)
-	fileRealm, controllerUser, err := aggregateFileRealm(c, sampleEsWithAuth, initDynamicWatches(), record.NewFakeRecorder(10))
+	fileRealm, controllerUser, _, err := aggregateFileRealm(c, credentials.Default, sampleEsWithAuth, initDynamicWatches(), record.NewFakeRecorder(10), acceptAll, time.Now())
 	require.NoError(t, err)
 	require.NotEmpty(t, controllerUser.Password)
 	actualUsers := fileRealm.UserNames()
@@ -75,6 +84,9 @@ func Test_aggregateRoles(t *testing.T) {
 	c := k8s.WrappedFakeClient(sampleUserProvidedRolesSecret...)
 	roles, err := aggregateRoles(c, sampleEsWithAuth, initDynamicWatches(), record.NewFakeRecorder(10))
 	require.NoError(t, err)
-	require.Len(t, roles, 3)
-	require.Contains(t, roles, ProbeUserRole, "role1", "role2")
+	roleNames := make([]string, 0, len(roles))
+	for name := range roles {
+		roleNames = append(roleNames, name)
+	}
+	require.ElementsMatch(t, []string{ProbeUserRole, AuditBeatUserRole, OperatorMonitoringUserRole, "role1", "role2"}, roleNames)
 }
@@ -18,12 +18,32 @@ const (
 	SuperUserBuiltinRole = "superuser"
 	// ProbeUserRole is the name of the role used by the internal probe user.
 	ProbeUserRole = "elastic_internal_probe_user"
+	// AuditBeatUserRole is the name of the role used by the audit beat sidecars shipping audit logs to a monitoring
+	// cluster.
+	AuditBeatUserRole = "elastic_internal_audit_beat"
+	// OperatorMonitoringUserRole is the name of the role used by the operator to ship its own logs and metrics to a
+	// monitoring cluster.
+	OperatorMonitoringUserRole = "elastic_internal_operator_monitoring"
 )
 
 var (
 	// PredefinedRoles to create for internal needs.
 	PredefinedRoles = RolesFileContent{
 		ProbeUserRole: esclient.Role{Cluster: []string{"monitor"}},
+		AuditBeatUserRole: esclient.Role{
+			Cluster: []string{"monitor", "manage_index_templates", "manage_ilm"},
+			Indices: []esclient.IndicesPrivileges{{
+				Names:      []string{"filebeat-*"},
+				Privileges: []string{"create_index", "write", "view_index_metadata", "manage", "manage_ilm"},
+			}},
+		},
+		OperatorMonitoringUserRole: esclient.Role{
+			Cluster: []string{"monitor"},
+			Indices: []esclient.IndicesPrivileges{{
+				Names:      []string{"eck-operator-*"},
+				Privileges: []string{"create_index", "index"},
+			}},
+		},
 	}
 )
 
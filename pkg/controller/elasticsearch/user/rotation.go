@@ -0,0 +1,261 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package user
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+const (
+	// RotatePasswordsAnnotation can be set on the Elasticsearch resource to rotate the passwords of the elastic user
+	// and of the internal users on demand. A rotation is triggered every time the annotation value changes.
+	RotatePasswordsAnnotation = "elasticsearch.k8s.elastic.co/rotate-passwords"
+
+	// PasswordsRotatedAtAnnotation records when the passwords of a predefined users secret were last generated.
+	PasswordsRotatedAtAnnotation = "elasticsearch.k8s.elastic.co/passwords-rotated-at"
+	// RotationRequestAnnotation records the last value of RotatePasswordsAnnotation handled for a predefined users secret.
+	RotationRequestAnnotation = "elasticsearch.k8s.elastic.co/rotation-request"
+	// RotationStartedAtAnnotation records when the file realm started to use the pending passwords of a predefined
+	// users secret.
+	RotationStartedAtAnnotation = "elasticsearch.k8s.elastic.co/rotation-started-at"
+
+	// PendingPasswordKeySuffix suffixes the keys of the pending passwords in a predefined users secret.
+	PendingPasswordKeySuffix = ".pending"
+
+	// ControllerTransitionUserName is the user the operator authenticates as while the new password of the controller
+	// user propagates to the Elasticsearch nodes.
+	ControllerTransitionUserName = "elastic-internal-transition"
+
+	// PasswordPropagationDelay is the time given to the kubelet to propagate an updated file realm to the
+	// Elasticsearch Pods, and to Elasticsearch to reload it, before verifying that the pending passwords are accepted.
+	PasswordPropagationDelay = 2 * time.Minute
+	// PasswordVerificationInterval is the interval at which pending passwords not accepted yet after
+	// PasswordPropagationDelay are verified again.
+	PasswordVerificationInterval = 10 * time.Second
+)
+
+// PasswordVerifier returns true if all the Elasticsearch nodes accept the given password of the given user.
+type PasswordVerifier func(username string, password []byte) (bool, error)
+
+// rotationAnnotations are the annotations managed by the operator on predefined users secrets.
+var rotationAnnotations = []string{PasswordsRotatedAtAnnotation, RotationRequestAnnotation, RotationStartedAtAnnotation}
+
+// rotationStrategy is the way the passwords of a predefined users secret are rotated.
+type rotationStrategy int
+
+const (
+	// rotateAtOnce rotates the passwords in the file realm and in the secret at once.
+	rotateAtOnce rotationStrategy = iota
+	// rotateStaged sets new passwords in the file realm first, and stores them as pending in the secret. They replace
+	// the existing passwords in the secret once accepted by Elasticsearch, so that the secret never holds a password
+	// not accepted yet. The existing passwords are rejected as soon as the Elasticsearch nodes reload the file realm.
+	rotateStaged
+	// rotateThroughTransitionUser rotates the password of the controller user without interrupting the requests of the
+	// operator: the new password is first set for ControllerTransitionUserName, the operator switches to this user
+	// once accepted by Elasticsearch, and switches back to the controller user once its new password is accepted in
+	// turn. The other users are rotated at once.
+	rotateThroughTransitionUser
+)
+
+// passwordRotation is the outcome of the rotation of the passwords of a predefined users secret.
+type passwordRotation struct {
+	// users with the password expected in the file realm
+	users users
+	// data and annotations of the predefined users secret
+	data        map[string][]byte
+	annotations map[string]string
+	// requeueAfter is the duration after which the rotation must be reconciled again, zero if not needed
+	requeueAfter time.Duration
+}
+
+// ControllerCredentials returns the credentials the operator authenticates with, from the data of the internal users
+// secret: the ones of ControllerTransitionUserName while the new password of the controller user propagates, the ones
+// of the controller user otherwise.
+func ControllerCredentials(data map[string][]byte) (client.BasicAuth, error) {
+	if password, exists := data[ControllerTransitionUserName]; exists {
+		return client.BasicAuth{Name: ControllerTransitionUserName, Password: string(password)}, nil
+	}
+	password, exists := data[ControllerUserName]
+	if !exists {
+		return client.BasicAuth{}, fmt.Errorf("no password for user %s", ControllerUserName)
+	}
+	return client.BasicAuth{Name: ControllerUserName, Password: string(password)}, nil
+}
+
+// rotatePasswords sets the passwords of the given users, reusing the ones of the existing secret if any, and rotating
+// them with the given strategy if requested through RotatePasswordsAnnotation or if older than the max age of the
+// rotation policy. A rotation only progresses once the new passwords are accepted by Elasticsearch.
+func rotatePasswords(
+	es esv1.Elasticsearch,
+	existing corev1.Secret,
+	predefined users,
+	strategy rotationStrategy,
+	now time.Time,
+	accepted func(username string, password []byte) bool,
+) passwordRotation {
+	rotatedAt, err := time.Parse(time.RFC3339, existing.Annotations[PasswordsRotatedAtAnnotation])
+	lastRequest := existing.Annotations[RotationRequestAnnotation]
+	request := es.Annotations[RotatePasswordsAnnotation]
+	if err != nil {
+		// first reconciliation of the secret, the current passwords can be considered as rotated now
+		rotatedAt = now
+		lastRequest = request
+	}
+
+	published := make([][]byte, len(predefined))
+	for i, u := range predefined {
+		if password, exists := existing.Data[u.Name]; exists {
+			published[i] = password
+		} else {
+			published[i] = common.RandomPasswordBytes()
+		}
+	}
+
+	// users whose new password is stored as pending until propagated
+	isStaged := func(u user) bool {
+		return strategy == rotateStaged || (strategy == rotateThroughTransitionUser && u.Name == ControllerUserName)
+	}
+
+	var pending [][]byte
+	// transition is the password of the transition user once the operator authenticates with it
+	var transition []byte
+	startedAt, err := time.Parse(time.RFC3339, existing.Annotations[RotationStartedAtAnnotation])
+	if err == nil {
+		pending = make([][]byte, len(predefined))
+		for i, u := range predefined {
+			if !isStaged(u) {
+				continue
+			}
+			password, exists := existing.Data[u.Name+PendingPasswordKeySuffix]
+			if !exists {
+				// the pending rotation cannot be completed, start a new one
+				pending = nil
+				break
+			}
+			pending[i] = password
+		}
+		if strategy == rotateThroughTransitionUser && pending == nil {
+			transition = existing.Data[ControllerTransitionUserName]
+		}
+	}
+
+	maxAge := es.Spec.Auth.PasswordRotationMaxAge()
+	rotationDue := request != lastRequest || (maxAge > 0 && now.Sub(rotatedAt) >= maxAge)
+	if pending == nil && transition == nil && rotationDue {
+		lastRequest = request
+		for i, u := range predefined {
+			generated := common.RandomPasswordBytes()
+			if !isStaged(u) {
+				published[i] = generated
+				continue
+			}
+			if pending == nil {
+				pending = make([][]byte, len(predefined))
+			}
+			pending[i] = generated
+		}
+		if pending == nil {
+			rotatedAt = now
+		} else {
+			startedAt = now
+		}
+	}
+
+	// pendingAccepted returns true if Elasticsearch accepts the pending passwords, set for the transition user when
+	// rotating through it
+	pendingAccepted := func() bool {
+		for i, u := range predefined {
+			if pending[i] == nil {
+				continue
+			}
+			name := u.Name
+			if strategy == rotateThroughTransitionUser {
+				name = ControllerTransitionUserName
+			}
+			if !accepted(name, pending[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case pending != nil && now.Sub(startedAt) >= PasswordPropagationDelay && pendingAccepted():
+		// pending passwords are now accepted by Elasticsearch and can be published
+		for i, password := range pending {
+			if password != nil {
+				published[i] = password
+				if strategy == rotateThroughTransitionUser {
+					// the operator authenticates as the transition user while the new password of the controller
+					// user propagates in turn
+					transition = password
+					startedAt = now
+				}
+			}
+		}
+		pending = nil
+		if transition == nil {
+			rotatedAt = now
+		}
+	case transition != nil && now.Sub(startedAt) >= PasswordPropagationDelay && accepted(ControllerUserName, transition):
+		// the new password of the controller user is now accepted, the transition user can be removed
+		transition = nil
+		rotatedAt = now
+	}
+
+	rotation := passwordRotation{
+		users: make(users, 0, len(predefined)+1),
+		data:  make(map[string][]byte, len(predefined)),
+		annotations: map[string]string{
+			PasswordsRotatedAtAnnotation: rotatedAt.Format(time.RFC3339),
+		},
+	}
+	if lastRequest != "" {
+		rotation.annotations[RotationRequestAnnotation] = lastRequest
+	}
+	var transitionUser *user
+	for i, u := range predefined {
+		u.Password = published[i]
+		rotation.data[u.Name] = published[i]
+		if pending != nil && pending[i] != nil {
+			rotation.data[u.Name+PendingPasswordKeySuffix] = pending[i]
+			if strategy == rotateThroughTransitionUser {
+				// the controller user keeps its current password until the operator authenticates as the
+				// transition user, which is given the new password
+				transitionUser = &user{Name: ControllerTransitionUserName, Password: pending[i], Roles: u.Roles}
+			} else {
+				u.Password = pending[i]
+			}
+		}
+		if transition != nil && u.Name == ControllerUserName {
+			rotation.data[ControllerTransitionUserName] = transition
+			transitionUser = &user{Name: ControllerTransitionUserName, Password: transition, Roles: u.Roles}
+		}
+		rotation.users = append(rotation.users, u)
+	}
+	if transitionUser != nil {
+		rotation.users = append(rotation.users, *transitionUser)
+	}
+
+	switch {
+	case pending != nil || transition != nil:
+		rotation.annotations[RotationStartedAtAnnotation] = startedAt.Format(time.RFC3339)
+		rotation.requeueAfter = PasswordPropagationDelay - now.Sub(startedAt)
+		if rotation.requeueAfter <= 0 {
+			// the new passwords are not accepted yet
+			rotation.requeueAfter = PasswordVerificationInterval
+		}
+	case maxAge > 0:
+		rotation.requeueAfter = maxAge - now.Sub(rotatedAt)
+	}
+	return rotation
+}
@@ -0,0 +1,213 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user/filerealm"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var rotationNow = time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// acceptAll is a PasswordVerifier for which Elasticsearch accepts all the passwords.
+func acceptAll(string, []byte) (bool, error) {
+	return true, nil
+}
+
+// acceptNone is a PasswordVerifier for which Elasticsearch does not accept any password yet.
+func acceptNone(string, []byte) (bool, error) {
+	return false, nil
+}
+
+func getSecret(t *testing.T, c k8s.Client, es esv1.Elasticsearch, name string) corev1.Secret {
+	var secret corev1.Secret
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: es.Namespace, Name: name}, &secret))
+	return secret
+}
+
+func Test_reconcileElasticUser_rotation(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	c := k8s.WrappedFakeClient()
+
+	// initial password, no rotation policy
+	u, requeueAfter, err := reconcileElasticUser(c, credentials.Default, es, filerealm.New(), acceptAll, rotationNow)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), requeueAfter)
+	initial := u[0].Password
+	secret := getSecret(t, c, es, esv1.ElasticUserSecret(es.Name))
+	require.Equal(t, rotationNow.Format(time.RFC3339), secret.Annotations[PasswordsRotatedAtAnnotation])
+
+	// request a rotation: the file realm uses a pending password, the secret still holds the initial one
+	es.Annotations = map[string]string{RotatePasswordsAnnotation: "1"}
+	now := rotationNow.Add(time.Hour)
+	u, requeueAfter, err = reconcileElasticUser(c, credentials.Default, es, users{{Name: ElasticUserName, PasswordHash: u[0].PasswordHash}}.fileRealm(), acceptAll, now)
+	require.NoError(t, err)
+	require.Equal(t, PasswordPropagationDelay, requeueAfter)
+	pending := u[0].Password
+	require.NotEqual(t, initial, pending)
+	require.NoError(t, bcrypt.CompareHashAndPassword(u[0].PasswordHash, pending))
+	secret = getSecret(t, c, es, esv1.ElasticUserSecret(es.Name))
+	require.Equal(t, initial, secret.Data[ElasticUserName])
+	require.Equal(t, pending, secret.Data[ElasticUserName+PendingPasswordKeySuffix])
+	require.Equal(t, now.Format(time.RFC3339), secret.Annotations[RotationStartedAtAnnotation])
+	require.Equal(t, "1", secret.Annotations[RotationRequestAnnotation])
+
+	// propagation still in progress: nothing changes
+	u, requeueAfter, err = reconcileElasticUser(c, credentials.Default, es, u.fileRealm(), acceptAll, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, PasswordPropagationDelay-time.Minute, requeueAfter)
+	require.Equal(t, pending, u[0].Password)
+	require.Equal(t, initial, getSecret(t, c, es, esv1.ElasticUserSecret(es.Name)).Data[ElasticUserName])
+
+	// propagation delay elapsed but pending password not accepted yet: nothing changes, the password is verified again
+	u, requeueAfter, err = reconcileElasticUser(c, credentials.Default, es, u.fileRealm(), acceptNone, now.Add(PasswordPropagationDelay))
+	require.NoError(t, err)
+	require.Equal(t, PasswordVerificationInterval, requeueAfter)
+	require.Equal(t, pending, u[0].Password)
+	require.Equal(t, initial, getSecret(t, c, es, esv1.ElasticUserSecret(es.Name)).Data[ElasticUserName])
+
+	// pending password accepted: it is published in the secret
+	now = now.Add(PasswordPropagationDelay)
+	u, requeueAfter, err = reconcileElasticUser(c, credentials.Default, es, u.fileRealm(), acceptAll, now)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), requeueAfter)
+	require.Equal(t, pending, u[0].Password)
+	secret = getSecret(t, c, es, esv1.ElasticUserSecret(es.Name))
+	require.Equal(t, map[string][]byte{ElasticUserName: pending}, secret.Data)
+	require.Equal(t, map[string]string{
+		PasswordsRotatedAtAnnotation: now.Format(time.RFC3339),
+		RotationRequestAnnotation:    "1",
+	}, secret.Annotations)
+
+	// same request value: no new rotation
+	u, _, err = reconcileElasticUser(c, credentials.Default, es, u.fileRealm(), acceptAll, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, pending, u[0].Password)
+}
+
+func Test_reconcileInternalUsers_rotation(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	es.Spec.Auth.PasswordRotation = &esv1.PasswordRotation{MaxAge: &metav1.Duration{Duration: 24 * time.Hour}}
+	c := k8s.WrappedFakeClient()
+
+	// accepted returns true if the given credentials are valid in the given file realm
+	accepted := func(realm filerealm.Realm, creds esclient.BasicAuth) bool {
+		return bcrypt.CompareHashAndPassword(realm.PasswordHashForUser(creds.Name), []byte(creds.Password)) == nil
+	}
+	// reconcile checks that the credentials of the operator are accepted by Elasticsearch with the previous file
+	// realm, still used by the nodes until the new one propagates, and with the new file realm
+	realm := filerealm.New()
+	reconcile := func(now time.Time) (users, esclient.BasicAuth, time.Duration) {
+		u, creds, requeueAfter, err := reconcileInternalUsers(c, credentials.Default, es, realm, acceptAll, now)
+		require.NoError(t, err)
+		if len(realm.UserNames()) > 0 {
+			require.True(t, accepted(realm, creds), "credentials of %s rejected by the previous file realm", creds.Name)
+		}
+		realm = u.fileRealm()
+		require.True(t, accepted(realm, creds), "credentials of %s rejected by the new file realm", creds.Name)
+		return u, creds, requeueAfter
+	}
+
+	u, creds, requeueAfter := reconcile(rotationNow)
+	require.Equal(t, 24*time.Hour, requeueAfter)
+	require.Equal(t, ControllerUserName, creds.Name)
+	initial := u
+
+	// not expired yet
+	u, _, requeueAfter = reconcile(rotationNow.Add(23 * time.Hour))
+	require.Equal(t, time.Hour, requeueAfter)
+	require.Equal(t, initial[0].Password, u[0].Password)
+
+	// expired: the probe user is rotated at once, the new controller user password is set for the transition user
+	now := rotationNow.Add(25 * time.Hour)
+	u, creds, requeueAfter = reconcile(now)
+	require.Equal(t, PasswordPropagationDelay, requeueAfter)
+	require.Equal(t, esclient.BasicAuth{Name: ControllerUserName, Password: string(initial[0].Password)}, creds)
+	require.Len(t, u, 3)
+	require.NotEqual(t, initial[1].Password, u[1].Password)
+	require.Equal(t, ControllerTransitionUserName, u[2].Name)
+	rotated := u[2].Password
+	secret := getSecret(t, c, es, esv1.InternalUsersSecret(es.Name))
+	require.Equal(t, initial[0].Password, secret.Data[ControllerUserName])
+	require.Equal(t, rotated, secret.Data[ControllerUserName+PendingPasswordKeySuffix])
+	require.Equal(t, u[1].Password, secret.Data[ProbeUserName])
+
+	// propagation still in progress: nothing changes
+	_, creds, requeueAfter = reconcile(now.Add(time.Minute))
+	require.Equal(t, PasswordPropagationDelay-time.Minute, requeueAfter)
+	require.Equal(t, ControllerUserName, creds.Name)
+
+	// the transition user is accepted: the operator switches to it, and the controller user gets the new password
+	now = now.Add(PasswordPropagationDelay)
+	u, creds, requeueAfter = reconcile(now)
+	require.Equal(t, PasswordPropagationDelay, requeueAfter)
+	require.Equal(t, esclient.BasicAuth{Name: ControllerTransitionUserName, Password: string(rotated)}, creds)
+	require.Equal(t, rotated, u[0].Password)
+	secret = getSecret(t, c, es, esv1.InternalUsersSecret(es.Name))
+	require.Equal(t, map[string][]byte{
+		ControllerUserName:           rotated,
+		ControllerTransitionUserName: rotated,
+		ProbeUserName:                u[1].Password,
+	}, secret.Data)
+	remoteCreds, err := ControllerCredentials(secret.Data)
+	require.NoError(t, err)
+	require.Equal(t, creds, remoteCreds)
+
+	// the new controller user password is accepted: the operator switches back, and the transition user is removed
+	now = now.Add(PasswordPropagationDelay)
+	u, creds, requeueAfter = reconcile(now)
+	require.Equal(t, 24*time.Hour, requeueAfter)
+	require.Equal(t, esclient.BasicAuth{Name: ControllerUserName, Password: string(rotated)}, creds)
+	require.Len(t, u, 2)
+	secret = getSecret(t, c, es, esv1.InternalUsersSecret(es.Name))
+	require.Len(t, secret.Data, 2)
+	require.Equal(t, now.Format(time.RFC3339), secret.Annotations[PasswordsRotatedAtAnnotation])
+	require.NotContains(t, secret.Annotations, RotationStartedAtAnnotation)
+	for _, user := range u {
+		require.NotEqual(t, initial[0].Password, user.Password)
+		require.NoError(t, bcrypt.CompareHashAndPassword(user.PasswordHash, user.Password))
+	}
+}
+
+func Test_rotatePasswords(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "es",
+		Annotations: map[string]string{RotatePasswordsAnnotation: "requested"},
+	}}
+	predefined := users{{Name: ElasticUserName}}
+	accepted := func(string, []byte) bool { return true }
+
+	// a request set before the secret was created does not trigger a rotation
+	rotation := rotatePasswords(es, corev1.Secret{}, predefined, rotateStaged, rotationNow, accepted)
+	require.Len(t, rotation.data, 1)
+	require.Equal(t, "requested", rotation.annotations[RotationRequestAnnotation])
+
+	// a pending rotation missing its passwords is restarted
+	existing := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			PasswordsRotatedAtAnnotation: rotationNow.Format(time.RFC3339),
+			RotationStartedAtAnnotation:  rotationNow.Format(time.RFC3339),
+		}},
+		Data: map[string][]byte{ElasticUserName: []byte("password")},
+	}
+	now := rotationNow.Add(time.Hour)
+	rotation = rotatePasswords(es, existing, predefined, rotateStaged, now, accepted)
+	require.Equal(t, []byte("password"), rotation.data[ElasticUserName])
+	require.NotEmpty(t, rotation.data[ElasticUserName+PendingPasswordKeySuffix])
+	require.Equal(t, now.Format(time.RFC3339), rotation.annotations[RotationStartedAtAnnotation])
+}
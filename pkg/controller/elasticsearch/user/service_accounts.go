@@ -0,0 +1,105 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// ServiceTokensFile is the name of the service account tokens file in the ES config dir.
+	ServiceTokensFile = "service_tokens"
+
+	// AssociatedServiceAccountTokenType is used to annotate an associated service account token secret, most likely
+	// created by an association controller.
+	AssociatedServiceAccountTokenType = "service-account-token"
+
+	// ServiceAccountNameField is the field in the secret that contains the qualified name of the service account.
+	ServiceAccountNameField = "serviceAccount"
+	// ServiceAccountTokenNameField is the field in the secret that contains the name of the token.
+	ServiceAccountTokenNameField = "tokenName"
+	// ServiceAccountTokenHashField is the field in the secret that contains the hash of the token.
+	ServiceAccountTokenHashField = "hash"
+)
+
+// AssociatedServiceAccountTokenLabels returns labels matching associated service account tokens for the given es resource.
+func AssociatedServiceAccountTokenLabels(es esv1.Elasticsearch) map[string]string {
+	return map[string]string{
+		label.ClusterNameLabelName: es.Name,
+		common.TypeLabelName:       AssociatedServiceAccountTokenType,
+	}
+}
+
+// ServiceAccountToken is a service account token, as specified in the service tokens file.
+type ServiceAccountToken struct {
+	// ServiceAccount is the qualified name of the service account, for example elastic/kibana.
+	ServiceAccount string
+	// Name of the token.
+	Name string
+	// Hash of the token.
+	Hash []byte
+}
+
+// ServiceAccountTokens are the service account tokens of the service tokens file.
+type ServiceAccountTokens []ServiceAccountToken
+
+// FileBytes returns the content of the service tokens file, sorted for stable comparisons.
+func (s ServiceAccountTokens) FileBytes() []byte {
+	lines := make([]string, 0, len(s))
+	for _, token := range s {
+		lines = append(lines, fmt.Sprintf("%s/%s:%s", token.ServiceAccount, token.Name, token.Hash))
+	}
+	sort.Strings(lines)
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// retrieveServiceAccountTokens fetches service account tokens resulting from an association.
+// Those tokens are created by an association controller.
+func retrieveServiceAccountTokens(c k8s.Client, es esv1.Elasticsearch) (ServiceAccountTokens, error) {
+	var secrets corev1.SecretList
+	if err := c.List(
+		&secrets,
+		client.InNamespace(es.Namespace),
+		client.MatchingLabels(AssociatedServiceAccountTokenLabels(es)),
+	); err != nil {
+		return nil, err
+	}
+	tokens := make(ServiceAccountTokens, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		token := ServiceAccountToken{}
+		for field, value := range map[string]*string{
+			ServiceAccountNameField:      &token.ServiceAccount,
+			ServiceAccountTokenNameField: &token.Name,
+		} {
+			data, exists := secret.Data[field]
+			if !exists || len(data) == 0 {
+				return nil, fmt.Errorf(fieldNotFound, field, secret.Namespace, secret.Name)
+			}
+			*value = string(data)
+		}
+		hash, exists := secret.Data[ServiceAccountTokenHashField]
+		if !exists || len(hash) == 0 {
+			return nil, fmt.Errorf(fieldNotFound, ServiceAccountTokenHashField, secret.Namespace, secret.Name)
+		}
+		token.Hash = hash
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
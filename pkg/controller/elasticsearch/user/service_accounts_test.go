@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_retrieveServiceAccountTokens(t *testing.T) {
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	tokenSecret := func(name, esName, tokenName string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      name,
+				Labels:    AssociatedServiceAccountTokenLabels(esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Name: esName}}),
+			},
+			Data: map[string][]byte{
+				ServiceAccountNameField:      []byte("elastic/kibana"),
+				ServiceAccountTokenNameField: []byte(tokenName),
+				ServiceAccountTokenHashField: []byte("{PBKDF2_STRETCH}10000$salt$hash"),
+			},
+		}
+	}
+	c := k8s.WrappedFakeClient(
+		tokenSecret("kb2", "es", "ns_kb2"),
+		tokenSecret("kb1", "es", "ns_kb1"),
+		tokenSecret("other", "other-es", "ns_other"),
+	)
+	tokens, err := retrieveServiceAccountTokens(c, es)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	require.Equal(t, `elastic/kibana/ns_kb1:{PBKDF2_STRETCH}10000$salt$hash
+elastic/kibana/ns_kb2:{PBKDF2_STRETCH}10000$salt$hash
+`, string(tokens.FileBytes()))
+
+	// invalid secret
+	invalid := tokenSecret("invalid", "es", "")
+	require.NoError(t, c.Create(invalid))
+	_, err = retrieveServiceAccountTokens(c, es)
+	require.Error(t, err)
+}
@@ -39,6 +39,16 @@ const (
 	ScriptsVolumeName      = "elastic-internal-scripts"
 	ScriptsVolumeMountPath = "/mnt/elastic-internal/scripts"
 
+	SAMLMetadataVolumeNamePrefix = "elastic-internal-saml-metadata-"
+	SAMLMetadataVolumeMountPath  = "/usr/share/elasticsearch/config/saml-metadata"
+
+	AnalysisFilesVolumeNamePrefix = "elastic-internal-analysis-files-"
+
+	GeoIPDatabasesVolumeName = "elastic-internal-geoip-databases"
+
+	DiagnosticsVolumeName      = "elastic-internal-diagnostics"
+	DiagnosticsVolumeMountPath = "/usr/share/elasticsearch/diagnostics"
+
 	DownwardAPIVolumeName = "downward-api"
 	DownwardAPIMountPath  = "/mnt/elastic-internal/downward-api"
 	LabelsFile            = "labels"
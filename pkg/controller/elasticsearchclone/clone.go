@@ -0,0 +1,361 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearchclone
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+const (
+	// ClonedFromAnnotation holds the namespace and name of the cluster an Elasticsearch resource was cloned from.
+	ClonedFromAnnotation = "elasticsearch.k8s.elastic.co/cloned-from"
+
+	// defaultIndices are the indices restored if none are specified: all of them except the hidden and system ones.
+	defaultIndices = "*,-.*"
+)
+
+// reconcileClone runs the step of the clone operation matching its current phase, and returns the updated status.
+func (r *ReconcileElasticsearchClone) reconcileClone(ctx context.Context, clone esv1.ElasticsearchClone) (esv1.ElasticsearchCloneStatus, error) {
+	status := clone.Status
+	var err error
+	switch status.Phase {
+	case "", esv1.CloneSnapshottingPhase:
+		status.Phase = esv1.CloneSnapshottingPhase
+		err = r.reconcileSnapshot(ctx, clone, &status)
+	case esv1.CloneCreatingPhase:
+		err = r.reconcileCluster(ctx, clone, &status)
+	case esv1.CloneRestoringPhase:
+		err = r.reconcileRestore(ctx, clone, &status)
+	}
+	return status, err
+}
+
+// fail moves the clone to the failed phase.
+func fail(status *esv1.ElasticsearchCloneStatus, format string, args ...interface{}) {
+	status.Phase = esv1.CloneFailedPhase
+	status.Message = fmt.Sprintf(format, args...)
+}
+
+// getSource returns the cluster to clone. It returns false if it does not exist or cannot be accessed, in which case
+// the status message is updated to report it.
+func (r *ReconcileElasticsearchClone) getSource(clone esv1.ElasticsearchClone, status *esv1.ElasticsearchCloneStatus) (esv1.Elasticsearch, bool, error) {
+	ref := clone.Spec.SourceRef.WithDefaultNamespace(clone.Namespace).NamespacedName()
+	var source esv1.Elasticsearch
+	if err := r.Get(ref, &source); err != nil {
+		if apierrors.IsNotFound(err) {
+			status.Message = fmt.Sprintf("Elasticsearch %s not found", ref)
+			return source, false, nil
+		}
+		return source, false, err
+	}
+	if ref.Namespace != clone.Namespace {
+		allowed, err := r.accessReviewer.AccessAllowed(clone.Spec.ServiceAccountName, clone.Namespace, &source)
+		if err != nil {
+			return source, false, err
+		}
+		if !allowed {
+			status.Message = fmt.Sprintf("Access to Elasticsearch %s is not allowed", ref)
+			return source, false, nil
+		}
+	}
+	return source, true, nil
+}
+
+// snapshotName returns the name of the snapshot taken for the clone, unique to the clone resource.
+func snapshotName(clone esv1.ElasticsearchClone) string {
+	uid := string(clone.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return strings.ToLower(clone.Name + "-" + uid)
+}
+
+// reconcileSnapshot takes the snapshot of the source cluster if needed, and waits for its completion.
+func (r *ReconcileElasticsearchClone) reconcileSnapshot(ctx context.Context, clone esv1.ElasticsearchClone, status *esv1.ElasticsearchCloneStatus) error {
+	source, ok, err := r.getSource(clone, status)
+	if err != nil || !ok {
+		return err
+	}
+	client, err := r.esClient(r.Client, r.Dialer, source)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status.Snapshot = clone.Spec.Snapshot
+	if status.Snapshot == "" {
+		status.Snapshot = snapshotName(clone)
+	}
+	snapshot, err := client.GetSnapshot(ctx, clone.Spec.Repository, status.Snapshot)
+	if esclient.IsNotFound(err) {
+		// a missing repository is reported as not found as well, the snapshot could never be taken
+		if _, err := client.GetSnapshotRepository(ctx, clone.Spec.Repository); esclient.IsNotFound(err) {
+			fail(status, "Snapshot repository %s not found", clone.Spec.Repository)
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	switch {
+	case esclient.IsNotFound(err) && clone.Spec.Snapshot == "":
+		log.Info("Creating snapshot", "namespace", clone.Namespace, "clone_name", clone.Name, "snapshot", status.Snapshot)
+		status.Message = "Snapshot started"
+		return client.CreateSnapshot(ctx, clone.Spec.Repository, status.Snapshot, esclient.SnapshotRequest{
+			Indices:            strings.Join(clone.Spec.Indices, ","),
+			IncludeGlobalState: false,
+		})
+	case esclient.IsNotFound(err):
+		fail(status, "Snapshot %s not found in repository %s", status.Snapshot, clone.Spec.Repository)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	switch snapshot.State {
+	case esclient.SnapshotStateInProgress:
+		status.Message = "Snapshot in progress"
+	case esclient.SnapshotStateSuccess:
+		status.Phase = esv1.CloneCreatingPhase
+		status.Message = ""
+	default:
+		fail(status, "Snapshot %s is %s", status.Snapshot, snapshot.State)
+	}
+	return nil
+}
+
+// newCluster returns the Elasticsearch resource created by the clone, with the specification of the source cluster.
+func newCluster(clone esv1.ElasticsearchClone, source esv1.Elasticsearch) (esv1.Elasticsearch, error) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clone.Namespace,
+			Name:      clone.ElasticsearchName(),
+			Annotations: map[string]string{
+				ClonedFromAnnotation: k8s.ExtractNamespacedName(&source).String(),
+			},
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	err := controllerutil.SetControllerReference(&clone, &es, scheme.Scheme)
+	return es, err
+}
+
+// referencedSecrets returns the names of the Secrets referenced by the specification of the given cluster.
+func referencedSecrets(es esv1.Elasticsearch) []string {
+	var names []string
+	for _, secureSettings := range es.SecureSettings() {
+		names = append(names, secureSettings.SecretName)
+	}
+	for _, roles := range es.Spec.Auth.Roles {
+		names = append(names, roles.SecretName)
+	}
+	for _, fileRealm := range es.Spec.Auth.FileRealm {
+		names = append(names, fileRealm.SecretName)
+	}
+	for _, realm := range es.Spec.Auth.SAML {
+		names = append(names, realm.IdPMetadata.SecretName)
+	}
+	names = append(names, es.Spec.HTTP.TLS.Certificate.SecretName)
+	if es.Spec.Plugins != nil && es.Spec.Plugins.Bundle != nil {
+		names = append(names, es.Spec.Plugins.Bundle.SecretName)
+	}
+	return names
+}
+
+// missingSecrets returns the names of the Secrets referenced by the source cluster that do not exist in the namespace
+// of the clone, which would prevent the new cluster from starting.
+func (r *ReconcileElasticsearchClone) missingSecrets(clone esv1.ElasticsearchClone, source esv1.Elasticsearch) ([]string, error) {
+	var missing []string
+	for _, name := range referencedSecrets(source) {
+		if name == "" || stringsutil.StringInSlice(name, missing) {
+			continue
+		}
+		var secret corev1.Secret
+		err := r.Get(types.NamespacedName{Namespace: clone.Namespace, Name: name}, &secret)
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+// reconcileCluster creates the new cluster, and starts the restore once it is ready.
+func (r *ReconcileElasticsearchClone) reconcileCluster(ctx context.Context, clone esv1.ElasticsearchClone, status *esv1.ElasticsearchCloneStatus) error {
+	var es esv1.Elasticsearch
+	err := r.Get(types.NamespacedName{Namespace: clone.Namespace, Name: clone.ElasticsearchName()}, &es)
+	switch {
+	case apierrors.IsNotFound(err):
+		source, ok, err := r.getSource(clone, status)
+		if err != nil || !ok {
+			return err
+		}
+		missing, err := r.missingSecrets(clone, source)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			fail(status, "Secrets %s referenced by Elasticsearch %s not found in namespace %s",
+				strings.Join(missing, ", "), k8s.ExtractNamespacedName(&source), clone.Namespace)
+			return nil
+		}
+		if es, err = newCluster(clone, source); err != nil {
+			return err
+		}
+		log.Info("Creating Elasticsearch cluster", "namespace", clone.Namespace, "clone_name", clone.Name, "es_name", es.Name)
+		status.Message = "Waiting for the cluster to be ready"
+		return r.Create(&es)
+	case err != nil:
+		return err
+	case !metav1.IsControlledBy(&es, &clone):
+		fail(status, "Elasticsearch %s already exists", es.Name)
+		return nil
+	case es.Status.Phase != esv1.ElasticsearchReadyPhase:
+		status.Message = "Waiting for the cluster to be ready"
+		return nil
+	}
+	return r.startRestore(ctx, clone, es, status)
+}
+
+// startRestore registers the snapshot repository in the new cluster and restores the snapshot, unless it is already
+// being restored.
+func (r *ReconcileElasticsearchClone) startRestore(ctx context.Context, clone esv1.ElasticsearchClone, es esv1.Elasticsearch, status *esv1.ElasticsearchCloneStatus) error {
+	client, err := r.esClient(r.Client, r.Dialer, es)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.GetSnapshotRepository(ctx, clone.Spec.Repository)
+	switch {
+	case esclient.IsNotFound(err):
+		registered, err := r.registerRepository(ctx, clone, client, status)
+		if err != nil || !registered {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+
+	recoveries, err := client.GetRecoveries(ctx)
+	if err != nil {
+		return err
+	}
+	// the restore may have been started by a previous reconciliation whose status update failed
+	if total, _ := restoreProgress(recoveries, clone.Spec.Repository, status.Snapshot); total == 0 {
+		indices := defaultIndices
+		if len(clone.Spec.Indices) > 0 {
+			indices = strings.Join(clone.Spec.Indices, ",")
+		}
+		log.Info("Restoring snapshot", "namespace", clone.Namespace, "clone_name", clone.Name, "snapshot", status.Snapshot)
+		if err := client.RestoreSnapshot(ctx, clone.Spec.Repository, status.Snapshot, esclient.RestoreRequest{
+			Indices:            indices,
+			IncludeGlobalState: false,
+		}); err != nil {
+			return err
+		}
+	}
+	status.Phase = esv1.CloneRestoringPhase
+	status.Message = "Restore in progress"
+	return nil
+}
+
+// registerRepository registers the snapshot repository of the source cluster in the new cluster, read-only so that
+// the new cluster cannot alter the snapshots of the source cluster. It returns false if the repository definition cannot
+// be retrieved from the source cluster, in which case the status is updated to report it.
+func (r *ReconcileElasticsearchClone) registerRepository(ctx context.Context, clone esv1.ElasticsearchClone, client esclient.Client, status *esv1.ElasticsearchCloneStatus) (bool, error) {
+	source, ok, err := r.getSource(clone, status)
+	if err != nil || !ok {
+		return false, err
+	}
+	sourceClient, err := r.esClient(r.Client, r.Dialer, source)
+	if err != nil {
+		return false, err
+	}
+	defer sourceClient.Close()
+	repository, err := sourceClient.GetSnapshotRepository(ctx, clone.Spec.Repository)
+	if esclient.IsNotFound(err) {
+		fail(status, "Snapshot repository %s not found", clone.Spec.Repository)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	settings := make(map[string]interface{}, len(repository.Settings)+1)
+	for k, v := range repository.Settings {
+		settings[k] = v
+	}
+	settings["readonly"] = true
+	repository.Settings = settings
+	log.Info("Registering snapshot repository", "namespace", clone.Namespace, "clone_name", clone.Name, "repository", clone.Spec.Repository)
+	return true, client.UpdateSnapshotRepository(ctx, clone.Spec.Repository, repository)
+}
+
+// restoreProgress returns the number of primary shards being restored from the given snapshot, and how many of them
+// are restored.
+func restoreProgress(recoveries esclient.Recoveries, repository string, snapshot string) (total int, restored int) {
+	for _, index := range recoveries {
+		for _, shard := range index.Shards {
+			if !shard.Primary || shard.Type != esclient.RecoveryTypeSnapshot ||
+				shard.Source.Repository != repository || shard.Source.Snapshot != snapshot {
+				continue
+			}
+			total++
+			if shard.Stage == esclient.RecoveryStageDone {
+				restored++
+			}
+		}
+	}
+	return total, restored
+}
+
+// reconcileRestore reports the progress of the restore, until all the shards are restored.
+func (r *ReconcileElasticsearchClone) reconcileRestore(ctx context.Context, clone esv1.ElasticsearchClone, status *esv1.ElasticsearchCloneStatus) error {
+	var es esv1.Elasticsearch
+	if err := r.Get(types.NamespacedName{Namespace: clone.Namespace, Name: clone.ElasticsearchName()}, &es); err != nil {
+		if apierrors.IsNotFound(err) {
+			fail(status, "Elasticsearch %s was deleted during the restore", clone.ElasticsearchName())
+			return nil
+		}
+		return err
+	}
+	client, err := r.esClient(r.Client, r.Dialer, es)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	recoveries, err := client.GetRecoveries(ctx)
+	if err != nil {
+		return err
+	}
+
+	status.TotalShards, status.RestoredShards = restoreProgress(recoveries, clone.Spec.Repository, status.Snapshot)
+	if status.RestoredShards < status.TotalShards {
+		status.Message = "Restore in progress"
+		return nil
+	}
+	now := metav1.Now()
+	status.Phase = esv1.CloneCompletedPhase
+	status.Message = fmt.Sprintf("%d primary shards restored", status.RestoredShards)
+	status.CompletedAt = &now
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearchclone
+
+import (
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+)
+
+// ElasticsearchClone controller
+//
+// This controller creates a new Elasticsearch cluster from an existing one, declared by an ElasticsearchClone:
+// - a snapshot of the source cluster is taken in the given repository, unless an existing snapshot is specified
+// - an Elasticsearch resource owned by the clone is created with the specification of the source cluster
+// - once the new cluster is ready, the repository is registered read-only and the snapshot is restored
+// The operation runs once: the clone must be deleted and created again to refresh the data. Deleting the clone also
+// deletes the new cluster.
+
+const name = "elasticsearchclone-controller"
+
+// requeueInterval is the interval at which the progress of the snapshot, the cluster creation and the restore is
+// checked.
+const requeueInterval = 10 * time.Second
+
+var log = logf.Log.WithName(name)
+
+// Add creates a new ElasticsearchClone Controller and adds it to the Manager with default RBAC. The Manager will set
+// fields on the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
+	r := newReconciler(mgr, accessReviewer, params)
+	c, err := common.NewController(mgr, name, &esv1.ElasticsearchClone{}, r, params)
+	if err != nil {
+		return err
+	}
+	return addWatches(c)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) *ReconcileElasticsearchClone {
+	return &ReconcileElasticsearchClone{
+		Client:         k8s.WrapClient(mgr.GetClient()),
+		accessReviewer: accessReviewer,
+		recorder:       mgr.GetEventRecorderFor(name),
+		esClient:       remotecluster.NewESClient,
+		Parameters:     params,
+	}
+}
+
+func addWatches(c controller.Controller) error {
+	// watch clones
+	if err := c.Watch(&source.Kind{Type: &esv1.ElasticsearchClone{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	// watch the Elasticsearch clusters created by the clones, to restore the data once they are ready
+	return c.Watch(&source.Kind{Type: &esv1.Elasticsearch{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &esv1.ElasticsearchClone{},
+	})
+}
+
+// esClientProvider returns a client for the given Elasticsearch cluster.
+type esClientProvider func(c k8s.Client, dialer net.Dialer, es esv1.Elasticsearch) (esclient.Client, error)
+
+var _ reconcile.Reconciler = &ReconcileElasticsearchClone{}
+
+// ReconcileElasticsearchClone reconciles an ElasticsearchClone object
+type ReconcileElasticsearchClone struct {
+	k8s.Client
+	accessReviewer rbac.AccessReviewer
+	recorder       record.EventRecorder
+	esClient       esClientProvider
+	operator.Parameters
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Reconcile moves the clone operation forward, and reports its progress in the status of the ElasticsearchClone.
+func (r *ReconcileElasticsearchClone) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "clone_name", &r.iteration)()
+	tx, ctx := tracing.NewTransaction(r.Tracer, request.NamespacedName, "elasticsearchclone")
+	defer tracing.EndTransaction(tx)
+
+	var clone esv1.ElasticsearchClone
+	if err := r.Get(request.NamespacedName, &clone); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the cluster created by the clone is garbage collected
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+
+	if !clone.DeletionTimestamp.IsZero() || clone.Status.IsDone() {
+		return reconcile.Result{}, nil
+	}
+
+	if common.IsPaused(clone.ObjectMeta) {
+		log.Info("Object is paused. Skipping reconciliation", "namespace", clone.Namespace, "clone_name", clone.Name)
+		return common.PauseRequeue, nil
+	}
+
+	results := reconciler.NewResult(ctx)
+	status, err := r.reconcileClone(ctx, clone)
+	if err != nil {
+		results.WithError(err)
+		k8s.EmitErrorEvent(r.recorder, err, &clone, events.EventReconciliationError, "Reconciliation error: %v", err)
+	}
+	if status.Phase != clone.Status.Phase {
+		r.recorder.Eventf(&clone, corev1.EventTypeNormal, events.EventReasonStateChange, "Clone phase changed to %s", status.Phase)
+	}
+	if !reflect.DeepEqual(status, clone.Status) {
+		clone.Status = status
+		if err := common.UpdateStatus(r.Client, &clone); err != nil {
+			if apierrors.IsConflict(err) {
+				log.V(1).Info("Conflict while updating status", "namespace", clone.Namespace, "clone_name", clone.Name)
+				return reconcile.Result{Requeue: true}, nil
+			}
+			results.WithError(err)
+		}
+	}
+	if !status.IsDone() {
+		results.WithResult(reconcile.Result{RequeueAfter: requeueInterval})
+	}
+	return results.Aggregate()
+}
@@ -0,0 +1,334 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearchclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+)
+
+// fakeCluster serves the snapshot and recovery APIs of an Elasticsearch cluster.
+type fakeCluster struct {
+	repositories map[string]string
+	snapshots    map[string]string
+	recoveries   string
+	restores     []string
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{repositories: map[string]string{}, snapshots: map[string]string{}, recoveries: "{}"}
+}
+
+func (f *fakeCluster) roundTrip(req *http.Request) *http.Response {
+	var body string
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(req.Body)
+		body = string(b)
+	}
+	path := strings.Split(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/_recovery":
+		return esclient.NewMockResponse(200, req, f.recoveries)
+	case len(path) == 2 && req.Method == http.MethodGet:
+		repository, exists := f.repositories[path[1]]
+		if !exists {
+			return esclient.NewMockResponse(404, req, "{}")
+		}
+		return esclient.NewMockResponse(200, req, fmt.Sprintf(`{%q: %s}`, path[1], repository))
+	case len(path) == 2 && req.Method == http.MethodPut:
+		f.repositories[path[1]] = body
+		return esclient.NewMockResponse(200, req, `{"acknowledged": true}`)
+	case len(path) == 3 && req.Method == http.MethodGet:
+		state, exists := f.snapshots[path[2]]
+		if !exists {
+			return esclient.NewMockResponse(404, req, "{}")
+		}
+		return esclient.NewMockResponse(200, req, fmt.Sprintf(`{"snapshots": [{"snapshot": %q, "state": %q}]}`, path[2], state))
+	case len(path) == 3 && req.Method == http.MethodPut:
+		f.snapshots[path[2]] = esclient.SnapshotStateInProgress
+		return esclient.NewMockResponse(200, req, `{"accepted": true}`)
+	case len(path) == 4 && req.Method == http.MethodPost && path[3] == "_restore":
+		f.restores = append(f.restores, body)
+		return esclient.NewMockResponse(200, req, `{"accepted": true}`)
+	}
+	return esclient.NewMockResponse(400, req, "{}")
+}
+
+func recoveries(repository, snapshot string, stages ...string) string {
+	shards := make([]esclient.ShardRecovery, 0, len(stages))
+	for i, stage := range stages {
+		shard := esclient.ShardRecovery{ID: i, Type: esclient.RecoveryTypeSnapshot, Stage: stage, Primary: true}
+		shard.Source.Repository = repository
+		shard.Source.Snapshot = snapshot
+		shards = append(shards, shard)
+	}
+	// replicas are not counted
+	shards = append(shards, esclient.ShardRecovery{Type: "PEER", Stage: "INDEX"})
+	value, _ := json.Marshal(map[string]interface{}{"index": map[string]interface{}{"shards": shards}})
+	return string(value)
+}
+
+type fakeAccessReviewer struct {
+	allowed bool
+}
+
+func (f fakeAccessReviewer) AccessAllowed(_ string, _ string, _ runtime.Object) (bool, error) {
+	return f.allowed, nil
+}
+
+func newTestReconciler(clusters map[string]*fakeCluster, allowed bool, objs ...runtime.Object) *ReconcileElasticsearchClone {
+	return &ReconcileElasticsearchClone{
+		Client:         k8s.WrappedFakeClient(objs...),
+		accessReviewer: fakeAccessReviewer{allowed: allowed},
+		recorder:       record.NewFakeRecorder(100),
+		esClient: func(_ k8s.Client, _ net.Dialer, es esv1.Elasticsearch) (esclient.Client, error) {
+			cluster, exists := clusters[es.Namespace+"/"+es.Name]
+			if !exists {
+				return nil, fmt.Errorf("no cluster %s/%s", es.Namespace, es.Name)
+			}
+			return esclient.NewMockClient(version.MustParse("7.6.0"), cluster.roundTrip), nil
+		},
+	}
+}
+
+func TestReconcileElasticsearchClone(t *testing.T) {
+	controllerscheme.SetupScheme()
+	source := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "source"},
+		Spec:       esv1.ElasticsearchSpec{Version: "7.6.0", NodeSets: []esv1.NodeSet{{Name: "default", Count: 3}}},
+	}
+	clone := esv1.ElasticsearchClone{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "refresh", UID: "0123456789"},
+		Spec: esv1.ElasticsearchCloneSpec{
+			SourceRef:  commonv1.ObjectSelector{Namespace: "prod", Name: "source"},
+			Repository: "backups",
+		},
+	}
+	sourceCluster := newFakeCluster()
+	sourceCluster.repositories["backups"] = `{"type": "fs", "settings": {"location": "/backups"}}`
+	targetCluster := newFakeCluster()
+	r := newTestReconciler(map[string]*fakeCluster{"prod/source": sourceCluster, "staging/refresh": targetCluster}, true, &source, &clone)
+
+	cloneRef := k8s.ExtractNamespacedName(&clone)
+	esRef := types.NamespacedName{Namespace: "staging", Name: "refresh"}
+	reconcileClone := func() esv1.ElasticsearchCloneStatus {
+		res, err := r.Reconcile(reconcile.Request{NamespacedName: cloneRef})
+		require.NoError(t, err)
+		var updated esv1.ElasticsearchClone
+		require.NoError(t, r.Get(cloneRef, &updated))
+		if !updated.Status.IsDone() {
+			require.Equal(t, requeueInterval, res.RequeueAfter)
+		}
+		return updated.Status
+	}
+
+	// a snapshot of the source cluster is taken
+	status := reconcileClone()
+	require.Equal(t, esv1.CloneSnapshottingPhase, status.Phase)
+	require.Equal(t, "refresh-01234567", status.Snapshot)
+	require.Equal(t, esclient.SnapshotStateInProgress, sourceCluster.snapshots["refresh-01234567"])
+	require.Equal(t, "Snapshot in progress", reconcileClone().Message)
+
+	// the new cluster is created once the snapshot succeeded
+	sourceCluster.snapshots["refresh-01234567"] = esclient.SnapshotStateSuccess
+	require.Equal(t, esv1.CloneCreatingPhase, reconcileClone().Phase)
+	require.Equal(t, "Waiting for the cluster to be ready", reconcileClone().Message)
+	var es esv1.Elasticsearch
+	require.NoError(t, r.Get(esRef, &es))
+	require.Equal(t, source.Spec, es.Spec)
+	require.Equal(t, "prod/source", es.Annotations[ClonedFromAnnotation])
+	require.True(t, metav1.IsControlledBy(&es, &clone))
+
+	// the snapshot is restored once the new cluster is ready
+	require.Equal(t, esv1.CloneCreatingPhase, reconcileClone().Phase)
+	es.Status.Phase = esv1.ElasticsearchReadyPhase
+	require.NoError(t, r.Update(&es))
+	targetCluster.recoveries = recoveries("backups", "refresh-01234567", "INDEX", "DONE")
+	status = reconcileClone()
+	require.Equal(t, esv1.CloneRestoringPhase, status.Phase)
+	require.JSONEq(t, `{"type": "fs", "settings": {"location": "/backups", "readonly": true}}`, targetCluster.repositories["backups"])
+	// the restore was already started: the recoveries of the snapshot exist
+	require.Empty(t, targetCluster.restores)
+
+	// the progress of the restore is reported
+	status = reconcileClone()
+	require.Equal(t, esv1.CloneRestoringPhase, status.Phase)
+	require.Equal(t, 2, status.TotalShards)
+	require.Equal(t, 1, status.RestoredShards)
+
+	targetCluster.recoveries = recoveries("backups", "refresh-01234567", "DONE", "DONE")
+	status = reconcileClone()
+	require.Equal(t, esv1.CloneCompletedPhase, status.Phase)
+	require.Equal(t, 2, status.RestoredShards)
+	require.NotNil(t, status.CompletedAt)
+
+	// nothing happens once completed
+	targetCluster.recoveries = "{}"
+	require.Equal(t, status, reconcileClone())
+}
+
+func TestReconcileElasticsearchClone_restore(t *testing.T) {
+	controllerscheme.SetupScheme()
+	clone := esv1.ElasticsearchClone{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "clone"},
+		Spec: esv1.ElasticsearchCloneSpec{
+			SourceRef:         commonv1.ObjectSelector{Name: "source"},
+			ElasticsearchName: "target",
+			Repository:        "backups",
+			Snapshot:          "nightly",
+			Indices:           []string{"logs-*", "metrics-*"},
+		},
+		Status: esv1.ElasticsearchCloneStatus{Phase: esv1.CloneCreatingPhase, Snapshot: "nightly"},
+	}
+	target, err := newCluster(clone, esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source"}})
+	require.NoError(t, err)
+	target.Status.Phase = esv1.ElasticsearchReadyPhase
+	targetCluster := newFakeCluster()
+	// the repository is already registered
+	targetCluster.repositories["backups"] = `{"type": "s3"}`
+	r := newTestReconciler(map[string]*fakeCluster{"ns/target": targetCluster}, true, &clone, &target)
+
+	_, err = r.Reconcile(reconcile.Request{NamespacedName: k8s.ExtractNamespacedName(&clone)})
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"indices":"logs-*,metrics-*","include_global_state":false}`}, targetCluster.restores)
+	require.Equal(t, `{"type": "s3"}`, targetCluster.repositories["backups"])
+}
+
+func TestReconcileElasticsearchClone_failures(t *testing.T) {
+	controllerscheme.SetupScheme()
+	source := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "source"},
+		Spec:       esv1.ElasticsearchSpec{Version: "7.6.0"},
+	}
+	sourceWithSecrets := *source.DeepCopy()
+	sourceWithSecrets.Spec.SecureSettings = []commonv1.SecretSource{{SecretName: "s3-credentials"}}
+	sourceWithSecrets.Spec.Auth.Roles = []esv1.RoleSource{{SecretRef: commonv1.SecretRef{SecretName: "roles"}}}
+	sourceWithSecrets.Spec.HTTP.TLS.Certificate.SecretName = "http-certs"
+	newClone := func(snapshot string, status esv1.ElasticsearchCloneStatus) *esv1.ElasticsearchClone {
+		return &esv1.ElasticsearchClone{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "clone"},
+			Spec: esv1.ElasticsearchCloneSpec{
+				SourceRef:  commonv1.ObjectSelector{Namespace: "prod", Name: "source"},
+				Repository: "backups",
+				Snapshot:   snapshot,
+			},
+			Status: status,
+		}
+	}
+	tests := []struct {
+		name      string
+		clone     *esv1.ElasticsearchClone
+		objs      []runtime.Object
+		snapshots map[string]string
+		noRepo    bool
+		denied    bool
+		want      esv1.ElasticsearchCloneStatus
+	}{
+		{
+			name:  "source not found",
+			clone: newClone("", esv1.ElasticsearchCloneStatus{}),
+			want:  esv1.ElasticsearchCloneStatus{Phase: esv1.CloneSnapshottingPhase, Message: "Elasticsearch prod/source not found"},
+		},
+		{
+			name:   "access to the source denied",
+			clone:  newClone("", esv1.ElasticsearchCloneStatus{}),
+			objs:   []runtime.Object{&source},
+			denied: true,
+			want:   esv1.ElasticsearchCloneStatus{Phase: esv1.CloneSnapshottingPhase, Message: "Access to Elasticsearch prod/source is not allowed"},
+		},
+		{
+			name:  "snapshot not found",
+			clone: newClone("nightly", esv1.ElasticsearchCloneStatus{}),
+			objs:  []runtime.Object{&source},
+			want: esv1.ElasticsearchCloneStatus{
+				Phase:    esv1.CloneFailedPhase,
+				Snapshot: "nightly",
+				Message:  "Snapshot nightly not found in repository backups",
+			},
+		},
+		{
+			name:   "repository not found",
+			clone:  newClone("nightly", esv1.ElasticsearchCloneStatus{}),
+			objs:   []runtime.Object{&source},
+			noRepo: true,
+			want: esv1.ElasticsearchCloneStatus{
+				Phase:    esv1.CloneFailedPhase,
+				Snapshot: "nightly",
+				Message:  "Snapshot repository backups not found",
+			},
+		},
+		{
+			name:      "partial snapshot",
+			clone:     newClone("nightly", esv1.ElasticsearchCloneStatus{}),
+			objs:      []runtime.Object{&source},
+			snapshots: map[string]string{"nightly": esclient.SnapshotStatePartial},
+			want: esv1.ElasticsearchCloneStatus{
+				Phase:    esv1.CloneFailedPhase,
+				Snapshot: "nightly",
+				Message:  "Snapshot nightly is PARTIAL",
+			},
+		},
+		{
+			name:  "cluster already exists",
+			clone: newClone("nightly", esv1.ElasticsearchCloneStatus{Phase: esv1.CloneCreatingPhase, Snapshot: "nightly"}),
+			objs:  []runtime.Object{&source, &esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "clone"}}},
+			want: esv1.ElasticsearchCloneStatus{
+				Phase:    esv1.CloneFailedPhase,
+				Snapshot: "nightly",
+				Message:  "Elasticsearch clone already exists",
+			},
+		},
+		{
+			name:  "secrets missing in the clone namespace",
+			clone: newClone("nightly", esv1.ElasticsearchCloneStatus{Phase: esv1.CloneCreatingPhase, Snapshot: "nightly"}),
+			objs: []runtime.Object{
+				&sourceWithSecrets,
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "roles"}},
+			},
+			want: esv1.ElasticsearchCloneStatus{
+				Phase:    esv1.CloneFailedPhase,
+				Snapshot: "nightly",
+				Message:  "Secrets s3-credentials, http-certs referenced by Elasticsearch prod/source not found in namespace staging",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceCluster := newFakeCluster()
+			if !tt.noRepo {
+				sourceCluster.repositories["backups"] = `{"type": "fs"}`
+			}
+			for name, state := range tt.snapshots {
+				sourceCluster.snapshots[name] = state
+			}
+			r := newTestReconciler(map[string]*fakeCluster{"prod/source": sourceCluster}, !tt.denied, append(tt.objs, tt.clone)...)
+			_, err := r.Reconcile(reconcile.Request{NamespacedName: k8s.ExtractNamespacedName(tt.clone)})
+			require.NoError(t, err)
+			var updated esv1.ElasticsearchClone
+			require.NoError(t, r.Get(k8s.ExtractNamespacedName(tt.clone), &updated))
+			require.Equal(t, tt.want, updated.Status)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticstack
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	stackv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stack/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// ElasticStack controller
+//
+// This controller upgrades the resources referenced by ElasticStack resources to the version of the stack, in the
+// documented upgrade order:
+// - Elasticsearch first, then Kibana, then APM Server and Enterprise Search
+// - a step starts once all the resources of the previous steps run the new version and report a green health
+// - a held resource keeps its current version, and holds the resources of the next steps
+// - downgrades are rejected, the resource is reported in error
+// The version of a resource is only bumped in its spec, the controller of the resource rolls out the new version.
+
+const (
+	name = "elasticstack-controller"
+
+	// upgradeRequeue is the delay after which the upgrade progress is checked again while resources are upgrading.
+	upgradeRequeue = 10 * time.Second
+)
+
+var log = logf.Log.WithName(name)
+
+// Add creates a new ElasticStack Controller and adds it to the Manager with default RBAC. The Manager will set
+// fields on the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, params operator.Parameters) error {
+	r := newReconciler(mgr, params)
+	c, err := common.NewController(mgr, name, &stackv1alpha1.ElasticStack{}, r, params)
+	if err != nil {
+		return err
+	}
+	return addWatches(c, r)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, params operator.Parameters) *ReconcileElasticStack {
+	return &ReconcileElasticStack{
+		Client:     k8s.WrapClient(mgr.GetClient()),
+		recorder:   mgr.GetEventRecorderFor(name),
+		Parameters: params,
+	}
+}
+
+func addWatches(c controller.Controller, r *ReconcileElasticStack) error {
+	// watch stacks
+	if err := c.Watch(&source.Kind{Type: &stackv1alpha1.ElasticStack{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	// any change to the health or the version of a resource may move the upgrade of the stacks of its namespace forward
+	stacksInNamespace := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+			requests, err := reconcileRequestsForStacks(r.Client, object.Meta.GetNamespace())
+			if err != nil {
+				log.Error(err, "failed to list stacks, dropping watch event")
+				return nil
+			}
+			return requests
+		}),
+	}
+	for _, t := range []runtime.Object{&esv1.Elasticsearch{}, &kbv1.Kibana{}, &apmv1.ApmServer{}, &entsv1beta1.EnterpriseSearch{}} {
+		if err := c.Watch(&source.Kind{Type: t}, stacksInNamespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reconcileRequestsForStacks(c k8s.Client, namespace string) ([]reconcile.Request, error) {
+	var stacks stackv1alpha1.ElasticStackList
+	if err := c.List(&stacks, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	requests := make([]reconcile.Request, 0, len(stacks.Items))
+	for _, s := range stacks.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: k8s.ExtractNamespacedName(&s)})
+	}
+	return requests, nil
+}
+
+var _ reconcile.Reconciler = &ReconcileElasticStack{}
+
+// ReconcileElasticStack reconciles an ElasticStack object
+type ReconcileElasticStack struct {
+	k8s.Client
+	recorder record.EventRecorder
+	operator.Parameters
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Reconcile moves the upgrade of the resources of an ElasticStack forward.
+func (r *ReconcileElasticStack) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "stack_name", &r.iteration)()
+	tx, ctx := tracing.NewTransaction(r.Tracer, request.NamespacedName, "elasticstack")
+	defer tracing.EndTransaction(tx)
+
+	var stack stackv1alpha1.ElasticStack
+	if err := r.Get(request.NamespacedName, &stack); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+
+	if common.IsPaused(stack.ObjectMeta) {
+		log.Info("Object is paused. Skipping reconciliation", "namespace", stack.Namespace, "stack_name", stack.Name)
+		return common.PauseRequeue, nil
+	}
+
+	results := reconciler.NewResult(ctx)
+	status, err := r.reconcileInternal(stack)
+	if err != nil {
+		results.WithError(err)
+		k8s.EmitErrorEvent(r.recorder, err, &stack, events.EventReconciliationError, "Reconciliation error: %v", err)
+	}
+	if status != nil {
+		if status.Phase == stackv1alpha1.UpgradingPhase {
+			results.WithResult(reconcile.Result{RequeueAfter: upgradeRequeue})
+		}
+		if !reflect.DeepEqual(*status, stack.Status) {
+			stack.Status = *status
+			if err := common.UpdateStatus(r.Client, &stack); err != nil {
+				if apierrors.IsConflict(err) {
+					log.V(1).Info("Conflict while updating status", "namespace", stack.Namespace, "stack_name", stack.Name)
+					return reconcile.Result{Requeue: true}, nil
+				}
+				results.WithError(err)
+			}
+		}
+	}
+	return results.Aggregate()
+}
+
+// reconcileInternal upgrades the resources of the current step of the stack, and returns the new status of the stack.
+func (r *ReconcileElasticStack) reconcileInternal(stack stackv1alpha1.ElasticStack) (*stackv1alpha1.ElasticStackStatus, error) {
+	target, err := version.Parse(stack.Spec.Version)
+	if err != nil {
+		r.recorder.Event(&stack, corev1.EventTypeWarning, events.EventReasonValidation, err.Error())
+		status := newStatus(stack, nil)
+		status.Phase = stackv1alpha1.ErrorPhase
+		return &status, nil
+	}
+
+	refs := make([]stackv1alpha1.StackResourceRef, len(stack.Spec.Resources))
+	copy(refs, stack.Spec.Resources)
+	sort.SliceStable(refs, func(i, j int) bool {
+		return upgradeStep(refs[i].Kind) < upgradeStep(refs[j].Kind)
+	})
+
+	statuses := make([]stackv1alpha1.StackResourceStatus, 0, len(refs))
+	// blocked is the reason why the resources of the next steps cannot be upgraded yet
+	var blocked string
+	for i := 0; i < len(refs); {
+		// upgrade the resources of a step together
+		step := upgradeStep(refs[i].Kind)
+		stepBlocked := blocked
+		for ; i < len(refs) && upgradeStep(refs[i].Kind) == step; i++ {
+			status, err := r.reconcileResource(stack, *target, refs[i], blocked)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, status)
+			if status.Phase != stackv1alpha1.UpgradedPhase && stepBlocked == "" {
+				stepBlocked = fmt.Sprintf("waiting for %s %s to be upgraded", refs[i].Kind, refs[i].Name)
+			}
+		}
+		blocked = stepBlocked
+	}
+
+	status := newStatus(stack, statuses)
+	return &status, nil
+}
+
+// reconcileResource upgrades the referenced resource if the previous steps are upgraded, and returns its status.
+func (r *ReconcileElasticStack) reconcileResource(
+	stack stackv1alpha1.ElasticStack,
+	target version.Version,
+	ref stackv1alpha1.StackResourceRef,
+	blocked string,
+) (stackv1alpha1.StackResourceStatus, error) {
+	status := stackv1alpha1.StackResourceStatus{Kind: ref.Kind, Name: ref.Name}
+	res, err := getStackResource(r.Client, stack.Namespace, ref)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			status.Phase = stackv1alpha1.ResourceErrorPhase
+			status.Message = "resource not found"
+			return status, nil
+		}
+		return status, err
+	}
+
+	status.Version, err = res.runningVersion(r.Client)
+	if err != nil {
+		return status, err
+	}
+
+	switch {
+	case res.specVersion() == target.String() && status.Version == target.String() && res.healthy():
+		status.Phase = stackv1alpha1.UpgradedPhase
+		return status, nil
+	case ref.Hold:
+		status.Phase = stackv1alpha1.ResourceHeldPhase
+		status.Message = "resource held at its current version"
+		return status, nil
+	case blocked != "":
+		status.Phase = stackv1alpha1.PendingPhase
+		status.Message = blocked
+		return status, nil
+	}
+
+	if res.specVersion() != target.String() {
+		current, err := version.Parse(res.specVersion())
+		if err != nil {
+			status.Phase = stackv1alpha1.ResourceErrorPhase
+			status.Message = err.Error()
+			return status, nil
+		}
+		if !target.IsSameOrAfter(*current) {
+			status.Phase = stackv1alpha1.ResourceErrorPhase
+			status.Message = fmt.Sprintf("downgrade from %s to %s is not supported", current, target)
+			return status, nil
+		}
+		res.setSpecVersion(target.String())
+		if err := r.Update(res.object()); err != nil {
+			return status, err
+		}
+		r.recorder.Eventf(&stack, corev1.EventTypeNormal, events.EventReasonStateChange,
+			"Upgrading %s %s from %s to %s", ref.Kind, ref.Name, current, target)
+	}
+	status.Phase = stackv1alpha1.ResourceUpgradingPhase
+	return status, nil
+}
+
+// newStatus returns the status of the stack from the status of its resources.
+func newStatus(stack stackv1alpha1.ElasticStack, resources []stackv1alpha1.StackResourceStatus) stackv1alpha1.ElasticStackStatus {
+	status := stackv1alpha1.ElasticStackStatus{
+		Phase:              stackv1alpha1.ReadyPhase,
+		Resources:          resources,
+		ObservedGeneration: stack.Generation,
+	}
+	for _, res := range resources {
+		switch res.Phase {
+		case stackv1alpha1.ResourceErrorPhase:
+			status.Phase = stackv1alpha1.ErrorPhase
+		case stackv1alpha1.ResourceHeldPhase:
+			if status.Phase != stackv1alpha1.ErrorPhase {
+				status.Phase = stackv1alpha1.HeldPhase
+			}
+		case stackv1alpha1.ResourceUpgradingPhase, stackv1alpha1.PendingPhase:
+			if status.Phase == stackv1alpha1.ReadyPhase {
+				status.Phase = stackv1alpha1.UpgradingPhase
+			}
+		}
+	}
+	return status
+}
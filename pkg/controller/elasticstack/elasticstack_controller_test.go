@@ -0,0 +1,201 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticstack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	stackv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stack/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/deployment"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+var stackKey = types.NamespacedName{Namespace: "ns", Name: "stack"}
+
+func newStack(version string, kbHold bool) *stackv1alpha1.ElasticStack {
+	return &stackv1alpha1.ElasticStack{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "stack", Generation: 1},
+		Spec: stackv1alpha1.ElasticStackSpec{
+			Version: version,
+			// declared out of the upgrade order on purpose
+			Resources: []stackv1alpha1.StackResourceRef{
+				{Kind: stackv1alpha1.KibanaKind, Name: "kb", Hold: kbHold},
+				{Kind: stackv1alpha1.ElasticsearchKind, Name: "es"},
+			},
+		},
+	}
+}
+
+func newES(version string) *esv1.Elasticsearch {
+	return &esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec:       esv1.ElasticsearchSpec{Version: version, NodeSets: []esv1.NodeSet{{Name: "default", Count: 1}}},
+		Status:     esv1.ElasticsearchStatus{Health: esv1.ElasticsearchGreenHealth},
+	}
+}
+
+func newESPod(version string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-es-default-0", Labels: map[string]string{
+		label.ClusterNameLabelName: "es",
+		label.VersionLabelName:     version,
+	}}}
+}
+
+func newKibana(version string) *kbv1.Kibana {
+	return &kbv1.Kibana{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "kb"},
+		Spec:       kbv1.KibanaSpec{Version: version},
+		Status:     kbv1.KibanaStatus{Health: kbv1.KibanaGreen},
+	}
+}
+
+func newKibanaDeployment(version string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "ns",
+			Name:       "kb-kb",
+			Generation: 2,
+			Labels:     map[string]string{deployment.VersionLabelName: version},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+}
+
+func reconcileStack(t *testing.T, objs ...runtime.Object) (k8s.Client, stackv1alpha1.ElasticStack) {
+	require.NoError(t, controllerscheme.SetupScheme())
+	r := &ReconcileElasticStack{Client: k8s.WrappedFakeClient(objs...), recorder: record.NewFakeRecorder(100)}
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: stackKey})
+	require.NoError(t, err)
+	var stack stackv1alpha1.ElasticStack
+	require.NoError(t, r.Get(stackKey, &stack))
+	return r.Client, stack
+}
+
+func resourcePhases(stack stackv1alpha1.ElasticStack) []stackv1alpha1.StackResourcePhase {
+	phases := make([]stackv1alpha1.StackResourcePhase, 0, len(stack.Status.Resources))
+	for _, res := range stack.Status.Resources {
+		phases = append(phases, res.Phase)
+	}
+	return phases
+}
+
+func TestReconcileElasticStack_Reconcile(t *testing.T) {
+	tests := []struct {
+		name           string
+		objs           []runtime.Object
+		wantPhase      stackv1alpha1.StackPhase
+		wantResources  []stackv1alpha1.StackResourcePhase
+		wantESVersion  string
+		wantKbVersion  string
+		wantESObserved string
+	}{
+		{
+			name:           "stack already running the version",
+			objs:           []runtime.Object{newStack("7.6.0", false), newES("7.6.0"), newESPod("7.6.0"), newKibana("7.6.0"), newKibanaDeployment("7.6.0")},
+			wantPhase:      stackv1alpha1.ReadyPhase,
+			wantResources:  []stackv1alpha1.StackResourcePhase{stackv1alpha1.UpgradedPhase, stackv1alpha1.UpgradedPhase},
+			wantESVersion:  "7.6.0",
+			wantKbVersion:  "7.6.0",
+			wantESObserved: "7.6.0",
+		},
+		{
+			name:           "Elasticsearch is upgraded first",
+			objs:           []runtime.Object{newStack("7.7.0", false), newES("7.6.0"), newESPod("7.6.0"), newKibana("7.6.0"), newKibanaDeployment("7.6.0")},
+			wantPhase:      stackv1alpha1.UpgradingPhase,
+			wantResources:  []stackv1alpha1.StackResourcePhase{stackv1alpha1.ResourceUpgradingPhase, stackv1alpha1.PendingPhase},
+			wantESVersion:  "7.7.0",
+			wantKbVersion:  "7.6.0",
+			wantESObserved: "7.6.0",
+		},
+		{
+			name:           "Kibana is upgraded once Elasticsearch runs the new version",
+			objs:           []runtime.Object{newStack("7.7.0", false), newES("7.7.0"), newESPod("7.7.0"), newKibana("7.6.0"), newKibanaDeployment("7.6.0")},
+			wantPhase:      stackv1alpha1.UpgradingPhase,
+			wantResources:  []stackv1alpha1.StackResourcePhase{stackv1alpha1.UpgradedPhase, stackv1alpha1.ResourceUpgradingPhase},
+			wantESVersion:  "7.7.0",
+			wantKbVersion:  "7.7.0",
+			wantESObserved: "7.7.0",
+		},
+		{
+			name:           "held Kibana keeps its version",
+			objs:           []runtime.Object{newStack("7.7.0", true), newES("7.7.0"), newESPod("7.7.0"), newKibana("7.6.0"), newKibanaDeployment("7.6.0")},
+			wantPhase:      stackv1alpha1.HeldPhase,
+			wantResources:  []stackv1alpha1.StackResourcePhase{stackv1alpha1.UpgradedPhase, stackv1alpha1.ResourceHeldPhase},
+			wantESVersion:  "7.7.0",
+			wantKbVersion:  "7.6.0",
+			wantESObserved: "7.7.0",
+		},
+		{
+			name:           "downgrade is rejected",
+			objs:           []runtime.Object{newStack("7.5.0", false), newES("7.6.0"), newESPod("7.6.0"), newKibana("7.6.0"), newKibanaDeployment("7.6.0")},
+			wantPhase:      stackv1alpha1.ErrorPhase,
+			wantResources:  []stackv1alpha1.StackResourcePhase{stackv1alpha1.ResourceErrorPhase, stackv1alpha1.PendingPhase},
+			wantESVersion:  "7.6.0",
+			wantKbVersion:  "7.6.0",
+			wantESObserved: "7.6.0",
+		},
+		{
+			name:          "missing Elasticsearch",
+			objs:          []runtime.Object{newStack("7.7.0", false), newKibana("7.6.0"), newKibanaDeployment("7.6.0")},
+			wantPhase:     stackv1alpha1.ErrorPhase,
+			wantResources: []stackv1alpha1.StackResourcePhase{stackv1alpha1.ResourceErrorPhase, stackv1alpha1.PendingPhase},
+			wantKbVersion: "7.6.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, stack := reconcileStack(t, tt.objs...)
+			require.Equal(t, tt.wantPhase, stack.Status.Phase)
+			require.Equal(t, tt.wantResources, resourcePhases(stack))
+			require.Equal(t, stackv1alpha1.ElasticsearchKind, stack.Status.Resources[0].Kind)
+			require.Equal(t, tt.wantESObserved, stack.Status.Resources[0].Version)
+			require.Equal(t, int64(1), stack.Status.ObservedGeneration)
+
+			if tt.wantESVersion != "" {
+				var es esv1.Elasticsearch
+				require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "es"}, &es))
+				require.Equal(t, tt.wantESVersion, es.Spec.Version)
+			}
+			var kb kbv1.Kibana
+			require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns", Name: "kb"}, &kb))
+			require.Equal(t, tt.wantKbVersion, kb.Spec.Version)
+		})
+	}
+}
+
+func Test_deploymentRunningVersion(t *testing.T) {
+	rollingOut := newKibanaDeployment("7.7.0")
+	rollingOut.Status.UpdatedReplicas = 0
+	notObserved := newKibanaDeployment("7.7.0")
+	notObserved.Generation = 3
+	for _, tt := range []struct {
+		name string
+		d    *appsv1.Deployment
+		want string
+	}{
+		{name: "rolled out", d: newKibanaDeployment("7.7.0"), want: "7.7.0"},
+		{name: "rolling out", d: rollingOut, want: ""},
+		{name: "generation not observed yet", d: notObserved, want: ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := deploymentRunningVersion(k8s.WrappedFakeClient(tt.d), types.NamespacedName{Namespace: "ns", Name: "kb-kb"})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticstack
+
+import (
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apmv1 "github.com/elastic/cloud-on-k8s/pkg/apis/apm/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	stackv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stack/v1alpha1"
+	apmname "github.com/elastic/cloud-on-k8s/pkg/controller/apmserver/name"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/deployment"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	entsname "github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch/name"
+	kbname "github.com/elastic/cloud-on-k8s/pkg/controller/kibana/name"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// upgradeStep returns the step of the upgrade order the resources of the given kind are upgraded at.
+func upgradeStep(kind stackv1alpha1.StackResourceKind) int {
+	switch kind {
+	case stackv1alpha1.ElasticsearchKind:
+		return 0
+	case stackv1alpha1.KibanaKind:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// stackResource is a resource of an Elastic Stack whose version is orchestrated.
+type stackResource interface {
+	// object returns the underlying resource.
+	object() runtime.Object
+	// specVersion returns the version in the spec of the resource.
+	specVersion() string
+	// setSpecVersion sets the version in the spec of the resource.
+	setSpecVersion(version string)
+	// healthy returns true if the resource reports a green health.
+	healthy() bool
+	// runningVersion returns the version all the Pods of the resource run, or an empty string if they run different
+	// versions or are being rolled out.
+	runningVersion(c k8s.Client) (string, error)
+}
+
+// getStackResource fetches the referenced resource in the given namespace.
+func getStackResource(c k8s.Client, namespace string, ref stackv1alpha1.StackResourceRef) (stackResource, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	var res stackResource
+	switch ref.Kind {
+	case stackv1alpha1.ElasticsearchKind:
+		res = &esResource{}
+	case stackv1alpha1.KibanaKind:
+		res = &kbResource{}
+	case stackv1alpha1.ApmServerKind:
+		res = &apmResource{}
+	case stackv1alpha1.EnterpriseSearchKind:
+		res = &entsResource{}
+	default:
+		return nil, errors.Errorf("unsupported kind %s", ref.Kind)
+	}
+	return res, c.Get(key, res.object())
+}
+
+// deploymentRunningVersion returns the version of the Pods of the given Deployment once it is rolled out.
+func deploymentRunningVersion(c k8s.Client, key types.NamespacedName) (string, error) {
+	var d appsv1.Deployment
+	if err := c.Get(key, &d); err != nil {
+		return "", err
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	// the version label is updated with the Pod template, the Pods run it once the Deployment is rolled out
+	if d.Status.ObservedGeneration < d.Generation ||
+		d.Status.UpdatedReplicas != replicas || d.Status.Replicas != replicas || d.Status.AvailableReplicas != replicas {
+		return "", nil
+	}
+	return d.Labels[deployment.VersionLabelName], nil
+}
+
+type esResource struct {
+	esv1.Elasticsearch
+}
+
+func (r *esResource) object() runtime.Object        { return &r.Elasticsearch }
+func (r *esResource) specVersion() string           { return r.Spec.Version }
+func (r *esResource) setSpecVersion(version string) { r.Spec.Version = version }
+func (r *esResource) healthy() bool                 { return r.Status.Health == esv1.ElasticsearchGreenHealth }
+func (r *esResource) runningVersion(c k8s.Client) (string, error) {
+	var pods corev1.PodList
+	if err := c.List(&pods, client.InNamespace(r.Namespace), label.NewLabelSelectorForElasticsearch(r.Elasticsearch)); err != nil {
+		return "", err
+	}
+	if len(pods.Items) != int(r.Spec.NodeCount()) {
+		return "", nil
+	}
+	var running string
+	for _, pod := range pods.Items {
+		podVersion := pod.Labels[label.VersionLabelName]
+		if running != "" && podVersion != running {
+			return "", nil
+		}
+		running = podVersion
+	}
+	return running, nil
+}
+
+type kbResource struct {
+	kbv1.Kibana
+}
+
+func (r *kbResource) object() runtime.Object        { return &r.Kibana }
+func (r *kbResource) specVersion() string           { return r.Spec.Version }
+func (r *kbResource) setSpecVersion(version string) { r.Spec.Version = version }
+func (r *kbResource) healthy() bool                 { return r.Status.Health == kbv1.KibanaGreen }
+func (r *kbResource) runningVersion(c k8s.Client) (string, error) {
+	return deploymentRunningVersion(c, types.NamespacedName{Namespace: r.Namespace, Name: kbname.Deployment(r.Name)})
+}
+
+type apmResource struct {
+	apmv1.ApmServer
+}
+
+func (r *apmResource) object() runtime.Object        { return &r.ApmServer }
+func (r *apmResource) specVersion() string           { return r.Spec.Version }
+func (r *apmResource) setSpecVersion(version string) { r.Spec.Version = version }
+func (r *apmResource) healthy() bool                 { return r.Status.Health == apmv1.ApmServerGreen }
+func (r *apmResource) runningVersion(c k8s.Client) (string, error) {
+	return deploymentRunningVersion(c, types.NamespacedName{Namespace: r.Namespace, Name: apmname.Deployment(r.Name)})
+}
+
+type entsResource struct {
+	entsv1beta1.EnterpriseSearch
+}
+
+func (r *entsResource) object() runtime.Object        { return &r.EnterpriseSearch }
+func (r *entsResource) specVersion() string           { return r.Spec.Version }
+func (r *entsResource) setSpecVersion(version string) { r.Spec.Version = version }
+func (r *entsResource) healthy() bool {
+	return r.Status.Health == entsv1beta1.EnterpriseSearchGreen
+}
+func (r *entsResource) runningVersion(c k8s.Client) (string, error) {
+	return deploymentRunningVersion(c, types.NamespacedName{Namespace: r.Namespace, Name: entsname.Deployment(r.Name)})
+}
@@ -12,6 +12,8 @@ import (
 
 	entsv1beta1 "github.com/elastic/cloud-on-k8s/pkg/apis/enterprisesearch/v1beta1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/deployment"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	entsname "github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch/name"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/maps"
@@ -26,7 +28,11 @@ func (r *ReconcileEnterpriseSearch) reconcileDeployment(
 	span, _ := apm.StartSpan(ctx, "reconcile_deployment", tracing.SpanTypeApp)
 	defer span.End()
 
-	deploy := deployment.New(r.deploymentParams(ents, configHash))
+	params := r.deploymentParams(ents, configHash)
+	if err := podsecurity.Enforce(r.PodSecurityStandard, &ents, &params.PodTemplateSpec); err != nil {
+		return state, err
+	}
+	deploy := deployment.New(params)
 	result, err := deployment.Reconcile(r.K8sClient(), deploy, &ents)
 	if err != nil {
 		return state, err
@@ -37,6 +43,9 @@ func (r *ReconcileEnterpriseSearch) reconcileDeployment(
 
 func (r *ReconcileEnterpriseSearch) deploymentParams(ents entsv1beta1.EnterpriseSearch, configHash string) deployment.Params {
 	podSpec := newPodSpec(ents, configHash)
+	if r.OpenShift {
+		openshift.SetRestrictedSecurityContext(&podSpec)
+	}
 	podLabels := NewLabels(ents.Name)
 	podSpec.Labels = maps.MergePreservingExistingKeys(podSpec.Labels, podLabels)
 
@@ -48,5 +57,6 @@ func (r *ReconcileEnterpriseSearch) deploymentParams(ents entsv1beta1.Enterprise
 		Labels:          NewLabels(ents.Name),
 		PodTemplateSpec: podSpec,
 		Strategy:        appsv1.RollingUpdateDeploymentStrategyType,
+		Version:         ents.Spec.Version,
 	}
 }
@@ -30,7 +30,9 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/driver"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/profile"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
 	entsname "github.com/elastic/cloud-on-k8s/pkg/controller/enterprisesearch/name"
@@ -49,7 +51,7 @@ var (
 //The Manager will set fields on the Controller and Start it when the Manager is Started.
 func Add(mgr manager.Manager, params operator.Parameters) error {
 	reconciler := newReconciler(mgr, params)
-	c, err := common.NewController(mgr, controllerName, reconciler, params)
+	c, err := common.NewController(mgr, controllerName, &entsv1beta1.EnterpriseSearch{}, reconciler, params)
 	if err != nil {
 		return err
 	}
@@ -103,6 +105,11 @@ func addWatches(c controller.Controller, r *ReconcileEnterpriseSearch) error {
 		return err
 	}
 
+	// watch the defaults profiles overriding the operator settings per namespace
+	if err := profile.WatchOverlays(c, r.Client, r.OperatorNamespace, &entsv1beta1.EnterpriseSearchList{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -173,6 +180,9 @@ func (r *ReconcileEnterpriseSearch) Reconcile(request reconcile.Request) (reconc
 	if !association.IsConfiguredIfSet(&ents, r.recorder) {
 		return reconcile.Result{}, nil
 	}
+	if allowed, err := association.AllowVersion(r.Client, &ents, ents.Spec.Version, r.recorder); err != nil || !allowed {
+		return association.VersionBlockedRequeue, tracing.CaptureError(ctx, err)
+	}
 
 	return r.doReconcile(ctx, request, ents)
 }
@@ -193,13 +203,19 @@ func (r *ReconcileEnterpriseSearch) isCompatible(ctx context.Context, ents *ents
 
 func (r *ReconcileEnterpriseSearch) doReconcile(ctx context.Context, request reconcile.Request, ents entsv1beta1.EnterpriseSearch) (reconcile.Result, error) {
 	state := NewState(request, &ents)
+	params := r.ForNamespace(ents.Namespace)
 
 	svc, err := common.ReconcileService(ctx, r.Client, NewService(ents), &ents)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if r.OpenShift {
+		if err := openshift.ReconcileRoute(r.Client, *svc, ents.Spec.HTTP.TLS.Enabled(), &ents); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
 
-	results := ReconcileCertificates(ctx, r, &ents, []corev1.Service{*svc}, r.CACertRotation, r.CertRotation)
+	results := ReconcileCertificates(ctx, r, &ents, []corev1.Service{*svc}, params.GetCACertRotation(), params.GetCertRotation())
 	if results.HasError() {
 		res, err := results.Aggregate()
 		k8s.EmitErrorEvent(r.recorder, err, &ents, events.EventReconciliationError, "Certificate reconciliation error: %v", err)
@@ -68,7 +68,7 @@ func newPodSpec(ents entsv1beta1.EnterpriseSearch, configHash string) corev1.Pod
 	builder := defaults.NewPodTemplateBuilder(
 		ents.Spec.PodTemplate, entsv1beta1.EnterpriseSearchContainerName).
 		WithResources(DefaultResources).
-		WithDockerImage(ents.Spec.Image, container.ImageRepository(container.EnterpriseSearchImage, ents.Spec.Version)).
+		WithDockerImage(ents.Spec.Image, container.ImageRepositoryForNamespace(container.EnterpriseSearchImage, ents.Spec.Version, ents.Namespace, "")).
 		WithPorts([]corev1.ContainerPort{
 			{Name: ents.Spec.HTTP.Protocol(), ContainerPort: int32(HTTPPort), Protocol: corev1.ProtocolTCP},
 		}).
@@ -58,7 +58,7 @@ var (
 
 func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
 	r := newReconciler(mgr, accessReviewer, params)
-	c, err := common.NewController(mgr, name, r, params)
+	c, err := common.NewController(mgr, name, &entsv1beta1.EnterpriseSearch{}, r, params)
 	if err != nil {
 		return err
 	}
@@ -123,6 +123,10 @@ func (r *ReconcileEnterpriseSearchElasticsearchAssociation) onDelete(obj types.N
 	// Clean up memory
 	r.watches.ElasticsearchClusters.RemoveHandlerForKey(elasticsearchWatchName(obj))
 	r.watches.Secrets.RemoveHandlerForKey(esCAWatchName(obj))
+	// Delete the user password from the external credentials store, if any
+	if err := association.DeleteStoredCredentials(obj, entSearchUserSuffix); err != nil {
+		return err
+	}
 	// Delete user
 	return k8s.DeleteSecretMatching(r.Client, NewUserLabelSelector(obj))
 }
@@ -405,6 +409,9 @@ func (r *ReconcileEnterpriseSearchElasticsearchAssociation) updateAssocConf(ctx
 func (r *ReconcileEnterpriseSearchElasticsearchAssociation) Unbind(entSearch commonv1.Associated) error {
 	entSearchKey := k8s.ExtractNamespacedName(entSearch)
 	// Ensure that user in Elasticsearch is deleted to prevent illegitimate access
+	if err := association.DeleteStoredCredentials(entSearchKey, entSearchUserSuffix); err != nil {
+		return err
+	}
 	if err := k8s.DeleteSecretMatching(r.Client, NewUserLabelSelector(entSearchKey)); err != nil {
 		return err
 	}
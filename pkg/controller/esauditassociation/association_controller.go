@@ -0,0 +1,396 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package esauditassociation
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"go.elastic.co/apm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/annotation"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/association"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+)
+
+// This controller associates an Elasticsearch cluster shipping its audit logs with the monitoring Elasticsearch
+// cluster receiving them. It is modelled on the other association controllers: the audited cluster is the associated
+// resource, the monitoring cluster is the Elasticsearch side of the association.
+
+const (
+	name                        = "elasticsearch-audit-association-controller"
+	auditBeatUserSuffix         = "audit-beat-user"
+	elasticsearchCASecretSuffix = "audit-es-ca" // nolint
+)
+
+var (
+	log            = logf.Log.WithName(name)
+	defaultRequeue = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+)
+
+func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
+	r := newReconciler(mgr, accessReviewer, params)
+	c, err := common.NewController(mgr, name, &esv1.Elasticsearch{}, r, params)
+	if err != nil {
+		return err
+	}
+	return addWatches(c, r)
+}
+
+func newReconciler(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) *ReconcileAuditAssociation {
+	client := k8s.WrapClient(mgr.GetClient())
+	return &ReconcileAuditAssociation{
+		Client:         client,
+		accessReviewer: accessReviewer,
+		watches:        watches.NewDynamicWatches(),
+		recorder:       mgr.GetEventRecorderFor(name),
+		Parameters:     params,
+	}
+}
+
+func addWatches(c controller.Controller, r *ReconcileAuditAssociation) error {
+	// Watch for changes to the audited Elasticsearch clusters
+	if err := c.Watch(&source.Kind{Type: &esv1.Elasticsearch{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	// Dynamically watch the monitoring Elasticsearch clusters
+	if err := c.Watch(&source.Kind{Type: &esv1.Elasticsearch{}}, r.watches.ElasticsearchClusters); err != nil {
+		return err
+	}
+
+	// Dynamically watch Elasticsearch public CA secrets for referenced ES clusters
+	if err := c.Watch(&source.Kind{Type: &corev1.Secret{}}, r.watches.Secrets); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var _ reconcile.Reconciler = &ReconcileAuditAssociation{}
+
+// ReconcileAuditAssociation reconciles the association between an Elasticsearch cluster and the monitoring
+// Elasticsearch cluster its audit logs are shipped to.
+type ReconcileAuditAssociation struct {
+	k8s.Client
+	accessReviewer rbac.AccessReviewer
+	recorder       record.EventRecorder
+	watches        watches.DynamicWatches
+	operator.Parameters
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+func (r *ReconcileAuditAssociation) onDelete(obj types.NamespacedName) error {
+	// Clean up memory
+	r.watches.ElasticsearchClusters.RemoveHandlerForKey(elasticsearchWatchName(obj))
+	r.watches.Secrets.RemoveHandlerForKey(esCAWatchName(obj))
+	// Delete the user password from the external credentials store, if any
+	if err := association.DeleteStoredCredentials(obj, auditBeatUserSuffix); err != nil {
+		return err
+	}
+	// Delete user
+	return k8s.DeleteSecretMatching(r.Client, NewUserLabelSelector(obj))
+}
+
+// Reconcile reads that state of the cluster for an Elasticsearch object and makes changes based on the state read
+// and what is in the audit section of its spec.
+func (r *ReconcileAuditAssociation) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "es_name", &r.iteration)()
+	tx, ctx := tracing.NewTransaction(r.Tracer, request.NamespacedName, "es-audit-association")
+	defer tracing.EndTransaction(tx)
+
+	var es esv1.Elasticsearch
+	if err := association.FetchWithAssociation(ctx, r.Client, request, &es); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Elasticsearch resource has been deleted, remove artifacts related to the association.
+			return reconcile.Result{}, r.onDelete(request.NamespacedName)
+		}
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+
+	if common.IsPaused(es.ObjectMeta) {
+		log.Info("Object is paused. Skipping reconciliation", "namespace", es.Namespace, "es_name", es.Name)
+		return common.PauseRequeue, nil
+	}
+
+	// Elasticsearch is being deleted, short-circuit reconciliation and remove artifacts related to the association.
+	if !es.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, tracing.CaptureError(ctx, r.onDelete(k8s.ExtractNamespacedName(&es)))
+	}
+
+	results := reconciler.NewResult(ctx)
+	newStatus, err := r.reconcileInternal(ctx, &es)
+	if err != nil {
+		results.WithError(err)
+	}
+
+	// we want to attempt a status update even in the presence of errors
+	if err := r.updateStatus(ctx, es, newStatus); err != nil {
+		return defaultRequeue, tracing.CaptureError(ctx, err)
+	}
+	return results.
+		WithError(err).
+		WithResult(association.RequeueRbacCheck(r.accessReviewer)).
+		WithResult(resultFromStatus(newStatus)).
+		Aggregate()
+}
+
+func elasticsearchWatchName(assocKey types.NamespacedName) string {
+	return assocKey.Namespace + "-" + assocKey.Name + "-audit-es-watch"
+}
+
+// esCAWatchName returns the name of the watch setup on the secret that
+// contains the HTTP certificate chain of the monitoring Elasticsearch cluster.
+func esCAWatchName(assocKey types.NamespacedName) string {
+	return assocKey.Namespace + "-" + assocKey.Name + "-audit-ca-watch"
+}
+
+func (r *ReconcileAuditAssociation) reconcileInternal(ctx context.Context, es *esv1.Elasticsearch) (commonv1.AssociationStatus, error) {
+	assocKey := k8s.ExtractNamespacedName(es)
+	elasticsearchRef := es.ElasticsearchRef()
+	if !elasticsearchRef.IsDefined() {
+		// audit logs are not shipped, remove any leftover from a previous association
+		if es.AssociationConf() != nil {
+			if err := r.Unbind(es); err != nil {
+				return commonv1.AssociationPending, err
+			}
+		}
+		r.watches.ElasticsearchClusters.RemoveHandlerForKey(elasticsearchWatchName(assocKey))
+		r.watches.Secrets.RemoveHandlerForKey(esCAWatchName(assocKey))
+		if err := deleteOrphanedResources(ctx, r, es); err != nil {
+			log.Error(err, "Error while trying to delete orphaned resources. Continuing.", "namespace", es.Namespace, "es_name", es.Name)
+		}
+		return "", nil
+	}
+	if elasticsearchRef.Namespace == "" {
+		// no namespace provided: default to the audited cluster namespace
+		elasticsearchRef.Namespace = es.Namespace
+	}
+	// Make sure we see events from the monitoring cluster using a dynamic watch
+	err := r.watches.ElasticsearchClusters.AddHandler(watches.NamedWatch{
+		Name:    elasticsearchWatchName(assocKey),
+		Watched: []types.NamespacedName{elasticsearchRef.NamespacedName()},
+		Watcher: assocKey,
+	})
+	if err != nil {
+		return commonv1.AssociationFailed, err
+	}
+
+	var monitoringES esv1.Elasticsearch
+	associationStatus, err := r.getElasticsearch(ctx, es, elasticsearchRef, &monitoringES)
+	if associationStatus != "" || err != nil {
+		return associationStatus, err
+	}
+
+	// Check if reference to the monitoring cluster is allowed to be established
+	if allowed, err := association.CheckAndUnbind(
+		r.accessReviewer,
+		es,
+		&monitoringES,
+		r,
+		r.recorder,
+	); err != nil || !allowed {
+		return commonv1.AssociationPending, err
+	}
+
+	if err := association.ReconcileEsUser(
+		ctx,
+		r.Client,
+		es,
+		map[string]string{
+			AssociationLabelName:      es.Name,
+			AssociationLabelNamespace: es.Namespace,
+		},
+		user.AuditBeatUserRole,
+		auditBeatUserSuffix,
+		monitoringES,
+	); err != nil { // TODO distinguish conflicts and non-recoverable errors here
+		return commonv1.AssociationPending, err
+	}
+
+	caSecret, err := r.reconcileElasticsearchCA(ctx, es, elasticsearchRef.NamespacedName())
+	if err != nil {
+		return commonv1.AssociationPending, err // maybe not created yet
+	}
+
+	// construct the expected ES output configuration
+	authSecretRef := association.ClearTextSecretKeySelector(es, auditBeatUserSuffix)
+	expectedAssocConf := &commonv1.AssociationConf{
+		AuthSecretName: authSecretRef.Name,
+		AuthSecretKey:  authSecretRef.Key,
+		CACertProvided: caSecret.CACertProvided,
+		CASecretName:   caSecret.Name,
+		URL:            services.ExternalServiceURL(monitoringES),
+	}
+
+	var status commonv1.AssociationStatus
+	status, err = r.updateAssocConf(ctx, expectedAssocConf, es)
+	if err != nil || status != "" {
+		return status, err
+	}
+
+	if err := deleteOrphanedResources(ctx, r, es); err != nil {
+		log.Error(err, "Error while trying to delete orphaned resources. Continuing.", "namespace", es.Namespace, "es_name", es.Name)
+	}
+	return commonv1.AssociationEstablished, nil
+}
+
+func (r *ReconcileAuditAssociation) updateStatus(ctx context.Context, es esv1.Elasticsearch, newStatus commonv1.AssociationStatus) error {
+	span, _ := apm.StartSpan(ctx, "update_association", tracing.SpanTypeApp)
+	defer span.End()
+
+	oldStatus := es.Status.AuditAssociationStatus
+	if !reflect.DeepEqual(oldStatus, newStatus) {
+		es.Status.AuditAssociationStatus = newStatus
+		if err := r.Status().Update(&es); err != nil {
+			return err
+		}
+		r.recorder.AnnotatedEventf(&es,
+			annotation.ForAssociationStatusChange(oldStatus, newStatus),
+			corev1.EventTypeNormal,
+			events.EventAssociationStatusChange,
+			"Audit association status changed from [%s] to [%s]", oldStatus, newStatus)
+	}
+	return nil
+}
+
+func (r *ReconcileAuditAssociation) getElasticsearch(ctx context.Context, es *esv1.Elasticsearch, elasticsearchRef commonv1.ObjectSelector, monitoringES *esv1.Elasticsearch) (commonv1.AssociationStatus, error) {
+	span, _ := apm.StartSpan(ctx, "get_elasticsearch", tracing.SpanTypeApp)
+	defer span.End()
+
+	err := r.Get(elasticsearchRef.NamespacedName(), monitoringES)
+	if err != nil {
+		k8s.EmitErrorEvent(r.recorder, err, es, events.EventAssociationError,
+			"Failed to find referenced monitoring cluster %s: %v", elasticsearchRef.NamespacedName(), err)
+		if apierrors.IsNotFound(err) {
+			// ES is not found, remove any existing monitoring configuration and retry in a bit.
+			if err := association.RemoveAssociationConf(r.Client, es); err != nil && !apierrors.IsConflict(err) {
+				log.Error(err, "Failed to remove audit association configuration from Elasticsearch object", "namespace", es.Namespace, "name", es.Name)
+				return commonv1.AssociationPending, err
+			}
+			return commonv1.AssociationPending, nil
+		}
+		return commonv1.AssociationFailed, err
+	}
+	return "", nil
+}
+
+// deleteOrphanedResources deletes the CA secret copied by this association once audit logs are not shipped anymore.
+func deleteOrphanedResources(ctx context.Context, c k8s.Client, es *esv1.Elasticsearch) error {
+	span, _ := apm.StartSpan(ctx, "delete_orphaned_resources", tracing.SpanTypeApp)
+	defer span.End()
+
+	var secrets corev1.SecretList
+	ns := client.InNamespace(es.Namespace)
+	matchLabels := client.MatchingLabels(NewResourceLabels(es.Name))
+	if err := c.List(&secrets, ns, matchLabels); err != nil {
+		return err
+	}
+
+	elasticsearchRef := es.ElasticsearchRef()
+	for _, s := range secrets.Items {
+		controlledBy := metav1.IsControlledBy(&s, es)
+		if controlledBy && !elasticsearchRef.IsDefined() {
+			log.Info("Deleting secret", "namespace", s.Namespace, "secret_name", s.Name, "es_name", es.Name)
+			if err := c.Delete(&s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func resultFromStatus(status commonv1.AssociationStatus) reconcile.Result {
+	switch status {
+	case commonv1.AssociationPending:
+		return defaultRequeue // retry
+	default:
+		return reconcile.Result{} // we are done or there is not much we can do
+	}
+}
+
+func (r *ReconcileAuditAssociation) reconcileElasticsearchCA(ctx context.Context, es *esv1.Elasticsearch, monitoringES types.NamespacedName) (association.CASecret, error) {
+	span, _ := apm.StartSpan(ctx, "reconcile_es_ca", tracing.SpanTypeApp)
+	defer span.End()
+
+	esKey := k8s.ExtractNamespacedName(es)
+	// watch ES CA secret to reconcile on any change
+	if err := r.watches.Secrets.AddHandler(watches.NamedWatch{
+		Name:    esCAWatchName(esKey),
+		Watched: []types.NamespacedName{http.PublicCertsSecretRef(esv1.ESNamer, monitoringES)},
+		Watcher: esKey,
+	}); err != nil {
+		return association.CASecret{}, err
+	}
+	// Build the labels applied on the secret
+	labels := label.NewLabels(esKey)
+	labels[AssociationLabelName] = es.Name
+	return association.ReconcileCASecret(
+		r.Client,
+		es,
+		monitoringES,
+		labels,
+		elasticsearchCASecretSuffix,
+	)
+}
+
+func (r *ReconcileAuditAssociation) updateAssocConf(ctx context.Context, expectedAssocConf *commonv1.AssociationConf, es *esv1.Elasticsearch) (commonv1.AssociationStatus, error) {
+	span, _ := apm.StartSpan(ctx, "update_es_audit_assoc", tracing.SpanTypeApp)
+	defer span.End()
+
+	if !reflect.DeepEqual(expectedAssocConf, es.AssociationConf()) {
+		log.Info("Updating Elasticsearch spec with audit association configuration", "namespace", es.Namespace, "name", es.Name)
+		if err := association.UpdateAssociationConf(r.Client, es, expectedAssocConf); err != nil {
+			if apierrors.IsConflict(err) {
+				return commonv1.AssociationPending, nil
+			}
+			log.Error(err, "Failed to update Elasticsearch audit association configuration", "namespace", es.Namespace, "name", es.Name)
+			return commonv1.AssociationPending, err
+		}
+		es.SetAssociationConf(expectedAssocConf)
+	}
+	return "", nil
+}
+
+// Unbind removes the association resources
+func (r *ReconcileAuditAssociation) Unbind(es commonv1.Associated) error {
+	esKey := k8s.ExtractNamespacedName(es)
+	// Ensure that user in the monitoring cluster is deleted to prevent illegitimate access
+	if err := association.DeleteStoredCredentials(esKey, auditBeatUserSuffix); err != nil {
+		return err
+	}
+	if err := k8s.DeleteSecretMatching(r.Client, NewUserLabelSelector(esKey)); err != nil {
+		return err
+	}
+	// Also remove the association configuration
+	return association.RemoveAssociationConf(r.Client, es)
+}
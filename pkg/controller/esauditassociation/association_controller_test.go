@@ -0,0 +1,252 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package esauditassociation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/association"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/label"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+)
+
+const (
+	// the user secret lives in the namespace of the audited cluster
+	userSecretName = "audited-audit-beat-user" // nolint
+	// the user lives in the namespace of the monitoring cluster
+	userName     = "ns1-audited-audit-beat-user"
+	caSecretName = "audited-audit-es-ca"
+)
+
+var (
+	auditedKey    = types.NamespacedName{Namespace: "ns1", Name: "audited"}
+	monitoringKey = types.NamespacedName{Namespace: "ns2", Name: "monitoring"}
+)
+
+func auditedFixture(ref commonv1.ObjectSelector) *esv1.Elasticsearch {
+	es := &esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: auditedKey.Namespace, Name: auditedKey.Name}}
+	if ref.IsDefined() {
+		es.Spec.Audit = &esv1.AuditLogging{Shipping: &esv1.AuditShipping{ElasticsearchRef: ref}}
+	}
+	return es
+}
+
+func monitoringFixtures() []runtime.Object {
+	return []runtime.Object{
+		&esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: monitoringKey.Namespace, Name: monitoringKey.Name}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: monitoringKey.Namespace,
+				Name:      http.PublicCertsSecretRef(esv1.ESNamer, monitoringKey).Name,
+			},
+			Data: map[string][]byte{certificates.CAFileName: []byte("ca")},
+		},
+	}
+}
+
+func testReconciler(objs ...runtime.Object) *ReconcileAuditAssociation {
+	return &ReconcileAuditAssociation{
+		Client:         k8s.WrappedFakeClient(objs...),
+		accessReviewer: rbac.NewPermissiveAccessReviewer(),
+		watches:        watches.NewDynamicWatches(),
+		recorder:       record.NewFakeRecorder(100),
+	}
+}
+
+func reconcileAudited(t *testing.T, r *ReconcileAuditAssociation) reconcile.Result {
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: auditedKey})
+	require.NoError(t, err)
+	return result
+}
+
+func getAudited(t *testing.T, r *ReconcileAuditAssociation) esv1.Elasticsearch {
+	var es esv1.Elasticsearch
+	require.NoError(t, association.FetchWithAssociation(context.Background(), r.Client, reconcile.Request{NamespacedName: auditedKey}, &es))
+	return es
+}
+
+func secretExists(t *testing.T, c k8s.Client, namespace, name string) bool {
+	var secret corev1.Secret
+	err := c.Get(types.NamespacedName{Namespace: namespace, Name: name}, &secret)
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+func TestReconcileAuditAssociation_Reconcile(t *testing.T) {
+	ref := commonv1.ObjectSelector{Namespace: monitoringKey.Namespace, Name: monitoringKey.Name}
+	r := testReconciler(auditedFixture(ref))
+
+	// the monitoring cluster does not exist yet: the association is pending
+	result := reconcileAudited(t, r)
+	require.Equal(t, defaultRequeue, result)
+	es := getAudited(t, r)
+	require.Equal(t, commonv1.AssociationPending, es.Status.AuditAssociationStatus)
+	require.Nil(t, es.AssociationConf())
+
+	// the monitoring cluster is created: the association is established
+	for _, obj := range monitoringFixtures() {
+		require.NoError(t, r.Client.Create(obj))
+	}
+	result = reconcileAudited(t, r)
+	require.Equal(t, reconcile.Result{}, result)
+	es = getAudited(t, r)
+	require.Equal(t, commonv1.AssociationEstablished, es.Status.AuditAssociationStatus)
+	require.Equal(t, &commonv1.AssociationConf{
+		AuthSecretName: userSecretName,
+		AuthSecretKey:  userName,
+		CACertProvided: true,
+		CASecretName:   caSecretName,
+		URL:            "https://monitoring-es-http.ns2.svc:9200",
+	}, es.AssociationConf())
+
+	// the password is in the namespace of the audited cluster, the user in the namespace of the monitoring cluster
+	var userSecret corev1.Secret
+	require.NoError(t, r.Client.Get(types.NamespacedName{Namespace: auditedKey.Namespace, Name: userSecretName}, &userSecret))
+	require.NotEmpty(t, userSecret.Data[userName])
+	var esUser corev1.Secret
+	require.NoError(t, r.Client.Get(types.NamespacedName{Namespace: monitoringKey.Namespace, Name: userName}, &esUser))
+	require.Equal(t, userName, string(esUser.Data[user.UserNameField]))
+	require.Equal(t, user.AuditBeatUserRole, string(esUser.Data[user.UserRolesField]))
+	require.Equal(t, monitoringKey.Name, esUser.Labels[label.ClusterNameLabelName])
+	require.Equal(t, user.AssociatedUserType, esUser.Labels[common.TypeLabelName])
+	// the CA of the monitoring cluster is copied to the namespace of the audited cluster
+	var caSecret corev1.Secret
+	require.NoError(t, r.Client.Get(types.NamespacedName{Namespace: auditedKey.Namespace, Name: caSecretName}, &caSecret))
+	require.Equal(t, []byte("ca"), caSecret.Data[certificates.CAFileName])
+
+	// reconciling again keeps the password
+	reconcileAudited(t, r)
+	var reconciledUserSecret corev1.Secret
+	require.NoError(t, r.Client.Get(types.NamespacedName{Namespace: auditedKey.Namespace, Name: userSecretName}, &reconciledUserSecret))
+	require.Equal(t, userSecret.Data, reconciledUserSecret.Data)
+
+	// the audit logs are not shipped anymore: the association is removed
+	es.Spec.Audit = nil
+	require.NoError(t, r.Client.Update(&es))
+	result = reconcileAudited(t, r)
+	require.Equal(t, reconcile.Result{}, result)
+	es = getAudited(t, r)
+	require.Equal(t, commonv1.AssociationStatus(""), es.Status.AuditAssociationStatus)
+	require.Nil(t, es.AssociationConf())
+	assert.False(t, secretExists(t, r.Client, monitoringKey.Namespace, userName))
+	assert.False(t, secretExists(t, r.Client, auditedKey.Namespace, userSecretName))
+	assert.False(t, secretExists(t, r.Client, auditedKey.Namespace, caSecretName))
+}
+
+func TestReconcileAuditAssociation_Reconcile_defaultNamespace(t *testing.T) {
+	// without a namespace, the monitoring cluster is in the namespace of the audited cluster
+	monitoring := &esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: auditedKey.Namespace, Name: "monitoring"}}
+	r := testReconciler(auditedFixture(commonv1.ObjectSelector{Name: "monitoring"}), monitoring)
+
+	reconcileAudited(t, r)
+	es := getAudited(t, r)
+	require.Equal(t, commonv1.AssociationEstablished, es.Status.AuditAssociationStatus)
+	require.True(t, secretExists(t, r.Client, auditedKey.Namespace, userName))
+	require.True(t, secretExists(t, r.Client, auditedKey.Namespace, userSecretName))
+	// the CA secret of the monitoring cluster does not exist yet
+	require.False(t, es.AssociationConf().CACertProvided)
+}
+
+func TestReconcileAuditAssociation_Reconcile_monitoringClusterDeleted(t *testing.T) {
+	ref := commonv1.ObjectSelector{Namespace: monitoringKey.Namespace, Name: monitoringKey.Name}
+	r := testReconciler(append(monitoringFixtures(), auditedFixture(ref))...)
+	reconcileAudited(t, r)
+	es := getAudited(t, r)
+	require.NotNil(t, es.AssociationConf())
+
+	// the monitoring cluster is deleted: the association configuration is removed until it is back
+	require.NoError(t, r.Client.Delete(&esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: monitoringKey.Namespace, Name: monitoringKey.Name}}))
+	result := reconcileAudited(t, r)
+	require.Equal(t, defaultRequeue, result)
+	es = getAudited(t, r)
+	require.Equal(t, commonv1.AssociationPending, es.Status.AuditAssociationStatus)
+	require.Nil(t, es.AssociationConf())
+}
+
+func TestReconcileAuditAssociation_Reconcile_auditedClusterDeleted(t *testing.T) {
+	ref := commonv1.ObjectSelector{Namespace: monitoringKey.Namespace, Name: monitoringKey.Name}
+	r := testReconciler(append(monitoringFixtures(), auditedFixture(ref))...)
+	reconcileAudited(t, r)
+	require.True(t, secretExists(t, r.Client, monitoringKey.Namespace, userName))
+
+	// the audited cluster is deleted: the user in the namespace of the monitoring cluster is deleted, the secrets
+	// in the namespace of the audited cluster are garbage collected with it
+	require.NoError(t, r.Client.Delete(auditedFixture(ref)))
+	result := reconcileAudited(t, r)
+	require.Equal(t, reconcile.Result{}, result)
+	assert.False(t, secretExists(t, r.Client, monitoringKey.Namespace, userName))
+}
+
+func Test_deleteOrphanedResources(t *testing.T) {
+	ref := commonv1.ObjectSelector{Namespace: monitoringKey.Namespace, Name: monitoringKey.Name}
+	tests := []struct {
+		name       string
+		es         *esv1.Elasticsearch
+		controlled bool
+		wantExist  bool
+	}{
+		{
+			name:       "audit logs shipped: keep the secrets",
+			es:         auditedFixture(ref),
+			controlled: true,
+			wantExist:  true,
+		},
+		{
+			name:       "audit logs not shipped: delete the secrets",
+			es:         auditedFixture(commonv1.ObjectSelector{}),
+			controlled: true,
+			wantExist:  false,
+		},
+		{
+			name:       "audit logs not shipped: keep the secrets not controlled by the cluster",
+			es:         auditedFixture(commonv1.ObjectSelector{}),
+			controlled: false,
+			wantExist:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.es.UID = "uid"
+			var ownerRefs []metav1.OwnerReference
+			if tt.controlled {
+				ownerRefs = []metav1.OwnerReference{*metav1.NewControllerRef(tt.es, esv1.GroupVersion.WithKind("Elasticsearch"))}
+			}
+			secret := func(name string) runtime.Object {
+				return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+					Namespace:       auditedKey.Namespace,
+					Name:            name,
+					Labels:          NewResourceLabels(auditedKey.Name),
+					OwnerReferences: ownerRefs,
+				}}
+			}
+			c := k8s.WrappedFakeClient(secret(userSecretName), secret(caSecretName))
+			require.NoError(t, deleteOrphanedResources(context.Background(), c, tt.es))
+			require.Equal(t, tt.wantExist, secretExists(t, c, auditedKey.Namespace, userSecretName))
+			require.Equal(t, tt.wantExist, secretExists(t, c, auditedKey.Namespace, caSecretName))
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package federation
+
+import (
+	"context"
+	"time"
+
+	"go.elastic.co/apm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/license"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates/transport"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster/remoteca"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// Federation controller
+//
+// This controller lets operators running in different Kubernetes clusters connect their Elasticsearch clusters as
+// remote clusters. The operators exchange their clusters through Secrets stored in a namespace shared by all of them,
+// the backend:
+// - each Elasticsearch cluster is published with its transport CA, its transport address and the federated remote
+//   clusters it declares
+// - the CA of the federated remote clusters of a cluster, and of the federated clusters declaring it as remote
+//   cluster, are copied into its remote CA Secrets, which adds them to its transport trust
+// - the transport address of the federated remote clusters is used as seed when configuring them in Elasticsearch
+// The backend is not watched: publications of the other operators are picked up every syncInterval.
+
+const name = "federation-controller"
+
+// syncInterval is the interval at which the publications of the other operators are read from the backend.
+const syncInterval = 1 * time.Minute
+
+var log = logf.Log.WithName(name)
+
+// Backend is the namespace of a Kubernetes cluster in which the federated operators exchange their clusters.
+type Backend struct {
+	// Client of the Kubernetes cluster of the backend.
+	Client k8s.Client
+	// Namespace holding the published clusters.
+	Namespace string
+}
+
+// Add creates a new Federation Controller and adds it to the Manager with default RBAC. The Manager will set fields
+// on the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, kubernetesCluster string, backend Backend, params operator.Parameters) error {
+	r := newReconciler(mgr, kubernetesCluster, backend, params)
+	c, err := common.NewController(mgr, name, &esv1.Elasticsearch{}, r, params)
+	if err != nil {
+		return err
+	}
+	return addWatches(c)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, kubernetesCluster string, backend Backend, params operator.Parameters) *ReconcileFederation {
+	c := k8s.WrapClient(mgr.GetClient())
+	return &ReconcileFederation{
+		Client:            c,
+		kubernetesCluster: kubernetesCluster,
+		backend:           backend,
+		recorder:          mgr.GetEventRecorderFor(name),
+		licenseChecker:    license.NewLicenseChecker(c, params.OperatorNamespace),
+		Parameters:        params,
+	}
+}
+
+func addWatches(c controller.Controller) error {
+	// watch Elasticsearch clusters
+	if err := c.Watch(&source.Kind{Type: &esv1.Elasticsearch{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	// watch the transport CA, the remote CAs and the transport service of the clusters
+	owner := &handler.EnqueueRequestForOwner{IsController: true, OwnerType: &esv1.Elasticsearch{}}
+	if err := c.Watch(&source.Kind{Type: &corev1.Secret{}}, owner); err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &corev1.Service{}}, owner)
+}
+
+var _ reconcile.Reconciler = &ReconcileFederation{}
+
+// ReconcileFederation publishes the Elasticsearch clusters to the backend, and imports the federated remote clusters.
+type ReconcileFederation struct {
+	k8s.Client
+	// kubernetesCluster is the name of this Kubernetes cluster in the federation
+	kubernetesCluster string
+	backend           Backend
+	recorder          record.EventRecorder
+	licenseChecker    license.Checker
+	operator.Parameters
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Reconcile publishes the Elasticsearch cluster, and copies the CA of the federated clusters it is connected to.
+func (r *ReconcileFederation) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "es_name", &r.iteration)()
+	tx, ctx := tracing.NewTransaction(r.Tracer, request.NamespacedName, "federation")
+	defer tracing.EndTransaction(tx)
+
+	var es esv1.Elasticsearch
+	if err := r.Get(request.NamespacedName, &es); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the remote CAs are garbage collected with the cluster
+			return reconcile.Result{}, tracing.CaptureError(ctx, r.unpublish(request.NamespacedName))
+		}
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+	if !es.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, tracing.CaptureError(ctx, r.unpublish(request.NamespacedName))
+	}
+
+	if common.IsPaused(es.ObjectMeta) {
+		log.Info("Object is paused. Skipping reconciliation", "namespace", es.Namespace, "es_name", es.Name)
+		return common.PauseRequeue, nil
+	}
+
+	enabled, err := r.licenseChecker.EnterpriseFeaturesEnabled()
+	if err != nil {
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+	if !enabled {
+		log.Info(
+			"Federation controller is an enterprise feature. Enterprise features are disabled",
+			"namespace", es.Namespace, "es_name", es.Name,
+		)
+		return reconcile.Result{}, nil
+	}
+
+	results := reconciler.NewResult(ctx)
+	results.WithError(r.publish(ctx, es))
+	results.WithError(r.importRemoteClusters(ctx, es))
+	return results.WithResult(reconcile.Result{RequeueAfter: syncInterval}).Aggregate()
+}
+
+// clusterRef returns the reference of a cluster of this Kubernetes cluster in the federation.
+func (r *ReconcileFederation) clusterRef(es types.NamespacedName) ClusterRef {
+	return ClusterRef{KubernetesCluster: r.kubernetesCluster, Namespace: es.Namespace, Name: es.Name}
+}
+
+// publish creates or updates the publication of the cluster in the backend, once its transport CA exists.
+func (r *ReconcileFederation) publish(ctx context.Context, es esv1.Elasticsearch) error {
+	span, _ := apm.StartSpan(ctx, "publish", tracing.SpanTypeApp)
+	defer span.End()
+
+	esKey := k8s.ExtractNamespacedName(&es)
+	var ca corev1.Secret
+	if err := r.Get(transport.PublicCertsSecretRef(esKey), &ca); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the transport CA Secret is watched
+			return nil
+		}
+		return err
+	}
+	var service corev1.Service
+	serviceKey := types.NamespacedName{Namespace: es.Namespace, Name: services.TransportServiceName(es.Name)}
+	if err := r.Get(serviceKey, &service); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	expected, err := Publication{
+		ClusterRef:       r.clusterRef(esKey),
+		CA:               ca.Data[certificates.CAFileName],
+		TransportAddress: transportAddress(es, service),
+		RemoteClusters:   federatedRemoteClusters(es),
+	}.toSecret(r.backend.Namespace)
+	if err != nil {
+		return err
+	}
+	// the publication lives in another Kubernetes cluster: it cannot be owned by the Elasticsearch resource
+	_, err = reconciler.ReconcileSecret(r.backend.Client, expected, nil)
+	return err
+}
+
+// unpublish deletes the publication of a deleted cluster from the backend.
+func (r *ReconcileFederation) unpublish(es types.NamespacedName) error {
+	log.Info("Deleting publication", "namespace", es.Namespace, "es_name", es.Name)
+	err := r.backend.Client.Delete(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: r.backend.Namespace, Name: secretName(r.clusterRef(es))},
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// importRemoteClusters copies the CA and the transport address of the federated clusters connected to the given
+// cluster into its remote CA Secrets, and deletes the ones of the clusters no longer connected.
+func (r *ReconcileFederation) importRemoteClusters(ctx context.Context, es esv1.Elasticsearch) error {
+	span, _ := apm.StartSpan(ctx, "import_remote_clusters", tracing.SpanTypeApp)
+	defer span.End()
+
+	var list corev1.SecretList
+	if err := r.backend.Client.List(&list, client.InNamespace(r.backend.Namespace), labelSelector()); err != nil {
+		return err
+	}
+	publications := make(map[ClusterRef]Publication, len(list.Items))
+	for _, secret := range list.Items {
+		publication, err := fromSecret(secret)
+		if err != nil {
+			log.Error(err, "Ignoring invalid publication", "namespace", secret.Namespace, "secret_name", secret.Name)
+			continue
+		}
+		publications[publication.ClusterRef] = publication
+	}
+
+	expected := make(map[string]corev1.Secret)
+	add := func(publication Publication) {
+		if len(publication.CA) == 0 {
+			return
+		}
+		meta := remoteca.FederatedRemoteCAObjectMeta(&es, publication.KubernetesCluster, publication.NamespacedName())
+		meta.Annotations = map[string]string{remoteca.TransportAddressAnnotationName: publication.TransportAddress}
+		expected[meta.Name] = corev1.Secret{
+			ObjectMeta: meta,
+			Data:       map[string][]byte{certificates.CAFileName: publication.CA},
+		}
+	}
+	// remote clusters declared by this cluster
+	for _, ref := range federatedRemoteClusters(es) {
+		publication, exists := publications[ref]
+		if !exists {
+			log.Info("Federated remote cluster not found", "namespace", es.Namespace, "es_name", es.Name, "remote_cluster", ref.String())
+			r.recorder.Eventf(&es, corev1.EventTypeWarning, events.EventAssociationError, "Federated remote cluster %s not found", ref)
+			continue
+		}
+		add(publication)
+	}
+	// clusters of other Kubernetes clusters declaring this cluster as remote cluster
+	self := r.clusterRef(k8s.ExtractNamespacedName(&es))
+	for _, publication := range publications {
+		if publication.KubernetesCluster != r.kubernetesCluster && publication.Declares(self) {
+			add(publication)
+		}
+	}
+
+	for _, secret := range expected {
+		if _, err := reconciler.ReconcileSecret(r.Client, secret, &es); err != nil {
+			return err
+		}
+	}
+
+	var current corev1.SecretList
+	if err := r.List(&current, client.InNamespace(es.Namespace), remoteca.LabelSelector(es.Name)); err != nil {
+		return err
+	}
+	for i := range current.Items {
+		secret := current.Items[i]
+		if _, isExpected := expected[secret.Name]; isExpected || !remoteca.IsFederated(secret) {
+			continue
+		}
+		log.Info("Deleting federated remote CA", "namespace", es.Namespace, "es_name", es.Name, "secret_name", secret.Name)
+		if err := r.Delete(&secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
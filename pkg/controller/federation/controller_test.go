@@ -0,0 +1,168 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package federation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/license"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/certificates/transport"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster/remoteca"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func publication(t *testing.T, p Publication) *corev1.Secret {
+	secret, err := p.toSecret("federation")
+	require.NoError(t, err)
+	return &secret
+}
+
+func TestReconcileFederation_Reconcile(t *testing.T) {
+	require.NoError(t, controllerscheme.SetupScheme())
+
+	es := &esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "es1", UID: "uid"},
+		Spec: esv1.ElasticsearchSpec{
+			RemoteClusters: []esv1.RemoteCluster{
+				{Name: "local", ElasticsearchRef: commonv1.ObjectSelector{Name: "es0"}},
+				{Name: "east", ElasticsearchRef: commonv1.ObjectSelector{Namespace: "ns2", Name: "es2"}, KubernetesCluster: "east"},
+			},
+		},
+	}
+	esKey := k8s.ExtractNamespacedName(es)
+	transportCA := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: transport.PublicCertsSecretRef(esKey).Name},
+		Data:       map[string][]byte{"ca.crt": []byte("es1-ca")},
+	}
+	transportService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: services.TransportServiceName("es1")},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}},
+		}},
+	}
+	staleMeta := remoteca.FederatedRemoteCAObjectMeta(es, "east", types.NamespacedName{Namespace: "ns2", Name: "removed"})
+	staleRemoteCA := &corev1.Secret{ObjectMeta: staleMeta}
+	c := k8s.WrappedFakeClient(es, transportCA, transportService, staleRemoteCA)
+
+	self := ClusterRef{KubernetesCluster: "west", Namespace: "ns1", Name: "es1"}
+	backend := k8s.WrappedFakeClient(
+		// remote cluster declared by es1
+		publication(t, Publication{
+			ClusterRef:       ClusterRef{KubernetesCluster: "east", Namespace: "ns2", Name: "es2"},
+			CA:               []byte("es2-ca"),
+			TransportAddress: "10.0.0.2:9300",
+		}),
+		// cluster declaring es1 as remote cluster
+		publication(t, Publication{
+			ClusterRef:     ClusterRef{KubernetesCluster: "east", Namespace: "ns3", Name: "es3"},
+			CA:             []byte("es3-ca"),
+			RemoteClusters: []ClusterRef{self},
+		}),
+		// unrelated cluster
+		publication(t, Publication{
+			ClusterRef: ClusterRef{KubernetesCluster: "east", Namespace: "ns4", Name: "es4"},
+			CA:         []byte("es4-ca"),
+		}),
+	)
+	r := &ReconcileFederation{
+		Client:            c,
+		kubernetesCluster: "west",
+		backend:           Backend{Client: backend, Namespace: "federation"},
+		recorder:          record.NewFakeRecorder(10),
+		licenseChecker:    license.MockChecker{},
+	}
+
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: esKey})
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{RequeueAfter: syncInterval}, result)
+
+	// es1 is published with its CA, its address and its federated remote clusters
+	var published corev1.Secret
+	require.NoError(t, backend.Get(types.NamespacedName{Namespace: "federation", Name: "eck-federation-west-ns1-es1"}, &published))
+	p, err := fromSecret(published)
+	require.NoError(t, err)
+	require.Equal(t, Publication{
+		ClusterRef:       self,
+		CA:               []byte("es1-ca"),
+		TransportAddress: "10.0.0.1:9300",
+		RemoteClusters:   []ClusterRef{{KubernetesCluster: "east", Namespace: "ns2", Name: "es2"}},
+	}, p)
+
+	// the CA of the connected clusters are imported, the stale one is deleted
+	var remoteCA corev1.Secret
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns1", Name: "es1-east-ns2-es2-es-remote-ca"}, &remoteCA))
+	require.Equal(t, []byte("es2-ca"), remoteCA.Data["ca.crt"])
+	require.Equal(t, "10.0.0.2:9300", remoteCA.Annotations[remoteca.TransportAddressAnnotationName])
+	require.True(t, remoteca.IsFederated(remoteCA))
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "ns1", Name: "es1-east-ns3-es3-es-remote-ca"}, &remoteCA))
+	require.Equal(t, []byte("es3-ca"), remoteCA.Data["ca.crt"])
+	err = c.Get(types.NamespacedName{Namespace: "ns1", Name: "es1-east-ns4-es4-es-remote-ca"}, &corev1.Secret{})
+	require.True(t, apierrors.IsNotFound(err))
+	err = c.Get(k8s.ExtractNamespacedName(staleRemoteCA), &corev1.Secret{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// es1 is deleted: its publication is deleted
+	require.NoError(t, c.Delete(es))
+	_, err = r.Reconcile(reconcile.Request{NamespacedName: esKey})
+	require.NoError(t, err)
+	err = backend.Get(k8s.ExtractNamespacedName(&published), &corev1.Secret{})
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func Test_transportAddress(t *testing.T) {
+	loadBalancer := func(ingress ...corev1.LoadBalancerIngress) corev1.Service {
+		return corev1.Service{
+			Spec:   corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+		}
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		service     corev1.Service
+		want        string
+	}{
+		{
+			name:    "no load balancer",
+			service: corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}},
+			want:    "",
+		},
+		{
+			name:    "load balancer not provisioned yet",
+			service: loadBalancer(),
+			want:    "",
+		},
+		{
+			name:    "load balancer with a hostname",
+			service: loadBalancer(corev1.LoadBalancerIngress{Hostname: "es.example.com"}),
+			want:    "es.example.com:9300",
+		},
+		{
+			name:        "address set in the annotation",
+			annotations: map[string]string{TransportAddressAnnotationName: "es.example.com:443"},
+			service:     loadBalancer(corev1.LoadBalancerIngress{IP: "10.0.0.1"}),
+			want:        "es.example.com:443",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			require.Equal(t, tt.want, transportAddress(es, tt.service))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package federation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/network"
+)
+
+const (
+	// TransportAddressAnnotationName holds the transport address at which an Elasticsearch cluster is reachable from
+	// the other Kubernetes clusters of the federation. Set on an Elasticsearch resource, it overrides the address of
+	// the load balancer of the transport service.
+	TransportAddressAnnotationName = "elasticsearch.k8s.elastic.co/federation-transport-address"
+	// RemoteClustersAnnotationName holds the federated remote clusters declared by a published Elasticsearch cluster.
+	RemoteClustersAnnotationName = "elasticsearch.k8s.elastic.co/federation-remote-clusters"
+
+	// KubernetesClusterLabelName is the Kubernetes cluster of a published Elasticsearch cluster.
+	KubernetesClusterLabelName = "elasticsearch.k8s.elastic.co/federation-kubernetes-cluster"
+	// NamespaceLabelName is the namespace of a published Elasticsearch cluster.
+	NamespaceLabelName = "elasticsearch.k8s.elastic.co/federation-namespace"
+	// NameLabelName is the name of a published Elasticsearch cluster.
+	NameLabelName = "elasticsearch.k8s.elastic.co/federation-name"
+	// TypeLabelValue identifies the Secrets holding a published Elasticsearch cluster.
+	TypeLabelValue = "federated-elasticsearch"
+)
+
+// ClusterRef identifies an Elasticsearch cluster in the federation.
+type ClusterRef struct {
+	KubernetesCluster string `json:"kubernetesCluster"`
+	Namespace         string `json:"namespace"`
+	Name              string `json:"name"`
+}
+
+// NamespacedName returns the namespace and name of the Elasticsearch cluster in its Kubernetes cluster.
+func (c ClusterRef) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{Namespace: c.Namespace, Name: c.Name}
+}
+
+func (c ClusterRef) String() string {
+	return fmt.Sprintf("%s/%s/%s", c.KubernetesCluster, c.Namespace, c.Name)
+}
+
+// Publication is an Elasticsearch cluster published to the other operators of the federation.
+type Publication struct {
+	ClusterRef
+	// CA is the transport CA of the cluster.
+	CA []byte
+	// TransportAddress is the address at which the cluster is reachable from other Kubernetes clusters, if known.
+	TransportAddress string
+	// RemoteClusters are the federated remote clusters declared by the cluster.
+	RemoteClusters []ClusterRef
+}
+
+// Declares returns true if the published cluster declares the given cluster as a remote cluster.
+func (p Publication) Declares(ref ClusterRef) bool {
+	for _, remote := range p.RemoteClusters {
+		if remote == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// secretName returns the name of the Secret holding the publication of the given cluster.
+func secretName(ref ClusterRef) string {
+	return fmt.Sprintf("eck-federation-%s-%s-%s", ref.KubernetesCluster, ref.Namespace, ref.Name)
+}
+
+// labelSelector selects the Secrets holding a publication.
+func labelSelector() client.MatchingLabels {
+	return map[string]string{common.TypeLabelName: TypeLabelValue}
+}
+
+// toSecret returns the Secret holding the publication in the given namespace of the backend.
+func (p Publication) toSecret(namespace string) (corev1.Secret, error) {
+	remoteClusters, err := json.Marshal(p.RemoteClusters)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      secretName(p.ClusterRef),
+			Labels: map[string]string{
+				common.TypeLabelName:       TypeLabelValue,
+				KubernetesClusterLabelName: p.KubernetesCluster,
+				NamespaceLabelName:         p.Namespace,
+				NameLabelName:              p.Name,
+			},
+			Annotations: map[string]string{
+				TransportAddressAnnotationName: p.TransportAddress,
+				RemoteClustersAnnotationName:   string(remoteClusters),
+			},
+		},
+		Data: map[string][]byte{
+			certificates.CAFileName: p.CA,
+		},
+	}, nil
+}
+
+// fromSecret returns the publication held by the given Secret.
+func fromSecret(secret corev1.Secret) (Publication, error) {
+	publication := Publication{
+		ClusterRef: ClusterRef{
+			KubernetesCluster: secret.Labels[KubernetesClusterLabelName],
+			Namespace:         secret.Labels[NamespaceLabelName],
+			Name:              secret.Labels[NameLabelName],
+		},
+		CA:               secret.Data[certificates.CAFileName],
+		TransportAddress: secret.Annotations[TransportAddressAnnotationName],
+	}
+	if serialized, exists := secret.Annotations[RemoteClustersAnnotationName]; exists {
+		if err := json.Unmarshal([]byte(serialized), &publication.RemoteClusters); err != nil {
+			return Publication{}, err
+		}
+	}
+	return publication, nil
+}
+
+// federatedRemoteClusters returns the remote clusters of the given cluster running in other Kubernetes clusters.
+func federatedRemoteClusters(es esv1.Elasticsearch) []ClusterRef {
+	var refs []ClusterRef
+	for _, remoteCluster := range es.Spec.RemoteClusters {
+		if !remoteCluster.IsFederated() || !remoteCluster.ElasticsearchRef.IsDefined() {
+			continue
+		}
+		ref := remoteCluster.ElasticsearchRef.WithDefaultNamespace(es.Namespace)
+		refs = append(refs, ClusterRef{
+			KubernetesCluster: remoteCluster.KubernetesCluster,
+			Namespace:         ref.Namespace,
+			Name:              ref.Name,
+		})
+	}
+	return refs
+}
+
+// transportAddress returns the address at which the transport service is reachable from other Kubernetes clusters:
+// the address set in the annotation of the cluster, or else the address of the load balancer of the service.
+func transportAddress(es esv1.Elasticsearch, service corev1.Service) string {
+	if address, exists := es.Annotations[TransportAddressAnnotationName]; exists {
+		return address
+	}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return ""
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		host := ingress.IP
+		if host == "" {
+			host = ingress.Hostname
+		}
+		if host != "" {
+			return net.JoinHostPort(host, strconv.Itoa(network.TransportPort))
+		}
+	}
+	return ""
+}
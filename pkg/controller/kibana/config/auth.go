@@ -0,0 +1,89 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	samlProvider  = "saml"
+	oidcProvider  = "oidc"
+	basicProvider = "basic"
+)
+
+// getAuthProvidersSettings returns the auth providers matching the SAML and OIDC realms of the associated
+// Elasticsearch cluster, if any.
+func getAuthProvidersSettings(client k8s.Client, kb kbv1.Kibana, v version.Version) (*settings.CanonicalConfig, error) {
+	if !kb.RequiresAssociation() {
+		return nil, nil
+	}
+	var es esv1.Elasticsearch
+	err := client.Get(kb.Spec.ElasticsearchRef.WithDefaultNamespace(kb.Namespace).NamespacedName(), &es)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	providers := authProvidersSettings(es.Spec.Auth, v)
+	if providers == nil {
+		return nil, nil
+	}
+	return settings.NewCanonicalConfigFrom(providers)
+}
+
+// authProvidersSettings returns the auth providers matching the given SAML and OIDC realms, in the same order. The basic
+// provider is appended last, for the users of the native and file realms to still be able to log in.
+// Kibana versions before 7.7 only support a single provider of each type: the first realm of each type is used.
+// Kibana 6.x is not supported, since it cannot be configured with OIDC and requires additional settings for SAML.
+func authProvidersSettings(auth esv1.Auth, v version.Version) map[string]interface{} {
+	if len(auth.SAML) == 0 && len(auth.OIDC) == 0 || v.Major < 7 {
+		return nil
+	}
+
+	if !v.IsSameOrAfter(version.MustParse("7.7.0")) {
+		var providers []string
+		cfg := map[string]interface{}{}
+		if len(auth.SAML) > 0 {
+			providers = append(providers, samlProvider)
+			cfg[XpackSecurityAuthcSAMLRealm] = auth.SAML[0].Name
+		}
+		if len(auth.OIDC) > 0 {
+			providers = append(providers, oidcProvider)
+			cfg[XpackSecurityAuthcOIDCRealm] = auth.OIDC[0].Name
+		}
+		cfg[XpackSecurityAuthcProviders] = append(providers, basicProvider)
+		return cfg
+	}
+
+	order := 0
+	samlProviders := map[string]interface{}{}
+	for _, realm := range auth.SAML {
+		samlProviders[realm.Name] = map[string]interface{}{"order": order, "realm": realm.Name}
+		order++
+	}
+	oidcProviders := map[string]interface{}{}
+	for _, realm := range auth.OIDC {
+		oidcProviders[realm.Name] = map[string]interface{}{"order": order, "realm": realm.Name}
+		order++
+	}
+	providers := map[string]interface{}{
+		basicProvider: map[string]interface{}{"basic1": map[string]interface{}{"order": order}},
+	}
+	if len(samlProviders) > 0 {
+		providers[samlProvider] = samlProviders
+	}
+	if len(oidcProviders) > 0 {
+		providers[oidcProvider] = oidcProviders
+	}
+	return map[string]interface{}{XpackSecurityAuthcProviders: providers}
+}
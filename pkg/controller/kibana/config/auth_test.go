@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+)
+
+func Test_authProvidersSettings(t *testing.T) {
+	auth := esv1.Auth{
+		SAML: []esv1.SAMLRealm{{Name: "saml1"}, {Name: "saml2"}},
+		OIDC: []esv1.OIDCRealm{{Name: "oidc1"}},
+	}
+	tests := []struct {
+		name    string
+		auth    esv1.Auth
+		version string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "no realms",
+			auth:    esv1.Auth{},
+			version: "7.7.0",
+			want:    nil,
+		},
+		{
+			name:    "6.x is not supported",
+			auth:    auth,
+			version: "6.8.0",
+			want:    nil,
+		},
+		{
+			name:    "before 7.7, a single provider per type",
+			auth:    auth,
+			version: "7.6.2",
+			want: map[string]interface{}{
+				XpackSecurityAuthcProviders: []string{"saml", "oidc", "basic"},
+				XpackSecurityAuthcSAMLRealm: "saml1",
+				XpackSecurityAuthcOIDCRealm: "oidc1",
+			},
+		},
+		{
+			name:    "from 7.7, ordered named providers",
+			auth:    auth,
+			version: "7.7.0",
+			want: map[string]interface{}{
+				XpackSecurityAuthcProviders: map[string]interface{}{
+					"saml": map[string]interface{}{
+						"saml1": map[string]interface{}{"order": 0, "realm": "saml1"},
+						"saml2": map[string]interface{}{"order": 1, "realm": "saml2"},
+					},
+					"oidc": map[string]interface{}{
+						"oidc1": map[string]interface{}{"order": 2, "realm": "oidc1"},
+					},
+					"basic": map[string]interface{}{
+						"basic1": map[string]interface{}{"order": 3},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, authProvidersSettings(tt.auth, version.MustParse(tt.version)))
+		})
+	}
+}
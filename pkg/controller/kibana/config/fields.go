@@ -11,14 +11,22 @@ const (
 	XpackMonitoringUiContainerElasticsearchEnabled = "xpack.monitoring.ui.container.elasticsearch.enabled"
 	XpackLicenseManagementUIEnabled                = "xpack.license_management.ui.enabled" // >= 7.6
 	XpackSecurityEncryptionKey                     = "xpack.security.encryptionKey"
+	XpackSecurityAuthcProviders                    = "xpack.security.authc.providers"
+	XpackSecurityAuthcSAMLRealm                    = "xpack.security.authc.saml.realm" // < 7.7
+	XpackSecurityAuthcOIDCRealm                    = "xpack.security.authc.oidc.realm" // < 7.7
 
 	ElasticsearchSslCertificateAuthorities = "elasticsearch.ssl.certificateAuthorities"
 	ElasticsearchSslVerificationMode       = "elasticsearch.ssl.verificationMode"
 
 	ElasticsearchUsername = "elasticsearch.username"
 	ElasticsearchPassword = "elasticsearch.password"
+	// ElasticsearchServiceAccountToken replaces the username and password for 7.13+ associations.
+	ElasticsearchServiceAccountToken = "elasticsearch.serviceAccountToken"
 
-	ElasticsearchHosts = "elasticsearch.hosts"
+	ElasticsearchHosts                  = "elasticsearch.hosts"
+	ElasticsearchSniffOnStart           = "elasticsearch.sniffOnStart"
+	ElasticsearchSniffInterval          = "elasticsearch.sniffInterval"
+	ElasticsearchSniffOnConnectionFault = "elasticsearch.sniffOnConnectionFault"
 
 	ServerSSLEnabled     = "server.ssl.enabled"
 	ServerSSLCertificate = "server.ssl.certificate"
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+)
+
+// ReloadableSettings are the top-level Kibana settings applied without restart: Kibana reloads them from its
+// configuration file when it receives SIGHUP.
+var ReloadableSettings = []string{"logging"}
+
+// HasReloadableSettings returns true if the given rendered Kibana configuration sets any reloadable setting.
+func HasReloadableSettings(rendered []byte) (bool, error) {
+	data, err := unpack(rendered)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range ReloadableSettings {
+		if _, exists := data[key]; exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RestartRequiredSettings returns the given rendered Kibana configuration without its reloadable settings, so that
+// Kibana is restarted only if the remaining settings change. The configuration is returned as is if it does not set
+// any reloadable setting.
+func RestartRequiredSettings(rendered []byte) ([]byte, error) {
+	data, err := unpack(rendered)
+	if err != nil {
+		return nil, err
+	}
+	reloadable := false
+	for _, key := range ReloadableSettings {
+		if _, exists := data[key]; exists {
+			reloadable = true
+			delete(data, key)
+		}
+	}
+	if !reloadable {
+		return rendered, nil
+	}
+	filtered, err := settings.NewCanonicalConfigFrom(data)
+	if err != nil {
+		return nil, err
+	}
+	return filtered.Render()
+}
+
+func unpack(rendered []byte) (map[string]interface{}, error) {
+	cfg, err := settings.ParseConfig(rendered)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := cfg.Unpack(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
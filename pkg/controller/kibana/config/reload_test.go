@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartRequiredSettings(t *testing.T) {
+	base := `
+server.name: kibana
+elasticsearch.hosts: ["https://es-http:9200"]
+`
+	restartRequired := func(yml string) string {
+		filtered, err := RestartRequiredSettings([]byte(yml))
+		require.NoError(t, err)
+		return string(filtered)
+	}
+	// configurations without reloadable settings are not changed
+	require.Equal(t, base, restartRequired(base))
+	expected := restartRequired(base + `
+logging.quiet: true
+`)
+	require.Contains(t, expected, "server:")
+	require.NotContains(t, expected, "logging")
+
+	// logging settings changes do not change the settings requiring a restart
+	require.Equal(t, expected, restartRequired(base+`
+logging.verbose: true
+logging:
+  quiet: false
+`))
+	// other settings do
+	require.NotEqual(t, expected, restartRequired(base+`
+server.basePath: /kibana
+`))
+
+	_, err := RestartRequiredSettings([]byte("not: [valid"))
+	require.Error(t, err)
+}
+
+func TestHasReloadableSettings(t *testing.T) {
+	for yml, want := range map[string]bool{
+		"server.name: kibana":                      false,
+		"server.name: kibana\nlogging.quiet: true": true,
+		"logging:\n  verbose: true":                true,
+		"server.logging: true":                     false,
+	} {
+		got, err := HasReloadableSettings([]byte(yml))
+		require.NoError(t, err)
+		require.Equal(t, want, got, yml)
+	}
+	_, err := HasReloadableSettings([]byte("not: [valid"))
+	require.Error(t, err)
+}
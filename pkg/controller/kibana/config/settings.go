@@ -14,9 +14,11 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/stackconfigpolicy"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/es"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/name"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"github.com/elastic/go-ucfg"
 	"github.com/pkg/errors"
@@ -68,17 +70,24 @@ func NewConfigSettings(ctx context.Context, client k8s.Client, kb kbv1.Kibana, v
 		return CanonicalConfig{}, err
 	}
 
+	policySettings, err := getPolicySettings(client, kb)
+	if err != nil {
+		return CanonicalConfig{}, err
+	}
+
 	cfg := settings.MustCanonicalConfig(baseSettings(&kb))
 	kibanaTLSCfg := settings.MustCanonicalConfig(kibanaTLSSettings(kb))
 	versionSpecificCfg := VersionDefaults(&kb, v)
 
 	if !kb.RequiresAssociation() {
-		// merge the configuration with userSettings last so they take precedence
+		// merge the configuration with userSettings last so they take precedence,
+		// followed by the settings enforced by a StackConfigPolicy
 		if err := cfg.MergeWith(
 			filteredCurrCfg,
 			versionSpecificCfg,
 			kibanaTLSCfg,
-			userSettings); err != nil {
+			userSettings,
+			policySettings); err != nil {
 			return CanonicalConfig{}, err
 		}
 		return CanonicalConfig{cfg}, nil
@@ -89,19 +98,22 @@ func NewConfigSettings(ctx context.Context, client k8s.Client, kb kbv1.Kibana, v
 		return CanonicalConfig{}, err
 	}
 
-	// merge the configuration with userSettings last so they take precedence
+	authProvidersSettings, err := getAuthProvidersSettings(client, kb, v)
+	if err != nil {
+		return CanonicalConfig{}, err
+	}
+
+	// merge the configuration with userSettings last so they take precedence,
+	// followed by the settings enforced by a StackConfigPolicy
 	err = cfg.MergeWith(
 		filteredCurrCfg,
 		versionSpecificCfg,
 		kibanaTLSCfg,
 		settings.MustCanonicalConfig(elasticsearchTLSSettings(kb)),
-		settings.MustCanonicalConfig(
-			map[string]interface{}{
-				ElasticsearchUsername: username,
-				ElasticsearchPassword: password,
-			},
-		),
+		settings.MustCanonicalConfig(elasticsearchAuthSettings(kb, username, password)),
+		authProvidersSettings,
 		userSettings,
+		policySettings,
 	)
 	if err != nil {
 		return CanonicalConfig{}, err
@@ -110,6 +122,27 @@ func NewConfigSettings(ctx context.Context, client k8s.Client, kb kbv1.Kibana, v
 	return CanonicalConfig{cfg}, nil
 }
 
+// elasticsearchAuthSettings returns the settings used by Kibana to authenticate against the associated Elasticsearch:
+// a service account token if the association relies on one, a username and password otherwise.
+func elasticsearchAuthSettings(kb kbv1.Kibana, username, password string) map[string]interface{} {
+	if kb.AssociationConf().GetIsServiceAccount() {
+		return map[string]interface{}{ElasticsearchServiceAccountToken: password}
+	}
+	return map[string]interface{}{
+		ElasticsearchUsername: username,
+		ElasticsearchPassword: password,
+	}
+}
+
+// getPolicySettings retrieves the settings distributed to the given Kibana by a StackConfigPolicy, if any
+func getPolicySettings(client k8s.Client, kb kbv1.Kibana) (*settings.CanonicalConfig, error) {
+	secret, err := stackconfigpolicy.GetConfigSecret(client, name.KBNamer, k8s.ExtractNamespacedName(&kb))
+	if err != nil || secret == nil {
+		return nil, err
+	}
+	return stackconfigpolicy.KibanaConfig(*secret)
+}
+
 // getExistingConfig retrieves the canonical config for a given Kibana, if one exists
 func getExistingConfig(client k8s.Client, kb kbv1.Kibana) (*settings.CanonicalConfig, error) {
 	var secret corev1.Secret
@@ -164,7 +197,13 @@ func baseSettings(kb *kbv1.Kibana) map[string]interface{} {
 	}
 
 	if kb.RequiresAssociation() {
-		conf[ElasticsearchHosts] = []string{kb.AssociationConf().GetURL()}
+		conf[ElasticsearchHosts] = kb.AssociationConf().GetHosts()
+		if kb.Spec.ElasticsearchHosts != nil {
+			// do not discover the nodes outside of the selected ones
+			conf[ElasticsearchSniffOnStart] = false
+			conf[ElasticsearchSniffInterval] = false
+			conf[ElasticsearchSniffOnConnectionFault] = false
+		}
 	}
 
 	return conf
@@ -52,6 +52,21 @@ elasticsearch:
     verificationMode: certificate
 `)
 
+var selectedNodesAssociationConfig = []byte(`
+elasticsearch:
+  hosts:
+    - "https://es-es-coord-0.es-es-coord.default.svc:9200"
+    - "https://es-es-coord-1.es-es-coord.default.svc:9200"
+  sniffOnStart: false
+  sniffInterval: false
+  sniffOnConnectionFault: false
+  username: "elastic"
+  password: "password"
+  ssl:
+    certificateAuthorities: /usr/share/kibana/config/elasticsearch-certs/ca.crt
+    verificationMode: certificate
+`)
+
 func TestNewConfigSettings(t *testing.T) {
 	defaultKb := mkKibana()
 	existingSecret := &corev1.Secret{
@@ -161,6 +176,52 @@ func TestNewConfigSettings(t *testing.T) {
 			}(),
 			wantErr: false,
 		},
+		{
+			name: "with Association to selected nodes",
+			args: args{
+				kb: func() kbv1.Kibana {
+					kb := mkKibana()
+					kb.Spec = kbv1.KibanaSpec{
+						ElasticsearchRef:   commonv1.ObjectSelector{Name: "test-es"},
+						ElasticsearchHosts: &kbv1.ElasticsearchHostsSelector{NodeSets: []string{"coord"}},
+					}
+					kb.SetAssociationConf(&commonv1.AssociationConf{
+						AuthSecretName: "auth-secret",
+						AuthSecretKey:  "elastic",
+						CASecretName:   "ca-secret",
+						CACertProvided: true,
+						URL:            "https://es-url:9200",
+						Hosts: []string{
+							"https://es-es-coord-0.es-es-coord.default.svc:9200",
+							"https://es-es-coord-1.es-es-coord.default.svc:9200",
+						},
+					})
+					return kb
+				},
+				client: k8s.WrappedFakeClient(
+					existingSecret,
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "auth-secret",
+							Namespace: mkKibana().Namespace,
+						},
+						Data: map[string][]byte{
+							"elastic": []byte("password"),
+						},
+					},
+				),
+			},
+			want: func() []byte {
+				cfg, err := settings.ParseConfig(defaultConfig)
+				require.NoError(t, err)
+				assocCfg, err := settings.ParseConfig(selectedNodesAssociationConfig)
+				require.NoError(t, err)
+				require.NoError(t, cfg.MergeWith(assocCfg))
+				bytes, err := cfg.Render()
+				require.NoError(t, err)
+				return bytes
+			}(),
+		},
 		{
 			name: "with user config",
 			args: args{
@@ -441,3 +502,17 @@ func Test_filterExistingConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_elasticsearchAuthSettings(t *testing.T) {
+	kb := mkKibana()
+	kb.SetAssociationConf(&commonv1.AssociationConf{AuthSecretName: "auth-secret", AuthSecretKey: "kibana-user"})
+	require.Equal(t, map[string]interface{}{
+		ElasticsearchUsername: "kibana-user",
+		ElasticsearchPassword: "password",
+	}, elasticsearchAuthSettings(kb, "kibana-user", "password"))
+
+	kb.SetAssociationConf(&commonv1.AssociationConf{AuthSecretName: "auth-secret", AuthSecretKey: "token", IsServiceAccount: true})
+	require.Equal(t, map[string]interface{}{
+		ElasticsearchServiceAccountToken: "AAEAAWVsYXN0aWM",
+	}, elasticsearchAuthSettings(kb, "token", "AAEAAWVsYXN0aWM"))
+}
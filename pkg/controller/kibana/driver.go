@@ -18,8 +18,11 @@ import (
 	driver2 "github.com/elastic/cloud-on-k8s/pkg/controller/common/driver"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/openshift"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/podsecurity"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/stackconfigpolicy"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
 	commonvolume "github.com/elastic/cloud-on-k8s/pkg/controller/common/volume"
@@ -44,9 +47,11 @@ import (
 // initContainersParameters is used to generate the init container that will load the secure settings into a keystore
 var initContainersParameters = keystore.InitContainerParameters{
 	KeystoreCreateCommand:         "/usr/share/kibana/bin/kibana-keystore create",
-	KeystoreAddCommand:            `/usr/share/kibana/bin/kibana-keystore add "$key" --stdin < "$filename"`,
+	KeystoreAddCommand:            `/usr/share/kibana/bin/kibana-keystore add "$key" --force --stdin < "$filename"`,
+	KeystoreRemoveCommand:         `/usr/share/kibana/bin/kibana-keystore remove "$key"`,
 	SecureSettingsVolumeMountPath: keystore.SecureSettingsVolumeMountPath,
 	DataVolumePath:                volume.DataVolumeMountPath,
+	KeystorePath:                  volume.DataVolumeMountPath + "/kibana.keystore",
 }
 
 // minSupportedVersion is the minimum version of Kibana supported by ECK. Currently this is set to version 6.8.0.
@@ -109,7 +114,19 @@ func (d *driver) deploymentParams(kb *kbv1.Kibana) (deployment.Params, error) {
 		return deployment.Params{}, err
 	}
 
-	kibanaPodSpec := pod.NewPodTemplateSpec(*kb, keystoreResources)
+	// get config secret to add its content to the config checksum, except for the settings Kibana reloads from the
+	// updated secret volume without restart
+	configSecret := corev1.Secret{}
+	err = d.client.Get(types.NamespacedName{Name: config.SecretName(*kb), Namespace: kb.Namespace}, &configSecret)
+	if err != nil {
+		return deployment.Params{}, err
+	}
+	reloadConfig, err := config.HasReloadableSettings(configSecret.Data[config.SettingsFilename])
+	if err != nil {
+		return deployment.Params{}, err
+	}
+
+	kibanaPodSpec := pod.NewPodTemplateSpec(*kb, keystoreResources, reloadConfig)
 
 	// Build a checksum of the configuration, which we can use to cause the Deployment to roll Kibana
 	// instances in case of any change in the CA file, secure settings or credentials contents.
@@ -173,13 +190,11 @@ func (d *driver) deploymentParams(kb *kbv1.Kibana) (deployment.Params, error) {
 		kibanaContainer.VolumeMounts = append(kibanaContainer.VolumeMounts, volume.VolumeMount())
 	}
 
-	// get config secret to add its content to the config checksum
-	configSecret := corev1.Secret{}
-	err = d.client.Get(types.NamespacedName{Name: config.SecretName(*kb), Namespace: kb.Namespace}, &configSecret)
+	restartRequiredSettings, err := config.RestartRequiredSettings(configSecret.Data[config.SettingsFilename])
 	if err != nil {
 		return deployment.Params{}, err
 	}
-	_, _ = configChecksum.Write(configSecret.Data[config.SettingsFilename])
+	_, _ = configChecksum.Write(restartRequiredSettings)
 
 	// add the checksum to a label for the deployment and its pods (the important bit is that the pod template
 	// changes, which will trigger a rolling update)
@@ -199,6 +214,7 @@ func (d *driver) deploymentParams(kb *kbv1.Kibana) (deployment.Params, error) {
 		Labels:          label.NewLabels(kb.Name),
 		PodTemplateSpec: kibanaPodSpec,
 		Strategy:        strategyType,
+		Version:         kb.Spec.Version,
 	}, nil
 }
 
@@ -212,18 +228,30 @@ func (d *driver) Reconcile(
 	if !association.IsConfiguredIfSet(kb, d.recorder) {
 		return results
 	}
+	if allowed, err := association.AllowVersion(d.client, kb, kb.Spec.Version, d.recorder); err != nil || !allowed {
+		return results.WithResult(association.VersionBlockedRequeue).WithError(err)
+	}
 
 	svc, err := common.ReconcileService(ctx, d.client, NewService(*kb), kb)
 	if err != nil {
 		// TODO: consider updating some status here?
 		return results.WithError(err)
 	}
+	if params.OpenShift {
+		if err := openshift.ReconcileRoute(d.client, *svc, kb.Spec.HTTP.TLS.Enabled(), kb); err != nil {
+			return results.WithError(err)
+		}
+	}
 
-	results.WithResults(kbcerts.Reconcile(ctx, d, *kb, []corev1.Service{*svc}, params.CACertRotation, params.CertRotation))
+	results.WithResults(kbcerts.Reconcile(ctx, d, *kb, []corev1.Service{*svc}, params.GetCACertRotation(), params.GetCertRotation()))
 	if results.HasError() {
 		return results
 	}
 
+	if err := watchElasticsearch(d.dynamicWatches, *kb); err != nil {
+		return results.WithError(err)
+	}
+
 	kbSettings, err := config.NewConfigSettings(ctx, d.client, *kb, d.version)
 	if err != nil {
 		return results.WithError(err)
@@ -234,13 +262,28 @@ func (d *driver) Reconcile(
 		return results.WithError(err)
 	}
 
+	// settings distributed by a StackConfigPolicy are applied once part of the configuration secret
+	if err := reconcilePolicyStatus(d.client, *kb); err != nil {
+		return results.WithError(err)
+	}
+
 	span, _ := apm.StartSpan(ctx, "reconcile_deployment", tracing.SpanTypeApp)
 	defer span.End()
 
+	if err := params.Overlay.ValidateSecureSettings(kb.Namespace, kb.SecureSettings()); err != nil {
+		k8s.EmitErrorEvent(d.recorder, err, kb, events.EventReasonValidation, "Invalid secure settings: %v", err)
+		return results.WithError(err)
+	}
 	deploymentParams, err := d.deploymentParams(kb)
 	if err != nil {
 		return results.WithError(err)
 	}
+	if params.OpenShift {
+		openshift.SetRestrictedSecurityContext(&deploymentParams.PodTemplateSpec)
+	}
+	if err := podsecurity.Enforce(params.PodSecurityStandard, kb, &deploymentParams.PodTemplateSpec); err != nil {
+		return results.WithError(err)
+	}
 
 	expectedDp := deployment.New(deploymentParams)
 	reconciledDp, err := deployment.Reconcile(d.client, expectedDp, kb)
@@ -276,3 +319,12 @@ func newDriver(
 		version:        *ver,
 	}, nil
 }
+
+// reconcilePolicyStatus records that the settings distributed to the given Kibana by a StackConfigPolicy are applied.
+func reconcilePolicyStatus(c k8s.Client, kb kbv1.Kibana) error {
+	secret, err := stackconfigpolicy.GetConfigSecret(c, kbname.KBNamer, k8s.ExtractNamespacedName(&kb))
+	if err != nil || secret == nil {
+		return err
+	}
+	return stackconfigpolicy.UpdateAppliedStatus(c, *secret, nil)
+}
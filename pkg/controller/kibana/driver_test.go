@@ -337,6 +337,7 @@ func TestDriverDeploymentParams(t *testing.T) {
 			want: func() deployment.Params {
 				p := expectedDeploymentParams()
 				p.PodTemplateSpec.Labels["kibana.k8s.elastic.co/version"] = "6.8.0"
+				p.Version = "6.8.0"
 				return p
 			}(),
 			wantErr: false,
@@ -354,6 +355,7 @@ func TestDriverDeploymentParams(t *testing.T) {
 			want: func() deployment.Params {
 				p := expectedDeploymentParams()
 				p.PodTemplateSpec.Labels["kibana.k8s.elastic.co/version"] = "6.8.0"
+				p.Version = "6.8.0"
 				return p
 			}(),
 			wantErr: false,
@@ -383,6 +385,38 @@ func TestDriverDeploymentParams(t *testing.T) {
 	}
 }
 
+func TestDriverDeploymentParams_ReloadableSettings(t *testing.T) {
+	deploymentParams := func(kibanaYml string) deployment.Params {
+		initialObjects := defaultInitialObjects()
+		for _, obj := range initialObjects {
+			if secret := obj.(*corev1.Secret); secret.Name == "test-kb-config" {
+				secret.Data = map[string][]byte{"kibana.yml": []byte(kibanaYml)}
+			}
+		}
+		kb := kibanaFixture()
+		d, err := newDriver(k8s.WrappedFakeClient(initialObjects...), watches.NewDynamicWatches(), record.NewFakeRecorder(100), kb)
+		require.NoError(t, err)
+		params, err := d.deploymentParams(kb)
+		require.NoError(t, err)
+		return params
+	}
+
+	quiet := deploymentParams("server.name: test\nlogging.quiet: true")
+	verbose := deploymentParams("server.name: test\nlogging.verbose: true")
+
+	// changing the logging settings does not restart Kibana
+	require.Equal(t, quiet.PodTemplateSpec.Labels[configChecksumLabel], verbose.PodTemplateSpec.Labels[configChecksumLabel])
+	// but changing the other settings does
+	other := deploymentParams("server.name: other\nlogging.quiet: true")
+	require.NotEqual(t, quiet.PodTemplateSpec.Labels[configChecksumLabel], other.PodTemplateSpec.Labels[configChecksumLabel])
+
+	// the config reloader sidecar sends SIGHUP to Kibana through the shared process namespace
+	require.Len(t, quiet.PodTemplateSpec.Spec.Containers, 2)
+	require.Equal(t, pod.ConfigReloaderContainerName, quiet.PodTemplateSpec.Spec.Containers[1].Name)
+	require.NotNil(t, quiet.PodTemplateSpec.Spec.ShareProcessNamespace)
+	require.True(t, *quiet.PodTemplateSpec.Spec.ShareProcessNamespace)
+}
+
 func TestMinSupportedVersion(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -432,6 +466,7 @@ func expectedDeploymentParams() deployment.Params {
 		Labels:    map[string]string{"common.k8s.elastic.co/type": "kibana", "kibana.k8s.elastic.co/name": "test"},
 		Replicas:  1,
 		Strategy:  appsv1.RollingUpdateDeploymentStrategyType,
+		Version:   "7.0.0",
 		PodTemplateSpec: corev1.PodTemplateSpec{
 			ObjectMeta: metav1.ObjectMeta{
 				Labels: map[string]string{
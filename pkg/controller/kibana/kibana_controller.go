@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"sync/atomic"
 
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/annotation"
@@ -17,6 +18,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/finalizer"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/profile"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/label"
@@ -46,7 +48,7 @@ var log = logf.Log.WithName(name)
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, params operator.Parameters) error {
 	reconciler := newReconciler(mgr, params)
-	c, err := common.NewController(mgr, name, reconciler, params)
+	c, err := common.NewController(mgr, name, &kbv1.Kibana{}, reconciler, params)
 	if err != nil {
 		return err
 	}
@@ -99,6 +101,16 @@ func addWatches(c controller.Controller, r *ReconcileKibana) error {
 		return err
 	}
 
+	// dynamically watch the associated Elasticsearch cluster to configure the auth providers matching its realms
+	if err := c.Watch(&source.Kind{Type: &esv1.Elasticsearch{}}, r.dynamicWatches.ElasticsearchClusters); err != nil {
+		return err
+	}
+
+	// watch the defaults profiles overriding the operator settings per namespace
+	if err := profile.WatchOverlays(c, r.Client, r.params.OperatorNamespace, &kbv1.KibanaList{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -186,7 +198,7 @@ func (r *ReconcileKibana) doReconcile(ctx context.Context, request reconcile.Req
 	}
 
 	state := NewState(request, kb)
-	results := driver.Reconcile(ctx, &state, kb, r.params)
+	results := driver.Reconcile(ctx, &state, kb, r.params.ForNamespace(kb.Namespace))
 
 	// update status
 	err = r.updateStatus(ctx, state)
@@ -223,4 +235,26 @@ func (r *ReconcileKibana) updateStatus(ctx context.Context, state State) error {
 func (r *ReconcileKibana) onDelete(obj types.NamespacedName) {
 	// Clean up watches set on secure settings
 	r.dynamicWatches.Secrets.RemoveHandlerForKey(keystore.SecureSettingsWatchName(obj))
+	// Clean up the watch set on the associated Elasticsearch cluster
+	r.dynamicWatches.ElasticsearchClusters.RemoveHandlerForKey(elasticsearchWatchName(obj))
+}
+
+// elasticsearchWatchName returns the name of the watch set on the Elasticsearch cluster associated to a Kibana.
+func elasticsearchWatchName(kibanaKey types.NamespacedName) string {
+	return kibanaKey.Namespace + "-" + kibanaKey.Name + "-es-auth-watch"
+}
+
+// watchElasticsearch watches the Elasticsearch cluster associated to the given Kibana, if any, for future
+// reconciliations to be triggered on any change of its realms.
+func watchElasticsearch(dynamicWatches watches.DynamicWatches, kb kbv1.Kibana) error {
+	kibanaKey := k8s.ExtractNamespacedName(&kb)
+	if !kb.RequiresAssociation() {
+		dynamicWatches.ElasticsearchClusters.RemoveHandlerForKey(elasticsearchWatchName(kibanaKey))
+		return nil
+	}
+	return dynamicWatches.ElasticsearchClusters.AddHandler(watches.NamedWatch{
+		Name:    elasticsearchWatchName(kibanaKey),
+		Watched: []types.NamespacedName{kb.Spec.ElasticsearchRef.WithDefaultNamespace(kb.Namespace).NamespacedName()},
+		Watcher: kibanaKey,
+	})
 }
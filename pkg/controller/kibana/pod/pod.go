@@ -6,6 +6,7 @@ package pod
 
 import (
 	"fmt"
+	"path"
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/annotation"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/container"
@@ -15,6 +16,7 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/defaults"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/pod"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/config"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/label"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/volume"
 
@@ -43,6 +45,67 @@ var (
 	}
 )
 
+const (
+	// ConfigReloaderContainerName is the name of the sidecar container sending SIGHUP to Kibana when its configuration
+	// file changes, so that Kibana reloads the settings that do not require a restart.
+	ConfigReloaderContainerName = "config-reloader"
+	// configReloadInterval is the interval at which the Kibana configuration file is checked for changes, in seconds.
+	configReloadInterval = 10
+	// kibanaProcessPattern matches the command line of the Kibana process.
+	kibanaProcessPattern = "/src/cli"
+)
+
+var (
+	// configReloaderScript looks up the Kibana process in the process namespace shared by the containers of the Pod,
+	// and sends it SIGHUP when the configuration file changes. The pattern matching the Kibana process is read from
+	// the environment, for the command line of this script not to match it.
+	configReloaderScript = fmt.Sprintf(`#!/usr/bin/env bash
+
+config=%s
+checksum=$(md5sum < "$config")
+echo "Checking the configuration file for changes every %d seconds."
+while sleep %d; do
+  current=$(md5sum < "$config")
+  if [[ "$current" == "$checksum" ]]; then
+    continue
+  fi
+  checksum=$current
+  for cmdline in /proc/[0-9]*/cmdline; do
+    if [[ "$(tr '\0' ' ' < "$cmdline" 2> /dev/null)" == *"$KIBANA_PROCESS_PATTERN"* ]]; then
+      pid=${cmdline#/proc/}
+      pid=${pid%%/cmdline}
+      echo "Configuration file changed, reloading the settings of Kibana process $pid"
+      kill -HUP "$pid"
+    fi
+  done
+done
+`, path.Join(config.VolumeMountPath, config.SettingsFilename), configReloadInterval, configReloadInterval)
+
+	// configReloaderResources are the default resources of the config reloader sidecar, which only runs a shell loop.
+	configReloaderResources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+	}
+)
+
+// configReloaderContainer returns the sidecar container reloading the settings of Kibana from the given image, run as
+// the same user as Kibana to be allowed to send it signals.
+func configReloaderContainer(kb kbv1.Kibana, image string) corev1.Container {
+	return corev1.Container{
+		Name:         ConfigReloaderContainerName,
+		Image:        image,
+		Command:      []string{"/usr/bin/env", "bash", "-c", configReloaderScript},
+		Env:          []corev1.EnvVar{{Name: "KIBANA_PROCESS_PATTERN", Value: kibanaProcessPattern}},
+		VolumeMounts: []corev1.VolumeMount{config.SecretVolume(kb).VolumeMount()},
+		Resources:    configReloaderResources,
+	}
+}
+
 // readinessProbe is the readiness probe for the Kibana container
 func readinessProbe(useTLS bool) corev1.Probe {
 	scheme := corev1.URISchemeHTTP
@@ -65,7 +128,11 @@ func readinessProbe(useTLS bool) corev1.Probe {
 	}
 }
 
-func NewPodTemplateSpec(kb kbv1.Kibana, keystore *keystore.Resources) corev1.PodTemplateSpec {
+// NewPodTemplateSpec returns the Pod template of the given Kibana. If reloadConfig is true, a sidecar container sharing
+// the process namespace of the Pod sends SIGHUP to Kibana when its configuration file changes, for Kibana to reload
+// its reloadable settings without restart: it is only added when such settings are set, not to change the Pods of
+// the other Kibana instances.
+func NewPodTemplateSpec(kb kbv1.Kibana, keystore *keystore.Resources, reloadConfig bool) corev1.PodTemplateSpec {
 	labels := label.NewLabels(kb.Name)
 	labels[label.KibanaVersionLabelName] = kb.Spec.Version
 	ports := getDefaultContainerPorts(kb)
@@ -73,7 +140,7 @@ func NewPodTemplateSpec(kb kbv1.Kibana, keystore *keystore.Resources) corev1.Pod
 		WithResources(DefaultResources).
 		WithLabels(labels).
 		WithAnnotations(DefaultAnnotations).
-		WithDockerImage(kb.Spec.Image, container.ImageRepository(container.KibanaImage, kb.Spec.Version)).
+		WithDockerImage(kb.Spec.Image, container.ImageRepositoryForNamespace(container.KibanaImage, kb.Spec.Version, kb.Namespace, "")).
 		WithReadinessProbe(readinessProbe(kb.Spec.HTTP.TLS.Enabled())).
 		WithPorts(ports).
 		WithVolumes(volume.KibanaDataVolume.Volume()).
@@ -85,6 +152,14 @@ func NewPodTemplateSpec(kb kbv1.Kibana, keystore *keystore.Resources) corev1.Pod
 			WithInitContainerDefaults()
 	}
 
+	if reloadConfig {
+		builder.WithSidecars(configReloaderContainer(kb, builder.Container.Image))
+		if builder.PodTemplate.Spec.ShareProcessNamespace == nil {
+			shareProcessNamespace := true
+			builder.PodTemplate.Spec.ShareProcessNamespace = &shareProcessNamespace
+		}
+	}
+
 	return builder.PodTemplate
 }
 
@@ -21,10 +21,11 @@ import (
 
 func TestNewPodTemplateSpec(t *testing.T) {
 	tests := []struct {
-		name       string
-		kb         kbv1.Kibana
-		keystore   *keystore.Resources
-		assertions func(pod corev1.PodTemplateSpec)
+		name         string
+		kb           kbv1.Kibana
+		keystore     *keystore.Resources
+		reloadConfig bool
+		assertions   func(pod corev1.PodTemplateSpec)
 	}{
 		{
 			name: "defaults",
@@ -45,6 +46,54 @@ func TestNewPodTemplateSpec(t *testing.T) {
 				assert.Equal(t, container.ImageRepository(container.KibanaImage, "7.1.0"), kibanaContainer.Image)
 				assert.NotNil(t, kibanaContainer.ReadinessProbe)
 				assert.NotEmpty(t, kibanaContainer.Ports)
+				// the entrypoint of the image is kept
+				assert.Empty(t, kibanaContainer.Command)
+				assert.Nil(t, pod.Spec.ShareProcessNamespace)
+			},
+		},
+		{
+			name: "with the config reloader",
+			kb: kbv1.Kibana{
+				ObjectMeta: metav1.ObjectMeta{Name: "kb"},
+				Spec: kbv1.KibanaSpec{
+					Version: "7.1.0",
+				},
+			},
+			reloadConfig: true,
+			assertions: func(pod corev1.PodTemplateSpec) {
+				assert.Len(t, pod.Spec.Containers, 2)
+				assert.True(t, *pod.Spec.ShareProcessNamespace)
+				kibanaContainer := GetKibanaContainer(pod.Spec)
+				assert.Empty(t, kibanaContainer.Command)
+				reloader := pod.Spec.Containers[1]
+				assert.Equal(t, ConfigReloaderContainerName, reloader.Name)
+				assert.Equal(t, kibanaContainer.Image, reloader.Image)
+				assert.Equal(t, []corev1.VolumeMount{{Name: "config", ReadOnly: true, MountPath: "/usr/share/kibana/config"}}, reloader.VolumeMounts)
+				assert.Equal(t, configReloaderResources, reloader.Resources)
+			},
+		},
+		{
+			name: "with the config reloader and a user-provided process namespace setting",
+			kb: kbv1.Kibana{
+				Spec: kbv1.KibanaSpec{
+					Version: "7.1.0",
+					PodTemplate: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						ShareProcessNamespace: new(bool),
+						Containers: []corev1.Container{{
+							Name:      ConfigReloaderContainerName,
+							Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Mi")}},
+						}},
+					}},
+				},
+			},
+			reloadConfig: true,
+			assertions: func(pod corev1.PodTemplateSpec) {
+				assert.False(t, *pod.Spec.ShareProcessNamespace)
+				assert.Len(t, pod.Spec.Containers, 2)
+				reloader := pod.Spec.Containers[0]
+				assert.Equal(t, ConfigReloaderContainerName, reloader.Name)
+				assert.NotEmpty(t, reloader.Command)
+				assert.Equal(t, resource.MustParse("32Mi"), reloader.Resources.Limits[corev1.ResourceMemory])
 			},
 		},
 		{
@@ -212,7 +261,7 @@ func TestNewPodTemplateSpec(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewPodTemplateSpec(tt.kb, tt.keystore)
+			got := NewPodTemplateSpec(tt.kb, tt.keystore, tt.reloadConfig)
 			tt.assertions(got)
 		})
 	}
@@ -22,7 +22,6 @@ import (
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
-	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/kibana/label"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/maps"
@@ -58,6 +57,8 @@ const (
 	name = "kibana-association-controller"
 	// kibanaUserSuffix is used to suffix user and associated secret resources.
 	kibanaUserSuffix = "kibana-user"
+	// kibanaServiceAccountTokenSuffix is used to suffix service account token and associated secret resources.
+	kibanaServiceAccountTokenSuffix = "kibana-token"
 	// ElasticsearchCASecretSuffix is used as suffix for CAPublicCertSecretName.
 	ElasticsearchCASecretSuffix = "kb-es-ca" // nolint
 	// KibanaSystemUserBuiltinRole is the name of the built-in role for the Kibana system user.
@@ -73,7 +74,7 @@ var (
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
 	r := newReconciler(mgr, accessReviewer, params)
-	c, err := common.NewController(mgr, name, r, params)
+	c, err := common.NewController(mgr, name, &kbv1.Kibana{}, r, params)
 	if err != nil {
 		return err
 	}
@@ -112,8 +113,16 @@ func (r *ReconcileAssociation) onDelete(obj types.NamespacedName) error {
 	r.watches.Secrets.RemoveHandlerForKey(esCAWatchName(obj))
 	// Remove watcher on the user Secret in the Elasticsearch namespace
 	r.watches.Secrets.RemoveHandlerForKey(elasticsearchWatchName(obj))
+	// Delete the user password and the token from the external credentials store, if any
+	if err := association.DeleteStoredCredentials(obj, kibanaUserSuffix, kibanaServiceAccountTokenSuffix); err != nil {
+		return err
+	}
 	// Delete user Secret in the Elasticsearch namespace
-	return k8s.DeleteSecretMatching(r.Client, newUserLabelSelector(obj))
+	if err := k8s.DeleteSecretMatching(r.Client, newUserLabelSelector(obj)); err != nil {
+		return err
+	}
+	// Delete service account token Secret in the Elasticsearch namespace, which invalidates the token
+	return k8s.DeleteSecretMatching(r.Client, newServiceAccountTokenLabelSelector(obj))
 }
 
 // Reconcile reads that state of the cluster for an Association object and makes changes based on the state read and what is in
@@ -247,10 +256,11 @@ func (r *ReconcileAssociation) reconcileInternal(ctx context.Context, kibana *kb
 	}
 
 	userSecretKey := association.UserKey(kibana, kibanaUserSuffix)
-	// watch the user secret in the ES namespace
+	tokenSecretKey := association.UserKey(kibana, kibanaServiceAccountTokenSuffix)
+	// watch the user and service account token secrets in the ES namespace
 	if err := r.watches.Secrets.AddHandler(watches.NamedWatch{
 		Name:    elasticsearchWatchName(kibanaKey),
-		Watched: []types.NamespacedName{userSecretKey},
+		Watched: []types.NamespacedName{userSecretKey, tokenSecretKey},
 		Watcher: kibanaKey,
 	}); err != nil {
 		return commonv1.AssociationFailed, err
@@ -272,14 +282,8 @@ func (r *ReconcileAssociation) reconcileInternal(ctx context.Context, kibana *kb
 		return commonv1.AssociationPending, err
 	}
 
-	if err := association.ReconcileEsUser(
-		ctx,
-		r.Client,
-		kibana,
-		associationLabels(kibana),
-		KibanaSystemUserBuiltinRole,
-		kibanaUserSuffix,
-		es); err != nil {
+	authSecret, useServiceAccount, err := r.reconcileCredentials(ctx, kibana, es)
+	if err != nil {
 		return commonv1.AssociationPending, err
 	}
 
@@ -288,20 +292,73 @@ func (r *ReconcileAssociation) reconcileInternal(ctx context.Context, kibana *kb
 		return commonv1.AssociationPending, err
 	}
 
+	url, hosts, err := elasticsearchHosts(r.Client, *kibana, es)
+	if err != nil {
+		k8s.EmitErrorEvent(r.recorder, err, kibana, events.EventAssociationError, "Failed to select Elasticsearch hosts: %v", err)
+		return commonv1.AssociationPending, err
+	}
+
 	// construct the expected association configuration
-	authSecret := association.ClearTextSecretKeySelector(kibana, kibanaUserSuffix)
 	expectedESAssoc := &commonv1.AssociationConf{
-		AuthSecretName: authSecret.Name,
-		AuthSecretKey:  authSecret.Key,
-		CACertProvided: caSecret.CACertProvided,
-		CASecretName:   caSecret.Name,
-		URL:            services.ExternalServiceURL(es),
+		AuthSecretName:   authSecret.Name,
+		AuthSecretKey:    authSecret.Key,
+		CACertProvided:   caSecret.CACertProvided,
+		CASecretName:     caSecret.Name,
+		URL:              url,
+		Hosts:            hosts,
+		IsServiceAccount: useServiceAccount,
 	}
 
 	// update the association configuration if necessary
 	return r.updateAssociationConf(ctx, expectedESAssoc, kibana)
 }
 
+// reconcileCredentials reconciles the credentials used by Kibana to authenticate against Elasticsearch: a token of the
+// kibana service account if supported by both Kibana and Elasticsearch, a user of the file realm otherwise.
+// Credentials that are not used anymore are deleted. It returns the secret holding the credentials, and whether it is
+// a service account token.
+func (r *ReconcileAssociation) reconcileCredentials(ctx context.Context, kibana *kbv1.Kibana, es esv1.Elasticsearch) (*corev1.SecretKeySelector, bool, error) {
+	useServiceAccount, err := association.SupportsServiceAccountToken(r.Client, kibana.Spec.Version, es)
+	if err != nil {
+		return nil, false, err
+	}
+	// once established, keep using the service account token even if older Elasticsearch nodes are temporarily
+	// added to the cluster, to not rotate the credentials back and forth
+	useServiceAccount = useServiceAccount || kibana.AssociationConf().GetIsServiceAccount()
+
+	if !useServiceAccount {
+		if err := association.ReconcileEsUser(
+			ctx,
+			r.Client,
+			kibana,
+			associationLabels(kibana),
+			KibanaSystemUserBuiltinRole,
+			kibanaUserSuffix,
+			es); err != nil {
+			return nil, false, err
+		}
+		return association.ClearTextSecretKeySelector(kibana, kibanaUserSuffix), false, nil
+	}
+
+	if err := association.ReconcileServiceAccountToken(
+		ctx,
+		r.Client,
+		kibana,
+		associationLabels(kibana),
+		association.KibanaServiceAccount,
+		kibanaServiceAccountTokenSuffix,
+		es); err != nil {
+		return nil, false, err
+	}
+	// the user is not used anymore once Kibana has been configured with the token
+	if kibana.AssociationConf().GetIsServiceAccount() {
+		if err := association.DeleteEsUser(r.Client, kibana, kibanaUserSuffix); err != nil {
+			return nil, false, err
+		}
+	}
+	return association.ServiceAccountTokenSecretKeySelector(kibana, kibanaServiceAccountTokenSuffix), true, nil
+}
+
 func (r *ReconcileAssociation) updateAssociationConf(ctx context.Context, expectedESAssoc *commonv1.AssociationConf, kibana *kbv1.Kibana) (commonv1.AssociationStatus, error) {
 	span, _ := apm.StartSpan(ctx, "update_assoc_conf", tracing.SpanTypeApp)
 	defer span.End()
@@ -323,10 +380,16 @@ func (r *ReconcileAssociation) updateAssociationConf(ctx context.Context, expect
 // Unbind removes the association resources
 func (r *ReconcileAssociation) Unbind(kibana commonv1.Associated) error {
 	kibanaKey := k8s.ExtractNamespacedName(kibana)
-	// Ensure that user in Elasticsearch is deleted to prevent illegitimate access
+	// Ensure that user and service account token in Elasticsearch are deleted to prevent illegitimate access
+	if err := association.DeleteStoredCredentials(kibanaKey, kibanaUserSuffix, kibanaServiceAccountTokenSuffix); err != nil {
+		return err
+	}
 	if err := k8s.DeleteSecretMatching(r.Client, newUserLabelSelector(kibanaKey)); err != nil {
 		return err
 	}
+	if err := k8s.DeleteSecretMatching(r.Client, newServiceAccountTokenLabelSelector(kibanaKey)); err != nil {
+		return err
+	}
 	// Also remove the association configuration
 	return association.RemoveAssociationConf(r.Client, kibana)
 }
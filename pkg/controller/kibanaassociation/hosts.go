@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibanaassociation
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/network"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/nodespec"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/sset"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/stringsutil"
+)
+
+// elasticsearchHosts returns the URL of the Elasticsearch service Kibana connects to, and the URLs of the individual
+// nodes it connects to instead if the Kibana spec selects NodeSets.
+func elasticsearchHosts(c k8s.Client, kibana kbv1.Kibana, es esv1.Elasticsearch) (string, []string, error) {
+	selector := kibana.Spec.ElasticsearchHosts
+	if selector == nil {
+		return services.ExternalServiceURL(es), nil, nil
+	}
+	if selector.ServiceName != "" && len(selector.NodeSets) > 0 {
+		return "", nil, errors.New("elasticsearchHosts.serviceName and elasticsearchHosts.nodeSets are mutually exclusive")
+	}
+	if selector.ServiceName != "" {
+		url, err := serviceURL(c, es, selector.ServiceName)
+		return url, nil, err
+	}
+	hosts, err := nodeSetsURLs(es, selector.NodeSets)
+	return services.ExternalServiceURL(es), hosts, err
+}
+
+// serviceURL returns the URL of the given Service in the namespace of the Elasticsearch cluster, on its first port.
+func serviceURL(c k8s.Client, es esv1.Elasticsearch, name string) (string, error) {
+	var svc corev1.Service
+	if err := c.Get(types.NamespacedName{Namespace: es.Namespace, Name: name}, &svc); err != nil {
+		return "", errors.Wrapf(err, "while getting Elasticsearch service %s/%s", es.Namespace, name)
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return "", errors.Errorf("Elasticsearch service %s/%s exposes no port", es.Namespace, name)
+	}
+	return stringsutil.Concat(es.Spec.EffectiveHTTP().Protocol(), "://", k8s.GetServiceDNSName(svc)[0], ":",
+		strconv.Itoa(int(svc.Spec.Ports[0].Port))), nil
+}
+
+// nodeSetsURLs returns the URLs of the nodes of the given NodeSets, reached through the headless service of their
+// StatefulSet. The URLs follow the count of the NodeSets in the Elasticsearch spec, and include the nodes of all the
+// zones of the NodeSets spread across zones.
+func nodeSetsURLs(es esv1.Elasticsearch, nodeSets []string) ([]string, error) {
+	var urls []string
+	for _, name := range nodeSets {
+		var nodeSet *esv1.NodeSet
+		for i := range es.Spec.NodeSets {
+			if es.Spec.NodeSets[i].Name == name {
+				nodeSet = &es.Spec.NodeSets[i]
+			}
+		}
+		if nodeSet == nil {
+			return nil, errors.Errorf("NodeSet %s not found in Elasticsearch %s/%s", name, es.Namespace, es.Name)
+		}
+		for _, expandedName := range nodeSet.ExpandedNames() {
+			ssetName := esv1.StatefulSet(es.Name, expandedName)
+			for ordinal := int32(0); ordinal < nodeSet.Count; ordinal++ {
+				urls = append(urls, stringsutil.Concat(es.Spec.EffectiveHTTP().Protocol(), "://", sset.PodName(ssetName, ordinal),
+					".", nodespec.HeadlessServiceName(ssetName), ".", es.Namespace, ".svc:", strconv.Itoa(network.HTTPPort)))
+			}
+		}
+	}
+	return urls, nil
+}
@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibanaassociation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+func Test_elasticsearchHosts(t *testing.T) {
+	es := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{NodeSets: []esv1.NodeSet{
+			{Name: "master", Count: 3},
+			{Name: "coord", Count: 2},
+			{Name: "zoned", Count: 1, ZoneSpread: &esv1.ZoneSpread{Zones: []string{"a", "b"}}},
+		}},
+	}
+	coordService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es-coordinating"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 9201}}},
+	}
+	tests := []struct {
+		name      string
+		selector  *kbv1.ElasticsearchHostsSelector
+		objects   []runtime.Object
+		wantURL   string
+		wantHosts []string
+		wantErr   bool
+	}{
+		{
+			name:    "default to the HTTP service",
+			wantURL: "https://es-es-http.ns.svc:9200",
+		},
+		{
+			name:     "custom service",
+			selector: &kbv1.ElasticsearchHostsSelector{ServiceName: "es-coordinating"},
+			objects:  []runtime.Object{coordService},
+			wantURL:  "https://es-coordinating.ns.svc:9201",
+		},
+		{
+			name:     "custom service not found",
+			selector: &kbv1.ElasticsearchHostsSelector{ServiceName: "es-coordinating"},
+			wantErr:  true,
+		},
+		{
+			name:     "nodes of a NodeSet",
+			selector: &kbv1.ElasticsearchHostsSelector{NodeSets: []string{"coord"}},
+			wantURL:  "https://es-es-http.ns.svc:9200",
+			wantHosts: []string{
+				"https://es-es-coord-0.es-es-coord.ns.svc:9200",
+				"https://es-es-coord-1.es-es-coord.ns.svc:9200",
+			},
+		},
+		{
+			name:     "nodes of a NodeSet spread across zones",
+			selector: &kbv1.ElasticsearchHostsSelector{NodeSets: []string{"zoned"}},
+			wantURL:  "https://es-es-http.ns.svc:9200",
+			wantHosts: []string{
+				"https://es-es-zoned-a-0.es-es-zoned-a.ns.svc:9200",
+				"https://es-es-zoned-b-0.es-es-zoned-b.ns.svc:9200",
+			},
+		},
+		{
+			name:     "unknown NodeSet",
+			selector: &kbv1.ElasticsearchHostsSelector{NodeSets: []string{"hot"}},
+			wantErr:  true,
+		},
+		{
+			name:     "both a service and NodeSets",
+			selector: &kbv1.ElasticsearchHostsSelector{ServiceName: "es-coordinating", NodeSets: []string{"coord"}},
+			objects:  []runtime.Object{coordService},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kibana := kbv1.Kibana{Spec: kbv1.KibanaSpec{ElasticsearchHosts: tt.selector}}
+			url, hosts, err := elasticsearchHosts(k8s.WrappedFakeClient(tt.objects...), kibana, es)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantURL, url)
+			require.Equal(t, tt.wantHosts, hosts)
+		})
+	}
+}
@@ -36,3 +36,14 @@ func newUserLabelSelector(
 			common.TypeLabelName:      esuser.AssociatedUserType,
 		})
 }
+
+func newServiceAccountTokenLabelSelector(
+	namespacedName types.NamespacedName,
+) client.MatchingLabels {
+	return client.MatchingLabels(
+		map[string]string{
+			AssociationLabelName:      namespacedName.Name,
+			AssociationLabelNamespace: namespacedName.Namespace,
+			common.TypeLabelName:      esuser.AssociatedServiceAccountTokenType,
+		})
+}
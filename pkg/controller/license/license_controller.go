@@ -60,7 +60,7 @@ func (r *ReconcileLicenses) Reconcile(request reconcile.Request) (reconcile.Resu
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, p operator.Parameters) error {
 	r := newReconciler(mgr, p)
-	c, err := common.NewController(mgr, name, r, p)
+	c, err := common.NewController(mgr, name, &esv1.Elasticsearch{}, r, p)
 	if err != nil {
 		return err
 	}
@@ -44,7 +44,7 @@ func TestReconcile(t *testing.T) {
 			Client:  k8s.WrapClient(mgr.GetClient()),
 			checker: license.MockChecker{},
 		}
-		c, err := common.NewController(mgr, name, r, p)
+		c, err := common.NewController(mgr, name, &esv1.Elasticsearch{}, r, p)
 		if err != nil {
 			return err
 		}
@@ -201,7 +201,7 @@ func addWatches(c controller.Controller) error {
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, params operator.Parameters) error {
 	r := newReconciler(mgr, params)
-	c, err := common.NewController(mgr, name, r, params)
+	c, err := common.NewController(mgr, name, &corev1.Secret{}, r, params)
 	if err != nil {
 		return err
 	}
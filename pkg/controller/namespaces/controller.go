@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package namespaces
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/namespaces"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	name = "namespaces-controller"
+	// NamespacesKey is the key of the ConfigMap data listing the managed namespaces, comma-separated.
+	NamespacesKey = "namespaces"
+)
+
+var log = logf.Log.WithName(name)
+
+var _ reconcile.Reconciler = &ReconcileNamespaces{}
+
+// ReconcileNamespaces updates the namespaces managed by the operator from the content of a ConfigMap.
+type ReconcileNamespaces struct {
+	k8s.Client
+	cache     *namespaces.DynamicCache
+	configMap types.NamespacedName
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Add creates a new namespaces controller updating the namespaces of the given cache from the given ConfigMap.
+func Add(mgr manager.Manager, cache *namespaces.DynamicCache, configMap types.NamespacedName, params operator.Parameters) error {
+	r := &ReconcileNamespaces{
+		Client:    k8s.WrapClient(mgr.GetClient()),
+		cache:     cache,
+		configMap: configMap,
+	}
+	c, err := common.NewController(mgr, name, &corev1.ConfigMap{}, r, params)
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &watches.NamedWatch{
+		Name:    "managed-namespaces",
+		Watched: []types.NamespacedName{configMap},
+		Watcher: configMap,
+	})
+}
+
+// Reconcile sets the managed namespaces to the ones listed in the ConfigMap.
+func (r *ReconcileNamespaces) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "configmap_name", &r.iteration)()
+	var configMap corev1.ConfigMap
+	err := r.Get(r.configMap, &configMap)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	// only the static namespaces are managed if the ConfigMap does not exist
+	if err := r.cache.SetNamespaces(parseNamespaces(configMap.Data[NamespacesKey])); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// parseNamespaces returns the namespaces of a comma or whitespace separated list.
+func parseNamespaces(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package operatorconfig
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+)
+
+// LogVerbosityKey is the ConfigMap key of the log verbosity, named after the flag of the logger package.
+const LogVerbosityKey = "log-verbosity"
+
+// apply sets the value of a ConfigMap key in the settings.
+type apply func(settings *operator.Settings, value string) error
+
+func durationSetter(set func(settings *operator.Settings, value time.Duration)) apply {
+	return func(settings *operator.Settings, value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if duration <= 0 {
+			return fmt.Errorf("duration must be positive")
+		}
+		set(settings, duration)
+		return nil
+	}
+}
+
+// reloadable are the flags that can be set in the ConfigMap, without restarting the operator.
+var reloadable = map[string]apply{
+	operator.CACertValidityFlag: durationSetter(func(s *operator.Settings, d time.Duration) { s.CACertRotation.Validity = d }),
+	operator.CACertRotateBeforeFlag: durationSetter(func(s *operator.Settings, d time.Duration) {
+		s.CACertRotation.RotateBefore = d
+	}),
+	operator.CertValidityFlag:                     durationSetter(func(s *operator.Settings, d time.Duration) { s.CertRotation.Validity = d }),
+	operator.CertRotateBeforeFlag:                 durationSetter(func(s *operator.Settings, d time.Duration) { s.CertRotation.RotateBefore = d }),
+	operator.ElasticsearchObservationIntervalFlag: durationSetter(func(s *operator.Settings, d time.Duration) { s.ObservationInterval = d }),
+	LogVerbosityKey: func(settings *operator.Settings, value string) error {
+		verbosity, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		settings.LogVerbosity = verbosity
+		return nil
+	},
+}
+
+// Parse returns the given settings, overridden by the values of the ConfigMap data. Keys of flags that cannot be
+// updated at runtime are ignored.
+func Parse(data map[string]string, defaults operator.Settings) (operator.Settings, error) {
+	settings := defaults
+	for key, value := range data {
+		set, exists := reloadable[key]
+		if !exists {
+			log.Info("Ignoring setting that cannot be updated at runtime", "key", key)
+			continue
+		}
+		if err := set(&settings, value); err != nil {
+			return defaults, pkgerrors.Wrapf(err, "invalid value for %s", key)
+		}
+	}
+	if settings.CACertRotation.RotateBefore > settings.CACertRotation.Validity {
+		return defaults, fmt.Errorf("%s must be larger than %s", operator.CACertValidityFlag, operator.CACertRotateBeforeFlag)
+	}
+	if settings.CertRotation.RotateBefore > settings.CertRotation.Validity {
+		return defaults, fmt.Errorf("%s must be larger than %s", operator.CertValidityFlag, operator.CertRotateBeforeFlag)
+	}
+	return settings, nil
+}
+
+// Load returns the settings from the given ConfigMap, or the defaults if it does not exist.
+func Load(c client.Reader, configMap types.NamespacedName, defaults operator.Settings) (operator.Settings, error) {
+	var cm corev1.ConfigMap
+	if err := c.Get(context.Background(), configMap, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return defaults, nil
+		}
+		return defaults, err
+	}
+	return Parse(cm.Data, defaults)
+}
@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package operatorconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+)
+
+var defaultSettings = operator.Settings{
+	CACertRotation:      certificates.RotationParams{Validity: 8760 * time.Hour, RotateBefore: 24 * time.Hour},
+	CertRotation:        certificates.RotationParams{Validity: 8760 * time.Hour, RotateBefore: 24 * time.Hour},
+	ObservationInterval: 10 * time.Second,
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    operator.Settings
+		wantErr bool
+	}{
+		{
+			name: "no data",
+			data: nil,
+			want: defaultSettings,
+		},
+		{
+			name: "all settings",
+			data: map[string]string{
+				"ca-cert-validity":                   "100h",
+				"ca-cert-rotate-before":              "10h",
+				"cert-validity":                      "50h",
+				"cert-rotate-before":                 "5h",
+				"elasticsearch-observation-interval": "30s",
+				"log-verbosity":                      "1",
+			},
+			want: operator.Settings{
+				CACertRotation:      certificates.RotationParams{Validity: 100 * time.Hour, RotateBefore: 10 * time.Hour},
+				CertRotation:        certificates.RotationParams{Validity: 50 * time.Hour, RotateBefore: 5 * time.Hour},
+				ObservationInterval: 30 * time.Second,
+				LogVerbosity:        1,
+			},
+		},
+		{
+			name: "settings that cannot be updated at runtime are ignored",
+			data: map[string]string{"metrics-port": "8080", "elasticsearch-observation-interval": "1m"},
+			want: operator.Settings{
+				CACertRotation:      defaultSettings.CACertRotation,
+				CertRotation:        defaultSettings.CertRotation,
+				ObservationInterval: 1 * time.Minute,
+			},
+		},
+		{
+			name:    "invalid duration",
+			data:    map[string]string{"cert-validity": "forever"},
+			want:    defaultSettings,
+			wantErr: true,
+		},
+		{
+			name:    "negative duration",
+			data:    map[string]string{"elasticsearch-observation-interval": "-1s"},
+			want:    defaultSettings,
+			wantErr: true,
+		},
+		{
+			name:    "rotation before the certificate validity",
+			data:    map[string]string{"cert-validity": "1h"},
+			want:    defaultSettings,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.data, defaultSettings)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcileOperatorConfig_Reconcile(t *testing.T) {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "elastic-system", Name: "config"},
+		Data:       map[string]string{"elasticsearch-observation-interval": "1m"},
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, &configMap)
+	config := operator.NewConfig(defaultSettings)
+	var updates []operator.Settings
+	config.OnChange(func(settings operator.Settings) {
+		updates = append(updates, settings)
+	})
+	r := &ReconcileOperatorConfig{
+		client:    c,
+		config:    config,
+		defaults:  defaultSettings,
+		configMap: types.NamespacedName{Namespace: "elastic-system", Name: "config"},
+	}
+
+	// settings are updated from the ConfigMap
+	_, err := r.Reconcile(reconcile.Request{})
+	require.NoError(t, err)
+	require.Equal(t, 1*time.Minute, config.Get().ObservationInterval)
+	require.Len(t, updates, 1)
+
+	// invalid settings are ignored
+	configMap.Data["elasticsearch-observation-interval"] = "often"
+	require.NoError(t, c.Update(context.Background(), &configMap))
+	_, err = r.Reconcile(reconcile.Request{})
+	require.NoError(t, err)
+	require.Equal(t, 1*time.Minute, config.Get().ObservationInterval)
+	require.Len(t, updates, 1)
+
+	// defaults are restored when the ConfigMap is deleted
+	require.NoError(t, c.Delete(context.Background(), &configMap))
+	_, err = r.Reconcile(reconcile.Request{})
+	require.NoError(t, err)
+	require.Equal(t, defaultSettings, config.Get())
+	require.Len(t, updates, 2)
+}
@@ -0,0 +1,70 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package operatorconfig
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+)
+
+const name = "operatorconfig-controller"
+
+var log = logf.Log.WithName(name)
+
+var _ reconcile.Reconciler = &ReconcileOperatorConfig{}
+
+// ReconcileOperatorConfig updates the operator settings from the content of a ConfigMap.
+type ReconcileOperatorConfig struct {
+	client    client.Client
+	config    *operator.Config
+	defaults  operator.Settings
+	configMap types.NamespacedName
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Add creates a new operator configuration controller updating the given config from the given ConfigMap. Settings
+// not specified in the ConfigMap are set to the given defaults.
+func Add(mgr manager.Manager, config *operator.Config, defaults operator.Settings, configMap types.NamespacedName, params operator.Parameters) error {
+	r := &ReconcileOperatorConfig{
+		client:    mgr.GetClient(),
+		config:    config,
+		defaults:  defaults,
+		configMap: configMap,
+	}
+	c, err := common.NewController(mgr, name, &corev1.ConfigMap{}, r, params)
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &watches.NamedWatch{
+		Name:    "operator-config",
+		Watched: []types.NamespacedName{configMap},
+		Watcher: configMap,
+	})
+}
+
+// Reconcile updates the operator settings. Invalid settings are reported and ignored until the ConfigMap is fixed.
+func (r *ReconcileOperatorConfig) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "configmap_name", &r.iteration)()
+	settings, err := Load(r.client, r.configMap, r.defaults)
+	if err != nil {
+		log.Error(err, "Invalid operator configuration, keeping the current settings", "namespace", r.configMap.Namespace, "configmap_name", r.configMap.Name)
+		return reconcile.Result{}, nil
+	}
+	if settings != r.config.Get() {
+		log.Info("Updating operator settings", "settings", settings)
+		r.config.Set(settings)
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,163 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package reindexjob
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
+)
+
+// ReindexJob controller
+//
+// This controller copies documents between the indices of an Elasticsearch cluster, declared by a ReindexJob:
+// - once the cluster is ready, a reindex task is started in the background with the slicing and throttling of the job
+// - the progress of the task is reported in the status of the job until it completes
+// - a failed task is started again, up to the maximum number of retries of the job
+// The operation runs once: the job must be deleted and created again to copy the documents again.
+
+const name = "reindexjob-controller"
+
+// requeueInterval is the interval at which the readiness of the cluster and the progress of the reindex task are
+// checked.
+const requeueInterval = 10 * time.Second
+
+var log = logf.Log.WithName(name)
+
+// Add creates a new ReindexJob Controller and adds it to the Manager with default RBAC. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
+	r := newReconciler(mgr, accessReviewer, params)
+	c, err := common.NewController(mgr, name, &esv1.ReindexJob{}, r, params)
+	if err != nil {
+		return err
+	}
+	return addWatches(c)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) *ReconcileReindexJob {
+	return &ReconcileReindexJob{
+		Client:         k8s.WrapClient(mgr.GetClient()),
+		accessReviewer: accessReviewer,
+		recorder:       mgr.GetEventRecorderFor(name),
+		esClient:       remotecluster.NewESClient,
+		Parameters:     params,
+	}
+}
+
+func addWatches(c controller.Controller) error {
+	// watch reindex jobs, the readiness of the referenced clusters is polled until the task starts
+	return c.Watch(&source.Kind{Type: &esv1.ReindexJob{}}, &handler.EnqueueRequestForObject{})
+}
+
+// esClientProvider returns a client for the given Elasticsearch cluster.
+type esClientProvider func(c k8s.Client, dialer net.Dialer, es esv1.Elasticsearch) (esclient.Client, error)
+
+var _ reconcile.Reconciler = &ReconcileReindexJob{}
+
+// ReconcileReindexJob reconciles a ReindexJob object
+type ReconcileReindexJob struct {
+	k8s.Client
+	accessReviewer rbac.AccessReviewer
+	recorder       record.EventRecorder
+	esClient       esClientProvider
+	operator.Parameters
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Reconcile moves the reindex operation forward, and reports its progress in the status of the ReindexJob.
+func (r *ReconcileReindexJob) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "reindex_job_name", &r.iteration)()
+	tx, ctx := tracing.NewTransaction(r.Tracer, request.NamespacedName, "reindexjob")
+	defer tracing.EndTransaction(tx)
+
+	var job esv1.ReindexJob
+	if err := r.Get(request.NamespacedName, &job); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+
+	if !job.DeletionTimestamp.IsZero() || job.Status.IsDone() {
+		return reconcile.Result{}, nil
+	}
+
+	if common.IsPaused(job.ObjectMeta) {
+		log.Info("Object is paused. Skipping reconciliation", "namespace", job.Namespace, "reindex_job_name", job.Name)
+		return common.PauseRequeue, nil
+	}
+
+	results := reconciler.NewResult(ctx)
+	status, err := r.reconcileJob(ctx, job)
+	if err != nil {
+		results.WithError(err)
+		k8s.EmitErrorEvent(r.recorder, err, &job, events.EventReconciliationError, "Reconciliation error: %v", err)
+	}
+	if status.Phase != job.Status.Phase {
+		r.recorder.Eventf(&job, corev1.EventTypeNormal, events.EventReasonStateChange, "Reindex job phase changed to %s", status.Phase)
+	}
+	if !reflect.DeepEqual(status, job.Status) {
+		if err := r.updateStatus(job, status); err != nil {
+			if apierrors.IsConflict(err) {
+				log.V(1).Info("Conflict while updating status", "namespace", job.Namespace, "reindex_job_name", job.Name)
+				return reconcile.Result{Requeue: true}, nil
+			}
+			results.WithError(err)
+		}
+	}
+	if !status.IsDone() {
+		results.WithResult(reconcile.Result{RequeueAfter: requeueInterval})
+	}
+	return results.Aggregate()
+}
+
+// updateStatus updates the status of the job. Conflicts are retried on top of the latest version of the job, as the
+// status may hold the ID of a reindex task just started, which would otherwise be started again.
+func (r *ReconcileReindexJob) updateStatus(job esv1.ReindexJob, status esv1.ReindexJobStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		job.Status = status
+		err := common.UpdateStatus(r.Client, &job)
+		if apierrors.IsConflict(err) {
+			if getErr := r.getLatest(&job); getErr != nil {
+				return getErr
+			}
+		}
+		return err
+	})
+}
+
+// getLatest reads the given job from the API server, bypassing the cache which may not hold its latest version yet.
+func (r *ReconcileReindexJob) getLatest(job *esv1.ReindexJob) error {
+	if r.APIReader == nil {
+		return r.Get(k8s.ExtractNamespacedName(job), job)
+	}
+	return r.APIReader.Get(context.Background(), k8s.ExtractNamespacedName(job), job)
+}
@@ -0,0 +1,281 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package reindexjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	controllerscheme "github.com/elastic/cloud-on-k8s/pkg/controller/common/scheme"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+)
+
+// fakeCluster serves the reindex and task management APIs of an Elasticsearch cluster.
+type fakeCluster struct {
+	// requests are the query strings and bodies of the reindex requests
+	requests []string
+	// tasks are the responses of the task management API, by task identifier
+	tasks map[string]string
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{tasks: map[string]string{}}
+}
+
+func (f *fakeCluster) roundTrip(req *http.Request) *http.Response {
+	switch {
+	case req.Method == http.MethodPost && req.URL.Path == "/_reindex":
+		body, _ := ioutil.ReadAll(req.Body)
+		f.requests = append(f.requests, req.URL.RawQuery+" "+string(body))
+		taskID := fmt.Sprintf("node:%d", len(f.requests))
+		f.tasks[taskID] = `{"completed": false, "task": {"status": {"total": 10}}}`
+		return esclient.NewMockResponse(200, req, fmt.Sprintf(`{"task": %q}`, taskID))
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/_tasks/"):
+		task, exists := f.tasks[strings.TrimPrefix(req.URL.Path, "/_tasks/")]
+		if !exists {
+			return esclient.NewMockResponse(404, req, "{}")
+		}
+		return esclient.NewMockResponse(200, req, task)
+	}
+	return esclient.NewMockResponse(400, req, "{}")
+}
+
+type fakeAccessReviewer struct {
+	allowed bool
+}
+
+func (f fakeAccessReviewer) AccessAllowed(_ string, _ string, _ runtime.Object) (bool, error) {
+	return f.allowed, nil
+}
+
+func newTestReconciler(cluster *fakeCluster, allowed bool, objs ...runtime.Object) *ReconcileReindexJob {
+	return &ReconcileReindexJob{
+		Client:         k8s.WrappedFakeClient(objs...),
+		accessReviewer: fakeAccessReviewer{allowed: allowed},
+		recorder:       record.NewFakeRecorder(100),
+		esClient: func(_ k8s.Client, _ net.Dialer, _ esv1.Elasticsearch) (esclient.Client, error) {
+			return esclient.NewMockClient(version.MustParse("7.6.0"), cluster.roundTrip), nil
+		},
+	}
+}
+
+func newJob(maxRetries int) *esv1.ReindexJob {
+	return &esv1.ReindexJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "migrate-logs"},
+		Spec: esv1.ReindexJobSpec{
+			ElasticsearchRef: commonv1.ObjectSelector{Name: "es"},
+			Source: esv1.ReindexSource{
+				Indices: []string{"logs-v1"},
+				Query:   &commonv1.Config{Data: map[string]interface{}{"term": map[string]interface{}{"level": "error"}}},
+			},
+			Destination:       esv1.ReindexDestination{Index: "logs-v2", Pipeline: "parse"},
+			Slices:            4,
+			RequestsPerSecond: 500,
+			Conflicts:         "proceed",
+			MaxRetries:        &maxRetries,
+		},
+	}
+}
+
+func TestReconcileReindexJob(t *testing.T) {
+	controllerscheme.SetupScheme()
+	es := esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"}}
+	job := newJob(1)
+	cluster := newFakeCluster()
+	r := newTestReconciler(cluster, true, &es, job)
+
+	jobRef := k8s.ExtractNamespacedName(job)
+	reconcileJob := func() esv1.ReindexJobStatus {
+		res, err := r.Reconcile(reconcile.Request{NamespacedName: jobRef})
+		require.NoError(t, err)
+		var updated esv1.ReindexJob
+		require.NoError(t, r.Get(jobRef, &updated))
+		if !updated.Status.IsDone() {
+			require.Equal(t, requeueInterval, res.RequeueAfter)
+		}
+		return updated.Status
+	}
+
+	// the task starts once the cluster is ready
+	status := reconcileJob()
+	require.Equal(t, esv1.ReindexPendingPhase, status.Phase)
+	require.Equal(t, "Waiting for Elasticsearch es to be ready", status.Message)
+	require.Empty(t, cluster.requests)
+	es.Status.Phase = esv1.ElasticsearchReadyPhase
+	require.NoError(t, r.Update(&es))
+	status = reconcileJob()
+	require.Equal(t, esv1.ReindexRunningPhase, status.Phase)
+	require.Equal(t, "node:1", status.TaskID)
+	require.Equal(t, 1, status.Attempts)
+	require.NotNil(t, status.StartedAt)
+	require.Equal(t, []string{
+		"wait_for_completion=false&slices=4&requests_per_second=500 " +
+			`{"source":{"index":["logs-v1"],"query":{"term":{"level":"error"}}},"dest":{"index":"logs-v2","pipeline":"parse"},"conflicts":"proceed"}`,
+	}, cluster.requests)
+
+	// the progress of the task is reported
+	cluster.tasks["node:1"] = `{"completed": false, "task": {"status": {"total": 10, "created": 4, "version_conflicts": 1}}}`
+	status = reconcileJob()
+	require.Equal(t, esv1.ReindexRunningPhase, status.Phase)
+	require.Equal(t, int64(10), status.Total)
+	require.Equal(t, int64(4), status.Created)
+	require.Equal(t, int64(1), status.VersionConflicts)
+
+	// a failed task is started again
+	cluster.tasks["node:1"] = `{"completed": true, "task": {"status": {"total": 10, "created": 4}}, "error": {"type": "es_rejected_execution_exception", "reason": "rejected"}}`
+	status = reconcileJob()
+	require.Equal(t, esv1.ReindexPendingPhase, status.Phase)
+	require.Equal(t, "Attempt 1 failed, retrying: es_rejected_execution_exception: rejected", status.Message)
+	status = reconcileJob()
+	require.Equal(t, esv1.ReindexRunningPhase, status.Phase)
+	require.Equal(t, "node:2", status.TaskID)
+	require.Equal(t, 2, status.Attempts)
+	require.Equal(t, int64(0), status.Created)
+
+	// the job completes with the task
+	cluster.tasks["node:2"] = `{"completed": true, "task": {"status": {"total": 10, "created": 6, "updated": 4}}, "response": {"failures": []}}`
+	status = reconcileJob()
+	require.Equal(t, esv1.ReindexCompletedPhase, status.Phase)
+	require.Equal(t, "6 documents created, 4 updated", status.Message)
+	require.NotNil(t, status.CompletedAt)
+
+	// nothing happens once completed
+	delete(cluster.tasks, "node:2")
+	require.Equal(t, status, reconcileJob())
+	require.Len(t, cluster.requests, 2)
+}
+
+func TestReconcileReindexJob_failures(t *testing.T) {
+	controllerscheme.SetupScheme()
+	readyES := esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "es"},
+		Status:     esv1.ElasticsearchStatus{Phase: esv1.ElasticsearchReadyPhase},
+	}
+	running := func(job *esv1.ReindexJob, attempts int) *esv1.ReindexJob {
+		job.Status = esv1.ReindexJobStatus{Phase: esv1.ReindexRunningPhase, TaskID: "node:1", Attempts: attempts}
+		return job
+	}
+	otherNamespace := newJob(3)
+	otherNamespace.Spec.ElasticsearchRef.Namespace = "other"
+	tests := []struct {
+		name   string
+		job    *esv1.ReindexJob
+		objs   []runtime.Object
+		tasks  map[string]string
+		denied bool
+		want   esv1.ReindexJobStatus
+	}{
+		{
+			name: "cluster not found",
+			job:  newJob(3),
+			want: esv1.ReindexJobStatus{Phase: esv1.ReindexPendingPhase, Message: "Elasticsearch ns/es not found"},
+		},
+		{
+			name:   "access to the cluster denied",
+			job:    otherNamespace,
+			objs:   []runtime.Object{&esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "es"}}},
+			denied: true,
+			want:   esv1.ReindexJobStatus{Phase: esv1.ReindexPendingPhase, Message: "Access to Elasticsearch other/es is not allowed"},
+		},
+		{
+			name: "task lost",
+			job:  running(newJob(3), 1),
+			objs: []runtime.Object{&readyES},
+			want: esv1.ReindexJobStatus{Phase: esv1.ReindexPendingPhase, Attempts: 1, Message: "Attempt 1 failed, retrying: task node:1 not found"},
+		},
+		{
+			name: "no retry left",
+			job:  running(newJob(1), 2),
+			objs: []runtime.Object{&readyES},
+			tasks: map[string]string{"node:1": `{"completed": true, "task": {"status": {"total": 2, "created": 1}},
+				"response": {"failures": [{"index": "logs-v2", "cause": {"type": "mapper_parsing_exception", "reason": "failed to parse"}}]}}`},
+			want: esv1.ReindexJobStatus{
+				Phase:    esv1.ReindexFailedPhase,
+				TaskID:   "node:1",
+				Attempts: 2,
+				Total:    2,
+				Created:  1,
+				Message:  "Attempt 2 failed: 1 documents failed, first failure in index logs-v2: mapper_parsing_exception: failed to parse",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newFakeCluster()
+			for id, task := range tt.tasks {
+				cluster.tasks[id] = task
+			}
+			r := newTestReconciler(cluster, !tt.denied, append(tt.objs, tt.job)...)
+			_, err := r.Reconcile(reconcile.Request{NamespacedName: k8s.ExtractNamespacedName(tt.job)})
+			require.NoError(t, err)
+			var updated esv1.ReindexJob
+			require.NoError(t, r.Get(k8s.ExtractNamespacedName(tt.job), &updated))
+			require.Equal(t, tt.want, updated.Status)
+			require.Empty(t, cluster.requests)
+		})
+	}
+}
+
+// conflictingClient fails the first status update with a conflict.
+type conflictingClient struct {
+	client.Client
+	conflicts *int
+}
+
+func (c conflictingClient) Status() client.StatusWriter {
+	return conflictingStatusWriter{StatusWriter: c.Client.Status(), conflicts: c.conflicts}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	conflicts *int
+}
+
+func (w conflictingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if *w.conflicts == 0 {
+		*w.conflicts++
+		return apierrors.NewConflict(schema.GroupResource{Resource: "reindexjobs"}, "migrate-logs", errors.New("conflict"))
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestReconcileReindexJob_updateStatusConflict(t *testing.T) {
+	controllerscheme.SetupScheme()
+	job := newJob(1)
+	r := newTestReconciler(newFakeCluster(), true, job)
+	conflicts := 0
+	r.Client = r.Client.WithInterceptor(func(c client.Client) client.Client {
+		return conflictingClient{Client: c, conflicts: &conflicts}
+	})
+	var read esv1.ReindexJob
+	require.NoError(t, r.Get(k8s.ExtractNamespacedName(job), &read))
+
+	// the ID of the started task is not lost on conflicts
+	status := esv1.ReindexJobStatus{Phase: esv1.ReindexRunningPhase, TaskID: "node:1", Attempts: 1}
+	require.NoError(t, r.updateStatus(read, status))
+	require.Equal(t, 1, conflicts)
+	var retrieved esv1.ReindexJob
+	require.NoError(t, r.Get(k8s.ExtractNamespacedName(job), &retrieved))
+	require.Equal(t, status, retrieved.Status)
+}
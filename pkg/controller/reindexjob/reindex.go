@@ -0,0 +1,151 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package reindexjob
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+)
+
+// reconcileJob runs the step of the reindex operation matching its current phase, and returns the updated status.
+func (r *ReconcileReindexJob) reconcileJob(ctx context.Context, job esv1.ReindexJob) (esv1.ReindexJobStatus, error) {
+	status := job.Status
+	if status.Phase == "" {
+		status.Phase = esv1.ReindexPendingPhase
+	}
+	es, ok, err := r.getCluster(job, &status)
+	if err != nil || !ok {
+		return status, err
+	}
+	if es.Status.Phase != esv1.ElasticsearchReadyPhase {
+		status.Message = fmt.Sprintf("Waiting for Elasticsearch %s to be ready", es.Name)
+		return status, nil
+	}
+	client, err := r.esClient(r.Client, r.Dialer, es)
+	if err != nil {
+		return status, err
+	}
+	defer client.Close()
+
+	switch status.Phase {
+	case esv1.ReindexPendingPhase:
+		err = startTask(ctx, client, job, &status)
+	case esv1.ReindexRunningPhase:
+		err = reconcileTask(ctx, client, job, &status)
+	}
+	return status, err
+}
+
+// getCluster returns the cluster the documents are reindexed in. It returns false if it does not exist or cannot be
+// accessed, in which case the status message is updated to report it.
+func (r *ReconcileReindexJob) getCluster(job esv1.ReindexJob, status *esv1.ReindexJobStatus) (esv1.Elasticsearch, bool, error) {
+	ref := job.Spec.ElasticsearchRef.WithDefaultNamespace(job.Namespace).NamespacedName()
+	var es esv1.Elasticsearch
+	if err := r.Get(ref, &es); err != nil {
+		if apierrors.IsNotFound(err) {
+			status.Message = fmt.Sprintf("Elasticsearch %s not found", ref)
+			return es, false, nil
+		}
+		return es, false, err
+	}
+	if ref.Namespace != job.Namespace {
+		allowed, err := r.accessReviewer.AccessAllowed(job.Spec.ServiceAccountName, job.Namespace, &es)
+		if err != nil {
+			return es, false, err
+		}
+		if !allowed {
+			status.Message = fmt.Sprintf("Access to Elasticsearch %s is not allowed", ref)
+			return es, false, nil
+		}
+	}
+	return es, true, nil
+}
+
+// reindexRequest returns the body of the reindex request of the given job.
+func reindexRequest(job esv1.ReindexJob) esclient.ReindexRequest {
+	request := esclient.ReindexRequest{
+		Source:    esclient.ReindexSource{Index: job.Spec.Source.Indices},
+		Dest:      esclient.ReindexDestination{Index: job.Spec.Destination.Index, Pipeline: job.Spec.Destination.Pipeline},
+		Conflicts: job.Spec.Conflicts,
+	}
+	if job.Spec.Source.Query != nil {
+		request.Source.Query = job.Spec.Source.Query.Data
+	}
+	return request
+}
+
+// startTask starts a new attempt of the reindex operation.
+func startTask(ctx context.Context, client esclient.Client, job esv1.ReindexJob, status *esv1.ReindexJobStatus) error {
+	log.Info("Starting reindex task", "namespace", job.Namespace, "reindex_job_name", job.Name,
+		"destination", job.Spec.Destination.Index, "attempt", status.Attempts+1)
+	taskID, err := client.StartReindex(ctx, reindexRequest(job), esclient.ReindexOptions{
+		Slices:            job.Spec.Slices,
+		RequestsPerSecond: job.Spec.RequestsPerSecond,
+	})
+	if err != nil {
+		return err
+	}
+	now := metav1.Now()
+	*status = esv1.ReindexJobStatus{
+		Phase:     esv1.ReindexRunningPhase,
+		TaskID:    taskID,
+		Attempts:  status.Attempts + 1,
+		Message:   "Reindex in progress",
+		StartedAt: &now,
+	}
+	return nil
+}
+
+// reconcileTask reports the progress of the reindex task of the current attempt, until it completes.
+func reconcileTask(ctx context.Context, client esclient.Client, job esv1.ReindexJob, status *esv1.ReindexJobStatus) error {
+	task, err := client.GetReindexTask(ctx, status.TaskID)
+	switch {
+	case esclient.IsNotFound(err):
+		// the task is lost if the node running it leaves the cluster before it completes
+		retryOrFail(job, status, fmt.Sprintf("task %s not found", status.TaskID))
+		return nil
+	case err != nil:
+		return err
+	}
+
+	status.Total = task.Task.Status.Total
+	status.Created = task.Task.Status.Created
+	status.Updated = task.Task.Status.Updated
+	status.VersionConflicts = task.Task.Status.VersionConflicts
+	if !task.Completed {
+		status.Message = "Reindex in progress"
+		return nil
+	}
+	if reason := task.FailureReason(); reason != "" {
+		retryOrFail(job, status, reason)
+		return nil
+	}
+	now := metav1.Now()
+	status.Phase = esv1.ReindexCompletedPhase
+	status.Message = fmt.Sprintf("%d documents created, %d updated", status.Created, status.Updated)
+	status.CompletedAt = &now
+	return nil
+}
+
+// retryOrFail starts the reindex operation again at the next reconciliation if the job has retries left, or moves it to
+// the failed phase.
+func retryOrFail(job esv1.ReindexJob, status *esv1.ReindexJobStatus, reason string) {
+	if status.Attempts > job.Spec.MaxRetriesOrDefault() {
+		status.Phase = esv1.ReindexFailedPhase
+		status.Message = fmt.Sprintf("Attempt %d failed: %s", status.Attempts, reason)
+		return
+	}
+	log.Info("Reindex task failed, retrying", "namespace", job.Namespace, "reindex_job_name", job.Name,
+		"attempt", status.Attempts, "reason", reason)
+	status.Phase = esv1.ReindexPendingPhase
+	status.TaskID = ""
+	status.Message = fmt.Sprintf("Attempt %d failed, retrying: %s", status.Attempts, reason)
+}
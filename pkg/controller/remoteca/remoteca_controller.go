@@ -5,8 +5,10 @@
 package remoteca
 
 import (
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster"
 	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/remotecluster/remoteca"
 	"github.com/elastic/cloud-on-k8s/pkg/utils/rbac"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -18,8 +20,8 @@ var (
 
 // Add creates a new RemoteCa Controller and adds it to the manager with default RBAC.
 func Add(mgr manager.Manager, accessReviewer rbac.AccessReviewer, params operator.Parameters) error {
-	r := remoteca.NewReconciler(mgr, accessReviewer, params)
-	c, err := common.NewController(mgr, name, r, params)
+	r := remoteca.NewReconciler(mgr, accessReviewer, remotecluster.NewESClient, params)
+	c, err := common.NewController(mgr, name, &esv1.Elasticsearch{}, r, params)
 	if err != nil {
 		return err
 	}
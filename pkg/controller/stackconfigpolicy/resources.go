@@ -0,0 +1,263 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stackconfigpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/hash"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/keystore"
+	commonname "github.com/elastic/cloud-on-k8s/pkg/controller/common/name"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/settings"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/stackconfigpolicy"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// policyLabels returns the labels set on the Secrets reconciled for the given policy.
+func policyLabels(policy types.NamespacedName) map[string]string {
+	return map[string]string{
+		common.TypeLabelName:                       stackconfigpolicy.SecretType,
+		stackconfigpolicy.PolicyNameLabelName:      policy.Name,
+		stackconfigpolicy.PolicyNamespaceLabelName: policy.Namespace,
+	}
+}
+
+// target is a resource selected by a policy.
+type target struct {
+	kind  string
+	owner metav1.Object
+	namer commonname.Namer
+}
+
+func (t target) key() string {
+	return fmt.Sprintf("%s/%s/%s", t.kind, t.owner.GetNamespace(), t.owner.GetName())
+}
+
+// expectedSecrets returns the Secrets to reconcile in the namespace of the target, given the config and secure
+// settings to distribute.
+func (t target) expectedSecrets(policy policyv1alpha1.StackConfigPolicy, config map[string][]byte, secureSettings map[string][]byte) []corev1.Secret {
+	labels := policyLabels(k8s.ExtractNamespacedName(&policy))
+	var secrets []corev1.Secret
+	if len(config) > 0 {
+		annotations := map[string]string{stackconfigpolicy.ConfigHashAnnotation: hash.HashObject(config)}
+		if t.kind == elasticsearchKind {
+			annotations[stackconfigpolicy.DriftModeAnnotation] = string(driftMode(policy))
+		}
+		secrets = append(secrets, corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   t.owner.GetNamespace(),
+				Name:        stackconfigpolicy.ConfigSecretName(t.namer, t.owner.GetName()),
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Data: config,
+		})
+	}
+	if len(secureSettings) > 0 {
+		secrets = append(secrets, corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: t.owner.GetNamespace(),
+				Name:      keystore.PolicySecureSettingsSecretName(t.namer, t.owner.GetName()),
+				Labels:    labels,
+			},
+			Data: secureSettings,
+		})
+	}
+	return secrets
+}
+
+// driftMode returns the drift mode of the policy, defaulting to Enforce.
+func driftMode(policy policyv1alpha1.StackConfigPolicy) policyv1alpha1.DriftMode {
+	if policy.Spec.Elasticsearch.DriftMode == "" {
+		return policyv1alpha1.EnforceDriftMode
+	}
+	return policy.Spec.Elasticsearch.DriftMode
+}
+
+// elasticsearchConfig returns the content of the config Secret of the Elasticsearch clusters selected by the policy.
+func elasticsearchConfig(policy policyv1alpha1.StackConfigPolicy) (map[string][]byte, error) {
+	spec := policy.Spec.Elasticsearch
+	data := map[string][]byte{}
+	for key, config := range map[string]*commonv1.Config{
+		stackconfigpolicy.SnapshotRepositoriesKey:   spec.SnapshotRepositories,
+		stackconfigpolicy.ClusterSettingsKey:        spec.ClusterSettings,
+		stackconfigpolicy.IndexLifecyclePoliciesKey: spec.IndexLifecyclePolicies,
+		stackconfigpolicy.IndexTemplatesKey:         spec.IndexTemplates,
+		stackconfigpolicy.IngestPipelinesKey:        spec.IngestPipelines,
+		stackconfigpolicy.WatchesKey:                spec.Watches,
+	} {
+		if config == nil || len(config.Data) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(config.Data)
+		if err != nil {
+			return nil, err
+		}
+		data[key] = raw
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// ingestPipelineSamples returns the sample documents of the ingest pipelines of the policy, held in the ConfigMap of the
+// policy namespace it references, or nil if it does not reference any.
+func ingestPipelineSamples(c k8s.Client, policy policyv1alpha1.StackConfigPolicy) ([]byte, error) {
+	ref := policy.Spec.Elasticsearch.IngestPipelineSamples
+	if ref == nil {
+		return nil, nil
+	}
+	var cm corev1.ConfigMap
+	err := c.Get(types.NamespacedName{Namespace: policy.Namespace, Name: ref.ConfigMapName}, &cm)
+	if apierrors.IsNotFound(err) {
+		return nil, errors.Errorf("ingest pipeline samples configmap %s not found", ref.ConfigMapName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pipelines map[string]interface{}
+	if policy.Spec.Elasticsearch.IngestPipelines != nil {
+		pipelines = policy.Spec.Elasticsearch.IngestPipelines.Data
+	}
+	samples := make(map[string][]map[string]interface{}, len(cm.Data))
+	for id, raw := range cm.Data {
+		if _, exists := pipelines[id]; !exists {
+			return nil, errors.Errorf("ingest pipeline samples configmap %s: unknown pipeline %s", ref.ConfigMapName, id)
+		}
+		var docs []map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &docs); err != nil {
+			return nil, errors.Wrapf(err, "ingest pipeline samples configmap %s: invalid documents for pipeline %s", ref.ConfigMapName, id)
+		}
+		samples[id] = docs
+	}
+	return json.Marshal(samples)
+}
+
+// kibanaConfig returns the content of the config Secret of the Kibana instances selected by the policy.
+func kibanaConfig(policy policyv1alpha1.StackConfigPolicy) (map[string][]byte, error) {
+	config := policy.Spec.Kibana.Config
+	if config == nil || len(config.Data) == 0 {
+		return nil, nil
+	}
+	cfg, err := settings.NewCanonicalConfigFrom(config.Data)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := cfg.Render()
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{stackconfigpolicy.KibanaConfigKey: raw}, nil
+}
+
+// secureSettings aggregates the content of the given secure settings Secrets of the policy namespace.
+func secureSettings(c k8s.Client, namespace string, sources []commonv1.SecretSource) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	for _, source := range sources {
+		if source.Namespace != "" && source.Namespace != namespace {
+			return nil, errors.Errorf("secure settings secret %s must be in the namespace of the policy", source.SecretName)
+		}
+		var secret corev1.Secret
+		err := c.Get(types.NamespacedName{Namespace: namespace, Name: source.SecretName}, &secret)
+		if apierrors.IsNotFound(err) {
+			return nil, errors.Errorf("secure settings secret %s not found", source.SecretName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if source.Entries == nil {
+			for k, v := range secret.Data {
+				data[source.Prefix+k] = v
+			}
+			continue
+		}
+		for _, entry := range source.Entries {
+			value, exists := secret.Data[entry.Key]
+			if !exists {
+				return nil, errors.Errorf("key %s not found in secure settings secret %s", entry.Key, source.SecretName)
+			}
+			key := entry.Path
+			if key == "" {
+				key = entry.Key
+			}
+			data[source.Prefix+key] = value
+		}
+	}
+	return data, nil
+}
+
+// secureSettingsSecretNames returns the names of all the secure settings Secrets referenced by the policy.
+func secureSettingsSecretNames(policy policyv1alpha1.StackConfigPolicy) []string {
+	sources := append(append([]commonv1.SecretSource{}, policy.Spec.Elasticsearch.SecureSettings...), policy.Spec.Kibana.SecureSettings...)
+	names := make([]string, 0, len(sources))
+	for _, s := range sources {
+		names = append(names, s.SecretName)
+	}
+	return names
+}
+
+// targetStatus returns the status of the application of the policy to a target, given its reconciled Secrets.
+func targetStatus(secrets []corev1.Secret) policyv1alpha1.ResourcePolicyStatus {
+	for _, s := range secrets {
+		if _, isConfig := s.Annotations[stackconfigpolicy.ConfigHashAnnotation]; !isConfig {
+			continue
+		}
+		if stackconfigpolicy.IsApplied(s) {
+			if drift := stackconfigpolicy.Drift(s); len(drift) > 0 {
+				return policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.DriftedPhase, Drift: drift}
+			}
+			return policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.AppliedPhase}
+		}
+		if errMsg := s.Annotations[stackconfigpolicy.ApplyErrorAnnotation]; errMsg != "" {
+			return policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.ResourceErrorPhase, Error: errMsg}
+		}
+		return policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.ApplyingPhase}
+	}
+	// secure settings are applied as soon as their Secret is reconciled
+	return policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.AppliedPhase}
+}
+
+// newStatus computes the status of the policy from the status of its targets.
+func newStatus(policy policyv1alpha1.StackConfigPolicy, statuses map[string]policyv1alpha1.ResourcePolicyStatus) policyv1alpha1.StackConfigPolicyStatus {
+	status := policyv1alpha1.StackConfigPolicyStatus{
+		Resources:          len(statuses),
+		ObservedGeneration: policy.Generation,
+		Phase:              policyv1alpha1.ReadyPhase,
+	}
+	if len(statuses) > 0 {
+		status.ResourcesStatuses = statuses
+	}
+	drifted := 0
+	for _, s := range statuses {
+		switch s.Phase {
+		case policyv1alpha1.AppliedPhase:
+			status.Ready++
+		case policyv1alpha1.DriftedPhase:
+			drifted++
+		case policyv1alpha1.ResourceErrorPhase, policyv1alpha1.ConflictPhase:
+			status.Errors++
+		}
+	}
+	switch {
+	case status.Errors > 0:
+		status.Phase = policyv1alpha1.ErrorPhase
+	case drifted > 0:
+		status.Phase = policyv1alpha1.DriftDetectedPhase
+	case status.Ready < status.Resources:
+		status.Phase = policyv1alpha1.ApplyingChangesPhase
+	}
+	return status
+}
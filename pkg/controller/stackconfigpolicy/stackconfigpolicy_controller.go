@@ -0,0 +1,404 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stackconfigpolicy
+
+import (
+	"reflect"
+
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/events"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/stackconfigpolicy"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/tracing"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	kbname "github.com/elastic/cloud-on-k8s/pkg/controller/kibana/name"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// StackConfigPolicy controller
+//
+// This controller distributes the configuration declared in StackConfigPolicy resources to the Elasticsearch clusters
+// and Kibana instances matching their resource selector:
+// - policies of the operator namespace apply to resources of all namespaces, other policies only apply to resources
+//   of their own namespace
+// - a resource can only be selected by one policy, resources selected by several policies are reported in conflict
+// - the configuration is written to Secrets owned by the selected resources, which are applied by the Elasticsearch
+//   and Kibana controllers
+// - the status of the policy reports the application of the policy to each selected resource, and the configuration
+//   drift detected by the Elasticsearch controller
+
+const (
+	name = "stackconfigpolicy-controller"
+
+	elasticsearchKind = "Elasticsearch"
+	kibanaKind        = "Kibana"
+)
+
+var log = logf.Log.WithName(name)
+
+// Add creates a new StackConfigPolicy Controller and adds it to the Manager with default RBAC. The Manager will set
+// fields on the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, params operator.Parameters) error {
+	r := newReconciler(mgr, params)
+	c, err := common.NewController(mgr, name, &policyv1alpha1.StackConfigPolicy{}, r, params)
+	if err != nil {
+		return err
+	}
+	return addWatches(c, r)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, params operator.Parameters) *ReconcileStackConfigPolicy {
+	return &ReconcileStackConfigPolicy{
+		Client:         k8s.WrapClient(mgr.GetClient()),
+		recorder:       mgr.GetEventRecorderFor(name),
+		dynamicWatches: watches.NewDynamicWatches(),
+		Parameters:     params,
+	}
+}
+
+func addWatches(c controller.Controller, r *ReconcileStackConfigPolicy) error {
+	// watch policies
+	if err := c.Watch(&source.Kind{Type: &policyv1alpha1.StackConfigPolicy{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	// any change to an Elasticsearch or Kibana resource may change the resources selected by the policies
+	allPolicies := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+			requests, err := reconcileRequestsForAllPolicies(r.Client)
+			if err != nil {
+				log.Error(err, "failed to list policies, dropping watch event")
+				return nil
+			}
+			return requests
+		}),
+	}
+	if err := c.Watch(&source.Kind{Type: &esv1.Elasticsearch{}}, allPolicies); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Kind{Type: &kbv1.Kibana{}}, allPolicies); err != nil {
+		return err
+	}
+	// watch the Secrets reconciled for the policies, annotated when applied
+	if err := c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []reconcile.Request {
+			objLabels := object.Meta.GetLabels()
+			if objLabels[common.TypeLabelName] != stackconfigpolicy.SecretType {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{
+				Namespace: objLabels[stackconfigpolicy.PolicyNamespaceLabelName],
+				Name:      objLabels[stackconfigpolicy.PolicyNameLabelName],
+			}}}
+		}),
+	}); err != nil {
+		return err
+	}
+	// dynamically watch the secure settings Secrets referenced by the policies
+	if err := c.Watch(&source.Kind{Type: &corev1.Secret{}}, r.dynamicWatches.Secrets); err != nil {
+		return err
+	}
+	// dynamically watch the ingest pipeline samples ConfigMaps referenced by the policies
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, r.dynamicWatches.ConfigMaps)
+}
+
+func reconcileRequestsForAllPolicies(c k8s.Client) ([]reconcile.Request, error) {
+	var policies policyv1alpha1.StackConfigPolicyList
+	if err := c.List(&policies); err != nil {
+		return nil, err
+	}
+	requests := make([]reconcile.Request, 0, len(policies.Items))
+	for _, p := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: k8s.ExtractNamespacedName(&p)})
+	}
+	return requests, nil
+}
+
+var _ reconcile.Reconciler = &ReconcileStackConfigPolicy{}
+
+// ReconcileStackConfigPolicy reconciles a StackConfigPolicy object
+type ReconcileStackConfigPolicy struct {
+	k8s.Client
+	recorder       record.EventRecorder
+	dynamicWatches watches.DynamicWatches
+	operator.Parameters
+	// iteration is the number of times this controller has run its Reconcile method
+	iteration uint64
+}
+
+// Reconcile distributes the configuration of a StackConfigPolicy to the resources it selects.
+func (r *ReconcileStackConfigPolicy) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	defer common.LogReconciliationRun(log, request, "policy_name", &r.iteration)()
+	tx, ctx := tracing.NewTransaction(r.Tracer, request.NamespacedName, "stackconfigpolicy")
+	defer tracing.EndTransaction(tx)
+
+	var policy policyv1alpha1.StackConfigPolicy
+	if err := r.Get(request.NamespacedName, &policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, r.onDelete(request.NamespacedName)
+		}
+		return reconcile.Result{}, tracing.CaptureError(ctx, err)
+	}
+
+	if !policy.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, tracing.CaptureError(ctx, r.onDelete(request.NamespacedName))
+	}
+
+	if common.IsPaused(policy.ObjectMeta) {
+		log.Info("Object is paused. Skipping reconciliation", "namespace", policy.Namespace, "policy_name", policy.Name)
+		return common.PauseRequeue, nil
+	}
+
+	results := reconciler.NewResult(ctx)
+	status, err := r.reconcileInternal(policy)
+	if err != nil {
+		results.WithError(err)
+		k8s.EmitErrorEvent(r.recorder, err, &policy, events.EventReconciliationError, "Reconciliation error: %v", err)
+	}
+	if status != nil && !reflect.DeepEqual(*status, policy.Status) {
+		policy.Status = *status
+		if err := common.UpdateStatus(r.Client, &policy); err != nil {
+			if apierrors.IsConflict(err) {
+				log.V(1).Info("Conflict while updating status", "namespace", policy.Namespace, "policy_name", policy.Name)
+				return reconcile.Result{Requeue: true}, nil
+			}
+			results.WithError(err)
+		}
+	}
+	return results.Aggregate()
+}
+
+// onDelete removes the watches and Secrets reconciled for a deleted policy.
+func (r *ReconcileStackConfigPolicy) onDelete(policy types.NamespacedName) error {
+	r.dynamicWatches.Secrets.RemoveHandlerForKey(secureSettingsWatchName(policy))
+	r.dynamicWatches.ConfigMaps.RemoveHandlerForKey(ingestPipelineSamplesWatchName(policy))
+	return k8s.DeleteSecretMatching(r.Client, client.MatchingLabels(policyLabels(policy)))
+}
+
+func secureSettingsWatchName(policy types.NamespacedName) string {
+	return policy.Namespace + "-" + policy.Name + "-policy-secure-settings"
+}
+
+func ingestPipelineSamplesWatchName(policy types.NamespacedName) string {
+	return policy.Namespace + "-" + policy.Name + "-policy-ingest-pipeline-samples"
+}
+
+// watchIngestPipelineSamples watches the ingest pipeline samples ConfigMap referenced by the policy, if any.
+func (r *ReconcileStackConfigPolicy) watchIngestPipelineSamples(policy policyv1alpha1.StackConfigPolicy) error {
+	policyKey := k8s.ExtractNamespacedName(&policy)
+	watchName := ingestPipelineSamplesWatchName(policyKey)
+	ref := policy.Spec.Elasticsearch.IngestPipelineSamples
+	if ref == nil {
+		r.dynamicWatches.ConfigMaps.RemoveHandlerForKey(watchName)
+		return nil
+	}
+	return r.dynamicWatches.ConfigMaps.AddHandler(watches.NamedWatch{
+		Name:    watchName,
+		Watched: []types.NamespacedName{{Namespace: policy.Namespace, Name: ref.ConfigMapName}},
+		Watcher: policyKey,
+	})
+}
+
+// reconcileInternal reconciles the Secrets of the resources selected by the policy, and returns its new status.
+func (r *ReconcileStackConfigPolicy) reconcileInternal(policy policyv1alpha1.StackConfigPolicy) (*policyv1alpha1.StackConfigPolicyStatus, error) {
+	policyKey := k8s.ExtractNamespacedName(&policy)
+	if err := watches.WatchUserProvidedSecrets(
+		policyKey, r.dynamicWatches, secureSettingsWatchName(policyKey), secureSettingsSecretNames(policy),
+	); err != nil {
+		return nil, err
+	}
+	if err := r.watchIngestPipelineSamples(policy); err != nil {
+		return nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.ResourceSelector)
+	if err != nil {
+		return r.invalid(policy, err), nil
+	}
+	esConfig, err := elasticsearchConfig(policy)
+	if err != nil {
+		return r.invalid(policy, err), nil
+	}
+	samples, err := ingestPipelineSamples(r.Client, policy)
+	if err != nil {
+		return r.invalid(policy, err), nil
+	}
+	if samples != nil && esConfig != nil {
+		esConfig[stackconfigpolicy.IngestPipelineSamplesKey] = samples
+	}
+	esSecureSettings, err := secureSettings(r.Client, policy.Namespace, policy.Spec.Elasticsearch.SecureSettings)
+	if err != nil {
+		return r.invalid(policy, err), nil
+	}
+	kbConfig, err := kibanaConfig(policy)
+	if err != nil {
+		return r.invalid(policy, err), nil
+	}
+	kbSecureSettings, err := secureSettings(r.Client, policy.Namespace, policy.Spec.Kibana.SecureSettings)
+	if err != nil {
+		return r.invalid(policy, err), nil
+	}
+
+	targets, err := r.selectedTargets(policy, selector)
+	if err != nil {
+		return nil, err
+	}
+	var policies policyv1alpha1.StackConfigPolicyList
+	if err := r.List(&policies); err != nil {
+		return nil, err
+	}
+
+	statuses := map[string]policyv1alpha1.ResourcePolicyStatus{}
+	expectedSecrets := map[types.NamespacedName]bool{}
+	for _, t := range targets {
+		if conflicting := r.conflictingPolicies(policy, policies.Items, t); len(conflicting) > 0 {
+			statuses[t.key()] = policyv1alpha1.ResourcePolicyStatus{
+				Phase: policyv1alpha1.ConflictPhase,
+				Error: "resource also selected by " + conflicting[0],
+			}
+			continue
+		}
+		config, secure := esConfig, esSecureSettings
+		if t.kind == kibanaKind {
+			config, secure = kbConfig, kbSecureSettings
+		}
+		var reconciled []corev1.Secret
+		for _, expected := range t.expectedSecrets(policy, config, secure) {
+			expectedSecrets[k8s.ExtractNamespacedName(&expected)] = true
+			secret, err := reconciler.ReconcileSecret(r.Client, expected, t.owner)
+			if err != nil {
+				return nil, err
+			}
+			reconciled = append(reconciled, secret)
+		}
+		statuses[t.key()] = targetStatus(reconciled)
+	}
+
+	// garbage collect the Secrets of the resources not selected anymore
+	var secrets corev1.SecretList
+	if err := r.List(&secrets, client.MatchingLabels(policyLabels(policyKey))); err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		if expectedSecrets[k8s.ExtractNamespacedName(&secrets.Items[i])] {
+			continue
+		}
+		if err := r.Delete(&secrets.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	status := newStatus(policy, statuses)
+	return &status, nil
+}
+
+// invalid returns the status of a policy that cannot be applied, and records the reason in an event.
+func (r *ReconcileStackConfigPolicy) invalid(policy policyv1alpha1.StackConfigPolicy, err error) *policyv1alpha1.StackConfigPolicyStatus {
+	r.recorder.Event(&policy, corev1.EventTypeWarning, events.EventReasonValidation, err.Error())
+	status := policy.Status.DeepCopy()
+	status.Phase = policyv1alpha1.InvalidPhase
+	status.ObservedGeneration = policy.Generation
+	return status
+}
+
+// namespaceScope returns the namespace the policy can select resources in, or an empty string for all namespaces.
+func (r *ReconcileStackConfigPolicy) namespaceScope(policy policyv1alpha1.StackConfigPolicy) string {
+	if policy.Namespace == r.OperatorNamespace {
+		return ""
+	}
+	return policy.Namespace
+}
+
+// selectedTargets returns the Elasticsearch and Kibana resources the policy distributes configuration to.
+func (r *ReconcileStackConfigPolicy) selectedTargets(policy policyv1alpha1.StackConfigPolicy, selector labels.Selector) ([]target, error) {
+	opts := []client.ListOption{client.MatchingLabelsSelector{Selector: selector}}
+	if ns := r.namespaceScope(policy); ns != "" {
+		opts = append(opts, client.InNamespace(ns))
+	}
+	var targets []target
+	if appliesToElasticsearch(policy) {
+		var esList esv1.ElasticsearchList
+		if err := r.List(&esList, opts...); err != nil {
+			return nil, err
+		}
+		for i := range esList.Items {
+			targets = append(targets, target{kind: elasticsearchKind, owner: &esList.Items[i], namer: esv1.ESNamer})
+		}
+	}
+	if appliesToKibana(policy) {
+		var kbList kbv1.KibanaList
+		if err := r.List(&kbList, opts...); err != nil {
+			return nil, err
+		}
+		for i := range kbList.Items {
+			targets = append(targets, target{kind: kibanaKind, owner: &kbList.Items[i], namer: kbname.KBNamer})
+		}
+	}
+	return targets, nil
+}
+
+// conflictingPolicies returns the names of the other policies distributing configuration to the given target.
+func (r *ReconcileStackConfigPolicy) conflictingPolicies(
+	policy policyv1alpha1.StackConfigPolicy,
+	policies []policyv1alpha1.StackConfigPolicy,
+	t target,
+) []string {
+	var conflicting []string
+	for _, other := range policies {
+		if other.Namespace == policy.Namespace && other.Name == policy.Name {
+			continue
+		}
+		if ns := r.namespaceScope(other); ns != "" && ns != t.owner.GetNamespace() {
+			continue
+		}
+		if (t.kind == elasticsearchKind && !appliesToElasticsearch(other)) || (t.kind == kibanaKind && !appliesToKibana(other)) {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&other.Spec.ResourceSelector)
+		if err != nil {
+			log.Error(pkgerrors.Wrap(err, "invalid resource selector"), "Ignoring policy", "namespace", other.Namespace, "policy_name", other.Name)
+			continue
+		}
+		if selector.Matches(labels.Set(t.owner.GetLabels())) {
+			conflicting = append(conflicting, other.Namespace+"/"+other.Name)
+		}
+	}
+	return conflicting
+}
+
+func appliesToElasticsearch(policy policyv1alpha1.StackConfigPolicy) bool {
+	spec := policy.Spec.Elasticsearch
+	for _, config := range []*commonv1.Config{spec.SnapshotRepositories, spec.ClusterSettings, spec.IndexLifecyclePolicies, spec.IndexTemplates, spec.IngestPipelines, spec.Watches} {
+		if config != nil && len(config.Data) > 0 {
+			return true
+		}
+	}
+	return len(spec.SecureSettings) > 0
+}
+
+func appliesToKibana(policy policyv1alpha1.StackConfigPolicy) bool {
+	spec := policy.Spec.Kibana
+	return (spec.Config != nil && len(spec.Config.Data) > 0) || len(spec.SecureSettings) > 0
+}
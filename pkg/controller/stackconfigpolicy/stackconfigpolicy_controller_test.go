@@ -0,0 +1,311 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stackconfigpolicy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	kbv1 "github.com/elastic/cloud-on-k8s/pkg/apis/kibana/v1"
+	policyv1alpha1 "github.com/elastic/cloud-on-k8s/pkg/apis/stackconfigpolicy/v1alpha1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/stackconfigpolicy"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/watches"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const operatorNs = "elastic-system"
+
+var errTest = errors.New("failed to apply")
+
+func newPolicy(namespace, name string, matchLabels map[string]string) *policyv1alpha1.StackConfigPolicy {
+	return &policyv1alpha1.StackConfigPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Generation: 1},
+		Spec: policyv1alpha1.StackConfigPolicySpec{
+			ResourceSelector: metav1.LabelSelector{MatchLabels: matchLabels},
+			Elasticsearch: policyv1alpha1.ElasticsearchConfigPolicySpec{
+				SnapshotRepositories: &commonv1.Config{Data: map[string]interface{}{
+					"backups": map[string]interface{}{"type": "fs", "settings": map[string]interface{}{"location": "/tmp"}},
+				}},
+			},
+			Kibana: policyv1alpha1.KibanaConfigPolicySpec{
+				Config: &commonv1.Config{Data: map[string]interface{}{"xpack.security.session.idleTimeout": "1h"}},
+				SecureSettings: []commonv1.SecretSource{
+					{SecretName: "kb-secure-settings"},
+				},
+			},
+		},
+	}
+}
+
+func newES(namespace, name string, labels map[string]string) *esv1.Elasticsearch {
+	return &esv1.Elasticsearch{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+}
+
+func newKibana(namespace, name string, labels map[string]string) *kbv1.Kibana {
+	return &kbv1.Kibana{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+}
+
+var kbSecureSettings = &corev1.Secret{
+	ObjectMeta: metav1.ObjectMeta{Namespace: operatorNs, Name: "kb-secure-settings"},
+	Data:       map[string][]byte{"elasticsearch.password": []byte("secret")},
+}
+
+func newReconcilerWith(objs ...runtime.Object) *ReconcileStackConfigPolicy {
+	return &ReconcileStackConfigPolicy{
+		Client:         k8s.WrappedFakeClient(objs...),
+		recorder:       record.NewFakeRecorder(100),
+		dynamicWatches: watches.NewDynamicWatches(),
+		Parameters:     operator.Parameters{OperatorNamespace: operatorNs},
+	}
+}
+
+func reconcilePolicy(t *testing.T, r *ReconcileStackConfigPolicy, policy types.NamespacedName) policyv1alpha1.StackConfigPolicy {
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: policy})
+	require.NoError(t, err)
+	var updated policyv1alpha1.StackConfigPolicy
+	require.NoError(t, r.Get(policy, &updated))
+	return updated
+}
+
+func secretExists(t *testing.T, c k8s.Client, nsn types.NamespacedName) bool {
+	var secret corev1.Secret
+	err := c.Get(nsn, &secret)
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+func TestReconcileStackConfigPolicy_Reconcile(t *testing.T) {
+	prodLabels := map[string]string{"env": "prod"}
+	policy := newPolicy(operatorNs, "prod", prodLabels)
+	policyKey := k8s.ExtractNamespacedName(policy)
+	r := newReconcilerWith(
+		policy,
+		kbSecureSettings,
+		newES("ns1", "es", prodLabels),
+		newKibana("ns2", "kb", prodLabels),
+		newES("ns1", "not-selected", nil),
+	)
+
+	updated := reconcilePolicy(t, r, policyKey)
+
+	// config Secrets are reconciled for the selected resources only
+	require.True(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-config"}))
+	require.True(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns2", Name: "kb-kb-policy-config"}))
+	require.True(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns2", Name: "kb-kb-policy-secure-settings"}))
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-secure-settings"}))
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "not-selected-es-policy-config"}))
+
+	// nothing is applied yet
+	require.Equal(t, policyv1alpha1.StackConfigPolicyStatus{
+		Resources: 2,
+		Phase:     policyv1alpha1.ApplyingChangesPhase,
+		ResourcesStatuses: map[string]policyv1alpha1.ResourcePolicyStatus{
+			"Elasticsearch/ns1/es": {Phase: policyv1alpha1.ApplyingPhase},
+			"Kibana/ns2/kb":        {Phase: policyv1alpha1.ApplyingPhase},
+		},
+		ObservedGeneration: 1,
+	}, updated.Status)
+
+	// simulate the Elasticsearch controller applying the configuration, and Kibana failing to do so
+	var esConfig, kbConfig corev1.Secret
+	require.NoError(t, r.Get(types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-config"}, &esConfig))
+	require.NoError(t, stackconfigpolicy.UpdateAppliedStatus(r.Client, esConfig, nil))
+	require.NoError(t, r.Get(types.NamespacedName{Namespace: "ns2", Name: "kb-kb-policy-config"}, &kbConfig))
+	require.NoError(t, stackconfigpolicy.UpdateAppliedStatus(r.Client, kbConfig, errTest))
+
+	updated = reconcilePolicy(t, r, policyKey)
+	require.Equal(t, 1, updated.Status.Ready)
+	require.Equal(t, 1, updated.Status.Errors)
+	require.Equal(t, policyv1alpha1.ErrorPhase, updated.Status.Phase)
+	require.Equal(t, policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.ResourceErrorPhase, Error: errTest.Error()},
+		updated.Status.ResourcesStatuses["Kibana/ns2/kb"])
+
+	// the Kibana resource is not selected anymore: its Secrets are garbage collected
+	var kb kbv1.Kibana
+	require.NoError(t, r.Get(types.NamespacedName{Namespace: "ns2", Name: "kb"}, &kb))
+	kb.Labels = nil
+	require.NoError(t, r.Update(&kb))
+
+	updated = reconcilePolicy(t, r, policyKey)
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns2", Name: "kb-kb-policy-config"}))
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns2", Name: "kb-kb-policy-secure-settings"}))
+	require.Equal(t, policyv1alpha1.StackConfigPolicyStatus{
+		Resources: 1,
+		Ready:     1,
+		Phase:     policyv1alpha1.ReadyPhase,
+		ResourcesStatuses: map[string]policyv1alpha1.ResourcePolicyStatus{
+			"Elasticsearch/ns1/es": {Phase: policyv1alpha1.AppliedPhase},
+		},
+		ObservedGeneration: 1,
+	}, updated.Status)
+
+	// the policy is deleted: all its Secrets are removed
+	require.NoError(t, r.Delete(&updated))
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: policyKey})
+	require.NoError(t, err)
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-config"}))
+}
+
+func TestReconcileStackConfigPolicy_Reconcile_NamespaceScope(t *testing.T) {
+	policy := newPolicy("ns1", "local", nil)
+	policy.Spec.Kibana = policyv1alpha1.KibanaConfigPolicySpec{}
+	r := newReconcilerWith(
+		policy,
+		newES("ns1", "es", nil),
+		newES("ns2", "es", nil),
+	)
+
+	updated := reconcilePolicy(t, r, k8s.ExtractNamespacedName(policy))
+	require.Equal(t, 1, updated.Status.Resources)
+	require.True(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-config"}))
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns2", Name: "es-es-policy-config"}))
+}
+
+func TestReconcileStackConfigPolicy_Reconcile_Conflict(t *testing.T) {
+	global := newPolicy(operatorNs, "global", nil)
+	global.Spec.Kibana = policyv1alpha1.KibanaConfigPolicySpec{}
+	local := newPolicy("ns1", "local", nil)
+	local.Spec.Kibana = policyv1alpha1.KibanaConfigPolicySpec{}
+	r := newReconcilerWith(global, local, newES("ns1", "es", nil), newES("ns2", "es", nil))
+
+	updated := reconcilePolicy(t, r, k8s.ExtractNamespacedName(global))
+	require.Equal(t, policyv1alpha1.ErrorPhase, updated.Status.Phase)
+	require.Equal(t, policyv1alpha1.ResourcePolicyStatus{Phase: policyv1alpha1.ConflictPhase, Error: "resource also selected by ns1/local"},
+		updated.Status.ResourcesStatuses["Elasticsearch/ns1/es"])
+	require.Equal(t, policyv1alpha1.ApplyingPhase, updated.Status.ResourcesStatuses["Elasticsearch/ns2/es"].Phase)
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-config"}))
+	require.True(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns2", Name: "es-es-policy-config"}))
+}
+
+func TestReconcileStackConfigPolicy_Reconcile_Drift(t *testing.T) {
+	policy := newPolicy(operatorNs, "prod", nil)
+	policy.Spec.Kibana = policyv1alpha1.KibanaConfigPolicySpec{}
+	policy.Spec.Elasticsearch.ClusterSettings = &commonv1.Config{Data: map[string]interface{}{"cluster.max_shards_per_node": 2000}}
+	policy.Spec.Elasticsearch.DriftMode = policyv1alpha1.ReportDriftMode
+	policyKey := k8s.ExtractNamespacedName(policy)
+	r := newReconcilerWith(policy, newES("ns1", "es", nil))
+
+	reconcilePolicy(t, r, policyKey)
+	var config corev1.Secret
+	configKey := types.NamespacedName{Namespace: "ns1", Name: "es-es-policy-config"}
+	require.NoError(t, r.Get(configKey, &config))
+	require.Equal(t, string(policyv1alpha1.ReportDriftMode), config.Annotations[stackconfigpolicy.DriftModeAnnotation])
+	require.Contains(t, config.Data, stackconfigpolicy.ClusterSettingsKey)
+	require.Contains(t, config.Data, stackconfigpolicy.SnapshotRepositoriesKey)
+
+	// simulate the Elasticsearch controller applying the configuration, then detecting drift
+	require.NoError(t, stackconfigpolicy.UpdateAppliedStatus(r.Client, config, nil))
+	require.NoError(t, r.Get(configKey, &config))
+	require.NoError(t, stackconfigpolicy.UpdateDriftStatus(r.Client, config, []string{"cluster_settings:cluster.max_shards_per_node"}))
+
+	updated := reconcilePolicy(t, r, policyKey)
+	require.Equal(t, policyv1alpha1.DriftDetectedPhase, updated.Status.Phase)
+	require.Equal(t, 0, updated.Status.Ready)
+	require.Equal(t, policyv1alpha1.ResourcePolicyStatus{
+		Phase: policyv1alpha1.DriftedPhase,
+		Drift: []string{"cluster_settings:cluster.max_shards_per_node"},
+	}, updated.Status.ResourcesStatuses["Elasticsearch/ns1/es"])
+
+	// the policy is updated: the drift of its previous content is not reported anymore once applied
+	updated.Spec.Elasticsearch.ClusterSettings.Data["cluster.max_shards_per_node"] = 3000
+	require.NoError(t, r.Update(&updated))
+	reconcilePolicy(t, r, policyKey)
+	require.NoError(t, r.Get(configKey, &config))
+	require.NoError(t, stackconfigpolicy.UpdateAppliedStatus(r.Client, config, nil))
+	updated = reconcilePolicy(t, r, policyKey)
+	require.Equal(t, policyv1alpha1.ReadyPhase, updated.Status.Phase)
+}
+
+func TestReconcileStackConfigPolicy_Reconcile_Invalid(t *testing.T) {
+	// the secure settings Secret does not exist
+	policy := newPolicy(operatorNs, "prod", nil)
+	r := newReconcilerWith(policy, newKibana("ns1", "kb", nil))
+
+	updated := reconcilePolicy(t, r, k8s.ExtractNamespacedName(policy))
+	require.Equal(t, policyv1alpha1.InvalidPhase, updated.Status.Phase)
+	require.False(t, secretExists(t, r.Client, types.NamespacedName{Namespace: "ns1", Name: "kb-kb-policy-config"}))
+}
+
+func Test_ingestPipelineSamples(t *testing.T) {
+	withPipelines := func(samplesConfigMap string) policyv1alpha1.StackConfigPolicy {
+		policy := newPolicy(operatorNs, "prod", nil)
+		policy.Spec.Elasticsearch.IngestPipelines = &commonv1.Config{Data: map[string]interface{}{
+			"logs": map[string]interface{}{"processors": []interface{}{}},
+		}}
+		if samplesConfigMap != "" {
+			policy.Spec.Elasticsearch.IngestPipelineSamples = &policyv1alpha1.IngestPipelineSamples{ConfigMapName: samplesConfigMap}
+		}
+		return *policy
+	}
+	samples := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: operatorNs, Name: "samples"}, Data: data}
+	}
+	tests := []struct {
+		name    string
+		policy  policyv1alpha1.StackConfigPolicy
+		objs    []runtime.Object
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "no samples",
+			policy: withPipelines(""),
+		},
+		{
+			name:    "configmap not found",
+			policy:  withPipelines("samples"),
+			wantErr: "ingest pipeline samples configmap samples not found",
+		},
+		{
+			name:    "unknown pipeline",
+			policy:  withPipelines("samples"),
+			objs:    []runtime.Object{samples(map[string]string{"metrics": `[{"value": 1}]`})},
+			wantErr: "ingest pipeline samples configmap samples: unknown pipeline metrics",
+		},
+		{
+			name:    "invalid documents",
+			policy:  withPipelines("samples"),
+			objs:    []runtime.Object{samples(map[string]string{"logs": `{"message": "not an array"}`})},
+			wantErr: "ingest pipeline samples configmap samples: invalid documents for pipeline logs",
+		},
+		{
+			name:   "samples",
+			policy: withPipelines("samples"),
+			objs:   []runtime.Object{samples(map[string]string{"logs": `[{"message": "GET /index.html 200"}]`})},
+			want:   `{"logs":[{"message":"GET /index.html 200"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ingestPipelineSamples(k8s.WrappedFakeClient(tt.objs...), tt.policy)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == "" {
+				require.Nil(t, got)
+				return
+			}
+			require.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
@@ -34,7 +34,7 @@ func (w *Params) shouldRenewCertificates(serverCertificates *corev1.Secret, webh
 	if serverCA == nil {
 		return true
 	}
-	if !certificates.CanReuseCA(serverCA, w.Rotation.RotateBefore) {
+	if !certificates.CanReuseCA(serverCA, w.rotation().RotateBefore) {
 		return true
 	}
 	// Read the certificate in the webhook configuration
@@ -53,7 +53,7 @@ func (w *Params) shouldRenewCertificates(serverCertificates *corev1.Secret, webh
 			return true
 		}
 		for _, cert := range certs {
-			if !certificates.CertIsValid(*cert, w.Rotation.RotateBefore) {
+			if !certificates.CertIsValid(*cert, w.rotation().RotateBefore) {
 				return true
 			}
 		}
@@ -68,6 +68,7 @@ func (w *Params) shouldRenewCertificates(serverCertificates *corev1.Secret, webh
 // certificate is about to expire or is missing.
 func (w *Params) newCertificates() (WebhookCertificates, error) {
 	webhookCertificates := WebhookCertificates{}
+	validity := w.rotation().Validity
 
 	// Create a new CA
 	ca, err := certificates.NewSelfSignedCA(certificates.CABuilderOptions{
@@ -75,7 +76,7 @@ func (w *Params) newCertificates() (WebhookCertificates, error) {
 			CommonName:         "elastic-webhook-ca",
 			OrganizationalUnit: []string{"elastic-webhook"},
 		},
-		ExpireIn: &w.Rotation.Validity,
+		ExpireIn: &validity,
 	})
 	if err != nil {
 		return webhookCertificates, err
@@ -112,7 +113,7 @@ func (w *Params) newCertificates() (WebhookCertificates, error) {
 		}),
 
 		NotBefore: time.Now().Add(-10 * time.Minute),
-		NotAfter:  time.Now().Add(w.Rotation.Validity),
+		NotAfter:  time.Now().Add(validity),
 
 		PublicKeyAlgorithm: parsedCSR.PublicKeyAlgorithm,
 		PublicKey:          parsedCSR.PublicKey,
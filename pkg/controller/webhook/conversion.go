@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package webhook
+
+import (
+	"encoding/base64"
+
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// crdGVK is the GroupVersionKind of the CustomResourceDefinitions, handled as unstructured objects.
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}
+
+// paths of the conversion webhook configuration in a CRD
+var (
+	conversionStrategyPath         = []string{"spec", "conversion", "strategy"}
+	conversionServiceNamePath      = []string{"spec", "conversion", "webhookClientConfig", "service", "name"}
+	conversionServiceNamespacePath = []string{"spec", "conversion", "webhookClientConfig", "service", "namespace"}
+	conversionCABundlePath         = []string{"spec", "conversion", "webhookClientConfig", "caBundle"}
+)
+
+// reconcileConversionWebhooks propagates the CA bundle to the conversion webhook configuration of the CRDs listed in
+// the parameters. Only the CRDs converting their versions with the Webhook strategy through the webhook service of
+// the operator are updated: missing CRDs, or CRDs with the None strategy, are left untouched. So are the CRDs the
+// operator is not allowed to get or update, the CA bundle being managed by the administrator in that case.
+func (w *Params) reconcileConversionWebhooks(c k8s.Client, caBundle []byte) error {
+	for _, name := range w.ConversionCRDs {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		if err := c.Get(types.NamespacedName{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if apierrors.IsForbidden(err) {
+				log.Info("Not allowed to get the CRD, skipping the conversion webhook CA bundle update", "crd", name)
+				continue
+			}
+			return err
+		}
+		if !w.isConversionWebhook(crd) {
+			continue
+		}
+		expected := base64.StdEncoding.EncodeToString(caBundle)
+		current, _, _ := unstructured.NestedString(crd.Object, conversionCABundlePath...)
+		if current == expected {
+			continue
+		}
+		if err := unstructured.SetNestedField(crd.Object, expected, conversionCABundlePath...); err != nil {
+			return err
+		}
+		log.Info("Updating conversion webhook CA bundle", "crd", name)
+		if err := c.Update(crd); err != nil {
+			if apierrors.IsForbidden(err) {
+				log.Info("Not allowed to update the CRD, skipping the conversion webhook CA bundle update", "crd", name)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// isConversionWebhook returns true if the given CRD converts its versions with the operator conversion webhook.
+func (w *Params) isConversionWebhook(crd *unstructured.Unstructured) bool {
+	strategy, _, _ := unstructured.NestedString(crd.Object, conversionStrategyPath...)
+	name, _, _ := unstructured.NestedString(crd.Object, conversionServiceNamePath...)
+	namespace, _, _ := unstructured.NestedString(crd.Object, conversionServiceNamespacePath...)
+	return strategy == "Webhook" && name == WebhookServiceName && namespace == w.Namespace
+}
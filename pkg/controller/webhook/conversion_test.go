@@ -0,0 +1,116 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package webhook
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// forbiddenClient denies the access to the CRDs of the given names.
+type forbiddenClient struct {
+	k8s.Client
+	get    string
+	update string
+}
+
+func (c forbiddenClient) Get(key client.ObjectKey, obj runtime.Object) error {
+	if key.Name == c.get {
+		return apierrors.NewForbidden(schema.GroupResource{Group: crdGVK.Group, Resource: "customresourcedefinitions"}, key.Name, nil)
+	}
+	return c.Client.Get(key, obj)
+}
+
+func (c forbiddenClient) Update(obj runtime.Object, opts ...client.UpdateOption) error {
+	if name := obj.(*unstructured.Unstructured).GetName(); name == c.update {
+		return apierrors.NewForbidden(schema.GroupResource{Group: crdGVK.Group, Resource: "customresourcedefinitions"}, name, nil)
+	}
+	return c.Client.Update(obj, opts...)
+}
+
+func newCRD(name string, conversion map[string]interface{}) *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"conversion": conversion},
+	}}
+	crd.SetGroupVersionKind(crdGVK)
+	crd.SetName(name)
+	return crd
+}
+
+func webhookConversion(serviceNamespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"strategy": "Webhook",
+		"webhookClientConfig": map[string]interface{}{
+			"service": map[string]interface{}{
+				"name":      WebhookServiceName,
+				"namespace": serviceNamespace,
+				"path":      "/convert",
+			},
+		},
+	}
+}
+
+func TestParams_reconcileConversionWebhooks(t *testing.T) {
+	c := k8s.WrappedFakeClient()
+	for _, crd := range []*unstructured.Unstructured{
+		newCRD("webhook", webhookConversion("elastic-system")),
+		newCRD("none", map[string]interface{}{"strategy": "None"}),
+		newCRD("other-service", webhookConversion("other")),
+		newCRD("not-listed", webhookConversion("elastic-system")),
+	} {
+		require.NoError(t, c.Create(crd))
+	}
+	w := Params{Namespace: "elastic-system", ConversionCRDs: []string{"webhook", "none", "other-service", "missing"}}
+	caBundle := func(name string) string {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		require.NoError(t, c.Get(types.NamespacedName{Name: name}, crd))
+		value, _, err := unstructured.NestedString(crd.Object, conversionCABundlePath...)
+		require.NoError(t, err)
+		return value
+	}
+
+	require.NoError(t, w.reconcileConversionWebhooks(c, []byte("ca")))
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("ca")), caBundle("webhook"))
+	require.Empty(t, caBundle("none"))
+	require.Empty(t, caBundle("other-service"))
+	require.Empty(t, caBundle("not-listed"))
+
+	// the CA bundle is updated on rotation
+	require.NoError(t, w.reconcileConversionWebhooks(c, []byte("rotated")))
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("rotated")), caBundle("webhook"))
+}
+
+func TestParams_reconcileConversionWebhooks_Forbidden(t *testing.T) {
+	c := k8s.WrappedFakeClient()
+	for _, name := range []string{"no-get", "no-update", "allowed"} {
+		require.NoError(t, c.Create(newCRD(name, webhookConversion("elastic-system"))))
+	}
+	w := Params{Namespace: "elastic-system", ConversionCRDs: []string{"no-get", "no-update", "allowed"}}
+
+	// the CRDs the operator is not allowed to get or update are skipped
+	require.NoError(t, w.reconcileConversionWebhooks(forbiddenClient{Client: c, get: "no-get", update: "no-update"}, []byte("ca")))
+	for name, expected := range map[string]string{
+		"no-get":    "",
+		"no-update": "",
+		"allowed":   base64.StdEncoding.EncodeToString([]byte("ca")),
+	} {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		require.NoError(t, c.Get(types.NamespacedName{Name: name}, crd))
+		value, _, err := unstructured.NestedString(crd.Object, conversionCABundlePath...)
+		require.NoError(t, err)
+		require.Equal(t, expected, value, name)
+	}
+}
@@ -5,19 +5,36 @@
 package webhook
 
 import (
+	"bytes"
+
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-// Params are params to create and manage the webhook resources (Cert secret and ValidatingWebhookConfiguration)
+// Params are params to create and manage the webhook resources (Cert secret, ValidatingWebhookConfiguration,
+// optional MutatingWebhookConfiguration and conversion webhooks of the CRDs)
 type Params struct {
 	Namespace                string
 	SecretName               string
 	WebhookConfigurationName string
+	// ConversionCRDs are the names of the CRDs whose conversion webhook is served by the operator
+	ConversionCRDs []string
 
 	// Certificate options
 	Rotation certificates.RotationParams
+	// Config holds the certificate options updated at runtime, overriding Rotation if not nil
+	Config *operator.Config
+}
+
+// rotation returns the current certificate options.
+func (w Params) rotation() certificates.RotationParams {
+	if w.Config != nil {
+		return w.Config.Get().CertRotation
+	}
+	return w.Rotation
 }
 
 // ReconcileResources reconciles the certificates used by the webhook client and the webhook server.
@@ -67,5 +84,33 @@ func (w *Params) ReconcileResources(clientset kubernetes.Interface) error {
 		}
 	}
 
-	return nil
+	if len(webhookConfiguration.Webhooks) == 0 {
+		return nil
+	}
+	return w.reconcileMutatingWebhookConfiguration(clientset, webhookConfiguration.Webhooks[0].ClientConfig.CABundle)
+}
+
+// reconcileMutatingWebhookConfiguration propagates the CA bundle to the MutatingWebhookConfiguration sharing the name
+// of the ValidatingWebhookConfiguration. The mutating webhooks are optional: nothing is done if it does not exist.
+func (w *Params) reconcileMutatingWebhookConfiguration(clientset kubernetes.Interface, caBundle []byte) error {
+	webhookConfiguration, err := clientset.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get(w.WebhookConfigurationName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	updateNeeded := false
+	for i := range webhookConfiguration.Webhooks {
+		if !bytes.Equal(webhookConfiguration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			webhookConfiguration.Webhooks[i].ClientConfig.CABundle = caBundle
+			updateNeeded = true
+		}
+	}
+	if !updateNeeded {
+		return nil
+	}
+	log.Info("Updating mutating webhook CA bundle", "webhook", webhookConfiguration.Name)
+	_, err = clientset.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Update(webhookConfiguration)
+	return err
 }
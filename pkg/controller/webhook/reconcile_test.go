@@ -8,8 +8,10 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"testing"
+	"time"
 
 	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/operator"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/admissionregistration/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -94,6 +96,61 @@ func TestParams_ReconcileResources(t *testing.T) {
 
 }
 
+func TestParams_ReconcileResources_MutatingWebhookConfiguration(t *testing.T) {
+	w := Params{
+		Namespace:                "elastic-system",
+		SecretName:               "elastic-webhook-server-cert",
+		WebhookConfigurationName: "elastic-webhook.k8s.elastic.co",
+		Rotation: certificates.RotationParams{
+			Validity:     certificates.DefaultCertValidity,
+			RotateBefore: certificates.DefaultRotateBefore,
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "elastic-system",
+			Name:      "elastic-webhook-server-cert",
+		},
+	}
+	validating := &v1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elastic-webhook.k8s.elastic.co",
+		},
+		Webhooks: []v1beta1.ValidatingWebhook{{Name: "elastic-es-validation-v1.k8s.elastic.co"}},
+	}
+
+	// the mutating webhook configuration is optional
+	clientset := fake.NewSimpleClientset(secret.DeepCopy(), validating.DeepCopy())
+	assert.NoError(t, w.ReconcileResources(clientset))
+
+	// it receives the same CA bundle as the validating webhook configuration
+	clientset = fake.NewSimpleClientset(secret.DeepCopy(), validating.DeepCopy(), &v1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elastic-webhook.k8s.elastic.co",
+		},
+		Webhooks: []v1beta1.MutatingWebhook{{Name: "elastic-es-defaults-v1.k8s.elastic.co"}},
+	})
+	assert.NoError(t, w.ReconcileResources(clientset))
+	validatingConfiguration, err := clientset.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(w.WebhookConfigurationName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	mutatingConfiguration, err := clientset.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get(w.WebhookConfigurationName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, mutatingConfiguration.Webhooks[0].ClientConfig.CABundle)
+	assert.Equal(t, validatingConfiguration.Webhooks[0].ClientConfig.CABundle, mutatingConfiguration.Webhooks[0].ClientConfig.CABundle)
+}
+
+func TestParams_rotation(t *testing.T) {
+	static := certificates.RotationParams{Validity: 10 * time.Hour, RotateBefore: time.Hour}
+	params := Params{Rotation: static}
+	assert.Equal(t, static, params.rotation())
+
+	// the settings updated at runtime take precedence
+	params.Config = operator.NewConfig(operator.Settings{CertRotation: static})
+	reloaded := certificates.RotationParams{Validity: 20 * time.Hour, RotateBefore: 2 * time.Hour}
+	params.Config.Set(operator.Settings{CertRotation: reloaded})
+	assert.Equal(t, reloaded, params.rotation())
+}
+
 func verifyCertificates(t *testing.T, rootCert []byte, serverCert []byte) {
 	ca := x509.NewCertPool()
 	ok := ca.AppendCertsFromPEM(rootCert)
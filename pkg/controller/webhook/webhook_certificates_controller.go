@@ -57,6 +57,15 @@ func (r *ReconcileWebhookResources) reconcileInternal() *reconciler.Results {
 	if err := r.webhookParams.ReconcileResources(r.clientset); err != nil {
 		return res.WithError(err)
 	}
+	webhookConfiguration, err := r.clientset.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get(r.webhookParams.WebhookConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		return res.WithError(err)
+	}
+	if len(webhookConfiguration.Webhooks) > 0 {
+		if err := r.webhookParams.reconcileConversionWebhooks(r.Client, webhookConfiguration.Webhooks[0].ClientConfig.CABundle); err != nil {
+			return res.WithError(err)
+		}
+	}
 
 	// Get the latest content of the webhook CA
 	webhookServerSecret, err := r.clientset.CoreV1().Secrets(r.webhookParams.Namespace).Get(r.webhookParams.SecretName, metav1.GetOptions{})
@@ -71,7 +80,7 @@ func (r *ReconcileWebhookResources) reconcileInternal() *reconciler.Results {
 	}
 
 	res.WithResult(reconcile.Result{
-		RequeueAfter: certificates.ShouldRotateIn(time.Now(), serverCA.Cert.NotAfter, r.webhookParams.Rotation.RotateBefore),
+		RequeueAfter: certificates.ShouldRotateIn(time.Now(), serverCA.Cert.NotAfter, r.webhookParams.rotation().RotateBefore),
 	})
 	return res
 }
@@ -120,5 +129,13 @@ func Add(mgr manager.Manager, webhookParams Params, clientset kubernetes.Interfa
 		return err
 	}
 
+	if err := c.Watch(&source.Kind{Type: &v1beta1.MutatingWebhookConfiguration{}}, &watches.NamedWatch{
+		Name:    "mutatingwebhookconfiguration",
+		Watched: []types.NamespacedName{webhookConfiguration},
+		Watcher: webhookConfiguration,
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -70,9 +70,9 @@ func (a Aggregator) aggregateElasticsearchMemory() (resource.Quantity, error) {
 				return resource.Quantity{}, errors.Wrap(err, "failed to aggregate Elasticsearch memory")
 			}
 
-			total.Add(multiply(mem, nodeSet.Count))
+			total.Add(multiply(mem, nodeSet.TotalCount()))
 			log.V(1).Info("Collecting", "namespace", es.Namespace, "es_name", es.Name,
-				"memory", mem.String(), "count", nodeSet.Count)
+				"memory", mem.String(), "count", nodeSet.TotalCount())
 		}
 	}
 
@@ -0,0 +1,78 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package selfmonitoring
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// metricsDocuments returns one document per metric of the given gatherer, in the format of the Metricbeat
+// Prometheus module: values are under prometheus.metrics and labels under prometheus.labels.
+func metricsDocuments(gatherer prometheus.Gatherer, now time.Time, hostname string) ([]map[string]interface{}, error) {
+	if gatherer == nil {
+		return nil, nil
+	}
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var docs []map[string]interface{}
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			values := metricValues(name, family.GetType(), metric)
+			if len(values) == 0 {
+				continue
+			}
+			doc := map[string]interface{}{
+				"@timestamp": now,
+				"host.name":  hostname,
+				"prometheus": map[string]interface{}{
+					"metrics": values,
+				},
+			}
+			if len(metric.GetLabel()) > 0 {
+				labels := make(map[string]string, len(metric.GetLabel()))
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				doc["prometheus"].(map[string]interface{})["labels"] = labels
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// metricValues returns the values of the given metric by name. Histograms and summaries are reduced to their sum
+// and count.
+func metricValues(name string, metricType dto.MetricType, metric *dto.Metric) map[string]float64 {
+	values := map[string]float64{}
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		values[name] = metric.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		values[name] = metric.GetGauge().GetValue()
+	case dto.MetricType_UNTYPED:
+		values[name] = metric.GetUntyped().GetValue()
+	case dto.MetricType_HISTOGRAM:
+		values[name+"_sum"] = metric.GetHistogram().GetSampleSum()
+		values[name+"_count"] = float64(metric.GetHistogram().GetSampleCount())
+	case dto.MetricType_SUMMARY:
+		values[name+"_sum"] = metric.GetSummary().GetSampleSum()
+		values[name+"_count"] = float64(metric.GetSummary().GetSampleCount())
+	}
+	for k, v := range values {
+		// not representable in JSON
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			delete(values, k)
+		}
+	}
+	return values
+}
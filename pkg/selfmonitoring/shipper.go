@@ -0,0 +1,315 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package selfmonitoring
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates"
+	certhttp "github.com/elastic/cloud-on-k8s/pkg/controller/common/certificates/http"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/services"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/net"
+)
+
+const (
+	// DefaultInterval is the default interval at which logs and metrics are shipped.
+	DefaultInterval = 30 * time.Second
+
+	// LogsIndexPrefix is the prefix of the daily indices holding the operator logs.
+	LogsIndexPrefix = "eck-operator-logs-"
+	// MetricsIndexPrefix is the prefix of the daily indices holding the operator metrics.
+	MetricsIndexPrefix = "eck-operator-metrics-"
+
+	// maxBufferedLines bounds the memory used by logs waiting to be shipped, older lines are dropped first.
+	maxBufferedLines = 10000
+	indexDateFormat  = "2006.01.02"
+)
+
+var log = logf.Log.WithName("self-monitoring")
+
+// Params are the parameters of a Shipper.
+type Params struct {
+	// Client is used to retrieve the monitoring cluster and reconcile the monitoring user.
+	Client k8s.Client
+	// Dialer is used to connect to the monitoring cluster, if not nil.
+	Dialer net.Dialer
+	// Elasticsearch is the monitoring cluster, managed by the operator.
+	Elasticsearch types.NamespacedName
+	// OperatorNamespace is the namespace of the operator, holding the password of the monitoring user.
+	OperatorNamespace string
+	// Interval is the interval at which logs and metrics are shipped.
+	Interval time.Duration
+	// Gatherer provides the metrics to ship.
+	Gatherer prometheus.Gatherer
+}
+
+// Shipper ships the operator logs and metrics to an Elasticsearch cluster managed by the operator. It receives the
+// logs as an io.Writer and buffers them in memory until they are shipped.
+type Shipper struct {
+	Params
+	hostname string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	lines   [][]byte
+	dropped int
+}
+
+// NewShipper returns a new Shipper for the given parameters.
+func NewShipper(params Params) *Shipper {
+	if params.Interval <= 0 {
+		params.Interval = DefaultInterval
+	}
+	hostname, _ := os.Hostname()
+	return &Shipper{Params: params, hostname: hostname, now: time.Now}
+}
+
+// Write buffers the given log line. It never logs itself, which would recurse.
+func (s *Shipper) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.append(line)
+	return len(p), nil
+}
+
+// append buffers the given lines, dropping the oldest ones beyond maxBufferedLines. The lock must be held.
+func (s *Shipper) append(lines ...[]byte) {
+	s.lines = append(s.lines, lines...)
+	if overflow := len(s.lines) - maxBufferedLines; overflow > 0 {
+		s.lines = s.lines[overflow:]
+		s.dropped += overflow
+	}
+}
+
+// takeLines returns the buffered lines and empties the buffer.
+func (s *Shipper) takeLines() ([][]byte, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines, dropped := s.lines, s.dropped
+	s.lines, s.dropped = nil, 0
+	return lines, dropped
+}
+
+// restoreLines puts back lines that could not be shipped, before the ones buffered in the meantime.
+func (s *Shipper) restoreLines(lines [][]byte, dropped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	newer := s.lines
+	s.lines = nil
+	s.dropped += dropped
+	s.append(append(lines, newer...)...)
+}
+
+// Start ships logs and metrics at regular intervals until the stop channel is closed, and ships them a last time
+// before returning. It implements the controller-runtime Runnable interface.
+func (s *Shipper) Start(stop <-chan struct{}) error {
+	log.Info("Shipping operator logs and metrics", "namespace", s.Elasticsearch.Namespace, "es_name", s.Elasticsearch.Name)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			if err := s.Ship(context.Background()); err != nil {
+				log.Error(err, "Failed to ship operator logs and metrics")
+			}
+			return nil
+		case <-ticker.C:
+			if err := s.Ship(context.Background()); err != nil {
+				log.Error(err, "Failed to ship operator logs and metrics", "namespace", s.Elasticsearch.Namespace, "es_name", s.Elasticsearch.Name)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false: each operator replica ships its own logs and metrics.
+func (s *Shipper) NeedLeaderElection() bool {
+	return false
+}
+
+// Ship indexes the buffered logs and the current metrics in the monitoring cluster. Logs are buffered again if they
+// could not be indexed.
+func (s *Shipper) Ship(ctx context.Context) error {
+	lines, dropped := s.takeLines()
+	client, v, err := s.newClient()
+	if err != nil {
+		s.restoreLines(lines, dropped)
+		return err
+	}
+	defer client.Close()
+
+	now := s.now()
+	body, err := s.bulkBody(now, v, lines, dropped)
+	if err != nil {
+		s.restoreLines(lines, dropped)
+		return err
+	}
+	failures, err := bulk(ctx, client, body)
+	if err != nil {
+		s.restoreLines(lines, dropped)
+		return err
+	}
+	if len(failures) > 0 {
+		// not buffered again since the other documents were indexed
+		return fmt.Errorf("%d documents could not be indexed: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// newClient returns a client for the monitoring cluster, authenticated as the monitoring user.
+func (s *Shipper) newClient() (esclient.Client, version.Version, error) {
+	var es esv1.Elasticsearch
+	if err := s.Client.Get(s.Elasticsearch, &es); err != nil {
+		return nil, version.Version{}, errors.Wrap(err, "while retrieving the monitoring cluster")
+	}
+	v, err := version.Parse(es.Spec.Version)
+	if err != nil {
+		return nil, version.Version{}, err
+	}
+	user, err := reconcileUser(s.Client, s.OperatorNamespace, es)
+	if err != nil {
+		return nil, version.Version{}, errors.Wrap(err, "while reconciling the monitoring user")
+	}
+	var caCerts []*x509.Certificate
+	if es.Spec.EffectiveHTTP().TLS.Enabled() {
+		var certsSecret corev1.Secret
+		if err := s.Client.Get(certhttp.PublicCertsSecretRef(esv1.ESNamer, s.Elasticsearch), &certsSecret); err != nil {
+			return nil, version.Version{}, errors.Wrap(err, "while retrieving the monitoring cluster certificates")
+		}
+		caCerts, err = certificates.ParsePEMCerts(certsSecret.Data[certificates.CAFileName])
+		if err != nil {
+			return nil, version.Version{}, err
+		}
+	}
+	return esclient.NewElasticsearchClient(s.Dialer, services.ExternalServiceURL(es), user, *v, caCerts), *v, nil
+}
+
+// bulkBody returns the body of the bulk request indexing the given log lines and the current metrics.
+func (s *Shipper) bulkBody(now time.Time, v version.Version, lines [][]byte, dropped int) ([]byte, error) {
+	var body bytes.Buffer
+	logsAction := indexAction(LogsIndexPrefix, now, v)
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			// development mode logs are not JSON
+			wrapped, err := json.Marshal(map[string]interface{}{
+				"@timestamp": now,
+				"message":    string(line),
+			})
+			if err != nil {
+				return nil, err
+			}
+			line = wrapped
+		}
+		body.Write(logsAction)
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+	if dropped > 0 {
+		if err := writeDoc(&body, logsAction, map[string]interface{}{
+			"@timestamp": now,
+			"log.level":  "warn",
+			"message":    fmt.Sprintf("%d log lines dropped before being shipped", dropped),
+			"host.name":  s.hostname,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := metricsDocuments(s.Gatherer, now, s.hostname)
+	if err != nil {
+		return nil, err
+	}
+	metricsAction := indexAction(MetricsIndexPrefix, now, v)
+	for _, doc := range docs {
+		if err := writeDoc(&body, metricsAction, doc); err != nil {
+			return nil, err
+		}
+	}
+	return body.Bytes(), nil
+}
+
+// indexAction returns the bulk action indexing a document in the daily index with the given prefix.
+func indexAction(indexPrefix string, now time.Time, v version.Version) []byte {
+	index := indexPrefix + now.UTC().Format(indexDateFormat)
+	if v.Major < 7 {
+		// mapping types are required before 7.0
+		return []byte(fmt.Sprintf(`{"index":{"_index":"%s","_type":"_doc"}}`+"\n", index))
+	}
+	return []byte(fmt.Sprintf(`{"index":{"_index":"%s"}}`+"\n", index))
+}
+
+func writeDoc(body *bytes.Buffer, action []byte, doc interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	body.Write(action)
+	body.Write(data)
+	body.WriteByte('\n')
+	return nil
+}
+
+// bulk sends the given bulk request body, and returns the reasons why documents could not be indexed, if any.
+func bulk(ctx context.Context, client esclient.Client, body []byte) ([]string, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	req, err := http.NewRequest(http.MethodPost, "/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Error *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+	var reasons []string
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Error != nil {
+				reasons = append(reasons, action.Error.Reason)
+			}
+		}
+	}
+	return reasons, nil
+}
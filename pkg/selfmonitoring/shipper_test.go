@@ -0,0 +1,206 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package selfmonitoring
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	commonv1 "github.com/elastic/cloud-on-k8s/pkg/apis/common/v1"
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/version"
+	esuser "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+// serverDialer dials the test server whatever the address.
+type serverDialer struct {
+	addr string
+}
+
+func (d serverDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	return (&net.Dialer{}).DialContext(ctx, network, d.addr)
+}
+
+// fakeES records the bulk requests it receives, and fails them with the given status code if not 0.
+type fakeES struct {
+	mu         sync.Mutex
+	statusCode int
+	requests   []string
+}
+
+func (f *fakeES) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != "/_bulk" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	f.requests = append(f.requests, string(body))
+	if f.statusCode != 0 {
+		w.WriteHeader(f.statusCode)
+		return
+	}
+	_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+}
+
+func monitoringES(version string) *esv1.Elasticsearch {
+	return &esv1.Elasticsearch{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Name: "es"},
+		Spec: esv1.ElasticsearchSpec{
+			Version: version,
+			HTTP: commonv1.HTTPConfig{
+				TLS: commonv1.TLSOptions{SelfSignedCertificate: &commonv1.SelfSignedCertificate{Disabled: true}},
+			},
+		},
+	}
+}
+
+func newTestShipper(es *esv1.Elasticsearch, gatherer prometheus.Gatherer) (*Shipper, *fakeES, k8s.Client, func()) {
+	fake := &fakeES{}
+	server := httptest.NewServer(fake)
+	c := k8s.WrappedFakeClient(es)
+	s := NewShipper(Params{
+		Client:            c,
+		Dialer:            serverDialer{addr: strings.TrimPrefix(server.URL, "http://")},
+		Elasticsearch:     types.NamespacedName{Namespace: es.Namespace, Name: es.Name},
+		OperatorNamespace: "elastic-system",
+		Gatherer:          gatherer,
+	})
+	s.now = func() time.Time { return time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC) }
+	return s, fake, c, server.Close
+}
+
+func TestShipper_Ship(t *testing.T) {
+	s, fake, c, closeServer := newTestShipper(monitoringES("7.6.0"), nil)
+	defer closeServer()
+
+	_, err := s.Write([]byte(`{"log.level":"info","message":"hello"}` + "\n"))
+	require.NoError(t, err)
+	_, err = s.Write([]byte("not json\n"))
+	require.NoError(t, err)
+	require.NoError(t, s.Ship(context.Background()))
+
+	// the monitoring user has been created
+	var password corev1.Secret
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "elastic-system", Name: UserSecretName}, &password))
+	require.NotEmpty(t, password.Data["elastic-system-eck-monitoring-user"])
+	var user corev1.Secret
+	require.NoError(t, c.Get(types.NamespacedName{Namespace: "monitoring", Name: "elastic-system-eck-monitoring-user"}, &user))
+	require.Equal(t, esuser.OperatorMonitoringUserRole, string(user.Data[esuser.UserRolesField]))
+
+	// both lines have been indexed
+	require.Len(t, fake.requests, 1)
+	lines := bulkLines(t, fake.requests[0])
+	require.Len(t, lines, 4)
+	require.Equal(t, `{"index":{"_index":"eck-operator-logs-2020.03.04"}}`, lines[0])
+	require.Equal(t, `{"log.level":"info","message":"hello"}`, lines[1])
+	require.Contains(t, lines[3], `"message":"not json"`)
+
+	// nothing left to ship
+	require.NoError(t, s.Ship(context.Background()))
+	require.Len(t, fake.requests, 1)
+}
+
+func TestShipper_Ship_Failure(t *testing.T) {
+	s, fake, _, closeServer := newTestShipper(monitoringES("6.8.0"), nil)
+	defer closeServer()
+	fake.statusCode = http.StatusServiceUnavailable
+
+	_, err := s.Write([]byte(`{"message":"first"}`))
+	require.NoError(t, err)
+	require.Error(t, s.Ship(context.Background()))
+
+	// the failed line is shipped again, before the newer one
+	fake.statusCode = 0
+	_, err = s.Write([]byte(`{"message":"second"}`))
+	require.NoError(t, err)
+	require.NoError(t, s.Ship(context.Background()))
+	require.Len(t, fake.requests, 2)
+	lines := bulkLines(t, fake.requests[1])
+	require.Equal(t, []string{
+		// mapping type required before 7.0
+		`{"index":{"_index":"eck-operator-logs-2020.03.04","_type":"_doc"}}`,
+		`{"message":"first"}`,
+		`{"index":{"_index":"eck-operator-logs-2020.03.04","_type":"_doc"}}`,
+		`{"message":"second"}`,
+	}, lines)
+}
+
+func TestShipper_Write_Overflow(t *testing.T) {
+	s := NewShipper(Params{})
+	for i := 0; i < maxBufferedLines+10; i++ {
+		_, err := s.Write([]byte(`{}`))
+		require.NoError(t, err)
+	}
+	lines, dropped := s.takeLines()
+	require.Len(t, lines, maxBufferedLines)
+	require.Equal(t, 10, dropped)
+
+	// a warning is indexed for the dropped lines
+	body, err := s.bulkBody(time.Now(), version.MustParse("7.6.0"), nil, dropped)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "10 log lines dropped before being shipped")
+}
+
+func Test_metricsDocuments(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "reconciles_total"}, []string{"controller"})
+	counter.WithLabelValues("elasticsearch").Add(3)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "reconcile_seconds"})
+	histogram.Observe(2)
+	registry.MustRegister(counter, histogram)
+
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	docs, err := metricsDocuments(registry, now, "operator-0")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	data, err := json.Marshal(docs)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{
+			"@timestamp": "2020-03-04T05:06:07Z",
+			"host.name": "operator-0",
+			"prometheus": {"metrics": {"reconcile_seconds_sum": 2, "reconcile_seconds_count": 1}}
+		},
+		{
+			"@timestamp": "2020-03-04T05:06:07Z",
+			"host.name": "operator-0",
+			"prometheus": {"metrics": {"reconciles_total": 3}, "labels": {"controller": "elasticsearch"}}
+		}
+	]`, string(data))
+
+	// no gatherer
+	docs, err = metricsDocuments(nil, now, "operator-0")
+	require.NoError(t, err)
+	require.Empty(t, docs)
+}
+
+func bulkLines(t *testing.T, body string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewBufferString(body))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
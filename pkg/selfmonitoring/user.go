@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package selfmonitoring
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	esv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/credentials"
+	"github.com/elastic/cloud-on-k8s/pkg/controller/common/reconciler"
+	esclient "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/client"
+	esuser "github.com/elastic/cloud-on-k8s/pkg/controller/elasticsearch/user"
+	"github.com/elastic/cloud-on-k8s/pkg/utils/k8s"
+)
+
+const (
+	// UserSecretName is the name of the secret holding the password of the monitoring user in the operator namespace.
+	UserSecretName = "eck-monitoring-user"
+	userSuffix     = "eck-monitoring-user"
+)
+
+// userName returns the name of the user the operator authenticates with in the monitoring cluster. It is namespaced
+// since several operators may ship to the same cluster.
+func userName(operatorNamespace string) string {
+	return operatorNamespace + "-" + userSuffix
+}
+
+// reconcileUser ensures the monitoring user exists in the file realm of the monitoring cluster, and returns its
+// credentials. The clear-text password is kept in a secret of the operator namespace.
+func reconcileUser(c k8s.Client, operatorNamespace string, es esv1.Elasticsearch) (esclient.BasicAuth, error) {
+	name := userName(operatorNamespace)
+	secKey := types.NamespacedName{Namespace: operatorNamespace, Name: UserSecretName}
+	expectedSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: secKey.Namespace,
+			Name:      secKey.Name,
+		},
+		Data: map[string][]byte{},
+	}
+
+	password := common.RandomPasswordBytes()
+	// reuse the existing password if there's one
+	var existingSecret corev1.Secret
+	if err := c.Get(secKey, &existingSecret); err != nil && !apierrors.IsNotFound(err) {
+		return esclient.BasicAuth{}, err
+	}
+	// the password held by an external credentials store takes precedence
+	if _, err := credentials.Load(secKey, &existingSecret); err != nil {
+		return esclient.BasicAuth{}, err
+	}
+	if existingPassword, exists := existingSecret.Data[name]; exists {
+		password = existingPassword
+	}
+	expectedSecret.Data[name] = password
+	if err := credentials.Save(secKey, expectedSecret.Data); err != nil {
+		return esclient.BasicAuth{}, err
+	}
+	// the secret is not owned: it must survive the monitoring cluster being recreated
+	if _, err := reconciler.ReconcileSecret(c, expectedSecret, nil); err != nil {
+		return esclient.BasicAuth{}, err
+	}
+
+	expectedUser := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: es.Namespace,
+			Name:      name,
+			Labels:    esuser.AssociatedUserLabels(es),
+		},
+		Data: map[string][]byte{
+			esuser.UserNameField:  []byte(name),
+			esuser.UserRolesField: []byte(esuser.OperatorMonitoringUserRole),
+		},
+	}
+	// reuse the existing hash if valid
+	bcryptHash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	if err != nil {
+		return esclient.BasicAuth{}, err
+	}
+	var existingUser corev1.Secret
+	if err := c.Get(k8s.ExtractNamespacedName(&expectedUser), &existingUser); err != nil && !apierrors.IsNotFound(err) {
+		return esclient.BasicAuth{}, err
+	}
+	if existingHash, exists := existingUser.Data[esuser.PasswordHashField]; exists {
+		if bcrypt.CompareHashAndPassword(existingHash, password) == nil {
+			bcryptHash = existingHash
+		}
+	}
+	expectedUser.Data[esuser.PasswordHashField] = bcryptHash
+	if _, err := reconciler.ReconcileSecret(c, expectedUser, &es); err != nil {
+		return esclient.BasicAuth{}, err
+	}
+	return esclient.BasicAuth{Name: name, Password: string(password)}, nil
+}
@@ -0,0 +1,135 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package chrono
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cronField is the range of values of a field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [...]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 6},
+}
+
+// CronSchedule is a schedule parsed from a cron expression of 5 fields: minute, hour, day of month, month and day of
+// week. Each field is either *, or a comma-separated list of values and ranges (1-5), optionally followed by a step
+// (*/15, 0-30/10). Sunday is day 0 of the week. When both the day of month and the day of week are restricted, a time
+// matches if either of them matches, as with the cron daemon.
+type CronSchedule struct {
+	fields [len(cronFields)]map[int]bool
+	// anyDayOfMonth and anyDayOfWeek are true if the corresponding fields are set to *.
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// ParseCronSchedule parses the given cron expression.
+func ParseCronSchedule(expr string) (CronSchedule, error) {
+	var s CronSchedule
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return s, errors.Errorf("expected %d fields in cron expression %q, got %d", len(cronFields), expr, len(parts))
+	}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return s, errors.Wrapf(err, "invalid cron expression %q", expr)
+		}
+		s.fields[i] = values
+	}
+	s.anyDayOfMonth = parts[2] == "*"
+	s.anyDayOfWeek = parts[4] == "*"
+	return s, nil
+}
+
+func parseCronField(part string, field cronField) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(part, ",") {
+		rangeExpr, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			rangeExpr = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step <= 0 {
+				return nil, errors.Errorf("invalid step in %s field %q", field.name, item)
+			}
+		}
+		low, high := field.min, field.max
+		if rangeExpr != "*" {
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, errors.Errorf("invalid value in %s field %q", field.name, item)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, errors.Errorf("invalid value in %s field %q", field.name, item)
+				}
+			}
+		}
+		if low < field.min || high > field.max || low > high {
+			return nil, errors.Errorf("%s field %q out of range %d-%d", field.name, item, field.min, field.max)
+		}
+		for v := low; v <= high; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Matches returns true if the schedule fires at the minute of the given time.
+func (s CronSchedule) Matches(t time.Time) bool {
+	if !s.fields[0][t.Minute()] || !s.fields[1][t.Hour()] || !s.fields[3][int(t.Month())] {
+		return false
+	}
+	dayOfMonth := s.fields[2][t.Day()]
+	dayOfWeek := s.fields[4][int(t.Weekday())]
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// Previous returns the latest time the schedule fired at, at or before the given time and after the given time minus
+// the given lookback, or false if it did not fire in this interval.
+func (s CronSchedule) Previous(t time.Time, lookback time.Duration) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	for since := time.Duration(0); since <= lookback; since += time.Minute {
+		if candidate := t.Add(-since); s.Matches(candidate) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Next returns the first time the schedule fires at after the given time, and before the given time plus the given
+// horizon, or false if it does not fire in this interval.
+func (s CronSchedule) Next(t time.Time, horizon time.Duration) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	for until := time.Minute; until <= horizon; until += time.Minute {
+		if candidate := t.Add(until); s.Matches(candidate) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
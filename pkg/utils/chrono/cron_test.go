@@ -0,0 +1,67 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package chrono
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "0 8 * * 1-5", "*/15 0-6,22,23 1,15 */2 0"} {
+		_, err := ParseCronSchedule(expr)
+		require.NoError(t, err, expr)
+	}
+	for _, expr := range []string{"", "0 8 * *", "60 * * * *", "* 5-2 * * *", "*/0 * * * *", "a * * * *", "* * 0 * *"} {
+		_, err := ParseCronSchedule(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestCronSchedule_Matches(t *testing.T) {
+	// Wednesday
+	wednesday := time.Date(2020, 3, 4, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{expr: "* * * * *", t: wednesday, want: true},
+		{expr: "30 8 * * 1-5", t: wednesday, want: true},
+		{expr: "30 8 * * 0,6", t: wednesday, want: false},
+		{expr: "*/15 8 * * *", t: wednesday, want: true},
+		{expr: "*/20 8 * * *", t: wednesday, want: false},
+		{expr: "30 8 4 3 *", t: wednesday, want: true},
+		{expr: "30 8 5 * *", t: wednesday, want: false},
+		// day of month or day of week
+		{expr: "30 8 5 * 3", t: wednesday, want: true},
+	}
+	for _, tt := range tests {
+		s, err := ParseCronSchedule(tt.expr)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, s.Matches(tt.t), tt.expr)
+	}
+}
+
+func TestCronSchedule_PreviousNext(t *testing.T) {
+	s, err := ParseCronSchedule("0 8 * * 1-5")
+	require.NoError(t, err)
+	// Saturday
+	saturday := time.Date(2020, 3, 7, 10, 12, 30, 0, time.UTC)
+
+	_, found := s.Previous(saturday, 12*time.Hour)
+	require.False(t, found)
+	previous, found := s.Previous(saturday, 48*time.Hour)
+	require.True(t, found)
+	require.Equal(t, time.Date(2020, 3, 6, 8, 0, 0, 0, time.UTC), previous)
+
+	_, found = s.Next(saturday, 24*time.Hour)
+	require.False(t, found)
+	next, found := s.Next(saturday, 7*24*time.Hour)
+	require.True(t, found)
+	require.Equal(t, time.Date(2020, 3, 9, 8, 0, 0, 0, time.UTC), next)
+}
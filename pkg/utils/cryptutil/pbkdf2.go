@@ -0,0 +1,67 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cryptutil
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	pbkdf2StretchPrefix = "{PBKDF2_STRETCH}"
+	pbkdf2DefaultCost   = 10000
+	pbkdf2KeyLength     = 32
+	pbkdf2SaltLength    = 32
+)
+
+// PBKDF2Stretch hashes the given secret with the PBKDF2_STRETCH algorithm of Elasticsearch: the secret is first
+// stretched with SHA-512, then hashed with PBKDF2 using HMAC-SHA512 and a random salt.
+func PBKDF2Stretch(secret []byte) ([]byte, error) {
+	salt := make([]byte, pbkdf2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return pbkdf2Stretch(secret, salt, pbkdf2DefaultCost), nil
+}
+
+// ComparePBKDF2StretchHash returns nil if the given hash, with the PBKDF2_STRETCH format of Elasticsearch, is a hash of
+// the given secret.
+func ComparePBKDF2StretchHash(hash, secret []byte) error {
+	if !strings.HasPrefix(string(hash), pbkdf2StretchPrefix) {
+		return fmt.Errorf("hash is not a %s hash", pbkdf2StretchPrefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(string(hash), pbkdf2StretchPrefix), "$")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid %s hash", pbkdf2StretchPrefix)
+	}
+	cost, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return err
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(pbkdf2Stretch(secret, salt, cost), hash) != 1 {
+		return fmt.Errorf("hash does not match the secret")
+	}
+	return nil
+}
+
+func pbkdf2Stretch(secret, salt []byte, cost int) []byte {
+	stretched := sha512.Sum512(secret)
+	key := pbkdf2.Key([]byte(hex.EncodeToString(stretched[:])), salt, cost, pbkdf2KeyLength, sha512.New)
+	return []byte(fmt.Sprintf("%s%d$%s$%s",
+		pbkdf2StretchPrefix, cost, base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(key),
+	))
+}
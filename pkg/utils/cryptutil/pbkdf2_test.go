@@ -0,0 +1,29 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cryptutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPBKDF2Stretch(t *testing.T) {
+	hash, err := PBKDF2Stretch([]byte("secret"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(hash), "{PBKDF2_STRETCH}10000$"))
+	require.NoError(t, ComparePBKDF2StretchHash(hash, []byte("secret")))
+	require.Error(t, ComparePBKDF2StretchHash(hash, []byte("other")))
+
+	// salts are random
+	otherHash, err := PBKDF2Stretch([]byte("secret"))
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+
+	// invalid hashes
+	require.Error(t, ComparePBKDF2StretchHash([]byte("$2a$10$abc"), []byte("secret")))
+	require.Error(t, ComparePBKDF2StretchHash([]byte("{PBKDF2_STRETCH}10000$abc"), []byte("secret")))
+}
@@ -32,6 +32,9 @@ type Client interface {
 	// WithTimeout returns a client with an overridden timeout value,
 	// to be used when no explicit context is passed.
 	WithTimeout(timeout time.Duration) Client
+	// WithInterceptor returns a client performing the requests with the controller-runtime client returned by
+	// the given function for the underlying one.
+	WithInterceptor(intercept func(client.Client) client.Client) Client
 
 	// Get wraps a controller-runtime client.Get call with a context.
 	Get(key client.ObjectKey, obj runtime.Object) error
@@ -76,6 +79,16 @@ func (w *clientWrapper) WithTimeout(timeout time.Duration) Client {
 	}
 }
 
+// WithInterceptor returns a client performing the requests with the controller-runtime client returned by
+// the given function for the underlying one.
+func (w *clientWrapper) WithInterceptor(intercept func(client.Client) client.Client) Client {
+	return &clientWrapper{
+		crClient: intercept(w.crClient),
+		timeout:  w.timeout,
+		ctx:      w.ctx,
+	}
+}
+
 // callWithContext calls f with the user-provided context. If no context was
 // provided, it uses the default one.
 func (w *clientWrapper) callWithContext(f func(ctx context.Context) error) error {
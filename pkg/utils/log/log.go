@@ -6,9 +6,11 @@ package log
 
 import (
 	"flag"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/elastic/cloud-on-k8s/pkg/about"
 	"github.com/elastic/cloud-on-k8s/pkg/dev"
@@ -27,6 +29,36 @@ const (
 
 var verbosity = flag.Int("log-verbosity", 0, "Verbosity level of logs (-2=Error, -1=Warn, 0=Info, >0=Debug)")
 
+// level is the level of the global logger, which can be changed once the logger is initialized.
+var level = zap.NewAtomicLevel()
+
+// output is the destination of the logs, to which additional writers can be added once the logger is initialized.
+var output = &multiWriter{writers: []io.Writer{os.Stderr}}
+
+// multiWriter duplicates its writes to all its writers, which can be added concurrently.
+type multiWriter struct {
+	mu      sync.RWMutex
+	writers []io.Writer
+}
+
+func (m *multiWriter) Write(p []byte) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, w := range m.writers {
+		if _, err := w.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// AddOutput duplicates the logs to the given writer, in addition to the standard error.
+func AddOutput(w io.Writer) {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	output.writers = append(output.writers, w)
+}
+
 // BindFlags attaches logging flags to the given flag set.
 func BindFlags(flags *pflag.FlagSet) {
 	flags.AddGoFlag(flag.Lookup("log-verbosity"))
@@ -50,15 +82,27 @@ func ChangeVerbosity(v int) {
 	setLogger(&v)
 }
 
-func setLogger(v *int) {
+// SetVerbosity changes the verbosity level of the global logger, without replacing it.
+func SetVerbosity(v int) {
+	setLevel(&v)
+}
+
+func setLevel(v *int) {
 	zapLevel := determineLogLevel(v)
 
 	// if the Zap custom level is less than debug (verbosity level 2 and above) set the klog level to the same level
+	klogLevel := 0
 	if zapLevel.Level() < zap.DebugLevel {
-		flagset := flag.NewFlagSet("", flag.ContinueOnError)
-		klog.InitFlags(flagset)
-		_ = flagset.Set("v", strconv.Itoa(int(zapLevel.Level())*-1))
+		klogLevel = int(zapLevel.Level()) * -1
 	}
+	flagset := flag.NewFlagSet("", flag.ContinueOnError)
+	klog.InitFlags(flagset)
+	_ = flagset.Set("v", strconv.Itoa(klogLevel))
+	level.SetLevel(zapLevel.Level())
+}
+
+func setLogger(v *int) {
+	setLevel(v)
 
 	opts := []zap.Option{zap.Fields(
 		zap.String("service.version", getVersionString()),
@@ -87,9 +131,9 @@ func setLogger(v *int) {
 
 	stackTraceLevel := zap.NewAtomicLevelAt(zapcore.ErrorLevel)
 	crlog.SetLogger(crzap.New(func(o *crzap.Options) {
-		o.DestWritter = os.Stderr
+		o.DestWritter = output
 		o.Development = dev.Enabled
-		o.Level = &zapLevel
+		o.Level = &level
 		o.StacktraceLevel = &stackTraceLevel
 		o.Encoder = encoder
 		o.ZapOpts = opts
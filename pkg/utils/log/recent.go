@@ -0,0 +1,60 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package log
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultRecentLogsSize is the default number of log lines kept in memory.
+const DefaultRecentLogsSize = 2000
+
+// RecentLogs keeps the most recent log lines in memory. It is an io.Writer to be added to the logger outputs.
+type RecentLogs struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewRecentLogs returns a RecentLogs keeping the given number of lines.
+func NewRecentLogs(size int) *RecentLogs {
+	return &RecentLogs{lines: make([]string, size)}
+}
+
+// Write records the given log entries, one per line.
+func (r *RecentLogs) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line == "" || len(r.lines) == 0 {
+			continue
+		}
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % len(r.lines)
+		if r.next == 0 {
+			r.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// Lines returns the recorded lines matching the given filter, from the oldest to the most recent one.
+func (r *RecentLogs) Lines(filter func(line string) bool) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ordered := r.lines[:r.next]
+	if r.full {
+		ordered = append(append([]string{}, r.lines[r.next:]...), r.lines[:r.next]...)
+	}
+	var lines []string
+	for _, line := range ordered {
+		if filter == nil || filter(line) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
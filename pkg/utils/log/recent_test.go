@@ -0,0 +1,31 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentLogs(t *testing.T) {
+	r := NewRecentLogs(3)
+	require.Empty(t, r.Lines(nil))
+
+	_, err := r.Write([]byte("a1\n"))
+	require.NoError(t, err)
+	_, err = r.Write([]byte("b2\nc3\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a1", "b2", "c3"}, r.Lines(nil))
+
+	// older lines are dropped
+	_, err = r.Write([]byte("d4\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b2", "c3", "d4"}, r.Lines(nil))
+	require.Equal(t, []string{"b2", "d4"}, r.Lines(func(line string) bool {
+		return strings.ContainsAny(line, "24")
+	}))
+}
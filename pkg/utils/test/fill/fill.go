@@ -0,0 +1,66 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fill
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// maxFillDepth bounds the recursion of Fill in recursive types.
+const maxFillDepth = 12
+
+// Fill recursively sets all the exported fields of the structure pointed to by obj to non-zero values: strings to "a",
+// numbers to 1, booleans to true, and slices and maps to a single filled element. Interfaces and types with a custom
+// JSON encoding, such as quantities or timestamps, are left zero. It allows
+// tests, such as the round-trip tests of API conversions, to cover all the fields of a type including the ones added
+// after the test was written.
+func Fill(obj interface{}) {
+	fill(reflect.ValueOf(obj).Elem(), 0)
+}
+
+func fill(v reflect.Value, depth int) {
+	if depth > maxFillDepth || v.Type().Implements(marshalerType) || reflect.PtrTo(v.Type()).Implements(marshalerType) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("a")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Ptr:
+		ptr := reflect.New(v.Type().Elem())
+		fill(ptr.Elem(), depth+1)
+		v.Set(ptr)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), 1, 1)
+		fill(slice.Index(0), depth+1)
+		v.Set(slice)
+	case reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		fill(key, depth+1)
+		value := reflect.New(v.Type().Elem()).Elem()
+		fill(value, depth+1)
+		m := reflect.MakeMap(v.Type())
+		m.SetMapIndex(key, value)
+		v.Set(m)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				// unexported
+				continue
+			}
+			fill(v.Field(i), depth+1)
+		}
+	}
+}